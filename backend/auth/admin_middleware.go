@@ -2,48 +2,25 @@ package auth
 
 import (
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"investorcenter-api/httputil"
 )
 
 // AdminMiddleware checks if the authenticated user is an admin
 // Must be used AFTER AuthMiddleware
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get user from context (set by AuthMiddleware)
-		_, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Unauthorized - authentication required",
-			})
-			c.Abort()
-			return
-		}
-
-		// Get isAdmin flag from context
-		isAdmin, exists := c.Get("is_admin")
-		if !exists {
-			userID, _ := c.Get("user_id")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   "Forbidden - admin access required",
-				"debug":   "is_admin not found in context",
-				"user_id": userID,
-			})
-			c.Abort()
+		if _, ok := UserID(c); !ok {
+			httputil.RespondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Unauthorized - authentication required")
 			return
 		}
 
-		isAdminBool, ok := isAdmin.(bool)
-		if !ok || !isAdminBool {
-			userID, _ := c.Get("user_id")
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":          "Forbidden - admin access required",
-				"debug":          "is_admin is false or not a bool",
-				"is_admin_value": isAdmin,
-				"is_admin_type":  ok,
-				"user_id":        userID,
-			})
-			c.Abort()
+		if !IsAdmin(c) {
+			httputil.RespondError(c, http.StatusForbidden, httputil.CodeForbidden, "Forbidden - admin access required")
 			return
 		}
 
@@ -52,3 +29,28 @@ func AdminMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AdminAllowlistEnv names the comma-separated list of admin emails allowed
+// to perform the most sensitive admin actions, such as granting admin and
+// purging user data
+const AdminAllowlistEnv = "ADMIN_SENSITIVE_ALLOWLIST"
+
+// RequireAdminAllowlist restricts a route to admins whose email is listed in
+// ADMIN_SENSITIVE_ALLOWLIST. When the variable is unset every admin passes,
+// so the allowlist is opt-in. Must be used AFTER AdminMiddleware.
+func RequireAdminAllowlist() gin.HandlerFunc {
+	allowed := make(map[string]bool)
+	for _, email := range strings.Split(os.Getenv(AdminAllowlistEnv), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			allowed[email] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		if len(allowed) > 0 && !allowed[strings.ToLower(UserEmail(c))] {
+			httputil.RespondError(c, http.StatusForbidden, httputil.CodeForbidden, "Forbidden - this action is restricted to allowlisted admins")
+			return
+		}
+		c.Next()
+	}
+}
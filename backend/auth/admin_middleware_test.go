@@ -123,7 +123,8 @@ func TestAdminMiddleware(t *testing.T) {
 
 		var resp map[string]interface{}
 		_ = json.Unmarshal(w.Body.Bytes(), &resp)
-		assert.Contains(t, resp["debug"], "is_admin not found in context")
+		assert.Equal(t, "forbidden", resp["code"])
+		assert.NotContains(t, resp, "user_id", "context values must not leak into errors")
 	})
 
 	t.Run("rejects when is_admin is not a bool", func(t *testing.T) {
@@ -194,3 +195,42 @@ func TestAdminMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, w.Code)
 	})
 }
+
+func TestRequireAdminAllowlist(t *testing.T) {
+	serve := func(email string) int {
+		w := httptest.NewRecorder()
+		_, r := gin.CreateTestContext(w)
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", "admin-user-1")
+			c.Set("user_email", email)
+			c.Set("is_admin", true)
+			c.Next()
+		})
+		r.Use(AdminMiddleware())
+		r.Use(RequireAdminAllowlist())
+		r.POST("/admin/purge", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		})
+
+		req, _ := http.NewRequest("POST", "/admin/purge", nil)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("allows every admin when unset", func(t *testing.T) {
+		t.Setenv(AdminAllowlistEnv, "")
+		assert.Equal(t, http.StatusOK, serve("admin@example.com"))
+	})
+
+	t.Run("allows listed admins case-insensitively", func(t *testing.T) {
+		t.Setenv(AdminAllowlistEnv, "root@example.com, Owner@Example.com")
+		assert.Equal(t, http.StatusOK, serve("owner@example.com"))
+		assert.Equal(t, http.StatusOK, serve("root@example.com"))
+	})
+
+	t.Run("rejects admins not on the list", func(t *testing.T) {
+		t.Setenv(AdminAllowlistEnv, "root@example.com")
+		assert.Equal(t, http.StatusForbidden, serve("admin@example.com"))
+		assert.Equal(t, http.StatusForbidden, serve(""))
+	})
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"investorcenter-api/httputil"
+)
+
+// Context keys set by AuthMiddleware and OptionalAuthMiddleware.
+const (
+	ContextUserID    = "user_id"
+	ContextUserEmail = "user_email"
+	ContextIsAdmin   = "is_admin"
+)
+
+// ContextRateLimited is set by the rate limit middlewares on a read request
+// over its limit that was let through to be served degraded.
+const ContextRateLimited = "rate_limited"
+
+// UserID returns the authenticated user's ID from the Gin context. ok is
+// false when no user is set, e.g. on public routes or OptionalAuthMiddleware
+// requests without a valid token.
+func UserID(c *gin.Context) (string, bool) {
+	userID := c.GetString(ContextUserID)
+	return userID, userID != ""
+}
+
+// UserEmail returns the authenticated user's email from the token claims, or
+// "" when no user is set.
+func UserEmail(c *gin.Context) string {
+	return c.GetString(ContextUserEmail)
+}
+
+// MustUser returns the authenticated user's ID, or responds 401 and returns
+// false when there is none. Handlers return immediately on false:
+//
+//	userID, ok := auth.MustUser(c)
+//	if !ok {
+//		return
+//	}
+func MustUser(c *gin.Context) (string, bool) {
+	userID, ok := UserID(c)
+	if !ok {
+		httputil.RespondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Unauthorized")
+	}
+	return userID, ok
+}
+
+// IsAdmin reports whether the authenticated user's token carries the admin
+// claim. False for unauthenticated requests.
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool(ContextIsAdmin)
+}
+
+// RateLimited reports whether the request is over its rate limit and should
+// be served from cache, or rejected if there is nothing cached.
+func RateLimited(c *gin.Context) bool {
+	return c.GetBool(ContextRateLimited)
+}
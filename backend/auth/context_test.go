@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserID(t *testing.T) {
+	t.Run("returns user ID when set", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set(ContextUserID, "user-123")
+
+		userID, ok := UserID(c)
+		assert.True(t, ok)
+		assert.Equal(t, "user-123", userID)
+	})
+
+	t.Run("absent when not set", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		userID, ok := UserID(c)
+		assert.False(t, ok)
+		assert.Empty(t, userID)
+	})
+
+	t.Run("absent when set to a non-string or empty value", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set(ContextUserID, 42)
+		_, ok := UserID(c)
+		assert.False(t, ok)
+
+		c.Set(ContextUserID, "")
+		_, ok = UserID(c)
+		assert.False(t, ok)
+	})
+}
+
+func TestMustUser(t *testing.T) {
+	t.Run("returns user without writing a response", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set(ContextUserID, "user-123")
+
+		userID, ok := MustUser(c)
+		assert.True(t, ok)
+		assert.Equal(t, "user-123", userID)
+		assert.False(t, c.IsAborted())
+		assert.Zero(t, w.Body.Len())
+	})
+
+	t.Run("responds 401 when absent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		_, r := gin.CreateTestContext(w)
+		reached := false
+		r.GET("/me", func(c *gin.Context) {
+			if _, ok := MustUser(c); !ok {
+				return
+			}
+			reached = true
+		})
+
+		req, _ := http.NewRequest("GET", "/me", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.False(t, reached)
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "unauthorized", resp["code"])
+		assert.Equal(t, "Unauthorized", resp["error"])
+	})
+}
+
+func TestIsAdmin(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.False(t, IsAdmin(c), "unauthenticated")
+
+	c.Set(ContextIsAdmin, false)
+	assert.False(t, IsAdmin(c))
+
+	c.Set(ContextIsAdmin, "true")
+	assert.False(t, IsAdmin(c), "non-bool claim")
+
+	c.Set(ContextIsAdmin, true)
+	assert.True(t, IsAdmin(c))
+}
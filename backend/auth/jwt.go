@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -60,8 +62,15 @@ func GenerateAccessToken(user *models.User) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
-// GenerateRefreshToken creates a long-lived refresh token
+// GenerateRefreshToken creates a long-lived refresh token. Each token gets a
+// random ID so tokens issued to a user within the same second still differ,
+// which refresh token rotation relies on.
 func GenerateRefreshToken(user *models.User) (string, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	claims := Claims{
 		UserID:  user.ID,
 		Email:   user.Email,
@@ -72,6 +81,7 @@ func GenerateRefreshToken(user *models.User) (string, error) {
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "investorcenter.ai",
 			Subject:   user.ID,
+			ID:        hex.EncodeToString(tokenID),
 		},
 	}
 
@@ -121,6 +121,20 @@ func TestGenerateRefreshToken(t *testing.T) {
 
 		assert.NotEqual(t, token1, token2)
 	})
+
+	t.Run("back-to-back tokens for the same user differ", func(t *testing.T) {
+		user := createTestUser()
+
+		token1, err := GenerateRefreshToken(user)
+		require.NoError(t, err)
+		token2, err := GenerateRefreshToken(user)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, token1, token2)
+		claims, err := ValidateToken(token1)
+		require.NoError(t, err)
+		assert.Len(t, claims.ID, 32)
+	})
 }
 
 func TestValidateToken(t *testing.T) {
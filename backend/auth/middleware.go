@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"investorcenter-api/httputil"
 )
 
 // AuthMiddleware validates JWT access token from Authorization header
@@ -13,16 +15,14 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			httputil.RespondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Authorization header required")
 			return
 		}
 
 		// Check Bearer prefix
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format. Use: Bearer <token>"})
-			c.Abort()
+			httputil.RespondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid authorization header format. Use: Bearer <token>")
 			return
 		}
 
@@ -31,23 +31,22 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Validate token
 		claims, err := ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			httputil.RespondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid or expired token")
 			return
 		}
 
 		// Store user info in context for handlers to access
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("is_admin", claims.IsAdmin)
+		c.Set(ContextUserID, claims.UserID)
+		c.Set(ContextUserEmail, claims.Email)
+		c.Set(ContextIsAdmin, claims.IsAdmin)
 
 		c.Next()
 	}
 }
 
 // OptionalAuthMiddleware extracts user info from JWT if present but does not reject
-// unauthenticated requests. Handlers check c.GetString("user_id") to determine tier.
-// Empty string = unauthenticated/free tier.
+// unauthenticated requests. Handlers check UserID to determine tier; no user
+// means unauthenticated/free tier.
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -72,18 +71,16 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("is_admin", claims.IsAdmin)
+		c.Set(ContextUserID, claims.UserID)
+		c.Set(ContextUserEmail, claims.Email)
+		c.Set(ContextIsAdmin, claims.IsAdmin)
 		c.Next()
 	}
 }
 
 // GetUserIDFromContext retrieves user ID from Gin context (set by AuthMiddleware)
+//
+// Deprecated: use UserID, or MustUser in handlers that require a user.
 func GetUserIDFromContext(c *gin.Context) (string, bool) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		return "", false
-	}
-	return userID.(string), true
+	return UserID(c)
 }
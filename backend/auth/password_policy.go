@@ -0,0 +1,138 @@
+package auth
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password rules reported in PasswordViolation.Rule
+const (
+	PasswordRuleMinLength   = "min_length"
+	PasswordRuleCharClasses = "char_classes"
+	PasswordRuleCommon      = "common_password"
+)
+
+// PasswordPolicy is the strength a new password must meet at signup,
+// reset and change. Character classes are lowercase, uppercase, digits
+// and symbols.
+type PasswordPolicy struct {
+	MinLength      int
+	MinCharClasses int
+	BlockCommon    bool // Reject passwords on the embedded common-password list
+}
+
+// PasswordViolation is one rule a password failed, suitable for returning
+// to the client as a field-level error
+type PasswordViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = parseCommonPasswords(commonPasswordList)
+
+// passwordPolicy is read once from PASSWORD_MIN_LENGTH (default 8),
+// PASSWORD_MIN_CHAR_CLASSES (default 2) and PASSWORD_BLOCK_COMMON
+// (default true)
+var passwordPolicy = loadPasswordPolicy()
+
+func loadPasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{MinLength: 8, MinCharClasses: 2, BlockCommon: true}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n >= 8 && n <= 128 {
+		policy.MinLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_CHAR_CLASSES")); err == nil && n >= 1 && n <= 4 {
+		policy.MinCharClasses = n
+	}
+	if os.Getenv("PASSWORD_BLOCK_COMMON") == "false" {
+		policy.BlockCommon = false
+	}
+	return policy
+}
+
+// ValidatePassword checks password against the configured policy and
+// returns every rule it fails, attributed to field (e.g. "new_password").
+// A nil result means the password is acceptable.
+func ValidatePassword(field, password string) []PasswordViolation {
+	return passwordPolicy.Validate(field, password)
+}
+
+// Validate checks password against p; see ValidatePassword
+func (p PasswordPolicy) Validate(field, password string) []PasswordViolation {
+	var violations []PasswordViolation
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Field:   field,
+			Rule:    PasswordRuleMinLength,
+			Message: fmt.Sprintf("Must be at least %d characters", p.MinLength),
+		})
+	}
+
+	if classes := passwordCharClasses(password); classes < p.MinCharClasses {
+		violations = append(violations, PasswordViolation{
+			Field: field,
+			Rule:  PasswordRuleCharClasses,
+			Message: fmt.Sprintf("Must mix at least %d of: lowercase letters, uppercase letters, digits, symbols",
+				p.MinCharClasses),
+		})
+	}
+
+	if p.BlockCommon && commonPasswords[strings.ToLower(password)] {
+		violations = append(violations, PasswordViolation{
+			Field:   field,
+			Rule:    PasswordRuleCommon,
+			Message: "Is too common; choose a less predictable password",
+		})
+	}
+
+	return violations
+}
+
+// passwordCharClasses counts how many of lowercase, uppercase, digit and
+// symbol characters password contains
+func passwordCharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// parseCommonPasswords turns the embedded list into a lookup set, skipping
+// blank lines and # comments
+func parseCommonPasswords(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = true
+	}
+	return set
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func violatedRules(violations []PasswordViolation) []string {
+	var rules []string
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 10, MinCharClasses: 3, BlockCommon: true}
+
+	t.Run("strong password passes", func(t *testing.T) {
+		assert.Empty(t, policy.Validate("password", "Tr4de-Winds"))
+		assert.Empty(t, policy.Validate("password", "correct horse Battery"))
+	})
+
+	t.Run("short password", func(t *testing.T) {
+		violations := policy.Validate("password", "Ab1!xyz")
+		assert.Equal(t, []string{PasswordRuleMinLength}, violatedRules(violations))
+		assert.Equal(t, "password", violations[0].Field)
+		assert.Equal(t, "Must be at least 10 characters", violations[0].Message)
+	})
+
+	t.Run("too few character classes", func(t *testing.T) {
+		violations := policy.Validate("new_password", "onlylowercaseletters")
+		assert.Equal(t, []string{PasswordRuleCharClasses}, violatedRules(violations))
+		assert.Equal(t, "new_password", violations[0].Field)
+	})
+
+	t.Run("common password regardless of case", func(t *testing.T) {
+		violations := policy.Validate("password", "P@ssw0rd")
+		assert.Contains(t, violatedRules(violations), PasswordRuleCommon)
+
+		violations = PasswordPolicy{MinLength: 8, MinCharClasses: 1, BlockCommon: true}.Validate("password", "QWERTY123")
+		assert.Equal(t, []string{PasswordRuleCommon}, violatedRules(violations))
+	})
+
+	t.Run("reports every failed rule", func(t *testing.T) {
+		violations := policy.Validate("password", "password")
+		assert.Equal(t, []string{PasswordRuleMinLength, PasswordRuleCharClasses, PasswordRuleCommon}, violatedRules(violations))
+	})
+
+	t.Run("blocklist can be turned off", func(t *testing.T) {
+		lenient := PasswordPolicy{MinLength: 8, MinCharClasses: 1}
+		assert.Empty(t, lenient.Validate("password", "password123"))
+	})
+
+	t.Run("length counts characters, not bytes", func(t *testing.T) {
+		assert.Contains(t, violatedRules(policy.Validate("password", "Ünïcødé1")), PasswordRuleMinLength)
+	})
+}
+
+func TestPasswordCharClasses(t *testing.T) {
+	assert.Equal(t, 0, passwordCharClasses(""))
+	assert.Equal(t, 1, passwordCharClasses("abc"))
+	assert.Equal(t, 2, passwordCharClasses("abc123"))
+	assert.Equal(t, 3, passwordCharClasses("Abc123"))
+	assert.Equal(t, 4, passwordCharClasses("Abc 123"))
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_LENGTH", "")
+		t.Setenv("PASSWORD_MIN_CHAR_CLASSES", "")
+		t.Setenv("PASSWORD_BLOCK_COMMON", "")
+		assert.Equal(t, PasswordPolicy{MinLength: 8, MinCharClasses: 2, BlockCommon: true}, loadPasswordPolicy())
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_LENGTH", "12")
+		t.Setenv("PASSWORD_MIN_CHAR_CLASSES", "3")
+		t.Setenv("PASSWORD_BLOCK_COMMON", "false")
+		assert.Equal(t, PasswordPolicy{MinLength: 12, MinCharClasses: 3}, loadPasswordPolicy())
+	})
+
+	t.Run("out of range values keep defaults", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_LENGTH", "4")
+		t.Setenv("PASSWORD_MIN_CHAR_CLASSES", "5")
+		assert.Equal(t, 8, loadPasswordPolicy().MinLength)
+		assert.Equal(t, 2, loadPasswordPolicy().MinCharClasses)
+	})
+}
+
+func TestCommonPasswordList(t *testing.T) {
+	assert.Greater(t, len(commonPasswords), 7000)
+	assert.True(t, commonPasswords["password123"])
+	assert.False(t, commonPasswords[""], "blank lines are skipped")
+	for entry := range commonPasswords {
+		assert.NotContains(t, entry, "#", "comments are skipped")
+	}
+}
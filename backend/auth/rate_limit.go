@@ -1,19 +1,29 @@
 package auth
 
 import (
+	"log"
+	"math"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"investorcenter-api/httputil"
 )
 
 // Simple in-memory rate limiter (use Redis in production for distributed systems)
 type rateLimiter struct {
 	mu       sync.RWMutex
 	attempts map[string][]time.Time
-	max      int
+	max      int // 0 turns the limit off
 	window   time.Duration
+	// degradeReads lets GET/HEAD requests over the limit through, marked with
+	// RateLimited, so the handler can serve cached data instead of a 429.
+	// Only set it for routes whose handler has such a fallback.
+	degradeReads bool
 }
 
 var loginLimiter = &rateLimiter{
@@ -22,56 +32,146 @@ var loginLimiter = &rateLimiter{
 	window:   15 * time.Minute, // Per 15 minutes
 }
 
+var exportLimiter = &rateLimiter{
+	attempts: make(map[string][]time.Time),
+	max:      3,         // Max 3 data exports
+	window:   time.Hour, // Per hour
+}
+
+var summaryLimiter = &rateLimiter{
+	attempts:     make(map[string][]time.Time),
+	max:          loadSummaryLimit(), // Market summary requests (off by default)
+	window:       time.Minute,        // Per minute
+	degradeReads: loadDegradeReads(),
+}
+
+// loadSummaryLimit reads MARKET_SUMMARY_RATE_LIMIT, the market summary
+// requests allowed per IP per minute. Unset or 0 leaves it unlimited.
+func loadSummaryLimit() int {
+	raw := os.Getenv("MARKET_SUMMARY_RATE_LIMIT")
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		log.Printf("⚠️  Ignoring invalid MARKET_SUMMARY_RATE_LIMIT %q, leaving it unlimited", raw)
+		return 0
+	}
+	return v
+}
+
+// loadDegradeReads reads RATE_LIMIT_DEGRADE_READS (default true). false
+// makes read endpoints with a cached fallback reject with 429 like the rest.
+func loadDegradeReads() bool {
+	raw := os.Getenv("RATE_LIMIT_DEGRADE_READS")
+	if raw == "" {
+		return true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("⚠️  Ignoring invalid RATE_LIMIT_DEGRADE_READS %q, using true", raw)
+		return true
+	}
+	return v
+}
+
 // RateLimitMiddleware limits requests by IP address
 func RateLimitMiddleware(limiter *rateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		if !limiter.Allow(ip) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests. Please try again later.",
-			})
-			c.Abort()
-			return
+		limiter.handle(c, c.ClientIP())
+	}
+}
+
+// UserRateLimitMiddleware limits requests by authenticated user, falling
+// back to IP address. Must run after AuthMiddleware.
+func UserRateLimitMiddleware(limiter *rateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := UserID(c)
+		if !ok {
+			key = c.ClientIP()
 		}
+		limiter.handle(c, key)
+	}
+}
 
+// handle counts the request against key's budget and sets X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset (Unix seconds). Requests over
+// the limit also get Retry-After and are rejected with 429, unless they are
+// reads the limiter degrades. A limiter turned off passes every request.
+func (rl *rateLimiter) handle(c *gin.Context, key string) {
+	if rl.max <= 0 {
 		c.Next()
+		return
 	}
+	d := rl.take(key)
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.max))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(d.reset.Unix(), 10))
+
+	if d.allowed {
+		c.Next()
+		return
+	}
+
+	retryAfter := int(math.Ceil(time.Until(d.reset).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	if rl.degradeReads && (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) {
+		c.Set(ContextRateLimited, true)
+		c.Next()
+		return
+	}
+	httputil.RespondError(c, http.StatusTooManyRequests, httputil.CodeRateLimited, "Too many requests. Please try again later.")
+}
+
+// rateDecision is the outcome of counting one request against a limiter
+type rateDecision struct {
+	allowed   bool
+	remaining int       // requests left in the window
+	reset     time.Time // when the oldest counted request leaves the window
 }
 
 // Allow checks if request from IP is allowed
 func (rl *rateLimiter) Allow(key string) bool {
+	return rl.take(key).allowed
+}
+
+// take counts a request for key if it is under the limit and reports the
+// budget left afterwards.
+func (rl *rateLimiter) take(key string) rateDecision {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	now := time.Now()
 	cutoff := now.Add(-rl.window)
 
-	// Get attempts for this key
-	attempts, exists := rl.attempts[key]
-	if !exists {
-		rl.attempts[key] = []time.Time{now}
-		return true
-	}
-
 	// Remove expired attempts
 	validAttempts := []time.Time{}
-	for _, t := range attempts {
+	for _, t := range rl.attempts[key] {
 		if t.After(cutoff) {
 			validAttempts = append(validAttempts, t)
 		}
 	}
 
 	// Check if under limit
-	if len(validAttempts) >= rl.max {
-		rl.attempts[key] = validAttempts
-		return false
+	allowed := len(validAttempts) < rl.max
+	if allowed {
+		validAttempts = append(validAttempts, now)
 	}
-
-	// Add new attempt
-	validAttempts = append(validAttempts, now)
 	rl.attempts[key] = validAttempts
-	return true
+
+	reset := now
+	if len(validAttempts) > 0 {
+		reset = validAttempts[0].Add(rl.window)
+	}
+	return rateDecision{
+		allowed:   allowed,
+		remaining: rl.max - len(validAttempts),
+		reset:     reset,
+	}
 }
 
 // Cleanup removes old entries (call periodically)
@@ -112,3 +212,13 @@ func StartRateLimiterCleanup(limiter *rateLimiter) {
 func GetLoginLimiter() *rateLimiter {
 	return loginLimiter
 }
+
+// GetExportLimiter returns the user data export limiter instance
+func GetExportLimiter() *rateLimiter {
+	return exportLimiter
+}
+
+// GetSummaryLimiter returns the market summary limiter instance
+func GetSummaryLimiter() *rateLimiter {
+	return summaryLimiter
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -169,6 +170,169 @@ func TestRateLimitMiddleware_BlocksExcessiveRequests(t *testing.T) {
 	assert.Equal(t, http.StatusTooManyRequests, w.Code)
 }
 
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	rl := newTestLimiter(2, time.Minute)
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(rl))
+	r.POST("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/test", nil))
+		return w
+	}
+
+	start := time.Now()
+	first := send()
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+	reset, err := strconv.ParseInt(first.Header().Get("X-RateLimit-Reset"), 10, 64)
+	assert.NoError(t, err)
+	// The budget frees up a window after the first request
+	assert.InDelta(t, start.Add(time.Minute).Unix(), reset, 1)
+	assert.Empty(t, first.Header().Get("Retry-After"))
+
+	second := send()
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "0", second.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, first.Header().Get("X-RateLimit-Reset"), second.Header().Get("X-RateLimit-Reset"))
+
+	blocked := send()
+	assert.Equal(t, http.StatusTooManyRequests, blocked.Code)
+	assert.Equal(t, "0", blocked.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, first.Header().Get("X-RateLimit-Reset"), blocked.Header().Get("X-RateLimit-Reset"))
+	retryAfter, err := strconv.Atoi(blocked.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, 60, retryAfter, 1)
+}
+
+func TestRateLimitMiddleware_RetryAfterTracksOldestRequest(t *testing.T) {
+	rl := newTestLimiter(1, 1500*time.Millisecond)
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(rl))
+	r.POST("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/test", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/test", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// 1.5s left rounds up, so clients never retry before the slot frees
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware_DegradesReads(t *testing.T) {
+	rl := newTestLimiter(1, time.Minute)
+	rl.degradeReads = true
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(rl))
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"degraded": RateLimited(c)})
+	}
+	r.GET("/test", handler)
+	r.POST("/test", handler)
+
+	send := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/test", nil))
+		return w
+	}
+
+	w := send("GET")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"degraded":false}`, w.Body.String())
+
+	// Over the limit, a read reaches the handler marked as rate limited
+	w = send("GET")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"degraded":true}`, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// Writes are still rejected
+	w = send("POST")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestLoadDegradeReads(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEGRADE_READS", "")
+	assert.True(t, loadDegradeReads())
+	t.Setenv("RATE_LIMIT_DEGRADE_READS", "false")
+	assert.False(t, loadDegradeReads())
+	t.Setenv("RATE_LIMIT_DEGRADE_READS", "nope")
+	assert.True(t, loadDegradeReads())
+}
+
+func TestLoadSummaryLimit(t *testing.T) {
+	t.Setenv("MARKET_SUMMARY_RATE_LIMIT", "")
+	assert.Equal(t, 0, loadSummaryLimit())
+	t.Setenv("MARKET_SUMMARY_RATE_LIMIT", "30")
+	assert.Equal(t, 30, loadSummaryLimit())
+	t.Setenv("MARKET_SUMMARY_RATE_LIMIT", "-1")
+	assert.Equal(t, 0, loadSummaryLimit())
+}
+
+func TestRateLimitMiddleware_OffLimiterPassesEverything(t *testing.T) {
+	rl := newTestLimiter(0, time.Minute)
+
+	r := gin.New()
+	r.Use(RateLimitMiddleware(rl))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestUserRateLimitMiddleware_KeysByUser(t *testing.T) {
+	rl := newTestLimiter(1, time.Minute)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Next()
+	})
+	r.Use(UserRateLimitMiddleware(rl))
+	r.GET("/test", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	send := func(user string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Test-User", user)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Same IP, different users: each user has their own budget
+	assert.Equal(t, http.StatusOK, send("user-1"))
+	assert.Equal(t, http.StatusOK, send("user-2"))
+	assert.Equal(t, http.StatusTooManyRequests, send("user-1"))
+}
+
+func TestGetExportLimiter(t *testing.T) {
+	limiter := GetExportLimiter()
+	assert.NotNil(t, limiter)
+	assert.Equal(t, 3, limiter.max)
+	assert.Equal(t, time.Hour, limiter.window)
+}
+
 func TestGetLoginLimiter(t *testing.T) {
 	limiter := GetLoginLimiter()
 	assert.NotNil(t, limiter)
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// When a refresh token is rotated, its successor is stored on the rotated
+// session sealed with AES-256-GCM under a key derived from the rotated
+// token. Only a client presenting the rotated token can open it, so the
+// database never holds a usable refresh token.
+
+func refreshSuccessorCipher(token string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("investorcenter-refresh-successor:" + token))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealRefreshSuccessor encrypts successor so that it can only be opened
+// with token, the refresh token it replaces
+func SealRefreshSuccessor(token, successor string) (string, error) {
+	gcm, err := refreshSuccessorCipher(token)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(successor), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenRefreshSuccessor decrypts a successor sealed by SealRefreshSuccessor
+func OpenRefreshSuccessor(token, sealed string) (string, error) {
+	gcm, err := refreshSuccessorCipher(token)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return "", errors.New("malformed sealed refresh token")
+	}
+	nonce, ciphertext := raw[:gcm.NonceSize()], raw[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to open sealed refresh token: %w", err)
+	}
+	return string(plain), nil
+}
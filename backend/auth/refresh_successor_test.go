@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshSuccessor_RoundTrip(t *testing.T) {
+	sealed, err := SealRefreshSuccessor("old-token", "new-token")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "new-token")
+
+	successor, err := OpenRefreshSuccessor("old-token", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "new-token", successor)
+
+	again, err := SealRefreshSuccessor("old-token", "new-token")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "each seal uses a fresh nonce")
+}
+
+func TestRefreshSuccessor_OnlyTheRotatedTokenOpens(t *testing.T) {
+	sealed, err := SealRefreshSuccessor("old-token", "new-token")
+	require.NoError(t, err)
+
+	_, err = OpenRefreshSuccessor("other-token", sealed)
+	assert.Error(t, err)
+	_, err = OpenRefreshSuccessor("old-token", "not base64!")
+	assert.Error(t, err)
+	_, err = OpenRefreshSuccessor("old-token", "")
+	assert.Error(t, err)
+}
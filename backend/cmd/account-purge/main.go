@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+const defaultGraceDays = 30
+
+// Command line flags
+var (
+	dryRun = flag.Bool("dry-run", false, "List accounts that would be purged without changing anything")
+	limit  = flag.Int("limit", 500, "Maximum number of accounts to purge in one run")
+)
+
+// purgeStore is the database access the job needs, swapped out in tests
+type purgeStore interface {
+	UsersPendingPurge(deletedBefore time.Time) ([]string, error)
+	PurgeUser(userID string) (*models.AccountPurgeReport, error)
+}
+
+type dbPurgeStore struct{}
+
+func (dbPurgeStore) UsersPendingPurge(deletedBefore time.Time) ([]string, error) {
+	return database.GetUsersPendingPurge(deletedBefore)
+}
+
+func (dbPurgeStore) PurgeUser(userID string) (*models.AccountPurgeReport, error) {
+	return database.PurgeUserData(userID)
+}
+
+func main() {
+	flag.Parse()
+
+	if err := database.Initialize(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	cutoff := time.Now().Add(-graceWindow())
+	os.Exit(run(dbPurgeStore{}, cutoff, *limit, *dryRun))
+}
+
+// graceWindow is how long a deleted account is kept before its data is
+// purged, from ACCOUNT_PURGE_GRACE_DAYS (default 30)
+func graceWindow() time.Duration {
+	days := defaultGraceDays
+	if v := os.Getenv("ACCOUNT_PURGE_GRACE_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			days = n
+		} else {
+			log.Printf("⚠️  Invalid ACCOUNT_PURGE_GRACE_DAYS %q, using %d", v, defaultGraceDays)
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// run purges every account deleted before cutoff, up to limit. A failed
+// purge is logged and the job moves on; the exit code is 1 if any failed.
+func run(store purgeStore, cutoff time.Time, limit int, dry bool) int {
+	userIDs, err := store.UsersPendingPurge(cutoff)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+	if len(userIDs) > limit {
+		log.Printf("%d accounts pending purge, processing the first %d", len(userIDs), limit)
+		userIDs = userIDs[:limit]
+	}
+	if len(userIDs) == 0 {
+		log.Println("✅ No deleted accounts past the grace window")
+		return 0
+	}
+
+	if dry {
+		for _, id := range userIDs {
+			log.Printf("[dry-run] would purge user %s", id)
+		}
+		return 0
+	}
+
+	failed := 0
+	for _, id := range userIDs {
+		report, err := store.PurgeUser(id)
+		if err != nil {
+			log.Printf("❌ Failed to purge user %s: %v", id, err)
+			failed++
+			continue
+		}
+		log.Printf("Purged user %s (%d rows)", id, report.TotalRows())
+		for _, t := range report.Tables {
+			if t.Rows > 0 {
+				log.Printf("  %s: %d %s", t.Table, t.Rows, t.Action)
+			}
+		}
+	}
+
+	log.Printf("Purged %d of %d accounts", len(userIDs)-failed, len(userIDs))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/models"
+)
+
+type fakePurgeStore struct {
+	pending []string
+	failFor map[string]bool
+	purged  []string
+}
+
+func (f *fakePurgeStore) UsersPendingPurge(deletedBefore time.Time) ([]string, error) {
+	return f.pending, nil
+}
+
+func (f *fakePurgeStore) PurgeUser(userID string) (*models.AccountPurgeReport, error) {
+	if f.failFor[userID] {
+		return nil, errors.New("deadlock detected")
+	}
+	f.purged = append(f.purged, userID)
+	return &models.AccountPurgeReport{UserID: userID, Tables: []models.TablePurgeCount{
+		{Table: "sessions", Action: models.PurgeActionDeleted, Rows: 2},
+	}}, nil
+}
+
+func TestRunPurgesPendingAccounts(t *testing.T) {
+	store := &fakePurgeStore{pending: []string{"u1", "u2", "u3"}, failFor: map[string]bool{"u2": true}}
+	assert.Equal(t, 1, run(store, time.Now(), 10, false), "a failed purge fails the run")
+	assert.Equal(t, []string{"u1", "u3"}, store.purged, "one failure must not stop the rest")
+
+	store = &fakePurgeStore{pending: []string{"u1", "u2", "u3"}}
+	assert.Equal(t, 0, run(store, time.Now(), 2, false))
+	assert.Equal(t, []string{"u1", "u2"}, store.purged)
+}
+
+func TestRunDryRunChangesNothing(t *testing.T) {
+	store := &fakePurgeStore{pending: []string{"u1"}}
+	assert.Equal(t, 0, run(store, time.Now(), 10, true))
+	assert.Empty(t, store.purged)
+}
+
+func TestGraceWindow(t *testing.T) {
+	t.Setenv("ACCOUNT_PURGE_GRACE_DAYS", "")
+	assert.Equal(t, 30*24*time.Hour, graceWindow())
+
+	t.Setenv("ACCOUNT_PURGE_GRACE_DAYS", "7")
+	assert.Equal(t, 7*24*time.Hour, graceWindow())
+
+	t.Setenv("ACCOUNT_PURGE_GRACE_DAYS", "soon")
+	assert.Equal(t, 30*24*time.Hour, graceWindow())
+}
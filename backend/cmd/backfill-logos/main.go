@@ -0,0 +1,234 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+	"investorcenter-api/services"
+)
+
+// Command line flags
+var (
+	limit     = flag.Int("limit", 0, "Limit number of tickers to backfill (0 = all tickers missing a logo)")
+	batchSize = flag.Int("batch-size", 50, "Tickers fetched between rate-limit pauses")
+	delay     = flag.Duration("delay", 2*time.Second, "Pause between batches to avoid rate limiting")
+	dryRun    = flag.Bool("dry-run", false, "Fetch and log logo URLs without updating tickers")
+	verbose   = flag.Bool("verbose", false, "Enable verbose logging")
+)
+
+// logoFetcher looks up a ticker's logo URL. Implemented by
+// services.TickerDetailsCache.
+type logoFetcher interface {
+	GetTickerLogoURL(symbol string) (string, error)
+}
+
+// logoStore updates tickers' logo_url, swapped out in tests
+type logoStore interface {
+	UpdateLogoURL(symbol, assetType, logoURL string) error
+}
+
+type dbLogoStore struct {
+	db *sql.DB
+}
+
+// UpdateLogoURL sets the ticker's logo_url unless one was stored since it
+// was loaded
+func (s dbLogoStore) UpdateLogoURL(symbol, assetType, logoURL string) error {
+	_, err := s.db.Exec(`
+		UPDATE tickers SET logo_url = $3, updated_at = NOW()
+		WHERE symbol = $1 AND asset_type = $2 AND COALESCE(logo_url, '') = ''`,
+		symbol, assetType, logoURL)
+	if err != nil {
+		return fmt.Errorf("failed to update logo for %s: %w", symbol, err)
+	}
+	return nil
+}
+
+// tickerRef identifies a tickers row
+type tickerRef struct {
+	Symbol    string
+	AssetType string
+}
+
+// backfillStats summarizes a run
+type backfillStats struct {
+	Updated int
+	NoLogo  int
+	Failed  int
+	Skipped int // not attempted because the Polygon quota ran out
+}
+
+// waitForQuota slows down near the monthly Polygon quota and fails once
+// it's used up, swapped out in tests
+var waitForQuota = func() error {
+	return services.UpstreamQuota().Wait(services.QuotaSourcePolygon)
+}
+
+func main() {
+	flag.Parse()
+
+	db, err := setupDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Count Polygon calls against POLYGON_MONTHLY_QUOTA, shared with the API
+	// server through upstream_quota_usage
+	quota := services.UpstreamQuota()
+	quota.SetStore(services.NewSQLQuotaStore(db))
+
+	apiKey := os.Getenv("POLYGON_API_KEY")
+	if apiKey == "" || apiKey == "demo" {
+		log.Println("Warning: POLYGON_API_KEY not set or using demo key. API calls may fail.")
+	}
+
+	tickers, err := loadTickersMissingLogo(db, *limit)
+	if err != nil {
+		log.Fatalf("Failed to load tickers: %v", err)
+	}
+	log.Printf("🔍 %d tickers missing a logo (batch size %d, dry-run=%v)", len(tickers), *batchSize, *dryRun)
+
+	// A symbol listed under more than one asset type is fetched once
+	cache := services.NewTickerDetailsCache(services.NewPolygonClient())
+	stats := backfillLogos(dbLogoStore{db: db}, cache, tickers, *batchSize, *delay, *dryRun, time.Sleep)
+	quota.Flush()
+
+	log.Println("\n📊 Logo Backfill Summary:")
+	log.Printf("  Tickers:        %d", len(tickers))
+	log.Printf("  Updated:        %d", stats.Updated)
+	log.Printf("  No logo:        %d", stats.NoLogo)
+	log.Printf("  Failed:         %d", stats.Failed)
+	log.Printf("  Skipped:        %d", stats.Skipped)
+	log.Printf("  Polygon calls:  %d", cache.Fetches())
+	if (stats.Failed > 0 || stats.Skipped > 0) && stats.Updated == 0 && stats.NoLogo == 0 {
+		os.Exit(1)
+	}
+}
+
+func setupDatabase() (*sql.DB, error) {
+	dbHost := getEnvOrDefault("DB_HOST", "localhost")
+	dbPort := getEnvOrDefault("DB_PORT", "5432")
+	dbUser := getEnvOrDefault("DB_USER", "investorcenter")
+	dbPassword := os.Getenv("DB_PASSWORD")
+	dbName := getEnvOrDefault("DB_NAME", "investorcenter_db")
+	sslMode := getEnvOrDefault("DB_SSLMODE", "disable")
+
+	if dbPassword == "" {
+		return nil, fmt.Errorf("DB_PASSWORD environment variable is required")
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		dbHost, dbPort, dbUser, dbPassword, dbName, sslMode)
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	log.Println("✅ Connected to database successfully")
+	return db, nil
+}
+
+// loadTickersMissingLogo lists active tickers without a logo_url, largest
+// first. Crypto is skipped: Polygon has no ticker details for it.
+func loadTickersMissingLogo(db *sql.DB, limit int) ([]tickerRef, error) {
+	query := `
+		SELECT symbol, asset_type FROM tickers
+		WHERE COALESCE(logo_url, '') = '' AND COALESCE(active, true)
+		  AND symbol NOT LIKE 'X:%' AND asset_type <> 'crypto'
+		ORDER BY market_cap DESC NULLS LAST, symbol`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT $1"
+		args = append(args, limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickers []tickerRef
+	for rows.Next() {
+		var t tickerRef
+		if err := rows.Scan(&t.Symbol, &t.AssetType); err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, t)
+	}
+	return tickers, rows.Err()
+}
+
+// backfillLogos fetches and stores the logo URL of each ticker, pausing for
+// delay between batches of size tickers. A ticker that fails is counted and
+// skipped; the run stops early only when the Polygon quota is used up, and
+// the tickers it didn't reach are counted as skipped.
+func backfillLogos(store logoStore, fetcher logoFetcher, tickers []tickerRef, size int, delay time.Duration, dry bool, sleep func(time.Duration)) backfillStats {
+	if size < 1 {
+		size = 1
+	}
+
+	var stats backfillStats
+	for start := 0; start < len(tickers); start += size {
+		if start > 0 && delay > 0 {
+			log.Printf("⏳ Processed %d/%d, waiting %s before next batch...", start, len(tickers), delay)
+			sleep(delay)
+		}
+
+		for i, t := range tickers[start:min(start+size, len(tickers))] {
+			if err := waitForQuota(); err != nil {
+				log.Printf("❌ %v", err)
+				stats.Skipped += len(tickers) - (start + i)
+				return stats
+			}
+
+			logoURL, err := fetcher.GetTickerLogoURL(t.Symbol)
+			switch {
+			case err != nil:
+				stats.Failed++
+				log.Printf("⚠️  %s: %v", t.Symbol, err)
+				continue
+			case logoURL == "":
+				stats.NoLogo++
+				if *verbose {
+					log.Printf("–  %s: no logo on Polygon", t.Symbol)
+				}
+				continue
+			}
+
+			if dry {
+				log.Printf("🔎 %s (%s): %s", t.Symbol, t.AssetType, logoURL)
+				stats.Updated++
+				continue
+			}
+			if err := store.UpdateLogoURL(t.Symbol, t.AssetType, logoURL); err != nil {
+				stats.Failed++
+				log.Printf("❌ %v", err)
+				continue
+			}
+			stats.Updated++
+			if *verbose {
+				log.Printf("✅ %s: %s", t.Symbol, logoURL)
+			}
+		}
+	}
+	return stats
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogoFetcher serves logos by symbol and records lookups
+type fakeLogoFetcher struct {
+	logos map[string]string
+	errs  map[string]error
+	calls []string
+}
+
+func (f *fakeLogoFetcher) GetTickerLogoURL(symbol string) (string, error) {
+	f.calls = append(f.calls, symbol)
+	if err := f.errs[symbol]; err != nil {
+		return "", err
+	}
+	return f.logos[symbol], nil
+}
+
+// fakeLogoStore records updates by symbol
+type fakeLogoStore struct {
+	updated map[string]string
+	err     error
+}
+
+func (s *fakeLogoStore) UpdateLogoURL(symbol, assetType, logoURL string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.updated[symbol] = logoURL
+	return nil
+}
+
+func refs(symbols ...string) []tickerRef {
+	tickers := make([]tickerRef, len(symbols))
+	for i, s := range symbols {
+		tickers[i] = tickerRef{Symbol: s, AssetType: "stock"}
+	}
+	return tickers
+}
+
+func TestBackfillLogos_BatchesWithDelay(t *testing.T) {
+	fetcher := &fakeLogoFetcher{
+		logos: map[string]string{"AAPL": "https://x/aapl.svg", "MSFT": "https://x/msft.svg", "SPY": "https://x/spy.svg"},
+		errs:  map[string]error{"BAD": errors.New("boom")},
+	}
+	store := &fakeLogoStore{updated: map[string]string{}}
+	var sleeps []time.Duration
+
+	stats := backfillLogos(store, fetcher, refs("AAPL", "MSFT", "NOLOGO", "BAD", "SPY"), 2, 2*time.Second, false,
+		func(d time.Duration) { sleeps = append(sleeps, d) })
+
+	assert.Equal(t, backfillStats{Updated: 3, NoLogo: 1, Failed: 1}, stats)
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second}, sleeps)
+	assert.Equal(t, map[string]string{"AAPL": "https://x/aapl.svg", "MSFT": "https://x/msft.svg", "SPY": "https://x/spy.svg"}, store.updated)
+	assert.Equal(t, []string{"AAPL", "MSFT", "NOLOGO", "BAD", "SPY"}, fetcher.calls)
+}
+
+func TestBackfillLogos_DryRunSkipsUpdates(t *testing.T) {
+	fetcher := &fakeLogoFetcher{logos: map[string]string{"AAPL": "https://x/aapl.svg"}}
+	store := &fakeLogoStore{updated: map[string]string{}}
+
+	stats := backfillLogos(store, fetcher, refs("AAPL"), 50, 2*time.Second, true, func(time.Duration) {
+		t.Fatal("single batch should not sleep")
+	})
+
+	assert.Equal(t, 1, stats.Updated)
+	assert.Empty(t, store.updated)
+}
+
+func TestBackfillLogos_StoreError(t *testing.T) {
+	fetcher := &fakeLogoFetcher{logos: map[string]string{"AAPL": "https://x/aapl.svg"}}
+	store := &fakeLogoStore{updated: map[string]string{}, err: errors.New("db down")}
+
+	stats := backfillLogos(store, fetcher, refs("AAPL"), 50, 0, false, func(time.Duration) {})
+
+	assert.Equal(t, backfillStats{Failed: 1}, stats)
+}
+
+func TestBackfillLogos_QuotaExhaustedSkipsRest(t *testing.T) {
+	fetcher := &fakeLogoFetcher{logos: map[string]string{"AAPL": "https://x/aapl.svg", "MSFT": "https://x/msft.svg"}}
+	store := &fakeLogoStore{updated: map[string]string{}}
+
+	calls := 0
+	prev := waitForQuota
+	waitForQuota = func() error {
+		calls++
+		if calls > 3 {
+			return errors.New("polygon monthly quota exhausted")
+		}
+		return nil
+	}
+	defer func() { waitForQuota = prev }()
+
+	stats := backfillLogos(store, fetcher, refs("AAPL", "MSFT", "NOLOGO", "SPY", "QQQ"), 2, 0, false, func(time.Duration) {})
+
+	assert.Equal(t, backfillStats{Updated: 2, NoLogo: 1, Skipped: 2}, stats)
+	assert.Equal(t, []string{"AAPL", "MSFT", "NOLOGO"}, fetcher.calls)
+}
+
+func TestLoadTickersMissingLogo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT symbol, asset_type FROM tickers .* LIMIT \$1`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "asset_type"}).
+			AddRow("AAPL", "stock").AddRow("SPY", "etf"))
+
+	tickers, err := loadTickersMissingLogo(db, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []tickerRef{{"AAPL", "stock"}, {"SPY", "etf"}}, tickers)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDBLogoStore_UpdateLogoURL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE tickers SET logo_url`).
+		WithArgs("AAPL", "stock", "https://x/aapl.svg").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, dbLogoStore{db: db}.UpdateLogoURL("AAPL", "stock", "https://x/aapl.svg"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+	"investorcenter-api/market"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+const dateLayout = "2006-01-02"
+
+// Command line flags
+var (
+	tickerFlag  = flag.String("ticker", "", "Comma-separated tickers to backfill (default: all active stocks and ETFs)")
+	fromFlag    = flag.String("from", "", "Start date YYYY-MM-DD (default: one year before -to)")
+	toFlag      = flag.String("to", "", "End date YYYY-MM-DD, inclusive (default: yesterday)")
+	concurrency = flag.Int("concurrency", 4, "Number of tickers processed in parallel")
+	dryRun      = flag.Bool("dry-run", false, "Report detected gaps without fetching or writing prices")
+	verbose     = flag.Bool("verbose", false, "Enable verbose logging")
+)
+
+// barFetcher fetches daily OHLCV bars. Implemented by services.PolygonClient.
+type barFetcher interface {
+	GetHistoricalData(symbol string, timespan string, from string, to string) ([]models.ChartDataPoint, error)
+}
+
+// dateRange is an inclusive range of calendar dates
+type dateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// backfillStats summarizes one ticker's backfill
+type backfillStats struct {
+	Ticker      string
+	MissingDays int
+	Ranges      int
+	Inserted    int
+	Err         error
+}
+
+var nyLocation = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}()
+
+func main() {
+	flag.Parse()
+
+	from, to, err := parseRange(*fromFlag, *toFlag, time.Now().In(nyLocation))
+	if err != nil {
+		log.Fatalf("Invalid date range: %v", err)
+	}
+
+	db, err := setupDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	// Count Polygon calls against POLYGON_MONTHLY_QUOTA, shared with the API
+	// server through upstream_quota_usage
+	quota := services.UpstreamQuota()
+	quota.SetStore(services.NewSQLQuotaStore(db))
+
+	apiKey := os.Getenv("POLYGON_API_KEY")
+	if apiKey == "" || apiKey == "demo" {
+		log.Println("Warning: POLYGON_API_KEY not set or using demo key. API calls may fail.")
+	}
+
+	tickers := parseTickers(*tickerFlag)
+	if len(tickers) == 0 {
+		tickers, err = loadActiveTickers(db)
+		if err != nil {
+			log.Fatalf("Failed to load tickers: %v", err)
+		}
+	}
+
+	log.Printf("🔍 Backfilling %d tickers from %s to %s (concurrency=%d, dry-run=%v)",
+		len(tickers), from.Format(dateLayout), to.Format(dateLayout), *concurrency, *dryRun)
+
+	stats := backfillAll(db, services.NewPolygonClient(), tickers, from, to, *concurrency, *dryRun)
+	quota.Flush()
+
+	var missing, inserted, failed int
+	for _, s := range stats {
+		missing += s.MissingDays
+		inserted += s.Inserted
+		if s.Err != nil {
+			failed++
+			log.Printf("❌ %s: %v", s.Ticker, s.Err)
+		}
+	}
+
+	log.Println("\n📊 Backfill Summary:")
+	log.Printf("  Tickers:      %d", len(stats))
+	log.Printf("  Missing days: %d", missing)
+	log.Printf("  Bars written: %d", inserted)
+	log.Printf("  Failed:       %d", failed)
+}
+
+func setupDatabase() (*sql.DB, error) {
+	dbHost := getEnvOrDefault("DB_HOST", "localhost")
+	dbPort := getEnvOrDefault("DB_PORT", "5432")
+	dbUser := getEnvOrDefault("DB_USER", "investorcenter")
+	dbPassword := os.Getenv("DB_PASSWORD")
+	dbName := getEnvOrDefault("DB_NAME", "investorcenter_db")
+	sslMode := getEnvOrDefault("DB_SSLMODE", "disable")
+
+	if dbPassword == "" {
+		return nil, fmt.Errorf("DB_PASSWORD environment variable is required")
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		dbHost, dbPort, dbUser, dbPassword, dbName, sslMode)
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	log.Println("✅ Connected to database successfully")
+	return db, nil
+}
+
+// parseRange resolves the -from/-to flags. -to defaults to yesterday (today's
+// bar isn't final yet) and -from to one year before -to.
+func parseRange(fromStr, toStr string, now time.Time) (time.Time, time.Time, error) {
+	to := dateOnly(now).AddDate(0, 0, -1)
+	if toStr != "" {
+		t, err := time.ParseInLocation(dateLayout, toStr, nyLocation)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -to: %w", err)
+		}
+		to = t
+	}
+
+	from := to.AddDate(-1, 0, 0)
+	if fromStr != "" {
+		f, err := time.ParseInLocation(dateLayout, fromStr, nyLocation)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %w", err)
+		}
+		from = f
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("-from %s is after -to %s", from.Format(dateLayout), to.Format(dateLayout))
+	}
+	return from, to, nil
+}
+
+func parseTickers(s string) []string {
+	var tickers []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tickers = append(tickers, t)
+	}
+	return tickers
+}
+
+func loadActiveTickers(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT symbol FROM tickers
+		WHERE COALESCE(active, true) AND asset_type IN ('CS', 'stock', 'PFD', 'ETF', 'etf')
+		ORDER BY symbol
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickers []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, symbol)
+	}
+	return tickers, rows.Err()
+}
+
+// backfillAll runs backfillTicker over tickers with bounded concurrency
+func backfillAll(db *sql.DB, client barFetcher, tickers []string, from, to time.Time, workers int, dry bool) []backfillStats {
+	if workers < 1 {
+		workers = 1
+	}
+
+	stats := make([]backfillStats, len(tickers))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stats[i] = backfillTicker(db, client, tickers[i], from, to, dry)
+			}
+		}()
+	}
+	for i := range tickers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return stats
+}
+
+// backfillTicker finds the trading days between from and to that have no
+// 1day bar and fetches only those ranges from Polygon
+func backfillTicker(db *sql.DB, client barFetcher, ticker string, from, to time.Time, dry bool) backfillStats {
+	stats := backfillStats{Ticker: ticker}
+
+	existing, err := existingDays(db, ticker, from, to)
+	if err != nil {
+		stats.Err = fmt.Errorf("failed to load existing bars: %w", err)
+		return stats
+	}
+
+	missing := missingDays(from, to, existing)
+	ranges := groupRanges(missing)
+	stats.MissingDays = len(missing)
+	stats.Ranges = len(ranges)
+
+	if len(missing) == 0 {
+		if *verbose {
+			log.Printf("✓ %s: no gaps", ticker)
+		}
+		return stats
+	}
+
+	log.Printf("🔎 %s: %d missing days in %d ranges", ticker, len(missing), len(ranges))
+	if dry {
+		for _, r := range ranges {
+			log.Printf("   %s → %s", r.From.Format(dateLayout), r.To.Format(dateLayout))
+		}
+		return stats
+	}
+
+	wanted := make(map[string]bool, len(missing))
+	for _, d := range missing {
+		wanted[d.Format(dateLayout)] = true
+	}
+
+	for _, r := range ranges {
+		// Slow down near the monthly Polygon quota and stop once it's used up
+		if err := services.UpstreamQuota().Wait(services.QuotaSourcePolygon); err != nil {
+			stats.Err = err
+			return stats
+		}
+		bars, err := client.GetHistoricalData(ticker, "day", r.From.Format(dateLayout), r.To.Format(dateLayout))
+		if err != nil {
+			stats.Err = fmt.Errorf("failed to fetch %s → %s: %w", r.From.Format(dateLayout), r.To.Format(dateLayout), err)
+			return stats
+		}
+
+		for _, bar := range bars {
+			day := dateOnly(bar.Timestamp.In(nyLocation))
+			if !wanted[day.Format(dateLayout)] {
+				continue
+			}
+			if err := upsertBar(db, ticker, day, bar); err != nil {
+				stats.Err = err
+				return stats
+			}
+			stats.Inserted++
+		}
+	}
+
+	log.Printf("✅ %s: wrote %d bars", ticker, stats.Inserted)
+	return stats
+}
+
+// existingDays returns the dates (YYYY-MM-DD, New York time) that already
+// have a 1day bar for ticker
+func existingDays(db *sql.DB, ticker string, from, to time.Time) (map[string]bool, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT to_char(time AT TIME ZONE 'America/New_York', 'YYYY-MM-DD')
+		FROM stock_prices
+		WHERE ticker = $1 AND interval = '1day' AND time >= $2 AND time < $3
+	`, ticker, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[string]bool)
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days[day] = true
+	}
+	return days, rows.Err()
+}
+
+// missingDays lists trading days between from and to (inclusive) with no
+// bar. Weekends and exchange holidays are skipped.
+func missingDays(from, to time.Time, existing map[string]bool) []time.Time {
+	cal := market.Default()
+	var missing []time.Time
+	for d := dateOnly(from); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if !cal.IsTradingDay(d) {
+			continue
+		}
+		if !existing[d.Format(dateLayout)] {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// groupRanges merges missing days into contiguous ranges. Days separated
+// only by weekends or holidays belong to the same range, so a week-long gap
+// is one upstream request rather than five.
+func groupRanges(days []time.Time) []dateRange {
+	cal := market.Default()
+	var ranges []dateRange
+	for _, d := range days {
+		if n := len(ranges); n > 0 && cal.NextTradingDay(ranges[n-1].To).Equal(d) {
+			ranges[n-1].To = d
+			continue
+		}
+		ranges = append(ranges, dateRange{From: d, To: d})
+	}
+	return ranges
+}
+
+func upsertBar(db *sql.DB, ticker string, day time.Time, bar models.ChartDataPoint) error {
+	_, err := db.Exec(`
+		INSERT INTO stock_prices (time, ticker, open, high, low, close, volume, interval)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '1day')
+		ON CONFLICT (ticker, time, interval) DO UPDATE SET
+			open = EXCLUDED.open,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			close = EXCLUDED.close,
+			volume = EXCLUDED.volume
+	`, day, ticker,
+		bar.Open.InexactFloat64(), bar.High.InexactFloat64(),
+		bar.Low.InexactFloat64(), bar.Close.InexactFloat64(), bar.Volume)
+	if err != nil {
+		return fmt.Errorf("failed to upsert %s bar for %s: %w", ticker, day.Format(dateLayout), err)
+	}
+	return nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, nyLocation)
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+// fakeBarFetcher records requested ranges and returns a bar for every
+// weekday in each range
+type fakeBarFetcher struct {
+	calls [][2]string
+	err   error
+}
+
+func (f *fakeBarFetcher) GetHistoricalData(symbol, timespan, from, to string) ([]models.ChartDataPoint, error) {
+	f.calls = append(f.calls, [2]string{from, to})
+	if f.err != nil {
+		return nil, f.err
+	}
+	start, _ := time.ParseInLocation(dateLayout, from, nyLocation)
+	end, _ := time.ParseInLocation(dateLayout, to, nyLocation)
+	var bars []models.ChartDataPoint
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		p := decimal.NewFromInt(100)
+		bars = append(bars, models.ChartDataPoint{Timestamp: d, Open: p, High: p, Low: p, Close: p, Volume: 10})
+	}
+	return bars, nil
+}
+
+func day(s string) time.Time {
+	d, _ := time.ParseInLocation(dateLayout, s, nyLocation)
+	return d
+}
+
+func TestMissingDaysAndRanges(t *testing.T) {
+	// Mon 2026-03-02 .. Fri 2026-03-13, with the first week's Wed and the
+	// second week's Mon-Tue already stored
+	existing := map[string]bool{
+		"2026-03-02": true, "2026-03-03": true, "2026-03-05": true,
+		"2026-03-09": true, "2026-03-10": true,
+	}
+	missing := missingDays(day("2026-03-02"), day("2026-03-13"), existing)
+
+	var got []string
+	for _, d := range missing {
+		got = append(got, d.Format(dateLayout))
+	}
+	assert.Equal(t, []string{"2026-03-04", "2026-03-06", "2026-03-11", "2026-03-12", "2026-03-13"}, got)
+
+	ranges := groupRanges(missing)
+	require.Len(t, ranges, 3)
+	assert.Equal(t, "2026-03-04", ranges[0].From.Format(dateLayout))
+	assert.Equal(t, "2026-03-04", ranges[0].To.Format(dateLayout))
+	assert.Equal(t, "2026-03-06", ranges[1].From.Format(dateLayout))
+	assert.Equal(t, "2026-03-06", ranges[1].To.Format(dateLayout))
+	assert.Equal(t, "2026-03-11", ranges[2].From.Format(dateLayout))
+	assert.Equal(t, "2026-03-13", ranges[2].To.Format(dateLayout))
+}
+
+func TestGroupRangesSpansWeekend(t *testing.T) {
+	ranges := groupRanges([]time.Time{day("2026-03-05"), day("2026-03-06"), day("2026-03-09")})
+	require.Len(t, ranges, 1)
+	assert.Equal(t, "2026-03-05", ranges[0].From.Format(dateLayout))
+	assert.Equal(t, "2026-03-09", ranges[0].To.Format(dateLayout))
+}
+
+func TestBackfillTicker_FetchesOnlyGaps(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Two full weeks; everything present except Wed 03-04 and Thu-Fri 03-12/13
+	rows := sqlmock.NewRows([]string{"day"})
+	for _, d := range []string{"2026-03-02", "2026-03-03", "2026-03-05", "2026-03-06",
+		"2026-03-09", "2026-03-10", "2026-03-11"} {
+		rows.AddRow(d)
+	}
+	mock.ExpectQuery("SELECT DISTINCT .+ FROM stock_prices").
+		WithArgs("AAPL", day("2026-03-02"), day("2026-03-14")).
+		WillReturnRows(rows)
+	for _, d := range []string{"2026-03-04", "2026-03-12", "2026-03-13"} {
+		mock.ExpectExec("INSERT INTO stock_prices .+ ON CONFLICT \\(ticker, time, interval\\)").
+			WithArgs(day(d), "AAPL", 100.0, 100.0, 100.0, 100.0, int64(10)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	fetcher := &fakeBarFetcher{}
+	stats := backfillTicker(db, fetcher, "AAPL", day("2026-03-02"), day("2026-03-13"), false)
+
+	require.NoError(t, stats.Err)
+	assert.Equal(t, 3, stats.MissingDays)
+	assert.Equal(t, 3, stats.Inserted)
+	assert.Equal(t, [][2]string{{"2026-03-04", "2026-03-04"}, {"2026-03-12", "2026-03-13"}}, fetcher.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillTicker_DryRunDoesNotFetch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT DISTINCT .+ FROM stock_prices").
+		WillReturnRows(sqlmock.NewRows([]string{"day"}))
+
+	fetcher := &fakeBarFetcher{}
+	stats := backfillTicker(db, fetcher, "AAPL", day("2026-03-02"), day("2026-03-06"), true)
+
+	assert.Equal(t, 5, stats.MissingDays)
+	assert.Equal(t, 1, stats.Ranges)
+	assert.Empty(t, fetcher.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillTicker_NoGaps(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT DISTINCT .+ FROM stock_prices").
+		WillReturnRows(sqlmock.NewRows([]string{"day"}).AddRow("2026-03-02").AddRow("2026-03-03"))
+
+	fetcher := &fakeBarFetcher{}
+	stats := backfillTicker(db, fetcher, "AAPL", day("2026-03-02"), day("2026-03-03"), false)
+
+	assert.Zero(t, stats.MissingDays)
+	assert.Empty(t, fetcher.calls)
+}
+
+func TestBackfillTicker_FetchError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT DISTINCT .+ FROM stock_prices").
+		WillReturnRows(sqlmock.NewRows([]string{"day"}))
+
+	fetcher := &fakeBarFetcher{err: errors.New("API error: NOT_AUTHORIZED")}
+	stats := backfillTicker(db, fetcher, "AAPL", day("2026-03-02"), day("2026-03-02"), false)
+
+	require.Error(t, stats.Err)
+	assert.Contains(t, stats.Err.Error(), "NOT_AUTHORIZED")
+}
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, nyLocation)
+
+	from, to, err := parseRange("", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-09", to.Format(dateLayout))
+	assert.Equal(t, "2025-03-09", from.Format(dateLayout))
+
+	from, to, err = parseRange("2026-01-05", "2026-01-09", now)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01-05", from.Format(dateLayout))
+	assert.Equal(t, "2026-01-09", to.Format(dateLayout))
+
+	_, _, err = parseRange("2026-02-01", "2026-01-01", now)
+	assert.Error(t, err)
+	_, _, err = parseRange("not-a-date", "", now)
+	assert.Error(t, err)
+}
+
+func TestParseTickers(t *testing.T) {
+	assert.Equal(t, []string{"AAPL", "MSFT"}, parseTickers(" aapl,MSFT,,AAPL "))
+	assert.Nil(t, parseTickers(""))
+}
+
+func TestMissingDaysSkipsHolidays(t *testing.T) {
+	// Thu 2026-04-02 .. Mon 2026-04-06; Good Friday 04-03 is a market holiday
+	missing := missingDays(day("2026-04-02"), day("2026-04-06"), map[string]bool{})
+	require.Len(t, missing, 2)
+	assert.Equal(t, "2026-04-02", missing[0].Format(dateLayout))
+	assert.Equal(t, "2026-04-06", missing[1].Format(dateLayout))
+
+	ranges := groupRanges(missing)
+	require.Len(t, ranges, 1, "days around a holiday form one range")
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+// Items per digest section
+const (
+	topMoversLimit    = 5
+	recentAlertsLimit = 10
+)
+
+// Command line flags
+var (
+	dryRun = flag.Bool("dry-run", false, "List digests that are due without sending or recording anything")
+)
+
+// digestStore is the database access the job needs, swapped out in tests
+type digestStore interface {
+	DigestRecipients() ([]models.DigestRecipient, error)
+	ClaimDigest(log *models.DigestLog) (bool, error)
+	MarkDigestSent(logID string, snapshot json.RawMessage) error
+	ReleaseDigest(logID string) error
+	PortfolioSummary(userID string) (*models.PortfolioSummary, error)
+	TopMovers(userID string, limit int) ([]models.TopMover, error)
+	RecentAlerts(userID string, since time.Time, limit int) ([]models.AlertLogWithRule, error)
+}
+
+// digestSender delivers a rendered digest; *services.EmailService in
+// production
+type digestSender interface {
+	SendDigestEmail(toEmail, digestType, timezone string, content *models.DigestContent) error
+}
+
+type dbDigestStore struct{}
+
+func (dbDigestStore) DigestRecipients() ([]models.DigestRecipient, error) {
+	return database.GetDigestRecipients()
+}
+
+func (dbDigestStore) ClaimDigest(log *models.DigestLog) (bool, error) {
+	return database.ClaimDigestPeriod(log)
+}
+
+func (dbDigestStore) MarkDigestSent(logID string, snapshot json.RawMessage) error {
+	return database.MarkDigestSent(logID, snapshot)
+}
+
+func (dbDigestStore) ReleaseDigest(logID string) error {
+	return database.ReleaseDigestClaim(logID)
+}
+
+func (dbDigestStore) PortfolioSummary(userID string) (*models.PortfolioSummary, error) {
+	return database.GetDigestPortfolioSummary(userID)
+}
+
+func (dbDigestStore) TopMovers(userID string, limit int) ([]models.TopMover, error) {
+	return database.GetDigestTopMovers(userID, limit)
+}
+
+func (dbDigestStore) RecentAlerts(userID string, since time.Time, limit int) ([]models.AlertLogWithRule, error) {
+	return database.GetDigestRecentAlerts(userID, since, limit)
+}
+
+func main() {
+	flag.Parse()
+
+	if err := database.Initialize(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	os.Exit(run(dbDigestStore{}, services.NewEmailService(), time.Now(), *dryRun))
+}
+
+// run sends every digest due at now. Each digest's period is claimed in
+// digest_logs before it is sent, so overlapping or repeated runs send it at
+// most once. A failed send releases the claim for the next run to retry;
+// the exit code is 1 if any digest failed.
+func run(store digestStore, sender digestSender, now time.Time, dry bool) int {
+	recipients, err := store.DigestRecipients()
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return 1
+	}
+
+	sent, failed := 0, 0
+	for i := range recipients {
+		r := &recipients[i]
+		for _, p := range dueDigests(r, now) {
+			if dry {
+				log.Printf("[dry-run] would send %s digest for %s to user %s", p.Type, p.Start.Format("2006-01-02"), r.UserID)
+				continue
+			}
+			ok, err := sendDigest(store, sender, r, p, now)
+			if err != nil {
+				log.Printf("❌ Failed to send %s digest to user %s: %v", p.Type, r.UserID, err)
+				failed++
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+
+	log.Printf("Sent %d digests (%d recipients checked)", sent, len(recipients))
+	if failed > 0 {
+		log.Printf("❌ %d digests failed", failed)
+		return 1
+	}
+	return 0
+}
+
+// sendDigest claims, assembles and sends one digest. It returns false
+// without sending when the period was already claimed by another run.
+func sendDigest(store digestStore, sender digestSender, r *models.DigestRecipient, p digestPeriod, now time.Time) (bool, error) {
+	entry := &models.DigestLog{
+		UserID:      r.UserID,
+		DigestType:  p.Type,
+		PeriodStart: p.Start,
+		PeriodEnd:   p.End,
+	}
+	claimed, err := store.ClaimDigest(entry)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	content, err := buildContent(store, r, now.Add(-p.Length), now)
+	if err == nil {
+		err = sender.SendDigestEmail(r.Email, p.Type, r.Timezone, content)
+	}
+	if err != nil {
+		if releaseErr := store.ReleaseDigest(entry.ID); releaseErr != nil {
+			log.Printf("⚠️  %v", releaseErr)
+		}
+		return false, err
+	}
+
+	// The email is out; if recording it fails the claim still stands, so
+	// the period is not sent again.
+	snapshot, _ := json.Marshal(content)
+	if err := store.MarkDigestSent(entry.ID, snapshot); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	return true, nil
+}
+
+// buildContent assembles the sections the recipient's digest_include_*
+// preferences ask for, covering since to until
+func buildContent(store digestStore, r *models.DigestRecipient, since, until time.Time) (*models.DigestContent, error) {
+	content := &models.DigestContent{
+		UserName:    r.FullName,
+		PeriodStart: since,
+		PeriodEnd:   until,
+	}
+	var err error
+	if r.DigestIncludePortfolioSummary {
+		if content.PortfolioSummary, err = store.PortfolioSummary(r.UserID); err != nil {
+			return nil, err
+		}
+	}
+	if r.DigestIncludeTopMovers {
+		if content.TopMovers, err = store.TopMovers(r.UserID, topMoversLimit); err != nil {
+			return nil, err
+		}
+	}
+	if r.DigestIncludeRecentAlerts {
+		if content.RecentAlerts, err = store.RecentAlerts(r.UserID, since, recentAlertsLimit); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+type claimKey struct {
+	userID, digestType string
+	start              int64
+}
+
+// fakeDigestStore keeps claims the way digest_logs' unique constraint does
+type fakeDigestStore struct {
+	recipients []models.DigestRecipient
+	claims     map[claimKey]string
+	sent       map[string]bool
+	calls      []string
+}
+
+func newFakeDigestStore(recipients ...models.DigestRecipient) *fakeDigestStore {
+	return &fakeDigestStore{recipients: recipients, claims: map[claimKey]string{}, sent: map[string]bool{}}
+}
+
+func (f *fakeDigestStore) DigestRecipients() ([]models.DigestRecipient, error) {
+	return f.recipients, nil
+}
+
+func (f *fakeDigestStore) ClaimDigest(log *models.DigestLog) (bool, error) {
+	key := claimKey{log.UserID, log.DigestType, log.PeriodStart.Unix()}
+	if _, ok := f.claims[key]; ok {
+		return false, nil
+	}
+	log.ID = log.UserID + "/" + log.DigestType + "/" + log.PeriodStart.Format(time.RFC3339)
+	f.claims[key] = log.ID
+	return true, nil
+}
+
+func (f *fakeDigestStore) MarkDigestSent(logID string, snapshot json.RawMessage) error {
+	f.sent[logID] = true
+	return nil
+}
+
+func (f *fakeDigestStore) ReleaseDigest(logID string) error {
+	for key, id := range f.claims {
+		if id == logID && !f.sent[id] {
+			delete(f.claims, key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeDigestStore) PortfolioSummary(userID string) (*models.PortfolioSummary, error) {
+	f.calls = append(f.calls, "portfolio")
+	return &models.PortfolioSummary{TotalValue: 1000}, nil
+}
+
+func (f *fakeDigestStore) TopMovers(userID string, limit int) ([]models.TopMover, error) {
+	f.calls = append(f.calls, "movers")
+	return []models.TopMover{{Symbol: "AAPL", ChangePct: 3.1, Direction: "up"}}, nil
+}
+
+func (f *fakeDigestStore) RecentAlerts(userID string, since time.Time, limit int) ([]models.AlertLogWithRule, error) {
+	f.calls = append(f.calls, "alerts")
+	return nil, nil
+}
+
+type fakeSender struct {
+	fail    bool
+	emails  []string
+	content []*models.DigestContent
+}
+
+func (f *fakeSender) SendDigestEmail(toEmail, digestType, timezone string, content *models.DigestContent) error {
+	if f.fail {
+		return errors.New("smtp: connection refused")
+	}
+	f.emails = append(f.emails, toEmail+" "+digestType)
+	f.content = append(f.content, content)
+	return nil
+}
+
+func TestRunSendsEachPeriodOnce(t *testing.T) {
+	store := newFakeDigestStore(*newRecipient())
+	sender := &fakeSender{}
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC) // 10:00 EDT
+
+	assert.Equal(t, 0, run(store, sender, now, false))
+	assert.Equal(t, 0, run(store, sender, now.Add(time.Hour), false), "a second run in the window")
+	assert.Equal(t, []string{"u1@example.com daily"}, sender.emails)
+	assert.Len(t, store.sent, 1)
+
+	assert.Equal(t, 0, run(store, sender, now.AddDate(0, 0, 1), false))
+	assert.Len(t, sender.emails, 2, "the next day is a new period")
+}
+
+func TestRunReleasesClaimWhenSendFails(t *testing.T) {
+	store := newFakeDigestStore(*newRecipient())
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 1, run(store, &fakeSender{fail: true}, now, false))
+	assert.Empty(t, store.claims)
+
+	sender := &fakeSender{}
+	assert.Equal(t, 0, run(store, sender, now.Add(time.Hour), false))
+	assert.Len(t, sender.emails, 1, "the failed period is retried")
+}
+
+func TestRunIncludesRequestedSections(t *testing.T) {
+	r := newRecipient()
+	r.FullName = "Ada"
+	r.DigestIncludeTopMovers = true
+	r.DigestIncludeRecentAlerts = true
+	store := newFakeDigestStore(*r)
+	sender := &fakeSender{}
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 0, run(store, sender, now, false))
+	assert.Equal(t, []string{"movers", "alerts"}, store.calls)
+	require.Len(t, sender.content, 1)
+	assert.Nil(t, sender.content[0].PortfolioSummary)
+	assert.Equal(t, "Ada", sender.content[0].UserName)
+	assert.Equal(t, now.Add(-24*time.Hour), sender.content[0].PeriodStart)
+}
+
+func TestRunDryRunChangesNothing(t *testing.T) {
+	store := newFakeDigestStore(*newRecipient())
+	sender := &fakeSender{}
+	assert.Equal(t, 0, run(store, sender, time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC), true))
+	assert.Empty(t, store.claims)
+	assert.Empty(t, sender.emails)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+// sendWindow is how long after its send time a digest can still go out, so
+// a missed run delays a digest rather than dropping it
+const sendWindow = 24 * time.Hour
+
+// defaultDigestTime matches the column default for daily_digest_time and
+// weekly_digest_time
+const defaultDigestTime = "09:00:00"
+
+// digestPeriod is one digest a recipient is due. Start and End bound the
+// local day (daily) or week (weekly) it is sent for; digest_logs allows one
+// digest per recipient, type and Start.
+type digestPeriod struct {
+	Type  string
+	Start time.Time
+	End   time.Time
+	// Length is how far back the digest's content reaches
+	Length time.Duration
+}
+
+// dueDigests returns the digests r should be sent at now. A digest is due
+// from its send time in the user's timezone, pushed to the end of quiet
+// hours when it falls inside them, until sendWindow later. Nothing is due
+// while the user is in quiet hours.
+func dueDigests(r *models.DigestRecipient, now time.Time) []digestPeriod {
+	quiet := newQuietHours(&r.NotificationPreferences)
+	if quiet.contains(now) {
+		return nil
+	}
+
+	loc := loadLocation(r.Timezone, r.UserID)
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	var due []digestPeriod
+	if r.DailyDigestEnabled {
+		clock := parseClock(r.DailyDigestTime, "daily_digest_time", r.UserID)
+		// Yesterday's digest is still due if quiet hours or a missed run
+		// pushed it into today; only the latest due one is sent.
+		for _, start := range []time.Time{today, today.AddDate(0, 0, -1)} {
+			if isDue(quiet.deferred(clock.on(start)), now) {
+				due = append(due, digestPeriod{
+					Type:   models.DigestTypeDaily,
+					Start:  start,
+					End:    start.AddDate(0, 0, 1),
+					Length: 24 * time.Hour,
+				})
+				break
+			}
+		}
+	}
+	if r.WeeklyDigestEnabled {
+		clock := parseClock(r.WeeklyDigestTime, "weekly_digest_time", r.UserID)
+		// weekly_digest_day counts from Sunday = 0, like time.Weekday
+		back := (int(today.Weekday()) - r.WeeklyDigestDay + 7) % 7
+		thisWeek := today.AddDate(0, 0, -back)
+		for _, start := range []time.Time{thisWeek, thisWeek.AddDate(0, 0, -7)} {
+			if isDue(quiet.deferred(clock.on(start)), now) {
+				due = append(due, digestPeriod{
+					Type:   models.DigestTypeWeekly,
+					Start:  start,
+					End:    start.AddDate(0, 0, 7),
+					Length: 7 * 24 * time.Hour,
+				})
+				break
+			}
+		}
+	}
+	return due
+}
+
+func isDue(sendAt, now time.Time) bool {
+	return !now.Before(sendAt) && now.Before(sendAt.Add(sendWindow))
+}
+
+// clock is a time of day
+type clock struct {
+	hour, min, sec int
+}
+
+// on returns the clock time on day's date, in day's location
+func (c clock) on(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.hour, c.min, c.sec, 0, day.Location())
+}
+
+func (c clock) seconds() int {
+	return c.hour*3600 + c.min*60 + c.sec
+}
+
+// parseClock reads a TIME column value, falling back to defaultDigestTime
+// when it can't be parsed
+func parseClock(raw, field, userID string) clock {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return clock{t.Hour(), t.Minute(), t.Second()}
+		}
+	}
+	log.Printf("⚠️  Invalid %s %q for user %s, using %s", field, raw, userID, defaultDigestTime)
+	t, _ := time.Parse("15:04:05", defaultDigestTime)
+	return clock{t.Hour(), t.Minute(), t.Second()}
+}
+
+// loadLocation resolves an IANA timezone name, falling back to UTC
+func loadLocation(name, userID string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️  Invalid timezone %q for user %s, using UTC", name, userID)
+		return time.UTC
+	}
+	return loc
+}
+
+// quietHours is a user's do-not-disturb window. It may wrap past midnight
+// (22:00-08:00); a window that starts and ends at the same time is empty.
+type quietHours struct {
+	enabled    bool
+	start, end clock
+	loc        *time.Location
+}
+
+func newQuietHours(p *models.NotificationPreferences) quietHours {
+	if !p.QuietHoursEnabled {
+		return quietHours{}
+	}
+	return quietHours{
+		enabled: true,
+		start:   parseClock(p.QuietHoursStart, "quiet_hours_start", p.UserID),
+		end:     parseClock(p.QuietHoursEnd, "quiet_hours_end", p.UserID),
+		loc:     loadLocation(p.QuietHoursTimezone, p.UserID),
+	}
+}
+
+func (q quietHours) contains(t time.Time) bool {
+	if !q.enabled {
+		return false
+	}
+	local := t.In(q.loc)
+	s := clock{local.Hour(), local.Minute(), local.Second()}.seconds()
+	start, end := q.start.seconds(), q.end.seconds()
+	if start <= end {
+		return s >= start && s < end
+	}
+	return s >= start || s < end
+}
+
+// deferred returns t, or the end of the quiet hours t falls in
+func (q quietHours) deferred(t time.Time) time.Time {
+	if !q.contains(t) {
+		return t
+	}
+	end := q.end.on(t.In(q.loc))
+	if !end.After(t) {
+		end = q.end.on(t.In(q.loc).AddDate(0, 0, 1))
+	}
+	return end
+}
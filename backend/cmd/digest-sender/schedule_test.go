@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+func newRecipient() *models.DigestRecipient {
+	return &models.DigestRecipient{
+		NotificationPreferences: models.NotificationPreferences{
+			UserID:             "u1",
+			DailyDigestEnabled: true,
+			DailyDigestTime:    "09:00:00",
+			WeeklyDigestDay:    1,
+			WeeklyDigestTime:   "09:00:00",
+			QuietHoursStart:    "22:00:00",
+			QuietHoursEnd:      "08:00:00",
+			QuietHoursTimezone: "America/New_York",
+		},
+		Email:    "u1@example.com",
+		Timezone: "America/New_York",
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestDueDigestsUsesUserTimezone(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	r := newRecipient()
+
+	// 08:59 in New York is before the send time, but yesterday's digest is
+	// still in its window (and is claimed already if it went out).
+	due := dueDigests(r, time.Date(2026, 3, 10, 8, 59, 0, 0, ny))
+	require.Len(t, due, 1)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, ny), due[0].Start)
+
+	due = dueDigests(r, time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)) // 09:00 EDT
+	require.Len(t, due, 1)
+	assert.Equal(t, models.DigestTypeDaily, due[0].Type)
+	assert.True(t, due[0].Start.Equal(time.Date(2026, 3, 10, 0, 0, 0, 0, ny)))
+	assert.True(t, due[0].End.Equal(time.Date(2026, 3, 11, 0, 0, 0, 0, ny)))
+	assert.Equal(t, 24*time.Hour, due[0].Length)
+}
+
+func TestDueDigestsWeekly(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	r := newRecipient()
+	r.DailyDigestEnabled = false
+	r.WeeklyDigestEnabled = true
+	r.WeeklyDigestDay = 2 // Tuesday
+
+	tuesday := time.Date(2026, 3, 10, 10, 0, 0, 0, ny)
+	due := dueDigests(r, tuesday)
+	require.Len(t, due, 1)
+	assert.Equal(t, models.DigestTypeWeekly, due[0].Type)
+	assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, ny), due[0].Start)
+	assert.Equal(t, time.Date(2026, 3, 17, 0, 0, 0, 0, ny), due[0].End)
+
+	assert.Empty(t, dueDigests(r, tuesday.AddDate(0, 0, 2)), "not due later in the week")
+}
+
+func TestDueDigestsQuietHours(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	r := newRecipient()
+	r.DailyDigestTime = "07:00:00"
+	r.QuietHoursEnabled = true
+
+	// 07:00 is inside 22:00-08:00 quiet hours, so the digest waits for 08:00.
+	assert.Empty(t, dueDigests(r, time.Date(2026, 3, 10, 7, 30, 0, 0, ny)))
+	due := dueDigests(r, time.Date(2026, 3, 10, 8, 0, 0, 0, ny))
+	require.Len(t, due, 1)
+	assert.Equal(t, time.Date(2026, 3, 10, 0, 0, 0, 0, ny), due[0].Start)
+
+	// Nothing goes out during quiet hours, even a digest already due.
+	r.DailyDigestTime = "21:00:00"
+	assert.Empty(t, dueDigests(r, time.Date(2026, 3, 10, 23, 0, 0, 0, ny)))
+}
+
+func TestQuietHoursDeferredAcrossMidnight(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	q := quietHours{enabled: true, start: clock{22, 0, 0}, end: clock{8, 0, 0}, loc: ny}
+
+	assert.Equal(t, time.Date(2026, 3, 11, 8, 0, 0, 0, ny), q.deferred(time.Date(2026, 3, 10, 23, 0, 0, 0, ny)))
+	assert.Equal(t, time.Date(2026, 3, 10, 8, 0, 0, 0, ny), q.deferred(time.Date(2026, 3, 10, 3, 0, 0, 0, ny)))
+	noon := time.Date(2026, 3, 10, 12, 0, 0, 0, ny)
+	assert.Equal(t, noon, q.deferred(noon))
+
+	q.end = q.start
+	assert.False(t, q.contains(time.Date(2026, 3, 10, 22, 0, 0, 0, ny)), "equal start and end is an empty window")
+}
+
+func TestParseClockFallsBack(t *testing.T) {
+	assert.Equal(t, clock{18, 30, 0}, parseClock("18:30", "daily_digest_time", "u1"))
+	assert.Equal(t, clock{9, 0, 0}, parseClock("evening", "daily_digest_time", "u1"))
+	assert.Equal(t, time.UTC, loadLocation("Mars/Olympus_Mons", "u1"))
+}
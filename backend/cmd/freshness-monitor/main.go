@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/services"
+)
+
+// Command line flags
+var (
+	dryRun  = flag.Bool("dry-run", false, "Log SLA breaches without sending notifications")
+	timeout = flag.Duration("timeout", 2*time.Minute, "Overall time limit for the check and notifications")
+)
+
+// Exit codes. A stale run exits non-zero so it also shows up as a failed
+// job in the CronJob history.
+const (
+	exitHealthy = 0
+	exitError   = 1
+	exitStale   = 2
+)
+
+func main() {
+	flag.Parse()
+
+	if err := database.Initialize(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	os.Exit(run(services.NewFreshnessMonitor(), *dryRun, *timeout))
+}
+
+func run(monitor *services.FreshnessMonitor, dry bool, timeout time.Duration) int {
+	if dry {
+		alerts := monitor.Check()
+		logAlerts(alerts)
+		if len(alerts) > 0 {
+			return exitStale
+		}
+		return exitHealthy
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	alerts, err := monitor.Run(ctx)
+	logAlerts(alerts)
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return exitError
+	}
+	if len(alerts) > 0 {
+		return exitStale
+	}
+	return exitHealthy
+}
+
+func logAlerts(alerts []services.FreshnessAlert) {
+	if len(alerts) == 0 {
+		log.Println("✅ All data domains are within their freshness SLA")
+		return
+	}
+	for _, a := range alerts {
+		log.Printf("⚠️  %s", a.Message())
+	}
+}
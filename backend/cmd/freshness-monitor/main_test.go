@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/services"
+)
+
+type fakeSource struct {
+	lastUpdated time.Time
+}
+
+func (f fakeSource) DomainLastUpdated(string) (*time.Time, error) { return &f.lastUpdated, nil }
+func (f fakeSource) LastSuccessfulRun(string) (*time.Time, error) { return &f.lastUpdated, nil }
+
+type fakeNotifier struct {
+	calls int
+	err   error
+}
+
+func (f *fakeNotifier) Notify(context.Context, []services.FreshnessAlert) error {
+	f.calls++
+	return f.err
+}
+
+func monitorWith(age time.Duration, n services.FreshnessNotifier) *services.FreshnessMonitor {
+	slas := []services.FreshnessSLA{{Domain: "prices", JobName: "ic-score-daily-price-update", MaxAge: 24 * time.Hour}}
+	return services.NewFreshnessMonitorWith(slas, fakeSource{lastUpdated: time.Now().Add(-age)}, n)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	n := &fakeNotifier{}
+	assert.Equal(t, exitHealthy, run(monitorWith(time.Hour, n), false, time.Second))
+	assert.Equal(t, 0, n.calls)
+
+	assert.Equal(t, exitStale, run(monitorWith(48*time.Hour, n), false, time.Second))
+	assert.Equal(t, 1, n.calls)
+
+	failing := &fakeNotifier{err: errors.New("down")}
+	assert.Equal(t, exitError, run(monitorWith(48*time.Hour, failing), false, time.Second))
+}
+
+func TestRunDryRunDoesNotNotify(t *testing.T) {
+	n := &fakeNotifier{}
+	assert.Equal(t, exitStale, run(monitorWith(48*time.Hour, n), true, time.Second))
+	assert.Equal(t, 0, n.calls)
+}
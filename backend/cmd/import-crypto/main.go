@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+	"investorcenter-api/services"
+)
+
+// Command line flags
+var (
+	pages     = flag.Int("pages", 4, "Pages of coins to import, largest market cap first (0 = every page)")
+	perPage   = flag.Int("per-page", services.CoinGeckoMaxPerPage, "Coins per CoinGecko page (max 250)")
+	delay     = flag.Duration("delay", 6*time.Second, "Pause between pages to stay under CoinGecko's per-minute rate limit")
+	quoteTTL  = flag.Duration("quote-ttl", 20*time.Minute, "How long the quotes written to Redis stay valid")
+	skipRedis = flag.Bool("skip-redis", false, "Only upsert tickers; don't publish quotes and the market cap ranking to Redis")
+	dryRun    = flag.Bool("dry-run", false, "Fetch and log coins without writing to the database or Redis")
+	verbose   = flag.Bool("verbose", false, "Enable verbose logging")
+)
+
+// Redis keys the crypto handlers read
+const (
+	quoteKeyFormat = "crypto:quote:%s"
+	rankedKey      = "crypto:symbols:ranked"
+)
+
+// marketFetcher lists coins' market data a page at a time. Implemented by
+// services.CoinGeckoClient.
+type marketFetcher interface {
+	GetCoinMarkets(page, perPage int) ([]services.CoinGeckoMarket, error)
+}
+
+// cryptoStore upserts crypto tickers, swapped out in tests
+type cryptoStore interface {
+	UpsertCrypto(coin services.CoinGeckoMarket) (inserted bool, err error)
+}
+
+type dbCryptoStore struct {
+	db *sql.DB
+}
+
+// UpsertCrypto inserts or updates coin's tickers row and reports whether it
+// was new. A coin seen again is marked active in case it had been delisted.
+func (s dbCryptoStore) UpsertCrypto(coin services.CoinGeckoMarket) (bool, error) {
+	var marketCap *float64
+	if coin.MarketCap > 0 {
+		marketCap = &coin.MarketCap
+	}
+
+	var inserted bool
+	err := s.db.QueryRow(`
+		INSERT INTO tickers (
+			symbol, name, exchange, sector, industry, country, currency,
+			market_cap, logo_url, asset_type, base_currency_symbol,
+			base_currency_name, currency_symbol, source_feed, active
+		) VALUES (
+			$1, $2, 'CRYPTO', 'Cryptocurrency', 'Digital Currency', 'GLOBAL', 'USD',
+			$3, $4, 'crypto', $1, $2, 'USD', 'coingecko', true
+		) ON CONFLICT (symbol, asset_type) DO UPDATE SET
+			name = EXCLUDED.name,
+			market_cap = COALESCE(EXCLUDED.market_cap, tickers.market_cap),
+			logo_url = COALESCE(NULLIF(EXCLUDED.logo_url, ''), tickers.logo_url),
+			base_currency_symbol = EXCLUDED.base_currency_symbol,
+			base_currency_name = EXCLUDED.base_currency_name,
+			currency_symbol = EXCLUDED.currency_symbol,
+			active = true,
+			delisted_at = NULL,
+			updated_at = NOW()
+		RETURNING (xmax = 0)`,
+		coin.Symbol, coin.Name, marketCap, coin.Image).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert %s: %w", coin.Symbol, err)
+	}
+	return inserted, nil
+}
+
+// importStats summarizes a run
+type importStats struct {
+	Inserted int
+	Updated  int
+	Failed   int
+}
+
+func main() {
+	flag.Parse()
+
+	client := services.NewCoinGeckoClient()
+	if client.APIKey == "" {
+		log.Println("Warning: COINGECKO_API_KEY not set. Using the public rate limit.")
+	}
+
+	var db *sql.DB
+	if !*dryRun {
+		var err error
+		db, err = setupDatabase()
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer db.Close()
+
+		// Count CoinGecko calls against COINGECKO_MONTHLY_QUOTA, shared with
+		// the API server through upstream_quota_usage
+		services.UpstreamQuota().SetStore(services.NewSQLQuotaStore(db))
+	}
+
+	markets, err := fetchMarkets(client, *pages, *perPage, *delay, time.Sleep)
+	services.UpstreamQuota().Flush()
+	if err != nil {
+		if len(markets) == 0 {
+			log.Fatalf("Failed to fetch coins from CoinGecko: %v", err)
+		}
+		log.Printf("Warning: Stopped after %d coins: %v", len(markets), err)
+	}
+
+	coins := uniqueCoins(markets)
+	log.Printf("📥 Fetched %d coins (%d unique symbols)", len(markets), len(coins))
+
+	if *dryRun {
+		log.Println("🔍 DRY RUN MODE - Not writing to the database or Redis")
+		for i, coin := range coins {
+			if i >= 10 {
+				log.Printf("... and %d more", len(coins)-10)
+				break
+			}
+			log.Printf("  #%d %s - %s ($%.8g)", coin.MarketCapRank, coin.Symbol, coin.Name, coin.CurrentPrice)
+		}
+		return
+	}
+
+	stats := importCoins(dbCryptoStore{db: db}, coins)
+
+	if !*skipRedis {
+		rdb := setupRedis()
+		defer rdb.Close()
+		if err := publishQuotes(context.Background(), rdb, coins, *quoteTTL, time.Now()); err != nil {
+			log.Printf("❌ Failed to publish quotes to Redis: %v", err)
+			stats.Failed += len(coins)
+		} else {
+			log.Printf("💾 Published %d quotes and the market cap ranking to Redis", len(coins))
+		}
+	}
+
+	log.Println("\n📊 Crypto Import Summary:")
+	log.Printf("  Coins:     %d", len(coins))
+	log.Printf("  Inserted:  %d", stats.Inserted)
+	log.Printf("  Updated:   %d", stats.Updated)
+	log.Printf("  Failed:    %d", stats.Failed)
+	if stats.Failed > 0 && stats.Inserted == 0 && stats.Updated == 0 {
+		os.Exit(1)
+	}
+}
+
+// fetchMarkets fetches up to maxPages pages of coins (0 = until a short
+// page), pausing for delay between pages. On an error the coins fetched so
+// far are returned with it.
+func fetchMarkets(fetcher marketFetcher, maxPages, perPage int, delay time.Duration, sleep func(time.Duration)) ([]services.CoinGeckoMarket, error) {
+	if perPage <= 0 || perPage > services.CoinGeckoMaxPerPage {
+		perPage = services.CoinGeckoMaxPerPage
+	}
+
+	var markets []services.CoinGeckoMarket
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		if page > 1 && delay > 0 {
+			log.Printf("⏳ Fetched %d coins, waiting %s before page %d...", len(markets), delay, page)
+			sleep(delay)
+		}
+
+		// Slow down near the monthly CoinGecko quota and stop once it's used up
+		if err := services.UpstreamQuota().Wait(services.QuotaSourceCoinGecko); err != nil {
+			return markets, err
+		}
+
+		batch, err := fetcher.GetCoinMarkets(page, perPage)
+		if err != nil {
+			return markets, fmt.Errorf("page %d: %w", page, err)
+		}
+		markets = append(markets, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	return markets, nil
+}
+
+// uniqueCoins uppercases symbols and keeps the first coin of each, which is
+// the largest since CoinGecko sorts by market cap. Many small coins reuse
+// the symbols of big ones.
+func uniqueCoins(markets []services.CoinGeckoMarket) []services.CoinGeckoMarket {
+	seen := make(map[string]bool, len(markets))
+	coins := make([]services.CoinGeckoMarket, 0, len(markets))
+	for _, coin := range markets {
+		coin.Symbol = strings.ToUpper(strings.TrimSpace(coin.Symbol))
+		if coin.Symbol == "" || seen[coin.Symbol] {
+			if *verbose && coin.Symbol != "" {
+				log.Printf("–  Skipping %s (%s): symbol taken by a larger coin", coin.Symbol, coin.ID)
+			}
+			continue
+		}
+		seen[coin.Symbol] = true
+		coins = append(coins, coin)
+	}
+	return coins
+}
+
+// importCoins upserts each coin into tickers. A coin that fails is counted
+// and skipped.
+func importCoins(store cryptoStore, coins []services.CoinGeckoMarket) importStats {
+	var stats importStats
+	for _, coin := range coins {
+		inserted, err := store.UpsertCrypto(coin)
+		switch {
+		case err != nil:
+			stats.Failed++
+			log.Printf("❌ %v", err)
+		case inserted:
+			stats.Inserted++
+			if *verbose {
+				log.Printf("✅ Inserted %s - %s", coin.Symbol, coin.Name)
+			}
+		default:
+			stats.Updated++
+		}
+	}
+	return stats
+}
+
+// cryptoQuote is the crypto:quote:<SYMBOL> value the crypto handlers read,
+// in the same shape the crypto price updater writes
+type cryptoQuote struct {
+	Symbol                   string  `json:"symbol"`
+	ID                       string  `json:"id"`
+	Name                     string  `json:"name"`
+	Image                    string  `json:"image"`
+	CurrentPrice             float64 `json:"current_price"`
+	MarketCap                float64 `json:"market_cap"`
+	MarketCapRank            int     `json:"market_cap_rank"`
+	TotalVolume              float64 `json:"total_volume"`
+	High24h                  float64 `json:"high_24h"`
+	Low24h                   float64 `json:"low_24h"`
+	PriceChange24h           float64 `json:"price_change_24h"`
+	PriceChangePercentage24h float64 `json:"price_change_percentage_24h"`
+	PriceChangePercentage1h  float64 `json:"price_change_percentage_1h"`
+	PriceChangePercentage7d  float64 `json:"price_change_percentage_7d"`
+	PriceChangePercentage30d float64 `json:"price_change_percentage_30d"`
+	CirculatingSupply        float64 `json:"circulating_supply"`
+	TotalSupply              float64 `json:"total_supply"`
+	MaxSupply                float64 `json:"max_supply"`
+	ATH                      float64 `json:"ath"`
+	ATHChangePercentage      float64 `json:"ath_change_percentage"`
+	ATHDate                  string  `json:"ath_date"`
+	ATL                      float64 `json:"atl"`
+	ATLChangePercentage      float64 `json:"atl_change_percentage"`
+	ATLDate                  string  `json:"atl_date"`
+	LastUpdated              string  `json:"last_updated"`
+	FetchedAt                string  `json:"fetched_at"`
+	Source                   string  `json:"source"`
+}
+
+func newCryptoQuote(coin services.CoinGeckoMarket, fetchedAt time.Time) cryptoQuote {
+	return cryptoQuote{
+		Symbol:                   coin.Symbol,
+		ID:                       coin.ID,
+		Name:                     coin.Name,
+		Image:                    coin.Image,
+		CurrentPrice:             coin.CurrentPrice,
+		MarketCap:                coin.MarketCap,
+		MarketCapRank:            coin.MarketCapRank,
+		TotalVolume:              coin.TotalVolume,
+		High24h:                  coin.High24h,
+		Low24h:                   coin.Low24h,
+		PriceChange24h:           coin.PriceChange24h,
+		PriceChangePercentage24h: coin.PriceChangePercentage24h,
+		PriceChangePercentage1h:  coin.PriceChangePercentage1h,
+		PriceChangePercentage7d:  coin.PriceChangePercentage7d,
+		PriceChangePercentage30d: coin.PriceChangePercentage30d,
+		CirculatingSupply:        coin.CirculatingSupply,
+		TotalSupply:              coin.TotalSupply,
+		MaxSupply:                coin.MaxSupply,
+		ATH:                      coin.ATH,
+		ATHChangePercentage:      coin.ATHChangePercentage,
+		ATHDate:                  coin.ATHDate,
+		ATL:                      coin.ATL,
+		ATLChangePercentage:      coin.ATLChangePercentage,
+		ATLDate:                  coin.ATLDate,
+		LastUpdated:              coin.LastUpdated,
+		FetchedAt:                fetchedAt.UTC().Format(time.RFC3339),
+		Source:                   "coingecko",
+	}
+}
+
+// publishQuotes writes each coin's quote with ttl and replaces the market
+// cap ranking GetAllCryptos pages through. coins must be in ranking order.
+// The ranking is swapped in atomically, so readers never see it half built.
+func publishQuotes(ctx context.Context, rdb *redis.Client, coins []services.CoinGeckoMarket, ttl time.Duration, now time.Time) error {
+	if len(coins) == 0 {
+		return nil
+	}
+
+	ranked := make([]*redis.Z, len(coins))
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, coin := range coins {
+			quote, err := json.Marshal(newCryptoQuote(coin, now))
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, fmt.Sprintf(quoteKeyFormat, coin.Symbol), quote, ttl)
+			ranked[i] = &redis.Z{Score: float64(i + 1), Member: coin.Symbol}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	next := rankedKey + ":next"
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, next)
+		pipe.ZAdd(ctx, next, ranked...)
+		pipe.Rename(ctx, next, rankedKey)
+		return nil
+	})
+	return err
+}
+
+func setupDatabase() (*sql.DB, error) {
+	dbHost := getEnvOrDefault("DB_HOST", "localhost")
+	dbPort := getEnvOrDefault("DB_PORT", "5432")
+	dbUser := getEnvOrDefault("DB_USER", "investorcenter")
+	dbPassword := os.Getenv("DB_PASSWORD")
+	dbName := getEnvOrDefault("DB_NAME", "investorcenter_db")
+	sslMode := getEnvOrDefault("DB_SSLMODE", "disable")
+
+	if dbPassword == "" {
+		return nil, fmt.Errorf("DB_PASSWORD environment variable is required")
+	}
+
+	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		dbHost, dbPort, dbUser, dbPassword, dbName, sslMode)
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	log.Println("✅ Connected to database successfully")
+	return db, nil
+}
+
+// setupRedis connects to the Redis the API server reads crypto quotes from
+func setupRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     getEnvOrDefault("REDIS_ADDR", "localhost:6379"),
+		Password: os.Getenv("REDIS_PASSWORD"),
+	})
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
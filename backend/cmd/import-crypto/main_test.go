@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"investorcenter-api/services"
+)
+
+// fakeMarketFetcher serves pages of coins and records the pages requested
+type fakeMarketFetcher struct {
+	pages [][]services.CoinGeckoMarket
+	err   error
+	calls []int
+}
+
+func (f *fakeMarketFetcher) GetCoinMarkets(page, perPage int) ([]services.CoinGeckoMarket, error) {
+	f.calls = append(f.calls, page)
+	if page > len(f.pages) {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, nil
+	}
+	return f.pages[page-1], nil
+}
+
+// fakeCryptoStore records upserts, treating symbols in existing as updates
+type fakeCryptoStore struct {
+	existing map[string]bool
+	errs     map[string]error
+	upserted []string
+}
+
+func (s *fakeCryptoStore) UpsertCrypto(coin services.CoinGeckoMarket) (bool, error) {
+	if err := s.errs[coin.Symbol]; err != nil {
+		return false, err
+	}
+	s.upserted = append(s.upserted, coin.Symbol)
+	return !s.existing[coin.Symbol], nil
+}
+
+func coins(symbols ...string) []services.CoinGeckoMarket {
+	markets := make([]services.CoinGeckoMarket, len(symbols))
+	for i, s := range symbols {
+		markets[i] = services.CoinGeckoMarket{ID: s + "-id", Symbol: s, Name: s + " Coin", MarketCapRank: i + 1}
+	}
+	return markets
+}
+
+func TestFetchMarkets_PaginatesUntilShortPage(t *testing.T) {
+	fetcher := &fakeMarketFetcher{pages: [][]services.CoinGeckoMarket{coins("btc", "eth"), coins("sol")}}
+	var slept []time.Duration
+
+	markets, err := fetchMarkets(fetcher, 0, 2, time.Second, func(d time.Duration) { slept = append(slept, d) })
+
+	require.NoError(t, err)
+	assert.Len(t, markets, 3)
+	assert.Equal(t, []int{1, 2}, fetcher.calls, "a short page is the last one")
+	assert.Equal(t, []time.Duration{time.Second}, slept, "pauses between pages only")
+}
+
+func TestFetchMarkets_StopsAtMaxPages(t *testing.T) {
+	fetcher := &fakeMarketFetcher{pages: [][]services.CoinGeckoMarket{coins("btc"), coins("eth"), coins("sol")}}
+
+	markets, err := fetchMarkets(fetcher, 2, 1, 0, func(time.Duration) { t.Fatal("no delay configured") })
+
+	require.NoError(t, err)
+	assert.Len(t, markets, 2)
+	assert.Equal(t, []int{1, 2}, fetcher.calls)
+}
+
+func TestFetchMarkets_ReturnsPartialResultsOnError(t *testing.T) {
+	fetcher := &fakeMarketFetcher{pages: [][]services.CoinGeckoMarket{coins("btc")}, err: errors.New("status 429")}
+
+	markets, err := fetchMarkets(fetcher, 0, 1, 0, func(time.Duration) {})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "page 2")
+	assert.Len(t, markets, 1, "coins already fetched are kept")
+}
+
+func TestUniqueCoins_KeepsLargestCoinPerSymbol(t *testing.T) {
+	markets := coins("btc", "eth", "ETH", "", " sol ")
+	markets[2].ID = "ethereum-wormhole"
+
+	got := uniqueCoins(markets)
+
+	require.Len(t, got, 3)
+	assert.Equal(t, "BTC", got[0].Symbol)
+	assert.Equal(t, "ETH", got[1].Symbol)
+	assert.Equal(t, "eth-id", got[1].ID, "the first, largest coin wins the symbol")
+	assert.Equal(t, "SOL", got[2].Symbol)
+}
+
+func TestImportCoins_CountsInsertsUpdatesAndFailures(t *testing.T) {
+	store := &fakeCryptoStore{
+		existing: map[string]bool{"BTC": true},
+		errs:     map[string]error{"SOL": errors.New("db down")},
+	}
+
+	stats := importCoins(store, coins("BTC", "ETH", "SOL"))
+
+	assert.Equal(t, importStats{Inserted: 1, Updated: 1, Failed: 1}, stats)
+	assert.Equal(t, []string{"BTC", "ETH"}, store.upserted)
+}
+
+func TestDBCryptoStore_UpsertCrypto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	coin := services.CoinGeckoMarket{Symbol: "BTC", Name: "Bitcoin", MarketCap: 1.2e12, Image: "https://example.com/btc.png"}
+	mock.ExpectQuery(`INSERT INTO tickers .* ON CONFLICT \(symbol, asset_type\) DO UPDATE`).
+		WithArgs("BTC", "Bitcoin", 1.2e12, "https://example.com/btc.png").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true))
+
+	inserted, err := dbCryptoStore{db: db}.UpsertCrypto(coin)
+
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPublishQuotes_WritesQuotesAndReplacesRanking(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	ctx := context.Background()
+
+	// A coin from an earlier run that has since dropped out
+	require.NoError(t, rdb.ZAdd(ctx, rankedKey, &redis.Z{Score: 1, Member: "LUNA"}).Err())
+
+	markets := coins("BTC", "ETH")
+	markets[0].CurrentPrice = 65000
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, publishQuotes(ctx, rdb, markets, 10*time.Minute, now))
+
+	ranked, err := rdb.ZRange(ctx, rankedKey, 0, -1).Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BTC", "ETH"}, ranked)
+	assert.False(t, mr.Exists(rankedKey+":next"))
+
+	raw, err := rdb.Get(ctx, "crypto:quote:BTC").Result()
+	require.NoError(t, err)
+	var quote cryptoQuote
+	require.NoError(t, json.Unmarshal([]byte(raw), &quote))
+	assert.Equal(t, 65000.0, quote.CurrentPrice)
+	assert.Equal(t, 1, quote.MarketCapRank)
+	assert.Equal(t, "2026-01-02T03:04:05Z", quote.FetchedAt)
+	assert.Equal(t, "coingecko", quote.Source)
+	assert.Equal(t, 10*time.Minute, mr.TTL("crypto:quote:BTC"))
+}
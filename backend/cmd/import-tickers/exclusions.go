@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"investorcenter-api/services"
+)
+
+// exclusionRule drops tickers matching every criterion it sets. Types are
+// Polygon type codes (WARRANT, UNIT, ...), Exchanges are Polygon primary
+// exchange MICs (XNAS, ...), and NamePattern is a regular expression matched
+// against the ticker's name. SICCodes only match tickers whose source
+// supplies a SIC code: Polygon's list endpoint and the NASDAQ Trader
+// directory don't, so the defaults never rely on it.
+type exclusionRule struct {
+	Name        string   `json:"name"`
+	Types       []string `json:"types,omitempty"`
+	NamePattern string   `json:"name_pattern,omitempty"`
+	Exchanges   []string `json:"exchanges,omitempty"`
+	SICCodes    []string `json:"sic_codes,omitempty"`
+
+	namePattern *regexp.Regexp
+}
+
+// exclusionRules is the ruleset applied to every import
+type exclusionRules struct {
+	Rules []exclusionRule `json:"rules"`
+}
+
+// defaultExclusionRules mirrors the Python ticker tooling: test issues,
+// warrants/rights/units, and blank-check companies (SPACs) aren't imported.
+// SPACs are recognised by name, since the listing carries no SIC code to
+// check for 6770.
+func defaultExclusionRules() *exclusionRules {
+	rules := &exclusionRules{Rules: []exclusionRule{
+		{Name: "test-issue", NamePattern: `(?i)\btest (issue|stock|symbol)\b`},
+		{Name: "derivative", Types: []string{"WARRANT", "RIGHT", "UNIT"}},
+		{Name: "spac", NamePattern: `(?i)\bacquisition (corp|corporation|co|company|inc|ltd|limited)\b`},
+	}}
+	// The defaults are constant, so compile can't fail
+	_ = rules.compile()
+	return rules
+}
+
+// loadExclusionRules reads a ruleset from a JSON file, or returns the
+// defaults when path is empty. A file with no rules disables exclusions.
+func loadExclusionRules(path string) (*exclusionRules, error) {
+	if path == "" {
+		return defaultExclusionRules(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exclusion rules: %w", err)
+	}
+	var rules exclusionRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse exclusion rules: %w", err)
+	}
+	if err := rules.compile(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// compile validates each rule and compiles its name pattern
+func (r *exclusionRules) compile() error {
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("exclusion rule %d has no name", i+1)
+		}
+		if len(rule.Types) == 0 && rule.NamePattern == "" && len(rule.Exchanges) == 0 && len(rule.SICCodes) == 0 {
+			return fmt.Errorf("exclusion rule %q has no criteria", rule.Name)
+		}
+		if rule.NamePattern != "" {
+			re, err := regexp.Compile(rule.NamePattern)
+			if err != nil {
+				return fmt.Errorf("exclusion rule %q has an invalid name pattern: %w", rule.Name, err)
+			}
+			rule.namePattern = re
+		}
+	}
+	return nil
+}
+
+// match returns the name of the first rule that excludes ticker, or "" if
+// it should be imported
+func (r *exclusionRules) match(ticker services.PolygonTicker) string {
+	if r == nil {
+		return ""
+	}
+	for _, rule := range r.Rules {
+		if rule.matches(ticker) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+func (rule exclusionRule) matches(ticker services.PolygonTicker) bool {
+	if len(rule.Types) > 0 && !containsFold(rule.Types, ticker.Type) {
+		return false
+	}
+	if len(rule.Exchanges) > 0 && !containsFold(rule.Exchanges, ticker.PrimaryExchange) {
+		return false
+	}
+	if len(rule.SICCodes) > 0 && !containsFold(rule.SICCodes, ticker.SICCode) {
+		return false
+	}
+	if rule.namePattern != nil && !rule.namePattern.MatchString(ticker.Name) {
+		return false
+	}
+	return true
+}
+
+// filter returns the tickers no rule excludes, and how many each rule
+// excluded
+func (r *exclusionRules) filter(tickers []services.PolygonTicker) ([]services.PolygonTicker, map[string]int) {
+	kept := make([]services.PolygonTicker, 0, len(tickers))
+	excluded := make(map[string]int)
+	for _, ticker := range tickers {
+		if name := r.match(ticker); name != "" {
+			excluded[name]++
+			continue
+		}
+		kept = append(kept, ticker)
+	}
+	return kept, excluded
+}
+
+// formatExclusions renders per-rule exclusion counts for the import summary,
+// e.g. "derivative: 12, test-issue: 3"
+func formatExclusions(excluded map[string]int) string {
+	names := make([]string, 0, len(excluded))
+	for name := range excluded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %d", name, excluded[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"investorcenter-api/services"
+)
+
+func TestDefaultExclusionRulesFilter(t *testing.T) {
+	tickers := []services.PolygonTicker{
+		{Ticker: "AAPL", Name: "Apple Inc.", Type: "CS", SICCode: "3571"},
+		{Ticker: "ZVZZT", Name: "NASDAQ TEST STOCK", Type: "CS"},
+		{Ticker: "ABCDW", Name: "ABCD Corp Warrants", Type: "WARRANT"},
+		{Ticker: "ABCDU", Name: "ABCD Corp Units", Type: "UNIT"},
+		// Polygon's list endpoint doesn't return sic_code
+		{Ticker: "SPAC", Name: "Churchill Capital Acquisition Corp I", Type: "CS"},
+		{Ticker: "TEST", Name: "Testing Solutions Inc", Type: "CS"},
+	}
+
+	kept, excluded := defaultExclusionRules().filter(tickers)
+
+	if len(kept) != 2 || kept[0].Ticker != "AAPL" || kept[1].Ticker != "TEST" {
+		t.Errorf("Expected AAPL and TEST to be kept, got %+v", kept)
+	}
+	want := map[string]int{"test-issue": 1, "derivative": 2, "spac": 1}
+	if len(excluded) != len(want) {
+		t.Fatalf("Expected exclusion counts %v, got %v", want, excluded)
+	}
+	for name, n := range want {
+		if excluded[name] != n {
+			t.Errorf("Expected %d excluded by %s, got %d", n, name, excluded[name])
+		}
+	}
+	if got := formatExclusions(excluded); got != "derivative: 2, spac: 1, test-issue: 1" {
+		t.Errorf("Unexpected exclusion summary %q", got)
+	}
+}
+
+func TestLoadExclusionRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.json")
+	rules := `{"rules": [{"name": "otc-funds", "types": ["fund"], "exchanges": ["OTC"]}]}`
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadExclusionRules(path)
+	if err != nil {
+		t.Fatalf("loadExclusionRules failed: %v", err)
+	}
+
+	// Every criterion in a rule must match
+	if got := loaded.match(services.PolygonTicker{Type: "FUND", PrimaryExchange: "OTC"}); got != "otc-funds" {
+		t.Errorf("Expected OTC fund to be excluded, got %q", got)
+	}
+	if got := loaded.match(services.PolygonTicker{Type: "FUND", PrimaryExchange: "XNYS"}); got != "" {
+		t.Errorf("Expected NYSE fund to be kept, got %q", got)
+	}
+	// A custom ruleset replaces the defaults
+	if got := loaded.match(services.PolygonTicker{Type: "WARRANT"}); got != "" {
+		t.Errorf("Expected warrants to be kept by a custom ruleset, got %q", got)
+	}
+}
+
+func TestLoadExclusionRulesInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, rules := range map[string]string{
+		"no name":     `{"rules": [{"types": ["UNIT"]}]}`,
+		"no criteria": `{"rules": [{"name": "everything"}]}`,
+		"bad pattern": `{"rules": [{"name": "bad", "name_pattern": "("}]}`,
+		"bad json":    `{"rules": [`,
+	} {
+		path := filepath.Join(dir, "rules.json")
+		if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadExclusionRules(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := loadExclusionRules(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestNilExclusionRulesKeepEverything(t *testing.T) {
+	var rules *exclusionRules
+	kept, excluded := rules.filter([]services.PolygonTicker{{Ticker: "ABCDW", Type: "WARRANT"}})
+	if len(kept) != 1 || len(excluded) != 0 {
+		t.Errorf("Expected nil rules to keep every ticker, got %v kept, %v excluded", kept, excluded)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"investorcenter-api/services"
+)
+
+// watermarkStore keeps each asset type's -since watermark, swapped out in
+// tests
+type watermarkStore interface {
+	Watermark(assetType string) (time.Time, bool, error)
+	SetWatermark(assetType string, at time.Time) error
+}
+
+type dbWatermarkStore struct {
+	db *sql.DB
+}
+
+func (s dbWatermarkStore) Watermark(assetType string) (time.Time, bool, error) {
+	var at time.Time
+	err := s.db.QueryRow("SELECT watermark FROM import_state WHERE asset_type = $1", assetType).Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return at, true, nil
+}
+
+func (s dbWatermarkStore) SetWatermark(assetType string, at time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO import_state (asset_type, watermark, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (asset_type) DO UPDATE SET watermark = EXCLUDED.watermark, updated_at = NOW()`,
+		assetType, at)
+	return err
+}
+
+// applyWatermark narrows tickers to those Polygon updated after assetType's
+// stored watermark. Without a watermark, or if it can't be loaded, every
+// ticker is kept (a full import).
+func applyWatermark(store watermarkStore, assetType string, tickers []services.PolygonTicker) []services.PolygonTicker {
+	since, ok, err := store.Watermark(assetType)
+	switch {
+	case err != nil:
+		log.Printf("Warning: Failed to load %s watermark, importing every ticker: %v", assetType, err)
+		return tickers
+	case !ok:
+		log.Printf("🕒 No %s watermark yet, importing every ticker", assetType)
+		return tickers
+	}
+
+	updated := updatedSince(tickers, since)
+	log.Printf("🕒 %d of %d %s tickers updated since %s", len(updated), len(tickers), assetType, since.UTC().Format(time.RFC3339))
+	return updated
+}
+
+// updatedSince keeps tickers updated after since. Tickers without a
+// readable last_updated_utc (e.g. from the NASDAQ Trader fallback) are kept.
+func updatedSince(tickers []services.PolygonTicker, since time.Time) []services.PolygonTicker {
+	kept := make([]services.PolygonTicker, 0, len(tickers))
+	for _, t := range tickers {
+		updated, ok := lastUpdated(t)
+		if !ok || updated.After(since) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// latestUpdate returns the newest last_updated_utc in tickers, or zero if
+// none has one. It's the watermark a successful run advances to.
+func latestUpdate(tickers []services.PolygonTicker) time.Time {
+	var latest time.Time
+	for _, t := range tickers {
+		if updated, ok := lastUpdated(t); ok && updated.After(latest) {
+			latest = updated
+		}
+	}
+	return latest
+}
+
+func lastUpdated(t services.PolygonTicker) (time.Time, bool) {
+	if t.LastUpdatedUTC == "" {
+		return time.Time{}, false
+	}
+	updated, err := time.Parse(time.RFC3339Nano, t.LastUpdatedUTC)
+	return updated, err == nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"investorcenter-api/services"
+)
+
+type fakeWatermarkStore struct {
+	at  time.Time
+	ok  bool
+	err error
+}
+
+func (s fakeWatermarkStore) Watermark(string) (time.Time, bool, error) { return s.at, s.ok, s.err }
+func (s fakeWatermarkStore) SetWatermark(string, time.Time) error      { return nil }
+
+var watermarkTickers = []services.PolygonTicker{
+	{Ticker: "OLD", LastUpdatedUTC: "2024-01-01T00:00:00Z"},
+	{Ticker: "NEW", LastUpdatedUTC: "2024-03-01T12:30:00.000Z"},
+	{Ticker: "SAME", LastUpdatedUTC: "2024-02-01T00:00:00Z"},
+	{Ticker: "NODATE"},
+	{Ticker: "BAD", LastUpdatedUTC: "yesterday"},
+}
+
+func symbolsOf(tickers []services.PolygonTicker) []string {
+	symbols := make([]string, len(tickers))
+	for i, t := range tickers {
+		symbols[i] = t.Ticker
+	}
+	return symbols
+}
+
+func TestApplyWatermark(t *testing.T) {
+	since := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		store fakeWatermarkStore
+		want  []string
+	}{
+		{"filters to updated after watermark", fakeWatermarkStore{at: since, ok: true}, []string{"NEW", "NODATE", "BAD"}},
+		{"no watermark imports everything", fakeWatermarkStore{}, []string{"OLD", "NEW", "SAME", "NODATE", "BAD"}},
+		{"load error imports everything", fakeWatermarkStore{err: errors.New("boom")}, []string{"OLD", "NEW", "SAME", "NODATE", "BAD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := symbolsOf(applyWatermark(tt.store, "stocks", watermarkTickers))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLatestUpdate(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	if got := latestUpdate(watermarkTickers); !got.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := latestUpdate([]services.PolygonTicker{{Ticker: "NODATE"}}); !got.IsZero() {
+		t.Errorf("Expected zero time without last_updated_utc, got %v", got)
+	}
+}
+
+func TestDBWatermarkStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := dbWatermarkStore{db: db}
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT watermark FROM import_state`).WithArgs("etf").
+		WillReturnRows(sqlmock.NewRows([]string{"watermark"}))
+	if _, ok, err := store.Watermark("etf"); err != nil || ok {
+		t.Errorf("Expected no watermark, got ok=%v err=%v", ok, err)
+	}
+
+	mock.ExpectQuery(`SELECT watermark FROM import_state`).WithArgs("stocks").
+		WillReturnRows(sqlmock.NewRows([]string{"watermark"}).AddRow(at))
+	got, ok, err := store.Watermark("stocks")
+	if err != nil || !ok || !got.Equal(at) {
+		t.Errorf("Expected %v, got %v ok=%v err=%v", at, got, ok, err)
+	}
+
+	mock.ExpectExec(`INSERT INTO import_state`).WithArgs("stocks", at).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := store.SetWatermark("stocks", at); err != nil {
+		t.Errorf("SetWatermark: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
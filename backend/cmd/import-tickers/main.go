@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -15,16 +16,116 @@ import (
 
 // Command line flags
 var (
-	assetType  = flag.String("type", "all", "Asset type to import: stocks, etf, crypto, indices, all_equities, or all")
-	limit      = flag.Int("limit", 0, "Limit number of tickers to import (0 = ALL tickers)")
-	dryRun     = flag.Bool("dry-run", false, "Preview what would be imported without actually importing")
-	verbose    = flag.Bool("verbose", false, "Enable verbose logging")
-	updateOnly = flag.Bool("update-only", false, "Only update existing tickers, don't insert new ones")
+	assetType        = flag.String("type", "all", "Asset type to import: stocks, etf, crypto, indices, all_equities, or all")
+	limit            = flag.Int("limit", 0, "Limit number of tickers to import (0 = ALL tickers)")
+	dryRun           = flag.Bool("dry-run", false, "Preview what would be imported without actually importing")
+	verbose          = flag.Bool("verbose", false, "Enable verbose logging")
+	updateOnly       = flag.Bool("update-only", false, "Only update existing tickers, don't insert new ones")
+	exclusionsFile   = flag.String("exclusions", "", "JSON file of exclusion rules (default: skip test issues, warrants/rights/units and SPACs)")
+	nasdaqFallback   = flag.Bool("nasdaq-fallback", true, "Fall back to the NASDAQ Trader symbol directory for stocks and ETFs when Polygon fails")
+	validate         = flag.Bool("validate", false, "Compare Polygon against the NASDAQ Trader symbol directory and print a JSON discrepancy report instead of importing")
+	maxDiscrepancies = flag.Int("max-discrepancies", 50, "With -validate, exit non-zero if the report has more discrepancies than this")
+	runWindow        = flag.Duration("run-window", 12*time.Hour, "With -type all, skip asset types completed within this window (0 = import every type)")
+	outputPath       = flag.String("output", "", "Write what was imported (or, with -validate, the discrepancy report) to this file")
+	outputFormat     = flag.String("format", "json", "Format of the -output file: json or csv")
+	since            = flag.Bool("since", false, "Only process tickers Polygon updated after the last fully successful run (full import when there's no watermark yet)")
+	reconcile        = flag.Bool("reconcile", false, "After importing, mark active tickers Polygon no longer lists as delisted (needs a full import: no -limit)")
 )
 
+// tickerSource lists tickers for an asset type. Implemented by
+// services.PolygonClient and services.NasdaqTraderSource.
+type tickerSource interface {
+	GetAllTickers(assetType string, limit int) ([]services.PolygonTicker, error)
+}
+
+// exclusions is the ruleset importTickers drops tickers by, loaded from -exclusions
+var exclusions *exclusionRules
+
+// recorder collects what importTickers did with each ticker for -output
+var recorder *importRecorder
+
+// allAssetTypes is the order -type all imports in (crypto excluded - see cmd/import-crypto)
+var allAssetTypes = []string{"stocks", "etf", "indices"}
+
+// progressStore records which asset types an import has completed, swapped
+// out in tests
+type progressStore interface {
+	CompletedSince(since time.Time) (map[string]bool, error)
+	MarkCompleted(assetType string, at time.Time) error
+}
+
+type dbProgressStore struct {
+	db *sql.DB
+}
+
+func (s dbProgressStore) CompletedSince(since time.Time) (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT asset_type FROM ticker_import_progress WHERE completed_at >= $1", since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completed := make(map[string]bool)
+	for rows.Next() {
+		var assetType string
+		if err := rows.Scan(&assetType); err != nil {
+			return nil, err
+		}
+		completed[assetType] = true
+	}
+	return completed, rows.Err()
+}
+
+func (s dbProgressStore) MarkCompleted(assetType string, at time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO ticker_import_progress (asset_type, completed_at) VALUES ($1, $2)
+		ON CONFLICT (asset_type) DO UPDATE SET completed_at = EXCLUDED.completed_at`,
+		assetType, at)
+	return err
+}
+
+// typeResult is the outcome of one asset type in an -type all run
+type typeResult struct {
+	AssetType string
+	Skipped   bool // already completed within the run window
+	Err       error
+}
+
 func main() {
 	flag.Parse()
 
+	rules, err := loadExclusionRules(*exclusionsFile)
+	if err != nil {
+		log.Fatalf("Invalid exclusion rules: %v", err)
+	}
+	exclusions = rules
+
+	if err := validateOutputFormat(*outputFormat); err != nil {
+		log.Fatalf("Invalid -format: %v", err)
+	}
+
+	if *validate {
+		var writeReport func(*validationReport) error
+		if *outputPath != "" {
+			writeReport = func(report *validationReport) error {
+				return writeOutputFile(*outputPath, func(w io.Writer) error {
+					return writeValidationReport(w, *outputFormat, report)
+				})
+			}
+		}
+		os.Exit(runValidation(services.NewPolygonClient(), services.NewNasdaqTraderSource(), *maxDiscrepancies, os.Stdout, writeReport))
+	}
+
+	// A limited import only sees part of the listing: reconciling it would
+	// delist everything past the limit
+	if *reconcile && *limit > 0 {
+		log.Fatalf("-reconcile needs a full import; drop -limit")
+	}
+
+	if *outputPath != "" {
+		recorder = &importRecorder{}
+	}
+
 	// Setup database connection
 	db, err := setupDatabase()
 	if err != nil {
@@ -32,6 +133,11 @@ func main() {
 	}
 	defer db.Close()
 
+	// Count Polygon calls against POLYGON_MONTHLY_QUOTA, shared with the API
+	// server through upstream_quota_usage
+	quota := services.UpstreamQuota()
+	quota.SetStore(services.NewSQLQuotaStore(db))
+
 	// Create Polygon client
 	polygonClient := services.NewPolygonClient()
 	apiKey := os.Getenv("POLYGON_API_KEY")
@@ -41,24 +147,43 @@ func main() {
 	}
 
 	// Import tickers based on type
+	failed := false
 	if *assetType == "all" {
-		// Import all asset types (crypto excluded - use CoinGecko)
-		importAllTypes(db, polygonClient)
+		// Import all asset types (crypto excluded - see cmd/import-crypto)
+		results := importAllTypes(db, polygonClient)
+		failed = printTypeResults(results)
 	} else if *assetType == "crypto" {
 		// Reject crypto imports - direct to CoinGecko
 		log.Println("❌ Crypto import from Polygon is no longer supported")
-		log.Println("💡 Please use CoinGecko API for cryptocurrency data")
+		log.Println("💡 Use cmd/import-crypto to import cryptocurrencies from CoinGecko")
 		log.Println("📚 See docs/CRYPTO_MIGRATION_EXECUTION_PLAN.md for migration guide")
-		log.Fatalf("Crypto import aborted - use CoinGecko instead")
+		log.Fatalf("Crypto import aborted - use import-crypto instead")
 	} else {
 		// Import specific asset type
 		if err := importTickers(db, polygonClient, *assetType); err != nil {
+			quota.Flush()
 			log.Fatalf("Failed to import %s tickers: %v", *assetType, err)
 		}
 	}
+	quota.Flush()
+
+	if recorder != nil {
+		err := writeOutputFile(*outputPath, func(w io.Writer) error {
+			return writeImportRecords(w, *outputFormat, recorder.records)
+		})
+		if err != nil {
+			log.Printf("❌ Failed to write %s: %v", *outputPath, err)
+			failed = true
+		} else {
+			log.Printf("📝 Wrote %d ticker records to %s", len(recorder.records), *outputPath)
+		}
+	}
 
 	// Print summary
 	printSummary(db)
+	if failed {
+		os.Exit(1)
+	}
 }
 
 func setupDatabase() (*sql.DB, error) {
@@ -92,43 +217,139 @@ func setupDatabase() (*sql.DB, error) {
 	return db, nil
 }
 
-func importAllTypes(db *sql.DB, client *services.PolygonClient) {
-	// Note: crypto removed - use CoinGecko for cryptocurrency data
-	types := []string{"stocks", "etf", "indices"}
+func importAllTypes(db *sql.DB, client *services.PolygonClient) []typeResult {
+	// Partial imports don't complete an asset type, so don't record them
+	var store progressStore
+	if !*dryRun && *limit == 0 {
+		store = dbProgressStore{db: db}
+	}
+	importFn := func(assetType string) error {
+		return importTickers(db, client, assetType)
+	}
+	return runAssetTypes(allAssetTypes, store, importFn, *runWindow, time.Now(), 2*time.Second)
+}
 
+// runAssetTypes imports each asset type in order, skipping any that store
+// reports completed within window of now so a re-run after a crash resumes
+// where the last run stopped. A failed type is recorded and the run moves on.
+// A nil store or zero window imports every type without tracking.
+func runAssetTypes(types []string, store progressStore, importFn func(string) error, window time.Duration, now time.Time, delay time.Duration) []typeResult {
+	completed := map[string]bool{}
+	if store != nil && window > 0 {
+		var err error
+		if completed, err = store.CompletedSince(now.Add(-window)); err != nil {
+			log.Printf("Warning: Failed to load import progress, importing every type: %v", err)
+			completed = map[string]bool{}
+		}
+	}
+
+	results := make([]typeResult, 0, len(types))
+	imported := false
 	for _, assetType := range types {
+		if completed[assetType] {
+			log.Printf("⏭️  Skipping %s: already imported within the last %s", assetType, window)
+			results = append(results, typeResult{AssetType: assetType, Skipped: true})
+			continue
+		}
+
+		// Add delay between different asset types to avoid rate limiting
+		if imported && delay > 0 {
+			log.Printf("⏳ Waiting %s before next asset type...", delay)
+			time.Sleep(delay)
+		}
+		imported = true
+
 		log.Printf("\n📦 Importing %s...\n", assetType)
-		if err := importTickers(db, client, assetType); err != nil {
+		err := importFn(assetType)
+		if err != nil {
 			log.Printf("Warning: Failed to import %s: %v", assetType, err)
+		} else if store != nil {
+			if markErr := store.MarkCompleted(assetType, time.Now()); markErr != nil {
+				log.Printf("Warning: Failed to record %s as imported: %v", assetType, markErr)
+			}
 		}
+		results = append(results, typeResult{AssetType: assetType, Err: err})
+	}
+	return results
+}
 
-		// Add delay between different asset types to avoid rate limiting
-		if assetType != types[len(types)-1] {
-			log.Println("⏳ Waiting 2 seconds before next asset type...")
-			time.Sleep(2 * time.Second)
+// printTypeResults logs each asset type's outcome for the cron monitor and
+// reports whether any failed
+func printTypeResults(results []typeResult) bool {
+	log.Println("\n📋 Asset Types:")
+	failed := false
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed = true
+			log.Printf("  %s: failed (%v)", r.AssetType, r.Err)
+		case r.Skipped:
+			log.Printf("  %s: skipped (completed earlier in this run window)", r.AssetType)
+		default:
+			log.Printf("  %s: succeeded", r.AssetType)
 		}
 	}
+	return failed
 }
 
 func importTickers(db *sql.DB, client *services.PolygonClient, assetType string) error {
 	// Reject crypto imports - direct to CoinGecko
 	if assetType == "crypto" {
-		return fmt.Errorf("❌ Crypto import from Polygon is no longer supported. Please use CoinGecko API for cryptocurrency data")
+		return fmt.Errorf("❌ Crypto import from Polygon is no longer supported. Use cmd/import-crypto to import cryptocurrencies from CoinGecko")
+	}
+
+	// Slow down near the monthly Polygon quota and stop once it's used up
+	if err := services.UpstreamQuota().Wait(services.QuotaSourcePolygon); err != nil {
+		return err
 	}
 
 	log.Printf("🔍 Fetching %s tickers from Polygon API (this will paginate automatically)...", assetType)
 
 	// Fetch ALL tickers from Polygon API (it will paginate automatically)
 	// Pass 0 as limit to fetch everything, or *limit to fetch specific amount
-	tickers, err := client.GetAllTickers(assetType, *limit)
+	var fallback tickerSource
+	if *nasdaqFallback {
+		fallback = services.NewNasdaqTraderSource()
+	}
+	tickers, fellBack, err := fetchTickers(client, fallback, assetType, *limit)
 	if err != nil {
 		return fmt.Errorf("failed to fetch tickers: %w", err)
 	}
+	listed := tickers // the full listing, for -reconcile
 
 	log.Printf("📊 Successfully fetched %d %s tickers", len(tickers), assetType)
 
+	if recorder != nil {
+		for _, ticker := range tickers {
+			if rule := exclusions.match(ticker); rule != "" {
+				recorder.add(ticker, actionExcluded, rule)
+			}
+		}
+	}
+	tickers, excludedByRule := exclusions.filter(tickers)
+	excluded := 0
+	for _, n := range excludedByRule {
+		excluded += n
+	}
+	if excluded > 0 {
+		log.Printf("🚫 Excluded %d tickers (%s)", excluded, formatExclusions(excludedByRule))
+	}
+
+	// -since skips tickers unchanged since the last successful run; the
+	// watermark is taken from the full listing, before exclusions
+	var watermarks watermarkStore
+	var nextWatermark time.Time
+	if *since {
+		watermarks = dbWatermarkStore{db: db}
+		nextWatermark = latestUpdate(listed)
+		tickers = applyWatermark(watermarks, assetType, tickers)
+	}
+
 	if *dryRun {
 		log.Println("🔍 DRY RUN MODE - Not inserting into database")
+		for _, ticker := range tickers {
+			recorder.add(ticker, actionDryRun, "")
+		}
 		// Just print first 10 for preview
 		for i, ticker := range tickers {
 			if i >= 10 {
@@ -138,6 +359,9 @@ func importTickers(db *sql.DB, client *services.PolygonClient, assetType string)
 			log.Printf("  %s - %s (Type: %s, Exchange: %s)",
 				ticker.Ticker, ticker.Name, ticker.Type, services.MapExchangeCode(ticker.PrimaryExchange))
 		}
+		if *reconcile {
+			log.Println("⏭️  Skipping delisting reconciliation in dry run")
+		}
 		return nil
 	}
 
@@ -164,21 +388,41 @@ func importTickers(db *sql.DB, client *services.PolygonClient, assetType string)
 			if *verbose {
 				log.Printf("Error checking ticker %s: %v", ticker.Ticker, err)
 			}
+			recorder.add(ticker, actionError, err.Error())
 			errors++
 			continue
 		}
 
 		if exists {
-			if *updateOnly || shouldUpdate(ticker) {
+			needsUpdate := *updateOnly || shouldUpdate(ticker)
+			if !needsUpdate {
+				// Polygon's active flag is the source of truth for delistings,
+				// so always sync it even when nothing else changed
+				changed, err := activeChanged(db, ticker.Ticker, assetType, ticker.Active)
+				if err != nil {
+					if *verbose {
+						log.Printf("Error checking active flag for %s: %v", ticker.Ticker, err)
+					}
+					recorder.add(ticker, actionError, err.Error())
+					errors++
+					continue
+				}
+				needsUpdate = changed
+			}
+
+			if needsUpdate {
 				if err := updateTicker(db, ticker); err != nil {
 					if *verbose {
 						log.Printf("Error updating ticker %s: %v", ticker.Ticker, err)
 					}
+					recorder.add(ticker, actionError, err.Error())
 					errors++
 				} else {
+					recorder.add(ticker, actionUpdated, "")
 					updated++
 				}
 			} else {
+				recorder.add(ticker, actionUnchanged, "")
 				skipped++
 			}
 		} else {
@@ -187,28 +431,90 @@ func importTickers(db *sql.DB, client *services.PolygonClient, assetType string)
 					if *verbose {
 						log.Printf("Error inserting ticker %s: %v", ticker.Ticker, err)
 					}
+					recorder.add(ticker, actionError, err.Error())
 					errors++
 				} else {
+					recorder.add(ticker, actionInserted, "")
 					inserted++
 				}
 			} else {
+				recorder.add(ticker, actionSkipped, "")
 				skipped++
 			}
 		}
 	}
 
-	log.Printf("✅ Import complete: %d inserted, %d updated, %d skipped, %d errors",
-		inserted, updated, skipped, errors)
+	log.Printf("✅ Import complete: %d inserted, %d updated, %d skipped, %d excluded, %d errors",
+		inserted, updated, skipped, excluded, errors)
+
+	if *reconcile {
+		// The NASDAQ Trader directory doesn't list everything Polygon does
+		if fellBack {
+			log.Printf("⏭️  Skipping delisting reconciliation for %s: listing came from the NASDAQ Trader fallback", assetType)
+			return nil
+		}
+		delisted, err := reconcileDelisted(db, assetType, listed)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile delistings: %w", err)
+		}
+		printDelisted(assetType, delisted)
+	}
+
+	// Advance the watermark only after a complete, error-free run, so
+	// tickers that failed are retried next time
+	if watermarks != nil {
+		switch {
+		case errors > 0:
+			log.Printf("⏭️  Keeping the %s watermark: %d tickers failed", assetType, errors)
+		case *limit > 0:
+			log.Printf("⏭️  Keeping the %s watermark: -limit imports only part of the listing", assetType)
+		case nextWatermark.IsZero():
+			log.Printf("⏭️  Keeping the %s watermark: no last_updated_utc in the listing", assetType)
+		default:
+			if err := watermarks.SetWatermark(assetType, nextWatermark); err != nil {
+				return fmt.Errorf("failed to save %s watermark: %w", assetType, err)
+			}
+			log.Printf("🕒 %s watermark advanced to %s", assetType, nextWatermark.UTC().Format(time.RFC3339))
+		}
+	}
 
 	return nil
 }
 
+// fetchTickers lists tickers from primary, falling back to fallback (if set)
+// when primary fails, and reports whether it fell back. Fallback tickers only
+// carry what the NASDAQ Trader directory has, so existing rows keep their
+// other Polygon fields.
+func fetchTickers(primary, fallback tickerSource, assetType string, limit int) ([]services.PolygonTicker, bool, error) {
+	tickers, err := primary.GetAllTickers(assetType, limit)
+	if err == nil || fallback == nil || (assetType != "stocks" && assetType != "etf" && assetType != "all_equities") {
+		return tickers, false, err
+	}
+
+	log.Printf("⚠️  Polygon unavailable (%v), falling back to the NASDAQ Trader symbol directory", err)
+	tickers, fallbackErr := fallback.GetAllTickers(assetType, limit)
+	if fallbackErr != nil {
+		return nil, false, fmt.Errorf("%w (NASDAQ Trader fallback also failed: %v)", err, fallbackErr)
+	}
+	return tickers, true, nil
+}
+
 func tickerExists(db *sql.DB, symbol string, assetType string) (bool, error) {
 	var count int
 	err := db.QueryRow("SELECT COUNT(*) FROM tickers WHERE symbol = $1 AND asset_type = $2", symbol, assetType).Scan(&count)
 	return count > 0, err
 }
 
+// activeChanged reports whether the stored active flag differs from Polygon's
+func activeChanged(db *sql.DB, symbol string, assetType string, active bool) (bool, error) {
+	var stored sql.NullBool
+	err := db.QueryRow("SELECT active FROM tickers WHERE symbol = $1 AND asset_type = $2", symbol, assetType).Scan(&stored)
+	if err != nil {
+		return false, err
+	}
+	return !stored.Valid || stored.Bool != active, nil
+}
+
 func shouldUpdate(ticker services.PolygonTicker) bool {
 	// Update if we have new data like market cap, employees, etc.
 	return ticker.MarketCap > 0 || ticker.TotalEmployees > 0 || ticker.HomepageURL != ""
@@ -231,6 +537,8 @@ func insertTicker(db *sql.DB, ticker services.PolygonTicker) error {
 			exchange = COALESCE(EXCLUDED.exchange, tickers.exchange),
 			market_cap = COALESCE(EXCLUDED.market_cap, tickers.market_cap),
 			website = COALESCE(EXCLUDED.website, tickers.website),
+			active = EXCLUDED.active,
+			delisted_at = CASE WHEN EXCLUDED.active THEN NULL ELSE tickers.delisted_at END,
 			updated_at = NOW()`
 
 	// Map values
@@ -294,7 +602,7 @@ func insertTicker(db *sql.DB, ticker services.PolygonTicker) error {
 		assetType,
 		nullIfEmpty(ticker.CIK),
 		ipoDate,
-		"", // logo_url - would need separate call
+		"", // logo_url - filled in by cmd/backfill-logos
 		nullIfEmpty(ticker.PrimaryExchange),
 		nullIfEmpty(ticker.CompositeFigi),
 		nullIfEmpty(ticker.ShareClassFigi),
@@ -328,6 +636,7 @@ func updateTicker(db *sql.DB, ticker services.PolygonTicker) error {
 			phone_number = COALESCE($9, phone_number),
 			weighted_shares_outstanding = COALESCE($10, weighted_shares_outstanding),
 			active = $11,
+			delisted_at = CASE WHEN $11 THEN NULL ELSE COALESCE(delisted_at, NOW()) END,
 			updated_at = NOW()
 		WHERE symbol = $1 AND asset_type = $12`
 
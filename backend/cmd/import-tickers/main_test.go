@@ -2,9 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/lib/pq"
 	"investorcenter-api/services"
@@ -144,6 +147,35 @@ func TestTickerExists(t *testing.T) {
 	}
 }
 
+func TestActiveChanged(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := db.Exec(`
+		INSERT INTO tickers (symbol, name, exchange, asset_type, active)
+		VALUES ('DELIST', 'Delisted Co', 'NYSE', 'stock', true)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+
+	changed, err := activeChanged(db, "DELIST", "stock", true)
+	if err != nil {
+		t.Fatalf("activeChanged failed: %v", err)
+	}
+	if changed {
+		t.Error("Expected no change when active flag matches")
+	}
+
+	changed, err = activeChanged(db, "DELIST", "stock", false)
+	if err != nil {
+		t.Fatalf("activeChanged failed: %v", err)
+	}
+	if !changed {
+		t.Error("Expected change when Polygon reports the ticker inactive")
+	}
+}
+
 func TestInsertTicker(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -389,3 +421,140 @@ func TestNullIfEmpty(t *testing.T) {
 		t.Error("Expected 'test' for non-empty string")
 	}
 }
+
+// fakeProgressStore records completions in memory
+type fakeProgressStore struct {
+	completedAt map[string]time.Time
+}
+
+func (f *fakeProgressStore) CompletedSince(since time.Time) (map[string]bool, error) {
+	completed := make(map[string]bool)
+	for assetType, at := range f.completedAt {
+		if !at.Before(since) {
+			completed[assetType] = true
+		}
+	}
+	return completed, nil
+}
+
+func (f *fakeProgressStore) MarkCompleted(assetType string, at time.Time) error {
+	f.completedAt[assetType] = at
+	return nil
+}
+
+func TestRunAssetTypesResumesAfterFailure(t *testing.T) {
+	store := &fakeProgressStore{completedAt: map[string]time.Time{}}
+	var imported []string
+	failIndices := true
+	importFn := func(assetType string) error {
+		imported = append(imported, assetType)
+		if assetType == "indices" && failIndices {
+			return errors.New("polygon returned 502")
+		}
+		return nil
+	}
+
+	results := runAssetTypes(allAssetTypes, store, importFn, 12*time.Hour, time.Now(), 0)
+	if !printTypeResults(results) {
+		t.Error("Expected a failed asset type to fail the run")
+	}
+	if results[2].Err == nil {
+		t.Errorf("Expected indices to be reported as failed, got %+v", results[2])
+	}
+	if len(store.completedAt) != 2 {
+		t.Errorf("Expected stocks and etf to be recorded as completed, got %v", store.completedAt)
+	}
+
+	// The re-run skips the types already completed and retries indices
+	imported = nil
+	failIndices = false
+	results = runAssetTypes(allAssetTypes, store, importFn, 12*time.Hour, time.Now(), 0)
+	if len(imported) != 1 || imported[0] != "indices" {
+		t.Errorf("Expected re-run to import only indices, got %v", imported)
+	}
+	if !results[0].Skipped || !results[1].Skipped || results[2].Skipped {
+		t.Errorf("Expected stocks and etf skipped and indices imported, got %+v", results)
+	}
+	if printTypeResults(results) {
+		t.Error("Expected the re-run to succeed")
+	}
+}
+
+func TestRunAssetTypesOutsideWindow(t *testing.T) {
+	now := time.Now()
+	store := &fakeProgressStore{completedAt: map[string]time.Time{
+		"stocks": now.Add(-time.Hour),
+		"etf":    now.Add(-13 * time.Hour),
+	}}
+	var imported []string
+	importFn := func(assetType string) error {
+		imported = append(imported, assetType)
+		return nil
+	}
+
+	runAssetTypes(allAssetTypes, store, importFn, 12*time.Hour, now, 0)
+	if len(imported) != 2 || imported[0] != "etf" || imported[1] != "indices" {
+		t.Errorf("Expected only types outside the run window to be imported, got %v", imported)
+	}
+
+	// A zero window or no store imports everything
+	imported = nil
+	runAssetTypes(allAssetTypes, store, importFn, 0, now, 0)
+	runAssetTypes(allAssetTypes, nil, importFn, 12*time.Hour, now, 0)
+	if len(imported) != 6 {
+		t.Errorf("Expected every type to be imported twice, got %v", imported)
+	}
+}
+
+// fakeTickerSource returns fixed tickers or an error, counting calls
+type fakeTickerSource struct {
+	tickers []services.PolygonTicker
+	err     error
+	calls   int
+}
+
+func (f *fakeTickerSource) GetAllTickers(assetType string, limit int) ([]services.PolygonTicker, error) {
+	f.calls++
+	return f.tickers, f.err
+}
+
+func TestFetchTickersFallsBackToNasdaqTrader(t *testing.T) {
+	polygon := &fakeTickerSource{err: errors.New("API request failed with status: 503 on page 1")}
+	nasdaq := &fakeTickerSource{tickers: []services.PolygonTicker{{Ticker: "AAPL", Type: "CS"}}}
+
+	tickers, fellBack, err := fetchTickers(polygon, nasdaq, "stocks", 0)
+	if err != nil {
+		t.Fatalf("Expected the fallback to succeed, got %v", err)
+	}
+	if len(tickers) != 1 || tickers[0].Ticker != "AAPL" || !fellBack {
+		t.Errorf("Expected fallback tickers, got %+v (fell back: %v)", tickers, fellBack)
+	}
+
+	// Indices aren't in the NASDAQ Trader directory
+	if _, _, err := fetchTickers(polygon, nasdaq, "indices", 0); err == nil {
+		t.Error("Expected indices to fail without a fallback")
+	}
+	if nasdaq.calls != 1 {
+		t.Errorf("Expected the fallback to be used once, got %d", nasdaq.calls)
+	}
+
+	// Both failing reports both errors
+	nasdaq.err = errors.New("NASDAQ Trader nasdaqlisted.txt returned status 500")
+	_, _, err = fetchTickers(polygon, nasdaq, "etf", 0)
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "nasdaqlisted.txt") {
+		t.Errorf("Expected both errors to be reported, got %v", err)
+	}
+}
+
+func TestFetchTickersPrefersPolygon(t *testing.T) {
+	polygon := &fakeTickerSource{tickers: []services.PolygonTicker{{Ticker: "MSFT"}}}
+	nasdaq := &fakeTickerSource{}
+
+	tickers, fellBack, err := fetchTickers(polygon, nasdaq, "stocks", 0)
+	if err != nil || len(tickers) != 1 || tickers[0].Ticker != "MSFT" || fellBack {
+		t.Errorf("Expected Polygon tickers, got %+v, %v (fell back: %v)", tickers, err, fellBack)
+	}
+	if nasdaq.calls != 0 {
+		t.Error("Expected the fallback not to be called when Polygon succeeds")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"investorcenter-api/services"
+)
+
+// Actions recorded for each ticker in the -output artifact
+const (
+	actionInserted  = "inserted"
+	actionUpdated   = "updated"
+	actionUnchanged = "unchanged"
+	actionSkipped   = "skipped" // new ticker with -update-only
+	actionExcluded  = "excluded"
+	actionError     = "error"
+	actionDryRun    = "dry_run"
+)
+
+// importRecord is one ticker an import processed, written to -output so
+// downstream tooling can see what a run changed
+type importRecord struct {
+	Symbol    string `json:"symbol"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Exchange  string `json:"exchange"`
+	AssetType string `json:"asset_type"`
+	Action    string `json:"action"`
+	Detail    string `json:"detail,omitempty"` // exclusion rule or error
+}
+
+var importRecordHeader = []string{"symbol", "name", "type", "exchange", "asset_type", "action", "detail"}
+
+// importRecorder collects importRecords across asset types. A nil recorder
+// (no -output) discards them.
+type importRecorder struct {
+	records []importRecord
+}
+
+func (r *importRecorder) add(ticker services.PolygonTicker, action, detail string) {
+	if r == nil {
+		return
+	}
+	r.records = append(r.records, importRecord{
+		Symbol:    ticker.Ticker,
+		Name:      ticker.Name,
+		Type:      ticker.Type,
+		Exchange:  services.MapExchangeCode(ticker.PrimaryExchange),
+		AssetType: services.MapAssetType(ticker.Type),
+		Action:    action,
+		Detail:    detail,
+	})
+}
+
+// validateOutputFormat checks -format
+func validateOutputFormat(format string) error {
+	switch format {
+	case "json", "csv":
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (want json or csv)", format)
+}
+
+// writeOutputFile creates path and writes to it with write
+func writeOutputFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeImportRecords writes records as a JSON array or a CSV with a header row
+func writeImportRecords(w io.Writer, format string, records []importRecord) error {
+	if format == "json" {
+		if records == nil {
+			records = []importRecord{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(importRecordHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{r.Symbol, r.Name, r.Type, r.Exchange, r.AssetType, r.Action, r.Detail}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var discrepancyHeader = []string{"kind", "symbol", "field", "primary", "secondary"}
+
+// writeValidationReport writes report as JSON, or as a CSV with one row per
+// discrepancy: kind is missing_from_primary, missing_from_secondary or
+// mismatch
+func writeValidationReport(w io.Writer, format string, report *validationReport) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	cw := csv.NewWriter(w)
+	rows := [][]string{discrepancyHeader}
+	for _, symbol := range report.MissingFromPrimary {
+		rows = append(rows, []string{"missing_from_primary", symbol, "", "", ""})
+	}
+	for _, symbol := range report.MissingFromSecondary {
+		rows = append(rows, []string{"missing_from_secondary", symbol, "", "", ""})
+	}
+	for _, m := range report.Mismatches {
+		rows = append(rows, []string{"mismatch", m.Symbol, m.Field, m.Primary, m.Secondary})
+	}
+	return cw.WriteAll(rows)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"investorcenter-api/services"
+)
+
+// processedRecords runs tickers through the recorder the way importTickers
+// does: excluded ones first, then one action per remaining ticker
+func processedRecords() []importRecord {
+	r := &importRecorder{}
+	tickers := []services.PolygonTicker{
+		{Ticker: "AAPL", Name: "Apple Inc.", Type: "CS", PrimaryExchange: "XNAS"},
+		{Ticker: "SPY", Name: "SPDR S&P 500 ETF Trust", Type: "ETF", PrimaryExchange: "ARCX"},
+		{Ticker: "ACAHW", Name: "Acme Acquisition Corp, \"Warrant\"", Type: "WARRANT", PrimaryExchange: "XNAS"},
+		{Ticker: "IBM", Name: "International Business Machines", Type: "CS", PrimaryExchange: "XNYS"},
+	}
+	r.add(tickers[2], actionExcluded, "derivative")
+	r.add(tickers[0], actionInserted, "")
+	r.add(tickers[1], actionUpdated, "")
+	r.add(tickers[3], actionError, "pq: connection reset, retry later")
+	return r.records
+}
+
+func writeRecordsFile(t *testing.T, format string, records []importRecord) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "import."+format)
+	err := writeOutputFile(path, func(w io.Writer) error {
+		return writeImportRecords(w, format, records)
+	})
+	if err != nil {
+		t.Fatalf("writeOutputFile: %v", err)
+	}
+	return path
+}
+
+func TestWriteImportRecordsJSON(t *testing.T) {
+	records := processedRecords()
+	data, err := os.ReadFile(writeRecordsFile(t, "json", records))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []importRecord
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Expected a JSON array, got %q: %v", data, err)
+	}
+	if !reflect.DeepEqual(got, records) {
+		t.Errorf("Expected the file to match the processed set\n got: %+v\nwant: %+v", got, records)
+	}
+	if got[1].Exchange != "NASDAQ" || got[1].AssetType != "stock" {
+		t.Errorf("Expected mapped exchange and asset type, got %q and %q", got[1].Exchange, got[1].AssetType)
+	}
+}
+
+func TestWriteImportRecordsCSV(t *testing.T) {
+	records := processedRecords()
+	f, err := os.Open(writeRecordsFile(t, "csv", records))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV: %v", err)
+	}
+	if !reflect.DeepEqual(rows[0], importRecordHeader) {
+		t.Errorf("Expected header %v, got %v", importRecordHeader, rows[0])
+	}
+	if len(rows) != len(records)+1 {
+		t.Fatalf("Expected %d rows, got %d", len(records)+1, len(rows))
+	}
+	for i, r := range records {
+		want := []string{r.Symbol, r.Name, r.Type, r.Exchange, r.AssetType, r.Action, r.Detail}
+		if !reflect.DeepEqual(rows[i+1], want) {
+			t.Errorf("Row %d: expected %v, got %v", i+1, want, rows[i+1])
+		}
+	}
+}
+
+func TestWriteImportRecordsEmpty(t *testing.T) {
+	data, err := os.ReadFile(writeRecordsFile(t, "json", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]\n" {
+		t.Errorf("Expected an empty JSON array, got %q", data)
+	}
+}
+
+func TestNilImportRecorderDiscards(t *testing.T) {
+	var r *importRecorder
+	r.add(services.PolygonTicker{Ticker: "AAPL"}, actionInserted, "")
+}
+
+func TestWriteValidationReport(t *testing.T) {
+	report := compareTickers(
+		[]services.PolygonTicker{
+			{Ticker: "GONE", Name: "Delisted Corp", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "KO", Name: "Coca-Cola Co", Type: "CS", PrimaryExchange: "XNYS"},
+		},
+		[]services.PolygonTicker{
+			{Ticker: "HIMS", Name: "Hims & Hers Health", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "KO", Name: "Coca-Cola Co", Type: "CS", PrimaryExchange: "XNAS"},
+		},
+	)
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := writeOutputFile(path, func(w io.Writer) error { return writeValidationReport(w, "csv", report) }); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		discrepancyHeader,
+		{"missing_from_primary", "HIMS", "", "", ""},
+		{"missing_from_secondary", "GONE", "", "", ""},
+		{"mismatch", "KO", "exchange", "XNYS", "XNAS"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected CSV rows %v, got %v", want, rows)
+	}
+
+	path = filepath.Join(t.TempDir(), "report.json")
+	if err := writeOutputFile(path, func(w io.Writer) error { return writeValidationReport(w, "json", report) }); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got validationReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, report) {
+		t.Errorf("Expected the JSON file to match the report\n got: %+v\nwant: %+v", got, *report)
+	}
+}
+
+func TestRunValidationWritesArtifact(t *testing.T) {
+	polygon := typedTickerSource{"stocks": {{Ticker: "AAPL", Name: "Apple Inc.", Type: "CS", PrimaryExchange: "XNAS"}}}
+	nasdaq := typedTickerSource{"stocks": {{Ticker: "AAPL", Name: "Apple Inc.", Type: "CS", PrimaryExchange: "XNAS"}}}
+
+	var written *validationReport
+	code := runValidation(polygon, nasdaq, 0, io.Discard, func(r *validationReport) error {
+		written = r
+		return nil
+	})
+	if code != 0 || written == nil || written.PrimaryCount != 1 {
+		t.Errorf("Expected the report passed to the artifact writer, got code %d and %+v", code, written)
+	}
+
+	code = runValidation(polygon, nasdaq, 0, io.Discard, func(*validationReport) error {
+		return os.ErrPermission
+	})
+	if code != 1 {
+		t.Errorf("Expected exit code 1 when the artifact can't be written, got %d", code)
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	for _, f := range []string{"json", "csv"} {
+		if err := validateOutputFormat(f); err != nil {
+			t.Errorf("Expected %s to be valid: %v", f, err)
+		}
+	}
+	if err := validateOutputFormat("xml"); err == nil {
+		t.Error("Expected xml to be rejected")
+	}
+}
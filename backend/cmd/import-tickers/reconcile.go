@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"sort"
+
+	"github.com/lib/pq"
+	"investorcenter-api/services"
+)
+
+// reconcileScope is the tickers.asset_type values each -type fetches the
+// complete listing of, and so can reconcile. Crypto is never reconciled.
+var reconcileScope = map[string][]string{
+	"stocks":       {"stock"},
+	"etf":          {"etf"},
+	"indices":      {"index"},
+	"all_equities": {"stock", "etf", "etn", "fund", "preferred", "warrant", "right", "bond", "adr", "other"},
+}
+
+// delistedTicker is a ticker reconcileDelisted marked inactive
+type delistedTicker struct {
+	Symbol    string
+	AssetType string
+}
+
+// reconcileDelisted marks active tickers of assetType's scope that aren't in
+// listed as delisted: active=false with delisted_at set. listed must be the
+// complete listing for assetType, before exclusions, or live tickers would
+// be delisted; an empty listing reconciles nothing.
+func reconcileDelisted(db *sql.DB, assetType string, listed []services.PolygonTicker) ([]delistedTicker, error) {
+	scope := reconcileScope[assetType]
+	if len(scope) == 0 || len(listed) == 0 {
+		return nil, nil
+	}
+
+	symbols := make([]string, len(listed))
+	types := make([]string, len(listed))
+	for i, t := range listed {
+		symbols[i] = t.Ticker
+		types[i] = services.MapAssetType(t.Type)
+	}
+
+	rows, err := db.Query(`
+		UPDATE tickers SET active = false, delisted_at = NOW(), updated_at = NOW()
+		WHERE asset_type = ANY($1) AND active = true
+		  AND (symbol, asset_type) NOT IN (SELECT * FROM unnest($2::text[], $3::text[]))
+		RETURNING symbol, asset_type`,
+		pq.Array(scope), pq.Array(symbols), pq.Array(types))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var delisted []delistedTicker
+	for rows.Next() {
+		var d delistedTicker
+		if err := rows.Scan(&d.Symbol, &d.AssetType); err != nil {
+			return nil, err
+		}
+		delisted = append(delisted, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(delisted, func(i, j int) bool { return delisted[i].Symbol < delisted[j].Symbol })
+	return delisted, nil
+}
+
+// printDelisted logs the tickers a reconciliation delisted
+func printDelisted(assetType string, delisted []delistedTicker) {
+	if len(delisted) == 0 {
+		log.Printf("🔁 Reconciled %s: no newly delisted tickers", assetType)
+		return
+	}
+	log.Printf("🔁 Reconciled %s: %d newly delisted tickers", assetType, len(delisted))
+	for _, d := range delisted {
+		log.Printf("  - %s (%s)", d.Symbol, d.AssetType)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"investorcenter-api/services"
+)
+
+func TestReconcileDelisted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	listed := []services.PolygonTicker{{Ticker: "AAPL", Type: "CS"}, {Ticker: "MSFT", Type: "CS"}}
+	mock.ExpectQuery(`UPDATE tickers SET active = false, delisted_at = NOW\(\)`).
+		WithArgs(pq.Array([]string{"stock"}), pq.Array([]string{"AAPL", "MSFT"}), pq.Array([]string{"stock", "stock"})).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "asset_type"}).
+			AddRow("TWTR", "stock").AddRow("ATVI", "stock"))
+
+	delisted, err := reconcileDelisted(db, "stocks", listed)
+	if err != nil {
+		t.Fatalf("reconcileDelisted: %v", err)
+	}
+	want := []delistedTicker{{"ATVI", "stock"}, {"TWTR", "stock"}}
+	if len(delisted) != len(want) || delisted[0] != want[0] || delisted[1] != want[1] {
+		t.Errorf("Expected %v delisted, got %v", want, delisted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReconcileDelistedSkipsEmptyListingAndCrypto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// An empty listing would delist every ticker, and crypto has no scope
+	if delisted, err := reconcileDelisted(db, "stocks", nil); err != nil || delisted != nil {
+		t.Errorf("Expected an empty listing to reconcile nothing, got %v, %v", delisted, err)
+	}
+	if delisted, err := reconcileDelisted(db, "crypto", []services.PolygonTicker{{Ticker: "X:BTCUSD"}}); err != nil || delisted != nil {
+		t.Errorf("Expected crypto to reconcile nothing, got %v, %v", delisted, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReconcileDelistedError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`UPDATE tickers`).WillReturnError(errors.New("connection reset"))
+	if _, err := reconcileDelisted(db, "etf", []services.PolygonTicker{{Ticker: "SPY", Type: "ETF"}}); err == nil {
+		t.Error("Expected the query error to be returned")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"investorcenter-api/services"
+)
+
+// validateAssetTypes are the asset types both sources list
+var validateAssetTypes = []string{"stocks", "etf"}
+
+// tickerMismatch is a symbol both sources list but describe differently
+type tickerMismatch struct {
+	Symbol    string `json:"symbol"`
+	Field     string `json:"field"` // name, exchange or type
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+}
+
+// validationReport compares the ticker universes of two sources. It's
+// printed as one JSON line so the cron monitor can pick it out of the logs.
+type validationReport struct {
+	GeneratedAt          time.Time        `json:"generated_at"`
+	PrimaryCount         int              `json:"primary_count"`
+	SecondaryCount       int              `json:"secondary_count"`
+	MissingFromPrimary   []string         `json:"missing_from_primary"`
+	MissingFromSecondary []string         `json:"missing_from_secondary"`
+	Mismatches           []tickerMismatch `json:"mismatches"`
+	Discrepancies        int              `json:"discrepancies"`
+}
+
+// runValidation compares primary against secondary, writes the report to w
+// (and to writeArtifact, if set) and returns the process exit code: 1 if the
+// comparison failed or found more than maxDiscrepancies discrepancies
+func runValidation(primary, secondary tickerSource, maxDiscrepancies int, w io.Writer, writeArtifact func(*validationReport) error) int {
+	covered := func(t services.PolygonTicker) bool {
+		return services.IsNasdaqTraderExchange(t.PrimaryExchange)
+	}
+	report, err := validateSources(primary, secondary, covered, time.Now().UTC())
+	if err != nil {
+		log.Printf("❌ Ticker validation failed: %v", err)
+		return 1
+	}
+
+	log.Printf("🔎 Validated %d Polygon tickers against %d NASDAQ Trader tickers: %d missing from Polygon, %d missing from NASDAQ Trader, %d mismatches",
+		report.PrimaryCount, report.SecondaryCount,
+		len(report.MissingFromPrimary), len(report.MissingFromSecondary), len(report.Mismatches))
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("❌ Failed to write validation report: %v", err)
+		return 1
+	}
+	if writeArtifact != nil {
+		if err := writeArtifact(report); err != nil {
+			log.Printf("❌ Failed to write validation report file: %v", err)
+			return 1
+		}
+	}
+
+	if report.Discrepancies > maxDiscrepancies {
+		log.Printf("❌ %d discrepancies exceeds the limit of %d", report.Discrepancies, maxDiscrepancies)
+		return 1
+	}
+	return 0
+}
+
+// validateSources lists validateAssetTypes from both sources and compares
+// them. Primary tickers on exchanges the secondary doesn't cover (OTC, ...)
+// are left out so they aren't all reported missing.
+func validateSources(primary, secondary tickerSource, covered func(services.PolygonTicker) bool, now time.Time) (*validationReport, error) {
+	var primaryTickers, secondaryTickers []services.PolygonTicker
+	for _, assetType := range validateAssetTypes {
+		p, err := primary.GetAllTickers(assetType, 0)
+		if err != nil {
+			return nil, fmt.Errorf("primary source %s: %w", assetType, err)
+		}
+		for _, t := range p {
+			if covered(t) {
+				primaryTickers = append(primaryTickers, t)
+			}
+		}
+
+		s, err := secondary.GetAllTickers(assetType, 0)
+		if err != nil {
+			return nil, fmt.Errorf("secondary source %s: %w", assetType, err)
+		}
+		secondaryTickers = append(secondaryTickers, s...)
+	}
+
+	report := compareTickers(primaryTickers, secondaryTickers)
+	report.GeneratedAt = now
+	return report, nil
+}
+
+// compareTickers reports symbols present in only one list and symbols whose
+// name, exchange or type differ between the two
+func compareTickers(primary, secondary []services.PolygonTicker) *validationReport {
+	p := tickersBySymbol(primary)
+	s := tickersBySymbol(secondary)
+
+	report := &validationReport{
+		PrimaryCount:         len(p),
+		SecondaryCount:       len(s),
+		MissingFromPrimary:   []string{},
+		MissingFromSecondary: []string{},
+		Mismatches:           []tickerMismatch{},
+	}
+
+	for symbol, pt := range p {
+		st, ok := s[symbol]
+		if !ok {
+			report.MissingFromSecondary = append(report.MissingFromSecondary, symbol)
+			continue
+		}
+		if !namesMatch(pt.Name, st.Name) {
+			report.Mismatches = append(report.Mismatches, tickerMismatch{symbol, "name", pt.Name, st.Name})
+		}
+		if pt.PrimaryExchange != st.PrimaryExchange {
+			report.Mismatches = append(report.Mismatches, tickerMismatch{symbol, "exchange", pt.PrimaryExchange, st.PrimaryExchange})
+		}
+		if pt.Type != st.Type {
+			report.Mismatches = append(report.Mismatches, tickerMismatch{symbol, "type", pt.Type, st.Type})
+		}
+	}
+	for symbol := range s {
+		if _, ok := p[symbol]; !ok {
+			report.MissingFromPrimary = append(report.MissingFromPrimary, symbol)
+		}
+	}
+
+	sort.Strings(report.MissingFromPrimary)
+	sort.Strings(report.MissingFromSecondary)
+	sort.Slice(report.Mismatches, func(i, j int) bool {
+		if report.Mismatches[i].Symbol != report.Mismatches[j].Symbol {
+			return report.Mismatches[i].Symbol < report.Mismatches[j].Symbol
+		}
+		return report.Mismatches[i].Field < report.Mismatches[j].Field
+	})
+	report.Discrepancies = len(report.MissingFromPrimary) + len(report.MissingFromSecondary) + len(report.Mismatches)
+	return report
+}
+
+func tickersBySymbol(tickers []services.PolygonTicker) map[string]services.PolygonTicker {
+	bySymbol := make(map[string]services.PolygonTicker, len(tickers))
+	for _, t := range tickers {
+		bySymbol[strings.ToUpper(t.Ticker)] = t
+	}
+	return bySymbol
+}
+
+var (
+	// securityDescriptionRe strips the security description NASDAQ Trader
+	// appends to company names, e.g. "Apple Inc. - Common Stock"
+	securityDescriptionRe = regexp.MustCompile(`\s+-\s+.*$`)
+	nonAlphanumericRe     = regexp.MustCompile(`[^A-Z0-9]+`)
+)
+
+// namesMatch compares company names loosely: case, punctuation and a
+// trailing security description are ignored, and one name may extend the
+// other ("Apple Inc" vs "Apple Inc Common Stock")
+func namesMatch(a, b string) bool {
+	na, nb := normalizeName(a), normalizeName(b)
+	if na == "" || nb == "" {
+		return true
+	}
+	return strings.HasPrefix(na, nb) || strings.HasPrefix(nb, na)
+}
+
+func normalizeName(name string) string {
+	name = securityDescriptionRe.ReplaceAllString(strings.ToUpper(name), "")
+	return strings.TrimSpace(nonAlphanumericRe.ReplaceAllString(name, " "))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"investorcenter-api/services"
+)
+
+// typedTickerSource returns fixed tickers per asset type
+type typedTickerSource map[string][]services.PolygonTicker
+
+func (s typedTickerSource) GetAllTickers(assetType string, limit int) ([]services.PolygonTicker, error) {
+	return s[assetType], nil
+}
+
+func TestRunValidationReportsDiscrepancies(t *testing.T) {
+	polygon := typedTickerSource{
+		"stocks": {
+			{Ticker: "AAPL", Name: "Apple Inc.", Type: "CS", PrimaryExchange: "XNAS"},
+			{Ticker: "IBM", Name: "International Business Machines", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "GONE", Name: "Delisted Corp", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "KO", Name: "Coca-Cola Co", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "OTCCO", Name: "Pink Sheets Inc", Type: "CS", PrimaryExchange: "XOTC"},
+		},
+		"etf": {
+			{Ticker: "SPY", Name: "SPDR S&P 500 ETF Trust", Type: "ETF", PrimaryExchange: "ARCX"},
+		},
+	}
+	nasdaq := typedTickerSource{
+		"stocks": {
+			{Ticker: "AAPL", Name: "Apple Inc. - Common Stock", Type: "CS", PrimaryExchange: "XNAS"},
+			{Ticker: "IBM", Name: "International Business Machines Corporation Common Stock", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "HIMS", Name: "Hims & Hers Health, Inc. Class A Common Stock", Type: "CS", PrimaryExchange: "XNYS"},
+			{Ticker: "KO", Name: "Keurig Dr Pepper", Type: "CS", PrimaryExchange: "XNAS"},
+		},
+		"etf": {
+			{Ticker: "SPY", Name: "SPDR S&P 500 ETF Trust", Type: "ETF", PrimaryExchange: "ARCX"},
+		},
+	}
+
+	var out bytes.Buffer
+	code := runValidation(polygon, nasdaq, 10, &out, nil)
+	if code != 0 {
+		t.Errorf("Expected exit code 0 within the discrepancy limit, got %d", code)
+	}
+
+	var report validationReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %q: %v", out.String(), err)
+	}
+	if report.PrimaryCount != 5 {
+		t.Errorf("Expected the OTC ticker to be left out of the comparison, got %d primary tickers", report.PrimaryCount)
+	}
+	if !reflect.DeepEqual(report.MissingFromPrimary, []string{"HIMS"}) {
+		t.Errorf("Expected HIMS missing from Polygon, got %v", report.MissingFromPrimary)
+	}
+	if !reflect.DeepEqual(report.MissingFromSecondary, []string{"GONE"}) {
+		t.Errorf("Expected GONE missing from NASDAQ Trader, got %v", report.MissingFromSecondary)
+	}
+	want := []tickerMismatch{
+		{Symbol: "KO", Field: "exchange", Primary: "XNYS", Secondary: "XNAS"},
+		{Symbol: "KO", Field: "name", Primary: "Coca-Cola Co", Secondary: "Keurig Dr Pepper"},
+	}
+	if !reflect.DeepEqual(report.Mismatches, want) {
+		t.Errorf("Expected KO name and exchange mismatches only, got %+v", report.Mismatches)
+	}
+	if report.Discrepancies != 4 {
+		t.Errorf("Expected 4 discrepancies, got %d", report.Discrepancies)
+	}
+
+	// Over the limit fails the run for the cron monitor
+	out.Reset()
+	if code := runValidation(polygon, nasdaq, 3, &out, nil); code != 1 {
+		t.Errorf("Expected exit code 1 over the discrepancy limit, got %d", code)
+	}
+}
+
+func TestRunValidationSourceError(t *testing.T) {
+	var out bytes.Buffer
+	failing := &fakeTickerSource{err: errors.New("API request failed with status: 503 on page 1")}
+	if code := runValidation(failing, typedTickerSource{}, 10, &out, nil); code != 1 {
+		t.Errorf("Expected exit code 1 when a source fails, got %d", code)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no report when a source fails, got %q", out.String())
+	}
+}
+
+func TestNamesMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Apple Inc.", "Apple Inc. - Common Stock", true},
+		{"Berkshire Hathaway Inc.", "BERKSHIRE HATHAWAY INC CLASS A", true},
+		{"Coca-Cola Co", "Keurig Dr Pepper", false},
+		{"", "Anything", true},
+	}
+	for _, tt := range tests {
+		if got := namesMatch(tt.a, tt.b); got != tt.want {
+			t.Errorf("namesMatch(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
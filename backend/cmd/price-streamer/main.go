@@ -0,0 +1,114 @@
+// Command price-streamer streams per-minute aggregates from Polygon's
+// websocket feed into stock_prices and publishes them as price updates for
+// the notification service. It runs until SIGINT or SIGTERM, then flushes
+// the bars it is holding and exits.
+//
+// By default it streams every symbol on a watch list or an active alert
+// rule, picking up changes every -refresh-interval.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/services"
+)
+
+// Command line flags
+var (
+	symbolsFlag     = flag.String("symbols", "", "Comma-separated symbols to stream (default: watched symbols from the database)")
+	flushInterval   = flag.Duration("flush-interval", 5*time.Second, "How often to write bars and publish price updates")
+	refreshInterval = flag.Duration("refresh-interval", 5*time.Minute, "How often to reload watched symbols")
+)
+
+func main() {
+	flag.Parse()
+
+	if os.Getenv("POLYGON_API_KEY") == "" {
+		log.Fatal("POLYGON_API_KEY is required")
+	}
+	if err := database.Initialize(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	symbols := parseSymbols(*symbolsFlag)
+	if len(symbols) == 0 {
+		var err error
+		if symbols, err = database.GetWatchedSymbols(); err != nil {
+			log.Fatalf("Failed to load watched symbols: %v", err)
+		}
+	}
+	client := services.NewPolygonWebsocketClient(symbols)
+	streamer := services.NewPriceStreamer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *symbolsFlag == "" {
+		go refreshSymbols(ctx, client, *refreshInterval, database.GetWatchedSymbols)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- streamer.Run(ctx, client, *flushInterval) }()
+	log.Printf("Price streamer running for %d symbols", len(symbols))
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	var err error
+	select {
+	case <-quit:
+		log.Println("Shutting down price streamer...")
+		cancel() // Close the websocket; Run flushes pending bars
+		err = <-done
+	case err = <-done:
+	}
+	if publisher := services.GetPriceUpdatePublisher(); publisher != nil {
+		publisher.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Price streamer stopped: %v", err)
+	}
+	log.Println("Price streamer stopped")
+}
+
+// parseSymbols splits a -symbols value, upper-casing and dropping blanks
+func parseSymbols(raw string) []string {
+	var symbols []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// refreshSymbols reloads the streamed symbols from list every interval
+// until ctx is done. A failed load keeps the current symbols.
+func refreshSymbols(ctx context.Context, client *services.PolygonWebsocketClient, interval time.Duration, list func() ([]string, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			symbols, err := list()
+			if err != nil {
+				log.Printf("⚠️ Failed to reload watched symbols: %v", err)
+				continue
+			}
+			if err := client.SetSymbols(symbols); err != nil {
+				log.Printf("⚠️ Failed to update subscriptions: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/services"
+)
+
+func TestParseSymbols(t *testing.T) {
+	assert.Equal(t, []string{"AAPL", "MSFT"}, parseSymbols(" aapl, ,MSFT,"))
+	assert.Empty(t, parseSymbols(""))
+}
+
+func TestRefreshSymbolsKeepsSymbolsOnError(t *testing.T) {
+	client := services.NewPolygonWebsocketClientWith("ws://unused", "key", []string{"AAPL"})
+	calls := 0
+	list := func() ([]string, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("db down")
+		}
+		return []string{"MSFT", "NVDA"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		refreshSymbols(ctx, client, 5*time.Millisecond, list)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(client.Symbols()) == 2
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, []string{"MSFT", "NVDA"}, client.Symbols())
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/services"
+)
+
+// Command line flags
+var (
+	symbolList = flag.String("symbols", "", "Comma-separated symbols to refresh (default: every active stock)")
+	batchSize  = flag.Int("batch-size", 500, "Symbols refreshed per pass; FMP is called once per 50 within a pass")
+	delay      = flag.Duration("delay", time.Second, "Pause between passes to stay under FMP's rate limit")
+	timeout    = flag.Duration("timeout", time.Hour, "Overall time limit for the run")
+)
+
+// refreshStats summarizes a run
+type refreshStats struct {
+	Updated   int
+	NoRatios  int
+	Failed    int
+	Attempted int
+}
+
+func main() {
+	flag.Parse()
+
+	if err := database.Initialize(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	symbols := parseSymbols(*symbolList)
+	if len(symbols) == 0 {
+		var err error
+		if symbols, err = database.GetActiveStockSymbols(); err != nil {
+			log.Fatalf("Failed to load symbols: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	stats := run(ctx, services.NewFundamentalsRefreshService(), symbols, *batchSize, *delay)
+	log.Printf("Fundamentals refresh done: %d updated (%d without FMP ratios), %d failed of %d",
+		stats.Updated, stats.NoRatios, stats.Failed, stats.Attempted)
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// run refreshes symbols batchSize at a time, pausing delay between batches
+func run(ctx context.Context, svc *services.FundamentalsRefreshService, symbols []string, batchSize int, delay time.Duration) refreshStats {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	var stats refreshStats
+	for start := 0; start < len(symbols); start += batchSize {
+		if start > 0 && delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			log.Printf("Stopping early: %v", ctx.Err())
+			break
+		}
+
+		batch := symbols[start:min(start+batchSize, len(symbols))]
+		for _, r := range svc.Refresh(ctx, batch) {
+			stats.Attempted++
+			switch {
+			case !r.Success:
+				stats.Failed++
+				log.Printf("❌ %s: %s", r.Symbol, r.Error)
+			case !r.RatiosTTM:
+				stats.Updated++
+				stats.NoRatios++
+			default:
+				stats.Updated++
+			}
+		}
+	}
+	return stats
+}
+
+func parseSymbols(raw string) []string {
+	var symbols []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/services"
+)
+
+// fakeFetcher has ratios for every symbol except those in noData
+type fakeFetcher struct {
+	batches [][]string
+	noData  map[string]bool
+}
+
+func (f *fakeFetcher) GetFundamentalsBatch(tickers []string) map[string]*services.FMPAllMetrics {
+	f.batches = append(f.batches, tickers)
+	results := make(map[string]*services.FMPAllMetrics, len(tickers))
+	for _, t := range tickers {
+		m := &services.FMPAllMetrics{Score: &services.FMPScore{Symbol: t}, Errors: map[string]error{}}
+		if f.noData[t] {
+			m.Errors["ratios-ttm"] = services.ErrFMPNoData
+		} else {
+			m.RatiosTTM = &services.FMPRatiosTTM{Symbol: t}
+		}
+		results[t] = m
+	}
+	return results
+}
+
+type nopStore struct{}
+
+func (nopStore) UpsertFMPSnapshots(string, map[string]json.RawMessage) error { return nil }
+
+func TestRunBatches(t *testing.T) {
+	fetcher := &fakeFetcher{noData: map[string]bool{"SPAC": true}}
+	svc := services.NewFundamentalsRefreshServiceWith(fetcher, nopStore{})
+
+	stats := run(context.Background(), svc, []string{"AAPL", "MSFT", "SPAC", "TSLA", "NVDA"}, 2, 0)
+	assert.Equal(t, refreshStats{Updated: 5, NoRatios: 1, Attempted: 5}, stats)
+	assert.Equal(t, [][]string{{"AAPL", "MSFT"}, {"SPAC", "TSLA"}, {"NVDA"}}, fetcher.batches)
+}
+
+func TestRunStopsWhenCanceled(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	svc := services.NewFundamentalsRefreshServiceWith(fetcher, nopStore{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats := run(ctx, svc, []string{"AAPL", "MSFT"}, 1, 0)
+	assert.Equal(t, 0, stats.Attempted)
+	assert.Empty(t, fetcher.batches)
+}
+
+func TestParseSymbols(t *testing.T) {
+	assert.Equal(t, []string{"AAPL", "MSFT"}, parseSymbols(" aapl, ,msft "))
+	assert.Nil(t, parseSymbols(""))
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"investorcenter-api/models"
+)
+
+// RecordAccountActivity stores an account activity event. A nil Metadata is
+// stored as an empty object.
+func RecordAccountActivity(activity *models.AccountActivity) error {
+	metadata := activity.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage(`{}`)
+	}
+
+	query := `
+		INSERT INTO account_activity (user_id, event_type, ip_address, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := DB.QueryRow(
+		query,
+		activity.UserID,
+		activity.EventType,
+		activity.IPAddress,
+		activity.UserAgent,
+		metadata,
+	).Scan(&activity.ID, &activity.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record account activity: %w", err)
+	}
+	activity.Metadata = metadata
+	return nil
+}
+
+// GetAccountActivity returns a page of a user's account activity, newest
+// first, along with the user's total number of events
+func GetAccountActivity(userID string, limit, offset int) ([]models.AccountActivity, int, error) {
+	var total int
+	if err := DB.Get(&total, `SELECT COUNT(*) FROM account_activity WHERE user_id = $1`, userID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count account activity: %w", err)
+	}
+
+	query := `
+		SELECT id, user_id, event_type, ip_address, user_agent, metadata, created_at
+		FROM account_activity
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+	activity := []models.AccountActivity{}
+	if err := DB.Select(&activity, query, userID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to get account activity: %w", err)
+	}
+	return activity, total, nil
+}
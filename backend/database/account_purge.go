@@ -0,0 +1,120 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+// ErrUserNotPendingPurge is returned when purging a user that is still
+// active or has already been purged
+var ErrUserNotPendingPurge = errors.New("user is not pending purge")
+
+// accountPurgeSteps run in order inside one transaction. Rows are deleted
+// explicitly, children before parents, rather than left to ON DELETE
+// CASCADE so the report can count them. The users row itself is kept as an
+// anonymized tombstone so rows owned by other users that reference it
+// (tasks, task files) stay valid.
+var accountPurgeSteps = []struct {
+	table  string
+	action string
+	query  string
+}{
+	{"alert_logs", models.PurgeActionDeleted, `DELETE FROM alert_logs WHERE user_id = $1`},
+	{"alert_rules", models.PurgeActionDeleted, `DELETE FROM alert_rules WHERE user_id = $1`},
+	{"heatmap_configs", models.PurgeActionDeleted, `DELETE FROM heatmap_configs WHERE user_id = $1`},
+	{"watch_list_items", models.PurgeActionDeleted, `DELETE FROM watch_list_items WHERE watch_list_id IN (SELECT id FROM watch_lists WHERE user_id = $1)`},
+	{"watch_lists", models.PurgeActionDeleted, `DELETE FROM watch_lists WHERE user_id = $1`},
+	{"portfolio_transactions", models.PurgeActionDeleted, `DELETE FROM portfolio_transactions WHERE portfolio_id IN (SELECT id FROM portfolios WHERE user_id = $1)`},
+	{"portfolio_holdings", models.PurgeActionDeleted, `DELETE FROM portfolio_holdings WHERE portfolio_id IN (SELECT id FROM portfolios WHERE user_id = $1)`},
+	{"portfolios", models.PurgeActionDeleted, `DELETE FROM portfolios WHERE user_id = $1`},
+	{"saved_screens", models.PurgeActionDeleted, `DELETE FROM saved_screens WHERE user_id = $1`},
+	{"notification_queue", models.PurgeActionDeleted, `DELETE FROM notification_queue WHERE user_id = $1`},
+	{"digest_logs", models.PurgeActionDeleted, `DELETE FROM digest_logs WHERE user_id = $1`},
+	{"notification_daily_counts", models.PurgeActionDeleted, `DELETE FROM notification_daily_counts WHERE user_id = $1`},
+	{"phone_verifications", models.PurgeActionDeleted, `DELETE FROM phone_verifications WHERE user_id = $1`},
+	{"notification_preferences", models.PurgeActionDeleted, `DELETE FROM notification_preferences WHERE user_id = $1`},
+	{"sessions", models.PurgeActionDeleted, `DELETE FROM sessions WHERE user_id = $1`},
+	{"account_activity", models.PurgeActionDeleted, `DELETE FROM account_activity WHERE user_id = $1`},
+	{"admin_audit_log", models.PurgeActionAnonymized, `UPDATE admin_audit_log SET actor_email = NULL WHERE actor_user_id = $1`},
+	{"user_two_factor", models.PurgeActionDeleted, `DELETE FROM user_two_factor WHERE user_id = $1`},
+	{"password_reset_tokens", models.PurgeActionDeleted, `DELETE FROM password_reset_tokens WHERE user_id = $1`},
+	{"oauth_providers", models.PurgeActionDeleted, `DELETE FROM oauth_providers WHERE user_id = $1`},
+	{"user_searches", models.PurgeActionDeleted, `DELETE FROM user_searches WHERE user_id = $1`},
+	{"payment_history", models.PurgeActionDeleted, `DELETE FROM payment_history WHERE user_id = $1`},
+	{"user_subscriptions", models.PurgeActionDeleted, `DELETE FROM user_subscriptions WHERE user_id = $1`},
+	{"backtest_jobs", models.PurgeActionAnonymized, `UPDATE backtest_jobs SET user_id = NULL WHERE user_id = $1`},
+	{"users", models.PurgeActionAnonymized, `
+		UPDATE users SET
+			email = 'deleted-' || id || '@deleted.invalid',
+			password_hash = NULL, full_name = '', timezone = 'UTC',
+			email_verified = FALSE,
+			email_verification_token = NULL, email_verification_expires_at = NULL,
+			password_reset_token = NULL, password_reset_expires_at = NULL,
+			last_login_at = NULL, last_activity_at = NULL,
+			is_premium = FALSE, is_admin = FALSE, is_worker = FALSE,
+			purged_at = NOW(), updated_at = NOW()
+		WHERE id = $1`},
+}
+
+// GetUsersPendingPurge returns soft-deleted users whose deletion is older
+// than deletedBefore and whose data has not been purged yet, oldest first
+func GetUsersPendingPurge(deletedBefore time.Time) ([]string, error) {
+	query := `
+		SELECT id FROM users
+		WHERE is_active = FALSE AND purged_at IS NULL
+		  AND deleted_at IS NOT NULL AND deleted_at < $1
+		ORDER BY deleted_at ASC
+	`
+	userIDs := []string{}
+	if err := DB.Select(&userIDs, query, deletedBefore); err != nil {
+		return nil, fmt.Errorf("failed to get users pending purge: %w", err)
+	}
+	return userIDs, nil
+}
+
+// PurgeUserData removes or anonymizes every row linked to a soft-deleted
+// user in a single transaction and reports the rows affected per table.
+// Active and already-purged users return ErrUserNotPendingPurge.
+func PurgeUserData(userID string) (*models.AccountPurgeReport, error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin purge transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the user row so a concurrent purge of the same account waits
+	var pending bool
+	err = tx.QueryRow(
+		`SELECT is_active = FALSE AND purged_at IS NULL FROM users WHERE id = $1 FOR UPDATE`,
+		userID,
+	).Scan(&pending)
+	if err == sql.ErrNoRows || (err == nil && !pending) {
+		return nil, ErrUserNotPendingPurge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock user for purge: %w", err)
+	}
+
+	report := &models.AccountPurgeReport{UserID: userID}
+	for _, step := range accountPurgeSteps {
+		result, err := tx.Exec(step.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", step.table, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count purged %s rows: %w", step.table, err)
+		}
+		report.Tables = append(report.Tables, models.TablePurgeCount{Table: step.table, Action: step.action, Rows: rows})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit purge: %w", err)
+	}
+	report.PurgedAt = time.Now().UTC()
+	return report, nil
+}
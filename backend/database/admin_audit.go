@@ -0,0 +1,60 @@
+package database
+
+import (
+	"fmt"
+
+	"investorcenter-api/models"
+)
+
+// RecordAdminAudit stores an admin audit entry, filling in its ID and
+// creation time
+func RecordAdminAudit(entry *models.AdminAuditEntry) error {
+	query := `
+		INSERT INTO admin_audit_log (actor_user_id, actor_email, action, target, status_code, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err := DB.QueryRow(
+		query,
+		entry.ActorUserID,
+		entry.ActorEmail,
+		entry.Action,
+		entry.Target,
+		entry.StatusCode,
+		entry.IPAddress,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record admin audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetAdminAuditLog returns a page of admin audit entries, newest first,
+// along with the total number of entries. A non-empty actorUserID limits
+// the page to that admin's actions.
+func GetAdminAuditLog(actorUserID string, limit, offset int) ([]models.AdminAuditEntry, int, error) {
+	where := ""
+	args := []interface{}{}
+	if actorUserID != "" {
+		where = "WHERE actor_user_id = $1"
+		args = append(args, actorUserID)
+	}
+
+	var total int
+	if err := DB.Get(&total, `SELECT COUNT(*) FROM admin_audit_log `+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count admin audit log: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_user_id, actor_email, action, target, status_code, ip_address, created_at
+		FROM admin_audit_log
+		%s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	entries := []models.AdminAuditEntry{}
+	if err := DB.Select(&entries, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to get admin audit log: %w", err)
+	}
+	return entries, total, nil
+}
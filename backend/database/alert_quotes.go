@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"investorcenter-api/models"
+)
+
+// GetAlertQuote returns the market data alert conditions are evaluated
+// against for symbol, as of the latest daily bar: close, volume, change vs
+// the prior close, 30/90-session average volume before it, and trailing P/E
+// at the close. It matches the notification service's end-of-day snapshot
+// (notification-service/database/snapshots.go). Returns nil, nil when the
+// symbol has no daily bars.
+func GetAlertQuote(symbol string) (*models.SymbolQuote, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	// Volume averages cover the sessions before the latest bar, so a spike
+	// isn't diluted by itself. 140 calendar days comfortably holds 91 sessions.
+	var row struct {
+		Close     sql.NullFloat64 `db:"close"`
+		Volume    sql.NullInt64   `db:"volume"`
+		PrevClose sql.NullFloat64 `db:"prev_close"`
+		Avg30     sql.NullFloat64 `db:"avg_30d"`
+		Avg90     sql.NullFloat64 `db:"avg_90d"`
+	}
+	err := DB.Get(&row, `
+		WITH bars AS (
+			SELECT close, volume,
+			       ROW_NUMBER() OVER (ORDER BY time DESC) AS rn
+			FROM stock_prices
+			WHERE ticker = $1 AND interval = '1day' AND close IS NOT NULL
+			  AND time >= NOW() - INTERVAL '140 days'
+		)
+		SELECT MAX(close) FILTER (WHERE rn = 1)::float8 AS close,
+		       MAX(volume) FILTER (WHERE rn = 1) AS volume,
+		       MAX(close) FILTER (WHERE rn = 2)::float8 AS prev_close,
+		       AVG(volume) FILTER (WHERE rn BETWEEN 2 AND 31)::float8 AS avg_30d,
+		       AVG(volume) FILTER (WHERE rn BETWEEN 2 AND 91)::float8 AS avg_90d
+		FROM bars
+	`, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily bars: %w", err)
+	}
+	if !row.Close.Valid {
+		return nil, nil
+	}
+
+	quote := &models.SymbolQuote{
+		Price:        row.Close.Float64,
+		Volume:       row.Volume.Int64,
+		AvgVolume30d: row.Avg30.Float64,
+		AvgVolume90d: row.Avg90.Float64,
+	}
+	if row.PrevClose.Valid && row.PrevClose.Float64 > 0 {
+		quote.ChangePct = (row.Close.Float64 - row.PrevClose.Float64) / row.PrevClose.Float64 * 100
+	}
+
+	// valuation_ratios holds the P/E at the price on its calculation date;
+	// earnings don't move with price, so rescale it to the latest close.
+	var valuation struct {
+		StockPrice float64 `db:"stock_price"`
+		PERatio    float64 `db:"ttm_pe_ratio"`
+	}
+	err = DB.Get(&valuation, `
+		SELECT stock_price::float8 AS stock_price, ttm_pe_ratio::float8 AS ttm_pe_ratio
+		FROM valuation_ratios
+		WHERE ticker = $1 AND ttm_pe_ratio > 0 AND stock_price > 0
+		ORDER BY calculation_date DESC
+		LIMIT 1
+	`, symbol)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, fmt.Errorf("failed to get valuation ratios: %w", err)
+	default:
+		quote.PERatio = valuation.PERatio * quote.Price / valuation.StockPrice
+	}
+
+	return quote, nil
+}
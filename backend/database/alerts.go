@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"investorcenter-api/models"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -12,6 +13,8 @@ import (
 // Sentinel errors for alert operations
 var (
 	ErrAlertAlreadyExists = errors.New("alert already exists for this ticker in this watchlist")
+	ErrAlertRuleNotFound  = errors.New("alert rule not found")
+	ErrAlertLogNotFound   = errors.New("alert log not found")
 )
 
 // Alert Rule Operations
@@ -59,7 +62,8 @@ func GetAlertRuleByID(alertID string, userID string) (*models.AlertRule, error)
 		SELECT
 			id, user_id, watch_list_id, watch_list_item_id, symbol, alert_type,
 			conditions, is_active, frequency, notify_email, notify_in_app,
-			name, description, last_triggered_at, trigger_count, created_at, updated_at
+			name, description, last_triggered_at, trigger_count, created_at, updated_at,
+			snoozed_until
 		FROM alert_rules
 		WHERE id = $1 AND user_id = $2
 	`
@@ -82,10 +86,11 @@ func GetAlertRuleByID(alertID string, userID string) (*models.AlertRule, error)
 		&alert.TriggerCount,
 		&alert.CreatedAt,
 		&alert.UpdatedAt,
+		&alert.SnoozedUntil,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("alert rule not found")
+		return nil, ErrAlertRuleNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get alert rule: %w", err)
@@ -101,7 +106,7 @@ func GetAlertRulesByUserID(userID string, watchListID string, isActive string) (
 			ar.id, ar.user_id, ar.watch_list_id, ar.watch_list_item_id, ar.symbol,
 			ar.alert_type, ar.conditions, ar.is_active, ar.frequency, ar.notify_email,
 			ar.notify_in_app, ar.name, ar.description, ar.last_triggered_at,
-			ar.trigger_count, ar.created_at, ar.updated_at,
+			ar.trigger_count, ar.created_at, ar.updated_at, ar.snoozed_until,
 			wl.name as watch_list_name,
 			COALESCE(t.name, '') as company_name
 		FROM alert_rules ar
@@ -153,6 +158,7 @@ func GetAlertRulesByUserID(userID string, watchListID string, isActive string) (
 			&alert.TriggerCount,
 			&alert.CreatedAt,
 			&alert.UpdatedAt,
+			&alert.SnoozedUntil,
 			&alert.WatchListName,
 			&alert.CompanyName,
 		)
@@ -171,7 +177,8 @@ func GetActiveAlertRules() ([]models.AlertRule, error) {
 		SELECT
 			id, user_id, watch_list_id, watch_list_item_id, symbol, alert_type,
 			conditions, is_active, frequency, notify_email, notify_in_app,
-			name, description, last_triggered_at, trigger_count, created_at, updated_at
+			name, description, last_triggered_at, trigger_count, created_at, updated_at,
+			snoozed_until
 		FROM alert_rules
 		WHERE is_active = true
 		ORDER BY created_at ASC
@@ -203,6 +210,7 @@ func GetActiveAlertRules() ([]models.AlertRule, error) {
 			&alert.TriggerCount,
 			&alert.CreatedAt,
 			&alert.UpdatedAt,
+			&alert.SnoozedUntil,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
@@ -251,7 +259,7 @@ func UpdateAlertRule(alertID string, userID string, updates map[string]interface
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("alert rule not found")
+		return ErrAlertRuleNotFound
 	}
 
 	return nil
@@ -271,7 +279,62 @@ func DeleteAlertRule(alertID string, userID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("alert rule not found")
+		return ErrAlertRuleNotFound
+	}
+
+	return nil
+}
+
+// SnoozeAlertRule mutes an alert rule until the given time
+func SnoozeAlertRule(alertID string, userID string, until time.Time) error {
+	query := `
+		UPDATE alert_rules
+		SET snoozed_until = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2
+	`
+	result, err := DB.Exec(query, alertID, userID, until)
+	if err != nil {
+		return fmt.Errorf("failed to snooze alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAlertRuleNotFound
+	}
+
+	return nil
+}
+
+// UnsnoozeAlertRule ends an alert rule's snooze now. snoozed_until is set to
+// the current time rather than cleared, so the notification service can tell
+// which events happened while the rule was muted. Rules that aren't snoozed
+// are left as they are.
+func UnsnoozeAlertRule(alertID string, userID string) error {
+	query := `
+		UPDATE alert_rules
+		SET snoozed_until = CASE
+		        WHEN snoozed_until > CURRENT_TIMESTAMP THEN CURRENT_TIMESTAMP
+		        ELSE snoozed_until
+		    END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2
+	`
+	result, err := DB.Exec(query, alertID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unsnooze alert rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAlertRuleNotFound
 	}
 
 	return nil
@@ -301,7 +364,8 @@ func GetAlertForWatchListItems(watchListID string, userID string) (map[string]*m
 		SELECT
 			id, user_id, watch_list_id, watch_list_item_id, symbol, alert_type,
 			conditions, is_active, frequency, notify_email, notify_in_app,
-			name, description, last_triggered_at, trigger_count, created_at, updated_at
+			name, description, last_triggered_at, trigger_count, created_at, updated_at,
+			snoozed_until
 		FROM alert_rules
 		WHERE watch_list_id = $1 AND user_id = $2
 		ORDER BY created_at DESC
@@ -333,6 +397,7 @@ func GetAlertForWatchListItems(watchListID string, userID string) (map[string]*m
 			&alert.TriggerCount,
 			&alert.CreatedAt,
 			&alert.UpdatedAt,
+			&alert.SnoozedUntil,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
@@ -369,6 +434,42 @@ func AlertExistsForSymbol(watchListID, symbol string) (bool, error) {
 	return exists, nil
 }
 
+// CountAlertRulesForSymbol counts a user's alert rules on symbol across all
+// of their watch lists, active or not
+func CountAlertRulesForSymbol(userID, symbol string) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM alert_rules WHERE user_id = $1 AND symbol = $2"
+	err := DB.QueryRow(query, userID, symbol).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count alert rules for symbol: %w", err)
+	}
+	return count, nil
+}
+
+// CountAlertRulesBySymbol counts a user's alert rules per symbol, so bulk
+// creation can apply the per-symbol cap without a query per ticker
+func CountAlertRulesBySymbol(userID string) (map[string]int, error) {
+	rows, err := DB.Query("SELECT symbol, COUNT(*) FROM alert_rules WHERE user_id = $1 GROUP BY symbol", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count alert rules by symbol: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var symbol string
+		var count int
+		if err := rows.Scan(&symbol, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule count: %w", err)
+		}
+		counts[symbol] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert rule counts: %w", err)
+	}
+	return counts, nil
+}
+
 // CreateAlertRuleIfNotExists inserts an alert rule only if no active alert
 // exists for the same (watch_list_id, symbol). Uses INSERT ... ON CONFLICT
 // to atomically handle duplicates in a single round-trip, eliminating the
@@ -548,7 +649,7 @@ func MarkAlertLogAsRead(logID string, userID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("alert log not found")
+		return ErrAlertLogNotFound
 	}
 
 	return nil
@@ -572,7 +673,7 @@ func MarkAlertLogAsDismissed(logID string, userID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("alert log not found")
+		return ErrAlertLogNotFound
 	}
 
 	return nil
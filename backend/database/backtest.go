@@ -134,22 +134,29 @@ func FailBacktestJob(id string, errMsg string) error {
 	return nil
 }
 
-// GetUserBacktestJobs retrieves all backtest jobs for a user
+// GetUserBacktestJobs retrieves a user's backtest jobs, newest first. A
+// limit of 0 returns all of them.
 func GetUserBacktestJobs(userID string, limit int) ([]models.BacktestJob, error) {
 	query := `
 		SELECT id, user_id, config, status, error, result, started_at, completed_at, created_at, updated_at
 		FROM backtest_jobs
 		WHERE user_id = $1
 		ORDER BY created_at DESC
-		LIMIT $2
 	`
-	rows, err := DB.Query(query, userID, limit)
+	args := []interface{}{userID}
+
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user backtest jobs: %w", err)
 	}
 	defer rows.Close()
 
-	var jobs []models.BacktestJob
+	jobs := []models.BacktestJob{}
 	for rows.Next() {
 		var job models.BacktestJob
 		err := rows.Scan(
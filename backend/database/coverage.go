@@ -0,0 +1,143 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"investorcenter-api/models"
+	"strings"
+)
+
+// coverageDomain describes where a data domain keeps its per-ticker data and
+// how old the newest row may be before the ticker counts as stale
+type coverageDomain struct {
+	name       string
+	maxAgeDays int
+	// latest selects (ticker, latest_at) with one row per ticker
+	latest string
+}
+
+// coverageDomains lists every domain reported by GetDataCoverage, in response
+// order. Ages follow each pipeline's schedule: prices and IC scores run
+// daily, financials quarterly, analyst ratings whenever firms publish.
+var coverageDomains = []coverageDomain{
+	{
+		name:       "prices",
+		maxAgeDays: 5,
+		latest: `SELECT ticker, MAX(time) FROM stock_prices
+			WHERE interval = '1day' GROUP BY ticker`,
+	},
+	{
+		name:       "financials",
+		maxAgeDays: 120,
+		latest: `SELECT tk.symbol, MAX(COALESCE(fs.filed_date, fs.period_end))::TIMESTAMPTZ
+			FROM financial_statements fs JOIN tickers tk ON tk.id = fs.ticker_id
+			GROUP BY tk.symbol`,
+	},
+	{
+		name:       "fundamentals",
+		maxAgeDays: 7,
+		latest: `SELECT ticker, MAX(calculation_date)::TIMESTAMPTZ FROM fundamental_metrics_extended
+			GROUP BY ticker`,
+	},
+	{
+		name:       "ic_scores",
+		maxAgeDays: 3,
+		latest:     `SELECT ticker, MAX(date)::TIMESTAMPTZ FROM ic_scores GROUP BY ticker`,
+	},
+	{
+		name:       "sentiment",
+		maxAgeDays: 2,
+		latest: `SELECT ticker, MAX(snapshot_time) FROM ticker_sentiment_snapshots
+			GROUP BY ticker`,
+	},
+	{
+		name:       "analyst",
+		maxAgeDays: 180,
+		latest:     `SELECT ticker, MAX(rating_date)::TIMESTAMPTZ FROM analyst_ratings GROUP BY ticker`,
+	},
+}
+
+// GetDataCoverage reports, for each data domain, how many active stocks have
+// recent data, how many are stale or missing, and which stale ticker is the
+// furthest behind. All domains are computed in a single query.
+func GetDataCoverage() ([]models.DomainCoverage, error) {
+	latest := make([]string, len(coverageDomains))
+	windows := make([]string, len(coverageDomains))
+	args := make([]interface{}, 0, len(coverageDomains)*2)
+	for i, d := range coverageDomains {
+		latest[i] = fmt.Sprintf("SELECT '%s' AS domain, l.* FROM (%s) AS l(ticker, latest_at)", d.name, d.latest)
+		windows[i] = fmt.Sprintf("($%d::TEXT, $%d::INTEGER, %d)", len(args)+1, len(args)+2, i)
+		args = append(args, d.name, d.maxAgeDays)
+	}
+
+	query := `
+		WITH universe AS (
+			SELECT symbol FROM tickers
+			WHERE COALESCE(active, TRUE) AND asset_type IN ('CS', 'stock')
+		),
+		windows(domain, max_age_days, ord) AS (
+			VALUES ` + strings.Join(windows, ", ") + `
+		),
+		latest AS (
+			` + strings.Join(latest, "\n\t\t\tUNION ALL ") + `
+		),
+		per_ticker AS (
+			SELECT w.domain, w.max_age_days, w.ord, u.symbol, l.latest_at,
+				l.latest_at >= NOW() - w.max_age_days * INTERVAL '1 day' AS fresh
+			FROM universe u
+			CROSS JOIN windows w
+			LEFT JOIN latest l ON l.domain = w.domain AND l.ticker = u.symbol
+		)
+		SELECT
+			domain,
+			max_age_days,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE fresh) AS fresh,
+			COUNT(*) FILTER (WHERE NOT fresh) AS stale,
+			COUNT(*) FILTER (WHERE latest_at IS NULL) AS missing,
+			(ARRAY_AGG(symbol ORDER BY latest_at, symbol) FILTER (WHERE NOT fresh))[1] AS oldest_stale_ticker,
+			MIN(latest_at) FILTER (WHERE NOT fresh) AS oldest_stale_at
+		FROM per_ticker
+		GROUP BY domain, max_age_days, ord
+		ORDER BY ord
+	`
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data coverage: %w", err)
+	}
+	defer rows.Close()
+
+	coverage := make([]models.DomainCoverage, 0, len(coverageDomains))
+	for rows.Next() {
+		var c models.DomainCoverage
+		var oldestTicker sql.NullString
+		var oldestAt sql.NullTime
+		if err := rows.Scan(&c.Domain, &c.MaxAgeDays, &c.TotalTickers, &c.FreshTickers,
+			&c.StaleTickers, &c.MissingTickers, &oldestTicker, &oldestAt); err != nil {
+			return nil, fmt.Errorf("failed to scan data coverage: %w", err)
+		}
+		if oldestTicker.Valid {
+			c.OldestStaleTicker = &oldestTicker.String
+		}
+		if oldestAt.Valid {
+			t := oldestAt.Time.UTC()
+			c.OldestStaleAt = &t
+		}
+		if c.TotalTickers > 0 {
+			c.CoveragePct = float64(c.FreshTickers) / float64(c.TotalTickers) * 100
+		}
+		coverage = append(coverage, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating data coverage: %w", err)
+	}
+
+	// With no active stocks the join yields no rows; still report every domain
+	if len(coverage) == 0 {
+		for _, d := range coverageDomains {
+			coverage = append(coverage, models.DomainCoverage{Domain: d.name, MaxAgeDays: d.maxAgeDays})
+		}
+	}
+	return coverage, nil
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+
+	"investorcenter-shared/tracing"
 )
 
 // DB holds the database connection
@@ -44,6 +47,17 @@ func getEnvWithDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvFloatWithDefault gets a float environment variable with fallback default
+func getEnvFloatWithDefault(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		log.Printf("Invalid %s=%q, using default %v", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
 // Connect establishes database connection
 func Connect() (*sqlx.DB, error) {
 	config := LoadConfigFromEnv()
@@ -54,11 +68,13 @@ func Connect() (*sqlx.DB, error) {
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode,
 	)
 
-	// Open database connection
-	db, err := sqlx.Connect("postgres", connStr)
+	// Open database connection, timing queries for the slow-query log and
+	// tracing them
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	db := sqlx.NewDb(tracing.OpenDB(newTimedConnector(connector, LoadSlowQueryThreshold())), "postgres")
 
 	// Configure connection pool
 	db.SetMaxOpenConns(25)                 // Maximum number of open connections
@@ -67,6 +83,7 @@ func Connect() (*sqlx.DB, error) {
 
 	// Test connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -89,6 +106,7 @@ func Initialize() error {
 
 // Close closes the database connection
 func Close() error {
+	preparedStmts.reset()
 	if DB != nil {
 		return DB.Close()
 	}
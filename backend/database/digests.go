@@ -0,0 +1,225 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+// GetDigestRecipients returns active users with email on and a daily or
+// weekly digest enabled. The digest goes to the preferences' email address
+// once it is verified, otherwise to the account email.
+func GetDigestRecipients() ([]models.DigestRecipient, error) {
+	query := `
+		SELECT
+			np.id, np.user_id, np.email_enabled, np.email_address, np.email_verified,
+			np.price_alerts_enabled, np.volume_alerts_enabled, np.news_alerts_enabled,
+			np.earnings_alerts_enabled, np.sec_filing_alerts_enabled,
+			np.daily_digest_enabled, np.daily_digest_time, np.weekly_digest_enabled,
+			np.weekly_digest_day, np.weekly_digest_time,
+			np.digest_include_portfolio_summary, np.digest_include_top_movers,
+			np.digest_include_recent_alerts, np.digest_include_news_highlights,
+			np.quiet_hours_enabled, np.quiet_hours_start, np.quiet_hours_end,
+			np.quiet_hours_timezone, np.max_alerts_per_day, np.max_emails_per_day,
+			np.created_at, np.updated_at,
+			CASE WHEN np.email_verified AND COALESCE(np.email_address, '') <> ''
+			     THEN np.email_address ELSE u.email END AS recipient_email,
+			COALESCE(u.full_name, '') AS full_name,
+			COALESCE(u.timezone, 'UTC') AS user_timezone
+		FROM notification_preferences np
+		JOIN users u ON u.id = np.user_id
+		WHERE u.is_active = TRUE
+		  AND np.email_enabled = TRUE
+		  AND (np.daily_digest_enabled = TRUE OR np.weekly_digest_enabled = TRUE)
+		ORDER BY np.user_id
+	`
+	recipients := []models.DigestRecipient{}
+	if err := DB.Select(&recipients, query); err != nil {
+		return nil, fmt.Errorf("failed to get digest recipients: %w", err)
+	}
+	return recipients, nil
+}
+
+// ClaimDigestPeriod records that a digest for the log's user, type and
+// period is being sent. It returns false, leaving log untouched, when the
+// period was already claimed, so concurrent or repeated runs send at most
+// one digest per period.
+func ClaimDigestPeriod(log *models.DigestLog) (bool, error) {
+	query := `
+		INSERT INTO digest_logs (user_id, digest_type, period_start, period_end, email_sent)
+		VALUES ($1, $2, $3, $4, false)
+		ON CONFLICT (user_id, digest_type, period_start) DO NOTHING
+		RETURNING id, sent_at
+	`
+	err := DB.QueryRow(query, log.UserID, log.DigestType, log.PeriodStart, log.PeriodEnd).
+		Scan(&log.ID, &log.SentAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest period: %w", err)
+	}
+	return true, nil
+}
+
+// MarkDigestSent records a claimed digest as delivered, with the content
+// that went out
+func MarkDigestSent(logID string, snapshot json.RawMessage) error {
+	query := `
+		UPDATE digest_logs
+		SET email_sent = true, sent_at = CURRENT_TIMESTAMP, content_snapshot = $2
+		WHERE id = $1
+	`
+	if _, err := DB.Exec(query, logID, snapshot); err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+	return nil
+}
+
+// ReleaseDigestClaim drops a claim whose digest was never delivered so a
+// later run can retry the period. Delivered digests are never released.
+func ReleaseDigestClaim(logID string) error {
+	query := `DELETE FROM digest_logs WHERE id = $1 AND email_sent = false`
+	if _, err := DB.Exec(query, logID); err != nil {
+		return fmt.Errorf("failed to release digest claim: %w", err)
+	}
+	return nil
+}
+
+// GetDigestPortfolioSummary totals a user's holdings across all their
+// portfolios at the latest daily close, with the change since the prior
+// close and since five sessions earlier. Changes only count holdings priced
+// at both closes. Returns nil, nil when no holding has a price.
+func GetDigestPortfolioSummary(userID string) (*models.PortfolioSummary, error) {
+	// 21 calendar days comfortably holds the 6 sessions the week change needs.
+	query := `
+		WITH bars AS (
+			SELECT ph.id, ph.shares, sp.close,
+			       ROW_NUMBER() OVER (PARTITION BY ph.id ORDER BY sp.time DESC) AS rn
+			FROM portfolio_holdings ph
+			JOIN portfolios p ON p.id = ph.portfolio_id
+			JOIN stock_prices sp ON sp.ticker = ph.symbol
+				AND sp.interval = '1day'
+				AND sp.close IS NOT NULL
+				AND sp.time >= NOW() - INTERVAL '21 days'
+			WHERE p.user_id = $1
+		), holdings AS (
+			SELECT shares,
+			       MAX(close) FILTER (WHERE rn = 1) AS last_close,
+			       MAX(close) FILTER (WHERE rn = 2) AS day_close,
+			       MAX(close) FILTER (WHERE rn = 6) AS week_close
+			FROM bars
+			GROUP BY id, shares
+		)
+		SELECT
+			COUNT(last_close) AS priced,
+			COALESCE(SUM(shares * last_close), 0)::float8 AS total_value,
+			COALESCE(SUM(shares * (last_close - day_close)), 0)::float8 AS day_change,
+			COALESCE(SUM(shares * day_close), 0)::float8 AS day_base,
+			COALESCE(SUM(shares * (last_close - week_close)), 0)::float8 AS week_change,
+			COALESCE(SUM(shares * week_close), 0)::float8 AS week_base
+		FROM holdings
+	`
+	var row struct {
+		Priced     int     `db:"priced"`
+		TotalValue float64 `db:"total_value"`
+		DayChange  float64 `db:"day_change"`
+		DayBase    float64 `db:"day_base"`
+		WeekChange float64 `db:"week_change"`
+		WeekBase   float64 `db:"week_base"`
+	}
+	if err := DB.Get(&row, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get portfolio summary: %w", err)
+	}
+	if row.Priced == 0 {
+		return nil, nil
+	}
+
+	summary := &models.PortfolioSummary{
+		TotalValue: row.TotalValue,
+		DayChange:  row.DayChange,
+		WeekChange: row.WeekChange,
+	}
+	if row.DayBase > 0 {
+		summary.DayChangePct = row.DayChange / row.DayBase * 100
+	}
+	if row.WeekBase > 0 {
+		summary.WeekChangePct = row.WeekChange / row.WeekBase * 100
+	}
+	return summary, nil
+}
+
+// GetDigestTopMovers returns the symbols on a user's watch lists and in
+// their portfolios with the largest moves, either way, between the last two
+// daily closes
+func GetDigestTopMovers(userID string, limit int) ([]models.TopMover, error) {
+	query := `
+		WITH symbols AS (
+			SELECT wli.symbol
+			FROM watch_list_items wli
+			JOIN watch_lists wl ON wl.id = wli.watch_list_id
+			WHERE wl.user_id = $1
+			UNION
+			SELECT ph.symbol
+			FROM portfolio_holdings ph
+			JOIN portfolios p ON p.id = ph.portfolio_id
+			WHERE p.user_id = $1
+		), bars AS (
+			SELECT s.symbol, sp.close,
+			       ROW_NUMBER() OVER (PARTITION BY s.symbol ORDER BY sp.time DESC) AS rn
+			FROM symbols s
+			JOIN stock_prices sp ON sp.ticker = s.symbol
+				AND sp.interval = '1day'
+				AND sp.close IS NOT NULL
+				AND sp.time >= NOW() - INTERVAL '14 days'
+		), moves AS (
+			SELECT symbol,
+			       MAX(close) FILTER (WHERE rn = 1) AS last_close,
+			       MAX(close) FILTER (WHERE rn = 2) AS prev_close
+			FROM bars
+			GROUP BY symbol
+		)
+		SELECT
+			m.symbol,
+			COALESCE(t.name, '') AS name,
+			m.last_close::float8 AS price,
+			((m.last_close - m.prev_close) / m.prev_close * 100)::float8 AS change_pct
+		FROM moves m
+		LEFT JOIN LATERAL (
+			SELECT name FROM tickers WHERE symbol = m.symbol LIMIT 1
+		) t ON true
+		WHERE m.last_close IS NOT NULL AND m.prev_close > 0
+		ORDER BY ABS(m.last_close - m.prev_close) / m.prev_close DESC, m.symbol
+		LIMIT $2
+	`
+	movers := []models.TopMover{}
+	if err := DB.Select(&movers, query, userID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get top movers: %w", err)
+	}
+	for i := range movers {
+		movers[i].Direction = "up"
+		if movers[i].ChangePct < 0 {
+			movers[i].Direction = "down"
+		}
+	}
+	return movers, nil
+}
+
+// GetDigestRecentAlerts returns a user's alerts triggered since since,
+// newest first
+func GetDigestRecentAlerts(userID string, since time.Time, limit int) ([]models.AlertLogWithRule, error) {
+	logs, err := GetAlertLogsByUserID(userID, "", "", limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	recent := logs[:0]
+	for _, l := range logs {
+		if !l.TriggeredAt.Before(since) {
+			recent = append(recent, l)
+		}
+	}
+	return recent, nil
+}
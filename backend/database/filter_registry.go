@@ -90,6 +90,51 @@ var RangeFilters = []RangeFilterDef{
 	{Column: "technical_score", GetMin: func(p *models.ScreenerParams) *float64 { return p.TechnicalScoreMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.TechnicalScoreMax }},
 }
 
+// PercentileFilterDef maps a screener_data column to the metric whose
+// per-sector distribution it is compared against in
+// mv_latest_sector_percentiles. GetMin/GetMax return percentiles (0-100).
+type PercentileFilterDef struct {
+	Column string                                  // SQL column in screener_data
+	Metric string                                  // metric_name in mv_latest_sector_percentiles
+	GetMin func(p *models.ScreenerParams) *float64 // Returns nil when no min bound is set
+	GetMax func(p *models.ScreenerParams) *float64 // Returns nil when no max bound is set
+}
+
+// PercentileFilters is the registry of sector-relative percentile filters.
+//
+// Only metrics whose sector distribution is computed from the same source
+// as the screener_data column are listed. ROE, margins and balance-sheet
+// ratios come from financials in the percentile pipeline but from
+// fundamental_metrics_extended in screener_data, so comparing them would
+// mix two definitions.
+var PercentileFilters = []PercentileFilterDef{
+	{Column: "pe_ratio", Metric: "pe_ratio", GetMin: func(p *models.ScreenerParams) *float64 { return p.PEPercentileMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.PEPercentileMax }},
+	{Column: "pb_ratio", Metric: "pb_ratio", GetMin: func(p *models.ScreenerParams) *float64 { return p.PBPercentileMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.PBPercentileMax }},
+	{Column: "ps_ratio", Metric: "ps_ratio", GetMin: func(p *models.ScreenerParams) *float64 { return p.PSPercentileMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.PSPercentileMax }},
+	{Column: "revenue_growth", Metric: "revenue_growth_yoy", GetMin: func(p *models.ScreenerParams) *float64 { return p.RevenueGrowthPercentileMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.RevenueGrowthPercentileMax }},
+	{Column: "eps_growth_yoy", Metric: "eps_growth_yoy", GetMin: func(p *models.ScreenerParams) *float64 { return p.EPSGrowthPercentileMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.EPSGrowthPercentileMax }},
+	{Column: "dividend_yield", Metric: "dividend_yield", GetMin: func(p *models.ScreenerParams) *float64 { return p.DividendYieldPercentileMin }, GetMax: func(p *models.ScreenerParams) *float64 { return p.DividendYieldPercentileMax }},
+}
+
+// sectorPercentileColumns maps the percentiles stored per sector to their
+// mv_latest_sector_percentiles columns
+var sectorPercentileColumns = map[float64]string{
+	0:   "min_value",
+	10:  "p10_value",
+	25:  "p25_value",
+	50:  "p50_value",
+	75:  "p75_value",
+	90:  "p90_value",
+	100: "max_value",
+}
+
+// IsSupportedSectorPercentile reports whether pct is one of the stored
+// breakpoints (0, 10, 25, 50, 75, 90, 100) a percentile filter can use
+func IsSupportedSectorPercentile(pct float64) bool {
+	_, ok := sectorPercentileColumns[pct]
+	return ok
+}
+
 // BuildFilterConditions converts ScreenerParams into parameterized SQL
 // WHERE conditions by walking the RangeFilters registry.
 //
@@ -149,5 +194,44 @@ func BuildFilterConditions(params *models.ScreenerParams, startIndex int) ([]str
 		}
 	}
 
+	// Sector-relative percentile filters. Each bound compares the row's
+	// value against its own sector's breakpoint via a correlated lookup, so
+	// a row whose sector is unknown or has no distribution yields NULL and
+	// is excluded rather than compared against another sector's numbers.
+	if params.SectorRelative {
+		percentileApplied := false
+		for _, f := range PercentileFilters {
+			minVal := f.GetMin(params)
+			maxVal := f.GetMax(params)
+
+			if minVal != nil && maxVal != nil && *minVal > *maxVal {
+				minVal, maxVal = maxVal, minVal
+			}
+
+			for _, bound := range []struct {
+				val *float64
+				op  string
+			}{{minVal, ">="}, {maxVal, "<="}} {
+				if bound.val == nil {
+					continue
+				}
+				column, ok := sectorPercentileColumns[*bound.val]
+				if !ok {
+					continue
+				}
+				conditions = append(conditions, fmt.Sprintf(
+					"%s %s (SELECT sp.%s FROM mv_latest_sector_percentiles sp WHERE sp.sector = screener_data.sector AND sp.metric_name = $%d)",
+					f.Column, bound.op, column, argIndex))
+				args = append(args, f.Metric)
+				argIndex++
+				percentileApplied = true
+			}
+		}
+		if percentileApplied {
+			// screener_data coalesces a missing sector to ''
+			conditions = append(conditions, "sector <> ''")
+		}
+	}
+
 	return conditions, args, argIndex
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"investorcenter-api/models"
 )
@@ -307,3 +308,58 @@ func TestFilterConditionsNoSQLInjectionInColumnNames(t *testing.T) {
 			"unexpected condition format: %q", cond)
 	}
 }
+
+// ─── Sector-relative percentile filters ─────────────────────────
+
+func TestFilterPercentileConditions(t *testing.T) {
+	max := 25.0
+	min := 75.0
+	params := &models.ScreenerParams{
+		SectorRelative:             true,
+		PEPercentileMax:            &max,
+		RevenueGrowthPercentileMin: &min,
+	}
+
+	conditions, args, next := BuildFilterConditions(params, 1)
+
+	assert.Equal(t, []string{
+		"pe_ratio <= (SELECT sp.p25_value FROM mv_latest_sector_percentiles sp WHERE sp.sector = screener_data.sector AND sp.metric_name = $1)",
+		"revenue_growth >= (SELECT sp.p75_value FROM mv_latest_sector_percentiles sp WHERE sp.sector = screener_data.sector AND sp.metric_name = $2)",
+		"sector <> ''",
+	}, conditions)
+	assert.Equal(t, []interface{}{"pe_ratio", "revenue_growth_yoy"}, args)
+	assert.Equal(t, 3, next)
+}
+
+func TestFilterPercentileRequiresSectorRelative(t *testing.T) {
+	max := 25.0
+	conditions, args, _ := BuildFilterConditions(&models.ScreenerParams{PEPercentileMax: &max}, 1)
+	assert.Empty(t, conditions)
+	assert.Empty(t, args)
+}
+
+func TestFilterPercentileUnsupportedBreakpointIgnored(t *testing.T) {
+	v := 30.0
+	conditions, _, _ := BuildFilterConditions(&models.ScreenerParams{SectorRelative: true, PEPercentileMax: &v}, 1)
+	assert.Empty(t, conditions, "30 is not a stored percentile and must not add the sector guard alone")
+}
+
+func TestFilterPercentileSwapsMinMax(t *testing.T) {
+	min, max := 75.0, 25.0
+	conditions, _, _ := BuildFilterConditions(&models.ScreenerParams{SectorRelative: true, PSPercentileMin: &min, PSPercentileMax: &max}, 1)
+	require.Len(t, conditions, 3)
+	assert.Contains(t, conditions[0], "ps_ratio >= (SELECT sp.p25_value")
+	assert.Contains(t, conditions[1], "ps_ratio <= (SELECT sp.p75_value")
+}
+
+func TestPercentileFilterRegistry(t *testing.T) {
+	for _, f := range PercentileFilters {
+		t.Run(f.Column, func(t *testing.T) {
+			_, ok := ValidScreenerSortColumns[f.Column]
+			assert.True(t, ok, "column %q should be a screener_data column", f.Column)
+			assert.NotEmpty(t, f.Metric)
+			assert.Nil(t, f.GetMin(&models.ScreenerParams{}))
+			assert.Nil(t, f.GetMax(&models.ScreenerParams{}))
+		})
+	}
+}
@@ -22,7 +22,7 @@ func GetTickerIDBySymbol(symbol string) (int, error) {
 	err := DB.Get(&tickerID, query, symbol)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return 0, fmt.Errorf("ticker not found: %s", symbol)
+			return 0, fmt.Errorf("%w: %s", ErrTickerNotFound, symbol)
 		}
 		return 0, fmt.Errorf("failed to get ticker ID: %w", err)
 	}
@@ -397,6 +397,55 @@ func HasFinancialStatements(ticker string) (bool, error) {
 	return count > 0, nil
 }
 
+// GetFinancialsCoverage summarizes the financial data on file for a ticker:
+// each stored statement type and timeframe with its period range, count
+// and last refresh, plus the IC Score ratio history behind the ratios
+// endpoint. Combinations with nothing stored are left out. Returns an
+// error wrapping ErrTickerNotFound for unknown tickers.
+func GetFinancialsCoverage(ticker string) (*models.FinancialsCoverage, error) {
+	tickerID, err := GetTickerIDBySymbol(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := &models.FinancialsCoverage{
+		Ticker:     strings.ToUpper(ticker),
+		Statements: []models.StatementCoverage{},
+	}
+
+	query := `
+		SELECT statement_type, timeframe, COUNT(*) AS period_count,
+			MIN(period_end)::text AS earliest_period,
+			MAX(period_end)::text AS latest_period,
+			MAX(updated_at) AS last_refreshed_at
+		FROM financial_statements
+		WHERE ticker_id = $1
+		GROUP BY statement_type, timeframe
+		ORDER BY statement_type, timeframe
+	`
+	if err := DB.Select(&coverage.Statements, query, tickerID); err != nil {
+		return nil, fmt.Errorf("failed to get financial statement coverage: %w", err)
+	}
+
+	coverage.Ratios = models.StatementCoverage{
+		StatementType: models.StatementTypeRatios,
+		Timeframe:     models.TimeframeTTM,
+	}
+	ratiosQuery := `
+		SELECT COUNT(*) AS period_count,
+			MIN(calculation_date)::text AS earliest_period,
+			MAX(calculation_date)::text AS latest_period,
+			MAX(created_at) AS last_refreshed_at
+		FROM valuation_ratios
+		WHERE UPPER(ticker) = UPPER($1)
+	`
+	if err := DB.Get(&coverage.Ratios, ratiosQuery, ticker); err != nil {
+		return nil, fmt.Errorf("failed to get ratio coverage: %w", err)
+	}
+
+	return coverage, nil
+}
+
 // GetOldestFinancialStatementDate returns the date of the oldest financial statement for a ticker
 func GetOldestFinancialStatementDate(ticker string) (*time.Time, error) {
 	tickerID, err := GetTickerIDBySymbol(ticker)
@@ -625,3 +674,187 @@ func ConvertICScoreRatiosToFinancialPeriods(records []ICScoreRatioRecord) []mode
 
 	return periods
 }
+
+// ttmQuarterCount is how many consecutive quarters a TTM rollup sums
+const ttmQuarterCount = 4
+
+// TTMGapError is returned by GetTTMFromStatements when four consecutive
+// quarters of a flow statement aren't on file. Missing names the first
+// quarter needed that isn't, e.g. "Q4 FY2023"; it is empty when there are
+// no quarterly statements at all.
+type TTMGapError struct {
+	StatementType models.StatementType
+	Missing       string
+}
+
+func (e *TTMGapError) Error() string {
+	if e.Missing == "" {
+		return fmt.Sprintf("no quarterly %s statements on file", e.StatementType)
+	}
+	return fmt.Sprintf("quarterly %s statement for %s is missing; a TTM rollup needs %d consecutive quarters", e.StatementType, e.Missing, ttmQuarterCount)
+}
+
+// fiscalQuarterKey orders fiscal quarters: Q1 FY2024 follows Q4 FY2023
+type fiscalQuarterKey struct {
+	year, quarter int
+}
+
+func (k fiscalQuarterKey) previous() fiscalQuarterKey {
+	if k.quarter == 1 {
+		return fiscalQuarterKey{k.year - 1, 4}
+	}
+	return fiscalQuarterKey{k.year, k.quarter - 1}
+}
+
+func (k fiscalQuarterKey) String() string {
+	return fmt.Sprintf("Q%d FY%d", k.quarter, k.year)
+}
+
+// GetTTMFromStatements rolls up a trailing-twelve-month statement from the
+// stored quarterly filings. Income statement and cash flow items are summed
+// over the four quarters ending with the latest quarterly income statement,
+// and the latest quarterly balance sheet is taken as is. Returns a
+// *TTMGapError when either flow statement lacks one of those quarters.
+func GetTTMFromStatements(ticker string) (*models.TTMFinancials, error) {
+	tickerID, err := GetTickerIDBySymbol(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch two years so a gap can be reported by quarter even when later
+	// quarters are on file
+	income, err := getQuarterlyStatements(tickerID, models.StatementTypeIncome, 2*ttmQuarterCount)
+	if err != nil {
+		return nil, err
+	}
+	if len(income) == 0 || income[0].FiscalQuarter == nil {
+		return nil, &TTMGapError{StatementType: models.StatementTypeIncome}
+	}
+	cashFlow, err := getQuarterlyStatements(tickerID, models.StatementTypeCashFlow, 2*ttmQuarterCount)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := getQuarterlyStatements(tickerID, models.StatementTypeBalanceSheet, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := fiscalQuarterKey{income[0].FiscalYear, *income[0].FiscalQuarter}
+	incomeQuarters, err := ttmWindow(income, models.StatementTypeIncome, latest)
+	if err != nil {
+		return nil, err
+	}
+	cashFlowQuarters, err := ttmWindow(cashFlow, models.StatementTypeCashFlow, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	ttm := &models.TTMFinancials{
+		Ticker:          strings.ToUpper(ticker),
+		FiscalYear:      latest.year,
+		FiscalQuarter:   latest.quarter,
+		PeriodEnd:       incomeQuarters[0].PeriodEnd.Format("2006-01-02"),
+		IncomeStatement: sumTTMData(incomeQuarters),
+		CashFlow:        sumTTMData(cashFlowQuarters),
+	}
+	for _, q := range incomeQuarters {
+		ttm.Quarters = append(ttm.Quarters, models.TTMQuarter{
+			FiscalYear:    q.FiscalYear,
+			FiscalQuarter: *q.FiscalQuarter,
+			PeriodEnd:     q.PeriodEnd.Format("2006-01-02"),
+		})
+	}
+	if len(balance) > 0 {
+		ttm.BalanceSheet = balance[0].Data
+		date := balance[0].PeriodEnd.Format("2006-01-02")
+		ttm.BalanceSheetDate = &date
+	}
+	return ttm, nil
+}
+
+// getQuarterlyStatements returns a ticker's latest quarterly statements of
+// one type, newest first
+func getQuarterlyStatements(tickerID int, statementType models.StatementType, limit int) ([]models.FinancialStatement, error) {
+	query := `
+		SELECT
+			id, ticker_id, cik, statement_type, timeframe, fiscal_year, fiscal_quarter,
+			period_start, period_end, filed_date, source_filing_url, source_filing_type,
+			data, created_at, updated_at
+		FROM financial_statements
+		WHERE ticker_id = $1 AND statement_type = $2 AND timeframe = $3 AND fiscal_quarter IS NOT NULL
+		ORDER BY fiscal_year DESC, fiscal_quarter DESC
+		LIMIT $4
+	`
+	var statements []models.FinancialStatement
+	if err := DB.Select(&statements, query, tickerID, statementType, models.TimeframeQuarterly, limit); err != nil {
+		return nil, fmt.Errorf("failed to get quarterly %s statements: %w", statementType, err)
+	}
+	return statements, nil
+}
+
+// ttmWindow picks the four quarters ending with latest out of statements,
+// newest first, or reports the first one missing
+func ttmWindow(statements []models.FinancialStatement, statementType models.StatementType, latest fiscalQuarterKey) ([]models.FinancialStatement, error) {
+	byQuarter := make(map[fiscalQuarterKey]models.FinancialStatement, len(statements))
+	for _, s := range statements {
+		if s.FiscalQuarter != nil {
+			byQuarter[fiscalQuarterKey{s.FiscalYear, *s.FiscalQuarter}] = s
+		}
+	}
+
+	window := make([]models.FinancialStatement, 0, ttmQuarterCount)
+	for key := latest; len(window) < ttmQuarterCount; key = key.previous() {
+		s, ok := byQuarter[key]
+		if !ok {
+			return nil, &TTMGapError{StatementType: statementType, Missing: key.String()}
+		}
+		window = append(window, s)
+	}
+	return window, nil
+}
+
+// sumTTMData adds up each numeric line item over quarters (newest first).
+// Share counts take the latest quarter's value rather than a sum, margins
+// and ratios are dropped, and items missing from any quarter are left out
+// rather than summed over fewer quarters. Labels and units come from the
+// latest quarter.
+func sumTTMData(quarters []models.FinancialStatement) models.FinancialData {
+	ttm := make(models.FinancialData)
+	latest := quarters[0].Data
+	for key, raw := range latest {
+		if strings.HasSuffix(key, "_label") || strings.HasSuffix(key, "_unit") ||
+			strings.HasSuffix(key, "_margin") || strings.HasSuffix(key, "_ratio") {
+			continue
+		}
+		value, ok := toFloat64(raw)
+		if !ok {
+			continue
+		}
+
+		unit, _ := latest[key+"_unit"].(string)
+		if unit == "shares" || (strings.Contains(key, "shares") && !strings.Contains(key, "per_share")) {
+			ttm[key] = value
+		} else {
+			sum, complete := 0.0, true
+			for _, q := range quarters {
+				v, ok := toFloat64(q.Data[key])
+				if !ok {
+					complete = false
+					break
+				}
+				sum += v
+			}
+			if !complete {
+				continue
+			}
+			ttm[key] = sum
+		}
+
+		for _, suffix := range []string{"_label", "_unit"} {
+			if meta, ok := latest[key+suffix]; ok {
+				ttm[key+suffix] = meta
+			}
+		}
+	}
+	return ttm
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+
+	"investorcenter-api/models"
+)
+
+// UpsertFMPSnapshots stores the latest FMP response for each symbol under
+// endpoint. A nil value records that FMP has no data for the symbol.
+func UpsertFMPSnapshots(endpoint string, data map[string]json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(data))
+	for symbol := range data {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	payloads := make([]sql.NullString, len(symbols))
+	for i, symbol := range symbols {
+		if raw := data[symbol]; raw != nil {
+			payloads[i] = sql.NullString{String: string(raw), Valid: true}
+		}
+	}
+
+	_, err := DB.Exec(`
+		INSERT INTO fmp_snapshots (symbol, endpoint, data, refreshed_at)
+		SELECT s.symbol, $1, s.data::jsonb, NOW()
+		FROM unnest($2::text[], $3::text[]) AS s(symbol, data)
+		ON CONFLICT (symbol, endpoint) DO UPDATE SET
+			data = EXCLUDED.data,
+			refreshed_at = EXCLUDED.refreshed_at
+	`, endpoint, pq.Array(symbols), pq.Array(payloads))
+	if err != nil {
+		return fmt.Errorf("failed to store FMP %s snapshots: %w", endpoint, err)
+	}
+	return nil
+}
+
+// GetFMPSnapshot returns the stored FMP response for symbol and endpoint,
+// or nil if it has never been refreshed
+func GetFMPSnapshot(symbol, endpoint string) (*models.FMPSnapshot, error) {
+	var snapshot models.FMPSnapshot
+	err := DB.Get(&snapshot, `
+		SELECT symbol, endpoint, data, refreshed_at
+		FROM fmp_snapshots
+		WHERE symbol = $1 AND endpoint = $2
+	`, symbol, endpoint)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get FMP %s snapshot for %s: %w", endpoint, symbol, err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// domainLastWriteQueries maps each coverage domain to a query returning the
+// time its pipeline last wrote a row. Unlike coverageDomains these look at
+// write timestamps, so a pipeline that reran but found nothing new still
+// counts as alive only if it touched the table.
+var domainLastWriteQueries = map[string]string{
+	"prices":       `SELECT MAX(time) FROM stock_prices WHERE interval = '1day'`,
+	"financials":   `SELECT MAX(updated_at) FROM financial_statements`,
+	"fundamentals": `SELECT MAX(created_at)::TIMESTAMPTZ FROM fundamental_metrics_extended`,
+	"ic_scores":    `SELECT MAX(created_at)::TIMESTAMPTZ FROM ic_scores`,
+	"sentiment":    `SELECT MAX(snapshot_time) FROM ticker_sentiment_snapshots`,
+	"analyst":      `SELECT MAX(created_at)::TIMESTAMPTZ FROM analyst_ratings`,
+	"tickers":      `SELECT MAX(updated_at) FROM tickers`,
+}
+
+// GetDomainLastUpdated returns when a data domain's table was last written,
+// or nil if it has no rows
+func GetDomainLastUpdated(domain string) (*time.Time, error) {
+	query, ok := domainLastWriteQueries[domain]
+	if !ok {
+		return nil, fmt.Errorf("unknown data domain %q", domain)
+	}
+
+	var last sql.NullTime
+	if err := DB.QueryRow(query).Scan(&last); err != nil {
+		return nil, fmt.Errorf("failed to get last update for %s: %w", domain, err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	t := last.Time.UTC()
+	return &t, nil
+}
+
+// GetLastSuccessfulJobRun returns when a cronjob last completed successfully
+// according to cronjob_execution_logs, or nil if it never has
+func GetLastSuccessfulJobRun(jobName string) (*time.Time, error) {
+	var last sql.NullTime
+	err := DB.QueryRow(`
+		SELECT MAX(COALESCE(completed_at, started_at))
+		FROM cronjob_execution_logs
+		WHERE job_name = $1 AND status = 'success'
+	`, jobName).Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last run of %s: %w", jobName, err)
+	}
+	if !last.Valid {
+		return nil, nil
+	}
+	t := last.Time.UTC()
+	return &t, nil
+}
+
+// GetAdminUserIDs returns the IDs of active admin users
+func GetAdminUserIDs() ([]string, error) {
+	var ids []string
+	err := DB.Select(&ids, `SELECT id FROM users WHERE is_admin = TRUE AND is_active = TRUE ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin users: %w", err)
+	}
+	return ids, nil
+}
+
+// GetOpenFreshnessAlerts returns the keys of freshness breaches that have
+// already been notified and haven't cleared since
+func GetOpenFreshnessAlerts() (map[string]bool, error) {
+	var keys []string
+	if err := DB.Select(&keys, `SELECT alert_key FROM data_freshness_alerts`); err != nil {
+		return nil, fmt.Errorf("failed to get open freshness alerts: %w", err)
+	}
+	open := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		open[k] = true
+	}
+	return open, nil
+}
+
+// SetOpenFreshnessAlerts replaces the open freshness breaches with keys.
+// Keys already open keep their opened_at.
+func SetOpenFreshnessAlerts(keys []string) error {
+	if keys == nil {
+		keys = []string{} // a NULL array would match nothing in the DELETE
+	}
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin freshness alert update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM data_freshness_alerts WHERE NOT (alert_key = ANY($1))`, pq.Array(keys)); err != nil {
+		return fmt.Errorf("failed to clear resolved freshness alerts: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO data_freshness_alerts (alert_key)
+		SELECT unnest($1::text[])
+		ON CONFLICT (alert_key) DO NOTHING
+	`, pq.Array(keys)); err != nil {
+		return fmt.Errorf("failed to record freshness alerts: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit freshness alerts: %w", err)
+	}
+	return nil
+}
@@ -10,6 +10,10 @@ import (
 
 // Heatmap Config Operations
 
+// ErrHeatmapConfigNotFound is returned when a config doesn't exist or belongs
+// to another user
+var ErrHeatmapConfigNotFound = errors.New("heatmap config not found")
+
 // CreateHeatmapConfig creates a new heatmap configuration
 func CreateHeatmapConfig(config *models.HeatmapConfig) error {
 	// If setting as default, unset other defaults for this watch list
@@ -149,7 +153,7 @@ func GetHeatmapConfigByID(configID string, userID string) (*models.HeatmapConfig
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("heatmap config not found")
+		return nil, ErrHeatmapConfigNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get heatmap config: %w", err)
@@ -283,7 +287,7 @@ func UpdateHeatmapConfig(config *models.HeatmapConfig) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("heatmap config not found or unauthorized")
+		return ErrHeatmapConfigNotFound
 	}
 
 	return nil
@@ -299,7 +303,7 @@ func DeleteHeatmapConfig(configID string, userID string) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("heatmap config not found or unauthorized")
+		return ErrHeatmapConfigNotFound
 	}
 
 	return nil
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"investorcenter-api/models"
+
+	"github.com/lib/pq"
+)
+
+// Open-market transaction types. The SEC ingestion pipeline writes
+// Purchase/Sale (Form 4 codes P and S); older rows use Buy/Sell.
+var (
+	InsiderBuyTypes  = []string{"Purchase", "Buy"}
+	InsiderSellTypes = []string{"Sale", "Sell"}
+)
+
+// InsiderTradeFilter narrows GetInsiderTrades. Empty fields don't filter.
+type InsiderTradeFilter struct {
+	TransactionTypes []string
+	Since            *time.Time
+}
+
+// GetInsiderTrades returns a page of a ticker's insider transactions, newest
+// first, and the total number matching filter
+func GetInsiderTrades(ticker string, filter InsiderTradeFilter, limit, offset int) ([]models.InsiderTrade, int, error) {
+	if DB == nil {
+		return nil, 0, fmt.Errorf("database not initialized")
+	}
+
+	where := "WHERE ticker = $1"
+	args := []interface{}{strings.ToUpper(ticker)}
+	if len(filter.TransactionTypes) > 0 {
+		args = append(args, pq.Array(filter.TransactionTypes))
+		where += fmt.Sprintf(" AND transaction_type = ANY($%d)", len(args))
+	}
+	if filter.Since != nil {
+		args = append(args, filter.Since.Format("2006-01-02"))
+		where += fmt.Sprintf(" AND transaction_date >= $%d", len(args))
+	}
+
+	var total int
+	if err := DB.Get(&total, "SELECT COUNT(*) FROM insider_trades "+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count insider trades: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, ticker, filing_date, transaction_date, insider_name,
+		       COALESCE(insider_title, '') as insider_title,
+		       COALESCE(transaction_type, '') as transaction_type,
+		       shares, price_per_share::float8 as price_per_share,
+		       total_value, shares_owned_after,
+		       COALESCE(is_derivative, false) as is_derivative,
+		       COALESCE(form_type, '') as form_type,
+		       COALESCE(sec_filing_url, '') as sec_filing_url
+		FROM insider_trades
+		%s
+		ORDER BY transaction_date DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+
+	trades := []models.InsiderTrade{}
+	if err := DB.Select(&trades, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to get insider trades: %w", err)
+	}
+	return trades, total, nil
+}
+
+// GetInsiderActivity aggregates a ticker's non-derivative open-market buys
+// and sells since a date by insider title. Value falls back to shares times
+// price when a filing has no total.
+func GetInsiderActivity(ticker string, since time.Time) ([]models.InsiderActivityRow, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `
+		SELECT COALESCE(insider_title, '') as insider_title,
+		       CASE WHEN transaction_type = ANY($2) THEN 'buy' ELSE 'sell' END as direction,
+		       COUNT(*) as transactions,
+		       COALESCE(SUM(shares), 0) as shares,
+		       COALESCE(SUM(COALESCE(total_value, shares * price_per_share)), 0)::float8 as value
+		FROM insider_trades
+		WHERE ticker = $1
+		  AND transaction_date >= $4
+		  AND NOT COALESCE(is_derivative, false)
+		  AND (transaction_type = ANY($2) OR transaction_type = ANY($3))
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows := []models.InsiderActivityRow{}
+	if err := DB.Select(&rows, query, strings.ToUpper(ticker),
+		pq.Array(InsiderBuyTypes), pq.Array(InsiderSellTypes), since.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("failed to get insider activity: %w", err)
+	}
+	return rows, nil
+}
@@ -2,10 +2,12 @@ package database
 
 import (
 	"encoding/json"
+	"fmt"
 	"investorcenter-api/models"
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -59,28 +61,316 @@ func TestIntegration_SearchStocks(t *testing.T) {
 		('ARKK', 'ARK Innovation ETF', 'etf')`)
 
 	// Search by symbol prefix
-	results, err := SearchStocks("AAPL", 10)
+	results, err := SearchStocks("AAPL", 10, false)
 	require.NoError(t, err)
 	require.GreaterOrEqual(t, len(results), 1)
 	assert.Equal(t, "AAPL", results[0].Symbol, "Exact match should come first")
 
 	// Search by partial name
-	results2, err := SearchStocks("Microsoft", 10)
+	results2, err := SearchStocks("Microsoft", 10, false)
 	require.NoError(t, err)
 	assert.Len(t, results2, 1)
 	assert.Equal(t, "MSFT", results2[0].Symbol)
 
 	// Search with limit
-	results3, err := SearchStocks("A", 2)
+	results3, err := SearchStocks("A", 2, false)
 	require.NoError(t, err)
 	assert.LessOrEqual(t, len(results3), 2)
 
 	// No results
-	results4, err := SearchStocks("ZZZZZZ", 10)
+	results4, err := SearchStocks("ZZZZZZ", 10, false)
 	require.NoError(t, err)
 	assert.Empty(t, results4)
 }
 
+func TestIntegration_GetTickerMetadata(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, exchange, sector, industry, logo_url, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'NASDAQ', 'Technology', 'Consumer Electronics', 'https://logo/aapl.png', 'stock'),
+		('SPY', 'SPDR S&P 500 ETF', 'NYSE', NULL, NULL, NULL, 'etf'),
+		('MSFT', 'Microsoft Corporation', 'NASDAQ', 'Technology', 'Software', NULL, 'stock')`)
+
+	results, err := GetTickerMetadata([]string{"AAPL", "SPY", "NOPE"}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "one entry per found symbol, unknowns omitted")
+
+	bySymbol := map[string]models.TickerMetadata{}
+	for _, r := range results {
+		bySymbol[r.Symbol] = r
+	}
+	assert.Equal(t, "Apple Inc.", bySymbol["AAPL"].Name)
+	assert.Equal(t, "NASDAQ", bySymbol["AAPL"].Exchange)
+	assert.Equal(t, "Technology", bySymbol["AAPL"].Sector)
+	assert.Equal(t, "Consumer Electronics", bySymbol["AAPL"].Industry)
+	assert.Equal(t, "https://logo/aapl.png", bySymbol["AAPL"].LogoURL)
+	assert.Equal(t, "etf", bySymbol["SPY"].AssetType)
+	assert.Equal(t, "", bySymbol["SPY"].Sector)
+	assert.NotContains(t, bySymbol, "MSFT")
+
+	empty, err := GetTickerMetadata([]string{"NOPE"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestIntegration_GetTickerSummaries(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type, market_cap) VALUES
+		('AAPL', 'Apple Inc.', 'stock', 3000000000000),
+		('MSFT', 'Microsoft Corporation', 'stock', NULL)`)
+	DB.MustExec(`INSERT INTO stock_prices (time, ticker, close, interval) VALUES
+		(NOW() - INTERVAL '3 days', 'AAPL', 180.00, '1day'),
+		(NOW() - INTERVAL '2 days', 'AAPL', 200.00, '1day'),
+		(NOW() - INTERVAL '1 day', 'AAPL', 210.00, '1day'),
+		(NOW() - INTERVAL '1 hour', 'AAPL', 999.00, '1min')`)
+
+	results, err := GetTickerSummaries([]string{"AAPL", "MSFT", "NOPE"}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "AAPL", results[0].Symbol)
+	require.NotNil(t, results[0].Price)
+	assert.Equal(t, 210.0, *results[0].Price, "latest daily close, not intraday")
+	require.NotNil(t, results[0].Change)
+	assert.Equal(t, 10.0, *results[0].Change)
+	require.NotNil(t, results[0].ChangePercent)
+	assert.InDelta(t, 5.0, *results[0].ChangePercent, 0.0001)
+	require.NotNil(t, results[0].MarketCap)
+	assert.Equal(t, 3e12, *results[0].MarketCap)
+
+	assert.Equal(t, "MSFT", results[1].Symbol)
+	assert.Nil(t, results[1].Price)
+	assert.Nil(t, results[1].MarketCap)
+}
+
+func TestIntegration_InactiveTickersHidden(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type, active) VALUES
+		('AAPL', 'Apple Inc.', 'stock', true),
+		('AAPLW', 'Apple Warrants (delisted)', 'stock', false),
+		('MSFT', 'Microsoft Corporation', 'stock', true)`)
+
+	// Search
+	results, err := SearchStocks("AAPL", 10, false)
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.NotEqual(t, "AAPLW", r.Symbol, "inactive ticker excluded by default")
+	}
+	results, err = SearchStocks("AAPL", 10, true)
+	require.NoError(t, err)
+	symbols := []string{}
+	for _, r := range results {
+		symbols = append(symbols, r.Symbol)
+	}
+	assert.Contains(t, symbols, "AAPLW", "override includes inactive ticker")
+
+	// Suggest
+	suggestions, err := SearchSuggestions("AAPL", 10, false)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+	suggestions, err = SearchSuggestions("AAPL", 10, true)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 2)
+
+	// Batch metadata
+	metadata, err := GetTickerMetadata([]string{"AAPL", "AAPLW"}, false)
+	require.NoError(t, err)
+	require.Len(t, metadata, 1)
+	assert.Equal(t, "AAPL", metadata[0].Symbol)
+	metadata, err = GetTickerMetadata([]string{"AAPL", "AAPLW"}, true)
+	require.NoError(t, err)
+	assert.Len(t, metadata, 2)
+
+	// Popular list
+	DB.MustExec(`UPDATE tickers SET active = false WHERE symbol = 'MSFT'`)
+	popular, err := GetPopularStocks(10, false)
+	require.NoError(t, err)
+	require.Len(t, popular, 1)
+	assert.Equal(t, "AAPL", popular[0].Symbol)
+	popular, err = GetPopularStocks(10, true)
+	require.NoError(t, err)
+	assert.Len(t, popular, 2)
+}
+
+func TestIntegration_SearchStocksBoost(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	// Both are prefix matches for "BA"; alphabetically BAAA would come first
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type, market_cap) VALUES
+		('BAAA', 'Tiny Holdings', 'stock', 5000000),
+		('BAC', 'Bank of America Corp', 'stock', 300000000000),
+		('BAXX', 'Micro Corp', 'stock', NULL)`)
+
+	results, err := SearchStocks("BA", 10, false)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "BAC", results[0].Symbol, "high market cap should outrank low-cap prefix match")
+	assert.Equal(t, "BAAA", results[1].Symbol)
+	assert.Equal(t, "BAXX", results[2].Symbol)
+
+	// Social popularity boosts a ticker with no market cap
+	DB.MustExec(`INSERT INTO reddit_ticker_rankings
+		(ticker_symbol, rank, mentions, snapshot_date, snapshot_time)
+		VALUES ('BAXX', 1, 100000000, CURRENT_DATE, NOW())`)
+	orig := SearchBoost
+	t.Cleanup(func() { SearchBoost = orig })
+	SearchBoost = SearchBoostConfig{MarketCapWeight: 0, SocialWeight: 1}
+
+	results, err = SearchStocks("BA", 10, false)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, "BAXX", results[0].Symbol)
+
+	// Exact symbol match still wins over a bigger company
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type, market_cap) VALUES ('BA', 'Boeing Co', 'stock', 1000)`)
+	SearchBoost = orig
+	results, err = SearchStocks("BA", 10, false)
+	require.NoError(t, err)
+	assert.Equal(t, "BA", results[0].Symbol)
+}
+
+func TestIntegration_SearchSecurities(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type, market_cap, avg_volume_30d) VALUES
+		('A', 'Agilent Technologies', 'stock', 40000000000, 2000000),
+		('AAPL', 'Apple Inc.', 'stock', 3000000000000, 60000000),
+		('AAZZ', 'Obscure Holdings', 'stock', NULL, 100),
+		('AADR', 'AdvisorShares ETF', 'etf', NULL, 5000),
+		('KO', 'Coca-Cola Co', 'stock', 250000000000, 15000000),
+		('X:AVAXUSD', 'Avalanche - United States Dollar', 'crypto', NULL, NULL)`)
+
+	results, err := SearchSecurities("A", SearchOptions{Limit: 10})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(results), 4)
+	assert.Equal(t, "A", results[0].Symbol, "exact symbol match ranks first")
+	assert.Equal(t, "AAPL", results[1].Symbol, "popular prefix match outranks obscure ones")
+	assert.Equal(t, "AAZZ", results[2].Symbol)
+	for _, r := range results[:3] {
+		assert.Equal(t, "symbol", r.MatchReason, r.Symbol)
+	}
+
+	// "cola" only matches a name
+	results, err = SearchSecurities("cola", SearchOptions{Limit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "KO", results[0].Symbol)
+	assert.Equal(t, "name", results[0].MatchReason)
+
+	// Volume alone lifts a ticker with no market cap
+	orig := SearchBoost
+	t.Cleanup(func() { SearchBoost = orig })
+	SearchBoost = SearchBoostConfig{VolumeWeight: 1}
+	DB.MustExec(`UPDATE tickers SET avg_volume_30d = 900000000 WHERE symbol = 'AAZZ'`)
+	results, err = SearchSecurities("AA", SearchOptions{Types: []string{"stock"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "AAZZ", results[0].Symbol)
+	SearchBoost = orig
+
+	// The types filter limits results to the requested asset types
+	results, err = SearchSecurities("A", SearchOptions{Types: []string{"etf", "crypto"}, Limit: 10})
+	require.NoError(t, err)
+	symbols := make([]string, len(results))
+	for i, r := range results {
+		symbols[i] = r.Symbol
+	}
+	assert.ElementsMatch(t, []string{"AADR", "X:AVAXUSD"}, symbols)
+}
+
+func TestIntegration_SearchStocksFuzzy(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'stock'),
+		('MSFT', 'Microsoft Corporation', 'stock'),
+		('NVDA', 'NVIDIA Corporation', 'stock')`)
+
+	// Transposed letters: no substring match, trigram similarity still finds Apple
+	results, err := SearchStocks("Appel", 10, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "AAPL", results[0].Symbol)
+
+	results2, err := SearchStocks("Mircosoft", 10, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, results2)
+	assert.Equal(t, "MSFT", results2[0].Symbol)
+
+	// Dropped letter: MSFT within the top results
+	typo, err := SearchStocks("microsft", 10, false)
+	require.NoError(t, err)
+	var top []string
+	for _, r := range typo[:min(3, len(typo))] {
+		top = append(top, r.Symbol)
+	}
+	assert.Contains(t, top, "MSFT")
+
+	// A strict threshold or fuzzy=false drops the typo match
+	fuzzyOff := false
+	off, err := SearchSecurities("microsft", SearchOptions{Limit: 10, Fuzzy: &fuzzyOff})
+	require.NoError(t, err)
+	assert.Empty(t, off)
+	strict, err := SearchSecurities("microsft", SearchOptions{Limit: 10, MinSimilarity: 0.95})
+	require.NoError(t, err)
+	assert.Empty(t, strict)
+
+	// Exact symbol match still ranks first, ahead of fuzzy matches
+	results3, err := SearchStocks("NVDA", 10, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, results3)
+	assert.Equal(t, "NVDA", results3[0].Symbol)
+	for _, r := range results3[1:] {
+		assert.NotEqual(t, "NVDA", r.Symbol, "direct hits are not repeated by the fuzzy fallback")
+	}
+}
+
+func TestIntegration_SearchSuggestions(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, exchange, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'NASDAQ', 'stock'),
+		('AAL', 'American Airlines Group', 'NASDAQ', 'stock')`)
+	DB.MustExec(`INSERT INTO stock_prices (time, ticker, close, interval) VALUES
+		(NOW() - INTERVAL '3 days', 'AAPL', 180.00, '1day'),
+		(NOW() - INTERVAL '2 days', 'AAPL', 200.00, '1day'),
+		(NOW() - INTERVAL '1 day', 'AAPL', 210.00, '1day'),
+		(NOW() - INTERVAL '1 hour', 'AAPL', 999.00, '1min')`)
+
+	results, err := SearchSuggestions("AAPL", 5, false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "AAPL", results[0].Symbol)
+	assert.Equal(t, "NASDAQ", results[0].Exchange)
+	require.NotNil(t, results[0].Price)
+	assert.InDelta(t, 210.0, *results[0].Price, 0.001, "latest daily close, ignoring intraday bars")
+	require.NotNil(t, results[0].Change)
+	assert.InDelta(t, 10.0, *results[0].Change, 0.001)
+	require.NotNil(t, results[0].ChangePercent)
+	assert.InDelta(t, 5.0, *results[0].ChangePercent, 0.001)
+
+	// Ticker without price history still matches, with nil price fields
+	results2, err := SearchSuggestions("AA", 5, false)
+	require.NoError(t, err)
+	require.Len(t, results2, 2)
+	assert.Equal(t, "AAL", results2[0].Symbol, "alphabetical within the same match rank")
+	assert.Nil(t, results2[0].Price)
+	assert.Nil(t, results2[0].ChangePercent)
+
+	results3, err := SearchSuggestions("ZZZZZZ", 5, false)
+	require.NoError(t, err)
+	assert.Empty(t, results3)
+}
+
 // ===================
 // User CRUD Tests
 // ===================
@@ -235,6 +525,16 @@ func TestIntegration_WatchlistCRUD(t *testing.T) {
 	err = AddTickerToWatchList(badItem)
 	assert.Error(t, err, "Non-existent ticker should fail")
 
+	// Batched validation agrees with the per-symbol existence check
+	symbols := []string{"AAPL", "FAKESYMBOL", "MSFT", "ZZZZ"}
+	found, err := TickersExist(symbols)
+	require.NoError(t, err)
+	for _, symbol := range symbols {
+		var exists bool
+		require.NoError(t, DB.QueryRow("SELECT EXISTS(SELECT 1 FROM tickers WHERE symbol = $1)", symbol).Scan(&exists))
+		assert.Equal(t, exists, found[symbol], "TickersExist mismatch for %s", symbol)
+	}
+
 	// Remove ticker
 	err = RemoveTickerFromWatchList(wl.ID, "AAPL")
 	require.NoError(t, err)
@@ -249,6 +549,134 @@ func TestIntegration_WatchlistCRUD(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestIntegration_UserSearches(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	pwHash := "$2a$10$hash"
+	user := &models.User{Email: "search@test.com", PasswordHash: &pwHash, FullName: "Search User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(user))
+
+	// Record three searches; re-searching "aapl" dedupes and bumps it to the top
+	_, err := RecordUserSearch(user.ID, "AAPL", nil)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = RecordUserSearch(user.ID, "Microsoft", nil)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	again, err := RecordUserSearch(user.ID, "  aapl ", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.SearchCount)
+	assert.Equal(t, "aapl", again.Query, "latest spelling is kept")
+
+	searches, err := GetUserSearches(user.ID)
+	require.NoError(t, err)
+	require.Len(t, searches, 2)
+	assert.Equal(t, "aapl", searches[0].Query, "most recent first")
+	assert.Equal(t, "Microsoft", searches[1].Query)
+
+	// Pin Microsoft; existing flag is kept on later plain records
+	pinned := true
+	_, err = RecordUserSearch(user.ID, "microsoft", &pinned)
+	require.NoError(t, err)
+	kept, err := RecordUserSearch(user.ID, "MICROSOFT", nil)
+	require.NoError(t, err)
+	assert.True(t, kept.Pinned)
+
+	// History is capped; pinned searches don't count toward the cap
+	for i := 0; i < MaxRecentSearches+5; i++ {
+		_, err := RecordUserSearch(user.ID, fmt.Sprintf("query %d", i), nil)
+		require.NoError(t, err)
+	}
+	searches, err = GetUserSearches(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, searches, MaxRecentSearches+1)
+	assert.Equal(t, fmt.Sprintf("query %d", MaxRecentSearches+4), searches[0].Query)
+
+	// Clear keeps pinned searches by default
+	deleted, err := ClearUserSearches(user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(MaxRecentSearches), deleted)
+	searches, err = GetUserSearches(user.ID)
+	require.NoError(t, err)
+	require.Len(t, searches, 1)
+	assert.Equal(t, "MICROSOFT", searches[0].Query)
+
+	// Delete a single search; deleting again is not found
+	require.NoError(t, DeleteUserSearch(user.ID, searches[0].ID))
+	assert.ErrorIs(t, DeleteUserSearch(user.ID, searches[0].ID), ErrUserSearchNotFound)
+
+	_, err = ClearUserSearches(user.ID, true)
+	require.NoError(t, err)
+	searches, err = GetUserSearches(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, searches)
+}
+
+func TestIntegration_SeedDefaultWatchList(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'stock'),
+		('MSFT', 'Microsoft', 'stock'),
+		('NVDA', 'NVIDIA', 'stock')`)
+
+	pwHash := "$2a$10$hash"
+	user := &models.User{Email: "onboard@test.com", PasswordHash: &pwHash, FullName: "New User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(user))
+
+	seeded, err := WatchListSeeded(user.ID)
+	require.NoError(t, err)
+	assert.False(t, seeded)
+
+	popular, err := GetPopularStocks(5, false)
+	require.NoError(t, err)
+	symbols := make([]string, len(popular))
+	for i, s := range popular {
+		symbols[i] = s.Symbol
+	}
+	// FAKESYMBOL isn't in tickers and is skipped
+	added, err := SeedDefaultWatchList(user.ID, append(symbols, "FAKESYMBOL"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	lists, err := GetWatchListsByUserID(user.ID)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.True(t, lists[0].IsDefault)
+	items, err := GetWatchListItems(lists[0].ID)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, []string{"AAPL", "MSFT", "NVDA"}, []string{items[0].Symbol, items[1].Symbol, items[2].Symbol})
+
+	seeded, err = WatchListSeeded(user.ID)
+	require.NoError(t, err)
+	assert.True(t, seeded)
+
+	// Seeding again is a no-op, even after the user empties the list
+	require.NoError(t, RemoveTickerFromWatchList(lists[0].ID, "AAPL"))
+	added, err = SeedDefaultWatchList(user.ID, symbols)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	items, err = GetWatchListItems(lists[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+	lists, err = GetWatchListsByUserID(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, lists, 1, "no second default list")
+
+	// Skipping marks the user seeded without adding anything
+	other := &models.User{Email: "skip@test.com", PasswordHash: &pwHash, FullName: "Skip User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(other))
+	added, err = SeedDefaultWatchList(other.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+	seeded, err = WatchListSeeded(other.ID)
+	require.NoError(t, err)
+	assert.True(t, seeded)
+}
+
 func TestIntegration_WatchlistMultiple(t *testing.T) {
 	setupTestDB(t)
 	cleanTables(t)
@@ -354,6 +782,49 @@ func TestIntegration_ScreenerWithFilters(t *testing.T) {
 	assert.Equal(t, "MSFT", stocks2[1].Symbol)
 }
 
+func TestIntegration_ScreenerSectorPercentileFilter(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO screener_data (symbol, name, sector, market_cap, pe_ratio) VALUES
+		('AAPL', 'Apple', 'Technology', 3000000000000, 28.5),
+		('MSFT', 'Microsoft', 'Technology', 2800000000000, 18.0),
+		('JNJ', 'Johnson & Johnson', 'Healthcare', 400000000000, 15.0),
+		('PFE', 'Pfizer', 'Healthcare', 150000000000, 10.0),
+		('NOSEC', 'No Sector', '', 1000000000, 5.0),
+		('XOM', 'Exxon', 'Energy', 450000000000, 8.0)`)
+	// Energy has no distribution; the unknown sector must not be bucketed anywhere
+	DB.MustExec(`INSERT INTO mv_latest_sector_percentiles
+		(sector, metric_name, calculated_at, min_value, p10_value, p25_value, p50_value, p75_value, p90_value, max_value)
+		VALUES
+		('Technology', 'pe_ratio', '2024-12-15', 5.0, 12.0, 20.0, 25.0, 35.0, 50.0, 100.0),
+		('Healthcare', 'pe_ratio', '2024-12-15', 4.0, 8.0, 12.0, 18.0, 24.0, 30.0, 60.0),
+		('Technology', 'ps_ratio', '2024-12-15', 0.5, 1.0, 99.0, 99.0, 99.0, 99.0, 99.0)`)
+
+	bottomQuartile := 25.0
+	params := models.ScreenerParams{
+		Page:            1,
+		Limit:           10,
+		Sort:            "pe_ratio",
+		Order:           "ASC",
+		SectorRelative:  true,
+		PEPercentileMax: &bottomQuartile,
+	}
+	stocks, total, err := GetScreenerStocks(params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, stocks, 2)
+	// PFE is under Healthcare's p25 (12) and MSFT under Technology's (20)
+	assert.Equal(t, "PFE", stocks[0].Symbol)
+	assert.Equal(t, "MSFT", stocks[1].Symbol)
+
+	// Without sector_relative the percentile bound is ignored
+	params.SectorRelative = false
+	_, total, err = GetScreenerStocks(params)
+	require.NoError(t, err)
+	assert.Equal(t, 6, total)
+}
+
 func TestIntegration_ScreenerPagination(t *testing.T) {
 	setupTestDB(t)
 	cleanTables(t)
@@ -581,6 +1052,44 @@ func TestIntegration_HasFinancialStatements(t *testing.T) {
 	assert.False(t, has3)
 }
 
+func TestIntegration_GetFinancialsCoverage(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type) VALUES ('AAPL', 'Apple', 'stock')`)
+	tickerID, _ := GetTickerIDBySymbol("AAPL")
+
+	for i, end := range []time.Time{
+		time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	} {
+		q := i + 3
+		require.NoError(t, UpsertFinancialStatement(&models.FinancialStatement{
+			TickerID: tickerID, StatementType: models.StatementTypeIncome,
+			Timeframe: models.TimeframeQuarterly, FiscalYear: 2024, FiscalQuarter: &q,
+			PeriodEnd: end, Data: models.FinancialData{"revenues": 1e9},
+		}))
+	}
+	DB.MustExec(`INSERT INTO valuation_ratios (ticker, calculation_date, ttm_pe_ratio) VALUES ('AAPL', '2025-01-15', 30)`)
+
+	coverage, err := GetFinancialsCoverage("aapl")
+	require.NoError(t, err)
+	assert.Equal(t, "AAPL", coverage.Ticker)
+	require.Len(t, coverage.Statements, 1, "only stored combinations are listed")
+	income := coverage.Statements[0]
+	assert.Equal(t, models.StatementTypeIncome, income.StatementType)
+	assert.Equal(t, models.TimeframeQuarterly, income.Timeframe)
+	assert.Equal(t, 2, income.PeriodCount)
+	assert.Equal(t, "2024-09-30", *income.EarliestPeriod)
+	assert.Equal(t, "2024-12-31", *income.LatestPeriod)
+	assert.NotNil(t, income.LastRefreshedAt)
+	assert.Equal(t, 1, coverage.Ratios.PeriodCount)
+	assert.Equal(t, "2025-01-15", *coverage.Ratios.LatestPeriod)
+
+	_, err = GetFinancialsCoverage("ZZZZ")
+	assert.ErrorIs(t, err, ErrTickerNotFound)
+}
+
 func TestIntegration_DeleteFinancialStatements(t *testing.T) {
 	setupTestDB(t)
 	cleanTables(t)
@@ -994,6 +1503,51 @@ func TestIntegration_SessionManagement(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestIntegration_SessionRotation(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	pwHash := "$2a$10$hash"
+	user := &models.User{Email: "rotate@test.com", PasswordHash: &pwHash, FullName: "Rotate User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(user))
+
+	first := &models.Session{UserID: user.ID, RefreshTokenHash: "rot_hash_1", ExpiresAt: time.Now().Add(24 * time.Hour)}
+	require.NoError(t, CreateSession(first))
+	current, err := GetSessionByRefreshTokenHash("rot_hash_1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, current.FamilyID, "new logins start a family")
+	assert.Nil(t, current.RotatedAt)
+
+	// Normal rotation: the old token is marked used, the new one joins the family
+	next := &models.Session{UserID: user.ID, RefreshTokenHash: "rot_hash_2", ExpiresAt: time.Now().Add(24 * time.Hour)}
+	require.NoError(t, RotateSession(current, next, "sealed_2"))
+	assert.Equal(t, current.FamilyID, next.FamilyID)
+
+	used, err := GetSessionByRefreshTokenHash("rot_hash_1")
+	require.NoError(t, err)
+	assert.NotNil(t, used.RotatedAt)
+	require.NotNil(t, used.SuccessorToken)
+	assert.Equal(t, "sealed_2", *used.SuccessorToken)
+
+	sessions, err := GetUserSessions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1, "rotated sessions aren't listed")
+	assert.Equal(t, next.ID, sessions[0].ID)
+
+	// Exchanging the old token again is reuse
+	again := &models.Session{UserID: user.ID, RefreshTokenHash: "rot_hash_3", ExpiresAt: time.Now().Add(24 * time.Hour)}
+	assert.ErrorIs(t, RotateSession(current, again, "sealed_3"), ErrRefreshTokenReused)
+	_, err = GetSessionByRefreshTokenHash("rot_hash_3")
+	assert.Error(t, err, "nothing is inserted for a reused token")
+
+	// Logout removes the whole family
+	require.NoError(t, DeleteSessionFamily(current.FamilyID))
+	_, err = GetSessionByRefreshTokenHash("rot_hash_1")
+	assert.Error(t, err)
+	_, err = GetSessionByRefreshTokenHash("rot_hash_2")
+	assert.Error(t, err)
+}
+
 func TestIntegration_PasswordResetFlow(t *testing.T) {
 	setupTestDB(t)
 	cleanTables(t)
@@ -1160,6 +1714,173 @@ func TestIntegration_RedditHeatmapData(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestIntegration_WeightedSentimentHistory(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	// Same day: one heavily-upvoted bullish post, one barely-seen bearish post
+	postedAt := time.Now().Add(-2 * time.Hour)
+	seed := func(externalID, sentiment string, upvotes, comments int) {
+		var postID int64
+		require.NoError(t, DB.QueryRow(`INSERT INTO reddit_posts_raw
+			(external_id, subreddit, title, url, upvotes, comment_count, posted_at,
+			 processed_at, is_finance_related, spam_score)
+			VALUES ($1, 'wallstreetbets', 'post', 'https://reddit.com', $2, $3, $4, NOW(), TRUE, 0)
+			RETURNING id`, externalID, upvotes, comments, postedAt).Scan(&postID))
+		DB.MustExec(`INSERT INTO reddit_post_tickers (post_id, ticker, sentiment, confidence)
+			VALUES ($1, 'GME', $2, 0.9)`, postID, sentiment)
+	}
+	seed("p1", "bullish", 5000, 800)
+	seed("p2", "bearish", 10, 1)
+
+	raw, err := GetSentimentHistory("GME", 7)
+	require.NoError(t, err)
+	require.Len(t, raw.History, 1)
+	assert.InDelta(t, 0.0, raw.History[0].Score, 0.0001, "unweighted: one bullish + one bearish cancel out")
+
+	weighted, err := GetWeightedSentimentHistory("GME", 7)
+	require.NoError(t, err)
+	require.Len(t, weighted, 1)
+	assert.Greater(t, weighted[0].Score, 0.4, "high-engagement bullish post should dominate")
+	assert.Equal(t, 2, weighted[0].PostCount)
+	assert.Equal(t, 1, weighted[0].Bullish)
+	assert.Equal(t, 1, weighted[0].Bearish)
+
+	// Flip engagement: the bearish post now dominates
+	cleanTables(t)
+	seed("p3", "bullish", 10, 1)
+	seed("p4", "bearish", 5000, 800)
+
+	weighted, err = GetWeightedSentimentHistory("GME", 7)
+	require.NoError(t, err)
+	require.Len(t, weighted, 1)
+	assert.Less(t, weighted[0].Score, -0.4)
+
+	// No posts → empty (not nil) series
+	empty, err := GetWeightedSentimentHistory("ZZZZ", 7)
+	require.NoError(t, err)
+	assert.NotNil(t, empty)
+	assert.Empty(t, empty)
+}
+
+func TestIntegration_PostCountByTickerFilters(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	postedAt := time.Now().Add(-2 * time.Hour)
+	seed := func(externalID string, upvotes, comments int) {
+		var postID int64
+		require.NoError(t, DB.QueryRow(`INSERT INTO reddit_posts_raw
+			(external_id, subreddit, title, url, upvotes, comment_count, posted_at,
+			 processed_at, is_finance_related, spam_score)
+			VALUES ($1, 'wallstreetbets', 'post', 'https://reddit.com', $2, $3, $4, NOW(), TRUE, 0)
+			RETURNING id`, externalID, upvotes, comments, postedAt).Scan(&postID))
+		DB.MustExec(`INSERT INTO reddit_post_tickers (post_id, ticker, sentiment, confidence)
+			VALUES ($1, 'GME', 'bullish', 0.9)`, postID)
+	}
+	seed("viral", 5000, 800)
+	seed("popular", 300, 40)
+	seed("quiet", 2, 0)
+	seed("ignored", 0, 0)
+
+	raw, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, raw)
+
+	byUpvotes, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{MinUpvotes: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, byUpvotes)
+
+	byComments, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{MinComments: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, byComments)
+
+	// Every post weighs at least 1, and the viral one far more
+	weighted, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{EngagementWeighted: true})
+	require.NoError(t, err)
+	assert.Greater(t, weighted, raw+10)
+
+	weightedFiltered, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{MinUpvotes: 100, EngagementWeighted: true})
+	require.NoError(t, err)
+	assert.Less(t, weightedFiltered, weighted)
+	assert.Greater(t, weightedFiltered, byUpvotes)
+
+	// Trending applies the same options
+	trending, err := GetTrendingTickers("24h", 10, models.PostCountOptions{EngagementWeighted: true})
+	require.NoError(t, err)
+	require.Len(t, trending.Tickers, 1)
+	assert.Equal(t, 4, trending.Tickers[0].PostCount)
+	assert.InDelta(t, weighted, trending.Tickers[0].WeightedCount, 0.0001)
+
+	// Fewer than three posts survive the filter, so GME no longer trends
+	trending, err = GetTrendingTickers("24h", 10, models.PostCountOptions{MinUpvotes: 100})
+	require.NoError(t, err)
+	assert.Empty(t, trending.Tickers)
+}
+
+func TestIntegration_GetTickerPostsV2SortAndFilter(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	now := time.Now()
+	seed := func(externalID, subreddit string, upvotes int, postedAgo time.Duration, confidence float64) {
+		var postID int64
+		require.NoError(t, DB.QueryRow(`INSERT INTO reddit_posts_raw
+			(external_id, subreddit, title, url, upvotes, comment_count, posted_at,
+			 processed_at, is_finance_related, spam_score)
+			VALUES ($1, $2, $1, 'https://reddit.com', $3, 0, $4, NOW(), TRUE, 0)
+			RETURNING id`, externalID, subreddit, upvotes, now.Add(-postedAgo)).Scan(&postID))
+		DB.MustExec(`INSERT INTO reddit_post_tickers (post_id, ticker, sentiment, confidence)
+			VALUES ($1, 'TSLA', 'bullish', $2)`, postID, confidence)
+	}
+	seed("newest", "stocks", 10, 1*time.Hour, 0.50)
+	seed("popular", "wallstreetbets", 5000, 5*time.Hour, 0.60)
+	seed("confident", "wallstreetbets", 100, 3*time.Hour, 0.99)
+
+	titles := func(resp *models.RepresentativePostsResponse) []string {
+		out := make([]string, len(resp.Posts))
+		for i, p := range resp.Posts {
+			out[i] = p.Title
+		}
+		return out
+	}
+
+	recent, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Sort: models.SortByRecent})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"newest", "confident", "popular"}, titles(recent))
+	assert.Equal(t, 3, recent.Total)
+
+	byUpvotes, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Sort: models.SortByUpvotes})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"popular", "confident", "newest"}, titles(byUpvotes))
+
+	byConfidence, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Sort: models.SortByConfidence})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"confident", "popular", "newest"}, titles(byConfidence))
+
+	// Subreddit filter is case-insensitive and narrows the total
+	wsb, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Sort: models.SortByUpvotes, Subreddit: "WallStreetBets"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"popular", "confident"}, titles(wsb))
+	assert.Equal(t, 2, wsb.Total)
+
+	// Pagination
+	page2, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Sort: models.SortByUpvotes, Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"newest"}, titles(page2))
+	assert.Equal(t, 3, page2.Total)
+
+	// Source filter
+	reddit, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Source: "reddit"})
+	require.NoError(t, err)
+	assert.Len(t, reddit.Posts, 3)
+
+	stocktwits, err := GetTickerPostsV2("TSLA", models.TickerPostsQuery{Source: "stocktwits"})
+	require.NoError(t, err)
+	assert.Empty(t, stocktwits.Posts)
+	assert.Equal(t, 0, stocktwits.Total)
+}
+
 // ========================================
 // Batch 4: Admin / Config Tests
 // ========================================
@@ -1327,6 +2048,488 @@ func TestIntegration_UserSubscriptions(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestIntegration_UserDataExport(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	pwHash := "$2a$10$hash"
+	user := &models.User{Email: "export@test.com", PasswordHash: &pwHash, FullName: "Export User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(user))
+
+	wl := &models.WatchList{UserID: user.ID, Name: "Export WL"}
+	require.NoError(t, CreateWatchList(wl))
+	DB.MustExec(`INSERT INTO watch_list_items (watch_list_id, symbol, notes, display_order) VALUES ($1, 'AAPL', 'long term', 0)`, wl.ID)
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type) VALUES ('AAPL', 'Apple', 'stock')`)
+
+	require.NoError(t, CreateAlertRule(&models.AlertRule{
+		UserID:      user.ID,
+		WatchListID: wl.ID,
+		Symbol:      "AAPL",
+		AlertType:   "price_above",
+		Conditions:  json.RawMessage(`{"threshold": 200.0}`),
+		IsActive:    true,
+		Frequency:   "once",
+		Name:        "AAPL above $200",
+	}))
+	DB.MustExec(`INSERT INTO notification_preferences (user_id, max_alerts_per_day) VALUES ($1, 25)`, user.ID)
+	require.NoError(t, CreateSession(&models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: "secret_refresh_hash",
+		ExpiresAt:        time.Now().Add(24 * time.Hour),
+		UserAgent:        strPtr("Mozilla/5.0"),
+	}))
+
+	DB.MustExec(`INSERT INTO subscription_plans (name, display_name, max_watch_lists) VALUES ('pro', 'Pro', 10)`)
+	pro, err := GetSubscriptionPlanByName("pro")
+	require.NoError(t, err)
+	require.NoError(t, CreateUserSubscription(&models.UserSubscription{
+		UserID:               user.ID,
+		PlanID:               pro.ID,
+		Status:               "active",
+		BillingPeriod:        "monthly",
+		CurrentPeriodStart:   time.Now(),
+		StripeSubscriptionID: strPtr("sub_internal"),
+		StripeCustomerID:     strPtr("cus_internal"),
+	}))
+
+	DB.MustExec(`INSERT INTO payment_history (user_id, amount, status, stripe_invoice_id) VALUES ($1, 9.99, 'succeeded', 'in_internal')`, user.ID)
+	DB.MustExec(`INSERT INTO alert_logs (alert_rule_id, user_id, symbol) SELECT id, user_id, symbol FROM alert_rules WHERE user_id = $1`, user.ID)
+	DB.MustExec(`INSERT INTO heatmap_configs (user_id, watch_list_id, name) VALUES ($1, $2, 'Export HM')`, user.ID, wl.ID)
+	DB.MustExec(`INSERT INTO notification_queue (user_id, type, title) VALUES ($1, 'alert', 'AAPL crossed $200')`, user.ID)
+	DB.MustExec(`INSERT INTO digest_logs (user_id, digest_type, period_start, period_end) VALUES ($1, 'weekly', NOW(), NOW())`, user.ID)
+	require.NoError(t, SavePhoneVerification(user.ID, "+15551234567", "phone_code_hash", time.Now().Add(10*time.Minute)))
+	require.NoError(t, SaveTwoFactorEnrollment(user.ID, "totp_secret_encrypted"))
+	require.NoError(t, RecordAccountActivity(&models.AccountActivity{UserID: user.ID, EventType: models.AccountEventLogin}))
+	DB.MustExec(`INSERT INTO oauth_providers (user_id, provider, provider_user_id) VALUES ($1, 'google', 'g-export')`, user.ID)
+	DB.MustExec(`INSERT INTO user_searches (user_id, query, normalized_query) VALUES ($1, 'aapl', 'aapl')`, user.ID)
+	DB.MustExec(`INSERT INTO backtest_jobs (user_id, config) VALUES ($1, '{}')`, user.ID)
+	portfolio := &models.Portfolio{UserID: user.ID, Name: "Main"}
+	require.NoError(t, CreatePortfolio(portfolio))
+	DB.MustExec(`INSERT INTO portfolio_holdings (portfolio_id, symbol, shares, average_cost) VALUES ($1, 'AAPL', 10, 150)`, portfolio.ID)
+	require.NoError(t, CreateSavedScreenAtomic(&models.SavedScreen{UserID: user.ID, Name: "Value"}, -1))
+
+	export, err := GetUserDataExport(user)
+	require.NoError(t, err)
+
+	assert.Equal(t, "export@test.com", export.Profile.Email)
+	require.Len(t, export.WatchLists, 1)
+	assert.Equal(t, "Export WL", export.WatchLists[0].Name)
+	require.Len(t, export.WatchLists[0].Items, 1)
+	assert.Equal(t, "AAPL", export.WatchLists[0].Items[0].Symbol)
+	require.Len(t, export.AlertRules, 1)
+	assert.Equal(t, "AAPL above $200", export.AlertRules[0].Name)
+	require.NotNil(t, export.NotificationPreferences)
+	assert.Equal(t, 25, export.NotificationPreferences.MaxAlertsPerDay)
+	require.Len(t, export.Sessions, 1)
+	assert.Equal(t, "Mozilla/5.0", *export.Sessions[0].UserAgent)
+	require.NotNil(t, export.Subscription)
+	assert.Equal(t, "pro", export.Subscription.PlanName)
+	require.Len(t, export.WatchLists[0].HeatmapConfigs, 1)
+	assert.Equal(t, "Export HM", export.WatchLists[0].HeatmapConfigs[0].Name)
+	assert.Len(t, export.AlertHistory, 1)
+	assert.Len(t, export.Notifications, 1)
+	assert.Len(t, export.Digests, 1)
+	require.NotNil(t, export.PhoneVerification)
+	assert.Equal(t, "+15551234567", export.PhoneVerification.PhoneNumber)
+	require.Len(t, export.Portfolios, 1)
+	assert.Len(t, export.Portfolios[0].Holdings, 1)
+	assert.Len(t, export.SavedScreens, 1)
+	assert.Len(t, export.Searches, 1)
+	assert.Len(t, export.Backtests, 1)
+	assert.Len(t, export.AccountActivity, 1)
+	require.NotNil(t, export.TwoFactor)
+	assert.False(t, export.TwoFactor.Enabled)
+	require.Len(t, export.LinkedAccounts, 1)
+	assert.Equal(t, "google", export.LinkedAccounts[0].Provider)
+	require.Len(t, export.Payments, 1)
+
+	// Secrets never make it into the serialized export
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	for _, secret := range []string{"$2a$10$hash", "secret_refresh_hash", "sub_internal", "cus_internal",
+		"in_internal", "phone_code_hash", "totp_secret_encrypted"} {
+		assert.NotContains(t, string(data), secret)
+	}
+}
+
+func TestIntegration_PurgeUserData(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	pwHash := "$2a$10$hash"
+	user := &models.User{Email: "purge@test.com", PasswordHash: &pwHash, FullName: "Purge User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(user))
+	other := &models.User{Email: "keep@test.com", PasswordHash: &pwHash, FullName: "Keep User", Timezone: "UTC"}
+	require.NoError(t, CreateUser(other))
+
+	// Seed a row in every user-linked table, for both users
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type) VALUES ('AAPL', 'Apple', 'stock')`)
+	DB.MustExec(`INSERT INTO subscription_plans (name, display_name) VALUES ('pro', 'Pro')`)
+	for i, id := range []string{user.ID, other.ID} {
+		wl := &models.WatchList{UserID: id, Name: "WL"}
+		require.NoError(t, CreateWatchList(wl))
+		DB.MustExec(`INSERT INTO watch_list_items (watch_list_id, symbol) VALUES ($1, 'AAPL')`, wl.ID)
+		rule := &models.AlertRule{UserID: id, WatchListID: wl.ID, Symbol: "AAPL", AlertType: "price_above",
+			Conditions: json.RawMessage(`{"threshold": 1}`), IsActive: true, Frequency: "once", Name: "r"}
+		require.NoError(t, CreateAlertRule(rule))
+		DB.MustExec(`INSERT INTO alert_logs (alert_rule_id, user_id, symbol) VALUES ($1, $2, 'AAPL')`, rule.ID, id)
+		DB.MustExec(`INSERT INTO heatmap_configs (user_id, watch_list_id, name) VALUES ($1, $2, 'hm')`, id, wl.ID)
+		DB.MustExec(`INSERT INTO notification_queue (user_id, type, title) VALUES ($1, 'alert', 't')`, id)
+		DB.MustExec(`INSERT INTO digest_logs (user_id, digest_type, period_start, period_end) VALUES ($1, 'daily', NOW(), NOW())`, id)
+		DB.MustExec(`INSERT INTO notification_daily_counts (user_id, day, alerts, emails) VALUES ($1, CURRENT_DATE, 2, 1)`, id)
+		DB.MustExec(`INSERT INTO notification_preferences (user_id) VALUES ($1)`, id)
+		require.NoError(t, CreateSession(&models.Session{UserID: id, RefreshTokenHash: fmt.Sprintf("hash-%d", i), ExpiresAt: time.Now().Add(time.Hour)}))
+		require.NoError(t, RecordAccountActivity(&models.AccountActivity{UserID: id, EventType: models.AccountEventLogin}))
+		require.NoError(t, SaveTwoFactorEnrollment(id, "encrypted"))
+		require.NoError(t, RecordAdminAudit(&models.AdminAuditEntry{ActorUserID: &id, ActorEmail: &[]string{fmt.Sprintf("admin-%d@test.com", i)}[0],
+			Action: "POST /api/v1/admin/notes/groups", Target: "/api/v1/admin/notes/groups", StatusCode: 201}))
+		DB.MustExec(`INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES ($1, $2, NOW())`, id, fmt.Sprintf("reset-%d", i))
+		DB.MustExec(`INSERT INTO oauth_providers (user_id, provider, provider_user_id) VALUES ($1, 'google', $2)`, id, fmt.Sprintf("g-%d", i))
+		DB.MustExec(`INSERT INTO user_searches (user_id, query, normalized_query) VALUES ($1, 'aapl', 'aapl')`, id)
+		DB.MustExec(`INSERT INTO user_subscriptions (user_id, plan_id) SELECT $1, id FROM subscription_plans WHERE name = 'pro'`, id)
+		DB.MustExec(`INSERT INTO payment_history (user_id, amount, status) VALUES ($1, 9.99, 'succeeded')`, id)
+		DB.MustExec(`INSERT INTO backtest_jobs (user_id, config) VALUES ($1, '{}')`, id)
+		portfolio := &models.Portfolio{UserID: id, Name: "Main"}
+		require.NoError(t, CreatePortfolio(portfolio))
+		DB.MustExec(`INSERT INTO portfolio_holdings (portfolio_id, symbol, shares, average_cost) VALUES ($1, 'AAPL', 10, 150)`, portfolio.ID)
+		require.NoError(t, CreateSavedScreenAtomic(&models.SavedScreen{UserID: id, Name: "Value"}, -1))
+	}
+
+	// Active users are never purged
+	_, err := PurgeUserData(user.ID)
+	assert.ErrorIs(t, err, ErrUserNotPendingPurge)
+
+	// Soft-deleted users only become pending once the grace window passes
+	require.NoError(t, SoftDeleteUser(user.ID))
+	pending, err := GetUsersPendingPurge(time.Now().Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	DB.MustExec(`UPDATE users SET deleted_at = NOW() - INTERVAL '31 days' WHERE id = $1`, user.ID)
+	pending, err = GetUsersPendingPurge(time.Now().Add(-30 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{user.ID}, pending)
+
+	report, err := PurgeUserData(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, report.UserID)
+	counts := make(map[string]int64)
+	for _, tc := range report.Tables {
+		counts[tc.Table] = tc.Rows
+	}
+	assert.Equal(t, int64(1), counts["watch_list_items"])
+	assert.Equal(t, int64(1), counts["portfolio_holdings"])
+	assert.Equal(t, int64(1), counts["sessions"])
+	assert.Equal(t, int64(1), counts["backtest_jobs"])
+	assert.Equal(t, int64(1), counts["users"])
+	assert.Equal(t, int64(1), counts["admin_audit_log"])
+	var auditEmails []*string
+	require.NoError(t, DB.Select(&auditEmails, `SELECT actor_email FROM admin_audit_log ORDER BY actor_email NULLS FIRST`))
+	require.Len(t, auditEmails, 2, "audit rows are kept")
+	assert.Nil(t, auditEmails[0], "the purged actor's email is cleared")
+	assert.Equal(t, "admin-1@test.com", *auditEmails[1])
+
+	userTables := []string{
+		"alert_logs", "alert_rules", "heatmap_configs", "watch_lists", "notification_queue", "digest_logs",
+		"notification_daily_counts", "notification_preferences", "sessions", "account_activity", "user_two_factor", "password_reset_tokens", "oauth_providers", "user_searches",
+		"user_subscriptions", "payment_history", "backtest_jobs", "portfolios", "saved_screens",
+	}
+	for _, table := range userTables {
+		var n int
+		require.NoError(t, DB.Get(&n, `SELECT COUNT(*) FROM `+table+` WHERE user_id = $1`, user.ID))
+		assert.Zero(t, n, "%s still has rows for the purged user", table)
+
+		require.NoError(t, DB.Get(&n, `SELECT COUNT(*) FROM `+table+` WHERE user_id = $1`, other.ID))
+		assert.Equal(t, 1, n, "%s lost rows for another user", table)
+	}
+	var items int
+	require.NoError(t, DB.Get(&items, `SELECT COUNT(*) FROM watch_list_items`))
+	assert.Equal(t, 1, items)
+	require.NoError(t, DB.Get(&items, `SELECT COUNT(*) FROM portfolio_holdings`))
+	assert.Equal(t, 1, items)
+
+	// The user row is kept as an anonymized tombstone
+	var tombstone struct {
+		Email        string  `db:"email"`
+		PasswordHash *string `db:"password_hash"`
+		FullName     string  `db:"full_name"`
+		Purged       bool    `db:"purged"`
+	}
+	require.NoError(t, DB.Get(&tombstone,
+		`SELECT email, password_hash, full_name, purged_at IS NOT NULL AS purged FROM users WHERE id = $1`, user.ID))
+	assert.Equal(t, "deleted-"+user.ID+"@deleted.invalid", tombstone.Email)
+	assert.Nil(t, tombstone.PasswordHash)
+	assert.Empty(t, tombstone.FullName)
+	assert.True(t, tombstone.Purged)
+
+	// Purged users are not picked up or purged again
+	pending, err = GetUsersPendingPurge(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	_, err = PurgeUserData(user.ID)
+	assert.ErrorIs(t, err, ErrUserNotPendingPurge)
+}
+
+func TestIntegration_PreparedStatementsMatchUnprepared(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+	t.Cleanup(preparedStmts.reset)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, exchange, asset_type, market_cap) VALUES
+		('AAPL', 'Apple Inc.', 'NASDAQ', 'stock', 3000000000000),
+		('AAL', 'American Airlines Group', 'NASDAQ', 'stock', 10000000000),
+		('X:AAPL', 'Apple Token', 'CRYPTO', 'crypto', NULL)`)
+	DB.MustExec(`INSERT INTO stock_prices (time, ticker, close, interval) VALUES
+		(NOW() - INTERVAL '2 days', 'AAPL', 200.00, '1day'),
+		(NOW() - INTERVAL '1 day', 'AAPL', 210.00, '1day')`)
+
+	run := func() (*models.Stock, []models.Stock, []models.SearchSuggestion) {
+		stock, err := GetStockBySymbol("aapl")
+		require.NoError(t, err)
+		stocks, err := SearchStocks("AA", 10, false)
+		require.NoError(t, err)
+		suggestions, err := SearchSuggestions("AA", 10, false)
+		require.NoError(t, err)
+		return stock, stocks, suggestions
+	}
+
+	t.Setenv("DB_PREPARED_STATEMENTS", "false")
+	wantStock, wantStocks, wantSuggestions := run()
+
+	t.Setenv("DB_PREPARED_STATEMENTS", "")
+	for i := 0; i < 2; i++ { // second pass reuses the cached statements
+		stock, stocks, suggestions := run()
+		assert.Equal(t, wantStock, stock)
+		assert.Equal(t, wantStocks, stocks)
+		assert.Equal(t, wantSuggestions, suggestions)
+	}
+	assert.Len(t, preparedStmts.stmts, 3)
+}
+
+// BenchmarkGetStockBySymbol compares the ticker lookup with and without the
+// prepared statement cache. Requires INTEGRATION_TEST_DB:
+//
+//	go test ./database -run '^$' -bench GetStockBySymbol
+func BenchmarkGetStockBySymbol(b *testing.B) {
+	setupTestDB(b)
+	cleanTables(b)
+	b.Cleanup(preparedStmts.reset)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, exchange, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'NASDAQ', 'stock')`)
+
+	for _, mode := range []struct{ name, env string }{
+		{"unprepared", "false"},
+		{"prepared", ""},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			b.Setenv("DB_PREPARED_STATEMENTS", mode.env)
+			for i := 0; i < b.N; i++ {
+				if _, err := GetStockBySymbol("AAPL"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestIntegration_Portfolios(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	pwHash := "$2a$10$hash"
+	owner := &models.User{Email: "owner@test.com", PasswordHash: &pwHash, FullName: "Owner", Timezone: "UTC"}
+	require.NoError(t, CreateUser(owner))
+	other := &models.User{Email: "other@test.com", PasswordHash: &pwHash, FullName: "Other", Timezone: "UTC"}
+	require.NoError(t, CreateUser(other))
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, exchange, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'NASDAQ', 'stock'),
+		('MSFT', 'Microsoft Corporation', 'NASDAQ', 'stock'),
+		('NEWCO', 'New Listing Co', 'NYSE', 'stock')`)
+	DB.MustExec(`INSERT INTO stock_prices (time, ticker, close, interval) VALUES
+		(NOW() - INTERVAL '2 days', 'AAPL', 190.00, '1day'),
+		(NOW() - INTERVAL '1 day', 'AAPL', 200.00, '1day'),
+		(NOW() - INTERVAL '1 hour', 'AAPL', 999.00, '1min'),
+		(NOW() - INTERVAL '1 day', 'MSFT', 400.00, '1day')`)
+
+	portfolio := &models.Portfolio{UserID: owner.ID, Name: "Core"}
+	require.NoError(t, CreatePortfolio(portfolio))
+
+	// Other users can't see or change it
+	_, err := GetPortfolioByID(portfolio.ID, other.ID)
+	assert.ErrorIs(t, err, ErrPortfolioNotFound)
+	assert.ErrorIs(t, DeletePortfolio(portfolio.ID, other.ID), ErrPortfolioNotFound)
+
+	for _, h := range []models.PortfolioHolding{
+		{PortfolioID: portfolio.ID, Symbol: "AAPL", Shares: 10, AverageCost: 150},
+		{PortfolioID: portfolio.ID, Symbol: "MSFT", Shares: 2.5, AverageCost: 300},
+		{PortfolioID: portfolio.ID, Symbol: "NEWCO", Shares: 1, AverageCost: 20},
+	} {
+		h := h
+		require.NoError(t, AddPortfolioHolding(&h))
+	}
+	assert.ErrorIs(t, AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: portfolio.ID, Symbol: "AAPL", Shares: 1}), ErrHoldingAlreadyExists)
+	assert.ErrorIs(t, AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: portfolio.ID, Symbol: "ZZZZ", Shares: 1}), ErrTickerNotFound)
+
+	list, err := GetPortfoliosByUserID(owner.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 3, list[0].HoldingCount)
+
+	holdings, err := GetPortfolioHoldingsWithPrices(portfolio.ID)
+	require.NoError(t, err)
+	require.Len(t, holdings, 3)
+	assert.Equal(t, "AAPL", holdings[0].Symbol)
+	assert.Equal(t, "Apple Inc.", holdings[0].Name)
+	require.NotNil(t, holdings[0].CurrentPrice)
+	assert.InDelta(t, 200.0, *holdings[0].CurrentPrice, 0.001, "latest daily close, ignoring intraday bars")
+	assert.InDelta(t, 2.5, holdings[1].Shares, 0.0001)
+	assert.Nil(t, holdings[2].CurrentPrice, "no price history")
+
+	// Only yesterday has a close for every holding once NEWCO is dropped
+	require.NoError(t, RemovePortfolioHolding(portfolio.ID, "NEWCO"))
+	points, err := GetPortfolioValueHistory(portfolio.ID, 30)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.InDelta(t, 10*200.0+2.5*400.0, points[0].Value, 0.001)
+
+	updated := &models.PortfolioHolding{PortfolioID: portfolio.ID, Symbol: "AAPL", Shares: 12, AverageCost: 160}
+	require.NoError(t, UpdatePortfolioHolding(updated))
+	assert.NotEmpty(t, updated.ID)
+	assert.ErrorIs(t, RemovePortfolioHolding(portfolio.ID, "NEWCO"), ErrPortfolioHoldingNotFound)
+
+	// Deleting the portfolio removes its holdings
+	require.NoError(t, DeletePortfolio(portfolio.ID, owner.ID))
+	var n int
+	require.NoError(t, DB.Get(&n, `SELECT COUNT(*) FROM portfolio_holdings`))
+	assert.Zero(t, n)
+}
+
+func TestIntegration_PortfolioTransactions(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	pwHash := "$2a$10$hash"
+	owner := &models.User{Email: "trader@test.com", PasswordHash: &pwHash, FullName: "Trader", Timezone: "UTC"}
+	require.NoError(t, CreateUser(owner))
+	DB.MustExec(`INSERT INTO tickers (symbol, name, exchange, asset_type) VALUES
+		('AAPL', 'Apple Inc.', 'NASDAQ', 'stock')`)
+	portfolio := &models.Portfolio{UserID: owner.ID, Name: "Trading"}
+	require.NoError(t, CreatePortfolio(portfolio))
+	require.NoError(t, AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: portfolio.ID, Symbol: "AAPL", Shares: 2, AverageCost: 100}))
+
+	// A stand-in for the service's FIFO replay: net shares at the last price
+	var seen []models.PortfolioTransaction
+	replay := func(history []models.PortfolioTransaction) (decimal.Decimal, decimal.Decimal, error) {
+		seen = history
+		shares := decimal.Zero
+		for _, h := range history {
+			if h.TransactionType == models.TransactionTypeSell {
+				shares = shares.Sub(h.Shares)
+			} else {
+				shares = shares.Add(h.Shares)
+			}
+		}
+		if shares.IsNegative() {
+			return decimal.Zero, decimal.Zero, ErrInsufficientShares
+		}
+		return shares, history[len(history)-1].Price, nil
+	}
+	record := func(kind, shares, price string) error {
+		return RecordPortfolioTransaction(&models.PortfolioTransaction{
+			PortfolioID:     portfolio.ID,
+			Symbol:          "AAPL",
+			TransactionType: kind,
+			Shares:          decimal.RequireFromString(shares),
+			Price:           decimal.RequireFromString(price),
+		}, replay)
+	}
+
+	// The manually added holding becomes an opening BUY
+	require.NoError(t, record(models.TransactionTypeBuy, "0.5", "120"))
+	require.Len(t, seen, 2)
+	assert.True(t, seen[0].Shares.Equal(decimal.NewFromInt(2)))
+	holdings, err := GetPortfolioHoldingsWithPrices(portfolio.ID)
+	require.NoError(t, err)
+	require.Len(t, holdings, 1)
+	assert.InDelta(t, 2.5, holdings[0].Shares, 0.000001)
+
+	// Manual edits are refused once the holding has history
+	assert.ErrorIs(t, UpdatePortfolioHolding(&models.PortfolioHolding{PortfolioID: portfolio.ID, Symbol: "AAPL", Shares: 1}), ErrHoldingHasTransactions)
+
+	// A failed replay leaves nothing behind
+	assert.ErrorIs(t, record(models.TransactionTypeSell, "3", "130"), ErrInsufficientShares)
+	transactions, err := GetPortfolioTransactions(portfolio.ID)
+	require.NoError(t, err)
+	assert.Len(t, transactions, 2)
+
+	// Selling out removes the holding but keeps the history
+	require.NoError(t, record(models.TransactionTypeSell, "2.5", "130"))
+	holdings, err = GetPortfolioHoldingsWithPrices(portfolio.ID)
+	require.NoError(t, err)
+	assert.Empty(t, holdings)
+	transactions, err = GetPortfolioTransactions(portfolio.ID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+	assert.Equal(t, models.TransactionTypeSell, transactions[2].TransactionType)
+	assert.ErrorIs(t, AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: portfolio.ID, Symbol: "AAPL", Shares: 1}), ErrHoldingHasTransactions)
+
+	// Removing a holding clears its history
+	require.NoError(t, record(models.TransactionTypeBuy, "1", "140"))
+	require.NoError(t, RemovePortfolioHolding(portfolio.ID, "AAPL"))
+	transactions, err = GetPortfolioTransactions(portfolio.ID)
+	require.NoError(t, err)
+	assert.Empty(t, transactions)
+}
+
+func TestIntegration_StockSplits(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	err := UpsertStockSplits([]models.StockSplit{
+		{Ticker: "AAPL", ExecutionDate: day("2020-08-31"), SplitFrom: 1, SplitTo: 4, Source: "polygon"},
+		{Ticker: "AAPL", ExecutionDate: day("2014-06-09"), SplitFrom: 1, SplitTo: 7, Source: "polygon"},
+		{Ticker: "GE", ExecutionDate: day("2021-08-02"), SplitFrom: 8, SplitTo: 1, Source: "fmp"},
+	})
+	require.NoError(t, err)
+
+	splits, err := GetStockSplits("aapl")
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+	assert.Equal(t, "2014-06-09", splits[0].ExecutionDate.Format("2006-01-02"), "oldest first")
+	assert.Equal(t, 7.0, splits[0].Ratio())
+
+	// Re-ingesting the same date replaces rather than duplicates
+	err = UpsertStockSplits([]models.StockSplit{
+		{Ticker: "GE", ExecutionDate: day("2021-08-02"), SplitFrom: 8, SplitTo: 1, Source: "polygon"},
+	})
+	require.NoError(t, err)
+	ge, err := GetStockSplits("GE")
+	require.NoError(t, err)
+	require.Len(t, ge, 1)
+	assert.Equal(t, "polygon", ge[0].Source)
+	assert.True(t, ge[0].IsReverse())
+	assert.InDelta(t, 0.125, ge[0].Ratio(), 1e-9)
+
+	none, err := GetStockSplits("MSFT")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	// A sync with no splits still records the sync time
+	syncedAt, err := GetStockSplitsSyncedAt("MSFT")
+	require.NoError(t, err)
+	assert.Nil(t, syncedAt)
+	require.NoError(t, SyncStockSplits("msft", nil))
+	syncedAt, err = GetStockSplitsSyncedAt("MSFT")
+	require.NoError(t, err)
+	require.NotNil(t, syncedAt)
+	assert.WithinDuration(t, time.Now(), *syncedAt, time.Minute)
+}
+
 // ========================================
 // Helpers
 // ========================================
@@ -1334,3 +2537,76 @@ func TestIntegration_UserSubscriptions(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestIntegration_DataCoverage(t *testing.T) {
+	setupTestDB(t)
+	cleanTables(t)
+
+	DB.MustExec(`INSERT INTO tickers (symbol, name, asset_type, active) VALUES
+		('AAA', 'Alpha', 'CS', TRUE),
+		('BBB', 'Beta', 'CS', TRUE),
+		('CCC', 'Gamma', 'stock', TRUE),
+		('DDD', 'Delta', 'CS', TRUE),
+		('OLD', 'Delisted', 'CS', FALSE),
+		('ETF1', 'Some Fund', 'ETF', TRUE)`)
+
+	now := time.Now()
+	price := func(ticker string, age time.Duration) {
+		DB.MustExec(`INSERT INTO stock_prices (time, ticker, close, interval) VALUES ($1, $2, 10, '1day')`,
+			now.Add(-age), ticker)
+	}
+	day := 24 * time.Hour
+	price("AAA", day)
+	price("AAA", 40*day) // older bars don't matter, only the latest
+	price("BBB", 30*day)
+	price("CCC", 60*day)
+	price("OLD", day) // inactive and non-stock tickers are outside the universe
+	price("ETF1", day)
+
+	DB.MustExec(`INSERT INTO ic_scores (ticker, date, overall_score) VALUES
+		('AAA', CURRENT_DATE, 70), ('BBB', CURRENT_DATE - 1, 55)`)
+	DB.MustExec(`INSERT INTO ticker_sentiment_snapshots (ticker, snapshot_time, time_range)
+		VALUES ('AAA', NOW() - INTERVAL '1 hour', '1d'), ('BBB', NOW() - INTERVAL '10 days', '1d')`)
+	DB.MustExec(`INSERT INTO financial_statements (ticker_id, statement_type, timeframe, fiscal_year, period_end, filed_date, data)
+		SELECT id, 'income', 'quarterly', 2026, CURRENT_DATE - 40, CURRENT_DATE - 10, '{}' FROM tickers WHERE symbol = 'AAA'`)
+
+	coverage, err := GetDataCoverage()
+	require.NoError(t, err)
+
+	byDomain := make(map[string]models.DomainCoverage)
+	var order []string
+	for _, c := range coverage {
+		byDomain[c.Domain] = c
+		order = append(order, c.Domain)
+	}
+	assert.Equal(t, []string{"prices", "financials", "fundamentals", "ic_scores", "sentiment", "analyst"}, order)
+
+	prices := byDomain["prices"]
+	assert.Equal(t, 4, prices.TotalTickers)
+	assert.Equal(t, 1, prices.FreshTickers)
+	assert.Equal(t, 2, prices.StaleTickers)
+	assert.Equal(t, 1, prices.MissingTickers)
+	assert.InDelta(t, 25.0, prices.CoveragePct, 0.001)
+	require.NotNil(t, prices.OldestStaleTicker)
+	assert.Equal(t, "CCC", *prices.OldestStaleTicker)
+	require.NotNil(t, prices.OldestStaleAt)
+	assert.WithinDuration(t, now.Add(-60*day), *prices.OldestStaleAt, time.Second)
+
+	assert.InDelta(t, 50.0, byDomain["ic_scores"].CoveragePct, 0.001)
+	assert.Nil(t, byDomain["ic_scores"].OldestStaleTicker)
+
+	sentiment := byDomain["sentiment"]
+	assert.InDelta(t, 25.0, sentiment.CoveragePct, 0.001)
+	assert.Equal(t, 1, sentiment.StaleTickers)
+	require.NotNil(t, sentiment.OldestStaleTicker)
+	assert.Equal(t, "BBB", *sentiment.OldestStaleTicker)
+
+	assert.InDelta(t, 25.0, byDomain["financials"].CoveragePct, 0.001)
+
+	for _, domain := range []string{"fundamentals", "analyst"} {
+		c := byDomain[domain]
+		assert.Equal(t, 0.0, c.CoveragePct, domain)
+		assert.Equal(t, 4, c.MissingTickers, domain)
+		assert.Nil(t, c.OldestStaleAt, domain)
+	}
+}
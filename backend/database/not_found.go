@@ -0,0 +1,30 @@
+package database
+
+import "errors"
+
+// ownedNotFoundErrors are the not-found errors of per-user lookups. Those
+// queries filter on user_id, so each of these also covers a row that exists
+// but belongs to someone else.
+var ownedNotFoundErrors = []error{
+	ErrWatchListNotFound,
+	ErrWatchListItemNotFound,
+	ErrAlertRuleNotFound,
+	ErrAlertLogNotFound,
+	ErrHeatmapConfigNotFound,
+	ErrNotificationNotFound,
+	ErrPortfolioNotFound,
+	ErrPortfolioHoldingNotFound,
+	ErrSavedScreenNotFound,
+	ErrUserSearchNotFound,
+}
+
+// IsNotFound reports whether err means a per-user resource doesn't exist or
+// isn't visible to the caller.
+func IsNotFound(err error) bool {
+	for _, target := range ownedNotFoundErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,13 @@ import (
 
 // Notification Preferences Operations
 
+// ErrNotificationPreferencesNotFound is returned when a user has no preferences row
+var ErrNotificationPreferencesNotFound = errors.New("notification preferences not found")
+
+// ErrNotificationNotFound is returned when a notification doesn't exist or
+// belongs to another user
+var ErrNotificationNotFound = errors.New("notification not found")
+
 // GetNotificationPreferences retrieves notification preferences for a user
 func GetNotificationPreferences(userID string) (*models.NotificationPreferences, error) {
 	query := `
@@ -22,6 +29,7 @@ func GetNotificationPreferences(userID string) (*models.NotificationPreferences,
 			digest_include_recent_alerts, digest_include_news_highlights,
 			quiet_hours_enabled, quiet_hours_start, quiet_hours_end,
 			quiet_hours_timezone, max_alerts_per_day, max_emails_per_day,
+			webhook_url, webhook_secret, sms_enabled, phone_number, phone_verified,
 			created_at, updated_at
 		FROM notification_preferences
 		WHERE user_id = $1
@@ -53,12 +61,17 @@ func GetNotificationPreferences(userID string) (*models.NotificationPreferences,
 		&prefs.QuietHoursTimezone,
 		&prefs.MaxAlertsPerDay,
 		&prefs.MaxEmailsPerDay,
+		&prefs.WebhookURL,
+		&prefs.WebhookSecret,
+		&prefs.SMSEnabled,
+		&prefs.PhoneNumber,
+		&prefs.PhoneVerified,
 		&prefs.CreatedAt,
 		&prefs.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("notification preferences not found")
+		return nil, ErrNotificationPreferencesNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
@@ -213,7 +226,7 @@ func MarkNotificationAsRead(notificationID string, userID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("notification not found")
+		return ErrNotificationNotFound
 	}
 
 	return nil
@@ -251,7 +264,7 @@ func DismissNotification(notificationID string, userID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("notification not found")
+		return ErrNotificationNotFound
 	}
 
 	return nil
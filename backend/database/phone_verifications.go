@@ -0,0 +1,95 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PhoneVerification is the pending code for confirming a user's phone number
+type PhoneVerification struct {
+	UserID      string    `db:"user_id"`
+	PhoneNumber string    `db:"phone_number"`
+	CodeHash    string    `db:"code_hash"`
+	Attempts    int       `db:"attempts"`
+	ExpiresAt   time.Time `db:"expires_at"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// SavePhoneVerification stores a new code for the user, replacing any
+// pending one
+func SavePhoneVerification(userID, phoneNumber, codeHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO phone_verifications (user_id, phone_number, code_hash, attempts, expires_at, created_at)
+		VALUES ($1, $2, $3, 0, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (user_id) DO UPDATE SET
+			phone_number = EXCLUDED.phone_number,
+			code_hash = EXCLUDED.code_hash,
+			attempts = 0,
+			expires_at = EXCLUDED.expires_at,
+			created_at = CURRENT_TIMESTAMP
+	`
+	if _, err := DB.Exec(query, userID, phoneNumber, codeHash, expiresAt); err != nil {
+		return fmt.Errorf("failed to save phone verification: %w", err)
+	}
+	return nil
+}
+
+// GetPhoneVerification returns the user's pending code, or nil if none
+func GetPhoneVerification(userID string) (*PhoneVerification, error) {
+	var v PhoneVerification
+	err := DB.Get(&v, `
+		SELECT user_id, phone_number, code_hash, attempts, expires_at, created_at
+		FROM phone_verifications
+		WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get phone verification: %w", err)
+	}
+	return &v, nil
+}
+
+// IncrementPhoneVerificationAttempts counts a wrong code against the
+// user's pending verification
+func IncrementPhoneVerificationAttempts(userID string) error {
+	if _, err := DB.Exec(`UPDATE phone_verifications SET attempts = attempts + 1 WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to count phone verification attempt: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPhoneNumber marks phoneNumber verified for the user, provided it
+// is still the number in their notification preferences, and removes the
+// pending code. It returns false if the number has since changed.
+func ConfirmPhoneNumber(userID, phoneNumber string) (bool, error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE notification_preferences
+		SET phone_verified = true, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND phone_number = $2
+	`, userID, phoneNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to confirm phone number: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM phone_verifications WHERE user_id = $1`, userID); err != nil {
+		return false, fmt.Errorf("failed to delete phone verification: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit phone confirmation: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
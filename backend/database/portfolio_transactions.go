@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"investorcenter-api/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Sentinel errors for portfolio transactions
+var (
+	ErrInsufficientShares     = errors.New("cannot sell more shares than held")
+	ErrHoldingHasTransactions = errors.New("holding is tracked by transactions; record a BUY or SELL instead")
+)
+
+// PositionReplay rebuilds a position from a symbol's full transaction
+// history, oldest first, returning the open shares and their average cost
+type PositionReplay func(history []models.PortfolioTransaction) (shares, averageCost decimal.Decimal, err error)
+
+// RecordPortfolioTransaction inserts txn and rebuilds the symbol's holding
+// from its history with replay, all while holding a lock on the portfolio so
+// concurrent trades replay in order. A holding that predates any transaction
+// is first recorded as an opening BUY at its average cost. The holding is
+// deleted when replay leaves no shares; a replay error rolls everything back.
+func RecordPortfolioTransaction(txn *models.PortfolioTransaction, replay PositionReplay) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked string
+	err = tx.QueryRow(`SELECT id FROM portfolios WHERE id = $1 FOR UPDATE`, txn.PortfolioID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrPortfolioNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock portfolio: %w", err)
+	}
+
+	if txn.TransactionType == models.TransactionTypeBuy {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM tickers WHERE symbol = $1)", txn.Symbol).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to verify ticker: %w", err)
+		}
+		if !exists {
+			return ErrTickerNotFound
+		}
+	}
+
+	history := []models.PortfolioTransaction{}
+	err = tx.Select(&history, `
+		SELECT id, portfolio_id, symbol, transaction_type, shares, price, executed_at, created_at
+		FROM portfolio_transactions
+		WHERE portfolio_id = $1 AND symbol = $2
+		ORDER BY executed_at ASC, created_at ASC, id ASC
+	`, txn.PortfolioID, txn.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction history: %w", err)
+	}
+
+	insert := `
+		INSERT INTO portfolio_transactions (portfolio_id, symbol, transaction_type, shares, price, executed_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, NOW()))
+		RETURNING id, executed_at, created_at
+	`
+
+	if len(history) == 0 {
+		opening := models.PortfolioTransaction{
+			PortfolioID:     txn.PortfolioID,
+			Symbol:          txn.Symbol,
+			TransactionType: models.TransactionTypeBuy,
+		}
+		err := tx.QueryRow(`
+			SELECT shares, average_cost, added_at
+			FROM portfolio_holdings
+			WHERE portfolio_id = $1 AND symbol = $2
+		`, txn.PortfolioID, txn.Symbol).Scan(&opening.Shares, &opening.Price, &opening.ExecutedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get holding: %w", err)
+		}
+		if err == nil {
+			err = tx.QueryRow(insert, opening.PortfolioID, opening.Symbol, opening.TransactionType,
+				opening.Shares, opening.Price, opening.ExecutedAt).
+				Scan(&opening.ID, &opening.ExecutedAt, &opening.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to record opening position: %w", err)
+			}
+			history = append(history, opening)
+		}
+	}
+
+	var executedAt interface{}
+	if !txn.ExecutedAt.IsZero() {
+		executedAt = txn.ExecutedAt
+	}
+	err = tx.QueryRow(insert, txn.PortfolioID, txn.Symbol, txn.TransactionType, txn.Shares, txn.Price, executedAt).
+		Scan(&txn.ID, &txn.ExecutedAt, &txn.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record transaction: %w", err)
+	}
+
+	// Stable, so a trade backdated to the same instant as an earlier one
+	// still replays after it
+	history = append(history, *txn)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].ExecutedAt.Before(history[j].ExecutedAt)
+	})
+
+	shares, averageCost, err := replay(history)
+	if err != nil {
+		return err
+	}
+
+	if shares.IsPositive() {
+		_, err = tx.Exec(`
+			INSERT INTO portfolio_holdings (portfolio_id, symbol, shares, average_cost)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (portfolio_id, symbol) DO UPDATE SET
+				shares = EXCLUDED.shares,
+				average_cost = EXCLUDED.average_cost,
+				updated_at = NOW()
+		`, txn.PortfolioID, txn.Symbol, shares.Round(6), averageCost.Round(4))
+	} else {
+		_, err = tx.Exec(`DELETE FROM portfolio_holdings WHERE portfolio_id = $1 AND symbol = $2`,
+			txn.PortfolioID, txn.Symbol)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update holding: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetPortfolioTransactions retrieves a portfolio's transactions, oldest first
+func GetPortfolioTransactions(portfolioID string) ([]models.PortfolioTransaction, error) {
+	query := `
+		SELECT id, portfolio_id, symbol, transaction_type, shares, price, executed_at, created_at
+		FROM portfolio_transactions
+		WHERE portfolio_id = $1
+		ORDER BY executed_at ASC, created_at ASC, id ASC
+	`
+	transactions := []models.PortfolioTransaction{}
+	if err := DB.Select(&transactions, query, portfolioID); err != nil {
+		return nil, fmt.Errorf("failed to get portfolio transactions: %w", err)
+	}
+	return transactions, nil
+}
+
+// hasPortfolioTransactions reports whether a symbol in a portfolio has any
+// recorded transactions, in which case its holding may only change through
+// new transactions
+func hasPortfolioTransactions(portfolioID string, symbol string) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM portfolio_transactions WHERE portfolio_id = $1 AND symbol = $2)",
+		portfolioID, symbol,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check transaction history: %w", err)
+	}
+	return exists, nil
+}
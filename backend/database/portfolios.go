@@ -0,0 +1,254 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"investorcenter-api/models"
+
+	"github.com/lib/pq"
+)
+
+// Sentinel errors for portfolio operations
+var (
+	ErrPortfolioNotFound        = errors.New("portfolio not found")
+	ErrPortfolioHoldingNotFound = errors.New("portfolio holding not found")
+	ErrHoldingAlreadyExists     = errors.New("ticker already held in this portfolio")
+)
+
+// Portfolio Operations
+
+// CreatePortfolio creates a new portfolio
+func CreatePortfolio(portfolio *models.Portfolio) error {
+	query := `
+		INSERT INTO portfolios (user_id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at
+	`
+	err := DB.QueryRow(query, portfolio.UserID, portfolio.Name, portfolio.Description).
+		Scan(&portfolio.ID, &portfolio.CreatedAt, &portfolio.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create portfolio: %w", err)
+	}
+	return nil
+}
+
+// GetPortfoliosByUserID retrieves all portfolios for a user
+func GetPortfoliosByUserID(userID string) ([]models.PortfolioListItem, error) {
+	query := `
+		SELECT
+			p.id, p.name, p.description, p.created_at, p.updated_at,
+			COUNT(ph.id) as holding_count
+		FROM portfolios p
+		LEFT JOIN portfolio_holdings ph ON p.id = ph.portfolio_id
+		WHERE p.user_id = $1
+		GROUP BY p.id, p.name, p.description, p.created_at, p.updated_at
+		ORDER BY p.created_at ASC
+	`
+	portfolios := []models.PortfolioListItem{}
+	if err := DB.Select(&portfolios, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get portfolios: %w", err)
+	}
+	return portfolios, nil
+}
+
+// GetPortfolioByID retrieves a single portfolio owned by userID
+func GetPortfolioByID(portfolioID string, userID string) (*models.Portfolio, error) {
+	query := `
+		SELECT id, user_id, name, description, created_at, updated_at
+		FROM portfolios
+		WHERE id = $1 AND user_id = $2
+	`
+	portfolio := &models.Portfolio{}
+	err := DB.Get(portfolio, query, portfolioID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPortfolioNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio: %w", err)
+	}
+	return portfolio, nil
+}
+
+// UpdatePortfolio updates portfolio metadata
+func UpdatePortfolio(portfolio *models.Portfolio) error {
+	query := `
+		UPDATE portfolios
+		SET name = $1, description = $2, updated_at = NOW()
+		WHERE id = $3 AND user_id = $4
+		RETURNING created_at, updated_at
+	`
+	err := DB.QueryRow(query, portfolio.Name, portfolio.Description, portfolio.ID, portfolio.UserID).
+		Scan(&portfolio.CreatedAt, &portfolio.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrPortfolioNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update portfolio: %w", err)
+	}
+	return nil
+}
+
+// DeletePortfolio deletes a portfolio and its holdings
+func DeletePortfolio(portfolioID string, userID string) error {
+	query := `DELETE FROM portfolios WHERE id = $1 AND user_id = $2`
+	result, err := DB.Exec(query, portfolioID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete portfolio: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPortfolioNotFound
+	}
+	return nil
+}
+
+// Portfolio Holding Operations
+
+// AddPortfolioHolding adds a position to a portfolio
+func AddPortfolioHolding(holding *models.PortfolioHolding) error {
+	// Verify ticker exists in tickers table
+	var exists bool
+	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM tickers WHERE symbol = $1)", holding.Symbol).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to verify ticker: %w", err)
+	}
+	if !exists {
+		return ErrTickerNotFound
+	}
+
+	tracked, err := hasPortfolioTransactions(holding.PortfolioID, holding.Symbol)
+	if err != nil {
+		return err
+	}
+	if tracked {
+		return ErrHoldingHasTransactions
+	}
+
+	query := `
+		INSERT INTO portfolio_holdings (portfolio_id, symbol, shares, average_cost)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, added_at, updated_at
+	`
+	err = DB.QueryRow(query, holding.PortfolioID, holding.Symbol, holding.Shares, holding.AverageCost).
+		Scan(&holding.ID, &holding.AddedAt, &holding.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrHoldingAlreadyExists
+		}
+		return fmt.Errorf("failed to add portfolio holding: %w", err)
+	}
+	return nil
+}
+
+// UpdatePortfolioHolding replaces the shares and average cost of a position.
+// Positions with transaction history can only change through transactions.
+func UpdatePortfolioHolding(holding *models.PortfolioHolding) error {
+	tracked, err := hasPortfolioTransactions(holding.PortfolioID, holding.Symbol)
+	if err != nil {
+		return err
+	}
+	if tracked {
+		return ErrHoldingHasTransactions
+	}
+
+	query := `
+		UPDATE portfolio_holdings
+		SET shares = $1, average_cost = $2, updated_at = NOW()
+		WHERE portfolio_id = $3 AND symbol = $4
+		RETURNING id, added_at, updated_at
+	`
+	err = DB.QueryRow(query, holding.Shares, holding.AverageCost, holding.PortfolioID, holding.Symbol).
+		Scan(&holding.ID, &holding.AddedAt, &holding.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrPortfolioHoldingNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update portfolio holding: %w", err)
+	}
+	return nil
+}
+
+// RemovePortfolioHolding removes a position and its transaction history from
+// a portfolio
+func RemovePortfolioHolding(portfolioID string, symbol string) error {
+	query := `
+		WITH history AS (
+			DELETE FROM portfolio_transactions WHERE portfolio_id = $1 AND symbol = $2
+		)
+		DELETE FROM portfolio_holdings WHERE portfolio_id = $1 AND symbol = $2
+	`
+	result, err := DB.Exec(query, portfolioID, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to remove portfolio holding: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPortfolioHoldingNotFound
+	}
+	return nil
+}
+
+// GetPortfolioHoldingsWithPrices retrieves a portfolio's holdings with the
+// ticker name and the latest daily close from stock_prices. Valuation fields
+// are left for the caller to compute.
+func GetPortfolioHoldingsWithPrices(portfolioID string) ([]models.PortfolioHoldingWithValue, error) {
+	query := `
+		SELECT
+			ph.id, ph.portfolio_id, ph.symbol, ph.shares, ph.average_cost, ph.added_at, ph.updated_at,
+			COALESCE(t.name, '') as name,
+			sp.close as current_price,
+			sp.time as price_date
+		FROM portfolio_holdings ph
+		LEFT JOIN LATERAL (
+			SELECT name FROM tickers WHERE symbol = ph.symbol LIMIT 1
+		) t ON true
+		LEFT JOIN LATERAL (
+			SELECT close, time
+			FROM stock_prices
+			WHERE ticker = ph.symbol AND interval = '1day' AND close IS NOT NULL
+			ORDER BY time DESC
+			LIMIT 1
+		) sp ON true
+		WHERE ph.portfolio_id = $1
+		ORDER BY ph.symbol ASC
+	`
+	holdings := []models.PortfolioHoldingWithValue{}
+	if err := DB.Select(&holdings, query, portfolioID); err != nil {
+		return nil, fmt.Errorf("failed to get portfolio holdings: %w", err)
+	}
+	return holdings, nil
+}
+
+// GetPortfolioValueHistory returns the combined value of a portfolio's
+// current holdings at each daily close over the last days days. Days on
+// which any holding has no close are skipped rather than reported low.
+func GetPortfolioValueHistory(portfolioID string, days int) ([]models.PortfolioValuePoint, error) {
+	query := `
+		SELECT
+			date_trunc('day', sp.time) as date,
+			SUM(ph.shares * sp.close) as value
+		FROM portfolio_holdings ph
+		JOIN stock_prices sp ON sp.ticker = ph.symbol
+			AND sp.interval = '1day'
+			AND sp.close IS NOT NULL
+			AND sp.time >= NOW() - INTERVAL '1 day' * $2
+		WHERE ph.portfolio_id = $1
+		GROUP BY date_trunc('day', sp.time)
+		HAVING COUNT(DISTINCT ph.symbol) = (SELECT COUNT(*) FROM portfolio_holdings WHERE portfolio_id = $1)
+		ORDER BY date ASC
+	`
+	points := []models.PortfolioValuePoint{}
+	if err := DB.Select(&points, query, portfolioID, days); err != nil {
+		return nil, fmt.Errorf("failed to get portfolio value history: %w", err)
+	}
+	return points, nil
+}
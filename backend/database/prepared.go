@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Prepared statement cache for hot, fixed-text queries (stock lookup,
+// search, latest prices). Each statement is parsed and planned by Postgres
+// once per pool connection instead of on every call; database/sql
+// re-prepares transparently on connections that come and go. If a statement
+// can't be prepared the query runs unprepared rather than failing.
+//
+// Statements belong to the *sqlx.DB that prepared them, so the cache
+// follows whichever DB it is asked about: passing a different DB (after
+// Initialize reconnects, or a test swaps the global) closes the old
+// statements first. Caching only applies to the postgres driver and can be
+// turned off with DB_PREPARED_STATEMENTS=false, e.g. behind PgBouncer in
+// transaction pooling mode where server-side statements don't survive.
+
+type stmtCache struct {
+	mu    sync.Mutex
+	db    *sqlx.DB
+	stmts map[string]*sqlx.Stmt
+}
+
+var preparedStmts = &stmtCache{}
+
+// preparedStatementsEnabled reports whether db should use cached statements
+func preparedStatementsEnabled(db *sqlx.DB) bool {
+	return db != nil && db.DriverName() == "postgres" && os.Getenv("DB_PREPARED_STATEMENTS") != "false"
+}
+
+// get returns the cached statement for query on db, preparing it on first use
+func (c *stmtCache) get(ctx context.Context, db *sqlx.DB, query string) (*sqlx.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.db != db {
+		c.closeLocked()
+		c.db = db
+	}
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*sqlx.Stmt)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// reset closes every cached statement
+func (c *stmtCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+	c.db = nil
+}
+
+func (c *stmtCache) closeLocked() {
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmts = nil
+}
+
+// preparedGet is DB.Get through the statement cache
+func preparedGet(dest interface{}, query string, args ...interface{}) error {
+	return PreparedGetContext(context.Background(), DB, dest, query, args...)
+}
+
+// preparedSelect is DB.Select through the statement cache
+func preparedSelect(dest interface{}, query string, args ...interface{}) error {
+	return PreparedSelectContext(context.Background(), DB, dest, query, args...)
+}
+
+// PreparedGetContext runs db.GetContext using a cached prepared statement
+// for query when caching is enabled
+func PreparedGetContext(ctx context.Context, db *sqlx.DB, dest interface{}, query string, args ...interface{}) error {
+	if !preparedStatementsEnabled(db) {
+		return db.GetContext(ctx, dest, query, args...)
+	}
+	stmt, err := preparedStmts.get(ctx, db, query)
+	if err != nil {
+		log.Printf("Prepared statement unavailable, running unprepared: %v", err)
+		return db.GetContext(ctx, dest, query, args...)
+	}
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+// PreparedSelectContext runs db.SelectContext using a cached prepared
+// statement for query when caching is enabled
+func PreparedSelectContext(ctx context.Context, db *sqlx.DB, dest interface{}, query string, args ...interface{}) error {
+	if !preparedStatementsEnabled(db) {
+		return db.SelectContext(ctx, dest, query, args...)
+	}
+	stmt, err := preparedStmts.get(ctx, db, query)
+	if err != nil {
+		log.Printf("Prepared statement unavailable, running unprepared: %v", err)
+		return db.SelectContext(ctx, dest, query, args...)
+	}
+	return stmt.SelectContext(ctx, dest, args...)
+}
+
+// PreparedQueryContext runs db.QueryContext using a cached prepared
+// statement for query when caching is enabled
+func PreparedQueryContext(ctx context.Context, db *sqlx.DB, query string, args ...interface{}) (*sql.Rows, error) {
+	if !preparedStatementsEnabled(db) {
+		return db.QueryContext(ctx, query, args...)
+	}
+	stmt, err := preparedStmts.get(ctx, db, query)
+	if err != nil {
+		log.Printf("Prepared statement unavailable, running unprepared: %v", err)
+		return db.QueryContext(ctx, query, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// PreparedQueryRowContext runs db.QueryRowContext using a cached prepared
+// statement for query when caching is enabled
+func PreparedQueryRowContext(ctx context.Context, db *sqlx.DB, query string, args ...interface{}) *sqlx.Row {
+	if !preparedStatementsEnabled(db) {
+		return db.QueryRowxContext(ctx, query, args...)
+	}
+	stmt, err := preparedStmts.get(ctx, db, query)
+	if err != nil {
+		log.Printf("Prepared statement unavailable, running unprepared: %v", err)
+		return db.QueryRowxContext(ctx, query, args...)
+	}
+	return stmt.QueryRowxContext(ctx, args...)
+}
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prepareCounter is a fake connector that answers every query with a single
+// "symbol" row echoing the first argument, and counts statement lifecycles
+type prepareCounter struct {
+	prepared   atomic.Int32
+	closed     atomic.Int32
+	executed   atomic.Int32
+	prepareErr error
+	delay      time.Duration
+}
+
+func (c *prepareCounter) Connect(context.Context) (driver.Conn, error) {
+	return &prepareCounterConn{c: c}, nil
+}
+
+func (c *prepareCounter) Driver() driver.Driver { return nil }
+
+type prepareCounterConn struct{ c *prepareCounter }
+
+func (cn *prepareCounterConn) Prepare(query string) (driver.Stmt, error) {
+	if cn.c.prepareErr != nil {
+		return nil, cn.c.prepareErr
+	}
+	cn.c.prepared.Add(1)
+	return &prepareCounterStmt{c: cn.c}, nil
+}
+
+func (cn *prepareCounterConn) Close() error              { return nil }
+func (cn *prepareCounterConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (cn *prepareCounterConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	cn.c.executed.Add(1)
+	return &symbolRows{symbol: args[0].Value}, nil
+}
+
+type prepareCounterStmt struct{ c *prepareCounter }
+
+func (s *prepareCounterStmt) Close() error  { s.c.closed.Add(1); return nil }
+func (s *prepareCounterStmt) NumInput() int { return -1 }
+
+func (s *prepareCounterStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *prepareCounterStmt) Query(args []driver.Value) (driver.Rows, error) {
+	time.Sleep(s.c.delay)
+	s.c.executed.Add(1)
+	return &symbolRows{symbol: args[0]}, nil
+}
+
+type symbolRows struct {
+	symbol driver.Value
+	done   bool
+}
+
+func (r *symbolRows) Columns() []string { return []string{"symbol"} }
+func (r *symbolRows) Close() error      { return nil }
+
+func (r *symbolRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.symbol
+	return nil
+}
+
+func preparedTestDB(t *testing.T, c *prepareCounter, driverName string) *sqlx.DB {
+	t.Helper()
+	db := sqlx.NewDb(sql.OpenDB(c), driverName)
+	t.Cleanup(func() {
+		preparedStmts.reset()
+		db.Close()
+	})
+	return db
+}
+
+const preparedTestQuery = `SELECT symbol FROM tickers WHERE symbol = $1`
+
+func TestPrepared_PreparesOnceAndReuses(t *testing.T) {
+	c := &prepareCounter{}
+	db := preparedTestDB(t, c, "postgres")
+	ctx := context.Background()
+
+	for _, want := range []string{"AAPL", "MSFT", "NVDA"} {
+		var got string
+		require.NoError(t, PreparedGetContext(ctx, db, &got, preparedTestQuery, want))
+		assert.Equal(t, want, got)
+	}
+
+	var got []string
+	require.NoError(t, PreparedSelectContext(ctx, db, &got, preparedTestQuery, "TSLA"))
+	assert.Equal(t, []string{"TSLA"}, got)
+
+	var row string
+	require.NoError(t, PreparedQueryRowContext(ctx, db, preparedTestQuery, "AMD").Scan(&row))
+	assert.Equal(t, "AMD", row)
+
+	assert.Equal(t, int32(1), c.prepared.Load(), "statement prepared once per connection")
+	assert.Equal(t, int32(5), c.executed.Load())
+}
+
+func TestPrepared_NewDBReplacesStatements(t *testing.T) {
+	first := &prepareCounter{}
+	second := &prepareCounter{}
+	db1 := preparedTestDB(t, first, "postgres")
+	db2 := preparedTestDB(t, second, "postgres")
+	ctx := context.Background()
+
+	var got string
+	require.NoError(t, PreparedGetContext(ctx, db1, &got, preparedTestQuery, "AAPL"))
+	require.NoError(t, PreparedGetContext(ctx, db2, &got, preparedTestQuery, "AAPL"))
+
+	assert.Equal(t, int32(1), first.closed.Load(), "statements on the old DB are closed")
+	assert.Equal(t, int32(1), second.prepared.Load())
+	assert.Same(t, db2, preparedStmts.db)
+}
+
+func TestPrepared_DisabledRunsUnprepared(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("env opt-out", func(t *testing.T) {
+		t.Setenv("DB_PREPARED_STATEMENTS", "false")
+		c := &prepareCounter{}
+		db := preparedTestDB(t, c, "postgres")
+
+		var got string
+		require.NoError(t, PreparedGetContext(ctx, db, &got, preparedTestQuery, "AAPL"))
+		assert.Equal(t, "AAPL", got)
+		assert.Zero(t, c.prepared.Load())
+	})
+
+	t.Run("non-postgres driver", func(t *testing.T) {
+		c := &prepareCounter{}
+		db := preparedTestDB(t, c, "sqlmock")
+
+		var got string
+		require.NoError(t, PreparedGetContext(ctx, db, &got, preparedTestQuery, "AAPL"))
+		assert.Zero(t, c.prepared.Load())
+	})
+}
+
+func TestPrepared_PrepareErrorFallsBack(t *testing.T) {
+	c := &prepareCounter{prepareErr: errors.New("prepared statement does not exist")}
+	db := preparedTestDB(t, c, "postgres")
+	ctx := context.Background()
+
+	var got string
+	require.NoError(t, PreparedGetContext(ctx, db, &got, preparedTestQuery, "AAPL"))
+	assert.Equal(t, "AAPL", got)
+
+	var row string
+	require.NoError(t, PreparedQueryRowContext(ctx, db, preparedTestQuery, "MSFT").Scan(&row))
+	assert.Equal(t, "MSFT", row)
+	assert.Empty(t, preparedStmts.stmts)
+}
+
+func TestPrepared_SlowStatementLogged(t *testing.T) {
+	lines := captureSlowQueryLog(t)
+	c := &prepareCounter{delay: 30 * time.Millisecond}
+	db := sqlx.NewDb(sql.OpenDB(newTimedConnector(c, 10*time.Millisecond)), "postgres")
+	t.Cleanup(func() {
+		preparedStmts.reset()
+		db.Close()
+	})
+
+	var got string
+	require.NoError(t, PreparedGetContext(context.Background(), db, &got, preparedTestQuery, "AAPL"))
+
+	require.Len(t, *lines, 1)
+	assert.Contains(t, (*lines)[0], "slow_query op=query caller=database.TestPrepared_SlowStatementLogged")
+	assert.Contains(t, (*lines)[0], `shape="SELECT symbol FROM tickers WHERE symbol = $1"`)
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+// Sentinel errors for saved screen operations
+var (
+	ErrSavedScreenNotFound = errors.New("saved screen not found")
+	ErrSavedScreenLimit    = errors.New("saved screen limit reached")
+)
+
+// savedScreenRow is a saved_screens row with params still encoded
+type savedScreenRow struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Name      string    `db:"name"`
+	Params    []byte    `db:"params"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r savedScreenRow) toModel() (models.SavedScreen, error) {
+	screen := models.SavedScreen{
+		ID:        r.ID,
+		UserID:    r.UserID,
+		Name:      r.Name,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+	if err := json.Unmarshal(r.Params, &screen.Params); err != nil {
+		return screen, fmt.Errorf("failed to decode saved screen %s params: %w", r.ID, err)
+	}
+	return screen, nil
+}
+
+// GetSavedScreensByUserID retrieves a user's saved screens, oldest first
+func GetSavedScreensByUserID(userID string) ([]models.SavedScreen, error) {
+	query := `
+		SELECT id, user_id, name, params, created_at, updated_at
+		FROM saved_screens
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+	var rows []savedScreenRow
+	if err := DB.Select(&rows, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get saved screens: %w", err)
+	}
+
+	screens := make([]models.SavedScreen, 0, len(rows))
+	for _, row := range rows {
+		screen, err := row.toModel()
+		if err != nil {
+			return nil, err
+		}
+		screens = append(screens, screen)
+	}
+	return screens, nil
+}
+
+// GetSavedScreenByID retrieves a single saved screen owned by userID
+func GetSavedScreenByID(screenID string, userID string) (*models.SavedScreen, error) {
+	query := `
+		SELECT id, user_id, name, params, created_at, updated_at
+		FROM saved_screens
+		WHERE id = $1 AND user_id = $2
+	`
+	var row savedScreenRow
+	err := DB.Get(&row, query, screenID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrSavedScreenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved screen: %w", err)
+	}
+
+	screen, err := row.toModel()
+	if err != nil {
+		return nil, err
+	}
+	return &screen, nil
+}
+
+// CreateSavedScreenAtomic inserts screen unless the user already has
+// maxScreens saved screens (-1 = unlimited). The count check and insert run
+// in one statement so concurrent requests can't exceed the limit.
+func CreateSavedScreenAtomic(screen *models.SavedScreen, maxScreens int) error {
+	params, err := json.Marshal(screen.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved screen params: %w", err)
+	}
+
+	query := `
+		INSERT INTO saved_screens (user_id, name, params)
+		SELECT $1, $2, $3
+		WHERE $4 < 0 OR (SELECT COUNT(*) FROM saved_screens WHERE user_id = $1) < $4
+		RETURNING id, created_at, updated_at
+	`
+	err = DB.QueryRow(query, screen.UserID, screen.Name, params, maxScreens).
+		Scan(&screen.ID, &screen.CreatedAt, &screen.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: maximum %d allowed", ErrSavedScreenLimit, maxScreens)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create saved screen: %w", err)
+	}
+	return nil
+}
+
+// UpdateSavedScreen replaces a saved screen's name and params
+func UpdateSavedScreen(screen *models.SavedScreen) error {
+	params, err := json.Marshal(screen.Params)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved screen params: %w", err)
+	}
+
+	query := `
+		UPDATE saved_screens
+		SET name = $1, params = $2, updated_at = NOW()
+		WHERE id = $3 AND user_id = $4
+		RETURNING created_at, updated_at
+	`
+	err = DB.QueryRow(query, screen.Name, params, screen.ID, screen.UserID).
+		Scan(&screen.CreatedAt, &screen.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrSavedScreenNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update saved screen: %w", err)
+	}
+	return nil
+}
+
+// DeleteSavedScreen deletes a saved screen owned by userID
+func DeleteSavedScreen(screenID string, userID string) error {
+	result, err := DB.Exec(`DELETE FROM saved_screens WHERE id = $1 AND user_id = $2`, screenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved screen: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSavedScreenNotFound
+	}
+	return nil
+}
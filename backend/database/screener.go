@@ -1,10 +1,13 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"investorcenter-api/models"
+
+	"github.com/jmoiron/sqlx"
 )
 
 // ValidScreenerSortColumns defines valid columns for sorting in the screener.
@@ -75,8 +78,46 @@ func GetScreenerStocks(params models.ScreenerParams) ([]models.ScreenerStock, in
 		return nil, 0, fmt.Errorf("database not connected")
 	}
 
+	dataQuery, countQuery, args := buildScreenerQueries(&params)
+
+	var total int
+	err := DB.Get(&total, countQuery, args[:len(args)-2]...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count screener stocks: %w", err)
+	}
+
+	// Execute query
+	stocks := make([]models.ScreenerStock, 0)
+	err = DB.Select(&stocks, dataQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch screener stocks: %w", err)
+	}
+
+	return stocks, total, nil
+}
+
+// QueryScreenerStocks runs the same query as GetScreenerStocks but returns
+// the open rows instead of loading them, so large result sets can be
+// streamed. The caller must close the rows.
+func QueryScreenerStocks(ctx context.Context, params models.ScreenerParams) (*sqlx.Rows, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	dataQuery, _, args := buildScreenerQueries(&params)
+	rows, err := DB.QueryxContext(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch screener stocks: %w", err)
+	}
+	return rows, nil
+}
+
+// buildScreenerQueries builds the screener data and count queries for
+// params. args ends with the data query's LIMIT and OFFSET, which the count
+// query doesn't take.
+func buildScreenerQueries(params *models.ScreenerParams) (dataQuery, countQuery string, args []interface{}) {
 	// Build WHERE conditions using the filter registry
-	conditions, args, argIndex := BuildFilterConditions(&params, 1)
+	conditions, args, argIndex := BuildFilterConditions(params, 1)
 
 	// Build WHERE clause
 	whereClause := ""
@@ -104,13 +145,7 @@ func GetScreenerStocks(params models.ScreenerParams) ([]models.ScreenerStock, in
 	}
 
 	// Count query - simple single table scan
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM screener_data %s", whereClause)
-
-	var total int
-	err := DB.Get(&total, countQuery, args...)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count screener stocks: %w", err)
-	}
+	countQuery = fmt.Sprintf("SELECT COUNT(*) FROM screener_data %s", whereClause)
 
 	// Calculate offset
 	offset := (params.Page - 1) * params.Limit
@@ -119,7 +154,7 @@ func GetScreenerStocks(params models.ScreenerParams) ([]models.ScreenerStock, in
 	// Note: ORDER BY column and direction cannot be parameterized in PostgreSQL.
 	// Both values are validated above via allowlist (sortColumn) and strict
 	// string comparison (order), making this safe from SQL injection.
-	dataQuery := fmt.Sprintf(`
+	dataQuery = fmt.Sprintf(`
 		SELECT
 			symbol,
 			name,
@@ -165,13 +200,5 @@ func GetScreenerStocks(params models.ScreenerParams) ([]models.ScreenerStock, in
 	`, whereClause, sortColumn, order, argIndex, argIndex+1)
 
 	args = append(args, params.Limit, offset)
-
-	// Execute query
-	stocks := make([]models.ScreenerStock, 0)
-	err = DB.Select(&stocks, dataQuery, args...)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch screener stocks: %w", err)
-	}
-
-	return stocks, total, nil
+	return dataQuery, countQuery, args
 }
@@ -275,22 +275,46 @@ func GetCompanyNames(symbols []string) (map[string]string, error) {
 }
 
 // GetTickerPostsV2 returns representative posts from the V2 pipeline tables
-// (reddit_posts_raw + reddit_post_tickers) for a specific ticker.
-func GetTickerPostsV2(ticker string, sort models.SocialPostSortOption, limit int) (*models.RepresentativePostsResponse, error) {
-	if limit <= 0 {
-		limit = 10
+// (reddit_posts_raw + reddit_post_tickers) for a specific ticker, filtered,
+// sorted and paginated according to q.
+func GetTickerPostsV2(ticker string, q models.TickerPostsQuery) (*models.RepresentativePostsResponse, error) {
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+	if q.Limit > 20 {
+		q.Limit = 20
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	if q.Sort == "" {
+		q.Sort = models.SortByRecent
 	}
-	if limit > 20 {
-		limit = 20
+
+	response := &models.RepresentativePostsResponse{
+		Ticker: ticker,
+		Posts:  []models.RepresentativePost{},
+		Sort:   string(q.Sort),
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	}
+
+	// The V2 tables only hold Reddit posts; other sources have no data yet.
+	if q.Source != "" && q.Source != "reddit" {
+		return response, nil
 	}
 
 	// Build ORDER BY and optional WHERE based on sort option
 	orderBy := "rpr.posted_at DESC" // default: recent
 	sentimentFilter := ""
 
-	switch sort {
+	switch q.Sort {
 	case models.SortByEngagement:
 		orderBy = "(rpr.upvotes + rpr.comment_count * 2) DESC"
+	case models.SortByUpvotes:
+		orderBy = "rpr.upvotes DESC, rpr.posted_at DESC"
+	case models.SortByConfidence:
+		orderBy = "rpt.confidence DESC NULLS LAST, rpr.posted_at DESC"
 	case models.SortByBullish:
 		sentimentFilter = "AND rpt.sentiment = 'bullish'"
 		orderBy = "rpt.confidence DESC NULLS LAST, rpr.upvotes DESC"
@@ -301,8 +325,24 @@ func GetTickerPostsV2(ticker string, sort models.SocialPostSortOption, limit int
 		orderBy = "rpr.posted_at DESC"
 	}
 
+	args := []interface{}{ticker}
+	subredditFilter := ""
+	if q.Subreddit != "" {
+		args = append(args, q.Subreddit)
+		subredditFilter = fmt.Sprintf("AND LOWER(rpr.subreddit) = LOWER($%d)", len(args))
+	}
+
 	// Safe: sentimentFilter and orderBy are derived from a typed enum switch
-	// above (never from user input), so fmt.Sprintf here is not a SQL injection risk.
+	// above (never from user input), and the subreddit is bound as a
+	// parameter, so fmt.Sprintf here is not a SQL injection risk.
+	whereClause := fmt.Sprintf(`
+		WHERE rpt.ticker = $1
+		  AND rpr.posted_at > NOW() - INTERVAL '7 days'
+		  AND rpr.is_finance_related = true
+		  %s
+		  %s
+	`, sentimentFilter, subredditFilter)
+
 	query := fmt.Sprintf(`
 		SELECT
 			rpr.id, rpr.title, rpr.body, rpr.url, rpr.subreddit,
@@ -311,21 +351,17 @@ func GetTickerPostsV2(ticker string, sort models.SocialPostSortOption, limit int
 			rpt.confidence
 		FROM reddit_posts_raw rpr
 		JOIN reddit_post_tickers rpt ON rpt.post_id = rpr.id
-		WHERE rpt.ticker = $1
-		  AND rpr.posted_at > NOW() - INTERVAL '7 days'
-		  AND rpr.is_finance_related = true
-		  %s
+		%s
 		ORDER BY %s
-		LIMIT $2
-	`, sentimentFilter, orderBy)
+		LIMIT $%d OFFSET $%d
+	`, whereClause, orderBy, len(args)+1, len(args)+2)
 
-	rows, err := DB.Query(query, ticker, limit)
+	rows, err := DB.Query(query, append(args, q.Limit, q.Offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ticker posts: %w", err)
 	}
 	defer rows.Close()
 
-	var posts []models.RepresentativePost
 	for rows.Next() {
 		var p models.RepresentativePost
 		var body, flair sql.NullString
@@ -360,38 +396,18 @@ func GetTickerPostsV2(ticker string, sort models.SocialPostSortOption, limit int
 		p.AwardCount = 0 // V2 tables don't track awards
 		p.PostedAt = postedAt.Format(time.RFC3339)
 
-		posts = append(posts, p)
+		response.Posts = append(response.Posts, p)
 	}
 
-	// Get total count (best-effort; returns 0 on failure)
-	var total int
+	// Get total count with the same filters (best-effort; returns 0 on failure)
 	countQuery := `
 		SELECT COUNT(*)
 		FROM reddit_posts_raw rpr
 		JOIN reddit_post_tickers rpt ON rpt.post_id = rpr.id
-		WHERE rpt.ticker = $1
-		  AND rpr.posted_at > NOW() - INTERVAL '7 days'
-		  AND rpr.is_finance_related = true
-	`
-	if err := DB.QueryRow(countQuery, ticker).Scan(&total); err != nil {
+	` + whereClause
+	if err := DB.QueryRow(countQuery, args...).Scan(&response.Total); err != nil {
 		log.Printf("warn: GetTickerPostsV2: count query failed: %v", err)
 	}
 
-	// Determine sort string for response
-	sortStr := "recent"
-	switch sort {
-	case models.SortByEngagement:
-		sortStr = "engagement"
-	case models.SortByBullish:
-		sortStr = "bullish"
-	case models.SortByBearish:
-		sortStr = "bearish"
-	}
-
-	return &models.RepresentativePostsResponse{
-		Ticker: ticker,
-		Posts:  posts,
-		Total:  total,
-		Sort:   sortStr,
-	}, nil
+	return response, nil
 }
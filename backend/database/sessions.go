@@ -1,11 +1,16 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"investorcenter-api/models"
 	"time"
 )
 
+// ErrRefreshTokenReused is returned by RotateSession when the session's
+// refresh token was already exchanged
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
 // CreateSession creates a new session (refresh token)
 func CreateSession(session *models.Session) error {
 	query := `
@@ -28,10 +33,12 @@ func CreateSession(session *models.Session) error {
 	return nil
 }
 
-// GetSessionByRefreshTokenHash retrieves session by refresh token hash
+// GetSessionByRefreshTokenHash retrieves session by refresh token hash,
+// including sessions already rotated (RotatedAt set) so reuse can be caught
 func GetSessionByRefreshTokenHash(tokenHash string) (*models.Session, error) {
 	query := `
-		SELECT id, user_id, refresh_token_hash, expires_at, created_at, last_used_at, user_agent, ip_address
+		SELECT id, user_id, refresh_token_hash, expires_at, created_at, last_used_at, user_agent, ip_address,
+			family_id, rotated_at, successor_token
 		FROM sessions
 		WHERE refresh_token_hash = $1 AND expires_at > $2
 	`
@@ -45,6 +52,9 @@ func GetSessionByRefreshTokenHash(tokenHash string) (*models.Session, error) {
 		&session.LastUsedAt,
 		&session.UserAgent,
 		&session.IPAddress,
+		&session.FamilyID,
+		&session.RotatedAt,
+		&session.SuccessorToken,
 	)
 
 	if err != nil {
@@ -53,6 +63,55 @@ func GetSessionByRefreshTokenHash(tokenHash string) (*models.Session, error) {
 	return session, nil
 }
 
+// RotateSession exchanges current's refresh token: it marks current rotated,
+// storing sealedSuccessor (next's refresh token, sealed) on it, and creates
+// next in the same family, filling in next's ID and timestamps. It returns
+// ErrRefreshTokenReused if current was already rotated, including by a
+// concurrent request.
+func RotateSession(current, next *models.Session, sealedSuccessor string) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		`UPDATE sessions SET rotated_at = $1, last_used_at = $1, successor_token = $2 WHERE id = $3 AND rotated_at IS NULL`,
+		now, sealedSuccessor, current.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrRefreshTokenReused
+	}
+
+	next.FamilyID = current.FamilyID
+	err = tx.QueryRow(`
+		INSERT INTO sessions (user_id, refresh_token_hash, expires_at, user_agent, ip_address, family_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, last_used_at`,
+		next.UserID, next.RefreshTokenHash, next.ExpiresAt, next.UserAgent, next.IPAddress, next.FamilyID,
+	).Scan(&next.ID, &next.CreatedAt, &next.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create rotated session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit session rotation: %w", err)
+	}
+	return nil
+}
+
+// DeleteSessionFamily deletes a session together with the sessions rotated
+// into or out of it (logout)
+func DeleteSessionFamily(familyID string) error {
+	query := `DELETE FROM sessions WHERE family_id = $1`
+	_, err := DB.Exec(query, familyID)
+	return err
+}
+
 // UpdateSessionLastUsed updates the last_used_at timestamp
 func UpdateSessionLastUsed(sessionID string) error {
 	query := `UPDATE sessions SET last_used_at = $1 WHERE id = $2`
@@ -80,3 +139,32 @@ func CleanupExpiredSessions() error {
 	_, err := DB.Exec(query, time.Now())
 	return err
 }
+
+// GetUserSessions lists a user's current (not rotated) sessions, newest
+// first. The refresh token hash is not selected.
+func GetUserSessions(userID string) ([]models.SessionExport, error) {
+	query := `
+		SELECT id, created_at, last_used_at, expires_at, user_agent, ip_address
+		FROM sessions
+		WHERE user_id = $1 AND rotated_at IS NULL
+		ORDER BY created_at DESC
+	`
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.SessionExport{}
+	for rows.Next() {
+		var s models.SessionExport
+		if err := rows.Scan(&s.ID, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.UserAgent, &s.IPAddress); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+	return sessions, nil
+}
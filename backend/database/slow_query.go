@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"expvar"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Slow query logging. Every query and exec on the connection pool is timed
+// at the driver level, so all of DB.Query/Get/Select/Exec, transactions and
+// cached prepared statements are covered without touching call sites.
+// Statements slower than DB_SLOW_QUERY_MS (default 500, 0 disables) are
+// logged with the calling function and the statement shape, and counted in
+// the db_slow_queries expvar map keyed by caller. Query arguments are never logged.
+
+const (
+	defaultSlowQueryThreshold = 500 * time.Millisecond
+	maxSlowQueryShapeLen      = 300
+)
+
+var (
+	slowQueryCount      = expvar.NewInt("db_slow_queries_total")
+	slowQueryCountByFn  = expvar.NewMap("db_slow_queries")
+	slowQueryLogPrinter = log.Printf
+)
+
+// LoadSlowQueryThreshold reads DB_SLOW_QUERY_MS
+func LoadSlowQueryThreshold() time.Duration {
+	v := os.Getenv("DB_SLOW_QUERY_MS")
+	if v == "" {
+		return defaultSlowQueryThreshold
+	}
+	ms, err := strconv.Atoi(v)
+	if err != nil || ms < 0 {
+		log.Printf("⚠️  Invalid DB_SLOW_QUERY_MS %q, using %v", v, defaultSlowQueryThreshold)
+		return defaultSlowQueryThreshold
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// SlowQueryCounts returns how many slow queries each caller has issued
+// since the process started
+func SlowQueryCounts() map[string]int64 {
+	counts := make(map[string]int64)
+	slowQueryCountByFn.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			counts[kv.Key] = v.Value()
+		}
+	})
+	return counts
+}
+
+// timedConnector wraps a driver.Connector so its connections time queries
+type timedConnector struct {
+	driver.Connector
+	threshold time.Duration
+}
+
+func newTimedConnector(c driver.Connector, threshold time.Duration) driver.Connector {
+	if threshold <= 0 {
+		return c
+	}
+	return &timedConnector{Connector: c, threshold: threshold}
+}
+
+func (t *timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := t.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn, threshold: t.threshold}, nil
+}
+
+// timedConn times QueryContext and ExecContext and passes everything else
+// through to the wrapped connection. Query timing covers execution up to
+// the first row, not the caller's iteration over the result.
+type timedConn struct {
+	driver.Conn
+	threshold time.Duration
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	c.observe("query", query, time.Since(start))
+	return rows, err
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+	c.observe("exec", query, time.Since(start))
+	return result, err
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// timedStmt times executions of a prepared statement, so queries that go
+// through the statement cache are still logged when slow
+type timedStmt struct {
+	driver.Stmt
+	conn  *timedConn
+	query string
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedValuesToValues(args)) //nolint:staticcheck // fallback for drivers without context support
+	}
+	s.conn.observe("query", s.query, time.Since(start))
+	return rows, err
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(namedValuesToValues(args)) //nolint:staticcheck // fallback for drivers without context support
+	}
+	s.conn.observe("exec", s.query, time.Since(start))
+	return result, err
+}
+
+func namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+func (c *timedConn) observe(op, query string, elapsed time.Duration) {
+	if elapsed < c.threshold {
+		return
+	}
+	caller := slowQueryCaller()
+	slowQueryCount.Add(1)
+	slowQueryCountByFn.Add(caller, 1)
+	slowQueryLogPrinter("slow_query op=%s caller=%s duration_ms=%d threshold_ms=%d shape=%q",
+		op, caller, elapsed.Milliseconds(), c.threshold.Milliseconds(), queryShape(query))
+}
+
+// slowQueryCaller names the first function on the stack outside the SQL
+// plumbing, e.g. "database.GetFundamentals" or
+// "handlers.(*AdminDataHandler).GetFundamentals"
+func slowQueryCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		fn := frame.Function
+		if !isSQLPlumbing(fn) {
+			if i := strings.LastIndex(fn, "/"); i >= 0 {
+				fn = fn[i+1:]
+			}
+			return fn
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func isSQLPlumbing(fn string) bool {
+	return strings.HasPrefix(fn, "database/sql.") ||
+		strings.HasPrefix(fn, "github.com/jmoiron/sqlx.") ||
+		strings.HasPrefix(fn, "investorcenter-api/database.(*timedConn)") ||
+		strings.HasPrefix(fn, "investorcenter-api/database.(*timedStmt)") ||
+		strings.HasPrefix(fn, "investorcenter-api/database.Prepared") ||
+		strings.HasPrefix(fn, "investorcenter-api/database.prepared") ||
+		strings.HasPrefix(fn, "investorcenter-api/database.slowQueryCaller") ||
+		strings.HasPrefix(fn, "runtime.")
+}
+
+// queryShape collapses whitespace and truncates a statement for logging.
+// Bind parameters ($1, $2, ...) keep the values themselves out of the log.
+func queryShape(query string) string {
+	shape := strings.Join(strings.Fields(query), " ")
+	if len(shape) > maxSlowQueryShapeLen {
+		shape = shape[:maxSlowQueryShapeLen] + "..."
+	}
+	return shape
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// delayConnector hands out connections whose queries take delay to run
+type delayConnector struct{ delay time.Duration }
+
+func (c *delayConnector) Connect(context.Context) (driver.Conn, error) {
+	return &delayConn{delay: c.delay}, nil
+}
+
+func (c *delayConnector) Driver() driver.Driver { return nil }
+
+type delayConn struct{ delay time.Duration }
+
+func (c *delayConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *delayConn) Close() error                        { return nil }
+func (c *delayConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c *delayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	time.Sleep(c.delay)
+	return &emptyRows{}, nil
+}
+
+func (c *delayConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(c.delay)
+	return driver.RowsAffected(1), nil
+}
+
+type emptyRows struct{}
+
+func (r *emptyRows) Columns() []string              { return []string{"id"} }
+func (r *emptyRows) Close() error                   { return nil }
+func (r *emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+// captureSlowQueryLog records slow-query log lines for the test
+func captureSlowQueryLog(t *testing.T) *[]string {
+	t.Helper()
+	var lines []string
+	orig := slowQueryLogPrinter
+	slowQueryLogPrinter = func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	t.Cleanup(func() { slowQueryLogPrinter = orig })
+	return &lines
+}
+
+func timedTestDB(delay, threshold time.Duration) *sqlx.DB {
+	return sqlx.NewDb(sql.OpenDB(newTimedConnector(&delayConnector{delay: delay}, threshold)), "postgres")
+}
+
+func TestSlowQuery_LogsSlowStatement(t *testing.T) {
+	lines := captureSlowQueryLog(t)
+	db := timedTestDB(30*time.Millisecond, 10*time.Millisecond)
+	defer db.Close()
+	before := SlowQueryCounts()["database.TestSlowQuery_LogsSlowStatement"]
+
+	var ids []string
+	require.NoError(t, db.Select(&ids, `
+		SELECT id
+		FROM users
+		WHERE email = $1`, "secret@example.com"))
+
+	require.Len(t, *lines, 1)
+	line := (*lines)[0]
+	assert.Contains(t, line, "slow_query op=query caller=database.TestSlowQuery_LogsSlowStatement")
+	assert.Contains(t, line, `shape="SELECT id FROM users WHERE email = $1"`)
+	assert.Contains(t, line, "threshold_ms=10")
+	assert.NotContains(t, line, "secret@example.com", "query arguments must not be logged")
+	assert.Equal(t, before+1, SlowQueryCounts()["database.TestSlowQuery_LogsSlowStatement"])
+
+	_, err := db.Exec(`UPDATE users SET is_active = FALSE WHERE id = $1`, "u1")
+	require.NoError(t, err)
+	require.Len(t, *lines, 2)
+	assert.Contains(t, (*lines)[1], "slow_query op=exec")
+}
+
+func TestSlowQuery_FastStatementNotLogged(t *testing.T) {
+	lines := captureSlowQueryLog(t)
+	db := timedTestDB(0, time.Second)
+	defer db.Close()
+
+	_, err := db.Exec(`SELECT 1`)
+	require.NoError(t, err)
+	assert.Empty(t, *lines)
+}
+
+func TestSlowQuery_ZeroThresholdDisables(t *testing.T) {
+	c := &delayConnector{}
+	assert.Same(t, driver.Connector(c), newTimedConnector(c, 0))
+}
+
+func TestLoadSlowQueryThreshold(t *testing.T) {
+	t.Setenv("DB_SLOW_QUERY_MS", "")
+	assert.Equal(t, 500*time.Millisecond, LoadSlowQueryThreshold())
+
+	t.Setenv("DB_SLOW_QUERY_MS", "1500")
+	assert.Equal(t, 1500*time.Millisecond, LoadSlowQueryThreshold())
+
+	t.Setenv("DB_SLOW_QUERY_MS", "0")
+	assert.Equal(t, time.Duration(0), LoadSlowQueryThreshold())
+
+	t.Setenv("DB_SLOW_QUERY_MS", "fast")
+	assert.Equal(t, 500*time.Millisecond, LoadSlowQueryThreshold())
+}
+
+func TestQueryShape_Truncates(t *testing.T) {
+	long := "SELECT " + fmt.Sprintf("%0400d", 0)
+	shape := queryShape(long)
+	assert.Len(t, shape, maxSlowQueryShapeLen+3)
+	assert.Equal(t, "...", shape[len(shape)-3:])
+}
@@ -16,6 +16,11 @@ const redditPostBaseFilter = `
 	AND COALESCE(r.spam_score, 0) < 0.5
 `
 
+// Per-post weight used by engagement-weighted sentiment. Log-scaled so a
+// 5000-upvote post outweighs a 10-upvote post without drowning out the
+// rest of the day; the +1 keeps zero-engagement posts in the average.
+const redditPostEngagementWeight = `(1 + LN(1 + GREATEST(r.upvotes, 0) + GREATEST(r.comment_count, 0) * 2))`
+
 // Whitelist maps for GetTrendingTickers — prevents SQL injection by mapping
 // user-supplied period strings to known-safe SQL interval literals.
 type trendingPeriod struct {
@@ -248,8 +253,124 @@ func GetSentimentHistory(ticker string, days int) (*models.SentimentHistoryRespo
 	}, nil
 }
 
-// GetTrendingTickers returns the most active tickers by social media activity
-func GetTrendingTickers(period string, limit int) (*models.TrendingResponse, error) {
+// GetWeightedSentimentHistory returns daily sentiment for a ticker with each
+// post weighted by engagement (upvotes and comments) instead of counted once.
+// Bullish/bearish/neutral remain raw post counts; only Score is weighted.
+func GetWeightedSentimentHistory(ticker string, days int) ([]models.SentimentHistoryPoint, error) {
+	if days <= 0 {
+		days = 7
+	}
+	if days > 90 {
+		days = 90
+	}
+
+	query := `
+		SELECT
+			DATE(r.posted_at) as date,
+			COALESCE(SUM(
+				CASE t.sentiment
+					WHEN 'bullish' THEN 1
+					WHEN 'bearish' THEN -1
+					ELSE 0
+				END * ` + redditPostEngagementWeight + `
+			) / NULLIF(SUM(` + redditPostEngagementWeight + `), 0), 0) as score,
+			COUNT(*) as post_count,
+			COUNT(*) FILTER (WHERE t.sentiment = 'bullish') as bullish,
+			COUNT(*) FILTER (WHERE t.sentiment = 'bearish') as bearish,
+			COUNT(*) FILTER (WHERE t.sentiment = 'neutral' OR t.sentiment IS NULL) as neutral
+		FROM reddit_post_tickers t
+		JOIN reddit_posts_raw r ON t.post_id = r.id
+		WHERE t.ticker = $1
+		  AND r.posted_at > NOW() - $2::INTEGER * INTERVAL '1 day'
+		  ` + redditPostBaseFilter + `
+		GROUP BY DATE(r.posted_at)
+		ORDER BY date ASC
+	`
+
+	rows, err := DB.Query(query, ticker, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weighted sentiment history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []models.SentimentHistoryPoint{}
+	for rows.Next() {
+		var point models.SentimentHistoryPoint
+		var date time.Time
+		err := rows.Scan(&date, &point.Score, &point.PostCount, &point.Bullish, &point.Bearish, &point.Neutral)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan weighted sentiment history: %w", err)
+		}
+		point.Date = date.Format("2006-01-02")
+		history = append(history, point)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating weighted sentiment history: %w", err)
+	}
+
+	return history, nil
+}
+
+// postCountFilter returns the engagement filter for opts as a WHERE fragment
+// with a leading AND, using placeholders starting at $next, plus its args.
+// Unset minimums add nothing so negatively-scored posts still count.
+func postCountFilter(opts models.PostCountOptions, next int) (string, []interface{}) {
+	var clause string
+	var args []interface{}
+	if opts.MinUpvotes > 0 {
+		clause += fmt.Sprintf(" AND r.upvotes >= $%d", next+len(args))
+		args = append(args, opts.MinUpvotes)
+	}
+	if opts.MinComments > 0 {
+		clause += fmt.Sprintf(" AND r.comment_count >= $%d", next+len(args))
+		args = append(args, opts.MinComments)
+	}
+	return clause, args
+}
+
+// postCountExpr is the aggregate used as a ticker's post count: one per post,
+// or the engagement weight per post when opts.EngagementWeighted is set.
+func postCountExpr(opts models.PostCountOptions) string {
+	if opts.EngagementWeighted {
+		return "COALESCE(SUM(" + redditPostEngagementWeight + "), 0)"
+	}
+	return "COUNT(*)"
+}
+
+// GetPostCountByTicker returns how many posts mentioned ticker in the last
+// days days, after the engagement filters in opts. With EngagementWeighted
+// the result is the sum of per-post engagement weights rather than a count.
+func GetPostCountByTicker(ticker string, days int, opts models.PostCountOptions) (float64, error) {
+	if days <= 0 {
+		days = 7
+	}
+	if days > 90 {
+		days = 90
+	}
+
+	filter, filterArgs := postCountFilter(opts, 3)
+	query := `
+		SELECT ` + postCountExpr(opts) + `
+		FROM reddit_post_tickers t
+		JOIN reddit_posts_raw r ON t.post_id = r.id
+		WHERE t.ticker = $1
+		  AND r.posted_at > NOW() - $2::INTEGER * INTERVAL '1 day'
+		  ` + redditPostBaseFilter + filter
+
+	args := append([]interface{}{ticker, days}, filterArgs...)
+
+	var count float64
+	if err := DB.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to get post count: %w", err)
+	}
+	return count, nil
+}
+
+// GetTrendingTickers returns the most active tickers by social media activity.
+// opts filters out low-engagement posts and, when EngagementWeighted is set,
+// ranks tickers and computes mention deltas by weighted count instead.
+func GetTrendingTickers(period string, limit int, opts models.PostCountOptions) (*models.TrendingResponse, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -265,6 +386,9 @@ func GetTrendingTickers(period string, limit int) (*models.TrendingResponse, err
 		tp = trendingIntervals["24h"]
 	}
 
+	filter, filterArgs := postCountFilter(opts, 2)
+	countExpr := postCountExpr(opts)
+
 	query := fmt.Sprintf(`
 		WITH current_period AS (
 			SELECT
@@ -276,22 +400,23 @@ func GetTrendingTickers(period string, limit int) (*models.TrendingResponse, err
 						ELSE 0
 					END
 				), 0) as score,
-				COUNT(*) as post_count
+				COUNT(*) as post_count,
+				`+countExpr+` as ranked_count
 			FROM reddit_post_tickers t
 			JOIN reddit_posts_raw r ON t.post_id = r.id
 			WHERE r.posted_at > NOW() - INTERVAL '%s'
-			  `+redditPostBaseFilter+`
+			  `+redditPostBaseFilter+filter+`
 			GROUP BY t.ticker
 		),
 		previous_period AS (
 			SELECT
 				t.ticker,
-				COUNT(*) as post_count
+				`+countExpr+` as ranked_count
 			FROM reddit_post_tickers t
 			JOIN reddit_posts_raw r ON t.post_id = r.id
 			WHERE r.posted_at > NOW() - INTERVAL '%s'
 			  AND r.posted_at <= NOW() - INTERVAL '%s'
-			  `+redditPostBaseFilter+`
+			  `+redditPostBaseFilter+filter+`
 			GROUP BY t.ticker
 		)
 		SELECT
@@ -299,9 +424,10 @@ func GetTrendingTickers(period string, limit int) (*models.TrendingResponse, err
 			COALESCE(s.name, '') as company_name,
 			c.score,
 			c.post_count,
+			c.ranked_count::float,
 			COALESCE(
-				CASE WHEN p.post_count > 0
-					THEN ((c.post_count::float - p.post_count::float) / p.post_count::float) * 100
+				CASE WHEN p.ranked_count > 0
+					THEN ((c.ranked_count::float - p.ranked_count::float) / p.ranked_count::float) * 100
 					ELSE 100
 				END,
 				100
@@ -310,11 +436,12 @@ func GetTrendingTickers(period string, limit int) (*models.TrendingResponse, err
 		LEFT JOIN previous_period p ON c.ticker = p.ticker
 		LEFT JOIN tickers s ON c.ticker = s.symbol
 		WHERE c.post_count >= 3
-		ORDER BY c.post_count DESC
+		ORDER BY c.ranked_count DESC
 		LIMIT $1
 	`, tp.interval, tp.previousInterval, tp.interval)
 
-	rows, err := DB.Query(query, limit)
+	args := append([]interface{}{limit}, filterArgs...)
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trending tickers: %w", err)
 	}
@@ -324,10 +451,14 @@ func GetTrendingTickers(period string, limit int) (*models.TrendingResponse, err
 	rank := 1
 	for rows.Next() {
 		var t models.TrendingTicker
-		err := rows.Scan(&t.Ticker, &t.CompanyName, &t.Score, &t.PostCount, &t.MentionDelta)
+		var rankedCount float64
+		err := rows.Scan(&t.Ticker, &t.CompanyName, &t.Score, &t.PostCount, &rankedCount, &t.MentionDelta)
 		if err != nil {
 			continue
 		}
+		if opts.EngagementWeighted {
+			t.WeightedCount = rankedCount
+		}
 		t.Label = models.GetSentimentLabel(t.Score)
 		t.Rank = rank
 		rank++
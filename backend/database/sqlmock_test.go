@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"regexp"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 )
 
 // setupMock creates a sqlmock DB, wraps it in sqlx, and assigns it to the
@@ -459,11 +461,11 @@ func TestGetSessionByRefreshTokenHash(t *testing.T) {
 		agent := "Mozilla/5.0"
 		ip := "127.0.0.1"
 
-		cols := []string{"id", "user_id", "refresh_token_hash", "expires_at", "created_at", "last_used_at", "user_agent", "ip_address"}
+		cols := []string{"id", "user_id", "refresh_token_hash", "expires_at", "created_at", "last_used_at", "user_agent", "ip_address", "family_id", "rotated_at", "successor_token"}
 		mock.ExpectQuery(`SELECT .+ FROM sessions WHERE refresh_token_hash = \$1`).
 			WithArgs("tokenhash", sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows(cols).
-				AddRow("sess-1", "user-1", "tokenhash", expires, now, now, &agent, &ip))
+				AddRow("sess-1", "user-1", "tokenhash", expires, now, now, &agent, &ip, "family-1", now, "sealed"))
 
 		session, err := GetSessionByRefreshTokenHash("tokenhash")
 		if err != nil {
@@ -472,6 +474,12 @@ func TestGetSessionByRefreshTokenHash(t *testing.T) {
 		if session.ID != "sess-1" {
 			t.Fatalf("expected sess-1, got %s", session.ID)
 		}
+		if session.FamilyID != "family-1" || session.RotatedAt == nil {
+			t.Fatalf("expected rotated session in family-1, got %+v", session)
+		}
+		if session.SuccessorToken == nil || *session.SuccessorToken != "sealed" {
+			t.Fatalf("expected the sealed successor, got %v", session.SuccessorToken)
+		}
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Fatalf("unmet expectations: %v", err)
 		}
@@ -493,6 +501,85 @@ func TestGetSessionByRefreshTokenHash(t *testing.T) {
 	})
 }
 
+func TestRotateSession(t *testing.T) {
+	current := &models.Session{ID: "sess-1", UserID: "user-1", FamilyID: "family-1"}
+
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+		expires := now.Add(24 * time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE sessions SET rotated_at = \$1, last_used_at = \$1, successor_token = \$2 WHERE id = \$3 AND rotated_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), "sealed-next", "sess-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO sessions .+ family_id`).
+			WithArgs("user-1", "newhash", expires, sqlmock.AnyArg(), sqlmock.AnyArg(), "family-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).AddRow("sess-2", now, now))
+		mock.ExpectCommit()
+
+		next := &models.Session{UserID: "user-1", RefreshTokenHash: "newhash", ExpiresAt: expires}
+		if err := RotateSession(current, next, "sealed-next"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if next.ID != "sess-2" || next.FamilyID != "family-1" {
+			t.Fatalf("expected sess-2 in family-1, got %+v", next)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("already_rotated", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE sessions SET rotated_at`).
+			WithArgs(sqlmock.AnyArg(), "sealed-next", "sess-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := RotateSession(current, &models.Session{UserID: "user-1", RefreshTokenHash: "newhash"}, "sealed-next")
+		if !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("insert_error_rolls_back", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE sessions SET rotated_at`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO sessions`).
+			WillReturnError(errors.New("insert failed"))
+		mock.ExpectRollback()
+
+		err := RotateSession(current, &models.Session{UserID: "user-1", RefreshTokenHash: "newhash"}, "sealed-next")
+		if err == nil || !contains(err.Error(), "failed to create rotated session") {
+			t.Fatalf("expected insert error, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteSessionFamily(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectExec(`DELETE FROM sessions WHERE family_id = \$1`).
+		WithArgs("family-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	if err := DeleteSessionFamily("family-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
 func TestDeleteSession(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mock := setupMock(t)
@@ -604,11 +691,14 @@ func TestGetStockBySymbol(t *testing.T) {
 func TestSearchStocks(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mock := setupMock(t)
-		mock.ExpectQuery(`SELECT .+ FROM tickers WHERE`).
-			WithArgs("%AAPL%", "%AAPL%", "AAPL", "AAPL%", "%AAPL%", 10).
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL .+ reddit_ticker_rankings .+ WHERE`).
+			WithArgs("%AAPL%", "%AAPL%", "AAPL", "AAPL%", "%AAPL%", 10, SearchBoost.MarketCapWeight, SearchBoost.SocialWeight, false, SearchBoost.VolumeWeight, sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows(stockColumns()).AddRow(stockRow()...))
+		mock.ExpectQuery(`similarity`).
+			WithArgs("AAPL", FuzzySearchThreshold, sqlmock.AnyArg(), 9, false, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
 
-		stocks, err := SearchStocks("AAPL", 10)
+		stocks, err := SearchStocks("AAPL", 10, false)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -622,11 +712,13 @@ func TestSearchStocks(t *testing.T) {
 
 	t.Run("empty_results", func(t *testing.T) {
 		mock := setupMock(t)
-		mock.ExpectQuery(`SELECT .+ FROM tickers WHERE`).
-			WithArgs("%ZZZZZ%", "%ZZZZZ%", "ZZZZZ", "ZZZZZ%", "%ZZZZZ%", 10).
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL .+ reddit_ticker_rankings .+ WHERE`).
+			WithArgs("%ZZZZZ%", "%ZZZZZ%", "ZZZZZ", "ZZZZZ%", "%ZZZZZ%", 10, SearchBoost.MarketCapWeight, SearchBoost.SocialWeight, false, SearchBoost.VolumeWeight, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+		mock.ExpectQuery(`similarity`).
 			WillReturnRows(sqlmock.NewRows(stockColumns()))
 
-		stocks, err := SearchStocks("ZZZZZ", 10)
+		stocks, err := SearchStocks("ZZZZZ", 10, false)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -639,6 +731,168 @@ func TestSearchStocks(t *testing.T) {
 	})
 }
 
+func TestSearchSecurities(t *testing.T) {
+	t.Run("types_filter_and_match_reason", func(t *testing.T) {
+		mock := setupMock(t)
+		columns := append(stockColumns(), "match_reason")
+		rows := sqlmock.NewRows(columns)
+		for _, reason := range []string{"symbol", "symbol", "name"} {
+			rows.AddRow(append(stockRow(), reason)...)
+		}
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL .+ WHERE .+ ANY\(\$11\)`).
+			WithArgs("%A%", "%A%", "A", "A%", "%A%", 10, SearchBoost.MarketCapWeight, SearchBoost.SocialWeight, false,
+				SearchBoost.VolumeWeight, pq.Array([]string{"stock", "etf"})).
+			WillReturnRows(rows)
+
+		results, err := SearchSecurities("A", SearchOptions{Types: []string{"stock", "etf"}, Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 3 || results[0].Symbol != "AAPL" {
+			t.Fatalf("expected 3 results led by AAPL, got %+v", results)
+		}
+		if results[0].MatchReason != "symbol" || results[2].MatchReason != "name" {
+			t.Fatalf("unexpected match reasons: %q, %q", results[0].MatchReason, results[2].MatchReason)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("fuzzy_keeps_types_filter", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL`).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+		mock.ExpectQuery(`similarity`).
+			WithArgs("Bitcon", FuzzySearchThreshold, sqlmock.AnyArg(), 10, false, pq.Array([]string{"crypto"})).
+			WillReturnRows(sqlmock.NewRows(append(stockColumns(), "match_reason")).AddRow(append(stockRow(), "name")...))
+
+		results, err := SearchSecurities("Bitcon", SearchOptions{Types: []string{"crypto"}, Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 1 || results[0].MatchReason != "name" {
+			t.Fatalf("expected one name match, got %+v", results)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("fuzzy_forced_tops_up_with_min_similarity", func(t *testing.T) {
+		mock := setupMock(t)
+		rows := sqlmock.NewRows(append(stockColumns(), "match_reason"))
+		for i := 0; i < 3; i++ {
+			rows.AddRow(append(stockRow(), "symbol")...)
+		}
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL`).WillReturnRows(rows)
+		mock.ExpectQuery(`similarity`).
+			WithArgs("A", 0.5, sqlmock.AnyArg(), 7, false, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+
+		fuzzy := true
+		if _, err := SearchSecurities("A", SearchOptions{Limit: 10, Fuzzy: &fuzzy, MinSimilarity: 0.5}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("fuzzy_disabled_skips_fallback", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL`).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+
+		fuzzy := false
+		results, err := SearchSecurities("Appel", SearchOptions{Limit: 10, Fuzzy: &fuzzy})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 0 {
+			t.Fatalf("expected no results without fuzzy fallback, got %+v", results)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("empty_results_not_nil", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL`).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+		mock.ExpectQuery(`similarity`).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+
+		results, err := SearchSecurities("ZZZZZ", SearchOptions{Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if results == nil || len(results) != 0 {
+			t.Fatalf("expected empty non-nil results, got %#v", results)
+		}
+	})
+}
+
+func TestSearchStocks_FuzzyFallback(t *testing.T) {
+	t.Run("typo_surfaces_similar_ticker", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL .+ reddit_ticker_rankings .+ WHERE`).
+			WithArgs("%Appel%", "%Appel%", "Appel", "Appel%", "%Appel%", 10, SearchBoost.MarketCapWeight, SearchBoost.SocialWeight, false, SearchBoost.VolumeWeight, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(stockColumns()))
+		mock.ExpectQuery(`similarity`).
+			WithArgs("Appel", FuzzySearchThreshold, sqlmock.AnyArg(), 10, false, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(stockColumns()).AddRow(stockRow()...))
+
+		stocks, err := SearchStocks("Appel", 10, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(stocks) != 1 || stocks[0].Symbol != "AAPL" {
+			t.Fatalf("expected fuzzy match AAPL, got %+v", stocks)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("skipped_when_enough_direct_matches", func(t *testing.T) {
+		mock := setupMock(t)
+		rows := sqlmock.NewRows(stockColumns())
+		for i := 0; i < fuzzySearchMinResults; i++ {
+			rows.AddRow(stockRow()...)
+		}
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL .+ reddit_ticker_rankings .+ WHERE`).WillReturnRows(rows)
+
+		stocks, err := SearchStocks("A", 10, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(stocks) != fuzzySearchMinResults {
+			t.Fatalf("expected %d stocks, got %d", fuzzySearchMinResults, len(stocks))
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("fuzzy_error_keeps_direct_matches", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM tickers LEFT JOIN LATERAL .+ reddit_ticker_rankings .+ WHERE`).
+			WillReturnRows(sqlmock.NewRows(stockColumns()).AddRow(stockRow()...))
+		mock.ExpectQuery(`similarity`).
+			WillReturnError(errors.New("function similarity does not exist"))
+
+		stocks, err := SearchStocks("AAPL", 10, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(stocks) != 1 {
+			t.Fatalf("expected 1 stock, got %d", len(stocks))
+		}
+	})
+}
+
 // popularStockColumns returns columns from GetPopularStocks (no asset_type, no logo_url).
 func popularStockColumns() []string {
 	return []string{
@@ -661,11 +915,11 @@ func TestGetPopularStocks(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mock := setupMock(t)
 		mock.ExpectQuery(`SELECT .+ FROM tickers WHERE symbol IN`).
-			WithArgs(10).
+			WithArgs(10, false).
 			WillReturnRows(sqlmock.NewRows(popularStockColumns()).
 				AddRow(popularStockRow()...))
 
-		stocks, err := GetPopularStocks(10)
+		stocks, err := GetPopularStocks(10, false)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -712,6 +966,7 @@ func notifPrefColumns() []string {
 		"digest_include_recent_alerts", "digest_include_news_highlights",
 		"quiet_hours_enabled", "quiet_hours_start", "quiet_hours_end",
 		"quiet_hours_timezone", "max_alerts_per_day", "max_emails_per_day",
+		"webhook_url", "webhook_secret", "sms_enabled", "phone_number", "phone_verified",
 		"created_at", "updated_at",
 	}
 }
@@ -729,6 +984,7 @@ func notifPrefRow() []driver.Value {
 		true, true,
 		false, "22:00", "06:00",
 		"UTC", 100, 50,
+		nil, nil, false, nil, false,
 		now, now,
 	}
 }
@@ -1082,6 +1338,7 @@ func alertRuleColumns() []string {
 		"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol", "alert_type",
 		"conditions", "is_active", "frequency", "notify_email", "notify_in_app",
 		"name", "description", "last_triggered_at", "trigger_count", "created_at", "updated_at",
+		"snoozed_until",
 	}
 }
 
@@ -1091,7 +1348,7 @@ func alertRuleRow() []driver.Value {
 	return []driver.Value{
 		"alert-1", "user-1", "wl-1", nil, "AAPL", "price_above",
 		conditions, true, "once", true, true,
-		"AAPL above 150", nil, nil, 0, now, now,
+		"AAPL above 150", nil, nil, 0, now, now, nil,
 	}
 }
 
@@ -1243,6 +1500,42 @@ func TestCountAlertRulesByUserID(t *testing.T) {
 	})
 }
 
+func TestCountAlertRulesForSymbol(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM alert_rules WHERE user_id = \$1 AND symbol = \$2`).
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := CountAlertRulesForSymbol("user-1", "AAPL")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3, got %d", count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestCountAlertRulesBySymbol(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectQuery(`SELECT symbol, COUNT\(\*\) FROM alert_rules WHERE user_id = \$1 GROUP BY symbol`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "count"}).AddRow("AAPL", 10).AddRow("MSFT", 1))
+
+	counts, err := CountAlertRulesBySymbol("user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counts["AAPL"] != 10 || counts["MSFT"] != 1 || len(counts) != 2 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
 func TestAlertExistsForSymbol(t *testing.T) {
 	t.Run("exists", func(t *testing.T) {
 		mock := setupMock(t)
@@ -1854,16 +2147,1096 @@ func TestGetUserTags(t *testing.T) {
 	})
 }
 
-// contains is a helper that checks if a string contains a substring.
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsImpl(s, substr))
-}
+// ---------------------------------------------------------------------------
+// social_posts.go — engagement-filtered post counts
+// ---------------------------------------------------------------------------
 
-func containsImpl(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
+func TestGetPostCountByTicker(t *testing.T) {
+	t.Run("raw count", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM reddit_post_tickers`).
+			WithArgs("GME", 7).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+		count, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 12 {
+			t.Fatalf("expected 12, got %v", count)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("filtered and weighted", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(.+LN.+r\.upvotes >= \$3 AND r\.comment_count >= \$4`).
+			WithArgs("GME", 30, 100, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(17.5))
+
+		count, err := GetPostCountByTicker("GME", 30, models.PostCountOptions{
+			MinUpvotes: 100, MinComments: 5, EngagementWeighted: true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count != 17.5 {
+			t.Fatalf("expected 17.5, got %v", count)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("days clamped", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT COUNT`).
+			WithArgs("GME", 90).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		if _, err := GetPostCountByTicker("GME", 365, models.PostCountOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT COUNT`).WillReturnError(errors.New("boom"))
+
+		if _, err := GetPostCountByTicker("GME", 7, models.PostCountOptions{}); err == nil {
+			t.Fatal("expected error")
 		}
+	})
+}
+
+func TestGetTrendingTickers_WeightedOptions(t *testing.T) {
+	mock := setupMock(t)
+	rows := sqlmock.NewRows([]string{"ticker", "company_name", "score", "post_count", "ranked_count", "mention_delta"}).
+		AddRow("GME", "GameStop", 0.5, 4, 21.3, 50.0)
+	mock.ExpectQuery(`ORDER BY c\.ranked_count DESC`).
+		WithArgs(10, 50).
+		WillReturnRows(rows)
+
+	resp, err := GetTrendingTickers("24h", 10, models.PostCountOptions{MinUpvotes: 50, EngagementWeighted: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Tickers) != 1 {
+		t.Fatalf("expected 1 ticker, got %d", len(resp.Tickers))
+	}
+	got := resp.Tickers[0]
+	if got.PostCount != 4 || got.WeightedCount != 21.3 || got.Rank != 1 {
+		t.Fatalf("unexpected ticker: %+v", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// coverage.go
+// ---------------------------------------------------------------------------
+
+func TestGetDataCoverage(t *testing.T) {
+	columns := []string{"domain", "max_age_days", "total", "fresh", "stale", "missing", "oldest_stale_ticker", "oldest_stale_at"}
+
+	t.Run("computes percentages", func(t *testing.T) {
+		mock := setupMock(t)
+		staleAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`WITH universe AS .+UNION ALL .+GROUP BY domain`).
+			WithArgs("prices", 5, "financials", 120, "fundamentals", 7, "ic_scores", 3, "sentiment", 2, "analyst", 180).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("prices", 5, 4, 3, 1, 0, "CCC", staleAt).
+				AddRow("financials", 120, 4, 0, 0, 4, nil, nil))
+
+		coverage, err := GetDataCoverage()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(coverage) != 2 {
+			t.Fatalf("expected 2 domains, got %d", len(coverage))
+		}
+		prices := coverage[0]
+		if prices.CoveragePct != 75 || prices.OldestStaleTicker == nil || *prices.OldestStaleTicker != "CCC" {
+			t.Fatalf("unexpected prices coverage: %+v", prices)
+		}
+		if prices.OldestStaleAt == nil || !prices.OldestStaleAt.Equal(staleAt) {
+			t.Fatalf("unexpected oldest stale time: %v", prices.OldestStaleAt)
+		}
+		if coverage[1].CoveragePct != 0 || coverage[1].OldestStaleTicker != nil {
+			t.Fatalf("unexpected financials coverage: %+v", coverage[1])
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("no active stocks still lists every domain", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`WITH universe AS`).WillReturnRows(sqlmock.NewRows(columns))
+
+		coverage, err := GetDataCoverage()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(coverage) != len(coverageDomains) {
+			t.Fatalf("expected %d domains, got %d", len(coverageDomains), len(coverage))
+		}
+		if coverage[0].Domain != "prices" || coverage[0].MaxAgeDays != 5 {
+			t.Fatalf("unexpected first domain: %+v", coverage[0])
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`WITH universe AS`).WillReturnError(errors.New("boom"))
+
+		if _, err := GetDataCoverage(); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// freshness.go
+// ---------------------------------------------------------------------------
+
+func TestGetDomainLastUpdated(t *testing.T) {
+	t.Run("returns latest write", func(t *testing.T) {
+		mock := setupMock(t)
+		last := time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`SELECT MAX\(time\) FROM stock_prices`).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(last))
+
+		got, err := GetDomainLastUpdated("prices")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got == nil || !got.Equal(last) {
+			t.Fatalf("expected %v, got %v", last, got)
+		}
+	})
+
+	t.Run("empty table", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`FROM ticker_sentiment_snapshots`).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+		got, err := GetDomainLastUpdated("sentiment")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != nil {
+			t.Fatalf("expected nil, got %v", got)
+		}
+	})
+
+	t.Run("unknown domain", func(t *testing.T) {
+		setupMock(t)
+		if _, err := GetDomainLastUpdated("horoscopes"); err == nil {
+			t.Fatal("expected error for unknown domain")
+		}
+	})
+}
+
+func TestGetLastSuccessfulJobRun(t *testing.T) {
+	mock := setupMock(t)
+	last := time.Date(2026, 3, 10, 23, 5, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM cronjob_execution_logs\s+WHERE job_name = \$1 AND status = 'success'`).
+		WithArgs("ic-score-daily-price-update").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(last))
+
+	got, err := GetLastSuccessfulJobRun("ic-score-daily-price-update")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got == nil || !got.Equal(last) {
+		t.Fatalf("expected %v, got %v", last, got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSetOpenFreshnessAlerts(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM data_freshness_alerts WHERE NOT \(alert_key = ANY\(\$1\)\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO data_freshness_alerts`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := SetOpenFreshnessAlerts([]string{"prices/data_stale"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetUserSessions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+		agent := "Mozilla/5.0"
+
+		cols := []string{"id", "created_at", "last_used_at", "expires_at", "user_agent", "ip_address"}
+		mock.ExpectQuery(`SELECT id, created_at, last_used_at, expires_at, user_agent, ip_address\s+FROM sessions\s+WHERE user_id = \$1`).
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("sess-2", now, now, now.Add(time.Hour), &agent, nil).
+				AddRow("sess-1", now, now, now.Add(time.Hour), nil, nil))
+
+		sessions, err := GetUserSessions("user-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 2 || sessions[0].ID != "sess-2" {
+			t.Fatalf("unexpected sessions: %+v", sessions)
+		}
+		if sessions[0].UserAgent == nil || *sessions[0].UserAgent != agent {
+			t.Fatalf("expected user agent %q", agent)
+		}
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`FROM sessions`).WillReturnError(errors.New("db down"))
+
+		_, err := GetUserSessions("user-1")
+		if err == nil || !contains(err.Error(), "failed to get sessions") {
+			t.Fatalf("expected wrapped error, got %v", err)
+		}
+	})
+}
+
+func TestPurgeUserData(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT is_active = FALSE AND purged_at IS NULL FROM users WHERE id = \$1 FOR UPDATE`).
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"pending"}).AddRow(true))
+		for i, step := range accountPurgeSteps {
+			mock.ExpectExec(regexp.QuoteMeta(step.query)).
+				WithArgs("user-1").
+				WillReturnResult(sqlmock.NewResult(0, int64(i%2)))
+		}
+		mock.ExpectCommit()
+
+		report, err := PurgeUserData("user-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(report.Tables) != len(accountPurgeSteps) {
+			t.Fatalf("expected %d tables in report, got %d", len(accountPurgeSteps), len(report.Tables))
+		}
+		if last := report.Tables[len(report.Tables)-1]; last.Table != "users" || last.Action != "anonymized" {
+			t.Fatalf("expected users to be anonymized last, got %+v", last)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("active user", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM users WHERE id = \$1 FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows([]string{"pending"}).AddRow(false))
+		mock.ExpectRollback()
+
+		_, err := PurgeUserData("user-1")
+		if !errors.Is(err, ErrUserNotPendingPurge) {
+			t.Fatalf("expected ErrUserNotPendingPurge, got %v", err)
+		}
+	})
+
+	t.Run("failed step rolls back", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows([]string{"pending"}).AddRow(true))
+		mock.ExpectExec(`DELETE FROM alert_logs`).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`DELETE FROM alert_rules`).WillReturnError(errors.New("lock timeout"))
+		mock.ExpectRollback()
+
+		_, err := PurgeUserData("user-1")
+		if err == nil || !contains(err.Error(), "failed to purge alert_rules") {
+			t.Fatalf("expected alert_rules failure, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetUsersPendingPurge(t *testing.T) {
+	mock := setupMock(t)
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	mock.ExpectQuery(`SELECT id FROM users\s+WHERE is_active = FALSE AND purged_at IS NULL`).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user-1").AddRow("user-2"))
+
+	ids, err := GetUsersPendingPurge(cutoff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "user-1" {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+}
+
+func TestGetPortfolioByID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+
+		cols := []string{"id", "user_id", "name", "description", "created_at", "updated_at"}
+		mock.ExpectQuery(`SELECT .+ FROM portfolios WHERE id = \$1 AND user_id = \$2`).
+			WithArgs("pf-1", "user-1").
+			WillReturnRows(sqlmock.NewRows(cols).AddRow("pf-1", "user-1", "Retirement", nil, now, now))
+
+		p, err := GetPortfolioByID("pf-1", "user-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if p.Name != "Retirement" {
+			t.Fatalf("expected 'Retirement', got %s", p.Name)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM portfolios WHERE id = \$1 AND user_id = \$2`).
+			WithArgs("pf-1", "user-2").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := GetPortfolioByID("pf-1", "user-2")
+		if !errors.Is(err, ErrPortfolioNotFound) {
+			t.Fatalf("expected ErrPortfolioNotFound, got %v", err)
+		}
+	})
+}
+
+func TestAddPortfolioHolding(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("AAPL").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM portfolio_transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(`INSERT INTO portfolio_holdings`).
+			WithArgs("pf-1", "AAPL", 10.0, 150.25).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "added_at", "updated_at"}).AddRow("h-1", now, now))
+
+		h := &models.PortfolioHolding{PortfolioID: "pf-1", Symbol: "AAPL", Shares: 10, AverageCost: 150.25}
+		if err := AddPortfolioHolding(h); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if h.ID != "h-1" {
+			t.Fatalf("expected id h-1, got %s", h.ID)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("unknown ticker", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("ZZZZ").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		err := AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: "pf-1", Symbol: "ZZZZ", Shares: 1})
+		if !errors.Is(err, ErrTickerNotFound) {
+			t.Fatalf("expected ErrTickerNotFound, got %v", err)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM portfolio_transactions`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(`INSERT INTO portfolio_holdings`).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		err := AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: "pf-1", Symbol: "AAPL", Shares: 1})
+		if !errors.Is(err, ErrHoldingAlreadyExists) {
+			t.Fatalf("expected ErrHoldingAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("tracked by transactions", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM portfolio_transactions`).
+			WithArgs("pf-1", "AAPL").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		err := AddPortfolioHolding(&models.PortfolioHolding{PortfolioID: "pf-1", Symbol: "AAPL", Shares: 1})
+		if !errors.Is(err, ErrHoldingHasTransactions) {
+			t.Fatalf("expected ErrHoldingHasTransactions, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpdatePortfolioHolding_NotFound(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM portfolio_transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`UPDATE portfolio_holdings`).
+		WithArgs(5.0, 100.0, "pf-1", "MSFT").
+		WillReturnError(sql.ErrNoRows)
+
+	err := UpdatePortfolioHolding(&models.PortfolioHolding{PortfolioID: "pf-1", Symbol: "MSFT", Shares: 5, AverageCost: 100})
+	if !errors.Is(err, ErrPortfolioHoldingNotFound) {
+		t.Fatalf("expected ErrPortfolioHoldingNotFound, got %v", err)
+	}
+}
+
+func TestRemovePortfolioHolding_NotFound(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectExec(`DELETE FROM portfolio_holdings WHERE portfolio_id = \$1 AND symbol = \$2`).
+		WithArgs("pf-1", "MSFT").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := RemovePortfolioHolding("pf-1", "MSFT")
+	if !errors.Is(err, ErrPortfolioHoldingNotFound) {
+		t.Fatalf("expected ErrPortfolioHoldingNotFound, got %v", err)
+	}
+}
+
+func TestUpdatePortfolioHolding_TrackedByTransactions(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM portfolio_transactions`).
+		WithArgs("pf-1", "MSFT").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err := UpdatePortfolioHolding(&models.PortfolioHolding{PortfolioID: "pf-1", Symbol: "MSFT", Shares: 5, AverageCost: 100})
+	if !errors.Is(err, ErrHoldingHasTransactions) {
+		t.Fatalf("expected ErrHoldingHasTransactions, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+var portfolioTransactionColumns = []string{
+	"id", "portfolio_id", "symbol", "transaction_type", "shares", "price", "executed_at", "created_at",
+}
+
+func TestRecordPortfolioTransaction_SeedsOpeningPosition(t *testing.T) {
+	mock := setupMock(t)
+	added := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM portfolios WHERE id = \$1 FOR UPDATE`).
+		WithArgs("pf-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("pf-1"))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM tickers`).
+		WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM portfolio_transactions`).
+		WithArgs("pf-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioTransactionColumns))
+	mock.ExpectQuery(`SELECT shares, average_cost, added_at`).
+		WithArgs("pf-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"shares", "average_cost", "added_at"}).AddRow("10", "100", added))
+	mock.ExpectQuery(`INSERT INTO portfolio_transactions`).
+		WithArgs("pf-1", "AAPL", "BUY", sqlmock.AnyArg(), sqlmock.AnyArg(), added).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at", "created_at"}).AddRow("tx-open", added, now))
+	mock.ExpectQuery(`INSERT INTO portfolio_transactions`).
+		WithArgs("pf-1", "AAPL", "BUY", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at", "created_at"}).AddRow("tx-1", now, now))
+	mock.ExpectExec(`INSERT INTO portfolio_holdings .+ ON CONFLICT`).
+		WithArgs("pf-1", "AAPL", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	txn := &models.PortfolioTransaction{
+		PortfolioID:     "pf-1",
+		Symbol:          "AAPL",
+		TransactionType: models.TransactionTypeBuy,
+		Shares:          decimal.RequireFromString("5"),
+		Price:           decimal.RequireFromString("130"),
+	}
+	var replayed []models.PortfolioTransaction
+	err := RecordPortfolioTransaction(txn, func(history []models.PortfolioTransaction) (decimal.Decimal, decimal.Decimal, error) {
+		replayed = history
+		return decimal.RequireFromString("15"), decimal.RequireFromString("110"), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if txn.ID != "tx-1" {
+		t.Fatalf("expected id tx-1, got %s", txn.ID)
+	}
+	if len(replayed) != 2 || replayed[0].ID != "tx-open" || !replayed[0].Shares.Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("expected opening BUY then the new transaction, got %+v", replayed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRecordPortfolioTransaction_ReplayErrorRollsBack(t *testing.T) {
+	mock := setupMock(t)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM portfolios WHERE id = \$1 FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("pf-1"))
+	mock.ExpectQuery(`FROM portfolio_transactions`).
+		WillReturnRows(sqlmock.NewRows(portfolioTransactionColumns).
+			AddRow("tx-1", "pf-1", "AAPL", "BUY", "2", "100", now.Add(-time.Hour), now.Add(-time.Hour)))
+	mock.ExpectQuery(`INSERT INTO portfolio_transactions`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at", "created_at"}).AddRow("tx-2", now, now))
+	mock.ExpectRollback()
+
+	txn := &models.PortfolioTransaction{
+		PortfolioID:     "pf-1",
+		Symbol:          "AAPL",
+		TransactionType: models.TransactionTypeSell,
+		Shares:          decimal.RequireFromString("3"),
+		Price:           decimal.RequireFromString("120"),
+	}
+	err := RecordPortfolioTransaction(txn, func([]models.PortfolioTransaction) (decimal.Decimal, decimal.Decimal, error) {
+		return decimal.Zero, decimal.Zero, ErrInsufficientShares
+	})
+	if !errors.Is(err, ErrInsufficientShares) {
+		t.Fatalf("expected ErrInsufficientShares, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestRecordPortfolioTransaction_PortfolioNotFound(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM portfolios WHERE id = \$1 FOR UPDATE`).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := RecordPortfolioTransaction(&models.PortfolioTransaction{PortfolioID: "pf-x"}, nil)
+	if !errors.Is(err, ErrPortfolioNotFound) {
+		t.Fatalf("expected ErrPortfolioNotFound, got %v", err)
+	}
+}
+
+func TestGetLatestClose(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT close\s+FROM stock_prices`).
+			WithArgs("KO").
+			WillReturnRows(sqlmock.NewRows([]string{"close"}).AddRow(62.15))
+
+		price, err := GetLatestClose("KO")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if price == nil || *price != 62.15 {
+			t.Fatalf("expected 62.15, got %v", price)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("no_prices", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT close\s+FROM stock_prices`).
+			WithArgs("NEWCO").
+			WillReturnError(sql.ErrNoRows)
+
+		price, err := GetLatestClose("NEWCO")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if price != nil {
+			t.Fatalf("expected nil price, got %v", *price)
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// account_activity.go
+// ---------------------------------------------------------------------------
+
+func TestRecordAccountActivity(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+		ip, ua := "203.0.113.7", "Mozilla/5.0"
+
+		mock.ExpectQuery(`INSERT INTO account_activity`).
+			WithArgs("user-1", models.AccountEventLogin, &ip, &ua, []byte(`{"session_id":"sess-1"}`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("act-1", now))
+
+		activity := &models.AccountActivity{
+			UserID:    "user-1",
+			EventType: models.AccountEventLogin,
+			IPAddress: &ip,
+			UserAgent: &ua,
+			Metadata:  json.RawMessage(`{"session_id":"sess-1"}`),
+		}
+		if err := RecordAccountActivity(activity); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if activity.ID != "act-1" {
+			t.Fatalf("expected act-1, got %s", activity.ID)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("nil_metadata", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`INSERT INTO account_activity`).
+			WithArgs("user-1", models.AccountEventPasswordChanged, nil, nil, []byte(`{}`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("act-2", time.Now()))
+
+		activity := &models.AccountActivity{UserID: "user-1", EventType: models.AccountEventPasswordChanged}
+		if err := RecordAccountActivity(activity); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(activity.Metadata) != "{}" {
+			t.Fatalf("expected empty metadata object, got %s", activity.Metadata)
+		}
+	})
+
+	t.Run("db_error", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`INSERT INTO account_activity`).WillReturnError(errors.New("insert failed"))
+
+		err := RecordAccountActivity(&models.AccountActivity{UserID: "user-1", EventType: models.AccountEventLogin})
+		if err == nil || !contains(err.Error(), "failed to record account activity") {
+			t.Fatalf("expected wrapped error, got %v", err)
+		}
+	})
+}
+
+func TestGetAccountActivity(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM account_activity WHERE user_id = \$1`).
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(`SELECT .+ FROM account_activity\s+WHERE user_id = \$1\s+ORDER BY created_at DESC`).
+			WithArgs("user-1", 2, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "event_type", "ip_address", "user_agent", "metadata", "created_at"}).
+				AddRow("act-3", "user-1", models.AccountEventPasswordChanged, "203.0.113.7", "Mozilla/5.0", []byte(`{}`), now).
+				AddRow("act-2", "user-1", models.AccountEventLogin, nil, nil, []byte(`{"session_id":"sess-1"}`), now.Add(-time.Hour)))
+
+		activity, total, err := GetAccountActivity("user-1", 2, 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if total != 3 || len(activity) != 2 {
+			t.Fatalf("expected 2 of 3 events, got %d of %d", len(activity), total)
+		}
+		if activity[0].EventType != models.AccountEventPasswordChanged || *activity[0].IPAddress != "203.0.113.7" {
+			t.Fatalf("unexpected first event: %+v", activity[0])
+		}
+		if activity[1].IPAddress != nil {
+			t.Fatalf("expected nil IP, got %v", *activity[1].IPAddress)
+		}
+	})
+
+	t.Run("count_error", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT COUNT`).WillReturnError(errors.New("db down"))
+
+		if _, _, err := GetAccountActivity("user-1", 50, 0); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// two_factor.go
+// ---------------------------------------------------------------------------
+
+func TestGetUserTwoFactor(t *testing.T) {
+	t.Run("not_enrolled", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery(`SELECT .+ FROM user_two_factor`).WithArgs("user-1").WillReturnError(sql.ErrNoRows)
+
+		tf, err := GetUserTwoFactor("user-1")
+		if err != nil || tf != nil {
+			t.Fatalf("expected nil enrollment, got %+v, %v", tf, err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		mock := setupMock(t)
+		now := time.Now()
+		mock.ExpectQuery(`SELECT .+ FROM user_two_factor`).
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"user_id", "secret_encrypted", "enabled", "recovery_code_hashes",
+				"last_used_step", "enabled_at", "created_at", "updated_at",
+			}).AddRow("user-1", "enc", true, "{aaa,bbb}", int64(42), now, now, now))
+
+		tf, err := GetUserTwoFactor("user-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !tf.Enabled || len(tf.RecoveryCodeHashes) != 2 || *tf.LastUsedStep != 42 {
+			t.Fatalf("unexpected enrollment: %+v", tf)
+		}
+	})
+}
+
+func TestSaveTwoFactorEnrollment(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectExec(`INSERT INTO user_two_factor .+ ON CONFLICT \(user_id\) DO UPDATE .+ WHERE NOT user_two_factor.enabled`).
+			WithArgs("user-1", "enc").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := SaveTwoFactorEnrollment("user-1", "enc"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("already_enabled", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectExec(`INSERT INTO user_two_factor`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := SaveTwoFactorEnrollment("user-1", "enc"); !errors.Is(err, ErrTwoFactorAlreadyEnabled) {
+			t.Fatalf("expected ErrTwoFactorAlreadyEnabled, got %v", err)
+		}
+	})
+}
+
+func TestUseTwoFactorStep(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectExec(`UPDATE user_two_factor SET last_used_step = \$2, .+ last_used_step < \$2`).
+		WithArgs("user-1", int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE user_two_factor SET last_used_step`).
+		WithArgs("user-1", int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if fresh, err := UseTwoFactorStep("user-1", 100); err != nil || !fresh {
+		t.Fatalf("expected first use to be accepted, got %v, %v", fresh, err)
+	}
+	if fresh, err := UseTwoFactorStep("user-1", 100); err != nil || fresh {
+		t.Fatalf("expected replay to be rejected, got %v, %v", fresh, err)
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectExec(`array_remove\(recovery_code_hashes, \$2\)`).
+		WithArgs("user-1", "hash-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`array_remove`).
+		WithArgs("user-1", "hash-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if used, err := UseRecoveryCode("user-1", "hash-1"); err != nil || !used {
+		t.Fatalf("expected code to be used, got %v, %v", used, err)
+	}
+	if used, err := UseRecoveryCode("user-1", "hash-1"); err != nil || used {
+		t.Fatalf("expected used-up code to be rejected, got %v, %v", used, err)
+	}
+}
+
+// contains is a helper that checks if a string contains a substring.
+func contains(s, substr string) bool {
+	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsImpl(s, substr))
+}
+
+func containsImpl(s, substr string) bool {
+	for i := 0; i <= len(s)-len(substr); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// financials.go — TTM rollup
+// ---------------------------------------------------------------------------
+
+var financialStatementColumns = []string{
+	"id", "ticker_id", "cik", "statement_type", "timeframe", "fiscal_year", "fiscal_quarter",
+	"period_start", "period_end", "filed_date", "source_filing_url", "source_filing_type",
+	"data", "created_at", "updated_at",
+}
+
+// quarterlyRows builds financial_statements rows, one per "FY/Q/period end/data JSON"
+func quarterlyRows(statementType models.StatementType, quarters ...[4]interface{}) *sqlmock.Rows {
+	rows := sqlmock.NewRows(financialStatementColumns)
+	now := time.Now()
+	for i, q := range quarters {
+		end, _ := time.Parse("2006-01-02", q[2].(string))
+		rows.AddRow(i+1, 7, nil, string(statementType), string(models.TimeframeQuarterly), q[0], q[1],
+			nil, end, nil, nil, nil, []byte(q[3].(string)), now, now)
+	}
+	return rows
+}
+
+func expectTTMQueries(mock sqlmock.Sqlmock, income, cashFlow, balance *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT id FROM tickers").
+		WithArgs("MSFT").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery("FROM financial_statements").
+		WithArgs(7, models.StatementTypeIncome, models.TimeframeQuarterly, 8).
+		WillReturnRows(income)
+	mock.ExpectQuery("FROM financial_statements").
+		WithArgs(7, models.StatementTypeCashFlow, models.TimeframeQuarterly, 8).
+		WillReturnRows(cashFlow)
+	mock.ExpectQuery("FROM financial_statements").
+		WithArgs(7, models.StatementTypeBalanceSheet, models.TimeframeQuarterly, 1).
+		WillReturnRows(balance)
+}
+
+func TestGetTTMFromStatements(t *testing.T) {
+	t.Run("sums four consecutive quarters", func(t *testing.T) {
+		mock := setupMock(t)
+		// Fiscal year ends in June, so Q1 FY2025 ends in September 2024
+		income := quarterlyRows(models.StatementTypeIncome,
+			[4]interface{}{2025, 1, "2024-09-30", `{"revenues": 65, "revenues_label": "Revenues", "revenues_unit": "USD", "basic_average_shares": 7430, "basic_average_shares_unit": "shares", "basic_earnings_per_share": 3.3, "gross_margin": 0.7, "one_off": 5}`},
+			[4]interface{}{2024, 4, "2024-06-30", `{"revenues": 64, "basic_average_shares": 7440, "basic_earnings_per_share": 2.95}`},
+			[4]interface{}{2024, 3, "2024-03-31", `{"revenues": 62, "basic_average_shares": 7450, "basic_earnings_per_share": 2.94}`},
+			[4]interface{}{2024, 2, "2023-12-31", `{"revenues": 62, "basic_average_shares": 7460, "basic_earnings_per_share": 2.93}`},
+			[4]interface{}{2024, 1, "2023-09-30", `{"revenues": 56}`},
+		)
+		cashFlow := quarterlyRows(models.StatementTypeCashFlow,
+			[4]interface{}{2025, 1, "2024-09-30", `{"net_cash_flow": 10}`},
+			[4]interface{}{2024, 4, "2024-06-30", `{"net_cash_flow": -2}`},
+			[4]interface{}{2024, 3, "2024-03-31", `{"net_cash_flow": 4}`},
+			[4]interface{}{2024, 2, "2023-12-31", `{"net_cash_flow": 3}`},
+		)
+		balance := quarterlyRows(models.StatementTypeBalanceSheet,
+			[4]interface{}{2025, 1, "2024-09-30", `{"assets": 523}`},
+		)
+		expectTTMQueries(mock, income, cashFlow, balance)
+
+		ttm, err := GetTTMFromStatements("MSFT")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ttm.FiscalYear != 2025 || ttm.FiscalQuarter != 1 || ttm.PeriodEnd != "2024-09-30" {
+			t.Errorf("expected Q1 FY2025 ending 2024-09-30, got Q%d FY%d ending %s", ttm.FiscalQuarter, ttm.FiscalYear, ttm.PeriodEnd)
+		}
+		if len(ttm.Quarters) != 4 || ttm.Quarters[3].FiscalYear != 2024 || ttm.Quarters[3].FiscalQuarter != 2 {
+			t.Errorf("expected Q1 FY2025 back to Q2 FY2024, got %+v", ttm.Quarters)
+		}
+		if got := ttm.IncomeStatement["revenues"]; got != float64(253) {
+			t.Errorf("expected revenues 253, got %v", got)
+		}
+		if got := ttm.IncomeStatement["basic_earnings_per_share"].(float64); got < 12.119 || got > 12.121 {
+			t.Errorf("expected EPS 12.12, got %v", got)
+		}
+		if got := ttm.IncomeStatement["basic_average_shares"]; got != float64(7430) {
+			t.Errorf("expected latest share count 7430, got %v", got)
+		}
+		if ttm.IncomeStatement["revenues_label"] != "Revenues" || ttm.IncomeStatement["revenues_unit"] != "USD" {
+			t.Errorf("expected label and unit from the latest quarter, got %v", ttm.IncomeStatement)
+		}
+		for _, key := range []string{"gross_margin", "one_off"} {
+			if _, ok := ttm.IncomeStatement[key]; ok {
+				t.Errorf("expected %s to be left out", key)
+			}
+		}
+		if got := ttm.CashFlow["net_cash_flow"]; got != float64(15) {
+			t.Errorf("expected net cash flow 15, got %v", got)
+		}
+		if ttm.BalanceSheet["assets"] != float64(523) || ttm.BalanceSheetDate == nil || *ttm.BalanceSheetDate != "2024-09-30" {
+			t.Errorf("expected the latest balance sheet, got %v at %v", ttm.BalanceSheet, ttm.BalanceSheetDate)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("reports the missing quarter", func(t *testing.T) {
+		mock := setupMock(t)
+		income := quarterlyRows(models.StatementTypeIncome,
+			[4]interface{}{2024, 2, "2024-06-30", `{"revenues": 10}`},
+			[4]interface{}{2024, 1, "2024-03-31", `{"revenues": 10}`},
+			[4]interface{}{2023, 3, "2023-09-30", `{"revenues": 10}`},
+			[4]interface{}{2023, 2, "2023-06-30", `{"revenues": 10}`},
+		)
+		cashFlow := quarterlyRows(models.StatementTypeCashFlow)
+		balance := quarterlyRows(models.StatementTypeBalanceSheet)
+		expectTTMQueries(mock, income, cashFlow, balance)
+
+		_, err := GetTTMFromStatements("MSFT")
+		var gap *TTMGapError
+		if !errors.As(err, &gap) {
+			t.Fatalf("expected TTMGapError, got %v", err)
+		}
+		if gap.StatementType != models.StatementTypeIncome || gap.Missing != "Q4 FY2023" {
+			t.Errorf("expected income Q4 FY2023 missing, got %+v", gap)
+		}
+		if !contains(err.Error(), "Q4 FY2023") {
+			t.Errorf("expected the error to name the gap, got %q", err.Error())
+		}
+	})
+
+	t.Run("cash flow must cover the same quarters", func(t *testing.T) {
+		mock := setupMock(t)
+		income := quarterlyRows(models.StatementTypeIncome,
+			[4]interface{}{2024, 4, "2024-12-31", `{"revenues": 10}`},
+			[4]interface{}{2024, 3, "2024-09-30", `{"revenues": 10}`},
+			[4]interface{}{2024, 2, "2024-06-30", `{"revenues": 10}`},
+			[4]interface{}{2024, 1, "2024-03-31", `{"revenues": 10}`},
+		)
+		cashFlow := quarterlyRows(models.StatementTypeCashFlow,
+			[4]interface{}{2024, 3, "2024-09-30", `{"net_cash_flow": 1}`},
+			[4]interface{}{2024, 2, "2024-06-30", `{"net_cash_flow": 1}`},
+			[4]interface{}{2024, 1, "2024-03-31", `{"net_cash_flow": 1}`},
+			[4]interface{}{2023, 4, "2023-12-31", `{"net_cash_flow": 1}`},
+		)
+		balance := quarterlyRows(models.StatementTypeBalanceSheet)
+		expectTTMQueries(mock, income, cashFlow, balance)
+
+		_, err := GetTTMFromStatements("MSFT")
+		var gap *TTMGapError
+		if !errors.As(err, &gap) || gap.StatementType != models.StatementTypeCashFlow || gap.Missing != "Q4 FY2024" {
+			t.Errorf("expected cash flow Q4 FY2024 missing, got %v", err)
+		}
+	})
+
+	t.Run("no quarterly statements", func(t *testing.T) {
+		mock := setupMock(t)
+		mock.ExpectQuery("SELECT id FROM tickers").
+			WithArgs("MSFT").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery("FROM financial_statements").
+			WillReturnRows(sqlmock.NewRows(financialStatementColumns))
+
+		_, err := GetTTMFromStatements("MSFT")
+		var gap *TTMGapError
+		if !errors.As(err, &gap) || gap.Missing != "" {
+			t.Fatalf("expected an empty TTMGapError, got %v", err)
+		}
+		if err.Error() != "no quarterly income statements on file" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	})
+}
+
+func TestBulkAddTickers_BatchedValidation(t *testing.T) {
+	mock := setupMock(t)
+	now := time.Now()
+
+	// One existence query covers every symbol; FAKE is never inserted
+	mock.ExpectQuery(`SELECT symbol FROM tickers WHERE symbol = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL").AddRow("MSFT"))
+	mock.ExpectQuery(`INSERT INTO watch_list_items`).
+		WithArgs("wl-1", "AAPL", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "added_at", "display_order"}).AddRow("item-1", now, 0))
+	mock.ExpectQuery(`INSERT INTO watch_list_items`).
+		WithArgs("wl-1", "MSFT", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "added_at", "display_order"}).AddRow("item-2", now, 1))
+
+	added, failed, err := BulkAddTickers("wl-1", []string{"AAPL", "FAKE", "MSFT"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(added) != 2 || added[0] != "AAPL" || added[1] != "MSFT" {
+		t.Fatalf("unexpected added: %v", added)
+	}
+	if len(failed) != 1 || failed[0] != "FAKE" {
+		t.Fatalf("unexpected failed: %v", failed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestTickersExist_Empty(t *testing.T) {
+	mock := setupMock(t)
+
+	found, err := TickersExist(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("expected empty set, got %v", found)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// fmp_snapshots.go
+// ---------------------------------------------------------------------------
+
+func TestUpsertFMPSnapshots(t *testing.T) {
+	mock := setupMock(t)
+	mock.ExpectExec(`INSERT INTO fmp_snapshots`).
+		WithArgs("ratios-ttm", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err := UpsertFMPSnapshots("ratios-ttm", map[string]json.RawMessage{
+		"AAPL": json.RawMessage(`{"symbol":"AAPL"}`),
+		"SPAC": nil,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+
+	// Nothing to store is not a query
+	if err := UpsertFMPSnapshots("score", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGetFMPSnapshot(t *testing.T) {
+	mock := setupMock(t)
+	now := time.Now()
+	mock.ExpectQuery(`FROM fmp_snapshots`).
+		WithArgs("SPAC", "ratios-ttm").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "endpoint", "data", "refreshed_at"}).
+			AddRow("SPAC", "ratios-ttm", nil, now))
+	mock.ExpectQuery(`FROM fmp_snapshots`).
+		WithArgs("NEW", "ratios-ttm").
+		WillReturnError(sql.ErrNoRows)
+
+	snapshot, err := GetFMPSnapshot("SPAC", "ratios-ttm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshot == nil || snapshot.Data != nil {
+		t.Fatalf("expected a snapshot without data, got %+v", snapshot)
+	}
+
+	snapshot, err = GetFMPSnapshot("NEW", "ratios-ttm")
+	if err != nil || snapshot != nil {
+		t.Fatalf("expected no snapshot, got %+v, %v", snapshot, err)
 	}
-	return false
 }
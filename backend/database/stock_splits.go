@@ -0,0 +1,108 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"investorcenter-api/models"
+)
+
+// GetStockSplits returns a ticker's splits, oldest first
+func GetStockSplits(ticker string) ([]models.StockSplit, error) {
+	query := `
+		SELECT id, ticker, execution_date, split_from, split_to, source
+		FROM stock_splits
+		WHERE ticker = $1
+		ORDER BY execution_date ASC
+	`
+	splits := []models.StockSplit{}
+	if err := DB.Select(&splits, query, strings.ToUpper(ticker)); err != nil {
+		return nil, fmt.Errorf("failed to get stock splits: %w", err)
+	}
+	return splits, nil
+}
+
+// UpsertStockSplits stores splits, replacing the ratio and source of any
+// split already recorded for the same ticker and date
+func UpsertStockSplits(splits []models.StockSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertStockSplits(tx, splits); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit splits: %w", err)
+	}
+	return nil
+}
+
+// SyncStockSplits stores a ticker's freshly fetched splits and records the
+// sync time, so a ticker with no splits isn't refetched on every request
+func SyncStockSplits(ticker string, splits []models.StockSplit) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertStockSplits(tx, splits); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO stock_split_syncs (ticker, synced_at) VALUES ($1, NOW())
+		ON CONFLICT (ticker) DO UPDATE SET synced_at = EXCLUDED.synced_at
+	`, strings.ToUpper(ticker))
+	if err != nil {
+		return fmt.Errorf("failed to record split sync for %s: %w", ticker, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit splits: %w", err)
+	}
+	return nil
+}
+
+// GetStockSplitsSyncedAt returns when a ticker's splits were last fetched
+// from upstream, or nil if they never have been
+func GetStockSplitsSyncedAt(ticker string) (*time.Time, error) {
+	var syncedAt time.Time
+	err := DB.Get(&syncedAt, `SELECT synced_at FROM stock_split_syncs WHERE ticker = $1`, strings.ToUpper(ticker))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get split sync time: %w", err)
+	}
+	return &syncedAt, nil
+}
+
+func upsertStockSplits(tx *sqlx.Tx, splits []models.StockSplit) error {
+	query := `
+		INSERT INTO stock_splits (ticker, execution_date, split_from, split_to, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (ticker, execution_date) DO UPDATE SET
+			split_from = EXCLUDED.split_from,
+			split_to = EXCLUDED.split_to,
+			source = EXCLUDED.source,
+			updated_at = NOW()
+	`
+	for _, s := range splits {
+		if _, err := tx.Exec(query, strings.ToUpper(s.Ticker), s.ExecutionDate.Format("2006-01-02"),
+			s.SplitFrom, s.SplitTo, s.Source); err != nil {
+			return fmt.Errorf("failed to upsert split for %s: %w", s.Ticker, err)
+		}
+	}
+	return nil
+}
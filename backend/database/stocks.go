@@ -1,8 +1,15 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+
 	"investorcenter-api/models"
+
+	"github.com/lib/pq"
 )
 
 // GetStockBySymbol retrieves stock information by symbol
@@ -35,7 +42,7 @@ func GetStockBySymbol(symbol string) (*models.Stock, error) {
 		LIMIT 1
 	`
 
-	err := DB.Get(&stock, query, symbol)
+	err := preparedGet(&stock, query, symbol)
 	if err != nil {
 		return nil, fmt.Errorf("stock not found: %w", err)
 	}
@@ -43,13 +50,75 @@ func GetStockBySymbol(symbol string) (*models.Stock, error) {
 	return &stock, nil
 }
 
-// SearchStocks searches for stocks by symbol or name
-// Returns all matching assets, prioritizing:
-// 1. Exact symbol match (stocks before crypto)
-// 2. Symbol starts with query (stocks before crypto)
-// 3. Name contains query (stocks before crypto)
-func SearchStocks(query string, limit int) ([]models.Stock, error) {
-	var stocks []models.Stock
+// SearchBoostConfig weights the popularity boost that orders search results
+// within the same match type and asset type. Each weight multiplies the log of
+// its signal, so a zero weight disables that signal.
+type SearchBoostConfig struct {
+	MarketCapWeight float64 // weight on LN(1 + market cap)
+	SocialWeight    float64 // weight on LN(1 + latest reddit mentions)
+	VolumeWeight    float64 // weight on LN(1 + 30-day average daily volume)
+}
+
+// SearchBoost is the boost configuration used by SearchSecurities.
+var SearchBoost = LoadSearchBoostFromEnv()
+
+// LoadSearchBoostFromEnv loads search boost weights from environment variables
+func LoadSearchBoostFromEnv() SearchBoostConfig {
+	return SearchBoostConfig{
+		MarketCapWeight: getEnvFloatWithDefault("SEARCH_BOOST_MARKET_CAP_WEIGHT", 1.0),
+		SocialWeight:    getEnvFloatWithDefault("SEARCH_BOOST_SOCIAL_WEIGHT", 1.0),
+		VolumeWeight:    getEnvFloatWithDefault("SEARCH_BOOST_VOLUME_WEIGHT", 1.0),
+	}
+}
+
+// SearchAssetTypes are the asset types SearchSecurities can filter on
+var SearchAssetTypes = []string{"stock", "etf", "index", "crypto"}
+
+// SearchOptions narrows and tunes SearchSecurities
+type SearchOptions struct {
+	Types           []string // asset types to search; all when empty
+	Limit           int
+	IncludeInactive bool // include inactive (delisted) tickers
+
+	// Fuzzy controls the trigram similarity fallback for typos: nil tops
+	// up results only when direct matches are sparse, true always tops up
+	// to Limit, and false turns it off.
+	Fuzzy *bool
+	// MinSimilarity is the pg_trgm similarity a fuzzy match needs, from
+	// FuzzySearchThreshold to 1; 0 means FuzzySearchThreshold.
+	MinSimilarity float64
+}
+
+// SearchStocks searches for stocks by symbol or name, ranked as
+// SearchSecurities ranks them across all asset types
+func SearchStocks(query string, limit int, includeInactive bool) ([]models.Stock, error) {
+	results, err := SearchSecurities(query, SearchOptions{Limit: limit, IncludeInactive: includeInactive})
+	if err != nil {
+		return nil, err
+	}
+	stocks := make([]models.Stock, len(results))
+	for i, r := range results {
+		stocks[i] = r.Stock
+	}
+	return stocks, nil
+}
+
+// SearchSecurities searches tickers of the asset types in opts (all types
+// when none are given) by symbol or name. Results are ranked by:
+// 1. Exact symbol match
+// 2. Symbol starts with query
+// 3. Name contains query
+// 4. Symbol contains query
+// and within each, stocks before ETFs, indexes and crypto, then by
+// popularity (market cap, recent volume and reddit mentions, weighted by
+// SearchBoost) so "A" surfaces AAPL before obscure tickers. Crypto symbols
+// match with or without their X: prefix. Each result says whether it
+// matched on its symbol or its name. Typos ("APPL", "microsft") are
+// caught by a trigram similarity fallback ranked after the direct hits;
+// see SearchOptions.Fuzzy.
+func SearchSecurities(query string, opts SearchOptions) ([]models.SecuritySearchResult, error) {
+	var results []models.SecuritySearchResult
+	limit := opts.Limit
 
 	searchQuery := `
 		SELECT id, symbol, name, COALESCE(exchange, '') as exchange,
@@ -62,13 +131,27 @@ func SearchStocks(query string, limit int) ([]models.Stock, error) {
 		       COALESCE(website, '') as website,
 		       COALESCE(asset_type, 'stock') as asset_type,
 		       COALESCE(logo_url, '') as logo_url,
-		       created_at, updated_at
+		       created_at, updated_at,
+		       CASE
+		         WHEN UPPER(symbol) LIKE UPPER($4) OR UPPER(REPLACE(symbol, 'X:', '')) LIKE UPPER($4) THEN 'symbol'
+		         WHEN UPPER(name) LIKE UPPER($2) THEN 'name'
+		         ELSE 'symbol'
+		       END as match_reason
 		FROM tickers
-		WHERE UPPER(symbol) LIKE UPPER($1)
+		LEFT JOIN LATERAL (
+			SELECT mentions
+			FROM reddit_ticker_rankings
+			WHERE ticker_symbol = tickers.symbol
+			ORDER BY snapshot_time DESC
+			LIMIT 1
+		) social ON true
+		WHERE (UPPER(symbol) LIKE UPPER($1)
 		   OR UPPER(name) LIKE UPPER($2)
-		   OR UPPER(REPLACE(symbol, 'X:', '')) LIKE UPPER($4)
+		   OR UPPER(REPLACE(symbol, 'X:', '')) LIKE UPPER($4))
+		  AND (COALESCE(active, true) OR $9)
+		  AND (COALESCE(cardinality($11::text[]), 0) = 0 OR COALESCE(asset_type, 'stock') = ANY($11))
 		ORDER BY
-		  -- First priority: match type (exact > starts with > contains)
+		  -- First priority: match type (exact > starts with > name > symbol contains)
 		  CASE
 		    WHEN UPPER(symbol) = UPPER($3) THEN 1
 		    WHEN UPPER(REPLACE(symbol, 'X:', '')) = UPPER($3) THEN 1
@@ -84,30 +167,297 @@ func SearchStocks(query string, limit int) ([]models.Stock, error) {
 		    WHEN 'index' THEN 2
 		    ELSE 3
 		  END,
-		  -- Third priority: alphabetical by symbol
+		  -- Third priority: popularity boost (market cap, volume and reddit mentions)
+		  $7 * LN(1 + GREATEST(COALESCE(market_cap, 0), 0))
+		    + $10 * LN(1 + GREATEST(COALESCE(avg_volume_30d, 0), 0))
+		    + $8 * LN(1 + GREATEST(COALESCE(social.mentions, 0), 0)) DESC,
+		  -- Fourth priority: alphabetical by symbol
 		  symbol
 		LIMIT $6
 	`
 
 	searchTerm := "%" + query + "%"
+	boost := SearchBoost
 
-	err := DB.Select(&stocks, searchQuery,
-		searchTerm, // $1: symbol LIKE
-		searchTerm, // $2: name LIKE
-		query,      // $3: exact symbol match (also checks stripped X: prefix)
-		query+"%",  // $4: symbol starts with (also matches stripped crypto prefix)
-		searchTerm, // $5: name LIKE
-		limit)      // $6: limit
+	err := preparedSelect(&results, searchQuery,
+		searchTerm,            // $1: symbol LIKE
+		searchTerm,            // $2: name LIKE
+		query,                 // $3: exact symbol match (also checks stripped X: prefix)
+		query+"%",             // $4: symbol starts with (also matches stripped crypto prefix)
+		searchTerm,            // $5: name LIKE
+		limit,                 // $6: limit
+		boost.MarketCapWeight, // $7: market cap boost weight
+		boost.SocialWeight,    // $8: social popularity boost weight
+		opts.IncludeInactive,  // $9: include delisted/inactive tickers
+		boost.VolumeWeight,    // $10: volume boost weight
+		pq.Array(opts.Types))  // $11: asset types, empty for all
 
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
-	return stocks, nil
+	// Too few exact/prefix/substring hits usually means a typo; top up with
+	// trigram matches ranked after the direct hits.
+	topUp := len(results) < fuzzySearchMinResults
+	if opts.Fuzzy != nil {
+		topUp = *opts.Fuzzy
+	}
+	if topUp && len(results) < limit {
+		fuzzy, err := searchSecuritiesFuzzy(query, opts, results, limit-len(results))
+		if err != nil {
+			// pg_trgm may be missing in some environments; direct hits are still valid
+			log.Printf("Fuzzy search failed for %q: %v", query, err)
+		} else {
+			results = append(results, fuzzy...)
+		}
+	}
+
+	if results == nil {
+		results = []models.SecuritySearchResult{}
+	}
+	return results, nil
+}
+
+// fuzzySearchMinResults is the number of direct matches below which
+// SearchSecurities falls back to trigram similarity by default.
+const fuzzySearchMinResults = 3
+
+// FuzzySearchThreshold is the default and lowest minimum pg_trgm similarity
+// for a fuzzy match. It matches pg_trgm's similarity_threshold, below which
+// the indexed % operator filters candidates out anyway.
+const FuzzySearchThreshold = 0.3
+
+// searchSecuritiesFuzzy returns tickers of the asset types in opts whose
+// symbol or name is similar to query (pg_trgm), ordered by similarity,
+// excluding the already-found results. The match reason is whichever of
+// symbol and name is more similar.
+func searchSecuritiesFuzzy(query string, opts SearchOptions, exclude []models.SecuritySearchResult, limit int) ([]models.SecuritySearchResult, error) {
+	results := []models.SecuritySearchResult{}
+	if limit <= 0 {
+		return results, nil
+	}
+
+	excludeSymbols := make([]string, len(exclude))
+	for i, s := range exclude {
+		excludeSymbols[i] = s.Symbol
+	}
+
+	fuzzyQuery := `
+		SELECT id, symbol, name, COALESCE(exchange, '') as exchange,
+		       COALESCE(sector, '') as sector,
+		       COALESCE(industry, '') as industry,
+		       COALESCE(country, 'US') as country,
+		       COALESCE(currency, 'USD') as currency,
+		       market_cap,
+		       COALESCE(description, '') as description,
+		       COALESCE(website, '') as website,
+		       COALESCE(asset_type, 'stock') as asset_type,
+		       COALESCE(logo_url, '') as logo_url,
+		       created_at, updated_at,
+		       CASE
+		         WHEN similarity(UPPER(symbol), UPPER($1)) >= similarity(UPPER(name), UPPER($1)) THEN 'symbol'
+		         ELSE 'name'
+		       END as match_reason
+		FROM tickers
+		WHERE (UPPER(name) % UPPER($1) OR UPPER(symbol) % UPPER($1))
+		  AND GREATEST(similarity(UPPER(name), UPPER($1)), similarity(UPPER(symbol), UPPER($1))) >= $2
+		  AND NOT (symbol = ANY($3))
+		  AND (COALESCE(active, true) OR $5)
+		  AND (COALESCE(cardinality($6::text[]), 0) = 0 OR COALESCE(asset_type, 'stock') = ANY($6))
+		ORDER BY
+		  GREATEST(similarity(UPPER(name), UPPER($1)), similarity(UPPER(symbol), UPPER($1))) DESC,
+		  CASE asset_type
+		    WHEN 'stock' THEN 0
+		    WHEN 'etf' THEN 1
+		    WHEN 'index' THEN 2
+		    ELSE 3
+		  END,
+		  symbol
+		LIMIT $4
+	`
+
+	minSimilarity := opts.MinSimilarity
+	if minSimilarity == 0 {
+		minSimilarity = FuzzySearchThreshold
+	}
+
+	err := DB.Select(&results, fuzzyQuery, query, minSimilarity, pq.Array(excludeSymbols), limit, opts.IncludeInactive, pq.Array(opts.Types))
+	if err != nil {
+		return nil, fmt.Errorf("fuzzy search failed: %w", err)
+	}
+
+	return results, nil
+}
+
+// SearchSuggestions returns the top search matches with their latest daily
+// close and day change, in one round trip for autocomplete.
+// Matches are ranked the same way as SearchStocks; the price lookup runs only
+// for the limited set of matches. Inactive tickers are skipped unless
+// includeInactive is set.
+func SearchSuggestions(query string, limit int, includeInactive bool) ([]models.SearchSuggestion, error) {
+	suggestions := []models.SearchSuggestion{}
+
+	suggestQuery := `
+		WITH matches AS (
+			SELECT symbol, name,
+			       COALESCE(exchange, '') as exchange,
+			       COALESCE(asset_type, 'stock') as asset_type,
+			       COALESCE(logo_url, '') as logo_url,
+			       CASE
+			         WHEN UPPER(symbol) = UPPER($3) THEN 1
+			         WHEN UPPER(REPLACE(symbol, 'X:', '')) = UPPER($3) THEN 1
+			         WHEN UPPER(symbol) LIKE UPPER($4) THEN 2
+			         WHEN UPPER(REPLACE(symbol, 'X:', '')) LIKE UPPER($4) THEN 2
+			         WHEN UPPER(name) LIKE UPPER($2) THEN 3
+			         ELSE 4
+			       END as match_rank,
+			       CASE asset_type
+			         WHEN 'stock' THEN 0
+			         WHEN 'etf' THEN 1
+			         WHEN 'index' THEN 2
+			         ELSE 3
+			       END as type_rank
+			FROM tickers
+			WHERE (UPPER(symbol) LIKE UPPER($1)
+			   OR UPPER(name) LIKE UPPER($2)
+			   OR UPPER(REPLACE(symbol, 'X:', '')) LIKE UPPER($4))
+			  AND (COALESCE(active, true) OR $6)
+			ORDER BY match_rank, type_rank, symbol
+			LIMIT $5
+		)
+		SELECT m.symbol, m.name, m.exchange, m.asset_type, m.logo_url,
+		       px.price,
+		       px.price - px.prev_close as change,
+		       CASE WHEN px.prev_close > 0
+		            THEN (px.price - px.prev_close) / px.prev_close * 100
+		       END as change_percent
+		FROM matches m
+		LEFT JOIN LATERAL (
+			SELECT (ARRAY_AGG(p.close ORDER BY p.time DESC))[1]::float8 as price,
+			       (ARRAY_AGG(p.close ORDER BY p.time DESC))[2]::float8 as prev_close
+			FROM (
+				SELECT close, time
+				FROM stock_prices
+				WHERE ticker = m.symbol
+				  AND interval = '1day'
+				ORDER BY time DESC
+				LIMIT 2
+			) p
+		) px ON true
+		ORDER BY m.match_rank, m.type_rank, m.symbol
+	`
+
+	searchTerm := "%" + query + "%"
+
+	err := preparedSelect(&suggestions, suggestQuery,
+		searchTerm,      // $1: symbol LIKE
+		searchTerm,      // $2: name LIKE
+		query,           // $3: exact symbol match
+		query+"%",       // $4: symbol starts with
+		limit,           // $5: limit
+		includeInactive) // $6: include delisted/inactive tickers
+
+	if err != nil {
+		return nil, fmt.Errorf("search suggestions failed: %w", err)
+	}
+
+	return suggestions, nil
 }
 
-// GetPopularStocks returns a list of popular/featured stocks
-func GetPopularStocks(limit int) ([]models.Stock, error) {
+// GetTickerMetadata returns metadata for the given symbols in one query.
+// Symbols are matched case-insensitively; unknown symbols, and inactive ones
+// unless includeInactive is set, are omitted. When a
+// symbol exists under several asset types, the same stock > etf > index > crypto
+// priority as GetStockBySymbol picks one.
+func GetTickerMetadata(symbols []string, includeInactive bool) ([]models.TickerMetadata, error) {
+	metadata := []models.TickerMetadata{}
+	if len(symbols) == 0 {
+		return metadata, nil
+	}
+
+	query := `
+		SELECT DISTINCT ON (UPPER(symbol))
+		       symbol, name,
+		       COALESCE(exchange, '') as exchange,
+		       COALESCE(sector, '') as sector,
+		       COALESCE(industry, '') as industry,
+		       COALESCE(logo_url, '') as logo_url,
+		       COALESCE(asset_type, 'stock') as asset_type
+		FROM tickers
+		WHERE UPPER(symbol) = ANY($1)
+		  AND (COALESCE(active, true) OR $2)
+		ORDER BY UPPER(symbol),
+		  CASE asset_type
+		    WHEN 'stock' THEN 0
+		    WHEN 'etf' THEN 1
+		    WHEN 'index' THEN 2
+		    ELSE 3
+		  END
+	`
+
+	if err := DB.Select(&metadata, query, pq.Array(symbols), includeInactive); err != nil {
+		return nil, fmt.Errorf("failed to get ticker metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// GetTickerSummaries returns name, market cap and latest price change for
+// the given symbols in one query, with the same matching and asset type
+// priority as GetTickerMetadata
+func GetTickerSummaries(symbols []string, includeInactive bool) ([]models.TickerSummary, error) {
+	summaries := []models.TickerSummary{}
+	if len(symbols) == 0 {
+		return summaries, nil
+	}
+
+	query := `
+		SELECT t.symbol, t.name, t.market_cap,
+		       px.price,
+		       px.price - px.prev_close as change,
+		       CASE WHEN px.prev_close > 0
+		            THEN (px.price - px.prev_close) / px.prev_close * 100
+		       END as change_percent
+		FROM (
+			SELECT DISTINCT ON (UPPER(symbol))
+			       symbol, name, market_cap::float8 as market_cap
+			FROM tickers
+			WHERE UPPER(symbol) = ANY($1)
+			  AND (COALESCE(active, true) OR $2)
+			ORDER BY UPPER(symbol),
+			  CASE asset_type
+			    WHEN 'stock' THEN 0
+			    WHEN 'etf' THEN 1
+			    WHEN 'index' THEN 2
+			    ELSE 3
+			  END
+		) t
+		LEFT JOIN LATERAL (
+			SELECT (ARRAY_AGG(sp.close ORDER BY sp.time DESC))[1]::float8 as price,
+			       (ARRAY_AGG(sp.close ORDER BY sp.time DESC))[2]::float8 as prev_close
+			FROM (
+				SELECT close, time
+				FROM stock_prices
+				WHERE ticker = t.symbol
+				  AND interval = '1day'
+				  AND close IS NOT NULL
+				ORDER BY time DESC
+				LIMIT 2
+			) sp
+		) px ON true
+		ORDER BY UPPER(t.symbol)
+	`
+
+	if err := DB.Select(&summaries, query, pq.Array(symbols), includeInactive); err != nil {
+		return nil, fmt.Errorf("failed to get ticker summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetPopularStocks returns a list of popular/featured stocks, skipping inactive
+// tickers unless includeInactive is set
+func GetPopularStocks(limit int, includeInactive bool) ([]models.Stock, error) {
 	var stocks []models.Stock
 
 	// Get some popular stocks - you can customize this query
@@ -123,11 +473,12 @@ func GetPopularStocks(limit int) ([]models.Stock, error) {
 		       created_at, updated_at
 		FROM tickers
 		WHERE symbol IN ('AAPL', 'GOOGL', 'MSFT', 'TSLA', 'AMZN', 'NVDA', 'META', 'NFLX', 'CRM', 'ORCL')
+		  AND (COALESCE(active, true) OR $2)
 		ORDER BY symbol
 		LIMIT $1
 	`
 
-	err := DB.Select(&stocks, query, limit)
+	err := DB.Select(&stocks, query, limit, includeInactive)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get popular stocks: %w", err)
 	}
@@ -135,6 +486,96 @@ func GetPopularStocks(limit int) ([]models.Stock, error) {
 	return stocks, nil
 }
 
+// ValidStockListSortColumns maps ListStocks sort keys to tickers columns
+var ValidStockListSortColumns = map[string]string{
+	"symbol":     "symbol",
+	"name":       "name",
+	"market_cap": "market_cap",
+	"sector":     "sector",
+}
+
+// StockListFilter narrows ListStocks. Empty and nil fields don't filter;
+// Sort must be a ValidStockListSortColumns key and Order asc or desc.
+type StockListFilter struct {
+	Sector          string
+	Exchange        string
+	AssetType       string
+	MinMarketCap    *float64
+	MaxMarketCap    *float64
+	IncludeInactive bool
+	Sort            string
+	Order           string
+	Limit           int
+	Offset          int
+}
+
+// ListStocks returns a page of tickers matching filter and the total number
+// that match
+func ListStocks(filter StockListFilter) ([]models.Stock, int, error) {
+	sortColumn, ok := ValidStockListSortColumns[filter.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sort column: %s", filter.Sort)
+	}
+	order := "ASC"
+	if filter.Order == "desc" {
+		order = "DESC"
+	}
+
+	conditions := []string{"(COALESCE(active, true) OR $1)"}
+	args := []interface{}{filter.IncludeInactive}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if filter.Sector != "" {
+		addCondition("sector = $%d", filter.Sector)
+	}
+	if filter.Exchange != "" {
+		addCondition("exchange = $%d", filter.Exchange)
+	}
+	if filter.AssetType != "" {
+		addCondition("asset_type = $%d", filter.AssetType)
+	}
+	if filter.MinMarketCap != nil {
+		addCondition("market_cap >= $%d", *filter.MinMarketCap)
+	}
+	if filter.MaxMarketCap != nil {
+		addCondition("market_cap <= $%d", *filter.MaxMarketCap)
+	}
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := DB.Get(&total, "SELECT COUNT(*) FROM tickers "+where, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count stocks: %w", err)
+	}
+
+	// sortColumn comes from the allowlist and order is one of two literals
+	query := fmt.Sprintf(`
+		SELECT id, symbol, name, COALESCE(exchange, '') as exchange,
+		       COALESCE(sector, '') as sector,
+		       COALESCE(industry, '') as industry,
+		       COALESCE(country, 'US') as country,
+		       COALESCE(currency, 'USD') as currency,
+		       market_cap,
+		       COALESCE(description, '') as description,
+		       COALESCE(website, '') as website,
+		       COALESCE(asset_type, 'stock') as asset_type,
+		       COALESCE(logo_url, '') as logo_url,
+		       COALESCE(active, true) as active,
+		       created_at, updated_at
+		FROM tickers
+		%s
+		ORDER BY %s %s NULLS LAST, symbol
+		LIMIT $%d OFFSET $%d
+	`, where, sortColumn, order, len(args)+1, len(args)+2)
+
+	stocks := []models.Stock{}
+	if err := DB.Select(&stocks, query, append(args, filter.Limit, filter.Offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list stocks: %w", err)
+	}
+	return stocks, total, nil
+}
+
 // GetStockCount returns the total number of stocks in the database
 func GetStockCount() (int, error) {
 	var count int
@@ -144,3 +585,24 @@ func GetStockCount() (int, error) {
 	}
 	return count, nil
 }
+
+// GetLatestClose returns a ticker's most recent daily close from
+// stock_prices, or nil if it has none
+func GetLatestClose(symbol string) (*float64, error) {
+	var price float64
+	query := `
+		SELECT close
+		FROM stock_prices
+		WHERE ticker = $1 AND interval = '1day' AND close IS NOT NULL
+		ORDER BY time DESC
+		LIMIT 1
+	`
+	err := preparedGet(&price, query, symbol)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest close: %w", err)
+	}
+	return &price, nil
+}
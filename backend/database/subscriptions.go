@@ -15,7 +15,8 @@ func GetAllSubscriptionPlans() ([]models.SubscriptionPlan, error) {
 		SELECT
 			id, name, display_name, description, price_monthly, price_yearly,
 			max_watch_lists, max_items_per_watch_list, max_alert_rules,
-			max_heatmap_configs, features, is_active, created_at, updated_at
+			max_heatmap_configs, max_saved_screens, features, is_active,
+			created_at, updated_at
 		FROM subscription_plans
 		WHERE is_active = true
 		ORDER BY price_monthly ASC
@@ -40,6 +41,7 @@ func GetAllSubscriptionPlans() ([]models.SubscriptionPlan, error) {
 			&plan.MaxItemsPerWatchList,
 			&plan.MaxAlertRules,
 			&plan.MaxHeatmapConfigs,
+			&plan.MaxSavedScreens,
 			&plan.Features,
 			&plan.IsActive,
 			&plan.CreatedAt,
@@ -60,7 +62,8 @@ func GetSubscriptionPlanByID(planID string) (*models.SubscriptionPlan, error) {
 		SELECT
 			id, name, display_name, description, price_monthly, price_yearly,
 			max_watch_lists, max_items_per_watch_list, max_alert_rules,
-			max_heatmap_configs, features, is_active, created_at, updated_at
+			max_heatmap_configs, max_saved_screens, features, is_active,
+			created_at, updated_at
 		FROM subscription_plans
 		WHERE id = $1
 	`
@@ -76,6 +79,7 @@ func GetSubscriptionPlanByID(planID string) (*models.SubscriptionPlan, error) {
 		&plan.MaxItemsPerWatchList,
 		&plan.MaxAlertRules,
 		&plan.MaxHeatmapConfigs,
+		&plan.MaxSavedScreens,
 		&plan.Features,
 		&plan.IsActive,
 		&plan.CreatedAt,
@@ -98,7 +102,8 @@ func GetSubscriptionPlanByName(name string) (*models.SubscriptionPlan, error) {
 		SELECT
 			id, name, display_name, description, price_monthly, price_yearly,
 			max_watch_lists, max_items_per_watch_list, max_alert_rules,
-			max_heatmap_configs, features, is_active, created_at, updated_at
+			max_heatmap_configs, max_saved_screens, features, is_active,
+			created_at, updated_at
 		FROM subscription_plans
 		WHERE name = $1
 	`
@@ -114,6 +119,7 @@ func GetSubscriptionPlanByName(name string) (*models.SubscriptionPlan, error) {
 		&plan.MaxItemsPerWatchList,
 		&plan.MaxAlertRules,
 		&plan.MaxHeatmapConfigs,
+		&plan.MaxSavedScreens,
 		&plan.Features,
 		&plan.IsActive,
 		&plan.CreatedAt,
@@ -174,7 +180,8 @@ func GetUserSubscription(userID string) (*models.UserSubscriptionWithPlan, error
 			us.next_payment_date, us.created_at, us.updated_at,
 			sp.name as plan_name, sp.display_name as plan_display_name,
 			sp.features as plan_features, sp.max_watch_lists,
-			sp.max_items_per_watch_list, sp.max_alert_rules, sp.max_heatmap_configs
+			sp.max_items_per_watch_list, sp.max_alert_rules, sp.max_heatmap_configs,
+			sp.max_saved_screens
 		FROM user_subscriptions us
 		JOIN subscription_plans sp ON us.plan_id = sp.id
 		WHERE us.user_id = $1
@@ -207,6 +214,7 @@ func GetUserSubscription(userID string) (*models.UserSubscriptionWithPlan, error
 		&sub.MaxItemsPerWatchList,
 		&sub.MaxAlertRules,
 		&sub.MaxHeatmapConfigs,
+		&sub.MaxSavedScreens,
 	)
 
 	if err == sql.ErrNoRows {
@@ -227,6 +235,7 @@ func GetUserSubscription(userID string) (*models.UserSubscriptionWithPlan, error
 			MaxItemsPerWatchList: freePlan.MaxItemsPerWatchList,
 			MaxAlertRules:        freePlan.MaxAlertRules,
 			MaxHeatmapConfigs:    freePlan.MaxHeatmapConfigs,
+			MaxSavedScreens:      freePlan.MaxSavedScreens,
 		}, nil
 	}
 	if err != nil {
@@ -312,6 +321,7 @@ func GetUserSubscriptionLimits(userID string) (*models.SubscriptionLimits, error
 		MaxItemsPerWatchList: sub.MaxItemsPerWatchList,
 		MaxAlertRules:        sub.MaxAlertRules,
 		MaxHeatmapConfigs:    sub.MaxHeatmapConfigs,
+		MaxSavedScreens:      sub.MaxSavedScreens,
 		Features:             sub.PlanFeatures,
 	}, nil
 }
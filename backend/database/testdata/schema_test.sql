@@ -3,6 +3,7 @@
 -- Uses regular tables (no materialized views, no TimescaleDB).
 
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 -- tickers table (core stock lookup)
 CREATE TABLE IF NOT EXISTS tickers (
@@ -46,6 +47,40 @@ CREATE TABLE IF NOT EXISTS tickers (
     UNIQUE(symbol)
 );
 
+-- stock_prices (daily OHLCV; a TimescaleDB hypertable in production)
+CREATE TABLE IF NOT EXISTS stock_prices (
+    time TIMESTAMPTZ NOT NULL,
+    ticker VARCHAR(10) NOT NULL,
+    open DECIMAL(10,2),
+    high DECIMAL(10,2),
+    low DECIMAL(10,2),
+    close DECIMAL(10,2),
+    volume BIGINT,
+    vwap DECIMAL(10,2),
+    interval VARCHAR(10) DEFAULT '1day',
+    UNIQUE(ticker, time, interval)
+);
+CREATE INDEX IF NOT EXISTS idx_prices_ticker_time ON stock_prices(ticker, time DESC);
+
+-- stock_splits (split history for price adjustment)
+CREATE TABLE IF NOT EXISTS stock_splits (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL,
+    execution_date DATE NOT NULL,
+    split_from NUMERIC(20,8) NOT NULL CHECK (split_from > 0),
+    split_to NUMERIC(20,8) NOT NULL CHECK (split_to > 0),
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (ticker, execution_date)
+);
+
+-- stock_split_syncs (when split history was last fetched)
+CREATE TABLE IF NOT EXISTS stock_split_syncs (
+    ticker VARCHAR(10) PRIMARY KEY,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
 -- users table (auth critical path)
 CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -65,7 +100,23 @@ CREATE TABLE IF NOT EXISTS users (
     is_active BOOLEAN DEFAULT TRUE,
     is_admin BOOLEAN DEFAULT FALSE,
     is_worker BOOLEAN DEFAULT FALSE,
-    last_activity_at TIMESTAMP
+    last_activity_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    purged_at TIMESTAMP,
+    watchlist_seeded_at TIMESTAMP
+);
+
+-- user_searches (recent and pinned searches per user)
+CREATE TABLE IF NOT EXISTS user_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    query VARCHAR(100) NOT NULL,
+    normalized_query VARCHAR(100) NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    search_count INTEGER NOT NULL DEFAULT 1,
+    searched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, normalized_query)
 );
 
 -- watch_lists table (FK relationships, JOINs)
@@ -302,7 +353,45 @@ CREATE TABLE IF NOT EXISTS sessions (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     user_agent TEXT,
-    ip_address VARCHAR(45)
+    ip_address VARCHAR(45),
+    family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    rotated_at TIMESTAMP,
+    successor_token TEXT
+);
+
+-- account_activity (security audit log per user)
+CREATE TABLE IF NOT EXISTS account_activity (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    ip_address INET,
+    user_agent TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- admin_audit_log (admin mutations)
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    actor_email VARCHAR(255),
+    action VARCHAR(255) NOT NULL,
+    target TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    ip_address INET,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- user_two_factor (TOTP 2FA enrollment)
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    recovery_code_hashes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_step BIGINT,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 -- password_reset_tokens (Batch 2: password reset)
@@ -474,6 +563,7 @@ CREATE TABLE IF NOT EXISTS subscription_plans (
     max_items_per_watch_list INTEGER DEFAULT 10,
     max_alert_rules INTEGER DEFAULT 10,
     max_heatmap_configs INTEGER DEFAULT 3,
+    max_saved_screens INTEGER DEFAULT 5,
     features JSONB DEFAULT '{}',
     is_active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
@@ -500,3 +590,147 @@ CREATE TABLE IF NOT EXISTS user_subscriptions (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
+
+-- ic_scores (owned by ic-score-service; subset of columns)
+CREATE TABLE IF NOT EXISTS ic_scores (
+    id BIGSERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL,
+    date DATE NOT NULL,
+    overall_score DECIMAL(5,2) NOT NULL,
+    rating VARCHAR(20),
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(ticker, date)
+);
+
+-- analyst_ratings (owned by ic-score-service; subset of columns)
+CREATE TABLE IF NOT EXISTS analyst_ratings (
+    id BIGSERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL,
+    rating_date DATE NOT NULL,
+    analyst_name VARCHAR(255) NOT NULL,
+    analyst_firm VARCHAR(255),
+    rating VARCHAR(50) NOT NULL,
+    price_target DECIMAL(10,2),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ticker_sentiment_snapshots (subset of columns)
+CREATE TABLE IF NOT EXISTS ticker_sentiment_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL,
+    snapshot_time TIMESTAMPTZ NOT NULL,
+    time_range VARCHAR(10) NOT NULL,
+    mention_count INTEGER NOT NULL DEFAULT 0,
+    sentiment_score FLOAT NOT NULL DEFAULT 0,
+    sentiment_label VARCHAR(10) NOT NULL DEFAULT 'neutral',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (ticker, snapshot_time, time_range)
+);
+
+-- oauth_providers (account purge; subset of columns)
+CREATE TABLE IF NOT EXISTS oauth_providers (
+    id SERIAL PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    provider_email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(provider, provider_user_id)
+);
+
+-- digest_logs (account purge and data export; subset of columns)
+CREATE TABLE IF NOT EXISTS digest_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    digest_type VARCHAR(20) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    email_sent BOOLEAN DEFAULT false
+);
+
+-- phone_verifications (pending SMS number confirmations)
+CREATE TABLE IF NOT EXISTS phone_verifications (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    phone_number VARCHAR(16) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- notification_daily_counts (account purge)
+CREATE TABLE IF NOT EXISTS notification_daily_counts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    alerts INTEGER NOT NULL DEFAULT 0,
+    emails INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+-- payment_history (account purge; subset of columns)
+CREATE TABLE IF NOT EXISTS payment_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    subscription_id UUID REFERENCES user_subscriptions(id) ON DELETE SET NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'USD',
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- backtest_jobs (account purge and data export)
+CREATE TABLE IF NOT EXISTS backtest_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    config JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    result JSONB
+);
+
+-- portfolios / portfolio_holdings / portfolio_transactions (user portfolios)
+CREATE TABLE IF NOT EXISTS portfolios (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_holdings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    shares DECIMAL(20, 6) NOT NULL CHECK (shares > 0),
+    average_cost DECIMAL(20, 4) NOT NULL CHECK (average_cost >= 0),
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (portfolio_id, symbol)
+);
+
+CREATE TABLE IF NOT EXISTS portfolio_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    transaction_type VARCHAR(4) NOT NULL CHECK (transaction_type IN ('BUY', 'SELL')),
+    shares DECIMAL(20, 6) NOT NULL CHECK (shares > 0),
+    price DECIMAL(20, 4) NOT NULL CHECK (price >= 0),
+    executed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- saved_screens (saved screener criteria)
+CREATE TABLE IF NOT EXISTS saved_screens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -12,7 +12,7 @@ import (
 // skipIfNoTestDB skips the test if INTEGRATION_TEST_DB is not set.
 // This allows integration tests to run in CI (with PostgreSQL service container)
 // while skipping gracefully in local dev without a database.
-func skipIfNoTestDB(t *testing.T) {
+func skipIfNoTestDB(t testing.TB) {
 	t.Helper()
 	if os.Getenv("INTEGRATION_TEST_DB") != "true" {
 		t.Skip("Skipping integration test: INTEGRATION_TEST_DB not set")
@@ -22,7 +22,7 @@ func skipIfNoTestDB(t *testing.T) {
 // setupTestDB connects to the test database, runs the schema, swaps
 // database.DB to point at the test DB, and registers cleanup to restore
 // the original DB and drop tables.
-func setupTestDB(t *testing.T) {
+func setupTestDB(t testing.TB) {
 	t.Helper()
 	skipIfNoTestDB(t)
 
@@ -66,11 +66,14 @@ func setupTestDB(t *testing.T) {
 		// Schema uses CREATE TABLE IF NOT EXISTS, so tables
 		// persist across tests without issue.
 		db.Exec(`TRUNCATE
-			tickers, users, watch_lists, watch_list_items, screener_data,
+			tickers, stock_prices, stock_splits, stock_split_syncs, users, user_searches, watch_lists, watch_list_items, screener_data,
 			financial_statements, eps_estimates, valuation_ratios, fundamental_metrics_extended,
-			mv_latest_sector_percentiles, alert_rules, alert_logs, sessions, password_reset_tokens,
+			mv_latest_sector_percentiles, alert_rules, alert_logs, sessions, account_activity, admin_audit_log, user_two_factor, password_reset_tokens,
 			notification_preferences, notification_queue, sentiment_lexicon, reddit_posts_raw, reddit_post_tickers,
-			reddit_heatmap_daily, heatmap_configs, subscription_plans, user_subscriptions
+		reddit_ticker_rankings,
+			reddit_heatmap_daily, heatmap_configs, subscription_plans, user_subscriptions,
+			ic_scores, analyst_ratings, ticker_sentiment_snapshots,
+			oauth_providers, digest_logs, notification_daily_counts, phone_verifications, payment_history, backtest_jobs, portfolios, portfolio_holdings, portfolio_transactions, saved_screens
 			CASCADE`)
 		db.Close()
 		DB = origDB
@@ -78,14 +81,17 @@ func setupTestDB(t *testing.T) {
 }
 
 // cleanTables truncates all test tables for isolation between tests.
-func cleanTables(t *testing.T) {
+func cleanTables(t testing.TB) {
 	t.Helper()
 	DB.MustExec(`TRUNCATE
-		tickers, users, watch_lists, watch_list_items, screener_data,
+		tickers, stock_prices, stock_splits, stock_split_syncs, users, watch_lists, watch_list_items, screener_data,
 		financial_statements, eps_estimates, valuation_ratios, fundamental_metrics_extended,
 		mv_latest_sector_percentiles, alert_rules, alert_logs, sessions, password_reset_tokens,
 		notification_preferences, notification_queue, sentiment_lexicon, reddit_posts_raw, reddit_post_tickers,
-		reddit_heatmap_daily, heatmap_configs, subscription_plans, user_subscriptions
+		reddit_ticker_rankings,
+		reddit_heatmap_daily, heatmap_configs, subscription_plans, user_subscriptions,
+		ic_scores, analyst_ratings, ticker_sentiment_snapshots,
+		oauth_providers, digest_logs, notification_daily_counts, phone_verifications, payment_history, backtest_jobs, portfolios, portfolio_holdings, portfolio_transactions, saved_screens
 		CASCADE`)
 }
 
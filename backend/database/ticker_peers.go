@@ -0,0 +1,115 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"investorcenter-api/models"
+)
+
+// TickerPeerMetrics are the metrics peers can be ranked by closeness in,
+// mapped to their SQL expression in GetTickerPeers. Column names can't be
+// parameterized, so only these keys are accepted.
+var TickerPeerMetrics = map[string]string{
+	"market_cap": "t.market_cap::float8",
+	"pe_ratio":   "v.pe_ratio",
+	"pb_ratio":   "v.pb_ratio",
+	"ps_ratio":   "v.ps_ratio",
+}
+
+// TickerPeerFilter selects the tickers considered peers. Non-empty fields
+// must match exactly; at least one must be set.
+type TickerPeerFilter struct {
+	Sector   string
+	Industry string
+	Exchange string
+}
+
+// GetTickerPeers returns up to limit active stocks matching filter, other
+// than symbol itself, ranked by how close metric is to symbol's own value.
+// Peers without a value for metric are left out.
+func GetTickerPeers(symbol string, filter TickerPeerFilter, metric string, limit int) ([]models.TickerPeer, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	metricExpr, ok := TickerPeerMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("invalid peer metric: %s", metric)
+	}
+
+	args := []interface{}{symbol}
+	var conditions []string
+	for _, f := range []struct{ column, value string }{
+		{"sector", filter.Sector},
+		{"industry", filter.Industry},
+		{"exchange", filter.Exchange},
+	} {
+		if f.value == "" {
+			continue
+		}
+		args = append(args, f.value)
+		conditions = append(conditions, fmt.Sprintf("t.%s = $%d", f.column, len(args)))
+	}
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("peer filter has no sector, industry or exchange")
+	}
+	args = append(args, limit)
+
+	valuation := `
+		LEFT JOIN LATERAL (
+			SELECT ttm_pe_ratio::float8 as pe_ratio,
+			       ttm_pb_ratio::float8 as pb_ratio,
+			       ttm_ps_ratio::float8 as ps_ratio
+			FROM valuation_ratios WHERE ticker = t.symbol
+			ORDER BY calculation_date DESC LIMIT 1
+		) v ON true`
+
+	// metricExpr comes from the TickerPeerMetrics allowlist above
+	query := fmt.Sprintf(`
+		WITH target AS (
+			SELECT %[1]s as value
+			FROM tickers t %[2]s
+			WHERE UPPER(t.symbol) = UPPER($1)
+			ORDER BY CASE t.asset_type WHEN 'stock' THEN 0 ELSE 1 END
+			LIMIT 1
+		)
+		SELECT p.symbol, p.name, p.market_cap, p.pe_ratio,
+		       px.price,
+		       px.price - px.prev_close as change,
+		       CASE WHEN px.prev_close > 0
+		            THEN (px.price - px.prev_close) / px.prev_close * 100
+		       END as change_percent
+		FROM (
+			SELECT t.symbol, t.name, t.market_cap::float8 as market_cap, v.pe_ratio,
+			       ABS(%[1]s - (SELECT value FROM target)) as distance
+			FROM tickers t %[2]s
+			WHERE %[3]s
+			  AND UPPER(t.symbol) != UPPER($1)
+			  AND t.asset_type = 'stock'
+			  AND COALESCE(t.active, true)
+			  AND %[1]s IS NOT NULL
+			ORDER BY distance ASC NULLS LAST, t.market_cap DESC NULLS LAST, t.symbol
+			LIMIT $%[4]d
+		) p
+		LEFT JOIN LATERAL (
+			SELECT (ARRAY_AGG(sp.close ORDER BY sp.time DESC))[1]::float8 as price,
+			       (ARRAY_AGG(sp.close ORDER BY sp.time DESC))[2]::float8 as prev_close
+			FROM (
+				SELECT close, time
+				FROM stock_prices
+				WHERE ticker = p.symbol
+				  AND interval = '1day'
+				ORDER BY time DESC
+				LIMIT 2
+			) sp
+		) px ON true
+		ORDER BY p.distance ASC NULLS LAST, p.market_cap DESC NULLS LAST, p.symbol
+	`, metricExpr, valuation, strings.Join(conditions, " AND "), len(args))
+
+	peers := []models.TickerPeer{}
+	if err := DB.Select(&peers, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get ticker peers: %w", err)
+	}
+	return peers, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrTwoFactorAlreadyEnabled is returned when enrolling or enabling 2FA for
+// a user who already has it on
+var ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// UserTwoFactor is a user's TOTP two-factor enrollment
+type UserTwoFactor struct {
+	UserID             string         `db:"user_id"`
+	SecretEncrypted    string         `db:"secret_encrypted"`
+	Enabled            bool           `db:"enabled"`
+	RecoveryCodeHashes pq.StringArray `db:"recovery_code_hashes"`
+	LastUsedStep       *int64         `db:"last_used_step"`
+	EnabledAt          *time.Time     `db:"enabled_at"`
+	CreatedAt          time.Time      `db:"created_at"`
+	UpdatedAt          time.Time      `db:"updated_at"`
+}
+
+// GetUserTwoFactor returns the user's 2FA enrollment, or nil if none
+func GetUserTwoFactor(userID string) (*UserTwoFactor, error) {
+	var tf UserTwoFactor
+	err := DB.Get(&tf, `
+		SELECT user_id, secret_encrypted, enabled, recovery_code_hashes,
+		       last_used_step, enabled_at, created_at, updated_at
+		FROM user_two_factor
+		WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	return &tf, nil
+}
+
+// SaveTwoFactorEnrollment stores a new, not yet enabled secret for the
+// user, replacing any unconfirmed enrollment. It returns
+// ErrTwoFactorAlreadyEnabled rather than replace an enabled one.
+func SaveTwoFactorEnrollment(userID, secretEncrypted string) error {
+	result, err := DB.Exec(`
+		INSERT INTO user_two_factor (user_id, secret_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret_encrypted = EXCLUDED.secret_encrypted,
+			recovery_code_hashes = '{}',
+			last_used_step = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE NOT user_two_factor.enabled
+	`, userID, secretEncrypted)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor enrollment: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTwoFactor turns on the user's pending enrollment with its recovery
+// code hashes, recording step as the confirming code's time step
+func EnableTwoFactor(userID string, recoveryCodeHashes []string, step int64) error {
+	result, err := DB.Exec(`
+		UPDATE user_two_factor SET
+			enabled = true,
+			recovery_code_hashes = $2,
+			last_used_step = $3,
+			enabled_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND NOT enabled
+	`, userID, pq.Array(recoveryCodeHashes), step)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// DisableTwoFactor removes the user's 2FA enrollment
+func DisableTwoFactor(userID string) error {
+	if _, err := DB.Exec(`DELETE FROM user_two_factor WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// UseTwoFactorStep records step as the user's last accepted TOTP time
+// step. It reports false when step isn't later than the last one, i.e. the
+// code was already used.
+func UseTwoFactorStep(userID string, step int64) (bool, error) {
+	result, err := DB.Exec(`
+		UPDATE user_two_factor SET last_used_step = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND (last_used_step IS NULL OR last_used_step < $2)
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code use: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code use: %w", err)
+	}
+	return n > 0, nil
+}
+
+// UseRecoveryCode removes a recovery code hash from the user's remaining
+// codes, reporting false if it wasn't one of them
+func UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := DB.Exec(`
+		UPDATE user_two_factor SET
+			recovery_code_hashes = array_remove(recovery_code_hashes, $2),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND $2 = ANY(recovery_code_hashes)
+	`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return n > 0, nil
+}
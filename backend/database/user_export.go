@@ -0,0 +1,230 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+// userExportTables lists every table with a user_id column and how
+// GetUserDataExport covers it: "" when the user's rows are exported,
+// otherwise why they are left out. A table added with a user_id column
+// must be added here, and the export test fails until it is.
+var userExportTables = map[string]string{
+	"watch_lists":               "",
+	"heatmap_configs":           "",
+	"alert_rules":               "",
+	"alert_logs":                "",
+	"notification_queue":        "",
+	"notification_preferences":  "",
+	"phone_verifications":       "",
+	"digest_logs":               "",
+	"portfolios":                "",
+	"saved_screens":             "",
+	"user_searches":             "",
+	"backtest_jobs":             "",
+	"sessions":                  "",
+	"account_activity":          "",
+	"user_two_factor":           "",
+	"oauth_providers":           "",
+	"user_subscriptions":        "",
+	"payment_history":           "",
+	"password_reset_tokens":     "single-use credentials",
+	"notification_daily_counts": "internal delivery counters, reset daily",
+	"alert_trigger_logs":        "legacy table from 012_alert_system.sql, never written",
+	"notifications":             "legacy table from 012_alert_system.sql, never written",
+}
+
+// GetUserDataExport assembles everything stored about user for a data
+// export. A user without notification preferences, 2FA enrollment or a
+// pending phone verification exports them as null.
+func GetUserDataExport(user *models.User) (*models.UserDataExport, error) {
+	export := &models.UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    user.ToPublic(),
+		WatchLists: []models.WatchListExport{},
+		Portfolios: []models.PortfolioExport{},
+	}
+
+	watchLists, err := GetWatchListsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, wl := range watchLists {
+		items, err := GetWatchListItems(wl.ID)
+		if err != nil {
+			return nil, err
+		}
+		heatmaps, err := GetHeatmapConfigsByWatchListID(wl.ID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		export.WatchLists = append(export.WatchLists, models.WatchListExport{WatchListSummary: wl, Items: items, HeatmapConfigs: heatmaps})
+	}
+
+	if export.AlertRules, err = GetAlertRulesByUserID(user.ID, "", ""); err != nil {
+		return nil, err
+	}
+	if export.AlertHistory, err = GetAlertLogsByUserID(user.ID, "", "", 0, 0); err != nil {
+		return nil, err
+	}
+	if export.Notifications, err = GetInAppNotifications(user.ID, false, 0); err != nil {
+		return nil, err
+	}
+
+	prefs, err := GetNotificationPreferences(user.ID)
+	if err != nil && !errors.Is(err, ErrNotificationPreferencesNotFound) {
+		return nil, err
+	}
+	export.NotificationPreferences = prefs
+
+	if export.PhoneVerification, err = getPhoneVerificationExport(user.ID); err != nil {
+		return nil, err
+	}
+	if export.Digests, err = getDigestLogExports(user.ID); err != nil {
+		return nil, err
+	}
+
+	portfolios, err := GetPortfoliosByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range portfolios {
+		holdings, err := getPortfolioHoldings(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		transactions, err := GetPortfolioTransactions(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		export.Portfolios = append(export.Portfolios, models.PortfolioExport{PortfolioListItem: p, Holdings: holdings, Transactions: transactions})
+	}
+
+	if export.SavedScreens, err = GetSavedScreensByUserID(user.ID); err != nil {
+		return nil, err
+	}
+	if export.Searches, err = GetUserSearches(user.ID); err != nil {
+		return nil, err
+	}
+	if export.Backtests, err = GetUserBacktestJobs(user.ID, 0); err != nil {
+		return nil, err
+	}
+
+	if export.Sessions, err = GetUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+	if export.AccountActivity, err = getAllAccountActivity(user.ID); err != nil {
+		return nil, err
+	}
+	if export.TwoFactor, err = getTwoFactorExport(user.ID); err != nil {
+		return nil, err
+	}
+	if export.LinkedAccounts, err = getLinkedAccountExports(user.ID); err != nil {
+		return nil, err
+	}
+
+	if export.Subscription, err = GetUserSubscription(user.ID); err != nil {
+		return nil, err
+	}
+	// Payment-provider identifiers are internal
+	export.Subscription.StripeSubscriptionID = nil
+	export.Subscription.StripeCustomerID = nil
+
+	if export.Payments, err = GetPaymentHistory(user.ID, 0); err != nil {
+		return nil, err
+	}
+	for i := range export.Payments {
+		export.Payments[i].StripePaymentIntentID = nil
+		export.Payments[i].StripeInvoiceID = nil
+	}
+
+	return export, nil
+}
+
+func getPhoneVerificationExport(userID string) (*models.PhoneVerificationExport, error) {
+	var v models.PhoneVerificationExport
+	err := DB.Get(&v, `
+		SELECT phone_number, attempts, expires_at, created_at
+		FROM phone_verifications
+		WHERE user_id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export phone verification: %w", err)
+	}
+	return &v, nil
+}
+
+func getDigestLogExports(userID string) ([]models.DigestLogExport, error) {
+	digests := []models.DigestLogExport{}
+	err := DB.Select(&digests, `
+		SELECT digest_type, period_start, period_end, sent_at, COALESCE(email_sent, false) AS email_sent
+		FROM digest_logs
+		WHERE user_id = $1
+		ORDER BY period_start DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export digests: %w", err)
+	}
+	return digests, nil
+}
+
+func getPortfolioHoldings(portfolioID string) ([]models.PortfolioHolding, error) {
+	holdings := []models.PortfolioHolding{}
+	err := DB.Select(&holdings, `
+		SELECT id, portfolio_id, symbol, shares, average_cost, added_at, updated_at
+		FROM portfolio_holdings
+		WHERE portfolio_id = $1
+		ORDER BY symbol
+	`, portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export portfolio holdings: %w", err)
+	}
+	return holdings, nil
+}
+
+func getAllAccountActivity(userID string) ([]models.AccountActivity, error) {
+	activity := []models.AccountActivity{}
+	err := DB.Select(&activity, `
+		SELECT id, user_id, event_type, ip_address, user_agent, metadata, created_at
+		FROM account_activity
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export account activity: %w", err)
+	}
+	return activity, nil
+}
+
+func getTwoFactorExport(userID string) (*models.TwoFactorExport, error) {
+	var tf models.TwoFactorExport
+	err := DB.Get(&tf, `SELECT enabled, enabled_at, created_at FROM user_two_factor WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to export two-factor settings: %w", err)
+	}
+	return &tf, nil
+}
+
+func getLinkedAccountExports(userID string) ([]models.LinkedAccountExport, error) {
+	accounts := []models.LinkedAccountExport{}
+	err := DB.Select(&accounts, `
+		SELECT provider, provider_user_id, provider_email, created_at
+		FROM oauth_providers
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export linked accounts: %w", err)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var (
+	createTableRe  = regexp.MustCompile(`(?is)CREATE TABLE (?:IF NOT EXISTS )?(\w+)\s*\((.*?)\n\s*\);`)
+	userIDColumnRe = regexp.MustCompile(`(?im)^\s*user_id\s`)
+	addUserIDRe    = regexp.MustCompile(`(?is)ALTER TABLE (?:IF EXISTS )?(\w+)\s+ADD COLUMN (?:IF NOT EXISTS )?user_id\s`)
+	dropTableRe    = regexp.MustCompile(`(?i)DROP TABLE (?:IF EXISTS )?(\w+)`)
+)
+
+// userIDTables returns the tables the migrations leave with a user_id column
+func userIDTables(t *testing.T) []string {
+	t.Helper()
+	migrationsFS := os.DirFS("..")
+	files, err := discoverMigrations(migrationsFS)
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+
+	tables := map[string]bool{}
+	for _, f := range files {
+		data, err := fs.ReadFile(migrationsFS, "migrations/"+f)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f, err)
+		}
+		sql := string(data)
+		for _, m := range createTableRe.FindAllStringSubmatch(sql, -1) {
+			if userIDColumnRe.MatchString(m[2]) {
+				tables[m[1]] = true
+			}
+		}
+		for _, m := range addUserIDRe.FindAllStringSubmatch(sql, -1) {
+			tables[m[1]] = true
+		}
+		for _, m := range dropTableRe.FindAllStringSubmatch(sql, -1) {
+			delete(tables, m[1])
+		}
+	}
+
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestUserExportTables_CoverEveryUserIDTable(t *testing.T) {
+	tables := userIDTables(t)
+	if len(tables) == 0 {
+		t.Fatal("found no user_id tables in the migrations")
+	}
+
+	found := map[string]bool{}
+	for _, table := range tables {
+		found[table] = true
+		if _, ok := userExportTables[table]; !ok {
+			t.Errorf("table %s has a user_id column but is neither exported nor excluded in userExportTables", table)
+		}
+	}
+	for table := range userExportTables {
+		if !found[table] {
+			t.Errorf("userExportTables lists %s, which no migration creates with a user_id column", table)
+		}
+	}
+}
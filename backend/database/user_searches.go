@@ -0,0 +1,131 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"investorcenter-api/models"
+)
+
+// MaxRecentSearches caps the unpinned search history kept per user
+const MaxRecentSearches = 20
+
+// MaxPinnedSearches caps the pinned searches per user
+const MaxPinnedSearches = 10
+
+// ErrUserSearchNotFound is returned when a saved search does not exist for the user
+var ErrUserSearchNotFound = errors.New("search not found")
+
+// ErrTooManyPinnedSearches is returned when pinning would exceed MaxPinnedSearches
+var ErrTooManyPinnedSearches = errors.New("too many pinned searches")
+
+// NormalizeSearchQuery lowercases a query and collapses whitespace so
+// "AAPL", " aapl " and "Aapl" dedupe to one history entry.
+func NormalizeSearchQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// RecordUserSearch upserts a query into the user's history, bumping it to the
+// most recent position. pinned, when non-nil, sets the pinned flag; otherwise
+// the existing flag is kept. Unpinned history beyond MaxRecentSearches is trimmed.
+func RecordUserSearch(userID, query string, pinned *bool) (*models.UserSearch, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	normalized := NormalizeSearchQuery(query)
+	if normalized == "" {
+		return nil, errors.New("search query is empty")
+	}
+
+	if pinned != nil && *pinned {
+		var pinnedCount int
+		err := DB.Get(&pinnedCount, `
+			SELECT COUNT(*) FROM user_searches
+			WHERE user_id = $1 AND pinned = true AND normalized_query <> $2
+		`, userID, normalized)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count pinned searches: %w", err)
+		}
+		if pinnedCount >= MaxPinnedSearches {
+			return nil, ErrTooManyPinnedSearches
+		}
+	}
+
+	upsertQuery := `
+		INSERT INTO user_searches (user_id, query, normalized_query, pinned)
+		VALUES ($1, $2, $3, COALESCE($4, false))
+		ON CONFLICT (user_id, normalized_query) DO UPDATE SET
+			query = EXCLUDED.query,
+			pinned = COALESCE($4, user_searches.pinned),
+			search_count = user_searches.search_count + 1,
+			searched_at = NOW()
+		RETURNING id, user_id, query, pinned, search_count, searched_at, created_at
+	`
+	search := &models.UserSearch{}
+	if err := DB.Get(search, upsertQuery, userID, query, normalized, pinned); err != nil {
+		return nil, fmt.Errorf("failed to record search: %w", err)
+	}
+
+	trimQuery := `
+		DELETE FROM user_searches
+		WHERE user_id = $1 AND pinned = false
+		  AND id NOT IN (
+			SELECT id FROM user_searches
+			WHERE user_id = $1 AND pinned = false
+			ORDER BY searched_at DESC
+			LIMIT $2
+		  )
+	`
+	if _, err := DB.Exec(trimQuery, userID, MaxRecentSearches); err != nil {
+		return nil, fmt.Errorf("failed to trim search history: %w", err)
+	}
+
+	return search, nil
+}
+
+// GetUserSearches returns a user's saved searches, most recent first
+func GetUserSearches(userID string) ([]models.UserSearch, error) {
+	searches := []models.UserSearch{}
+	query := `
+		SELECT id, user_id, query, pinned, search_count, searched_at, created_at
+		FROM user_searches
+		WHERE user_id = $1
+		ORDER BY searched_at DESC
+	`
+	if err := DB.Select(&searches, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get searches: %w", err)
+	}
+	return searches, nil
+}
+
+// DeleteUserSearch removes a single saved search owned by the user
+func DeleteUserSearch(userID, searchID string) error {
+	result, err := DB.Exec(`DELETE FROM user_searches WHERE id = $1 AND user_id = $2`, searchID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserSearchNotFound
+	}
+	return nil
+}
+
+// ClearUserSearches removes the user's recent searches, keeping pinned ones
+// unless includePinned is set. Returns the number of rows removed.
+func ClearUserSearches(userID string, includePinned bool) (int64, error) {
+	query := `DELETE FROM user_searches WHERE user_id = $1 AND (pinned = false OR $2)`
+	result, err := DB.Exec(query, userID, includePinned)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear searches: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
@@ -236,9 +236,35 @@ func GetUserByPasswordResetToken(token string) (*models.User, error) {
 	return user, nil
 }
 
-// SoftDeleteUser marks user as inactive (soft delete)
+// SoftDeleteUser marks user as inactive (soft delete). Their data is removed
+// by PurgeUserData once the account-purge grace window has passed.
 func SoftDeleteUser(userID string) error {
-	query := `UPDATE users SET is_active = FALSE WHERE id = $1`
+	query := `UPDATE users SET is_active = FALSE, deleted_at = NOW() WHERE id = $1`
 	_, err := DB.Exec(query, userID)
 	return err
 }
+
+// ErrUserNotFound is returned when updating a user that doesn't exist or
+// has been deleted
+var ErrUserNotFound = errors.New("user not found")
+
+// SetUserAdmin grants or revokes an active user's admin role. The change
+// reaches the user's tokens on their next refresh.
+func SetUserAdmin(userID string, isAdmin bool) error {
+	query := `
+		UPDATE users SET is_admin = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND is_active = TRUE
+	`
+	result, err := DB.Exec(query, isAdmin, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update admin role: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update admin role: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
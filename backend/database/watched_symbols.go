@@ -0,0 +1,33 @@
+package database
+
+import "fmt"
+
+// GetWatchedSymbols returns every stock symbol on a watch list or an
+// active alert rule, sorted. Crypto symbols (X: prefix) are left out.
+func GetWatchedSymbols() ([]string, error) {
+	query := `
+		SELECT symbol FROM watch_list_items WHERE symbol NOT LIKE 'X:%'
+		UNION
+		SELECT symbol FROM alert_rules WHERE is_active = true AND symbol NOT LIKE 'X:%'
+		ORDER BY symbol
+	`
+	var symbols []string
+	if err := DB.Select(&symbols, query); err != nil {
+		return nil, fmt.Errorf("failed to get watched symbols: %w", err)
+	}
+	return symbols, nil
+}
+
+// GetActiveStockSymbols returns every active common stock symbol, sorted
+func GetActiveStockSymbols() ([]string, error) {
+	var symbols []string
+	err := DB.Select(&symbols, `
+		SELECT symbol FROM tickers
+		WHERE COALESCE(active, TRUE) AND asset_type IN ('CS', 'stock')
+		ORDER BY symbol
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active stock symbols: %w", err)
+	}
+	return symbols, nil
+}
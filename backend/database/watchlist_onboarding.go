@@ -0,0 +1,95 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// WatchListSeeded reports whether onboarding has already run (or been
+// skipped) for a user. Unknown users return ErrUserNotFound.
+func WatchListSeeded(userID string) (bool, error) {
+	var seeded bool
+	err := DB.QueryRow(`SELECT watchlist_seeded_at IS NOT NULL FROM users WHERE id = $1`, userID).Scan(&seeded)
+	if err == sql.ErrNoRows {
+		return false, ErrUserNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check watch list onboarding: %w", err)
+	}
+	return seeded, nil
+}
+
+// SeedDefaultWatchList adds symbols to the user's default watch list, in
+// order, and marks the user seeded, all in one transaction. It runs once
+// per user: later calls add nothing and return 0. Symbols missing from the
+// tickers table or already on the list are skipped; an empty symbols list
+// only marks the user seeded. The default list is created if the user has
+// none. Returns the number of items added.
+func SeedDefaultWatchList(userID string, symbols []string) (int, error) {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin watch list onboarding: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the user row so concurrent logins can't both seed
+	var seeded bool
+	err = tx.QueryRow(
+		`SELECT watchlist_seeded_at IS NOT NULL FROM users WHERE id = $1 FOR UPDATE`,
+		userID,
+	).Scan(&seeded)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock user for watch list onboarding: %w", err)
+	}
+	if seeded {
+		return 0, nil
+	}
+
+	var added int64
+	if len(symbols) > 0 {
+		var watchListID string
+		err = tx.QueryRow(
+			`SELECT id FROM watch_lists WHERE user_id = $1 AND is_default = TRUE ORDER BY display_order LIMIT 1`,
+			userID,
+		).Scan(&watchListID)
+		if err == sql.ErrNoRows {
+			// Normally created by the auto_create_default_watch_list trigger
+			err = tx.QueryRow(`
+				INSERT INTO watch_lists (user_id, name, description, is_default, display_order)
+				VALUES ($1, 'My Watch List', 'Default watch list', TRUE, 0)
+				RETURNING id
+			`, userID).Scan(&watchListID)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get default watch list: %w", err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO watch_list_items (watch_list_id, symbol, tags, display_order)
+			SELECT $1, s.symbol, '{}',
+				COALESCE((SELECT MAX(display_order) + 1 FROM watch_list_items WHERE watch_list_id = $1), 0) + s.ord - 1
+			FROM unnest($2::text[]) WITH ORDINALITY AS s(symbol, ord)
+			WHERE EXISTS (SELECT 1 FROM tickers WHERE symbol = s.symbol)
+			ON CONFLICT (watch_list_id, symbol) DO NOTHING
+		`, watchListID, pq.Array(symbols))
+		if err != nil {
+			return 0, fmt.Errorf("failed to seed watch list items: %w", err)
+		}
+		if added, err = result.RowsAffected(); err != nil {
+			return 0, fmt.Errorf("failed to count seeded watch list items: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET watchlist_seeded_at = NOW() WHERE id = $1`, userID); err != nil {
+		return 0, fmt.Errorf("failed to mark watch list onboarding done: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit watch list onboarding: %w", err)
+	}
+	return int(added), nil
+}
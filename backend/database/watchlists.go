@@ -173,13 +173,44 @@ func AddTickerToWatchList(item *models.WatchListItem) error {
 	if !exists {
 		return ErrTickerNotFound
 	}
+	return insertWatchListItem(item)
+}
+
+// TickersExist returns the subset of symbols present in the tickers table,
+// checked in a single query
+func TickersExist(symbols []string) (map[string]bool, error) {
+	found := make(map[string]bool, len(symbols))
+	if len(symbols) == 0 {
+		return found, nil
+	}
+
+	rows, err := DB.Query("SELECT symbol FROM tickers WHERE symbol = ANY($1)", pq.Array(symbols))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify tickers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("failed to scan ticker: %w", err)
+		}
+		found[symbol] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to verify tickers: %w", err)
+	}
+	return found, nil
+}
 
+// insertWatchListItem inserts an item whose ticker is already known to exist
+func insertWatchListItem(item *models.WatchListItem) error {
 	query := `
 		INSERT INTO watch_list_items (watch_list_id, symbol, notes, tags, target_buy_price, target_sell_price, display_order)
 		VALUES ($1, $2, $3, $4, $5, $6, COALESCE((SELECT MAX(display_order) + 1 FROM watch_list_items WHERE watch_list_id = $1), 0))
 		RETURNING id, added_at, display_order
 	`
-	err = DB.QueryRow(
+	err := DB.QueryRow(
 		query,
 		item.WatchListID,
 		item.Symbol,
@@ -548,18 +579,28 @@ func RemoveTickerFromWatchList(watchListID string, symbol string) error {
 	return nil
 }
 
-// BulkAddTickers adds multiple tickers to a watch list
+// BulkAddTickers adds multiple tickers to a watch list. All symbols are
+// validated with one TickersExist query before any are inserted.
 func BulkAddTickers(watchListID string, symbols []string) ([]string, []string, error) {
 	added := []string{}
 	failed := []string{}
 
+	valid, err := TickersExist(symbols)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	for _, symbol := range symbols {
+		if !valid[symbol] {
+			failed = append(failed, symbol)
+			continue
+		}
 		item := &models.WatchListItem{
 			WatchListID: watchListID,
 			Symbol:      symbol,
 			Tags:        []string{},
 		}
-		err := AddTickerToWatchList(item)
+		err := insertWatchListItem(item)
 		if err == nil {
 			added = append(added, symbol)
 		} else {
@@ -626,7 +667,7 @@ func ValidateItemsBelongToWatchList(watchListID string, itemIDs []string) error
 		return fmt.Errorf("failed to validate item ownership: %w", err)
 	}
 	if count != len(itemIDs) {
-		return errors.New("one or more items do not belong to this watch list")
+		return fmt.Errorf("one or more items do not belong to this watch list: %w", ErrWatchListItemNotFound)
 	}
 	return nil
 }
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// recordAccountActivity logs a security-relevant event on userID's account
+// with the request's IP address and user agent. It is best-effort: the
+// action being audited has already succeeded, so a failure is only logged.
+func recordAccountActivity(c *gin.Context, userID, eventType string, metadata map[string]interface{}) {
+	activity := &models.AccountActivity{
+		UserID:    userID,
+		EventType: eventType,
+	}
+	if ip := c.ClientIP(); ip != "" {
+		activity.IPAddress = &ip
+	}
+	if ua := c.Request.UserAgent(); ua != "" {
+		activity.UserAgent = &ua
+	}
+	if metadata != nil {
+		raw, err := json.Marshal(metadata)
+		if err != nil {
+			log.Printf("Failed to encode %s activity metadata for user %s: %v", eventType, userID, err)
+		}
+		activity.Metadata = raw
+	}
+
+	if err := database.RecordAccountActivity(activity); err != nil {
+		log.Printf("Failed to record %s activity for user %s: %v", eventType, userID, err)
+	}
+}
+
+// GetAccountActivity handles GET /api/v1/user/activity
+// Returns the user's logins, password changes and subscription changes,
+// newest first. Supports ?limit= (default 50) and ?offset=.
+func GetAccountActivity(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := parsePagination(c, 50)
+
+	activity, total, err := database.GetAccountActivity(userID, limit, offset)
+	if err != nil {
+		log.Printf("Error fetching account activity for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch account activity")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": activity,
+		"meta": gin.H{
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
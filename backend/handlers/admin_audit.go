@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// AdminAuditMiddleware records every admin mutation (any method but GET,
+// HEAD and OPTIONS) in the admin audit log once the request has been
+// handled, including requests later middleware rejected. Recording is
+// best-effort: a failure is logged and the response is unaffected.
+// Must be used AFTER auth.AdminMiddleware.
+func AdminAuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+
+		entry := &models.AdminAuditEntry{
+			Action:     c.Request.Method + " " + c.FullPath(),
+			Target:     c.Request.URL.Path,
+			StatusCode: c.Writer.Status(),
+		}
+		if userID, ok := auth.UserID(c); ok {
+			entry.ActorUserID = &userID
+		}
+		if email := auth.UserEmail(c); email != "" {
+			entry.ActorEmail = &email
+		}
+		if ip := c.ClientIP(); ip != "" {
+			entry.IPAddress = &ip
+		}
+
+		if err := database.RecordAdminAudit(entry); err != nil {
+			log.Printf("Failed to record admin audit entry %s %s: %v", entry.Action, entry.Target, err)
+		}
+	}
+}
+
+// GetAdminAuditLog handles GET /api/v1/admin/audit-log
+// Returns admin mutations newest first. Supports ?actor= (user ID),
+// ?limit= (default 50) and ?offset=.
+func GetAdminAuditLog(c *gin.Context) {
+	limit, offset := parsePagination(c, 50)
+	actor := c.Query("actor")
+
+	entries, total, err := database.GetAdminAuditLog(actor, limit, offset)
+	if err != nil {
+		log.Printf("Error fetching admin audit log: %v", err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch admin audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": entries,
+		"meta": gin.H{
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
+
+// SetUserAdmin handles PUT /api/v1/admin/users/:id/admin
+// Grants or revokes a user's admin role. Admins cannot change their own
+// role, so the last admin can't lock everyone out by accident.
+func SetUserAdmin(c *gin.Context) {
+	actorID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+	userID := c.Param("id")
+
+	var req models.SetUserAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request", err.Error())
+		return
+	}
+	if userID == actorID {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "You cannot change your own admin role")
+		return
+	}
+
+	if err := database.SetUserAdmin(userID, *req.IsAdmin); err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+			return
+		}
+		log.Printf("Error setting admin role for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update admin role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"user_id": userID, "is_admin": *req.IsAdmin}})
+}
+
+// PurgeUser handles POST /api/v1/admin/users/:id/purge
+// Purges a soft-deleted user's data now instead of waiting for the
+// account-purge job, returning the rows removed per table.
+func PurgeUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	report, err := database.PurgeUserData(userID)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotPendingPurge) {
+			respondError(c, http.StatusConflict, httputil.CodeConflict, "User is not pending purge", "Only deleted accounts that have not been purged yet can be purged")
+			return
+		}
+		log.Printf("Error purging user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to purge user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/auth"
+)
+
+// setupAdminRouter mirrors the /api/v1/admin group in main.go for an admin
+// signed in as adminID/email
+func setupAdminRouter(adminID, email string) *gin.Engine {
+	r := setupMockRouterNoAuth()
+	admin := r.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set(auth.ContextUserID, adminID)
+		c.Set(auth.ContextUserEmail, email)
+		c.Set(auth.ContextIsAdmin, true)
+		c.Next()
+	})
+	admin.Use(auth.AdminMiddleware())
+	admin.Use(AdminAuditMiddleware())
+	admin.GET("/audit-log", GetAdminAuditLog)
+	sensitive := admin.Group("/users")
+	sensitive.Use(auth.RequireAdminAllowlist())
+	sensitive.PUT("/:id/admin", SetUserAdmin)
+	sensitive.POST("/:id/purge", PurgeUser)
+	return r
+}
+
+// expectAdminAudit expects an admin_audit_log insert for the given actor,
+// route, path and status
+func expectAdminAudit(mock sqlmock.Sqlmock, adminID, email, action, target string, status int) {
+	mock.ExpectQuery("INSERT INTO admin_audit_log").
+		WithArgs(adminID, email, action, target, status, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("audit-1", time.Now()))
+}
+
+func TestSetUserAdmin_Audited(t *testing.T) {
+	t.Setenv(auth.AdminAllowlistEnv, "")
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE users SET is_admin").
+		WithArgs(true, "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAdminAudit(mock, "admin-1", "admin@example.com",
+		"PUT /api/v1/admin/users/:id/admin", "/api/v1/admin/users/user-2/admin", http.StatusOK)
+
+	r := setupAdminRouter("admin-1", "admin@example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/user-2/admin", strings.NewReader(`{"is_admin": true}`)))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			UserID  string `json:"user_id"`
+			IsAdmin bool   `json:"is_admin"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "user-2", resp.Data.UserID)
+	assert.True(t, resp.Data.IsAdmin)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetUserAdmin_Errors(t *testing.T) {
+	t.Setenv(auth.AdminAllowlistEnv, "")
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupAdminRouter("admin-1", "admin@example.com")
+
+	t.Run("rejects a missing is_admin", func(t *testing.T) {
+		expectAdminAudit(mock, "admin-1", "admin@example.com",
+			"PUT /api/v1/admin/users/:id/admin", "/api/v1/admin/users/user-2/admin", http.StatusBadRequest)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/user-2/admin", strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rejects changing your own role", func(t *testing.T) {
+		expectAdminAudit(mock, "admin-1", "admin@example.com",
+			"PUT /api/v1/admin/users/:id/admin", "/api/v1/admin/users/admin-1/admin", http.StatusBadRequest)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/admin-1/admin", strings.NewReader(`{"is_admin": false}`)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mock.ExpectExec("UPDATE users SET is_admin").
+			WithArgs(false, "missing").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		expectAdminAudit(mock, "admin-1", "admin@example.com",
+			"PUT /api/v1/admin/users/:id/admin", "/api/v1/admin/users/missing/admin", http.StatusNotFound)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/missing/admin", strings.NewReader(`{"is_admin": false}`)))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSensitiveAdminActions_Allowlist(t *testing.T) {
+	t.Setenv(auth.AdminAllowlistEnv, "owner@example.com")
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	t.Run("admin not on the allowlist is rejected and audited", func(t *testing.T) {
+		// No UPDATE or purge is expected: sqlmock fails any other query
+		expectAdminAudit(mock, "admin-1", "admin@example.com",
+			"PUT /api/v1/admin/users/:id/admin", "/api/v1/admin/users/user-2/admin", http.StatusForbidden)
+		expectAdminAudit(mock, "admin-1", "admin@example.com",
+			"POST /api/v1/admin/users/:id/purge", "/api/v1/admin/users/user-2/purge", http.StatusForbidden)
+
+		r := setupAdminRouter("admin-1", "admin@example.com")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/user-2/admin", strings.NewReader(`{"is_admin": true}`)))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-2/purge", nil))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("allowlisted admin may purge", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT is_active = FALSE AND purged_at IS NULL FROM users").
+			WithArgs("user-2").
+			WillReturnRows(sqlmock.NewRows([]string{"pending"}).AddRow(false))
+		mock.ExpectRollback()
+		expectAdminAudit(mock, "owner-1", "Owner@Example.com",
+			"POST /api/v1/admin/users/:id/purge", "/api/v1/admin/users/user-2/purge", http.StatusConflict)
+
+		r := setupAdminRouter("owner-1", "Owner@Example.com")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/user-2/purge", nil))
+		assert.Equal(t, http.StatusConflict, w.Code, "past the allowlist, an active user can't be purged")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetAdminAuditLog(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM admin_audit_log WHERE actor_user_id = \\$1").
+		WithArgs("admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT .+ FROM admin_audit_log").
+		WithArgs("admin-1", 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_user_id", "actor_email", "action", "target", "status_code", "ip_address", "created_at"}).
+			AddRow("audit-1", "admin-1", "admin@example.com", "PUT /api/v1/admin/users/:id/admin", "/api/v1/admin/users/user-2/admin", 200, "192.0.2.1", now))
+
+	// Reading the log is not itself audited, so no insert is expected
+	r := setupAdminRouter("admin-1", "admin@example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit-log?actor=admin-1", nil))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "PUT /api/v1/admin/users/:id/admin", resp.Data[0]["action"])
+	assert.Equal(t, "/api/v1/admin/users/user-2/admin", resp.Data[0]["target"])
+	assert.Equal(t, "admin@example.com", resp.Data[0]["actor_email"])
+	assert.Equal(t, float64(1), resp.Meta["total"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDataCoverage returns, per data domain, how many active stocks have
+// recent data versus the total, plus the stalest ticker, so admins get one
+// data-health view instead of checking each admin table separately.
+//
+// Example: GET /api/v1/admin/coverage
+func GetDataCoverage(c *gin.Context) {
+	coverage, err := database.GetDataCoverage()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to compute data coverage", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": coverage,
+		"meta": gin.H{
+			"domains":   len(coverage),
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDataCoverage_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"domain", "max_age_days", "total", "fresh", "stale", "missing", "oldest_stale_ticker", "oldest_stale_at"}).
+		AddRow("prices", 5, 10, 8, 1, 1, "XYZ", nil).
+		AddRow("sentiment", 2, 10, 3, 0, 7, nil, nil)
+	mock.ExpectQuery("WITH universe AS").WillReturnRows(rows)
+
+	r := setupMockRouterNoAuth()
+	r.GET("/admin/coverage", GetDataCoverage)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/coverage", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []struct {
+			Domain            string  `json:"domain"`
+			CoveragePct       float64 `json:"coverage_pct"`
+			OldestStaleTicker *string `json:"oldest_stale_ticker"`
+		} `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "prices", resp.Data[0].Domain)
+	assert.InDelta(t, 80.0, resp.Data[0].CoveragePct, 0.001)
+	require.NotNil(t, resp.Data[0].OldestStaleTicker)
+	assert.Equal(t, "XYZ", *resp.Data[0].OldestStaleTicker)
+	assert.InDelta(t, 30.0, resp.Data[1].CoveragePct, 0.001)
+	assert.Equal(t, float64(2), resp.Meta["domains"])
+}
+
+func TestGetDataCoverage_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("WITH universe AS").WillReturnError(fmt.Errorf("db down"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/admin/coverage", GetDataCoverage)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/coverage", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Failed to compute data coverage")
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+
+	"investorcenter-api/httputil"
 )
 
 // AdminDataHandler handles admin queries for all data types
@@ -22,8 +24,7 @@ func NewAdminDataHandler(db *sqlx.DB) *AdminDataHandler {
 
 // GetStocks returns all stocks with pagination and search
 func (h *AdminDataHandler) GetStocks(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 	sortBy := c.DefaultQuery("sort", "symbol")
 	order := c.DefaultQuery("order", "asc")
@@ -71,7 +72,7 @@ func (h *AdminDataHandler) GetStocks(c *gin.Context) {
 	// Execute query
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stocks"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch stocks")
 		return
 	}
 	defer rows.Close()
@@ -119,8 +120,7 @@ func (h *AdminDataHandler) GetStocks(c *gin.Context) {
 
 // GetUsers returns all users (admin only)
 func (h *AdminDataHandler) GetUsers(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -150,7 +150,7 @@ func (h *AdminDataHandler) GetUsers(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch users")
 		return
 	}
 	defer rows.Close()
@@ -199,8 +199,7 @@ func (h *AdminDataHandler) GetUsers(c *gin.Context) {
 
 // GetNewsArticles returns all news articles with pagination
 func (h *AdminDataHandler) GetNewsArticles(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -230,7 +229,7 @@ func (h *AdminDataHandler) GetNewsArticles(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch news", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch news", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -275,8 +274,7 @@ func (h *AdminDataHandler) GetNewsArticles(c *gin.Context) {
 
 // GetFundamentals returns all fundamentals data
 func (h *AdminDataHandler) GetFundamentals(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -321,7 +319,7 @@ func (h *AdminDataHandler) GetFundamentals(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch fundamentals", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch fundamentals", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -369,12 +367,12 @@ func (h *AdminDataHandler) GetFundamentals(c *gin.Context) {
 
 // GetAlerts returns all alert rules
 func (h *AdminDataHandler) GetAlerts(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 
 	query := `
 		SELECT id, user_id, watch_list_id, symbol, alert_type,
-		       frequency, notify_email, notify_in_app, is_active, created_at
+		       frequency, notify_email, notify_in_app, is_active, created_at,
+		       snoozed_until, COALESCE(snoozed_until > NOW(), false) AS is_snoozed
 		FROM alert_rules
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -385,7 +383,7 @@ func (h *AdminDataHandler) GetAlerts(c *gin.Context) {
 
 	rows, err := h.db.Query(query, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch alerts")
 		return
 	}
 	defer rows.Close()
@@ -395,11 +393,12 @@ func (h *AdminDataHandler) GetAlerts(c *gin.Context) {
 		var id, userID sql.NullString
 		var watchListID sql.NullString
 		var symbol, alertType, frequency sql.NullString
-		var notifyEmail, notifyInApp, isActive sql.NullBool
-		var createdAt sql.NullTime
+		var notifyEmail, notifyInApp, isActive, isSnoozed sql.NullBool
+		var createdAt, snoozedUntil sql.NullTime
 
 		err := rows.Scan(&id, &userID, &watchListID, &symbol, &alertType,
-			&frequency, &notifyEmail, &notifyInApp, &isActive, &createdAt)
+			&frequency, &notifyEmail, &notifyInApp, &isActive, &createdAt,
+			&snoozedUntil, &isSnoozed)
 		if err != nil {
 			continue
 		}
@@ -415,6 +414,10 @@ func (h *AdminDataHandler) GetAlerts(c *gin.Context) {
 			"notify_in_app": notifyInApp.Bool,
 			"is_active":     isActive.Bool,
 			"created_at":    createdAt.Time,
+			"is_snoozed":    isSnoozed.Bool,
+		}
+		if isSnoozed.Bool {
+			alert["snoozed_until"] = snoozedUntil.Time
 		}
 		alerts = append(alerts, alert)
 	}
@@ -431,8 +434,7 @@ func (h *AdminDataHandler) GetAlerts(c *gin.Context) {
 
 // GetWatchLists returns all watch lists
 func (h *AdminDataHandler) GetWatchLists(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 
 	query := `
 		SELECT id, user_id, name, description, is_default,
@@ -447,7 +449,7 @@ func (h *AdminDataHandler) GetWatchLists(c *gin.Context) {
 
 	rows, err := h.db.Query(query, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watch lists"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch watch lists")
 		return
 	}
 	defer rows.Close()
@@ -490,8 +492,7 @@ func (h *AdminDataHandler) GetWatchLists(c *gin.Context) {
 
 // GetSECFinancials returns raw quarterly SEC financial data
 func (h *AdminDataHandler) GetSECFinancials(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -527,7 +528,7 @@ func (h *AdminDataHandler) GetSECFinancials(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SEC financials", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch SEC financials", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -602,8 +603,7 @@ func (h *AdminDataHandler) GetSECFinancials(c *gin.Context) {
 
 // GetTTMFinancials returns TTM financial data
 func (h *AdminDataHandler) GetTTMFinancials(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -639,7 +639,7 @@ func (h *AdminDataHandler) GetTTMFinancials(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch TTM financials", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch TTM financials", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -713,8 +713,7 @@ func (h *AdminDataHandler) GetTTMFinancials(c *gin.Context) {
 
 // GetValuationRatios returns valuation ratios data
 func (h *AdminDataHandler) GetValuationRatios(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -747,7 +746,7 @@ func (h *AdminDataHandler) GetValuationRatios(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch valuation ratios", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch valuation ratios", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -844,8 +843,7 @@ func (h *AdminDataHandler) GetDatabaseStats(c *gin.Context) {
 
 // GetAnalystRatings returns analyst ratings data
 func (h *AdminDataHandler) GetAnalystRatings(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -877,7 +875,7 @@ func (h *AdminDataHandler) GetAnalystRatings(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analyst ratings", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch analyst ratings", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -947,8 +945,7 @@ func (h *AdminDataHandler) GetAnalystRatings(c *gin.Context) {
 
 // GetInsiderTrades returns insider trading data
 func (h *AdminDataHandler) GetInsiderTrades(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -981,7 +978,7 @@ func (h *AdminDataHandler) GetInsiderTrades(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch insider trades", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch insider trades", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -1042,8 +1039,7 @@ func (h *AdminDataHandler) GetInsiderTrades(c *gin.Context) {
 
 // GetInstitutionalHoldings returns institutional holdings data (13F filings)
 func (h *AdminDataHandler) GetInstitutionalHoldings(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -1076,7 +1072,7 @@ func (h *AdminDataHandler) GetInstitutionalHoldings(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch institutional holdings", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch institutional holdings", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -1141,8 +1137,7 @@ func (h *AdminDataHandler) GetInstitutionalHoldings(c *gin.Context) {
 
 // GetTechnicalIndicators returns technical indicators data
 func (h *AdminDataHandler) GetTechnicalIndicators(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -1172,7 +1167,7 @@ func (h *AdminDataHandler) GetTechnicalIndicators(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch technical indicators", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch technical indicators", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -1217,8 +1212,7 @@ func (h *AdminDataHandler) GetTechnicalIndicators(c *gin.Context) {
 
 // GetCompanies returns companies master data
 func (h *AdminDataHandler) GetCompanies(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -1251,7 +1245,7 @@ func (h *AdminDataHandler) GetCompanies(c *gin.Context) {
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch companies", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch companies", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -1309,8 +1303,7 @@ func (h *AdminDataHandler) GetCompanies(c *gin.Context) {
 
 // GetRiskMetrics returns risk metrics data
 func (h *AdminDataHandler) GetRiskMetrics(c *gin.Context) {
-	limit := parseQueryInt(c, "limit", 50)
-	offset := parseQueryInt(c, "offset", 0)
+	limit, offset := parsePagination(c, 0)
 	search := c.Query("search")
 
 	query := `
@@ -1345,7 +1338,7 @@ func (h *AdminDataHandler) GetRiskMetrics(c *gin.Context) {
 	// Execute query
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch risk metrics"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch risk metrics")
 		return
 	}
 	defer rows.Close()
@@ -1424,8 +1417,8 @@ func parseQueryInt(c *gin.Context, key string, defaultValue int) int {
 		return defaultValue
 	}
 	// Cap maximum values
-	if key == "limit" && intVal > 200 {
-		return 200
+	if key == "limit" && intVal > maxPageLimit {
+		return maxPageLimit
 	}
 	return intVal
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // newAdminHandler creates an AdminDataHandler backed by sqlmock.
@@ -219,7 +221,9 @@ func TestAdminGetAlerts_Mock_Success(t *testing.T) {
 	rows := sqlmock.NewRows([]string{
 		"id", "user_id", "watch_list_id", "symbol", "alert_type",
 		"frequency", "notify_email", "notify_in_app", "is_active", "created_at",
-	}).AddRow("a1", "u1", "wl1", "AAPL", "price_above", "once", true, true, true, now)
+		"snoozed_until", "is_snoozed",
+	}).AddRow("a1", "u1", "wl1", "AAPL", "price_above", "once", true, true, true, now, nil, false).
+		AddRow("a2", "u1", "wl1", "MSFT", "price_below", "daily", true, true, true, now, now.Add(time.Hour), true)
 
 	mock.ExpectQuery("SELECT .+ FROM alert_rules").WillReturnRows(rows)
 
@@ -232,6 +236,16 @@ func TestAdminGetAlerts_Mock_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "AAPL")
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, false, resp.Data[0]["is_snoozed"])
+	assert.NotContains(t, resp.Data[0], "snoozed_until")
+	assert.Equal(t, true, resp.Data[1]["is_snoozed"])
+	assert.Contains(t, resp.Data[1], "snoozed_until")
 }
 
 // ---------------------------------------------------------------------------
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"investorcenter-api/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSlowQueryCounts returns how many queries over the DB_SLOW_QUERY_MS
+// threshold each caller has issued since this API instance started. The
+// matching log lines (grep "slow_query") carry durations and query shapes.
+//
+// Example: GET /api/v1/admin/slow-queries
+func GetSlowQueryCounts(c *gin.Context) {
+	counts := database.SlowQueryCounts()
+
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": counts,
+		"meta": gin.H{
+			"total":        total,
+			"threshold_ms": database.LoadSlowQueryThreshold().Milliseconds(),
+			"timestamp":    time.Now().UTC(),
+		},
+	})
+}
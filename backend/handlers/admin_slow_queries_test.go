@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSlowQueryCounts(t *testing.T) {
+	t.Setenv("DB_SLOW_QUERY_MS", "750")
+
+	r := setupMockRouterNoAuth()
+	r.GET("/admin/slow-queries", GetSlowQueryCounts)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/slow-queries", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data map[string]int64       `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotNil(t, resp.Data)
+	assert.Equal(t, 750.0, resp.Meta["threshold_ms"])
+	assert.Contains(t, resp.Meta, "total")
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"investorcenter-api/auth"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,13 +31,17 @@ func NewAlertHandler(alertService *services.AlertService) *AlertHandler {
 // @Success 200 {array} models.AlertRuleWithDetails
 // @Router /api/v1/alerts [get]
 func (h *AlertHandler) ListAlertRules(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	watchListID := c.Query("watch_list_id")
 	isActive := c.Query("is_active")
 
 	alerts, err := h.alertService.GetUserAlerts(userID, watchListID, isActive)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch alerts")
 		return
 	}
 
@@ -50,28 +57,36 @@ func (h *AlertHandler) ListAlertRules(c *gin.Context) {
 // @Success 201 {object} models.AlertRule
 // @Router /api/v1/alerts [post]
 func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	var req models.CreateAlertRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	// Market-wide alerts take their symbol from conditions
+	if req.Symbol == "" && !services.IsMarketAlertType(req.AlertType) {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "symbol is required")
 		return
 	}
 
 	// Validate watch list ownership
 	if err := h.alertService.ValidateWatchListOwnership(userID, req.WatchListID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Watch list not found"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	// Check tier limits
 	canCreate, err := h.alertService.CanCreateAlert(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check limits"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to check limits")
 		return
 	}
 	if !canCreate {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Alert limit reached. Upgrade to Premium for more alerts."})
+		respondError(c, http.StatusForbidden, httputil.CodeLimitReached, "Alert limit reached. Upgrade to Premium for more alerts.")
 		return
 	}
 
@@ -80,10 +95,19 @@ func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
 	if err != nil {
 		// Catch unique constraint violation (race-condition-safe duplicate guard)
 		if errors.Is(err, database.ErrAlertAlreadyExists) {
-			c.JSON(http.StatusConflict, gin.H{"error": "Alert already exists for this ticker in this watchlist"})
+			respondError(c, http.StatusConflict, httputil.CodeConflict, "Alert already exists for this ticker in this watchlist")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAlertConditions) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrSymbolAlertLimit) {
+			respondError(c, http.StatusForbidden, httputil.CodeLimitReached,
+				fmt.Sprintf("Alert limit reached for %s. Delete an existing alert on it first.", req.Symbol))
+			return
+		}
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -98,12 +122,16 @@ func (h *AlertHandler) CreateAlertRule(c *gin.Context) {
 // @Success 200 {object} models.AlertRule
 // @Router /api/v1/alerts/:id [get]
 func (h *AlertHandler) GetAlertRule(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	alertID := c.Param("id")
 
 	alert, err := h.alertService.GetAlertByID(alertID, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		respondOwnedLookupError(c, err, "Alert not found", "Failed to fetch alert")
 		return
 	}
 
@@ -120,18 +148,30 @@ func (h *AlertHandler) GetAlertRule(c *gin.Context) {
 // @Success 200 {object} models.AlertRule
 // @Router /api/v1/alerts/:id [put]
 func (h *AlertHandler) UpdateAlertRule(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	alertID := c.Param("id")
 
 	var req models.UpdateAlertRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	alert, err := h.alertService.UpdateAlert(alertID, userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrInvalidAlertConditions) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+			return
+		}
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Alert not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -145,17 +185,146 @@ func (h *AlertHandler) UpdateAlertRule(c *gin.Context) {
 // @Success 204
 // @Router /api/v1/alerts/:id [delete]
 func (h *AlertHandler) DeleteAlertRule(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	alertID := c.Param("id")
 
 	if err := h.alertService.DeleteAlert(alertID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert"})
+		respondOwnedLookupError(c, err, "Alert not found", "Failed to delete alert")
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// SnoozeAlertRule godoc
+// @Summary Snooze alert rule
+// @Description Mutes the rule for a duration ("90m", "4h", "7d") or until a time, without deleting it
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Param snooze body models.SnoozeAlertRequest true "Duration or until"
+// @Success 200 {object} models.AlertRule
+// @Router /api/v1/alerts/:id/snooze [post]
+func (h *AlertHandler) SnoozeAlertRule(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	alertID := c.Param("id")
+
+	var req models.SnoozeAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	alert, err := h.alertService.SnoozeAlert(alertID, userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSnooze) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+			return
+		}
+		respondOwnedLookupError(c, err, "Alert not found", "Failed to snooze alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// UnsnoozeAlertRule godoc
+// @Summary Unsnooze alert rule
+// @Description Ends the rule's snooze now. Events that happened while it was snoozed aren't notified.
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Success 200 {object} models.AlertRule
+// @Router /api/v1/alerts/:id/unsnooze [post]
+func (h *AlertHandler) UnsnoozeAlertRule(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	alertID := c.Param("id")
+
+	alert, err := h.alertService.UnsnoozeAlert(alertID, userID)
+	if err != nil {
+		respondOwnedLookupError(c, err, "Alert not found", "Failed to unsnooze alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// fetchLiveAlertQuote is swapped out in tests to avoid calling Polygon.
+var fetchLiveAlertQuote = func(symbol string) (*models.StockPrice, error) {
+	return services.NewPolygonClient().GetQuote(symbol)
+}
+
+// TestAlertRule godoc
+// @Summary Test-fire an alert rule
+// @Description Evaluates the rule against current market data the way the notification service would, without logging or notifying
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Success 200 {object} models.AlertTestResult
+// @Router /api/v1/alerts/:id/test [post]
+func (h *AlertHandler) TestAlertRule(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	alertID := c.Param("id")
+
+	alert, err := h.alertService.GetAlertByID(alertID, userID)
+	if err != nil {
+		respondOwnedLookupError(c, err, "Alert not found", "Failed to fetch alert")
+		return
+	}
+
+	quote, err := database.GetAlertQuote(alert.Symbol)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch market data")
+		return
+	}
+	if quote == nil {
+		quote = &models.SymbolQuote{}
+	}
+	live := false
+	if price, err := fetchLiveAlertQuote(alert.Symbol); err == nil && price != nil {
+		services.ApplyLiveQuote(quote, price)
+		live = quote.Price > 0
+	}
+	if quote.Price <= 0 {
+		respondError(c, http.StatusUnprocessableEntity, httputil.CodeUnprocessable, fmt.Sprintf("No market data available for %s", alert.Symbol))
+		return
+	}
+
+	eval, err := services.EvaluateAlertCondition(alert, quote)
+	if err != nil {
+		respondError(c, http.StatusUnprocessableEntity, httputil.CodeUnprocessable, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AlertTestResult{
+		AlertID:      alert.ID,
+		Symbol:       alert.Symbol,
+		AlertType:    alert.AlertType,
+		WouldTrigger: eval.WouldTrigger,
+		Compared:     eval.Compared,
+		Quote:        *quote,
+		Live:         live,
+		EvaluatedAt:  time.Now().UTC(),
+	})
+}
+
 // BulkCreateAlertRules godoc
 // @Summary Create alerts for all tickers in a watchlist
 // @Tags alerts
@@ -166,11 +335,14 @@ func (h *AlertHandler) DeleteAlertRule(c *gin.Context) {
 // @Success 200 {object} models.BulkCreateAlertResponse "All skipped"
 // @Router /api/v1/alerts/bulk [post]
 func (h *AlertHandler) BulkCreateAlertRules(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	var req models.BulkCreateAlertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -179,19 +351,85 @@ func (h *AlertHandler) BulkCreateAlertRules(c *gin.Context) {
 		// If result is non-nil, the error is a partial failure (limit reached).
 		// Return the partial counts with a 403.
 		if result != nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":   err.Error(),
+			respondError(c, http.StatusForbidden, httputil.CodeLimitReached, err.Error(), gin.H{
 				"created": result.Created,
 				"skipped": result.Skipped,
 			})
 			return
 		}
 		// Ownership or other hard failure
-		if err.Error() == "watch list not found" || err.Error() == "unauthorized" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Watch list not found"})
+		if database.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Watch list not found")
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAlertConditions) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
+		return
+	}
+
+	if result.Created == 0 {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// ExportAlertRules godoc
+// @Summary Export all alert rules as JSON
+// @Description Exports the user's alert rules in a portable format that POST /api/v1/alerts/import accepts, for keeping them under version control
+// @Tags alerts
+// @Produce json
+// @Success 200 {object} models.AlertExport
+// @Router /api/v1/alerts/export [get]
+func (h *AlertHandler) ExportAlertRules(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	export, err := h.alertService.ExportAlerts(userID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to export alerts")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="alerts.json"`)
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportAlertRules godoc
+// @Summary Import alert rules from an export
+// @Description Recreates the alert rules of an export, matching watch lists by name. Duplicates of existing rules are skipped; invalid rules and rules past the plan's limits are reported in failed.
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param export body models.AlertExport true "Alert export"
+// @Success 201 {object} models.AlertImportResponse
+// @Success 200 {object} models.AlertImportResponse "Nothing created"
+// @Router /api/v1/alerts/import [post]
+func (h *AlertHandler) ImportAlertRules(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var export models.AlertExport
+	if err := c.ShouldBindJSON(&export); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	result, err := h.alertService.ImportAlerts(userID, &export)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAlertImport) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to import alerts")
 		return
 	}
 
@@ -214,16 +452,19 @@ func (h *AlertHandler) BulkCreateAlertRules(c *gin.Context) {
 // @Success 200 {array} models.AlertLogWithRule
 // @Router /api/v1/alerts/logs [get]
 func (h *AlertHandler) ListAlertLogs(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	alertID := c.Query("alert_id")
 	symbol := c.Query("symbol")
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, offset := parsePagination(c, 50)
 
 	logs, err := h.alertService.GetAlertLogs(userID, alertID, symbol, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert logs"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch alert logs")
 		return
 	}
 
@@ -237,11 +478,15 @@ func (h *AlertHandler) ListAlertLogs(c *gin.Context) {
 // @Success 200
 // @Router /api/v1/alerts/logs/:id/read [post]
 func (h *AlertHandler) MarkAlertLogRead(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	logID := c.Param("id")
 
 	if err := h.alertService.MarkAlertLogAsRead(logID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark alert log as read"})
+		respondOwnedLookupError(c, err, "Alert log not found", "Failed to mark alert log as read")
 		return
 	}
 
@@ -255,11 +500,15 @@ func (h *AlertHandler) MarkAlertLogRead(c *gin.Context) {
 // @Success 200
 // @Router /api/v1/alerts/logs/:id/dismiss [post]
 func (h *AlertHandler) DismissAlertLog(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	logID := c.Param("id")
 
 	if err := h.alertService.MarkAlertLogAsDismissed(logID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss alert log"})
+		respondOwnedLookupError(c, err, "Alert log not found", "Failed to dismiss alert log")
 		return
 	}
 
@@ -37,13 +37,13 @@ func TestListAlertRules_Mock_Success(t *testing.T) {
 			"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
 			"alert_type", "conditions", "is_active", "frequency", "notify_email",
 			"notify_in_app", "name", "description", "last_triggered_at",
-			"trigger_count", "created_at", "updated_at",
+			"trigger_count", "created_at", "updated_at", "snoozed_until",
 			"watch_list_name", "company_name",
 		}).AddRow(
 			"alert-1", "user-1", "wl-1", nil, "AAPL",
 			"price_above", []byte(`{"threshold":150}`), true, "once", true,
 			true, "AAPL Alert", nil, nil,
-			0, now, now,
+			0, now, now, nil,
 			"My Watchlist", "Apple Inc.",
 		))
 
@@ -73,7 +73,7 @@ func TestListAlertRules_Mock_Empty(t *testing.T) {
 			"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
 			"alert_type", "conditions", "is_active", "frequency", "notify_email",
 			"notify_in_app", "name", "description", "last_triggered_at",
-			"trigger_count", "created_at", "updated_at",
+			"trigger_count", "created_at", "updated_at", "snoozed_until",
 			"watch_list_name", "company_name",
 		}))
 
@@ -118,7 +118,7 @@ func TestListAlertRules_Mock_WithFilters(t *testing.T) {
 			"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
 			"alert_type", "conditions", "is_active", "frequency", "notify_email",
 			"notify_in_app", "name", "description", "last_triggered_at",
-			"trigger_count", "created_at", "updated_at",
+			"trigger_count", "created_at", "updated_at", "snoozed_until",
 			"watch_list_name", "company_name",
 		}))
 
@@ -149,12 +149,12 @@ func TestGetAlertRule_Mock_Success(t *testing.T) {
 			"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
 			"alert_type", "conditions", "is_active", "frequency", "notify_email",
 			"notify_in_app", "name", "description", "last_triggered_at",
-			"trigger_count", "created_at", "updated_at",
+			"trigger_count", "created_at", "updated_at", "snoozed_until",
 		}).AddRow(
 			"alert-1", "user-1", "wl-1", nil, "AAPL",
 			"price_above", []byte(`{"threshold":150}`), true, "once", true,
 			true, "AAPL Alert", nil, nil,
-			0, now, now,
+			0, now, now, nil,
 		))
 
 	handler := newTestAlertHandler()
@@ -373,6 +373,26 @@ func TestListAlertLogs_Mock_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestListAlertLogs_Mock_ClampsLimit(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM alert_logs .+ LIMIT \\$2 OFFSET \\$3").
+		WithArgs("user-1", maxPageLimit, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	handler := newTestAlertHandler()
+	r := setupMockRouter("user-1")
+	r.GET("/alerts/logs", handler.ListAlertLogs)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/alerts/logs?limit=100000&offset=20", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestListAlertLogs_Mock_DBError(t *testing.T) {
 	mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -437,12 +457,12 @@ func TestUpdateAlertRule_Mock_Success(t *testing.T) {
 			"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
 			"alert_type", "conditions", "is_active", "frequency", "notify_email",
 			"notify_in_app", "name", "description", "last_triggered_at",
-			"trigger_count", "created_at", "updated_at",
+			"trigger_count", "created_at", "updated_at", "snoozed_until",
 		}).AddRow(
 			"alert-1", "user-1", "wl-1", nil, "AAPL",
 			"price_above", []byte(`{"threshold":200}`), true, "daily", true,
 			true, "Updated Alert", nil, nil,
-			0, now, now,
+			0, now, now, nil,
 		))
 
 	handler := newTestAlertHandler()
@@ -497,7 +517,7 @@ func TestCreateAlertRule_Mock_OwnershipFails(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 	var resp map[string]string
 	json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.Equal(t, "Watch list not found", resp["error"])
@@ -558,6 +578,152 @@ func TestCreateAlertRule_Mock_LimitReached(t *testing.T) {
 	assert.True(t, w.Code == http.StatusForbidden || w.Code == http.StatusInternalServerError)
 }
 
+// expectCreateAlertUpToSymbolCap expects the queries CreateAlertRule runs
+// before the per-symbol cap check: ownership, plan limit, and the watch list
+// holding AAPL. The user has no subscription (free tier, 10 alerts) and 2
+// alerts in total.
+func expectCreateAlertUpToSymbolCap(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "name", "description", "is_default", "display_order",
+			"is_public", "public_slug", "created_at", "updated_at",
+		}).AddRow("wl-1", "user-1", "Test WL", nil, false, 0, false, nil, time.Now(), time.Now()))
+	mock.ExpectQuery("FROM user_subscriptions").WillReturnError(fmt.Errorf("no subscription"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM alert_rules WHERE user_id = \\$1$").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("FROM watch_list_items").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "watch_list_id", "symbol", "notes", "tags", "target_buy_price",
+			"target_sell_price", "added_at", "display_order",
+		}).AddRow("item-1", "wl-1", "AAPL", nil, "{}", nil, nil, time.Now(), 0))
+}
+
+func postAAPLAlert(handler *AlertHandler) *httptest.ResponseRecorder {
+	r := setupMockRouter("user-1")
+	r.POST("/alerts", handler.CreateAlertRule)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"watch_list_id": "wl-1",
+		"symbol":        "AAPL",
+		"alert_type":    "price_above",
+		"conditions":    map[string]interface{}{"threshold": 150},
+		"name":          "Test Alert",
+		"frequency":     "once",
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/alerts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateAlertRule_Mock_SymbolLimitReached(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectCreateAlertUpToSymbolCap(mock)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM alert_rules WHERE user_id = \\$1 AND symbol = \\$2").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	handler := NewAlertHandler(&services.AlertService{MaxAlertRulesPerSymbol: 2})
+	w := postAAPLAlert(handler)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Contains(t, resp["error"], "Alert limit reached for AAPL")
+	assert.NoError(t, mock.ExpectationsWereMet(), "no alert is inserted past the cap")
+}
+
+func TestCreateAlertRule_Mock_BelowSymbolLimit(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectCreateAlertUpToSymbolCap(mock)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM alert_rules WHERE user_id = \\$1 AND symbol = \\$2").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO alert_rules").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "trigger_count"}).
+			AddRow("alert-1", time.Now(), time.Now(), 0))
+
+	handler := NewAlertHandler(&services.AlertService{MaxAlertRulesPerSymbol: 2})
+	w := postAAPLAlert(handler)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAlertRule_Mock_IndexMove(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "name", "description", "is_default", "display_order",
+			"is_public", "public_slug", "created_at", "updated_at",
+		}).AddRow("wl-1", "user-1", "Test WL", nil, false, 0, false, nil, time.Now(), time.Now()))
+	mock.ExpectQuery("FROM user_subscriptions").WillReturnError(fmt.Errorf("no subscription"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM alert_rules WHERE user_id = \\$1$").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	// No watch list items lookup: the index's ETF needn't be in the watch list
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM alert_rules WHERE user_id = \\$1 AND symbol = \\$2").
+		WithArgs("user-1", "SPY").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("INSERT INTO alert_rules").
+		WithArgs("user-1", "wl-1", nil, "SPY", "index_move", sqlmock.AnyArg(), true, "daily", false, true, "S&P down 3%", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "trigger_count"}).
+			AddRow("alert-1", time.Now(), time.Now(), 0))
+
+	handler := NewAlertHandler(&services.AlertService{})
+	r := setupMockRouter("user-1")
+	r.POST("/alerts", handler.CreateAlertRule)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"watch_list_id": "wl-1",
+		"alert_type":    "index_move",
+		"conditions":    map[string]interface{}{"index": "SPX", "percent_change": 3, "direction": "down"},
+		"name":          "S&P down 3%",
+		"frequency":     "daily",
+		"notify_in_app": true,
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/alerts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var alert map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &alert)
+	assert.Equal(t, "SPY", alert["symbol"], "the rule tracks the index's ETF")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAlertRule_Mock_MissingSymbol(t *testing.T) {
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	handler := newTestAlertHandler()
+	r := setupMockRouter("user-1")
+	r.POST("/alerts", handler.CreateAlertRule)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"watch_list_id": "wl-1",
+		"alert_type":    "price_above",
+		"conditions":    map[string]interface{}{"threshold": 150},
+		"name":          "Test Alert",
+		"frequency":     "once",
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/alerts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "per-ticker alerts still need a symbol")
+}
+
 func TestUpdateAlertRule_Mock_InvalidJSON(t *testing.T) {
 	_, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -641,6 +807,46 @@ func TestBulkCreateAlertRules_Mock_OwnershipFails(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAlertRule_Mock_InvalidConditions(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	// ValidateWatchListOwnership succeeds
+	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "name", "description", "is_default", "display_order",
+			"is_public", "public_slug", "created_at", "updated_at",
+		}).AddRow("wl-1", "user-1", "Test WL", nil, false, 0, false, nil, time.Now(), time.Now()))
+	// CanCreateAlert: no subscription (free tier), no existing alerts
+	mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("no subscription"))
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	handler := newTestAlertHandler()
+	r := setupMockRouter("user-1")
+	r.POST("/alerts", handler.CreateAlertRule)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"watch_list_id": "wl-1",
+		"symbol":        "AAPL",
+		"alert_type":    "pe_crosses",
+		"conditions":    map[string]interface{}{"threshold": 30, "direction": "sideways"},
+		"name":          "AAPL P/E",
+		"frequency":     "daily",
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/alerts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "invalid_request", resp["code"])
+	assert.Contains(t, resp["error"], "direction must be above, below or either")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
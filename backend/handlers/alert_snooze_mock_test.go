@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+func serveSnooze(path, body string) *httptest.ResponseRecorder {
+	handler := newTestAlertHandler()
+	r := setupMockRouter("user-1")
+	r.POST("/alerts/:id/snooze", handler.SnoozeAlertRule)
+	r.POST("/alerts/:id/unsnooze", handler.UnsnoozeAlertRule)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSnoozeAlertRule_Mock_Duration(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE alert_rules\\s+SET snoozed_until = \\$3").
+		WithArgs("alert-1", "user-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAlertRule(mock, "price_above", `{"threshold":150}`)
+
+	w := serveSnooze("/alerts/alert-1/snooze", `{"duration":"4h"}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var alert models.AlertRule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &alert))
+	assert.Equal(t, "alert-1", alert.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSnoozeAlertRule_Mock_Invalid(t *testing.T) {
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	for _, body := range []string{
+		`{}`,
+		`{"duration":"soon"}`,
+		`{"duration":"1h","until":"2030-01-01T00:00:00Z"}`,
+		`{"until":"2020-01-01T00:00:00Z"}`,
+	} {
+		w := serveSnooze("/alerts/alert-1/snooze", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestSnoozeAlertRule_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	until := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	mock.ExpectExec("UPDATE alert_rules").
+		WithArgs("alert-other", "user-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := serveSnooze("/alerts/alert-other/snooze", `{"until":"`+until+`"}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnsnoozeAlertRule_Mock(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	// Ends the snooze now rather than clearing it
+	mock.ExpectExec("UPDATE alert_rules\\s+SET snoozed_until = CASE").
+		WithArgs("alert-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAlertRule(mock, "price_above", `{"threshold":150}`)
+
+	w := serveSnooze("/alerts/alert-1/unsnooze", "")
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnsnoozeAlertRule_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE alert_rules").
+		WithArgs("alert-other", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	w := serveSnooze("/alerts/alert-other/unsnooze", "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
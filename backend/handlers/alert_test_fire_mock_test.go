@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+func expectAlertRule(mock sqlmock.Sqlmock, alertType, conditions string) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM alert_rules WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs("alert-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
+			"alert_type", "conditions", "is_active", "frequency", "notify_email",
+			"notify_in_app", "name", "description", "last_triggered_at",
+			"trigger_count", "created_at", "updated_at", "snoozed_until",
+		}).AddRow(
+			"alert-1", "user-1", "wl-1", nil, "AAPL",
+			alertType, []byte(conditions), true, "daily", true,
+			true, "AAPL alert", nil, nil,
+			0, now, now, nil,
+		))
+}
+
+func expectAlertQuote(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM stock_prices").
+		WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"close", "volume", "prev_close", "avg_30d", "avg_90d"}).
+			AddRow(200.0, int64(3_000_000), 190.0, 1_000_000.0, 1_200_000.0))
+	mock.ExpectQuery("FROM valuation_ratios").
+		WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_price", "ttm_pe_ratio"}).AddRow(180.0, 27.0))
+}
+
+func withLiveAlertQuote(t *testing.T, price *models.StockPrice, err error) {
+	orig := fetchLiveAlertQuote
+	fetchLiveAlertQuote = func(string) (*models.StockPrice, error) { return price, err }
+	t.Cleanup(func() { fetchLiveAlertQuote = orig })
+}
+
+func serveTestAlert(t *testing.T) *httptest.ResponseRecorder {
+	handler := newTestAlertHandler()
+	r := setupMockRouter("user-1")
+	r.POST("/alerts/:id/test", handler.TestAlertRule)
+	r.POST("/alerts/logs/:id/read", handler.MarkAlertLogRead)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/alerts/alert-1/test", nil))
+	return w
+}
+
+func TestTestAlertRule_Mock_DailyData(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	expectAlertRule(mock, "pe_crosses", `{"threshold":29,"direction":"above"}`)
+	expectAlertQuote(mock)
+	withLiveAlertQuote(t, nil, errors.New("polygon unavailable"))
+
+	w := serveTestAlert(t)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp models.AlertTestResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "alert-1", resp.AlertID)
+	assert.False(t, resp.Live)
+	assert.Equal(t, 200.0, resp.Quote.Price)
+	// P/E 27 at 180 is 30 at the 200 close, and 28.5 at the 190 prior close.
+	assert.InDelta(t, 30.0, resp.Compared["pe_ratio"], 1e-9)
+	assert.InDelta(t, 28.5, resp.Compared["prior_pe_ratio"], 1e-9)
+	assert.True(t, resp.WouldTrigger)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTestAlertRule_Mock_LivePrice(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	expectAlertRule(mock, "price_above", `{"threshold":205}`)
+	expectAlertQuote(mock)
+	withLiveAlertQuote(t, &models.StockPrice{
+		Price:         decimal.NewFromFloat(204.5),
+		Volume:        1_500_000,
+		ChangePercent: decimal.NewFromFloat(2.25),
+	}, nil)
+
+	w := serveTestAlert(t)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp models.AlertTestResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Live)
+	assert.False(t, resp.WouldTrigger)
+	assert.Equal(t, map[string]float64{"price": 204.5, "threshold": 205}, resp.Compared)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTestAlertRule_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	mock.ExpectQuery("SELECT .+ FROM alert_rules").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := serveTestAlert(t)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTestAlertRule_Mock_NotTestable(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	expectAlertRule(mock, "news", `{"keywords":["merger"]}`)
+	expectAlertQuote(mock)
+	withLiveAlertQuote(t, nil, errors.New("polygon unavailable"))
+
+	w := serveTestAlert(t)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "unprocessable", resp["code"])
+}
+
+func TestTestAlertRule_Mock_NoMarketData(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	expectAlertRule(mock, "price_above", `{"threshold":205}`)
+	mock.ExpectQuery("FROM stock_prices").
+		WillReturnRows(sqlmock.NewRows([]string{"close", "volume", "prev_close", "avg_30d", "avg_90d"}).
+			AddRow(nil, nil, nil, nil, nil))
+	withLiveAlertQuote(t, nil, errors.New("polygon unavailable"))
+
+	w := serveTestAlert(t)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
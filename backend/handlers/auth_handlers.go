@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/auth"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 )
@@ -21,28 +23,31 @@ var emailService = services.NewEmailService()
 func Signup(c *gin.Context) {
 	var req models.SignupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	if !checkPasswordPolicy(c, "password", req.Password) {
 		return
 	}
 
 	// Check if user already exists
 	existingUser, _ := database.GetUserByEmail(req.Email)
 	if existingUser != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		respondError(c, http.StatusConflict, httputil.CodeConflict, "Email already registered")
 		return
 	}
 
 	// Hash password
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to hash password")
 		return
 	}
 
 	// Generate email verification token
 	verificationToken, err := generateRandomToken(32)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate verification token"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate verification token")
 		return
 	}
 
@@ -63,7 +68,7 @@ func Signup(c *gin.Context) {
 	}
 
 	if err := database.CreateUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create user")
 		return
 	}
 
@@ -77,13 +82,13 @@ func Signup(c *gin.Context) {
 	// Generate tokens
 	accessToken, err := auth.GenerateAccessToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
 		return
 	}
 
 	refreshToken, err := auth.GenerateRefreshToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate refresh token")
 		return
 	}
 
@@ -97,9 +102,11 @@ func Signup(c *gin.Context) {
 		IPAddress:        ptrString(c.ClientIP()),
 	}
 	if err := database.CreateSession(session); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create session")
 		return
 	}
+	recordAccountActivity(c, user.ID, models.AccountEventSignup, map[string]interface{}{"session_id": session.ID})
+	seedOnboardingWatchList(user.ID, req.SkipOnboarding)
 
 	c.JSON(http.StatusCreated, models.AuthResponse{
 		AccessToken:  accessToken,
@@ -113,23 +120,48 @@ func Signup(c *gin.Context) {
 func Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Get user by email
 	user, err := database.GetUserByEmail(req.Email)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid email or password")
 		return
 	}
 
 	// Check password
 	if user.PasswordHash == nil || !auth.CheckPasswordHash(req.Password, *user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid email or password")
 		return
 	}
 
+	// With 2FA enabled, the password alone isn't enough
+	tf, err := database.GetUserTwoFactor(user.ID)
+	if err != nil {
+		log.Printf("Failed to check two-factor settings for user %s: %v", user.ID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to verify login")
+		return
+	}
+	var secondFactor string
+	if tf != nil && tf.Enabled {
+		if req.TOTPCode == "" {
+			respondError(c, http.StatusUnauthorized, httputil.CodeTwoFactorRequired, "Two-factor code required")
+			return
+		}
+		secondFactor, err = verifySecondFactor(tf, req.TOTPCode)
+		if err != nil {
+			log.Printf("Failed to verify two-factor code for user %s: %v", user.ID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to verify login")
+			return
+		}
+		if secondFactor == "" {
+			respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid two-factor code")
+			return
+		}
+	}
+
 	// Update last login (best-effort, non-critical)
 	if err := database.UpdateLastLogin(user.ID); err != nil {
 		log.Printf("Failed to update last login for user %s: %v", user.ID, err)
@@ -138,13 +170,13 @@ func Login(c *gin.Context) {
 	// Generate tokens
 	accessToken, err := auth.GenerateAccessToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
 		return
 	}
 
 	refreshToken, err := auth.GenerateRefreshToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate refresh token")
 		return
 	}
 
@@ -158,9 +190,15 @@ func Login(c *gin.Context) {
 		IPAddress:        ptrString(c.ClientIP()),
 	}
 	if err := database.CreateSession(session); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create session")
 		return
 	}
+	metadata := map[string]interface{}{"session_id": session.ID}
+	if secondFactor != "" {
+		metadata["two_factor"] = secondFactor
+	}
+	recordAccountActivity(c, user.ID, models.AccountEventLogin, metadata)
+	seedOnboardingWatchList(user.ID, false)
 
 	c.JSON(http.StatusOK, models.AuthResponse{
 		AccessToken:  accessToken,
@@ -170,11 +208,14 @@ func Login(c *gin.Context) {
 	})
 }
 
-// RefreshToken generates a new access token using refresh token
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token (rotation); the presented refresh token stops working. A
+// refresh token presented again after it was exchanged was most likely
+// copied by someone else, so all of the user's sessions are revoked.
 func RefreshToken(c *gin.Context) {
 	var req models.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -184,40 +225,141 @@ func RefreshToken(c *gin.Context) {
 	// Get session
 	session, err := database.GetSessionByRefreshTokenHash(tokenHash)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	if session.RotatedAt != nil {
+		handleRotatedRefreshToken(c, req.RefreshToken, session)
 		return
 	}
 
 	// Get user
 	user, err := database.GetUserByID(session.UserID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "User not found")
 		return
 	}
 
 	// Generate new access token
 	accessToken, err := auth.GenerateAccessToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
+		return
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken(user)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate refresh token")
+		return
+	}
+
+	sealedSuccessor, err := auth.SealRefreshSuccessor(req.RefreshToken, refreshToken)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to refresh session")
+		return
+	}
+
+	next := &models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(168 * time.Hour), // 7 days
+		UserAgent:        ptrString(c.Request.UserAgent()),
+		IPAddress:        ptrString(c.ClientIP()),
+	}
+	if err := database.RotateSession(session, next, sealedSuccessor); err != nil {
+		if errors.Is(err, database.ErrRefreshTokenReused) {
+			// Another request exchanged this token first; answer with the
+			// successor it was issued
+			if rotated, err := database.GetSessionByRefreshTokenHash(tokenHash); err == nil {
+				session = rotated
+			}
+			handleRotatedRefreshToken(c, req.RefreshToken, session)
+			return
+		}
+		log.Printf("Failed to rotate session %s: %v", session.ID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to refresh session")
 		return
 	}
 
-	// Update session last used (best-effort, non-critical)
-	if err := database.UpdateSessionLastUsed(session.ID); err != nil {
-		log.Printf("Failed to update session last used for session %s: %v", session.ID, err)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    auth.GetAccessTokenExpirySeconds(),
+	})
+}
+
+// refreshTokenReuseGrace is how long after a refresh token is exchanged
+// exchanging it again returns the successor instead of counting as reuse, so
+// tabs or retried requests racing to refresh don't sign the user out.
+// REFRESH_TOKEN_REUSE_GRACE_SECONDS overrides the default of 30; 0 disables
+// the grace so every second exchange counts as reuse.
+var refreshTokenReuseGrace = time.Duration(loadEnvInt("REFRESH_TOKEN_REUSE_GRACE_SECONDS", 30, 0)) * time.Second
+
+// handleRotatedRefreshToken answers a refresh token that was already
+// exchanged. Within refreshTokenReuseGrace of the exchange, and while the
+// successor is unused, the client gets that successor with a new access
+// token; otherwise the token was copied and every session is revoked.
+func handleRotatedRefreshToken(c *gin.Context, token string, session *models.Session) {
+	successor, user, ok := graceSuccessor(token, session)
+	if !ok {
+		revokeSessionsOnTokenReuse(c, session)
+		return
 	}
 
+	accessToken, err := auth.GenerateAccessToken(user)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate access token")
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"access_token": accessToken,
-		"expires_in":   auth.GetAccessTokenExpirySeconds(),
+		"access_token":  accessToken,
+		"refresh_token": successor,
+		"expires_in":    auth.GetAccessTokenExpirySeconds(),
 	})
 }
 
-// Logout invalidates the refresh token
+// graceSuccessor returns the refresh token session was rotated into, and
+// the session's user, if the rotation was within refreshTokenReuseGrace and
+// the successor hasn't been exchanged itself
+func graceSuccessor(token string, session *models.Session) (string, *models.User, bool) {
+	if refreshTokenReuseGrace <= 0 || session.RotatedAt == nil || session.SuccessorToken == nil ||
+		time.Since(*session.RotatedAt) > refreshTokenReuseGrace {
+		return "", nil, false
+	}
+	successor, err := auth.OpenRefreshSuccessor(token, *session.SuccessorToken)
+	if err != nil {
+		return "", nil, false
+	}
+	next, err := database.GetSessionByRefreshTokenHash(hashToken(successor))
+	if err != nil || next.RotatedAt != nil {
+		return "", nil, false
+	}
+	user, err := database.GetUserByID(session.UserID)
+	if err != nil {
+		return "", nil, false
+	}
+	return successor, user, true
+}
+
+// revokeSessionsOnTokenReuse handles a refresh token presented after it was
+// already exchanged: it signs the user out everywhere and records the event
+func revokeSessionsOnTokenReuse(c *gin.Context, session *models.Session) {
+	log.Printf("Refresh token reuse for user %s (session family %s); revoking all sessions", session.UserID, session.FamilyID)
+	if err := database.DeleteUserSessions(session.UserID); err != nil {
+		log.Printf("Failed to revoke sessions for user %s after refresh token reuse: %v", session.UserID, err)
+	}
+	recordAccountActivity(c, session.UserID, models.AccountEventRefreshTokenReused, map[string]interface{}{
+		"session_family": session.FamilyID,
+	})
+	respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Refresh token was already used; please sign in again")
+}
+
+// Logout invalidates the refresh token along with the rest of its session
+// family
 func Logout(c *gin.Context) {
 	var req models.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -225,7 +367,7 @@ func Logout(c *gin.Context) {
 
 	session, err := database.GetSessionByRefreshTokenHash(tokenHash)
 	if err == nil {
-		if delErr := database.DeleteSession(session.ID); delErr != nil {
+		if delErr := database.DeleteSessionFamily(session.FamilyID); delErr != nil {
 			log.Printf("Failed to delete session %s during logout: %v", session.ID, delErr)
 		}
 	}
@@ -237,13 +379,13 @@ func Logout(c *gin.Context) {
 func VerifyEmail(c *gin.Context) {
 	token := c.Query("token")
 	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Verification token required")
 		return
 	}
 
 	err := database.VerifyEmail(token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -254,7 +396,7 @@ func VerifyEmail(c *gin.Context) {
 func ForgotPassword(c *gin.Context) {
 	var req models.ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -290,28 +432,31 @@ func ForgotPassword(c *gin.Context) {
 func ResetPassword(c *gin.Context) {
 	var req models.ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	if !checkPasswordPolicy(c, "new_password", req.NewPassword) {
 		return
 	}
 
 	// Get user by reset token
 	user, err := database.GetUserByPasswordResetToken(req.Token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid or expired reset token")
 		return
 	}
 
 	// Hash new password
 	passwordHash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to hash password")
 		return
 	}
 
 	// Update password
 	err = database.UpdateUserPassword(user.ID, passwordHash)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update password")
 		return
 	}
 
@@ -319,6 +464,7 @@ func ResetPassword(c *gin.Context) {
 	if err := database.DeleteUserSessions(user.ID); err != nil {
 		log.Printf("Failed to delete sessions for user %s after password reset: %v", user.ID, err)
 	}
+	recordAccountActivity(c, user.ID, models.AccountEventPasswordReset, nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
 }
@@ -345,3 +491,15 @@ func ptrString(s string) *string {
 func ptrTime(t time.Time) *time.Time {
 	return &t
 }
+
+// checkPasswordPolicy responds 400 with one field-level error per failed
+// rule when password doesn't meet the password policy, and reports whether
+// it passed
+func checkPasswordPolicy(c *gin.Context, field, password string) bool {
+	violations := auth.ValidatePassword(field, password)
+	if len(violations) == 0 {
+		return true
+	}
+	respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Password does not meet the strength requirements", violations)
+	return false
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"investorcenter-api/auth"
+	"investorcenter-api/models"
 )
 
 // ---------------------------------------------------------------------------
@@ -112,6 +113,11 @@ func TestLogin_Success(t *testing.T) {
 			false, true, false, false, nil,
 		))
 
+	// GetUserTwoFactor — not enrolled
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+
 	// UpdateLastLogin
 	mock.ExpectExec("UPDATE users SET last_login_at").
 		WillReturnResult(sqlmock.NewResult(0, 1))
@@ -201,6 +207,11 @@ func TestLogin_SessionCreationFailure(t *testing.T) {
 			false, true, false, false, nil,
 		))
 
+	// GetUserTwoFactor — not enrolled
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+
 	// UpdateLastLogin
 	mock.ExpectExec("UPDATE users SET last_login_at").
 		WillReturnResult(sqlmock.NewResult(0, 1))
@@ -389,6 +400,54 @@ func TestSignup_DefaultTimezone(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSignup_SkipOnboarding(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE email = \\$1").
+		WithArgs("skip@example.com").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("INSERT INTO users").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).
+			AddRow("user-skip", now, now))
+	mock.ExpectQuery("INSERT INTO sessions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).
+			AddRow("session-skip", now, now))
+
+	// Onboarding is marked done without touching the watch list
+	mock.ExpectQuery("SELECT watchlist_seeded_at IS NOT NULL FROM users").
+		WithArgs("user-skip").
+		WillReturnRows(sqlmock.NewRows([]string{"seeded"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT watchlist_seeded_at IS NOT NULL FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-skip").
+		WillReturnRows(sqlmock.NewRows([]string{"seeded"}).AddRow(false))
+	mock.ExpectExec("UPDATE users SET watchlist_seeded_at").
+		WithArgs("user-skip").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"email":           "skip@example.com",
+		"password":        "securepass123",
+		"full_name":       "Skip User",
+		"skip_onboarding": true,
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	Signup(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // ---------------------------------------------------------------------------
 // RefreshToken — DB-backed tests via sqlmock
 // ---------------------------------------------------------------------------
@@ -444,9 +503,11 @@ func TestRefreshToken_UserNotFound(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{
 			"id", "user_id", "refresh_token_hash", "expires_at",
 			"created_at", "last_used_at", "user_agent", "ip_address",
+			"family_id", "rotated_at", "successor_token",
 		}).AddRow(
 			"session-1", "user-deleted", "hash", now.Add(24*time.Hour),
 			now, now, nil, nil,
+			"family-1", nil, nil,
 		))
 
 	// GetUserByID returns no rows
@@ -485,9 +546,11 @@ func TestRefreshToken_Success(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{
 			"id", "user_id", "refresh_token_hash", "expires_at",
 			"created_at", "last_used_at", "user_agent", "ip_address",
+			"family_id", "rotated_at", "successor_token",
 		}).AddRow(
 			"session-1", "user-1", "hash", now.Add(24*time.Hour),
 			now, now, nil, nil,
+			"family-1", nil, nil,
 		))
 
 	// GetUserByID
@@ -503,9 +566,18 @@ func TestRefreshToken_Success(t *testing.T) {
 			false, true, false, false, nil,
 		))
 
-	// UpdateSessionLastUsed
-	mock.ExpectExec("UPDATE sessions SET last_used_at").
+	// RotateSession: mark the presented session rotated, storing the sealed
+	// successor, and insert the successor
+	newTokenHash := &capturedActivity{}
+	sealedSuccessor := &capturedActivity{}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE sessions SET rotated_at = \\$1, last_used_at = \\$1, successor_token = \\$2 WHERE id = \\$3 AND rotated_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), sealedSuccessor, "session-1").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO sessions .+ family_id").
+		WithArgs("user-1", newTokenHash, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "family-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).AddRow("session-2", now, now))
+	mock.ExpectCommit()
 
 	body, _ := json.Marshal(map[string]string{
 		"refresh_token": "some-refresh-token",
@@ -523,6 +595,197 @@ func TestRefreshToken_Success(t *testing.T) {
 	json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NotEmpty(t, resp["access_token"])
 	assert.NotNil(t, resp["expires_in"])
+	newToken, _ := resp["refresh_token"].(string)
+	require.NotEmpty(t, newToken, "a new refresh token is issued on every refresh")
+	assert.NotEqual(t, "some-refresh-token", newToken)
+	assert.Equal(t, hashToken(newToken), newTokenHash.value, "only the new token's hash is stored")
+	successor, err := auth.OpenRefreshSuccessor("some-refresh-token", sealedSuccessor.value.(string))
+	require.NoError(t, err)
+	assert.Equal(t, newToken, successor, "the successor is sealed under the presented token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// refreshWithToken posts token to RefreshToken
+func refreshWithToken(token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"refresh_token": token})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	RefreshToken(c)
+	return w
+}
+
+func TestRefreshToken_ReusedTokenRevokesAllSessions(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+
+	now := time.Now()
+	rotatedAt := now.Add(-time.Hour)
+
+	// The token was already exchanged an hour ago
+	mock.ExpectQuery("SELECT .+ FROM sessions WHERE refresh_token_hash = \\$1").
+		WithArgs(hashToken("stolen-refresh-token"), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "refresh_token_hash", "expires_at",
+			"created_at", "last_used_at", "user_agent", "ip_address",
+			"family_id", "rotated_at", "successor_token",
+		}).AddRow(
+			"session-1", "user-1", "hash", now.Add(24*time.Hour),
+			now, now, nil, nil,
+			"family-1", rotatedAt, nil,
+		))
+	mock.ExpectExec("DELETE FROM sessions WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	_, _, metadata := expectActivityInsert(mock, "user-1", models.AccountEventRefreshTokenReused, now)
+
+	w := refreshWithToken("stolen-refresh-token")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "already used")
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.JSONEq(t, `{"session_family":"family-1"}`, string(metadata.value.([]byte)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectSessionLookup expects GetSessionByRefreshTokenHash for token,
+// returning a session in family-1 of user-1
+func expectSessionLookup(mock sqlmock.Sqlmock, id, token string, rotatedAt *time.Time, sealedSuccessor *string) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM sessions WHERE refresh_token_hash = \\$1").
+		WithArgs(hashToken(token), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "refresh_token_hash", "expires_at",
+			"created_at", "last_used_at", "user_agent", "ip_address",
+			"family_id", "rotated_at", "successor_token",
+		}).AddRow(
+			id, "user-1", hashToken(token), now.Add(24*time.Hour),
+			now, now, nil, nil,
+			"family-1", rotatedAt, sealedSuccessor,
+		))
+}
+
+// expectUserLookup expects GetUserByID for user-1
+func expectUserLookup(mock sqlmock.Sqlmock) {
+	now := time.Now()
+	hash := "some-hash"
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "password_hash", "full_name", "timezone",
+			"created_at", "updated_at", "last_login_at", "email_verified",
+			"is_premium", "is_active", "is_admin", "is_worker", "last_activity_at",
+		}).AddRow(
+			"user-1", "test@example.com", &hash, "Test User", "UTC",
+			now, now, nil, true,
+			false, true, false, false, nil,
+		))
+}
+
+func TestRefreshToken_RepeatWithinGraceGetsSuccessor(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+
+	rotatedAt := time.Now().Add(-5 * time.Second)
+	sealed, err := auth.SealRefreshSuccessor("old-refresh-token", "successor-token")
+	require.NoError(t, err)
+
+	// Another tab exchanged the token a few seconds ago
+	expectSessionLookup(mock, "session-1", "old-refresh-token", &rotatedAt, &sealed)
+	expectSessionLookup(mock, "session-2", "successor-token", nil, nil)
+	expectUserLookup(mock)
+
+	w := refreshWithToken("old-refresh-token")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp["access_token"])
+	assert.Equal(t, "successor-token", resp["refresh_token"], "the already-issued successor is returned")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshToken_RepeatWithinGraceAfterSuccessorUsedRevokes(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+
+	now := time.Now()
+	rotatedAt := now.Add(-5 * time.Second)
+	sealed, err := auth.SealRefreshSuccessor("old-refresh-token", "successor-token")
+	require.NoError(t, err)
+
+	expectSessionLookup(mock, "session-1", "old-refresh-token", &rotatedAt, &sealed)
+	// The successor has been exchanged too
+	expectSessionLookup(mock, "session-2", "successor-token", &now, nil)
+	mock.ExpectExec("DELETE FROM sessions WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	expectActivityInsert(mock, "user-1", models.AccountEventRefreshTokenReused, now)
+
+	w := refreshWithToken("old-refresh-token")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshToken_RepeatWithGraceDisabledRevokes(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+	prev := refreshTokenReuseGrace
+	refreshTokenReuseGrace = 0
+	defer func() { refreshTokenReuseGrace = prev }()
+
+	now := time.Now()
+	rotatedAt := now.Add(-time.Second)
+	sealed, err := auth.SealRefreshSuccessor("old-refresh-token", "successor-token")
+	require.NoError(t, err)
+
+	// REFRESH_TOKEN_REUSE_GRACE_SECONDS=0: even an immediate repeat is reuse
+	expectSessionLookup(mock, "session-1", "old-refresh-token", &rotatedAt, &sealed)
+	mock.ExpectExec("DELETE FROM sessions WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	expectActivityInsert(mock, "user-1", models.AccountEventRefreshTokenReused, now)
+
+	w := refreshWithToken("old-refresh-token")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotContains(t, w.Body.String(), "access_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshToken_ConcurrentRefreshGetsSuccessor(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+
+	now := time.Now()
+	sealed, err := auth.SealRefreshSuccessor("raced-refresh-token", "winner-token")
+	require.NoError(t, err)
+
+	expectSessionLookup(mock, "session-1", "raced-refresh-token", nil, nil)
+	expectUserLookup(mock)
+	// Another request rotated the session between the lookup and the update
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE sessions SET rotated_at").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "session-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	// The winner's successor is handed back instead of revoking
+	expectSessionLookup(mock, "session-1", "raced-refresh-token", &now, &sealed)
+	expectSessionLookup(mock, "session-2", "winner-token", nil, nil)
+	expectUserLookup(mock)
+
+	w := refreshWithToken("raced-refresh-token")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"refresh_token":"winner-token"`)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -541,14 +804,17 @@ func TestLogout_ValidToken(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{
 			"id", "user_id", "refresh_token_hash", "expires_at",
 			"created_at", "last_used_at", "user_agent", "ip_address",
+			"family_id", "rotated_at", "successor_token",
 		}).AddRow(
 			"session-1", "user-1", "hash", now.Add(24*time.Hour),
 			now, now, nil, nil,
+			"family-1", nil, nil,
 		))
 
-	// DeleteSession
-	mock.ExpectExec("DELETE FROM sessions WHERE id = \\$1").
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	// DeleteSessionFamily
+	mock.ExpectExec("DELETE FROM sessions WHERE family_id = \\$1").
+		WithArgs("family-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
 
 	body, _ := json.Marshal(map[string]string{
 		"refresh_token": "some-token",
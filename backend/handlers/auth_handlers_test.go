@@ -10,6 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/auth"
 )
 
 func init() {
@@ -181,6 +183,43 @@ func TestSignup_PasswordTooShort(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestSignup_PasswordPolicy(t *testing.T) {
+	signup := func(password string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(map[string]string{
+			"email":     "test@example.com",
+			"password":  password,
+			"full_name": "John Doe",
+		})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/signup", bytes.NewBuffer(jsonBody))
+		c.Request.Header.Set("Content-Type", "application/json")
+		Signup(c)
+		return w
+	}
+
+	t.Run("weak password lists failed rules", func(t *testing.T) {
+		w := signup("allletterspassphrase")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp struct {
+			Code    string                   `json:"code"`
+			Details []auth.PasswordViolation `json:"details"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "invalid_request", resp.Code)
+		require.Len(t, resp.Details, 1)
+		assert.Equal(t, "password", resp.Details[0].Field)
+		assert.Equal(t, auth.PasswordRuleCharClasses, resp.Details[0].Rule)
+	})
+
+	t.Run("common password", func(t *testing.T) {
+		w := signup("Password123")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), auth.PasswordRuleCommon)
+	})
+}
+
 func TestSignup_MissingFullName(t *testing.T) {
 	body := map[string]string{
 		"email":    "test@example.com",
@@ -366,6 +405,24 @@ func TestResetPassword_PasswordTooShort(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestResetPassword_WeakPassword(t *testing.T) {
+	jsonBody, _ := json.Marshal(map[string]string{
+		"token":        "valid-token",
+		"new_password": "12345678901",
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/reset-password", bytes.NewBuffer(jsonBody))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	ResetPassword(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"new_password"`)
+	assert.Contains(t, w.Body.String(), auth.PasswordRuleCharClasses)
+}
+
 // ---------------------------------------------------------------------------
 // VerifyEmail — request validation
 // ---------------------------------------------------------------------------
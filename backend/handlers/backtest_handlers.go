@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 )
@@ -25,20 +26,20 @@ func NewBacktestHandler(service *services.BacktestService) *BacktestHandler {
 func (h *BacktestHandler) RunBacktest(c *gin.Context) {
 	var config models.BacktestConfig
 	if err := c.ShouldBindJSON(&config); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Validate configuration
 	if err := h.service.ValidateConfig(config); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Run backtest (synchronous for simple requests)
 	summary, err := h.service.RunBacktest(config)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -50,13 +51,13 @@ func (h *BacktestHandler) RunBacktest(c *gin.Context) {
 func (h *BacktestHandler) SubmitBacktestJob(c *gin.Context) {
 	var config models.BacktestConfig
 	if err := c.ShouldBindJSON(&config); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Validate configuration
 	if err := h.service.ValidateConfig(config); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -71,7 +72,7 @@ func (h *BacktestHandler) SubmitBacktestJob(c *gin.Context) {
 	// Submit job
 	job, err := h.service.SubmitBacktestJob(config, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -88,13 +89,13 @@ func (h *BacktestHandler) GetBacktestJobStatus(c *gin.Context) {
 	jobID := c.Param("jobId")
 
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Job ID is required")
 		return
 	}
 
 	job, err := h.service.GetJobStatus(jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Job not found")
 		return
 	}
 
@@ -125,13 +126,13 @@ func (h *BacktestHandler) GetBacktestJobResult(c *gin.Context) {
 	jobID := c.Param("jobId")
 
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Job ID is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Job ID is required")
 		return
 	}
 
 	summary, err := h.service.GetJobResult(jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, err.Error())
 		return
 	}
 
@@ -143,7 +144,7 @@ func (h *BacktestHandler) GetBacktestJobResult(c *gin.Context) {
 func (h *BacktestHandler) GetLatestBacktest(c *gin.Context) {
 	summary, err := h.service.GetLatestBacktest()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No completed backtests found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No completed backtests found")
 		return
 	}
 
@@ -163,7 +164,7 @@ func (h *BacktestHandler) GetBacktestCharts(c *gin.Context) {
 	// Get the latest backtest results
 	summary, err := h.service.GetLatestBacktest()
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No completed backtests found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No completed backtests found")
 		return
 	}
 
@@ -176,19 +177,19 @@ func (h *BacktestHandler) GetBacktestCharts(c *gin.Context) {
 func (h *BacktestHandler) GetUserBacktests(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Authentication required")
 		return
 	}
 
 	u, ok := user.(*models.User)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid user context")
 		return
 	}
 
 	jobs, err := h.service.GetUserBacktests(u.ID, 10)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -228,7 +229,7 @@ func (h *BacktestHandler) RunQuickBacktest(c *gin.Context) {
 
 	summary, err := h.service.GetCachedOrRunBacktest(config)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
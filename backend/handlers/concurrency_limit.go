@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"investorcenter-api/httputil"
+)
+
+// concurrencyRetryAfterSeconds is sent as Retry-After when a request is shed
+const concurrencyRetryAfterSeconds = 2
+
+// DefaultConcurrencyLimits caps in-flight requests for routes whose queries
+// are heavy enough that a burst could exhaust the database pool (25
+// connections). Keys are gin route patterns as returned by c.FullPath().
+var DefaultConcurrencyLimits = map[string]int{
+	"/api/v1/screener/stocks":         10,
+	"/api/v1/tickers/:symbol":         15,
+	"/api/v1/admin/stocks":            3,
+	"/api/v1/admin/fundamentals":      3,
+	"/api/v1/admin/watchlists":        3,
+	"/api/v1/admin/alerts":            3,
+	"/api/v1/admin/stats":             2,
+	"/api/v1/admin/coverage":          2,
+	"/api/v1/admin/cronjobs/overview": 2,
+}
+
+// LoadConcurrencyLimits returns DefaultConcurrencyLimits with overrides from
+// CONCURRENCY_LIMITS, a comma-separated list of route=limit pairs such as
+// "/api/v1/screener/stocks=20,/api/v1/admin/stats=0". A limit of 0 removes
+// the cap for that route. Malformed entries are logged and skipped.
+func LoadConcurrencyLimits() map[string]int {
+	limits := make(map[string]int, len(DefaultConcurrencyLimits))
+	for route, max := range DefaultConcurrencyLimits {
+		limits[route] = max
+	}
+
+	for _, entry := range strings.Split(os.Getenv("CONCURRENCY_LIMITS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		max, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || max < 0 {
+			log.Printf("⚠️  Ignoring invalid CONCURRENCY_LIMITS entry %q", entry)
+			continue
+		}
+		limits[strings.TrimSpace(route)] = max
+	}
+	return limits
+}
+
+// ConcurrencyLimitMiddleware sheds requests to a limited route once that
+// route already has its maximum number of requests in flight, responding
+// 503 with Retry-After instead of queueing more work on the database.
+// Each route has its own limit; routes without one are not limited.
+func ConcurrencyLimitMiddleware(limits map[string]int) gin.HandlerFunc {
+	slots := make(map[string]chan struct{}, len(limits))
+	for route, max := range limits {
+		if max > 0 {
+			slots[route] = make(chan struct{}, max)
+		}
+	}
+
+	return func(c *gin.Context) {
+		sem, ok := slots[c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			c.Next()
+		default:
+			c.Header("Retry-After", strconv.Itoa(concurrencyRetryAfterSeconds))
+			respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Service is busy, please retry shortly", "too many concurrent requests to "+c.FullPath())
+		}
+	}
+}
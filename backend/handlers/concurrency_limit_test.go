@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingRouter serves /limited/:id, whose handler signals entered and then
+// blocks until release is closed, and /unlimited, which returns at once
+func blockingRouter(limits map[string]int, entered chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ConcurrencyLimitMiddleware(limits))
+	handler := func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	}
+	r.GET("/limited/:id", handler)
+	r.GET("/unlimited", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	return r
+}
+
+func TestConcurrencyLimit_ShedsRequestOverLimit(t *testing.T) {
+	const limit = 3
+	entered := make(chan struct{}, limit+1)
+	release := make(chan struct{})
+	r := blockingRouter(map[string]int{"/limited/:id": limit}, entered, release)
+
+	// Fill every slot with a request that stays in flight
+	var wg sync.WaitGroup
+	codes := make([]int, limit)
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited/x", nil))
+			codes[i] = w.Code
+		}(i)
+	}
+	for i := 0; i < limit; i++ {
+		<-entered
+	}
+
+	// The N+1th concurrent request is shed, whatever its path params
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited/y", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Service is busy")
+
+	close(release)
+	wg.Wait()
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	// Slots are released once requests finish
+	go func() { <-entered }()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited/z", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestConcurrencyLimit_OtherRoutesUnaffected(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	r := blockingRouter(map[string]int{"/limited/:id": 1, "/unlimited": 0}, entered, release)
+
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/limited/x", nil))
+		close(done)
+	}()
+	<-entered
+
+	// The limited route is full; a route without a limit (or with 0) still serves
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unlimited", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	close(release)
+	<-done
+}
+
+func TestLoadConcurrencyLimits(t *testing.T) {
+	t.Setenv("CONCURRENCY_LIMITS", " /api/v1/screener/stocks=25, /api/v1/admin/stats=0,/custom/:id=4,bad,/x=-1")
+
+	limits := LoadConcurrencyLimits()
+	assert.Equal(t, 25, limits["/api/v1/screener/stocks"])
+	assert.Equal(t, 0, limits["/api/v1/admin/stats"])
+	assert.Equal(t, 4, limits["/custom/:id"])
+	assert.NotContains(t, limits, "/x")
+	assert.Equal(t, DefaultConcurrencyLimits["/api/v1/admin/coverage"], limits["/api/v1/admin/coverage"])
+	require.Equal(t, 10, DefaultConcurrencyLimits["/api/v1/screener/stocks"], "defaults must not be mutated")
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"net/http"
 	"strconv"
@@ -36,7 +37,7 @@ func NewCronjobHandler(cronjobService CronjobServicer) *CronjobHandler {
 func (h *CronjobHandler) GetOverview(c *gin.Context) {
 	overview, err := h.cronjobService.GetOverview()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cronjob overview", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get cronjob overview", err.Error())
 		return
 	}
 
@@ -55,12 +56,11 @@ func (h *CronjobHandler) GetOverview(c *gin.Context) {
 // @Router /api/v1/admin/cronjobs/{jobName}/history [get]
 func (h *CronjobHandler) GetJobHistory(c *gin.Context) {
 	jobName := c.Param("jobName")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, offset := parsePagination(c, 50)
 
 	history, err := h.cronjobService.GetJobHistory(jobName, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job history", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get job history", err.Error())
 		return
 	}
 
@@ -81,10 +81,10 @@ func (h *CronjobHandler) GetJobDetails(c *gin.Context) {
 	details, err := h.cronjobService.GetJobDetails(executionID)
 	if err != nil {
 		if err.Error() == "execution not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Execution not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Execution not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job details", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get job details", err.Error())
 		return
 	}
 
@@ -104,7 +104,7 @@ func (h *CronjobHandler) GetMetrics(c *gin.Context) {
 
 	metrics, err := h.cronjobService.GetMetrics(period)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get metrics", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get metrics", err.Error())
 		return
 	}
 
@@ -121,7 +121,7 @@ func (h *CronjobHandler) GetMetrics(c *gin.Context) {
 func (h *CronjobHandler) GetAllSchedules(c *gin.Context) {
 	schedules, err := h.cronjobService.GetAllSchedules()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get schedules", "details": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get schedules", err.Error())
 		return
 	}
 
@@ -3,15 +3,22 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
+
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
 )
 
 // Redis client for crypto prices
@@ -65,45 +72,144 @@ type CryptoRealTimePrice struct {
 	Source                   string  `json:"source"`
 }
 
-// GetCryptoRealTimePrice handles GET /api/v1/crypto/:symbol/price
+// quoteCurrencyPattern matches CoinGecko vs_currencies codes (usd, eur, btc, ...)
+var quoteCurrencyPattern = regexp.MustCompile(`^[a-z]{2,10}$`)
+
+// GetCryptoRealTimePrice handles GET /api/v1/crypto/:symbol/price. It
+// returns a coin's live price in the same shape as GetTickerRealTimePrice,
+// quoted in USD unless ?vs= names another currency. USD prices come from the
+// Redis quote cache when it has the coin; anything else asks CoinGecko.
 func GetCryptoRealTimePrice(c *gin.Context) {
 	symbol := strings.ToUpper(c.Param("symbol"))
+	vs := strings.ToLower(c.DefaultQuery("vs", "usd"))
+	if !quoteCurrencyPattern.MatchString(vs) {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid quote currency", fmt.Sprintf("%q is not a currency code", vs))
+		return
+	}
 
-	// Get real-time price from Redis
 	ctx := context.Background()
-	// Try the new key format first (from crypto_complete_service.py)
 	priceKey := fmt.Sprintf("crypto:quote:%s", symbol)
 
+	var cached *CryptoRealTimePrice
 	priceData, err := redisClient.Get(ctx, priceKey).Result()
-	if err == redis.Nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("Real-time price not available for %s", symbol),
-		})
-		return
-	} else if err != nil {
+	if err == nil {
+		var price CryptoRealTimePrice
+		if err := json.Unmarshal([]byte(priceData), &price); err != nil {
+			log.Printf("Failed to parse price data for %s: %v", symbol, err)
+		} else {
+			cached = &price
+		}
+	} else if err != redis.Nil {
 		log.Printf("Redis error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch price",
-		})
+	}
+
+	if cached != nil && vs == "usd" {
+		c.JSON(http.StatusOK, cryptoPriceResponse(symbol, vs, cached.CurrentPrice, cached.PriceChange24h,
+			cached.PriceChangePercentage24h, cached.TotalVolume, convertCryptoPriceToStockPrice(cached).Timestamp, "redis"))
 		return
 	}
 
-	// Parse and return price data
-	var price CryptoRealTimePrice
-	if err := json.Unmarshal([]byte(priceData), &price); err != nil {
-		log.Printf("Failed to parse price data: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid price data",
-		})
+	coinGeckoClient := services.NewCoinGeckoClient()
+	coinID := coinGeckoClient.MapSymbolToCoinGeckoID(symbol)
+	if cached != nil && cached.ID != "" {
+		coinID = cached.ID
+	}
+	quote, err := coinGeckoClient.GetCoinQuote(coinID, vs)
+	switch {
+	case errors.Is(err, services.ErrCoinNotFound):
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, fmt.Sprintf("Real-time price not available for %s", symbol))
+		return
+	case errors.Is(err, services.ErrUnsupportedCurrency):
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid quote currency", fmt.Sprintf("%s is not priced in %s", symbol, strings.ToUpper(vs)))
+		return
+	case err != nil:
+		log.Printf("CoinGecko error for %s: %v", symbol, err)
+		respondError(c, http.StatusBadGateway, httputil.CodeUpstream, "Failed to fetch price")
 		return
 	}
 
-	// Populate alias fields for frontend compatibility
-	price.Price = price.CurrentPrice
-	price.Volume24h = price.TotalVolume
-	price.Change24h = price.PriceChangePercentage24h
+	// CoinGecko's simple price has only the percent change; back out the
+	// absolute change from the price it applies to
+	change := quote.Price - quote.Price/(1+quote.ChangePercent/100)
+	c.JSON(http.StatusOK, cryptoPriceResponse(symbol, vs, quote.Price, change,
+		quote.ChangePercent, quote.Volume, quote.LastUpdated, "coingecko"))
+}
 
-	c.JSON(http.StatusOK, price)
+// cryptoPriceResponse builds a GetTickerRealTimePrice-shaped response for a
+// coin. Prices keep their full precision since many coins trade well below
+// a cent.
+func cryptoPriceResponse(symbol, currency string, price, change, changePercent, volume float64, asOf time.Time, source string) gin.H {
+	return gin.H{
+		"data": withPriceFreshness(gin.H{
+			"symbol":        symbol,
+			"price":         decimal.NewFromFloat(price).String(),
+			"change":        decimal.NewFromFloat(change).Round(8).String(),
+			"changePercent": decimal.NewFromFloat(changePercent).StringFixed(2),
+			"volume":        int64(volume),
+			"timestamp":     asOf.Unix(),
+			"lastUpdated":   asOf.UTC().Format(time.RFC3339),
+			"assetType":     "crypto",
+			"currency":      strings.ToUpper(currency),
+		}, asOf, true),
+		"market": gin.H{
+			"session":        marketStatusOpen,
+			"isOpen":         true,
+			"updateInterval": getUpdateInterval(true, marketStatusOpen),
+		},
+		"meta": gin.H{
+			"timestamp": time.Now().UTC(),
+			"source":    source,
+		},
+	}
+}
+
+// GetCryptoChart handles GET /api/v1/crypto/:symbol/chart, returning OHLCV
+// candles in the same shape as GetTickerChart
+func GetCryptoChart(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	period := chartPeriod(c)
+
+	chartData, err := getCryptoChartData(symbol, period)
+	if errors.Is(err, services.ErrCoinNotFound) {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, fmt.Sprintf("Chart data not available for %s", symbol))
+		return
+	} else if err != nil {
+		log.Printf("Failed to get crypto chart data for %s: %v", symbol, err)
+		respondError(c, http.StatusBadGateway, httputil.CodeUpstream, "Chart data temporarily unavailable")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"symbol":      symbol,
+			"period":      period,
+			"dataPoints":  chartData,
+			"count":       len(chartData),
+			"lastUpdated": time.Now().UTC(),
+		},
+		"meta": gin.H{
+			"symbol":    symbol,
+			"period":    period,
+			"count":     len(chartData),
+			"isCrypto":  true,
+			"source":    "coingecko",
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
+
+// getCryptoChartData fetches a coin's candles for period. Ranges CoinGecko
+// won't serve (the free tier stops at a year) fall back to a year of OHLC
+// or market_chart data.
+func getCryptoChartData(symbol, period string) ([]models.ChartDataPoint, error) {
+	coinGeckoClient := services.NewCoinGeckoClient()
+	candles, err := coinGeckoClient.GetCandles(symbol, period)
+	if err == nil || errors.Is(err, services.ErrCoinNotFound) {
+		return candles, err
+	}
+	log.Printf("CoinGecko candles failed for %s (%s), falling back to chart data: %v", symbol, period, err)
+	return coinGeckoClient.GetChartData(symbol, period)
 }
 
 // GetAllCryptoRealTimePrices handles GET /api/v1/crypto/prices
@@ -114,9 +220,7 @@ func GetAllCryptoRealTimePrices(c *gin.Context) {
 	symbols, err := redisClient.ZRange(ctx, "crypto:symbols:ranked", 0, -1).Result()
 	if err != nil {
 		log.Printf("Failed to get symbols: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch symbols",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch symbols")
 		return
 	}
 
@@ -12,6 +12,8 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/services"
 )
 
 // setupMiniRedis starts a miniredis instance and swaps the package-level redisClient.
@@ -35,13 +37,45 @@ func setupCryptoRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.GET("/api/v1/crypto/:symbol/price", GetCryptoRealTimePrice)
+	r.GET("/api/v1/crypto/:symbol/chart", GetCryptoChart)
 	r.GET("/api/v1/crypto/prices", GetAllCryptoRealTimePrices)
 	r.GET("/api/v1/crypto/stream", StreamCryptoPrices)
 	return r
 }
 
+// setupCoinGecko points the CoinGecko client at a stub /simple/price that
+// serves body for any request
+func setupCoinGecko(t *testing.T, body string) *int {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(body))
+	}))
+
+	origURL := services.CoinGeckoBaseURL
+	services.CoinGeckoBaseURL = server.URL
+	t.Cleanup(func() {
+		services.CoinGeckoBaseURL = origURL
+		server.Close()
+	})
+	return &calls
+}
+
+func getCryptoJSON(t *testing.T, router *gin.Engine, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(w, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w, resp
+}
+
 func TestGetCryptoRealTimePrice_Success(t *testing.T) {
 	mr := setupMiniRedis(t)
+	calls := setupCoinGecko(t, `{}`)
 	router := setupCryptoRouter()
 
 	priceData := CryptoRealTimePrice{
@@ -50,6 +84,7 @@ func TestGetCryptoRealTimePrice_Success(t *testing.T) {
 		CurrentPrice:             67500.50,
 		MarketCap:                1300000000000,
 		TotalVolume:              25000000000,
+		PriceChange24h:           1646.35,
 		PriceChangePercentage24h: 2.5,
 		LastUpdated:              "2024-12-15T10:00:00Z",
 		Source:                   "coingecko",
@@ -57,48 +92,101 @@ func TestGetCryptoRealTimePrice_Success(t *testing.T) {
 	data, _ := json.Marshal(priceData)
 	mr.Set("crypto:quote:BTC", string(data))
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/api/v1/crypto/BTC/price", nil)
-	router.ServeHTTP(w, req)
+	w, resp := getCryptoJSON(t, router, "/api/v1/crypto/BTC/price")
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Zero(t, *calls, "cached USD quotes don't call CoinGecko")
+
+	// Same shape as GetTickerRealTimePrice
+	price := resp["data"].(map[string]interface{})
+	assert.Equal(t, "BTC", price["symbol"])
+	assert.Equal(t, "67500.5", price["price"])
+	assert.Equal(t, "1646.35", price["change"])
+	assert.Equal(t, "2.50", price["changePercent"])
+	assert.Equal(t, float64(25000000000), price["volume"])
+	assert.Equal(t, "2024-12-15T10:00:00Z", price["lastUpdated"])
+	assert.Equal(t, "USD", price["currency"])
+	assert.Equal(t, "crypto", price["assetType"])
+	assert.Equal(t, "open", price["marketStatus"])
+
+	marketData := resp["market"].(map[string]interface{})
+	assert.Equal(t, true, marketData["isOpen"])
+	assert.Equal(t, "redis", resp["meta"].(map[string]interface{})["source"])
+}
 
-	var resp CryptoRealTimePrice
-	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
-	assert.Equal(t, "BTC", resp.Symbol)
-	assert.Equal(t, 67500.50, resp.CurrentPrice)
-	// Alias fields should be populated
-	assert.Equal(t, 67500.50, resp.Price)
-	assert.Equal(t, 25000000000.0, resp.Volume24h)
-	assert.Equal(t, 2.5, resp.Change24h)
+func TestGetCryptoRealTimePrice_OtherCurrency(t *testing.T) {
+	mr := setupMiniRedis(t)
+	setupCoinGecko(t, `{"bitcoin":{"eur":60000,"eur_24h_vol":21000000000,"eur_24h_change":20,"last_updated_at":1734256800}}`)
+	router := setupCryptoRouter()
+
+	data, _ := json.Marshal(CryptoRealTimePrice{Symbol: "BTC", ID: "bitcoin", CurrentPrice: 67500.0})
+	mr.Set("crypto:quote:BTC", string(data))
+
+	w, resp := getCryptoJSON(t, router, "/api/v1/crypto/BTC/price?vs=EUR")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	price := resp["data"].(map[string]interface{})
+	assert.Equal(t, "60000", price["price"])
+	assert.Equal(t, "10000", price["change"], "change is backed out of the 24h percent")
+	assert.Equal(t, "20.00", price["changePercent"])
+	assert.Equal(t, float64(21000000000), price["volume"])
+	assert.Equal(t, "EUR", price["currency"])
+	assert.Equal(t, "coingecko", resp["meta"].(map[string]interface{})["source"])
+}
+
+func TestGetCryptoRealTimePrice_CacheMiss(t *testing.T) {
+	_ = setupMiniRedis(t) // empty Redis
+	setupCoinGecko(t, `{"solana":{"usd":150.25,"usd_24h_vol":3000000000,"usd_24h_change":0,"last_updated_at":1734256800}}`)
+	router := setupCryptoRouter()
+
+	w, resp := getCryptoJSON(t, router, "/api/v1/crypto/SOL/price")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	price := resp["data"].(map[string]interface{})
+	assert.Equal(t, "150.25", price["price"])
+	assert.Equal(t, "USD", price["currency"])
 }
 
 func TestGetCryptoRealTimePrice_NotFound(t *testing.T) {
 	_ = setupMiniRedis(t) // empty Redis
+	setupCoinGecko(t, `{}`)
 	router := setupCryptoRouter()
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/api/v1/crypto/UNKNOWN/price", nil)
-	router.ServeHTTP(w, req)
+	w, resp := getCryptoJSON(t, router, "/api/v1/crypto/UNKNOWN/price")
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "not_found", resp["code"])
+}
+
+func TestGetCryptoRealTimePrice_InvalidCurrency(t *testing.T) {
+	_ = setupMiniRedis(t)
+	setupCoinGecko(t, `{"bitcoin":{"last_updated_at":1734256800}}`)
+	router := setupCryptoRouter()
+
+	w, _ := getCryptoJSON(t, router, "/api/v1/crypto/BTC/price?vs=usd1")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "malformed currency code")
+
+	w, _ = getCryptoJSON(t, router, "/api/v1/crypto/BTC/price?vs=zzz")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "currency CoinGecko doesn't quote")
 }
 
 func TestGetCryptoRealTimePrice_InvalidJSON(t *testing.T) {
 	mr := setupMiniRedis(t)
+	setupCoinGecko(t, `{"bitcoin":{"usd":67000}}`)
 	router := setupCryptoRouter()
 
 	mr.Set("crypto:quote:BTC", "not valid json")
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/api/v1/crypto/BTC/price", nil)
-	router.ServeHTTP(w, req)
+	w, resp := getCryptoJSON(t, router, "/api/v1/crypto/BTC/price")
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	// A corrupt cache entry falls back to CoinGecko
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "coingecko", resp["meta"].(map[string]interface{})["source"])
 }
 
 func TestGetCryptoRealTimePrice_CaseInsensitive(t *testing.T) {
 	mr := setupMiniRedis(t)
+	setupCoinGecko(t, `{}`)
 	router := setupCryptoRouter()
 
 	priceData := CryptoRealTimePrice{Symbol: "BTC", CurrentPrice: 67500.0, Source: "coingecko"}
@@ -113,6 +201,43 @@ func TestGetCryptoRealTimePrice_CaseInsensitive(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestGetCryptoChart_Success(t *testing.T) {
+	setupCoinGecko(t, `{"prices":[[1734220800000,100],[1734300000000,110]],"total_volumes":[[1734220800000,5000],[1734300000000,6000]]}`)
+	router := setupCryptoRouter()
+
+	// Symbol unique to this test, since candles are cached per symbol
+	w, resp := getCryptoJSON(t, router, "/api/v1/crypto/CHARTCOIN/chart?period=1M")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	data := resp["data"].(map[string]interface{})
+	assert.Equal(t, "1M", data["period"])
+	assert.Equal(t, float64(1), data["count"])
+	points := data["dataPoints"].([]interface{})
+	candle := points[0].(map[string]interface{})
+	assert.Equal(t, "2024-12-15T00:00:00Z", candle["timestamp"], "daily candles are stamped at midnight UTC")
+	assert.Equal(t, "100", candle["open"])
+	assert.Equal(t, "110", candle["close"])
+	assert.Equal(t, float64(6000), candle["volume"])
+	assert.Equal(t, true, resp["meta"].(map[string]interface{})["isCrypto"])
+}
+
+func TestGetCryptoChart_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	origURL := services.CoinGeckoBaseURL
+	services.CoinGeckoBaseURL = server.URL
+	t.Cleanup(func() {
+		services.CoinGeckoBaseURL = origURL
+		server.Close()
+	})
+	router := setupCryptoRouter()
+
+	w, _ := getCryptoJSON(t, router, "/api/v1/crypto/NOPECOIN/chart?period=1D")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func TestGetAllCryptoRealTimePrices_Success(t *testing.T) {
 	mr := setupMiniRedis(t)
 	router := setupCryptoRouter()
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/services"
+)
+
+// dividendsCacheTTL is the Redis cache TTL for per-ticker dividend history.
+const dividendsCacheTTL = 1 * time.Hour
+
+// dividendsCacheVersion is bumped when the response shape changes.
+const dividendsCacheVersion = "v1"
+
+// GetTickerDividends handles GET /api/v1/tickers/:symbol/dividends
+// Returns dividend history (newest first) with the TTM dividend, yield
+// against the latest close, payment frequency and dividend-paying streak.
+// Tickers that don't pay dividends get an empty history, not an error.
+func GetTickerDividends(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	if !validTickerRe.MatchString(symbol) {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid ticker symbol")
+		return
+	}
+
+	ctx := c.Request.Context()
+	cacheKey := fmt.Sprintf("dividends:%s:ticker:%s", dividendsCacheVersion, symbol)
+
+	if redisClient != nil {
+		cached, err := redisClient.Get(ctx, cacheKey).Result()
+		if err == nil {
+			c.Data(http.StatusOK, "application/json", []byte(cached))
+			return
+		}
+		if err != redis.Nil {
+			log.Printf("Redis GET error for %s: %v", cacheKey, err)
+		}
+	}
+
+	if !isFMPReady() {
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "FMP not configured", "Dividend data is not available at this time")
+		return
+	}
+
+	dividends, err := fmpClient.GetDividendHistory(symbol)
+	if err != nil {
+		log.Printf("FMP dividend history fetch error for %s: %v", symbol, err)
+		respondError(c, http.StatusBadGateway, httputil.CodeUpstream, "Upstream service unavailable", "Failed to fetch dividend data")
+		return
+	}
+
+	// Yield is left out rather than failing the request if there's no price
+	var price *float64
+	if database.DB != nil {
+		if price, err = database.GetLatestClose(symbol); err != nil {
+			log.Printf("Warning: latest close unavailable for %s: %v", symbol, err)
+		}
+	}
+
+	response := gin.H{
+		"data": services.SummarizeDividends(dividends, price, time.Now()),
+		"meta": gin.H{
+			"ticker":    symbol,
+			"count":     len(dividends),
+			"timestamp": time.Now().UTC(),
+		},
+	}
+
+	if redisClient != nil {
+		responseJSON, err := json.Marshal(response)
+		if err != nil {
+			log.Printf("JSON marshal error for dividends %s: %v", symbol, err)
+		} else if err := redisClient.Set(ctx, cacheKey, responseJSON, dividendsCacheTTL).Err(); err != nil {
+			log.Printf("Redis SET error for %s: %v", cacheKey, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
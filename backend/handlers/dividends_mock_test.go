@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/services"
+)
+
+// useFMPServer points the shared FMP client at a test server
+func useFMPServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	origClient, origURL := fmpClient, services.FMPBaseURL
+	fmpClient = &services.FMPClient{APIKey: "test-key", Client: server.Client()}
+	services.FMPBaseURL = server.URL
+	t.Cleanup(func() {
+		fmpClient, services.FMPBaseURL = origClient, origURL
+		server.Close()
+	})
+}
+
+func TestGetTickerDividends(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	recent := time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+	older := time.Now().AddDate(0, -4, 0).Format("2006-01-02")
+	useFMPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/dividend/KO")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"historical": []services.FMPDividendHistorical{
+				{Symbol: "KO", Date: older, AdjDividend: 0.485, Dividend: 0.485},
+				{Symbol: "KO", Date: recent, AdjDividend: 0.51, Dividend: 0.51},
+			},
+		})
+	})
+	mock.ExpectQuery("SELECT close\\s+FROM stock_prices").
+		WithArgs("KO").
+		WillReturnRows(sqlmock.NewRows([]string{"close"}).AddRow(62.0))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/tickers/:symbol/dividends", GetTickerDividends)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tickers/ko/dividends", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data services.DividendSummary `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.History, 2)
+	assert.Equal(t, recent, resp.Data.History[0].Date, "newest first")
+	assert.InDelta(t, 0.995, resp.Data.TTMDividend, 1e-9)
+	require.NotNil(t, resp.Data.DividendYield)
+	assert.InDelta(t, 0.995/62*100, *resp.Data.DividendYield, 1e-9)
+	assert.GreaterOrEqual(t, resp.Data.ConsecutiveYears, 1)
+	assert.Equal(t, "semi-annual", resp.Data.Frequency)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerDividends_NonPayer(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	useFMPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	mock.ExpectQuery("SELECT close\\s+FROM stock_prices").
+		WithArgs("TSLA").
+		WillReturnRows(sqlmock.NewRows([]string{"close"}).AddRow(250.0))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/tickers/:symbol/dividends", GetTickerDividends)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tickers/TSLA/dividends", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []interface{}{}, resp.Data["history"], "empty array, not null")
+	assert.Equal(t, 0.0, resp.Data["ttm_dividend"])
+	assert.Nil(t, resp.Data["dividend_yield"])
+	assert.Equal(t, "none", resp.Data["frequency"])
+}
+
+func TestGetTickerDividends_UpstreamError(t *testing.T) {
+	useFMPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/tickers/:symbol/dividends", GetTickerDividends)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tickers/KO/dividends", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestGetTickerDividends_InvalidTicker(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/tickers/:symbol/dividends", GetTickerDividends)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tickers/BAD$TICKER/dividends", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -38,7 +39,7 @@ func isFMPReady() bool {
 func GetStockEarnings(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if !validTickerRe.MatchString(ticker) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticker symbol"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid ticker symbol")
 		return
 	}
 
@@ -59,20 +60,14 @@ func GetStockEarnings(c *gin.Context) {
 
 	// Fetch from FMP
 	if !isFMPReady() {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "FMP not configured",
-			"message": "Earnings data is not available at this time",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "FMP not configured", "Earnings data is not available at this time")
 		return
 	}
 
 	records, err := fmpClient.GetEarnings(ticker)
 	if err != nil {
 		log.Printf("FMP earnings fetch error for %s: %v", ticker, err)
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":   "Upstream service unavailable",
-			"message": "Failed to fetch earnings data",
-		})
+		respondError(c, http.StatusBadGateway, httputil.CodeUpstream, "Upstream service unavailable", "Failed to fetch earnings data")
 		return
 	}
 
@@ -129,20 +124,18 @@ func GetEarningsCalendar(c *gin.Context) {
 	// Validate date format
 	fromDate, err := time.Parse("2006-01-02", from)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date format. Use YYYY-MM-DD"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid 'from' date format. Use YYYY-MM-DD")
 		return
 	}
 	toDate, err := time.Parse("2006-01-02", to)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date format. Use YYYY-MM-DD"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid 'to' date format. Use YYYY-MM-DD")
 		return
 	}
 
 	// Validate max 14-day window (inclusive: 14 days exactly is the limit)
 	if toDate.Sub(fromDate).Hours() >= 15*24 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Date range must not exceed 14 days",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Date range must not exceed 14 days")
 		return
 	}
 
@@ -163,20 +156,14 @@ func GetEarningsCalendar(c *gin.Context) {
 
 	// Fetch from FMP
 	if !isFMPReady() {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "FMP not configured",
-			"message": "Earnings calendar is not available at this time",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "FMP not configured", "Earnings calendar is not available at this time")
 		return
 	}
 
 	records, err := fmpClient.GetEarningsCalendar(from, to)
 	if err != nil {
 		log.Printf("FMP earnings calendar fetch error: %v", err)
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":   "Upstream service unavailable",
-			"message": "Failed to fetch earnings calendar",
-		})
+		respondError(c, http.StatusBadGateway, httputil.CodeUpstream, "Upstream service unavailable", "Failed to fetch earnings calendar")
 		return
 	}
 
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/httputil"
+	"investorcenter-api/services"
+)
+
+// EmailSandboxHandler exposes the emails captured in sandbox mode, so
+// staging checks and integration tests can assert on what would have been
+// sent. Like the notification service's canary endpoint it is guarded by a
+// shared CANARY_TOKEN rather than a user login.
+type EmailSandboxHandler struct {
+	sandbox *services.EmailSandbox
+	token   string
+}
+
+// NewEmailSandboxHandler creates a handler over sandbox. An empty token
+// denies every request.
+func NewEmailSandboxHandler(sandbox *services.EmailSandbox, token string) *EmailSandboxHandler {
+	return &EmailSandboxHandler{sandbox: sandbox, token: token}
+}
+
+// RequireCanaryToken rejects requests without "Authorization: Bearer <CANARY_TOKEN>"
+func (h *EmailSandboxHandler) RequireCanaryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader("Authorization")
+		if h.token == "" || subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+h.token)) != 1 {
+			respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Unauthorized - provide Authorization: Bearer <CANARY_TOKEN>")
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetCapturedEmails returns the most recently captured emails, newest first,
+// optionally only those sent to ?to=.
+//
+// Example: GET /api/v1/canary/emails?to=user@example.com&limit=5
+func (h *EmailSandboxHandler) GetCapturedEmails(c *gin.Context) {
+	limit := parseLimitUpTo(c, 20, 100)
+	emails := h.sandbox.Recent(c.Query("to"), limit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": emails,
+		"meta": gin.H{
+			"count":     len(emails),
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
+
+// ClearCapturedEmails drops the captured emails, so a test run starts from
+// an empty sandbox.
+//
+// Example: DELETE /api/v1/canary/emails
+func (h *EmailSandboxHandler) ClearCapturedEmails(c *gin.Context) {
+	h.sandbox.Clear()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/services"
+)
+
+func setupEmailSandboxRouter(sandbox *services.EmailSandbox, token string) *gin.Engine {
+	h := NewEmailSandboxHandler(sandbox, token)
+	r := setupMockRouterNoAuth()
+	g := r.Group("/canary")
+	g.Use(h.RequireCanaryToken())
+	g.GET("/emails", h.GetCapturedEmails)
+	g.DELETE("/emails", h.ClearCapturedEmails)
+	return r
+}
+
+func TestGetCapturedEmails(t *testing.T) {
+	sandbox := services.NewEmailSandbox(10, "")
+	require.NoError(t, sandbox.Capture("a@example.com", "first", "<p>1</p>"))
+	require.NoError(t, sandbox.Capture("b@example.com", "second", "<p>2</p>"))
+	r := setupEmailSandboxRouter(sandbox, "s3cret")
+
+	req := httptest.NewRequest(http.MethodGet, "/canary/emails?to=a@example.com", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []services.CapturedEmail `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "first", resp.Data[0].Subject)
+	assert.Equal(t, 1.0, resp.Meta["count"])
+}
+
+func TestCapturedEmails_RequiresToken(t *testing.T) {
+	sandbox := services.NewEmailSandbox(10, "")
+
+	for name, tc := range map[string]struct{ configured, sent string }{
+		"missing header":     {configured: "s3cret", sent: ""},
+		"wrong token":        {configured: "s3cret", sent: "Bearer nope"},
+		"token unconfigured": {configured: "", sent: "Bearer "},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := setupEmailSandboxRouter(sandbox, tc.configured)
+			req := httptest.NewRequest(http.MethodGet, "/canary/emails", nil)
+			if tc.sent != "" {
+				req.Header.Set("Authorization", tc.sent)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestClearCapturedEmails(t *testing.T) {
+	sandbox := services.NewEmailSandbox(10, "")
+	require.NoError(t, sandbox.Capture("a@example.com", "first", "<p>1</p>"))
+	r := setupEmailSandboxRouter(sandbox, "s3cret")
+
+	req := httptest.NewRequest(http.MethodDelete, "/canary/emails", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, sandbox.Recent("", 0))
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+)
+
+// respondError writes the unified error body (httputil.APIError) and aborts.
+// Pass "" as code to use the default for status.
+func respondError(c *gin.Context, status int, code, message string, details ...interface{}) {
+	httputil.RespondError(c, status, code, message, details...)
+}
+
+// Owned resources (watch lists and their items, alerts and alert logs,
+// heatmap configs, notifications, portfolios, saved screens and searches)
+// are always looked up by (id, user_id), so another user's resource is
+// indistinguishable from one that doesn't exist, and both get a 404. A 403
+// would confirm the ID exists. 403 is kept for refusing an action on a
+// resource the caller can see (plan limits, deleting the default watch
+// list) and for role checks on admin routes.
+
+// respondOwnedLookupError responds to a failed lookup of an owned resource:
+// 404 with notFound when err is a database not-found error, otherwise 500
+// with failed, logging err.
+func respondOwnedLookupError(c *gin.Context, err error, notFound, failed string) {
+	if database.IsNotFound(err) {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, notFound)
+		return
+	}
+	log.Printf("%s: %v", failed, err)
+	respondError(c, http.StatusInternalServerError, httputil.CodeInternal, failed)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/auth"
+	"investorcenter-api/httputil"
+	"investorcenter-shared/requestid"
+)
+
+// assertAPIError checks w carries the unified error body with code and the
+// request's ID
+func assertAPIError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) map[string]interface{} {
+	t.Helper()
+	require.Equal(t, status, w.Code, w.Body.String())
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, code, body["code"])
+	assert.NotEmpty(t, body["error"], "message is always sent under \"error\"")
+	assert.Equal(t, "test-request-id", body["request_id"])
+	for key := range body {
+		assert.Contains(t, []string{"code", "error", "details", "request_id"}, key, "unexpected key %q", key)
+	}
+	return body
+}
+
+func serveErrorCase(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(requestid.Header, "test-request-id")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUnifiedErrors_ValidationError(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.Use(requestid.Middleware())
+	r.POST("/tickers/batch", GetTickersBatch)
+
+	w := serveErrorCase(r, http.MethodPost, "/tickers/batch", `{"symbols": "AAPL"}`)
+	body := assertAPIError(t, w, http.StatusBadRequest, httputil.CodeInvalidRequest)
+	assert.Equal(t, "Invalid request", body["error"])
+	assert.NotEmpty(t, body["details"])
+}
+
+func TestUnifiedErrors_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	mock.ExpectQuery("FROM saved_screens").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	r := setupMockRouter("user-1")
+	r.Use(requestid.Middleware())
+	r.GET("/screens/:id", GetSavedScreen)
+
+	w := serveErrorCase(r, http.MethodGet, "/screens/missing", "")
+	assertAPIError(t, w, http.StatusNotFound, httputil.CodeNotFound)
+}
+
+func TestUnifiedErrors_ServiceUnavailable(t *testing.T) {
+	origDB := getDatabaseDB()
+	setDatabaseDBNil()
+	defer restoreDatabaseDB(origDB)
+
+	r := setupMockRouterNoAuth()
+	r.Use(requestid.Middleware())
+	r.GET("/screener/stocks", GetScreenerStocks)
+
+	w := serveErrorCase(r, http.MethodGet, "/screener/stocks", "")
+	body := assertAPIError(t, w, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable)
+	assert.Equal(t, "Screener service is temporarily unavailable", body["details"])
+}
+
+func TestUnifiedErrors_AuthMiddleware(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.Use(requestid.Middleware())
+	r.GET("/private", auth.AuthMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := serveErrorCase(r, http.MethodGet, "/private", "")
+	assertAPIError(t, w, http.StatusUnauthorized, httputil.CodeUnauthorized)
+}
+
+func TestUnifiedErrors_LimitReached(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectSubscriptionLimits(mock, "user-1", 2)
+	mock.ExpectQuery("INSERT INTO saved_screens").WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-1")
+	r.Use(requestid.Middleware())
+	r.POST("/screens", CreateSavedScreen)
+
+	w := serveErrorCase(r, http.MethodPost, "/screens", `{"name":"Third","params":{}}`)
+	assertAPIError(t, w, http.StatusForbidden, httputil.CodeLimitReached)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 
@@ -39,13 +42,7 @@ func parseFinancialsParams(c *gin.Context) (timeframe models.Timeframe, limit in
 	}
 
 	// Limit: default 8, max 40
-	limit = 8
-	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
-		if l > 40 {
-			l = 40
-		}
-		limit = l
-	}
+	limit = parseLimitUpTo(c, 8, 40)
 
 	// Fiscal year filter
 	if fy, err := strconv.Atoi(c.Query("fiscal_year")); err == nil {
@@ -61,31 +58,105 @@ func parseFinancialsParams(c *gin.Context) (timeframe models.Timeframe, limit in
 	return
 }
 
+// parseCommonSizeMode reads ?mode=: absolute (default) returns the stored
+// amounts only; common_size also returns each amount as a fraction of the
+// statement's base. Responds 400 and returns ok=false for other values.
+func parseCommonSizeMode(c *gin.Context) (commonSize, ok bool) {
+	switch strings.ToLower(c.DefaultQuery("mode", "absolute")) {
+	case "absolute":
+		return false, true
+	case "common_size":
+		return true, true
+	}
+	respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "mode must be absolute or common_size")
+	return false, false
+}
+
+// addCommonSize fills in each period's CommonSize alongside its absolute
+// amounts, leaving Data untouched
+func addCommonSize(response *models.FinancialsResponse) {
+	bases := services.CommonSizeBases[response.StatementType]
+	for i := range response.Periods {
+		response.Periods[i].CommonSize = services.CommonSizeData(response.Periods[i].Data, bases)
+	}
+}
+
+// parseWithDeltas reads ?with_deltas=, which adds each line item's change
+// versus the prior comparable period to the income, balance sheet, cash
+// flow and all-financials responses. Responds 400 and returns ok=false
+// when it isn't a boolean.
+func parseWithDeltas(c *gin.Context) (withDeltas, ok bool) {
+	raw := c.Query("with_deltas")
+	if raw == "" {
+		return false, true
+	}
+	withDeltas, err := strconv.ParseBool(raw)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "with_deltas must be true or false")
+		return false, false
+	}
+	return withDeltas, true
+}
+
+// deltaFetchLimit is how many periods to fetch to return limit of them:
+// with deltas, a year more, so the oldest period returned still has its
+// prior-year comparison
+func deltaFetchLimit(timeframe models.Timeframe, limit int, withDeltas bool) int {
+	if !withDeltas {
+		return limit
+	}
+	if timeframe == models.TimeframeAnnual {
+		return limit + 1
+	}
+	return limit + 4
+}
+
+// addDeltas fills in each period's Deltas, then drops the extra periods
+// fetched for comparison only. Periods come newest first.
+func addDeltas(response *models.FinancialsResponse, limit int) {
+	services.AddPeriodDeltas(response.Periods)
+	if len(response.Periods) > limit {
+		response.Periods = response.Periods[:limit]
+	}
+}
+
 // GetIncomeStatements handles GET /api/v1/stocks/:ticker/financials/income
+// With ?mode=common_size each period also carries its amounts as a fraction
+// of revenue.
 func (h *FinancialsHandler) GetIncomeStatements(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
+	commonSize, ok := parseCommonSizeMode(c)
+	if !ok {
+		return
+	}
+
+	withDeltas, ok := parseWithDeltas(c)
+	if !ok {
+		return
+	}
+
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial statements service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
 		return
 	}
 
 	timeframe, limit, _, _ := parseFinancialsParams(c)
 
-	response, err := h.service.GetIncomeStatements(c.Request.Context(), ticker, timeframe, limit)
+	response, err := h.service.GetIncomeStatements(c.Request.Context(), ticker, timeframe, deltaFetchLimit(timeframe, limit, withDeltas))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Financial data not found",
-			"message": err.Error(),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", err.Error())
 		return
 	}
 
+	if withDeltas {
+		addDeltas(response, limit)
+	}
+	if commonSize {
+		addCommonSize(response)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": response,
 		"meta": gin.H{
@@ -95,29 +166,41 @@ func (h *FinancialsHandler) GetIncomeStatements(c *gin.Context) {
 }
 
 // GetBalanceSheets handles GET /api/v1/stocks/:ticker/financials/balance
+// With ?mode=common_size each period also carries its amounts as a fraction
+// of total assets.
 func (h *FinancialsHandler) GetBalanceSheets(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
+	commonSize, ok := parseCommonSizeMode(c)
+	if !ok {
+		return
+	}
+
+	withDeltas, ok := parseWithDeltas(c)
+	if !ok {
+		return
+	}
+
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial statements service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
 		return
 	}
 
 	timeframe, limit, _, _ := parseFinancialsParams(c)
 
-	response, err := h.service.GetBalanceSheets(c.Request.Context(), ticker, timeframe, limit)
+	response, err := h.service.GetBalanceSheets(c.Request.Context(), ticker, timeframe, deltaFetchLimit(timeframe, limit, withDeltas))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Financial data not found",
-			"message": err.Error(),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", err.Error())
 		return
 	}
 
+	if withDeltas {
+		addDeltas(response, limit)
+	}
+	if commonSize {
+		addCommonSize(response)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data": response,
 		"meta": gin.H{
@@ -130,23 +213,21 @@ func (h *FinancialsHandler) GetBalanceSheets(c *gin.Context) {
 func (h *FinancialsHandler) GetCashFlowStatements(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
+	withDeltas, ok := parseWithDeltas(c)
+	if !ok {
+		return
+	}
+
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial statements service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
 		return
 	}
 
 	timeframe, limit, _, _ := parseFinancialsParams(c)
 
-	response, err := h.service.GetCashFlowStatements(c.Request.Context(), ticker, timeframe, limit)
+	response, err := h.service.GetCashFlowStatements(c.Request.Context(), ticker, timeframe, deltaFetchLimit(timeframe, limit, withDeltas))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Financial data not found",
-			"message": err.Error(),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", err.Error())
 		return
 	}
 
@@ -154,6 +235,9 @@ func (h *FinancialsHandler) GetCashFlowStatements(c *gin.Context) {
 	for i := range response.Periods {
 		response.Periods[i].Data = services.EnrichCashFlowData(response.Periods[i].Data)
 	}
+	if withDeltas {
+		addDeltas(response, limit)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data": response,
@@ -168,10 +252,7 @@ func (h *FinancialsHandler) GetRatios(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial statements service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
 		return
 	}
 
@@ -179,11 +260,7 @@ func (h *FinancialsHandler) GetRatios(c *gin.Context) {
 
 	response, err := h.service.GetRatios(c.Request.Context(), ticker, timeframe, limit)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Financial data not found",
-			"message": err.Error(),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", err.Error())
 		return
 	}
 
@@ -195,25 +272,91 @@ func (h *FinancialsHandler) GetRatios(c *gin.Context) {
 	})
 }
 
+// GetTTMFinancials handles GET /api/v1/stocks/:ticker/financials/ttm
+// Returns income statement and cash flow items summed over the latest four
+// consecutive quarters, with the latest balance sheet. Responds 422 naming
+// the missing quarter when four consecutive quarters aren't on file.
+func (h *FinancialsHandler) GetTTMFinancials(c *gin.Context) {
+	ticker := strings.ToUpper(c.Param("ticker"))
+
+	if database.DB == nil {
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
+		return
+	}
+
+	ttm, err := h.service.GetTTMFromStatements(c.Request.Context(), ticker)
+	if err != nil {
+		var gap *database.TTMGapError
+		if errors.As(err, &gap) {
+			respondError(c, http.StatusUnprocessableEntity, httputil.CodeUnprocessable, "Not enough consecutive quarters for a TTM rollup", err.Error())
+			return
+		}
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": ttm,
+		"meta": gin.H{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+// GetFinancialsCoverage handles GET /api/v1/stocks/:ticker/financials/coverage
+// Reports which statement types and timeframes are stored for the ticker,
+// with their period range, count and last refresh, the IC Score ratio
+// history, and whether FMP had TTM ratios for it at the last fundamentals
+// refresh. Nothing is ingested and FMP isn't called.
+func (h *FinancialsHandler) GetFinancialsCoverage(c *gin.Context) {
+	ticker := strings.ToUpper(c.Param("ticker"))
+
+	if database.DB == nil {
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
+		return
+	}
+
+	coverage, err := database.GetFinancialsCoverage(ticker)
+	if err != nil {
+		if errors.Is(err, database.ErrTickerNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Ticker not found", err.Error())
+			return
+		}
+		log.Printf("Error fetching financials coverage for %s: %v", ticker, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch financials coverage")
+		return
+	}
+
+	// The fundamentals refresh stores FMP's answer, including "no data";
+	// a ticker it hasn't reached yet stays unknown
+	snapshot, err := database.GetFMPSnapshot(ticker, services.FMPEndpointRatiosTTM)
+	if err != nil {
+		log.Printf("Error fetching FMP ratios snapshot for %s: %v", ticker, err)
+	} else if snapshot != nil {
+		available := snapshot.Data != nil
+		coverage.FMPRatiosAvailable = &available
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": coverage,
+		"meta": gin.H{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+}
+
 // RefreshFinancials handles POST /api/v1/stocks/:ticker/financials/refresh
 func (h *FinancialsHandler) RefreshFinancials(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial statements service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
 		return
 	}
 
 	err := h.service.RefreshFinancials(c.Request.Context(), ticker)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to refresh financial data",
-			"message": err.Error(),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to refresh financial data", err.Error())
 		return
 	}
 
@@ -231,28 +374,26 @@ func (h *FinancialsHandler) RefreshFinancials(c *gin.Context) {
 func (h *FinancialsHandler) GetAllFinancials(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
+	withDeltas, ok := parseWithDeltas(c)
+	if !ok {
+		return
+	}
+
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial statements service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
 		return
 	}
 
 	timeframe, limit, _, _ := parseFinancialsParams(c)
 
 	// Fetch all three statement types
-	income, incomeErr := h.service.GetIncomeStatements(c.Request.Context(), ticker, timeframe, limit)
-	balance, balanceErr := h.service.GetBalanceSheets(c.Request.Context(), ticker, timeframe, limit)
-	cashflow, cashflowErr := h.service.GetCashFlowStatements(c.Request.Context(), ticker, timeframe, limit)
+	income, incomeErr := h.service.GetIncomeStatements(c.Request.Context(), ticker, timeframe, deltaFetchLimit(timeframe, limit, withDeltas))
+	balance, balanceErr := h.service.GetBalanceSheets(c.Request.Context(), ticker, timeframe, deltaFetchLimit(timeframe, limit, withDeltas))
+	cashflow, cashflowErr := h.service.GetCashFlowStatements(c.Request.Context(), ticker, timeframe, deltaFetchLimit(timeframe, limit, withDeltas))
 
 	// If all failed, return error
 	if incomeErr != nil && balanceErr != nil && cashflowErr != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Financial data not found",
-			"message": "No financial statements available for this ticker",
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", "No financial statements available for this ticker")
 		return
 	}
 
@@ -263,6 +404,14 @@ func (h *FinancialsHandler) GetAllFinancials(c *gin.Context) {
 		}
 	}
 
+	if withDeltas {
+		for _, response := range []*models.FinancialsResponse{income, balance, cashflow} {
+			if response != nil {
+				addDeltas(response, limit)
+			}
+		}
+	}
+
 	// Get metadata from the first successful response
 	var metadata models.FinancialsMetadata
 	if income != nil {
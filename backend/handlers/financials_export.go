@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// financialsExportLimit is how many periods of each statement an export
+// fetches before the year range is applied: 40 years or 10 years of quarters
+const financialsExportLimit = 40
+
+// financialStatementExport describes one statement in an export: its sheet
+// name, CSV file name, and the line items listed first, in reading order.
+// Line items not listed follow alphabetically.
+type financialStatementExport struct {
+	statementType models.StatementType
+	sheetName     string
+	fileName      string
+	lineItemOrder []string
+}
+
+var financialStatementExports = []financialStatementExport{
+	{models.StatementTypeIncome, "Income Statement", "income_statement.csv", []string{
+		"revenue", "revenues", "cost_of_revenue", "gross_profit", "operating_expenses", "operating_income",
+		"net_income", "basic_earnings_per_share", "diluted_earnings_per_share",
+		"gross_margin", "operating_margin", "net_margin",
+	}},
+	{models.StatementTypeBalanceSheet, "Balance Sheet", "balance_sheet.csv", []string{
+		"cash_and_cash_equivalents", "total_assets", "short_term_debt", "long_term_debt",
+		"total_liabilities", "stockholders_equity",
+		"current_ratio", "debt_to_equity", "return_on_equity", "return_on_assets",
+	}},
+	{models.StatementTypeCashFlow, "Cash Flow", "cash_flow.csv", []string{
+		"net_cash_flow_from_operating_activities", "net_cash_flow_from_investing_activities",
+		"net_cash_flow_from_financing_activities", "capital_expenditure", "free_cash_flow",
+		"shares_outstanding",
+	}},
+}
+
+// financialsTable is one statement laid out for a spreadsheet: periods as
+// columns, oldest first, and line items as rows
+type financialsTable struct {
+	export  financialStatementExport
+	periods []string
+	items   []string
+	values  map[string][]*float64 // line item → value per period
+}
+
+// ExportFinancials handles GET /api/v1/stocks/:ticker/financials/export
+// Downloads the income statement, balance sheet and cash flow statement as
+// an Excel workbook with one sheet per statement (format=xlsx, default) or a
+// zip of three CSVs (format=csv). Query params: timeframe=annual (default)
+// or quarterly, and from_year/to_year to limit the fiscal years included.
+func (h *FinancialsHandler) ExportFinancials(c *gin.Context) {
+	ticker := strings.ToUpper(c.Param("ticker"))
+
+	format := strings.ToLower(c.DefaultQuery("format", "xlsx"))
+	if format != "xlsx" && format != "csv" {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "format must be xlsx or csv")
+		return
+	}
+
+	var timeframe models.Timeframe
+	switch strings.ToLower(c.DefaultQuery("timeframe", "annual")) {
+	case "annual":
+		timeframe = models.TimeframeAnnual
+	case "quarterly":
+		timeframe = models.TimeframeQuarterly
+	default:
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "timeframe must be annual or quarterly")
+		return
+	}
+
+	fromYear, toYear, err := parseExportYearRange(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if database.DB == nil {
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial statements service is temporarily unavailable")
+		return
+	}
+
+	var tables []financialsTable
+	for _, export := range financialStatementExports {
+		response, err := h.fetchStatement(c, ticker, export.statementType, timeframe)
+		if err != nil {
+			log.Printf("Financials export: no %s for %s: %v", export.statementType, ticker, err)
+			continue
+		}
+		table := buildFinancialsTable(export, response.Periods, timeframe, fromYear, toYear)
+		if len(table.periods) > 0 {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", "No financial statements available for this ticker and year range")
+		return
+	}
+
+	var buf bytes.Buffer
+	filename := fmt.Sprintf("%s-financials-%s", ticker, timeframe)
+	contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	if format == "csv" {
+		filename += ".zip"
+		contentType = "application/zip"
+		err = writeFinancialsCSVZip(&buf, tables)
+	} else {
+		filename += ".xlsx"
+		err = writeFinancialsXLSX(&buf, tables)
+	}
+	if err != nil {
+		log.Printf("Financials export failed for %s: %v", ticker, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to build export")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// fetchStatement loads one statement type, enriching cash flows with the
+// same calculated fields the JSON endpoints return
+func (h *FinancialsHandler) fetchStatement(c *gin.Context, ticker string, statementType models.StatementType, timeframe models.Timeframe) (*models.FinancialsResponse, error) {
+	ctx := c.Request.Context()
+	switch statementType {
+	case models.StatementTypeBalanceSheet:
+		return h.service.GetBalanceSheets(ctx, ticker, timeframe, financialsExportLimit)
+	case models.StatementTypeCashFlow:
+		response, err := h.service.GetCashFlowStatements(ctx, ticker, timeframe, financialsExportLimit)
+		if err != nil {
+			return nil, err
+		}
+		for i := range response.Periods {
+			response.Periods[i].Data = services.EnrichCashFlowData(response.Periods[i].Data)
+		}
+		return response, nil
+	default:
+		return h.service.GetIncomeStatements(ctx, ticker, timeframe, financialsExportLimit)
+	}
+}
+
+// parseExportYearRange reads the optional from_year and to_year fiscal year
+// bounds; zero means unbounded
+func parseExportYearRange(c *gin.Context) (fromYear, toYear int, err error) {
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"from_year", &fromYear}, {"to_year", &toYear}} {
+		raw := c.Query(p.name)
+		if raw == "" {
+			continue
+		}
+		year, err := strconv.Atoi(raw)
+		if err != nil || year < 1900 || year > 2100 {
+			return 0, 0, fmt.Errorf("%s must be a four-digit year", p.name)
+		}
+		*p.value = year
+	}
+	if fromYear > 0 && toYear > 0 && fromYear > toYear {
+		return 0, 0, fmt.Errorf("from_year must not be after to_year")
+	}
+	return fromYear, toYear, nil
+}
+
+// buildFinancialsTable lays out periods within [fromYear, toYear] (zero
+// bounds are open) oldest first, with a row per line item any period has
+func buildFinancialsTable(export financialStatementExport, periods []models.FinancialPeriod, timeframe models.Timeframe, fromYear, toYear int) financialsTable {
+	var kept []models.FinancialPeriod
+	for _, p := range periods {
+		if (fromYear > 0 && p.FiscalYear < fromYear) || (toYear > 0 && p.FiscalYear > toYear) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].FiscalYear != kept[j].FiscalYear {
+			return kept[i].FiscalYear < kept[j].FiscalYear
+		}
+		return kept[i].PeriodEnd < kept[j].PeriodEnd
+	})
+
+	table := financialsTable{export: export, values: make(map[string][]*float64)}
+	for i, p := range kept {
+		table.periods = append(table.periods, financialsPeriodLabel(p, timeframe))
+		for key, raw := range p.Data {
+			value, ok := services.FinancialAmount(raw)
+			if !ok {
+				continue
+			}
+			if _, seen := table.values[key]; !seen {
+				table.values[key] = make([]*float64, len(kept))
+			}
+			table.values[key][i] = &value
+		}
+	}
+
+	rank := make(map[string]int, len(export.lineItemOrder))
+	for i, key := range export.lineItemOrder {
+		rank[key] = i + 1
+	}
+	for key := range table.values {
+		table.items = append(table.items, key)
+	}
+	sort.Slice(table.items, func(i, j int) bool {
+		ri, rj := rank[table.items[i]], rank[table.items[j]]
+		switch {
+		case ri > 0 && rj > 0:
+			return ri < rj
+		case ri > 0 || rj > 0:
+			return ri > 0
+		default:
+			return table.items[i] < table.items[j]
+		}
+	})
+	return table
+}
+
+// financialsPeriodLabel names a period column: FY2024, or Q3 FY2024 for
+// quarterly statements
+func financialsPeriodLabel(p models.FinancialPeriod, timeframe models.Timeframe) string {
+	if timeframe == models.TimeframeQuarterly && p.FiscalQuarter != nil {
+		return fmt.Sprintf("Q%d FY%d", *p.FiscalQuarter, p.FiscalYear)
+	}
+	return fmt.Sprintf("FY%d", p.FiscalYear)
+}
+
+// financialsLineItemLabel turns a line item key into a row label:
+// net_cash_flow_from_operating_activities → Net Cash Flow From Operating Activities
+func financialsLineItemLabel(key string) string {
+	words := strings.Split(key, "_")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// rows returns the table as a header row of period labels followed by one
+// row per line item, for both the XLSX and CSV writers
+func (t financialsTable) rows() [][]interface{} {
+	header := []interface{}{"Line Item"}
+	for _, p := range t.periods {
+		header = append(header, p)
+	}
+	rows := [][]interface{}{header}
+	for _, key := range t.items {
+		row := []interface{}{financialsLineItemLabel(key)}
+		for _, v := range t.values[key] {
+			if v == nil {
+				row = append(row, nil)
+			} else {
+				row = append(row, *v)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeFinancialsXLSX(buf *bytes.Buffer, tables []financialsTable) error {
+	sheets := make([]xlsxSheet, len(tables))
+	for i, t := range tables {
+		sheets[i] = xlsxSheet{name: t.export.sheetName, rows: t.rows()}
+	}
+	return writeXLSX(buf, sheets)
+}
+
+func writeFinancialsCSVZip(buf *bytes.Buffer, tables []financialsTable) error {
+	zw := zip.NewWriter(buf)
+	for _, t := range tables {
+		f, err := zw.Create(t.export.fileName)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", t.export.fileName, err)
+		}
+		w := csv.NewWriter(f)
+		for _, row := range t.rows() {
+			record := make([]string, len(row))
+			for i, cell := range row {
+				switch v := cell.(type) {
+				case nil:
+				case float64:
+					record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+				default:
+					record[i] = fmt.Sprint(v)
+				}
+			}
+			if err := w.Write(record); err != nil {
+				return fmt.Errorf("failed to write %s: %w", t.export.fileName, err)
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", t.export.fileName, err)
+		}
+	}
+	return zw.Close()
+}
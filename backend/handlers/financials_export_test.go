@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+func intPtr(v int) *int { return &v }
+
+// readZip returns the files in a zip archive by name
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestBuildFinancialsTable(t *testing.T) {
+	periods := []models.FinancialPeriod{
+		{FiscalYear: 2024, FiscalQuarter: intPtr(2), PeriodEnd: "2024-06-30", Data: map[string]interface{}{
+			"revenue": 120.0, "net_income": 30.0, "zeta_item": 1.0, "note": "text",
+		}},
+		{FiscalYear: 2024, FiscalQuarter: intPtr(1), PeriodEnd: "2024-03-31", Data: map[string]interface{}{
+			"revenue": int64(100), "gross_profit": map[string]interface{}{"value": 60.0}, "alpha_item": 2.0,
+		}},
+		{FiscalYear: 2022, FiscalQuarter: intPtr(4), PeriodEnd: "2022-12-31", Data: map[string]interface{}{"revenue": 90.0}},
+	}
+
+	table := buildFinancialsTable(financialStatementExports[0], periods, models.TimeframeQuarterly, 2023, 0)
+
+	assert.Equal(t, []string{"Q1 FY2024", "Q2 FY2024"}, table.periods, "oldest first, outside the year range dropped")
+	assert.Equal(t, []string{"revenue", "gross_profit", "net_income", "alpha_item", "zeta_item"}, table.items,
+		"known line items in statement order, then the rest alphabetically; non-numeric values skipped")
+	assert.Equal(t, 100.0, *table.values["revenue"][0])
+	assert.Equal(t, 120.0, *table.values["revenue"][1])
+	assert.Equal(t, 60.0, *table.values["gross_profit"][0])
+	assert.Nil(t, table.values["net_income"][0], "missing values stay empty")
+
+	annual := buildFinancialsTable(financialStatementExports[0], periods, models.TimeframeAnnual, 0, 2022)
+	assert.Equal(t, []string{"FY2022"}, annual.periods)
+}
+
+func TestFinancialsLineItemLabel(t *testing.T) {
+	assert.Equal(t, "Net Cash Flow From Operating Activities", financialsLineItemLabel("net_cash_flow_from_operating_activities"))
+	assert.Equal(t, "Revenue", financialsLineItemLabel("revenue"))
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 1: "B", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, xlsxColumnName(col))
+	}
+}
+
+func TestWriteFinancialsXLSX(t *testing.T) {
+	income := buildFinancialsTable(financialStatementExports[0], []models.FinancialPeriod{
+		{FiscalYear: 2023, Data: map[string]interface{}{"revenue": 383285000000.0, "diluted_earnings_per_share": 6.13}},
+	}, models.TimeframeAnnual, 0, 0)
+	balance := buildFinancialsTable(financialStatementExports[1], []models.FinancialPeriod{
+		{FiscalYear: 2023, Data: map[string]interface{}{"total_assets": 352583000000.0}},
+	}, models.TimeframeAnnual, 0, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeFinancialsXLSX(&buf, []financialsTable{income, balance}))
+	files := readZip(t, buf.Bytes())
+
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(t, files, part)
+	}
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Income Statement" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Balance Sheet" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, files["[Content_Types].xml"], `/xl/worksheets/sheet2.xml`)
+	assert.Contains(t, files["xl/_rels/workbook.xml.rels"], `Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" s="1" t="inlineStr"><is><t>Line Item</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B1" s="1" t="inlineStr"><is><t>FY2023</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t>Revenue</t></is></c><c r="B2"><v>383285000000</v></c>`)
+	assert.Contains(t, sheet, `<c r="B3"><v>6.13</v></c>`)
+	assert.Contains(t, files["xl/worksheets/sheet2.xml"], `<v>352583000000</v>`)
+}
+
+func TestXLSXSheetXML_EscapesText(t *testing.T) {
+	sheet := xlsxSheetXML(xlsxSheet{name: "S", rows: [][]interface{}{{"R&D <total>", nil, 1.5}}})
+	assert.Contains(t, sheet, `<t>R&amp;D &lt;total&gt;</t>`)
+	assert.NotContains(t, sheet, `r="B1"`, "nil cells are left out")
+	assert.Contains(t, sheet, `<c r="C1" s="1"><v>1.5</v></c>`)
+}
+
+func TestWriteFinancialsCSVZip(t *testing.T) {
+	cashflow := buildFinancialsTable(financialStatementExports[2], []models.FinancialPeriod{
+		{FiscalYear: 2022, Data: map[string]interface{}{"free_cash_flow": 100.5}},
+		{FiscalYear: 2023, Data: map[string]interface{}{"free_cash_flow": 120.0, "capital_expenditure": -10.0}},
+	}, models.TimeframeAnnual, 0, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeFinancialsCSVZip(&buf, []financialsTable{cashflow}))
+	files := readZip(t, buf.Bytes())
+
+	require.Contains(t, files, "cash_flow.csv")
+	records, err := csv.NewReader(strings.NewReader(files["cash_flow.csv"])).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Line Item", "FY2022", "FY2023"},
+		{"Capital Expenditure", "", "-10"},
+		{"Free Cash Flow", "100.5", "120"},
+	}, records)
+}
+
+func TestExportFinancials_Validation(t *testing.T) {
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/export", NewFinancialsHandler().ExportFinancials)
+
+	for _, query := range []string{"format=pdf", "timeframe=ttm", "from_year=abc", "from_year=2024&to_year=2020"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/export?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestExportFinancials_NilDB(t *testing.T) {
+	origDB := getDatabaseDB()
+	setDatabaseDBNil()
+	defer restoreDatabaseDB(origDB)
+
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/export", NewFinancialsHandler().ExportFinancials)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/export", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestExportFinancials_Annual(t *testing.T) {
+	icScore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("statement_type") {
+		case "income":
+			json.NewEncoder(w).Encode(map[string]interface{}{"periods": []map[string]interface{}{
+				{"fiscal_year": 2023, "period_end_date": "2023-09-30", "revenue": 383285000000},
+				{"fiscal_year": 2020, "period_end_date": "2020-09-30", "revenue": 274515000000},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer icScore.Close()
+	t.Setenv("IC_SCORE_API_URL", icScore.URL)
+
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/export", NewFinancialsHandler().ExportFinancials)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/aapl/financials/export?from_year=2021", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="AAPL-financials-annual.xlsx"`, w.Header().Get("Content-Disposition"))
+
+	files := readZip(t, w.Body.Bytes())
+	assert.Contains(t, files["xl/workbook.xml"], "Income Statement")
+	assert.NotContains(t, files["xl/workbook.xml"], "Balance Sheet", "statements without data are left out")
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], "<v>383285000000</v>")
+	assert.NotContains(t, files["xl/worksheets/sheet1.xml"], "FY2020")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/export?format=csv", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Contains(t, readZip(t, w.Body.Bytes()), "income_statement.csv")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/export?to_year=2010", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
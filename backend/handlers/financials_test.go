@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"investorcenter-api/database"
 	"investorcenter-api/models"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // ---------------------------------------------------------------------------
@@ -330,3 +337,311 @@ func TestFinancialsHandler_GetAllFinancials_NoDB(t *testing.T) {
 
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 }
+
+// ---------------------------------------------------------------------------
+// ?mode=common_size
+// ---------------------------------------------------------------------------
+
+func TestFinancialsHandler_CommonSize(t *testing.T) {
+	icScore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var periods []map[string]interface{}
+		switch r.URL.Query().Get("statement_type") {
+		case "income":
+			periods = []map[string]interface{}{
+				{"fiscal_year": 2024, "period_end_date": "2024-09-30", "revenue": 400.0, "net_income": 100.0, "gross_margin": 0.45},
+				{"fiscal_year": 2023, "period_end_date": "2023-09-30", "net_income": 90.0},
+			}
+		case "balance":
+			periods = []map[string]interface{}{
+				{"fiscal_year": 2024, "period_end_date": "2024-09-30", "total_assets": 1000.0, "total_liabilities": 600.0},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"periods": periods})
+	}))
+	defer icScore.Close()
+	t.Setenv("IC_SCORE_API_URL", icScore.URL)
+
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+	handler := NewFinancialsHandler()
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/income", handler.GetIncomeStatements)
+	r.GET("/stocks/:ticker/financials/balance", handler.GetBalanceSheets)
+
+	get := func(path string) []models.FinancialPeriod {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data models.FinancialsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data.Periods
+	}
+
+	income := get("/stocks/AAPL/financials/income?timeframe=annual&mode=common_size")
+	require.Len(t, income, 2)
+	assert.Equal(t, 400.0, income[0].Data["revenue"], "absolute amounts are still returned")
+	assert.InDelta(t, 1.0, *income[0].CommonSize["revenue"], 1e-9)
+	assert.InDelta(t, 0.25, *income[0].CommonSize["net_income"], 1e-9)
+	assert.NotContains(t, income[0].CommonSize, "gross_margin", "ratios aren't amounts")
+	require.Contains(t, income[1].CommonSize, "net_income")
+	assert.Nil(t, income[1].CommonSize["net_income"], "no revenue, no ratio")
+
+	balance := get("/stocks/AAPL/financials/balance?timeframe=annual&mode=common_size")
+	require.Len(t, balance, 1)
+	assert.InDelta(t, 0.6, *balance[0].CommonSize["total_liabilities"], 1e-9)
+
+	plain := get("/stocks/AAPL/financials/income?timeframe=annual")
+	assert.Nil(t, plain[0].CommonSize, "absolute is the default")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/income?mode=percent", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAddCommonSize_Quarterly(t *testing.T) {
+	q := 2
+	data := map[string]interface{}{"total_assets": map[string]interface{}{"value": 500.0}, "cash_and_cash_equivalents": 50.0}
+	response := &models.FinancialsResponse{
+		StatementType: models.StatementTypeBalanceSheet,
+		Timeframe:     models.TimeframeQuarterly,
+		Periods:       []models.FinancialPeriod{{FiscalYear: 2024, FiscalQuarter: &q, Data: data}},
+	}
+
+	addCommonSize(response)
+
+	assert.InDelta(t, 0.1, *response.Periods[0].CommonSize["cash_and_cash_equivalents"], 1e-9)
+	assert.InDelta(t, 1.0, *response.Periods[0].CommonSize["total_assets"], 1e-9)
+	assert.Equal(t, 50.0, data["cash_and_cash_equivalents"], "stored data is not modified")
+	assert.Len(t, data, 2)
+}
+
+// ---------------------------------------------------------------------------
+// ?with_deltas=true
+// ---------------------------------------------------------------------------
+
+func TestFinancialsHandler_WithDeltas(t *testing.T) {
+	var gotLimit string
+	icScore := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		json.NewEncoder(w).Encode(map[string]interface{}{"periods": []map[string]interface{}{
+			{"fiscal_year": 2024, "period_end_date": "2024-09-30", "revenue": 390.0},
+			{"fiscal_year": 2023, "period_end_date": "2023-09-30", "revenue": 300.0},
+			{"fiscal_year": 2022, "period_end_date": "2022-09-30", "revenue": 400.0},
+		}})
+	}))
+	defer icScore.Close()
+	t.Setenv("IC_SCORE_API_URL", icScore.URL)
+
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/income", NewFinancialsHandler().GetIncomeStatements)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/income?timeframe=annual&limit=2&with_deltas=true", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "3", gotLimit, "one more year is fetched for the oldest period's comparison")
+
+	var resp struct {
+		Data models.FinancialsResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	periods := resp.Data.Periods
+	require.Len(t, periods, 2, "the comparison-only year is not returned")
+	assert.InDelta(t, 90.0, periods[0].Deltas["revenue"].Change, 1e-9)
+	assert.InDelta(t, 0.3, *periods[0].Deltas["revenue"].PercentChange, 1e-9)
+	assert.InDelta(t, -100.0, periods[1].Deltas["revenue"].Change, 1e-9)
+	assert.InDelta(t, -0.25, *periods[1].Deltas["revenue"].PercentChange, 1e-9)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/income?timeframe=annual&limit=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", gotLimit)
+	assert.NotContains(t, w.Body.String(), `"deltas"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/income?with_deltas=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeltaFetchLimit(t *testing.T) {
+	assert.Equal(t, 8, deltaFetchLimit(models.TimeframeQuarterly, 8, false))
+	assert.Equal(t, 12, deltaFetchLimit(models.TimeframeQuarterly, 8, true))
+	assert.Equal(t, 12, deltaFetchLimit(models.TimeframeTTM, 8, true))
+	assert.Equal(t, 6, deltaFetchLimit(models.TimeframeAnnual, 5, true))
+}
+
+// ---------------------------------------------------------------------------
+// FinancialsHandler.GetTTMFinancials
+// ---------------------------------------------------------------------------
+
+func TestFinancialsHandler_GetTTMFinancials(t *testing.T) {
+	columns := []string{
+		"id", "ticker_id", "cik", "statement_type", "timeframe", "fiscal_year", "fiscal_quarter",
+		"period_start", "period_end", "filed_date", "source_filing_url", "source_filing_type",
+		"data", "created_at", "updated_at",
+	}
+	quarters := func(statementType models.StatementType, n int) *sqlmock.Rows {
+		rows := sqlmock.NewRows(columns)
+		end := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < n; i++ {
+			rows.AddRow(i+1, 7, nil, string(statementType), "quarterly", 2024, 4-i, nil, end.AddDate(0, -3*i, 0), nil, nil, nil, []byte(`{"revenues": 25}`), end, end)
+		}
+		return rows
+	}
+	expectQueries := func(mock sqlmock.Sqlmock, incomeQuarters int) {
+		// Ingestion check, then the rollup
+		mock.ExpectQuery("SELECT id FROM tickers").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		mock.ExpectQuery("SELECT id FROM tickers").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectQuery("FROM financial_statements").WillReturnRows(quarters(models.StatementTypeIncome, incomeQuarters))
+		mock.ExpectQuery("FROM financial_statements").WillReturnRows(quarters(models.StatementTypeCashFlow, 4))
+		mock.ExpectQuery("FROM financial_statements").WillReturnRows(quarters(models.StatementTypeBalanceSheet, 1))
+	}
+
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/ttm", NewFinancialsHandler().GetTTMFinancials)
+
+	expectQueries(mock, 4)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/aapl/financials/ttm", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data models.TTMFinancials `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "AAPL", resp.Data.Ticker)
+	assert.Equal(t, 100.0, resp.Data.IncomeStatement["revenues"])
+	assert.Len(t, resp.Data.Quarters, 4)
+
+	expectQueries(mock, 3)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/ttm", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "Q1 FY2024")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// FinancialsHandler.GetFinancialsCoverage
+// ---------------------------------------------------------------------------
+
+func TestFinancialsHandler_GetFinancialsCoverage(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	useFMPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("coverage shouldn't call FMP, got %s", r.URL.Path)
+	})
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/coverage", NewFinancialsHandler().GetFinancialsCoverage)
+
+	refreshed := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT id FROM tickers").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery("FROM financial_statements").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"statement_type", "timeframe", "period_count", "earliest_period", "latest_period", "last_refreshed_at"}).
+			AddRow("balance_sheet", "quarterly", 8, "2023-03-31", "2024-12-31", refreshed).
+			AddRow("income", "quarterly", 12, "2022-03-31", "2024-12-31", refreshed))
+	mock.ExpectQuery("FROM valuation_ratios").
+		WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"period_count", "earliest_period", "latest_period", "last_refreshed_at"}).
+			AddRow(0, nil, nil, nil))
+	expectRatiosSnapshot(mock, []byte(`{"symbol":"AAPL"}`))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/aapl/financials/coverage", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data models.FinancialsCoverage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "AAPL", resp.Data.Ticker)
+	require.Len(t, resp.Data.Statements, 2)
+	income := resp.Data.Statements[1]
+	assert.Equal(t, models.StatementTypeIncome, income.StatementType)
+	assert.Equal(t, 12, income.PeriodCount)
+	assert.Equal(t, "2022-03-31", *income.EarliestPeriod)
+	assert.Equal(t, "2024-12-31", *income.LatestPeriod)
+	assert.True(t, refreshed.Equal(*income.LastRefreshedAt))
+	assert.Equal(t, 0, resp.Data.Ratios.PeriodCount)
+	assert.Nil(t, resp.Data.Ratios.LatestPeriod)
+	require.NotNil(t, resp.Data.FMPRatiosAvailable)
+	assert.True(t, *resp.Data.FMPRatiosAvailable)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectRatiosSnapshot expects the FMP TTM ratios snapshot lookup, returning
+// data (nil for FMP having none)
+func expectRatiosSnapshot(mock sqlmock.Sqlmock, data []byte) {
+	var value driver.Value
+	if data != nil {
+		value = data
+	}
+	mock.ExpectQuery("FROM fmp_snapshots").
+		WithArgs("AAPL", "ratios-ttm").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "endpoint", "data", "refreshed_at"}).
+			AddRow("AAPL", "ratios-ttm", value, time.Now()))
+}
+
+func TestFinancialsHandler_GetFinancialsCoverage_FMPRatios(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		want   *bool
+	}{
+		{"no data at last refresh", func(mock sqlmock.Sqlmock) { expectRatiosSnapshot(mock, nil) }, new(bool)},
+		{"not refreshed yet", func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("FROM fmp_snapshots").WillReturnError(sql.ErrNoRows)
+		}, nil},
+		{"lookup failed", func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("FROM fmp_snapshots").WillReturnError(errors.New("connection reset"))
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup := setupMockDB(t)
+			defer cleanup()
+			r := setupMockRouterNoAuth()
+			r.GET("/stocks/:ticker/financials/coverage", NewFinancialsHandler().GetFinancialsCoverage)
+
+			mock.ExpectQuery("SELECT id FROM tickers").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			mock.ExpectQuery("FROM financial_statements").
+				WillReturnRows(sqlmock.NewRows([]string{"statement_type", "timeframe", "period_count", "earliest_period", "latest_period", "last_refreshed_at"}))
+			mock.ExpectQuery("FROM valuation_ratios").
+				WillReturnRows(sqlmock.NewRows([]string{"period_count", "earliest_period", "latest_period", "last_refreshed_at"}).
+					AddRow(0, nil, nil, nil))
+			tt.expect(mock)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/AAPL/financials/coverage", nil))
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var resp struct {
+				Data models.FinancialsCoverage `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp.Data.FMPRatiosAvailable)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFinancialsHandler_GetFinancialsCoverage_UnknownTicker(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupMockRouterNoAuth()
+	r.GET("/stocks/:ticker/financials/coverage", NewFinancialsHandler().GetFinancialsCoverage)
+
+	mock.ExpectQuery("SELECT id FROM tickers").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stocks/ZZZZ/financials/coverage", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -7,12 +7,12 @@ import (
 	"math"
 	"net/http"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 
@@ -34,35 +34,24 @@ func NewFundamentalsHandler() *FundamentalsHandler {
 func (h *FundamentalsHandler) GetSectorPercentiles(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Sector percentiles are temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Sector percentiles are temporarily unavailable")
 		return
 	}
 
 	// Get stock's sector
 	stock, err := database.GetStockBySymbol(ticker)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Stock not found",
-			"message": fmt.Sprintf("No data available for %s", ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Stock not found", fmt.Sprintf("No data available for %s", ticker))
 		return
 	}
 
 	if stock.Sector == "" {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Sector not available",
-			"message": fmt.Sprintf("No sector classification available for %s", ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Sector not available", fmt.Sprintf("No sector classification available for %s", ticker))
 		return
 	}
 
@@ -88,19 +77,12 @@ func (h *FundamentalsHandler) GetSectorPercentiles(c *gin.Context) {
 
 	if percErr != nil {
 		log.Printf("Error fetching sector percentiles for %s: %v", stock.Sector, percErr)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch sector percentiles",
-			"message": "An error occurred while retrieving sector data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch sector percentiles", "An error occurred while retrieving sector data")
 		return
 	}
 
 	if len(percentiles) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "No percentile data",
-			"message": fmt.Sprintf("No sector percentile data available for sector %s", stock.Sector),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No percentile data", fmt.Sprintf("No sector percentile data available for sector %s", stock.Sector))
 		return
 	}
 
@@ -178,33 +160,21 @@ func (h *FundamentalsHandler) GetSectorPercentiles(c *gin.Context) {
 func (h *FundamentalsHandler) GetStockPeers(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Peer comparison is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Peer comparison is temporarily unavailable")
 		return
 	}
 
-	limit := 5
-	if limitStr := c.DefaultQuery("limit", "5"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 10 {
-			limit = parsed
-		}
-	}
+	limit := parseLimitUpTo(c, 5, 10)
 
 	// Get stock info
 	stock, err := database.GetStockBySymbol(ticker)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Stock not found",
-			"message": fmt.Sprintf("No data available for %s", ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Stock not found", fmt.Sprintf("No data available for %s", ticker))
 		return
 	}
 
@@ -214,11 +184,7 @@ func (h *FundamentalsHandler) GetStockPeers(c *gin.Context) {
 	}
 
 	if marketCap == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Market cap not available",
-			"message": fmt.Sprintf("Market cap data not available for %s, cannot determine peers", ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Market cap not available", fmt.Sprintf("Market cap data not available for %s, cannot determine peers", ticker))
 		return
 	}
 
@@ -238,10 +204,7 @@ func (h *FundamentalsHandler) GetStockPeers(c *gin.Context) {
 		peers, err = database.GetEnrichedSectorPeers(stock.Sector, marketCap, ticker, limit)
 		if err != nil {
 			log.Printf("Error fetching sector peers for %s: %v", ticker, err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to fetch peers",
-				"message": "An error occurred while retrieving peer data",
-			})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch peers", "An error occurred while retrieving peer data")
 			return
 		}
 	}
@@ -343,15 +306,12 @@ func (h *FundamentalsHandler) GetStockPeers(c *gin.Context) {
 func (h *FundamentalsHandler) GetFairValue(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Fair value estimates are temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Fair value estimates are temporarily unavailable")
 		return
 	}
 
@@ -508,26 +468,19 @@ func (h *FundamentalsHandler) GetFairValue(c *gin.Context) {
 func (h *FundamentalsHandler) GetHealthSummary(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Health summary is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Health summary is temporarily unavailable")
 		return
 	}
 
 	// Get stock's sector
 	stock, err := database.GetStockBySymbol(ticker)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Stock not found",
-			"message": fmt.Sprintf("No data available for %s", ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Stock not found", fmt.Sprintf("No data available for %s", ticker))
 		return
 	}
 
@@ -746,7 +699,7 @@ func (h *FundamentalsHandler) GetMetricHistory(c *gin.Context) {
 	metric := strings.ToLower(c.Param("metric"))
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
@@ -758,8 +711,7 @@ func (h *FundamentalsHandler) GetMetricHistory(c *gin.Context) {
 			validMetrics = append(validMetrics, k)
 		}
 		sort.Strings(validMetrics)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":         "Unknown metric",
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Unknown metric", gin.H{
 			"message":       fmt.Sprintf("Metric '%s' is not supported", metric),
 			"valid_metrics": validMetrics,
 		})
@@ -767,54 +719,32 @@ func (h *FundamentalsHandler) GetMetricHistory(c *gin.Context) {
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Metric history is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Metric history is temporarily unavailable")
 		return
 	}
 
 	timeframe := c.DefaultQuery("timeframe", "quarterly")
 	if timeframe != "quarterly" && timeframe != "annual" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid timeframe",
-			"message": "Timeframe must be 'quarterly' or 'annual'",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid timeframe", "Timeframe must be 'quarterly' or 'annual'")
 		return
 	}
 
-	limit := 20
-	if limitStr := c.DefaultQuery("limit", "20"); limitStr != "" {
-		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 40 {
-			limit = parsed
-		}
-	}
+	limit := parseLimitUpTo(c, 20, 40)
 
 	// Fetch metric history
 	rows, err := database.GetMetricHistory(ticker, mapping.StatementType, mapping.FieldName, timeframe, limit)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "No data found",
-				"message": fmt.Sprintf("No %s history available for %s", metric, ticker),
-				"ticker":  ticker,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No data found", fmt.Sprintf("No %s history available for %s", metric, ticker))
 			return
 		}
 		log.Printf("Error fetching metric history for %s/%s: %v", ticker, metric, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch metric history",
-			"message": "An error occurred while retrieving historical data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch metric history", "An error occurred while retrieving historical data")
 		return
 	}
 
 	if len(rows) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "No data found",
-			"message": fmt.Sprintf("No %s history available for %s", metric, ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No data found", fmt.Sprintf("No %s history available for %s", metric, ticker))
 		return
 	}
 
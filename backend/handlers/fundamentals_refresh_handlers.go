@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+// maxFundamentalsRefreshSymbols caps the symbols accepted by one refresh
+// request; FMP sees at most a few batch requests per call.
+const maxFundamentalsRefreshSymbols = 500
+
+// newFundamentalsRefreshService is swapped out in tests to avoid calling FMP.
+var newFundamentalsRefreshService = services.NewFundamentalsRefreshService
+
+// RefreshFundamentals handles POST /api/v1/fundamentals/refresh
+// Fetches FMP TTM ratios and scores for a list of symbols through FMP's
+// batch endpoints and stores them in fmp_snapshots. The response lists the
+// outcome per symbol in request order.
+func RefreshFundamentals(c *gin.Context) {
+	var req models.FundamentalsRefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request", err.Error())
+		return
+	}
+
+	symbols, ok := refreshSymbols(c, req.Symbols, maxFundamentalsRefreshSymbols)
+	if !ok {
+		return
+	}
+
+	results := newFundamentalsRefreshService().Refresh(c.Request.Context(), symbols)
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+	log.Printf("Fundamentals refresh: %d/%d symbols updated", succeeded, len(symbols))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"requested": len(symbols),
+			"succeeded": succeeded,
+			"failed":    len(symbols) - succeeded,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
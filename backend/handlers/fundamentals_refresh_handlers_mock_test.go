@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/services"
+)
+
+type fakeFundamentalsFetcher struct {
+	requested []string
+}
+
+func (f *fakeFundamentalsFetcher) GetFundamentalsBatch(tickers []string) map[string]*services.FMPAllMetrics {
+	f.requested = tickers
+	results := make(map[string]*services.FMPAllMetrics, len(tickers))
+	for _, t := range tickers {
+		results[t] = &services.FMPAllMetrics{
+			RatiosTTM: &services.FMPRatiosTTM{Symbol: t},
+			Score:     &services.FMPScore{Symbol: t},
+			Errors:    map[string]error{},
+		}
+	}
+	return results
+}
+
+type fakeSnapshotStore struct {
+	stored map[string]int
+}
+
+func (s *fakeSnapshotStore) UpsertFMPSnapshots(endpoint string, data map[string]json.RawMessage) error {
+	s.stored[endpoint] = len(data)
+	return nil
+}
+
+func postFundamentalsRefresh(r *gin.Engine, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/fundamentals/refresh", bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRefreshFundamentals(t *testing.T) {
+	fetcher := &fakeFundamentalsFetcher{}
+	store := &fakeSnapshotStore{stored: map[string]int{}}
+	orig := newFundamentalsRefreshService
+	newFundamentalsRefreshService = func() *services.FundamentalsRefreshService {
+		return services.NewFundamentalsRefreshServiceWith(fetcher, store)
+	}
+	t.Cleanup(func() { newFundamentalsRefreshService = orig })
+
+	r := setupMockRouterNoAuth()
+	r.POST("/api/v1/fundamentals/refresh", RefreshFundamentals)
+
+	w := postFundamentalsRefresh(r, map[string]interface{}{"symbols": []string{"aapl", "MSFT", "AAPL"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []services.FundamentalsRefreshResult `json:"data"`
+		Meta map[string]interface{}               `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "AAPL", resp.Data[0].Symbol)
+	assert.True(t, resp.Data[0].Success)
+	assert.Equal(t, float64(2), resp.Meta["succeeded"])
+	assert.Equal(t, []string{"AAPL", "MSFT"}, fetcher.requested, "symbols go to FMP in one batch")
+	assert.Equal(t, map[string]int{"ratios-ttm": 2, "score": 2}, store.stored)
+
+	assert.Equal(t, http.StatusBadRequest, postFundamentalsRefresh(r, map[string]interface{}{"symbols": []string{" "}}).Code)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -83,7 +84,7 @@ func TestCreateHeatmapConfig_Mock_OwnershipFails(t *testing.T) {
 
 	// Ownership check fails
 	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.POST("/watchlists/:id/heatmap/configs", CreateHeatmapConfig)
@@ -104,7 +105,7 @@ func TestCreateHeatmapConfig_Mock_OwnershipFails(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestCreateHeatmapConfig_Mock_DBError(t *testing.T) {
@@ -189,7 +190,7 @@ func TestUpdateHeatmapConfig_Mock_ConfigNotFound(t *testing.T) {
 
 	// GetHeatmapConfigByID returns not found
 	mock.ExpectQuery("SELECT .+ FROM heatmap_configs").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.PUT("/watchlists/:id/heatmap/configs/:configId", UpdateHeatmapConfig)
@@ -352,7 +353,7 @@ func TestDeleteHeatmapConfig_Mock_ConfigNotFound(t *testing.T) {
 
 	// GetHeatmapConfigByID returns not found
 	mock.ExpectQuery("SELECT .+ FROM heatmap_configs").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.DELETE("/watchlists/:id/heatmap/configs/:configId", DeleteHeatmapConfig)
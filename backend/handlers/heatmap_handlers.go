@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/auth"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 )
@@ -14,9 +15,8 @@ var heatmapService = services.NewHeatmapService()
 
 // GetHeatmapData generates heatmap data for a watch list
 func GetHeatmapData(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -35,14 +35,14 @@ func GetHeatmapData(c *gin.Context) {
 
 	// Verify ownership
 	if err := heatmapService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	// Generate heatmap data
 	heatmapData, err := heatmapService.GenerateHeatmapData(watchListID, userID, configID, overrides)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -51,9 +51,8 @@ func GetHeatmapData(c *gin.Context) {
 
 // ListHeatmapConfigs retrieves all configs for a watch list
 func ListHeatmapConfigs(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -61,7 +60,7 @@ func ListHeatmapConfigs(c *gin.Context) {
 
 	// Verify ownership
 	if err := heatmapService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
@@ -77,15 +76,14 @@ func ListHeatmapConfigs(c *gin.Context) {
 
 // CreateHeatmapConfig saves a new heatmap configuration
 func CreateHeatmapConfig(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	var req models.CreateHeatmapConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -93,13 +91,13 @@ func CreateHeatmapConfig(c *gin.Context) {
 
 	// Ensure the watch list ID in the URL matches the request body
 	if req.WatchListID != watchListID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Watch list ID mismatch"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Watch list ID mismatch")
 		return
 	}
 
 	// Verify ownership
 	if err := heatmapService.ValidateWatchListOwnership(req.WatchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
@@ -120,7 +118,7 @@ func CreateHeatmapConfig(c *gin.Context) {
 
 	err := database.CreateHeatmapConfig(config)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create heatmap config"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create heatmap config")
 		return
 	}
 
@@ -129,9 +127,8 @@ func CreateHeatmapConfig(c *gin.Context) {
 
 // UpdateHeatmapConfig updates an existing configuration
 func UpdateHeatmapConfig(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -140,26 +137,26 @@ func UpdateHeatmapConfig(c *gin.Context) {
 
 	var req models.UpdateHeatmapConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Verify ownership of watch list
 	if err := heatmapService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	// Get existing config to verify ownership
 	existingConfig, err := database.GetHeatmapConfigByID(configID, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Heatmap config not found"})
+		respondOwnedLookupError(c, err, "Heatmap config not found", "Failed to fetch heatmap config")
 		return
 	}
 
 	// Verify the config belongs to the specified watch list
 	if existingConfig.WatchListID != watchListID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Config does not belong to this watch list"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Config does not belong to this watch list")
 		return
 	}
 
@@ -195,7 +192,7 @@ func UpdateHeatmapConfig(c *gin.Context) {
 
 	err = database.UpdateHeatmapConfig(existingConfig)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update heatmap config"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update heatmap config")
 		return
 	}
 
@@ -204,9 +201,8 @@ func UpdateHeatmapConfig(c *gin.Context) {
 
 // DeleteHeatmapConfig deletes a configuration
 func DeleteHeatmapConfig(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -215,25 +211,25 @@ func DeleteHeatmapConfig(c *gin.Context) {
 
 	// Verify ownership of watch list
 	if err := heatmapService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	// Get config to verify it belongs to the watch list
 	config, err := database.GetHeatmapConfigByID(configID, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Heatmap config not found"})
+		respondOwnedLookupError(c, err, "Heatmap config not found", "Failed to fetch heatmap config")
 		return
 	}
 
 	if config.WatchListID != watchListID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Config does not belong to this watch list"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Config does not belong to this watch list")
 		return
 	}
 
 	err = database.DeleteHeatmapConfig(configID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
 
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -35,7 +36,7 @@ func TestGetHeatmapData_Mock_OwnershipFails(t *testing.T) {
 
 	// ValidateWatchListOwnership fails
 	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.GET("/watchlists/:id/heatmap", GetHeatmapData)
@@ -44,7 +45,7 @@ func TestGetHeatmapData_Mock_OwnershipFails(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/watchlists/wl-other/heatmap", nil)
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 // ---------------------------------------------------------------------------
@@ -70,7 +71,7 @@ func TestListHeatmapConfigs_Mock_OwnershipFails(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.GET("/watchlists/:id/heatmap/configs", ListHeatmapConfigs)
@@ -79,16 +80,15 @@ func TestListHeatmapConfigs_Mock_OwnershipFails(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/watchlists/wl-other/heatmap/configs", nil)
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestListHeatmapConfigs_Mock_DBError(t *testing.T) {
 	mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
-	// Ownership passes
 	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(fmt.Errorf("connection refused"))
 
 	r := setupMockRouter("user-1")
 	r.GET("/watchlists/:id/heatmap/configs", ListHeatmapConfigs)
@@ -97,8 +97,8 @@ func TestListHeatmapConfigs_Mock_DBError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/watchlists/wl-1/heatmap/configs", nil)
 	r.ServeHTTP(w, req)
 
-	// Ownership fails → 403
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	// A failed ownership lookup isn't reported as not found
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
 // ---------------------------------------------------------------------------
@@ -199,7 +199,7 @@ func TestUpdateHeatmapConfig_Mock_OwnershipFails(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.PUT("/watchlists/:id/heatmap/configs/:configId", UpdateHeatmapConfig)
@@ -219,7 +219,7 @@ func TestUpdateHeatmapConfig_Mock_OwnershipFails(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 // ---------------------------------------------------------------------------
@@ -245,7 +245,7 @@ func TestDeleteHeatmapConfig_Mock_OwnershipFails(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id").
-		WillReturnError(fmt.Errorf("not found"))
+		WillReturnError(sql.ErrNoRows)
 
 	r := setupMockRouter("user-1")
 	r.DELETE("/watchlists/:id/heatmap/configs/:configId", DeleteHeatmapConfig)
@@ -254,5 +254,5 @@ func TestDeleteHeatmapConfig_Mock_OwnershipFails(t *testing.T) {
 	req := httptest.NewRequest(http.MethodDelete, "/watchlists/wl-1/heatmap/configs/cfg-1", nil)
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 
@@ -32,16 +33,13 @@ func GetICScore(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "IC Score service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "IC Score service is temporarily unavailable")
 		return
 	}
 
@@ -64,18 +62,11 @@ func GetICScore(c *gin.Context) {
 	err := database.DB.Get(&icScore, query, ticker)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "IC Score not found",
-				"message": fmt.Sprintf("No IC Score available for %s. Score calculation may not have been run yet.", ticker),
-				"ticker":  ticker,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "IC Score not found", fmt.Sprintf("No IC Score available for %s. Score calculation may not have been run yet.", ticker))
 			return
 		}
 		log.Printf("Error fetching IC Score for %s: %v", ticker, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch IC Score",
-			"message": "An error occurred while retrieving the IC Score",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch IC Score", "An error occurred while retrieving the IC Score")
 		return
 	}
 
@@ -96,28 +87,17 @@ func GetICScore(c *gin.Context) {
 func GetICScores(c *gin.Context) {
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "IC Score service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "IC Score service is temporarily unavailable")
 		return
 	}
 
 	// Parse query parameters
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit := parseLimitUpTo(c, 20, 100)
+	offset := parseOffset(c)
 	search := strings.ToUpper(c.DefaultQuery("search", ""))
 	sort := c.DefaultQuery("sort", "overall_score")
 	order := c.DefaultQuery("order", "desc")
 
-	// Validate limit
-	if limit > 100 {
-		limit = 100
-	}
-	if limit < 1 {
-		limit = 20
-	}
-
 	// Validate sort column
 	validSortColumns := map[string]bool{
 		"ticker":            true,
@@ -169,10 +149,7 @@ func GetICScores(c *gin.Context) {
 	err := database.DB.Select(&scores, query, args...)
 	if err != nil {
 		log.Printf("Error fetching IC Scores: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch IC Scores",
-			"message": "An error occurred while retrieving IC Scores",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch IC Scores", "An error occurred while retrieving IC Scores")
 		return
 	}
 
@@ -224,16 +201,13 @@ func GetFinancialMetrics(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Financial metrics service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Financial metrics service is temporarily unavailable")
 		return
 	}
 
@@ -309,18 +283,11 @@ func GetFinancialMetrics(c *gin.Context) {
 	// If both FMP and DB failed, return error
 	if fmpData == nil && !dbHasData {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Financial data not found",
-				"message": fmt.Sprintf("No financial data available for %s", ticker),
-				"ticker":  ticker,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", fmt.Sprintf("No financial data available for %s", ticker))
 			return
 		}
 		log.Printf("Error fetching financial metrics for %s: %v", ticker, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch financial metrics",
-			"message": "An error occurred while retrieving financial data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch financial metrics", "An error occurred while retrieving financial data")
 		return
 	}
 
@@ -400,7 +367,7 @@ func GetComprehensiveFinancialMetrics(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
@@ -891,11 +858,7 @@ func GetComprehensiveFinancialMetrics(c *gin.Context) {
 
 	// If no data available at all, return error
 	if !merged.FMPAvailable && allMetrics != nil && len(allMetrics.Errors) == 6 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Financial data not found",
-			"message": fmt.Sprintf("No financial data available for %s from FMP", ticker),
-			"ticker":  ticker,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Financial data not found", fmt.Sprintf("No financial data available for %s from FMP", ticker))
 		return
 	}
 
@@ -1077,16 +1040,13 @@ func GetRiskMetrics(c *gin.Context) {
 	period := c.DefaultQuery("period", "1Y")
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Risk metrics service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Risk metrics service is temporarily unavailable")
 		return
 	}
 
@@ -1120,19 +1080,11 @@ func GetRiskMetrics(c *gin.Context) {
 	err := database.DB.Get(&result, query, ticker, period)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Risk metrics not found",
-				"message": fmt.Sprintf("No risk metrics available for %s with period %s", ticker, period),
-				"ticker":  ticker,
-				"period":  period,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Risk metrics not found", fmt.Sprintf("No risk metrics available for %s with period %s", ticker, period))
 			return
 		}
 		log.Printf("Error fetching risk metrics for %s: %v", ticker, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch risk metrics",
-			"message": "An error occurred while retrieving risk data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch risk metrics", "An error occurred while retrieving risk data")
 		return
 	}
 
@@ -1165,16 +1117,13 @@ func GetTechnicalIndicators(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Technical indicators service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Technical indicators service is temporarily unavailable")
 		return
 	}
 
@@ -1231,18 +1180,11 @@ func GetTechnicalIndicators(c *gin.Context) {
 	err := database.DB.Get(&result, query, ticker)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Technical indicators not found",
-				"message": fmt.Sprintf("No technical indicators available for %s", ticker),
-				"ticker":  ticker,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Technical indicators not found", fmt.Sprintf("No technical indicators available for %s", ticker))
 			return
 		}
 		log.Printf("Error fetching technical indicators for %s: %v", ticker, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch technical indicators",
-			"message": "An error occurred while retrieving technical data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch technical indicators", "An error occurred while retrieving technical data")
 		return
 	}
 
@@ -1281,15 +1223,13 @@ func GetICScoreHistory(c *gin.Context) {
 	days, _ := strconv.Atoi(c.DefaultQuery("days", "90"))
 
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Ticker symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Database not available",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -1318,9 +1258,7 @@ func GetICScoreHistory(c *gin.Context) {
 	err := database.DB.Select(&scores, query, ticker, days)
 	if err != nil {
 		log.Printf("Error fetching IC Score history for %s: %v", ticker, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch IC Score history",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch IC Score history")
 		return
 	}
 
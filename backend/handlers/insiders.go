@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/services"
+)
+
+const (
+	defaultInsiderTradesLimit = 50
+	maxInsiderTradesLimit     = 200
+
+	// insiderActivityWindow is the lookback of the net-activity summary
+	insiderActivityWindow = 90 * 24 * time.Hour
+)
+
+// GetTickerInsiders handles GET /api/v1/tickers/:symbol/insiders
+// Returns the ticker's insider transactions, newest first, paginated with
+// ?limit= (default 50, max 200) and ?offset=, filtered by ?type=buy|sell and
+// ?since=YYYY-MM-DD. The summary nets open-market buys against sells over the
+// last 90 days, overall and by insider role, regardless of the filters.
+func GetTickerInsiders(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	if !validTickerRe.MatchString(symbol) {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid ticker symbol")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultInsiderTradesLimit)))
+	if err != nil || limit < 1 || limit > maxInsiderTradesLimit {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxInsiderTradesLimit))
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "offset must be a non-negative integer")
+		return
+	}
+
+	var filter database.InsiderTradeFilter
+	switch txType := strings.ToLower(c.Query("type")); txType {
+	case "":
+	case "buy":
+		filter.TransactionTypes = database.InsiderBuyTypes
+	case "sell":
+		filter.TransactionTypes = database.InsiderSellTypes
+	default:
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "type must be buy or sell")
+		return
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "since must be a date in YYYY-MM-DD format")
+			return
+		}
+		filter.Since = &since
+	}
+
+	trades, total, err := database.GetInsiderTrades(symbol, filter, limit, offset)
+	if err != nil {
+		log.Printf("Error fetching insider trades for %s: %v", symbol, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch insider trades", err.Error())
+		return
+	}
+
+	summarySince := time.Now().UTC().Add(-insiderActivityWindow)
+	activity, err := database.GetInsiderActivity(symbol, summarySince)
+	if err != nil {
+		log.Printf("Error fetching insider activity for %s: %v", symbol, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch insider activity", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"transactions": trades,
+			"summary":      services.SummarizeInsiderActivity(activity, summarySince),
+		},
+		"meta": gin.H{
+			"ticker":    symbol,
+			"count":     len(trades),
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
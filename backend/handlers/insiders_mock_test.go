@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var insiderTradeCols = []string{
+	"id", "ticker", "filing_date", "transaction_date", "insider_name", "insider_title",
+	"transaction_type", "shares", "price_per_share", "total_value", "shares_owned_after",
+	"is_derivative", "form_type", "sec_filing_url",
+}
+
+var insiderActivityCols = []string{"insider_title", "direction", "transactions", "shares", "value"}
+
+func getTickerInsiders(path string) *httptest.ResponseRecorder {
+	r := setupMockRouterNoAuth()
+	r.GET("/tickers/:symbol/insiders", GetTickerInsiders)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestGetTickerInsiders_Mock_TransactionsAndSummary(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	day := time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM insider_trades WHERE ticker = \$1$`).
+		WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery("SELECT id, ticker, filing_date").
+		WithArgs("AAPL", 2, 0).
+		WillReturnRows(sqlmock.NewRows(insiderTradeCols).
+			AddRow(2, "AAPL", day, day, "Cook Timothy D", "Chief Executive Officer", "Sale", 50000, 225.5, 11275000, 3280000, false, "4", "https://www.sec.gov/a").
+			AddRow(1, "AAPL", day, day, "Levinson Arthur D", "Director", "Purchase", 1000, nil, nil, nil, false, "4", ""))
+	mock.ExpectQuery("CASE WHEN transaction_type = ANY").
+		WithArgs("AAPL", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(insiderActivityCols).
+			AddRow("Chief Executive Officer", "sell", 1, 50000, 11275000.0).
+			AddRow("Director", "buy", 1, 1000, 220000.0))
+
+	w := getTickerInsiders("/tickers/aapl/insiders?limit=2")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data struct {
+			Transactions []map[string]interface{} `json:"transactions"`
+			Summary      map[string]interface{}   `json:"summary"`
+		} `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Transactions, 2)
+	assert.Equal(t, "Cook Timothy D", resp.Data.Transactions[0]["insider_name"])
+	assert.Equal(t, 225.5, resp.Data.Transactions[0]["price_per_share"])
+	assert.Nil(t, resp.Data.Transactions[1]["total_value"])
+
+	assert.Equal(t, float64(-49000), resp.Data.Summary["net_shares"])
+	assert.Equal(t, float64(-11055000), resp.Data.Summary["net_value"])
+	byRole := resp.Data.Summary["by_role"].([]interface{})
+	require.Len(t, byRole, 2)
+	assert.Equal(t, "CEO", byRole[0].(map[string]interface{})["role"])
+	assert.Equal(t, "Director", byRole[1].(map[string]interface{})["role"])
+
+	assert.Equal(t, float64(12), resp.Meta["total"])
+	assert.Equal(t, float64(2), resp.Meta["count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerInsiders_Mock_TypeAndSinceFilters(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`COUNT\(\*\) FROM insider_trades WHERE ticker = \$1 AND transaction_type = ANY\(\$2\) AND transaction_date >= \$3`).
+		WithArgs("MSFT", `{"Sale","Sell"}`, "2026-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT id, ticker, filing_date").
+		WithArgs("MSFT", `{"Sale","Sell"}`, "2026-01-01", 50, 10).
+		WillReturnRows(sqlmock.NewRows(insiderTradeCols))
+	mock.ExpectQuery("CASE WHEN transaction_type = ANY").
+		WillReturnRows(sqlmock.NewRows(insiderActivityCols))
+
+	w := getTickerInsiders("/tickers/MSFT/insiders?type=SELL&since=2026-01-01&offset=10")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"transactions":[]`)
+	assert.Contains(t, w.Body.String(), `"by_role":[]`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerInsiders_Mock_InvalidParams(t *testing.T) {
+	for _, path := range []string{
+		"/tickers/AAPL/insiders?type=hold",
+		"/tickers/AAPL/insiders?since=01/02/2026",
+		"/tickers/AAPL/insiders?limit=0",
+		"/tickers/AAPL/insiders?limit=201",
+		"/tickers/AAPL/insiders?offset=-1",
+	} {
+		assert.Equal(t, http.StatusBadRequest, getTickerInsiders(path).Code, path)
+	}
+}
+
+func TestGetTickerInsiders_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT COUNT").WillReturnError(fmt.Errorf("connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, getTickerInsiders("/tickers/AAPL/insiders").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"bytes"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -57,15 +58,25 @@ func TestIntegration_Login_FullCycle_Success(t *testing.T) {
 			false, true, false, false, nil,
 		))
 
-	// 2. UpdateLastLogin
+	// 2. GetUserTwoFactor — not enrolled
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WithArgs("user-integ-1").
+		WillReturnError(sql.ErrNoRows)
+
+	// 3. UpdateLastLogin
 	mock.ExpectExec("UPDATE users SET last_login_at").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	// 3. CreateSession
+	// 4. CreateSession
 	mock.ExpectQuery("INSERT INTO sessions").
 		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).
 			AddRow("session-integ-1", now, now))
 
+	// 5. RecordAccountActivity
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WithArgs("user-integ-1", "login", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-integ-1", now))
+
 	// Set up router (no auth middleware needed for login)
 	r := setupMockRouterNoAuth()
 	r.POST("/api/v1/auth/login", Login)
@@ -746,7 +757,7 @@ func TestIntegration_AlertCreate_OwnershipFailing(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 
 	var resp map[string]string
 	json.Unmarshal(w.Body.Bytes(), &resp)
@@ -774,12 +785,8 @@ func TestIntegration_AlertCreate_NoAuth(t *testing.T) {
 	mock, cleanup := setupMockDB(t)
 	defer cleanup()
 
-	// When user_id is empty (no auth), the handler still calls
-	// ValidateWatchListOwnership which calls GetWatchListByID.
-	// The empty user_id will not match any watchlist, so we mock
-	// the query to return not found.
-	mock.ExpectQuery("SELECT .+ FROM watch_lists WHERE id = \\$1 AND user_id = \\$2").
-		WillReturnError(sql.ErrNoRows)
+	// Without an authenticated user the handler rejects the request before
+	// any query runs.
 
 	handler := NewAlertHandler(services.NewAlertService())
 	r := setupMockRouterNoAuth()
@@ -799,13 +806,11 @@ func TestIntegration_AlertCreate_NoAuth(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	r.ServeHTTP(w, req)
 
-	// AlertHandler uses c.GetString("user_id") which returns "" for no-auth.
-	// ValidateWatchListOwnership fails because empty user_id won't match.
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
 	var resp map[string]string
 	json.Unmarshal(w.Body.Bytes(), &resp)
-	assert.Equal(t, "Watch list not found", resp["error"])
+	assert.Equal(t, "Unauthorized", resp["error"])
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -925,3 +930,384 @@ func TestIntegration_WatchlistCRUD_FullLifecycle(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+// ---------------------------------------------------------------------------
+// Integration Test: Alert Export → Import round trip
+// ---------------------------------------------------------------------------
+
+func exportAlertRows(now time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "user_id", "watch_list_id", "watch_list_item_id", "symbol",
+		"alert_type", "conditions", "is_active", "frequency", "notify_email",
+		"notify_in_app", "name", "description", "last_triggered_at",
+		"trigger_count", "created_at", "updated_at", "snoozed_until",
+		"watch_list_name", "company_name",
+	})
+}
+
+func expectImportSetup(mock sqlmock.Sqlmock, now time.Time, existing *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT .+ FROM watch_lists wl").
+		WithArgs("user-b").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "description", "is_default", "created_at", "updated_at", "item_count",
+		}).AddRow("wl-b", "Tech", nil, true, now, now, 2))
+	mock.ExpectQuery("SELECT .+ FROM alert_rules ar").
+		WithArgs("user-b").
+		WillReturnRows(existing)
+	mock.ExpectQuery("FROM user_subscriptions").WillReturnError(fmt.Errorf("no subscription"))
+	mock.ExpectQuery("FROM watch_list_items").
+		WithArgs("wl-b").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "watch_list_id", "symbol", "notes", "tags", "target_buy_price",
+			"target_sell_price", "added_at", "display_order",
+		}).
+			AddRow("item-1", "wl-b", "AAPL", nil, "{}", nil, nil, now, 0).
+			AddRow("item-2", "wl-b", "MSFT", nil, "{}", nil, nil, now, 1))
+}
+
+func postAlertImport(t *testing.T, userID string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	r := setupMockRouter(userID)
+	r.POST("/alerts/import", NewAlertHandler(services.NewAlertService()).ImportAlertRules)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/alerts/import", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIntegration_AlertExportImport_RoundTrip(t *testing.T) {
+	now := time.Now()
+
+	// 1. Export user A's alerts (listed newest first)
+	mock, cleanup := setupMockDB(t)
+	mock.ExpectQuery("SELECT .+ FROM alert_rules ar").
+		WithArgs("user-a").
+		WillReturnRows(exportAlertRows(now).
+			AddRow("alert-2", "user-a", "wl-a", nil, "MSFT",
+				"news", []byte(`{"keywords":["earnings"]}`), false, "daily", false,
+				true, "MSFT news", nil, nil,
+				3, now, now, nil,
+				"Tech", "Microsoft").
+			AddRow("alert-1", "user-a", "wl-a", nil, "AAPL",
+				"price_above", []byte(`{"threshold":200}`), true, "once", true,
+				true, "AAPL above 200", nil, nil,
+				0, now.Add(-time.Hour), now, nil,
+				"Tech", "Apple"))
+
+	r := setupMockRouter("user-a")
+	r.GET("/alerts/export", NewAlertHandler(services.NewAlertService()).ExportAlertRules)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/alerts/export", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "alerts.json")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	cleanup()
+
+	exported := w.Body.Bytes()
+	var export struct {
+		Version int `json:"version"`
+		Alerts  []struct {
+			WatchList string `json:"watch_list"`
+			Symbol    string `json:"symbol"`
+			IsActive  bool   `json:"is_active"`
+		} `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal(exported, &export))
+	assert.Equal(t, 1, export.Version)
+	require.Len(t, export.Alerts, 2)
+	assert.Equal(t, "AAPL", export.Alerts[0].Symbol, "oldest first")
+	assert.Equal(t, "Tech", export.Alerts[0].WatchList)
+	assert.False(t, export.Alerts[1].IsActive)
+
+	// 2. Import into user B, whose "Tech" watch list holds both symbols
+	mock, cleanup = setupMockDB(t)
+	expectImportSetup(mock, now, exportAlertRows(now))
+	mock.ExpectQuery("INSERT INTO alert_rules .+ ON CONFLICT").
+		WithArgs("user-b", "wl-b", nil, "AAPL", "price_above",
+			[]byte(`{"threshold":200}`), true, "once", true, true, "AAPL above 200", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "trigger_count"}).
+			AddRow("alert-b1", now, now, 0))
+	mock.ExpectQuery("INSERT INTO alert_rules .+ ON CONFLICT").
+		WithArgs("user-b", "wl-b", nil, "MSFT", "news",
+			[]byte(`{"keywords":["earnings"]}`), false, "daily", false, true, "MSFT news", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "trigger_count"}).
+			AddRow("alert-b2", now, now, 0))
+
+	w = postAlertImport(t, "user-b", exported)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, float64(2), result["created"])
+	assert.Equal(t, float64(0), result["skipped"])
+	assert.Empty(t, result["failed"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+	cleanup()
+
+	// 3. Importing the same export again creates nothing
+	mock, cleanup = setupMockDB(t)
+	defer cleanup()
+	expectImportSetup(mock, now, exportAlertRows(now).
+		AddRow("alert-b2", "user-b", "wl-b", nil, "MSFT",
+			"news", []byte(`{"keywords":["earnings"]}`), false, "daily", false,
+			true, "MSFT news", nil, nil, 0, now, now, nil, "Tech", "Microsoft").
+		AddRow("alert-b1", "user-b", "wl-b", nil, "AAPL",
+			"price_above", []byte(`{"threshold":200}`), true, "once", true,
+			true, "AAPL above 200", nil, nil, 0, now, now, nil, "Tech", "Apple"))
+
+	w = postAlertImport(t, "user-b", exported)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, float64(0), result["created"])
+	assert.Equal(t, float64(2), result["skipped"])
+	assert.NoError(t, mock.ExpectationsWereMet(), "no alert is inserted twice")
+}
+
+func TestIntegration_AlertImport_ReportsInvalidRulesAndPlanLimit(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+
+	// User B already has the free plan's 10 alerts, none on AAPL
+	existing := exportAlertRows(now)
+	for i := 0; i < 10; i++ {
+		existing.AddRow(fmt.Sprintf("alert-%d", i), "user-b", "wl-b", nil, fmt.Sprintf("SYM%d", i),
+			"news", []byte(`{}`), true, "daily", false,
+			true, "news", nil, nil, 0, now, now, nil, "Tech", "")
+	}
+	expectImportSetup(mock, now, existing)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"version": 1,
+		"alerts": []map[string]interface{}{
+			{"watch_list": "Tech", "symbol": "AAPL", "alert_type": "price_above",
+				"conditions": map[string]interface{}{"threshold": 200}, "name": "AAPL", "frequency": "once", "is_active": true},
+			{"watch_list": "Tech", "symbol": "AAPL", "alert_type": "pe_crosses",
+				"conditions": map[string]interface{}{"threshold": 30, "direction": "sideways"}, "name": "P/E", "frequency": "once"},
+			{"watch_list": "Crypto", "symbol": "BTC", "alert_type": "news",
+				"conditions": map[string]interface{}{}, "name": "BTC news", "frequency": "daily"},
+			{"watch_list": "Tech", "symbol": "TSLA", "alert_type": "news",
+				"conditions": map[string]interface{}{}, "name": "TSLA news", "frequency": "daily"},
+		},
+	})
+
+	w := postAlertImport(t, "user-b", body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result struct {
+		Created int `json:"created"`
+		Failed  []struct {
+			Index int    `json:"index"`
+			Error string `json:"error"`
+		} `json:"failed"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 0, result.Created)
+	require.Len(t, result.Failed, 4)
+	assert.Equal(t, "alert limit reached", result.Failed[0].Error)
+	assert.Contains(t, result.Failed[1].Error, "direction must be above, below or either")
+	assert.Contains(t, result.Failed[2].Error, `watch list "Crypto" not found`)
+	assert.Equal(t, "symbol not found in watch list", result.Failed[3].Error)
+	assert.NoError(t, mock.ExpectationsWereMet(), "nothing is inserted past the plan limit")
+}
+
+func TestIntegration_AlertImport_UnsupportedVersion(t *testing.T) {
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	w := postAlertImport(t, "user-b", []byte(`{"version": 2, "alerts": []}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unsupported export version 2")
+}
+
+// ---------------------------------------------------------------------------
+// Integration Test: Account Activity
+// ---------------------------------------------------------------------------
+
+// capturedActivity is a sqlmock argument matcher standing in for a column of
+// an account_activity insert; it keeps the value so the test can return the
+// recorded row from the activity listing
+type capturedActivity struct {
+	value driver.Value
+}
+
+func (c *capturedActivity) Match(v driver.Value) bool {
+	c.value = v
+	return true
+}
+
+// expectActivityInsert expects an account_activity insert of eventType for
+// userID and returns matchers capturing its ip_address, user_agent and
+// metadata
+func expectActivityInsert(mock sqlmock.Sqlmock, userID, eventType string, now time.Time) (ip, ua, metadata *capturedActivity) {
+	ip, ua, metadata = &capturedActivity{}, &capturedActivity{}, &capturedActivity{}
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WithArgs(userID, eventType, ip, ua, metadata).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-"+eventType, now))
+	return ip, ua, metadata
+}
+
+// getAccountActivity lists userID's account activity, returning the rows
+// given as what the database holds
+func getAccountActivity(t *testing.T, mock sqlmock.Sqlmock, userID string, rows *sqlmock.Rows, total int) []map[string]interface{} {
+	t.Helper()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM account_activity").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(total))
+	mock.ExpectQuery("SELECT .+ FROM account_activity").
+		WithArgs(userID, 50, 0).
+		WillReturnRows(rows)
+
+	r := setupMockRouter(userID)
+	r.GET("/api/v1/user/activity", GetAccountActivity)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/activity", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(total), resp.Meta["total"])
+	return resp.Data
+}
+
+var accountActivityColumns = []string{"id", "user_id", "event_type", "ip_address", "user_agent", "metadata", "created_at"}
+
+func TestIntegration_AccountActivity_LoginRecorded(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+
+	password := "integration-test-pass"
+	hash, err := auth.HashPassword(password)
+	require.NoError(t, err)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE email = \\$1").
+		WithArgs("activity@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "password_hash", "full_name", "timezone",
+			"created_at", "updated_at", "last_login_at", "email_verified",
+			"is_premium", "is_active", "is_admin", "is_worker", "last_activity_at",
+		}).AddRow(
+			"user-act-1", "activity@example.com", &hash, "Activity User", "UTC",
+			now, now, nil, true,
+			false, true, false, false, nil,
+		))
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WithArgs("user-act-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("UPDATE users SET last_login_at").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO sessions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).
+			AddRow("session-act-1", now, now))
+	ip, ua, metadata := expectActivityInsert(mock, "user-act-1", "login", now)
+
+	r := setupMockRouterNoAuth()
+	r.POST("/api/v1/auth/login", Login)
+
+	body, _ := json.Marshal(map[string]string{"email": "activity@example.com", "password": password})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ActivityTest/1.0")
+	req.RemoteAddr = "203.0.113.7:51234"
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "203.0.113.7", ip.value)
+	assert.Equal(t, "ActivityTest/1.0", ua.value)
+	assert.JSONEq(t, `{"session_id": "session-act-1"}`, string(metadata.value.([]byte)))
+
+	events := getAccountActivity(t, mock, "user-act-1",
+		sqlmock.NewRows(accountActivityColumns).
+			AddRow("activity-login", "user-act-1", "login", ip.value, ua.value, metadata.value, now), 1)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "login", events[0]["event_type"])
+	assert.Equal(t, "203.0.113.7", events[0]["ip_address"])
+	assert.Equal(t, "ActivityTest/1.0", events[0]["user_agent"])
+	assert.Equal(t, map[string]interface{}{"session_id": "session-act-1"}, events[0]["metadata"])
+	assert.NotContains(t, events[0], "user_id")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIntegration_AccountActivity_PasswordChangeRecorded(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	oldPassword := "oldpass12345"
+	oldHash, err := auth.HashPassword(oldPassword)
+	require.NoError(t, err)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WithArgs("user-act-2").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "password_hash", "full_name", "timezone",
+			"created_at", "updated_at", "last_login_at", "email_verified",
+			"is_premium", "is_active", "is_admin", "is_worker", "last_activity_at",
+		}).AddRow(
+			"user-act-2", "activity2@example.com", &oldHash, "Activity User", "UTC",
+			now, now, nil, true,
+			false, true, false, false, nil,
+		))
+	mock.ExpectExec("UPDATE users SET password_hash").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	ip, ua, metadata := expectActivityInsert(mock, "user-act-2", "password_changed", now)
+
+	r := setupMockRouter("user-act-2")
+	r.PUT("/api/v1/user/password", ChangePassword)
+
+	body, _ := json.Marshal(map[string]string{"current_password": oldPassword, "new_password": "newpass12345"})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/user/password", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ActivityTest/1.0")
+	req.RemoteAddr = "198.51.100.4:40000"
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, "198.51.100.4", ip.value)
+	assert.Equal(t, "ActivityTest/1.0", ua.value)
+
+	// The change is listed ahead of the earlier login
+	events := getAccountActivity(t, mock, "user-act-2",
+		sqlmock.NewRows(accountActivityColumns).
+			AddRow("activity-password_changed", "user-act-2", "password_changed", ip.value, ua.value, metadata.value, now).
+			AddRow("activity-login", "user-act-2", "login", "198.51.100.4", "ActivityTest/1.0", []byte(`{}`), now.Add(-time.Hour)), 2)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "password_changed", events[0]["event_type"])
+	assert.Equal(t, "198.51.100.4", events[0]["ip_address"])
+	assert.Equal(t, map[string]interface{}{}, events[0]["metadata"])
+	assert.Equal(t, "login", events[1]["event_type"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIntegration_AccountActivity_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM account_activity").
+		WillReturnError(fmt.Errorf("connection refused"))
+
+	r := setupMockRouter("user-act-3")
+	r.GET("/api/v1/user/activity", GetAccountActivity)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/user/activity", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Failed to fetch account activity")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
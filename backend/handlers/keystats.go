@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 )
 
 // KeyStats represents the ingested key stats data
@@ -26,43 +27,29 @@ func PostKeyStats(c *gin.Context) {
 	symbol := strings.ToUpper(c.Param("symbol"))
 
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Ticker symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Key stats service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Key stats service is temporarily unavailable")
 		return
 	}
 
 	var requestData map[string]interface{}
 	if err := c.ShouldBindJSON(&requestData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid JSON format",
-			"message": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid JSON format", err.Error())
 		return
 	}
 
 	if len(requestData) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Empty data",
-			"message": "Please provide at least one metric",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Empty data", "Please provide at least one metric")
 		return
 	}
 
 	dataJSON, err := json.Marshal(requestData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to process data",
-			"message": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to process data", err.Error())
 		return
 	}
 
@@ -80,10 +67,7 @@ func PostKeyStats(c *gin.Context) {
 	err = database.DB.QueryRowx(query, symbol, dataJSON).StructScan(&result)
 	if err != nil {
 		log.Printf("Error upserting key stats for %s: %v", symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to save data",
-			"message": "An error occurred while saving key stats data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save data", "An error occurred while saving key stats data")
 		return
 	}
 
@@ -104,17 +88,12 @@ func GetKeyStats(c *gin.Context) {
 	symbol := strings.ToUpper(c.Param("symbol"))
 
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Ticker symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Key stats service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Key stats service is temporarily unavailable")
 		return
 	}
 
@@ -128,28 +107,18 @@ func GetKeyStats(c *gin.Context) {
 	err := database.DB.QueryRowx(query, symbol).StructScan(&result)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Data not found",
-				"message": "No key stats data available for this ticker",
-				"ticker":  symbol,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Data not found", "No key stats data available for this ticker")
 			return
 		}
 		log.Printf("Error fetching key stats for %s: %v", symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch data",
-			"message": "An error occurred while retrieving key stats data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch data", "An error occurred while retrieving key stats data")
 		return
 	}
 
 	var parsedData map[string]interface{}
 	if err := json.Unmarshal(result.KeyStats, &parsedData); err != nil {
 		log.Printf("Error parsing JSON data for %s: %v", symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Data corruption",
-			"message": "Failed to parse stored data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Data corruption", "Failed to parse stored data")
 		return
 	}
 
@@ -170,17 +139,12 @@ func DeleteKeyStats(c *gin.Context) {
 	symbol := strings.ToUpper(c.Param("symbol"))
 
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Ticker symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Key stats service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Key stats service is temporarily unavailable")
 		return
 	}
 
@@ -188,20 +152,13 @@ func DeleteKeyStats(c *gin.Context) {
 	result, err := database.DB.Exec(query, symbol)
 	if err != nil {
 		log.Printf("Error deleting key stats for %s: %v", symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete data",
-			"message": "An error occurred while deleting key stats data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete data", "An error occurred while deleting key stats data")
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "Data not found",
-			"message": "No key stats data found for this ticker",
-			"ticker":  symbol,
-		})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Data not found", "No key stats data found for this ticker")
 		return
 	}
 
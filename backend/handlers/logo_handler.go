@@ -1,15 +1,31 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	_ "image/gif" // GIF and JPEG decoders for resizeLogo
+	_ "image/jpeg"
+	"image/png"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/services"
 )
 
 // ProxyLogo proxies logo requests to Polygon.io with the API key
@@ -19,19 +35,19 @@ func ProxyLogo(c *gin.Context) {
 	// Get stock from database to find logo URL
 	stock, err := database.GetStockBySymbol(symbol)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Stock not found")
 		return
 	}
 
 	if stock.LogoURL == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No logo available"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No logo available")
 		return
 	}
 
 	// Add API key to the Polygon URL
 	apiKey := os.Getenv("POLYGON_API_KEY")
 	if apiKey == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "API key not configured"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "API key not configured")
 		return
 	}
 
@@ -47,13 +63,13 @@ func ProxyLogo(c *gin.Context) {
 	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Get(logoURL)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch logo"})
+		respondError(c, http.StatusBadGateway, httputil.CodeUpstream, "Failed to fetch logo")
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		c.JSON(resp.StatusCode, gin.H{"error": "Logo not available"})
+		respondError(c, resp.StatusCode, "", "Logo not available")
 		return
 	}
 
@@ -66,3 +82,343 @@ func ProxyLogo(c *gin.Context) {
 		log.Printf("Failed to stream logo for %s: %v", symbol, err)
 	}
 }
+
+// LogoCache caches fetched logo images in memory with TTL
+type LogoCache struct {
+	mu         sync.RWMutex
+	entries    map[string]cachedLogo
+	cacheTTL   time.Duration
+	maxEntries int
+}
+
+type cachedLogo struct {
+	data        []byte
+	contentType string
+	cachedAt    time.Time
+}
+
+var logoCache = &LogoCache{
+	entries:    make(map[string]cachedLogo),
+	cacheTTL:   24 * time.Hour,
+	maxEntries: 5000,
+}
+
+func (c *LogoCache) get(symbol string) (cachedLogo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[symbol]
+	if !ok || time.Since(entry.cachedAt) > c.cacheTTL {
+		return cachedLogo{}, false
+	}
+	return entry, true
+}
+
+func (c *LogoCache) set(symbol string, data []byte, contentType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Full cache: drop an arbitrary entry rather than growing unbounded
+	if len(c.entries) >= c.maxEntries {
+		for key := range c.entries {
+			delete(c.entries, key)
+			break
+		}
+	}
+	c.entries[symbol] = cachedLogo{data: data, contentType: contentType, cachedAt: time.Now()}
+}
+
+// maxLogoBytes caps the size of an upstream logo we are willing to cache
+const maxLogoBytes = 1 << 20
+
+// logoFetchClient is the HTTP client used for upstream logo fetches
+var logoFetchClient = &http.Client{Timeout: 10 * time.Second}
+
+// Logo sizes accepted by ?size=, in pixels
+const (
+	minLogoSize = 16
+	maxLogoSize = 512
+)
+
+// maxLogoDimension caps the width and height of a logo we will decode. A
+// small compressed file can declare an enormous canvas, and decoding
+// allocates the whole canvas up front.
+const maxLogoDimension = 4096
+
+// LogoStore keeps original logo images across restarts and replicas.
+// Implemented by services.S3LogoStore.
+type LogoStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+var (
+	logoStore     LogoStore
+	logoStoreOnce sync.Once
+)
+
+// getLogoStore returns the S3 logo store when LOGO_S3_BUCKET is set, and
+// nil (memory cache only) otherwise
+func getLogoStore() LogoStore {
+	logoStoreOnce.Do(func() {
+		bucket := os.Getenv("LOGO_S3_BUCKET")
+		if bucket == "" {
+			return
+		}
+		store, err := services.NewS3LogoStore(bucket)
+		if err != nil {
+			log.Printf("⚠️ Failed to set up logo storage: %v (memory cache only)", err)
+			return
+		}
+		logoStore = store
+		log.Printf("✅ Storing ticker logos in s3://%s", bucket)
+	})
+	return logoStore
+}
+
+// logoStoreKey names a stored logo by symbol and source URL, so a changed
+// logo_url is fetched afresh rather than served from the old object
+func logoStoreKey(symbol, logoURL string) string {
+	sum := sha256.Sum256([]byte(logoURL))
+	return fmt.Sprintf("ticker-logos/%s/%s", url.PathEscape(symbol), hex.EncodeToString(sum[:8]))
+}
+
+// GetTickerLogo handles GET /api/v1/tickers/:symbol/logo?size=
+// Serves the ticker's logo from our domain so pages avoid mixed-content and
+// hotlinking problems with third-party hosts. Logos come from an in-memory
+// cache, then S3 (when LOGO_S3_BUCKET is set), then the stored logo_url.
+// Unknown tickers, missing logos and upstream failures get a generated SVG
+// placeholder instead of an error, so <img> tags always render. ?size=
+// (16-512) shrinks raster logos to fit a size x size box as PNG; SVGs scale
+// on their own and are served unchanged. X-Logo-Source reports cache, s3,
+// upstream or placeholder.
+func GetTickerLogo(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	size := 0
+	if raw := c.Query("size"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < minLogoSize || n > maxLogoSize {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest,
+				fmt.Sprintf("size must be between %d and %d", minLogoSize, maxLogoSize))
+			return
+		}
+		size = n
+	}
+
+	cacheKey := symbol
+	if size > 0 {
+		cacheKey = fmt.Sprintf("%s@%d", symbol, size)
+	}
+	if entry, ok := logoCache.get(cacheKey); ok {
+		serveLogo(c, entry.data, entry.contentType, "cache")
+		return
+	}
+
+	data, contentType, source, ok := loadTickerLogo(c.Request.Context(), symbol)
+	if !ok {
+		servePlaceholderLogo(c, symbol, size)
+		return
+	}
+
+	if size > 0 {
+		if resized, err := resizeLogo(data, size); err == nil {
+			data, contentType = resized, "image/png"
+		} else if contentType != "image/svg+xml" {
+			log.Printf("Logo resize failed for %s: %v", symbol, err)
+		}
+		logoCache.set(cacheKey, data, contentType)
+	}
+	serveLogo(c, data, contentType, source)
+}
+
+// loadTickerLogo returns the full-size logo for symbol and where it came
+// from, or false when the ticker has no usable logo
+func loadTickerLogo(ctx context.Context, symbol string) ([]byte, string, string, bool) {
+	if entry, ok := logoCache.get(symbol); ok {
+		return entry.data, entry.contentType, "cache", true
+	}
+
+	stock, err := database.GetStockBySymbol(symbol)
+	if err != nil || stock.LogoURL == "" {
+		return nil, "", "", false
+	}
+
+	store := getLogoStore()
+	key := logoStoreKey(symbol, stock.LogoURL)
+	if store != nil {
+		if data, err := store.Get(ctx, key); err == nil {
+			if contentType := logoContentType(data); contentType != "" {
+				logoCache.set(symbol, data, contentType)
+				return data, contentType, "s3", true
+			}
+		}
+	}
+
+	data, contentType, err := fetchLogo(stock.LogoURL)
+	if err != nil {
+		log.Printf("Logo fetch failed for %s: %v", symbol, err)
+		return nil, "", "", false
+	}
+
+	logoCache.set(symbol, data, contentType)
+	if store != nil {
+		if err := store.Put(ctx, key, data, contentType); err != nil {
+			log.Printf("Failed to store logo for %s: %v", symbol, err)
+		}
+	}
+	return data, contentType, "upstream", true
+}
+
+// logoContentType sniffs a stored logo's image type, or returns "" when
+// the data isn't an image
+func logoContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if strings.HasPrefix(contentType, "image/") {
+		return contentType
+	}
+	// DetectContentType reports SVG as text/xml or text/plain
+	if bytes.Contains(data[:min(len(data), 512)], []byte("<svg")) {
+		return "image/svg+xml"
+	}
+	return ""
+}
+
+// resizeLogo shrinks a PNG, JPEG or GIF logo to fit within size x size,
+// keeping its aspect ratio, and re-encodes it as PNG. Each output pixel
+// averages the source pixels it covers. Logos already that small are
+// re-encoded at their own size rather than enlarged. Images wider or
+// taller than maxLogoDimension are rejected before being decoded.
+func resizeLogo(data []byte, size int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+	if cfg.Width > maxLogoDimension || cfg.Height > maxLogoDimension {
+		return nil, fmt.Errorf("logo is %dx%d, larger than %dx%d", cfg.Width, cfg.Height, maxLogoDimension, maxLogoDimension)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("empty logo image")
+	}
+	dstWidth, dstHeight := width, height
+	if width > size || height > size {
+		if width >= height {
+			dstWidth, dstHeight = size, max(1, height*size/width)
+		} else {
+			dstWidth, dstHeight = max(1, width*size/height), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := bounds.Min.Y + (y+1)*height/dstHeight
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := bounds.Min.X + (x+1)*width/dstWidth
+			// RGBA() is alpha-premultiplied, so averaging keeps transparent
+			// edges from bleeding dark fringes
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("failed to encode logo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fetchLogo downloads an image, adding the Polygon API key only for
+// polygon.io URLs so the key never leaks to third-party hosts.
+func fetchLogo(logoURL string) ([]byte, string, error) {
+	parsed, err := url.Parse(logoURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid logo URL: %w", err)
+	}
+	if isPolygonHost(parsed.Hostname()) {
+		apiKey := os.Getenv("POLYGON_API_KEY")
+		if apiKey == "" {
+			return nil, "", fmt.Errorf("POLYGON_API_KEY not configured")
+		}
+		query := parsed.Query()
+		query.Set("apiKey", apiKey)
+		parsed.RawQuery = query.Encode()
+	}
+
+	resp, err := logoFetchClient.Get(parsed.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch logo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLogoBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read logo: %w", err)
+	}
+	if len(data) > maxLogoBytes {
+		return nil, "", fmt.Errorf("logo exceeds %d bytes", maxLogoBytes)
+	}
+
+	return data, contentType, nil
+}
+
+// isPolygonHost reports whether host is polygon.io or one of its
+// subdomains; a bare suffix match would also accept e.g. evilpolygon.io
+func isPolygonHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "polygon.io" || strings.HasSuffix(host, ".polygon.io")
+}
+
+func serveLogo(c *gin.Context, data []byte, contentType, source string) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("X-Logo-Source", source)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// servePlaceholderLogo renders a neutral SVG badge with the symbol's first
+// letters, size pixels square (64 when size is 0). It is cached briefly so
+// a newly added logo shows up soon.
+func servePlaceholderLogo(c *gin.Context, symbol string, size int) {
+	if size == 0 {
+		size = 64
+	}
+	label := strings.TrimPrefix(symbol, "X:")
+	if len(label) > 4 {
+		label = label[:4]
+	}
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 64 64">`+
+		`<rect width="64" height="64" rx="12" fill="#E5E7EB"/>`+
+		`<text x="32" y="38" font-family="Arial, sans-serif" font-size="16" font-weight="600" fill="#4B5563" text-anchor="middle">%s</text>`+
+		`</svg>`, size, size, html.EscapeString(label))
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Header("X-Logo-Source", "placeholder")
+	c.Data(http.StatusOK, "image/svg+xml", []byte(svg))
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTickerLogoRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	// Isolate each test from logos cached by earlier tests
+	orig := logoCache
+	logoCache = &LogoCache{entries: make(map[string]cachedLogo), cacheTTL: time.Hour, maxEntries: 10}
+	t.Cleanup(func() { logoCache = orig })
+
+	r := setupMockRouterNoAuth()
+	r.GET("/tickers/:symbol/logo", GetTickerLogo)
+	return r
+}
+
+func expectStockLogoURL(mock sqlmock.Sqlmock, symbol, logoURL string) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM tickers").
+		WithArgs(symbol).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "symbol", "name", "exchange", "sector", "industry",
+			"country", "currency", "market_cap", "description", "website",
+			"asset_type", "logo_url", "created_at", "updated_at",
+		}).AddRow(1, symbol, symbol+" Corp", "NASDAQ", "", "", "US", "USD", nil, "", "", "stock", logoURL, now, now))
+}
+
+func TestGetTickerLogo_Mock_CacheHit(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+
+	logoCache.set("AAPL", []byte("cached-png"), "image/png")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/aapl/logo", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "cache", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "cached-png", w.Body.String())
+	// Served without touching the database
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerLogo_Mock_FetchesOnceThenCaches(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		assert.Empty(t, r.URL.Query().Get("apiKey"), "API key must not be sent to non-Polygon hosts")
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("fresh-png"))
+	}))
+	defer upstream.Close()
+
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+	expectStockLogoURL(mock, "MSFT", upstream.URL+"/msft.png")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/MSFT/logo", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "upstream", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, "fresh-png", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/MSFT/logo", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cache", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, "fresh-png", w.Body.String())
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerLogo_Mock_PlaceholderWhenNoLogo(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+	expectStockLogoURL(mock, "NOLOG", "")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/NOLOG/logo", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, "placeholder", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), ">NOLO</text>")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerLogo_Mock_PlaceholderForUnknownTicker(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+	mock.ExpectQuery("SELECT .+ FROM tickers").WillReturnError(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/ZZZ/logo", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "placeholder", w.Header().Get("X-Logo-Source"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerLogo_Mock_PlaceholderOnUpstreamError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer upstream.Close()
+
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+	expectStockLogoURL(mock, "ERR", upstream.URL+"/err.png")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/ERR/logo", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "placeholder", w.Header().Get("X-Logo-Source"))
+	_, cached := logoCache.get("ERR")
+	assert.False(t, cached, "failed fetches are not cached")
+}
+
+// memoryLogoStore stands in for S3 logo storage
+type memoryLogoStore struct {
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func (m *memoryLogoStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	m.contentTypes[key] = contentType
+	return nil
+}
+
+func (m *memoryLogoStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("S3 get returned status 404")
+	}
+	return data, nil
+}
+
+func setupLogoStore(t *testing.T) *memoryLogoStore {
+	t.Helper()
+	logoStoreOnce.Do(func() {}) // Keep getLogoStore from reading LOGO_S3_BUCKET
+	store := &memoryLogoStore{objects: make(map[string][]byte), contentTypes: make(map[string]string)}
+	orig := logoStore
+	logoStore = store
+	t.Cleanup(func() { logoStore = orig })
+	return store
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 30, B: 30, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestGetTickerLogo_Mock_ResizesRaster(t *testing.T) {
+	original := testPNG(t, 200, 100)
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(original)
+	}))
+	defer upstream.Close()
+
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+	expectStockLogoURL(mock, "AAPL", upstream.URL+"/aapl.png")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/AAPL/logo?size=64", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "upstream", w.Header().Get("X-Logo-Source"))
+
+	resized, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 32), resized.Bounds(), "fits the box, keeping aspect ratio")
+	r8, g8, b8, a8 := resized.At(10, 10).RGBA()
+	assert.Equal(t, []uint32{200, 30, 30, 255}, []uint32{r8 >> 8, g8 >> 8, b8 >> 8, a8 >> 8})
+
+	// Both the resized and the full-size logo are now cached
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/AAPL/logo?size=64", nil))
+	assert.Equal(t, "cache", w.Header().Get("X-Logo-Source"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/AAPL/logo", nil))
+	assert.Equal(t, "cache", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, original, w.Body.Bytes())
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerLogo_Mock_SVGNotResized(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 10 10"></svg>`)
+	logoCache.set("SVGCO", svg, "image/svg+xml")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/SVGCO/logo?size=32", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, svg, w.Body.Bytes())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerLogo_Mock_InvalidSize(t *testing.T) {
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+
+	for _, size := range []string{"abc", "8", "1024"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/AAPL/logo?size="+size, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "size=%s", size)
+	}
+}
+
+func TestGetTickerLogo_Mock_PlaceholderHonorsSize(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	r := setupTickerLogoRouter(t)
+	expectStockLogoURL(mock, "NOLOG", "")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/NOLOG/logo?size=32", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "placeholder", w.Header().Get("X-Logo-Source"))
+	assert.Contains(t, w.Body.String(), `width="32" height="32"`)
+}
+
+func TestGetTickerLogo_Mock_StoresInS3(t *testing.T) {
+	original := testPNG(t, 8, 8)
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(original)
+	}))
+	defer upstream.Close()
+
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	store := setupLogoStore(t)
+	r := setupTickerLogoRouter(t)
+	logoURL := upstream.URL + "/nvda.png"
+	expectStockLogoURL(mock, "NVDA", logoURL)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/NVDA/logo", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "upstream", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, original, store.objects[logoStoreKey("NVDA", logoURL)])
+	assert.Equal(t, "image/png", store.contentTypes[logoStoreKey("NVDA", logoURL)])
+
+	// A fresh replica (empty memory cache) reads from S3, not upstream
+	logoCache = &LogoCache{entries: make(map[string]cachedLogo), cacheTTL: time.Hour, maxEntries: 10}
+	expectStockLogoURL(mock, "NVDA", logoURL)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tickers/NVDA/logo", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "s3", w.Header().Get("X-Logo-Source"))
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, original, w.Body.Bytes())
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResizeLogo_RejectsOversizedCanvas(t *testing.T) {
+	_, err := resizeLogo(testPNG(t, maxLogoDimension+1, 1), 64)
+	assert.ErrorContains(t, err, "larger than")
+
+	_, err = resizeLogo(testPNG(t, 1, maxLogoDimension+1), 64)
+	assert.ErrorContains(t, err, "larger than")
+
+	resized, err := resizeLogo(testPNG(t, 200, 100), 64)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resized)
+}
+
+func TestIsPolygonHost(t *testing.T) {
+	assert.True(t, isPolygonHost("polygon.io"))
+	assert.True(t, isPolygonHost("api.polygon.io"))
+	assert.True(t, isPolygonHost("API.Polygon.IO"))
+	assert.False(t, isPolygonHost("evilpolygon.io"))
+	assert.False(t, isPolygonHost("polygon.io.example.com"))
+	assert.False(t, isPolygonHost("logo.clearbit.com"))
+}
+
+func TestLogoStoreKey_ChangesWithURL(t *testing.T) {
+	a := logoStoreKey("X:BTCUSD", "https://example.com/a.png")
+	assert.Regexp(t, `^ticker-logos/X:BTCUSD/[0-9a-f]{16}$`, a)
+	assert.NotEqual(t, a, logoStoreKey("X:BTCUSD", "https://example.com/b.png"))
+}
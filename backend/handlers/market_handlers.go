@@ -1,16 +1,24 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"math"
 	"net/http"
+	"os"
+	"slices"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"investorcenter-api/auth"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
 )
 
@@ -156,13 +164,7 @@ func GetMarketIndices(c *gin.Context) {
 	// If we couldn't fetch any indices, return an error with details
 	if len(indices) == 0 {
 		log.Printf("Error: Failed to fetch any market indices. Errors: %v", fetchErrors)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Failed to fetch market indices from Polygon.io. Please check API key and connectivity.",
-			"details": fetchErrors,
-			"meta": gin.H{
-				"timestamp": time.Now().UTC(),
-			},
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Failed to fetch market indices from Polygon.io. Please check API key and connectivity.", fetchErrors)
 		return
 	}
 
@@ -194,11 +196,7 @@ func GetMarketMovers(c *gin.Context) {
 	}
 
 	// Parse limit parameter (default 5)
-	limitStr := c.DefaultQuery("limit", "5")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 || limit > 20 {
-		limit = 5
-	}
+	limit := parseLimitUpTo(c, 5, 20)
 
 	polygonClient := services.NewPolygonClient()
 
@@ -206,12 +204,7 @@ func GetMarketMovers(c *gin.Context) {
 	snapshots, err := polygonClient.GetBulkStockSnapshots()
 	if err != nil {
 		log.Printf("Error fetching bulk stock snapshots: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Failed to fetch market movers from Polygon.io",
-			"meta": gin.H{
-				"timestamp": time.Now().UTC(),
-			},
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Failed to fetch market movers from Polygon.io")
 		return
 	}
 
@@ -348,23 +341,14 @@ func GetMarketMovers(c *gin.Context) {
 // GetMarketNews returns general market news (not ticker-specific) from Polygon.io.
 // Delegates to the PolygonClient service layer for API access and response parsing.
 func GetMarketNews(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "10")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 || limit > 50 {
-		limit = 10
-	}
+	limit := parseLimitUpTo(c, 10, 50)
 
 	polygonClient := services.NewPolygonClient()
 
 	articles, err := polygonClient.GetGeneralNews(limit)
 	if err != nil {
 		log.Printf("Error fetching market news: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Failed to fetch market news",
-			"meta": gin.H{
-				"timestamp": time.Now().UTC(),
-			},
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Failed to fetch market news")
 		return
 	}
 
@@ -379,3 +363,138 @@ func GetMarketNews(c *gin.Context) {
 		},
 	})
 }
+
+// hideInactiveTickers drops active=false (delisted) tickers from public search
+// and listing endpoints. Set HIDE_INACTIVE_TICKERS=false to show everything.
+var hideInactiveTickers = os.Getenv("HIDE_INACTIVE_TICKERS") != "false"
+
+// IncludeInactiveTickers reports whether a public read endpoint should return
+// inactive tickers: when hiding is disabled, or for an admin passing
+// ?include_inactive=true. Routes need OptionalAuthMiddleware to see admins.
+func IncludeInactiveTickers(c *gin.Context) bool {
+	if !hideInactiveTickers {
+		return true
+	}
+	if c.Query("include_inactive") != "true" {
+		return false
+	}
+	return auth.IsAdmin(c)
+}
+
+// ParseSearchTypes reads ?types=, a comma-separated list of asset types to
+// limit search to (e.g. stock,etf,crypto). It returns nil, meaning all
+// types, when the parameter is missing, and an error naming any type that
+// isn't one of database.SearchAssetTypes.
+func ParseSearchTypes(c *gin.Context) ([]string, error) {
+	raw := c.Query("types")
+	if raw == "" {
+		return nil, nil
+	}
+	var types []string
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if !slices.Contains(database.SearchAssetTypes, t) {
+			return nil, fmt.Errorf("unknown type %q; must be one of %s", t, strings.Join(database.SearchAssetTypes, ", "))
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+// ParseSearchOptions reads the ticker search query parameters: ?types= (see
+// ParseSearchTypes), ?include_inactive= (see IncludeInactiveTickers),
+// ?fuzzy=true|false to force the typo-tolerant fallback on or off, and
+// ?min_similarity= for the fuzzy match threshold.
+func ParseSearchOptions(c *gin.Context, limit int) (database.SearchOptions, error) {
+	opts := database.SearchOptions{Limit: limit, IncludeInactive: IncludeInactiveTickers(c)}
+
+	types, err := ParseSearchTypes(c)
+	if err != nil {
+		return opts, err
+	}
+	opts.Types = types
+
+	if raw := c.Query("fuzzy"); raw != "" {
+		fuzzy, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, fmt.Errorf("fuzzy must be true or false")
+		}
+		opts.Fuzzy = &fuzzy
+	}
+
+	if raw := c.Query("min_similarity"); raw != "" {
+		minSimilarity, err := strconv.ParseFloat(raw, 64)
+		if err != nil || minSimilarity < database.FuzzySearchThreshold || minSimilarity > 1 {
+			return opts, fmt.Errorf("min_similarity must be a number between %g and 1", database.FuzzySearchThreshold)
+		}
+		opts.MinSimilarity = minSimilarity
+	}
+	return opts, nil
+}
+
+// searchSuggestCacheTTL is short so suggested prices stay close to live.
+const searchSuggestCacheTTL = 30 * time.Second
+
+// searchSuggestCacheVersion is bumped when the suggest payload shape changes.
+const searchSuggestCacheVersion = "v1"
+
+// GetSearchSuggestions handles GET /api/v1/markets/search/suggest?q=
+// Returns the top search matches with latest price and day change for
+// autocomplete. The full searchSecurities endpoint remains for detailed results.
+func GetSearchSuggestions(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Query parameter 'q' is required")
+		return
+	}
+
+	limit := parseLimitUpTo(c, 8, 20)
+
+	includeInactive := IncludeInactiveTickers(c)
+
+	ctx := c.Request.Context()
+	cacheKey := fmt.Sprintf("search:%s:suggest:%s:%d:%t", searchSuggestCacheVersion, strings.ToUpper(query), limit, includeInactive)
+
+	if redisClient != nil {
+		cached, err := redisClient.Get(ctx, cacheKey).Result()
+		if err == nil {
+			c.Data(http.StatusOK, "application/json", []byte(cached))
+			return
+		}
+		if err != redis.Nil {
+			log.Printf("Redis GET error for %s: %v", cacheKey, err)
+		}
+	}
+
+	suggestions, err := database.SearchSuggestions(query, limit, includeInactive)
+	if err != nil {
+		log.Printf("Search suggestions failed for %q: %v", query, err)
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Search temporarily unavailable", err.Error())
+		return
+	}
+
+	response := gin.H{
+		"data": suggestions,
+		"meta": gin.H{
+			"query":     query,
+			"count":     len(suggestions),
+			"timestamp": time.Now().UTC(),
+		},
+	}
+
+	if redisClient != nil {
+		responseJSON, err := json.Marshal(response)
+		if err != nil {
+			log.Printf("JSON marshal error for search suggestions %q: %v", query, err)
+		} else if err := redisClient.Set(ctx, cacheKey, responseJSON, searchSuggestCacheTTL).Err(); err != nil {
+			log.Printf("Redis SET error for %s: %v", cacheKey, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"investorcenter-api/database"
+)
+
+// ---------------------------------------------------------------------------
+// GetSearchSuggestions
+// ---------------------------------------------------------------------------
+
+var searchSuggestionCols = []string{
+	"symbol", "name", "exchange", "asset_type", "logo_url",
+	"price", "change", "change_percent",
+}
+
+func TestGetSearchSuggestions_Mock_EnrichedPayload(t *testing.T) {
+	setupMiniRedis(t)
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("WITH matches AS").
+		WithArgs("%AA%", "%AA%", "AA", "AA%", 8, false).
+		WillReturnRows(sqlmock.NewRows(searchSuggestionCols).
+			AddRow("AAPL", "Apple Inc.", "NASDAQ", "stock", "https://logo/aapl.png", 190.5, 2.5, 1.3298).
+			AddRow("AAL", "American Airlines Group", "NASDAQ", "stock", "", nil, nil, nil))
+
+	router := setupMockRouterNoAuth()
+	router.GET("/markets/search/suggest", GetSearchSuggestions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/markets/search/suggest?q=AA", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+
+	first := resp.Data[0]
+	assert.Equal(t, "AAPL", first["symbol"])
+	assert.Equal(t, "Apple Inc.", first["name"])
+	assert.Equal(t, "NASDAQ", first["exchange"])
+	assert.Equal(t, "stock", first["type"])
+	assert.Equal(t, 190.5, first["price"])
+	assert.Equal(t, 2.5, first["change"])
+	assert.InDelta(t, 1.33, first["changePercent"], 0.01)
+
+	// No price history: price fields are present but null
+	second := resp.Data[1]
+	assert.Equal(t, "AAL", second["symbol"])
+	assert.Contains(t, second, "price")
+	assert.Nil(t, second["price"])
+	assert.Nil(t, second["changePercent"])
+
+	assert.Equal(t, "AA", resp.Meta["query"])
+	assert.Equal(t, float64(2), resp.Meta["count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSearchSuggestions_Mock_CachedResponse(t *testing.T) {
+	mr := setupMiniRedis(t)
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("WITH matches AS").
+		WillReturnRows(sqlmock.NewRows(searchSuggestionCols).
+			AddRow("MSFT", "Microsoft Corporation", "NASDAQ", "stock", "", 410.0, -1.0, -0.24))
+
+	router := setupMockRouterNoAuth()
+	router.GET("/markets/search/suggest", GetSearchSuggestions)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/markets/search/suggest?q=msft", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"price":410`)
+	}
+
+	// Second request is served from cache; only one query was expected
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.True(t, mr.Exists(fmt.Sprintf("search:%s:suggest:MSFT:8:false", searchSuggestCacheVersion)))
+}
+
+func TestGetSearchSuggestions_Mock_MissingQuery(t *testing.T) {
+	router := setupMockRouterNoAuth()
+	router.GET("/markets/search/suggest", GetSearchSuggestions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/markets/search/suggest?q=%20", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetSearchSuggestions_Mock_DBError(t *testing.T) {
+	setupMiniRedis(t)
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("WITH matches AS").
+		WillReturnError(fmt.Errorf("connection refused"))
+
+	router := setupMockRouterNoAuth()
+	router.GET("/markets/search/suggest", GetSearchSuggestions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/markets/search/suggest?q=NVDA", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// IncludeInactiveTickers
+// ---------------------------------------------------------------------------
+
+func TestIncludeInactiveTickers(t *testing.T) {
+	check := func(url string, isAdmin *bool) bool {
+		r := setupMockRouterNoAuth()
+		var got bool
+		r.GET("/x", func(c *gin.Context) {
+			if isAdmin != nil {
+				c.Set("is_admin", *isAdmin)
+			}
+			got = IncludeInactiveTickers(c)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		return got
+	}
+	admin, user := true, false
+
+	assert.False(t, check("/x", &admin), "hidden by default, even for admins")
+	assert.True(t, check("/x?include_inactive=true", &admin))
+	assert.False(t, check("/x?include_inactive=true", &user), "override is admin-only")
+	assert.False(t, check("/x?include_inactive=true", nil), "anonymous requests can't override")
+
+	orig := hideInactiveTickers
+	hideInactiveTickers = false
+	defer func() { hideInactiveTickers = orig }()
+	assert.True(t, check("/x", nil), "HIDE_INACTIVE_TICKERS=false shows everything")
+}
+
+func TestParseSearchTypes(t *testing.T) {
+	parse := func(url string) ([]string, error) {
+		r := setupMockRouterNoAuth()
+		var types []string
+		var err error
+		r.GET("/x", func(c *gin.Context) { types, err = ParseSearchTypes(c) })
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		return types, err
+	}
+
+	types, err := parse("/x")
+	assert.NoError(t, err)
+	assert.Nil(t, types, "no filter searches every type")
+
+	types, err = parse("/x?types=Stock,%20etf,,crypto,stock")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"stock", "etf", "crypto"}, types)
+
+	_, err = parse("/x?types=stock,bond")
+	assert.ErrorContains(t, err, `unknown type "bond"`)
+}
+
+func TestParseSearchOptions(t *testing.T) {
+	parse := func(url string) (database.SearchOptions, error) {
+		r := setupMockRouterNoAuth()
+		var opts database.SearchOptions
+		var err error
+		r.GET("/x", func(c *gin.Context) { opts, err = ParseSearchOptions(c, 10) })
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		return opts, err
+	}
+
+	opts, err := parse("/x")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, opts.Limit)
+	assert.Nil(t, opts.Fuzzy, "fuzzy fallback is automatic by default")
+	assert.Zero(t, opts.MinSimilarity)
+
+	opts, err = parse("/x?fuzzy=true&min_similarity=0.45&types=stock")
+	assert.NoError(t, err)
+	if assert.NotNil(t, opts.Fuzzy) {
+		assert.True(t, *opts.Fuzzy)
+	}
+	assert.Equal(t, 0.45, opts.MinSimilarity)
+	assert.Equal(t, []string{"stock"}, opts.Types)
+
+	opts, err = parse("/x?fuzzy=false")
+	assert.NoError(t, err)
+	if assert.NotNil(t, opts.Fuzzy) {
+		assert.False(t, *opts.Fuzzy)
+	}
+
+	_, err = parse("/x?fuzzy=maybe")
+	assert.ErrorContains(t, err, "fuzzy must be true or false")
+	_, err = parse("/x?min_similarity=0.1")
+	assert.ErrorContains(t, err, "min_similarity must be a number between 0.3 and 1")
+	_, err = parse("/x?min_similarity=abc")
+	assert.Error(t, err)
+	_, err = parse("/x?types=bond")
+	assert.Error(t, err)
+}
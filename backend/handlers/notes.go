@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 )
 
 // FeatureGroup represents a top-level group of features
@@ -60,7 +61,7 @@ type GroupWithFeatures struct {
 // GetNotesTree returns the full hierarchy for the sidebar
 func GetNotesTree(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -69,7 +70,7 @@ func GetNotesTree(c *gin.Context) {
 	err := database.DB.Select(&groups, "SELECT * FROM feature_groups ORDER BY sort_order, created_at")
 	if err != nil {
 		log.Printf("Error fetching feature groups: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch groups")
 		return
 	}
 
@@ -78,7 +79,7 @@ func GetNotesTree(c *gin.Context) {
 	err = database.DB.Select(&features, "SELECT * FROM features ORDER BY sort_order, created_at")
 	if err != nil {
 		log.Printf("Error fetching features: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch features"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch features")
 		return
 	}
 
@@ -92,7 +93,7 @@ func GetNotesTree(c *gin.Context) {
 	err = database.DB.Select(&noteCounts, "SELECT feature_id, section, COUNT(*) as count FROM feature_notes GROUP BY feature_id, section")
 	if err != nil {
 		log.Printf("Error fetching note counts: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch note counts"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch note counts")
 		return
 	}
 
@@ -144,7 +145,7 @@ func GetNotesTree(c *gin.Context) {
 // ListFeatureGroups handles GET /admin/notes/groups
 func ListFeatureGroups(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -152,7 +153,7 @@ func ListFeatureGroups(c *gin.Context) {
 	err := database.DB.Select(&groups, "SELECT * FROM feature_groups ORDER BY sort_order, created_at")
 	if err != nil {
 		log.Printf("Error fetching feature groups: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch groups")
 		return
 	}
 
@@ -165,7 +166,7 @@ func ListFeatureGroups(c *gin.Context) {
 // CreateFeatureGroup handles POST /admin/notes/groups
 func CreateFeatureGroup(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -174,7 +175,7 @@ func CreateFeatureGroup(c *gin.Context) {
 		Notes string `json:"notes" binding:"max=10000"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -185,7 +186,7 @@ func CreateFeatureGroup(c *gin.Context) {
 	).StructScan(&group)
 	if err != nil {
 		log.Printf("Error creating feature group: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create group")
 		return
 	}
 
@@ -198,7 +199,7 @@ func CreateFeatureGroup(c *gin.Context) {
 // UpdateFeatureGroup handles PUT /admin/notes/groups/:id
 func UpdateFeatureGroup(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -210,7 +211,7 @@ func UpdateFeatureGroup(c *gin.Context) {
 		SortOrder *int    `json:"sort_order" binding:"omitempty,min=0,max=10000"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -225,11 +226,11 @@ func UpdateFeatureGroup(c *gin.Context) {
 	).StructScan(&group)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
 			return
 		}
 		log.Printf("Error updating feature group: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update group"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update group")
 		return
 	}
 
@@ -242,7 +243,7 @@ func UpdateFeatureGroup(c *gin.Context) {
 // DeleteFeatureGroup handles DELETE /admin/notes/groups/:id
 func DeleteFeatureGroup(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -251,13 +252,13 @@ func DeleteFeatureGroup(c *gin.Context) {
 	result, err := database.DB.Exec("DELETE FROM feature_groups WHERE id = $1", id)
 	if err != nil {
 		log.Printf("Error deleting feature group: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete group")
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
 		return
 	}
 
@@ -272,7 +273,7 @@ func DeleteFeatureGroup(c *gin.Context) {
 // ListFeatures handles GET /admin/notes/groups/:groupId/features
 func ListFeatures(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -285,7 +286,7 @@ func ListFeatures(c *gin.Context) {
 	)
 	if err != nil {
 		log.Printf("Error fetching features: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch features"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch features")
 		return
 	}
 
@@ -298,7 +299,7 @@ func ListFeatures(c *gin.Context) {
 // CreateFeature handles POST /admin/notes/groups/:groupId/features
 func CreateFeature(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -309,7 +310,7 @@ func CreateFeature(c *gin.Context) {
 		Notes string `json:"notes" binding:"max=10000"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -320,7 +321,7 @@ func CreateFeature(c *gin.Context) {
 	).StructScan(&feature)
 	if err != nil {
 		log.Printf("Error creating feature: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create feature"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create feature")
 		return
 	}
 
@@ -333,7 +334,7 @@ func CreateFeature(c *gin.Context) {
 // UpdateFeature handles PUT /admin/notes/features/:id
 func UpdateFeature(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -345,7 +346,7 @@ func UpdateFeature(c *gin.Context) {
 		SortOrder *int    `json:"sort_order" binding:"omitempty,min=0,max=10000"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -360,11 +361,11 @@ func UpdateFeature(c *gin.Context) {
 	).StructScan(&feature)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Feature not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Feature not found")
 			return
 		}
 		log.Printf("Error updating feature: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update feature"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update feature")
 		return
 	}
 
@@ -377,7 +378,7 @@ func UpdateFeature(c *gin.Context) {
 // DeleteFeature handles DELETE /admin/notes/features/:id
 func DeleteFeature(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -386,13 +387,13 @@ func DeleteFeature(c *gin.Context) {
 	result, err := database.DB.Exec("DELETE FROM features WHERE id = $1", id)
 	if err != nil {
 		log.Printf("Error deleting feature: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete feature"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete feature")
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Feature not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Feature not found")
 		return
 	}
 
@@ -407,7 +408,7 @@ func DeleteFeature(c *gin.Context) {
 // ListFeatureNotes handles GET /admin/notes/features/:featureId/notes
 func ListFeatureNotes(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -431,7 +432,7 @@ func ListFeatureNotes(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Error fetching feature notes: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notes"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch notes")
 		return
 	}
 
@@ -444,7 +445,7 @@ func ListFeatureNotes(c *gin.Context) {
 // CreateFeatureNote handles POST /admin/notes/features/:featureId/notes
 func CreateFeatureNote(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -456,14 +457,14 @@ func CreateFeatureNote(c *gin.Context) {
 		Content string `json:"content" binding:"max=50000"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Validate section
 	validSections := map[string]bool{"ui": true, "backend": true, "data": true, "infra": true}
 	if !validSections[req.Section] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section. Must be one of: ui, backend, data, infra"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid section. Must be one of: ui, backend, data, infra")
 		return
 	}
 
@@ -479,7 +480,7 @@ func CreateFeatureNote(c *gin.Context) {
 	).StructScan(&note)
 	if err != nil {
 		log.Printf("Error creating feature note: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create note"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create note")
 		return
 	}
 
@@ -492,7 +493,7 @@ func CreateFeatureNote(c *gin.Context) {
 // UpdateFeatureNote handles PUT /admin/notes/notes/:id
 func UpdateFeatureNote(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -504,7 +505,7 @@ func UpdateFeatureNote(c *gin.Context) {
 		SortOrder *int    `json:"sort_order" binding:"omitempty,min=0,max=10000"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -519,11 +520,11 @@ func UpdateFeatureNote(c *gin.Context) {
 	).StructScan(&note)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Note not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Note not found")
 			return
 		}
 		log.Printf("Error updating feature note: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update note")
 		return
 	}
 
@@ -536,7 +537,7 @@ func UpdateFeatureNote(c *gin.Context) {
 // DeleteFeatureNote handles DELETE /admin/notes/notes/:id
 func DeleteFeatureNote(c *gin.Context) {
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not available"})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available")
 		return
 	}
 
@@ -545,13 +546,13 @@ func DeleteFeatureNote(c *gin.Context) {
 	result, err := database.DB.Exec("DELETE FROM feature_notes WHERE id = $1", id)
 	if err != nil {
 		log.Printf("Error deleting feature note: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete note"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete note")
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Note not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Note not found")
 		return
 	}
 
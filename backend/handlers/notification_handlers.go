@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"investorcenter-api/auth"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,11 +26,14 @@ func NewNotificationHandler(notificationService *services.NotificationService) *
 // @Success 200 {object} models.NotificationPreferences
 // @Router /api/v1/notifications/preferences [get]
 func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	prefs, err := h.notificationService.GetNotificationPreferences(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification preferences"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get notification preferences")
 		return
 	}
 
@@ -44,17 +49,84 @@ func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
 // @Success 200 {object} models.NotificationPreferences
 // @Router /api/v1/notifications/preferences [put]
 func (h *NotificationHandler) UpdateNotificationPreferences(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	var req models.UpdateNotificationPreferencesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	prefs, err := h.notificationService.UpdateNotificationPreferences(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrSMSNotInPlan) {
+			respondError(c, http.StatusForbidden, httputil.CodeForbidden, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// SendPhoneVerification godoc
+// @Summary Text a verification code to the phone number in notification preferences
+// @Tags notifications
+// @Produce json
+// @Success 200
+// @Router /api/v1/notifications/phone/verify [post]
+func (h *NotificationHandler) SendPhoneVerification(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	err := h.notificationService.SendPhoneVerificationCode(c.Request.Context(), userID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	case errors.Is(err, services.ErrSMSNotInPlan):
+		respondError(c, http.StatusForbidden, httputil.CodeForbidden, err.Error())
+	case errors.Is(err, services.ErrNoPhoneNumber):
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+	case errors.Is(err, services.ErrPhoneCodeTooSoon):
+		respondError(c, http.StatusTooManyRequests, httputil.CodeRateLimited, err.Error())
+	default:
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to send verification code")
+	}
+}
+
+// ConfirmPhoneVerification godoc
+// @Summary Confirm the phone number in notification preferences with the texted code
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body models.ConfirmPhoneRequest true "Verification code"
+// @Success 200 {object} models.NotificationPreferences
+// @Router /api/v1/notifications/phone/verify/confirm [post]
+func (h *NotificationHandler) ConfirmPhoneVerification(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConfirmPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	prefs, err := h.notificationService.ConfirmPhoneNumber(userID, req.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPhoneCode) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to confirm phone number")
 		return
 	}
 
@@ -70,13 +142,17 @@ func (h *NotificationHandler) UpdateNotificationPreferences(c *gin.Context) {
 // @Success 200 {array} models.InAppNotification
 // @Router /api/v1/notifications [get]
 func (h *NotificationHandler) GetInAppNotifications(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	unreadOnly := c.Query("unread_only") == "true"
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	limit := parseLimit(c, 50)
 
 	notifications, err := h.notificationService.GetInAppNotifications(userID, unreadOnly, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch notifications")
 		return
 	}
 
@@ -90,11 +166,14 @@ func (h *NotificationHandler) GetInAppNotifications(c *gin.Context) {
 // @Success 200 {object} map[string]int
 // @Router /api/v1/notifications/unread-count [get]
 func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	count, err := h.notificationService.GetUnreadNotificationCount(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get unread count"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to get unread count")
 		return
 	}
 
@@ -108,11 +187,15 @@ func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
 // @Success 200
 // @Router /api/v1/notifications/:id/read [post]
 func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	notificationID := c.Param("id")
 
 	if err := h.notificationService.MarkNotificationAsRead(notificationID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+		respondOwnedLookupError(c, err, "Notification not found", "Failed to mark notification as read")
 		return
 	}
 
@@ -125,10 +208,13 @@ func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
 // @Success 200
 // @Router /api/v1/notifications/read-all [post]
 func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	if err := h.notificationService.MarkAllNotificationsAsRead(userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark all notifications as read"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to mark all notifications as read")
 		return
 	}
 
@@ -142,11 +228,15 @@ func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
 // @Success 200
 // @Router /api/v1/notifications/:id/dismiss [post]
 func (h *NotificationHandler) DismissNotification(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
 	notificationID := c.Param("id")
 
 	if err := h.notificationService.DismissNotification(notificationID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss notification"})
+		respondOwnedLookupError(c, err, "Notification not found", "Failed to dismiss notification")
 		return
 	}
 
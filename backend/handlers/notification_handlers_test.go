@@ -52,6 +52,66 @@ func TestUpdateNotificationPreferences_InvalidEmailAddress(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestUpdateNotificationPreferences_InvalidWebhookURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &NotificationHandler{notificationService: nil}
+
+	for _, url := range []string{"not-a-url", "http://hooks.example.com/alerts"} {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"webhook_url": url})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/notifications/preferences", bytes.NewBuffer(jsonBody))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", "test-user")
+
+		handler.UpdateNotificationPreferences(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func TestUpdateNotificationPreferences_InvalidPhoneNumber(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &NotificationHandler{notificationService: nil}
+
+	for _, phone := range []string{"555-123-4567", "+1415", "14155550123"} {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"phone_number": phone})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/notifications/preferences", bytes.NewBuffer(jsonBody))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", "test-user")
+
+		handler.UpdateNotificationPreferences(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, phone)
+	}
+}
+
+func TestConfirmPhoneVerification_InvalidCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &NotificationHandler{notificationService: nil}
+
+	for _, code := range []string{"", "12345", "1234567", "12a456"} {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"code": code})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/notifications/phone/verify/confirm", bytes.NewBuffer(jsonBody))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", "test-user")
+
+		handler.ConfirmPhoneVerification(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, code)
+	}
+}
+
 func TestUpdateNotificationPreferences_ValidPartialUpdate(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/httputil"
+	"investorcenter-api/services"
+)
+
+// Owned resources are looked up by (id, user_id), so another user's ID finds
+// nothing. Each case asks for a resource owned by someone else as user-1 and
+// expects the same 404 a nonexistent ID gets, never a 403.
+func TestOwnedResources_Mock_CrossUserReturnsNotFound(t *testing.T) {
+	alerts := newTestAlertHandler()
+	notifications := NewNotificationHandler(services.NewNotificationService(nil))
+
+	tests := []struct {
+		name   string
+		method string
+		route  string
+		path   string
+		body   string
+		handle gin.HandlerFunc
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "get watch list", method: http.MethodGet,
+			route: "/watchlists/:id", path: "/watchlists/wl-other",
+			handle: GetWatchList,
+			expect: expectNoRows("FROM watch_lists", "wl-other"),
+		},
+		{
+			name: "add ticker to watch list", method: http.MethodPost,
+			route: "/watchlists/:id/items", path: "/watchlists/wl-other/items",
+			body:   `{"symbol":"AAPL"}`,
+			handle: AddTickerToWatchList,
+			expect: expectNoRows("FROM watch_lists", "wl-other"),
+		},
+		{
+			name: "heatmap data", method: http.MethodGet,
+			route: "/watchlists/:id/heatmap", path: "/watchlists/wl-other/heatmap",
+			handle: GetHeatmapData,
+			expect: expectNoRows("FROM watch_lists", "wl-other"),
+		},
+		{
+			name: "get alert", method: http.MethodGet,
+			route: "/alerts/:id", path: "/alerts/alert-other",
+			handle: alerts.GetAlertRule,
+			expect: expectNoRows("FROM alert_rules", "alert-other"),
+		},
+		{
+			name: "update alert", method: http.MethodPut,
+			route: "/alerts/:id", path: "/alerts/alert-other",
+			body:   `{"name":"Mine now"}`,
+			handle: alerts.UpdateAlertRule,
+			expect: expectNoRowsAffected("UPDATE alert_rules"),
+		},
+		{
+			name: "delete alert", method: http.MethodDelete,
+			route: "/alerts/:id", path: "/alerts/alert-other",
+			handle: alerts.DeleteAlertRule,
+			expect: expectNoRowsAffected("DELETE FROM alert_rules", "alert-other"),
+		},
+		{
+			name: "test-fire alert", method: http.MethodPost,
+			route: "/alerts/:id/test", path: "/alerts/alert-other/test",
+			handle: alerts.TestAlertRule,
+			expect: expectNoRows("FROM alert_rules", "alert-other"),
+		},
+		{
+			name: "mark alert log read", method: http.MethodPost,
+			route: "/alerts/logs/:id/read", path: "/alerts/logs/log-other/read",
+			handle: alerts.MarkAlertLogRead,
+			expect: expectNoRowsAffected("UPDATE alert_logs", "log-other"),
+		},
+		{
+			name: "mark notification read", method: http.MethodPost,
+			route: "/notifications/:id/read", path: "/notifications/n-other/read",
+			handle: notifications.MarkNotificationRead,
+			expect: expectNoRowsAffected("UPDATE notification_queue", "n-other"),
+		},
+		{
+			name: "dismiss notification", method: http.MethodPost,
+			route: "/notifications/:id/dismiss", path: "/notifications/n-other/dismiss",
+			handle: notifications.DismissNotification,
+			expect: expectNoRowsAffected("UPDATE notification_queue", "n-other"),
+		},
+		{
+			name: "get portfolio", method: http.MethodGet,
+			route: "/portfolios/:id", path: "/portfolios/p-other",
+			handle: GetPortfolio,
+			expect: expectNoRows("FROM portfolios", "p-other"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup := setupMockDB(t)
+			defer cleanup()
+			tt.expect(mock)
+
+			r := setupMockRouter("user-1")
+			r.Handle(tt.method, tt.route, tt.handle)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, httputil.CodeNotFound, resp["code"])
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectNoRows expects a lookup of id filtered to user-1 that finds nothing.
+func expectNoRows(table, id string) func(sqlmock.Sqlmock) {
+	return func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(table).
+			WithArgs(id, "user-1").
+			WillReturnError(sql.ErrNoRows)
+	}
+}
+
+// expectNoRowsAffected expects a write filtered to user-1 that touches
+// nothing. With an id, the statement's args must start with (id, user-1).
+func expectNoRowsAffected(statement string, id ...string) func(sqlmock.Sqlmock) {
+	return func(mock sqlmock.Sqlmock) {
+		exec := mock.ExpectExec(statement)
+		if len(id) > 0 {
+			exec = exec.WithArgs(id[0], "user-1")
+		}
+		exec.WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes shared by list endpoints. DEFAULT_PAGE_LIMIT applies where an
+// endpoint has no default of its own; MAX_PAGE_LIMIT caps every ?limit= a
+// client can request so an oversized limit can't force an expensive query.
+var (
+	defaultPageLimit = loadPageLimit("DEFAULT_PAGE_LIMIT", 50)
+	maxPageLimit     = loadPageLimit("MAX_PAGE_LIMIT", 200)
+)
+
+func loadPageLimit(key string, def int) int {
+	return loadEnvInt(key, def, 1)
+}
+
+// loadEnvInt reads an integer setting from key, falling back to def (with a
+// warning) when it is set but not an integer of at least min.
+func loadEnvInt(key string, def, min int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < min {
+		log.Printf("⚠️  Ignoring invalid %s %q, using %d", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// parseLimit reads ?limit=, using def (or DEFAULT_PAGE_LIMIT when def is 0)
+// when it is missing or not a positive integer, and clamping it to
+// MAX_PAGE_LIMIT.
+func parseLimit(c *gin.Context, def int) int {
+	return parseLimitUpTo(c, def, maxPageLimit)
+}
+
+// parseLimitUpTo is parseLimit with an endpoint-specific cap in place of
+// MAX_PAGE_LIMIT, for endpoints with a tighter upstream limit or, like the
+// screener, a deliberately larger bulk response.
+func parseLimitUpTo(c *gin.Context, def, max int) int {
+	if def <= 0 {
+		def = defaultPageLimit
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = def
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit
+}
+
+// parseOffset reads ?offset=, treating a missing, invalid or negative
+// offset as 0.
+func parseOffset(c *gin.Context) int {
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// parsePage reads the 1-based ?page=, treating a missing or invalid page
+// as 1.
+func parsePage(c *gin.Context) int {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// parsePagination reads ?limit= and ?offset= for limit/offset list
+// endpoints. See parseLimit for how def applies.
+func parsePagination(c *gin.Context, def int) (limit, offset int) {
+	return parseLimit(c, def), parseOffset(c)
+}
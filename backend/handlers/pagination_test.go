@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func paginationContext(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/test?"+query, nil)
+	return c
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		def   int
+		want  int
+	}{
+		{"endpoint default", "", 20, 20},
+		{"global default", "", 0, defaultPageLimit},
+		{"requested", "limit=75", 20, 75},
+		{"clamped to max", "limit=100000", 20, maxPageLimit},
+		{"zero uses default", "limit=0", 20, 20},
+		{"negative uses default", "limit=-5", 20, 20},
+		{"invalid uses default", "limit=abc", 20, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseLimit(paginationContext(tt.query), tt.def))
+		})
+	}
+}
+
+func TestParseLimitUpTo(t *testing.T) {
+	assert.Equal(t, 10, parseLimitUpTo(paginationContext("limit=500"), 5, 10), "clamped to endpoint cap")
+	assert.Equal(t, 5, parseLimitUpTo(paginationContext(""), 5, 10))
+	assert.Equal(t, 10, parseLimitUpTo(paginationContext(""), 50, 10), "default above cap is clamped")
+	assert.Equal(t, 20000, parseLimitUpTo(paginationContext("limit=25000"), 20000, 20000), "bulk cap above MAX_PAGE_LIMIT")
+}
+
+func TestParseLimit_ConfiguredLimits(t *testing.T) {
+	origDefault, origMax := defaultPageLimit, maxPageLimit
+	defer func() { defaultPageLimit, maxPageLimit = origDefault, origMax }()
+	defaultPageLimit, maxPageLimit = 25, 100
+
+	assert.Equal(t, 25, parseLimit(paginationContext(""), 0))
+	assert.Equal(t, 100, parseLimit(paginationContext("limit=150"), 0))
+}
+
+func TestParseOffsetAndPage(t *testing.T) {
+	assert.Equal(t, 40, parseOffset(paginationContext("offset=40")))
+	assert.Equal(t, 0, parseOffset(paginationContext("offset=-1")))
+	assert.Equal(t, 0, parseOffset(paginationContext("offset=x")))
+
+	assert.Equal(t, 3, parsePage(paginationContext("page=3")))
+	assert.Equal(t, 1, parsePage(paginationContext("page=0")))
+	assert.Equal(t, 1, parsePage(paginationContext("")))
+
+	limit, offset := parsePagination(paginationContext("limit=999&offset=10"), 50)
+	assert.Equal(t, maxPageLimit, limit)
+	assert.Equal(t, 10, offset)
+}
+
+func TestLoadPageLimit(t *testing.T) {
+	t.Setenv("TEST_PAGE_LIMIT", "75")
+	assert.Equal(t, 75, loadPageLimit("TEST_PAGE_LIMIT", 50))
+
+	t.Setenv("TEST_PAGE_LIMIT", "0")
+	assert.Equal(t, 50, loadPageLimit("TEST_PAGE_LIMIT", 50))
+
+	t.Setenv("TEST_PAGE_LIMIT", "lots")
+	assert.Equal(t, 50, loadPageLimit("TEST_PAGE_LIMIT", 50))
+
+	assert.Equal(t, 50, loadPageLimit("TEST_PAGE_LIMIT_UNSET", 50))
+}
+
+func TestLoadEnvInt_AllowsMinimum(t *testing.T) {
+	t.Setenv("TEST_ENV_INT", "0")
+	assert.Equal(t, 0, loadEnvInt("TEST_ENV_INT", 30, 0))
+
+	t.Setenv("TEST_ENV_INT", "-1")
+	assert.Equal(t, 30, loadEnvInt("TEST_ENV_INT", 30, 0))
+}
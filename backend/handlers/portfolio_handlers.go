@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+var portfolioService = services.NewPortfolioService()
+
+// ListPortfolios returns all portfolios for the authenticated user
+func ListPortfolios(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolios, err := database.GetPortfoliosByUserID(userID)
+	if err != nil {
+		log.Printf("Error fetching portfolios for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch portfolios")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"portfolios": portfolios})
+}
+
+// CreatePortfolio creates a new portfolio
+func CreatePortfolio(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	portfolio := &models.Portfolio{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := database.CreatePortfolio(portfolio); err != nil {
+		log.Printf("Error creating portfolio for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create portfolio")
+		return
+	}
+
+	c.JSON(http.StatusCreated, portfolio)
+}
+
+// GetPortfolio retrieves a portfolio with its holdings valued at the latest
+// daily close and portfolio totals
+func GetPortfolio(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+
+	result, err := portfolioService.GetPortfolioWithHoldings(portfolioID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrPortfolioNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+		} else {
+			log.Printf("Error fetching portfolio %s for user %s: %v", portfolioID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch portfolio")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UpdatePortfolio updates portfolio metadata
+func UpdatePortfolio(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+
+	var req models.UpdatePortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	portfolio := &models.Portfolio{
+		ID:          portfolioID,
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := database.UpdatePortfolio(portfolio); err != nil {
+		if errors.Is(err, database.ErrPortfolioNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+		} else {
+			log.Printf("Error updating portfolio %s for user %s: %v", portfolioID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update portfolio")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// DeletePortfolio deletes a portfolio and its holdings
+func DeletePortfolio(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+
+	if err := database.DeletePortfolio(portfolioID, userID); err != nil {
+		if errors.Is(err, database.ErrPortfolioNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+		} else {
+			log.Printf("Error deleting portfolio %s for user %s: %v", portfolioID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete portfolio")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Portfolio deleted successfully"})
+}
+
+// GetPortfolioPerformance returns the daily value of the portfolio's current
+// holdings over ?period= (default 1M)
+func GetPortfolioPerformance(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+	period := c.DefaultQuery("period", "1M")
+
+	result, err := portfolioService.GetPortfolioPerformance(portfolioID, userID, period)
+	if err != nil {
+		if errors.Is(err, database.ErrPortfolioNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+		} else {
+			log.Printf("Error fetching performance for portfolio %s: %v", portfolioID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch portfolio performance")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AddPortfolioHolding adds a position to a portfolio
+func AddPortfolioHolding(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+	if !verifyPortfolioOwnership(c, portfolioID, userID) {
+		return
+	}
+
+	var req models.AddHoldingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	holding := &models.PortfolioHolding{
+		PortfolioID: portfolioID,
+		Symbol:      strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		Shares:      req.Shares,
+		AverageCost: req.AverageCost,
+	}
+
+	if err := database.AddPortfolioHolding(holding); err != nil {
+		switch {
+		case errors.Is(err, database.ErrHoldingAlreadyExists):
+			respondError(c, http.StatusConflict, httputil.CodeConflict, err.Error())
+		case errors.Is(err, database.ErrTickerNotFound):
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		case errors.Is(err, database.ErrHoldingHasTransactions):
+			respondError(c, http.StatusConflict, httputil.CodeConflict, err.Error())
+		default:
+			log.Printf("Error adding %s to portfolio %s: %v", holding.Symbol, portfolioID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to add holding")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, holding)
+}
+
+// UpdatePortfolioHolding replaces the shares and average cost of a position
+func UpdatePortfolioHolding(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+	if !verifyPortfolioOwnership(c, portfolioID, userID) {
+		return
+	}
+
+	var req models.UpdateHoldingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	holding := &models.PortfolioHolding{
+		PortfolioID: portfolioID,
+		Symbol:      strings.ToUpper(c.Param("symbol")),
+		Shares:      req.Shares,
+		AverageCost: req.AverageCost,
+	}
+
+	if err := database.UpdatePortfolioHolding(holding); err != nil {
+		switch {
+		case errors.Is(err, database.ErrPortfolioHoldingNotFound):
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Holding not found in portfolio")
+		case errors.Is(err, database.ErrHoldingHasTransactions):
+			respondError(c, http.StatusConflict, httputil.CodeConflict, err.Error())
+		default:
+			log.Printf("Error updating %s in portfolio %s: %v", holding.Symbol, portfolioID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update holding")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, holding)
+}
+
+// RemovePortfolioHolding removes a position and its transaction history from
+// a portfolio
+func RemovePortfolioHolding(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+	symbol := strings.ToUpper(c.Param("symbol"))
+	if !verifyPortfolioOwnership(c, portfolioID, userID) {
+		return
+	}
+
+	if err := database.RemovePortfolioHolding(portfolioID, symbol); err != nil {
+		if errors.Is(err, database.ErrPortfolioHoldingNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Holding not found in portfolio")
+		} else {
+			log.Printf("Error removing %s from portfolio %s: %v", symbol, portfolioID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to remove holding")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holding removed successfully"})
+}
+
+// verifyPortfolioOwnership writes a 404 or 500 response and returns false
+// unless userID owns the portfolio
+func verifyPortfolioOwnership(c *gin.Context, portfolioID, userID string) bool {
+	err := portfolioService.ValidatePortfolioOwnership(portfolioID, userID)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, database.ErrPortfolioNotFound) {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+	} else {
+		log.Printf("Error verifying portfolio %s for user %s: %v", portfolioID, userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to verify portfolio")
+	}
+	return false
+}
+
+// ListPortfolioTransactions returns a portfolio's transactions, newest first,
+// with realized gains on sells and the performance of open positions
+func ListPortfolioTransactions(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+
+	result, err := portfolioService.GetTransactionHistory(portfolioID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrPortfolioNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+		} else {
+			log.Printf("Error fetching transactions for portfolio %s: %v", portfolioID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch transactions")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreatePortfolioTransaction records a BUY or SELL and updates the holding's
+// shares and average cost
+func CreatePortfolioTransaction(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	portfolioID := c.Param("id")
+	if !verifyPortfolioOwnership(c, portfolioID, userID) {
+		return
+	}
+
+	var req models.CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	if msg := validateTransactionRequest(&req); msg != "" {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, msg)
+		return
+	}
+
+	txn := &models.PortfolioTransaction{
+		PortfolioID:     portfolioID,
+		Symbol:          strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		TransactionType: strings.ToUpper(req.TransactionType),
+		Shares:          req.Shares,
+		Price:           req.Price,
+	}
+	if req.ExecutedAt != nil {
+		txn.ExecutedAt = *req.ExecutedAt
+	}
+
+	if err := portfolioService.RecordTransaction(txn); err != nil {
+		switch {
+		case errors.Is(err, database.ErrInsufficientShares), errors.Is(err, database.ErrTickerNotFound):
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		case errors.Is(err, database.ErrPortfolioNotFound):
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Portfolio not found")
+		default:
+			log.Printf("Error recording %s %s in portfolio %s: %v", txn.TransactionType, txn.Symbol, portfolioID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to record transaction")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, txn)
+}
+
+// validateTransactionRequest returns an error message for amounts the
+// portfolio tables can't store exactly, or "" if the request is valid.
+// Shares allow 6 decimal places and prices 4, matching their columns.
+func validateTransactionRequest(req *models.CreateTransactionRequest) string {
+	switch {
+	case !req.Shares.IsPositive():
+		return "shares must be greater than 0"
+	case !req.Shares.Equal(req.Shares.Round(6)):
+		return "shares supports at most 6 decimal places"
+	case req.Price.IsNegative():
+		return "price must not be negative"
+	case !req.Price.Equal(req.Price.Round(4)):
+		return "price supports at most 4 decimal places"
+	case req.ExecutedAt != nil && req.ExecutedAt.After(time.Now()):
+		return "executed_at must not be in the future"
+	}
+	return ""
+}
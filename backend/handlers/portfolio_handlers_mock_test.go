@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"investorcenter-api/models"
+)
+
+var portfolioCols = []string{"id", "user_id", "name", "description", "created_at", "updated_at"}
+
+// ---------------------------------------------------------------------------
+// Portfolio CRUD — DB-backed mock tests
+// ---------------------------------------------------------------------------
+
+func TestListPortfolios_NoAuth(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.GET("/portfolios", ListPortfolios)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/portfolios", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestListPortfolios_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios p LEFT JOIN portfolio_holdings").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_at", "updated_at", "holding_count"}).
+			AddRow("pf-1", "Core", nil, now, now, 4))
+
+	r := setupMockRouter("user-1")
+	r.GET("/portfolios", ListPortfolios)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/portfolios", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string][]map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp["portfolios"], 1)
+	assert.Equal(t, float64(4), resp["portfolios"][0]["holding_count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePortfolio_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO portfolios").
+		WithArgs("user-1", "Retirement", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("pf-1", now, now))
+
+	r := setupMockRouter("user-1")
+	r.POST("/portfolios", CreatePortfolio)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/portfolios", bytes.NewBufferString(`{"name":"Retirement"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"pf-1"`)
+	assert.Contains(t, w.Body.String(), `"user_id":"user-1"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePortfolio_MissingName(t *testing.T) {
+	r := setupMockRouter("user-1")
+	r.POST("/portfolios", CreatePortfolio)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/portfolios", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetPortfolio_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs("pf-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+	mock.ExpectQuery("SELECT .+ FROM portfolio_holdings ph").
+		WithArgs("pf-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "portfolio_id", "symbol", "shares", "average_cost", "added_at", "updated_at",
+			"name", "current_price", "price_date",
+		}).AddRow("h-1", "pf-1", "AAPL", 10.0, 150.0, now, now, "Apple Inc.", 200.0, now).
+			AddRow("h-2", "pf-1", "NEWCO", 1.0, 20.0, now, now, "New Listing Co", nil, nil))
+
+	r := setupMockRouter("user-1")
+	r.GET("/portfolios/:id", GetPortfolio)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/portfolios/pf-1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Holdings []struct {
+			Symbol      string   `json:"symbol"`
+			MarketValue *float64 `json:"market_value"`
+		} `json:"holdings"`
+		TotalMarketValue float64 `json:"total_market_value"`
+		TotalCostBasis   float64 `json:"total_cost_basis"`
+		UnpricedCount    int     `json:"unpriced_count"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Holdings, 2)
+	require.NotNil(t, resp.Holdings[0].MarketValue)
+	assert.InDelta(t, 2000.0, *resp.Holdings[0].MarketValue, 1e-9)
+	assert.Nil(t, resp.Holdings[1].MarketValue)
+	assert.InDelta(t, 2000.0, resp.TotalMarketValue, 1e-9)
+	assert.InDelta(t, 1520.0, resp.TotalCostBasis, 1e-9)
+	assert.Equal(t, 1, resp.UnpricedCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPortfolio_Mock_OtherUsersPortfolio(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs("pf-1", "user-2").
+		WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-2")
+	r.GET("/portfolios/:id", GetPortfolio)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/portfolios/pf-1", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePortfolio_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM portfolios WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs("pf-9", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := setupMockRouter("user-1")
+	r.DELETE("/portfolios/:id", DeletePortfolio)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/portfolios/pf-9", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPortfolioPerformance_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	day := 24 * time.Hour
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WithArgs("pf-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+	mock.ExpectQuery("SELECT .+ SUM\\(ph.shares \\* sp.close\\)").
+		WithArgs("pf-1", 90).
+		WillReturnRows(sqlmock.NewRows([]string{"date", "value"}).
+			AddRow(now.Add(-2*day), 1000.0).
+			AddRow(now.Add(-day), 1100.0))
+	mock.ExpectQuery("SELECT .+ FROM portfolio_holdings ph").
+		WithArgs("pf-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "portfolio_id", "symbol", "shares", "average_cost", "added_at", "updated_at",
+			"name", "current_price", "price_date",
+		}).AddRow("h-1", "pf-1", "AAPL", 10.0, 90.0, now, now, "Apple Inc.", 110.0, now))
+
+	r := setupMockRouter("user-1")
+	r.GET("/portfolios/:id/performance", GetPortfolioPerformance)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/portfolios/pf-1/performance?period=3m", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Period         string   `json:"period"`
+		TotalCostBasis float64  `json:"total_cost_basis"`
+		Change         *float64 `json:"change"`
+		ChangePct      *float64 `json:"change_pct"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "3M", resp.Period)
+	assert.InDelta(t, 900.0, resp.TotalCostBasis, 1e-9)
+	require.NotNil(t, resp.ChangePct)
+	assert.InDelta(t, 100.0, *resp.Change, 1e-9)
+	assert.InDelta(t, 10.0, *resp.ChangePct, 1e-9)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// Portfolio holdings — DB-backed mock tests
+// ---------------------------------------------------------------------------
+
+func TestAddPortfolioHolding_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WithArgs("pf-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM portfolio_transactions").
+		WithArgs("pf-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO portfolio_holdings").
+		WithArgs("pf-1", "AAPL", 10.0, 150.5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "added_at", "updated_at"}).AddRow("h-1", now, now))
+
+	r := setupMockRouter("user-1")
+	r.POST("/portfolios/:id/holdings", AddPortfolioHolding)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/portfolios/pf-1/holdings",
+		bytes.NewBufferString(`{"symbol":" aapl ","shares":10,"average_cost":150.5}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"symbol":"AAPL"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddPortfolioHolding_Mock_NotOwner(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WithArgs("pf-1", "user-2").
+		WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-2")
+	r.POST("/portfolios/:id/holdings", AddPortfolioHolding)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/portfolios/pf-1/holdings",
+		bytes.NewBufferString(`{"symbol":"AAPL","shares":10,"average_cost":150}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddPortfolioHolding_InvalidShares(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+
+	r := setupMockRouter("user-1")
+	r.POST("/portfolios/:id/holdings", AddPortfolioHolding)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/portfolios/pf-1/holdings",
+		bytes.NewBufferString(`{"symbol":"AAPL","shares":-1,"average_cost":150}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRemovePortfolioHolding_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WithArgs("pf-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+	mock.ExpectExec("DELETE FROM portfolio_holdings").
+		WithArgs("pf-1", "MSFT").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := setupMockRouter("user-1")
+	r.DELETE("/portfolios/:id/holdings/:symbol", RemovePortfolioHolding)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/portfolios/pf-1/holdings/msft", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// Portfolio transactions — DB-backed mock tests
+// ---------------------------------------------------------------------------
+
+var portfolioTransactionCols = []string{
+	"id", "portfolio_id", "symbol", "transaction_type", "shares", "price", "executed_at", "created_at",
+}
+
+// expectTransactionPreamble expects the ownership check, portfolio lock and
+// AAPL history load that every recorded transaction starts with
+func expectTransactionPreamble(mock sqlmock.Sqlmock, history *sqlmock.Rows) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WithArgs("pf-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM portfolios WHERE id = \\$1 FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("pf-1"))
+	mock.ExpectQuery("FROM portfolio_transactions").
+		WithArgs("pf-1", "AAPL").
+		WillReturnRows(history)
+}
+
+func postTransaction(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/portfolios/pf-1/transactions", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreatePortfolioTransaction_Mock_SellRealizesFIFOGain(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	expectTransactionPreamble(mock, sqlmock.NewRows(portfolioTransactionCols).
+		AddRow("tx-1", "pf-1", "AAPL", "BUY", "1.5", "100", now.Add(-48*time.Hour), now.Add(-48*time.Hour)).
+		AddRow("tx-2", "pf-1", "AAPL", "BUY", "2", "130", now.Add(-24*time.Hour), now.Add(-24*time.Hour)))
+	mock.ExpectQuery("INSERT INTO portfolio_transactions").
+		WithArgs("pf-1", "AAPL", "SELL", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at", "created_at"}).AddRow("tx-3", now, now))
+	// 1.5 @ 100 and 0.25 @ 130 are sold; 1.75 @ 130 remain
+	mock.ExpectExec("INSERT INTO portfolio_holdings .+ ON CONFLICT").
+		WithArgs("pf-1", "AAPL", decimal.RequireFromString("1.75"), decimal.RequireFromString("130")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	r := setupMockRouter("user-1")
+	r.POST("/portfolios/:id/transactions", CreatePortfolioTransaction)
+
+	w := postTransaction(r, `{"symbol":"aapl","transaction_type":"sell","shares":1.75,"price":150}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var resp models.PortfolioTransaction
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "SELL", resp.TransactionType)
+	require.NotNil(t, resp.RealizedGain)
+	assert.Equal(t, "80", resp.RealizedGain.String(), "1.5*(150-100) + 0.25*(150-130)")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePortfolioTransaction_Mock_OversellReturns400(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	expectTransactionPreamble(mock, sqlmock.NewRows(portfolioTransactionCols).
+		AddRow("tx-1", "pf-1", "AAPL", "BUY", "2", "100", now.Add(-time.Hour), now.Add(-time.Hour)))
+	mock.ExpectQuery("INSERT INTO portfolio_transactions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at", "created_at"}).AddRow("tx-2", now, now))
+	mock.ExpectRollback()
+
+	r := setupMockRouter("user-1")
+	r.POST("/portfolios/:id/transactions", CreatePortfolioTransaction)
+
+	w := postTransaction(r, `{"symbol":"AAPL","transaction_type":"SELL","shares":"2.000001","price":"110"}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "cannot sell more shares than held")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePortfolioTransaction_InvalidAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"zero shares", `{"symbol":"AAPL","transaction_type":"BUY","shares":0,"price":10}`, "shares must be greater than 0"},
+		{"too precise shares", `{"symbol":"AAPL","transaction_type":"BUY","shares":"0.1234567","price":10}`, "at most 6 decimal places"},
+		{"negative price", `{"symbol":"AAPL","transaction_type":"BUY","shares":1,"price":-1}`, "price must not be negative"},
+		{"too precise price", `{"symbol":"AAPL","transaction_type":"BUY","shares":1,"price":10.12345}`, "at most 4 decimal places"},
+		{"future date", `{"symbol":"AAPL","transaction_type":"BUY","shares":1,"price":10,"executed_at":"2999-01-01T00:00:00Z"}`, "future"},
+		{"bad type", `{"symbol":"AAPL","transaction_type":"HOLD","shares":1,"price":10}`, "TransactionType"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup := setupMockDB(t)
+			defer cleanup()
+
+			now := time.Now()
+			mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+				WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+
+			r := setupMockRouter("user-1")
+			r.POST("/portfolios/:id/transactions", CreatePortfolioTransaction)
+
+			w := postTransaction(r, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestListPortfolioTransactions_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM portfolios WHERE id = \\$1").
+		WithArgs("pf-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("pf-1", "user-1", "Core", nil, now, now))
+	mock.ExpectQuery("FROM portfolio_transactions").
+		WithArgs("pf-1").
+		WillReturnRows(sqlmock.NewRows(portfolioTransactionCols).
+			AddRow("tx-1", "pf-1", "AAPL", "BUY", "4", "100", now.Add(-48*time.Hour), now).
+			AddRow("tx-2", "pf-1", "AAPL", "SELL", "1", "120", now.Add(-24*time.Hour), now))
+	mock.ExpectQuery("SELECT .+ FROM portfolio_holdings ph").
+		WithArgs("pf-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "portfolio_id", "symbol", "shares", "average_cost", "added_at", "updated_at",
+			"name", "current_price", "price_date",
+		}).
+			AddRow("h-1", "pf-1", "AAPL", 3.0, 100.0, now, now, "Apple Inc.", 110.0, now))
+
+	r := setupMockRouter("user-1")
+	r.GET("/portfolios/:id/transactions", ListPortfolioTransactions)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/portfolios/pf-1/transactions", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp models.PortfolioTransactionHistory
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Transactions, 2)
+	assert.Equal(t, "tx-2", resp.Transactions[0].ID, "newest first")
+	require.NotNil(t, resp.Transactions[0].RealizedGain)
+	assert.Equal(t, "20", resp.Transactions[0].RealizedGain.String())
+	assert.Equal(t, "20", resp.Performance.TotalRealizedGain.String())
+	assert.Equal(t, "30", resp.Performance.TotalUnrealizedGain.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
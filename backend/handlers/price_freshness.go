@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/market"
+)
+
+// stalePriceGrace is how far behind a price may lag before it is labeled
+// stale: behind now while the market is open, or behind the last session
+// close while it is shut.
+const stalePriceGrace = 15 * time.Minute
+
+// Market status labels returned alongside prices
+const (
+	marketStatusOpen   = "open"
+	marketStatusClosed = "closed"
+)
+
+// priceFreshness labels a price observed at asOf. marketStatus tells
+// clients whether to show the price as live ("open") or as the last close
+// ("closed"); isStale flags prices that are older than that label implies
+// (e.g. no tick for 15 minutes mid-session, or a close from days ago).
+// Crypto trades around the clock and is always "open".
+func priceFreshness(asOf, now time.Time, isCrypto bool, cal *market.Calendar) gin.H {
+	status := marketStatusClosed
+	reference := cal.LastClose(now)
+	if isCrypto || cal.IsMarketOpen(now) {
+		status = marketStatusOpen
+		reference = now
+	}
+
+	return gin.H{
+		"marketStatus": status,
+		"isStale":      asOf.IsZero() || reference.Sub(asOf) > stalePriceGrace,
+		"asOf":         asOf.UTC().Format(time.RFC3339),
+	}
+}
+
+// withPriceFreshness adds priceFreshness labels to a price payload
+func withPriceFreshness(data gin.H, asOf time.Time, isCrypto bool) gin.H {
+	for k, v := range priceFreshness(asOf, time.Now(), isCrypto, market.Default()) {
+		data[k] = v
+	}
+	return data
+}
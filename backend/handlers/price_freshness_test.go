@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/market"
+)
+
+func freshnessCalendar(t *testing.T) *market.Calendar {
+	t.Helper()
+	cal, err := market.NewCalendar(market.DefaultHolidays, market.DefaultHalfDays)
+	require.NoError(t, err)
+	return cal
+}
+
+func TestPriceFreshness_MarketOpen(t *testing.T) {
+	cal := freshnessCalendar(t)
+	now := time.Date(2026, 3, 10, 11, 0, 0, 0, cal.Location) // Tuesday mid-session
+
+	live := priceFreshness(now.Add(-30*time.Second), now, false, cal)
+	assert.Equal(t, "open", live["marketStatus"])
+	assert.Equal(t, false, live["isStale"])
+	assert.Equal(t, "2026-03-10T14:59:30Z", live["asOf"])
+
+	lagging := priceFreshness(now.Add(-20*time.Minute), now, false, cal)
+	assert.Equal(t, "open", lagging["marketStatus"])
+	assert.Equal(t, true, lagging["isStale"])
+}
+
+func TestPriceFreshness_MarketClosed(t *testing.T) {
+	cal := freshnessCalendar(t)
+	lastClose := time.Date(2026, 3, 10, 16, 0, 0, 0, cal.Location)
+
+	// Evening: the 4 PM close is the expected price
+	evening := lastClose.Add(4 * time.Hour)
+	closing := priceFreshness(lastClose.Add(-time.Minute), evening, false, cal)
+	assert.Equal(t, "closed", closing["marketStatus"])
+	assert.Equal(t, false, closing["isStale"])
+
+	// A price from the previous session is stale even though the market is shut
+	old := priceFreshness(lastClose.AddDate(0, 0, -1), evening, false, cal)
+	assert.Equal(t, "closed", old["marketStatus"])
+	assert.Equal(t, true, old["isStale"])
+
+	// Weekend and holiday: Friday's (or the pre-holiday) close is current
+	saturday := time.Date(2026, 3, 14, 12, 0, 0, 0, cal.Location)
+	friday := time.Date(2026, 3, 13, 16, 0, 0, 0, cal.Location)
+	assert.Equal(t, false, priceFreshness(friday, saturday, false, cal)["isStale"])
+
+	goodFriday := time.Date(2026, 4, 3, 12, 0, 0, 0, cal.Location)
+	thursdayClose := time.Date(2026, 4, 2, 16, 0, 0, 0, cal.Location)
+	f := priceFreshness(thursdayClose, goodFriday, false, cal)
+	assert.Equal(t, "closed", f["marketStatus"])
+	assert.Equal(t, false, f["isStale"])
+}
+
+func TestPriceFreshness_PreMarket(t *testing.T) {
+	cal := freshnessCalendar(t)
+	preMarket := time.Date(2026, 3, 10, 8, 0, 0, 0, cal.Location)
+	prevClose := time.Date(2026, 3, 9, 16, 0, 0, 0, cal.Location)
+
+	f := priceFreshness(prevClose, preMarket, false, cal)
+	assert.Equal(t, "closed", f["marketStatus"])
+	assert.Equal(t, false, f["isStale"])
+}
+
+func TestPriceFreshness_Crypto(t *testing.T) {
+	cal := freshnessCalendar(t)
+	saturday := time.Date(2026, 3, 14, 12, 0, 0, 0, cal.Location)
+
+	f := priceFreshness(saturday.Add(-time.Minute), saturday, true, cal)
+	assert.Equal(t, "open", f["marketStatus"])
+	assert.Equal(t, false, f["isStale"])
+
+	assert.Equal(t, true, priceFreshness(saturday.Add(-time.Hour), saturday, true, cal)["isStale"])
+}
+
+func TestPriceFreshness_ZeroTimestampIsStale(t *testing.T) {
+	cal := freshnessCalendar(t)
+	now := time.Date(2026, 3, 10, 11, 0, 0, 0, cal.Location)
+	assert.Equal(t, true, priceFreshness(time.Time{}, now, false, cal)["isStale"])
+}
+
+func TestWithPriceFreshness_AddsFields(t *testing.T) {
+	data := withPriceFreshness(gin.H{"price": "1.00"}, time.Now(), true)
+	assert.Equal(t, "1.00", data["price"])
+	assert.Equal(t, "open", data["marketStatus"])
+	assert.Contains(t, data, "isStale")
+	assert.Contains(t, data, "asOf")
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+// maxPriceRefreshSymbols caps the symbols accepted by one refresh request.
+const maxPriceRefreshSymbols = 100
+
+// newPriceRefreshService is swapped out in tests to avoid calling Polygon.
+var newPriceRefreshService = services.NewPriceRefreshService
+
+// RequireAdminOrWorker allows admins and worker accounts (data pipelines).
+// Must be used AFTER auth.AuthMiddleware.
+func RequireAdminOrWorker() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := auth.UserID(c)
+		if !ok {
+			respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Unauthorized - authentication required")
+			return
+		}
+
+		if auth.IsAdmin(c) {
+			c.Next()
+			return
+		}
+
+		user, err := database.GetUserByID(userID)
+		if err != nil || !user.IsWorker {
+			respondError(c, http.StatusForbidden, httputil.CodeForbidden, "Forbidden - admin or worker access required")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RefreshPrices handles POST /api/v1/prices/refresh
+// Fetches current prices for a list of symbols from Polygon and upserts
+// today's 1day bar in stock_prices. Each symbol succeeds or fails on its own;
+// the response lists the outcome per symbol in request order.
+func RefreshPrices(c *gin.Context) {
+	var req models.PriceRefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request", err.Error())
+		return
+	}
+
+	symbols, ok := refreshSymbols(c, req.Symbols, maxPriceRefreshSymbols)
+	if !ok {
+		return
+	}
+
+	results := newPriceRefreshService().Refresh(c.Request.Context(), symbols)
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+	log.Printf("Price refresh: %d/%d symbols updated", succeeded, len(symbols))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"requested": len(symbols),
+			"succeeded": succeeded,
+			"failed":    len(symbols) - succeeded,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
+
+// refreshSymbols upper-cases and de-duplicates the symbols of a bulk refresh
+// request, preserving order. It responds 400 and returns false when none
+// are left or there are more than max.
+func refreshSymbols(c *gin.Context, raw []string, max int) ([]string, bool) {
+	symbols := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, s := range raw {
+		symbol := strings.ToUpper(strings.TrimSpace(s))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+
+	if len(symbols) == 0 {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "At least one symbol is required")
+		return nil, false
+	}
+	if len(symbols) > max {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, fmt.Sprintf("Too many symbols. Maximum is %d", max))
+		return nil, false
+	}
+	return symbols, true
+}
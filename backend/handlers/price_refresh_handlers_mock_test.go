@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+type fakeQuoteFetcher struct {
+	prices map[string]float64
+}
+
+func (f *fakeQuoteFetcher) GetStockRealTimePrice(symbol string) (*models.StockPrice, error) {
+	return f.get(symbol)
+}
+
+func (f *fakeQuoteFetcher) GetCryptoRealTimePrice(symbol string) (*models.StockPrice, error) {
+	return f.get(symbol)
+}
+
+func (f *fakeQuoteFetcher) get(symbol string) (*models.StockPrice, error) {
+	p, ok := f.prices[symbol]
+	if !ok {
+		return nil, errors.New("stock snapshot API request failed with status: 404")
+	}
+	d := decimal.NewFromFloat(p)
+	return &models.StockPrice{Symbol: symbol, Price: d, Open: d, High: d, Low: d, Close: d, Timestamp: time.Now()}, nil
+}
+
+type fakePriceStore struct {
+	stored []string
+}
+
+func (s *fakePriceStore) UpsertDailyPrice(ctx context.Context, price *models.StockPrice) error {
+	s.stored = append(s.stored, price.Symbol)
+	return nil
+}
+
+func useFakePriceRefresh(t *testing.T, prices map[string]float64) *fakePriceStore {
+	t.Helper()
+	store := &fakePriceStore{}
+	orig := newPriceRefreshService
+	newPriceRefreshService = func() *services.PriceRefreshService {
+		return services.NewPriceRefreshServiceWith(&fakeQuoteFetcher{prices: prices}, store, 2, 0)
+	}
+	t.Cleanup(func() { newPriceRefreshService = orig })
+	return store
+}
+
+func postRefresh(r *gin.Engine, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/prices/refresh", bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRefreshPrices_PerSymbolResults(t *testing.T) {
+	store := useFakePriceRefresh(t, map[string]float64{"AAPL": 190.5, "X:BTCUSD": 65000})
+
+	r := setupMockRouterNoAuth()
+	r.POST("/api/v1/prices/refresh", RefreshPrices)
+
+	w := postRefresh(r, map[string]interface{}{"symbols": []string{"aapl", "NOPE", "X:BTCUSD", "AAPL"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []services.PriceRefreshResult `json:"data"`
+		Meta map[string]interface{}        `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 3)
+	assert.Equal(t, "AAPL", resp.Data[0].Symbol)
+	assert.True(t, resp.Data[0].Success)
+	assert.Equal(t, 190.5, *resp.Data[0].Price)
+	assert.Equal(t, "NOPE", resp.Data[1].Symbol)
+	assert.False(t, resp.Data[1].Success)
+	assert.Contains(t, resp.Data[1].Error, "404")
+	assert.True(t, resp.Data[2].Success)
+	assert.Equal(t, float64(3), resp.Meta["requested"])
+	assert.Equal(t, float64(2), resp.Meta["succeeded"])
+	assert.Equal(t, float64(1), resp.Meta["failed"])
+	assert.ElementsMatch(t, []string{"AAPL", "X:BTCUSD"}, store.stored)
+}
+
+func TestRefreshPrices_Validation(t *testing.T) {
+	useFakePriceRefresh(t, nil)
+
+	r := setupMockRouterNoAuth()
+	r.POST("/api/v1/prices/refresh", RefreshPrices)
+
+	assert.Equal(t, http.StatusBadRequest, postRefresh(r, map[string]interface{}{}).Code)
+	assert.Equal(t, http.StatusBadRequest, postRefresh(r, map[string]interface{}{"symbols": []string{" "}}).Code)
+
+	many := make([]string, maxPriceRefreshSymbols+1)
+	for i := range many {
+		many[i] = "T" + string(rune('A'+i%26)) + string(rune('A'+i/26))
+	}
+	assert.Equal(t, http.StatusBadRequest, postRefresh(r, map[string]interface{}{"symbols": many}).Code)
+}
+
+func TestRequireAdminOrWorker(t *testing.T) {
+	useFakePriceRefresh(t, map[string]float64{"AAPL": 1})
+	body := map[string]interface{}{"symbols": []string{"AAPL"}}
+
+	userRow := func(isWorker bool) *sqlmock.Rows {
+		now := time.Now()
+		return sqlmock.NewRows([]string{
+			"id", "email", "password_hash", "full_name", "timezone",
+			"created_at", "updated_at", "last_login_at", "email_verified",
+			"is_premium", "is_active", "is_admin", "is_worker", "last_activity_at",
+		}).AddRow(
+			"user-1", "test@example.com", nil, "Test User", "UTC",
+			now, now, nil, true,
+			false, true, false, isWorker, nil,
+		)
+	}
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		r := setupMockRouterNoAuth()
+		r.POST("/api/v1/prices/refresh", RequireAdminOrWorker(), RefreshPrices)
+		assert.Equal(t, http.StatusUnauthorized, postRefresh(r, body).Code)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		r := setupMockRouterNoAuth()
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", "admin-1")
+			c.Set("is_admin", true)
+			c.Next()
+		})
+		r.POST("/api/v1/prices/refresh", RequireAdminOrWorker(), RefreshPrices)
+		assert.Equal(t, http.StatusOK, postRefresh(r, body).Code)
+	})
+
+	t.Run("worker", func(t *testing.T) {
+		mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+			WithArgs("user-1").
+			WillReturnRows(userRow(true))
+
+		r := setupMockRouter("user-1")
+		r.POST("/api/v1/prices/refresh", RequireAdminOrWorker(), RefreshPrices)
+		assert.Equal(t, http.StatusOK, postRefresh(r, body).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("regular user", func(t *testing.T) {
+		mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+			WithArgs("user-1").
+			WillReturnRows(userRow(false))
+
+		r := setupMockRouter("user-1")
+		r.POST("/api/v1/prices/refresh", RequireAdminOrWorker(), RefreshPrices)
+		assert.Equal(t, http.StatusForbidden, postRefresh(r, body).Code)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+			WithArgs("user-1").
+			WillReturnError(sql.ErrNoRows)
+
+		r := setupMockRouter("user-1")
+		r.POST("/api/v1/prices/refresh", RequireAdminOrWorker(), RefreshPrices)
+		assert.Equal(t, http.StatusForbidden, postRefresh(r, body).Code)
+	})
+}
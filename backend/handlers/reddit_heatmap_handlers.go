@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -58,10 +59,7 @@ func GetRedditHeatmap(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch Reddit heatmap data",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch Reddit heatmap data", err.Error())
 		return
 	}
 
@@ -129,9 +127,7 @@ func GetRedditPipelineHealth(c *gin.Context) {
 	health, err := database.GetRedditPipelineHealth()
 	if err != nil {
 		log.Printf("Error fetching pipeline health: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch pipeline health",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch pipeline health")
 		return
 	}
 	c.JSON(http.StatusOK, health)
@@ -145,9 +141,7 @@ func GetRedditPipelineHealth(c *gin.Context) {
 func GetTickerRedditHistory(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Symbol is required")
 		return
 	}
 
@@ -165,18 +159,11 @@ func GetTickerRedditHistory(c *gin.Context) {
 	history, err := database.GetTickerRedditHistory(symbol, days)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":  "No Reddit data found for this ticker",
-				"symbol": symbol,
-				"days":   days,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "No Reddit data found for this ticker")
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch Reddit history",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch Reddit history", err.Error())
 		return
 	}
 
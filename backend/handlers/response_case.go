@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"mime"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseEnvelopeVersion identifies the {"data", "meta"} response envelope
+// shape. It is sent on every response so clients can detect envelope changes.
+const ResponseEnvelopeVersion = "1"
+
+// Response key conventions a client can request with ?case= or an Accept
+// parameter such as "Accept: application/json; case=snake".
+const (
+	ResponseCaseCamel = "camel"
+	ResponseCaseSnake = "snake"
+)
+
+// ResponseCaseMiddleware rewrites JSON object keys into the case convention
+// the client asks for. Without a case preference responses are untouched, so
+// existing clients keep the current mixed camelCase/snake_case payloads.
+// Keys without lowercase letters (ticker symbols like "AAPL") are never changed.
+func ResponseCaseMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Envelope-Version", ResponseEnvelopeVersion)
+
+		keyCase := requestedResponseCase(c)
+		if keyCase == "" {
+			c.Next()
+			return
+		}
+
+		w := &caseConvertingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Header("X-Response-Case", keyCase)
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.passthrough || w.body.Len() == 0 {
+			return
+		}
+
+		body := w.body.Bytes()
+		if strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+			if converted, err := convertJSONKeys(body, keyCase); err == nil {
+				body = converted
+			} else {
+				log.Printf("Response case conversion skipped for %s: %v", c.Request.URL.Path, err)
+			}
+		}
+		if _, err := w.ResponseWriter.Write(body); err != nil {
+			log.Printf("Error writing response for %s: %v", c.Request.URL.Path, err)
+		}
+	}
+}
+
+// requestedResponseCase returns the case convention requested by the client,
+// preferring the query parameter over the Accept header, or "" for default.
+func requestedResponseCase(c *gin.Context) string {
+	if keyCase := normalizeResponseCase(c.Query("case")); keyCase != "" {
+		return keyCase
+	}
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		if _, params, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil {
+			if keyCase := normalizeResponseCase(params["case"]); keyCase != "" {
+				return keyCase
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeResponseCase(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "camel", "camelcase":
+		return ResponseCaseCamel
+	case "snake", "snake_case":
+		return ResponseCaseSnake
+	}
+	return ""
+}
+
+// caseConvertingWriter buffers the response body so its keys can be rewritten.
+// A Flush (e.g. server-sent events) switches it to pass-through.
+type caseConvertingWriter struct {
+	gin.ResponseWriter
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (w *caseConvertingWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *caseConvertingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *caseConvertingWriter) Flush() {
+	if !w.passthrough {
+		w.passthrough = true
+		if w.body.Len() > 0 {
+			w.ResponseWriter.Write(w.body.Bytes())
+			w.body.Reset()
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// convertJSONKeys rewrites every object key in a JSON document.
+func convertJSONKeys(body []byte, keyCase string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	convert := toCamelCaseKey
+	if keyCase == ResponseCaseSnake {
+		convert = toSnakeCaseKey
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(convertKeys(value, convert)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func convertKeys(value interface{}, convert func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[convert(key)] = convertKeys(item, convert)
+		}
+		return out
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertKeys(item, convert)
+		}
+		return v
+	}
+	return value
+}
+
+// hasLower reports whether s contains a lowercase letter. Keys without one are
+// data (ticker symbols, sector codes) rather than field names.
+func hasLower(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLower) >= 0
+}
+
+// toSnakeCaseKey converts "changePercent" to "change_percent" and
+// "logoURL" to "logo_url". snake_case keys are returned unchanged.
+func toSnakeCaseKey(key string) string {
+	if !hasLower(key) {
+		return key
+	}
+
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteRune('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toCamelCaseKey converts "pe_ratio" to "peRatio". camelCase keys are
+// returned unchanged.
+func toCamelCaseKey(key string) string {
+	if !strings.Contains(key, "_") || !hasLower(key) {
+		return key
+	}
+
+	parts := strings.Split(key, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		runes := []rune(part)
+		if b.Len() > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupResponseCaseRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ResponseCaseMiddleware())
+	r.GET("/mixed", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"data": []gin.H{{
+				"symbol":        "AAPL",
+				"changePercent": 1.25,
+				"pe_ratio":      28.4,
+				"logoURL":       "https://logo/aapl.png",
+			}},
+			"meta": gin.H{
+				"has_more": false,
+				"bySector": gin.H{"AAPL": 1, "BRK.B": 2},
+			},
+		})
+	})
+	return r
+}
+
+func getResponseCase(t *testing.T, r *gin.Engine, url, accept string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w, body
+}
+
+func TestResponseCaseMiddleware_DefaultUnchanged(t *testing.T) {
+	w, body := getResponseCase(t, setupResponseCaseRouter(), "/mixed", "")
+
+	item := body["data"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, item, "changePercent")
+	assert.Contains(t, item, "pe_ratio")
+	assert.Equal(t, ResponseEnvelopeVersion, w.Header().Get("X-Envelope-Version"))
+	assert.Empty(t, w.Header().Get("X-Response-Case"))
+}
+
+func TestResponseCaseMiddleware_SameDataBothConventions(t *testing.T) {
+	r := setupResponseCaseRouter()
+
+	w, camel := getResponseCase(t, r, "/mixed?case=camel", "")
+	assert.Equal(t, ResponseCaseCamel, w.Header().Get("X-Response-Case"))
+	camelItem := camel["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"symbol":        "AAPL",
+		"changePercent": 1.25,
+		"peRatio":       28.4,
+		"logoURL":       "https://logo/aapl.png",
+	}, camelItem)
+	assert.Contains(t, camel["meta"], "hasMore")
+
+	w, snake := getResponseCase(t, r, "/mixed", "application/json; case=snake")
+	assert.Equal(t, ResponseCaseSnake, w.Header().Get("X-Response-Case"))
+	snakeItem := snake["data"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"symbol":         "AAPL",
+		"change_percent": 1.25,
+		"pe_ratio":       28.4,
+		"logo_url":       "https://logo/aapl.png",
+	}, snakeItem)
+
+	// Ticker-symbol keys are data, not field names
+	snakeMeta := snake["meta"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"AAPL": 1.0, "BRK.B": 2.0}, snakeMeta["by_sector"])
+}
+
+func TestResponseCaseMiddleware_QueryOverridesAccept(t *testing.T) {
+	_, body := getResponseCase(t, setupResponseCaseRouter(), "/mixed?case=camel", "application/json; case=snake")
+	item := body["data"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, item, "peRatio")
+}
+
+func TestResponseCaseMiddleware_NonJSONPassthrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ResponseCaseMiddleware())
+	r.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusCreated, "plain_text_body")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/text?case=camel", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "plain_text_body", w.Body.String())
+}
+
+func TestResponseCaseKeyConversion(t *testing.T) {
+	snake := map[string]string{
+		"changePercent": "change_percent",
+		"logoURL":       "logo_url",
+		"week52High":    "week52_high",
+		"pe_ratio":      "pe_ratio",
+		"AAPL":          "AAPL",
+		"ID":            "ID",
+	}
+	for in, want := range snake {
+		assert.Equal(t, want, toSnakeCaseKey(in), in)
+	}
+
+	camel := map[string]string{
+		"pe_ratio":     "peRatio",
+		"week_52_high": "week52High",
+		"has_more":     "hasMore",
+		"symbol":       "symbol",
+		"BRK_B":        "BRK_B",
+	}
+	for in, want := range camel {
+		assert.Equal(t, want, toCamelCaseKey(in), in)
+	}
+}
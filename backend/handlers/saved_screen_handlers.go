@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// defaultMaxSavedScreens applies when a user's plan limits can't be loaded
+// (the free plan's limit)
+const defaultMaxSavedScreens = 5
+
+// ListSavedScreens returns all saved screens for the authenticated user
+func ListSavedScreens(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	screens, err := database.GetSavedScreensByUserID(userID)
+	if err != nil {
+		log.Printf("Error fetching saved screens for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch saved screens")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"screens": screens})
+}
+
+// CreateSavedScreen saves a named set of screener criteria, up to the
+// user's plan max_saved_screens
+func CreateSavedScreen(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.SaveScreenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	normalizeScreenerParams(&req.Params)
+
+	maxScreens := defaultMaxSavedScreens
+	if limits, err := database.GetUserSubscriptionLimits(userID); err != nil {
+		log.Printf("Error fetching subscription limits for user %s, using free tier: %v", userID, err)
+	} else {
+		maxScreens = limits.MaxSavedScreens
+	}
+
+	screen := &models.SavedScreen{
+		UserID: userID,
+		Name:   req.Name,
+		Params: req.Params,
+	}
+
+	// Atomic insert with count check to prevent TOCTOU race
+	if err := database.CreateSavedScreenAtomic(screen, maxScreens); err != nil {
+		if errors.Is(err, database.ErrSavedScreenLimit) {
+			respondError(c, http.StatusForbidden, httputil.CodeLimitReached, fmt.Sprintf("Saved screen limit reached. Maximum %d saved screens allowed", maxScreens))
+			return
+		}
+		log.Printf("Error creating saved screen for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create saved screen")
+		return
+	}
+
+	c.JSON(http.StatusCreated, screen)
+}
+
+// GetSavedScreen retrieves a single saved screen
+func GetSavedScreen(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	screenID := c.Param("id")
+
+	screen, err := database.GetSavedScreenByID(screenID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrSavedScreenNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Saved screen not found")
+		} else {
+			log.Printf("Error fetching saved screen %s for user %s: %v", screenID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch saved screen")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, screen)
+}
+
+// UpdateSavedScreen replaces a saved screen's name and criteria
+func UpdateSavedScreen(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	screenID := c.Param("id")
+
+	var req models.SaveScreenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	normalizeScreenerParams(&req.Params)
+
+	screen := &models.SavedScreen{
+		ID:     screenID,
+		UserID: userID,
+		Name:   req.Name,
+		Params: req.Params,
+	}
+
+	if err := database.UpdateSavedScreen(screen); err != nil {
+		if errors.Is(err, database.ErrSavedScreenNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Saved screen not found")
+		} else {
+			log.Printf("Error updating saved screen %s for user %s: %v", screenID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update saved screen")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, screen)
+}
+
+// DeleteSavedScreen deletes a saved screen
+func DeleteSavedScreen(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	screenID := c.Param("id")
+
+	if err := database.DeleteSavedScreen(screenID, userID); err != nil {
+		if errors.Is(err, database.ErrSavedScreenNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Saved screen not found")
+		} else {
+			log.Printf("Error deleting saved screen %s for user %s: %v", screenID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete saved screen")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved screen deleted successfully"})
+}
+
+// RunSavedScreen runs a saved screen's criteria through the screener and
+// returns the same response as GET /api/v1/screener/stocks
+func RunSavedScreen(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	screenID := c.Param("id")
+
+	screen, err := database.GetSavedScreenByID(screenID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrSavedScreenNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Saved screen not found")
+		} else {
+			log.Printf("Error fetching saved screen %s for user %s: %v", screenID, userID, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch saved screen")
+		}
+		return
+	}
+
+	params := screen.Params
+	normalizeScreenerParams(&params)
+
+	stocks, total, err := database.GetScreenerStocks(params)
+	if err != nil {
+		log.Printf("Error running saved screen %s: %v", screenID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch stocks", "An error occurred while retrieving screener data")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ScreenerResponse{
+		Data: stocks,
+		Meta: models.ScreenerMeta{
+			Total:      total,
+			Page:       params.Page,
+			Limit:      params.Limit,
+			TotalPages: int(math.Ceil(float64(total) / float64(params.Limit))),
+			Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+}
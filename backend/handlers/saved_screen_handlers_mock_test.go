@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"investorcenter-api/models"
+)
+
+var savedScreenCols = []string{"id", "user_id", "name", "params", "created_at", "updated_at"}
+
+// expectSubscriptionLimits mocks GetUserSubscription for a plan allowing
+// maxSavedScreens saved screens
+func expectSubscriptionLimits(mock sqlmock.Sqlmock, userID string, maxSavedScreens int) {
+	now := time.Now()
+	mock.ExpectQuery("FROM user_subscriptions us").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "plan_id", "status", "billing_period",
+			"started_at", "current_period_start", "current_period_end",
+			"canceled_at", "ended_at", "stripe_subscription_id",
+			"stripe_customer_id", "payment_method", "last_payment_date",
+			"next_payment_date", "created_at", "updated_at",
+			"plan_name", "plan_display_name", "plan_features", "max_watch_lists",
+			"max_items_per_watch_list", "max_alert_rules", "max_heatmap_configs",
+			"max_saved_screens",
+		}).AddRow("sub-1", userID, "plan-1", "active", "monthly",
+			now, now, nil, nil, nil, nil, nil, nil, nil, nil, now, now,
+			"premium", "Premium", []byte(`{}`), 20, 100, 100, 10, maxSavedScreens))
+}
+
+// capturedJSON is a sqlmock argument matcher that keeps the JSON it's given
+type capturedJSON struct {
+	value []byte
+}
+
+func (c *capturedJSON) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	if !ok || !json.Valid(b) {
+		return false
+	}
+	c.value = b
+	return true
+}
+
+func TestListSavedScreens_NoAuth(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.GET("/screens", ListSavedScreens)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/screens", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestListSavedScreens_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM saved_screens").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(savedScreenCols).
+			AddRow("scr-1", "user-1", "Cheap tech", []byte(`{"sectors":["Technology"],"pe_max":15}`), now, now))
+
+	r := setupMockRouter("user-1")
+	r.GET("/screens", ListSavedScreens)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/screens", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Screens []models.SavedScreen `json:"screens"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Screens, 1)
+	assert.Equal(t, "Cheap tech", resp.Screens[0].Name)
+	assert.Equal(t, []string{"Technology"}, resp.Screens[0].Params.Sectors)
+	require.NotNil(t, resp.Screens[0].Params.PEMax)
+	assert.Equal(t, 15.0, *resp.Screens[0].Params.PEMax)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateSavedScreen_Mock_RoundTripsParams(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectSubscriptionLimits(mock, "user-1", 50)
+	stored := &capturedJSON{}
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO saved_screens").
+		WithArgs("user-1", "Value", stored, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("scr-1", now, now))
+
+	r := setupMockRouter("user-1")
+	r.POST("/screens", CreateSavedScreen)
+	r.GET("/screens/:id", GetSavedScreen)
+
+	body := `{"name":"Value","params":{"sectors":[" Financials "],"pe_min":0,"pe_max":12.5,
+		"dividend_yield_min":3,"ic_score_min":70,"sort":"pe_ratio","order":"ASC"}}`
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/screens", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// Read the stored JSON back the way GetSavedScreen would
+	mock.ExpectQuery("FROM saved_screens").
+		WithArgs("scr-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(savedScreenCols).AddRow("scr-1", "user-1", "Value", stored.value, now, now))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/screens/scr-1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var screen models.SavedScreen
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &screen))
+	params := screen.Params
+	require.NotNil(t, params.PEMin, "a zero pe_min is a filter, not absent")
+	assert.Equal(t, 0.0, *params.PEMin)
+	require.NotNil(t, params.PEMax)
+	assert.Equal(t, 12.5, *params.PEMax)
+	require.NotNil(t, params.DividendYieldMin)
+	assert.Equal(t, 3.0, *params.DividendYieldMin)
+	require.NotNil(t, params.ICScoreMin)
+	assert.Equal(t, 70.0, *params.ICScoreMin)
+	assert.Nil(t, params.PBMin)
+	assert.Equal(t, []string{"Financials"}, params.Sectors)
+	assert.Equal(t, "pe_ratio", params.Sort)
+	assert.Equal(t, "asc", params.Order)
+	assert.Equal(t, "CS", params.AssetType)
+	assert.Equal(t, 1, params.Page)
+	assert.Equal(t, 20000, params.Limit)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateSavedScreen_Mock_LimitReached(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectSubscriptionLimits(mock, "user-1", 2)
+	mock.ExpectQuery("INSERT INTO saved_screens").
+		WithArgs("user-1", "Third", sqlmock.AnyArg(), 2).
+		WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-1")
+	r.POST("/screens", CreateSavedScreen)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/screens", bytes.NewBufferString(`{"name":"Third","params":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "Maximum 2 saved screens")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateSavedScreen_Mock_LimitsUnavailableUsesFreeTier(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM user_subscriptions us").WillReturnError(fmt.Errorf("db error"))
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO saved_screens").
+		WithArgs("user-1", "Growth", sqlmock.AnyArg(), defaultMaxSavedScreens).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("scr-2", now, now))
+
+	r := setupMockRouter("user-1")
+	r.POST("/screens", CreateSavedScreen)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/screens", bytes.NewBufferString(`{"name":"Growth"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateSavedScreen_MissingName(t *testing.T) {
+	r := setupMockRouter("user-1")
+	r.POST("/screens", CreateSavedScreen)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/screens", bytes.NewBufferString(`{"params":{}}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetSavedScreen_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM saved_screens").
+		WithArgs("scr-9", "user-1").
+		WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-1")
+	r.GET("/screens/:id", GetSavedScreen)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/screens/scr-9", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateSavedScreen_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("UPDATE saved_screens").
+		WithArgs("Renamed", sqlmock.AnyArg(), "scr-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+
+	r := setupMockRouter("user-1")
+	r.PUT("/screens/:id", UpdateSavedScreen)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/screens/scr-1", bytes.NewBufferString(`{"name":"Renamed","params":{"pe_max":20}}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"pe_max":20`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSavedScreen_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM saved_screens").
+		WithArgs("scr-9", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := setupMockRouter("user-1")
+	r.DELETE("/screens/:id", DeleteSavedScreen)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/screens/scr-9", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunSavedScreen_Mock_RunsSavedParams(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM saved_screens").
+		WithArgs("scr-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(savedScreenCols).AddRow("scr-1", "user-1", "Cheap",
+			[]byte(`{"page":1,"limit":50,"sort":"pe_ratio","order":"asc","asset_type":"CS","pe_min":0,"pe_max":10}`), now, now))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM screener_data WHERE .*pe_ratio >= \$1 AND .*pe_ratio <= \$2`).
+		WithArgs(0.0, 10.0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`ORDER BY "pe_ratio" ASC NULLS LAST LIMIT \$3 OFFSET \$4`).
+		WithArgs(0.0, 10.0, 50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "name", "pe_ratio"}).AddRow("XOM", "Exxon Mobil", 8.2))
+
+	r := setupMockRouter("user-1")
+	r.POST("/screens/:id/run", RunSavedScreen)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/screens/scr-1/run", nil))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp models.ScreenerResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "XOM", resp.Data[0].Symbol)
+	assert.Equal(t, 1, resp.Meta.Total)
+	assert.Equal(t, 50, resp.Meta.Limit)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
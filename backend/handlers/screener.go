@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"math"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 
 	"github.com/gin-gonic/gin"
@@ -16,27 +18,31 @@ import (
 
 // GetScreenerStocks handles the stock screener endpoint
 // GET /api/v1/screener/stocks
+// With format=csv the result set is streamed as CSV (see GetScreenerStocksCSV).
 func GetScreenerStocks(c *gin.Context) {
 	// Check database connection
 	if database.DB == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Database not available",
-			"message": "Screener service is temporarily unavailable",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Screener service is temporarily unavailable")
 		return
 	}
 
 	// Parse query parameters
-	params := parseScreenerParams(c)
+	params, err := parseScreenerParams(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		streamScreenerCSV(c, params)
+		return
+	}
 
 	// Fetch stocks from database
 	stocks, total, err := database.GetScreenerStocks(params)
 	if err != nil {
 		log.Printf("Error fetching screener stocks: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch stocks",
-			"message": "An error occurred while retrieving screener data",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch stocks", "An error occurred while retrieving screener data")
 		return
 	}
 
@@ -146,8 +152,27 @@ var rangeParams = []floatParam{
 	{key: "technical_score_max", setter: func(p *models.ScreenerParams, v float64) { p.TechnicalScoreMax = &v }},
 }
 
-// parseScreenerParams extracts and validates query parameters
-func parseScreenerParams(c *gin.Context) models.ScreenerParams {
+// percentileParams defines the sector-relative percentile query parameters
+// (see database.PercentileFilters). They take effect with sector_relative=true.
+var percentileParams = []floatParam{
+	{key: "pe_percentile_min", setter: func(p *models.ScreenerParams, v float64) { p.PEPercentileMin = &v }},
+	{key: "pe_percentile_max", setter: func(p *models.ScreenerParams, v float64) { p.PEPercentileMax = &v }},
+	{key: "pb_percentile_min", setter: func(p *models.ScreenerParams, v float64) { p.PBPercentileMin = &v }},
+	{key: "pb_percentile_max", setter: func(p *models.ScreenerParams, v float64) { p.PBPercentileMax = &v }},
+	{key: "ps_percentile_min", setter: func(p *models.ScreenerParams, v float64) { p.PSPercentileMin = &v }},
+	{key: "ps_percentile_max", setter: func(p *models.ScreenerParams, v float64) { p.PSPercentileMax = &v }},
+	{key: "revenue_growth_percentile_min", setter: func(p *models.ScreenerParams, v float64) { p.RevenueGrowthPercentileMin = &v }},
+	{key: "revenue_growth_percentile_max", setter: func(p *models.ScreenerParams, v float64) { p.RevenueGrowthPercentileMax = &v }},
+	{key: "eps_growth_percentile_min", setter: func(p *models.ScreenerParams, v float64) { p.EPSGrowthPercentileMin = &v }},
+	{key: "eps_growth_percentile_max", setter: func(p *models.ScreenerParams, v float64) { p.EPSGrowthPercentileMax = &v }},
+	{key: "dividend_yield_percentile_min", setter: func(p *models.ScreenerParams, v float64) { p.DividendYieldPercentileMin = &v }},
+	{key: "dividend_yield_percentile_max", setter: func(p *models.ScreenerParams, v float64) { p.DividendYieldPercentileMax = &v }},
+}
+
+// parseScreenerParams extracts and validates query parameters. Malformed
+// range filters are ignored; a sector percentile filter that isn't one of
+// the stored breakpoints is an error naming the offending parameter.
+func parseScreenerParams(c *gin.Context) (models.ScreenerParams, error) {
 	params := models.ScreenerParams{
 		Page:      1,
 		Limit:     20000, // High default for client-side filtering
@@ -156,18 +181,9 @@ func parseScreenerParams(c *gin.Context) models.ScreenerParams {
 		AssetType: "CS",
 	}
 
-	// Page
-	if page, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && page > 0 {
-		params.Page = page
-	}
-
+	params.Page = parsePage(c)
 	// Limit (max 20000 for client-side filtering screener)
-	if limit, err := strconv.Atoi(c.DefaultQuery("limit", "20000")); err == nil && limit > 0 {
-		if limit > 20000 {
-			limit = 20000
-		}
-		params.Limit = limit
-	}
+	params.Limit = parseLimitUpTo(c, 20000, 20000)
 
 	// Sort field
 	sort := c.DefaultQuery("sort", "market_cap")
@@ -206,19 +222,65 @@ func parseScreenerParams(c *gin.Context) models.ScreenerParams {
 		}
 	}
 
+	// Sector-relative percentiles. Only the stored breakpoints
+	// (0, 10, 25, 50, 75, 90, 100) are accepted; anything else is rejected
+	// rather than silently dropped, which would widen the result set.
+	if relative, err := strconv.ParseBool(c.Query("sector_relative")); err == nil {
+		params.SectorRelative = relative
+	}
+	for _, fp := range percentileParams {
+		if raw := c.Query(fp.key); raw != "" {
+			val, err := strconv.ParseFloat(raw, 64)
+			if err != nil || !database.IsSupportedSectorPercentile(val) {
+				return params, fmt.Errorf("%s must be one of 0, 10, 25, 50, 75, 90, 100", fp.key)
+			}
+			fp.setter(&params, val)
+		}
+	}
+
 	// Asset type (validated against allowlist)
 	if assetType := c.Query("asset_type"); assetType != "" {
-		validAssetTypes := map[string]bool{
-			"CS": true, "ETF": true, "ADRC": true, "ADRW": true,
-			"WARRANT": true, "RIGHT": true, "UNIT": true,
-			"PFD": true, "FUND": true, "SP": true, "OS": true,
-			"crypto": true,
-		}
-		if validAssetTypes[assetType] {
+		if validScreenerAssetTypes[assetType] {
 			params.AssetType = assetType
 		}
 		// Invalid values silently fall back to default "CS"
 	}
 
-	return params
+	return params, nil
+}
+
+// validScreenerAssetTypes is the asset_type allowlist
+var validScreenerAssetTypes = map[string]bool{
+	"CS": true, "ETF": true, "ADRC": true, "ADRW": true,
+	"WARRANT": true, "RIGHT": true, "UNIT": true,
+	"PFD": true, "FUND": true, "SP": true, "OS": true,
+	"crypto": true,
+}
+
+// normalizeScreenerParams applies parseScreenerParams' defaults and
+// allowlists to params that didn't come from a query string (saved screens),
+// so they run exactly as the equivalent URL would
+func normalizeScreenerParams(params *models.ScreenerParams) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 || params.Limit > 20000 {
+		params.Limit = 20000
+	}
+	if _, ok := database.ValidScreenerSortColumns[params.Sort]; !ok {
+		params.Sort = "market_cap"
+	}
+	params.Order = strings.ToLower(params.Order)
+	if params.Order != "asc" {
+		params.Order = "desc"
+	}
+	if !validScreenerAssetTypes[params.AssetType] {
+		params.AssetType = "CS"
+	}
+	for i := range params.Sectors {
+		params.Sectors[i] = strings.TrimSpace(params.Sectors[i])
+	}
+	for i := range params.Industries {
+		params.Industries[i] = strings.TrimSpace(params.Industries[i])
+	}
 }
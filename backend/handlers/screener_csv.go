@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// screenerCSVBatchSize is how many rows each streaming step writes before
+// flushing to the client.
+const screenerCSVBatchSize = 500
+
+// screenerCSVColumn is one column of the screener CSV export.
+type screenerCSVColumn struct {
+	header string
+	value  func(s *models.ScreenerStock) string
+}
+
+// screenerCSVColumns defines the export's columns in order. The header is part
+// of the export's contract: append new columns at the end rather than
+// reordering or renaming existing ones.
+var screenerCSVColumns = []screenerCSVColumn{
+	{"symbol", func(s *models.ScreenerStock) string { return s.Symbol }},
+	{"name", func(s *models.ScreenerStock) string { return s.Name }},
+	{"sector", func(s *models.ScreenerStock) string { return csvString(s.Sector) }},
+	{"industry", func(s *models.ScreenerStock) string { return csvString(s.Industry) }},
+	{"market_cap", func(s *models.ScreenerStock) string { return csvFloat(s.MarketCap) }},
+	{"price", func(s *models.ScreenerStock) string { return csvFloat(s.Price) }},
+	{"pe_ratio", func(s *models.ScreenerStock) string { return csvFloat(s.PERatio) }},
+	{"pb_ratio", func(s *models.ScreenerStock) string { return csvFloat(s.PBRatio) }},
+	{"ps_ratio", func(s *models.ScreenerStock) string { return csvFloat(s.PSRatio) }},
+	{"roe", func(s *models.ScreenerStock) string { return csvFloat(s.ROE) }},
+	{"roa", func(s *models.ScreenerStock) string { return csvFloat(s.ROA) }},
+	{"gross_margin", func(s *models.ScreenerStock) string { return csvFloat(s.GrossMargin) }},
+	{"operating_margin", func(s *models.ScreenerStock) string { return csvFloat(s.OperatingMargin) }},
+	{"net_margin", func(s *models.ScreenerStock) string { return csvFloat(s.NetMargin) }},
+	{"debt_to_equity", func(s *models.ScreenerStock) string { return csvFloat(s.DebtToEquity) }},
+	{"current_ratio", func(s *models.ScreenerStock) string { return csvFloat(s.CurrentRatio) }},
+	{"revenue_growth", func(s *models.ScreenerStock) string { return csvFloat(s.RevenueGrowth) }},
+	{"eps_growth_yoy", func(s *models.ScreenerStock) string { return csvFloat(s.EPSGrowthYoY) }},
+	{"dividend_yield", func(s *models.ScreenerStock) string { return csvFloat(s.DividendYield) }},
+	{"payout_ratio", func(s *models.ScreenerStock) string { return csvFloat(s.PayoutRatio) }},
+	{"consecutive_dividend_years", func(s *models.ScreenerStock) string { return csvInt(s.ConsecutiveDividendYears) }},
+	{"beta", func(s *models.ScreenerStock) string { return csvFloat(s.Beta) }},
+	{"dcf_upside_percent", func(s *models.ScreenerStock) string { return csvFloat(s.DCFUpsidePercent) }},
+	{"ic_score", func(s *models.ScreenerStock) string { return csvFloat(s.ICScore) }},
+	{"ic_rating", func(s *models.ScreenerStock) string { return csvString(s.ICRating) }},
+	{"value_score", func(s *models.ScreenerStock) string { return csvFloat(s.ValueScore) }},
+	{"growth_score", func(s *models.ScreenerStock) string { return csvFloat(s.GrowthScore) }},
+	{"profitability_score", func(s *models.ScreenerStock) string { return csvFloat(s.ProfitabilityScore) }},
+	{"financial_health_score", func(s *models.ScreenerStock) string { return csvFloat(s.FinancialHealthScore) }},
+	{"momentum_score", func(s *models.ScreenerStock) string { return csvFloat(s.MomentumScore) }},
+	{"analyst_consensus_score", func(s *models.ScreenerStock) string { return csvFloat(s.AnalystConsensusScore) }},
+	{"insider_activity_score", func(s *models.ScreenerStock) string { return csvFloat(s.InsiderActivityScore) }},
+	{"institutional_score", func(s *models.ScreenerStock) string { return csvFloat(s.InstitutionalScore) }},
+	{"news_sentiment_score", func(s *models.ScreenerStock) string { return csvFloat(s.NewsSentimentScore) }},
+	{"technical_score", func(s *models.ScreenerStock) string { return csvFloat(s.TechnicalScore) }},
+	{"ic_sector_percentile", func(s *models.ScreenerStock) string { return csvFloat(s.ICSectorPercentile) }},
+	{"lifecycle_stage", func(s *models.ScreenerStock) string { return csvString(s.LifecycleStage) }},
+}
+
+func csvString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func csvFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func csvInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// GetScreenerStocksCSV streams the screener result set as a CSV attachment.
+// It accepts the same filters as GetScreenerStocks.
+// GET /api/v1/screener/stocks.csv (or /api/v1/screener/stocks?format=csv)
+func GetScreenerStocksCSV(c *gin.Context) {
+	if database.DB == nil {
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Database not available", "Screener service is temporarily unavailable")
+		return
+	}
+
+	params, err := parseScreenerParams(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	streamScreenerCSV(c, params)
+}
+
+// streamScreenerCSV writes the rows matching params to the response in
+// batches, so the full result set is never held in memory. Errors before the
+// first byte is written return a JSON 500; errors mid-stream can only be
+// logged and end the response early.
+func streamScreenerCSV(c *gin.Context, params models.ScreenerParams) {
+	rows, err := database.QueryScreenerStocks(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("Error exporting screener stocks: %v", err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch stocks", "An error occurred while retrieving screener data")
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("screener-%s.csv", time.Now().UTC().Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	header := make([]string, len(screenerCSVColumns))
+	for i, col := range screenerCSVColumns {
+		header[i] = col.header
+	}
+
+	wroteHeader := false
+	record := make([]string, len(screenerCSVColumns))
+	c.Stream(func(w io.Writer) bool {
+		cw := csv.NewWriter(w)
+		if !wroteHeader {
+			if err := cw.Write(header); err != nil {
+				log.Printf("Error writing screener CSV: %v", err)
+				return false
+			}
+			wroteHeader = true
+		}
+
+		more := true
+		for i := 0; i < screenerCSVBatchSize; i++ {
+			if !rows.Next() {
+				more = false
+				break
+			}
+			var stock models.ScreenerStock
+			if err := rows.StructScan(&stock); err != nil {
+				log.Printf("Error scanning screener stock for CSV: %v", err)
+				more = false
+				break
+			}
+			for j, col := range screenerCSVColumns {
+				record[j] = col.value(&stock)
+			}
+			if err := cw.Write(record); err != nil {
+				log.Printf("Error writing screener CSV: %v", err)
+				return false
+			}
+		}
+
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			log.Printf("Error writing screener CSV: %v", err)
+			return false
+		}
+		if !more {
+			if err := rows.Err(); err != nil {
+				log.Printf("Error iterating screener stocks for CSV: %v", err)
+			}
+		}
+		return more
+	})
+}
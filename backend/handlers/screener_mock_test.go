@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // ---------------------------------------------------------------------------
@@ -52,3 +56,123 @@ func TestGetScreenerStocks_Mock_DBError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "Failed to fetch stocks")
 }
+
+func TestGetScreenerStocks_Mock_UnsupportedPercentile(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	r := setupMockRouterNoAuth()
+	r.GET("/screener/stocks", GetScreenerStocks)
+	r.GET("/screener/stocks.csv", GetScreenerStocksCSV)
+
+	for _, path := range []string{"/screener/stocks", "/screener/stocks.csv"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path+"?pe_percentile_max=30", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"code":"invalid_request"`, path)
+		assert.Contains(t, w.Body.String(), "pe_percentile_max", path)
+	}
+	// Rejected before any query runs
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// Screener CSV export
+// ---------------------------------------------------------------------------
+
+// streamRecorder adds the CloseNotify that gin's Context.Stream requires to
+// httptest.ResponseRecorder.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{httptest.NewRecorder()}
+}
+
+func (r *streamRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func TestGetScreenerStocksCSV_Mock_StreamsRows(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"symbol", "name", "sector", "market_cap", "pe_ratio", "consecutive_dividend_years"}).
+		AddRow("AAPL", "Apple Inc.", "Technology", 3.5e12, 28.4, 12).
+		AddRow("XYZ", "Acme, Corp", nil, nil, nil, nil)
+	mock.ExpectQuery("FROM screener_data").
+		WithArgs(sqlmock.AnyArg(), 20000, 0).
+		WillReturnRows(rows)
+
+	r := setupMockRouterNoAuth()
+	r.GET("/screener/stocks.csv", GetScreenerStocksCSV)
+
+	w := newStreamRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/screener/stocks.csv?sectors=Technology", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="screener-\d{4}-\d{2}-\d{2}\.csv"$`, w.Header().Get("Content-Disposition"))
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Len(t, records[0], len(screenerCSVColumns))
+	assert.Equal(t, []string{"symbol", "name", "sector", "industry", "market_cap"}, records[0][:5])
+	assert.Equal(t, "lifecycle_stage", records[0][len(records[0])-1])
+
+	assert.Equal(t, "AAPL", records[1][0])
+	assert.Equal(t, "Technology", records[1][2])
+	assert.Equal(t, "3500000000000", records[1][4])
+	assert.Equal(t, "28.4", records[1][6])
+	assert.Equal(t, "12", records[1][20])
+
+	assert.Equal(t, "Acme, Corp", records[2][1])
+	assert.Equal(t, "", records[2][2])
+	assert.Equal(t, "", records[2][4])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetScreenerStocks_Mock_FormatCSV(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	// No count query: the CSV path only runs the data query
+	mock.ExpectQuery("FROM screener_data").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "name"}).AddRow("MSFT", "Microsoft"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/screener/stocks", GetScreenerStocks)
+
+	w := newStreamRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/screener/stocks?format=csv", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.True(t, strings.HasPrefix(w.Body.String(), "symbol,name,sector,"))
+	assert.Contains(t, w.Body.String(), "\nMSFT,Microsoft,")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetScreenerStocksCSV_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM screener_data").WillReturnError(fmt.Errorf("db error"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/screener/stocks.csv", GetScreenerStocksCSV)
+
+	w := newStreamRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/screener/stocks.csv", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Failed to fetch stocks")
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -31,29 +32,20 @@ type NLPQueryRequest struct {
 func PostScreenerNLP(c *gin.Context) {
 	// Check Gemini client is available
 	if geminiClient == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "NLP service not available",
-			"message": "AI search is not configured. GEMINI_API_KEY is required.",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "NLP service not available", "AI search is not configured. GEMINI_API_KEY is required.")
 		return
 	}
 
 	// Parse request body
 	var req NLPQueryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request",
-			"message": "A non-empty 'query' field is required.",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request", "A non-empty 'query' field is required.")
 		return
 	}
 
 	// Validate query length
 	if len(req.Query) > 500 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Query too long",
-			"message": "Query must be 500 characters or fewer.",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Query too long", "Query must be 500 characters or fewer.")
 		return
 	}
 
@@ -61,10 +53,7 @@ func PostScreenerNLP(c *gin.Context) {
 	result, err := geminiClient.ParseScreenerQuery(req.Query)
 	if err != nil {
 		log.Printf("Gemini NLP query failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to process query",
-			"message": "AI could not interpret the query. Try rephrasing.",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to process query", "AI could not interpret the query. Try rephrasing.")
 		return
 	}
 
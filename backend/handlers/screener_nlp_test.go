@@ -107,7 +107,7 @@ func TestPostScreenerNLP_QueryTooLong(t *testing.T) {
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("failed to parse response: %v", err)
 	}
-	if msg, ok := resp["message"].(string); !ok || !strings.Contains(msg, "500") {
+	if msg, ok := resp["details"].(string); !ok || !strings.Contains(msg, "500") {
 		t.Errorf("expected message mentioning 500-char limit, got %q", msg)
 	}
 }
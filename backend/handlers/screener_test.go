@@ -3,9 +3,11 @@ package handlers
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"investorcenter-api/database"
+	"investorcenter-api/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,13 +22,23 @@ func createTestContext(queryString string) (*gin.Context, *httptest.ResponseReco
 	return c, w
 }
 
+// mustParseScreenerParams parses the context's query, failing the test on error.
+func mustParseScreenerParams(t *testing.T, c *gin.Context) models.ScreenerParams {
+	t.Helper()
+	params, err := parseScreenerParams(c)
+	if err != nil {
+		t.Fatalf("parseScreenerParams: %v", err)
+	}
+	return params
+}
+
 // ---------------------------------------------------------------------------
 // parseScreenerParams — defaults
 // ---------------------------------------------------------------------------
 
 func TestParseScreenerParamsDefaults(t *testing.T) {
 	c, _ := createTestContext("")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 
 	if params.Page != 1 {
 		t.Errorf("expected default page=1, got %d", params.Page)
@@ -65,7 +77,7 @@ func TestParseScreenerParamsPage(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.query, func(t *testing.T) {
 			c, _ := createTestContext(tc.query)
-			params := parseScreenerParams(c)
+			params := mustParseScreenerParams(t, c)
 			if params.Page != tc.wantPage {
 				t.Errorf("query=%q: expected page=%d, got %d", tc.query, tc.wantPage, params.Page)
 			}
@@ -88,7 +100,7 @@ func TestParseScreenerParamsLimit(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.query, func(t *testing.T) {
 			c, _ := createTestContext(tc.query)
-			params := parseScreenerParams(c)
+			params := mustParseScreenerParams(t, c)
 			if params.Limit != tc.wantLimit {
 				t.Errorf("query=%q: expected limit=%d, got %d", tc.query, tc.wantLimit, params.Limit)
 			}
@@ -102,7 +114,7 @@ func TestParseScreenerParamsLimit(t *testing.T) {
 
 func TestParseScreenerParamsSortValid(t *testing.T) {
 	c, _ := createTestContext("sort=ic_score")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 	if params.Sort != "ic_score" {
 		t.Errorf("expected sort=ic_score, got %q", params.Sort)
 	}
@@ -111,7 +123,7 @@ func TestParseScreenerParamsSortValid(t *testing.T) {
 func TestParseScreenerParamsSortInvalid(t *testing.T) {
 	// SQL injection attempt should fall back to default
 	c, _ := createTestContext("sort=market_cap%3BDROP%20TABLE%20stocks")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 	if params.Sort != "market_cap" {
 		t.Errorf("expected sort to fall back to market_cap, got %q", params.Sort)
 	}
@@ -121,7 +133,7 @@ func TestParseScreenerParamsSortAllValid(t *testing.T) {
 	for key := range database.ValidScreenerSortColumns {
 		t.Run(key, func(t *testing.T) {
 			c, _ := createTestContext("sort=" + key)
-			params := parseScreenerParams(c)
+			params := mustParseScreenerParams(t, c)
 			if params.Sort != key {
 				t.Errorf("expected sort=%q, got %q", key, params.Sort)
 			}
@@ -148,7 +160,7 @@ func TestParseScreenerParamsOrder(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.query, func(t *testing.T) {
 			c, _ := createTestContext(tc.query)
-			params := parseScreenerParams(c)
+			params := mustParseScreenerParams(t, c)
 			if params.Order != tc.wantOrder {
 				t.Errorf("query=%q: expected order=%q, got %q", tc.query, tc.wantOrder, params.Order)
 			}
@@ -162,7 +174,7 @@ func TestParseScreenerParamsOrder(t *testing.T) {
 
 func TestParseScreenerParamsSectors(t *testing.T) {
 	c, _ := createTestContext("sectors=Technology,Healthcare,Energy")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 	if len(params.Sectors) != 3 {
 		t.Fatalf("expected 3 sectors, got %d: %v", len(params.Sectors), params.Sectors)
 	}
@@ -173,7 +185,7 @@ func TestParseScreenerParamsSectors(t *testing.T) {
 
 func TestParseScreenerParamsSectorsWhitespaceTrimmed(t *testing.T) {
 	c, _ := createTestContext("sectors=Technology%2C+Healthcare+%2C+Energy")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 	for _, s := range params.Sectors {
 		if s != "Technology" && s != "Healthcare" && s != "Energy" {
 			t.Errorf("sector not trimmed properly: %q", s)
@@ -183,7 +195,7 @@ func TestParseScreenerParamsSectorsWhitespaceTrimmed(t *testing.T) {
 
 func TestParseScreenerParamsIndustries(t *testing.T) {
 	c, _ := createTestContext("industries=Software,Semiconductors")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 	if len(params.Industries) != 2 {
 		t.Fatalf("expected 2 industries, got %d: %v", len(params.Industries), params.Industries)
 	}
@@ -198,7 +210,7 @@ func TestParseScreenerParamsIndustries(t *testing.T) {
 
 func TestParseScreenerParamsRangeFilters(t *testing.T) {
 	c, _ := createTestContext("pe_min=5&pe_max=25&roe_min=10&beta_max=1.5")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 
 	if params.PEMin == nil || *params.PEMin != 5.0 {
 		t.Errorf("expected pe_min=5, got %v", params.PEMin)
@@ -217,7 +229,7 @@ func TestParseScreenerParamsRangeFilters(t *testing.T) {
 func TestParseScreenerParamsRangeFiltersInvalid(t *testing.T) {
 	// Non-numeric values should be ignored (field stays nil)
 	c, _ := createTestContext("pe_min=abc&pe_max=&roe_min=not_a_number")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 
 	if params.PEMin != nil {
 		t.Errorf("expected pe_min to be nil for invalid input, got %v", *params.PEMin)
@@ -232,7 +244,7 @@ func TestParseScreenerParamsRangeFiltersInvalid(t *testing.T) {
 
 func TestParseScreenerParamsNegativeRangeValues(t *testing.T) {
 	c, _ := createTestContext("revenue_growth_min=-50&dcf_upside_min=-25.5")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 
 	if params.RevenueGrowthMin == nil || *params.RevenueGrowthMin != -50.0 {
 		t.Errorf("expected revenue_growth_min=-50, got %v", params.RevenueGrowthMin)
@@ -244,7 +256,7 @@ func TestParseScreenerParamsNegativeRangeValues(t *testing.T) {
 
 func TestParseScreenerParamsICScoreSubFactors(t *testing.T) {
 	c, _ := createTestContext("value_score_min=60&growth_score_max=90&momentum_score_min=50&technical_score_max=80")
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 
 	if params.ValueScoreMin == nil || *params.ValueScoreMin != 60.0 {
 		t.Errorf("expected value_score_min=60, got %v", params.ValueScoreMin)
@@ -279,7 +291,7 @@ func TestParseScreenerParamsAssetType(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.query, func(t *testing.T) {
 			c, _ := createTestContext(tc.query)
-			params := parseScreenerParams(c)
+			params := mustParseScreenerParams(t, c)
 			if params.AssetType != tc.wantAssetType {
 				t.Errorf("query=%q: expected asset_type=%q, got %q", tc.query, tc.wantAssetType, params.AssetType)
 			}
@@ -295,7 +307,7 @@ func TestParseScreenerParamsCombined(t *testing.T) {
 	c, _ := createTestContext(
 		"page=2&limit=25&sort=ic_score&order=asc&sectors=Technology&pe_max=30&ic_score_min=70&asset_type=CS",
 	)
-	params := parseScreenerParams(c)
+	params := mustParseScreenerParams(t, c)
 
 	if params.Page != 2 {
 		t.Errorf("expected page=2, got %d", params.Page)
@@ -320,6 +332,58 @@ func TestParseScreenerParamsCombined(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// parseScreenerParams — sector-relative percentiles
+// ---------------------------------------------------------------------------
+
+func TestParseScreenerParamsSectorPercentiles(t *testing.T) {
+	c, _ := createTestContext("pe_percentile_max=25&dividend_yield_percentile_min=75&sector_relative=true")
+	params := mustParseScreenerParams(t, c)
+
+	if !params.SectorRelative {
+		t.Error("expected sector_relative=true")
+	}
+	if params.PEPercentileMax == nil || *params.PEPercentileMax != 25 {
+		t.Errorf("expected pe_percentile_max=25, got %v", params.PEPercentileMax)
+	}
+	if params.DividendYieldPercentileMin == nil || *params.DividendYieldPercentileMin != 75 {
+		t.Errorf("expected dividend_yield_percentile_min=75, got %v", params.DividendYieldPercentileMin)
+	}
+}
+
+func TestParseScreenerParamsSectorRelativeInvalidIgnored(t *testing.T) {
+	c, _ := createTestContext("sector_relative=maybe")
+	params := mustParseScreenerParams(t, c)
+
+	if params.SectorRelative {
+		t.Error("expected invalid sector_relative to be ignored")
+	}
+}
+
+func TestParseScreenerParamsSectorPercentilesUnsupported(t *testing.T) {
+	// Only stored breakpoints are accepted; the error names the parameter
+	tests := []struct {
+		query     string
+		wantField string
+	}{
+		{"pe_percentile_max=30", "pe_percentile_max"},
+		{"pb_percentile_min=abc", "pb_percentile_min"},
+		{"pe_percentile_max=25&ps_percentile_min=101", "ps_percentile_min"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			c, _ := createTestContext(tc.query)
+			_, err := parseScreenerParams(c)
+			if err == nil {
+				t.Fatalf("query=%q: expected an error", tc.query)
+			}
+			if !strings.Contains(err.Error(), tc.wantField) {
+				t.Errorf("query=%q: expected error naming %q, got %q", tc.query, tc.wantField, err)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // rangeParams registry — completeness
 // ---------------------------------------------------------------------------
@@ -338,7 +402,7 @@ func TestRangeParamsAllSetters(t *testing.T) {
 	// Verify that every setter actually sets a value (doesn't panic)
 	for _, fp := range rangeParams {
 		t.Run(fp.key, func(t *testing.T) {
-			var params = parseScreenerParams(func() *gin.Context {
+			var params = mustParseScreenerParams(t, func() *gin.Context {
 				c, _ := createTestContext(fp.key + "=42.5")
 				return c
 			}())
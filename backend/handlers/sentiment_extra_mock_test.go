@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"investorcenter-api/models"
+
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // snapshotColumns returns the column names for the ticker_sentiment_snapshots table.
@@ -147,6 +151,71 @@ func TestGetTrendingSentiment_Mock_CompanyNamesFails(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "AAPL")
 }
 
+func TestGetTrendingSentiment_Mock_EngagementFiltersQueryLive(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	// Filters bypass snapshots and hit the raw posts directly
+	rows := sqlmock.NewRows([]string{"ticker", "company_name", "score", "post_count", "ranked_count", "mention_delta"}).
+		AddRow("GME", "GameStop Corp.", 0.4, 5, 18.2, 25.0)
+	mock.ExpectQuery("FROM reddit_post_tickers").
+		WithArgs(20, 100, 10).
+		WillReturnRows(rows)
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/trending", GetTrendingSentiment)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/trending?min_upvotes=100&min_comments=10&weighted=true", nil)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp models.TrendingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Tickers, 1)
+	assert.Equal(t, "GME", resp.Tickers[0].Ticker)
+	assert.Equal(t, 5, resp.Tickers[0].PostCount)
+	assert.InDelta(t, 18.2, resp.Tickers[0].WeightedCount, 0.0001)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTrendingSentiment_Mock_InvalidFiltersUseSnapshots(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows(snapshotColumns())
+	addSnapshotRow(rows, 1, "AAPL", 1, "1d")
+	mock.ExpectQuery("ticker_sentiment_snapshots").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"symbol", "name"}))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/trending", GetTrendingSentiment)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/trending?min_upvotes=-5&min_comments=abc&weighted=no", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "AAPL")
+	assert.NotContains(t, w.Body.String(), "weighted_count")
+}
+
+func TestGetTrendingSentiment_Mock_EngagementFiltersDBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM reddit_post_tickers").WillReturnError(fmt.Errorf("db down"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/trending", GetTrendingSentiment)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/trending?weighted=true", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
 // ---------------------------------------------------------------------------
 // GetTickerSentiment — success path tests
 // ---------------------------------------------------------------------------
@@ -272,6 +341,83 @@ func TestGetTickerSentimentHistory_Mock_Success(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "AAPL")
 }
 
+func TestGetTickerSentimentHistory_Mock_Weighted(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+
+	histCols := []string{
+		"time", "ticker", "sentiment_score", "bullish_pct", "bearish_pct", "neutral_pct", "mention_count", "composite_score",
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(histCols).
+		AddRow(now, "AAPL", 0.0, 0.50, 0.50, 0.0, 2, 50.0))
+
+	// GetWeightedSentimentHistory scans: date, score, post_count, bullish, bearish, neutral
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"date", "score", "post_count", "bullish", "bearish", "neutral"}).
+			AddRow(now, 0.72, 2, 1, 1, 0))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/history", GetTickerSentimentHistory)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/history?days=7&weighted=true", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp models.SentimentHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.History, 1)
+	require.Len(t, resp.WeightedHistory, 1)
+	assert.Equal(t, 0.0, resp.History[0].Score)
+	assert.Equal(t, 0.72, resp.WeightedHistory[0].Score)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerSentimentHistory_Mock_WeightedDBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	histCols := []string{
+		"time", "ticker", "sentiment_score", "bullish_pct", "bearish_pct", "neutral_pct", "mention_count", "composite_score",
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(histCols))
+	mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("db error"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/history", GetTickerSentimentHistory)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/history?weighted=true", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "Failed to fetch weighted sentiment history")
+}
+
+func TestGetTickerSentimentHistory_Mock_UnweightedOmitsSeries(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	histCols := []string{
+		"time", "ticker", "sentiment_score", "bullish_pct", "bearish_pct", "neutral_pct", "mention_count", "composite_score",
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(histCols))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/history", GetTickerSentimentHistory)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/history", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "weighted_history")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // ---------------------------------------------------------------------------
 // GetTickerPosts — success path test
 // ---------------------------------------------------------------------------
@@ -307,3 +453,120 @@ func TestGetTickerPosts_Mock_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "AAPL to the moon")
 }
+
+func TestGetTickerPosts_Mock_Envelope(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	postCols := []string{
+		"id", "title", "body", "url", "subreddit",
+		"upvotes", "comment_count", "flair", "posted_at",
+		"sentiment", "confidence",
+	}
+	mock.ExpectQuery(`ORDER BY rpr.upvotes DESC`).
+		WithArgs("AAPL", 2, 4).
+		WillReturnRows(sqlmock.NewRows(postCols).
+			AddRow(7, "Big post", nil, "https://reddit.com/7", "stocks", 900, 10, nil, time.Now(), "bullish", 0.9))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/posts", GetTickerPosts)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/posts?sort=upvotes&limit=2&offset=4", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []models.RepresentativePost `json:"data"`
+		Meta map[string]interface{}      `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "Big post", resp.Data[0].Title)
+	assert.Equal(t, "upvotes", resp.Meta["sort"])
+	assert.Equal(t, float64(7), resp.Meta["total"])
+	assert.Equal(t, float64(4), resp.Meta["offset"])
+	assert.Equal(t, true, resp.Meta["has_more"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPosts_Mock_SortConfidence(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`ORDER BY rpt.confidence DESC NULLS LAST, rpr.posted_at DESC`).
+		WillReturnError(fmt.Errorf("db error"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/posts", GetTickerPosts)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/posts?sort=confidence", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPosts_Mock_SubredditFilter(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	postCols := []string{
+		"id", "title", "body", "url", "subreddit",
+		"upvotes", "comment_count", "flair", "posted_at",
+		"sentiment", "confidence",
+	}
+	mock.ExpectQuery(`LOWER\(rpr.subreddit\) = LOWER\(\$2\)`).
+		WithArgs("AAPL", "wallstreetbets", 10, 0).
+		WillReturnRows(sqlmock.NewRows(postCols))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("AAPL", "wallstreetbets").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/posts", GetTickerPosts)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/posts?subreddit=r/wallstreetbets", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":[]`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPosts_Mock_SourceWithoutData(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/posts", GetTickerPosts)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/posts?source=stocktwits", nil)
+	r.ServeHTTP(w, req)
+
+	// No query is issued: the V2 tables only hold Reddit posts
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":[]`)
+	assert.Contains(t, w.Body.String(), `"source":"stocktwits"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPosts_Mock_InvalidSource(t *testing.T) {
+	_, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	r := setupMockRouterNoAuth()
+	r.GET("/sentiment/:ticker/posts", GetTickerPosts)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/sentiment/AAPL/posts?source=twitter", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid source")
+}
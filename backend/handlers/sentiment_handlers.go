@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,11 @@ import (
 // Query params:
 //   - period: "24h" or "7d" (default: "24h")
 //   - limit: number of results (default: 20, max: 50)
+//   - min_upvotes, min_comments: drop posts below these engagement levels
+//   - weighted: "true" to rank by engagement-weighted post count
+//
+// Snapshots are precomputed without engagement filters, so requests that set
+// any of the last three are computed live from the raw posts instead.
 //
 // Example: GET /api/sentiment/trending?period=24h&limit=20
 func GetTrendingSentiment(c *gin.Context) {
@@ -31,13 +37,20 @@ func GetTrendingSentiment(c *gin.Context) {
 		period = "24h"
 	}
 
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 20
-	}
-	if limit > 50 {
-		limit = 50
+	limit := parseLimitUpTo(c, 20, 50)
+
+	opts := parsePostCountOptions(c)
+	if !opts.IsZero() {
+		resp, err := database.GetTrendingTickers(period, limit, opts)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch trending sentiment", err.Error())
+			return
+		}
+		if resp.Tickers == nil {
+			resp.Tickers = []models.TrendingTicker{}
+		}
+		c.JSON(http.StatusOK, resp)
+		return
 	}
 
 	// Map API period to snapshot time_range
@@ -48,10 +61,7 @@ func GetTrendingSentiment(c *gin.Context) {
 
 	snapshots, err := database.GetLatestSnapshots(timeRange, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch trending sentiment",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch trending sentiment", err.Error())
 		return
 	}
 
@@ -94,6 +104,20 @@ func GetTrendingSentiment(c *gin.Context) {
 	})
 }
 
+// parsePostCountOptions reads the min_upvotes, min_comments and weighted
+// query params. Invalid or negative values are ignored.
+func parsePostCountOptions(c *gin.Context) models.PostCountOptions {
+	var opts models.PostCountOptions
+	if n, err := strconv.Atoi(c.Query("min_upvotes")); err == nil && n > 0 {
+		opts.MinUpvotes = n
+	}
+	if n, err := strconv.Atoi(c.Query("min_comments")); err == nil && n > 0 {
+		opts.MinComments = n
+	}
+	opts.EngagementWeighted = c.Query("weighted") == "true"
+	return opts
+}
+
 // GetTickerSentiment returns sentiment analysis for a specific ticker.
 // Reads from ticker_sentiment_snapshots (V2) instead of social_posts (V1).
 //
@@ -103,19 +127,14 @@ func GetTrendingSentiment(c *gin.Context) {
 func GetTickerSentiment(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Ticker symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
 	// Get 7d snapshot for main metrics
 	snapshot7d, err := database.GetTickerSnapshot(ticker, "7d")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch ticker sentiment",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ticker sentiment", err.Error())
 		return
 	}
 
@@ -186,14 +205,13 @@ func GetTickerSentiment(c *gin.Context) {
 // URL param: ticker (required)
 // Query params:
 //   - days: number of days (default: 7, max: 90)
+//   - weighted: "true" to also return an engagement-weighted daily series
 //
-// Example: GET /api/sentiment/AAPL/history?days=30
+// Example: GET /api/sentiment/AAPL/history?days=30&weighted=true
 func GetTickerSentimentHistory(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Ticker symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
@@ -208,22 +226,31 @@ func GetTickerSentimentHistory(c *gin.Context) {
 
 	points, err := database.GetSentimentTimeSeries(ticker, days)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch sentiment history",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch sentiment history", err.Error())
 		return
 	}
 
 	// Group points by date (multiple snapshots per day → pick latest)
 	history := groupTimeSeriesByDate(points)
 
-	period := fmt.Sprintf("%dd", days)
-	c.JSON(http.StatusOK, &models.SentimentHistoryResponse{
+	response := &models.SentimentHistoryResponse{
 		Ticker:  ticker,
-		Period:  period,
+		Period:  fmt.Sprintf("%dd", days),
 		History: history,
-	})
+	}
+
+	// Engagement-weighted series is computed from raw posts, so it is
+	// opt-in to keep the default path on the pre-aggregated hypertable.
+	if c.Query("weighted") == "true" {
+		weighted, err := database.GetWeightedSentimentHistory(ticker, days)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch weighted sentiment history", err.Error())
+			return
+		}
+		response.WeightedHistory = weighted
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetTickerPosts returns representative social media posts for a ticker.
@@ -233,26 +260,22 @@ func GetTickerSentimentHistory(c *gin.Context) {
 // URL param: ticker (required)
 // Query params:
 //   - limit: number of posts (default: 10, max: 20)
-//   - sort: sort option (default: "recent", options: "recent", "engagement", "bullish", "bearish")
+//   - offset: number of posts to skip for pagination (default: 0)
+//   - sort: sort option (default: "recent", options: "recent", "engagement",
+//     "upvotes", "confidence", "bullish", "bearish")
+//   - source: "reddit" or "stocktwits" (default: all sources)
+//   - subreddit: only posts from this subreddit (case-insensitive)
 //
-// Example: GET /api/sentiment/AAPL/posts?limit=10&sort=engagement
+// Example: GET /api/sentiment/AAPL/posts?limit=10&sort=upvotes&subreddit=wallstreetbets
 func GetTickerPosts(c *gin.Context) {
 	ticker := strings.ToUpper(c.Param("ticker"))
 	if ticker == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Ticker symbol is required",
-		})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Ticker symbol is required")
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 10
-	}
-	if limit > 20 {
-		limit = 20
-	}
+	limit := parseLimitUpTo(c, 10, 20)
+	offset := parseOffset(c)
 
 	// Parse sort parameter
 	sortStr := c.DefaultQuery("sort", "recent")
@@ -260,6 +283,10 @@ func GetTickerPosts(c *gin.Context) {
 	switch sortStr {
 	case "engagement":
 		sortOpt = models.SortByEngagement
+	case "upvotes":
+		sortOpt = models.SortByUpvotes
+	case "confidence":
+		sortOpt = models.SortByConfidence
 	case "bullish":
 		sortOpt = models.SortByBullish
 	case "bearish":
@@ -268,16 +295,39 @@ func GetTickerPosts(c *gin.Context) {
 		sortOpt = models.SortByRecent
 	}
 
-	posts, err := database.GetTickerPostsV2(ticker, sortOpt, limit)
+	source := strings.ToLower(c.Query("source"))
+	if source != "" && !models.ValidPostSources[source] {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid source. Must be one of: reddit, stocktwits")
+		return
+	}
+	subreddit := strings.TrimPrefix(c.Query("subreddit"), "r/")
+
+	posts, err := database.GetTickerPostsV2(ticker, models.TickerPostsQuery{
+		Sort:      sortOpt,
+		Source:    source,
+		Subreddit: subreddit,
+		Limit:     limit,
+		Offset:    offset,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch posts",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch posts", err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, posts)
+	c.JSON(http.StatusOK, gin.H{
+		"data": posts.Posts,
+		"meta": gin.H{
+			"ticker":    posts.Ticker,
+			"total":     posts.Total,
+			"sort":      posts.Sort,
+			"source":    source,
+			"subreddit": subreddit,
+			"limit":     posts.Limit,
+			"offset":    posts.Offset,
+			"has_more":  posts.Offset+len(posts.Posts) < posts.Total,
+			"timestamp": time.Now().UTC(),
+		},
+	})
 }
 
 // --- Helper functions ---
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+)
+
+const (
+	defaultStockListLimit = 50
+	maxStockListLimit     = 200
+)
+
+// GetStocks handles GET /api/v1/tickers/
+// Lists tickers a page at a time (?page=, ?limit= up to 200), filtered by
+// ?sector=, ?exchange=, ?asset_type=, ?min_market_cap= and ?max_market_cap=
+// and sorted by ?sort= (symbol, name, market_cap or sector) and ?order=.
+// Unknown sort columns and orders fall back to symbol asc, as in the admin
+// stock list. meta echoes the applied filters and sort.
+func GetStocks(c *gin.Context) {
+	page := parsePage(c)
+	limit := parseLimitUpTo(c, defaultStockListLimit, maxStockListLimit)
+
+	sortBy := c.DefaultQuery("sort", "symbol")
+	if _, ok := database.ValidStockListSortColumns[sortBy]; !ok {
+		sortBy = "symbol"
+	}
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		order = "asc"
+	}
+
+	filter := database.StockListFilter{
+		Sector:          c.Query("sector"),
+		Exchange:        c.Query("exchange"),
+		AssetType:       c.Query("asset_type"),
+		IncludeInactive: IncludeInactiveTickers(c),
+		Sort:            sortBy,
+		Order:           order,
+		Limit:           limit,
+		Offset:          (page - 1) * limit,
+	}
+
+	applied := gin.H{}
+	for key, value := range map[string]string{
+		"sector": filter.Sector, "exchange": filter.Exchange, "asset_type": filter.AssetType,
+	} {
+		if value != "" {
+			applied[key] = value
+		}
+	}
+	for key, dest := range map[string]**float64{
+		"min_market_cap": &filter.MinMarketCap,
+		"max_market_cap": &filter.MaxMarketCap,
+	} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, key+" must be a non-negative number")
+			return
+		}
+		*dest = &v
+		applied[key] = v
+	}
+	if filter.MinMarketCap != nil && filter.MaxMarketCap != nil && *filter.MinMarketCap > *filter.MaxMarketCap {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "min_market_cap must not exceed max_market_cap")
+		return
+	}
+
+	stocks, total, err := database.ListStocks(filter)
+	if err != nil {
+		log.Printf("Error listing stocks: %v", err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch stocks", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": stocks,
+		"meta": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + limit - 1) / limit,
+			"sort":       sortBy,
+			"order":      order,
+			"filters":    applied,
+			"timestamp":  time.Now().UTC(),
+		},
+	})
+}
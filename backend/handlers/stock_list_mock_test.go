@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stockListCols = []string{
+	"id", "symbol", "name", "exchange", "sector", "industry", "country", "currency",
+	"market_cap", "description", "website", "asset_type", "logo_url", "active",
+	"created_at", "updated_at",
+}
+
+func getStocks(path string) *httptest.ResponseRecorder {
+	r := setupMockRouterNoAuth()
+	r.GET("/tickers/", GetStocks)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+type stockListResponse struct {
+	Data []map[string]interface{} `json:"data"`
+	Meta map[string]interface{}   `json:"meta"`
+}
+
+func TestGetStockList_Mock_FiltersAndSort(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	where := `WHERE \(COALESCE\(active, true\) OR \$1\) AND sector = \$2 AND exchange = \$3 AND market_cap >= \$4 AND market_cap <= \$5`
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tickers `+where).
+		WithArgs(false, "Technology", "NASDAQ", 1e9, 5e11).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(45))
+	now := time.Now()
+	mock.ExpectQuery(where+`\s+ORDER BY market_cap DESC NULLS LAST, symbol\s+LIMIT \$6 OFFSET \$7`).
+		WithArgs(false, "Technology", "NASDAQ", 1e9, 5e11, 20, 20).
+		WillReturnRows(sqlmock.NewRows(stockListCols).
+			AddRow(1, "AVGO", "Broadcom Inc.", "NASDAQ", "Technology", "Semiconductors", "US", "USD",
+				"450000000000", "", "", "stock", "", true, now, now))
+
+	w := getStocks("/tickers/?sector=Technology&exchange=NASDAQ&min_market_cap=1e9&max_market_cap=500000000000&sort=market_cap&order=desc&page=2&limit=20")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp stockListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "AVGO", resp.Data[0]["symbol"])
+	assert.Equal(t, "market_cap", resp.Meta["sort"])
+	assert.Equal(t, "desc", resp.Meta["order"])
+	assert.Equal(t, float64(45), resp.Meta["total"])
+	assert.Equal(t, float64(3), resp.Meta["totalPages"])
+	assert.Equal(t, map[string]interface{}{
+		"sector":         "Technology",
+		"exchange":       "NASDAQ",
+		"min_market_cap": 1e9,
+		"max_market_cap": 5e11,
+	}, resp.Meta["filters"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockList_Mock_DefaultsAndInvalidSort(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM tickers WHERE \(COALESCE\(active, true\) OR \$1\)$`).
+		WithArgs(false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`ORDER BY symbol ASC NULLS LAST, symbol\s+LIMIT \$2 OFFSET \$3`).
+		WithArgs(false, 50, 0).
+		WillReturnRows(sqlmock.NewRows(stockListCols))
+
+	// Unknown sort columns fall back to symbol rather than reaching SQL
+	w := getStocks("/tickers/?sort=description%3BDROP%20TABLE%20tickers&order=sideways")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp stockListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotNil(t, resp.Data)
+	assert.Empty(t, resp.Data)
+	assert.Equal(t, "symbol", resp.Meta["sort"])
+	assert.Equal(t, "asc", resp.Meta["order"])
+	assert.Equal(t, map[string]interface{}{}, resp.Meta["filters"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockList_Mock_AssetTypeFilter(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery(`AND asset_type = \$2$`).
+		WithArgs(false, "etf").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`ORDER BY name ASC`).
+		WithArgs(false, "etf", 50, 0).
+		WillReturnRows(sqlmock.NewRows(stockListCols))
+
+	w := getStocks("/tickers/?asset_type=etf&sort=name")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"filters":{"asset_type":"etf"}`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockList_Mock_InvalidMarketCap(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, getStocks("/tickers/?min_market_cap=big").Code)
+	assert.Equal(t, http.StatusBadRequest, getStocks("/tickers/?max_market_cap=-1").Code)
+
+	w := getStocks("/tickers/?min_market_cap=10&max_market_cap=5")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "must not exceed")
+}
+
+func TestGetStockList_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT COUNT").WillReturnError(fmt.Errorf("connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, getStocks("/tickers/").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
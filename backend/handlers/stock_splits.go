@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+// splitsCacheTTL is the Redis cache TTL for per-stock split history. Splits
+// are rare, and tickers that never split would otherwise hit the upstream
+// on every request.
+const splitsCacheTTL = 24 * time.Hour
+
+// splitsCacheVersion is bumped when the response shape changes.
+const splitsCacheVersion = "v1"
+
+// newSplitService is swapped out in tests to avoid calling Polygon and FMP.
+var newSplitService = func() *services.SplitService {
+	return services.NewSplitServiceWith(polygonClient, fmpClient)
+}
+
+// GetStockSplits handles GET /api/v1/stocks/:ticker/splits
+// Returns split history (oldest first) with each split's ratio, whether it
+// was a reverse split, and the cumulative factor that pre-split prices are
+// divided by to match today's share count.
+func GetStockSplits(c *gin.Context) {
+	ticker := strings.ToUpper(c.Param("ticker"))
+	if !validTickerRe.MatchString(ticker) {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid ticker symbol")
+		return
+	}
+
+	ctx := c.Request.Context()
+	cacheKey := fmt.Sprintf("splits:%s:stock:%s", splitsCacheVersion, ticker)
+
+	if redisClient != nil {
+		cached, err := redisClient.Get(ctx, cacheKey).Result()
+		if err == nil {
+			c.Data(http.StatusOK, "application/json", []byte(cached))
+			return
+		}
+		if err != redis.Nil {
+			log.Printf("Redis GET error for %s: %v", cacheKey, err)
+		}
+	}
+
+	splits, err := newSplitService().GetSplits(ticker)
+	if err != nil {
+		log.Printf("Error fetching splits for %s: %v", ticker, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch stock splits", err.Error())
+		return
+	}
+
+	response := gin.H{
+		"data": services.DescribeSplits(splits),
+		"meta": gin.H{
+			"ticker":    ticker,
+			"count":     len(splits),
+			"timestamp": time.Now().UTC(),
+		},
+	}
+
+	if redisClient != nil {
+		responseJSON, err := json.Marshal(response)
+		if err != nil {
+			log.Printf("JSON marshal error for splits %s: %v", ticker, err)
+		} else if err := redisClient.Set(ctx, cacheKey, responseJSON, splitsCacheTTL).Err(); err != nil {
+			log.Printf("Redis SET error for %s: %v", cacheKey, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// adjustChartForSplits split-adjusts daily bars read from stock_prices.
+// Polygon and FMP bars come back adjusted already and must not go through
+// here. If the split history can't be loaded the bars are returned as is.
+func adjustChartForSplits(symbol string, points []models.ChartDataPoint) []models.ChartDataPoint {
+	splits, err := newSplitService().GetSplits(symbol)
+	if err != nil {
+		log.Printf("Chart for %s is not split-adjusted: %v", symbol, err)
+		return points
+	}
+	return services.AdjustUnreflectedSplits(points, splits)
+}
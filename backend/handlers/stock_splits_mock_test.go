@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+	"investorcenter-api/services"
+)
+
+type stubSplitSource struct {
+	splits []models.StockSplit
+	err    error
+	calls  int
+}
+
+func (s *stubSplitSource) GetStockSplits(symbol string) ([]models.StockSplit, error) {
+	s.calls++
+	return s.splits, s.err
+}
+
+func useSplitSources(t *testing.T, sources ...services.SplitFetcher) {
+	t.Helper()
+	orig := newSplitService
+	newSplitService = func() *services.SplitService { return services.NewSplitServiceWith(sources...) }
+	t.Cleanup(func() { newSplitService = orig })
+}
+
+func splitRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "ticker", "execution_date", "split_from", "split_to", "source"})
+}
+
+// expectSplitsSyncedAt expects the sync time lookup; nil means never synced
+func expectSplitsSyncedAt(mock sqlmock.Sqlmock, ticker string, syncedAt *time.Time) {
+	q := mock.ExpectQuery("SELECT synced_at FROM stock_split_syncs WHERE ticker = \\$1").WithArgs(ticker)
+	if syncedAt == nil {
+		q.WillReturnError(sql.ErrNoRows)
+		return
+	}
+	q.WillReturnRows(sqlmock.NewRows([]string{"synced_at"}).AddRow(*syncedAt))
+}
+
+func TestGetStockSplits_FromDatabase(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	source := &stubSplitSource{}
+	useSplitSources(t, source)
+
+	synced := time.Now().Add(-time.Hour)
+	expectSplitsSyncedAt(mock, "AAPL", &synced)
+	mock.ExpectQuery("SELECT .+ FROM stock_splits WHERE ticker = \\$1").
+		WithArgs("AAPL").
+		WillReturnRows(splitRows().
+			AddRow(1, "AAPL", time.Date(2014, 6, 9, 0, 0, 0, 0, time.UTC), 1.0, 7.0, "polygon").
+			AddRow(2, "AAPL", time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC), 1.0, 4.0, "polygon"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/stocks/:ticker/splits", GetStockSplits)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/aapl/splits", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []services.SplitDetail `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, 28.0, resp.Data[0].AdjustmentFactor)
+	assert.Equal(t, 4.0, resp.Data[1].AdjustmentFactor)
+	assert.Equal(t, "AAPL", resp.Meta["ticker"])
+	assert.Zero(t, source.calls, "recently synced splits are not refetched")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockSplits_IngestsWhenMissing(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	reverse := models.StockSplit{Ticker: "GE", ExecutionDate: time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC), SplitFrom: 8, SplitTo: 1, Source: "fmp"}
+	failing := &stubSplitSource{err: errors.New("splits API request failed with status: 429")}
+	fallback := &stubSplitSource{splits: []models.StockSplit{reverse}}
+	useSplitSources(t, failing, fallback)
+
+	expectSplitsSyncedAt(mock, "GE", nil)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO stock_splits .+ ON CONFLICT \\(ticker, execution_date\\)").
+		WithArgs("GE", "2021-08-02", 8.0, 1.0, "fmp").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO stock_split_syncs").WithArgs("GE").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT .+ FROM stock_splits").WithArgs("GE").
+		WillReturnRows(splitRows().AddRow(1, "GE", reverse.ExecutionDate, 8.0, 1.0, "fmp"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/stocks/:ticker/splits", GetStockSplits)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/GE/splits", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []services.SplitDetail `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.True(t, resp.Data[0].IsReverse)
+	assert.InDelta(t, 0.125, resp.Data[0].AdjustmentFactor, 1e-9)
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, fallback.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockSplits_InvalidTicker(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/stocks/:ticker/splits", GetStockSplits)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/BAD$TICKER/splits", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetStockSplits_AllSourcesFail(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	useSplitSources(t, &stubSplitSource{err: errors.New("upstream down")})
+
+	expectSplitsSyncedAt(mock, "AAPL", nil)
+	mock.ExpectQuery("SELECT .+ FROM stock_splits").WithArgs("AAPL").WillReturnRows(splitRows())
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/stocks/:ticker/splits", GetStockSplits)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/AAPL/splits", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockSplits_RefreshesStaleSync(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	later := models.StockSplit{Ticker: "NVDA", ExecutionDate: time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), SplitFrom: 1, SplitTo: 10, Source: "polygon"}
+	source := &stubSplitSource{splits: []models.StockSplit{later}}
+	useSplitSources(t, source)
+
+	synced := time.Now().Add(-72 * time.Hour)
+	expectSplitsSyncedAt(mock, "NVDA", &synced)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO stock_splits").WithArgs("NVDA", "2024-06-10", 1.0, 10.0, "polygon").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO stock_split_syncs").WithArgs("NVDA").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT .+ FROM stock_splits").WithArgs("NVDA").
+		WillReturnRows(splitRows().AddRow(1, "NVDA", later.ExecutionDate, 1.0, 10.0, "polygon"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/stocks/:ticker/splits", GetStockSplits)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/NVDA/splits", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, source.calls, "a stale sync is refetched")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockSplits_ServesStoredWhenRefreshFails(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	useSplitSources(t, &stubSplitSource{err: errors.New("upstream down")})
+
+	synced := time.Now().Add(-72 * time.Hour)
+	expectSplitsSyncedAt(mock, "AAPL", &synced)
+	mock.ExpectQuery("SELECT .+ FROM stock_splits").WithArgs("AAPL").
+		WillReturnRows(splitRows().AddRow(1, "AAPL", time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC), 1.0, 4.0, "polygon"))
+
+	r := setupMockRouterNoAuth()
+	r.GET("/api/v1/stocks/:ticker/splits", GetStockSplits)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stocks/AAPL/splits", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdjustChartForSplits(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	useSplitSources(t)
+
+	synced := time.Now()
+	expectSplitsSyncedAt(mock, "AAPL", &synced)
+	mock.ExpectQuery("SELECT .+ FROM stock_splits").WithArgs("AAPL").
+		WillReturnRows(splitRows().AddRow(1, "AAPL", time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC), 1.0, 4.0, "polygon"))
+
+	points := []models.ChartDataPoint{
+		{Timestamp: time.Date(2020, 8, 28, 0, 0, 0, 0, time.UTC), Close: decimal.NewFromInt(500)},
+		{Timestamp: time.Date(2020, 8, 31, 0, 0, 0, 0, time.UTC), Close: decimal.NewFromInt(129)},
+	}
+	adjusted := adjustChartForSplits("AAPL", points)
+	assert.True(t, adjusted[0].Close.Equal(decimal.NewFromInt(125)), "got %s", adjusted[0].Close)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Without split history the chart is served unadjusted
+	mock.ExpectQuery("SELECT synced_at").WillReturnError(errors.New("connection refused"))
+	assert.Equal(t, points, adjustChartForSplits("AAPL", points))
+}
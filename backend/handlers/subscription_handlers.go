@@ -1,10 +1,11 @@
 package handlers
 
 import (
+	"investorcenter-api/auth"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -26,7 +27,7 @@ func NewSubscriptionHandler(subscriptionService *services.SubscriptionService) *
 func (h *SubscriptionHandler) ListSubscriptionPlans(c *gin.Context) {
 	plans, err := h.subscriptionService.GetAllPlans()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription plans"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch subscription plans")
 		return
 	}
 
@@ -45,7 +46,7 @@ func (h *SubscriptionHandler) GetSubscriptionPlan(c *gin.Context) {
 
 	plan, err := h.subscriptionService.GetPlanByID(planID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription plan not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Subscription plan not found")
 		return
 	}
 
@@ -59,11 +60,14 @@ func (h *SubscriptionHandler) GetSubscriptionPlan(c *gin.Context) {
 // @Success 200 {object} models.UserSubscriptionWithPlan
 // @Router /api/v1/subscriptions/me [get]
 func (h *SubscriptionHandler) GetUserSubscription(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	subscription, err := h.subscriptionService.GetUserSubscription(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch subscription")
 		return
 	}
 
@@ -79,20 +83,28 @@ func (h *SubscriptionHandler) GetUserSubscription(c *gin.Context) {
 // @Success 201 {object} models.UserSubscription
 // @Router /api/v1/subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	var req models.CreateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	subscription, err := h.subscriptionService.CreateSubscription(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
+	recordAccountActivity(c, userID, models.AccountEventSubscriptionCreated, map[string]interface{}{
+		"plan_id":        subscription.PlanID,
+		"billing_period": subscription.BillingPeriod,
+	})
+
 	c.JSON(http.StatusCreated, subscription)
 }
 
@@ -105,19 +117,26 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 // @Success 200 {object} models.UserSubscription
 // @Router /api/v1/subscriptions/me [put]
 func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	var req models.UpdateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	subscription, err := h.subscriptionService.UpdateSubscription(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
+	recordAccountActivity(c, userID, models.AccountEventSubscriptionUpdated, map[string]interface{}{
+		"plan_id":        subscription.PlanID,
+		"billing_period": subscription.BillingPeriod,
+	})
 
 	c.JSON(http.StatusOK, subscription)
 }
@@ -128,12 +147,16 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 // @Success 200
 // @Router /api/v1/subscriptions/me/cancel [post]
 func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	if err := h.subscriptionService.CancelSubscription(userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, err.Error())
 		return
 	}
+	recordAccountActivity(c, userID, models.AccountEventSubscriptionCanceled, nil)
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Subscription canceled successfully"})
 }
@@ -145,11 +168,14 @@ func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
 // @Success 200 {object} models.SubscriptionLimits
 // @Router /api/v1/subscriptions/limits [get]
 func (h *SubscriptionHandler) GetSubscriptionLimits(c *gin.Context) {
-	userID := c.GetString("user_id")
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
 
 	limits, err := h.subscriptionService.GetUserLimits(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscription limits"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch subscription limits")
 		return
 	}
 
@@ -164,12 +190,16 @@ func (h *SubscriptionHandler) GetSubscriptionLimits(c *gin.Context) {
 // @Success 200 {array} models.PaymentHistory
 // @Router /api/v1/subscriptions/payments [get]
 func (h *SubscriptionHandler) GetPaymentHistory(c *gin.Context) {
-	userID := c.GetString("user_id")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	limit := parseLimit(c, 50)
 
 	payments, err := h.subscriptionService.GetPaymentHistory(userID, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payment history"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch payment history")
 		return
 	}
 
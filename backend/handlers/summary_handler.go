@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"time"
 
+	"investorcenter-api/auth"
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -19,7 +21,8 @@ func init() {
 
 // GetMarketSummary handles GET /api/v1/markets/summary
 // Returns an LLM-generated (or template-based fallback) market summary.
-// Results are cached in Redis for 15 minutes.
+// Results are cached in Redis for 15 minutes. Rate-limited requests are
+// served from cache only.
 func GetMarketSummary(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -39,14 +42,16 @@ func GetMarketSummary(c *gin.Context) {
 		return
 	}
 
+	if auth.RateLimited(c) {
+		respondError(c, http.StatusTooManyRequests, httputil.CodeRateLimited, "Too many requests. Please try again later.")
+		return
+	}
+
 	// Generate on-demand
 	result, err := summaryGenerator.GenerateMarketSummary(ctx)
 	if err != nil {
 		log.Printf("Error generating market summary: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Failed to generate market summary",
-			"message": "Market summary is temporarily unavailable. Please try again later.",
-		})
+		respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Failed to generate market summary", "Market summary is temporarily unavailable. Please try again later.")
 		return
 	}
 
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/auth"
 )
 
 func TestGetMarketSummary_HandlerExists(t *testing.T) {
@@ -13,3 +18,19 @@ func TestGetMarketSummary_HandlerExists(t *testing.T) {
 	// Should not panic — proves the handler has the correct gin.HandlerFunc signature
 	r.GET("/api/v1/markets/summary", GetMarketSummary)
 }
+
+func TestGetMarketSummary_RateLimitedWithoutCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/v1/markets/summary", func(c *gin.Context) {
+		c.Set(auth.ContextRateLimited, true)
+		c.Next()
+	}, GetMarketSummary)
+
+	// Nothing is cached (no Redis in tests), so a degraded request is
+	// rejected rather than generating a fresh summary
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/markets/summary", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// maxTickerBatchSymbols caps the symbols accepted by one batch request.
+const maxTickerBatchSymbols = 100
+
+// GetTickersBatch handles POST /api/v1/tickers/batch
+// Returns the compact payload (name, price, change, market cap) for a list of
+// symbols in one query, keyed by symbol, so a watchlist renders without a
+// GetTicker call per row. Unknown (and, by default, inactive) symbols are
+// listed in missing instead of failing the request.
+func GetTickersBatch(c *gin.Context) {
+	var req models.TickerBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request", err.Error())
+		return
+	}
+
+	symbols := normalizeTickerSymbols(req.Symbols)
+	if len(symbols) == 0 {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "At least one symbol is required")
+		return
+	}
+	if len(symbols) > maxTickerBatchSymbols {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, fmt.Sprintf("Too many symbols. Maximum is %d", maxTickerBatchSymbols))
+		return
+	}
+
+	summaries, err := database.GetTickerSummaries(symbols, IncludeInactiveTickers(c))
+	if err != nil {
+		log.Printf("Error fetching ticker summaries for %d symbols: %v", len(symbols), err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch tickers", err.Error())
+		return
+	}
+
+	data := make(map[string]models.TickerSummary, len(summaries))
+	for _, s := range summaries {
+		data[strings.ToUpper(s.Symbol)] = s
+	}
+	missing := []string{}
+	for _, symbol := range symbols {
+		if _, ok := data[symbol]; !ok {
+			missing = append(missing, symbol)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    data,
+		"missing": missing,
+		"meta": gin.H{
+			"requested": len(symbols),
+			"count":     len(data),
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tickerSummaryCols = []string{"symbol", "name", "market_cap", "price", "change", "change_percent"}
+
+func postTickersBatch(body string) *httptest.ResponseRecorder {
+	r := setupMockRouterNoAuth()
+	r.POST("/tickers/batch", GetTickersBatch)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/tickers/batch", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetTickersBatch_Mock_MapsBySymbolAndReportsMissing(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM stock_prices").
+		WithArgs(`{"AAPL","MSFT","FAKE"}`, false).
+		WillReturnRows(sqlmock.NewRows(tickerSummaryCols).
+			AddRow("AAPL", "Apple Inc.", 3.4e12, 230.5, 2.5, 1.0965).
+			AddRow("MSFT", "Microsoft Corporation", 3.1e12, nil, nil, nil))
+
+	w := postTickersBatch(`{"symbols": ["aapl", "MSFT", "AAPL", " fake "]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data    map[string]map[string]interface{} `json:"data"`
+		Missing []string                          `json:"missing"`
+		Meta    map[string]interface{}            `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "Apple Inc.", resp.Data["AAPL"]["name"])
+	assert.Equal(t, 230.5, resp.Data["AAPL"]["price"])
+	assert.Equal(t, 2.5, resp.Data["AAPL"]["change"])
+	assert.Equal(t, 3.4e12, resp.Data["AAPL"]["marketCap"])
+	assert.Nil(t, resp.Data["MSFT"]["price"], "no price data is null, not zero")
+	assert.Equal(t, []string{"FAKE"}, resp.Missing)
+	assert.Equal(t, float64(3), resp.Meta["requested"])
+	assert.Equal(t, float64(2), resp.Meta["count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickersBatch_Mock_AllMissing(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM stock_prices").
+		WillReturnRows(sqlmock.NewRows(tickerSummaryCols))
+
+	w := postTickersBatch(`{"symbols": ["NOPE"]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{}`, mustJSONField(t, w.Body.Bytes(), "data"))
+	assert.JSONEq(t, `["NOPE"]`, mustJSONField(t, w.Body.Bytes(), "missing"))
+}
+
+func TestGetTickersBatch_Mock_TooManySymbols(t *testing.T) {
+	symbols := make([]string, maxTickerBatchSymbols+1)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf(`"T%d"`, i)
+	}
+
+	w := postTickersBatch(`{"symbols": [` + strings.Join(symbols, ",") + `]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Maximum is 100")
+}
+
+func TestGetTickersBatch_Mock_EmptyList(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, postTickersBatch(`{"symbols": []}`).Code)
+	assert.Equal(t, http.StatusBadRequest, postTickersBatch(`{"symbols": [" "]}`).Code)
+}
+
+func TestGetTickersBatch_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM stock_prices").WillReturnError(fmt.Errorf("connection refused"))
+
+	w := postTickersBatch(`{"symbols": ["AAPL"]}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func mustJSONField(t *testing.T, body []byte, field string) string {
+	t.Helper()
+	var resp map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &resp))
+	return string(resp[field])
+}
@@ -6,13 +6,13 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/shopspring/decimal"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 )
@@ -47,10 +47,7 @@ func GetTicker(c *gin.Context) {
 			log.Printf("Found crypto %s in Redis: %s", symbol, cryptoData.Name)
 		} else {
 			log.Printf("Symbol %s not found in database or Redis", symbol)
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":  "Ticker not found",
-				"symbol": symbol,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Ticker not found")
 			return
 		}
 	} else {
@@ -73,10 +70,7 @@ func GetTicker(c *gin.Context) {
 			log.Printf("✓ Got crypto price for %s from Redis: $%.2f", symbol, cryptoData.CurrentPrice)
 		} else {
 			log.Printf("Failed to get crypto price for %s from Redis", symbol)
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":  "Price data temporarily unavailable",
-				"symbol": symbol,
-			})
+			respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Price data temporarily unavailable")
 			return
 		}
 		marketStatus = "open" // Crypto markets are always open
@@ -112,10 +106,7 @@ func GetTicker(c *gin.Context) {
 
 		if priceErr != nil {
 			log.Printf("Failed to get real-time price data for %s: %v", symbol, priceErr)
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":  "Price data temporarily unavailable",
-				"symbol": symbol,
-			})
+			respondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Price data temporarily unavailable")
 			return
 		}
 
@@ -170,7 +161,7 @@ func GetTicker(c *gin.Context) {
 					"isCrypto":  isCrypto,
 					"logoUrl":   stock.LogoURL,
 				},
-				"price": gin.H{
+				"price": withPriceFreshness(gin.H{
 					"price":         priceData.Price.String(),
 					"open":          priceData.Open.String(),
 					"high":          priceData.High.String(),
@@ -181,7 +172,7 @@ func GetTicker(c *gin.Context) {
 					"changePercent": priceData.ChangePercent.String(),
 					"timestamp":     priceData.Timestamp.Unix(),
 					"lastUpdated":   priceData.Timestamp.Format(time.RFC3339),
-				},
+				}, priceData.Timestamp, isCrypto),
 				"market":       buildInitialMarketResponse(c, isCrypto, marketStatus, shouldUpdateRealtime),
 				"keyMetrics":   buildKeyMetrics(priceData, fundamentals, stock),
 				"fundamentals": fundamentals,
@@ -202,17 +193,7 @@ func GetTicker(c *gin.Context) {
 // GetTickerChart returns chart data for a symbol
 func GetTickerChart(c *gin.Context) {
 	symbol := strings.ToUpper(c.Param("symbol"))
-	period := c.DefaultQuery("period", "1Y")
-
-	// Validate period against allowed values
-	validPeriods := map[string]bool{
-		"1D": true, "5D": true, "1W": true, "1M": true,
-		"3M": true, "6M": true, "YTD": true, "1Y": true,
-		"3Y": true, "5Y": true, "MAX": true,
-	}
-	if !validPeriods[period] {
-		period = "1Y"
-	}
+	period := chartPeriod(c)
 
 	log.Printf("GetTickerChart called for symbol: %s, period: %s", symbol, period)
 
@@ -236,8 +217,7 @@ func GetTickerChart(c *gin.Context) {
 	if isCrypto {
 		// Use CoinGecko for crypto charts
 		log.Printf("Fetching crypto chart data for %s from CoinGecko", symbol)
-		coinGeckoClient := services.NewCoinGeckoClient()
-		chartData, chartErr = coinGeckoClient.GetChartData(symbol, period)
+		chartData, chartErr = getCryptoChartData(symbol, period)
 		dataSource = "coingecko"
 
 		if chartErr != nil {
@@ -264,7 +244,7 @@ func GetTickerChart(c *gin.Context) {
 			return
 		}
 	} else {
-		// For stocks: Try database first (faster, has 3 years of data), fallback to Polygon
+		// For stocks: Try database first (faster, has 3 years of data), fallback to FMP then Polygon
 		if period == "1D" {
 			// For intraday data, must use Polygon
 			log.Printf("Fetching intraday chart data for %s from Polygon", symbol)
@@ -278,14 +258,24 @@ func GetTickerChart(c *gin.Context) {
 			chartData, chartErr = priceService.GetHistoricalPrices(c.Request.Context(), symbol, period)
 
 			if chartErr == nil && len(chartData) > 0 {
+				chartData = adjustChartForSplits(symbol, chartData)
 				dataSource = "database"
 				log.Printf("✓ Successfully fetched %d data points from database for %s", len(chartData), symbol)
 			} else {
-				// Fallback to Polygon if database query fails or returns no data
-				log.Printf("Database query failed or returned no data for %s, falling back to Polygon: %v", symbol, chartErr)
-				polygonClient := services.NewPolygonClient()
-				chartData, chartErr = polygonClient.GetDailyData(symbol, services.GetDaysFromPeriod(period))
-				dataSource = "polygon"
+				// Fallback to FMP, then Polygon, if database query fails or returns no data
+				log.Printf("Database query failed or returned no data for %s, falling back to FMP: %v", symbol, chartErr)
+				to := time.Now()
+				from := to.AddDate(0, 0, -services.GetDaysFromPeriod(period))
+				prices, fmpErr := fmpClient.GetHistoricalPrices(symbol, from, to)
+				if fmpErr == nil && len(prices) > 0 {
+					chartData, chartErr = services.FMPHistoricalToChartData(prices), nil
+					dataSource = "fmp"
+				} else {
+					log.Printf("FMP returned no price history for %s, falling back to Polygon: %v", symbol, fmpErr)
+					polygonClient := services.NewPolygonClient()
+					chartData, chartErr = polygonClient.GetDailyData(symbol, services.GetDaysFromPeriod(period))
+					dataSource = "polygon"
+				}
 			}
 		}
 
@@ -320,6 +310,22 @@ func GetTickerChart(c *gin.Context) {
 
 // Helper functions
 
+// chartPeriods are the periods the chart endpoints accept
+var chartPeriods = map[string]bool{
+	"1D": true, "5D": true, "1W": true, "1M": true,
+	"3M": true, "6M": true, "YTD": true, "1Y": true,
+	"3Y": true, "5Y": true, "MAX": true,
+}
+
+// chartPeriod reads the ?period= of a chart request, defaulting to 1Y
+func chartPeriod(c *gin.Context) string {
+	period := c.DefaultQuery("period", "1Y")
+	if !chartPeriods[period] {
+		period = "1Y"
+	}
+	return period
+}
+
 func isCryptoAsset(assetType, symbol string) bool {
 	// Check asset type from database
 	if assetType == "crypto" {
@@ -456,7 +462,7 @@ func GetTickerRealTimePrice(c *gin.Context) {
 		var price CryptoRealTimePrice
 		if err := json.Unmarshal([]byte(cryptoData), &price); err == nil {
 			c.JSON(http.StatusOK, gin.H{
-				"data": gin.H{
+				"data": withPriceFreshness(gin.H{
 					"symbol":        symbol,
 					"price":         fmt.Sprintf("%.2f", price.Price),
 					"change":        fmt.Sprintf("%.2f", price.Price*price.Change24h/100),
@@ -464,9 +470,8 @@ func GetTickerRealTimePrice(c *gin.Context) {
 					"volume":        price.Volume24h,
 					"timestamp":     time.Now().Unix(),
 					"lastUpdated":   price.LastUpdated,
-					"marketStatus":  "open", // Crypto is always open
 					"assetType":     "crypto",
-				},
+				}, convertCryptoPriceToStockPrice(&price).Timestamp, true), // marketStatus is always "open" for crypto
 				"meta": gin.H{
 					"timestamp": time.Now().UTC(),
 					"source":    "redis",
@@ -487,11 +492,7 @@ func GetTickerRealTimePrice(c *gin.Context) {
 		priceData, fallbackErr := polygonClient.GetQuote(symbol)
 		if fallbackErr != nil {
 			log.Printf("Polygon API error for %s: %v", symbol, fallbackErr)
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Price not available",
-				"symbol":  symbol,
-				"message": "This ticker is not currently tracked",
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Price not available", "This ticker is not currently tracked")
 			return
 		}
 		isOpen := polygonClient.IsMarketOpen()
@@ -500,7 +501,7 @@ func GetTickerRealTimePrice(c *gin.Context) {
 			session = "regular"
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"data": gin.H{
+			"data": withPriceFreshness(gin.H{
 				"symbol":        symbol,
 				"price":         priceData.Price.String(),
 				"change":        priceData.Change.String(),
@@ -508,7 +509,7 @@ func GetTickerRealTimePrice(c *gin.Context) {
 				"volume":        priceData.Volume,
 				"timestamp":     priceData.Timestamp.Unix(),
 				"lastUpdated":   priceData.Timestamp.Format(time.RFC3339),
-			},
+			}, priceData.Timestamp, false),
 			"market": gin.H{
 				"session":        session,
 				"isOpen":         isOpen,
@@ -544,7 +545,7 @@ func GetTickerRealTimePrice(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": gin.H{
+		"data": withPriceFreshness(gin.H{
 			"symbol":        symbol,
 			"price":         snapshot.Price.StringFixed(2),
 			"change":        snapshot.Change.StringFixed(2),
@@ -552,7 +553,7 @@ func GetTickerRealTimePrice(c *gin.Context) {
 			"volume":        snapshot.Volume,
 			"timestamp":     snapshot.Timestamp.Unix(),
 			"lastUpdated":   snapshot.Timestamp.Format(time.RFC3339),
-		},
+		}, snapshot.Timestamp, false),
 		"market": marketData,
 		"meta": gin.H{
 			"timestamp": time.Now().UTC(),
@@ -610,7 +611,7 @@ func GetTickerNews(c *gin.Context) {
 	// Fallback to Polygon API for news
 	polygonClient := services.NewPolygonClient()
 	url := "https://api.polygon.io/v2/reference/news?ticker=" + symbol + "&limit=30&apikey=" + polygonClient.APIKey
-	resp, err := polygonClient.Client.Get(url)
+	resp, err := polygonClient.Get(url)
 
 	if err != nil || resp.StatusCode != 200 {
 		log.Printf("Failed to get news from Polygon for %s: %v", symbol, err)
@@ -659,22 +660,14 @@ func GetTickerNews(c *gin.Context) {
 func GetAllCryptos(c *gin.Context) {
 	log.Printf("GetAllCryptos called")
 
-	// Get page parameter (default to 1)
-	page := 1
-	if pageParam := c.Query("page"); pageParam != "" {
-		if p, err := strconv.Atoi(pageParam); err == nil && p > 0 {
-			page = p
-		}
-	}
+	page := parsePage(c)
 
 	// Get crypto symbols from Redis (ranked by market cap from CoinGecko)
 	ctx := context.Background()
 	symbols, err := redisClient.ZRange(ctx, "crypto:symbols:ranked", 0, -1).Result()
 	if err != nil {
 		log.Printf("Failed to get crypto symbols from Redis: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch crypto symbols",
-		})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch crypto symbols")
 		return
 	}
 
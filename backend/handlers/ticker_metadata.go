@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// maxTickerMetadataSymbols caps the symbols accepted by one metadata request.
+const maxTickerMetadataSymbols = 200
+
+// GetTickersMetadata handles POST /api/v1/tickers/metadata
+// Returns name, exchange, sector, industry, logo and asset type for a list of
+// symbols in one query. Symbols are uppercased and deduped; unknown (and, by
+// default, inactive) symbols are omitted from data and listed in meta.not_found.
+func GetTickersMetadata(c *gin.Context) {
+	var req models.TickerMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid request", err.Error())
+		return
+	}
+
+	symbols := normalizeTickerSymbols(req.Symbols)
+	if len(symbols) == 0 {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "At least one symbol is required")
+		return
+	}
+	if len(symbols) > maxTickerMetadataSymbols {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, fmt.Sprintf("Too many symbols. Maximum is %d", maxTickerMetadataSymbols))
+		return
+	}
+
+	metadata, err := database.GetTickerMetadata(symbols, IncludeInactiveTickers(c))
+	if err != nil {
+		log.Printf("Error fetching ticker metadata for %d symbols: %v", len(symbols), err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ticker metadata", err.Error())
+		return
+	}
+
+	found := make(map[string]bool, len(metadata))
+	for _, m := range metadata {
+		found[strings.ToUpper(m.Symbol)] = true
+	}
+	notFound := []string{}
+	for _, symbol := range symbols {
+		if !found[symbol] {
+			notFound = append(notFound, symbol)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": metadata,
+		"meta": gin.H{
+			"requested": len(symbols),
+			"count":     len(metadata),
+			"not_found": notFound,
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
+
+// normalizeTickerSymbols uppercases, trims and dedupes symbols, dropping
+// blanks and keeping first-seen order
+func normalizeTickerSymbols(raw []string) []string {
+	symbols := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, s := range raw {
+		symbol := strings.ToUpper(strings.TrimSpace(s))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
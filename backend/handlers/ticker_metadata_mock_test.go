@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tickerMetadataCols = []string{"symbol", "name", "exchange", "sector", "industry", "logo_url", "asset_type"}
+
+func postTickerMetadata(body string) *httptest.ResponseRecorder {
+	r := setupMockRouterNoAuth()
+	r.POST("/tickers/metadata", GetTickersMetadata)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/tickers/metadata", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetTickersMetadata_Mock_DedupesAndReportsMissing(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT DISTINCT ON").
+		WithArgs(`{"AAPL","MSFT","FAKE"}`, false).
+		WillReturnRows(sqlmock.NewRows(tickerMetadataCols).
+			AddRow("AAPL", "Apple Inc.", "NASDAQ", "Technology", "Consumer Electronics", "https://logo/aapl.png", "stock").
+			AddRow("MSFT", "Microsoft Corporation", "NASDAQ", "Technology", "Software", "", "stock"))
+
+	w := postTickerMetadata(`{"symbols": ["aapl", "MSFT", "AAPL", " fake "]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "AAPL", resp.Data[0]["symbol"])
+	assert.Equal(t, "Technology", resp.Data[0]["sector"])
+	assert.Equal(t, "Consumer Electronics", resp.Data[0]["industry"])
+	assert.Equal(t, "https://logo/aapl.png", resp.Data[0]["logoUrl"])
+	assert.Equal(t, "stock", resp.Data[0]["assetType"])
+	assert.Equal(t, float64(3), resp.Meta["requested"])
+	assert.Equal(t, []interface{}{"FAKE"}, resp.Meta["not_found"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickersMetadata_Mock_TooManySymbols(t *testing.T) {
+	symbols := make([]string, maxTickerMetadataSymbols+1)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf(`"T%d"`, i)
+	}
+
+	w := postTickerMetadata(`{"symbols": [` + strings.Join(symbols, ",") + `]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Too many symbols")
+}
+
+func TestGetTickersMetadata_Mock_EmptyList(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, postTickerMetadata(`{"symbols": []}`).Code)
+	assert.Equal(t, http.StatusBadRequest, postTickerMetadata(`{"symbols": ["  "]}`).Code)
+}
+
+func TestGetTickersMetadata_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT DISTINCT ON").WillReturnError(fmt.Errorf("connection refused"))
+
+	w := postTickerMetadata(`{"symbols": ["AAPL"]}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
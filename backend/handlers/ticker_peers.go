@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+const (
+	defaultTickerPeersLimit = 10
+	maxTickerPeersLimit     = 50
+)
+
+// GetTickerPeers handles GET /api/v1/tickers/:symbol/peers
+// Returns the stocks in the same sector and industry whose market cap is
+// closest to the ticker's, with market cap, P/E and the latest price change.
+// ?metric= ranks by closeness in pe_ratio, pb_ratio or ps_ratio instead, and
+// ?limit= (default 10, max 50) caps the list. Tickers without a sector or
+// industry get peers from the same exchange; meta.match says which was used.
+func GetTickerPeers(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	if !validTickerRe.MatchString(symbol) {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid ticker symbol")
+		return
+	}
+
+	limit := defaultTickerPeersLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxTickerPeersLimit {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, fmt.Sprintf("limit must be between 1 and %d", maxTickerPeersLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	metric := c.DefaultQuery("metric", "market_cap")
+	if _, ok := database.TickerPeerMetrics[metric]; !ok {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "metric must be one of market_cap, pe_ratio, pb_ratio, ps_ratio")
+		return
+	}
+
+	stock, err := database.GetStockBySymbol(symbol)
+	if err != nil {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Stock not found", fmt.Sprintf("No data available for %s", symbol))
+		return
+	}
+
+	var filter database.TickerPeerFilter
+	match := "none"
+	switch {
+	case stock.Industry != "":
+		filter, match = database.TickerPeerFilter{Sector: stock.Sector, Industry: stock.Industry}, "industry"
+	case stock.Sector != "":
+		filter, match = database.TickerPeerFilter{Sector: stock.Sector}, "sector"
+	case stock.Exchange != "":
+		filter, match = database.TickerPeerFilter{Exchange: stock.Exchange}, "exchange"
+	}
+
+	peers := []models.TickerPeer{}
+	if match != "none" {
+		peers, err = database.GetTickerPeers(symbol, filter, metric, limit)
+		if err != nil {
+			log.Printf("Error fetching peers for %s: %v", symbol, err)
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch ticker peers", err.Error())
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": peers,
+		"meta": gin.H{
+			"ticker":    symbol,
+			"match":     match,
+			"metric":    metric,
+			"count":     len(peers),
+			"timestamp": time.Now().UTC(),
+		},
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tickerPeerCols = []string{"symbol", "name", "market_cap", "pe_ratio", "price", "change", "change_percent"}
+
+func expectPeerTarget(mock sqlmock.Sqlmock, symbol, exchange, sector, industry string) {
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM tickers").
+		WithArgs(symbol).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "symbol", "name", "exchange", "sector", "industry",
+			"country", "currency", "market_cap", "description", "website",
+			"asset_type", "logo_url", "created_at", "updated_at",
+		}).AddRow(1, symbol, symbol+" Corp", exchange, sector, industry, "US", "USD", nil, "", "", "stock", "", now, now))
+}
+
+func getTickerPeers(path string) *httptest.ResponseRecorder {
+	r := setupMockRouterNoAuth()
+	r.GET("/tickers/:symbol/peers", GetTickerPeers)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+type tickerPeersResponse struct {
+	Data []map[string]interface{} `json:"data"`
+	Meta map[string]interface{}   `json:"meta"`
+}
+
+func TestGetTickerPeers_Mock_IndustryPeers(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectPeerTarget(mock, "AAPL", "NASDAQ", "Technology", "Consumer Electronics")
+	mock.ExpectQuery(`WITH target AS .+ABS\(t\.market_cap::float8 - .+t\.sector = \$2 AND t\.industry = \$3`).
+		WithArgs("AAPL", "Technology", "Consumer Electronics", 10).
+		WillReturnRows(sqlmock.NewRows(tickerPeerCols).
+			AddRow("SONY", "Sony Group Corp", 1.2e11, 17.5, 95.0, 1.5, 1.6).
+			AddRow("GPRO", "GoPro Inc", 2.1e8, nil, nil, nil, nil))
+
+	w := getTickerPeers("/tickers/aapl/peers")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp tickerPeersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "SONY", resp.Data[0]["symbol"])
+	assert.Equal(t, 1.2e11, resp.Data[0]["marketCap"])
+	assert.Equal(t, 17.5, resp.Data[0]["peRatio"])
+	assert.Equal(t, 1.6, resp.Data[0]["changePercent"])
+	assert.Nil(t, resp.Data[1]["price"])
+	assert.Equal(t, "industry", resp.Meta["match"])
+	assert.Equal(t, "market_cap", resp.Meta["metric"])
+	assert.Equal(t, float64(2), resp.Meta["count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPeers_Mock_MetricAndLimit(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectPeerTarget(mock, "JPM", "NYSE", "Financial Services", "")
+	mock.ExpectQuery(`ABS\(v\.pe_ratio - .+WHERE t\.sector = \$2\s`).
+		WithArgs("JPM", "Financial Services", 3).
+		WillReturnRows(sqlmock.NewRows(tickerPeerCols).
+			AddRow("BAC", "Bank of America Corp", 3.0e11, 12.1, 40.0, -0.2, -0.5))
+
+	w := getTickerPeers("/tickers/JPM/peers?metric=pe_ratio&limit=3")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp tickerPeersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "sector", resp.Meta["match"])
+	assert.Equal(t, "pe_ratio", resp.Meta["metric"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPeers_Mock_ExchangeFallback(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectPeerTarget(mock, "NEWCO", "NYSE", "", "")
+	mock.ExpectQuery(`WHERE t\.exchange = \$2\s`).
+		WithArgs("NEWCO", "NYSE", 10).
+		WillReturnRows(sqlmock.NewRows(tickerPeerCols))
+
+	w := getTickerPeers("/tickers/NEWCO/peers")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp tickerPeersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "exchange", resp.Meta["match"])
+	assert.NotNil(t, resp.Data)
+	assert.Empty(t, resp.Data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPeers_Mock_InvalidParams(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, getTickerPeers("/tickers/AAPL/peers?limit=0").Code)
+	assert.Equal(t, http.StatusBadRequest, getTickerPeers("/tickers/AAPL/peers?limit=51").Code)
+	assert.Equal(t, http.StatusBadRequest, getTickerPeers("/tickers/AAPL/peers?limit=abc").Code)
+
+	w := getTickerPeers("/tickers/AAPL/peers?metric=volume")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "pe_ratio")
+}
+
+func TestGetTickerPeers_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM tickers").WithArgs("ZZZZ").WillReturnError(fmt.Errorf("sql: no rows in result set"))
+
+	assert.Equal(t, http.StatusNotFound, getTickerPeers("/tickers/ZZZZ/peers").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTickerPeers_Mock_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectPeerTarget(mock, "AAPL", "NASDAQ", "Technology", "Consumer Electronics")
+	mock.ExpectQuery("WITH target AS").WillReturnError(fmt.Errorf("connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, getTickerPeers("/tickers/AAPL/peers").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// Second factor methods, as recorded in account activity
+const (
+	secondFactorTOTP         = "totp"
+	secondFactorRecoveryCode = "recovery_code"
+)
+
+// verifySecondFactor checks code against an enabled 2FA enrollment, as
+// either a current TOTP code or an unused recovery code. It returns the
+// method that matched, or "" when the code is wrong or a TOTP code is
+// being replayed. A matched recovery code is used up.
+func verifySecondFactor(tf *database.UserTwoFactor, code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if !strings.Contains(code, "-") {
+		secret, err := auth.DecryptTOTPSecret(tf.SecretEncrypted)
+		if err != nil {
+			return "", err
+		}
+		step, ok := auth.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return "", nil
+		}
+		fresh, err := database.UseTwoFactorStep(tf.UserID, step)
+		if err != nil || !fresh {
+			return "", err
+		}
+		return secondFactorTOTP, nil
+	}
+
+	used, err := database.UseRecoveryCode(tf.UserID, auth.HashRecoveryCode(code))
+	if err != nil || !used {
+		return "", err
+	}
+	return secondFactorRecoveryCode, nil
+}
+
+// GetTwoFactorStatus handles GET /api/v1/user/2fa
+// Reports whether 2FA is enabled and how many recovery codes are left.
+func GetTwoFactorStatus(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	tf, err := database.GetUserTwoFactor(userID)
+	if err != nil {
+		log.Printf("Error fetching two-factor settings for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch two-factor settings")
+		return
+	}
+
+	status := gin.H{"enabled": false, "recovery_codes_remaining": 0}
+	if tf != nil && tf.Enabled {
+		status["enabled"] = true
+		status["enabled_at"] = tf.EnabledAt
+		status["recovery_codes_remaining"] = len(tf.RecoveryCodeHashes)
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTwoFactor handles POST /api/v1/user/2fa/enroll
+// Generates a TOTP secret for the user to add to an authenticator app. 2FA
+// stays off until EnableTwoFactor confirms a code; enrolling again before
+// then replaces the secret.
+func EnrollTwoFactor(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate two-factor secret")
+		return
+	}
+	encrypted, err := auth.EncryptTOTPSecret(secret)
+	if err != nil {
+		log.Printf("Failed to encrypt TOTP secret for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate two-factor secret")
+		return
+	}
+
+	if err := database.SaveTwoFactorEnrollment(userID, encrypted); err != nil {
+		if errors.Is(err, database.ErrTwoFactorAlreadyEnabled) {
+			respondError(c, http.StatusConflict, httputil.CodeConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		log.Printf("Error saving two-factor enrollment for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to start two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(secret, user.Email),
+	})
+}
+
+// EnableTwoFactor handles POST /api/v1/user/2fa/enable
+// Turns 2FA on once the user proves their app generates valid codes, and
+// returns single-use recovery codes. They are only shown here.
+func EnableTwoFactor(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorEnableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	tf, err := database.GetUserTwoFactor(userID)
+	if err != nil {
+		log.Printf("Error fetching two-factor settings for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to enable two-factor authentication")
+		return
+	}
+	if tf == nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Start two-factor enrollment first")
+		return
+	}
+	if tf.Enabled {
+		respondError(c, http.StatusConflict, httputil.CodeConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := auth.DecryptTOTPSecret(tf.SecretEncrypted)
+	if err != nil {
+		log.Printf("Failed to decrypt TOTP secret for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to enable two-factor authentication")
+		return
+	}
+	step, valid := auth.ValidateTOTP(secret, req.Code, time.Now())
+	if !valid {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid verification code")
+		return
+	}
+
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to generate recovery codes")
+		return
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	if err := database.EnableTwoFactor(userID, hashes, step); err != nil {
+		if errors.Is(err, database.ErrTwoFactorAlreadyEnabled) {
+			respondError(c, http.StatusConflict, httputil.CodeConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		log.Printf("Error enabling two-factor authentication for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to enable two-factor authentication")
+		return
+	}
+	recordAccountActivity(c, userID, models.AccountEventTwoFactorEnabled, nil)
+
+	c.JSON(http.StatusOK, models.TwoFactorEnableResponse{Enabled: true, RecoveryCodes: codes})
+}
+
+// DisableTwoFactor handles POST /api/v1/user/2fa/disable
+// Turns 2FA off. Requires the password and a TOTP or recovery code so a
+// stolen access token alone can't remove the second factor.
+func DisableTwoFactor(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorDisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+	if user.PasswordHash == nil || !auth.CheckPasswordHash(req.Password, *user.PasswordHash) {
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Password is incorrect")
+		return
+	}
+
+	tf, err := database.GetUserTwoFactor(userID)
+	if err != nil {
+		log.Printf("Error fetching two-factor settings for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to disable two-factor authentication")
+		return
+	}
+	if tf == nil || !tf.Enabled {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Two-factor authentication is not enabled")
+		return
+	}
+
+	method, err := verifySecondFactor(tf, req.Code)
+	if err != nil {
+		log.Printf("Error verifying two-factor code for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to disable two-factor authentication")
+		return
+	}
+	if method == "" {
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid two-factor code")
+		return
+	}
+
+	if err := database.DisableTwoFactor(userID); err != nil {
+		log.Printf("Error disabling two-factor authentication for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to disable two-factor authentication")
+		return
+	}
+	recordAccountActivity(c, userID, models.AccountEventTwoFactorDisabled, nil)
+
+	c.JSON(http.StatusOK, gin.H{"enabled": false})
+}
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"investorcenter-api/auth"
+)
+
+var userColumns = []string{
+	"id", "email", "password_hash", "full_name", "timezone",
+	"created_at", "updated_at", "last_login_at", "email_verified",
+	"is_premium", "is_active", "is_admin", "is_worker", "last_activity_at",
+}
+
+var twoFactorColumns = []string{
+	"user_id", "secret_encrypted", "enabled", "recovery_code_hashes",
+	"last_used_step", "enabled_at", "created_at", "updated_at",
+}
+
+// setupTwoFactorKey pins the TOTP secret encryption key for a test
+func setupTwoFactorKey(t *testing.T) {
+	t.Helper()
+	t.Setenv("TWO_FACTOR_ENCRYPTION_KEY", "test-two-factor-encryption-key")
+}
+
+// enrolledSecret returns a fresh TOTP secret and its encrypted form
+func enrolledSecret(t *testing.T) (secret, encrypted string) {
+	t.Helper()
+	secret, err := auth.GenerateTOTPSecret()
+	require.NoError(t, err)
+	encrypted, err = auth.EncryptTOTPSecret(secret)
+	require.NoError(t, err)
+	return secret, encrypted
+}
+
+// capturedString is a sqlmock argument matcher that keeps the string it's given
+type capturedString struct {
+	value string
+}
+
+func (c *capturedString) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	c.value = s
+	return ok
+}
+
+func postTwoFactorJSON(r http.Handler, path string, body interface{}) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// ---------------------------------------------------------------------------
+// Enrollment
+// ---------------------------------------------------------------------------
+
+func TestEnrollTwoFactor_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	setupTwoFactorKey(t)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			"user-1", "jane@example.com", nil, "Jane", "UTC",
+			now, now, nil, true, false, true, false, false, nil))
+	stored := &capturedString{}
+	mock.ExpectExec("INSERT INTO user_two_factor").
+		WithArgs("user-1", stored).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/enroll", EnrollTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/enroll", nil)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Secret)
+	assert.Contains(t, resp.ProvisioningURI, "otpauth://totp/InvestorCenter:jane@example.com?")
+	assert.Contains(t, resp.ProvisioningURI, "secret="+resp.Secret)
+
+	assert.NotContains(t, stored.value, resp.Secret, "the secret is stored encrypted")
+	decrypted, err := auth.DecryptTOTPSecret(stored.value)
+	require.NoError(t, err)
+	assert.Equal(t, resp.Secret, decrypted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnrollTwoFactor_AlreadyEnabled(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	setupTwoFactorKey(t)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			"user-1", "jane@example.com", nil, "Jane", "UTC",
+			now, now, nil, true, false, true, false, false, nil))
+	// The upsert skips enabled enrollments
+	mock.ExpectExec("INSERT INTO user_two_factor").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/enroll", EnrollTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/enroll", nil)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnableTwoFactor_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	setupTwoFactorKey(t)
+	secret, encrypted := enrolledSecret(t)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(twoFactorColumns).
+			AddRow("user-1", encrypted, false, "{}", nil, nil, now, now))
+	mock.ExpectExec("UPDATE user_two_factor SET\\s+enabled = true").
+		WithArgs("user-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WithArgs("user-1", "two_factor_enabled", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", now))
+
+	code, err := auth.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/enable", EnableTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/enable", map[string]string{"code": code})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Enabled       bool     `json:"enabled"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Enabled)
+	assert.Len(t, resp.RecoveryCodes, auth.RecoveryCodeCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnableTwoFactor_WrongCode(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	setupTwoFactorKey(t)
+	_, encrypted := enrolledSecret(t)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WillReturnRows(sqlmock.NewRows(twoFactorColumns).
+			AddRow("user-1", encrypted, false, "{}", nil, nil, now, now))
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/enable", EnableTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/enable", map[string]string{"code": "000000x"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid verification code")
+	assert.NoError(t, mock.ExpectationsWereMet(), "2FA stays off")
+}
+
+func TestEnableTwoFactor_NotEnrolled(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/enable", EnableTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/enable", map[string]string{"code": "123456"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Start two-factor enrollment first")
+}
+
+// ---------------------------------------------------------------------------
+// Login with 2FA
+// ---------------------------------------------------------------------------
+
+// expectTwoFactorLoginUser expects the login lookups for a user with 2FA
+// enabled and returns the user's password
+func expectTwoFactorLoginUser(t *testing.T, mock sqlmock.Sqlmock, encrypted string, recoveryHashes []string) string {
+	t.Helper()
+	password := "securepass123"
+	hash, err := auth.HashPassword(password)
+	require.NoError(t, err)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE email = \\$1").
+		WithArgs("jane@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			"user-1", "jane@example.com", &hash, "Jane", "UTC",
+			now, now, nil, true, false, true, false, false, nil))
+	hashes, _ := pq.Array(recoveryHashes).(driver.Valuer).Value()
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(twoFactorColumns).
+			AddRow("user-1", encrypted, true, hashes, nil, now, now, now))
+	return password
+}
+
+func postLogin(body map[string]string) *httptest.ResponseRecorder {
+	r := setupMockRouterNoAuth()
+	r.POST("/login", Login)
+	return postTwoFactorJSON(r, "/login", body)
+}
+
+func TestLogin_TwoFactor_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+	setupTwoFactorKey(t)
+	secret, encrypted := enrolledSecret(t)
+	now := time.Now()
+
+	password := expectTwoFactorLoginUser(t, mock, encrypted, nil)
+	mock.ExpectExec("UPDATE user_two_factor SET last_used_step").
+		WithArgs("user-1", now.Unix()/30).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET last_login_at").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO sessions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).AddRow("session-1", now, now))
+	metadata := &capturedActivity{}
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WithArgs("user-1", "login", sqlmock.AnyArg(), sqlmock.AnyArg(), metadata).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", now))
+
+	code, err := auth.TOTPCode(secret, now)
+	require.NoError(t, err)
+	w := postLogin(map[string]string{"email": "jane@example.com", "password": password, "totp_code": code})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp["access_token"])
+	assert.NotEmpty(t, resp["refresh_token"])
+	assert.JSONEq(t, `{"session_id": "session-1", "two_factor": "totp"}`, string(metadata.value.([]byte)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogin_TwoFactor_CodeRequired(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+	setupTwoFactorKey(t)
+	_, encrypted := enrolledSecret(t)
+
+	password := expectTwoFactorLoginUser(t, mock, encrypted, nil)
+	w := postLogin(map[string]string{"email": "jane@example.com", "password": password})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "two_factor_required", resp["code"])
+	assert.Nil(t, resp["access_token"])
+	assert.NoError(t, mock.ExpectationsWereMet(), "no session is created")
+}
+
+func TestLogin_TwoFactor_WrongCode(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+	setupTwoFactorKey(t)
+	secret, encrypted := enrolledSecret(t)
+
+	password := expectTwoFactorLoginUser(t, mock, encrypted, nil)
+
+	// A code from well outside the drift window
+	stale, err := auth.TOTPCode(secret, time.Now().Add(-10*time.Minute))
+	require.NoError(t, err)
+	w := postLogin(map[string]string{"email": "jane@example.com", "password": password, "totp_code": stale})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "unauthorized", resp["code"])
+	assert.Equal(t, "Invalid two-factor code", resp["error"])
+	assert.Nil(t, resp["access_token"])
+	assert.NoError(t, mock.ExpectationsWereMet(), "no session is created")
+}
+
+func TestLogin_TwoFactor_ReplayedCode(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+	setupTwoFactorKey(t)
+	secret, encrypted := enrolledSecret(t)
+
+	password := expectTwoFactorLoginUser(t, mock, encrypted, nil)
+	// The code's step was already used
+	mock.ExpectExec("UPDATE user_two_factor SET last_used_step").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	code, err := auth.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	w := postLogin(map[string]string{"email": "jane@example.com", "password": password, "totp_code": code})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogin_TwoFactor_RecoveryCode(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ensureJWTSecret(t)
+	setupTwoFactorKey(t)
+	_, encrypted := enrolledSecret(t)
+	now := time.Now()
+	hash := auth.HashRecoveryCode("abcde-12345")
+
+	password := expectTwoFactorLoginUser(t, mock, encrypted, []string{hash})
+	mock.ExpectExec("UPDATE user_two_factor SET\\s+recovery_code_hashes = array_remove").
+		WithArgs("user-1", hash).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET last_login_at").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO sessions").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).AddRow("session-1", now, now))
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", now))
+
+	w := postLogin(map[string]string{"email": "jane@example.com", "password": password, "totp_code": "ABCDE-12345"})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// Disable
+// ---------------------------------------------------------------------------
+
+func TestDisableTwoFactor_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	setupTwoFactorKey(t)
+	secret, encrypted := enrolledSecret(t)
+	now := time.Now()
+	password := "securepass123"
+	hash, err := auth.HashPassword(password)
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			"user-1", "jane@example.com", &hash, "Jane", "UTC",
+			now, now, nil, true, false, true, false, false, nil))
+	mock.ExpectQuery("SELECT .+ FROM user_two_factor").
+		WillReturnRows(sqlmock.NewRows(twoFactorColumns).
+			AddRow("user-1", encrypted, true, "{}", nil, now, now, now))
+	mock.ExpectExec("UPDATE user_two_factor SET last_used_step").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM user_two_factor WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WithArgs("user-1", "two_factor_disabled", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", now))
+
+	code, err := auth.TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/disable", DisableTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/disable", map[string]string{"password": password, "code": code})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDisableTwoFactor_WrongPassword(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	hash, err := auth.HashPassword("securepass123")
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(
+			"user-1", "jane@example.com", &hash, "Jane", "UTC",
+			now, now, nil, true, false, true, false, false, nil))
+
+	r := setupMockRouter("user-1")
+	r.POST("/2fa/disable", DisableTwoFactor)
+	w := postTwoFactorJSON(r, "/2fa/disable", map[string]string{"password": "wrong-password", "code": "123456"})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet(), "2FA is left on")
+}
@@ -1,26 +1,27 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/auth"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 )
 
 // GetCurrentUser returns the authenticated user's profile
 func GetCurrentUser(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	user, err := database.GetUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
 		return
 	}
 
@@ -29,21 +30,20 @@ func GetCurrentUser(c *gin.Context) {
 
 // UpdateProfile updates the user's profile information
 func UpdateProfile(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	var req models.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	user, err := database.GetUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
 		return
 	}
 
@@ -57,7 +57,7 @@ func UpdateProfile(c *gin.Context) {
 
 	err = database.UpdateUser(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update profile")
 		return
 	}
 
@@ -66,59 +66,87 @@ func UpdateProfile(c *gin.Context) {
 
 // ChangePassword changes the user's password
 func ChangePassword(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	if !checkPasswordPolicy(c, "new_password", req.NewPassword) {
 		return
 	}
 
 	user, err := database.GetUserByID(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
 		return
 	}
 
 	// Verify current password
 	if user.PasswordHash == nil || !auth.CheckPasswordHash(req.CurrentPassword, *user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		respondError(c, http.StatusUnauthorized, httputil.CodeUnauthorized, "Current password is incorrect")
 		return
 	}
 
 	// Hash new password
 	newPasswordHash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to hash password")
 		return
 	}
 
 	// Update password
 	err = database.UpdateUserPassword(user.ID, newPasswordHash)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update password")
 		return
 	}
+	recordAccountActivity(c, user.ID, models.AccountEventPasswordChanged, nil)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
 
+// ExportUserData returns everything stored about the authenticated user as
+// a downloadable JSON file
+func ExportUserData(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	user, err := database.GetUserByID(userID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+
+	export, err := database.GetUserDataExport(user)
+	if err != nil {
+		log.Printf("Failed to export data for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to export user data")
+		return
+	}
+
+	filename := fmt.Sprintf("investorcenter-export-%s.json", export.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.IndentedJSON(http.StatusOK, export)
+}
+
 // DeleteAccount soft-deletes the user account
 func DeleteAccount(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	// Soft delete user
 	err := database.SoftDeleteUser(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete account")
 		return
 	}
 
@@ -239,6 +239,11 @@ func TestChangePassword_Success(t *testing.T) {
 	mock.ExpectExec("UPDATE users SET password_hash").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
+	// RecordAccountActivity
+	mock.ExpectQuery("INSERT INTO account_activity").
+		WithArgs("user-1", "password_changed", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", now))
+
 	r := setupMockRouter("user-1")
 	r.POST("/change-password", ChangePassword)
 
@@ -452,3 +457,168 @@ func TestDeleteAccount_SoftDeleteFails(t *testing.T) {
 	assert.Equal(t, "Failed to delete account", resp["error"])
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+// ---------------------------------------------------------------------------
+// ExportUserData — DB-backed tests via sqlmock
+// ---------------------------------------------------------------------------
+
+func expectExportUser(mock sqlmock.Sqlmock, now time.Time) {
+	hash := "hash"
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "password_hash", "full_name", "timezone",
+			"created_at", "updated_at", "last_login_at", "email_verified",
+			"is_premium", "is_active", "is_admin", "is_worker", "last_activity_at",
+		}).AddRow(
+			"user-1", "test@example.com", &hash, "Test User", "UTC",
+			now, now, nil, true,
+			false, true, false, false, nil,
+		))
+}
+
+func TestExportUserData_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	expectExportUser(mock, now)
+	mock.ExpectQuery("FROM watch_lists wl").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "is_default", "created_at", "updated_at", "item_count"}))
+	mock.ExpectQuery("FROM alert_rules").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM alert_logs al").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM notification_queue").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM notification_preferences").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM phone_verifications").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM digest_logs").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"digest_type"}))
+	mock.ExpectQuery("FROM portfolios p").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM saved_screens").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM user_searches").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM backtest_jobs").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT id, created_at, last_used_at, expires_at, user_agent, ip_address\\s+FROM sessions").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at", "expires_at", "user_agent", "ip_address"}).
+			AddRow("sess-1", now, now, now.Add(time.Hour), "Mozilla/5.0", "10.0.0.1"))
+	mock.ExpectQuery("FROM account_activity").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM user_two_factor").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"enabled", "enabled_at", "created_at"}).AddRow(true, now, now))
+	mock.ExpectQuery("FROM oauth_providers").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"provider"}))
+	mock.ExpectQuery("FROM user_subscriptions us").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("FROM subscription_plans").
+		WithArgs("free").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "display_name", "description", "price_monthly", "price_yearly",
+			"max_watch_lists", "max_items_per_watch_list", "max_alert_rules",
+			"max_heatmap_configs", "max_saved_screens", "features", "is_active", "created_at", "updated_at",
+		}).AddRow("plan-1", "free", "Free", nil, 0.0, 0.0, 3, 10, 5, 1, 5, []byte(`{}`), true, now, now))
+	mock.ExpectQuery("FROM payment_history").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	r := setupMockRouter("user-1")
+	r.GET("/export", ExportUserData)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `attachment; filename="investorcenter-export-`)
+
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	for _, section := range []string{
+		"profile", "watch_lists", "alert_rules", "alert_history", "notifications", "notification_preferences",
+		"phone_verification", "digests", "portfolios", "saved_screens", "searches", "backtests", "sessions",
+		"account_activity", "two_factor", "linked_accounts", "subscription", "payments",
+	} {
+		assert.Contains(t, resp, section)
+	}
+	assert.Nil(t, resp["notification_preferences"])
+	assert.Nil(t, resp["phone_verification"])
+	assert.Equal(t, true, resp["two_factor"].(map[string]interface{})["enabled"])
+	sessions := resp["sessions"].([]interface{})
+	assert.Len(t, sessions, 1)
+	assert.NotContains(t, w.Body.String(), "password_hash")
+	assert.NotContains(t, w.Body.String(), "refresh_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportUserData_UserNotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE id = \\$1").
+		WithArgs("user-1").
+		WillReturnError(sql.ErrNoRows)
+
+	r := setupMockRouter("user-1")
+	r.GET("/export", ExportUserData)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportUserData_DBError(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	expectExportUser(mock, time.Now())
+	mock.ExpectQuery("FROM watch_lists wl").
+		WillReturnError(fmt.Errorf("connection reset"))
+
+	r := setupMockRouter("user-1")
+	r.GET("/export", ExportUserData)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "Failed to export user data", resp["error"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportUserData_Unauthorized(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.GET("/export", ExportUserData)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -187,6 +187,32 @@ func TestChangePassword_NewPasswordTooShort(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestChangePassword_CommonPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", "test-user")
+		c.Next()
+	})
+	router.POST("/api/v1/users/me/change-password", ChangePassword)
+
+	body := map[string]string{
+		"current_password": "oldpass123",
+		"new_password":     "qwerty123",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/change-password", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "common_password")
+}
+
 // ---------------------------------------------------------------------------
 // DeleteAccount — no auth returns 401
 // ---------------------------------------------------------------------------
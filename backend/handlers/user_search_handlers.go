@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"investorcenter-api/auth"
+	"investorcenter-api/database"
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+// ListUserSearches handles GET /api/v1/user/searches
+// Returns the user's recent and pinned searches, most recent first.
+func ListUserSearches(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	searches, err := database.GetUserSearches(userID)
+	if err != nil {
+		log.Printf("Error fetching searches for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch searches")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": searches,
+		"meta": gin.H{
+			"count":      len(searches),
+			"max_recent": database.MaxRecentSearches,
+			"max_pinned": database.MaxPinnedSearches,
+			"timestamp":  time.Now().UTC(),
+		},
+	})
+}
+
+// SaveUserSearch handles POST /api/v1/user/searches
+// Records a search (deduped) and optionally pins or unpins it.
+func SaveUserSearch(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.RecordUserSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
+		return
+	}
+	if database.NormalizeSearchQuery(req.Query) == "" {
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Query must not be blank")
+		return
+	}
+
+	search, err := database.RecordUserSearch(userID, req.Query, req.Pinned)
+	if err != nil {
+		if errors.Is(err, database.ErrTooManyPinnedSearches) {
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, fmt.Sprintf("Cannot pin more than %d searches", database.MaxPinnedSearches))
+			return
+		}
+		log.Printf("Error saving search for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to save search")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": search})
+}
+
+// DeleteUserSearch handles DELETE /api/v1/user/searches/:id
+func DeleteUserSearch(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	searchID := c.Param("id")
+	if err := database.DeleteUserSearch(userID, searchID); err != nil {
+		if errors.Is(err, database.ErrUserSearchNotFound) {
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Search not found")
+			return
+		}
+		log.Printf("Error deleting search %s for user %s: %v", searchID, userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete search")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Search deleted successfully"})
+}
+
+// ClearUserSearches handles DELETE /api/v1/user/searches
+// Clears recent searches; pinned searches are kept unless include_pinned=true.
+func ClearUserSearches(c *gin.Context) {
+	userID, ok := auth.MustUser(c)
+	if !ok {
+		return
+	}
+
+	includePinned := c.Query("include_pinned") == "true"
+	deleted, err := database.ClearUserSearches(userID, includePinned)
+	if err != nil {
+		log.Printf("Error clearing searches for user %s: %v", userID, err)
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to clear searches")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Search history cleared",
+		"deleted": deleted,
+	})
+}
+
+// RecordSearchHistory saves query to the authenticated user's recent searches.
+// Called from search endpoints; does nothing for anonymous requests or when
+// the client opts out with record=false. Failures are logged, never surfaced,
+// so history can't break search.
+func RecordSearchHistory(c *gin.Context, query string) {
+	if c.Query("record") == "false" {
+		return
+	}
+	userID, ok := auth.UserID(c)
+	if !ok {
+		return
+	}
+	if _, err := database.RecordUserSearch(userID, query, nil); err != nil {
+		log.Printf("Error recording search for user %s: %v", userID, err)
+	}
+}
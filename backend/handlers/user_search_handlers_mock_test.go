@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"investorcenter-api/database"
+)
+
+var userSearchCols = []string{"id", "user_id", "query", "pinned", "search_count", "searched_at", "created_at"}
+
+// ---------------------------------------------------------------------------
+// ListUserSearches
+// ---------------------------------------------------------------------------
+
+func TestListUserSearches_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM user_searches").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(userSearchCols).
+			AddRow("s1", "user-1", "nvda", false, 3, now, now).
+			AddRow("s2", "user-1", "Apple", true, 1, now.Add(-time.Hour), now))
+
+	r := setupMockRouter("user-1")
+	r.GET("/user/searches", ListUserSearches)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/searches", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta map[string]interface{}   `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "nvda", resp.Data[0]["query"])
+	assert.Equal(t, true, resp.Data[1]["pinned"])
+	assert.Equal(t, float64(2), resp.Meta["count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListUserSearches_Mock_Unauthorized(t *testing.T) {
+	r := setupMockRouterNoAuth()
+	r.GET("/user/searches", ListUserSearches)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/searches", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// ---------------------------------------------------------------------------
+// SaveUserSearch
+// ---------------------------------------------------------------------------
+
+func TestSaveUserSearch_Mock_Success(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO user_searches").
+		WithArgs("user-1", "Tesla Inc", "tesla inc", nil).
+		WillReturnRows(sqlmock.NewRows(userSearchCols).
+			AddRow("s1", "user-1", "Tesla Inc", false, 1, now, now))
+	mock.ExpectExec("DELETE FROM user_searches").
+		WithArgs("user-1", database.MaxRecentSearches).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := setupMockRouter("user-1")
+	r.POST("/user/searches", SaveUserSearch)
+
+	w := httptest.NewRecorder()
+	body := bytes.NewBufferString(`{"query": "  Tesla   Inc "}`)
+	req := httptest.NewRequest(http.MethodPost, "/user/searches", body)
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"query":"Tesla Inc"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveUserSearch_Mock_PinLimit(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT COUNT").
+		WithArgs("user-1", "amd").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(database.MaxPinnedSearches))
+
+	r := setupMockRouter("user-1")
+	r.POST("/user/searches", SaveUserSearch)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/user/searches", bytes.NewBufferString(`{"query": "AMD", "pinned": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf("more than %d", database.MaxPinnedSearches))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveUserSearch_Mock_BlankQuery(t *testing.T) {
+	r := setupMockRouter("user-1")
+	r.POST("/user/searches", SaveUserSearch)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/user/searches", bytes.NewBufferString(`{"query": "   "}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// ---------------------------------------------------------------------------
+// DeleteUserSearch / ClearUserSearches
+// ---------------------------------------------------------------------------
+
+func TestDeleteUserSearch_Mock_NotFound(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM user_searches WHERE id").
+		WithArgs("missing", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	r := setupMockRouter("user-1")
+	r.DELETE("/user/searches/:id", DeleteUserSearch)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/user/searches/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClearUserSearches_Mock_KeepsPinnedByDefault(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM user_searches WHERE user_id").
+		WithArgs("user-1", false).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	r := setupMockRouter("user-1")
+	r.DELETE("/user/searches", ClearUserSearches)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/user/searches", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"deleted":4`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// ---------------------------------------------------------------------------
+// RecordSearchHistory
+// ---------------------------------------------------------------------------
+
+func TestRecordSearchHistory_Mock(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO user_searches").
+		WithArgs("user-1", "aapl", "aapl", nil).
+		WillReturnRows(sqlmock.NewRows(userSearchCols).AddRow("s1", "user-1", "aapl", false, 1, now, now))
+	mock.ExpectExec("DELETE FROM user_searches").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := func(c *gin.Context) {
+		RecordSearchHistory(c, c.Query("q"))
+		c.Status(http.StatusOK)
+	}
+
+	// Signed in: recorded
+	r := setupMockRouter("user-1")
+	r.GET("/search", handler)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search?q=aapl", nil))
+
+	// Opted out: not recorded
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search?q=aapl&record=false", nil))
+
+	// Anonymous: not recorded
+	anon := setupMockRouterNoAuth()
+	anon.GET("/search", handler)
+	anon.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/search?q=aapl", nil))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
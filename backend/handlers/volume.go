@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -17,7 +18,7 @@ var volumeService = services.NewVolumeService()
 func GetTickerVolume(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Symbol is required")
 		return
 	}
 
@@ -32,10 +33,7 @@ func GetTickerVolume(c *gin.Context) {
 			// Fallback to database if API fails
 			dbVolume, dbErr := database.GetTickerVolume(symbol)
 			if dbErr != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "Failed to fetch volume data",
-					"details": err.Error(),
-				})
+				respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch volume data", err.Error())
 				return
 			}
 			c.JSON(http.StatusOK, gin.H{
@@ -60,10 +58,7 @@ func GetTickerVolume(c *gin.Context) {
 		// If not in database, try to fetch real-time
 		volumeData, apiErr := volumeService.GetRealTimeVolume(symbol)
 		if apiErr != nil {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":  "Volume data not found",
-				"symbol": symbol,
-			})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Volume data not found")
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
@@ -85,7 +80,7 @@ func GetTickerVolume(c *gin.Context) {
 func GetVolumeAggregates(c *gin.Context) {
 	symbol := c.Param("symbol")
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Symbol is required")
 		return
 	}
 
@@ -93,7 +88,7 @@ func GetVolumeAggregates(c *gin.Context) {
 	daysStr := c.DefaultQuery("days", "90")
 	days, err := strconv.Atoi(daysStr)
 	if err != nil || days < 1 || days > 365 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days parameter (1-365)"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Invalid days parameter (1-365)")
 		return
 	}
 
@@ -103,10 +98,7 @@ func GetVolumeAggregates(c *gin.Context) {
 		// Try to get from database as fallback
 		dbAggregates, dbErr := database.GetVolumeAggregates(symbol)
 		if dbErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to fetch volume aggregates",
-				"details": err.Error(),
-			})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch volume aggregates", err.Error())
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"investorcenter-api/auth"
 	"investorcenter-api/database"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 	"investorcenter-api/services"
 )
@@ -16,16 +17,15 @@ var watchListService = services.NewWatchListService()
 
 // ListWatchLists returns all watch lists for the authenticated user
 func ListWatchLists(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	watchLists, err := database.GetWatchListsByUserID(userID)
 	if err != nil {
 		log.Printf("Error fetching watch lists for user %s: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watch lists"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch watch lists")
 		return
 	}
 
@@ -34,15 +34,14 @@ func ListWatchLists(c *gin.Context) {
 
 // CreateWatchList creates a new watch list
 func CreateWatchList(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	var req models.CreateWatchListRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -57,13 +56,11 @@ func CreateWatchList(c *gin.Context) {
 	err := database.CreateWatchListAtomic(watchList, database.MaxWatchListsPerUser)
 	if err != nil {
 		if isWatchListLimitError(err) {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Watch list limit reached. Maximum 3 watch lists allowed",
-			})
+			respondError(c, http.StatusForbidden, httputil.CodeLimitReached, "Watch list limit reached. Maximum 3 watch lists allowed")
 			return
 		}
 		log.Printf("Error creating watch list for user %s: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create watch list"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to create watch list")
 		return
 	}
 
@@ -74,9 +71,8 @@ func CreateWatchList(c *gin.Context) {
 // Returns IC Score, fundamentals, valuation ratios, Reddit data,
 // alert counts, and summary metrics alongside real-time prices.
 func GetWatchList(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -86,10 +82,10 @@ func GetWatchList(c *gin.Context) {
 	result, err := watchListService.GetWatchListWithItems(watchListID, userID)
 	if err != nil {
 		if errors.Is(err, database.ErrWatchListNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Watch list not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Watch list not found")
 		} else {
 			log.Printf("Error fetching watch list %s for user %s: %v", watchListID, userID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watch list"})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch watch list")
 		}
 		return
 	}
@@ -118,9 +114,8 @@ func GetWatchList(c *gin.Context) {
 
 // UpdateWatchList updates watch list metadata
 func UpdateWatchList(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -128,7 +123,7 @@ func UpdateWatchList(c *gin.Context) {
 
 	var req models.UpdateWatchListRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -142,10 +137,10 @@ func UpdateWatchList(c *gin.Context) {
 	err := database.UpdateWatchList(watchList)
 	if err != nil {
 		if errors.Is(err, database.ErrWatchListNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Watch list not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Watch list not found")
 		} else {
 			log.Printf("Error updating watch list %s for user %s: %v", watchListID, userID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update watch list"})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update watch list")
 		}
 		return
 	}
@@ -155,9 +150,8 @@ func UpdateWatchList(c *gin.Context) {
 
 // DeleteWatchList deletes a watch list (protects default watch lists from deletion)
 func DeleteWatchList(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -167,23 +161,23 @@ func DeleteWatchList(c *gin.Context) {
 	watchList, err := database.GetWatchListByID(watchListID, userID)
 	if err != nil {
 		if errors.Is(err, database.ErrWatchListNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Watch list not found"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Watch list not found")
 		} else {
 			log.Printf("Error fetching watch list %s for deletion: %v", watchListID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete watch list"})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete watch list")
 		}
 		return
 	}
 
 	if watchList.IsDefault {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot delete the default watch list"})
+		respondError(c, http.StatusForbidden, httputil.CodeForbidden, "Cannot delete the default watch list")
 		return
 	}
 
 	err = database.DeleteWatchList(watchListID, userID)
 	if err != nil {
 		log.Printf("Error deleting watch list %s for user %s: %v", watchListID, userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete watch list"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to delete watch list")
 		return
 	}
 
@@ -192,9 +186,8 @@ func DeleteWatchList(c *gin.Context) {
 
 // AddTickerToWatchList adds a ticker to a watch list
 func AddTickerToWatchList(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -202,13 +195,13 @@ func AddTickerToWatchList(c *gin.Context) {
 
 	// Verify ownership
 	if err := watchListService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	var req models.AddTickerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -225,14 +218,14 @@ func AddTickerToWatchList(c *gin.Context) {
 	if err != nil {
 		switch {
 		case errors.Is(err, database.ErrTickerAlreadyExists):
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			respondError(c, http.StatusConflict, httputil.CodeConflict, err.Error())
 		case errors.Is(err, database.ErrWatchListItemLimitReached):
-			c.JSON(http.StatusForbidden, gin.H{"error": "Watch list item limit reached. Maximum 10 tickers per watch list"})
+			respondError(c, http.StatusForbidden, httputil.CodeLimitReached, "Watch list item limit reached. Maximum 10 tickers per watch list")
 		case errors.Is(err, database.ErrTickerNotFound):
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		default:
 			log.Printf("Error adding ticker %s to watch list %s: %v", req.Symbol, watchListID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add ticker to watch list"})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to add ticker to watch list")
 		}
 		return
 	}
@@ -242,9 +235,8 @@ func AddTickerToWatchList(c *gin.Context) {
 
 // RemoveTickerFromWatchList removes a ticker from watch list
 func RemoveTickerFromWatchList(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -253,17 +245,17 @@ func RemoveTickerFromWatchList(c *gin.Context) {
 
 	// Verify ownership
 	if err := watchListService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	err := database.RemoveTickerFromWatchList(watchListID, symbol)
 	if err != nil {
 		if errors.Is(err, database.ErrWatchListItemNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Ticker not found in watch list"})
+			respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Ticker not found in watch list")
 		} else {
 			log.Printf("Error removing ticker %s from watch list %s: %v", symbol, watchListID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove ticker"})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to remove ticker")
 		}
 		return
 	}
@@ -273,9 +265,8 @@ func RemoveTickerFromWatchList(c *gin.Context) {
 
 // UpdateWatchListItem updates ticker metadata
 func UpdateWatchListItem(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -284,13 +275,13 @@ func UpdateWatchListItem(c *gin.Context) {
 
 	// Verify ownership
 	if err := watchListService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	var req models.UpdateTickerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -298,7 +289,7 @@ func UpdateWatchListItem(c *gin.Context) {
 	items, err := database.GetWatchListItems(watchListID)
 	if err != nil {
 		log.Printf("Error fetching watch list items for update (list=%s, symbol=%s): %v", watchListID, symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch watch list items"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch watch list items")
 		return
 	}
 
@@ -311,7 +302,7 @@ func UpdateWatchListItem(c *gin.Context) {
 	}
 
 	if targetItem == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ticker not found in watch list"})
+		respondError(c, http.StatusNotFound, httputil.CodeNotFound, "Ticker not found in watch list")
 		return
 	}
 
@@ -324,7 +315,7 @@ func UpdateWatchListItem(c *gin.Context) {
 	err = database.UpdateWatchListItem(targetItem)
 	if err != nil {
 		log.Printf("Error updating watch list item (list=%s, symbol=%s): %v", watchListID, symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update ticker"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update ticker")
 		return
 	}
 
@@ -333,9 +324,8 @@ func UpdateWatchListItem(c *gin.Context) {
 
 // BulkAddTickers adds multiple tickers from CSV import
 func BulkAddTickers(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -343,20 +333,20 @@ func BulkAddTickers(c *gin.Context) {
 
 	// Verify ownership
 	if err := watchListService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	var req models.BulkAddTickersRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	added, failed, err := database.BulkAddTickers(watchListID, req.Symbols)
 	if err != nil {
 		log.Printf("Error bulk adding tickers to watch list %s: %v", watchListID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bulk add tickers"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to bulk add tickers")
 		return
 	}
 
@@ -369,9 +359,8 @@ func BulkAddTickers(c *gin.Context) {
 
 // ReorderWatchListItems updates display order
 func ReorderWatchListItems(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
@@ -379,13 +368,13 @@ func ReorderWatchListItems(c *gin.Context) {
 
 	// Verify ownership
 	if err := watchListService.ValidateWatchListOwnership(watchListID, userID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized access to watch list"})
+		respondOwnedLookupError(c, err, "Watch list not found", "Failed to fetch watch list")
 		return
 	}
 
 	var req models.ReorderItemsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -395,7 +384,7 @@ func ReorderWatchListItems(c *gin.Context) {
 		itemIDs[i] = order.ItemID
 	}
 	if err := database.ValidateItemsBelongToWatchList(watchListID, itemIDs); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "One or more items do not belong to this watch list"})
+		respondOwnedLookupError(c, err, "One or more items not found in this watch list", "Failed to validate watch list items")
 		return
 	}
 
@@ -404,7 +393,7 @@ func ReorderWatchListItems(c *gin.Context) {
 		err := database.UpdateItemDisplayOrder(itemOrder.ItemID, itemOrder.DisplayOrder)
 		if err != nil {
 			log.Printf("Error reordering watch list item %s in list %s: %v", itemOrder.ItemID, watchListID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item order"})
+			respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to update item order")
 			return
 		}
 	}
@@ -414,16 +403,15 @@ func ReorderWatchListItems(c *gin.Context) {
 
 // GetUserTags returns all distinct tags used across the authenticated user's watchlist items.
 func GetUserTags(c *gin.Context) {
-	userID, exists := auth.GetUserIDFromContext(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, ok := auth.MustUser(c)
+	if !ok {
 		return
 	}
 
 	tags, err := database.GetUserTags(userID)
 	if err != nil {
 		log.Printf("Error fetching tags for user %s: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch tags")
 		return
 	}
 
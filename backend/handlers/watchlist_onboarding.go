@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"investorcenter-api/database"
+)
+
+// Onboarding seeds a new user's default watch list with popular tickers.
+// WATCHLIST_ONBOARDING=false turns it off, WATCHLIST_ONBOARDING_SYMBOLS
+// (comma-separated) replaces the popular list and WATCHLIST_ONBOARDING_SIZE
+// caps how many are added (default 5, at most the free plan's
+// max_items_per_watch_list).
+var (
+	watchListOnboardingEnabled = os.Getenv("WATCHLIST_ONBOARDING") != "false"
+	watchListOnboardingSymbols = parseOnboardingSymbols(os.Getenv("WATCHLIST_ONBOARDING_SYMBOLS"))
+	watchListOnboardingSize    = loadEnvInt("WATCHLIST_ONBOARDING_SIZE", 5, 0)
+)
+
+func parseOnboardingSymbols(raw string) []string {
+	var symbols []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+	return symbols
+}
+
+// onboardingSymbols returns the tickers to seed, in watch list order. New
+// accounts are on the free plan, so no more are seeded than it allows in a
+// watch list; more would trip the item limit and roll the whole seed back.
+func onboardingSymbols() ([]string, error) {
+	free, err := database.GetSubscriptionPlanByName("free")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get free plan limits: %w", err)
+	}
+	size := min(watchListOnboardingSize, free.MaxItemsPerWatchList)
+	if size <= 0 {
+		return nil, nil
+	}
+
+	if len(watchListOnboardingSymbols) > 0 {
+		return watchListOnboardingSymbols[:min(len(watchListOnboardingSymbols), size)], nil
+	}
+	stocks, err := database.GetPopularStocks(size, false)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]string, len(stocks))
+	for i, s := range stocks {
+		symbols[i] = s.Symbol
+	}
+	return symbols, nil
+}
+
+// seedOnboardingWatchList runs watch list onboarding for a user who hasn't
+// had it yet; Signup calls it for every new account and Login retries it
+// if that failed. Skipped or disabled onboarding still marks the user
+// seeded, so their list isn't filled in later. Best-effort: failures are
+// logged and the user's request goes ahead.
+func seedOnboardingWatchList(userID string, skip bool) {
+	seeded, err := database.WatchListSeeded(userID)
+	if err != nil {
+		log.Printf("Failed to check watch list onboarding for user %s: %v", userID, err)
+		return
+	}
+	if seeded {
+		return
+	}
+
+	var symbols []string
+	if watchListOnboardingEnabled && !skip {
+		if symbols, err = onboardingSymbols(); err != nil {
+			log.Printf("Failed to load watch list onboarding symbols for user %s: %v", userID, err)
+			return
+		}
+	}
+
+	if _, err := database.SeedDefaultWatchList(userID, symbols); err != nil {
+		log.Printf("Failed to seed watch list for user %s: %v", userID, err)
+	}
+}
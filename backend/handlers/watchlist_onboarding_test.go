@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingSymbols_CappedByFreePlan(t *testing.T) {
+	mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	prevSymbols, prevSize := watchListOnboardingSymbols, watchListOnboardingSize
+	watchListOnboardingSymbols = []string{"AAPL", "MSFT", "NVDA", "AMZN"}
+	watchListOnboardingSize = 5
+	defer func() { watchListOnboardingSymbols, watchListOnboardingSize = prevSymbols, prevSize }()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT .+ FROM subscription_plans WHERE name = \\$1").
+		WithArgs("free").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "display_name", "description", "price_monthly", "price_yearly",
+			"max_watch_lists", "max_items_per_watch_list", "max_alert_rules",
+			"max_heatmap_configs", "max_saved_screens", "features", "is_active",
+			"created_at", "updated_at",
+		}).AddRow(
+			"plan-free", "free", "Free", nil, 0, 0,
+			3, 2, 10,
+			3, 5, []byte(`{}`), true,
+			now, now,
+		))
+
+	symbols, err := onboardingSymbols()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "MSFT"}, symbols)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
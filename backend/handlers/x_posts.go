@@ -10,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+
+	"investorcenter-api/httputil"
 )
 
 // XPost represents a single X/Twitter post for API response
@@ -42,7 +44,7 @@ type XPostsResponse struct {
 func GetXPosts(c *gin.Context) {
 	symbol := strings.ToUpper(c.Param("symbol"))
 	if symbol == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Symbol is required"})
+		respondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Symbol is required")
 		return
 	}
 
@@ -61,7 +63,7 @@ func GetXPosts(c *gin.Context) {
 	}
 	if err != nil {
 		log.Printf("Redis error reading x:posts:%s: %v", symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to fetch posts")
 		return
 	}
 
@@ -69,7 +71,7 @@ func GetXPosts(c *gin.Context) {
 	var cached map[string]interface{}
 	if err := json.Unmarshal([]byte(data), &cached); err != nil {
 		log.Printf("Failed to parse cached X posts for %s: %v", symbol, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse cached posts"})
+		respondError(c, http.StatusInternalServerError, httputil.CodeInternal, "Failed to parse cached posts")
 		return
 	}
 
@@ -0,0 +1,90 @@
+package httputil
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"investorcenter-shared/requestid"
+)
+
+// Error codes returned in APIError.Code. Clients should branch on these
+// rather than on the human-readable message.
+const (
+	CodeInvalidRequest     = "invalid_request"     // 400
+	CodeUnauthorized       = "unauthorized"        // 401
+	CodeTwoFactorRequired  = "two_factor_required" // 401, login needs a TOTP code
+	CodeForbidden          = "forbidden"           // 403
+	CodeLimitReached       = "limit_reached"       // 403, plan or per-user cap
+	CodeNotFound           = "not_found"           // 404, also another user's resource
+	CodeConflict           = "conflict"            // 409
+	CodePayloadTooLarge    = "payload_too_large"   // 413
+	CodeUnprocessable      = "unprocessable"       // 422
+	CodeRateLimited        = "rate_limited"        // 429
+	CodeInternal           = "internal_error"      // 500
+	CodeNotImplemented     = "not_implemented"     // 501
+	CodeUpstream           = "upstream_error"      // 502
+	CodeServiceUnavailable = "service_unavailable" // 503
+	CodeTimeout            = "timeout"             // 504
+)
+
+// APIError is the body of every API error response. Message is serialized
+// as "error", the key clients have always read the message from.
+type APIError struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"error"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// CodeForStatus returns the default error code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// RespondError writes an APIError with status and aborts the handler chain.
+// An empty code falls back to CodeForStatus. details is optional; when
+// given, the first value is sent as-is (a string or a small object).
+func RespondError(c *gin.Context, status int, code, message string, details ...interface{}) {
+	if code == "" {
+		code = CodeForStatus(status)
+	}
+	body := APIError{
+		Code:      code,
+		Message:   message,
+		RequestID: requestid.FromContext(c),
+	}
+	if len(details) > 0 {
+		body.Details = details[0]
+	}
+	c.AbortWithStatusJSON(status, body)
+}
@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-shared/requestid"
+)
+
+func serveWithRequestID(t *testing.T, handler gin.HandlerFunc, requestID string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestid.Middleware())
+	r.GET("/", handler, func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"reached": true}) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRespondError_Shape(t *testing.T) {
+	w := serveWithRequestID(t, func(c *gin.Context) {
+		RespondError(c, http.StatusNotFound, CodeNotFound, "Ticker not found", "no ticker FAKE")
+	}, "req-123")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "req-123", w.Header().Get(requestid.Header))
+	assert.JSONEq(t, `{"code":"not_found","error":"Ticker not found","details":"no ticker FAKE","request_id":"req-123"}`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "reached", "RespondError aborts the chain")
+}
+
+func TestRespondError_DefaultsCodeAndOmitsDetails(t *testing.T) {
+	w := serveWithRequestID(t, func(c *gin.Context) {
+		RespondError(c, http.StatusTooManyRequests, "", "Slow down")
+	}, "")
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, CodeRateLimited, body["code"])
+	assert.NotContains(t, body, "details")
+	assert.NotEmpty(t, body["request_id"], "an ID is generated when the client sends none")
+	assert.Equal(t, body["request_id"], w.Header().Get(requestid.Header))
+}
+
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, CodeInvalidRequest, CodeForStatus(http.StatusBadRequest))
+	assert.Equal(t, CodeUnauthorized, CodeForStatus(http.StatusUnauthorized))
+	assert.Equal(t, CodeNotFound, CodeForStatus(http.StatusNotFound))
+	assert.Equal(t, CodeServiceUnavailable, CodeForStatus(http.StatusServiceUnavailable))
+	assert.Equal(t, CodeInternal, CodeForStatus(http.StatusInternalServerError))
+	assert.Equal(t, CodeInternal, CodeForStatus(599))
+}
@@ -0,0 +1,68 @@
+package httputil
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"investorcenter-shared/tracing"
+)
+
+// Connection pool settings for the shared transport. http.DefaultTransport
+// keeps only 2 idle connections per host, so batch jobs hammering one
+// upstream (the importer, price backfills) end up redialing constantly.
+const (
+	MaxIdleConns        = 100
+	MaxIdleConnsPerHost = 32
+	IdleConnTimeout     = 90 * time.Second
+	// ClientTimeout bounds each request made with the shared client,
+	// including reading the body
+	ClientTimeout = 30 * time.Second
+)
+
+var (
+	sharedTransport     *http.Transport
+	sharedClient        *http.Client
+	sharedTransportOnce sync.Once
+)
+
+// NewTransport returns a transport with the shared pool settings. Most
+// callers want SharedTransport so connections are reused across clients.
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          MaxIdleConns,
+		MaxIdleConnsPerHost:   MaxIdleConnsPerHost,
+		IdleConnTimeout:       IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func initShared() {
+	sharedTransportOnce.Do(func() {
+		sharedTransport = NewTransport()
+		sharedClient = &http.Client{Timeout: ClientTimeout, Transport: tracing.Transport(sharedTransport)}
+	})
+}
+
+// SharedTransport returns the process-wide pooled transport the upstream
+// clients share
+func SharedTransport() *http.Transport {
+	initShared()
+	return sharedTransport
+}
+
+// SharedClient returns the process-wide client using SharedTransport, traced,
+// with a ClientTimeout overall timeout. Don't modify it; build a new http.Client
+// around SharedTransport for different settings.
+func SharedClient() *http.Client {
+	initShared()
+	return sharedClient
+}
@@ -0,0 +1,54 @@
+package httputil
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-shared/tracing"
+)
+
+func TestSharedClient_UsesPooledTransport(t *testing.T) {
+	client := SharedClient()
+	assert.Same(t, client, SharedClient())
+	// SharedTransport, wrapped for tracing
+	assert.IsType(t, tracing.Transport(SharedTransport()), client.Transport)
+	assert.Equal(t, ClientTimeout, client.Timeout)
+
+	transport := SharedTransport()
+	assert.Equal(t, MaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, IdleConnTimeout, transport.IdleConnTimeout)
+}
+
+func TestSharedClient_ReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	reused := 0
+	for i := 0; i < 3; i++ {
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				if info.Reused {
+					reused++
+				}
+			},
+		}
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+		resp, err := SharedClient().Do(req)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 2, reused, "requests after the first should reuse the pooled connection")
+}
@@ -0,0 +1,129 @@
+// Package httputil holds HTTP helpers shared by the upstream API clients
+// (FMP, Polygon, CoinGecko) so flaky-upstream handling doesn't drift
+// between them, and the error contract and request IDs shared by the API's
+// own handlers and middleware.
+package httputil
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures DoWithRetry. The zero value makes a single attempt.
+type RetryPolicy struct {
+	// MaxRetries is how many times a request is retried after a retryable
+	// outcome. Zero disables retries.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; each further retry
+	// doubles it
+	BaseDelay time.Duration
+	// MaxDelay caps both the backoff and a server's Retry-After. Zero means
+	// no cap.
+	MaxDelay time.Duration
+	// Jitter is the randomized fraction of each backoff: 0.5 waits between
+	// half and all of it, 0 waits exactly the backoff
+	Jitter float64
+	// Retryable decides whether an attempt is retried. Nil uses
+	// RetryableResponse.
+	Retryable func(resp *http.Response, err error) bool
+	// Sleep waits between retries. Nil uses time.Sleep; tests swap it out.
+	Sleep func(time.Duration)
+}
+
+// DoWithRetry calls do until it returns a non-retryable outcome or retries
+// run out, waiting the jittered exponential backoff between attempts, or the
+// server's Retry-After when it sends one. do must build a fresh request each
+// call. Retried response bodies are drained and closed; once retries run out
+// the last response is returned so callers report its status as before.
+func DoWithRetry(policy RetryPolicy, do func() (*http.Response, error)) (*http.Response, error) {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = RetryableResponse
+	}
+	sleep := policy.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := do()
+		if attempt >= policy.MaxRetries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		delay := policy.Backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := ParseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		sleep(delay)
+	}
+}
+
+// Backoff returns the delay before retry attempt+1: BaseDelay * 2^attempt,
+// capped at MaxDelay, with the Jitter fraction of it randomized
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d < 0 || (d == 0 && p.BaseDelay > 0) || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		// Shifted past the cap or overflowed
+		d = p.MaxDelay
+	}
+
+	jitter := p.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	spread := time.Duration(float64(d) * jitter)
+	if spread <= 0 {
+		return d
+	}
+	return d - spread + time.Duration(rand.Int63n(int64(spread)))
+}
+
+// RetryableResponse retries transport errors and RetryableStatus responses
+func RetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp != nil && RetryableStatus(resp.StatusCode)
+}
+
+// RetryableStatus reports whether a status is worth retrying: rate limiting
+// and the 5xx codes upstreams return while overloaded or restarting
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ParseRetryAfter reads a Retry-After header in either delta-seconds or
+// HTTP-date form
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
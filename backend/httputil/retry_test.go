@@ -0,0 +1,234 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSleeps returns a policy whose sleeps are recorded instead of waited
+func recordSleeps(policy RetryPolicy) (RetryPolicy, *[]time.Duration) {
+	var delays []time.Duration
+	policy.Sleep = func(d time.Duration) { delays = append(delays, d) }
+	return policy, &delays
+}
+
+func TestRetryableStatus(t *testing.T) {
+	for _, code := range []int{429, 500, 502, 503, 504} {
+		assert.True(t, RetryableStatus(code), code)
+	}
+	for _, code := range []int{200, 204, 301, 400, 401, 403, 404, 422, 501} {
+		assert.False(t, RetryableStatus(code), code)
+	}
+}
+
+func TestRetryableResponse(t *testing.T) {
+	assert.True(t, RetryableResponse(nil, errors.New("connection reset by peer")))
+	assert.True(t, RetryableResponse(&http.Response{StatusCode: http.StatusTooManyRequests}, nil))
+	assert.False(t, RetryableResponse(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	assert.False(t, RetryableResponse(&http.Response{StatusCode: http.StatusOK}, nil))
+	assert.False(t, RetryableResponse(nil, nil))
+}
+
+func TestBackoff_Schedule(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+	want := []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second,
+		5 * time.Second, 5 * time.Second, // capped
+	}
+	for attempt, d := range want {
+		assert.Equal(t, d, p.Backoff(attempt), "attempt %d", attempt)
+	}
+
+	// Shifting far enough overflows; that's capped too rather than going negative
+	assert.Equal(t, 5*time.Second, p.Backoff(70))
+}
+
+func TestBackoff_NoCap(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second}
+	assert.Equal(t, 8*time.Second, p.Backoff(3))
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.Backoff(2))
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.5}
+	for i := 0; i < 200; i++ {
+		d := p.Backoff(2)
+		assert.GreaterOrEqual(t, d, 2*time.Second)
+		assert.Less(t, d, 4*time.Second)
+	}
+
+	full := RetryPolicy{BaseDelay: time.Second, Jitter: 3} // clamped to 1
+	for i := 0; i < 200; i++ {
+		d := full.Backoff(0)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.Less(t, d, time.Second)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := ParseRetryAfter("120")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, d)
+
+	d, ok = ParseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour.Seconds(), d.Seconds(), 2)
+
+	d, ok = ParseRetryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), d, "a date in the past means retry now")
+
+	_, ok = ParseRetryAfter("")
+	assert.False(t, ok)
+	_, ok = ParseRetryAfter("soon")
+	assert.False(t, ok)
+	_, ok = ParseRetryAfter("-5")
+	assert.False(t, ok)
+}
+
+// statusServer replies with statuses in order, then 200 "ok"
+func statusServer(t *testing.T, calls *int32, statuses ...int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(calls, 1))
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "3")
+			}
+			w.WriteHeader(statuses[n-1])
+			fmt.Fprintf(w, "attempt %d", n)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDoWithRetry_RetriesUntilSuccess(t *testing.T) {
+	var calls int32
+	server := statusServer(t, &calls, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	policy, delays := recordSleeps(RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second})
+
+	resp, err := DoWithRetry(policy, func() (*http.Response, error) { return http.Get(server.URL) })
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 3 * time.Second}, *delays,
+		"backoff first, then the 429's Retry-After")
+}
+
+func TestDoWithRetry_ReturnsLastResponseWhenExhausted(t *testing.T) {
+	var calls int32
+	server := statusServer(t, &calls, 502, 502, 502, 502)
+	policy, delays := recordSleeps(RetryPolicy{MaxRetries: 2, BaseDelay: time.Second})
+
+	resp, err := DoWithRetry(policy, func() (*http.Response, error) { return http.Get(server.URL) })
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "attempt 3", string(body), "the last response's body is left readable")
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *delays)
+}
+
+func TestDoWithRetry_CapsRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	policy, delays := recordSleeps(RetryPolicy{MaxRetries: 1, BaseDelay: time.Second, MaxDelay: 30 * time.Second})
+
+	resp, err := DoWithRetry(policy, func() (*http.Response, error) { return http.Get(server.URL) })
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []time.Duration{30 * time.Second}, *delays)
+}
+
+func TestDoWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := statusServer(t, &calls, http.StatusNotFound)
+	policy, delays := recordSleeps(RetryPolicy{MaxRetries: 3, BaseDelay: time.Second})
+
+	resp, err := DoWithRetry(policy, func() (*http.Response, error) { return http.Get(server.URL) })
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Empty(t, *delays)
+}
+
+func TestDoWithRetry_RetriesTransportErrors(t *testing.T) {
+	policy, delays := recordSleeps(RetryPolicy{MaxRetries: 2, BaseDelay: time.Second})
+
+	attempts := 0
+	resp, err := DoWithRetry(policy, func() (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+	assert.Len(t, *delays, 2)
+
+	attempts = 0
+	_, err = DoWithRetry(policy, func() (*http.Response, error) {
+		attempts++
+		return nil, errors.New("dial tcp: connection refused")
+	})
+	assert.EqualError(t, err, "dial tcp: connection refused")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestDoWithRetry_ZeroPolicyMakesOneAttempt(t *testing.T) {
+	var calls int32
+	server := statusServer(t, &calls, http.StatusServiceUnavailable)
+
+	resp, err := DoWithRetry(RetryPolicy{}, func() (*http.Response, error) { return http.Get(server.URL) })
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDoWithRetry_CustomPredicate(t *testing.T) {
+	var calls int32
+	server := statusServer(t, &calls, http.StatusConflict)
+	policy, _ := recordSleeps(RetryPolicy{
+		MaxRetries: 2,
+		Retryable: func(resp *http.Response, err error) bool {
+			return err == nil && resp.StatusCode == http.StatusConflict
+		},
+	})
+
+	resp, err := DoWithRetry(policy, func() (*http.Response, error) { return http.Get(server.URL) })
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
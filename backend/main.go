@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"investorcenter-api/auth"
 	"investorcenter-api/database"
 	"investorcenter-api/handlers"
+	"investorcenter-api/httputil"
 	"investorcenter-api/services"
+	"investorcenter-shared/logging"
+	"investorcenter-shared/requestid"
+	"investorcenter-shared/securityheaders"
+	"investorcenter-shared/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -26,9 +34,20 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	// Mask emails, tokens and passwords in everything we log
+	redactor := logging.Install()
+
 	// Validate JWT secret before starting — fail fast if missing or too short
 	auth.ValidateJWTSecret()
 
+	// Trace requests, queries and upstream calls when an OTLP endpoint is set
+	shutdownTracing, err := tracing.Init(context.Background(), "investorcenter-api")
+	if err != nil {
+		log.Printf("⚠️ Tracing disabled: %v", err)
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
 	// Initialize database connection
 	if err := database.Initialize(); err != nil {
 		log.Printf("Database connection failed: %v", err)
@@ -41,6 +60,12 @@ func main() {
 		if err := database.RunMigrations(migrationsFS); err != nil {
 			log.Fatalf("Database migration failed: %v", err)
 		}
+
+		// Persist upstream API call counts so quota tracking survives restarts
+		services.UpstreamQuota().SetStore(services.NewSQLQuotaStore(database.DB.DB))
+		services.StartUpstreamQuotaFlush(time.Minute)
+		services.StartUpstreamQuotaAlerts(5 * time.Minute)
+		defer services.UpstreamQuota().Flush()
 	}
 
 	// Set Gin mode
@@ -48,8 +73,15 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Create Gin router
-	r := gin.Default()
+	// Create Gin router. The request logger writes to gin.DefaultWriter, so
+	// point it through the same redaction as the standard logger first.
+	if redactor != nil {
+		gin.DefaultWriter = logging.NewWriter(os.Stdout, redactor)
+		gin.DefaultErrorWriter = logging.NewWriter(os.Stderr, redactor)
+	}
+	r := gin.New()
+	// Tracing sits outside Recovery so a panicking request's span records the 500
+	r.Use(requestid.AccessLog(), tracing.Middleware(), gin.Recovery())
 
 	// Configure CORS
 	config := cors.DefaultConfig()
@@ -59,18 +91,32 @@ func main() {
 		"https://www.investorcenter.ai",
 	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID"}
 	config.AllowCredentials = true
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.ExposeHeaders = []string{"Content-Length", "X-Envelope-Version", "X-Response-Case", "Retry-After", "X-Request-ID"}
 	config.MaxAge = 12 * time.Hour
 	r.Use(cors.New(config))
 
+	// nosniff, framing, referrer, HSTS and CSP headers (SECURITY_* overrides)
+	r.Use(securityheaders.Middleware(securityheaders.FromEnv()))
+
+	// Request IDs, echoed in X-Request-ID and in every error body
+	r.Use(requestid.Middleware())
+
+	// Optional camelCase/snake_case response keys (?case= or Accept: ...; case=)
+	r.Use(handlers.ResponseCaseMiddleware())
+
+	// Per-route in-flight caps on DB-heavy endpoints (CONCURRENCY_LIMITS overrides)
+	r.Use(handlers.ConcurrencyLimitMiddleware(handlers.LoadConcurrencyLimits()))
+
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		response := gin.H{
 			"status":    "healthy",
 			"timestamp": time.Now().UTC(),
 			"service":   "investorcenter-api",
+			// Remaining FMP/Polygon/CoinGecko budget this month
+			"upstream_quota": services.UpstreamQuota().Status(),
 		}
 
 		// Check database health
@@ -91,6 +137,8 @@ func main() {
 
 	// Start rate limiter cleanup
 	auth.StartRateLimiterCleanup(auth.GetLoginLimiter())
+	auth.StartRateLimiterCleanup(auth.GetExportLimiter())
+	auth.StartRateLimiterCleanup(auth.GetSummaryLimiter())
 
 	// Auth routes (public, no middleware)
 	authRoutes := r.Group("/api/v1/auth")
@@ -114,16 +162,21 @@ func main() {
 			markets.GET("/indices", handlers.GetMarketIndices)
 			markets.GET("/movers", handlers.GetMarketMovers)
 			markets.GET("/news", handlers.GetMarketNews)
-			markets.GET("/search", searchSecurities)
-			markets.GET("/summary", handlers.GetMarketSummary)
+			markets.GET("/search", auth.OptionalAuthMiddleware(), searchSecurities) // Records recent searches for signed-in users
+			markets.GET("/search/suggest", auth.OptionalAuthMiddleware(), handlers.GetSearchSuggestions)
+			markets.GET("/summary", auth.RateLimitMiddleware(auth.GetSummaryLimiter()), handlers.GetMarketSummary)
 		}
 
 		// Ticker page endpoints
 		tickers := v1.Group("/tickers")
 		{
-			tickers.GET("/:symbol", handlers.GetTicker)                    // Comprehensive ticker data with real-time prices
-			tickers.GET("/:symbol/chart", handlers.GetTickerChart)         // Chart data for stocks and crypto
-			tickers.GET("/:symbol/price", handlers.GetTickerRealTimePrice) // Real-time price updates only
+			tickers.GET("/", auth.OptionalAuthMiddleware(), handlers.GetStocks)                   // Paginated ticker list with sector/exchange/type/market cap filters and ?sort=
+			tickers.POST("/metadata", auth.OptionalAuthMiddleware(), handlers.GetTickersMetadata) // Batch name/exchange/sector/logo by symbol list
+			tickers.POST("/batch", auth.OptionalAuthMiddleware(), handlers.GetTickersBatch)       // Batch price/change/market cap by symbol list (watchlists)
+			tickers.GET("/:symbol", handlers.GetTicker)                                           // Comprehensive ticker data with real-time prices
+			tickers.GET("/:symbol/chart", handlers.GetTickerChart)                                // Chart data for stocks and crypto
+			tickers.GET("/:symbol/price", handlers.GetTickerRealTimePrice)                        // Real-time price updates only
+			tickers.GET("/:symbol/logo", handlers.GetTickerLogo)                                  // Cached logo image with placeholder fallback
 
 			// Volume endpoints (hybrid: database + real-time)
 			tickers.GET("/:symbol/volume", handlers.GetTickerVolume)                // Get volume data (add ?realtime=true for fresh data)
@@ -131,6 +184,9 @@ func main() {
 
 			// Additional ticker endpoints
 			tickers.GET("/:symbol/news", handlers.GetTickerNews)
+			tickers.GET("/:symbol/dividends", handlers.GetTickerDividends) // Dividend history with TTM, yield and frequency (FMP)
+			tickers.GET("/:symbol/peers", handlers.GetTickerPeers)         // Sector/industry peers ranked by market cap or ?metric= closeness
+			tickers.GET("/:symbol/insiders", handlers.GetTickerInsiders)   // Form 4 transactions with 90-day net activity by role
 
 			// Key stats endpoints (user-ingested data)
 			tickers.GET("/:symbol/keystats", handlers.GetKeyStats)       // Get key stats data
@@ -151,6 +207,7 @@ func main() {
 			stocks.GET("/:ticker/risk", handlers.GetRiskMetrics)                      // Get risk metrics (Beta, Alpha, Sharpe)
 			stocks.GET("/:ticker/technical", handlers.GetTechnicalIndicators)         // Get technical indicators
 			stocks.GET("/:ticker/earnings", handlers.GetStockEarnings)                // Get earnings history (FMP)
+			stocks.GET("/:ticker/splits", handlers.GetStockSplits)                    // Get split history with adjustment factors
 
 			// Financial Statements endpoints (SEC EDGAR data)
 			financialsHandler := handlers.NewFinancialsHandler()
@@ -159,6 +216,9 @@ func main() {
 			stocks.GET("/:ticker/financials/balance", financialsHandler.GetBalanceSheets)       // Get balance sheets
 			stocks.GET("/:ticker/financials/cashflow", financialsHandler.GetCashFlowStatements) // Get cash flow statements
 			stocks.GET("/:ticker/financials/ratios", financialsHandler.GetRatios)               // Get financial ratios
+			stocks.GET("/:ticker/financials/ttm", financialsHandler.GetTTMFinancials)           // Trailing twelve months rolled up from quarterly statements
+			stocks.GET("/:ticker/financials/coverage", financialsHandler.GetFinancialsCoverage) // Periods on file per statement type and timeframe
+			stocks.GET("/:ticker/financials/export", financialsHandler.ExportFinancials)        // Download statements as XLSX or zipped CSVs
 			stocks.POST("/:ticker/financials/refresh", financialsHandler.RefreshFinancials)     // Refresh financial data
 
 			// Fundamentals enhancement endpoints (Project 1) — optional auth for tier detection
@@ -196,7 +256,9 @@ func main() {
 		// Crypto endpoints
 		crypto := v1.Group("/crypto")
 		{
-			crypto.GET("/", handlers.GetAllCryptos) // All crypto prices with pagination
+			crypto.GET("/", handlers.GetAllCryptos)                       // All crypto prices with pagination
+			crypto.GET("/:symbol/price", handlers.GetCryptoRealTimePrice) // Live price for one coin, ?vs= quote currency
+			crypto.GET("/:symbol/chart", handlers.GetCryptoChart)         // OHLCV candles, same schema as ticker charts
 		}
 
 		// Reddit popularity endpoints
@@ -211,19 +273,20 @@ func main() {
 		sentiment := v1.Group("/sentiment")
 		{
 			// IMPORTANT: /trending must come before /:ticker to avoid matching "trending" as a ticker
-			sentiment.GET("/trending", handlers.GetTrendingSentiment)             // GET /api/v1/sentiment/trending?period=24h&limit=20
+			sentiment.GET("/trending", handlers.GetTrendingSentiment)             // GET /api/v1/sentiment/trending?period=24h&limit=20[&min_upvotes=&min_comments=&weighted=true]
 			sentiment.GET("/:ticker", handlers.GetTickerSentiment)                // GET /api/v1/sentiment/AAPL
 			sentiment.GET("/:ticker/history", handlers.GetTickerSentimentHistory) // GET /api/v1/sentiment/AAPL/history?days=30
-			sentiment.GET("/:ticker/posts", handlers.GetTickerPosts)              // GET /api/v1/sentiment/AAPL/posts?limit=10
+			sentiment.GET("/:ticker/posts", handlers.GetTickerPosts)              // GET /api/v1/sentiment/AAPL/posts?limit=10&sort=upvotes&source=reddit
 		}
 
 		// Screener endpoint (real implementation in handlers)
-		// Note: Portfolio and Analytics endpoints were removed (mock-only, not implemented)
+		// Note: Analytics endpoints were removed (mock-only, not implemented); portfolios live under /user/portfolios
 
 		// Screener endpoints
 		screener := v1.Group("/screener")
 		{
 			screener.GET("/stocks", handlers.GetScreenerStocks)
+			screener.GET("/stocks.csv", handlers.GetScreenerStocksCSV)
 			screener.POST("/nlp", handlers.PostScreenerNLP)
 		}
 
@@ -244,6 +307,41 @@ func main() {
 		userRoutes.PUT("/me", handlers.UpdateProfile)
 		userRoutes.PUT("/password", handlers.ChangePassword)
 		userRoutes.DELETE("/me", handlers.DeleteAccount)
+		userRoutes.GET("/export", auth.UserRateLimitMiddleware(auth.GetExportLimiter()), handlers.ExportUserData)
+		userRoutes.GET("/activity", handlers.GetAccountActivity) // GET /api/v1/user/activity?limit=50&offset=0
+		userRoutes.GET("/2fa", handlers.GetTwoFactorStatus)
+		userRoutes.POST("/2fa/enroll", handlers.EnrollTwoFactor)      // Returns a TOTP secret and otpauth:// URI for the QR code
+		userRoutes.POST("/2fa/enable", handlers.EnableTwoFactor)      // Confirms a code, returns recovery codes
+		userRoutes.POST("/2fa/disable", handlers.DisableTwoFactor)    // Needs password and a TOTP or recovery code
+		userRoutes.GET("/searches", handlers.ListUserSearches)        // GET /api/v1/user/searches
+		userRoutes.POST("/searches", handlers.SaveUserSearch)         // POST /api/v1/user/searches
+		userRoutes.DELETE("/searches", handlers.ClearUserSearches)    // DELETE /api/v1/user/searches
+		userRoutes.DELETE("/searches/:id", handlers.DeleteUserSearch) // DELETE /api/v1/user/searches/:id
+
+		// Portfolios (scoped to the authenticated user)
+		userRoutes.GET("/portfolios", handlers.ListPortfolios)                                 // GET /api/v1/user/portfolios
+		userRoutes.POST("/portfolios", handlers.CreatePortfolio)                               // POST /api/v1/user/portfolios
+		userRoutes.GET("/portfolios/:id", handlers.GetPortfolio)                               // GET /api/v1/user/portfolios/:id
+		userRoutes.PUT("/portfolios/:id", handlers.UpdatePortfolio)                            // PUT /api/v1/user/portfolios/:id
+		userRoutes.DELETE("/portfolios/:id", handlers.DeletePortfolio)                         // DELETE /api/v1/user/portfolios/:id
+		userRoutes.GET("/portfolios/:id/performance", handlers.GetPortfolioPerformance)        // GET /api/v1/user/portfolios/:id/performance?period=1M
+		userRoutes.POST("/portfolios/:id/holdings", handlers.AddPortfolioHolding)              // POST /api/v1/user/portfolios/:id/holdings
+		userRoutes.PUT("/portfolios/:id/holdings/:symbol", handlers.UpdatePortfolioHolding)    // PUT /api/v1/user/portfolios/:id/holdings/:symbol
+		userRoutes.DELETE("/portfolios/:id/holdings/:symbol", handlers.RemovePortfolioHolding) // DELETE /api/v1/user/portfolios/:id/holdings/:symbol
+		userRoutes.GET("/portfolios/:id/transactions", handlers.ListPortfolioTransactions)     // GET /api/v1/user/portfolios/:id/transactions
+		userRoutes.POST("/portfolios/:id/transactions", handlers.CreatePortfolioTransaction)   // POST /api/v1/user/portfolios/:id/transactions
+	}
+
+	// Saved screener criteria (protected, require authentication)
+	screenRoutes := v1.Group("/screens")
+	screenRoutes.Use(auth.AuthMiddleware())
+	{
+		screenRoutes.GET("", handlers.ListSavedScreens)         // GET /api/v1/screens
+		screenRoutes.POST("", handlers.CreateSavedScreen)       // POST /api/v1/screens
+		screenRoutes.GET("/:id", handlers.GetSavedScreen)       // GET /api/v1/screens/:id
+		screenRoutes.PUT("/:id", handlers.UpdateSavedScreen)    // PUT /api/v1/screens/:id
+		screenRoutes.DELETE("/:id", handlers.DeleteSavedScreen) // DELETE /api/v1/screens/:id
+		screenRoutes.POST("/:id/run", handlers.RunSavedScreen)  // POST /api/v1/screens/:id/run
 	}
 
 	// Watch List routes (protected, require authentication)
@@ -288,17 +386,22 @@ func main() {
 	// Alert routes (protected, require authentication)
 	//
 	// IMPORTANT: Route ordering — Gin matches routes top-down. Static segments
-	// (/bulk, /logs) MUST be registered before parametric segments (/:id) to
-	// avoid Gin treating "bulk" or "logs" as an :id value.
+	// (/bulk, /export, /import, /logs) MUST be registered before parametric
+	// segments (/:id) to avoid Gin treating "bulk" or "logs" as an :id value.
 	alertRoutes := v1.Group("/alerts")
 	alertRoutes.Use(auth.AuthMiddleware())
 	{
-		alertRoutes.GET("", alertHandler.ListAlertRules)             // GET /api/v1/alerts
-		alertRoutes.POST("", alertHandler.CreateAlertRule)           // POST /api/v1/alerts
-		alertRoutes.POST("/bulk", alertHandler.BulkCreateAlertRules) // POST /api/v1/alerts/bulk  — must be before /:id
-		alertRoutes.GET("/:id", alertHandler.GetAlertRule)           // GET /api/v1/alerts/:id
-		alertRoutes.PUT("/:id", alertHandler.UpdateAlertRule)        // PUT /api/v1/alerts/:id
-		alertRoutes.DELETE("/:id", alertHandler.DeleteAlertRule)     // DELETE /api/v1/alerts/:id
+		alertRoutes.GET("", alertHandler.ListAlertRules)                  // GET /api/v1/alerts
+		alertRoutes.POST("", alertHandler.CreateAlertRule)                // POST /api/v1/alerts
+		alertRoutes.POST("/bulk", alertHandler.BulkCreateAlertRules)      // POST /api/v1/alerts/bulk  — must be before /:id
+		alertRoutes.GET("/export", alertHandler.ExportAlertRules)         // GET /api/v1/alerts/export
+		alertRoutes.POST("/import", alertHandler.ImportAlertRules)        // POST /api/v1/alerts/import
+		alertRoutes.GET("/:id", alertHandler.GetAlertRule)                // GET /api/v1/alerts/:id
+		alertRoutes.PUT("/:id", alertHandler.UpdateAlertRule)             // PUT /api/v1/alerts/:id
+		alertRoutes.DELETE("/:id", alertHandler.DeleteAlertRule)          // DELETE /api/v1/alerts/:id
+		alertRoutes.POST("/:id/test", alertHandler.TestAlertRule)         // POST /api/v1/alerts/:id/test
+		alertRoutes.POST("/:id/snooze", alertHandler.SnoozeAlertRule)     // POST /api/v1/alerts/:id/snooze
+		alertRoutes.POST("/:id/unsnooze", alertHandler.UnsnoozeAlertRule) // POST /api/v1/alerts/:id/unsnooze
 
 		// Alert logs — /logs must be before /:id above
 		alertRoutes.GET("/logs", alertHandler.ListAlertLogs)                // GET /api/v1/alerts/logs
@@ -319,6 +422,10 @@ func main() {
 		// Notification preferences
 		notificationRoutes.GET("/preferences", notificationHandler.GetNotificationPreferences)    // GET /api/v1/notifications/preferences
 		notificationRoutes.PUT("/preferences", notificationHandler.UpdateNotificationPreferences) // PUT /api/v1/notifications/preferences
+
+		// SMS phone number verification
+		notificationRoutes.POST("/phone/verify", notificationHandler.SendPhoneVerification)            // POST /api/v1/notifications/phone/verify
+		notificationRoutes.POST("/phone/verify/confirm", notificationHandler.ConfirmPhoneVerification) // POST /api/v1/notifications/phone/verify/confirm
 	}
 
 	// Subscription routes (protected, require authentication)
@@ -352,6 +459,7 @@ func main() {
 	adminRoutes := v1.Group("/admin")
 	adminRoutes.Use(auth.AuthMiddleware())
 	adminRoutes.Use(auth.AdminMiddleware())
+	adminRoutes.Use(handlers.AdminAuditMiddleware())
 	{
 		adminRoutes.GET("/stocks", adminDataHandler.GetStocks)                    // GET /api/v1/admin/stocks
 		adminRoutes.GET("/users", adminDataHandler.GetUsers)                      // GET /api/v1/admin/users
@@ -363,6 +471,8 @@ func main() {
 		adminRoutes.GET("/alerts", adminDataHandler.GetAlerts)                    // GET /api/v1/admin/alerts
 		adminRoutes.GET("/watchlists", adminDataHandler.GetWatchLists)            // GET /api/v1/admin/watchlists
 		adminRoutes.GET("/stats", adminDataHandler.GetDatabaseStats)              // GET /api/v1/admin/stats
+		adminRoutes.GET("/coverage", handlers.GetDataCoverage)                    // GET /api/v1/admin/coverage
+		adminRoutes.GET("/slow-queries", handlers.GetSlowQueryCounts)             // GET /api/v1/admin/slow-queries
 		// IC Score pipeline data (from IC Score service database)
 		adminRoutes.GET("/analyst-ratings", adminDataHandler.GetAnalystRatings)               // GET /api/v1/admin/analyst-ratings
 		adminRoutes.GET("/insider-trades", adminDataHandler.GetInsiderTrades)                 // GET /api/v1/admin/insider-trades
@@ -371,6 +481,15 @@ func main() {
 		adminRoutes.GET("/companies", adminDataHandler.GetCompanies)                          // GET /api/v1/admin/companies
 		adminRoutes.GET("/risk-metrics", adminDataHandler.GetRiskMetrics)                     // GET /api/v1/admin/risk-metrics
 		adminRoutes.GET("/ic-scores", handlers.GetICScores)                                   // GET /api/v1/admin/ic-scores
+		adminRoutes.GET("/audit-log", handlers.GetAdminAuditLog)                              // GET /api/v1/admin/audit-log
+
+		// Sensitive actions, limited to ADMIN_SENSITIVE_ALLOWLIST when set
+		sensitive := adminRoutes.Group("/users")
+		sensitive.Use(auth.RequireAdminAllowlist())
+		{
+			sensitive.PUT("/:id/admin", handlers.SetUserAdmin) // PUT /api/v1/admin/users/:id/admin
+			sensitive.POST("/:id/purge", handlers.PurgeUser)   // POST /api/v1/admin/users/:id/purge
+		}
 
 		// Notes/brainstorming endpoints
 		notes := adminRoutes.Group("/notes")
@@ -395,6 +514,26 @@ func main() {
 
 	}
 
+	// Price maintenance routes (protected, require authentication + admin or
+	// worker role; refreshes are recorded in the admin audit log)
+	priceRoutes := v1.Group("/prices")
+	priceRoutes.Use(auth.AuthMiddleware())
+	priceRoutes.Use(handlers.RequireAdminOrWorker())
+	priceRoutes.Use(handlers.AdminAuditMiddleware())
+	{
+		priceRoutes.POST("/refresh", handlers.RefreshPrices) // POST /api/v1/prices/refresh
+	}
+
+	// Fundamentals maintenance routes (protected, require authentication +
+	// admin or worker role; refreshes are recorded in the admin audit log)
+	fundamentalsRoutes := v1.Group("/fundamentals")
+	fundamentalsRoutes.Use(auth.AuthMiddleware())
+	fundamentalsRoutes.Use(handlers.RequireAdminOrWorker())
+	fundamentalsRoutes.Use(handlers.AdminAuditMiddleware())
+	{
+		fundamentalsRoutes.POST("/refresh", handlers.RefreshFundamentals) // POST /api/v1/fundamentals/refresh
+	}
+
 	// Task service routes — proxied to task-service (protected, require authentication)
 	taskProxy := services.TaskServiceProxy()
 	taskRoutes := v1.Group("")
@@ -415,16 +554,53 @@ func main() {
 		ingestRoutes.Any("/ingest/*path", ingestProxy)
 	}
 
+	// Captured email routes, only in sandbox mode (EMAIL_SANDBOX=true)
+	if sandbox := services.EmailSandboxFromEnv(); sandbox != nil {
+		sandboxHandler := handlers.NewEmailSandboxHandler(sandbox, os.Getenv("CANARY_TOKEN"))
+		canaryRoutes := v1.Group("/canary")
+		canaryRoutes.Use(sandboxHandler.RequireCanaryToken())
+		{
+			canaryRoutes.GET("/emails", sandboxHandler.GetCapturedEmails)      // GET /api/v1/canary/emails
+			canaryRoutes.DELETE("/emails", sandboxHandler.ClearCapturedEmails) // DELETE /api/v1/canary/emails
+		}
+	}
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Starting InvestorCenter API server on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+
+	go func() {
+		log.Printf("Starting InvestorCenter API server on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down InvestorCenter API server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ Server forced to shutdown: %v", err)
+	}
+
+	// Price updates batched by SNS_PUBLISH_BATCH_WINDOW would otherwise be
+	// lost with the process
+	if publisher := services.GetPriceUpdatePublisher(); publisher != nil {
+		publisher.Flush()
 	}
+
+	log.Println("InvestorCenter API server exited")
 }
 
 // Market data handlers
@@ -432,31 +608,37 @@ func searchSecurities(c *gin.Context) {
 	query := c.Query("q")
 
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, "Query parameter 'q' is required")
+		return
+	}
+
+	opts, err := handlers.ParseSearchOptions(c, 10)
+	if err != nil {
+		httputil.RespondError(c, http.StatusBadRequest, httputil.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Use service layer for database operations
 	stockService := services.NewStockService()
-	stocks, err := stockService.SearchStocks(c.Request.Context(), query, 10)
+	stocks, err := stockService.SearchSecurities(c.Request.Context(), query, opts)
 	if err != nil {
 		log.Printf("Database search failed: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "Search temporarily unavailable",
-			"details": "Database connection failed",
-		})
+		httputil.RespondError(c, http.StatusServiceUnavailable, httputil.CodeServiceUnavailable, "Search temporarily unavailable", "Database connection failed")
 		return
 	}
 
+	handlers.RecordSearchHistory(c, query)
+
 	// Convert to API format
 	results := make([]gin.H, len(stocks))
 	for i, stock := range stocks {
 		results[i] = gin.H{
-			"symbol":   stock.Symbol,
-			"name":     stock.Name,
-			"type":     stock.AssetType,
-			"exchange": stock.Exchange,
-			"logo_url": stock.LogoURL,
+			"symbol":       stock.Symbol,
+			"name":         stock.Name,
+			"type":         stock.AssetType,
+			"exchange":     stock.Exchange,
+			"logo_url":     stock.LogoURL,
+			"match_reason": stock.MatchReason,
 		}
 	}
 
@@ -464,6 +646,8 @@ func searchSecurities(c *gin.Context) {
 		"data": results,
 		"meta": gin.H{
 			"query":     query,
+			"types":     opts.Types,
+			"fuzzy":     opts.Fuzzy,
 			"count":     len(results),
 			"timestamp": time.Now().UTC(),
 			"source":    "database",
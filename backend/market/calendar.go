@@ -0,0 +1,208 @@
+// Package market describes US equity market trading hours and holidays.
+package market
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// DefaultHolidays are full-day NYSE/Nasdaq closures. Override with the
+// MARKET_HOLIDAYS env var (comma-separated YYYY-MM-DD) when the exchanges
+// publish a new year or announce an unscheduled closure.
+var DefaultHolidays = []string{
+	// 2025
+	"2025-01-01", "2025-01-09", "2025-01-20", "2025-02-17", "2025-04-18", "2025-05-26",
+	"2025-06-19", "2025-07-04", "2025-09-01", "2025-11-27", "2025-12-25",
+	// 2026
+	"2026-01-01", "2026-01-19", "2026-02-16", "2026-04-03", "2026-05-25",
+	"2026-06-19", "2026-07-03", "2026-09-07", "2026-11-26", "2026-12-25",
+	// 2027
+	"2027-01-01", "2027-01-18", "2027-02-15", "2027-03-26", "2027-05-31",
+	"2027-06-18", "2027-07-05", "2027-09-06", "2027-11-25", "2027-12-24",
+}
+
+// DefaultHalfDays are sessions that close early at 1:00 PM ET. Override
+// with the MARKET_HALF_DAYS env var.
+var DefaultHalfDays = []string{
+	"2025-07-03", "2025-11-28", "2025-12-24",
+	"2026-11-27", "2026-12-24",
+	"2027-11-26",
+}
+
+// Calendar knows the regular session hours, holidays and early closes of
+// the US equity market. All times are interpreted in America/New_York.
+type Calendar struct {
+	Location *time.Location
+	holidays map[string]bool
+	halfDays map[string]bool
+}
+
+// Regular session hours (minutes after midnight ET)
+const (
+	openMinute       = 9*60 + 30
+	closeMinute      = 16 * 60
+	earlyCloseMinute = 13 * 60
+)
+
+var (
+	defaultCalendar     *Calendar
+	defaultCalendarOnce sync.Once
+)
+
+// NewCalendar creates a calendar with the given holiday and half-day dates
+// (YYYY-MM-DD)
+func NewCalendar(holidays, halfDays []string) (*Calendar, error) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load market timezone: %w", err)
+	}
+
+	c := &Calendar{
+		Location: loc,
+		holidays: make(map[string]bool, len(holidays)),
+		halfDays: make(map[string]bool, len(halfDays)),
+	}
+	for _, d := range holidays {
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: %w", d, err)
+		}
+		c.holidays[d] = true
+	}
+	for _, d := range halfDays {
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return nil, fmt.Errorf("invalid half day %q: %w", d, err)
+		}
+		c.halfDays[d] = true
+	}
+	return c, nil
+}
+
+// Default returns the shared US market calendar. MARKET_HOLIDAYS and
+// MARKET_HALF_DAYS, when set, replace the built-in date lists.
+func Default() *Calendar {
+	defaultCalendarOnce.Do(func() {
+		holidays := parseDateList(os.Getenv("MARKET_HOLIDAYS"), DefaultHolidays)
+		halfDays := parseDateList(os.Getenv("MARKET_HALF_DAYS"), DefaultHalfDays)
+
+		c, err := NewCalendar(holidays, halfDays)
+		if err != nil {
+			log.Printf("Warning: invalid market calendar config, using built-in dates: %v", err)
+			c, err = NewCalendar(DefaultHolidays, DefaultHalfDays)
+		}
+		if err != nil {
+			// Timezone data unavailable: fall back to UTC-5 so callers
+			// still get a usable (if DST-unaware) calendar
+			c = &Calendar{Location: time.FixedZone("EST", -5*60*60), holidays: map[string]bool{}, halfDays: map[string]bool{}}
+		}
+		defaultCalendar = c
+	})
+	return defaultCalendar
+}
+
+func parseDateList(value string, fallback []string) []string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	var dates []string
+	for _, d := range strings.Split(value, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// IsHoliday reports whether the market is closed all day on t's date
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	return c.holidays[t.In(c.Location).Format(dateLayout)]
+}
+
+// IsHalfDay reports whether the market closes early on t's date
+func (c *Calendar) IsHalfDay(t time.Time) bool {
+	return c.halfDays[t.In(c.Location).Format(dateLayout)]
+}
+
+// IsTradingDay reports whether t's date has a regular session
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	local := t.In(c.Location)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(local)
+}
+
+// SessionOpen returns the opening time of t's date
+func (c *Calendar) SessionOpen(t time.Time) time.Time {
+	return c.atMinute(t, openMinute)
+}
+
+// SessionClose returns the closing time of t's date, accounting for
+// early closes
+func (c *Calendar) SessionClose(t time.Time) time.Time {
+	if c.IsHalfDay(t) {
+		return c.atMinute(t, earlyCloseMinute)
+	}
+	return c.atMinute(t, closeMinute)
+}
+
+// IsMarketOpen reports whether the regular session is in progress at t
+func (c *Calendar) IsMarketOpen(t time.Time) bool {
+	if !c.IsTradingDay(t) {
+		return false
+	}
+	return !t.Before(c.SessionOpen(t)) && t.Before(c.SessionClose(t))
+}
+
+// NextOpen returns the start of the next regular session after t. If t is
+// before today's open on a trading day, that is today's open.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	day := t.In(c.Location)
+	if c.IsTradingDay(day) && t.Before(c.SessionOpen(day)) {
+		return c.SessionOpen(day)
+	}
+	return c.SessionOpen(c.NextTradingDay(day))
+}
+
+// LastClose returns the end of the most recent regular session that closed
+// at or before t
+func (c *Calendar) LastClose(t time.Time) time.Time {
+	day := t.In(c.Location)
+	if c.IsTradingDay(day) && !t.Before(c.SessionClose(day)) {
+		return c.SessionClose(day)
+	}
+	return c.SessionClose(c.PreviousTradingDay(day))
+}
+
+// NextTradingDay returns midnight of the first trading day after t's date
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	d := c.midnight(t).AddDate(0, 0, 1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// PreviousTradingDay returns midnight of the last trading day before t's date
+func (c *Calendar) PreviousTradingDay(t time.Time) time.Time {
+	d := c.midnight(t).AddDate(0, 0, -1)
+	for !c.IsTradingDay(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+func (c *Calendar) midnight(t time.Time) time.Time {
+	local := t.In(c.Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.Location)
+}
+
+func (c *Calendar) atMinute(t time.Time, minute int) time.Time {
+	local := t.In(c.Location)
+	return time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, c.Location)
+}
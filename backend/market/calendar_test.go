@@ -0,0 +1,108 @@
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCalendar(t *testing.T) *Calendar {
+	t.Helper()
+	c, err := NewCalendar(DefaultHolidays, DefaultHalfDays)
+	require.NoError(t, err)
+	return c
+}
+
+func et(c *Calendar, s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, c.Location)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestIsMarketOpen_RegularSession(t *testing.T) {
+	c := testCalendar(t)
+
+	assert.False(t, c.IsMarketOpen(et(c, "2026-03-10 09:29")))
+	assert.True(t, c.IsMarketOpen(et(c, "2026-03-10 09:30")))
+	assert.True(t, c.IsMarketOpen(et(c, "2026-03-10 15:59")))
+	assert.False(t, c.IsMarketOpen(et(c, "2026-03-10 16:00")))
+
+	// Same instant expressed in UTC
+	assert.True(t, c.IsMarketOpen(et(c, "2026-03-10 12:00").UTC()))
+}
+
+func TestIsMarketOpen_Weekend(t *testing.T) {
+	c := testCalendar(t)
+
+	assert.False(t, c.IsTradingDay(et(c, "2026-03-07 12:00"))) // Saturday
+	assert.False(t, c.IsMarketOpen(et(c, "2026-03-07 12:00")))
+	assert.False(t, c.IsMarketOpen(et(c, "2026-03-08 12:00"))) // Sunday
+}
+
+func TestIsMarketOpen_Holidays(t *testing.T) {
+	c := testCalendar(t)
+
+	for _, d := range []string{"2026-01-01", "2026-04-03", "2026-07-03", "2026-11-26", "2026-12-25"} {
+		noon := et(c, d+" 12:00")
+		assert.True(t, c.IsHoliday(noon), d)
+		assert.False(t, c.IsTradingDay(noon), d)
+		assert.False(t, c.IsMarketOpen(noon), d)
+	}
+}
+
+func TestIsMarketOpen_HalfDays(t *testing.T) {
+	c := testCalendar(t)
+
+	// Day after Thanksgiving closes at 1:00 PM
+	assert.True(t, c.IsHalfDay(et(c, "2026-11-27 10:00")))
+	assert.True(t, c.IsMarketOpen(et(c, "2026-11-27 12:59")))
+	assert.False(t, c.IsMarketOpen(et(c, "2026-11-27 13:00")))
+	assert.Equal(t, et(c, "2026-11-27 13:00"), c.SessionClose(et(c, "2026-11-27 10:00")))
+	assert.Equal(t, et(c, "2026-11-30 16:00"), c.SessionClose(et(c, "2026-11-30 10:00")))
+}
+
+func TestNextOpen(t *testing.T) {
+	c := testCalendar(t)
+
+	// Before the open on a trading day: today's open
+	assert.Equal(t, et(c, "2026-03-10 09:30"), c.NextOpen(et(c, "2026-03-10 07:00")))
+	// During the session: tomorrow's open
+	assert.Equal(t, et(c, "2026-03-11 09:30"), c.NextOpen(et(c, "2026-03-10 11:00")))
+	// Friday evening: Monday's open
+	assert.Equal(t, et(c, "2026-03-09 09:30"), c.NextOpen(et(c, "2026-03-06 18:00")))
+	// Thursday before Good Friday: skips the holiday and weekend
+	assert.Equal(t, et(c, "2026-04-06 09:30"), c.NextOpen(et(c, "2026-04-02 17:00")))
+}
+
+func TestLastClose(t *testing.T) {
+	c := testCalendar(t)
+
+	assert.Equal(t, et(c, "2026-03-09 16:00"), c.LastClose(et(c, "2026-03-10 10:00")))
+	assert.Equal(t, et(c, "2026-03-10 16:00"), c.LastClose(et(c, "2026-03-10 16:00")))
+	// Monday morning after a weekend: Friday's close
+	assert.Equal(t, et(c, "2026-03-06 16:00"), c.LastClose(et(c, "2026-03-09 08:00")))
+	// Saturday after an early close
+	assert.Equal(t, et(c, "2026-11-27 13:00"), c.LastClose(et(c, "2026-11-28 12:00")))
+}
+
+func TestNewCalendar_ConfigurableHolidays(t *testing.T) {
+	c, err := NewCalendar([]string{"2026-03-10"}, []string{"2026-03-11"})
+	require.NoError(t, err)
+
+	assert.False(t, c.IsMarketOpen(et(c, "2026-03-10 12:00")))
+	assert.False(t, c.IsMarketOpen(et(c, "2026-03-11 14:00")))
+	assert.True(t, c.IsMarketOpen(et(c, "2026-03-12 14:00")))
+	assert.True(t, c.IsTradingDay(et(c, "2026-12-25 12:00")), "built-in holidays are replaced, not merged")
+
+	_, err = NewCalendar([]string{"not-a-date"}, nil)
+	assert.Error(t, err)
+}
+
+func TestParseDateList(t *testing.T) {
+	assert.Equal(t, []string{"2026-01-01", "2026-01-02"}, parseDateList(" 2026-01-01, ,2026-01-02", nil))
+	assert.Equal(t, DefaultHalfDays, parseDateList("", DefaultHalfDays))
+}
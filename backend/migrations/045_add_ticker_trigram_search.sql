@@ -0,0 +1,13 @@
+-- Migration: Trigram indexes for typo-tolerant ticker search
+--
+-- SearchStocks falls back to pg_trgm similarity ranking when exact/prefix
+-- matches are sparse (e.g. "Appel" for "Apple"). GIN trigram indexes keep the
+-- similarity operator (%) from scanning the whole tickers table.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tickers_name_trgm
+    ON tickers USING GIN (UPPER(name) gin_trgm_ops);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tickers_symbol_trgm
+    ON tickers USING GIN (UPPER(symbol) gin_trgm_ops);
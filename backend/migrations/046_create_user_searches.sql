@@ -0,0 +1,18 @@
+-- Create user_searches table for per-user recent and pinned searches
+-- Powers the "recent searches" dropdown. Queries are deduped per user on
+-- their normalized form; unpinned history is capped by the API.
+
+CREATE TABLE IF NOT EXISTS user_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    query VARCHAR(100) NOT NULL,
+    normalized_query VARCHAR(100) NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    search_count INTEGER NOT NULL DEFAULT 1,
+    searched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, normalized_query)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_searches_user_searched_at
+    ON user_searches(user_id, searched_at DESC);
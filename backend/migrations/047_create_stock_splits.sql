@@ -0,0 +1,19 @@
+-- Create stock_splits table for corporate-action (split) history
+-- Populated from Polygon (falling back to FMP) and used to split-adjust
+-- historical prices. split_from:split_to is the share ratio, so a 4-for-1
+-- split is 1:4 and a 1-for-10 reverse split is 10:1.
+
+CREATE TABLE IF NOT EXISTS stock_splits (
+    id SERIAL PRIMARY KEY,
+    ticker VARCHAR(10) NOT NULL,
+    execution_date DATE NOT NULL,
+    split_from NUMERIC(20,8) NOT NULL CHECK (split_from > 0),
+    split_to NUMERIC(20,8) NOT NULL CHECK (split_to > 0),
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (ticker, execution_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_splits_ticker_date
+    ON stock_splits(ticker, execution_date DESC);
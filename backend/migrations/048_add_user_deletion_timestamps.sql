@@ -0,0 +1,12 @@
+-- Track when an account was deleted and when its data was purged
+-- DeleteAccount sets deleted_at; the account-purge job removes the user's
+-- data once the grace window has passed and sets purged_at. Accounts that
+-- were soft-deleted before this migration start their grace window now.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP;
+
+UPDATE users SET deleted_at = NOW() WHERE is_active = FALSE AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_pending_purge
+    ON users(deleted_at) WHERE purged_at IS NULL AND deleted_at IS NOT NULL;
@@ -0,0 +1,26 @@
+-- Create portfolios and portfolio_holdings tables
+-- Per-user portfolios of held positions. Each holding tracks shares and
+-- average cost per share; current value is computed at read time from the
+-- latest daily close in stock_prices.
+
+CREATE TABLE IF NOT EXISTS portfolios (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolios_user_id ON portfolios(user_id);
+
+CREATE TABLE IF NOT EXISTS portfolio_holdings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    shares DECIMAL(20, 6) NOT NULL CHECK (shares > 0),
+    average_cost DECIMAL(20, 4) NOT NULL CHECK (average_cost >= 0),
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (portfolio_id, symbol)
+);
@@ -0,0 +1,18 @@
+-- Create portfolio_transactions table
+-- Buys and sells of portfolio holdings. A symbol's transactions are replayed
+-- with FIFO lot matching to derive its shares, average cost and realized
+-- gains; portfolio_holdings caches the resulting position.
+
+CREATE TABLE IF NOT EXISTS portfolio_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    portfolio_id UUID NOT NULL REFERENCES portfolios(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    transaction_type VARCHAR(4) NOT NULL CHECK (transaction_type IN ('BUY', 'SELL')),
+    shares DECIMAL(20, 6) NOT NULL CHECK (shares > 0),
+    price DECIMAL(20, 4) NOT NULL CHECK (price >= 0),
+    executed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_portfolio_transactions_portfolio_symbol
+    ON portfolio_transactions(portfolio_id, symbol, executed_at);
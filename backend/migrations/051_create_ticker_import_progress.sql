@@ -0,0 +1,9 @@
+-- Create ticker_import_progress table
+-- Records when import-tickers last finished each asset type, so a run that
+-- crashes partway through can be re-run without re-importing the asset types
+-- it already completed.
+
+CREATE TABLE IF NOT EXISTS ticker_import_progress (
+    asset_type VARCHAR(20) PRIMARY KEY,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
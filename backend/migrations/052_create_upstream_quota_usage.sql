@@ -0,0 +1,12 @@
+-- Create upstream_quota_usage table
+-- Counts calls made to each upstream data provider (FMP, Polygon, CoinGecko)
+-- per calendar month, so quota tracking survives restarts and is shared by
+-- the API server and batch jobs.
+
+CREATE TABLE IF NOT EXISTS upstream_quota_usage (
+    source VARCHAR(20) NOT NULL,
+    period_start DATE NOT NULL,
+    calls BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, period_start)
+);
@@ -0,0 +1,20 @@
+-- Create saved_screens table
+-- Named screener criteria a user can re-run. params holds the screener's
+-- ScreenerParams as JSON. The number of screens a user may save is capped by
+-- their plan's max_saved_screens (-1 = unlimited).
+
+ALTER TABLE subscription_plans ADD COLUMN IF NOT EXISTS max_saved_screens INTEGER NOT NULL DEFAULT 5;
+
+UPDATE subscription_plans SET max_saved_screens = 50 WHERE name = 'premium';
+UPDATE subscription_plans SET max_saved_screens = -1 WHERE name = 'enterprise';
+
+CREATE TABLE IF NOT EXISTS saved_screens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_screens_user_id ON saved_screens(user_id);
@@ -0,0 +1,4 @@
+-- Record when a quota alert was sent for each source and month, so only one
+-- API server replica sends it and restarts don't repeat it.
+
+ALTER TABLE upstream_quota_usage ADD COLUMN IF NOT EXISTS alert_sent_at TIMESTAMP WITH TIME ZONE;
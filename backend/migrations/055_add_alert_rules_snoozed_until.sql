@@ -0,0 +1,7 @@
+-- Let users mute an alert rule temporarily without deleting it. The
+-- notification service skips rules whose snoozed_until is in the future.
+-- Unsnoozing sets snoozed_until to the time the mute ended instead of
+-- clearing it, so event alerts (e.g. dividend declarations) that happened
+-- while muted aren't notified once the rule is live again.
+
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,15 @@
+-- Per-user daily counters the notification service enforces
+-- max_alerts_per_day and max_emails_per_day against. day is the user's
+-- local date in their quiet_hours_timezone. A slot is reserved with a
+-- conditional upsert before an alert fires or an email is sent, so
+-- concurrent consumers can't push a user past their cap.
+
+CREATE TABLE IF NOT EXISTS notification_daily_counts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    alerts INTEGER NOT NULL DEFAULT 0,
+    emails INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_daily_counts_day ON notification_daily_counts(day);
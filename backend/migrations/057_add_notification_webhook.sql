@@ -0,0 +1,7 @@
+-- Let users have alerts POSTed to a webhook (e.g. a Slack or Discord
+-- incoming webhook) alongside email. The notification service sends to
+-- webhook_url when it is set and, when webhook_secret is set, signs each
+-- payload with HMAC-SHA256 so receivers can verify it came from us.
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS webhook_url TEXT;
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS webhook_secret TEXT;
@@ -0,0 +1,25 @@
+-- SMS alert delivery (via Twilio) for paid plans. Users add a phone number
+-- in E.164 form to their notification preferences and confirm it with a
+-- code sent by SMS; the notification service texts price alerts only to
+-- verified numbers, with sms_enabled on, on a plan with the sms_alerts
+-- feature.
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS sms_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS phone_number VARCHAR(16);
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT false;
+
+-- The pending verification code for each user's phone number, stored as a
+-- SHA-256 hash. Sending a new code replaces the old one.
+CREATE TABLE IF NOT EXISTS phone_verifications (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    phone_number VARCHAR(16) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+UPDATE subscription_plans SET features = features || '{"sms_alerts": true}'::jsonb
+WHERE name IN ('premium', 'enterprise');
+UPDATE subscription_plans SET features = features || '{"sms_alerts": false}'::jsonb
+WHERE name = 'free';
@@ -0,0 +1,14 @@
+-- Price update messages the notification service has handled, so copies
+-- SQS redelivers (it is at-least-once) are acknowledged without firing
+-- alerts again. message_id is the message's idempotency key, or its SNS
+-- message ID. While a message is being handled its row is a lease that
+-- runs out with the SQS visibility timeout, so a retry after a crash is
+-- still processed; once handled the row is kept until expires_at and
+-- then purged.
+
+CREATE TABLE IF NOT EXISTS processed_messages (
+    message_id TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_messages_expires_at ON processed_messages(expires_at);
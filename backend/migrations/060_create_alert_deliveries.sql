@@ -0,0 +1,18 @@
+-- Delivery state of each triggered alert on each channel (email, in_app,
+-- webhook, sms), so a retry after a partial failure re-sends only the
+-- channels that failed. A channel is claimed ('sending', with a lease)
+-- before it is sent and then marked delivered, failed or suppressed. A
+-- lease that runs out (the sender crashed mid-send) can be claimed again.
+
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    alert_log_id UUID NOT NULL REFERENCES alert_logs(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('sending', 'delivered', 'failed', 'suppressed')),
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT,
+    lease_until TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (alert_log_id, channel)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_failed ON alert_deliveries(updated_at) WHERE status = 'failed';
@@ -0,0 +1,6 @@
+-- When import-tickers -reconcile found a ticker missing from Polygon's
+-- listing and marked it inactive. Cleared if the ticker is listed again.
+
+ALTER TABLE tickers ADD COLUMN IF NOT EXISTS delisted_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN tickers.delisted_at IS 'When the ticker disappeared from the Polygon listing (NULL while listed)';
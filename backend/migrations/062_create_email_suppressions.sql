@@ -0,0 +1,14 @@
+-- Addresses the notification service no longer emails, from SES bounce and
+-- complaint feedback (delivered through SNS to its /webhooks/ses endpoint).
+-- Hard bounces and complaints are added here, and the matching
+-- notification_preferences are marked unverified; a complaint also turns
+-- email off. Transient bounces aren't recorded. Addresses are stored
+-- lowercased.
+
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    email VARCHAR(255) PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('bounce', 'complaint')),
+    detail TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
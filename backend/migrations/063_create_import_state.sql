@@ -0,0 +1,10 @@
+-- Create import_state table
+-- The watermark import-tickers -since resumes from: the newest
+-- last_updated_utc Polygon reported for the asset type in the last fully
+-- successful run. Tickers updated at or before it are skipped.
+
+CREATE TABLE IF NOT EXISTS import_state (
+    asset_type VARCHAR(20) PRIMARY KEY,
+    watermark TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
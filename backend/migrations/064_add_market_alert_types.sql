@@ -0,0 +1,12 @@
+-- Allow the alert types added since 012: pct_change and pe_crosses, and the
+-- market-wide index_move and sector_move, whose symbol is the ETF standing
+-- in for the index or sector in their conditions.
+
+ALTER TABLE alert_rules DROP CONSTRAINT IF EXISTS valid_alert_type;
+
+ALTER TABLE alert_rules ADD CONSTRAINT valid_alert_type CHECK (alert_type IN (
+    'price_above', 'price_below', 'price_change_pct', 'price_change_amount',
+    'pct_change', 'pe_crosses', 'index_move', 'sector_move',
+    'volume_spike', 'unusual_volume', 'volume_above', 'volume_below',
+    'news', 'earnings', 'dividend', 'sec_filing', 'analyst_rating'
+));
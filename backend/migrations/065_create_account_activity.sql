@@ -0,0 +1,18 @@
+-- Security-relevant events on a user's account, listed to the user at
+-- GET /api/v1/user/activity. Rows are written best-effort by the auth, user
+-- and subscription handlers; login and signup rows record the session they
+-- created in metadata. IP address and user agent are those of the request
+-- that caused the event.
+
+CREATE TABLE IF NOT EXISTS account_activity (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    ip_address INET,
+    user_agent TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_activity_user_created
+    ON account_activity(user_id, created_at DESC);
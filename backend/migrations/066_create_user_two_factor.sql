@@ -0,0 +1,18 @@
+-- Optional TOTP two-factor authentication. A row is created on enrollment
+-- with enabled = false and turned on once the user confirms a code from
+-- their authenticator app; disabling 2FA deletes it. The TOTP secret is
+-- AES-GCM encrypted by the API (see auth/totp.go), recovery codes are
+-- stored as SHA-256 hashes and removed as they're used, and
+-- last_used_step is the last accepted TOTP time step so a code can't be
+-- replayed.
+
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    recovery_code_hashes TEXT[] NOT NULL DEFAULT '{}',
+    last_used_step BIGINT,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
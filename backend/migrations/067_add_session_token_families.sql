@@ -0,0 +1,15 @@
+-- Refresh token rotation. Each refresh marks the presented session row
+-- rotated and inserts a new row, with a new refresh token, in the same
+-- family. A rotated token being presented again means it was copied, so
+-- the API revokes all of the user's sessions. Rotated rows are kept until
+-- they expire so reuse can still be recognized.
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS family_id UUID;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMP;
+
+-- Existing sessions each start their own family
+UPDATE sessions SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE sessions ALTER COLUMN family_id SET DEFAULT gen_random_uuid();
+ALTER TABLE sessions ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
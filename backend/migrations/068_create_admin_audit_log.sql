@@ -0,0 +1,20 @@
+-- Audit trail of admin mutations. Every non-GET request to /api/v1/admin
+-- is recorded after it is handled, including rejected ones, with the route
+-- as the action and the concrete request path as the target. Rows are kept
+-- when the actor's account is purged; only their email is cleared.
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    actor_email VARCHAR(255),
+    action VARCHAR(255) NOT NULL,
+    target TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    ip_address INET,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created
+    ON admin_audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_actor
+    ON admin_audit_log(actor_user_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Onboarding: a new user's default watch list is seeded with popular
+-- tickers once, on signup or, if that failed, their next login. The
+-- timestamp records that seeding ran (or was skipped) so it never repeats,
+-- even after the user empties the list.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS watchlist_seeded_at TIMESTAMP;
+
+-- Existing users have already set up their lists; only new users are seeded
+UPDATE users SET watchlist_seeded_at = COALESCE(created_at, CURRENT_TIMESTAMP)
+WHERE watchlist_seeded_at IS NULL;
@@ -0,0 +1,24 @@
+-- Register the cronjobs the freshness monitor checks that weren't in
+-- cronjob_schedules yet. cronjob-monitor only logs executions of scheduled
+-- jobs, so without these rows they never show a successful run.
+
+INSERT INTO cronjob_schedules (job_name, job_category, description, schedule_cron, schedule_description, expected_duration_seconds, timeout_seconds)
+VALUES
+    ('ic-score-daily-price-update', 'ic_score_pipeline', 'Fetches end-of-day prices from Polygon into stock_prices', '30 22 * * 1-5', 'Daily at 10:30 PM UTC on weekdays', 1800, 7200),
+    ('ic-score-fundamental-metrics', 'ic_score_pipeline', 'Calculates extended fundamental metrics from TTM financials', '0 5 * * *', 'Daily at 5:00 AM UTC', 3600, 10800),
+    ('reddit-sentiment-pipeline', 'core_pipeline', 'Collects Reddit posts and aggregates ticker sentiment snapshots', '0 * * * *', 'Hourly', 600, 3000)
+ON CONFLICT (job_name) DO UPDATE SET
+    job_category = EXCLUDED.job_category,
+    description = EXCLUDED.description,
+    schedule_cron = EXCLUDED.schedule_cron,
+    schedule_description = EXCLUDED.schedule_description,
+    expected_duration_seconds = EXCLUDED.expected_duration_seconds,
+    timeout_seconds = EXCLUDED.timeout_seconds,
+    updated_at = CURRENT_TIMESTAMP;
+
+INSERT INTO cronjob_alerts (job_name, alert_type, alert_threshold, notification_channels)
+VALUES
+    ('ic-score-daily-price-update', 'failure', 2, '["email"]'::JSONB),
+    ('ic-score-fundamental-metrics', 'failure', 3, '["email"]'::JSONB),
+    ('reddit-sentiment-pipeline', 'failure', 3, '["email"]'::JSONB)
+ON CONFLICT DO NOTHING;
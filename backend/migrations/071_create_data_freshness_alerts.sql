@@ -0,0 +1,10 @@
+-- Freshness breaches admins have already been told about. The freshness
+-- monitor only notifies for breaches missing here, and deletes a row once
+-- its breach clears so a recurrence alerts again.
+
+CREATE TABLE IF NOT EXISTS data_freshness_alerts (
+    alert_key VARCHAR(100) PRIMARY KEY,  -- "<domain>/<reason>"
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE data_freshness_alerts IS 'Open data freshness SLA breaches that have already been notified';
@@ -0,0 +1,13 @@
+-- Latest FMP responses per symbol and endpoint, written by the bulk
+-- fundamentals refresh. Lets readers such as the financials coverage
+-- endpoint know what FMP has without calling it per request.
+
+CREATE TABLE IF NOT EXISTS fmp_snapshots (
+    symbol VARCHAR(20) NOT NULL,
+    endpoint VARCHAR(50) NOT NULL,   -- FMP endpoint, e.g. 'ratios-ttm', 'score'
+    data JSONB,                      -- NULL when FMP has no data for the symbol
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (symbol, endpoint)
+);
+
+COMMENT ON TABLE fmp_snapshots IS 'Latest FMP response per symbol and endpoint; NULL data means FMP had nothing for the symbol';
@@ -0,0 +1,9 @@
+-- When each ticker's split history was last fetched from upstream. Tickers
+-- that have never split have no stock_splits rows, so the sync time is kept
+-- separately; the split service refetches once it is older than
+-- SPLITS_SYNC_TTL_HOURS so new splits are picked up.
+
+CREATE TABLE IF NOT EXISTS stock_split_syncs (
+    ticker VARCHAR(10) PRIMARY KEY,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
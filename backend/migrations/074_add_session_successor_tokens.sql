@@ -0,0 +1,8 @@
+-- Refresh token reuse grace window. When a session is rotated its
+-- successor refresh token is stored on the rotated row, sealed under a key
+-- derived from the rotated token (only its holder can open it). A rotated
+-- token presented again shortly after rotation, e.g. by a second tab
+-- refreshing at the same time, gets that successor back instead of
+-- revoking every session.
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS successor_token TEXT;
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Account activity event types
+const (
+	AccountEventSignup               = "signup"
+	AccountEventLogin                = "login"
+	AccountEventPasswordChanged      = "password_changed"
+	AccountEventPasswordReset        = "password_reset"
+	AccountEventTwoFactorEnabled     = "two_factor_enabled"
+	AccountEventTwoFactorDisabled    = "two_factor_disabled"
+	AccountEventRefreshTokenReused   = "refresh_token_reused"
+	AccountEventSubscriptionCreated  = "subscription_created"
+	AccountEventSubscriptionUpdated  = "subscription_updated"
+	AccountEventSubscriptionCanceled = "subscription_canceled"
+)
+
+// AccountActivity is a security-relevant event on a user's account, with
+// the IP address and user agent of the request that caused it
+type AccountActivity struct {
+	ID        string          `json:"id" db:"id"`
+	UserID    string          `json:"-" db:"user_id"`
+	EventType string          `json:"event_type" db:"event_type"`
+	IPAddress *string         `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent *string         `json:"user_agent,omitempty" db:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata" db:"metadata"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Actions recorded in an AccountPurgeReport
+const (
+	PurgeActionDeleted    = "deleted"
+	PurgeActionAnonymized = "anonymized"
+)
+
+// AccountPurgeReport lists what was removed when a deleted account's data
+// was purged
+type AccountPurgeReport struct {
+	UserID   string            `json:"user_id"`
+	PurgedAt time.Time         `json:"purged_at"`
+	Tables   []TablePurgeCount `json:"tables"`
+}
+
+// TablePurgeCount is the number of rows affected in one table
+type TablePurgeCount struct {
+	Table  string `json:"table"`
+	Action string `json:"action"`
+	Rows   int64  `json:"rows"`
+}
+
+// TotalRows sums rows affected across all tables
+func (r *AccountPurgeReport) TotalRows() int64 {
+	var total int64
+	for _, t := range r.Tables {
+		total += t.Rows
+	}
+	return total
+}
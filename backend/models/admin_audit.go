@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AdminAuditEntry records one admin mutation: who made it, the route
+// (Action, e.g. "PUT /api/v1/admin/users/:id/admin"), the concrete request
+// path it targeted and the response status. Rejected attempts are recorded
+// too.
+type AdminAuditEntry struct {
+	ID          string    `json:"id" db:"id"`
+	ActorUserID *string   `json:"actor_user_id" db:"actor_user_id"`
+	ActorEmail  *string   `json:"actor_email" db:"actor_email"`
+	Action      string    `json:"action" db:"action"`
+	Target      string    `json:"target" db:"target"`
+	StatusCode  int       `json:"status_code" db:"status_code"`
+	IPAddress   *string   `json:"ip_address,omitempty" db:"ip_address"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// SetUserAdminRequest grants or revokes a user's admin role
+type SetUserAdminRequest struct {
+	IsAdmin *bool `json:"is_admin" binding:"required"`
+}
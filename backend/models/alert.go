@@ -24,6 +24,15 @@ type AlertRule struct {
 	TriggerCount    int             `json:"trigger_count" db:"trigger_count"`
 	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+	// SnoozedUntil mutes the rule while it is in the future. Once a snooze
+	// ends it keeps the time it ended, which the notification service uses
+	// to skip events that happened while muted.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty" db:"snoozed_until"`
+}
+
+// IsSnoozed reports whether the rule is muted at now
+func (a *AlertRule) IsSnoozed(now time.Time) bool {
+	return a.SnoozedUntil != nil && a.SnoozedUntil.After(now)
 }
 
 // AlertLog represents a triggered alert instance
@@ -62,9 +71,32 @@ type VolumeCondition struct {
 	Comparison string  `json:"comparison"` // "above", "below"
 }
 
+// VolumeSpikeCondition fires when volume is volume_multiplier times, or
+// percent_above percent over, the lookback_days (30 or 90) average volume.
+// Baseline is the older way of choosing the average.
 type VolumeSpikeCondition struct {
-	VolumeMultiplier float64 `json:"volume_multiplier"`
-	Baseline         string  `json:"baseline"` // "avg_30d", "avg_90d"
+	VolumeMultiplier float64 `json:"volume_multiplier,omitempty"`
+	PercentAbove     float64 `json:"percent_above,omitempty"`
+	LookbackDays     int     `json:"lookback_days,omitempty"`
+	Baseline         string  `json:"baseline,omitempty"` // "avg_30d", "avg_90d"
+}
+
+// PECrossesCondition fires when the trailing P/E crosses Threshold since
+// the prior close.
+type PECrossesCondition struct {
+	Threshold float64 `json:"threshold"`
+	Direction string  `json:"direction"` // "above", "below", "either"
+}
+
+// MarketMoveCondition covers the market-wide index_move and sector_move
+// alert types: the index (e.g. "SPX") or sector (e.g. "Technology") moving
+// PercentChange percent on the day. Exactly one of Index and Sector is set,
+// matching the alert type.
+type MarketMoveCondition struct {
+	Index         string  `json:"index,omitempty"`
+	Sector        string  `json:"sector,omitempty"`
+	PercentChange float64 `json:"percent_change"`
+	Direction     string  `json:"direction"` // "up", "down", "either"
 }
 
 type NewsCondition struct {
@@ -75,8 +107,8 @@ type NewsCondition struct {
 // CreateAlertRuleRequest is the API request for creating alerts
 type CreateAlertRuleRequest struct {
 	WatchListID string          `json:"watch_list_id" binding:"required,max=100"`
-	Symbol      string          `json:"symbol" binding:"required,min=1,max=20"`
-	AlertType   string          `json:"alert_type" binding:"required,oneof=price_above price_below price_change volume_above volume_spike news earnings sec_filing"`
+	Symbol      string          `json:"symbol" binding:"max=20"` // set from conditions for index_move and sector_move
+	AlertType   string          `json:"alert_type" binding:"required,oneof=price_above price_below price_change pct_change volume_above volume_spike pe_crosses index_move sector_move news earnings sec_filing"`
 	Conditions  json.RawMessage `json:"conditions" binding:"required"`
 	Name        string          `json:"name" binding:"required,min=1,max=255"`
 	Description *string         `json:"description,omitempty" binding:"omitempty,max=5000"`
@@ -96,6 +128,13 @@ type UpdateAlertRuleRequest struct {
 	NotifyInApp *bool           `json:"notify_in_app,omitempty"`
 }
 
+// SnoozeAlertRequest mutes an alert rule either for a duration ("90m", "4h",
+// "7d") or until a time. Exactly one of the two is required.
+type SnoozeAlertRequest struct {
+	Duration string     `json:"duration,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
 // AlertRuleWithDetails includes related watch list info
 type AlertRuleWithDetails struct {
 	AlertRule
@@ -127,12 +166,72 @@ type BulkCreateAlertResponse struct {
 	Skipped int `json:"skipped"`
 }
 
+// AlertExportVersion is the version of the alert export format
+const AlertExportVersion = 1
+
+// AlertExport is a user's alert rules in a portable form, for keeping them
+// under version control and importing them back. Rules reference watch
+// lists by name, since IDs differ between accounts.
+type AlertExport struct {
+	Version    int                 `json:"version"`
+	ExportedAt time.Time           `json:"exported_at"`
+	Alerts     []ExportedAlertRule `json:"alerts" binding:"max=1000"`
+}
+
+// ExportedAlertRule is one alert rule of an AlertExport
+type ExportedAlertRule struct {
+	WatchList   string          `json:"watch_list"`
+	Symbol      string          `json:"symbol"`
+	AlertType   string          `json:"alert_type"`
+	Conditions  json.RawMessage `json:"conditions"`
+	Name        string          `json:"name"`
+	Description *string         `json:"description,omitempty"`
+	Frequency   string          `json:"frequency"`
+	NotifyEmail bool            `json:"notify_email"`
+	NotifyInApp bool            `json:"notify_in_app"`
+	IsActive    bool            `json:"is_active"`
+}
+
+// AlertImportResponse reports what an import did. Skipped rules duplicate
+// an existing one; Failed lists the rules that were rejected and why.
+type AlertImportResponse struct {
+	Created int                  `json:"created"`
+	Skipped int                  `json:"skipped"`
+	Failed  []AlertImportFailure `json:"failed"`
+}
+
+// AlertImportFailure is a rule an import rejected. Index is its position in
+// the imported alerts.
+type AlertImportFailure struct {
+	Index  int    `json:"index"`
+	Symbol string `json:"symbol"`
+	Error  string `json:"error"`
+}
+
+// AlertTestResult is the response of test-firing an alert rule: whether it
+// would trigger on current market data, and the values that decided it.
+// Nothing is logged or delivered.
+type AlertTestResult struct {
+	AlertID      string             `json:"alert_id"`
+	Symbol       string             `json:"symbol"`
+	AlertType    string             `json:"alert_type"`
+	WouldTrigger bool               `json:"would_trigger"`
+	Compared     map[string]float64 `json:"compared"`
+	Quote        SymbolQuote        `json:"quote"`
+	Live         bool               `json:"live"` // quote has a live price; false means the latest daily close
+	EvaluatedAt  time.Time          `json:"evaluated_at"`
+}
+
 // alertTypeLabels maps alert type identifiers to human-readable labels.
 var alertTypeLabels = map[string]string{
 	"price_above":         "Price Above",
 	"price_below":         "Price Below",
 	"price_change_pct":    "Price Change %",
 	"price_change_amount": "Price Change $",
+	"pct_change":          "Daily Move %",
+	"pe_crosses":          "P/E Crosses",
+	"index_move":          "Index Move",
+	"sector_move":         "Sector Move",
 	"volume_spike":        "Volume Spike",
 	"unusual_volume":      "Unusual Volume",
 	"volume_above":        "Volume Above",
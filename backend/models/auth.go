@@ -8,12 +8,18 @@ type SignupRequest struct {
 	Password string `json:"password" binding:"required,min=8,max=128"`
 	FullName string `json:"full_name" binding:"required,max=255"`
 	Timezone string `json:"timezone" binding:"max=100"` // Optional, defaults to UTC
+	// SkipOnboarding leaves the default watch list empty instead of seeding
+	// it with popular tickers
+	SkipOnboarding bool `json:"skip_onboarding"`
 }
 
-// LoginRequest represents login form data
+// LoginRequest represents login form data. TOTPCode is required when the
+// user has two-factor authentication enabled; a recovery code is accepted
+// in its place.
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email,max=254"`
 	Password string `json:"password" binding:"required,max=128"`
+	TOTPCode string `json:"totp_code" binding:"max=32"`
 }
 
 // AuthResponse is returned after successful login/signup
@@ -57,3 +63,29 @@ type OAuthCallbackRequest struct {
 	Code  string `form:"code" binding:"required,max=2048"`
 	State string `form:"state" binding:"required,max=512"`
 }
+
+// TwoFactorEnrollResponse carries a new TOTP secret and the otpauth:// URI
+// to render as a QR code for authenticator apps
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorEnableRequest confirms enrollment with a code from the
+// authenticator app
+type TwoFactorEnableRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// TwoFactorEnableResponse returns the recovery codes, which are shown once
+type TwoFactorEnableResponse struct {
+	Enabled       bool     `json:"enabled"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorDisableRequest turns 2FA off; it needs the password and a TOTP
+// or recovery code
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" binding:"required,max=128"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// DomainCoverage summarizes how many tickers have recent data in one data
+// domain (prices, financials, sentiment, ...)
+type DomainCoverage struct {
+	Domain         string  `json:"domain"`
+	MaxAgeDays     int     `json:"max_age_days"`    // Data older than this counts as stale
+	TotalTickers   int     `json:"total_tickers"`   // Active stocks checked
+	FreshTickers   int     `json:"fresh_tickers"`   // Tickers with data newer than MaxAgeDays
+	StaleTickers   int     `json:"stale_tickers"`   // Tickers whose latest data is too old
+	MissingTickers int     `json:"missing_tickers"` // Tickers with no data at all
+	CoveragePct    float64 `json:"coverage_pct"`    // FreshTickers / TotalTickers * 100
+	// OldestStaleTicker/At identify the stale ticker whose latest data is oldest
+	OldestStaleTicker *string    `json:"oldest_stale_ticker"`
+	OldestStaleAt     *time.Time `json:"oldest_stale_at"`
+}
@@ -73,12 +73,70 @@ type FinancialStatement struct {
 
 // FinancialPeriod represents a single period's financial data for API response
 type FinancialPeriod struct {
-	FiscalYear    int                    `json:"fiscal_year"`
-	FiscalQuarter *int                   `json:"fiscal_quarter,omitempty"`
-	PeriodEnd     string                 `json:"period_end"`
-	FiledDate     *string                `json:"filed_date,omitempty"`
-	Data          map[string]interface{} `json:"data"`
-	YoYChange     map[string]*float64    `json:"yoy_change,omitempty"`
+	FiscalYear    int                       `json:"fiscal_year"`
+	FiscalQuarter *int                      `json:"fiscal_quarter,omitempty"`
+	PeriodEnd     string                    `json:"period_end"`
+	FiledDate     *string                   `json:"filed_date,omitempty"`
+	Data          map[string]interface{}    `json:"data"`
+	YoYChange     map[string]*float64       `json:"yoy_change,omitempty"`
+	CommonSize    map[string]*float64       `json:"common_size,omitempty"` // fraction of revenue or total assets, with ?mode=common_size
+	Deltas        map[string]*LineItemDelta `json:"deltas,omitempty"`      // change vs. the prior comparable period, with ?with_deltas=true
+}
+
+// LineItemDelta is a line item's change from the same fiscal period a year
+// earlier: the prior fiscal year for annual statements, the same fiscal
+// quarter of the prior year for quarterly and TTM ones. PercentChange is a
+// fraction of the prior value's magnitude (0.12 = up 12%) and is nil when
+// the prior value is zero.
+type LineItemDelta struct {
+	Change        float64  `json:"change"`
+	PercentChange *float64 `json:"percent_change"`
+}
+
+// TTMFinancials is a trailing-twelve-month statement rolled up from the
+// latest four consecutive quarterly filings: income statement and cash flow
+// items are summed over the quarters, the balance sheet is the most recent
+// one. The fiscal year and quarter are those of the latest quarter.
+type TTMFinancials struct {
+	Ticker           string        `json:"ticker"`
+	FiscalYear       int           `json:"fiscal_year"`
+	FiscalQuarter    int           `json:"fiscal_quarter"`
+	PeriodEnd        string        `json:"period_end"`
+	Quarters         []TTMQuarter  `json:"quarters"` // newest first
+	IncomeStatement  FinancialData `json:"income_statement"`
+	CashFlow         FinancialData `json:"cash_flow"`
+	BalanceSheet     FinancialData `json:"balance_sheet"`
+	BalanceSheetDate *string       `json:"balance_sheet_date"`
+}
+
+// TTMQuarter identifies one of the quarters a TTM rollup sums
+type TTMQuarter struct {
+	FiscalYear    int    `json:"fiscal_year"`
+	FiscalQuarter int    `json:"fiscal_quarter"`
+	PeriodEnd     string `json:"period_end"`
+}
+
+// FinancialsCoverage describes the financial data on file for a ticker so
+// clients can disable tabs with no data and label the rest "data as of"
+type FinancialsCoverage struct {
+	Ticker     string              `json:"ticker"`
+	Statements []StatementCoverage `json:"statements"` // only combinations with stored periods
+	Ratios     StatementCoverage   `json:"ratios"`     // IC Score ratio history
+	// FMPRatiosAvailable reports whether FMP had TTM ratios for the ticker
+	// at the last fundamentals refresh; nil when it hasn't been refreshed
+	// yet or the stored result couldn't be read
+	FMPRatiosAvailable *bool `json:"fmp_ratios_available"`
+}
+
+// StatementCoverage summarizes the stored periods of one statement type
+// and timeframe. Periods are YYYY-MM-DD period end dates.
+type StatementCoverage struct {
+	StatementType   StatementType `json:"statement_type" db:"statement_type"`
+	Timeframe       Timeframe     `json:"timeframe" db:"timeframe"`
+	PeriodCount     int           `json:"period_count" db:"period_count"`
+	EarliestPeriod  *string       `json:"earliest_period" db:"earliest_period"`
+	LatestPeriod    *string       `json:"latest_period" db:"latest_period"`
+	LastRefreshedAt *time.Time    `json:"last_refreshed_at" db:"last_refreshed_at"`
 }
 
 // FinancialsMetadata contains company metadata for financial statements
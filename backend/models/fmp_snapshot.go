@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FMPSnapshot is the latest FMP response stored for a symbol and endpoint
+type FMPSnapshot struct {
+	Symbol      string           `json:"symbol" db:"symbol"`
+	Endpoint    string           `json:"endpoint" db:"endpoint"`
+	Data        *json.RawMessage `json:"data" db:"data"` // nil when FMP has no data for the symbol
+	RefreshedAt time.Time        `json:"refreshed_at" db:"refreshed_at"`
+}
+
+// FundamentalsRefreshRequest is the API request for a bulk FMP
+// fundamentals refresh
+type FundamentalsRefreshRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1,dive,max=20"`
+}
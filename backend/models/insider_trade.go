@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// InsiderTrade is a transaction from an SEC Form 4 (or Form 3/5) filing
+type InsiderTrade struct {
+	ID               int64     `json:"id" db:"id"`
+	Ticker           string    `json:"ticker" db:"ticker"`
+	FilingDate       time.Time `json:"filing_date" db:"filing_date"`
+	TransactionDate  time.Time `json:"transaction_date" db:"transaction_date"`
+	InsiderName      string    `json:"insider_name" db:"insider_name"`
+	InsiderTitle     string    `json:"insider_title" db:"insider_title"`
+	TransactionType  string    `json:"transaction_type" db:"transaction_type"`
+	Shares           int64     `json:"shares" db:"shares"`
+	PricePerShare    *float64  `json:"price_per_share" db:"price_per_share"`
+	TotalValue       *int64    `json:"total_value" db:"total_value"`
+	SharesOwnedAfter *int64    `json:"shares_owned_after" db:"shares_owned_after"`
+	IsDerivative     bool      `json:"is_derivative" db:"is_derivative"`
+	FormType         string    `json:"form_type" db:"form_type"`
+	SECFilingURL     string    `json:"sec_filing_url" db:"sec_filing_url"`
+}
+
+// InsiderActivityRow aggregates one insider title's open-market buys or
+// sells, as read from insider_trades before role bucketing
+type InsiderActivityRow struct {
+	InsiderTitle string  `db:"insider_title"`
+	Direction    string  `db:"direction"` // buy or sell
+	Transactions int     `db:"transactions"`
+	Shares       int64   `db:"shares"`
+	Value        float64 `db:"value"`
+}
+
+// InsiderActivitySummary is net open-market insider buying and selling
+// since a date. Net figures are bought minus sold.
+type InsiderActivitySummary struct {
+	Since            string                `json:"since"`
+	BuyTransactions  int                   `json:"buy_transactions"`
+	SellTransactions int                   `json:"sell_transactions"`
+	SharesBought     int64                 `json:"shares_bought"`
+	SharesSold       int64                 `json:"shares_sold"`
+	NetShares        int64                 `json:"net_shares"`
+	ValueBought      float64               `json:"value_bought"`
+	ValueSold        float64               `json:"value_sold"`
+	NetValue         float64               `json:"net_value"`
+	ByRole           []InsiderRoleActivity `json:"by_role"`
+}
+
+// InsiderRoleActivity is the net activity of one insider role (CEO, CFO,
+// Director, ...) within an InsiderActivitySummary
+type InsiderRoleActivity struct {
+	Role             string  `json:"role"`
+	BuyTransactions  int     `json:"buy_transactions"`
+	SellTransactions int     `json:"sell_transactions"`
+	NetShares        int64   `json:"net_shares"`
+	NetValue         float64 `json:"net_value"`
+}
@@ -32,6 +32,11 @@ type NotificationPreferences struct {
 	QuietHoursTimezone            string    `json:"quiet_hours_timezone" db:"quiet_hours_timezone"`
 	MaxAlertsPerDay               int       `json:"max_alerts_per_day" db:"max_alerts_per_day"`
 	MaxEmailsPerDay               int       `json:"max_emails_per_day" db:"max_emails_per_day"`
+	WebhookURL                    *string   `json:"webhook_url,omitempty" db:"webhook_url"`
+	WebhookSecret                 *string   `json:"-" db:"webhook_secret"` // write-only; signs webhook payloads
+	SMSEnabled                    bool      `json:"sms_enabled" db:"sms_enabled"`
+	PhoneNumber                   *string   `json:"phone_number,omitempty" db:"phone_number"` // E.164
+	PhoneVerified                 bool      `json:"phone_verified" db:"phone_verified"`
 	CreatedAt                     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt                     time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -58,6 +63,18 @@ type UpdateNotificationPreferencesRequest struct {
 	QuietHoursStart               *string `json:"quiet_hours_start,omitempty" binding:"omitempty,max=10"`
 	QuietHoursEnd                 *string `json:"quiet_hours_end,omitempty" binding:"omitempty,max=10"`
 	QuietHoursTimezone            *string `json:"quiet_hours_timezone,omitempty" binding:"omitempty,max=100"`
+	// An empty webhook_url turns webhook delivery off
+	WebhookURL    *string `json:"webhook_url,omitempty" binding:"omitempty,url,startswith=https://,max=2048"`
+	WebhookSecret *string `json:"webhook_secret,omitempty" binding:"omitempty,max=256"`
+	// SMS alerts need a plan with the sms_alerts feature and a verified
+	// phone number; an empty phone_number removes it
+	SMSEnabled  *bool   `json:"sms_enabled,omitempty"`
+	PhoneNumber *string `json:"phone_number,omitempty" binding:"omitempty,e164"`
+}
+
+// ConfirmPhoneRequest confirms a phone number with the code texted to it
+type ConfirmPhoneRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
 }
 
 // InAppNotification represents in-app notification
@@ -91,6 +108,21 @@ type DigestLog struct {
 	ContentSnapshot json.RawMessage `json:"content_snapshot,omitempty" db:"content_snapshot"`
 }
 
+// Digest types stored in digest_logs.digest_type
+const (
+	DigestTypeDaily  = "daily"
+	DigestTypeWeekly = "weekly"
+)
+
+// DigestRecipient is a user with a digest enabled, with the preferences that
+// decide when it is sent and what it includes
+type DigestRecipient struct {
+	NotificationPreferences
+	Email    string `db:"recipient_email"`
+	FullName string `db:"full_name"`
+	Timezone string `db:"user_timezone"`
+}
+
 // DigestContent represents digest email content
 type DigestContent struct {
 	UserName         string             `json:"user_name"`
@@ -103,19 +135,19 @@ type DigestContent struct {
 }
 
 type PortfolioSummary struct {
-	TotalValue    float64 `json:"total_value"`
-	DayChange     float64 `json:"day_change"`
-	DayChangePct  float64 `json:"day_change_pct"`
-	WeekChange    float64 `json:"week_change"`
-	WeekChangePct float64 `json:"week_change_pct"`
+	TotalValue    float64 `json:"total_value" db:"total_value"`
+	DayChange     float64 `json:"day_change" db:"day_change"`
+	DayChangePct  float64 `json:"day_change_pct" db:"day_change_pct"`
+	WeekChange    float64 `json:"week_change" db:"week_change"`
+	WeekChangePct float64 `json:"week_change_pct" db:"week_change_pct"`
 }
 
 type TopMover struct {
-	Symbol    string  `json:"symbol"`
-	Name      string  `json:"name"`
-	Price     float64 `json:"price"`
-	ChangePct float64 `json:"change_pct"`
-	Direction string  `json:"direction"` // "up" or "down"
+	Symbol    string  `json:"symbol" db:"symbol"`
+	Name      string  `json:"name" db:"name"`
+	Price     float64 `json:"price" db:"price"`
+	ChangePct float64 `json:"change_pct" db:"change_pct"`
+	Direction string  `json:"direction" db:"-"` // "up" or "down"
 }
 
 type NewsHighlight struct {
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Portfolio represents a user's portfolio of held positions
+type Portfolio struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PortfolioListItem for list views (without holdings)
+type PortfolioListItem struct {
+	ID           string    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	Description  *string   `json:"description" db:"description"`
+	HoldingCount int       `json:"holding_count" db:"holding_count"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PortfolioHolding represents a position in a portfolio
+type PortfolioHolding struct {
+	ID          string    `json:"id" db:"id"`
+	PortfolioID string    `json:"portfolio_id" db:"portfolio_id"`
+	Symbol      string    `json:"symbol" db:"symbol"`
+	Shares      float64   `json:"shares" db:"shares"`
+	AverageCost float64   `json:"average_cost" db:"average_cost"`
+	AddedAt     time.Time `json:"added_at" db:"added_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PortfolioHoldingWithValue includes ticker info and valuation at the latest
+// daily close. Price-derived fields are null when the symbol has no prices.
+type PortfolioHoldingWithValue struct {
+	PortfolioHolding
+	Name         string     `json:"name" db:"name"`
+	CurrentPrice *float64   `json:"current_price" db:"current_price"`
+	PriceDate    *time.Time `json:"price_date" db:"price_date"`
+	CostBasis    float64    `json:"cost_basis"`
+	MarketValue  *float64   `json:"market_value"`
+	GainLoss     *float64   `json:"gain_loss"`
+	GainLossPct  *float64   `json:"gain_loss_pct"`
+	Weight       *float64   `json:"weight"` // share of total market value, in percent
+}
+
+// PortfolioWithHoldings is the full portfolio view with computed totals.
+// Totals cover only holdings that have a current price; UnpricedCount says
+// how many were left out.
+type PortfolioWithHoldings struct {
+	Portfolio
+	Holdings         []PortfolioHoldingWithValue `json:"holdings"`
+	TotalCostBasis   float64                     `json:"total_cost_basis"`
+	TotalMarketValue float64                     `json:"total_market_value"`
+	TotalGainLoss    float64                     `json:"total_gain_loss"`
+	TotalGainLossPct *float64                    `json:"total_gain_loss_pct"`
+	UnpricedCount    int                         `json:"unpriced_count"`
+}
+
+// PortfolioValuePoint is the value of a portfolio's holdings at one day's close
+type PortfolioValuePoint struct {
+	Date  time.Time `json:"date" db:"date"`
+	Value float64   `json:"value" db:"value"`
+}
+
+// PortfolioPerformance is the daily value history of a portfolio's current
+// holdings over a period
+type PortfolioPerformance struct {
+	PortfolioID    string                `json:"portfolio_id"`
+	Period         string                `json:"period"`
+	TotalCostBasis float64               `json:"total_cost_basis"`
+	Points         []PortfolioValuePoint `json:"points"`
+	StartValue     *float64              `json:"start_value"`
+	EndValue       *float64              `json:"end_value"`
+	Change         *float64              `json:"change"`
+	ChangePct      *float64              `json:"change_pct"`
+}
+
+// Request/Response DTOs
+
+// CreatePortfolioRequest for creating a new portfolio
+type CreatePortfolioRequest struct {
+	Name        string  `json:"name" binding:"required,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=5000"`
+}
+
+// UpdatePortfolioRequest for updating portfolio metadata
+type UpdatePortfolioRequest struct {
+	Name        string  `json:"name" binding:"required,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=5000"`
+}
+
+// AddHoldingRequest for adding a position to a portfolio
+type AddHoldingRequest struct {
+	Symbol      string  `json:"symbol" binding:"required,min=1,max=20"`
+	Shares      float64 `json:"shares" binding:"required,gt=0"`
+	AverageCost float64 `json:"average_cost" binding:"gte=0"`
+}
+
+// UpdateHoldingRequest for updating a position
+type UpdateHoldingRequest struct {
+	Shares      float64 `json:"shares" binding:"required,gt=0"`
+	AverageCost float64 `json:"average_cost" binding:"gte=0"`
+}
+
+// Portfolio transaction types
+const (
+	TransactionTypeBuy  = "BUY"
+	TransactionTypeSell = "SELL"
+)
+
+// PortfolioTransaction is a buy or sell of shares in a portfolio. A symbol's
+// transactions are the source of truth for its lots; the holding row caches
+// the resulting shares and average cost.
+type PortfolioTransaction struct {
+	ID              string          `json:"id" db:"id"`
+	PortfolioID     string          `json:"portfolio_id" db:"portfolio_id"`
+	Symbol          string          `json:"symbol" db:"symbol"`
+	TransactionType string          `json:"transaction_type" db:"transaction_type"`
+	Shares          decimal.Decimal `json:"shares" db:"shares"`
+	Price           decimal.Decimal `json:"price" db:"price"`
+	ExecutedAt      time.Time       `json:"executed_at" db:"executed_at"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	// RealizedGain is computed for sells by FIFO lot matching
+	RealizedGain *decimal.Decimal `json:"realized_gain,omitempty" db:"-"`
+}
+
+// HoldingPerformance is one position's cost basis and unrealized gain at the
+// latest price. Price-derived fields are null when the symbol has no price.
+type HoldingPerformance struct {
+	Symbol            string           `json:"symbol"`
+	Shares            decimal.Decimal  `json:"shares"`
+	AverageCost       decimal.Decimal  `json:"average_cost"`
+	CostBasis         decimal.Decimal  `json:"cost_basis"`
+	CurrentPrice      *decimal.Decimal `json:"current_price"`
+	MarketValue       *decimal.Decimal `json:"market_value"`
+	UnrealizedGain    *decimal.Decimal `json:"unrealized_gain"`
+	UnrealizedGainPct *decimal.Decimal `json:"unrealized_gain_pct"`
+}
+
+// PortfolioHoldingsPerformance aggregates HoldingPerformance across a
+// portfolio. Unrealized totals cover only priced holdings.
+type PortfolioHoldingsPerformance struct {
+	Holdings            []HoldingPerformance `json:"holdings"`
+	TotalCostBasis      decimal.Decimal      `json:"total_cost_basis"`
+	TotalMarketValue    decimal.Decimal      `json:"total_market_value"`
+	TotalUnrealizedGain decimal.Decimal      `json:"total_unrealized_gain"`
+	TotalRealizedGain   decimal.Decimal      `json:"total_realized_gain"`
+	UnpricedCount       int                  `json:"unpriced_count"`
+}
+
+// PortfolioTransactionHistory is a portfolio's transactions, newest first,
+// with the performance of its open positions
+type PortfolioTransactionHistory struct {
+	Transactions []PortfolioTransaction       `json:"transactions"`
+	Performance  PortfolioHoldingsPerformance `json:"performance"`
+}
+
+// CreateTransactionRequest for recording a buy or sell. Shares and price are
+// decimals so fractional shares round-trip exactly.
+type CreateTransactionRequest struct {
+	Symbol          string          `json:"symbol" binding:"required,min=1,max=20"`
+	TransactionType string          `json:"transaction_type" binding:"required,oneof=BUY SELL buy sell"`
+	Shares          decimal.Decimal `json:"shares"`
+	Price           decimal.Decimal `json:"price"`
+	ExecutedAt      *time.Time      `json:"executed_at"`
+}
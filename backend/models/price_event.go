@@ -1,7 +1,15 @@
 package models
 
+// PriceUpdateIdempotencyKey is the SNS message attribute carrying a price
+// update's idempotency key, the hex SHA-256 of its body. The notification
+// service handles each key once, so a redelivered or republished copy
+// doesn't fire alerts twice.
+const PriceUpdateIdempotencyKey = "idempotency_key"
+
 // PriceUpdateMessage is published to SNS every ~5 seconds during market hours.
 // The notification Lambda subscribes to evaluate alert rules against live prices.
+// Each snapshot is split across messages of up to 500 symbols that share its
+// timestamp.
 type PriceUpdateMessage struct {
 	Timestamp int64                  `json:"timestamp"`
 	Source    string                 `json:"source"` // "polygon_snapshot"
@@ -10,8 +18,23 @@ type PriceUpdateMessage struct {
 
 // SymbolQuote is a lightweight price snapshot for a single symbol,
 // used inside PriceUpdateMessage for SNS delivery.
+//
+// Fields each alert type reads in the notification service:
+//
+//	price_above, price_below       price
+//	price_change_pct, pct_change   change_pct
+//	index_move, sector_move        change_pct
+//	volume_spike                   volume, avg_volume_30d or avg_volume_90d
+//	pe_crosses                     pe_ratio, change_pct
+//
+// volume_spike and pe_crosses are also evaluated daily from stored data, so
+// publishers may leave their fields unset; those alerts then fire only on
+// the daily pass.
 type SymbolQuote struct {
-	Price     float64 `json:"price"`
-	Volume    int64   `json:"volume"`
-	ChangePct float64 `json:"change_pct"`
+	Price        float64 `json:"price"`
+	Volume       int64   `json:"volume"`
+	ChangePct    float64 `json:"change_pct"`               // change vs prior close, in percent
+	AvgVolume30d float64 `json:"avg_volume_30d,omitempty"` // average daily volume, 30 sessions
+	AvgVolume90d float64 `json:"avg_volume_90d,omitempty"` // average daily volume, 90 sessions
+	PERatio      float64 `json:"pe_ratio,omitempty"`       // trailing P/E at price; 0 if unknown or unprofitable
 }
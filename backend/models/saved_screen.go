@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// SavedScreen is a user's named set of screener criteria
+type SavedScreen struct {
+	ID        string         `json:"id"`
+	UserID    string         `json:"user_id"`
+	Name      string         `json:"name"`
+	Params    ScreenerParams `json:"params"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// SaveScreenRequest creates a saved screen or replaces one's name and
+// criteria. Params uses the screener's query parameter names (sectors is
+// a list; range filters are numbers or null).
+type SaveScreenRequest struct {
+	Name   string         `json:"name" binding:"required,min=1,max=255"`
+	Params ScreenerParams `json:"params"`
+}
@@ -12,8 +12,41 @@ const (
 	SortByEngagement SocialPostSortOption = "engagement"
 	SortByBullish    SocialPostSortOption = "bullish"
 	SortByBearish    SocialPostSortOption = "bearish"
+	SortByUpvotes    SocialPostSortOption = "upvotes"
+	SortByConfidence SocialPostSortOption = "confidence"
 )
 
+// ValidPostSources lists the social sources accepted by the posts endpoint
+var ValidPostSources = map[string]bool{
+	"reddit":     true,
+	"stocktwits": true,
+}
+
+// TickerPostsQuery holds the filter, sort and pagination options for
+// GET /api/sentiment/:ticker/posts
+type TickerPostsQuery struct {
+	Sort      SocialPostSortOption
+	Source    string // "" for all sources
+	Subreddit string // "" for all subreddits
+	Limit     int
+	Offset    int
+}
+
+// PostCountOptions narrows and weights post counts used for trending
+// signals. The zero value counts every post once.
+type PostCountOptions struct {
+	MinUpvotes  int
+	MinComments int
+	// EngagementWeighted sums a log-scaled engagement weight per post
+	// instead of counting each post once
+	EngagementWeighted bool
+}
+
+// IsZero reports whether no filter or weighting is set
+func (o PostCountOptions) IsZero() bool {
+	return o.MinUpvotes <= 0 && o.MinComments <= 0 && !o.EngagementWeighted
+}
+
 // SentimentLexiconTerm represents a term in the sentiment lexicon
 type SentimentLexiconTerm struct {
 	ID        int       `json:"id" db:"id"`
@@ -90,6 +123,9 @@ type SentimentHistoryResponse struct {
 	Ticker  string                  `json:"ticker"`
 	Period  string                  `json:"period"` // "7d", "30d", "90d"
 	History []SentimentHistoryPoint `json:"history"`
+	// WeightedHistory is the engagement-weighted daily series, only
+	// populated when the request sets weighted=true.
+	WeightedHistory []SentimentHistoryPoint `json:"weighted_history,omitempty"`
 }
 
 // TrendingTicker represents a ticker in the trending list
@@ -101,6 +137,9 @@ type TrendingTicker struct {
 	PostCount    int     `json:"post_count"`             // Posts in period
 	MentionDelta float64 `json:"mention_delta"`          // % change from previous period
 	Rank         int     `json:"rank"`
+	// WeightedCount is the engagement-weighted post count, only populated
+	// when the request sets weighted=true
+	WeightedCount float64 `json:"weighted_count,omitempty"`
 }
 
 // TrendingResponse for GET /api/sentiment/trending
@@ -132,7 +171,9 @@ type RepresentativePostsResponse struct {
 	Ticker string               `json:"ticker"`
 	Posts  []RepresentativePost `json:"posts"`
 	Total  int                  `json:"total"`
-	Sort   string               `json:"sort"` // Sort option used: recent, engagement, bullish, bearish
+	Sort   string               `json:"sort"` // Sort option used: recent, engagement, upvotes, confidence, bullish, bearish
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
 }
 
 // GetSentimentLabel converts a sentiment score to a human-readable label
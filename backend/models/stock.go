@@ -37,6 +37,76 @@ type Stock struct {
 	LogoURL             string `json:"logoUrl,omitempty" db:"logo_url"`
 }
 
+// SecuritySearchResult is a ticker matched by search, with whether the
+// query matched its symbol or its name
+type SecuritySearchResult struct {
+	Stock
+	MatchReason string `json:"matchReason" db:"match_reason"`
+}
+
+// SearchSuggestion is a compact search hit enriched with the latest daily
+// price, used by autocomplete. Price fields are nil when no price data exists.
+type SearchSuggestion struct {
+	Symbol        string   `json:"symbol" db:"symbol"`
+	Name          string   `json:"name" db:"name"`
+	Exchange      string   `json:"exchange" db:"exchange"`
+	AssetType     string   `json:"type" db:"asset_type"`
+	LogoURL       string   `json:"logoUrl,omitempty" db:"logo_url"`
+	Price         *float64 `json:"price" db:"price"`
+	Change        *float64 `json:"change" db:"change"`
+	ChangePercent *float64 `json:"changePercent" db:"change_percent"`
+}
+
+// TickerMetadata is the descriptive subset of a ticker used to render lists
+type TickerMetadata struct {
+	Symbol    string `json:"symbol" db:"symbol"`
+	Name      string `json:"name" db:"name"`
+	Exchange  string `json:"exchange" db:"exchange"`
+	Sector    string `json:"sector" db:"sector"`
+	Industry  string `json:"industry" db:"industry"`
+	LogoURL   string `json:"logoUrl" db:"logo_url"`
+	AssetType string `json:"assetType" db:"asset_type"`
+}
+
+// TickerPeer is a comparable company for a ticker's peers list. Price fields
+// come from the latest two daily closes and are nil without price data.
+type TickerPeer struct {
+	Symbol        string   `json:"symbol" db:"symbol"`
+	Name          string   `json:"name" db:"name"`
+	MarketCap     *float64 `json:"marketCap" db:"market_cap"`
+	PERatio       *float64 `json:"peRatio" db:"pe_ratio"`
+	Price         *float64 `json:"price" db:"price"`
+	Change        *float64 `json:"change" db:"change"`
+	ChangePercent *float64 `json:"changePercent" db:"change_percent"`
+}
+
+// TickerMetadataRequest is the API request for batch ticker metadata
+type TickerMetadataRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1,dive,max=20"`
+}
+
+// TickerBatchRequest is the API request for batch ticker summaries
+type TickerBatchRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1,dive,max=20"`
+}
+
+// TickerSummary is the compact ticker payload used to render watchlists.
+// Price fields come from the latest two daily closes and are nil without
+// price data.
+type TickerSummary struct {
+	Symbol        string   `json:"symbol" db:"symbol"`
+	Name          string   `json:"name" db:"name"`
+	MarketCap     *float64 `json:"marketCap" db:"market_cap"`
+	Price         *float64 `json:"price" db:"price"`
+	Change        *float64 `json:"change" db:"change"`
+	ChangePercent *float64 `json:"changePercent" db:"change_percent"`
+}
+
+// PriceRefreshRequest is the API request for a bulk price refresh
+type PriceRefreshRequest struct {
+	Symbols []string `json:"symbols" binding:"required,min=1,dive,max=20"`
+}
+
 // StockPrice represents current and historical price data
 type StockPrice struct {
 	Symbol        string          `json:"symbol" db:"symbol"`
@@ -408,6 +478,22 @@ type ScreenerParams struct {
 	SentimentScoreMax       *float64 `json:"sentiment_score_max"`
 	TechnicalScoreMin       *float64 `json:"technical_score_min"`
 	TechnicalScoreMax       *float64 `json:"technical_score_max"`
+
+	// Sector-relative percentile filters (0-100): where the value falls in
+	// its sector's distribution. Only applied when SectorRelative is set.
+	SectorRelative             bool     `json:"sector_relative"`
+	PEPercentileMin            *float64 `json:"pe_percentile_min"`
+	PEPercentileMax            *float64 `json:"pe_percentile_max"`
+	PBPercentileMin            *float64 `json:"pb_percentile_min"`
+	PBPercentileMax            *float64 `json:"pb_percentile_max"`
+	PSPercentileMin            *float64 `json:"ps_percentile_min"`
+	PSPercentileMax            *float64 `json:"ps_percentile_max"`
+	RevenueGrowthPercentileMin *float64 `json:"revenue_growth_percentile_min"`
+	RevenueGrowthPercentileMax *float64 `json:"revenue_growth_percentile_max"`
+	EPSGrowthPercentileMin     *float64 `json:"eps_growth_percentile_min"`
+	EPSGrowthPercentileMax     *float64 `json:"eps_growth_percentile_max"`
+	DividendYieldPercentileMin *float64 `json:"dividend_yield_percentile_min"`
+	DividendYieldPercentileMax *float64 `json:"dividend_yield_percentile_max"`
 }
 
 // ScreenerResponse represents the paginated response for the screener endpoint
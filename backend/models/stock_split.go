@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// StockSplit is a stock split (or reverse split) for a ticker.
+// SplitFrom:SplitTo is the share ratio: a 4-for-1 split is 1:4 and a
+// 1-for-10 reverse split is 10:1.
+type StockSplit struct {
+	ID            int       `json:"id" db:"id"`
+	Ticker        string    `json:"ticker" db:"ticker"`
+	ExecutionDate time.Time `json:"execution_date" db:"execution_date"`
+	SplitFrom     float64   `json:"split_from" db:"split_from"`
+	SplitTo       float64   `json:"split_to" db:"split_to"`
+	Source        string    `json:"source" db:"source"`
+}
+
+// Ratio is the number of new shares per old share (4 for a 4-for-1 split,
+// 0.1 for a 1-for-10 reverse split)
+func (s StockSplit) Ratio() float64 {
+	if s.SplitFrom == 0 {
+		return 1
+	}
+	return s.SplitTo / s.SplitFrom
+}
+
+// IsReverse reports whether the split reduced the share count
+func (s StockSplit) IsReverse() bool {
+	return s.SplitTo < s.SplitFrom
+}
@@ -17,6 +17,7 @@ type SubscriptionPlan struct {
 	MaxItemsPerWatchList int             `json:"max_items_per_watch_list" db:"max_items_per_watch_list"`
 	MaxAlertRules        int             `json:"max_alert_rules" db:"max_alert_rules"`
 	MaxHeatmapConfigs    int             `json:"max_heatmap_configs" db:"max_heatmap_configs"`
+	MaxSavedScreens      int             `json:"max_saved_screens" db:"max_saved_screens"`
 	Features             json.RawMessage `json:"features" db:"features"`
 	IsActive             bool            `json:"is_active" db:"is_active"`
 	CreatedAt            time.Time       `json:"created_at" db:"created_at"`
@@ -70,6 +71,7 @@ type UserSubscriptionWithPlan struct {
 	MaxItemsPerWatchList int             `json:"max_items_per_watch_list"`
 	MaxAlertRules        int             `json:"max_alert_rules"`
 	MaxHeatmapConfigs    int             `json:"max_heatmap_configs"`
+	MaxSavedScreens      int             `json:"max_saved_screens"`
 }
 
 // CreateSubscriptionRequest for initiating a subscription
@@ -92,5 +94,23 @@ type SubscriptionLimits struct {
 	MaxItemsPerWatchList int             `json:"max_items_per_watch_list"`
 	MaxAlertRules        int             `json:"max_alert_rules"`
 	MaxHeatmapConfigs    int             `json:"max_heatmap_configs"`
+	MaxSavedScreens      int             `json:"max_saved_screens"`
 	Features             json.RawMessage `json:"features"`
 }
+
+// FeatureSMSAlerts is the plan feature that allows SMS alert delivery
+const FeatureSMSAlerts = "sms_alerts"
+
+// HasFeature reports whether the subscription is active (or trialing) on a
+// plan whose features turn feature on
+func (s *UserSubscriptionWithPlan) HasFeature(feature string) bool {
+	if s.Status != "active" && s.Status != "trialing" {
+		return false
+	}
+	var features map[string]interface{}
+	if err := json.Unmarshal(s.PlanFeatures, &features); err != nil {
+		return false
+	}
+	enabled, _ := features[feature].(bool)
+	return enabled
+}
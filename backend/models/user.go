@@ -68,6 +68,13 @@ type Session struct {
 	LastUsedAt       time.Time `json:"last_used_at" db:"last_used_at"`
 	UserAgent        *string   `json:"user_agent" db:"user_agent"`
 	IPAddress        *string   `json:"ip_address" db:"ip_address"`
+	// FamilyID is shared by a login's session and the sessions rotated
+	// from it; RotatedAt is set once its refresh token has been exchanged
+	FamilyID  string     `json:"-" db:"family_id"`
+	RotatedAt *time.Time `json:"-" db:"rotated_at"`
+	// SuccessorToken is the refresh token this session was rotated into,
+	// sealed with auth.SealRefreshSuccessor under the rotated token
+	SuccessorToken *string `json:"-" db:"successor_token"`
 }
 
 // OAuthProvider represents a linked OAuth account
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// UserDataExport is everything stored about a user, returned as a download
+// by GET /api/v1/user/export. Credentials (password hash, refresh token
+// hashes, verification/reset tokens, 2FA secrets and recovery codes, OAuth
+// tokens, phone verification codes) and payment-provider IDs are never
+// included.
+type UserDataExport struct {
+	ExportedAt              time.Time                 `json:"exported_at"`
+	Profile                 UserPublic                `json:"profile"`
+	WatchLists              []WatchListExport         `json:"watch_lists"`
+	AlertRules              []AlertRuleWithDetails    `json:"alert_rules"`
+	AlertHistory            []AlertLogWithRule        `json:"alert_history"`
+	Notifications           []InAppNotification       `json:"notifications"`
+	NotificationPreferences *NotificationPreferences  `json:"notification_preferences"`
+	PhoneVerification       *PhoneVerificationExport  `json:"phone_verification"`
+	Digests                 []DigestLogExport         `json:"digests"`
+	Portfolios              []PortfolioExport         `json:"portfolios"`
+	SavedScreens            []SavedScreen             `json:"saved_screens"`
+	Searches                []UserSearch              `json:"searches"`
+	Backtests               []BacktestJob             `json:"backtests"`
+	Sessions                []SessionExport           `json:"sessions"`
+	AccountActivity         []AccountActivity         `json:"account_activity"`
+	TwoFactor               *TwoFactorExport          `json:"two_factor"`
+	LinkedAccounts          []LinkedAccountExport     `json:"linked_accounts"`
+	Subscription            *UserSubscriptionWithPlan `json:"subscription"`
+	Payments                []PaymentHistory          `json:"payments"`
+}
+
+// WatchListExport is a watch list together with its items and heatmap
+// configurations
+type WatchListExport struct {
+	WatchListSummary
+	Items          []WatchListItem `json:"items"`
+	HeatmapConfigs []HeatmapConfig `json:"heatmap_configs"`
+}
+
+// PortfolioExport is a portfolio together with its holdings and transactions
+type PortfolioExport struct {
+	PortfolioListItem
+	Holdings     []PortfolioHolding     `json:"holdings"`
+	Transactions []PortfolioTransaction `json:"transactions"`
+}
+
+// SessionExport is a login session without its refresh token hash
+type SessionExport struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  *string   `json:"user_agent"`
+	IPAddress  *string   `json:"ip_address"`
+}
+
+// TwoFactorExport is a user's 2FA enrollment without its secret or
+// recovery codes
+type TwoFactorExport struct {
+	Enabled   bool       `json:"enabled" db:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at" db:"enabled_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PhoneVerificationExport is a pending phone number confirmation without
+// its code hash
+type PhoneVerificationExport struct {
+	PhoneNumber string    `json:"phone_number" db:"phone_number"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// DigestLogExport is a daily or weekly digest sent to the user
+type DigestLogExport struct {
+	DigestType  string     `json:"digest_type" db:"digest_type"`
+	PeriodStart time.Time  `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end" db:"period_end"`
+	SentAt      *time.Time `json:"sent_at" db:"sent_at"`
+	EmailSent   bool       `json:"email_sent" db:"email_sent"`
+}
+
+// LinkedAccountExport is an OAuth sign-in linked to the user, without its
+// access or refresh tokens
+type LinkedAccountExport struct {
+	Provider       string    `json:"provider" db:"provider"`
+	ProviderUserID string    `json:"provider_user_id" db:"provider_user_id"`
+	ProviderEmail  *string   `json:"provider_email" db:"provider_email"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
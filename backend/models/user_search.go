@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UserSearch is a query in a user's recent or pinned search history
+type UserSearch struct {
+	ID          string    `json:"id" db:"id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Query       string    `json:"query" db:"query"`
+	Pinned      bool      `json:"pinned" db:"pinned"`
+	SearchCount int       `json:"search_count" db:"search_count"`
+	SearchedAt  time.Time `json:"searched_at" db:"searched_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// RecordUserSearchRequest is the API request for saving or pinning a search
+type RecordUserSearchRequest struct {
+	Query  string `json:"query" binding:"required,max=100"`
+	Pinned *bool  `json:"pinned,omitempty"`
+}
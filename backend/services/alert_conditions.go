@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"investorcenter-api/models"
+)
+
+// ErrInvalidAlertConditions is returned when an alert rule's conditions
+// don't match the schema its alert type is evaluated with.
+var ErrInvalidAlertConditions = errors.New("invalid alert conditions")
+
+// ValidateAlertConditions checks conditions against the schema the
+// notification service evaluates alertType with. Types without a typed
+// schema only need a JSON object.
+func ValidateAlertConditions(alertType string, conditions json.RawMessage) error {
+	var conditionsMap map[string]interface{}
+	if err := json.Unmarshal(conditions, &conditionsMap); err != nil || conditionsMap == nil {
+		return fmt.Errorf("%w: conditions must be a JSON object", ErrInvalidAlertConditions)
+	}
+
+	switch alertType {
+	case "volume_spike":
+		var cond models.VolumeSpikeCondition
+		if err := json.Unmarshal(conditions, &cond); err != nil {
+			return invalidConditions(alertType, err.Error())
+		}
+		switch {
+		case cond.VolumeMultiplier == 0 && cond.PercentAbove == 0:
+			return invalidConditions(alertType, "volume_multiplier or percent_above is required")
+		case cond.VolumeMultiplier != 0 && cond.PercentAbove != 0:
+			return invalidConditions(alertType, "set only one of volume_multiplier and percent_above")
+		case cond.VolumeMultiplier < 0 || (cond.VolumeMultiplier > 0 && cond.VolumeMultiplier <= 1):
+			return invalidConditions(alertType, "volume_multiplier must be greater than 1")
+		case cond.PercentAbove < 0:
+			return invalidConditions(alertType, "percent_above must be positive")
+		}
+		if cond.LookbackDays != 0 && cond.LookbackDays != 30 && cond.LookbackDays != 90 {
+			return invalidConditions(alertType, "lookback_days must be 30 or 90")
+		}
+		if cond.Baseline != "" && cond.Baseline != "avg_30d" && cond.Baseline != "avg_90d" {
+			return invalidConditions(alertType, "baseline must be avg_30d or avg_90d")
+		}
+
+	case "pct_change":
+		var cond models.PriceChangeCondition
+		if err := json.Unmarshal(conditions, &cond); err != nil {
+			return invalidConditions(alertType, err.Error())
+		}
+		if cond.PercentChange <= 0 {
+			return invalidConditions(alertType, "percent_change must be positive")
+		}
+		if !oneOf(cond.Direction, "", "up", "down", "either") {
+			return invalidConditions(alertType, "direction must be up, down or either")
+		}
+
+	case "index_move", "sector_move":
+		if _, err := MarketAlertSymbol(alertType, conditions); err != nil {
+			return err
+		}
+		var cond models.MarketMoveCondition
+		_ = json.Unmarshal(conditions, &cond)
+		if cond.PercentChange <= 0 {
+			return invalidConditions(alertType, "percent_change must be positive")
+		}
+		if !oneOf(cond.Direction, "", "up", "down", "either") {
+			return invalidConditions(alertType, "direction must be up, down or either")
+		}
+
+	case "pe_crosses":
+		var cond models.PECrossesCondition
+		if err := json.Unmarshal(conditions, &cond); err != nil {
+			return invalidConditions(alertType, err.Error())
+		}
+		if cond.Threshold <= 0 {
+			return invalidConditions(alertType, "threshold must be positive")
+		}
+		if !oneOf(cond.Direction, "", "above", "below", "either") {
+			return invalidConditions(alertType, "direction must be above, below or either")
+		}
+	}
+	return nil
+}
+
+func invalidConditions(alertType, reason string) error {
+	return fmt.Errorf("%w for %s: %s", ErrInvalidAlertConditions, alertType, reason)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidateAlertConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		alertType  string
+		conditions string
+		wantErr    bool
+	}{
+		{"untyped alert needs only an object", "news", `{"keywords":["merger"]}`, false},
+		{"not an object", "price_above", `[1,2]`, true},
+		{"null conditions", "price_above", `null`, true},
+
+		{"volume spike multiplier", "volume_spike", `{"volume_multiplier":2,"baseline":"avg_30d"}`, false},
+		{"volume spike percent over lookback", "volume_spike", `{"percent_above":150,"lookback_days":90}`, false},
+		{"volume spike needs a size", "volume_spike", `{"lookback_days":30}`, true},
+		{"volume spike with both sizes", "volume_spike", `{"volume_multiplier":2,"percent_above":100}`, true},
+		{"volume spike multiplier at average", "volume_spike", `{"volume_multiplier":1}`, true},
+		{"volume spike negative percent", "volume_spike", `{"percent_above":-10}`, true},
+		{"volume spike unsupported lookback", "volume_spike", `{"percent_above":50,"lookback_days":10}`, true},
+		{"volume spike unknown baseline", "volume_spike", `{"volume_multiplier":2,"baseline":"avg_1y"}`, true},
+		{"volume spike wrong field type", "volume_spike", `{"volume_multiplier":"2"}`, true},
+
+		{"daily move", "pct_change", `{"percent_change":5,"direction":"down"}`, false},
+		{"daily move default direction", "pct_change", `{"percent_change":5}`, false},
+		{"daily move zero", "pct_change", `{"percent_change":0}`, true},
+		{"daily move bad direction", "pct_change", `{"percent_change":5,"direction":"sideways"}`, true},
+
+		{"index move", "index_move", `{"index":"SPX","percent_change":3,"direction":"down"}`, false},
+		{"index move lowercase index", "index_move", `{"index":"comp","percent_change":2}`, false},
+		{"index move unknown index", "index_move", `{"index":"FTSE","percent_change":3}`, true},
+		{"index move with sector", "index_move", `{"index":"SPX","sector":"Energy","percent_change":3}`, true},
+		{"index move zero", "index_move", `{"index":"SPX","percent_change":0}`, true},
+		{"sector move", "sector_move", `{"sector":"Technology","percent_change":2,"direction":"either"}`, false},
+		{"sector move unknown sector", "sector_move", `{"sector":"Crypto","percent_change":2}`, true},
+		{"sector move bad direction", "sector_move", `{"sector":"Energy","percent_change":2,"direction":"sideways"}`, true},
+
+		{"pe crosses", "pe_crosses", `{"threshold":25,"direction":"above"}`, false},
+		{"pe crosses either", "pe_crosses", `{"threshold":25}`, false},
+		{"pe crosses missing threshold", "pe_crosses", `{"direction":"below"}`, true},
+		{"pe crosses bad direction", "pe_crosses", `{"threshold":25,"direction":"up"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAlertConditions(tt.alertType, json.RawMessage(tt.conditions))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidAlertConditions) {
+				t.Errorf("expected ErrInvalidAlertConditions, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMarketAlertSymbol(t *testing.T) {
+	symbol, err := MarketAlertSymbol("index_move", json.RawMessage(`{"index":"spx","percent_change":3}`))
+	if err != nil || symbol != "SPY" {
+		t.Errorf("index_move SPX = (%q, %v), want SPY", symbol, err)
+	}
+	symbol, err = MarketAlertSymbol("sector_move", json.RawMessage(`{"sector":"Real Estate","percent_change":2}`))
+	if err != nil || symbol != "XLRE" {
+		t.Errorf("sector_move Real Estate = (%q, %v), want XLRE", symbol, err)
+	}
+	if _, err := MarketAlertSymbol("pct_change", json.RawMessage(`{"percent_change":2}`)); err == nil {
+		t.Error("expected an error for a per-ticker alert type")
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"investorcenter-api/models"
+	"investorcenter-shared/alertcond"
+)
+
+// ErrAlertNotTestable is returned for alert types that aren't decided by a
+// market data quote (news, earnings, IC Score, dividends, ...).
+var ErrAlertNotTestable = errors.New("alert type can't be test-fired against market data")
+
+// AlertEvaluation is an alertcond.Result as the test-fire endpoint
+// returns it.
+type AlertEvaluation struct {
+	WouldTrigger bool               `json:"would_trigger"`
+	Compared     map[string]float64 `json:"compared"`
+}
+
+// EvaluateAlertCondition checks an alert's condition against a quote with
+// the shared alertcond rules the notification service fires alerts with.
+func EvaluateAlertCondition(alert *models.AlertRule, quote *models.SymbolQuote) (*AlertEvaluation, error) {
+	result, err := alertcond.Evaluate(alert.AlertType, alert.Conditions, alertcond.Quote{
+		Price:        quote.Price,
+		Volume:       quote.Volume,
+		ChangePct:    quote.ChangePct,
+		AvgVolume30d: quote.AvgVolume30d,
+		AvgVolume90d: quote.AvgVolume90d,
+		PERatio:      quote.PERatio,
+	})
+	if errors.Is(err, alertcond.ErrNotQuoteEvaluated) {
+		return nil, fmt.Errorf("%w: %s", ErrAlertNotTestable, alert.AlertType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &AlertEvaluation{WouldTrigger: result.Triggered, Compared: result.Compared}, nil
+}
+
+// ApplyLiveQuote overlays a live quote's price, volume and daily change on
+// an end-of-day quote, rescaling the trailing P/E to the live price.
+func ApplyLiveQuote(quote *models.SymbolQuote, live *models.StockPrice) {
+	price, _ := live.Price.Float64()
+	if price <= 0 {
+		return
+	}
+	if quote.PERatio > 0 && quote.Price > 0 {
+		quote.PERatio = quote.PERatio * price / quote.Price
+	}
+	quote.Price = price
+	quote.Volume = live.Volume
+	quote.ChangePct, _ = live.ChangePercent.Float64()
+}
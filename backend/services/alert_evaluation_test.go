@@ -0,0 +1,80 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+func TestEvaluateAlertCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		alertType string
+		cond      string
+		quote     models.SymbolQuote
+		triggered bool
+		compared  map[string]float64
+	}{
+		{"price_above", "price_above", `{"threshold":150}`, models.SymbolQuote{Price: 151},
+			true, map[string]float64{"price": 151, "threshold": 150}},
+		{"price_below not met", "price_below", `{"threshold":150}`, models.SymbolQuote{Price: 151},
+			false, map[string]float64{"price": 151, "threshold": 150}},
+		{"pct_change", "pct_change", `{"percent_change":5}`, models.SymbolQuote{ChangePct: -3},
+			false, map[string]float64{"change_pct": -3, "percent_change": 5}},
+		{"pct_change down", "pct_change", `{"percent_change":5,"direction":"down"}`, models.SymbolQuote{ChangePct: -6},
+			true, map[string]float64{"change_pct": -6, "percent_change": 5}},
+		{"volume_spike", "volume_spike", `{"percent_above":100,"lookback_days":90}`, models.SymbolQuote{Volume: 500, AvgVolume90d: 200},
+			true, map[string]float64{"volume": 500, "baseline_volume": 200, "required_volume": 400}},
+		{"volume_spike without history", "volume_spike", `{"volume_multiplier":2}`, models.SymbolQuote{Volume: 500},
+			false, map[string]float64{"volume": 500, "baseline_volume": 0, "required_volume": 0}},
+		{"pe_crosses", "pe_crosses", `{"threshold":22}`, models.SymbolQuote{ChangePct: 25, PERatio: 25},
+			true, map[string]float64{"pe_ratio": 25, "prior_pe_ratio": 20, "threshold": 22}},
+		{"pe_crosses without P/E", "pe_crosses", `{"threshold":22}`, models.SymbolQuote{ChangePct: 25},
+			false, map[string]float64{"pe_ratio": 0, "threshold": 22}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &models.AlertRule{AlertType: tt.alertType, Conditions: json.RawMessage(tt.cond)}
+			eval, err := EvaluateAlertCondition(alert, &tt.quote)
+			require.NoError(t, err)
+			assert.Equal(t, tt.triggered, eval.WouldTrigger)
+			assert.Equal(t, tt.compared, eval.Compared)
+		})
+	}
+}
+
+func TestEvaluateAlertCondition_Errors(t *testing.T) {
+	_, err := EvaluateAlertCondition(&models.AlertRule{AlertType: "news", Conditions: json.RawMessage(`{}`)}, &models.SymbolQuote{})
+	assert.True(t, errors.Is(err, ErrAlertNotTestable))
+
+	_, err = EvaluateAlertCondition(&models.AlertRule{AlertType: "price_above", Conditions: json.RawMessage(`{"threshold":0}`)}, &models.SymbolQuote{})
+	assert.Error(t, err)
+
+	_, err = EvaluateAlertCondition(&models.AlertRule{AlertType: "volume_spike", Conditions: json.RawMessage(`{`)}, &models.SymbolQuote{})
+	assert.Error(t, err)
+}
+
+func TestApplyLiveQuote(t *testing.T) {
+	quote := &models.SymbolQuote{Price: 100, Volume: 1_000, ChangePct: 1, AvgVolume30d: 900, PERatio: 20}
+	ApplyLiveQuote(quote, &models.StockPrice{
+		Price:         decimal.NewFromFloat(110),
+		Volume:        2_500,
+		ChangePercent: decimal.NewFromFloat(10),
+	})
+
+	assert.Equal(t, 110.0, quote.Price)
+	assert.Equal(t, int64(2_500), quote.Volume)
+	assert.Equal(t, 10.0, quote.ChangePct)
+	assert.InDelta(t, 22.0, quote.PERatio, 1e-9, "P/E rescaled to the live price")
+	assert.Equal(t, 900.0, quote.AvgVolume30d, "averages keep the daily values")
+
+	empty := &models.SymbolQuote{Price: 100}
+	ApplyLiveQuote(empty, &models.StockPrice{})
+	assert.Equal(t, 100.0, empty.Price, "a live quote without a price is ignored")
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+// ErrInvalidAlertImport is returned when an alert import as a whole can't be
+// read, e.g. it's from an unsupported export version
+var ErrInvalidAlertImport = errors.New("invalid alert import")
+
+// ExportAlerts returns all of a user's alert rules, active or not, oldest
+// first so successive exports diff cleanly
+func (s *AlertService) ExportAlerts(userID string) (*models.AlertExport, error) {
+	rules, err := database.GetAlertRulesByUserID(userID, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.AlertExport{
+		Version:    models.AlertExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Alerts:     make([]models.ExportedAlertRule, 0, len(rules)),
+	}
+	// GetAlertRulesByUserID lists newest first
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		export.Alerts = append(export.Alerts, models.ExportedAlertRule{
+			WatchList:   rule.WatchListName,
+			Symbol:      rule.Symbol,
+			AlertType:   rule.AlertType,
+			Conditions:  rule.Conditions,
+			Name:        rule.Name,
+			Description: rule.Description,
+			Frequency:   rule.Frequency,
+			NotifyEmail: rule.NotifyEmail,
+			NotifyInApp: rule.NotifyInApp,
+			IsActive:    rule.IsActive,
+		})
+	}
+	return export, nil
+}
+
+// ImportAlerts recreates the rules of an export for a user, matching watch
+// lists by name. A rule duplicating an existing one (same watch list,
+// symbol and alert type) is skipped, so importing an export twice is a
+// no-op. Rules that fail validation, reference a missing watch list or
+// symbol (market-wide rules need only the watch list), or would exceed the
+// plan's max_alert_rules or the per-symbol cap are reported in Failed and
+// the rest are still imported.
+func (s *AlertService) ImportAlerts(userID string, export *models.AlertExport) (*models.AlertImportResponse, error) {
+	if export.Version != models.AlertExportVersion {
+		return nil, fmt.Errorf("%w: unsupported export version %d", ErrInvalidAlertImport, export.Version)
+	}
+
+	watchLists, err := database.GetWatchListsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	watchListIDs := make(map[string]string, len(watchLists))
+	for _, wl := range watchLists {
+		watchListIDs[wl.Name] = wl.ID
+	}
+
+	existing, err := database.GetAlertRulesByUserID(userID, "", "")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	symbolCounts := make(map[string]int)
+	for _, rule := range existing {
+		seen[alertRuleKey(rule.WatchListID, rule.Symbol, rule.AlertType)] = true
+		symbolCounts[rule.Symbol]++
+	}
+
+	limits, err := database.GetUserSubscriptionLimits(userID)
+	if err != nil {
+		// No subscription found — use free tier limits
+		limits = &models.SubscriptionLimits{
+			MaxAlertRules: 10,
+		}
+	}
+	// remaining is -1 when unlimited
+	remaining := -1
+	if limits.MaxAlertRules != -1 {
+		remaining = max(limits.MaxAlertRules-len(existing), 0)
+	}
+	maxPerSymbol := s.maxPerSymbol()
+
+	// Symbols of each watch list the import touches, loaded on first use
+	symbols := make(map[string]map[string]bool)
+
+	result := &models.AlertImportResponse{Failed: []models.AlertImportFailure{}}
+	for i, rule := range export.Alerts {
+		fail := func(msg string) {
+			result.Failed = append(result.Failed, models.AlertImportFailure{Index: i, Symbol: rule.Symbol, Error: msg})
+		}
+
+		if rule.Name == "" || len(rule.Name) > 255 {
+			fail("name must be 1-255 characters")
+			continue
+		}
+		if rule.Description != nil && len(*rule.Description) > 5000 {
+			fail("description must be at most 5000 characters")
+			continue
+		}
+		if err := validateAlertRule(rule.AlertType, rule.Frequency, rule.Conditions); err != nil {
+			fail(err.Error())
+			continue
+		}
+
+		watchListID, ok := watchListIDs[rule.WatchList]
+		if !ok {
+			fail(fmt.Sprintf("watch list %q not found", rule.WatchList))
+			continue
+		}
+		if IsMarketAlertType(rule.AlertType) {
+			// Market-wide alerts track a reference ticker outside the watch
+			// list; take it from the conditions rather than the export
+			rule.Symbol, _ = MarketAlertSymbol(rule.AlertType, rule.Conditions)
+		} else {
+			if symbols[watchListID] == nil {
+				items, err := database.GetWatchListItems(watchListID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch watchlist items: %w", err)
+				}
+				symbols[watchListID] = make(map[string]bool, len(items))
+				for _, item := range items {
+					symbols[watchListID][item.Symbol] = true
+				}
+			}
+			if !symbols[watchListID][rule.Symbol] {
+				fail("symbol not found in watch list")
+				continue
+			}
+		}
+
+		key := alertRuleKey(watchListID, rule.Symbol, rule.AlertType)
+		if seen[key] {
+			result.Skipped++
+			continue
+		}
+		if remaining == 0 {
+			fail("alert limit reached")
+			continue
+		}
+		if maxPerSymbol >= 0 && symbolCounts[rule.Symbol] >= maxPerSymbol {
+			fail(fmt.Sprintf("%s: maximum %d alerts per symbol", ErrSymbolAlertLimit, maxPerSymbol))
+			continue
+		}
+
+		alert := &models.AlertRule{
+			UserID:      userID,
+			WatchListID: watchListID,
+			Symbol:      rule.Symbol,
+			AlertType:   rule.AlertType,
+			Conditions:  rule.Conditions,
+			Name:        rule.Name,
+			Description: rule.Description,
+			Frequency:   rule.Frequency,
+			NotifyEmail: rule.NotifyEmail,
+			NotifyInApp: rule.NotifyInApp,
+			IsActive:    rule.IsActive,
+		}
+		created, err := database.CreateAlertRuleIfNotExists(alert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import alert for %s: %w", rule.Symbol, err)
+		}
+		seen[key] = true
+		if !created {
+			// Another active alert already covers this watch list and symbol
+			result.Skipped++
+			continue
+		}
+		result.Created++
+		symbolCounts[rule.Symbol]++
+		if remaining > 0 {
+			remaining--
+		}
+	}
+
+	return result, nil
+}
+
+// alertRuleKey identifies the rules an import treats as duplicates
+func alertRuleKey(watchListID, symbol, alertType string) string {
+	return watchListID + "\x00" + symbol + "\x00" + alertType
+}
@@ -9,61 +9,113 @@ import (
 	"time"
 )
 
-type AlertService struct{}
+// defaultMaxAlertRulesPerSymbol is how many alert rules a user may have on
+// one symbol unless ALERT_MAX_RULES_PER_SYMBOL overrides it
+const defaultMaxAlertRulesPerSymbol = 10
+
+// ErrSymbolAlertLimit is returned when a user already has the maximum number
+// of alert rules on a symbol
+var ErrSymbolAlertLimit = errors.New("alert limit for this symbol reached")
+
+type AlertService struct {
+	// MaxAlertRulesPerSymbol caps a user's alert rules on any one symbol, on
+	// top of their plan's max_alert_rules. -1 is unlimited; 0 uses the
+	// default.
+	MaxAlertRulesPerSymbol int
+}
 
 func NewAlertService() *AlertService {
-	return &AlertService{}
+	return &AlertService{
+		MaxAlertRulesPerSymbol: envInt("ALERT_MAX_RULES_PER_SYMBOL", defaultMaxAlertRulesPerSymbol),
+	}
 }
 
-// CreateAlert creates a new alert rule
-func (s *AlertService) CreateAlert(userID string, req *models.CreateAlertRuleRequest) (*models.AlertRule, error) {
-	// Validate alert type
-	validTypes := map[string]bool{
-		"price_above":         true,
-		"price_below":         true,
-		"price_change_pct":    true,
-		"price_change_amount": true,
-		"volume_spike":        true,
-		"unusual_volume":      true,
-		"volume_above":        true,
-		"volume_below":        true,
-		"news":                true,
-		"earnings":            true,
-		"dividend":            true,
-		"sec_filing":          true,
-		"analyst_rating":      true,
+// maxPerSymbol returns the per-symbol cap, or -1 when unlimited
+func (s *AlertService) maxPerSymbol() int {
+	switch {
+	case s.MaxAlertRulesPerSymbol < 0:
+		return -1
+	case s.MaxAlertRulesPerSymbol == 0:
+		return defaultMaxAlertRulesPerSymbol
 	}
+	return s.MaxAlertRulesPerSymbol
+}
 
-	if !validTypes[req.AlertType] {
-		return nil, errors.New("invalid alert type")
-	}
+// validAlertTypes are the alert types a rule can be created with
+var validAlertTypes = map[string]bool{
+	"price_above":         true,
+	"price_below":         true,
+	"price_change_pct":    true,
+	"price_change_amount": true,
+	"pct_change":          true,
+	"pe_crosses":          true,
+	"index_move":          true,
+	"sector_move":         true,
+	"volume_spike":        true,
+	"unusual_volume":      true,
+	"volume_above":        true,
+	"volume_below":        true,
+	"news":                true,
+	"earnings":            true,
+	"dividend":            true,
+	"sec_filing":          true,
+	"analyst_rating":      true,
+}
 
-	// Validate frequency
-	if req.Frequency != "once" && req.Frequency != "daily" && req.Frequency != "always" {
-		return nil, errors.New("invalid frequency: must be 'once', 'daily', or 'always'")
+// validateAlertRule checks a new rule's alert type, frequency and conditions
+func validateAlertRule(alertType, frequency string, conditions json.RawMessage) error {
+	if !validAlertTypes[alertType] {
+		return errors.New("invalid alert type")
 	}
-
-	// Validate conditions JSON
-	var conditionsMap map[string]interface{}
-	if err := json.Unmarshal(req.Conditions, &conditionsMap); err != nil {
-		return nil, errors.New("invalid conditions format")
+	if frequency != "once" && frequency != "daily" && frequency != "always" {
+		return errors.New("invalid frequency: must be 'once', 'daily', or 'always'")
 	}
+	return ValidateAlertConditions(alertType, conditions)
+}
 
-	// Validate that symbol exists in the watch list
-	items, err := database.GetWatchListItems(req.WatchListID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to validate watch list: %w", err)
+// CreateAlert creates a new alert rule
+func (s *AlertService) CreateAlert(userID string, req *models.CreateAlertRuleRequest) (*models.AlertRule, error) {
+	if err := validateAlertRule(req.AlertType, req.Frequency, req.Conditions); err != nil {
+		return nil, err
 	}
 
-	symbolExists := false
-	for _, item := range items {
-		if item.Symbol == req.Symbol {
-			symbolExists = true
-			break
+	if IsMarketAlertType(req.AlertType) {
+		// Market-wide alerts track the index or sector's reference ticker,
+		// which needn't be in the watch list
+		symbol, err := MarketAlertSymbol(req.AlertType, req.Conditions)
+		if err != nil {
+			return nil, err
+		}
+		req.Symbol = symbol
+	} else {
+		// Validate that symbol exists in the watch list
+		items, err := database.GetWatchListItems(req.WatchListID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate watch list: %w", err)
+		}
+
+		symbolExists := false
+		for _, item := range items {
+			if item.Symbol == req.Symbol {
+				symbolExists = true
+				break
+			}
+		}
+		if !symbolExists {
+			return nil, errors.New("symbol not found in watch list")
 		}
 	}
-	if !symbolExists {
-		return nil, errors.New("symbol not found in watch list")
+
+	// Cap alerts per symbol, so one ticker can't be flooded with rules
+	// spread across watch lists
+	if limit := s.maxPerSymbol(); limit >= 0 {
+		count, err := database.CountAlertRulesForSymbol(userID, req.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if count >= limit {
+			return nil, fmt.Errorf("%w: maximum %d alerts per symbol", ErrSymbolAlertLimit, limit)
+		}
 	}
 
 	// Create alert rule
@@ -109,12 +161,19 @@ func (s *AlertService) UpdateAlert(alertID string, userID string, req *models.Up
 		updates["description"] = *req.Description
 	}
 	if req.Conditions != nil {
-		// Validate conditions JSON
-		var conditionsMap map[string]interface{}
-		if err := json.Unmarshal(req.Conditions, &conditionsMap); err != nil {
-			return nil, errors.New("invalid conditions format")
+		// Conditions are validated against the rule's existing type
+		existing, err := database.GetAlertRuleByID(alertID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateAlertConditions(existing.AlertType, req.Conditions); err != nil {
+			return nil, err
 		}
 		updates["conditions"] = req.Conditions
+		if IsMarketAlertType(existing.AlertType) {
+			// The index or sector may have changed, and with it the ticker
+			updates["symbol"], _ = MarketAlertSymbol(existing.AlertType, req.Conditions)
+		}
 	}
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
@@ -144,14 +203,15 @@ func (s *AlertService) DeleteAlert(alertID string, userID string) error {
 	return database.DeleteAlertRule(alertID, userID)
 }
 
-// ValidateWatchListOwnership checks if user owns the watch list
+// ValidateWatchListOwnership checks if user owns the watch list. Another
+// user's watch list is reported as database.ErrWatchListNotFound.
 func (s *AlertService) ValidateWatchListOwnership(userID string, watchListID string) error {
 	watchList, err := database.GetWatchListByID(watchListID, userID)
 	if err != nil {
-		return errors.New("watch list not found")
+		return err
 	}
 	if watchList.UserID != userID {
-		return errors.New("unauthorized")
+		return database.ErrWatchListNotFound
 	}
 	return nil
 }
@@ -184,17 +244,16 @@ func (s *AlertService) CanCreateAlert(userID string) (bool, error) {
 // BulkCreateAlerts creates one alert per ticker in the given watchlist.
 // Skips tickers that already have an active alert (inactive alerts for the same
 // symbol are intentionally allowed — users may want fresh alerts after disabling
-// old ones). Stops early if the user's subscription limit is reached.
+// old ones) and tickers at the per-symbol alert cap. Stops early if the user's
+// subscription limit is reached.
 func (s *AlertService) BulkCreateAlerts(userID string, req *models.BulkCreateAlertRequest) (*models.BulkCreateAlertResponse, error) {
 	// Validate watchlist ownership
 	if err := s.ValidateWatchListOwnership(userID, req.WatchListID); err != nil {
 		return nil, err
 	}
 
-	// Validate conditions JSON
-	var conditionsMap map[string]interface{}
-	if err := json.Unmarshal(req.Conditions, &conditionsMap); err != nil {
-		return nil, errors.New("invalid conditions format")
+	if err := ValidateAlertConditions(req.AlertType, req.Conditions); err != nil {
+		return nil, err
 	}
 
 	// Fetch all tickers in the watchlist
@@ -221,6 +280,14 @@ func (s *AlertService) BulkCreateAlerts(userID string, req *models.BulkCreateAle
 	if limits.MaxAlertRules != -1 {
 		remaining = limits.MaxAlertRules - currentCount
 	}
+	maxPerSymbol := s.maxPerSymbol()
+	var symbolCounts map[string]int
+	if maxPerSymbol >= 0 {
+		symbolCounts, err = database.CountAlertRulesBySymbol(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	created := 0
 	skipped := 0
@@ -232,6 +299,10 @@ func (s *AlertService) BulkCreateAlerts(userID string, req *models.BulkCreateAle
 			return &models.BulkCreateAlertResponse{Created: created, Skipped: skipped},
 				fmt.Errorf("alert limit reached after creating %d alerts", created)
 		}
+		if maxPerSymbol >= 0 && symbolCounts[item.Symbol] >= maxPerSymbol {
+			skipped++
+			continue
+		}
 
 		// Use INSERT ... ON CONFLICT DO NOTHING to atomically skip duplicates.
 		// This replaces the separate AlertExistsForSymbol check + CreateAlertRule
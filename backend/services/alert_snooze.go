@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+// ErrInvalidSnooze is returned for a snooze request that doesn't give
+// exactly one of duration or until, or that doesn't end in the next year.
+var ErrInvalidSnooze = errors.New("invalid snooze")
+
+// maxAlertSnooze caps how far ahead a rule can be muted.
+const maxAlertSnooze = 365 * 24 * time.Hour
+
+// SnoozeEnd resolves a snooze request to the time the mute ends.
+func SnoozeEnd(req *models.SnoozeAlertRequest, now time.Time) (time.Time, error) {
+	var until time.Time
+	switch {
+	case req.Duration != "" && req.Until != nil:
+		return time.Time{}, fmt.Errorf("%w: give duration or until, not both", ErrInvalidSnooze)
+	case req.Duration != "":
+		d, err := parseSnoozeDuration(req.Duration)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSnooze, err)
+		}
+		until = now.Add(d)
+	case req.Until != nil:
+		until = *req.Until
+	default:
+		return time.Time{}, fmt.Errorf("%w: duration or until is required", ErrInvalidSnooze)
+	}
+
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("%w: snooze must end in the future", ErrInvalidSnooze)
+	}
+	if until.Sub(now) > maxAlertSnooze {
+		return time.Time{}, fmt.Errorf("%w: snooze can't be longer than 365 days", ErrInvalidSnooze)
+	}
+	return until, nil
+}
+
+// parseSnoozeDuration parses a Go duration ("90m", "4h") or a whole number
+// of days ("7d").
+func parseSnoozeDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// SnoozeAlert mutes an alert rule until the end of the requested snooze
+func (s *AlertService) SnoozeAlert(alertID string, userID string, req *models.SnoozeAlertRequest) (*models.AlertRule, error) {
+	until, err := SnoozeEnd(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := database.SnoozeAlertRule(alertID, userID, until); err != nil {
+		return nil, err
+	}
+	return database.GetAlertRuleByID(alertID, userID)
+}
+
+// UnsnoozeAlert ends an alert rule's snooze now
+func (s *AlertService) UnsnoozeAlert(alertID string, userID string) (*models.AlertRule, error) {
+	if err := database.UnsnoozeAlertRule(alertID, userID); err != nil {
+		return nil, err
+	}
+	return database.GetAlertRuleByID(alertID, userID)
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"investorcenter-api/models"
+)
+
+func TestSnoozeEnd(t *testing.T) {
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { u := now.Add(d); return &u }
+
+	tests := []struct {
+		name string
+		req  models.SnoozeAlertRequest
+		want time.Time
+		err  bool
+	}{
+		{"minutes", models.SnoozeAlertRequest{Duration: "90m"}, now.Add(90 * time.Minute), false},
+		{"hours", models.SnoozeAlertRequest{Duration: "4h"}, now.Add(4 * time.Hour), false},
+		{"days", models.SnoozeAlertRequest{Duration: "7d"}, now.Add(7 * 24 * time.Hour), false},
+		{"until", models.SnoozeAlertRequest{Until: at(48 * time.Hour)}, now.Add(48 * time.Hour), false},
+		{"neither", models.SnoozeAlertRequest{}, time.Time{}, true},
+		{"both", models.SnoozeAlertRequest{Duration: "1h", Until: at(time.Hour)}, time.Time{}, true},
+		{"bad duration", models.SnoozeAlertRequest{Duration: "soon"}, time.Time{}, true},
+		{"zero days", models.SnoozeAlertRequest{Duration: "0d"}, time.Time{}, true},
+		{"negative", models.SnoozeAlertRequest{Duration: "-1h"}, time.Time{}, true},
+		{"until in the past", models.SnoozeAlertRequest{Until: at(-time.Minute)}, time.Time{}, true},
+		{"over a year", models.SnoozeAlertRequest{Duration: "366d"}, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SnoozeEnd(&tt.req, now)
+			if tt.err {
+				assert.True(t, errors.Is(err, ErrInvalidSnooze), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"investorcenter-api/database"
 	"investorcenter-api/models"
+	"investorcenter-shared/tracing"
 )
 
 // BacktestService handles backtest operations
@@ -30,7 +31,8 @@ func NewBacktestService() *BacktestService {
 	return &BacktestService{
 		icScoreAPIURL: apiURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Minute, // Backtests can take a while
+			Timeout:   30 * time.Minute, // Backtests can take a while
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -2,35 +2,230 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"investorcenter-api/httputil"
 	"investorcenter-api/models"
 )
 
 var (
 	CoinGeckoBaseURL = "https://api.coingecko.com/api/v3"
+
+	// CoinGeckoRetryBaseDelay is the backoff before the first retry; each
+	// further retry doubles it. The free tier rate limits per minute, so
+	// it starts higher than the other clients.
+	CoinGeckoRetryBaseDelay = 2 * time.Second
+	// CoinGeckoMaxRetryDelay caps both the backoff and a server's Retry-After
+	CoinGeckoMaxRetryDelay = 30 * time.Second
+
+	// coinGeckoSleep waits between retries, swapped out in tests
+	coinGeckoSleep = time.Sleep
 )
 
+// Errors returned by GetCoinQuote and GetCandles
+var (
+	ErrCoinNotFound        = errors.New("coin not found")
+	ErrUnsupportedCurrency = errors.New("unsupported quote currency")
+)
+
+// CoinGeckoMaxPerPage is the most coins /coins/markets returns per page
+const CoinGeckoMaxPerPage = 250
+
 // CoinGeckoClient handles CoinGecko API requests
 type CoinGeckoClient struct {
+	// APIKey is a CoinGecko demo API key, sent with every request when set
 	APIKey string
 	Client *http.Client
+	// MaxRetries is how many times a request is retried after a 429, 5xx or
+	// transport error. Zero disables retries.
+	MaxRetries int
 }
 
-// NewCoinGeckoClient creates a new CoinGecko API client
+// NewCoinGeckoClient creates a new CoinGecko API client. Requests are
+// retried up to COINGECKO_MAX_RETRIES times (default 2).
 func NewCoinGeckoClient() *CoinGeckoClient {
 	// API key is optional for free tier
 	// For higher rate limits, set COINGECKO_API_KEY environment variable
 	return &CoinGeckoClient{
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		APIKey:     os.Getenv("COINGECKO_API_KEY"),
+		Client:     upstreamClient("coingecko"),
+		MaxRetries: maxRetriesFromEnv("COINGECKO_MAX_RETRIES"),
+	}
+}
+
+// get GETs url, retrying 429 and 5xx responses and transport errors with
+// jittered exponential backoff (see httputil.DoWithRetry)
+func (c *CoinGeckoClient) get(url string) (*http.Response, error) {
+	policy := httputil.RetryPolicy{
+		MaxRetries: c.MaxRetries,
+		BaseDelay:  CoinGeckoRetryBaseDelay,
+		MaxDelay:   CoinGeckoMaxRetryDelay,
+		Jitter:     0.5,
+		Sleep:      coinGeckoSleep,
+	}
+	return httputil.DoWithRetry(policy, func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.APIKey != "" {
+			req.Header.Set("x-cg-demo-api-key", c.APIKey)
+		}
+		UpstreamQuota().Record(QuotaSourceCoinGecko)
+		return c.Client.Do(req)
+	})
+}
+
+// getJSON GETs url and decodes the JSON response into v
+func (c *CoinGeckoClient) getJSON(url string, v interface{}) error {
+	resp, err := c.get(url)
+	if err != nil {
+		return fmt.Errorf("CoinGecko API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Coin endpoints 404 for IDs CoinGecko doesn't know
+		return fmt.Errorf("%w: CoinGecko API error: status %d", ErrCoinNotFound, resp.StatusCode)
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("CoinGecko API error: status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse CoinGecko response: %w", err)
+	}
+	return nil
+}
+
+// CoinGeckoCoin is an entry in CoinGecko's coin list
+type CoinGeckoCoin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+// GetCoins fetches every coin CoinGecko lists. Symbols aren't unique: many
+// coins share one, so look coins up by ID.
+func (c *CoinGeckoClient) GetCoins() ([]CoinGeckoCoin, error) {
+	var coins []CoinGeckoCoin
+	if err := c.getJSON(CoinGeckoBaseURL+"/coins/list", &coins); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// CoinGeckoMarket is a coin's USD market data from /coins/markets. Fields
+// CoinGecko reports as null are zero.
+type CoinGeckoMarket struct {
+	ID                       string  `json:"id"`
+	Symbol                   string  `json:"symbol"`
+	Name                     string  `json:"name"`
+	Image                    string  `json:"image"`
+	CurrentPrice             float64 `json:"current_price"`
+	MarketCap                float64 `json:"market_cap"`
+	MarketCapRank            int     `json:"market_cap_rank"`
+	TotalVolume              float64 `json:"total_volume"`
+	High24h                  float64 `json:"high_24h"`
+	Low24h                   float64 `json:"low_24h"`
+	PriceChange24h           float64 `json:"price_change_24h"`
+	PriceChangePercentage24h float64 `json:"price_change_percentage_24h"`
+	PriceChangePercentage1h  float64 `json:"price_change_percentage_1h_in_currency"`
+	PriceChangePercentage7d  float64 `json:"price_change_percentage_7d_in_currency"`
+	PriceChangePercentage30d float64 `json:"price_change_percentage_30d_in_currency"`
+	CirculatingSupply        float64 `json:"circulating_supply"`
+	TotalSupply              float64 `json:"total_supply"`
+	MaxSupply                float64 `json:"max_supply"`
+	ATH                      float64 `json:"ath"`
+	ATHChangePercentage      float64 `json:"ath_change_percentage"`
+	ATHDate                  string  `json:"ath_date"`
+	ATL                      float64 `json:"atl"`
+	ATLChangePercentage      float64 `json:"atl_change_percentage"`
+	ATLDate                  string  `json:"atl_date"`
+	LastUpdated              string  `json:"last_updated"`
+}
+
+// GetCoinMarkets fetches one page (1-based) of coins' USD market data,
+// largest market cap first. perPage is capped at CoinGeckoMaxPerPage; a
+// page shorter than perPage is the last one.
+func (c *CoinGeckoClient) GetCoinMarkets(page, perPage int) ([]CoinGeckoMarket, error) {
+	if perPage <= 0 || perPage > CoinGeckoMaxPerPage {
+		perPage = CoinGeckoMaxPerPage
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	url := fmt.Sprintf("%s/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=%d&page=%d&sparkline=false&price_change_percentage=1h,24h,7d,30d",
+		CoinGeckoBaseURL, perPage, page)
+
+	var markets []CoinGeckoMarket
+	if err := c.getJSON(url, &markets); err != nil {
+		return nil, err
+	}
+	return markets, nil
+}
+
+// GetCoinPrice fetches a coin's current USD price, resolving symbol with
+// MapSymbolToCoinGeckoID
+func (c *CoinGeckoClient) GetCoinPrice(symbol string) (float64, error) {
+	quote, err := c.GetCoinQuote(c.MapSymbolToCoinGeckoID(symbol), "usd")
+	if err != nil {
+		return 0, fmt.Errorf("no CoinGecko price for %s: %w", symbol, err)
+	}
+	return quote.Price, nil
+}
+
+// CoinGeckoQuote is a coin's live price in a single quote currency
+type CoinGeckoQuote struct {
+	CoinID        string
+	Currency      string // lowercase, e.g. "usd"
+	Price         float64
+	ChangePercent float64 // over the last 24h
+	Volume        float64 // over the last 24h, in Currency
+	LastUpdated   time.Time
+}
+
+// GetCoinQuote fetches a coin's price, 24h change and 24h volume in the vs
+// currency. It returns ErrCoinNotFound when CoinGecko doesn't know coinID
+// and ErrUnsupportedCurrency when it doesn't price the coin in vs.
+func (c *CoinGeckoClient) GetCoinQuote(coinID, vs string) (*CoinGeckoQuote, error) {
+	vs = strings.ToLower(vs)
+	endpoint := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=%s"+
+		"&include_24hr_vol=true&include_24hr_change=true&include_last_updated_at=true",
+		CoinGeckoBaseURL, url.QueryEscape(coinID), url.QueryEscape(vs))
+
+	var prices map[string]map[string]float64
+	if err := c.getJSON(endpoint, &prices); err != nil {
+		return nil, err
+	}
+	fields, ok := prices[coinID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCoinNotFound, coinID)
+	}
+	price, ok := fields[vs]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, vs)
+	}
+
+	quote := &CoinGeckoQuote{
+		CoinID:        coinID,
+		Currency:      vs,
+		Price:         price,
+		ChangePercent: fields[vs+"_24h_change"],
+		Volume:        fields[vs+"_24h_vol"],
+	}
+	if ts := fields["last_updated_at"]; ts > 0 {
+		quote.LastUpdated = time.Unix(int64(ts), 0).UTC()
 	}
+	return quote, nil
 }
 
 // MapSymbolToCoinGeckoID maps ticker symbols to CoinGecko IDs
@@ -104,7 +299,7 @@ func (c *CoinGeckoClient) GetMarketChart(symbol string, days int) ([]models.Char
 	log.Printf("Fetching CoinGecko market chart for %s (id: %s, days: %d)", symbol, coinID, days)
 
 	// Make request
-	resp, err := c.Client.Get(url)
+	resp, err := c.get(url)
 	if err != nil {
 		return nil, fmt.Errorf("CoinGecko API request failed: %w", err)
 	}
@@ -183,7 +378,7 @@ func (c *CoinGeckoClient) GetOHLC(symbol string, days int) ([]models.ChartDataPo
 		symbol, coinID, days, validDays)
 
 	// Make request
-	resp, err := c.Client.Get(url)
+	resp, err := c.get(url)
 	if err != nil {
 		return nil, fmt.Errorf("CoinGecko OHLC API request failed: %w", err)
 	}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"investorcenter-api/models"
+)
+
+// ============================================================================
+// Candle Cache
+// ============================================================================
+
+// CoinGeckoCandleCacheTTL is how long a coin's candles for a period are
+// served from memory before CoinGecko is queried again. It matches the
+// narrowest candle so a 1D chart never lags more than one candle behind.
+var CoinGeckoCandleCacheTTL = 5 * time.Minute
+
+// candleCache caches candles in memory, keyed by symbol and period, and is
+// shared by all CoinGeckoClients
+type candleCache struct {
+	mu      sync.Mutex
+	entries map[string]candleEntry
+}
+
+type candleEntry struct {
+	candles  []models.ChartDataPoint
+	cachedAt time.Time
+}
+
+var coinGeckoCandleCache = &candleCache{entries: make(map[string]candleEntry)}
+
+func (c *candleCache) get(key string) ([]models.ChartDataPoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.cachedAt) > CoinGeckoCandleCacheTTL {
+		return nil, false
+	}
+	return entry.candles, true
+}
+
+func (c *candleCache) set(key string, candles []models.ChartDataPoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired charts so the cache doesn't grow with every coin viewed
+	for k, entry := range c.entries {
+		if time.Since(entry.cachedAt) > CoinGeckoCandleCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = candleEntry{candles: candles, cachedAt: time.Now()}
+}
+
+// ============================================================================
+// Candle Fetch Methods
+// ============================================================================
+
+// coinGeckoHourlyRangeDays is the longest range market_chart/range returns
+// hourly points for. Beyond it the points are daily, one price per day, so
+// candles built from them would have no real open, high or low.
+const coinGeckoHourlyRangeDays = 90
+
+// CryptoCandleWidth returns the candle width for a chart period. CoinGecko's
+// market_chart/range picks its granularity from the range (5-minute points
+// within a day, hourly up to 90 days, daily beyond), so candles are never
+// narrower than the points they're built from: 1D charts get 5-minute
+// candles, 5D and 1W hourly, and 1M and 3M daily. Longer periods come from
+// the /ohlc endpoint, whose candles are 4 days wide at those ranges.
+func CryptoCandleWidth(period string) time.Duration {
+	switch days := GetDaysFromPeriod(period); {
+	case days <= 1:
+		return 5 * time.Minute
+	case days <= 7:
+		return time.Hour
+	case days <= coinGeckoHourlyRangeDays:
+		return 24 * time.Hour
+	default:
+		return 4 * 24 * time.Hour
+	}
+}
+
+// GetCandles fetches a coin's OHLCV candles for a chart period (1D, 1W, 1M,
+// 1Y, ...), oldest first: from market_chart/range up to 90 days, and from
+// /ohlc beyond that. Results are cached for CoinGeckoCandleCacheTTL. It
+// returns ErrCoinNotFound for unknown coins.
+func (c *CoinGeckoClient) GetCandles(symbol, period string) ([]models.ChartDataPoint, error) {
+	symbol, period = strings.ToUpper(symbol), strings.ToUpper(period)
+	cacheKey := symbol + "|" + period
+	if candles, ok := coinGeckoCandleCache.get(cacheKey); ok {
+		return candles, nil
+	}
+
+	coinID := c.MapSymbolToCoinGeckoID(symbol)
+	days := GetDaysFromPeriod(period)
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -days)
+
+	var candles []models.ChartDataPoint
+	if days > coinGeckoHourlyRangeDays {
+		endpoint := fmt.Sprintf("%s/coins/%s/ohlc?vs_currency=usd&days=%s",
+			CoinGeckoBaseURL, url.PathEscape(coinID), ohlcDays(days))
+		var ohlc OHLCResponse
+		if err := c.getJSON(endpoint, &ohlc); err != nil {
+			return nil, err
+		}
+		candles = buildOHLCCandles(ohlc, from, CryptoCandleWidth(period))
+	} else {
+		endpoint := fmt.Sprintf("%s/coins/%s/market_chart/range?vs_currency=usd&from=%d&to=%d",
+			CoinGeckoBaseURL, url.PathEscape(coinID), from.Unix(), to.Unix())
+		var chart MarketChartResponse
+		if err := c.getJSON(endpoint, &chart); err != nil {
+			return nil, err
+		}
+		candles = buildCandles(chart, CryptoCandleWidth(period))
+	}
+
+	coinGeckoCandleCache.set(cacheKey, candles)
+	return candles, nil
+}
+
+// ohlcDays picks the smallest range /ohlc serves (it only accepts 1, 7, 14,
+// 30, 90, 180, 365 or max days) that covers days
+func ohlcDays(days int) string {
+	for _, d := range []int{180, 365} {
+		if days <= d {
+			return strconv.Itoa(d)
+		}
+	}
+	return "max"
+}
+
+// buildOHLCCandles converts /ohlc rows ([close time ms, open, high, low,
+// close]) into candles stamped with their UTC start, dropping candles that
+// closed before from. /ohlc has no volume, so the candles carry none.
+func buildOHLCCandles(rows OHLCResponse, from time.Time, width time.Duration) []models.ChartDataPoint {
+	candles := make([]models.ChartDataPoint, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		closedAt := time.UnixMilli(int64(row[0])).UTC()
+		if closedAt.Before(from) {
+			continue
+		}
+		candles = append(candles, models.ChartDataPoint{
+			Timestamp: closedAt.Add(-width).Truncate(24 * time.Hour),
+			Open:      decimal.NewFromFloat(row[1]),
+			High:      decimal.NewFromFloat(row[2]),
+			Low:       decimal.NewFromFloat(row[3]),
+			Close:     decimal.NewFromFloat(row[4]),
+		})
+	}
+	sort.SliceStable(candles, func(i, j int) bool { return candles[i].Timestamp.Before(candles[j].Timestamp) })
+	return candles
+}
+
+// buildCandles buckets market chart points into candles of the given width.
+// Each candle is stamped with the UTC start of its bucket so crypto lines up
+// with stock bars on a shared axis: daily candles fall on midnight UTC like
+// the daily bars from FMP and the database, and intraday candles on the same
+// 5-minute and hourly boundaries as Polygon's aggregates. CoinGecko only
+// reports a rolling 24h volume, so daily candles take the volume at their
+// last point and intraday candles have none.
+func buildCandles(chart MarketChartResponse, width time.Duration) []models.ChartDataPoint {
+	volumes := make(map[int64]float64, len(chart.TotalVolumes))
+	for _, v := range chart.TotalVolumes {
+		if len(v) >= 2 {
+			volumes[int64(v[0])] = v[1]
+		}
+	}
+
+	prices := make([][]float64, 0, len(chart.Prices))
+	for _, p := range chart.Prices {
+		if len(p) >= 2 {
+			prices = append(prices, p)
+		}
+	}
+	sort.SliceStable(prices, func(i, j int) bool { return prices[i][0] < prices[j][0] })
+
+	candles := make([]models.ChartDataPoint, 0)
+	for _, p := range prices {
+		ms := int64(p[0])
+		start := time.UnixMilli(ms).UTC().Truncate(width)
+		price := decimal.NewFromFloat(p[1])
+
+		n := len(candles)
+		if n == 0 || !candles[n-1].Timestamp.Equal(start) {
+			candles = append(candles, models.ChartDataPoint{
+				Timestamp: start,
+				Open:      price,
+				High:      price,
+				Low:       price,
+			})
+			n++
+		}
+		candle := &candles[n-1]
+		if price.GreaterThan(candle.High) {
+			candle.High = price
+		}
+		if price.LessThan(candle.Low) {
+			candle.Low = price
+		}
+		candle.Close = price
+		if width >= 24*time.Hour {
+			candle.Volume = decimal.NewFromFloat(volumes[ms]).IntPart()
+		}
+	}
+	return candles
+}
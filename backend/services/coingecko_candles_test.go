@@ -0,0 +1,164 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetCoinGeckoCandleCache empties the shared candle cache for test isolation
+func resetCoinGeckoCandleCache(t *testing.T) {
+	t.Helper()
+	coinGeckoCandleCache.mu.Lock()
+	coinGeckoCandleCache.entries = make(map[string]candleEntry)
+	coinGeckoCandleCache.mu.Unlock()
+}
+
+func TestCryptoCandleWidth(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, CryptoCandleWidth("1D"))
+	assert.Equal(t, time.Hour, CryptoCandleWidth("5D"))
+	assert.Equal(t, time.Hour, CryptoCandleWidth("1W"))
+	assert.Equal(t, 24*time.Hour, CryptoCandleWidth("1M"))
+	assert.Equal(t, 24*time.Hour, CryptoCandleWidth("3M"))
+	assert.Equal(t, 4*24*time.Hour, CryptoCandleWidth("1Y"))
+}
+
+func TestBuildCandles_Intraday(t *testing.T) {
+	base := time.Date(2024, 12, 15, 10, 0, 0, 0, time.UTC)
+	ms := func(offset time.Duration) float64 { return float64(base.Add(offset).UnixMilli()) }
+
+	chart := MarketChartResponse{
+		Prices: [][]float64{
+			{ms(61 * time.Second), 100},
+			{ms(2 * time.Minute), 104},
+			{ms(4*time.Minute + 59*time.Second), 98},
+			{ms(3 * time.Minute), 102},
+			{ms(7 * time.Minute), 101},
+			{ms(8 * time.Minute)}, // malformed, skipped
+		},
+		TotalVolumes: [][]float64{{ms(61 * time.Second), 5e9}},
+	}
+
+	candles := buildCandles(chart, 5*time.Minute)
+
+	require.Len(t, candles, 2)
+	assert.Equal(t, base, candles[0].Timestamp, "candles start on a UTC 5-minute boundary")
+	assert.Equal(t, time.UTC, candles[0].Timestamp.Location())
+	assert.True(t, decimal.NewFromInt(100).Equal(candles[0].Open))
+	assert.True(t, decimal.NewFromInt(104).Equal(candles[0].High))
+	assert.True(t, decimal.NewFromInt(98).Equal(candles[0].Low))
+	assert.True(t, decimal.NewFromInt(98).Equal(candles[0].Close), "points are ordered by time before bucketing")
+	assert.Zero(t, candles[0].Volume, "rolling 24h volume isn't split across intraday candles")
+
+	assert.Equal(t, base.Add(5*time.Minute), candles[1].Timestamp)
+	assert.True(t, decimal.NewFromInt(101).Equal(candles[1].Close))
+}
+
+func TestBuildCandles_Daily(t *testing.T) {
+	day := time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)
+	ms := func(offset time.Duration) float64 { return float64(day.Add(offset).UnixMilli()) }
+
+	chart := MarketChartResponse{
+		Prices: [][]float64{
+			{ms(0), 100},
+			{ms(24 * time.Hour), 110},
+			{ms(30 * time.Hour), 120},
+		},
+		TotalVolumes: [][]float64{
+			{ms(0), 1e9},
+			{ms(24 * time.Hour), 2e9},
+			{ms(30 * time.Hour), 3e9},
+		},
+	}
+
+	candles := buildCandles(chart, 24*time.Hour)
+
+	require.Len(t, candles, 2)
+	assert.Equal(t, day, candles[0].Timestamp, "daily candles fall on midnight UTC like stock daily bars")
+	assert.Equal(t, int64(1e9), candles[0].Volume)
+	assert.Equal(t, day.AddDate(0, 0, 1), candles[1].Timestamp)
+	assert.True(t, decimal.NewFromInt(110).Equal(candles[1].Open))
+	assert.True(t, decimal.NewFromInt(120).Equal(candles[1].Close))
+	assert.Equal(t, int64(3e9), candles[1].Volume, "volume is the 24h volume at the candle's last point")
+}
+
+func TestGetCandles_Cached(t *testing.T) {
+	resetCoinGeckoCandleCache(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "/coins/bitcoin/market_chart/range", r.URL.Path)
+		assert.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+		from, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+		to, _ := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+		assert.InDelta(t, 7*24*3600, to-from, 3600, "1W asks for a week")
+		_, _ = w.Write([]byte(`{"prices":[[1734256800000,100],[1734258600000,101]],"total_volumes":[]}`))
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	client := NewCoinGeckoClient()
+	candles, err := client.GetCandles("btc", "1w")
+	require.NoError(t, err)
+	require.Len(t, candles, 1, "both points fall in one hourly candle")
+
+	again, err := client.GetCandles("BTC", "1W")
+	require.NoError(t, err)
+	assert.Equal(t, candles, again)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "second call is served from cache")
+}
+
+func TestGetCandles_LongRangeUsesOHLC(t *testing.T) {
+	resetCoinGeckoCandleCache(t)
+
+	closeAt := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	tooOld := closeAt.AddDate(-2, 0, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/coins/bitcoin/ohlc", r.URL.Path)
+		assert.Equal(t, "365", r.URL.Query().Get("days"))
+		_, _ = w.Write([]byte(`[` +
+			`[` + strconv.FormatInt(tooOld.UnixMilli(), 10) + `,1,2,0.5,1.5],` +
+			`[` + strconv.FormatInt(closeAt.UnixMilli(), 10) + `,100,130,90,120]]`))
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	candles, err := NewCoinGeckoClient().GetCandles("BTC", "1Y")
+	require.NoError(t, err)
+	require.Len(t, candles, 1, "candles closing before the period are dropped")
+	assert.Equal(t, closeAt.AddDate(0, 0, -4), candles[0].Timestamp, "stamped with the candle's start")
+	assert.True(t, decimal.NewFromInt(100).Equal(candles[0].Open))
+	assert.True(t, decimal.NewFromInt(130).Equal(candles[0].High))
+	assert.True(t, decimal.NewFromInt(90).Equal(candles[0].Low))
+	assert.True(t, decimal.NewFromInt(120).Equal(candles[0].Close))
+}
+
+func TestGetCandles_UnknownCoin(t *testing.T) {
+	resetCoinGeckoCandleCache(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	_, err := NewCoinGeckoClient().GetCandles("NOPE", "1D")
+	assert.ErrorIs(t, err, ErrCoinNotFound)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -120,7 +121,9 @@ func TestGetMarketChart_Success(t *testing.T) {
 }
 
 func TestGetMarketChart_APIError(t *testing.T) {
+	var calls int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
 		w.WriteHeader(http.StatusTooManyRequests)
 	}))
 	defer server.Close()
@@ -129,11 +132,48 @@ func TestGetMarketChart_APIError(t *testing.T) {
 	CoinGeckoBaseURL = server.URL
 	defer func() { CoinGeckoBaseURL = originalURL }()
 
+	var delays []time.Duration
+	origSleep := coinGeckoSleep
+	coinGeckoSleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { coinGeckoSleep = origSleep }()
+
 	client := NewCoinGeckoClient()
+	client.MaxRetries = 2
 	_, err := client.GetMarketChart("BTC", 7)
 
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "status 429")
+	assert.Contains(t, err.Error(), "status 429", "the last response is reported once retries run out")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Len(t, delays, 2)
+}
+
+func TestGetMarketChart_RetriesTransientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(MarketChartResponse{Prices: [][]float64{{1700000000000, 50000.0}}})
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	var delays []time.Duration
+	origSleep := coinGeckoSleep
+	coinGeckoSleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { coinGeckoSleep = origSleep }()
+
+	client := &CoinGeckoClient{Client: server.Client(), MaxRetries: 2}
+	dataPoints, err := client.GetMarketChart("BTC", 1)
+
+	require.NoError(t, err)
+	assert.Len(t, dataPoints, 1)
+	assert.Equal(t, []time.Duration{5 * time.Second}, delays)
 }
 
 func TestGetMarketChart_InvalidJSON(t *testing.T) {
@@ -359,3 +399,135 @@ func TestMarketChartResponse_PartialPriceData(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, dataPoints, 1, "should skip price data with fewer than 2 elements")
 }
+
+// ---------------------------------------------------------------------------
+// GetCoins / GetCoinMarkets / GetCoinPrice / GetCoinQuote
+// ---------------------------------------------------------------------------
+
+func TestGetCoins_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/coins/list", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"id":"bitcoin","symbol":"btc","name":"Bitcoin"},{"id":"ethereum","symbol":"eth","name":"Ethereum"}]`))
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	coins, err := NewCoinGeckoClient().GetCoins()
+
+	require.NoError(t, err)
+	assert.Equal(t, []CoinGeckoCoin{{"bitcoin", "btc", "Bitcoin"}, {"ethereum", "eth", "Ethereum"}}, coins)
+}
+
+func TestGetCoinMarkets_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/coins/markets", r.URL.Path)
+		assert.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+		assert.Equal(t, "market_cap_desc", r.URL.Query().Get("order"))
+		assert.Equal(t, "250", r.URL.Query().Get("per_page"), "per_page is capped")
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "demo-key", r.Header.Get("x-cg-demo-api-key"))
+		_, _ = w.Write([]byte(`[{"id":"bitcoin","symbol":"btc","name":"Bitcoin","current_price":65000.5,
+			"market_cap":1280000000000,"market_cap_rank":1,"max_supply":null,
+			"price_change_percentage_7d_in_currency":3.2}]`))
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	client := NewCoinGeckoClient()
+	client.APIKey = "demo-key"
+	markets, err := client.GetCoinMarkets(2, 1000)
+
+	require.NoError(t, err)
+	require.Len(t, markets, 1)
+	assert.Equal(t, "bitcoin", markets[0].ID)
+	assert.Equal(t, 65000.5, markets[0].CurrentPrice)
+	assert.Equal(t, 1, markets[0].MarketCapRank)
+	assert.Equal(t, 3.2, markets[0].PriceChangePercentage7d)
+	assert.Zero(t, markets[0].MaxSupply, "null fields decode as zero")
+}
+
+func TestGetCoinMarkets_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	client := &CoinGeckoClient{Client: server.Client()}
+	_, err := client.GetCoinMarkets(1, 100)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 429")
+}
+
+func TestGetCoinPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/simple/price", r.URL.Path)
+		if r.URL.Query().Get("ids") == "bitcoin" {
+			_, _ = w.Write([]byte(`{"bitcoin":{"usd":65000.5}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	client := NewCoinGeckoClient()
+	price, err := client.GetCoinPrice("BTC")
+	require.NoError(t, err)
+	assert.Equal(t, 65000.5, price)
+
+	_, err = client.GetCoinPrice("NOPE")
+	assert.Error(t, err)
+}
+
+func TestGetCoinQuote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/simple/price", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("include_24hr_change"))
+		assert.Equal(t, "true", r.URL.Query().Get("include_24hr_vol"))
+		switch r.URL.Query().Get("ids") + "/" + r.URL.Query().Get("vs_currencies") {
+		case "bitcoin/eur":
+			_, _ = w.Write([]byte(`{"bitcoin":{"eur":60000.25,"eur_24h_vol":21000000000,"eur_24h_change":-1.5,"last_updated_at":1734256800}}`))
+		case "bitcoin/zzz":
+			_, _ = w.Write([]byte(`{"bitcoin":{"last_updated_at":1734256800}}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	originalURL := CoinGeckoBaseURL
+	CoinGeckoBaseURL = server.URL
+	defer func() { CoinGeckoBaseURL = originalURL }()
+
+	client := NewCoinGeckoClient()
+	quote, err := client.GetCoinQuote("bitcoin", "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, &CoinGeckoQuote{
+		CoinID:        "bitcoin",
+		Currency:      "eur",
+		Price:         60000.25,
+		ChangePercent: -1.5,
+		Volume:        21000000000,
+		LastUpdated:   time.Unix(1734256800, 0).UTC(),
+	}, quote)
+
+	_, err = client.GetCoinQuote("bitcoin", "zzz")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	_, err = client.GetCoinQuote("not-a-coin", "usd")
+	assert.ErrorIs(t, err, ErrCoinNotFound)
+}
@@ -6,9 +6,10 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	apihttp "investorcenter-api/httputil"
 )
 
 var dataIngestionProxy *httputil.ReverseProxy
@@ -25,25 +26,17 @@ func init() {
 		return
 	}
 
-	dataIngestionProxy = httputil.NewSingleHostReverseProxy(target)
-
-	originalDirector := dataIngestionProxy.Director
-	dataIngestionProxy.Director = func(req *http.Request) {
-		originalDirector(req)
-		// Strip /api/v1 prefix — data ingestion service routes start at /ingest
-		req.URL.Path = strings.TrimPrefix(req.URL.Path, "/api/v1")
-		req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, "/api/v1")
-	}
+	// Data ingestion service routes start at /ingest
+	dataIngestionProxy = newServiceProxy(target)
 }
 
 // DataIngestionProxy returns a Gin handler that proxies requests to the data ingestion service.
 func DataIngestionProxy() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if dataIngestionProxy == nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Data ingestion service not configured"})
-			c.Abort()
+			apihttp.RespondError(c, http.StatusServiceUnavailable, apihttp.CodeServiceUnavailable, "Data ingestion service not configured")
 			return
 		}
-		dataIngestionProxy.ServeHTTP(c.Writer, c.Request)
+		serveProxy(c, dataIngestionProxy)
 	}
 }
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sort"
+	"time"
+)
+
+// DividendSummary is a ticker's dividend history with figures derived from it.
+// Tickers that don't pay dividends get an empty history and zero TTM.
+type DividendSummary struct {
+	History          []FMPDividendHistorical `json:"history"`
+	TTMDividend      float64                 `json:"ttm_dividend"`
+	DividendYield    *float64                `json:"dividend_yield"` // percent of current price; nil without a price or dividends
+	CurrentPrice     *float64                `json:"current_price"`
+	Frequency        string                  `json:"frequency"`
+	ConsecutiveYears int                     `json:"consecutive_years"`
+	LastExDate       *string                 `json:"last_ex_date"`
+}
+
+// SummarizeDividends sorts dividends newest first and computes the TTM
+// dividend (split-adjusted, ex-dates within a year of now), the yield against
+// price, the payment frequency and the dividend-paying years streak.
+func SummarizeDividends(dividends []FMPDividendHistorical, price *float64, now time.Time) DividendSummary {
+	history := make([]FMPDividendHistorical, len(dividends))
+	copy(history, dividends)
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Date > history[j].Date })
+
+	summary := DividendSummary{
+		History:          history,
+		CurrentPrice:     price,
+		Frequency:        estimateDividendFrequency(history),
+		ConsecutiveYears: countConsecutiveDividendYears(history),
+	}
+	if len(history) == 0 {
+		summary.Frequency = "none"
+		return summary
+	}
+	summary.LastExDate = &history[0].Date
+
+	oneYearAgo := now.AddDate(-1, 0, 0)
+	for _, d := range history {
+		date, err := time.Parse("2006-01-02", d.Date)
+		if err != nil || !date.After(oneYearAgo) || date.After(now) {
+			continue
+		}
+		amount := d.AdjDividend
+		if amount == 0 {
+			amount = d.Dividend
+		}
+		summary.TTMDividend += amount
+	}
+
+	if price != nil && *price > 0 && summary.TTMDividend > 0 {
+		yield := summary.TTMDividend / *price * 100
+		summary.DividendYield = &yield
+	}
+	return summary
+}
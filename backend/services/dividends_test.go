@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeDividends(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	price := 100.0
+	dividends := []FMPDividendHistorical{
+		{Date: "2025-03-14", AdjDividend: 0.25, Dividend: 1.0}, // before the 4:1 split
+		{Date: "2025-06-13", AdjDividend: 0.26, Dividend: 0.26},
+		{Date: "2025-09-12", Dividend: 0.26}, // no adjusted amount reported
+		{Date: "2025-12-12", AdjDividend: 0.26, Dividend: 0.26},
+		{Date: "2026-03-13", AdjDividend: 0.27, Dividend: 0.27}, // declared, not yet ex
+		{Date: "2024-12-13", AdjDividend: 0.25, Dividend: 1.0},  // outside the TTM window
+	}
+
+	summary := SummarizeDividends(dividends, &price, now)
+
+	require.Len(t, summary.History, 6)
+	assert.Equal(t, "2026-03-13", summary.History[0].Date, "newest first")
+	assert.Equal(t, "2024-12-13", summary.History[5].Date)
+	assert.InDelta(t, 1.03, summary.TTMDividend, 1e-9, "split-adjusted, past ex-dates within a year")
+	require.NotNil(t, summary.DividendYield)
+	assert.InDelta(t, 1.03, *summary.DividendYield, 1e-9)
+	assert.Equal(t, 3, summary.ConsecutiveYears)
+	require.NotNil(t, summary.LastExDate)
+	assert.Equal(t, "2026-03-13", *summary.LastExDate)
+	assert.Equal(t, "2025-03-14", dividends[0].Date, "input is not reordered")
+}
+
+func TestSummarizeDividends_NoPriceOrDividends(t *testing.T) {
+	summary := SummarizeDividends(nil, nil, time.Now())
+	assert.NotNil(t, summary.History)
+	assert.Empty(t, summary.History)
+	assert.Zero(t, summary.TTMDividend)
+	assert.Nil(t, summary.DividendYield)
+	assert.Nil(t, summary.LastExDate)
+	assert.Equal(t, "none", summary.Frequency)
+
+	summary = SummarizeDividends([]FMPDividendHistorical{{Date: time.Now().AddDate(0, -1, 0).Format("2006-01-02"), Dividend: 0.5}}, nil, time.Now())
+	assert.Equal(t, 0.5, summary.TTMDividend)
+	assert.Nil(t, summary.DividendYield, "no yield without a price")
+}
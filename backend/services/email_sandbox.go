@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEmailSandboxSize is how many captured emails the sandbox keeps when
+// EMAIL_SANDBOX_SIZE isn't set
+const defaultEmailSandboxSize = 100
+
+// CapturedEmail is an email the sandbox kept instead of sending
+type CapturedEmail struct {
+	To         string    `json:"to"`
+	Subject    string    `json:"subject"`
+	HTMLBody   string    `json:"html_body"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// EmailSandbox captures outgoing emails in place of SMTP, for staging and
+// tests. The most recent emails are kept in memory, and with a directory set
+// each one is also written there as an .html file.
+type EmailSandbox struct {
+	dir  string // "" keeps emails in memory only
+	size int
+
+	mu     sync.Mutex
+	emails []CapturedEmail // oldest first, at most size
+}
+
+// NewEmailSandbox creates a sandbox keeping the last size emails, also
+// writing them to dir when it's not empty
+func NewEmailSandbox(size int, dir string) *EmailSandbox {
+	if size <= 0 {
+		size = defaultEmailSandboxSize
+	}
+	return &EmailSandbox{dir: dir, size: size}
+}
+
+var (
+	emailSandbox     *EmailSandbox
+	emailSandboxOnce sync.Once
+)
+
+// EmailSandboxFromEnv returns the process-wide sandbox when EMAIL_SANDBOX is
+// true, or nil when emails should really be sent. EMAIL_SANDBOX_SIZE and
+// EMAIL_SANDBOX_DIR configure it. Every EmailService in the process captures
+// into the same sandbox, so the canary endpoint sees them all.
+func EmailSandboxFromEnv() *EmailSandbox {
+	emailSandboxOnce.Do(func() {
+		if enabled, _ := strconv.ParseBool(os.Getenv("EMAIL_SANDBOX")); !enabled {
+			return
+		}
+		size, _ := strconv.Atoi(os.Getenv("EMAIL_SANDBOX_SIZE"))
+		emailSandbox = NewEmailSandbox(size, os.Getenv("EMAIL_SANDBOX_DIR"))
+		fmt.Println("Email sandbox enabled: outgoing emails are captured, not sent")
+	})
+	return emailSandbox
+}
+
+// Capture records an email. The in-memory copy is always kept; an error
+// means only the file copy failed.
+func (s *EmailSandbox) Capture(to, subject, htmlBody string) error {
+	email := CapturedEmail{To: to, Subject: subject, HTMLBody: htmlBody, CapturedAt: time.Now().UTC()}
+
+	s.mu.Lock()
+	s.emails = append(s.emails, email)
+	if len(s.emails) > s.size {
+		s.emails = s.emails[len(s.emails)-s.size:]
+	}
+	s.mu.Unlock()
+
+	if s.dir == "" {
+		return nil
+	}
+	return s.writeFile(email)
+}
+
+// Recent returns up to limit captured emails, newest first, only those sent
+// to to when it's not empty. limit <= 0 returns them all.
+func (s *EmailSandbox) Recent(to string, limit int) []CapturedEmail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []CapturedEmail{}
+	for i := len(s.emails) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		if to != "" && !strings.EqualFold(s.emails[i].To, to) {
+			continue
+		}
+		result = append(result, s.emails[i])
+	}
+	return result
+}
+
+// Clear drops the captured emails, e.g. between test runs. Files already
+// written are left alone.
+func (s *EmailSandbox) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = nil
+}
+
+// writeFile saves email to the sandbox directory, named by capture time and
+// recipient so a listing sorts chronologically
+func (s *EmailSandbox) writeFile(email CapturedEmail) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("email sandbox: %w", err)
+	}
+	name := fmt.Sprintf("%s_%s.html",
+		email.CapturedAt.Format("20060102T150405.000000000"), sanitizeFileName(email.To))
+	content := fmt.Sprintf("<!-- To: %s -->\n<!-- Subject: %s -->\n%s", email.To, email.Subject, email.HTMLBody)
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("email sandbox: %w", err)
+	}
+	return nil
+}
+
+// sanitizeFileName keeps letters, digits, '@', '.', '-' and '_'
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '@', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
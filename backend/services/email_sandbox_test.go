@@ -0,0 +1,53 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailSandbox_KeepsMostRecent(t *testing.T) {
+	s := NewEmailSandbox(2, "")
+	require.NoError(t, s.Capture("a@example.com", "one", "<p>1</p>"))
+	require.NoError(t, s.Capture("b@example.com", "two", "<p>2</p>"))
+	require.NoError(t, s.Capture("a@example.com", "three", "<p>3</p>"))
+
+	recent := s.Recent("", 0)
+	require.Len(t, recent, 2)
+	assert.Equal(t, "three", recent[0].Subject)
+	assert.Equal(t, "two", recent[1].Subject)
+
+	assert.Len(t, s.Recent("", 1), 1)
+
+	toA := s.Recent("A@example.com", 0)
+	require.Len(t, toA, 1)
+	assert.Equal(t, "three", toA[0].Subject)
+
+	s.Clear()
+	assert.Empty(t, s.Recent("", 0))
+}
+
+func TestEmailSandbox_WritesFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "emails")
+	s := NewEmailSandbox(0, dir)
+	require.NoError(t, s.Capture("user@example.com", "Hello", "<p>body</p>"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, strings.HasSuffix(entries[0].Name(), "_user@example.com.html"))
+
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Subject: Hello")
+	assert.Contains(t, string(content), "<p>body</p>")
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	assert.Equal(t, "a_b@x.com", sanitizeFileName("a/b@x.com"))
+	assert.Equal(t, "..__", sanitizeFileName("../ "))
+}
@@ -2,8 +2,13 @@ package services
 
 import (
 	"fmt"
+	"html"
 	"net/smtp"
 	"os"
+	"time"
+
+	"investorcenter-api/models"
+	"investorcenter-shared/emailtmpl"
 )
 
 type EmailService struct {
@@ -14,6 +19,7 @@ type EmailService struct {
 	fromEmail    string
 	fromName     string
 	frontendURL  string
+	sandbox      *EmailSandbox // set when EMAIL_SANDBOX captures instead of sending
 }
 
 func NewEmailService() *EmailService {
@@ -25,6 +31,7 @@ func NewEmailService() *EmailService {
 		fromEmail:    os.Getenv("SMTP_FROM_EMAIL"),
 		fromName:     os.Getenv("SMTP_FROM_NAME"),
 		frontendURL:  os.Getenv("FRONTEND_URL"),
+		sandbox:      EmailSandboxFromEnv(),
 	}
 }
 
@@ -73,8 +80,76 @@ func (es *EmailService) SendPasswordResetEmail(toEmail, fullName, token string)
 	return es.sendEmail(toEmail, subject, body)
 }
 
+// SendOpsAlertEmail sends a plain-text operational alert (quota warnings and
+// the like) to an ops recipient
+func (es *EmailService) SendOpsAlertEmail(toEmail, subject, text string) error {
+	body := fmt.Sprintf(`
+		<html>
+		<body style="font-family: Arial, sans-serif;">
+			<pre style="font-family: inherit; white-space: pre-wrap;">%s</pre>
+		</body>
+		</html>
+	`, html.EscapeString(text))
+
+	return es.sendEmail(toEmail, subject, body)
+}
+
+// SendDigestEmail sends a daily or weekly digest, as plaintext and HTML in
+// the language resolved from the user's timezone, with times shown in that
+// timezone. Sections are only rendered for the content that was assembled,
+// so an empty digest still goes out as a short "nothing to report" note.
+func (es *EmailService) SendDigestEmail(toEmail, digestType, timezone string, content *models.DigestContent) error {
+	renderer, err := emailtmpl.Default()
+	if err != nil {
+		return err
+	}
+	msg, err := renderer.Render(emailtmpl.DigestTemplate, emailtmpl.ResolveLocale(timezone), digestEmailData(digestType, timezone, content, es.frontendURL))
+	if err != nil {
+		return err
+	}
+	return es.sendMessage(toEmail, msg)
+}
+
+// digestEmailData converts digest content to the template's data, with
+// times in timezone (UTC if it isn't a known zone)
+func digestEmailData(digestType, timezone string, content *models.DigestContent, frontendURL string) emailtmpl.Digest {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		loc = time.UTC
+	}
+	data := emailtmpl.Digest{
+		Weekly:      digestType == models.DigestTypeWeekly,
+		UserName:    content.UserName,
+		PeriodStart: content.PeriodStart.In(loc),
+		PeriodEnd:   content.PeriodEnd.In(loc),
+		SettingsURL: frontendURL + "/alerts",
+	}
+	if p := content.PortfolioSummary; p != nil {
+		data.Portfolio = &emailtmpl.DigestPortfolio{
+			TotalValue:    p.TotalValue,
+			DayChange:     p.DayChange,
+			DayChangePct:  p.DayChangePct,
+			WeekChange:    p.WeekChange,
+			WeekChangePct: p.WeekChangePct,
+		}
+	}
+	for _, m := range content.TopMovers {
+		data.TopMovers = append(data.TopMovers, emailtmpl.DigestMover{Symbol: m.Symbol, Name: m.Name, Price: m.Price, ChangePct: m.ChangePct})
+	}
+	for _, a := range content.RecentAlerts {
+		data.RecentAlerts = append(data.RecentAlerts, emailtmpl.DigestAlert{Symbol: a.Symbol, RuleName: a.RuleName, TriggeredAt: a.TriggeredAt.In(loc)})
+	}
+	return data
+}
+
 // sendEmail is a helper to send HTML emails via SMTP
 func (es *EmailService) sendEmail(to, subject, htmlBody string) error {
+	// In sandbox mode nothing leaves the process, even with SMTP configured
+	if es.sandbox != nil {
+		fmt.Printf("Email sandbox: captured email to %s\n", to)
+		return es.sandbox.Capture(to, subject, htmlBody)
+	}
+
 	// If SMTP is not configured, skip sending email (for development)
 	if es.smtpHost == "" || es.smtpPassword == "" {
 		fmt.Printf("SMTP not configured. Skipping email to %s\n", to)
@@ -82,8 +157,6 @@ func (es *EmailService) sendEmail(to, subject, htmlBody string) error {
 		return nil
 	}
 
-	fmt.Printf("Attempting to send email to %s via %s:%s\n", to, es.smtpHost, es.smtpPort)
-
 	from := fmt.Sprintf("%s <%s>", es.fromName, es.fromEmail)
 	msg := []byte(fmt.Sprintf("From: %s\r\n"+
 		"To: %s\r\n"+
@@ -93,6 +166,34 @@ func (es *EmailService) sendEmail(to, subject, htmlBody string) error {
 		"\r\n"+
 		"%s", from, to, subject, htmlBody))
 
+	return es.smtpSend(to, msg)
+}
+
+// sendMessage sends a rendered plaintext and HTML email via SMTP. The
+// sandbox captures its HTML part.
+func (es *EmailService) sendMessage(to string, email *emailtmpl.Message) error {
+	if es.sandbox != nil {
+		fmt.Printf("Email sandbox: captured email to %s\n", to)
+		return es.sandbox.Capture(to, email.Subject, email.HTML)
+	}
+
+	if es.smtpHost == "" || es.smtpPassword == "" {
+		fmt.Printf("SMTP not configured. Skipping email to %s\n", to)
+		fmt.Printf("Subject: %s\n", email.Subject)
+		return nil
+	}
+
+	msg, err := email.Bytes(fmt.Sprintf("%s <%s>", es.fromName, es.fromEmail), to)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	return es.smtpSend(to, msg)
+}
+
+// smtpSend sends a formatted message to to
+func (es *EmailService) smtpSend(to string, msg []byte) error {
+	fmt.Printf("Attempting to send email to %s via %s:%s\n", to, es.smtpHost, es.smtpPort)
+
 	auth := smtp.PlainAuth("", es.smtpUsername, es.smtpPassword, es.smtpHost)
 	addr := fmt.Sprintf("%s:%s", es.smtpHost, es.smtpPort)
 
@@ -3,9 +3,12 @@ package services
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
 )
 
 // ---------------------------------------------------------------------------
@@ -121,3 +124,84 @@ func TestSendPasswordResetEmail_SkipsWhenNotConfigured(t *testing.T) {
 	err := es.SendPasswordResetEmail("user@example.com", "Jane Doe", "reset-token")
 	assert.NoError(t, err)
 }
+
+// ---------------------------------------------------------------------------
+// SendDigestEmail — template generation
+// ---------------------------------------------------------------------------
+
+func TestSendDigestEmail_SkipsWhenNotConfigured(t *testing.T) {
+	es := &EmailService{
+		smtpHost:    "",
+		frontendURL: "https://app.example.com",
+	}
+
+	err := es.SendDigestEmail("user@example.com", models.DigestTypeWeekly, "America/New_York", &models.DigestContent{
+		UserName:         "Jane Doe",
+		PeriodStart:      time.Now().Add(-7 * 24 * time.Hour),
+		PeriodEnd:        time.Now(),
+		PortfolioSummary: &models.PortfolioSummary{TotalValue: 1000, DayChange: -12.5, DayChangePct: -1.23},
+		TopMovers:        []models.TopMover{{Symbol: "AAPL", Name: "Apple Inc.", Price: 190, ChangePct: 3.2}},
+	})
+	assert.NoError(t, err)
+}
+
+func TestSendDigestEmail_SandboxCapturesLocalizedDigest(t *testing.T) {
+	sandbox := NewEmailSandbox(10, "")
+	es := &EmailService{frontendURL: "https://app.example.com", sandbox: sandbox}
+
+	err := es.SendDigestEmail("user@example.com", models.DigestTypeDaily, "America/Mexico_City", &models.DigestContent{
+		UserName:    "Ana",
+		PeriodStart: time.Date(2026, 3, 9, 14, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC),
+		RecentAlerts: []models.AlertLogWithRule{{
+			AlertLog: models.AlertLog{Symbol: "AAPL", TriggeredAt: time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)},
+			RuleName: "AAPL above 200",
+		}},
+	})
+	require.NoError(t, err)
+
+	captured := sandbox.Recent("user@example.com", 0)
+	require.Len(t, captured, 1)
+	assert.Equal(t, "Tu resumen diario de InvestorCenter.ai", captured[0].Subject)
+	assert.Contains(t, captured[0].HTMLBody, "Alertas recientes")
+	// 15:30 UTC is 09:30 in Mexico City
+	assert.Contains(t, captured[0].HTMLBody, "10 Mar 2026 09:30 CST")
+	assert.Contains(t, captured[0].HTMLBody, `href="https://app.example.com/alerts"`)
+}
+
+func TestDigestEmailData_UnknownTimezoneUsesUTC(t *testing.T) {
+	start := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	data := digestEmailData(models.DigestTypeWeekly, "Not/AZone", &models.DigestContent{
+		PeriodStart:      start,
+		PortfolioSummary: &models.PortfolioSummary{TotalValue: 1000},
+	}, "https://app.example.com")
+
+	assert.True(t, data.Weekly)
+	assert.Equal(t, time.UTC, data.PeriodStart.Location())
+	require.NotNil(t, data.Portfolio)
+	assert.Equal(t, 1000.0, data.Portfolio.TotalValue)
+}
+
+// ---------------------------------------------------------------------------
+// sendEmail — sandbox mode captures instead of sending
+// ---------------------------------------------------------------------------
+
+func TestSendEmail_SandboxCapturesInsteadOfSending(t *testing.T) {
+	sandbox := NewEmailSandbox(10, "")
+	// SMTP points nowhere reachable: a real send would fail
+	es := &EmailService{
+		smtpHost:     "127.0.0.1",
+		smtpPort:     "1",
+		smtpPassword: "secret",
+		fromEmail:    "noreply@example.com",
+		frontendURL:  "https://app.example.com",
+		sandbox:      sandbox,
+	}
+
+	require.NoError(t, es.SendPasswordResetEmail("user@example.com", "Test User", "tok123"))
+
+	captured := sandbox.Recent("user@example.com", 0)
+	require.Len(t, captured, 1)
+	assert.Equal(t, "Reset your InvestorCenter.ai password", captured[0].Subject)
+	assert.Contains(t, captured[0].HTMLBody, "https://app.example.com/auth/reset-password?token=tok123")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 type FinancialsService struct {
 	polygonClient *PolygonFinancialsClient
 	icScoreClient *ICScoreClient
+	secClient     *SECEdgarClient
 }
 
 // NewFinancialsService creates a new financials service
@@ -22,6 +24,7 @@ func NewFinancialsService() *FinancialsService {
 	return &FinancialsService{
 		polygonClient: NewPolygonFinancialsClient(),
 		icScoreClient: NewICScoreClient(),
+		secClient:     NewSECEdgarClient(),
 	}
 }
 
@@ -42,10 +45,25 @@ func (s *FinancialsService) IngestFinancials(ctx context.Context, ticker string)
 		Limit:     100, // Get up to 100 quarters (25 years)
 	}
 
-	// Fetch all financial data
+	// Fetch all financial data, falling back to SEC EDGAR when Polygon has
+	// nothing for the ticker
 	financials, err := s.polygonClient.GetAllFinancialsWithPagination(ctx, params)
-	if err != nil {
-		return fmt.Errorf("failed to fetch financials from Polygon: %w", err)
+	if err != nil || len(financials) == 0 {
+		if err != nil {
+			log.Printf("Polygon financials failed for %s, trying SEC EDGAR: %v", ticker, err)
+		} else {
+			log.Printf("Polygon has no financials for %s, trying SEC EDGAR", ticker)
+		}
+		secErr := s.ingestFromSEC(ctx, ticker, tickerID)
+		if secErr == nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch financials from Polygon: %w (SEC EDGAR fallback: %v)", err, secErr)
+		}
+		// As before the fallback, a ticker no source covers isn't an error
+		log.Printf("SEC EDGAR fallback failed for %s: %v", ticker, secErr)
+		return nil
 	}
 
 	log.Printf("Fetched %d financial records for %s", len(financials), ticker)
@@ -113,6 +131,42 @@ func (s *FinancialsService) IngestFinancials(ctx context.Context, ticker string)
 	return nil
 }
 
+// ingestFromSEC stores quarterly statements built from the ticker's SEC
+// EDGAR company facts, found by the CIK on the tickers table
+func (s *FinancialsService) ingestFromSEC(ctx context.Context, ticker string, tickerID int) error {
+	metadata, err := database.GetCompanyMetadata(ticker)
+	if err != nil {
+		return err
+	}
+	if metadata.CIK == nil {
+		return fmt.Errorf("no CIK on file for %s", ticker)
+	}
+
+	facts, err := s.secClient.GetCompanyFacts(ctx, *metadata.CIK)
+	if err != nil {
+		return err
+	}
+	statements := ConvertSECCompanyFacts(facts, tickerID)
+	if len(statements) == 0 {
+		return fmt.Errorf("no quarterly statements in SEC company facts for %s", ticker)
+	}
+
+	var stored int
+	for _, stmt := range statements {
+		if err := database.UpsertFinancialStatement(stmt); err != nil {
+			log.Printf("Warning: Failed to store SEC %s statement for %s: %v", stmt.StatementType, ticker, err)
+			continue
+		}
+		stored++
+	}
+
+	log.Printf("Completed SEC EDGAR ingestion for %s: %d of %d statements stored", ticker, stored, len(statements))
+	if stored == 0 {
+		return fmt.Errorf("failed to store any SEC financial statements for %s", ticker)
+	}
+	return nil
+}
+
 // IngestFinancialsIfNeeded checks if data needs to be refreshed and ingests if necessary
 func (s *FinancialsService) IngestFinancialsIfNeeded(ctx context.Context, ticker string) error {
 	// Check if we have any data
@@ -149,6 +203,15 @@ func (s *FinancialsService) GetCashFlowStatements(ctx context.Context, ticker st
 	return s.getStatements(ctx, ticker, models.StatementTypeCashFlow, timeframe, limit)
 }
 
+// GetTTMFromStatements rolls up trailing-twelve-month figures from the
+// stored quarterly statements, ingesting them first if there are none
+func (s *FinancialsService) GetTTMFromStatements(ctx context.Context, ticker string) (*models.TTMFinancials, error) {
+	if err := s.IngestFinancialsIfNeeded(ctx, ticker); err != nil {
+		log.Printf("Warning: Failed to ingest financials for %s: %v", ticker, err)
+	}
+	return database.GetTTMFromStatements(ticker)
+}
+
 // GetRatios returns financial ratios for a ticker from IC Score calculated data
 func (s *FinancialsService) GetRatios(ctx context.Context, ticker string, timeframe models.Timeframe, limit int) (*models.FinancialsResponse, error) {
 	// Get company metadata
@@ -318,6 +381,133 @@ func EnrichCashFlowData(data map[string]interface{}) map[string]interface{} {
 	return enriched
 }
 
+// CommonSizeBases lists, per statement type, the line items tried in order
+// as the base of a common-size statement
+var CommonSizeBases = map[models.StatementType][]string{
+	models.StatementTypeIncome:       {"revenue", "revenues"},
+	models.StatementTypeBalanceSheet: {"total_assets"},
+}
+
+// CommonSizeData expresses each amount in data as a fraction of the first
+// base line item present and non-zero. Ratios, margins, per-share values and
+// share counts are left out since they aren't amounts. When no base is
+// usable every amount maps to nil. data is not modified.
+func CommonSizeData(data map[string]interface{}, baseKeys []string) map[string]*float64 {
+	var base float64
+	for _, key := range baseKeys {
+		if v, ok := FinancialAmount(data[key]); ok && v != 0 {
+			base = v
+			break
+		}
+	}
+
+	common := make(map[string]*float64)
+	for key, raw := range data {
+		if !isCommonSizeAmount(key) {
+			continue
+		}
+		v, ok := FinancialAmount(raw)
+		if !ok {
+			continue
+		}
+		if base == 0 {
+			common[key] = nil
+			continue
+		}
+		ratio := v / base
+		common[key] = &ratio
+	}
+	return common
+}
+
+// FinancialAmount reads a numeric line item, unwrapping Polygon-style
+// {"value": n} objects
+func FinancialAmount(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case map[string]interface{}:
+		return FinancialAmount(v["value"])
+	}
+	return 0, false
+}
+
+// isCommonSizeAmount reports whether a line item is a currency amount that
+// can be divided by the statement's base
+func isCommonSizeAmount(key string) bool {
+	for _, suffix := range []string{"_label", "_unit", "_margin", "_ratio", "_per_share"} {
+		if strings.HasSuffix(key, suffix) {
+			return false
+		}
+	}
+	if strings.HasPrefix(key, "return_on_") || strings.Contains(key, "shares") {
+		return false
+	}
+	switch key {
+	case "debt_to_equity", "effective_tax_rate":
+		return false
+	}
+	return true
+}
+
+// AddPeriodDeltas sets each period's Deltas to the change in every numeric
+// line item versus the period with the same fiscal quarter one fiscal year
+// earlier. Matching on fiscal year and quarter rather than dates compares
+// like with like for companies whose fiscal year isn't the calendar year. A
+// line item maps to nil when the prior period or its value is missing.
+// Data is not modified.
+func AddPeriodDeltas(periods []models.FinancialPeriod) {
+	type periodKey struct{ year, quarter int }
+	keyOf := func(p models.FinancialPeriod) periodKey {
+		k := periodKey{year: p.FiscalYear}
+		if p.FiscalQuarter != nil {
+			k.quarter = *p.FiscalQuarter
+		}
+		return k
+	}
+
+	byKey := make(map[periodKey]int, len(periods))
+	for i, p := range periods {
+		if _, seen := byKey[keyOf(p)]; !seen {
+			byKey[keyOf(p)] = i
+		}
+	}
+
+	for i, p := range periods {
+		key := keyOf(p)
+		key.year--
+		var prior map[string]interface{}
+		if j, ok := byKey[key]; ok {
+			prior = periods[j].Data
+		}
+
+		deltas := make(map[string]*models.LineItemDelta)
+		for item, raw := range p.Data {
+			if strings.HasSuffix(item, "_label") || strings.HasSuffix(item, "_unit") {
+				continue
+			}
+			current, ok := FinancialAmount(raw)
+			if !ok {
+				continue
+			}
+			previous, ok := FinancialAmount(prior[item])
+			if !ok {
+				deltas[item] = nil
+				continue
+			}
+			deltas[item] = &models.LineItemDelta{
+				Change:        current - previous,
+				PercentChange: CalculateYoYChange(current, previous),
+			}
+		}
+		periods[i].Deltas = deltas
+	}
+}
+
 // BatchIngestFinancials ingests financial data for multiple tickers
 func (s *FinancialsService) BatchIngestFinancials(ctx context.Context, tickers []string) map[string]error {
 	results := make(map[string]error)
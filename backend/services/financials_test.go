@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
 )
 
 // ---------------------------------------------------------------------------
@@ -162,3 +164,90 @@ func TestNewFinancialsService(t *testing.T) {
 	assert.NotNil(t, svc.polygonClient)
 	assert.NotNil(t, svc.icScoreClient)
 }
+
+// ---------------------------------------------------------------------------
+// CommonSizeData
+// ---------------------------------------------------------------------------
+
+func TestCommonSizeData_DividesByBase(t *testing.T) {
+	data := map[string]interface{}{
+		"revenue":                    float64(200),
+		"cost_of_revenue":            float64(120),
+		"net_income":                 map[string]interface{}{"value": float64(-20)},
+		"diluted_earnings_per_share": 1.5,
+		"net_margin":                 -0.1,
+		"weighted_average_shares":    float64(1000),
+		"revenue_label":              "Revenue",
+	}
+
+	common := CommonSizeData(data, CommonSizeBases[models.StatementTypeIncome])
+
+	assert.InDelta(t, 1.0, *common["revenue"], 1e-9)
+	assert.InDelta(t, 0.6, *common["cost_of_revenue"], 1e-9)
+	assert.InDelta(t, -0.1, *common["net_income"], 1e-9)
+	assert.Len(t, common, 3, "per-share values, margins, share counts and labels are skipped")
+	assert.Equal(t, float64(200), data["revenue"], "data is not modified")
+}
+
+func TestCommonSizeData_FallsBackToNextBase(t *testing.T) {
+	data := map[string]interface{}{"revenue": float64(0), "revenues": float64(50), "gross_profit": float64(10)}
+
+	common := CommonSizeData(data, CommonSizeBases[models.StatementTypeIncome])
+
+	assert.InDelta(t, 0.2, *common["gross_profit"], 1e-9)
+}
+
+func TestCommonSizeData_MissingBase(t *testing.T) {
+	data := map[string]interface{}{"total_liabilities": float64(600)}
+
+	common := CommonSizeData(data, CommonSizeBases[models.StatementTypeBalanceSheet])
+
+	assert.Contains(t, common, "total_liabilities")
+	assert.Nil(t, common["total_liabilities"])
+}
+
+// ---------------------------------------------------------------------------
+// AddPeriodDeltas
+// ---------------------------------------------------------------------------
+
+func TestAddPeriodDeltas_Quarterly(t *testing.T) {
+	q := func(n int) *int { return &n }
+	// Fiscal year ends in June: Q1 FY2025 ends 2024-09-30
+	periods := []models.FinancialPeriod{
+		{FiscalYear: 2025, FiscalQuarter: q(1), PeriodEnd: "2024-09-30", Data: map[string]interface{}{"revenue": float64(120), "net_income": float64(10)}},
+		{FiscalYear: 2024, FiscalQuarter: q(4), PeriodEnd: "2024-06-30", Data: map[string]interface{}{"revenue": float64(200)}},
+		{FiscalYear: 2024, FiscalQuarter: q(1), PeriodEnd: "2023-09-30", Data: map[string]interface{}{"revenue": float64(100), "net_income": float64(0)}},
+	}
+
+	AddPeriodDeltas(periods)
+
+	revenue := periods[0].Deltas["revenue"]
+	require.NotNil(t, revenue)
+	assert.InDelta(t, 20.0, revenue.Change, 1e-9, "compared with Q1 FY2024, not the sequential Q4")
+	assert.InDelta(t, 0.2, *revenue.PercentChange, 1e-9)
+
+	netIncome := periods[0].Deltas["net_income"]
+	require.NotNil(t, netIncome)
+	assert.InDelta(t, 10.0, netIncome.Change, 1e-9)
+	assert.Nil(t, netIncome.PercentChange, "no percent change from zero")
+
+	assert.Contains(t, periods[1].Deltas, "revenue")
+	assert.Nil(t, periods[1].Deltas["revenue"], "no Q4 FY2023 to compare with")
+	assert.Equal(t, float64(120), periods[0].Data["revenue"], "data is not modified")
+}
+
+func TestAddPeriodDeltas_Annual(t *testing.T) {
+	periods := []models.FinancialPeriod{
+		{FiscalYear: 2024, Data: map[string]interface{}{"total_assets": float64(90), "total_liabilities": float64(50)}},
+		{FiscalYear: 2023, Data: map[string]interface{}{"total_assets": map[string]interface{}{"value": float64(100)}}},
+	}
+
+	AddPeriodDeltas(periods)
+
+	assets := periods[0].Deltas["total_assets"]
+	require.NotNil(t, assets)
+	assert.InDelta(t, -10.0, assets.Change, 1e-9)
+	assert.InDelta(t, -0.1, *assets.PercentChange, 1e-9)
+	assert.Nil(t, periods[0].Deltas["total_liabilities"], "missing in the prior year")
+	assert.Nil(t, periods[1].Deltas["total_assets"])
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrFMPNoData means FMP answered but has nothing for the symbol, as
+// opposed to a failed request
+var ErrFMPNoData = errors.New("no FMP data found")
+
+// FMPMaxBatchSymbols is the most symbols sent in one comma-separated FMP
+// request; larger lists are split across several requests
+var FMPMaxBatchSymbols = 50
+
+// ============================================================================
+// FMP Batch Fetch Methods
+// ============================================================================
+
+// GetRatiosTTMBatch fetches TTM ratios for many tickers, FMPMaxBatchSymbols
+// per request, keyed by upper-cased symbol. FMP omits symbols it has no data
+// for, so callers should treat a missing key as "no data". If a request
+// fails, the ratios from the requests that succeeded are returned with the
+// error.
+func (c *FMPClient) GetRatiosTTMBatch(tickers []string) (map[string]*FMPRatiosTTM, error) {
+	results := make(map[string]*FMPRatiosTTM, len(tickers))
+	var firstErr error
+	for _, chunk := range chunkSymbols(tickers, FMPMaxBatchSymbols) {
+		var ratios []FMPRatiosTTM
+		if err := c.getBatch("ratios-ttm", chunk, &ratios); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for i := range ratios {
+			results[strings.ToUpper(ratios[i].Symbol)] = &ratios[i]
+		}
+	}
+	return results, firstErr
+}
+
+// GetScoreBatch fetches Altman Z and Piotroski F scores for many tickers,
+// with the same batching and partial-response behaviour as GetRatiosTTMBatch
+func (c *FMPClient) GetScoreBatch(tickers []string) (map[string]*FMPScore, error) {
+	results := make(map[string]*FMPScore, len(tickers))
+	var firstErr error
+	for _, chunk := range chunkSymbols(tickers, FMPMaxBatchSymbols) {
+		var scores []FMPScore
+		if err := c.getBatch("score", chunk, &scores); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for i := range scores {
+			results[strings.ToUpper(scores[i].Symbol)] = &scores[i]
+		}
+	}
+	return results, firstErr
+}
+
+// GetFundamentalsBatch fetches TTM ratios and scores for a set of tickers,
+// keyed by upper-cased symbol. A single ticker uses the per-symbol endpoints;
+// more than one uses the batch endpoints so a bulk refresh costs a couple of
+// requests per FMPMaxBatchSymbols tickers instead of two per ticker. Every
+// requested ticker gets an entry; missing data is recorded in its Errors.
+func (c *FMPClient) GetFundamentalsBatch(tickers []string) map[string]*FMPAllMetrics {
+	symbols := uniqueSymbols(tickers)
+	results := make(map[string]*FMPAllMetrics, len(symbols))
+	for _, symbol := range symbols {
+		results[symbol] = &FMPAllMetrics{Errors: make(map[string]error)}
+	}
+
+	if len(symbols) == 1 {
+		m := results[symbols[0]]
+		if data, err := c.GetRatiosTTM(symbols[0]); err != nil {
+			m.Errors["ratios-ttm"] = err
+		} else {
+			m.RatiosTTM = data
+		}
+		if data, err := c.GetScore(symbols[0]); err != nil {
+			m.Errors["score"] = err
+		} else {
+			m.Score = data
+		}
+		return results
+	}
+
+	var (
+		wg                  sync.WaitGroup
+		ratios              map[string]*FMPRatiosTTM
+		scores              map[string]*FMPScore
+		ratiosErr, scoreErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ratios, ratiosErr = c.GetRatiosTTMBatch(symbols)
+	}()
+	go func() {
+		defer wg.Done()
+		scores, scoreErr = c.GetScoreBatch(symbols)
+	}()
+	wg.Wait()
+
+	for symbol, m := range results {
+		if data, ok := ratios[symbol]; ok {
+			m.RatiosTTM = data
+		} else {
+			m.Errors["ratios-ttm"] = missingBatchData(symbol, ratiosErr)
+		}
+		if data, ok := scores[symbol]; ok {
+			m.Score = data
+		} else {
+			m.Errors["score"] = missingBatchData(symbol, scoreErr)
+		}
+	}
+	return results
+}
+
+// getBatch requests endpoint for a comma-separated list of symbols and
+// decodes the JSON array response into out
+func (c *FMPClient) getBatch(endpoint string, symbols []string, out interface{}) error {
+	if c.APIKey == "" {
+		return fmt.Errorf("FMP API key not configured")
+	}
+
+	params := url.Values{}
+	params.Set("symbol", strings.Join(symbols, ","))
+	params.Set("apikey", c.APIKey)
+	reqURL := fmt.Sprintf("%s/%s?%s", FMPBaseURL, endpoint, params.Encode())
+
+	resp, err := c.doRequest(reqURL)
+	if err != nil {
+		return fmt.Errorf("FMP %s batch request failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("FMP %s batch returned status %d", endpoint, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode FMP %s batch response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// missingBatchData explains why a batch response had nothing for symbol:
+// either its request failed or FMP has no data for it
+func missingBatchData(symbol string, requestErr error) error {
+	if requestErr != nil {
+		return requestErr
+	}
+	return fmt.Errorf("%w for %s", ErrFMPNoData, symbol)
+}
+
+// uniqueSymbols upper-cases tickers and drops blanks and duplicates,
+// preserving order
+func uniqueSymbols(tickers []string) []string {
+	seen := make(map[string]bool, len(tickers))
+	symbols := make([]string, 0, len(tickers))
+	for _, t := range tickers {
+		symbol := strings.ToUpper(strings.TrimSpace(t))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// chunkSymbols normalizes tickers with uniqueSymbols and splits them into
+// groups of at most size
+func chunkSymbols(tickers []string, size int) [][]string {
+	symbols := uniqueSymbols(tickers)
+	if size <= 0 {
+		size = len(symbols)
+	}
+	var chunks [][]string
+	for len(symbols) > 0 {
+		n := size
+		if n > len(symbols) {
+			n = len(symbols)
+		}
+		chunks = append(chunks, symbols[:n])
+		symbols = symbols[n:]
+	}
+	return chunks
+}
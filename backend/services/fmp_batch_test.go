@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFMP_GetRatiosTTMBatch_ChunksAndDemultiplexes(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/ratios-ttm")
+		assert.Equal(t, "test-key", r.URL.Query().Get("apikey"))
+		symbols := r.URL.Query().Get("symbol")
+		mu.Lock()
+		requested = append(requested, symbols)
+		mu.Unlock()
+
+		// FMP omits symbols it has no data for (ZZZZ here)
+		var out []FMPRatiosTTM
+		for _, s := range strings.Split(symbols, ",") {
+			if s != "ZZZZ" {
+				pe := float64(len(s))
+				out = append(out, FMPRatiosTTM{Symbol: s, PriceToEarningsRatioTTM: &pe})
+			}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+	origBatch := FMPMaxBatchSymbols
+	FMPMaxBatchSymbols = 2
+	defer func() { FMPMaxBatchSymbols = origBatch }()
+
+	client := newFMPTestClient(server.URL)
+	ratios, err := client.GetRatiosTTMBatch([]string{"aapl", "MSFT", "ZZZZ", "AAPL", "GOOGL"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAPL,MSFT", "ZZZZ,GOOGL"}, requested, "deduplicated and split into batches")
+	require.Len(t, ratios, 3)
+	assert.Equal(t, 4.0, *ratios["MSFT"].PriceToEarningsRatioTTM)
+	assert.Equal(t, 5.0, *ratios["GOOGL"].PriceToEarningsRatioTTM)
+	assert.NotContains(t, ratios, "ZZZZ")
+}
+
+func TestFMP_GetScoreBatch_PartialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/score")
+		if strings.Contains(r.URL.Query().Get("symbol"), "TSLA") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		z := 3.1
+		json.NewEncoder(w).Encode([]FMPScore{{Symbol: "AAPL", AltmanZScore: &z}})
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+	origBatch := FMPMaxBatchSymbols
+	FMPMaxBatchSymbols = 2
+	defer func() { FMPMaxBatchSymbols = origBatch }()
+
+	client := newFMPTestClient(server.URL)
+	scores, err := client.GetScoreBatch([]string{"AAPL", "MSFT", "TSLA"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+	require.Len(t, scores, 1, "scores from successful batches are still returned")
+	assert.Equal(t, 3.1, *scores["AAPL"].AltmanZScore)
+}
+
+func TestFMP_GetFundamentalsBatch(t *testing.T) {
+	var mu sync.Mutex
+	paths := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		symbols := r.URL.Query().Get("symbol")
+		mu.Lock()
+		paths[r.URL.Path] = symbols
+		mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/ratios-ttm"):
+			json.NewEncoder(w).Encode([]FMPRatiosTTM{{Symbol: "AAPL"}, {Symbol: "MSFT"}})
+		case strings.HasSuffix(r.URL.Path, "/score"):
+			json.NewEncoder(w).Encode([]FMPScore{{Symbol: "AAPL"}})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	results := client.GetFundamentalsBatch([]string{"AAPL", "msft"})
+
+	assert.Len(t, paths, 2, "one request per endpoint for the whole batch")
+	assert.Equal(t, "AAPL,MSFT", paths["/ratios-ttm"])
+	require.Len(t, results, 2)
+	assert.NotNil(t, results["AAPL"].RatiosTTM)
+	assert.NotNil(t, results["AAPL"].Score)
+	assert.Empty(t, results["AAPL"].Errors)
+	assert.NotNil(t, results["MSFT"].RatiosTTM)
+	assert.Nil(t, results["MSFT"].Score)
+	assert.Contains(t, results["MSFT"].Errors["score"].Error(), "no FMP data found for MSFT")
+	assert.ErrorIs(t, results["MSFT"].Errors["score"], ErrFMPNoData)
+}
+
+func TestFMP_GetFundamentalsBatch_SingleTicker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AAPL", r.URL.Query().Get("symbol"))
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/ratios-ttm"):
+			json.NewEncoder(w).Encode([]FMPRatiosTTM{{Symbol: "AAPL"}})
+		case strings.HasSuffix(r.URL.Path, "/score"):
+			json.NewEncoder(w).Encode([]FMPScore{})
+		}
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	results := client.GetFundamentalsBatch([]string{"aapl"})
+
+	require.Len(t, results, 1)
+	assert.NotNil(t, results["AAPL"].RatiosTTM)
+	assert.ErrorIs(t, results["AAPL"].Errors["score"], ErrFMPNoData)
+}
+
+func TestFMP_GetRatiosTTMBatch_NoAPIKey(t *testing.T) {
+	client := &FMPClient{APIKey: ""}
+	ratios, err := client.GetRatiosTTMBatch([]string{"AAPL", "MSFT"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API key not configured")
+	assert.Empty(t, ratios)
+}
+
+func TestChunkSymbols(t *testing.T) {
+	assert.Equal(t, [][]string{{"A", "B"}, {"C"}}, chunkSymbols([]string{"a", " b ", "", "A", "c"}, 2))
+	assert.Nil(t, chunkSymbols(nil, 2))
+	assert.Equal(t, [][]string{{"A", "B"}}, chunkSymbols([]string{"A", "B"}, 0))
+}
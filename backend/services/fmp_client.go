@@ -1,24 +1,42 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"net/http"
 	"os"
 	"sync"
 	"time"
+
+	"investorcenter-api/httputil"
 )
 
 var (
 	FMPBaseURL = "https://financialmodelingprep.com/stable"
+
+	// FMPRetryBaseDelay is the backoff before the first retry; each further
+	// retry doubles it
+	FMPRetryBaseDelay = 500 * time.Millisecond
+	// FMPMaxRetryDelay caps both the backoff and a server's Retry-After
+	FMPMaxRetryDelay = 30 * time.Second
+
+	// fmpSleep waits between retries, swapped out in tests
+	fmpSleep = time.Sleep
 )
 
 // FMPClient handles Financial Modeling Prep API requests
 type FMPClient struct {
 	APIKey string
 	Client *http.Client
+	// MaxRetries is how many times a request is retried after a 429, 5xx or
+	// transport error. Zero disables retries.
+	MaxRetries int
+	// RequestTimeout bounds each attempt. Zero leaves timeouts to Client.
+	RequestTimeout time.Duration
 }
 
 // ============================================================================
@@ -327,20 +345,81 @@ type FMPPriceTargetSummary struct {
 // Client Constructor
 // ============================================================================
 
-// NewFMPClient creates a new FMP API client
+// NewFMPClient creates a new FMP API client. Requests are retried up to
+// FMP_MAX_RETRIES times (default 2, so 3 attempts), each attempt with its
+// own 10s timeout.
 func NewFMPClient() *FMPClient {
 	apiKey := os.Getenv("FMP_API_KEY")
 	if apiKey == "" {
 		log.Println("Warning: FMP_API_KEY not set, FMP features will be disabled")
 	}
 	return &FMPClient{
-		APIKey: apiKey,
-		Client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		APIKey:         apiKey,
+		Client:         upstreamClient("fmp"),
+		MaxRetries:     maxRetriesFromEnv("FMP_MAX_RETRIES"),
+		RequestTimeout: 10 * time.Second,
+	}
+}
+
+// ============================================================================
+// Request Helpers
+// ============================================================================
+
+// doRequest GETs url, retrying 429 and 5xx responses and transport errors
+// with jittered exponential backoff (see httputil.DoWithRetry). Once retries
+// run out the last response is returned so callers report its status as
+// before.
+func (c *FMPClient) doRequest(url string) (*http.Response, error) {
+	return httputil.DoWithRetry(c.retryPolicy(), func() (*http.Response, error) {
+		UpstreamQuota().Record(QuotaSourceFMP)
+		return c.get(url)
+	})
+}
+
+func (c *FMPClient) retryPolicy() httputil.RetryPolicy {
+	return httputil.RetryPolicy{
+		MaxRetries: c.MaxRetries,
+		BaseDelay:  FMPRetryBaseDelay,
+		MaxDelay:   FMPMaxRetryDelay,
+		Jitter:     0.5,
+		Sleep:      fmpSleep,
 	}
 }
 
+// get makes a single attempt, bounded by RequestTimeout. The timeout stays
+// in force until the response body is closed.
+func (c *FMPClient) get(url string) (*http.Response, error) {
+	if c.RequestTimeout <= 0 {
+		return c.Client.Get(url)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.RequestTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // ============================================================================
 // API Fetch Functions
 // ============================================================================
@@ -353,7 +432,7 @@ func (c *FMPClient) GetRatiosTTM(ticker string) (*FMPRatiosTTM, error) {
 
 	url := fmt.Sprintf("%s/ratios-ttm?symbol=%s&apikey=%s", FMPBaseURL, ticker, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP API request failed: %w", err)
 	}
@@ -369,7 +448,7 @@ func (c *FMPClient) GetRatiosTTM(ticker string) (*FMPRatiosTTM, error) {
 	}
 
 	if len(results) == 0 {
-		return nil, fmt.Errorf("no FMP data found for %s", ticker)
+		return nil, fmt.Errorf("%w for %s", ErrFMPNoData, ticker)
 	}
 
 	return &results[0], nil
@@ -383,7 +462,7 @@ func (c *FMPClient) GetKeyMetricsTTM(ticker string) (*FMPKeyMetricsTTM, error) {
 
 	url := fmt.Sprintf("%s/key-metrics-ttm?symbol=%s&apikey=%s", FMPBaseURL, ticker, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP key-metrics-ttm request failed: %w", err)
 	}
@@ -414,7 +493,7 @@ func (c *FMPClient) GetFinancialGrowth(ticker string, limit int) ([]FMPFinancial
 	url := fmt.Sprintf("%s/financial-growth?symbol=%s&period=annual&limit=%d&apikey=%s",
 		FMPBaseURL, ticker, limit, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP financial-growth request failed: %w", err)
 	}
@@ -441,7 +520,7 @@ func (c *FMPClient) GetAnalystEstimates(ticker string, limit int) ([]FMPAnalystE
 	url := fmt.Sprintf("%s/analyst-estimates?symbol=%s&limit=%d&apikey=%s",
 		FMPBaseURL, ticker, limit, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP analyst-estimates request failed: %w", err)
 	}
@@ -467,7 +546,7 @@ func (c *FMPClient) GetScore(ticker string) (*FMPScore, error) {
 
 	url := fmt.Sprintf("%s/score?symbol=%s&apikey=%s", FMPBaseURL, ticker, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP score request failed: %w", err)
 	}
@@ -483,7 +562,7 @@ func (c *FMPClient) GetScore(ticker string) (*FMPScore, error) {
 	}
 
 	if len(results) == 0 {
-		return nil, fmt.Errorf("no FMP score data found for %s", ticker)
+		return nil, fmt.Errorf("%w for %s", ErrFMPNoData, ticker)
 	}
 
 	return &results[0], nil
@@ -498,7 +577,7 @@ func (c *FMPClient) GetDividendHistory(ticker string) ([]FMPDividendHistorical,
 	url := fmt.Sprintf("%s/historical-price-eod/dividend/%s?apikey=%s",
 		FMPBaseURL, ticker, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP dividend history request failed: %w", err)
 	}
@@ -526,7 +605,7 @@ func (c *FMPClient) GetGradesSummary(ticker string) (*FMPGradesSummary, error) {
 
 	url := fmt.Sprintf("%s/grades-summary?symbol=%s&apikey=%s", FMPBaseURL, ticker, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP grades-summary request failed: %w", err)
 	}
@@ -556,7 +635,7 @@ func (c *FMPClient) GetPriceTargetConsensus(ticker string) (*FMPPriceTargetConse
 
 	url := fmt.Sprintf("%s/price-target-consensus?symbol=%s&apikey=%s", FMPBaseURL, ticker, c.APIKey)
 
-	resp, err := c.Client.Get(url)
+	resp, err := c.doRequest(url)
 	if err != nil {
 		return nil, fmt.Errorf("FMP price-target-consensus request failed: %w", err)
 	}
@@ -79,7 +79,7 @@ func (c *FMPClient) GetEarnings(ticker string) ([]FMPEarningsRecord, error) {
 	params.Set("apikey", c.APIKey)
 	reqURL := fmt.Sprintf("%s/earnings?%s", FMPBaseURL, params.Encode())
 
-	resp, err := c.Client.Get(reqURL)
+	resp, err := c.doRequest(reqURL)
 	if err != nil {
 		return nil, fmt.Errorf("FMP earnings request failed: %w", err)
 	}
@@ -109,7 +109,7 @@ func (c *FMPClient) GetEarningsCalendar(from, to string) ([]FMPEarningsRecord, e
 	params.Set("apikey", c.APIKey)
 	reqURL := fmt.Sprintf("%s/earnings-calendar?%s", FMPBaseURL, params.Encode())
 
-	resp, err := c.Client.Get(reqURL)
+	resp, err := c.doRequest(reqURL)
 	if err != nil {
 		return nil, fmt.Errorf("FMP earnings-calendar request failed: %w", err)
 	}
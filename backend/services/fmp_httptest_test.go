@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -388,7 +389,7 @@ func TestFMP_GetScore_EmptyResults(t *testing.T) {
 
 	_, err := client.GetScore("FAKE")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "no FMP score data")
+	assert.ErrorIs(t, err, ErrFMPNoData)
 }
 
 func TestFMP_GetScore_ServerError(t *testing.T) {
@@ -743,6 +744,143 @@ func TestFMP_GetAllMetrics_PartialFailures(t *testing.T) {
 	assert.True(t, len(result.Errors) > 0, "should have recorded some errors")
 }
 
+// ===========================================================================
+// doRequest retries
+// ===========================================================================
+
+// recordFMPSleeps replaces the retry sleep with one that records delays
+func recordFMPSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	orig := fmpSleep
+	fmpSleep = func(d time.Duration) { delays = append(delays, d) }
+	t.Cleanup(func() { fmpSleep = orig })
+	return &delays
+}
+
+func TestFMP_DoRequest_RetriesTransientErrors(t *testing.T) {
+	delays := recordFMPSleeps(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			json.NewEncoder(w).Encode([]FMPScore{{Symbol: "AAPL"}})
+		}
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	client.MaxRetries = 2
+
+	score, err := client.GetScore("AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, "AAPL", score.Symbol)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	require.Len(t, *delays, 2)
+	assert.GreaterOrEqual(t, (*delays)[0], FMPRetryBaseDelay/2, "first backoff is jittered around the base delay")
+	assert.Less(t, (*delays)[0], FMPRetryBaseDelay)
+	assert.Equal(t, 3*time.Second, (*delays)[1], "Retry-After is honored")
+}
+
+func TestFMP_DoRequest_GivesUpAfterMaxRetries(t *testing.T) {
+	delays := recordFMPSleeps(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	client.MaxRetries = 2
+
+	_, err := client.GetRatiosTTM("AAPL")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, []time.Duration{FMPMaxRetryDelay, FMPMaxRetryDelay}, *delays, "Retry-After is capped")
+}
+
+func TestFMP_DoRequest_DoesNotRetryClientErrors(t *testing.T) {
+	delays := recordFMPSleeps(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	client.MaxRetries = 2
+
+	_, err := client.GetRatiosTTM("AAPL")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Empty(t, *delays)
+}
+
+func TestFMP_DoRequest_PerAttemptTimeout(t *testing.T) {
+	recordFMPSleeps(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		json.NewEncoder(w).Encode([]FMPRatiosTTM{{Symbol: "AAPL"}})
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	client.MaxRetries = 1
+	client.RequestTimeout = 50 * time.Millisecond
+
+	ratios, err := client.GetRatiosTTM("AAPL")
+	require.NoError(t, err, "the slow first attempt times out and the retry succeeds")
+	assert.Equal(t, "AAPL", ratios.Symbol)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNewFMPClient_MaxRetriesFromEnv(t *testing.T) {
+	t.Setenv("FMP_MAX_RETRIES", "")
+	assert.Equal(t, 2, NewFMPClient().MaxRetries)
+
+	t.Setenv("FMP_MAX_RETRIES", "5")
+	assert.Equal(t, 5, NewFMPClient().MaxRetries)
+
+	t.Setenv("FMP_MAX_RETRIES", "-1")
+	assert.Equal(t, 0, NewFMPClient().MaxRetries)
+}
+
 // contains checks if s contains substr (helper for routing in test server).
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && len(substr) > 0 && containsStr(s, substr))
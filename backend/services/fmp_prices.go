@@ -0,0 +1,146 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"investorcenter-api/models"
+)
+
+// ============================================================================
+// FMP Historical Price Structs
+// ============================================================================
+
+// FMPHistoricalPrice is one daily bar from the FMP historical-price-eod/full
+// endpoint. AdjClose is zero when the endpoint doesn't report it.
+type FMPHistoricalPrice struct {
+	Date     string  `json:"date"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	AdjClose float64 `json:"adjClose"`
+	Volume   int64   `json:"volume"`
+}
+
+// ============================================================================
+// Historical Price Cache
+// ============================================================================
+
+// FMPHistoricalCacheTTL is how long a ticker's price range is served from
+// memory before FMP is queried again
+var FMPHistoricalCacheTTL = 15 * time.Minute
+
+// historicalPriceCache caches FMP price ranges in memory, keyed by ticker and
+// date range, and is shared by all FMPClients
+type historicalPriceCache struct {
+	mu      sync.Mutex
+	entries map[string]historicalPriceEntry
+}
+
+type historicalPriceEntry struct {
+	prices   []FMPHistoricalPrice
+	cachedAt time.Time
+}
+
+var fmpHistoricalCache = &historicalPriceCache{entries: make(map[string]historicalPriceEntry)}
+
+func (c *historicalPriceCache) get(key string) ([]FMPHistoricalPrice, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Since(entry.cachedAt) > FMPHistoricalCacheTTL {
+		return nil, false
+	}
+	return entry.prices, true
+}
+
+func (c *historicalPriceCache) set(key string, prices []FMPHistoricalPrice) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired ranges so the cache doesn't grow with every ticker viewed
+	for k, entry := range c.entries {
+		if time.Since(entry.cachedAt) > FMPHistoricalCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = historicalPriceEntry{prices: prices, cachedAt: time.Now()}
+}
+
+// ============================================================================
+// FMP Historical Price Fetch Methods
+// ============================================================================
+
+// GetHistoricalPrices fetches daily OHLCV bars for a ticker between from and
+// to (inclusive, by date), newest first as FMP returns them. Results are
+// cached for FMPHistoricalCacheTTL so repeated chart loads don't re-hit the API.
+func (c *FMPClient) GetHistoricalPrices(ticker string, from, to time.Time) ([]FMPHistoricalPrice, error) {
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("FMP API key not configured")
+	}
+
+	ticker = strings.ToUpper(ticker)
+	fromDate, toDate := from.Format("2006-01-02"), to.Format("2006-01-02")
+	cacheKey := ticker + "|" + fromDate + "|" + toDate
+	if prices, ok := fmpHistoricalCache.get(cacheKey); ok {
+		return prices, nil
+	}
+
+	params := url.Values{}
+	params.Set("symbol", ticker)
+	params.Set("from", fromDate)
+	params.Set("to", toDate)
+	params.Set("apikey", c.APIKey)
+	reqURL := fmt.Sprintf("%s/historical-price-eod/full?%s", FMPBaseURL, params.Encode())
+
+	resp, err := c.doRequest(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("FMP historical prices request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FMP historical prices returned status %d", resp.StatusCode)
+	}
+
+	var prices []FMPHistoricalPrice
+	if err := json.NewDecoder(resp.Body).Decode(&prices); err != nil {
+		return nil, fmt.Errorf("failed to decode FMP historical prices response: %w", err)
+	}
+
+	fmpHistoricalCache.set(cacheKey, prices)
+	return prices, nil
+}
+
+// FMPHistoricalToChartData converts FMP daily bars to chart points, oldest
+// first. Bars with an unparseable date are skipped.
+func FMPHistoricalToChartData(prices []FMPHistoricalPrice) []models.ChartDataPoint {
+	points := make([]models.ChartDataPoint, 0, len(prices))
+	for _, p := range prices {
+		date, err := time.Parse("2006-01-02", p.Date)
+		if err != nil {
+			continue
+		}
+		points = append(points, models.ChartDataPoint{
+			Timestamp: date,
+			Open:      decimal.NewFromFloat(p.Open),
+			High:      decimal.NewFromFloat(p.High),
+			Low:       decimal.NewFromFloat(p.Low),
+			Close:     decimal.NewFromFloat(p.Close),
+			Volume:    p.Volume,
+		})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
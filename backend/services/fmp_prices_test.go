@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetFMPHistoricalCache empties the shared price cache for test isolation
+func resetFMPHistoricalCache(t *testing.T) {
+	t.Helper()
+	fmpHistoricalCache.mu.Lock()
+	fmpHistoricalCache.entries = make(map[string]historicalPriceEntry)
+	fmpHistoricalCache.mu.Unlock()
+}
+
+func TestFMP_GetHistoricalPrices_Success(t *testing.T) {
+	resetFMPHistoricalCache(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/historical-price-eod/full")
+		assert.Equal(t, "AAPL", r.URL.Query().Get("symbol"))
+		assert.Equal(t, "2024-01-01", r.URL.Query().Get("from"))
+		assert.Equal(t, "2024-01-31", r.URL.Query().Get("to"))
+		assert.Equal(t, "test-key", r.URL.Query().Get("apikey"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"symbol":"AAPL","date":"2024-01-03","open":184.22,"high":185.88,"low":183.43,"close":184.25,"adjClose":183.6,"volume":58414460},
+			{"symbol":"AAPL","date":"2024-01-02","open":187.15,"high":188.44,"low":183.89,"close":185.64,"volume":82488700}
+		]`))
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	prices, err := client.GetHistoricalPrices("aapl",
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "2024-01-03", prices[0].Date)
+	assert.Equal(t, 183.6, prices[0].AdjClose)
+	assert.Equal(t, int64(82488700), prices[1].Volume)
+	assert.Zero(t, prices[1].AdjClose)
+}
+
+func TestFMP_GetHistoricalPrices_Cached(t *testing.T) {
+	resetFMPHistoricalCache(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		json.NewEncoder(w).Encode([]FMPHistoricalPrice{{Date: "2024-01-02", Close: 185.64}})
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	from := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	_, err := client.GetHistoricalPrices("AAPL", from, to)
+	require.NoError(t, err)
+	// Same ticker and dates (times of day don't matter) is served from memory
+	prices, err := client.GetHistoricalPrices("AAPL", from.Add(time.Hour), to.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, prices, 1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// A different range or ticker is fetched
+	_, err = client.GetHistoricalPrices("AAPL", from.AddDate(0, 0, -1), to)
+	require.NoError(t, err)
+	_, err = client.GetHistoricalPrices("MSFT", from, to)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// Expired entries are refetched
+	origTTL := FMPHistoricalCacheTTL
+	FMPHistoricalCacheTTL = 0
+	defer func() { FMPHistoricalCacheTTL = origTTL }()
+	_, err = client.GetHistoricalPrices("AAPL", from, to)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestFMP_GetHistoricalPrices_ErrorNotCached(t *testing.T) {
+	resetFMPHistoricalCache(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	restore := saveFMPBaseURL()
+	defer restore()
+	FMPBaseURL = server.URL
+
+	client := newFMPTestClient(server.URL)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		_, err := client.GetHistoricalPrices("AAPL", now.AddDate(0, -1, 0), now)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "429")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestFMP_GetHistoricalPrices_NoAPIKey(t *testing.T) {
+	client := &FMPClient{APIKey: ""}
+	_, err := client.GetHistoricalPrices("AAPL", time.Now().AddDate(0, -1, 0), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API key not configured")
+}
+
+func TestFMPHistoricalToChartData(t *testing.T) {
+	points := FMPHistoricalToChartData([]FMPHistoricalPrice{
+		{Date: "2024-01-03", Open: 184.22, High: 185.88, Low: 183.43, Close: 184.25, Volume: 58414460},
+		{Date: "not-a-date", Close: 1},
+		{Date: "2024-01-02", Open: 187.15, High: 188.44, Low: 183.89, Close: 185.64, Volume: 82488700},
+	})
+
+	require.Len(t, points, 2)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), points[0].Timestamp, "oldest first")
+	assert.Equal(t, "185.64", points[0].Close.String())
+	assert.Equal(t, "184.22", points[1].Open.String())
+	assert.Equal(t, int64(58414460), points[1].Volume)
+}
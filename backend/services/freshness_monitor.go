@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"investorcenter-api/database"
+)
+
+// Freshness alert reasons
+const (
+	FreshnessReasonDataStale = "data_stale"  // Table hasn't been written within the SLA
+	FreshnessReasonNoData    = "no_data"     // Table is empty
+	FreshnessReasonJobStale  = "job_not_run" // Pipeline hasn't logged a successful run within the SLA
+)
+
+// FreshnessSLA is how old a data domain, and the cronjob that feeds it, may
+// get before admins are alerted
+type FreshnessSLA struct {
+	Domain  string
+	JobName string // cronjob_execution_logs job name; "" skips the job check
+	MaxAge  time.Duration
+}
+
+// DefaultFreshnessSLAs covers the domains reported by the admin coverage
+// endpoint plus the ticker import. Job names are cronjob_schedules entries,
+// the only jobs cronjob-monitor logs. Each MaxAge leaves slack over the
+// pipeline's schedule so one late run doesn't page anyone.
+var DefaultFreshnessSLAs = []FreshnessSLA{
+	{Domain: "prices", JobName: "ic-score-daily-price-update", MaxAge: 30 * time.Hour},
+	{Domain: "financials", JobName: "ic-score-sec-financials", MaxAge: 8 * 24 * time.Hour},
+	{Domain: "fundamentals", JobName: "ic-score-fundamental-metrics", MaxAge: 30 * time.Hour},
+	{Domain: "ic_scores", JobName: "ic-score-calculator", MaxAge: 30 * time.Hour},
+	{Domain: "sentiment", JobName: "reddit-sentiment-pipeline", MaxAge: 6 * time.Hour},
+	{Domain: "analyst", JobName: "ic-score-analyst-ratings", MaxAge: 8 * 24 * time.Hour},
+	{Domain: "tickers", JobName: "polygon-ticker-update", MaxAge: 30 * time.Hour},
+}
+
+// LoadFreshnessSLAs returns DefaultFreshnessSLAs with MaxAge overridden by
+// FRESHNESS_SLA_<DOMAIN>_HOURS where set (e.g. FRESHNESS_SLA_PRICES_HOURS=12)
+func LoadFreshnessSLAs() []FreshnessSLA {
+	slas := make([]FreshnessSLA, len(DefaultFreshnessSLAs))
+	copy(slas, DefaultFreshnessSLAs)
+	for i, sla := range slas {
+		key := "FRESHNESS_SLA_" + strings.ToUpper(sla.Domain) + "_HOURS"
+		if hours := envInt(key, 0); hours > 0 {
+			slas[i].MaxAge = time.Duration(hours) * time.Hour
+		}
+	}
+	return slas
+}
+
+// FreshnessAlert describes one SLA breach
+type FreshnessAlert struct {
+	Domain      string     `json:"domain"`
+	Reason      string     `json:"reason"`
+	JobName     string     `json:"job_name,omitempty"`
+	LastUpdated *time.Time `json:"last_updated"` // Last table write, or last successful run for job_not_run
+	MaxAgeHours float64    `json:"max_age_hours"`
+	AgeHours    *float64   `json:"age_hours"`
+}
+
+// key identifies the breach across runs; a change of reason is a new breach
+func (a FreshnessAlert) key() string {
+	return a.Domain + "/" + a.Reason
+}
+
+// Message is a one-line human readable description of the alert
+func (a FreshnessAlert) Message() string {
+	switch a.Reason {
+	case FreshnessReasonNoData:
+		return fmt.Sprintf("%s: no data has ever been written", a.Domain)
+	case FreshnessReasonJobStale:
+		if a.AgeHours == nil {
+			return fmt.Sprintf("%s: job %s has never completed successfully", a.Domain, a.JobName)
+		}
+		return fmt.Sprintf("%s: job %s last succeeded %.1fh ago (SLA %.0fh)", a.Domain, a.JobName, *a.AgeHours, a.MaxAgeHours)
+	default:
+		return fmt.Sprintf("%s: last updated %.1fh ago (SLA %.0fh)", a.Domain, *a.AgeHours, a.MaxAgeHours)
+	}
+}
+
+// FreshnessSource reports when domains were last written and jobs last ran.
+// Implemented by DBFreshnessSource.
+type FreshnessSource interface {
+	DomainLastUpdated(domain string) (*time.Time, error)
+	LastSuccessfulRun(jobName string) (*time.Time, error)
+}
+
+// DBFreshnessSource reads freshness from the application database
+type DBFreshnessSource struct{}
+
+// DomainLastUpdated returns the domain table's latest write time
+func (DBFreshnessSource) DomainLastUpdated(domain string) (*time.Time, error) {
+	return database.GetDomainLastUpdated(domain)
+}
+
+// LastSuccessfulRun returns the job's latest successful execution time
+func (DBFreshnessSource) LastSuccessfulRun(jobName string) (*time.Time, error) {
+	return database.GetLastSuccessfulJobRun(jobName)
+}
+
+// FreshnessAlertState remembers which breaches have already been notified,
+// so a breach alerts once rather than on every run. Implemented by
+// DBFreshnessAlertState.
+type FreshnessAlertState interface {
+	OpenAlerts() (map[string]bool, error)
+	SetOpenAlerts(keys []string) error
+}
+
+// DBFreshnessAlertState keeps notified breaches in data_freshness_alerts,
+// so they survive between monitor runs
+type DBFreshnessAlertState struct{}
+
+// OpenAlerts returns the keys of notified, unresolved breaches
+func (DBFreshnessAlertState) OpenAlerts() (map[string]bool, error) {
+	return database.GetOpenFreshnessAlerts()
+}
+
+// SetOpenAlerts replaces the notified, unresolved breaches
+func (DBFreshnessAlertState) SetOpenAlerts(keys []string) error {
+	return database.SetOpenFreshnessAlerts(keys)
+}
+
+// memoryFreshnessAlertState keeps notified breaches for the life of the
+// process
+type memoryFreshnessAlertState struct {
+	open map[string]bool
+}
+
+func (m *memoryFreshnessAlertState) OpenAlerts() (map[string]bool, error) {
+	return m.open, nil
+}
+
+func (m *memoryFreshnessAlertState) SetOpenAlerts(keys []string) error {
+	m.open = make(map[string]bool, len(keys))
+	for _, k := range keys {
+		m.open[k] = true
+	}
+	return nil
+}
+
+// FreshnessNotifier delivers freshness alerts
+type FreshnessNotifier interface {
+	Notify(ctx context.Context, alerts []FreshnessAlert) error
+}
+
+// FreshnessMonitor checks every domain against its SLA and notifies on breaches
+type FreshnessMonitor struct {
+	slas      []FreshnessSLA
+	source    FreshnessSource
+	notifiers []FreshnessNotifier
+	state     FreshnessAlertState
+	now       func() time.Time
+}
+
+// NewFreshnessMonitor creates a monitor using env-configured SLAs, the
+// database, and whichever notifiers are configured: the webhook when
+// FRESHNESS_ALERT_WEBHOOK_URL is set, and in-app notifications to admins
+// unless FRESHNESS_ALERT_ADMIN_NOTIFY=false.
+func NewFreshnessMonitor() *FreshnessMonitor {
+	var notifiers []FreshnessNotifier
+	if url := os.Getenv("FRESHNESS_ALERT_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewWebhookFreshnessNotifier(url))
+	}
+	if os.Getenv("FRESHNESS_ALERT_ADMIN_NOTIFY") != "false" {
+		notifiers = append(notifiers, NewAdminFreshnessNotifier())
+	}
+	m := NewFreshnessMonitorWith(LoadFreshnessSLAs(), DBFreshnessSource{}, notifiers...)
+	m.state = DBFreshnessAlertState{}
+	return m
+}
+
+// NewFreshnessMonitorWith creates a monitor with explicit dependencies.
+// Notified breaches are remembered in memory only.
+func NewFreshnessMonitorWith(slas []FreshnessSLA, source FreshnessSource, notifiers ...FreshnessNotifier) *FreshnessMonitor {
+	return &FreshnessMonitor{
+		slas:      slas,
+		source:    source,
+		notifiers: notifiers,
+		state:     &memoryFreshnessAlertState{},
+		now:       time.Now,
+	}
+}
+
+// Check returns an alert for every domain whose data or feeding job is older
+// than its SLA. A domain that can't be checked is logged and skipped so one
+// missing table doesn't hide breaches elsewhere.
+func (m *FreshnessMonitor) Check() []FreshnessAlert {
+	now := m.now()
+	var alerts []FreshnessAlert
+
+	for _, sla := range m.slas {
+		maxAgeHours := sla.MaxAge.Hours()
+
+		last, err := m.source.DomainLastUpdated(sla.Domain)
+		if err != nil {
+			log.Printf("Freshness monitor: failed to check %s: %v", sla.Domain, err)
+		} else if last == nil {
+			alerts = append(alerts, FreshnessAlert{Domain: sla.Domain, Reason: FreshnessReasonNoData, MaxAgeHours: maxAgeHours})
+		} else if age := now.Sub(*last); age > sla.MaxAge {
+			ageHours := age.Hours()
+			alerts = append(alerts, FreshnessAlert{
+				Domain:      sla.Domain,
+				Reason:      FreshnessReasonDataStale,
+				LastUpdated: last,
+				MaxAgeHours: maxAgeHours,
+				AgeHours:    &ageHours,
+			})
+		}
+
+		if sla.JobName == "" {
+			continue
+		}
+		lastRun, err := m.source.LastSuccessfulRun(sla.JobName)
+		if err != nil {
+			log.Printf("Freshness monitor: failed to check job %s: %v", sla.JobName, err)
+			continue
+		}
+		alert := FreshnessAlert{Domain: sla.Domain, Reason: FreshnessReasonJobStale, JobName: sla.JobName, MaxAgeHours: maxAgeHours}
+		if lastRun == nil {
+			alerts = append(alerts, alert)
+		} else if age := now.Sub(*lastRun); age > sla.MaxAge {
+			ageHours := age.Hours()
+			alert.LastUpdated = lastRun
+			alert.AgeHours = &ageHours
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// Run checks freshness and sends breaches that haven't been notified yet to
+// every notifier; a breach alerts again only after it clears or its reason
+// changes. It returns every current alert, notified or not. If any notifier
+// fails, the new breaches aren't recorded so the next run retries them;
+// failures are logged and joined into the error.
+func (m *FreshnessMonitor) Run(ctx context.Context) ([]FreshnessAlert, error) {
+	alerts := m.Check()
+
+	open, err := m.state.OpenAlerts()
+	if err != nil {
+		// Repeating an alert beats missing one
+		log.Printf("Freshness monitor: failed to load notified alerts: %v", err)
+		open = nil
+	}
+
+	var fresh []FreshnessAlert
+	var stillOpen []string
+	for _, a := range alerts {
+		if open[a.key()] {
+			stillOpen = append(stillOpen, a.key())
+		} else {
+			fresh = append(fresh, a)
+		}
+	}
+
+	var errs []string
+	if len(fresh) > 0 {
+		for _, n := range m.notifiers {
+			if err := n.Notify(ctx, fresh); err != nil {
+				log.Printf("Freshness monitor: notifier failed: %v", err)
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	keys := stillOpen
+	if len(errs) == 0 {
+		for _, a := range fresh {
+			keys = append(keys, a.key())
+		}
+	}
+	if err := m.state.SetOpenAlerts(keys); err != nil {
+		log.Printf("Freshness monitor: failed to record notified alerts: %v", err)
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) > 0 {
+		return alerts, fmt.Errorf("failed to deliver freshness alerts: %s", strings.Join(errs, "; "))
+	}
+	return alerts, nil
+}
+
+// WebhookFreshnessNotifier posts alerts as JSON to a webhook. The "text"
+// field makes the payload render directly in Slack-compatible receivers.
+type WebhookFreshnessNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookFreshnessNotifier creates a webhook notifier for url
+func NewWebhookFreshnessNotifier(url string) *WebhookFreshnessNotifier {
+	return &WebhookFreshnessNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts all alerts in a single request
+func (w *WebhookFreshnessNotifier) Notify(ctx context.Context, alerts []FreshnessAlert) error {
+	return postAlertWebhook(ctx, w.Client, w.URL, freshnessSummary(alerts), alerts)
+}
+
+// postAlertWebhook posts {"text": text, "alerts": alerts} to url. The
+// "text" field makes the payload render directly in Slack-compatible
+// receivers.
+func postAlertWebhook(ctx context.Context, client *http.Client, url, text string, alerts interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"text":   text,
+		"alerts": alerts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AdminFreshnessNotifier sends an in-app notification to every admin
+type AdminFreshnessNotifier struct {
+	notifications *NotificationService
+	adminIDs      func() ([]string, error)
+}
+
+// NewAdminFreshnessNotifier creates a notifier backed by notification_queue
+func NewAdminFreshnessNotifier() *AdminFreshnessNotifier {
+	return &AdminFreshnessNotifier{
+		notifications: NewNotificationService(nil),
+		adminIDs:      database.GetAdminUserIDs,
+	}
+}
+
+// Notify creates one notification per admin summarizing all alerts
+func (a *AdminFreshnessNotifier) Notify(ctx context.Context, alerts []FreshnessAlert) error {
+	ids, err := a.adminIDs()
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%d data freshness alert(s)", len(alerts))
+	message := freshnessSummary(alerts)
+	for _, id := range ids {
+		if err := a.notifications.CreateInAppNotification(id, nil, "data_freshness", title, message, alerts); err != nil {
+			return fmt.Errorf("failed to notify admin %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func freshnessSummary(alerts []FreshnessAlert) string {
+	lines := make([]string, 0, len(alerts)+1)
+	lines = append(lines, fmt.Sprintf("⚠️ %d data pipeline freshness alert(s):", len(alerts)))
+	for _, a := range alerts {
+		lines = append(lines, "• "+a.Message())
+	}
+	return strings.Join(lines, "\n")
+}
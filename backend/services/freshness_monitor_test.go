@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFreshnessSource returns canned last-update and last-run times
+type stubFreshnessSource struct {
+	updated map[string]*time.Time
+	runs    map[string]*time.Time
+	errs    map[string]error
+}
+
+func (s *stubFreshnessSource) DomainLastUpdated(domain string) (*time.Time, error) {
+	if err := s.errs[domain]; err != nil {
+		return nil, err
+	}
+	return s.updated[domain], nil
+}
+
+func (s *stubFreshnessSource) LastSuccessfulRun(jobName string) (*time.Time, error) {
+	if err := s.errs[jobName]; err != nil {
+		return nil, err
+	}
+	return s.runs[jobName], nil
+}
+
+// recordingNotifier captures every batch of alerts it is asked to send
+type recordingNotifier struct {
+	batches [][]FreshnessAlert
+	err     error
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, alerts []FreshnessAlert) error {
+	r.batches = append(r.batches, alerts)
+	return r.err
+}
+
+var freshnessNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func hoursAgo(h float64) *time.Time {
+	t := freshnessNow.Add(-time.Duration(h * float64(time.Hour)))
+	return &t
+}
+
+func newTestFreshnessMonitor(source FreshnessSource, notifiers ...FreshnessNotifier) *FreshnessMonitor {
+	m := NewFreshnessMonitorWith([]FreshnessSLA{
+		{Domain: "prices", JobName: "ic-score-daily-price-update", MaxAge: 24 * time.Hour},
+		{Domain: "sentiment", MaxAge: 6 * time.Hour},
+	}, source, notifiers...)
+	m.now = func() time.Time { return freshnessNow }
+	return m
+}
+
+func TestFreshnessMonitor_FreshDomainsDontAlert(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(2), "sentiment": hoursAgo(5.9)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(3)},
+	}
+	notifier := &recordingNotifier{}
+
+	alerts, err := newTestFreshnessMonitor(source, notifier).Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+	assert.Empty(t, notifier.batches, "nothing to send when every domain is fresh")
+}
+
+func TestFreshnessMonitor_StaleDomainAlerts(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(30), "sentiment": hoursAgo(1)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(2)},
+	}
+	notifier := &recordingNotifier{}
+
+	alerts, err := newTestFreshnessMonitor(source, notifier).Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "prices", alerts[0].Domain)
+	assert.Equal(t, FreshnessReasonDataStale, alerts[0].Reason)
+	require.NotNil(t, alerts[0].AgeHours)
+	assert.InDelta(t, 30.0, *alerts[0].AgeHours, 0.001)
+	assert.Equal(t, 24.0, alerts[0].MaxAgeHours)
+	assert.Equal(t, "prices: last updated 30.0h ago (SLA 24h)", alerts[0].Message())
+
+	require.Len(t, notifier.batches, 1)
+	assert.Equal(t, alerts, notifier.batches[0])
+}
+
+func TestFreshnessMonitor_JobNotRunAlerts(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(1), "sentiment": hoursAgo(1)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(48)},
+	}
+
+	alerts := newTestFreshnessMonitor(source).Check()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, FreshnessReasonJobStale, alerts[0].Reason)
+	assert.Equal(t, "ic-score-daily-price-update", alerts[0].JobName)
+	assert.Contains(t, alerts[0].Message(), "job ic-score-daily-price-update last succeeded 48.0h ago")
+
+	// A job that never logged a success is also a breach
+	source.runs = map[string]*time.Time{}
+	alerts = newTestFreshnessMonitor(source).Check()
+	require.Len(t, alerts, 1)
+	assert.Nil(t, alerts[0].AgeHours)
+	assert.Contains(t, alerts[0].Message(), "has never completed successfully")
+}
+
+func TestFreshnessMonitor_EmptyTableAlerts(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(1)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(1)},
+	}
+
+	alerts := newTestFreshnessMonitor(source).Check()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "sentiment", alerts[0].Domain)
+	assert.Equal(t, FreshnessReasonNoData, alerts[0].Reason)
+}
+
+func TestFreshnessMonitor_SourceErrorSkipsDomain(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"sentiment": hoursAgo(10)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(1)},
+		errs:    map[string]error{"prices": errors.New("relation does not exist")},
+	}
+
+	alerts := newTestFreshnessMonitor(source).Check()
+	require.Len(t, alerts, 1, "an unreadable domain must not hide other breaches")
+	assert.Equal(t, "sentiment", alerts[0].Domain)
+}
+
+func TestFreshnessMonitor_NotifierErrorIsReturned(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(100), "sentiment": hoursAgo(1)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(1)},
+	}
+	failing := &recordingNotifier{err: errors.New("webhook down")}
+	working := &recordingNotifier{}
+
+	alerts, err := newTestFreshnessMonitor(source, failing, working).Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook down")
+	assert.Len(t, alerts, 1)
+	assert.Len(t, working.batches, 1, "one failing notifier must not block the others")
+}
+
+func TestFreshnessMonitor_RepeatAlertsSuppressed(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(30), "sentiment": hoursAgo(1)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(2)},
+	}
+	notifier := &recordingNotifier{}
+	monitor := newTestFreshnessMonitor(source, notifier)
+
+	_, err := monitor.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, notifier.batches, 1)
+
+	// Same breach on the next run: still reported, not re-sent
+	alerts, err := monitor.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Len(t, notifier.batches, 1)
+
+	// A second breach alerts on its own
+	source.updated["sentiment"] = hoursAgo(10)
+	_, err = monitor.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, notifier.batches, 2)
+	require.Len(t, notifier.batches[1], 1)
+	assert.Equal(t, "sentiment", notifier.batches[1][0].Domain)
+
+	// Once prices recovers, a later breach alerts again
+	source.updated["prices"] = hoursAgo(1)
+	_, err = monitor.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, notifier.batches, 2)
+	source.updated["prices"] = hoursAgo(40)
+	_, err = monitor.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, notifier.batches, 3)
+	assert.Equal(t, "prices", notifier.batches[2][0].Domain)
+}
+
+func TestFreshnessMonitor_FailedDeliveryRetried(t *testing.T) {
+	source := &stubFreshnessSource{
+		updated: map[string]*time.Time{"prices": hoursAgo(30), "sentiment": hoursAgo(1)},
+		runs:    map[string]*time.Time{"ic-score-daily-price-update": hoursAgo(2)},
+	}
+	notifier := &recordingNotifier{err: errors.New("webhook down")}
+	monitor := newTestFreshnessMonitor(source, notifier)
+
+	_, err := monitor.Run(context.Background())
+	require.Error(t, err)
+
+	notifier.err = nil
+	_, err = monitor.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, notifier.batches, 2, "an undelivered alert is sent again")
+}
+
+// TestDefaultFreshnessSLAs_JobsAreScheduled guards against job names that
+// cronjob-monitor never logs, which would report job_not_run forever
+func TestDefaultFreshnessSLAs_JobsAreScheduled(t *testing.T) {
+	files, err := filepath.Glob("../migrations/*.sql")
+	require.NoError(t, err)
+	var scheduled strings.Builder
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		require.NoError(t, err)
+		if strings.Contains(string(b), "INSERT INTO cronjob_schedules") {
+			scheduled.Write(b)
+		}
+	}
+	for _, sla := range DefaultFreshnessSLAs {
+		assert.Contains(t, scheduled.String(), "('"+sla.JobName+"',", "%s job is not in cronjob_schedules", sla.Domain)
+	}
+}
+
+func TestLoadFreshnessSLAs_EnvOverride(t *testing.T) {
+	t.Setenv("FRESHNESS_SLA_PRICES_HOURS", "12")
+	t.Setenv("FRESHNESS_SLA_SENTIMENT_HOURS", "not-a-number")
+
+	slas := LoadFreshnessSLAs()
+	byDomain := make(map[string]FreshnessSLA)
+	for _, s := range slas {
+		byDomain[s.Domain] = s
+	}
+	assert.Equal(t, 12*time.Hour, byDomain["prices"].MaxAge)
+	assert.Equal(t, 6*time.Hour, byDomain["sentiment"].MaxAge)
+	assert.Equal(t, 30*time.Hour, DefaultFreshnessSLAs[0].MaxAge, "defaults must not be mutated")
+}
+
+func TestWebhookFreshnessNotifier(t *testing.T) {
+	var payload struct {
+		Text   string           `json:"text"`
+		Alerts []FreshnessAlert `json:"alerts"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	age := 30.0
+	alerts := []FreshnessAlert{{Domain: "prices", Reason: FreshnessReasonDataStale, MaxAgeHours: 24, AgeHours: &age}}
+	require.NoError(t, NewWebhookFreshnessNotifier(server.URL).Notify(context.Background(), alerts))
+	assert.Contains(t, payload.Text, "prices: last updated 30.0h ago")
+	require.Len(t, payload.Alerts, 1)
+	assert.Equal(t, "prices", payload.Alerts[0].Domain)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	err := NewWebhookFreshnessNotifier(failing.URL).Notify(context.Background(), alerts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"investorcenter-api/database"
+)
+
+// FMP endpoints stored by the fundamentals refresh, as fmp_snapshots.endpoint
+const (
+	FMPEndpointRatiosTTM = "ratios-ttm"
+	FMPEndpointScore     = "score"
+)
+
+// FundamentalsFetcher fetches FMP fundamentals for many tickers at once.
+// Implemented by FMPClient.
+type FundamentalsFetcher interface {
+	GetFundamentalsBatch(tickers []string) map[string]*FMPAllMetrics
+}
+
+// FMPSnapshotStore persists the latest FMP response per symbol; a nil
+// value means FMP has no data. Implemented by DBFMPSnapshotStore.
+type FMPSnapshotStore interface {
+	UpsertFMPSnapshots(endpoint string, data map[string]json.RawMessage) error
+}
+
+// DBFMPSnapshotStore stores snapshots in fmp_snapshots
+type DBFMPSnapshotStore struct{}
+
+// UpsertFMPSnapshots stores data under endpoint
+func (DBFMPSnapshotStore) UpsertFMPSnapshots(endpoint string, data map[string]json.RawMessage) error {
+	return database.UpsertFMPSnapshots(endpoint, data)
+}
+
+// FundamentalsRefreshResult is the outcome of refreshing a single symbol.
+// RatiosTTM and Score report whether FMP had that data.
+type FundamentalsRefreshResult struct {
+	Symbol    string `json:"symbol"`
+	Success   bool   `json:"success"`
+	RatiosTTM bool   `json:"ratios_ttm"`
+	Score     bool   `json:"score"`
+	Error     string `json:"error,omitempty"`
+}
+
+// FundamentalsRefreshService refreshes stored FMP TTM ratios and scores for
+// a set of symbols using FMP's batch endpoints
+type FundamentalsRefreshService struct {
+	fetcher FundamentalsFetcher
+	store   FMPSnapshotStore
+}
+
+// NewFundamentalsRefreshService creates a refresh service backed by FMP and
+// the database
+func NewFundamentalsRefreshService() *FundamentalsRefreshService {
+	return NewFundamentalsRefreshServiceWith(NewFMPClient(), DBFMPSnapshotStore{})
+}
+
+// NewFundamentalsRefreshServiceWith creates a refresh service with explicit
+// dependencies
+func NewFundamentalsRefreshServiceWith(fetcher FundamentalsFetcher, store FMPSnapshotStore) *FundamentalsRefreshService {
+	return &FundamentalsRefreshService{fetcher: fetcher, store: store}
+}
+
+// Refresh fetches TTM ratios and scores for symbols, a couple of FMP
+// requests per FMPMaxBatchSymbols symbols, and stores them. FMP having no
+// data for a symbol is stored too; a failed request leaves that symbol's
+// stored data alone and marks it failed. Results are in symbols order.
+func (s *FundamentalsRefreshService) Refresh(ctx context.Context, symbols []string) []FundamentalsRefreshResult {
+	results := make([]FundamentalsRefreshResult, len(symbols))
+	if len(symbols) == 0 {
+		return results
+	}
+	if err := ctx.Err(); err != nil {
+		for i, symbol := range symbols {
+			results[i] = FundamentalsRefreshResult{Symbol: symbol, Error: err.Error()}
+		}
+		return results
+	}
+
+	metrics := s.fetcher.GetFundamentalsBatch(symbols)
+	ratios := make(map[string]json.RawMessage, len(symbols))
+	scores := make(map[string]json.RawMessage, len(symbols))
+	errs := make([][]string, len(symbols))
+
+	for i, symbol := range symbols {
+		results[i].Symbol = symbol
+		m, ok := metrics[symbol]
+		if !ok {
+			errs[i] = append(errs[i], "no FMP response")
+			continue
+		}
+		if raw, err := snapshotData(m.RatiosTTM != nil, m.RatiosTTM, m.Errors[FMPEndpointRatiosTTM]); err != nil {
+			errs[i] = append(errs[i], err.Error())
+		} else {
+			ratios[symbol] = raw
+			results[i].RatiosTTM = raw != nil
+		}
+		if raw, err := snapshotData(m.Score != nil, m.Score, m.Errors[FMPEndpointScore]); err != nil {
+			errs[i] = append(errs[i], err.Error())
+		} else {
+			scores[symbol] = raw
+			results[i].Score = raw != nil
+		}
+	}
+
+	ratiosErr := s.store.UpsertFMPSnapshots(FMPEndpointRatiosTTM, ratios)
+	scoresErr := s.store.UpsertFMPSnapshots(FMPEndpointScore, scores)
+
+	for i, symbol := range symbols {
+		if _, ok := ratios[symbol]; ok && ratiosErr != nil {
+			errs[i] = append(errs[i], ratiosErr.Error())
+		}
+		if _, ok := scores[symbol]; ok && scoresErr != nil {
+			errs[i] = append(errs[i], scoresErr.Error())
+		}
+		if len(errs[i]) == 0 {
+			results[i].Success = true
+		} else {
+			results[i].Error = strings.Join(errs[i], "; ")
+		}
+	}
+	return results
+}
+
+// snapshotData returns the JSON to store for one FMP response: the data if
+// present, nil if FMP has none, or an error if the request failed
+func snapshotData(present bool, data interface{}, fetchErr error) (json.RawMessage, error) {
+	if present {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode FMP data: %w", err)
+		}
+		return raw, nil
+	}
+	if fetchErr == nil || errors.Is(fetchErr, ErrFMPNoData) {
+		return nil, nil
+	}
+	return nil, fetchErr
+}
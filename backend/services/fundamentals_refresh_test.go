@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFundamentalsFetcher returns canned batch results and records calls
+type stubFundamentalsFetcher struct {
+	results map[string]*FMPAllMetrics
+	calls   [][]string
+}
+
+func (f *stubFundamentalsFetcher) GetFundamentalsBatch(tickers []string) map[string]*FMPAllMetrics {
+	f.calls = append(f.calls, tickers)
+	return f.results
+}
+
+// stubSnapshotStore records stored snapshots by endpoint
+type stubSnapshotStore struct {
+	stored map[string]map[string]json.RawMessage
+	err    error
+}
+
+func (s *stubSnapshotStore) UpsertFMPSnapshots(endpoint string, data map[string]json.RawMessage) error {
+	if s.stored == nil {
+		s.stored = make(map[string]map[string]json.RawMessage)
+	}
+	s.stored[endpoint] = data
+	return s.err
+}
+
+func TestFundamentalsRefresh_StoresBatchResults(t *testing.T) {
+	fetcher := &stubFundamentalsFetcher{results: map[string]*FMPAllMetrics{
+		"AAPL": {RatiosTTM: &FMPRatiosTTM{Symbol: "AAPL"}, Score: &FMPScore{Symbol: "AAPL"}, Errors: map[string]error{}},
+		"SPAC": {Errors: map[string]error{
+			"ratios-ttm": fmt.Errorf("%w for SPAC", ErrFMPNoData),
+			"score":      fmt.Errorf("%w for SPAC", ErrFMPNoData),
+		}},
+		"MSFT": {Score: &FMPScore{Symbol: "MSFT"}, Errors: map[string]error{
+			"ratios-ttm": errors.New("FMP ratios-ttm batch returned status 429"),
+		}},
+	}}
+	store := &stubSnapshotStore{}
+
+	results := NewFundamentalsRefreshServiceWith(fetcher, store).Refresh(context.Background(), []string{"AAPL", "SPAC", "MSFT"})
+
+	require.Len(t, fetcher.calls, 1, "one batch call for every symbol")
+	assert.Equal(t, []string{"AAPL", "SPAC", "MSFT"}, fetcher.calls[0])
+
+	require.Len(t, results, 3)
+	assert.Equal(t, FundamentalsRefreshResult{Symbol: "AAPL", Success: true, RatiosTTM: true, Score: true}, results[0])
+	assert.Equal(t, FundamentalsRefreshResult{Symbol: "SPAC", Success: true}, results[1], "no data is a successful refresh")
+	assert.False(t, results[2].Success)
+	assert.True(t, results[2].Score)
+	assert.Contains(t, results[2].Error, "429")
+
+	ratios := store.stored[FMPEndpointRatiosTTM]
+	assert.Contains(t, string(ratios["AAPL"]), `"symbol":"AAPL"`)
+	assert.Contains(t, ratios, "SPAC")
+	assert.Nil(t, ratios["SPAC"], "no data is stored as NULL")
+	assert.NotContains(t, ratios, "MSFT", "a failed request keeps the stored ratios")
+	assert.Contains(t, store.stored[FMPEndpointScore], "MSFT")
+}
+
+func TestFundamentalsRefresh_StoreError(t *testing.T) {
+	fetcher := &stubFundamentalsFetcher{results: map[string]*FMPAllMetrics{
+		"AAPL": {RatiosTTM: &FMPRatiosTTM{Symbol: "AAPL"}, Score: &FMPScore{Symbol: "AAPL"}, Errors: map[string]error{}},
+	}}
+	store := &stubSnapshotStore{err: errors.New("connection refused")}
+
+	results := NewFundamentalsRefreshServiceWith(fetcher, store).Refresh(context.Background(), []string{"AAPL"})
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.Contains(t, results[0].Error, "connection refused")
+}
+
+func TestFundamentalsRefresh_CanceledContext(t *testing.T) {
+	fetcher := &stubFundamentalsFetcher{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewFundamentalsRefreshServiceWith(fetcher, &stubSnapshotStore{}).Refresh(ctx, []string{"AAPL"})
+	assert.Empty(t, fetcher.calls)
+	assert.False(t, results[0].Success)
+	assert.Contains(t, results[0].Error, "canceled")
+}
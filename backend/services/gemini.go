@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"investorcenter-shared/tracing"
 )
 
 const (
@@ -30,7 +32,7 @@ func NewGeminiClient() *GeminiClient {
 	}
 	return &GeminiClient{
 		apiKey: apiKey,
-		client: &http.Client{Timeout: 30 * time.Second},
+		client: &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(nil)},
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"investorcenter-api/models"
+	"investorcenter-shared/tracing"
 )
 
 // ICScoreClient is an HTTP client for the IC Score service API
@@ -78,7 +79,8 @@ func NewICScoreClient() *ICScoreClient {
 	return &ICScoreClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+// Insider roles, in display order
+var insiderRoles = []string{"CEO", "CFO", "Officer", "Director", "10% Owner", "Other"}
+
+var (
+	ceoTitlePattern = regexp.MustCompile(`(?i)\bCEO\b|chief executive`)
+	cfoTitlePattern = regexp.MustCompile(`(?i)\bCFO\b|chief financial`)
+)
+
+// InsiderRole buckets a free-form Form 4 insider title. The ingestion
+// pipeline records "Director", "10% Owner" or "Other" for non-officers and
+// the officer's own title (or "Officer") otherwise.
+func InsiderRole(title string) string {
+	t := strings.TrimSpace(title)
+	switch {
+	case ceoTitlePattern.MatchString(t):
+		return "CEO"
+	case cfoTitlePattern.MatchString(t):
+		return "CFO"
+	case strings.Contains(t, "10%"):
+		return "10% Owner"
+	case strings.EqualFold(t, "Director"):
+		return "Director"
+	case t == "" || strings.EqualFold(t, "Other"):
+		return "Other"
+	}
+	return "Officer"
+}
+
+// SummarizeInsiderActivity totals buy and sell rows into net shares and
+// dollar value, overall and per insider role. Roles without activity are
+// left out of ByRole.
+func SummarizeInsiderActivity(rows []models.InsiderActivityRow, since time.Time) models.InsiderActivitySummary {
+	summary := models.InsiderActivitySummary{
+		Since:  since.Format("2006-01-02"),
+		ByRole: []models.InsiderRoleActivity{},
+	}
+
+	byRole := make(map[string]*models.InsiderRoleActivity)
+	for _, r := range rows {
+		role := InsiderRole(r.InsiderTitle)
+		ra, ok := byRole[role]
+		if !ok {
+			ra = &models.InsiderRoleActivity{Role: role}
+			byRole[role] = ra
+		}
+
+		if r.Direction == "buy" {
+			summary.BuyTransactions += r.Transactions
+			summary.SharesBought += r.Shares
+			summary.ValueBought += r.Value
+			ra.BuyTransactions += r.Transactions
+			ra.NetShares += r.Shares
+			ra.NetValue += r.Value
+		} else {
+			summary.SellTransactions += r.Transactions
+			summary.SharesSold += r.Shares
+			summary.ValueSold += r.Value
+			ra.SellTransactions += r.Transactions
+			ra.NetShares -= r.Shares
+			ra.NetValue -= r.Value
+		}
+	}
+	summary.NetShares = summary.SharesBought - summary.SharesSold
+	summary.NetValue = summary.ValueBought - summary.ValueSold
+
+	for _, role := range insiderRoles {
+		if ra, ok := byRole[role]; ok {
+			summary.ByRole = append(summary.ByRole, *ra)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"investorcenter-api/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsiderRole(t *testing.T) {
+	tests := map[string]string{
+		"Chief Executive Officer":   "CEO",
+		"CEO and Chairman":          "CEO",
+		"EVP, CFO":                  "CFO",
+		"Chief Financial Officer":   "CFO",
+		"Director":                  "Director",
+		"10% Owner":                 "10% Owner",
+		"General Counsel":           "Officer",
+		"Officer":                   "Officer",
+		"Other":                     "Other",
+		"":                          "Other",
+		"Chief Operating Officer":   "Officer",
+		"SVP, Corporate Controller": "Officer",
+	}
+	for title, want := range tests {
+		assert.Equal(t, want, InsiderRole(title), title)
+	}
+}
+
+func TestSummarizeInsiderActivity(t *testing.T) {
+	since := time.Date(2026, 7, 19, 0, 0, 0, 0, time.UTC)
+	rows := []models.InsiderActivityRow{
+		{InsiderTitle: "Chief Executive Officer", Direction: "sell", Transactions: 3, Shares: 30000, Value: 6_000_000},
+		{InsiderTitle: "Director", Direction: "buy", Transactions: 2, Shares: 5000, Value: 900_000},
+		{InsiderTitle: "Director", Direction: "sell", Transactions: 1, Shares: 1000, Value: 200_000},
+		{InsiderTitle: "CEO", Direction: "buy", Transactions: 1, Shares: 2000, Value: 400_000},
+	}
+
+	s := SummarizeInsiderActivity(rows, since)
+
+	assert.Equal(t, "2026-07-19", s.Since)
+	assert.Equal(t, 3, s.BuyTransactions)
+	assert.Equal(t, 4, s.SellTransactions)
+	assert.Equal(t, int64(7000), s.SharesBought)
+	assert.Equal(t, int64(31000), s.SharesSold)
+	assert.Equal(t, int64(-24000), s.NetShares)
+	assert.InDelta(t, -4_900_000, s.NetValue, 0.01)
+
+	require.Len(t, s.ByRole, 2)
+	assert.Equal(t, models.InsiderRoleActivity{
+		Role: "CEO", BuyTransactions: 1, SellTransactions: 3, NetShares: -28000, NetValue: -5_600_000,
+	}, s.ByRole[0])
+	assert.Equal(t, "Director", s.ByRole[1].Role)
+	assert.Equal(t, int64(4000), s.ByRole[1].NetShares)
+}
+
+func TestSummarizeInsiderActivity_NoTrades(t *testing.T) {
+	s := SummarizeInsiderActivity(nil, time.Now())
+	assert.Zero(t, s.NetShares)
+	assert.NotNil(t, s.ByRole)
+	assert.Empty(t, s.ByRole)
+}
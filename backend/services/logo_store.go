@@ -0,0 +1,29 @@
+package services
+
+import "context"
+
+// S3LogoStore keeps original ticker logo images as S3 objects, each with
+// its own image Content-Type
+type S3LogoStore struct {
+	bucket *s3Bucket
+}
+
+// NewS3LogoStore creates a logo store for bucket in AWS_REGION (default
+// us-east-1)
+func NewS3LogoStore(bucket string) (*S3LogoStore, error) {
+	b, err := newS3Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &S3LogoStore{bucket: b}, nil
+}
+
+// Put uploads a logo image of contentType under key
+func (s *S3LogoStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	return s.bucket.put(ctx, key, data, contentType)
+}
+
+// Get downloads the logo at key
+func (s *S3LogoStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.bucket.get(ctx, key)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"investorcenter-api/models"
+)
+
+// Market-wide alert types fire on a broad move, e.g. "S&P 500 down 3%
+// today", rather than on a single holding. Each is evaluated against a
+// reference ticker standing in for the index or sector; that ticker is
+// stored as the rule's symbol, so market-wide rules ride the same price
+// updates as every other rule and need no watch list entry.
+
+// marketIndexTickers maps the indexes an index_move alert can reference to
+// the ETF tracking each. Price updates carry stock and ETF quotes, not index
+// values, so these are the proxies GetMarketIndices falls back to.
+var marketIndexTickers = map[string]string{
+	"SPX":  "SPY", // S&P 500
+	"DJI":  "DIA", // Dow Jones
+	"COMP": "QQQ", // Nasdaq Composite
+	"RUT":  "IWM", // Russell 2000
+}
+
+// sectorTickers maps the sectors a sector_move alert can reference to the
+// SPDR sector ETF aggregating them
+var sectorTickers = map[string]string{
+	"Technology":             "XLK",
+	"Healthcare":             "XLV",
+	"Financial Services":     "XLF",
+	"Consumer Cyclical":      "XLY",
+	"Consumer Defensive":     "XLP",
+	"Industrials":            "XLI",
+	"Energy":                 "XLE",
+	"Basic Materials":        "XLB",
+	"Real Estate":            "XLRE",
+	"Communication Services": "XLC",
+	"Utilities":              "XLU",
+}
+
+// IsMarketAlertType reports whether alertType is a market-wide alert type
+func IsMarketAlertType(alertType string) bool {
+	return alertType == "index_move" || alertType == "sector_move"
+}
+
+// MarketAlertSymbol returns the reference ticker a market-wide alert is
+// evaluated against, from the index or sector in its conditions
+func MarketAlertSymbol(alertType string, conditions json.RawMessage) (string, error) {
+	var cond models.MarketMoveCondition
+	if err := json.Unmarshal(conditions, &cond); err != nil {
+		return "", invalidConditions(alertType, err.Error())
+	}
+
+	switch alertType {
+	case "index_move":
+		if cond.Sector != "" {
+			return "", invalidConditions(alertType, "sector is not allowed; use sector_move")
+		}
+		ticker, ok := marketIndexTickers[strings.ToUpper(cond.Index)]
+		if !ok {
+			return "", invalidConditions(alertType, "index must be one of "+strings.Join(sortedKeys(marketIndexTickers), ", "))
+		}
+		return ticker, nil
+	case "sector_move":
+		if cond.Index != "" {
+			return "", invalidConditions(alertType, "index is not allowed; use index_move")
+		}
+		ticker, ok := sectorTickers[cond.Sector]
+		if !ok {
+			return "", invalidConditions(alertType, "sector must be one of "+strings.Join(sortedKeys(sectorTickers), ", "))
+		}
+		return ticker, nil
+	}
+	return "", fmt.Errorf("%s is not a market-wide alert type", alertType)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"investorcenter-api/httputil"
+)
+
+// NasdaqTraderBaseURL serves the NASDAQ Trader symbol directory, the HTTPS
+// mirror of ftp.nasdaqtrader.com/SymbolDirectory
+var NasdaqTraderBaseURL = "https://www.nasdaqtrader.com/dynamic/SymDir"
+
+// otherListedExchanges maps otherlisted.txt exchange codes to the MIC codes
+// Polygon reports as primary_exchange
+var otherListedExchanges = map[string]string{
+	"A": "XASE", // NYSE American
+	"N": "XNYS", // NYSE
+	"P": "ARCX", // NYSE Arca
+	"Z": "BATS", // Cboe BZX
+	"V": "IEXG", // IEX
+}
+
+// IsNasdaqTraderExchange reports whether the symbol directory lists the
+// exchange with this MIC code, i.e. whether a Polygon ticker on it should
+// also appear in NasdaqTraderSource
+func IsNasdaqTraderExchange(mic string) bool {
+	if mic == "XNAS" {
+		return true
+	}
+	for _, listed := range otherListedExchanges {
+		if listed == mic {
+			return true
+		}
+	}
+	return false
+}
+
+// Security name patterns for instruments the directory doesn't flag
+var (
+	warrantNamePattern   = regexp.MustCompile(`(?i)\bwarrants?\b`)
+	rightNamePattern     = regexp.MustCompile(`(?i)\brights?\b`)
+	unitNamePattern      = regexp.MustCompile(`(?i)\bunits?\b`)
+	preferredNamePattern = regexp.MustCompile(`(?i)\b(preferred|depositary shares)\b`)
+
+	// MLPs and other partnerships list their common equity as units
+	partnershipUnitNamePattern = regexp.MustCompile(`(?i)\b(common units?|units? representing|limited partner(ship)? units?)\b`)
+)
+
+// NasdaqTraderSource lists US exchange tickers from the NASDAQ Trader symbol
+// directory (nasdaqlisted.txt and otherlisted.txt). It returns the same
+// PolygonTicker struct as PolygonClient.GetAllTickers, so it can stand in for
+// Polygon when it's unavailable or be compared against it. The directory only
+// has symbols, names, exchanges and the ETF flag; other fields are empty.
+type NasdaqTraderSource struct {
+	Client            *http.Client
+	IncludeTestIssues bool
+}
+
+// NewNasdaqTraderSource creates a NASDAQ Trader symbol directory source
+func NewNasdaqTraderSource() *NasdaqTraderSource {
+	return &NasdaqTraderSource{
+		Client: httputil.SharedClient(),
+	}
+}
+
+// GetAllTickers lists tickers for the "stocks", "etf" or "all_equities" asset
+// types, up to limit (0 = all). Other asset types aren't in the directory.
+func (s *NasdaqTraderSource) GetAllTickers(assetType string, limit int) ([]PolygonTicker, error) {
+	var keep func(PolygonTicker) bool
+	switch assetType {
+	case "stocks":
+		keep = func(t PolygonTicker) bool { return t.Type == "CS" }
+	case "etf":
+		keep = func(t PolygonTicker) bool { return t.Type == "ETF" }
+	case "all_equities":
+		keep = func(PolygonTicker) bool { return true }
+	default:
+		return nil, fmt.Errorf("NASDAQ Trader symbol directory has no %s tickers", assetType)
+	}
+
+	nasdaq, err := s.fetch("nasdaqlisted.txt", ParseNasdaqListed)
+	if err != nil {
+		return nil, err
+	}
+	other, err := s.fetch("otherlisted.txt", ParseOtherListed)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickers []PolygonTicker
+	seen := make(map[string]bool)
+	for _, t := range append(nasdaq, other...) {
+		if !keep(t) || seen[t.Ticker] {
+			continue
+		}
+		seen[t.Ticker] = true
+		tickers = append(tickers, t)
+		if limit > 0 && len(tickers) >= limit {
+			break
+		}
+	}
+	return tickers, nil
+}
+
+func (s *NasdaqTraderSource) fetch(file string, parse func(io.Reader, bool) ([]PolygonTicker, error)) ([]PolygonTicker, error) {
+	resp, err := s.Client.Get(fmt.Sprintf("%s/%s", NasdaqTraderBaseURL, file))
+	if err != nil {
+		return nil, fmt.Errorf("NASDAQ Trader %s request failed: %w", file, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NASDAQ Trader %s returned status %d", file, resp.StatusCode)
+	}
+
+	tickers, err := parse(resp.Body, s.IncludeTestIssues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NASDAQ Trader %s: %w", file, err)
+	}
+	return tickers, nil
+}
+
+// ParseNasdaqListed parses nasdaqlisted.txt. Every row is a Nasdaq listing.
+func ParseNasdaqListed(r io.Reader, includeTestIssues bool) ([]PolygonTicker, error) {
+	return parseSymbolDirectory(r, "Symbol", includeTestIssues, func(map[string]string) (string, bool) {
+		return "XNAS", true
+	})
+}
+
+// ParseOtherListed parses otherlisted.txt (NYSE, NYSE American, NYSE Arca,
+// Cboe and IEX listings). Rows with an unknown exchange code are skipped.
+func ParseOtherListed(r io.Reader, includeTestIssues bool) ([]PolygonTicker, error) {
+	return parseSymbolDirectory(r, "ACT Symbol", includeTestIssues, func(row map[string]string) (string, bool) {
+		mic, ok := otherListedExchanges[row["Exchange"]]
+		return mic, ok
+	})
+}
+
+// parseSymbolDirectory parses a pipe-delimited symbol directory file. Columns
+// are looked up by the header row rather than by position: otherlisted.txt
+// has been published with both 8 and 9 fields per row (a trailing pipe), and
+// positional parsing misread NYSE rows when the layout changed.
+func parseSymbolDirectory(r io.Reader, symbolColumn string, includeTestIssues bool, exchange func(map[string]string) (string, bool)) ([]PolygonTicker, error) {
+	scanner := bufio.NewScanner(r)
+
+	var header []string
+	var tickers []PolygonTicker
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "File Creation Time") {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		if header == nil {
+			header = trimTrailingEmpty(fields)
+			for _, col := range []string{symbolColumn, "Security Name", "Test Issue", "ETF"} {
+				if indexOf(header, col) < 0 {
+					return nil, fmt.Errorf("missing %q column in header", col)
+				}
+			}
+			continue
+		}
+
+		if len(fields) < len(header) || !allEmpty(fields[len(header):]) {
+			// Malformed row; skip it rather than misalign its columns
+			continue
+		}
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			row[col] = fields[i]
+		}
+
+		if row[symbolColumn] == "" || (row["Test Issue"] == "Y" && !includeTestIssues) {
+			continue
+		}
+		mic, ok := exchange(row)
+		if !ok {
+			continue
+		}
+
+		tickers = append(tickers, PolygonTicker{
+			Ticker:          strings.ToUpper(row[symbolColumn]),
+			Name:            row["Security Name"],
+			Market:          "stocks",
+			Locale:          "us",
+			Type:            symbolDirectoryType(row["ETF"], row["Security Name"]),
+			Active:          true,
+			CurrencyName:    "usd",
+			PrimaryExchange: mic,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("empty symbol directory")
+	}
+	return tickers, nil
+}
+
+// symbolDirectoryType infers a Polygon type code. The directory only flags
+// ETFs, so warrants, rights, units and preferreds are recognized by name.
+// Units are SPAC-style units, checked first as their names describe the
+// share and warrant or right they hold; a partnership's common units are
+// its stock.
+func symbolDirectoryType(etf, name string) string {
+	if etf == "Y" {
+		return "ETF"
+	}
+	switch {
+	case unitNamePattern.MatchString(name) && !partnershipUnitNamePattern.MatchString(name):
+		return "UNIT"
+	case warrantNamePattern.MatchString(name):
+		return "WARRANT"
+	case rightNamePattern.MatchString(name):
+		return "RIGHT"
+	case preferredNamePattern.MatchString(name):
+		return "PFD"
+	}
+	return "CS"
+}
+
+func trimTrailingEmpty(fields []string) []string {
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
+
+func allEmpty(values []string) bool {
+	for _, v := range values {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf(values []string, s string) int {
+	for i, v := range values {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func tickerSymbols(tickers []PolygonTicker) []string {
+	symbols := make([]string, len(tickers))
+	for i, t := range tickers {
+		symbols[i] = t.Ticker
+	}
+	return symbols
+}
+
+func TestParseNasdaqListed(t *testing.T) {
+	tickers, err := ParseNasdaqListed(openFixture(t, "nasdaqlisted.txt"), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"AAPL", "QQQ", "ACAHW", "UBSI"}, tickerSymbols(tickers), "test issue and footer skipped")
+	assert.Equal(t, PolygonTicker{
+		Ticker: "AAPL", Name: "Apple Inc. - Common Stock", Market: "stocks", Locale: "us",
+		Type: "CS", Active: true, CurrencyName: "usd", PrimaryExchange: "XNAS",
+	}, tickers[0])
+	assert.Equal(t, "ETF", tickers[1].Type)
+	assert.Equal(t, "WARRANT", tickers[2].Type)
+	assert.Equal(t, "CS", tickers[3].Type, "United isn't a unit")
+}
+
+func TestSymbolDirectoryType(t *testing.T) {
+	tests := []struct {
+		name string
+		etf  string
+		want string
+	}{
+		{"Apple Inc. - Common Stock", "N", "CS"},
+		{"Invesco QQQ Trust, Series 1", "Y", "ETF"},
+		{"Acme Acquisition Corp - Units", "N", "UNIT"},
+		{"Acme Acquisition Corp - Unit", "N", "UNIT"},
+		{"Acme Acquisition Corp Units, each consisting of one Class A ordinary share and one-half of one redeemable warrant", "N", "UNIT"},
+		{"Acme Acquisition Corp - Warrant", "N", "WARRANT"},
+		{"Acme Acquisition Corp - Rights", "N", "RIGHT"},
+		{"Energy Transfer LP Common Units representing limited partner interests", "N", "CS"},
+		{"Enterprise Products Partners L.P. Common Units", "N", "CS"},
+		{"Alliance Resource Partners, L.P. - Common Units Representing Limited Partners Interests", "N", "CS"},
+		{"Western Midstream Partners, LP Limited Partner Units", "N", "CS"},
+		{"United Bankshares, Inc. - Common Stock", "N", "CS"},
+		{"Bank of America Corporation Depositary Shares, each representing a 1/1000th interest in a share of Preferred Stock", "N", "PFD"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, symbolDirectoryType(tt.etf, tt.name), tt.name)
+	}
+}
+
+func TestParseNasdaqListed_IncludeTestIssues(t *testing.T) {
+	tickers, err := ParseNasdaqListed(openFixture(t, "nasdaqlisted.txt"), true)
+	require.NoError(t, err)
+	assert.Contains(t, tickerSymbols(tickers), "ZVZZT")
+}
+
+func TestParseOtherListed(t *testing.T) {
+	// The fixture has a trailing pipe on every line (9 fields for 8 columns)
+	tickers, err := ParseOtherListed(openFixture(t, "otherlisted.txt"), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"BRK.A", "IBM", "SPY", "IMO"}, tickerSymbols(tickers),
+		"test issue, truncated row and unknown exchange skipped")
+	assert.Equal(t, "Berkshire Hathaway Inc. Class A", tickers[0].Name)
+	assert.Equal(t, "XNYS", tickers[0].PrimaryExchange)
+	assert.Equal(t, "ARCX", tickers[2].PrimaryExchange)
+	assert.Equal(t, "ETF", tickers[2].Type)
+	assert.Equal(t, "XASE", tickers[3].PrimaryExchange, "empty last column still parses")
+}
+
+func TestParseOtherListed_EightColumns(t *testing.T) {
+	content := strings.Join([]string{
+		"ACT Symbol|Security Name|Exchange|CQS Symbol|ETF|Round Lot Size|Test Issue|NASDAQ Symbol",
+		"IBM|International Business Machines Corporation Common Stock|N|IBM|N|100|N|IBM",
+		"SPY|SPDR S&P 500 ETF Trust|P|SPY|Y|100|N|SPY",
+		"File Creation Time: 0302202612:00|||||||",
+	}, "\n")
+
+	tickers, err := ParseOtherListed(strings.NewReader(content), false)
+	require.NoError(t, err)
+	require.Len(t, tickers, 2)
+	assert.Equal(t, "IBM", tickers[0].Ticker)
+	assert.Equal(t, "XNYS", tickers[0].PrimaryExchange)
+	assert.Equal(t, "CS", tickers[0].Type)
+	assert.Equal(t, "ETF", tickers[1].Type)
+}
+
+func TestParseOtherListed_ReorderedColumns(t *testing.T) {
+	content := "Exchange|ETF|ACT Symbol|Test Issue|Security Name\nN|N|IBM|N|International Business Machines\n"
+
+	tickers, err := ParseOtherListed(strings.NewReader(content), false)
+	require.NoError(t, err)
+	require.Len(t, tickers, 1)
+	assert.Equal(t, "IBM", tickers[0].Ticker)
+	assert.Equal(t, "International Business Machines", tickers[0].Name)
+}
+
+func TestParseOtherListed_BadHeader(t *testing.T) {
+	_, err := ParseOtherListed(strings.NewReader("Symbol|Name\nIBM|IBM\n"), false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ACT Symbol")
+
+	_, err = ParseNasdaqListed(strings.NewReader(""), false)
+	require.Error(t, err)
+}
+
+func TestNasdaqTraderSource_GetAllTickers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata"+r.URL.Path)
+	}))
+	defer server.Close()
+
+	orig := NasdaqTraderBaseURL
+	NasdaqTraderBaseURL = server.URL
+	defer func() { NasdaqTraderBaseURL = orig }()
+
+	source := &NasdaqTraderSource{Client: server.Client()}
+
+	stocks, err := source.GetAllTickers("stocks", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AAPL", "UBSI", "BRK.A", "IBM", "IMO"}, tickerSymbols(stocks))
+
+	etfs, err := source.GetAllTickers("etf", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"QQQ", "SPY"}, tickerSymbols(etfs))
+
+	limited, err := source.GetAllTickers("all_equities", 3)
+	require.NoError(t, err)
+	assert.Len(t, limited, 3)
+
+	_, err = source.GetAllTickers("indices", 0)
+	assert.Error(t, err)
+}
+
+func TestNasdaqTraderSource_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	orig := NasdaqTraderBaseURL
+	NasdaqTraderBaseURL = server.URL
+	defer func() { NasdaqTraderBaseURL = orig }()
+
+	_, err := (&NasdaqTraderSource{Client: server.Client()}).GetAllTickers("stocks", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
@@ -10,11 +10,13 @@ import (
 
 type NotificationService struct {
 	emailService *EmailService
+	smsService   *SMSService
 }
 
 func NewNotificationService(emailService *EmailService) *NotificationService {
 	return &NotificationService{
 		emailService: emailService,
+		smsService:   NewSMSService(),
 	}
 }
 
@@ -25,6 +27,12 @@ func (s *NotificationService) GetNotificationPreferences(userID string) (*models
 
 // UpdateNotificationPreferences updates notification preferences
 func (s *NotificationService) UpdateNotificationPreferences(userID string, req *models.UpdateNotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+	if req.SMSEnabled != nil && *req.SMSEnabled {
+		if err := requireSMSPlan(userID); err != nil {
+			return nil, err
+		}
+	}
+
 	updates := make(map[string]interface{})
 
 	if req.EmailEnabled != nil {
@@ -89,6 +97,24 @@ func (s *NotificationService) UpdateNotificationPreferences(userID string, req *
 	if req.QuietHoursTimezone != nil {
 		updates["quiet_hours_timezone"] = *req.QuietHoursTimezone
 	}
+	if req.WebhookURL != nil {
+		updates["webhook_url"] = *req.WebhookURL
+	}
+	if req.WebhookSecret != nil {
+		updates["webhook_secret"] = *req.WebhookSecret
+	}
+	if req.SMSEnabled != nil {
+		updates["sms_enabled"] = *req.SMSEnabled
+	}
+	if req.PhoneNumber != nil {
+		if *req.PhoneNumber == "" {
+			updates["phone_number"] = nil
+		} else {
+			updates["phone_number"] = *req.PhoneNumber
+		}
+		// A new number has to be confirmed again
+		updates["phone_verified"] = false
+	}
 
 	if err := database.UpdateNotificationPreferences(userID, updates); err != nil {
 		return nil, err
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+var (
+	// ErrSMSNotInPlan is returned when the user's plan doesn't include SMS
+	// alerts (the sms_alerts plan feature)
+	ErrSMSNotInPlan = errors.New("SMS alerts are not included in your plan")
+	// ErrNoPhoneNumber is returned for a verification request before a
+	// phone number is saved in notification preferences
+	ErrNoPhoneNumber = errors.New("no phone number in notification preferences")
+	// ErrPhoneCodeTooSoon is returned when a code was sent less than
+	// phoneCodeResendAfter ago
+	ErrPhoneCodeTooSoon = errors.New("a verification code was just sent; try again in a minute")
+	// ErrInvalidPhoneCode is returned for a wrong, expired or used-up code
+	ErrInvalidPhoneCode = errors.New("invalid or expired verification code")
+)
+
+const (
+	phoneCodeTTL         = 10 * time.Minute
+	phoneCodeResendAfter = time.Minute
+	phoneCodeMaxAttempts = 5
+)
+
+// requireSMSPlan returns ErrSMSNotInPlan unless the user's subscription is
+// active on a plan with the sms_alerts feature
+func requireSMSPlan(userID string) error {
+	sub, err := database.GetUserSubscription(userID)
+	if err != nil {
+		return err
+	}
+	if !sub.HasFeature(models.FeatureSMSAlerts) {
+		return ErrSMSNotInPlan
+	}
+	return nil
+}
+
+// SendPhoneVerificationCode texts a code to the phone number in the user's
+// notification preferences, replacing any code sent before
+func (s *NotificationService) SendPhoneVerificationCode(ctx context.Context, userID string) error {
+	if err := requireSMSPlan(userID); err != nil {
+		return err
+	}
+	prefs, err := database.GetNotificationPreferences(userID)
+	if err != nil {
+		return err
+	}
+	if prefs.PhoneNumber == nil || *prefs.PhoneNumber == "" {
+		return ErrNoPhoneNumber
+	}
+
+	pending, err := database.GetPhoneVerification(userID)
+	if err != nil {
+		return err
+	}
+	if pending != nil && time.Since(pending.CreatedAt) < phoneCodeResendAfter {
+		return ErrPhoneCodeTooSoon
+	}
+
+	code, err := generatePhoneCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	if err := database.SavePhoneVerification(userID, *prefs.PhoneNumber, hashPhoneCode(code), time.Now().Add(phoneCodeTTL)); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Your InvestorCenter verification code is %s. It expires in 10 minutes.", code)
+	if err := s.smsService.Send(ctx, *prefs.PhoneNumber, body); err != nil {
+		return fmt.Errorf("failed to send verification code: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPhoneNumber marks the user's phone number verified if code is the
+// one last sent to it
+func (s *NotificationService) ConfirmPhoneNumber(userID, code string) (*models.NotificationPreferences, error) {
+	pending, err := database.GetPhoneVerification(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPhoneCode(pending, code, time.Now()); err != nil {
+		if pending != nil && !errors.Is(err, errPhoneCodeExhausted) {
+			if err := database.IncrementPhoneVerificationAttempts(userID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidPhoneCode
+	}
+
+	confirmed, err := database.ConfirmPhoneNumber(userID, pending.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		// The number was changed after the code was sent
+		return nil, ErrInvalidPhoneCode
+	}
+	return database.GetNotificationPreferences(userID)
+}
+
+// errPhoneCodeExhausted marks a pending code that is expired or out of
+// attempts, which further tries don't count against
+var errPhoneCodeExhausted = errors.New("verification code expired or out of attempts")
+
+// checkPhoneCode reports whether code matches the pending verification
+func checkPhoneCode(pending *database.PhoneVerification, code string, now time.Time) error {
+	if pending == nil {
+		return ErrInvalidPhoneCode
+	}
+	if now.After(pending.ExpiresAt) || pending.Attempts >= phoneCodeMaxAttempts {
+		return errPhoneCodeExhausted
+	}
+	if subtle.ConstantTimeCompare([]byte(hashPhoneCode(code)), []byte(pending.CodeHash)) != 1 {
+		return ErrInvalidPhoneCode
+	}
+	return nil
+}
+
+// generatePhoneCode returns a random 6-digit code
+func generatePhoneCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashPhoneCode returns the hex SHA-256 of code, as stored
+func hashPhoneCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
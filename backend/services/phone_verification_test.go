@@ -0,0 +1,57 @@
+package services
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+func TestGeneratePhoneCode_SixDigits(t *testing.T) {
+	digits := regexp.MustCompile(`^\d{6}$`)
+	for i := 0; i < 50; i++ {
+		code, err := generatePhoneCode()
+		require.NoError(t, err)
+		assert.Regexp(t, digits, code)
+	}
+}
+
+func TestCheckPhoneCode(t *testing.T) {
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	pending := func(attempts int, expiresAt time.Time) *database.PhoneVerification {
+		return &database.PhoneVerification{
+			PhoneNumber: "+14155550123",
+			CodeHash:    hashPhoneCode("123456"),
+			Attempts:    attempts,
+			ExpiresAt:   expiresAt,
+		}
+	}
+
+	assert.NoError(t, checkPhoneCode(pending(0, now.Add(time.Minute)), "123456", now))
+	assert.ErrorIs(t, checkPhoneCode(pending(0, now.Add(time.Minute)), "654321", now), ErrInvalidPhoneCode)
+	assert.ErrorIs(t, checkPhoneCode(nil, "123456", now), ErrInvalidPhoneCode)
+	assert.ErrorIs(t, checkPhoneCode(pending(0, now.Add(-time.Second)), "123456", now), errPhoneCodeExhausted)
+	assert.ErrorIs(t, checkPhoneCode(pending(phoneCodeMaxAttempts, now.Add(time.Minute)), "123456", now), errPhoneCodeExhausted)
+}
+
+func TestUserSubscriptionHasFeature(t *testing.T) {
+	sub := func(status, features string) *models.UserSubscriptionWithPlan {
+		return &models.UserSubscriptionWithPlan{
+			UserSubscription: models.UserSubscription{Status: status},
+			PlanFeatures:     json.RawMessage(features),
+		}
+	}
+
+	assert.True(t, sub("active", `{"sms_alerts": true}`).HasFeature(models.FeatureSMSAlerts))
+	assert.True(t, sub("trialing", `{"sms_alerts": true}`).HasFeature(models.FeatureSMSAlerts))
+	assert.False(t, sub("active", `{"sms_alerts": false}`).HasFeature(models.FeatureSMSAlerts))
+	assert.False(t, sub("active", `{}`).HasFeature(models.FeatureSMSAlerts))
+	assert.False(t, sub("canceled", `{"sms_alerts": true}`).HasFeature(models.FeatureSMSAlerts))
+	assert.False(t, sub("active", ``).HasFeature(models.FeatureSMSAlerts))
+}
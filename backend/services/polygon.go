@@ -13,20 +13,35 @@ import (
 	"github.com/shopspring/decimal"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"investorcenter-api/httputil"
+	"investorcenter-api/market"
 	"investorcenter-api/models"
 )
 
 var (
 	PolygonBaseURL = "https://api.polygon.io"
+
+	// PolygonRetryBaseDelay is the backoff before the first retry; each
+	// further retry doubles it
+	PolygonRetryBaseDelay = 1 * time.Second
+	// PolygonMaxRetryDelay caps both the backoff and a server's Retry-After
+	PolygonMaxRetryDelay = 30 * time.Second
+
+	// polygonSleep waits between retries, swapped out in tests
+	polygonSleep = time.Sleep
 )
 
 // PolygonClient handles Polygon.io API requests
 type PolygonClient struct {
 	APIKey string
 	Client *http.Client
+	// MaxRetries is how many times a request is retried after a 429, 5xx or
+	// transport error. Zero disables retries.
+	MaxRetries int
 }
 
-// NewPolygonClient creates a new Polygon.io client
+// NewPolygonClient creates a new Polygon.io client. Requests are retried up
+// to POLYGON_MAX_RETRIES times (default 2).
 func NewPolygonClient() *PolygonClient {
 	apiKey := os.Getenv("POLYGON_API_KEY")
 	if apiKey == "" {
@@ -34,13 +49,38 @@ func NewPolygonClient() *PolygonClient {
 	}
 
 	return &PolygonClient{
-		APIKey: apiKey,
-		Client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		APIKey:     apiKey,
+		Client:     upstreamClient("polygon"),
+		MaxRetries: maxRetriesFromEnv("POLYGON_MAX_RETRIES"),
 	}
 }
 
+// Do sends req, retrying 429 and 5xx responses and transport errors with
+// jittered exponential backoff (see httputil.DoWithRetry). req is resent as
+// is, so it must not have a body.
+func (p *PolygonClient) Do(req *http.Request) (*http.Response, error) {
+	policy := httputil.RetryPolicy{
+		MaxRetries: p.MaxRetries,
+		BaseDelay:  PolygonRetryBaseDelay,
+		MaxDelay:   PolygonMaxRetryDelay,
+		Jitter:     0.5,
+		Sleep:      polygonSleep,
+	}
+	return httputil.DoWithRetry(policy, func() (*http.Response, error) {
+		UpstreamQuota().Record(QuotaSourcePolygon)
+		return p.Client.Do(req)
+	})
+}
+
+// Get GETs url through Do
+func (p *PolygonClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p.Do(req)
+}
+
 // QuoteResponse represents Polygon.io quote response
 type PolygonQuoteResponse struct {
 	Status    string `json:"status"`
@@ -145,7 +185,7 @@ func (p *PolygonClient) GetHistoricalData(symbol string, timespan string, from s
 	url := fmt.Sprintf("%s/v2/aggs/ticker/%s/range/1/%s/%s/%s?adjusted=true&sort=asc&apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), timespan, from, to, p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch historical data: %w", err)
 	}
@@ -199,7 +239,7 @@ func (p *PolygonClient) GetIntradayData(symbol string) ([]models.ChartDataPoint,
 	url := fmt.Sprintf("%s/v2/aggs/ticker/%s/range/5/minute/%s/%s?adjusted=true&sort=asc&apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), tradingDayStr, tradingDayStr, p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch intraday data: %w", err)
 	}
@@ -274,7 +314,7 @@ func (p *PolygonClient) GetTickerDetails(symbol string) (*TickerDetailsResponse,
 	url := fmt.Sprintf("%s/v3/reference/tickers/%s?apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch ticker details: %w", err)
 	}
@@ -292,6 +332,25 @@ func (p *PolygonClient) GetTickerDetails(symbol string) (*TickerDetailsResponse,
 	return &detailsResp, nil
 }
 
+// GetTickerLogoURL fetches the ticker's logo URL from its details: the
+// branding logo, or its icon when there is no logo. Returns "" when Polygon
+// has neither. The URL needs the API key appended to be fetched.
+func (p *PolygonClient) GetTickerLogoURL(symbol string) (string, error) {
+	details, err := p.GetTickerDetails(symbol)
+	if err != nil {
+		return "", err
+	}
+	return details.LogoURL(), nil
+}
+
+// LogoURL returns the branding logo URL, falling back to the icon
+func (d *TickerDetailsResponse) LogoURL() string {
+	if d.Results.Branding.LogoURL != "" {
+		return d.Results.Branding.LogoURL
+	}
+	return d.Results.Branding.IconURL
+}
+
 // GetMultipleQuotes fetches quotes for multiple symbols efficiently
 // This method uses bulk snapshots and filters for the requested symbols
 func (p *PolygonClient) GetMultipleQuotes(symbols []string) (map[string]*QuoteData, error) {
@@ -426,24 +485,10 @@ func (p *PolygonClient) getBulkCryptoQuotes(symbols []string) (map[string]*Quote
 	return quotes, nil
 }
 
-// IsMarketOpen checks if the US market is currently open
+// IsMarketOpen checks if the US market is currently open, accounting for
+// weekends, exchange holidays and early closes
 func (p *PolygonClient) IsMarketOpen() bool {
-	now := time.Now()
-
-	// Convert to Eastern Time (US market timezone)
-	est, _ := time.LoadLocation("America/New_York")
-	estTime := now.In(est)
-
-	// Check if it's a weekday
-	if estTime.Weekday() == time.Saturday || estTime.Weekday() == time.Sunday {
-		return false
-	}
-
-	// Market hours: 9:30 AM - 4:00 PM EST
-	marketOpen := time.Date(estTime.Year(), estTime.Month(), estTime.Day(), 9, 30, 0, 0, est)
-	marketClose := time.Date(estTime.Year(), estTime.Month(), estTime.Day(), 16, 0, 0, 0, est)
-
-	return estTime.After(marketOpen) && estTime.Before(marketClose)
+	return market.Default().IsMarketOpen(time.Now())
 }
 
 // FinancialsResponse represents comprehensive financial statements response
@@ -576,7 +621,7 @@ func (p *PolygonClient) GetFundamentals(symbol string) (*models.Fundamentals, er
 	url := fmt.Sprintf("%s/vX/reference/financials?ticker=%s&timeframe=ttm&limit=1&apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch fundamentals: %w", err)
 	}
@@ -757,7 +802,7 @@ func (p *PolygonClient) GetNews(symbol string, limit int) ([]models.NewsArticle,
 	url := fmt.Sprintf("%s/v2/reference/news?ticker=%s&limit=%d&apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), limit, p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch news: %w", err)
 	}
@@ -831,7 +876,7 @@ func (p *PolygonClient) GetGeneralNews(limit int) ([]GeneralNewsArticle, error)
 	url := fmt.Sprintf("%s/v2/reference/news?limit=%d&order=desc&sort=published_utc&apikey=%s",
 		PolygonBaseURL, limit, p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch general news: %w", err)
 	}
@@ -987,7 +1032,7 @@ func (p *PolygonClient) GetAllTickers(assetType string, limit int) ([]PolygonTic
 		pageCount++
 		log.Printf("Fetching page %d (already have %d tickers)...", pageCount, len(allTickers))
 
-		resp, err := p.Client.Get(url)
+		resp, err := p.Get(url)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch tickers on page %d: %w", pageCount, err)
 		}
@@ -1049,7 +1094,7 @@ func (p *PolygonClient) GetTickersByType(tickerType string) ([]PolygonTicker, er
 	url := fmt.Sprintf("%s/v3/reference/tickers?type=%s&active=true&limit=1000&apikey=%s",
 		PolygonBaseURL, tickerType, p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch tickers by type: %w", err)
 	}
@@ -1315,7 +1360,7 @@ func (p *PolygonClient) GetUnifiedSnapshot(symbol string) (*UnifiedSnapshotResul
 	url := fmt.Sprintf("%s/v3/snapshot?ticker.any_of=%s&apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch unified snapshot: %w", err)
 	}
@@ -1512,7 +1557,7 @@ func (p *PolygonClient) GetBulkStockSnapshots() (*BulkStockSnapshotResponse, err
 	snapshotURL := fmt.Sprintf("%s/v2/snapshot/locale/us/markets/stocks/tickers?apikey=%s",
 		PolygonBaseURL, p.APIKey)
 
-	resp, err := p.Client.Get(snapshotURL)
+	resp, err := p.Get(snapshotURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch bulk stock snapshots: %w", err)
 	}
@@ -1582,7 +1627,7 @@ func (p *PolygonClient) GetBulkCryptoSnapshots() (*BulkCryptoSnapshotResponse, e
 	snapshotURL := fmt.Sprintf("%s/v2/snapshot/locale/global/markets/crypto/tickers?apikey=%s",
 		PolygonBaseURL, p.APIKey)
 
-	resp, err := p.Client.Get(snapshotURL)
+	resp, err := p.Get(snapshotURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch bulk crypto snapshots: %w", err)
 	}
@@ -1648,7 +1693,7 @@ func (p *PolygonClient) GetIndexSnapshots(symbols []string) ([]IndexSnapshotResu
 	url := fmt.Sprintf("%s/v3/snapshot?ticker.any_of=%s&apikey=%s",
 		PolygonBaseURL, tickerParam, p.APIKey)
 
-	resp, err := p.Client.Get(url)
+	resp, err := p.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch index snapshots: %w", err)
 	}
@@ -1698,7 +1743,7 @@ func (p *PolygonClient) GetStockRealTimePrice(symbol string) (*models.StockPrice
 	snapshotURL := fmt.Sprintf("%s/v2/snapshot/locale/us/markets/stocks/tickers/%s?apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), p.APIKey)
 
-	resp, err := p.Client.Get(snapshotURL)
+	resp, err := p.Get(snapshotURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch stock snapshot: %w", err)
 	}
@@ -1774,7 +1819,7 @@ func (p *PolygonClient) GetCryptoRealTimePrice(symbol string) (*models.StockPric
 	snapshotURL := fmt.Sprintf("%s/v2/snapshot/locale/global/markets/crypto/tickers/%s?apikey=%s",
 		PolygonBaseURL, strings.ToUpper(symbol), p.APIKey)
 
-	resp, err := p.Client.Get(snapshotURL)
+	resp, err := p.Get(snapshotURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch crypto snapshot: %w", err)
 	}
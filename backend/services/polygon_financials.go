@@ -120,7 +120,7 @@ func (c *PolygonFinancialsClient) GetRatios(ctx context.Context, params Financia
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch ratios: %w", err)
 	}
@@ -151,7 +151,7 @@ func (c *PolygonFinancialsClient) fetchFinancials(ctx context.Context, endpoint
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch financials: %w", err)
 	}
@@ -229,7 +229,7 @@ func (c *PolygonFinancialsClient) GetAllFinancialsWithPagination(ctx context.Con
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
-		resp, err := c.Client.Do(req)
+		resp, err := c.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch financials: %w", err)
 		}
@@ -909,6 +909,25 @@ func TestPolygon_HTTP_GetTickerDetails_ConnectionError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestPolygon_HTTP_GetTickerLogoURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/reference/tickers/MSFT", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"OK","results":{"ticker":"MSFT","branding":{
+			"logo_url":"https://api.polygon.io/v1/reference/company-branding/msft/logo.svg",
+			"icon_url":"https://api.polygon.io/v1/reference/company-branding/msft/icon.png"}}}`))
+	}))
+	defer server.Close()
+
+	restore := savePolygonBaseURL()
+	defer restore()
+	PolygonBaseURL = server.URL
+
+	url, err := newPolygonTestClient().GetTickerLogoURL("msft")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.polygon.io/v1/reference/company-branding/msft/logo.svg", url)
+}
+
 // ===========================================================================
 // GetNews — additional edge cases
 // ===========================================================================
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// polygonStocksSocketURL is Polygon's real-time stocks websocket feed
+const polygonStocksSocketURL = "wss://socket.polygon.io/stocks"
+
+// Reconnect backoff: doubled after each failed attempt, and reset once a
+// connection authenticates
+const (
+	polygonSocketMinBackoff = time.Second
+	polygonSocketMaxBackoff = time.Minute
+)
+
+// Keepalive: the client pings every polygonSocketPingInterval and drops the
+// connection when neither a message nor a pong arrives within
+// polygonSocketPongWait, so a silently dead connection is reconnected
+// instead of blocking the read forever
+const (
+	polygonSocketPingInterval = 30 * time.Second
+	polygonSocketPongWait     = 60 * time.Second
+	polygonSocketWriteWait    = 10 * time.Second
+)
+
+// ErrPolygonAuthFailed is returned by Run when Polygon rejects the API key.
+// Reconnecting won't help, so Run gives up.
+var ErrPolygonAuthFailed = errors.New("polygon websocket authentication failed")
+
+// PolygonAggregate is a per-minute aggregate ("AM") event for one symbol
+type PolygonAggregate struct {
+	EventType string  `json:"ev"`
+	Symbol    string  `json:"sym"`
+	Volume    int64   `json:"v"`  // volume in this minute
+	DayVolume int64   `json:"av"` // accumulated volume for the day
+	DayOpen   float64 `json:"op"` // official opening price for the day
+	Open      float64 `json:"o"`
+	High      float64 `json:"h"`
+	Low       float64 `json:"l"`
+	Close     float64 `json:"c"`
+	Start     int64   `json:"s"` // Unix ms
+	End       int64   `json:"e"` // Unix ms
+}
+
+// polygonSocketEvent is one element of a Polygon websocket message: a
+// status event or a market data event
+type polygonSocketEvent struct {
+	PolygonAggregate
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// polygonSocketAction is a client message: auth, subscribe or unsubscribe
+type polygonSocketAction struct {
+	Action string `json:"action"`
+	Params string `json:"params"`
+}
+
+// PolygonWebsocketClient streams per-minute aggregates for a set of symbols
+// from Polygon's websocket feed. Run keeps the connection up, reconnecting
+// with backoff and subscribing to the current symbols again each time.
+type PolygonWebsocketClient struct {
+	url        string
+	apiKey     string
+	dialer     *websocket.Dialer
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	pingInterval time.Duration
+	pongWait     time.Duration
+
+	mu      sync.Mutex // guards symbols and writes to conn
+	symbols map[string]bool
+	conn    *websocket.Conn
+}
+
+// NewPolygonWebsocketClient creates a client for symbols, authenticating
+// with POLYGON_API_KEY. POLYGON_WS_URL overrides the feed URL.
+func NewPolygonWebsocketClient(symbols []string) *PolygonWebsocketClient {
+	url := os.Getenv("POLYGON_WS_URL")
+	if url == "" {
+		url = polygonStocksSocketURL
+	}
+	return NewPolygonWebsocketClientWith(url, os.Getenv("POLYGON_API_KEY"), symbols)
+}
+
+// NewPolygonWebsocketClientWith creates a client for the feed at url
+func NewPolygonWebsocketClientWith(url, apiKey string, symbols []string) *PolygonWebsocketClient {
+	c := &PolygonWebsocketClient{
+		url:        url,
+		apiKey:     apiKey,
+		dialer:     &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+		minBackoff: polygonSocketMinBackoff,
+		maxBackoff: polygonSocketMaxBackoff,
+		symbols:    make(map[string]bool, len(symbols)),
+
+		pingInterval: polygonSocketPingInterval,
+		pongWait:     polygonSocketPongWait,
+	}
+	for _, s := range symbols {
+		c.symbols[strings.ToUpper(s)] = true
+	}
+	return c
+}
+
+// Symbols returns the subscribed symbols, sorted
+func (c *PolygonWebsocketClient) Symbols() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sortedSymbolsLocked()
+}
+
+// SetSymbols replaces the subscribed symbols. On a live connection only the
+// difference is subscribed and unsubscribed.
+func (c *PolygonWebsocketClient) SetSymbols(symbols []string) error {
+	next := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		next[strings.ToUpper(s)] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var added, removed []string
+	for s := range next {
+		if !c.symbols[s] {
+			added = append(added, s)
+		}
+	}
+	for s := range c.symbols {
+		if !next[s] {
+			removed = append(removed, s)
+		}
+	}
+	c.symbols = next
+
+	if c.conn == nil {
+		return nil // subscribed on the next connect
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	if err := c.sendLocked("subscribe", added); err != nil {
+		return err
+	}
+	return c.sendLocked("unsubscribe", removed)
+}
+
+// Run connects and passes each aggregate to handle until ctx is done,
+// reconnecting whenever the connection drops. handle is called from a
+// single goroutine. Run returns nil once ctx is done, or
+// ErrPolygonAuthFailed if the API key is rejected.
+func (c *PolygonWebsocketClient) Run(ctx context.Context, handle func(PolygonAggregate)) error {
+	backoff := c.minBackoff
+	for {
+		authenticated, err := c.session(ctx, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrPolygonAuthFailed) {
+			return err
+		}
+		if authenticated {
+			backoff = c.minBackoff
+		}
+		log.Printf("⚠️ Polygon websocket disconnected: %v (reconnecting in %s)", err, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil
+		}
+		backoff = min(backoff*2, c.maxBackoff)
+	}
+}
+
+// session runs one connection: connect, authenticate, subscribe, then read
+// until the connection fails or ctx is done. It reports whether the
+// connection got as far as authenticating.
+func (c *PolygonWebsocketClient) session(ctx context.Context, handle func(PolygonAggregate)) (bool, error) {
+	conn, _, err := c.dialer.DialContext(ctx, c.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read below when ctx is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err := c.authenticate(conn); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	err = c.sendLocked("subscribe", c.sortedSymbolsLocked())
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+	}()
+	if err != nil {
+		return true, err
+	}
+	log.Printf("✓ Polygon websocket connected, subscribed to %d symbols", len(c.Symbols()))
+
+	conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	})
+	go c.keepAlive(conn, done)
+
+	for {
+		events, err := readSocketEvents(conn)
+		if err != nil {
+			return true, err
+		}
+		conn.SetReadDeadline(time.Now().Add(c.pongWait))
+		for _, e := range events {
+			switch e.EventType {
+			case "AM":
+				handle(e.PolygonAggregate)
+			case "status":
+				if e.Status == "error" {
+					log.Printf("⚠️ Polygon websocket error: %s", e.Message)
+				}
+			}
+		}
+	}
+}
+
+// keepAlive pings conn every pingInterval until done is closed. A failed
+// ping closes conn, which ends the session's read.
+func (c *PolygonWebsocketClient) keepAlive(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(c.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(polygonSocketWriteWait)); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// authenticate waits for the connected status, then sends the API key and
+// waits for the result
+func (c *PolygonWebsocketClient) authenticate(conn *websocket.Conn) error {
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	if err := expectSocketStatus(conn, "connected"); err != nil {
+		return err
+	}
+	if err := conn.WriteJSON(polygonSocketAction{Action: "auth", Params: c.apiKey}); err != nil {
+		return fmt.Errorf("send auth: %w", err)
+	}
+	return expectSocketStatus(conn, "auth_success")
+}
+
+// expectSocketStatus reads messages until one carries a status event, and
+// checks it is want
+func expectSocketStatus(conn *websocket.Conn, want string) error {
+	for {
+		events, err := readSocketEvents(conn)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if e.EventType != "status" {
+				continue
+			}
+			switch e.Status {
+			case want:
+				return nil
+			case "auth_failed":
+				return fmt.Errorf("%w: %s", ErrPolygonAuthFailed, e.Message)
+			default:
+				return fmt.Errorf("expected status %q, got %q: %s", want, e.Status, e.Message)
+			}
+		}
+	}
+}
+
+// readSocketEvents reads one message, a JSON array of events
+func readSocketEvents(conn *websocket.Conn) ([]polygonSocketEvent, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	var events []polygonSocketEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("parse message: %w", err)
+	}
+	return events, nil
+}
+
+// sendLocked sends action for the per-minute aggregate channels of symbols.
+// The caller holds c.mu.
+func (c *PolygonWebsocketClient) sendLocked(action string, symbols []string) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	channels := make([]string, len(symbols))
+	for i, s := range symbols {
+		channels[i] = "AM." + s
+	}
+	if err := c.conn.WriteJSON(polygonSocketAction{Action: action, Params: strings.Join(channels, ",")}); err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	return nil
+}
+
+func (c *PolygonWebsocketClient) sortedSymbolsLocked() []string {
+	symbols := make([]string, 0, len(c.symbols))
+	for s := range c.symbols {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePolygonSocket is a Polygon websocket feed that accepts apiKey,
+// records what each connection subscribes to, sends one aggregate per
+// subscribed symbol and then drops the first connection
+type fakePolygonSocket struct {
+	t      *testing.T
+	apiKey string
+
+	mu         sync.Mutex
+	subscribes [][]string // subscribe params, one entry per message
+	conns      int
+}
+
+func (f *fakePolygonSocket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	require.NoError(f.t, err)
+	defer conn.Close()
+
+	f.mu.Lock()
+	f.conns++
+	n := f.conns
+	f.mu.Unlock()
+
+	_ = conn.WriteJSON([]map[string]string{{"ev": "status", "status": "connected"}})
+
+	var auth polygonSocketAction
+	if err := conn.ReadJSON(&auth); err != nil || auth.Action != "auth" {
+		return
+	}
+	if auth.Params != f.apiKey {
+		_ = conn.WriteJSON([]map[string]string{{"ev": "status", "status": "auth_failed", "message": "authentication failed"}})
+		return
+	}
+	_ = conn.WriteJSON([]map[string]string{{"ev": "status", "status": "auth_success"}})
+
+	for {
+		var action polygonSocketAction
+		if err := conn.ReadJSON(&action); err != nil {
+			return
+		}
+		channels := strings.Split(action.Params, ",")
+		f.mu.Lock()
+		if action.Action == "subscribe" {
+			f.subscribes = append(f.subscribes, channels)
+		}
+		f.mu.Unlock()
+
+		for _, ch := range channels {
+			_ = conn.WriteJSON([]PolygonAggregate{{EventType: "AM", Symbol: strings.TrimPrefix(ch, "AM."), Close: 100, End: time.Now().UnixMilli()}})
+		}
+		if n == 1 {
+			return // drop the first connection
+		}
+	}
+}
+
+func (f *fakePolygonSocket) subscribed() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.subscribes...)
+}
+
+func newTestSocketClient(url, apiKey string, symbols []string) *PolygonWebsocketClient {
+	c := NewPolygonWebsocketClientWith("ws"+strings.TrimPrefix(url, "http"), apiKey, symbols)
+	c.minBackoff = time.Millisecond
+	c.maxBackoff = 5 * time.Millisecond
+	c.pingInterval = 20 * time.Millisecond
+	c.pongWait = 100 * time.Millisecond
+	return c
+}
+
+func TestPolygonWebsocketClient_ResubscribesAfterReconnect(t *testing.T) {
+	fake := &fakePolygonSocket{t: t, apiKey: "key"}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := newTestSocketClient(srv.URL, "key", []string{"msft", "AAPL"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var got []string
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Run(ctx, func(agg PolygonAggregate) {
+			mu.Lock()
+			got = append(got, agg.Symbol)
+			mu.Unlock()
+		})
+	}()
+
+	require.Eventually(t, func() bool { return len(fake.subscribed()) >= 2 }, 2*time.Second, 5*time.Millisecond)
+	want := []string{"AM.AAPL", "AM.MSFT"}
+	assert.Equal(t, want, fake.subscribed()[0])
+	assert.Equal(t, want, fake.subscribed()[1])
+
+	cancel()
+	assert.NoError(t, <-done)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, got, "AAPL")
+	assert.Contains(t, got, "MSFT")
+}
+
+func TestPolygonWebsocketClient_SetSymbolsSubscribesDifference(t *testing.T) {
+	fake := &fakePolygonSocket{t: t, apiKey: "key", conns: 1} // keep every connection open
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := newTestSocketClient(srv.URL, "key", []string{"AAPL"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = client.Run(ctx, func(PolygonAggregate) {}) }()
+
+	require.Eventually(t, func() bool { return len(fake.subscribed()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	require.NoError(t, client.SetSymbols([]string{"AAPL", "NVDA"}))
+	require.Eventually(t, func() bool { return len(fake.subscribed()) == 2 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"AM.NVDA"}, fake.subscribed()[1])
+	assert.Equal(t, []string{"AAPL", "NVDA"}, client.Symbols())
+}
+
+func TestPolygonWebsocketClient_AuthFailedStops(t *testing.T) {
+	srv := httptest.NewServer(&fakePolygonSocket{t: t, apiKey: "key"})
+	defer srv.Close()
+
+	client := newTestSocketClient(srv.URL, "wrong", []string{"AAPL"})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := client.Run(ctx, func(PolygonAggregate) {})
+	assert.ErrorIs(t, err, ErrPolygonAuthFailed)
+}
+
+func TestPolygonWebsocketClient_PongsKeepIdleConnectionOpen(t *testing.T) {
+	fake := &fakePolygonSocket{t: t, apiKey: "key", conns: 1} // keep every connection open
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := newTestSocketClient(srv.URL, "key", []string{"AAPL"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = client.Run(ctx, func(PolygonAggregate) {}) }()
+
+	require.Eventually(t, func() bool { return len(fake.subscribed()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	// The feed sends nothing more, but it answers pings, well past pongWait
+	time.Sleep(3 * client.pongWait)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, 2, fake.conns, "no reconnect while pongs arrive")
+}
+
+func TestPolygonWebsocketClient_ReconnectsWhenPongsStop(t *testing.T) {
+	stop := make(chan struct{})
+	var mu sync.Mutex
+	conns := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		mu.Lock()
+		conns++
+		mu.Unlock()
+
+		_ = conn.WriteJSON([]map[string]string{{"ev": "status", "status": "connected"}})
+		var auth polygonSocketAction
+		if err := conn.ReadJSON(&auth); err != nil {
+			return
+		}
+		_ = conn.WriteJSON([]map[string]string{{"ev": "status", "status": "auth_success"}})
+
+		// Stop reading, so pings go unanswered, as on a dead connection
+		<-stop
+	}))
+	defer srv.Close()
+	defer close(stop)
+
+	client := newTestSocketClient(srv.URL, "key", []string{"AAPL"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = client.Run(ctx, func(PolygonAggregate) {}) }()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return conns >= 2
+	}, 2*time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+	"log"
+	"strings"
+)
+
+// PortfolioService handles business logic for user portfolios
+type PortfolioService struct{}
+
+func NewPortfolioService() *PortfolioService {
+	return &PortfolioService{}
+}
+
+// GetPortfolioWithHoldings retrieves a portfolio with its holdings valued at
+// the latest daily close, plus portfolio totals.
+func (s *PortfolioService) GetPortfolioWithHoldings(portfolioID string, userID string) (*models.PortfolioWithHoldings, error) {
+	portfolio, err := database.GetPortfolioByID(portfolioID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings, err := database.GetPortfolioHoldingsWithPrices(portfolioID)
+	if err != nil {
+		log.Printf("Error fetching holdings for portfolio %s: %v", portfolioID, err)
+		return nil, fmt.Errorf("failed to fetch portfolio holdings: %w", err)
+	}
+
+	result := &models.PortfolioWithHoldings{
+		Portfolio: *portfolio,
+		Holdings:  holdings,
+	}
+	computePortfolioValues(result)
+	return result, nil
+}
+
+// computePortfolioValues fills in per-holding valuation, weights and the
+// portfolio totals. Holdings without a current price count toward
+// UnpricedCount and are left out of the totals, so gain/loss compares like
+// with like.
+func computePortfolioValues(p *models.PortfolioWithHoldings) {
+	p.TotalCostBasis, p.TotalMarketValue, p.TotalGainLoss, p.UnpricedCount = 0, 0, 0, 0
+	var pricedCostBasis float64
+
+	for i := range p.Holdings {
+		h := &p.Holdings[i]
+		h.CostBasis = h.Shares * h.AverageCost
+		p.TotalCostBasis += h.CostBasis
+
+		if h.CurrentPrice == nil {
+			p.UnpricedCount++
+			continue
+		}
+		marketValue := h.Shares * *h.CurrentPrice
+		gainLoss := marketValue - h.CostBasis
+		h.MarketValue = &marketValue
+		h.GainLoss = &gainLoss
+		if h.CostBasis > 0 {
+			pct := gainLoss / h.CostBasis * 100
+			h.GainLossPct = &pct
+		}
+
+		p.TotalMarketValue += marketValue
+		pricedCostBasis += h.CostBasis
+	}
+
+	p.TotalGainLoss = p.TotalMarketValue - pricedCostBasis
+	if pricedCostBasis > 0 {
+		pct := p.TotalGainLoss / pricedCostBasis * 100
+		p.TotalGainLossPct = &pct
+	}
+
+	if p.TotalMarketValue > 0 {
+		for i := range p.Holdings {
+			if mv := p.Holdings[i].MarketValue; mv != nil {
+				weight := *mv / p.TotalMarketValue * 100
+				p.Holdings[i].Weight = &weight
+			}
+		}
+	}
+}
+
+// GetPortfolioPerformance returns the daily value of the portfolio's current
+// holdings over period (1W, 1M, 3M, 6M, YTD, 1Y, 5Y, MAX). Past trades are
+// not tracked, so this shows how today's positions would have moved.
+func (s *PortfolioService) GetPortfolioPerformance(portfolioID string, userID string, period string) (*models.PortfolioPerformance, error) {
+	if _, err := database.GetPortfolioByID(portfolioID, userID); err != nil {
+		return nil, err
+	}
+
+	period = strings.ToUpper(period)
+	points, err := database.GetPortfolioValueHistory(portfolioID, GetDaysFromPeriod(period))
+	if err != nil {
+		return nil, err
+	}
+
+	holdings, err := database.GetPortfolioHoldingsWithPrices(portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch portfolio holdings: %w", err)
+	}
+
+	perf := &models.PortfolioPerformance{
+		PortfolioID: portfolioID,
+		Period:      period,
+		Points:      points,
+	}
+	for _, h := range holdings {
+		perf.TotalCostBasis += h.Shares * h.AverageCost
+	}
+	if len(points) > 0 {
+		start, end := points[0].Value, points[len(points)-1].Value
+		change := end - start
+		perf.StartValue = &start
+		perf.EndValue = &end
+		perf.Change = &change
+		if start > 0 {
+			pct := change / start * 100
+			perf.ChangePct = &pct
+		}
+	}
+	return perf, nil
+}
+
+// ValidatePortfolioOwnership checks if user owns the portfolio
+func (s *PortfolioService) ValidatePortfolioOwnership(portfolioID string, userID string) error {
+	_, err := database.GetPortfolioByID(portfolioID, userID)
+	return err
+}
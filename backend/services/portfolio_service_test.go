@@ -0,0 +1,154 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+func holdingWithPrice(symbol string, shares, avgCost float64, price *float64) models.PortfolioHoldingWithValue {
+	return models.PortfolioHoldingWithValue{
+		PortfolioHolding: models.PortfolioHolding{Symbol: symbol, Shares: shares, AverageCost: avgCost},
+		CurrentPrice:     price,
+	}
+}
+
+func TestComputePortfolioValues(t *testing.T) {
+	aapl, msft := 200.0, 400.0
+	p := &models.PortfolioWithHoldings{
+		Holdings: []models.PortfolioHoldingWithValue{
+			holdingWithPrice("AAPL", 10, 150, &aapl), // cost 1500, value 2000
+			holdingWithPrice("MSFT", 5, 500, &msft),  // cost 2500, value 2000
+			holdingWithPrice("NEWCO", 100, 1.5, nil), // cost 150, unpriced
+			holdingWithPrice("GIFT", 4, 0, &aapl),    // zero cost basis, value 800
+		},
+	}
+
+	computePortfolioValues(p)
+
+	aaplH := p.Holdings[0]
+	assert.InDelta(t, 1500.0, aaplH.CostBasis, 1e-9)
+	require.NotNil(t, aaplH.MarketValue)
+	assert.InDelta(t, 2000.0, *aaplH.MarketValue, 1e-9)
+	assert.InDelta(t, 500.0, *aaplH.GainLoss, 1e-9)
+	assert.InDelta(t, 33.3333, *aaplH.GainLossPct, 1e-4)
+	assert.InDelta(t, 2000.0/4800*100, *aaplH.Weight, 1e-9)
+
+	assert.InDelta(t, -20.0, *p.Holdings[1].GainLossPct, 1e-9)
+
+	unpriced := p.Holdings[2]
+	assert.InDelta(t, 150.0, unpriced.CostBasis, 1e-9)
+	assert.Nil(t, unpriced.MarketValue)
+	assert.Nil(t, unpriced.GainLoss)
+	assert.Nil(t, unpriced.Weight)
+
+	assert.Nil(t, p.Holdings[3].GainLossPct, "no percentage on a zero cost basis")
+
+	assert.InDelta(t, 4150.0, p.TotalCostBasis, 1e-9, "cost basis includes unpriced holdings")
+	assert.InDelta(t, 4800.0, p.TotalMarketValue, 1e-9)
+	assert.InDelta(t, 800.0, p.TotalGainLoss, 1e-9, "gain/loss excludes unpriced holdings")
+	require.NotNil(t, p.TotalGainLossPct)
+	assert.InDelta(t, 20.0, *p.TotalGainLossPct, 1e-9)
+	assert.Equal(t, 1, p.UnpricedCount)
+}
+
+func TestComputePortfolioValues_Empty(t *testing.T) {
+	p := &models.PortfolioWithHoldings{Holdings: []models.PortfolioHoldingWithValue{}}
+	computePortfolioValues(p)
+
+	assert.Zero(t, p.TotalMarketValue)
+	assert.Zero(t, p.TotalGainLoss)
+	assert.Nil(t, p.TotalGainLossPct)
+}
+
+func trade(kind, shares, price string) models.PortfolioTransaction {
+	return models.PortfolioTransaction{
+		Symbol:          "AAPL",
+		TransactionType: kind,
+		Shares:          decimal.RequireFromString(shares),
+		Price:           decimal.RequireFromString(price),
+	}
+}
+
+func TestReplayFIFO(t *testing.T) {
+	history := []models.PortfolioTransaction{
+		trade(models.TransactionTypeBuy, "10", "100"),
+		trade(models.TransactionTypeBuy, "5", "130"),
+		trade(models.TransactionTypeSell, "12", "150"), // 10 @ 100 then 2 @ 130
+		trade(models.TransactionTypeBuy, "1", "160"),
+	}
+
+	pos, err := replayFIFO(history)
+	require.NoError(t, err)
+
+	require.NotNil(t, history[2].RealizedGain)
+	assert.Equal(t, "540", history[2].RealizedGain.String(), "10*(150-100) + 2*(150-130)")
+	assert.Nil(t, history[0].RealizedGain, "buys have no realized gain")
+	assert.Equal(t, "4", pos.shares.String())
+	assert.Equal(t, "540", pos.realized.String())
+	assert.Equal(t, "137.5", pos.averageCost().String(), "(3*130 + 1*160) / 4")
+}
+
+func TestReplayFIFO_FractionalShares(t *testing.T) {
+	history := []models.PortfolioTransaction{
+		trade(models.TransactionTypeBuy, "0.1", "100.10"),
+		trade(models.TransactionTypeBuy, "0.2", "100.20"),
+		trade(models.TransactionTypeSell, "0.3", "101"),
+	}
+
+	pos, err := replayFIFO(history)
+	require.NoError(t, err)
+
+	// Exact in decimal: 0.1 + 0.2 sells out to zero rather than a float residue
+	assert.True(t, pos.shares.IsZero())
+	assert.Empty(t, pos.lots)
+	assert.True(t, pos.averageCost().IsZero())
+	assert.Equal(t, "0.25", history[2].RealizedGain.String(), "0.1*0.90 + 0.2*0.80")
+}
+
+func TestReplayFIFO_InsufficientShares(t *testing.T) {
+	history := []models.PortfolioTransaction{
+		trade(models.TransactionTypeBuy, "1.5", "100"),
+		trade(models.TransactionTypeSell, "1.500001", "110"),
+	}
+
+	_, err := replayFIFO(history)
+	assert.ErrorIs(t, err, database.ErrInsufficientShares)
+
+	_, err = replayFIFO([]models.PortfolioTransaction{trade(models.TransactionTypeSell, "1", "10")})
+	assert.ErrorIs(t, err, database.ErrInsufficientShares, "selling with no position")
+}
+
+func TestCalculateHoldingPerformance(t *testing.T) {
+	holdings := []models.PortfolioHolding{
+		{Symbol: "AAPL", Shares: 2.5, AverageCost: 100},
+		{Symbol: "MSFT", Shares: 1, AverageCost: 400},
+		{Symbol: "NEWCO", Shares: 10, AverageCost: 2},
+	}
+	prices := map[string]decimal.Decimal{
+		"AAPL": decimal.RequireFromString("120"),
+		"MSFT": decimal.RequireFromString("300"),
+	}
+
+	perf := CalculateHoldingPerformance(holdings, prices)
+
+	require.Len(t, perf.Holdings, 3)
+	aapl := perf.Holdings[0]
+	assert.Equal(t, "250", aapl.CostBasis.String())
+	require.NotNil(t, aapl.UnrealizedGain)
+	assert.Equal(t, "300", aapl.MarketValue.String())
+	assert.Equal(t, "50", aapl.UnrealizedGain.String())
+	assert.Equal(t, "20", aapl.UnrealizedGainPct.String())
+	assert.Equal(t, "-25", perf.Holdings[1].UnrealizedGainPct.String())
+	assert.Nil(t, perf.Holdings[2].MarketValue)
+
+	assert.Equal(t, "670", perf.TotalCostBasis.String(), "cost basis includes unpriced holdings")
+	assert.Equal(t, "600", perf.TotalMarketValue.String())
+	assert.Equal(t, "-50", perf.TotalUnrealizedGain.String(), "unrealized excludes unpriced holdings")
+	assert.Equal(t, 1, perf.UnpricedCount)
+}
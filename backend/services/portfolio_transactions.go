@@ -0,0 +1,199 @@
+package services
+
+import (
+	"fmt"
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+	"log"
+
+	"github.com/shopspring/decimal"
+)
+
+// RecordTransaction records a buy or sell and rebuilds the holding from the
+// symbol's full history. Sells that exceed the shares held at their execution
+// time fail with database.ErrInsufficientShares. For sells, txn.RealizedGain
+// is set from FIFO lot matching.
+func (s *PortfolioService) RecordTransaction(txn *models.PortfolioTransaction) error {
+	return database.RecordPortfolioTransaction(txn, func(history []models.PortfolioTransaction) (decimal.Decimal, decimal.Decimal, error) {
+		pos, err := replayFIFO(history)
+		if err != nil {
+			return decimal.Zero, decimal.Zero, err
+		}
+		for _, h := range history {
+			if h.ID == txn.ID {
+				txn.RealizedGain = h.RealizedGain
+			}
+		}
+		return pos.shares, pos.averageCost(), nil
+	})
+}
+
+// GetTransactionHistory returns a portfolio's transactions, newest first,
+// with realized gains on sells and the unrealized performance of its open
+// positions at the latest daily close.
+func (s *PortfolioService) GetTransactionHistory(portfolioID string, userID string) (*models.PortfolioTransactionHistory, error) {
+	if _, err := database.GetPortfolioByID(portfolioID, userID); err != nil {
+		return nil, err
+	}
+
+	transactions, err := database.GetPortfolioTransactions(portfolioID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := make(map[string][]int)
+	for i, t := range transactions {
+		bySymbol[t.Symbol] = append(bySymbol[t.Symbol], i)
+	}
+	totalRealized := decimal.Zero
+	for symbol, indexes := range bySymbol {
+		history := make([]models.PortfolioTransaction, len(indexes))
+		for j, i := range indexes {
+			history[j] = transactions[i]
+		}
+		pos, err := replayFIFO(history)
+		if err != nil {
+			log.Printf("Error replaying %s transactions in portfolio %s: %v", symbol, portfolioID, err)
+			return nil, fmt.Errorf("failed to replay %s transactions: %w", symbol, err)
+		}
+		for j, i := range indexes {
+			transactions[i].RealizedGain = history[j].RealizedGain
+		}
+		totalRealized = totalRealized.Add(pos.realized)
+	}
+
+	holdings, err := database.GetPortfolioHoldingsWithPrices(portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch portfolio holdings: %w", err)
+	}
+	positions := make([]models.PortfolioHolding, len(holdings))
+	prices := make(map[string]decimal.Decimal)
+	for i, h := range holdings {
+		positions[i] = h.PortfolioHolding
+		if h.CurrentPrice != nil {
+			prices[h.Symbol] = decimal.NewFromFloat(*h.CurrentPrice)
+		}
+	}
+
+	performance := CalculateHoldingPerformance(positions, prices)
+	performance.TotalRealizedGain = totalRealized
+
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+	return &models.PortfolioTransactionHistory{
+		Transactions: transactions,
+		Performance:  performance,
+	}, nil
+}
+
+// CalculateHoldingPerformance values each holding at its latest price and
+// returns the unrealized gain/loss per position and in aggregate. Holdings
+// missing from prices count toward UnpricedCount and are left out of the
+// market value and unrealized totals. Realized gains come from transaction
+// history and are left for the caller.
+func CalculateHoldingPerformance(holdings []models.PortfolioHolding, prices map[string]decimal.Decimal) models.PortfolioHoldingsPerformance {
+	result := models.PortfolioHoldingsPerformance{Holdings: make([]models.HoldingPerformance, 0, len(holdings))}
+	pricedCostBasis := decimal.Zero
+	hundred := decimal.NewFromInt(100)
+
+	for _, h := range holdings {
+		shares := decimal.NewFromFloat(h.Shares)
+		averageCost := decimal.NewFromFloat(h.AverageCost)
+		perf := models.HoldingPerformance{
+			Symbol:      h.Symbol,
+			Shares:      shares,
+			AverageCost: averageCost,
+			CostBasis:   shares.Mul(averageCost),
+		}
+		result.TotalCostBasis = result.TotalCostBasis.Add(perf.CostBasis)
+
+		price, ok := prices[h.Symbol]
+		if !ok {
+			result.UnpricedCount++
+			result.Holdings = append(result.Holdings, perf)
+			continue
+		}
+		marketValue := shares.Mul(price)
+		gain := marketValue.Sub(perf.CostBasis)
+		perf.CurrentPrice = &price
+		perf.MarketValue = &marketValue
+		perf.UnrealizedGain = &gain
+		if perf.CostBasis.IsPositive() {
+			pct := gain.Div(perf.CostBasis).Mul(hundred).Round(4)
+			perf.UnrealizedGainPct = &pct
+		}
+		result.Holdings = append(result.Holdings, perf)
+
+		result.TotalMarketValue = result.TotalMarketValue.Add(marketValue)
+		pricedCostBasis = pricedCostBasis.Add(perf.CostBasis)
+	}
+
+	result.TotalUnrealizedGain = result.TotalMarketValue.Sub(pricedCostBasis)
+	return result
+}
+
+// taxLot is shares bought together at one price
+type taxLot struct {
+	shares decimal.Decimal
+	price  decimal.Decimal
+}
+
+// fifoPosition is what remains after replaying a symbol's transactions
+type fifoPosition struct {
+	lots     []taxLot
+	shares   decimal.Decimal
+	realized decimal.Decimal
+}
+
+// averageCost is the cost per share of the open lots
+func (p *fifoPosition) averageCost() decimal.Decimal {
+	if !p.shares.IsPositive() {
+		return decimal.Zero
+	}
+	cost := decimal.Zero
+	for _, l := range p.lots {
+		cost = cost.Add(l.shares.Mul(l.price))
+	}
+	return cost.Div(p.shares)
+}
+
+// replayFIFO replays one symbol's transactions in order. Buys open a lot;
+// sells close the oldest lots first and set RealizedGain on the sell. A sell
+// larger than the shares open at that point returns ErrInsufficientShares.
+func replayFIFO(history []models.PortfolioTransaction) (*fifoPosition, error) {
+	pos := &fifoPosition{}
+	for i := range history {
+		t := &history[i]
+		switch t.TransactionType {
+		case models.TransactionTypeBuy:
+			pos.lots = append(pos.lots, taxLot{shares: t.Shares, price: t.Price})
+			pos.shares = pos.shares.Add(t.Shares)
+
+		case models.TransactionTypeSell:
+			if t.Shares.GreaterThan(pos.shares) {
+				return nil, fmt.Errorf("%w: selling %s %s on %s with %s held", database.ErrInsufficientShares,
+					t.Shares, t.Symbol, t.ExecutedAt.Format("2006-01-02"), pos.shares)
+			}
+			remaining := t.Shares
+			gain := decimal.Zero
+			for remaining.IsPositive() {
+				lot := &pos.lots[0]
+				matched := decimal.Min(lot.shares, remaining)
+				gain = gain.Add(t.Price.Sub(lot.price).Mul(matched))
+				lot.shares = lot.shares.Sub(matched)
+				remaining = remaining.Sub(matched)
+				if !lot.shares.IsPositive() {
+					pos.lots = pos.lots[1:]
+				}
+			}
+			pos.shares = pos.shares.Sub(t.Shares)
+			pos.realized = pos.realized.Add(gain)
+			t.RealizedGain = &gain
+
+		default:
+			return nil, fmt.Errorf("unknown transaction type %q", t.TransactionType)
+		}
+	}
+	return pos, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"investorcenter-api/models"
+)
+
+const (
+	defaultPriceRefreshConcurrency = 4
+	// Minimum gap between upstream snapshot calls, shared by all workers
+	defaultPriceRefreshMinInterval = 100 * time.Millisecond
+)
+
+// PriceQuoteFetcher fetches a current price snapshot from the upstream
+// provider. Implemented by PolygonClient.
+type PriceQuoteFetcher interface {
+	GetStockRealTimePrice(symbol string) (*models.StockPrice, error)
+	GetCryptoRealTimePrice(symbol string) (*models.StockPrice, error)
+}
+
+// PriceUpserter persists a price snapshot. Implemented by PriceService.
+type PriceUpserter interface {
+	UpsertDailyPrice(ctx context.Context, price *models.StockPrice) error
+}
+
+// PriceRefreshResult is the outcome of refreshing a single symbol
+type PriceRefreshResult struct {
+	Symbol  string   `json:"symbol"`
+	Success bool     `json:"success"`
+	Price   *float64 `json:"price,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// PriceRefreshService fetches current prices for a set of symbols and
+// upserts them into stock_prices
+type PriceRefreshService struct {
+	fetcher     PriceQuoteFetcher
+	store       PriceUpserter
+	concurrency int
+	minInterval time.Duration
+}
+
+// NewPriceRefreshService creates a refresh service backed by Polygon and
+// TimescaleDB. Concurrency and upstream pacing come from
+// PRICE_REFRESH_CONCURRENCY and PRICE_REFRESH_MIN_INTERVAL_MS.
+func NewPriceRefreshService() *PriceRefreshService {
+	return NewPriceRefreshServiceWith(
+		NewPolygonClient(),
+		NewPriceService(),
+		envInt("PRICE_REFRESH_CONCURRENCY", defaultPriceRefreshConcurrency),
+		time.Duration(envInt("PRICE_REFRESH_MIN_INTERVAL_MS", int(defaultPriceRefreshMinInterval/time.Millisecond)))*time.Millisecond,
+	)
+}
+
+// NewPriceRefreshServiceWith creates a refresh service with explicit
+// dependencies
+func NewPriceRefreshServiceWith(fetcher PriceQuoteFetcher, store PriceUpserter, concurrency int, minInterval time.Duration) *PriceRefreshService {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if minInterval < 0 {
+		minInterval = 0
+	}
+	return &PriceRefreshService{
+		fetcher:     fetcher,
+		store:       store,
+		concurrency: concurrency,
+		minInterval: minInterval,
+	}
+}
+
+// Refresh fetches and stores the current price of each symbol. Results are
+// returned in the same order as symbols; a failure for one symbol never
+// aborts the others. Symbols prefixed with "X:" are fetched as crypto.
+func (s *PriceRefreshService) Refresh(ctx context.Context, symbols []string) []PriceRefreshResult {
+	results := make([]PriceRefreshResult, len(symbols))
+	if len(symbols) == 0 {
+		return results
+	}
+
+	// A single ticker paces upstream calls across all workers so the
+	// provider's rate limit holds regardless of concurrency
+	var throttle <-chan time.Time
+	if s.minInterval > 0 {
+		ticker := time.NewTicker(s.minInterval)
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := s.concurrency
+	if workers > len(symbols) {
+		workers = len(symbols)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.refreshOne(ctx, symbols[i], throttle)
+			}
+		}()
+	}
+
+	for i := range symbols {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func (s *PriceRefreshService) refreshOne(ctx context.Context, symbol string, throttle <-chan time.Time) PriceRefreshResult {
+	result := PriceRefreshResult{Symbol: symbol}
+
+	if throttle != nil {
+		select {
+		case <-throttle:
+		case <-ctx.Done():
+			result.Error = ctx.Err().Error()
+			return result
+		}
+	} else if err := ctx.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var price *models.StockPrice
+	var err error
+	if strings.HasPrefix(symbol, "X:") {
+		price, err = s.fetcher.GetCryptoRealTimePrice(symbol)
+	} else {
+		price, err = s.fetcher.GetStockRealTimePrice(symbol)
+	}
+	if err != nil {
+		log.Printf("Price refresh: failed to fetch %s: %v", symbol, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	price.Symbol = symbol
+	if err := s.store.UpsertDailyPrice(ctx, price); err != nil {
+		log.Printf("Price refresh: failed to store %s: %v", symbol, err)
+		result.Error = err.Error()
+		return result
+	}
+
+	p := price.Close.InexactFloat64()
+	result.Success = true
+	result.Price = &p
+	return result
+}
+
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+// stubQuoteFetcher returns canned snapshots and tracks peak concurrency
+type stubQuoteFetcher struct {
+	prices   map[string]float64
+	delay    time.Duration
+	inFlight int32
+	peak     int32
+	mu       sync.Mutex
+	stock    []string
+	crypto   []string
+}
+
+func (f *stubQuoteFetcher) GetStockRealTimePrice(symbol string) (*models.StockPrice, error) {
+	f.mu.Lock()
+	f.stock = append(f.stock, symbol)
+	f.mu.Unlock()
+	return f.get(symbol)
+}
+
+func (f *stubQuoteFetcher) GetCryptoRealTimePrice(symbol string) (*models.StockPrice, error) {
+	f.mu.Lock()
+	f.crypto = append(f.crypto, symbol)
+	f.mu.Unlock()
+	return f.get(symbol)
+}
+
+func (f *stubQuoteFetcher) get(symbol string) (*models.StockPrice, error) {
+	n := atomic.AddInt32(&f.inFlight, 1)
+	defer atomic.AddInt32(&f.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&f.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&f.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+
+	p, ok := f.prices[symbol]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	d := decimal.NewFromFloat(p)
+	return &models.StockPrice{
+		Open: d, High: d, Low: d, Close: d, Price: d, Volume: 1000,
+		Timestamp: time.Date(2026, 3, 2, 15, 30, 0, 0, time.UTC),
+	}, nil
+}
+
+func newMockPriceService(t *testing.T) (*PriceService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return &PriceService{db: sqlx.NewDb(db, "sqlmock")}, mock
+}
+
+func TestPriceRefresh_UpsertsFetchedPrices(t *testing.T) {
+	store, mock := newMockPriceService(t)
+	fetcher := &stubQuoteFetcher{prices: map[string]float64{"AAPL": 190.25, "X:BTCUSD": 65000}}
+
+	ny, _ := time.LoadLocation("America/New_York")
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, ny)
+
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectExec("INSERT INTO stock_prices .+ ON CONFLICT \\(ticker, time, interval\\) DO UPDATE").
+		WithArgs(day, "AAPL", 190.25, 190.25, 190.25, 190.25, int64(1000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_prices .+ ON CONFLICT \\(ticker, time, interval\\) DO UPDATE").
+		WithArgs(day, "X:BTCUSD", 65000.0, 65000.0, 65000.0, 65000.0, int64(1000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	svc := NewPriceRefreshServiceWith(fetcher, store, 2, 0)
+	results := svc.Refresh(context.Background(), []string{"AAPL", "MISSING", "X:BTCUSD"})
+
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, 190.25, *results[0].Price)
+	assert.False(t, results[1].Success)
+	assert.Equal(t, "not found", results[1].Error)
+	assert.Nil(t, results[1].Price)
+	assert.True(t, results[2].Success)
+	assert.Equal(t, []string{"X:BTCUSD"}, fetcher.crypto)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPriceRefresh_StoreErrorIsPerSymbol(t *testing.T) {
+	store, mock := newMockPriceService(t)
+	fetcher := &stubQuoteFetcher{prices: map[string]float64{"AAPL": 1, "MSFT": 2}}
+
+	mock.ExpectExec("INSERT INTO stock_prices").WithArgs(sqlmock.AnyArg(), "AAPL", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec("INSERT INTO stock_prices").WithArgs(sqlmock.AnyArg(), "MSFT", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	results := NewPriceRefreshServiceWith(fetcher, store, 1, 0).Refresh(context.Background(), []string{"AAPL", "MSFT"})
+
+	assert.False(t, results[0].Success)
+	assert.Contains(t, results[0].Error, "connection reset")
+	assert.True(t, results[1].Success)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPriceRefresh_BoundedConcurrency(t *testing.T) {
+	store, mock := newMockPriceService(t)
+	mock.MatchExpectationsInOrder(false)
+
+	symbols := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	prices := map[string]float64{}
+	for _, s := range symbols {
+		prices[s] = 10
+		mock.ExpectExec("INSERT INTO stock_prices").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	fetcher := &stubQuoteFetcher{prices: prices, delay: 20 * time.Millisecond}
+
+	results := NewPriceRefreshServiceWith(fetcher, store, 3, 0).Refresh(context.Background(), symbols)
+
+	for _, r := range results {
+		assert.True(t, r.Success, r.Symbol)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&fetcher.peak), int32(3))
+	assert.Greater(t, atomic.LoadInt32(&fetcher.peak), int32(1))
+}
+
+func TestPriceRefresh_MinIntervalPacesUpstream(t *testing.T) {
+	store, mock := newMockPriceService(t)
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < 4; i++ {
+		mock.ExpectExec("INSERT INTO stock_prices").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	fetcher := &stubQuoteFetcher{prices: map[string]float64{"A": 1, "B": 1, "C": 1, "D": 1}}
+
+	start := time.Now()
+	NewPriceRefreshServiceWith(fetcher, store, 4, 25*time.Millisecond).
+		Refresh(context.Background(), []string{"A", "B", "C", "D"})
+
+	// Four calls behind a 25ms ticker can't finish before the fourth tick
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestPriceRefresh_CancelledContext(t *testing.T) {
+	store, _ := newMockPriceService(t)
+	fetcher := &stubQuoteFetcher{prices: map[string]float64{"AAPL": 1}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := NewPriceRefreshServiceWith(fetcher, store, 1, 0).Refresh(ctx, []string{"AAPL"})
+	assert.False(t, results[0].Success)
+	assert.Equal(t, context.Canceled.Error(), results[0].Error)
+	assert.Empty(t, fetcher.stock)
+}
+
+func TestTradingDay(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	// 02:00 UTC on Mar 3 is still Mar 2 in New York
+	got := tradingDay(time.Date(2026, 3, 3, 2, 0, 0, 0, time.UTC))
+	assert.True(t, got.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, ny)))
+}
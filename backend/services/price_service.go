@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 	"investorcenter-api/database"
 	"investorcenter-api/models"
@@ -44,7 +45,7 @@ func (s *PriceService) GetHistoricalPrices(ctx context.Context, symbol string, p
 		ORDER BY time ASC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, symbol, days+30) // Add buffer for trading days
+	rows, err := database.PreparedQueryContext(ctx, s.db, query, symbol, days+30) // Add buffer for trading days
 	if err != nil {
 		return nil, fmt.Errorf("failed to query prices: %w", err)
 	}
@@ -99,7 +100,7 @@ func (s *PriceService) Get52WeekHighLow(ctx context.Context, symbol string) (hig
 		  AND time >= NOW() - INTERVAL '365 days'
 	`
 
-	err = s.db.QueryRowContext(ctx, query, symbol).Scan(&high, &low)
+	err = database.PreparedQueryRowContext(ctx, s.db, query, symbol).Scan(&high, &low)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return 0, 0, fmt.Errorf("no price data found for %s", symbol)
@@ -123,7 +124,7 @@ func (s *PriceService) GetLatestPrice(ctx context.Context, symbol string) (float
 
 	var close sql.NullFloat64
 
-	err := s.db.QueryRowContext(ctx, query, symbol).Scan(&close)
+	err := database.PreparedQueryRowContext(ctx, s.db, query, symbol).Scan(&close)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -139,6 +140,104 @@ func (s *PriceService) GetLatestPrice(ctx context.Context, symbol string) (float
 	return close.Float64, nil
 }
 
+// UpsertDailyPrice stores a price snapshot as the 1day bar for its trading
+// date, replacing any bar already stored for that date. The bar time is
+// midnight America/New_York, matching the daily bars written by the
+// ingestion pipeline.
+func (s *PriceService) UpsertDailyPrice(ctx context.Context, price *models.StockPrice) error {
+	query := `
+		INSERT INTO stock_prices (time, ticker, open, high, low, close, volume, interval)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '1day')
+		ON CONFLICT (ticker, time, interval) DO UPDATE SET
+			open = EXCLUDED.open,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			close = EXCLUDED.close,
+			volume = EXCLUDED.volume
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		tradingDay(price.Timestamp),
+		price.Symbol,
+		price.Open.InexactFloat64(),
+		price.High.InexactFloat64(),
+		price.Low.InexactFloat64(),
+		price.Close.InexactFloat64(),
+		price.Volume,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert price for %s: %w", price.Symbol, err)
+	}
+	return nil
+}
+
+// MergeDailyPrice folds a partial day's bar into the 1day bar for its
+// trading date: the stored open is kept, high and low are widened, and
+// close and volume move forward. Used for streamed bars, where each write
+// covers only the minutes since the last one.
+func (s *PriceService) MergeDailyPrice(ctx context.Context, price *models.StockPrice) error {
+	query := `
+		INSERT INTO stock_prices (time, ticker, open, high, low, close, volume, interval)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '1day')
+		ON CONFLICT (ticker, time, interval) DO UPDATE SET
+			open = COALESCE(stock_prices.open, EXCLUDED.open),
+			high = GREATEST(stock_prices.high, EXCLUDED.high),
+			low = LEAST(stock_prices.low, EXCLUDED.low),
+			close = EXCLUDED.close,
+			volume = GREATEST(stock_prices.volume, EXCLUDED.volume)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		tradingDay(price.Timestamp),
+		price.Symbol,
+		price.Open.InexactFloat64(),
+		price.High.InexactFloat64(),
+		price.Low.InexactFloat64(),
+		price.Close.InexactFloat64(),
+		price.Volume,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to merge price for %s: %w", price.Symbol, err)
+	}
+	return nil
+}
+
+// GetPriorCloses returns the latest 1day close before the trading date of
+// day for each of symbols. Symbols without one are left out.
+func (s *PriceService) GetPriorCloses(ctx context.Context, symbols []string, day time.Time) (map[string]float64, error) {
+	query := `
+		SELECT DISTINCT ON (ticker) ticker, close
+		FROM stock_prices
+		WHERE ticker = ANY($1) AND interval = '1day' AND time < $2
+		ORDER BY ticker, time DESC
+	`
+
+	var rows []struct {
+		Ticker string          `db:"ticker"`
+		Close  sql.NullFloat64 `db:"close"`
+	}
+	if err := s.db.SelectContext(ctx, &rows, query, pq.Array(symbols), tradingDay(day)); err != nil {
+		return nil, fmt.Errorf("failed to get prior closes: %w", err)
+	}
+	closes := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		if r.Close.Valid {
+			closes[r.Ticker] = r.Close.Float64
+		}
+	}
+	return closes, nil
+}
+
+// tradingDay truncates t to midnight of its date in America/New_York
+func tradingDay(t time.Time) time.Time {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
 // Helper functions
 func getFloat64(n sql.NullFloat64) float64 {
 	if n.Valid {
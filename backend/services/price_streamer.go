@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-api/models"
+	"investorcenter-shared/tracing"
+)
+
+// StreamedPriceStore persists streamed bars. Implemented by PriceService.
+type StreamedPriceStore interface {
+	MergeDailyPrice(ctx context.Context, price *models.StockPrice) error
+	GetPriorCloses(ctx context.Context, symbols []string, day time.Time) (map[string]float64, error)
+}
+
+// PriceUpdateSink receives price update messages. Implemented by
+// PriceUpdatePublisher.
+type PriceUpdateSink interface {
+	Publish(ctx context.Context, msg models.PriceUpdateMessage) error
+}
+
+// PriceStreamer turns streamed Polygon aggregates into daily bars in
+// stock_prices and price updates for the notification service. Aggregates
+// are collected per symbol and written out together on each flush, so a
+// busy minute costs one write and one message per symbol rather than one
+// per event.
+type PriceStreamer struct {
+	store     StreamedPriceStore
+	publisher PriceUpdateSink // nil when SNS isn't configured
+
+	mu         sync.Mutex
+	pending    map[string]*models.StockPrice // bar since the last flush, by symbol
+	dayVolumes map[string]dayVolume          // volume so far today by symbol, kept across flushes
+
+	closesMu  sync.Mutex         // held while looking up closes, apart from mu so aggregates keep flowing
+	closesDay time.Time          // trading day closes is for
+	closes    map[string]float64 // prior close by symbol; 0 if none
+}
+
+// dayVolume is a symbol's volume so far on one trading day. Aggregates
+// without Polygon's accumulated volume add their minute to it, so bars
+// carry the day total rather than only the minutes since the last flush.
+type dayVolume struct {
+	day    time.Time
+	volume int64
+}
+
+// NewPriceStreamer creates a streamer writing to TimescaleDB and publishing
+// to SNS_PRICE_UPDATES_ARN when set
+func NewPriceStreamer() *PriceStreamer {
+	var sink PriceUpdateSink
+	if publisher := GetPriceUpdatePublisher(); publisher != nil {
+		sink = publisher
+	}
+	return NewPriceStreamerWith(NewPriceService(), sink)
+}
+
+// NewPriceStreamerWith creates a streamer over store and publisher. A nil
+// publisher skips publishing.
+func NewPriceStreamerWith(store StreamedPriceStore, publisher PriceUpdateSink) *PriceStreamer {
+	return &PriceStreamer{
+		store:      store,
+		publisher:  publisher,
+		pending:    make(map[string]*models.StockPrice),
+		dayVolumes: make(map[string]dayVolume),
+	}
+}
+
+// Run streams from client, flushing every interval, until ctx is done or
+// client gives up. Bars still pending when it stops are flushed before Run
+// returns.
+func (s *PriceStreamer) Run(ctx context.Context, client *PolygonWebsocketClient, interval time.Duration) error {
+	errc := make(chan error, 1)
+	go func() { errc <- client.Run(ctx, s.HandleAggregate) }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				log.Printf("⚠️ Price stream flush: %v", err)
+			}
+		case err := <-errc:
+			// ctx may be done; give the last flush its own deadline
+			flushCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if ferr := s.Flush(flushCtx); ferr != nil {
+				log.Printf("⚠️ Price stream flush: %v", ferr)
+			}
+			return err
+		}
+	}
+}
+
+// HandleAggregate folds a per-minute aggregate into the symbol's pending bar
+func (s *PriceStreamer) HandleAggregate(agg PolygonAggregate) {
+	if agg.Symbol == "" || agg.Close <= 0 {
+		return
+	}
+	at := time.UnixMilli(agg.End)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bar := s.pending[agg.Symbol]
+	if bar == nil || !tradingDay(bar.Timestamp).Equal(tradingDay(at)) {
+		open := agg.DayOpen
+		if open <= 0 {
+			open = agg.Open
+		}
+		bar = &models.StockPrice{
+			Symbol: agg.Symbol,
+			Open:   decimal.NewFromFloat(open),
+			High:   decimal.NewFromFloat(agg.High),
+			Low:    decimal.NewFromFloat(agg.Low),
+		}
+		s.pending[agg.Symbol] = bar
+	} else {
+		bar.High = decimal.Max(bar.High, decimal.NewFromFloat(agg.High))
+		bar.Low = decimal.Min(bar.Low, decimal.NewFromFloat(agg.Low))
+	}
+	bar.Close = decimal.NewFromFloat(agg.Close)
+	bar.Price = bar.Close
+	bar.Volume = s.addDayVolume(agg, at)
+	bar.Timestamp = at
+}
+
+// addDayVolume folds agg into its symbol's day volume, starting over on a
+// new trading day, and returns the day total. Must be called with mu held.
+func (s *PriceStreamer) addDayVolume(agg PolygonAggregate, at time.Time) int64 {
+	dv := s.dayVolumes[agg.Symbol]
+	if day := tradingDay(at); !dv.day.Equal(day) {
+		dv = dayVolume{day: day}
+	}
+	if agg.DayVolume > 0 {
+		dv.volume = agg.DayVolume
+	} else {
+		dv.volume += agg.Volume
+	}
+	s.dayVolumes[agg.Symbol] = dv
+	return dv.volume
+}
+
+// Flush writes the pending bars to stock_prices and publishes them as a
+// price update. Bars that fail to write are still published; the errors
+// are returned together.
+func (s *PriceStreamer) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	bars := s.pending
+	s.pending = make(map[string]*models.StockPrice)
+	s.mu.Unlock()
+	if len(bars) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, bar := range bars {
+		if err := s.store.MergeDailyPrice(ctx, bar); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := s.publish(ctx, bars); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// publish sends bars to the notification service, with each symbol's
+// change against its prior close
+func (s *PriceStreamer) publish(ctx context.Context, bars map[string]*models.StockPrice) error {
+	if s.publisher == nil {
+		return nil // SNS not configured (local dev)
+	}
+
+	var latest time.Time
+	for _, bar := range bars {
+		if bar.Timestamp.After(latest) {
+			latest = bar.Timestamp
+		}
+	}
+	closes, err := s.priorCloses(ctx, bars, latest)
+	if err != nil {
+		// Still publish: price and volume alerts don't need the change
+		log.Printf("⚠️ Price stream: %v", err)
+	}
+
+	msg := models.PriceUpdateMessage{
+		Timestamp: latest.Unix(),
+		Source:    "polygon_websocket",
+		Symbols:   make(map[string]models.SymbolQuote, len(bars)),
+	}
+	for symbol, bar := range bars {
+		price := bar.Close.InexactFloat64()
+		quote := models.SymbolQuote{Price: price, Volume: bar.Volume}
+		if prior := closes[symbol]; prior > 0 {
+			quote.ChangePct = (price - prior) / prior * 100
+		}
+		msg.Symbols[symbol] = quote
+	}
+
+	// The notification service continues this trace from the message
+	// attributes when it processes the update.
+	ctx, span := tracing.Tracer().Start(ctx, "price_updates publish",
+		trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	var errs []error
+	for _, part := range splitPriceUpdate(msg, priceUpdateChunkSymbols) {
+		if err := s.publisher.Publish(ctx, part); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// priorCloses returns the prior close of each symbol in bars, looking up
+// only symbols not already cached for day's trading date
+func (s *PriceStreamer) priorCloses(ctx context.Context, bars map[string]*models.StockPrice, day time.Time) (map[string]float64, error) {
+	s.closesMu.Lock()
+	defer s.closesMu.Unlock()
+
+	if d := tradingDay(day); !d.Equal(s.closesDay) {
+		s.closesDay = d
+		s.closes = make(map[string]float64)
+	}
+	var missing []string
+	for symbol := range bars {
+		if _, ok := s.closes[symbol]; !ok {
+			missing = append(missing, symbol)
+		}
+	}
+	if len(missing) > 0 {
+		found, err := s.store.GetPriorCloses(ctx, missing, day)
+		if err != nil {
+			return nil, fmt.Errorf("prior closes: %w", err)
+		}
+		for _, symbol := range missing {
+			s.closes[symbol] = found[symbol] // 0 if none: not looked up again today
+		}
+	}
+
+	closes := make(map[string]float64, len(bars))
+	for symbol := range bars {
+		closes[symbol] = s.closes[symbol]
+	}
+	return closes, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+type fakeStreamedPriceStore struct {
+	mu         sync.Mutex
+	merged     []models.StockPrice
+	closes     map[string]float64
+	closeCalls [][]string
+	mergeErr   error
+}
+
+func (f *fakeStreamedPriceStore) MergeDailyPrice(_ context.Context, price *models.StockPrice) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.merged = append(f.merged, *price)
+	return f.mergeErr
+}
+
+func (f *fakeStreamedPriceStore) GetPriorCloses(_ context.Context, symbols []string, _ time.Time) (map[string]float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closeCalls = append(f.closeCalls, symbols)
+	return f.closes, nil
+}
+
+type fakePriceUpdateSink struct {
+	msgs []models.PriceUpdateMessage
+}
+
+func (f *fakePriceUpdateSink) Publish(_ context.Context, msg models.PriceUpdateMessage) error {
+	f.msgs = append(f.msgs, msg)
+	return nil
+}
+
+// marketMinute returns 10:mm on a trading day, in Unix ms
+func marketMinute(mm int) int64 {
+	loc, _ := time.LoadLocation("America/New_York")
+	return time.Date(2026, 3, 10, 10, mm, 0, 0, loc).UnixMilli()
+}
+
+func TestPriceStreamer_FlushMergesAndPublishes(t *testing.T) {
+	store := &fakeStreamedPriceStore{closes: map[string]float64{"AAPL": 200}}
+	sink := &fakePriceUpdateSink{}
+	s := NewPriceStreamerWith(store, sink)
+
+	s.HandleAggregate(PolygonAggregate{Symbol: "AAPL", DayOpen: 201, Open: 203, High: 205, Low: 202, Close: 204, Volume: 1000, DayVolume: 50000, End: marketMinute(1)})
+	s.HandleAggregate(PolygonAggregate{Symbol: "AAPL", Open: 204, High: 206, Low: 199, Close: 210, Volume: 2000, DayVolume: 52000, End: marketMinute(2)})
+	s.HandleAggregate(PolygonAggregate{Symbol: "MSFT", Open: 400, High: 401, Low: 399, Close: 400.5, Volume: 500, End: marketMinute(2)})
+
+	require.NoError(t, s.Flush(context.Background()))
+
+	require.Len(t, store.merged, 2)
+	bars := map[string]models.StockPrice{}
+	for _, b := range store.merged {
+		bars[b.Symbol] = b
+	}
+	aapl := bars["AAPL"]
+	assert.Equal(t, 201.0, aapl.Open.InexactFloat64())
+	assert.Equal(t, 206.0, aapl.High.InexactFloat64())
+	assert.Equal(t, 199.0, aapl.Low.InexactFloat64())
+	assert.Equal(t, 210.0, aapl.Close.InexactFloat64())
+	assert.Equal(t, int64(52000), aapl.Volume)
+	assert.Equal(t, int64(500), bars["MSFT"].Volume)
+
+	require.Len(t, sink.msgs, 1)
+	msg := sink.msgs[0]
+	assert.Equal(t, "polygon_websocket", msg.Source)
+	assert.Equal(t, marketMinute(2)/1000, msg.Timestamp)
+	assert.InDelta(t, 5.0, msg.Symbols["AAPL"].ChangePct, 1e-9)
+	assert.Zero(t, msg.Symbols["MSFT"].ChangePct) // no prior close
+
+	// Nothing pending: nothing written
+	require.NoError(t, s.Flush(context.Background()))
+	assert.Len(t, store.merged, 2)
+	assert.Len(t, sink.msgs, 1)
+}
+
+func TestPriceStreamer_DayVolumeCarriesAcrossFlushes(t *testing.T) {
+	store := &fakeStreamedPriceStore{}
+	sink := &fakePriceUpdateSink{}
+	s := NewPriceStreamerWith(store, sink)
+
+	// MSFT aggregates arrive without the accumulated day volume
+	s.HandleAggregate(PolygonAggregate{Symbol: "MSFT", Close: 400, Volume: 500, End: marketMinute(1)})
+	require.NoError(t, s.Flush(context.Background()))
+	s.HandleAggregate(PolygonAggregate{Symbol: "MSFT", Close: 401, Volume: 300, End: marketMinute(2)})
+	require.NoError(t, s.Flush(context.Background()))
+
+	require.Len(t, store.merged, 2)
+	assert.Equal(t, int64(800), store.merged[1].Volume, "the day total, not just the minutes since the last flush")
+	assert.Equal(t, int64(800), sink.msgs[1].Symbols["MSFT"].Volume)
+
+	// The next session starts from zero
+	loc, _ := time.LoadLocation("America/New_York")
+	nextDay := time.Date(2026, 3, 11, 9, 31, 0, 0, loc).UnixMilli()
+	s.HandleAggregate(PolygonAggregate{Symbol: "MSFT", Close: 402, Volume: 100, End: nextDay})
+	require.NoError(t, s.Flush(context.Background()))
+	assert.Equal(t, int64(100), store.merged[2].Volume)
+}
+
+func TestPriceStreamer_PriorClosesCachedPerDay(t *testing.T) {
+	store := &fakeStreamedPriceStore{closes: map[string]float64{"AAPL": 200}}
+	s := NewPriceStreamerWith(store, &fakePriceUpdateSink{})
+
+	for i := 1; i <= 3; i++ {
+		s.HandleAggregate(PolygonAggregate{Symbol: "AAPL", Close: 201, End: marketMinute(i)})
+		require.NoError(t, s.Flush(context.Background()))
+	}
+	assert.Len(t, store.closeCalls, 1)
+}
+
+func TestPriceStreamer_FlushReturnsStoreErrorsAndStillPublishes(t *testing.T) {
+	store := &fakeStreamedPriceStore{mergeErr: errors.New("db down")}
+	sink := &fakePriceUpdateSink{}
+	s := NewPriceStreamerWith(store, sink)
+
+	s.HandleAggregate(PolygonAggregate{Symbol: "AAPL", Close: 201, End: marketMinute(1)})
+	assert.Error(t, s.Flush(context.Background()))
+	assert.Len(t, sink.msgs, 1)
+}
+
+func TestPriceStreamer_IgnoresEmptyAggregates(t *testing.T) {
+	store := &fakeStreamedPriceStore{}
+	s := NewPriceStreamerWith(store, nil)
+
+	s.HandleAggregate(PolygonAggregate{Symbol: "AAPL", End: marketMinute(1)})
+	s.HandleAggregate(PolygonAggregate{Close: 10, End: marketMinute(1)})
+	require.NoError(t, s.Flush(context.Background()))
+	assert.Empty(t, store.merged)
+}
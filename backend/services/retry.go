@@ -0,0 +1,15 @@
+package services
+
+// defaultMaxRetries is how many times upstream clients retry a transient
+// failure unless overridden by their <PROVIDER>_MAX_RETRIES variable
+const defaultMaxRetries = 2
+
+// maxRetriesFromEnv reads an upstream client's retry count from key,
+// defaulting to defaultMaxRetries. Negative values disable retries.
+func maxRetriesFromEnv(key string) int {
+	n := envInt(key, defaultMaxRetries)
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3RequestTimeout bounds each S3 call, retries included
+const s3RequestTimeout = 10 * time.Second
+
+// s3Bucket reads and writes objects in one S3 bucket with the default AWS
+// credential chain (IRSA in K8s, env vars locally). The recording and logo
+// stores each wrap one.
+type s3Bucket struct {
+	name   string
+	client *s3.Client
+}
+
+// newS3Bucket connects to bucket in AWS_REGION (default us-east-1)
+func newS3Bucket(bucket string) (*s3Bucket, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return &s3Bucket{name: bucket, client: s3.NewFromConfig(cfg)}, nil
+}
+
+// put uploads data under key with the given Content-Type
+func (b *s3Bucket) put(ctx context.Context, key string, data []byte, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, s3RequestTimeout)
+	defer cancel()
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := b.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to upload %s to S3: %w", key, err)
+	}
+	return nil
+}
+
+// get downloads the object at key
+func (b *s3Bucket) get(ctx context.Context, key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3RequestTimeout)
+	defer cancel()
+
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s from S3: %w", key, err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
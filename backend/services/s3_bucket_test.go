@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestS3Bucket points an s3Bucket at an in-memory server that checks
+// requests are signed and records each object's Content-Type. Requests use
+// path-style addressing, so keys arrive as /test-bucket/<key>.
+func newTestS3Bucket(t *testing.T) (*s3Bucket, map[string]string) {
+	t.Helper()
+	objects := make(map[string][]byte)
+	contentTypes := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/"))
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = body
+			contentTypes[r.URL.Path] = r.Header.Get("Content-Type")
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		HTTPClient:   server.Client(),
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret"}, nil
+		}),
+	})
+	return &s3Bucket{name: "test-bucket", client: client}, contentTypes
+}
+
+func TestS3RecordingStore_SignedPutAndGet(t *testing.T) {
+	bucket, contentTypes := newTestS3Bucket(t)
+	store := &S3RecordingStore{bucket: bucket}
+
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "polygon/AAPL/1.json", []byte(`{"a":1}`)))
+	got, err := store.Get(ctx, "polygon/AAPL/1.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(got))
+	assert.Equal(t, "application/json", contentTypes["/test-bucket/polygon/AAPL/1.json"])
+
+	_, err = store.Get(ctx, "polygon/AAPL/missing.json")
+	assert.Error(t, err)
+}
+
+func TestS3LogoStore_KeepsContentType(t *testing.T) {
+	bucket, contentTypes := newTestS3Bucket(t)
+	store := &S3LogoStore{bucket: bucket}
+
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "ticker-logos/AAPL/abc", []byte("<svg/>"), "image/svg+xml"))
+	got, err := store.Get(ctx, "ticker-logos/AAPL/abc")
+	require.NoError(t, err)
+	assert.Equal(t, "<svg/>", string(got))
+	assert.Equal(t, "image/svg+xml", contentTypes["/test-bucket/ticker-logos/AAPL/abc"])
+}
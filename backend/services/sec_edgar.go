@@ -0,0 +1,495 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"investorcenter-api/httputil"
+	"investorcenter-api/models"
+)
+
+var (
+	SECEdgarBaseURL = "https://data.sec.gov"
+
+	// SECRetryBaseDelay is the backoff before the first retry after a 429
+	// or 5xx; each further retry doubles it
+	SECRetryBaseDelay = time.Second
+	// SECMaxRetryDelay caps both the backoff and a server's Retry-After
+	SECMaxRetryDelay = 30 * time.Second
+
+	// secSleep waits between retries, swapped out in tests
+	secSleep = time.Sleep
+)
+
+// ErrSECNoCompanyFacts is returned when EDGAR has no XBRL facts for a CIK
+var ErrSECNoCompanyFacts = errors.New("no SEC company facts")
+
+// SECEdgarClient fetches XBRL company facts from SEC EDGAR. The SEC requires
+// every request to carry a User-Agent naming the requester with a contact
+// email (SEC_USER_AGENT, e.g. "InvestorCenter ops@example.com") and blocks
+// clients making more than 10 requests per second, so requests are rate
+// limited client-side. Without a User-Agent the client is disabled.
+type SECEdgarClient struct {
+	UserAgent string
+	Client    *http.Client
+	// MaxRetries is how many times a request is retried after a 429, 5xx or
+	// transport error. Zero disables retries.
+	MaxRetries  int
+	rateLimiter *RateLimiter
+}
+
+// NewSECEdgarClient creates a new SEC EDGAR client. Requests are retried up
+// to SEC_MAX_RETRIES times (default 2).
+func NewSECEdgarClient() *SECEdgarClient {
+	userAgent := strings.TrimSpace(os.Getenv("SEC_USER_AGENT"))
+	if userAgent == "" {
+		log.Println("Warning: SEC_USER_AGENT not set, SEC EDGAR financials fallback will be disabled")
+	}
+	return &SECEdgarClient{
+		UserAgent:   userAgent,
+		Client:      upstreamClient("sec"),
+		MaxRetries:  maxRetriesFromEnv("SEC_MAX_RETRIES"),
+		rateLimiter: NewRateLimiter(10, time.Second),
+	}
+}
+
+// SECCompanyFacts is the companyfacts response: every XBRL fact a company
+// has filed, by taxonomy ("us-gaap", "dei") and tag
+type SECCompanyFacts struct {
+	CIK        int                              `json:"cik"`
+	EntityName string                           `json:"entityName"`
+	Facts      map[string]map[string]SECConcept `json:"facts"`
+}
+
+// SECConcept holds one tag's facts by unit ("USD", "USD/shares", "shares")
+type SECConcept struct {
+	Label string               `json:"label"`
+	Units map[string][]SECFact `json:"units"`
+}
+
+// SECFact is one reported value. Start is empty for point-in-time (balance
+// sheet) values. FY, FP and Form describe the filing the value appeared
+// in, which also reports prior periods for comparison.
+type SECFact struct {
+	Start string  `json:"start,omitempty"`
+	End   string  `json:"end"`
+	Val   float64 `json:"val"`
+	Accn  string  `json:"accn"`
+	FY    int     `json:"fy"`
+	FP    string  `json:"fp"`
+	Form  string  `json:"form"`
+	Filed string  `json:"filed"`
+}
+
+// PadCIK formats a CIK as the 10-digit, zero-padded form EDGAR uses
+func PadCIK(cik string) (string, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(cik), 10, 64)
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("invalid CIK %q", cik)
+	}
+	return fmt.Sprintf("%010d", n), nil
+}
+
+// GetCompanyFacts fetches all XBRL facts filed by the company with the
+// given CIK. Returns ErrSECNoCompanyFacts when EDGAR has none.
+func (c *SECEdgarClient) GetCompanyFacts(ctx context.Context, cik string) (*SECCompanyFacts, error) {
+	if c.UserAgent == "" {
+		return nil, fmt.Errorf("SEC_USER_AGENT not configured")
+	}
+	padded, err := PadCIK(cik)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/xbrl/companyfacts/CIK%s.json", SECEdgarBaseURL, padded)
+	resp, err := httputil.DoWithRetry(c.retryPolicy(), func() (*http.Response, error) {
+		c.rateLimiter.Wait()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", c.UserAgent)
+		req.Header.Set("Accept", "application/json")
+		return c.Client.Do(req)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SEC EDGAR request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w for CIK %s", ErrSECNoCompanyFacts, padded)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("SEC EDGAR returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var facts SECCompanyFacts
+	if err := json.NewDecoder(resp.Body).Decode(&facts); err != nil {
+		return nil, fmt.Errorf("failed to decode SEC company facts: %w", err)
+	}
+	return &facts, nil
+}
+
+func (c *SECEdgarClient) retryPolicy() httputil.RetryPolicy {
+	return httputil.RetryPolicy{
+		MaxRetries: c.MaxRetries,
+		BaseDelay:  SECRetryBaseDelay,
+		MaxDelay:   SECMaxRetryDelay,
+		Jitter:     0.5,
+		Sleep:      secSleep,
+	}
+}
+
+// secLineItem maps a FinancialData key, named as Polygon names it so SEC
+// and Polygon statements read alike, to the us-gaap tags that report it
+type secLineItem struct {
+	key  string
+	tags []string // first tag with facts in unit wins
+	unit string   // XBRL unit
+	// negate flips the sign: capital expenditure is reported as a positive
+	// payment but stored as an outflow
+	negate bool
+}
+
+// secUnitLabels are the unit labels Polygon uses, stored as <key>_unit
+var secUnitLabels = map[string]string{
+	"USD":        "USD",
+	"USD/shares": "USD / shares",
+	"shares":     "shares",
+}
+
+// secStatementItems lists the line items mapped for each statement type.
+// Balance sheet items are point-in-time; the rest cover a period.
+var secStatementItems = map[models.StatementType][]secLineItem{
+	models.StatementTypeIncome: {
+		{key: "revenues", tags: []string{"Revenues", "RevenueFromContractWithCustomerExcludingAssessedTax", "SalesRevenueNet"}, unit: "USD"},
+		{key: "cost_of_revenue", tags: []string{"CostOfRevenue", "CostOfGoodsAndServicesSold"}, unit: "USD"},
+		{key: "gross_profit", tags: []string{"GrossProfit"}, unit: "USD"},
+		{key: "research_and_development", tags: []string{"ResearchAndDevelopmentExpense"}, unit: "USD"},
+		{key: "selling_general_and_administrative_expenses", tags: []string{"SellingGeneralAndAdministrativeExpense"}, unit: "USD"},
+		{key: "operating_expenses", tags: []string{"OperatingExpenses"}, unit: "USD"},
+		{key: "operating_income_loss", tags: []string{"OperatingIncomeLoss"}, unit: "USD"},
+		{key: "income_tax_expense_benefit", tags: []string{"IncomeTaxExpenseBenefit"}, unit: "USD"},
+		{key: "net_income_loss", tags: []string{"NetIncomeLoss"}, unit: "USD"},
+		{key: "basic_earnings_per_share", tags: []string{"EarningsPerShareBasic"}, unit: "USD/shares"},
+		{key: "diluted_earnings_per_share", tags: []string{"EarningsPerShareDiluted"}, unit: "USD/shares"},
+		{key: "basic_average_shares", tags: []string{"WeightedAverageNumberOfSharesOutstandingBasic"}, unit: "shares"},
+		{key: "diluted_average_shares", tags: []string{"WeightedAverageNumberOfDilutedSharesOutstanding"}, unit: "shares"},
+	},
+	models.StatementTypeBalanceSheet: {
+		{key: "assets", tags: []string{"Assets"}, unit: "USD"},
+		{key: "current_assets", tags: []string{"AssetsCurrent"}, unit: "USD"},
+		{key: "noncurrent_assets", tags: []string{"AssetsNoncurrent"}, unit: "USD"},
+		{key: "cash", tags: []string{"CashAndCashEquivalentsAtCarryingValue"}, unit: "USD"},
+		{key: "inventory", tags: []string{"InventoryNet"}, unit: "USD"},
+		{key: "liabilities", tags: []string{"Liabilities"}, unit: "USD"},
+		{key: "current_liabilities", tags: []string{"LiabilitiesCurrent"}, unit: "USD"},
+		{key: "noncurrent_liabilities", tags: []string{"LiabilitiesNoncurrent"}, unit: "USD"},
+		{key: "accounts_payable", tags: []string{"AccountsPayableCurrent"}, unit: "USD"},
+		{key: "long_term_debt", tags: []string{"LongTermDebtNoncurrent"}, unit: "USD"},
+		{key: "equity", tags: []string{"StockholdersEquityIncludingPortionAttributableToNoncontrollingInterest", "StockholdersEquity"}, unit: "USD"},
+		{key: "equity_attributable_to_parent", tags: []string{"StockholdersEquity"}, unit: "USD"},
+		{key: "liabilities_and_equity", tags: []string{"LiabilitiesAndStockholdersEquity"}, unit: "USD"},
+	},
+	models.StatementTypeCashFlow: {
+		{key: "net_cash_flow_from_operating_activities", tags: []string{"NetCashProvidedByUsedInOperatingActivities"}, unit: "USD"},
+		{key: "net_cash_flow_from_investing_activities", tags: []string{"NetCashProvidedByUsedInInvestingActivities"}, unit: "USD"},
+		{key: "net_cash_flow_from_financing_activities", tags: []string{"NetCashProvidedByUsedInFinancingActivities"}, unit: "USD"},
+		{key: "capital_expenditure", tags: []string{"PaymentsToAcquirePropertyPlantAndEquipment"}, unit: "USD", negate: true},
+		{key: "net_cash_flow", tags: []string{"CashCashEquivalentsRestrictedCashAndRestrictedCashEquivalentsPeriodIncreaseDecreaseIncludingExchangeRateEffect", "CashAndCashEquivalentsPeriodIncreaseDecrease"}, unit: "USD"},
+	},
+}
+
+// A fiscal quarter is 13 weeks give or take one for 52/53-week years;
+// these bounds leave room for calendar quarters and short stub periods
+const (
+	secMinQuarterDays = 75
+	secMaxQuarterDays = 105
+)
+
+// secPeriod is a quarter reported by one 10-Q or 10-K filing
+type secPeriod struct {
+	end, form, filed, accn string
+	fiscalYear, quarter    int
+}
+
+// ConvertSECCompanyFacts maps company facts to quarterly income statement,
+// balance sheet and cash flow statements, one per quarter a 10-Q or 10-K
+// reported, with the same FinancialData keys Polygon statements use. 10-Ks
+// report the fiscal year, so Q4 is the year less the first nine months.
+// Cash flows are reported year-to-date, so each quarter is the difference
+// between consecutive year-to-date values. Per-share values and share
+// counts can't be differenced and are only kept when a filing reports the
+// quarter itself. Statements come newest first.
+func ConvertSECCompanyFacts(facts *SECCompanyFacts, tickerID int) []*models.FinancialStatement {
+	gaap := facts.Facts["us-gaap"]
+	if len(gaap) == 0 {
+		return nil
+	}
+	cik := fmt.Sprintf("%010d", facts.CIK)
+
+	var statements []*models.FinancialStatement
+	for _, period := range secFilingPeriods(gaap) {
+		for _, statementType := range []models.StatementType{
+			models.StatementTypeIncome,
+			models.StatementTypeBalanceSheet,
+			models.StatementTypeCashFlow,
+		} {
+			stmt := secStatement(gaap, period, statementType)
+			if stmt == nil {
+				continue
+			}
+			stmt.TickerID = tickerID
+			stmt.CIK = &cik
+			sourceURL := fmt.Sprintf("https://www.sec.gov/Archives/edgar/data/%d/%s/", facts.CIK, strings.ReplaceAll(period.accn, "-", ""))
+			stmt.SourceFilingURL = &sourceURL
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// secFilingPeriods finds the quarter each 10-Q and 10-K filing reports: its
+// latest period end, with the fiscal year and period the filing declares.
+// When a quarter was filed more than once (amendments) the latest filing
+// wins. Periods come newest first.
+func secFilingPeriods(gaap map[string]SECConcept) []secPeriod {
+	byAccn := make(map[string]*secPeriod)
+	for _, items := range secStatementItems {
+		for _, item := range items {
+			for _, tag := range item.tags {
+				for _, fact := range gaap[tag].Units[item.unit] {
+					quarter := secFiscalQuarter(fact.FP)
+					form := strings.TrimSuffix(fact.Form, "/A")
+					if quarter == 0 || (form != "10-Q" && form != "10-K") || fact.FY == 0 {
+						continue
+					}
+					p, ok := byAccn[fact.Accn]
+					if !ok {
+						p = &secPeriod{accn: fact.Accn, form: fact.Form, filed: fact.Filed, fiscalYear: fact.FY, quarter: quarter}
+						byAccn[fact.Accn] = p
+					}
+					if fact.End > p.end {
+						p.end = fact.End
+					}
+				}
+			}
+		}
+	}
+
+	type quarterKey struct{ year, quarter int }
+	latest := make(map[quarterKey]secPeriod)
+	for _, p := range byAccn {
+		key := quarterKey{p.fiscalYear, p.quarter}
+		if prev, ok := latest[key]; !ok || p.filed > prev.filed {
+			latest[key] = *p
+		}
+	}
+
+	periods := make([]secPeriod, 0, len(latest))
+	for _, p := range latest {
+		periods = append(periods, p)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].end > periods[j].end })
+	return periods
+}
+
+// secFiscalQuarter maps a filing's fiscal period to its quarter; a 10-K's
+// FY covers the fourth. Other values return 0.
+func secFiscalQuarter(fp string) int {
+	switch fp {
+	case "Q1":
+		return 1
+	case "Q2":
+		return 2
+	case "Q3":
+		return 3
+	case "FY":
+		return 4
+	}
+	return 0
+}
+
+// secStatement builds one statement for a period, or returns nil when none
+// of its line items could be resolved
+func secStatement(gaap map[string]SECConcept, period secPeriod, statementType models.StatementType) *models.FinancialStatement {
+	data := make(models.FinancialData)
+	var periodStart *time.Time
+	for _, item := range secStatementItems[statementType] {
+		concept, facts := secItemFacts(gaap, item)
+		if facts == nil {
+			continue
+		}
+
+		var val float64
+		var ok bool
+		if statementType == models.StatementTypeBalanceSheet {
+			val, ok = secInstantValue(facts, period.end)
+		} else {
+			var start time.Time
+			val, start, ok = secQuarterValue(facts, period.end, item.unit == "USD")
+			if ok && periodStart == nil {
+				periodStart = &start
+			}
+		}
+		if !ok {
+			continue
+		}
+		if item.negate {
+			val = -val
+		}
+		data[item.key] = val
+		data[item.key+"_label"] = concept.Label
+		data[item.key+"_unit"] = secUnitLabels[item.unit]
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	periodEnd, err := time.Parse("2006-01-02", period.end)
+	if err != nil {
+		return nil
+	}
+	quarter := period.quarter
+	form := period.form
+	stmt := &models.FinancialStatement{
+		StatementType:    statementType,
+		Timeframe:        models.TimeframeQuarterly,
+		FiscalYear:       period.fiscalYear,
+		FiscalQuarter:    &quarter,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		SourceFilingType: &form,
+		Data:             data,
+	}
+	if filed, err := time.Parse("2006-01-02", period.filed); err == nil {
+		stmt.FiledDate = &filed
+	}
+	return stmt
+}
+
+// secItemFacts returns the facts of the first of item's tags reported in
+// its unit
+func secItemFacts(gaap map[string]SECConcept, item secLineItem) (SECConcept, []SECFact) {
+	for _, tag := range item.tags {
+		concept, ok := gaap[tag]
+		if !ok {
+			continue
+		}
+		if facts := concept.Units[item.unit]; len(facts) > 0 {
+			return concept, facts
+		}
+	}
+	return SECConcept{}, nil
+}
+
+// secInstantValue returns the point-in-time value at end, as most recently
+// filed
+func secInstantValue(facts []SECFact, end string) (float64, bool) {
+	var best *SECFact
+	for i := range facts {
+		f := &facts[i]
+		if f.Start != "" || f.End != end {
+			continue
+		}
+		if best == nil || f.Filed > best.Filed {
+			best = f
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.Val, true
+}
+
+// secQuarterValue returns the value for the quarter ending at end, and the
+// quarter's start. A reported quarter-length value is used as is; failing
+// that, when derive is set, the quarter is the year-to-date value ending at
+// end less the year-to-date value one quarter earlier.
+func secQuarterValue(facts []SECFact, end string, derive bool) (float64, time.Time, bool) {
+	// Latest filing wins for each start/end span, so restatements apply
+	type span struct{ start, end string }
+	latest := make(map[span]SECFact)
+	for _, f := range facts {
+		if f.Start == "" {
+			continue
+		}
+		key := span{f.Start, f.End}
+		if prev, ok := latest[key]; !ok || f.Filed > prev.Filed {
+			latest[key] = f
+		}
+	}
+
+	var direct, ytd *SECFact
+	var ytdDays int
+	for key, f := range latest {
+		if key.end != end {
+			continue
+		}
+		f := f
+		days, ok := secSpanDays(key.start, key.end)
+		if !ok {
+			continue
+		}
+		if days >= secMinQuarterDays && days <= secMaxQuarterDays {
+			if direct == nil || f.Filed > direct.Filed {
+				direct = &f
+			}
+		} else if days > ytdDays {
+			ytd, ytdDays = &f, days
+		}
+	}
+	if direct != nil {
+		start, _ := time.Parse("2006-01-02", direct.Start)
+		return direct.Val, start, true
+	}
+	if !derive || ytd == nil {
+		return 0, time.Time{}, false
+	}
+
+	// The year to date one quarter earlier shares the start date
+	var prior *SECFact
+	for key, f := range latest {
+		if key.start != ytd.Start || key.end >= end {
+			continue
+		}
+		f := f
+		if prior == nil || key.end > prior.End {
+			prior = &f
+		}
+	}
+	if prior == nil {
+		return 0, time.Time{}, false
+	}
+	days, ok := secSpanDays(prior.End, end)
+	if !ok || days < secMinQuarterDays || days > secMaxQuarterDays {
+		return 0, time.Time{}, false
+	}
+	priorEnd, _ := time.Parse("2006-01-02", prior.End)
+	return ytd.Val - prior.Val, priorEnd.AddDate(0, 0, 1), true
+}
+
+// secSpanDays returns the number of days from start to end
+func secSpanDays(start, end string) (int, bool) {
+	s, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return 0, false
+	}
+	e, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return 0, false
+	}
+	return int(e.Sub(s).Hours() / 24), true
+}
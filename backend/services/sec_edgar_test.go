@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+// newSECTestClient creates an SECEdgarClient pointing at server, without
+// backoff delays
+func newSECTestClient(t *testing.T, server *httptest.Server) *SECEdgarClient {
+	t.Helper()
+	origURL, origSleep := SECEdgarBaseURL, secSleep
+	SECEdgarBaseURL = server.URL
+	secSleep = func(time.Duration) {}
+	t.Cleanup(func() { SECEdgarBaseURL, secSleep = origURL, origSleep })
+	return &SECEdgarClient{
+		UserAgent:   "InvestorCenter test@example.com",
+		Client:      server.Client(),
+		MaxRetries:  2,
+		rateLimiter: NewRateLimiter(100, time.Second),
+	}
+}
+
+func TestPadCIK(t *testing.T) {
+	padded, err := PadCIK("320193")
+	require.NoError(t, err)
+	assert.Equal(t, "0000320193", padded)
+
+	padded, err = PadCIK("0000320193")
+	require.NoError(t, err)
+	assert.Equal(t, "0000320193", padded)
+
+	_, err = PadCIK("not-a-cik")
+	assert.Error(t, err)
+}
+
+func TestSECEdgar_GetCompanyFacts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/xbrl/companyfacts/CIK0000320193.json", r.URL.Path)
+		assert.Equal(t, "InvestorCenter test@example.com", r.Header.Get("User-Agent"))
+		// First attempt is throttled, the retry succeeds
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(SECCompanyFacts{CIK: 320193, EntityName: "Apple Inc."})
+	}))
+	defer server.Close()
+
+	facts, err := newSECTestClient(t, server).GetCompanyFacts(context.Background(), "320193")
+	require.NoError(t, err)
+	assert.Equal(t, "Apple Inc.", facts.EntityName)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestSECEdgar_GetCompanyFacts_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	client := newSECTestClient(t, server)
+
+	_, err := client.GetCompanyFacts(context.Background(), "1")
+	assert.True(t, errors.Is(err, ErrSECNoCompanyFacts), "got %v", err)
+
+	client.UserAgent = ""
+	_, err = client.GetCompanyFacts(context.Background(), "1")
+	assert.ErrorContains(t, err, "SEC_USER_AGENT")
+}
+
+// secTestFacts is a calendar-year filer's 2024: three 10-Qs, an amended
+// Q2, and the 10-K. Cash flows are only reported year to date.
+func secTestFacts() *SECCompanyFacts {
+	q1 := func(start, end string, val float64) SECFact {
+		return SECFact{Start: start, End: end, Val: val, Accn: "0000320193-24-000001", FY: 2024, FP: "Q1", Form: "10-Q", Filed: "2024-05-01"}
+	}
+	q2 := func(start, end string, val float64) SECFact {
+		return SECFact{Start: start, End: end, Val: val, Accn: "0000320193-24-000002", FY: 2024, FP: "Q2", Form: "10-Q", Filed: "2024-08-01"}
+	}
+	q2a := func(start, end string, val float64) SECFact {
+		return SECFact{Start: start, End: end, Val: val, Accn: "0000320193-24-000022", FY: 2024, FP: "Q2", Form: "10-Q/A", Filed: "2024-09-01"}
+	}
+	q3 := func(start, end string, val float64) SECFact {
+		return SECFact{Start: start, End: end, Val: val, Accn: "0000320193-24-000003", FY: 2024, FP: "Q3", Form: "10-Q", Filed: "2024-11-01"}
+	}
+	fy := func(start, end string, val float64) SECFact {
+		return SECFact{Start: start, End: end, Val: val, Accn: "0000320193-25-000004", FY: 2024, FP: "FY", Form: "10-K", Filed: "2025-02-01"}
+	}
+	usd := func(label string, facts ...SECFact) SECConcept {
+		return SECConcept{Label: label, Units: map[string][]SECFact{"USD": facts}}
+	}
+
+	return &SECCompanyFacts{
+		CIK:        320193,
+		EntityName: "Apple Inc.",
+		Facts: map[string]map[string]SECConcept{"us-gaap": {
+			"Revenues": usd("Revenues",
+				q1("2024-01-01", "2024-03-31", 100), q1("2023-01-01", "2023-03-31", 90),
+				q2("2024-04-01", "2024-06-30", 110), q2("2024-01-01", "2024-06-30", 210),
+				q2a("2024-04-01", "2024-06-30", 112),
+				q3("2024-07-01", "2024-09-30", 120), q3("2024-01-01", "2024-09-30", 330),
+				fy("2024-01-01", "2024-12-31", 480)),
+			"NetCashProvidedByUsedInOperatingActivities": usd("Operating cash flow",
+				q1("2024-01-01", "2024-03-31", 30),
+				q2("2024-01-01", "2024-06-30", 70),
+				q3("2024-01-01", "2024-09-30", 100),
+				fy("2024-01-01", "2024-12-31", 150)),
+			"PaymentsToAcquirePropertyPlantAndEquipment": usd("Capital expenditure",
+				q1("2024-01-01", "2024-03-31", 8),
+				q2("2024-01-01", "2024-06-30", 20)),
+			"Assets": usd("Assets",
+				q1("", "2024-03-31", 1000),
+				fy("", "2024-12-31", 1200), fy("", "2023-12-31", 900)),
+			"EarningsPerShareBasic": {Label: "EPS", Units: map[string][]SECFact{"USD/shares": {
+				q1("2024-01-01", "2024-03-31", 1.0),
+				fy("2024-01-01", "2024-12-31", 4.8),
+			}}},
+		}},
+	}
+}
+
+func TestConvertSECCompanyFacts(t *testing.T) {
+	statements := ConvertSECCompanyFacts(secTestFacts(), 7)
+
+	find := func(quarter int, statementType models.StatementType) *models.FinancialStatement {
+		for _, s := range statements {
+			if *s.FiscalQuarter == quarter && s.StatementType == statementType {
+				return s
+			}
+		}
+		return nil
+	}
+
+	// Q2 has no balance sheet; nothing was reported at June 30
+	require.Len(t, statements, 10)
+	assert.Nil(t, find(2, models.StatementTypeBalanceSheet))
+	assert.Equal(t, 4, *statements[0].FiscalQuarter, "newest first")
+
+	q1 := find(1, models.StatementTypeIncome)
+	require.NotNil(t, q1)
+	assert.Equal(t, 7, q1.TickerID)
+	assert.Equal(t, "0000320193", *q1.CIK)
+	assert.Equal(t, models.TimeframeQuarterly, q1.Timeframe)
+	assert.Equal(t, 2024, q1.FiscalYear)
+	assert.Equal(t, "2024-03-31", q1.PeriodEnd.Format("2006-01-02"))
+	assert.Equal(t, "2024-01-01", q1.PeriodStart.Format("2006-01-02"))
+	assert.Equal(t, "10-Q", *q1.SourceFilingType)
+	assert.Equal(t, "https://www.sec.gov/Archives/edgar/data/320193/000032019324000001/", *q1.SourceFilingURL)
+	assert.Equal(t, 100.0, q1.Data["revenues"])
+	assert.Equal(t, "Revenues", q1.Data["revenues_label"])
+	assert.Equal(t, "USD", q1.Data["revenues_unit"])
+	assert.Equal(t, 1.0, q1.Data["basic_earnings_per_share"])
+	assert.Equal(t, "USD / shares", q1.Data["basic_earnings_per_share_unit"])
+
+	// The amended 10-Q replaces the original
+	q2 := find(2, models.StatementTypeIncome)
+	require.NotNil(t, q2)
+	assert.Equal(t, 112.0, q2.Data["revenues"])
+	assert.Equal(t, "10-Q/A", *q2.SourceFilingType)
+
+	// Q4 is the 10-K's year less the first nine months; per-share values
+	// can't be derived that way
+	q4 := find(4, models.StatementTypeIncome)
+	require.NotNil(t, q4)
+	assert.Equal(t, 150.0, q4.Data["revenues"])
+	assert.Equal(t, "2024-10-01", q4.PeriodStart.Format("2006-01-02"))
+	assert.NotContains(t, q4.Data, "basic_earnings_per_share")
+	assert.Equal(t, "10-K", *q4.SourceFilingType)
+
+	// Cash flows are differenced from year-to-date values, capex stored as
+	// an outflow
+	cf2 := find(2, models.StatementTypeCashFlow)
+	require.NotNil(t, cf2)
+	assert.Equal(t, 40.0, cf2.Data["net_cash_flow_from_operating_activities"])
+	assert.Equal(t, -12.0, cf2.Data["capital_expenditure"])
+	assert.Equal(t, 50.0, find(4, models.StatementTypeCashFlow).Data["net_cash_flow_from_operating_activities"])
+	assert.NotContains(t, find(3, models.StatementTypeCashFlow).Data, "capital_expenditure")
+
+	bs4 := find(4, models.StatementTypeBalanceSheet)
+	require.NotNil(t, bs4)
+	assert.Equal(t, 1200.0, bs4.Data["assets"])
+	assert.Nil(t, bs4.PeriodStart)
+}
+
+func TestConvertSECCompanyFacts_NoGAAPFacts(t *testing.T) {
+	assert.Empty(t, ConvertSECCompanyFacts(&SECCompanyFacts{CIK: 1}, 7))
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"investorcenter-shared/requestid"
+	"investorcenter-shared/securityheaders"
+	"investorcenter-shared/tracing"
+)
+
+// newServiceProxy returns a reverse proxy to one of our downstream services,
+// whose routes sit at the root rather than under /api/v1
+func newServiceProxy(target *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	originalDirector := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		originalDirector(req)
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, "/api/v1")
+		req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, "/api/v1")
+	}
+	// The service echoes the X-Request-ID we forwarded and sets its own
+	// security headers; our middleware has already set both on the response,
+	// so don't send them twice.
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		resp.Header.Del(requestid.Header)
+		for _, name := range securityheaders.Names {
+			resp.Header.Del(name)
+		}
+		return nil
+	}
+	// Carries the trace context, so the service's spans join the request's trace
+	proxy.Transport = tracing.Transport(nil)
+	return proxy
+}
+
+// serveProxy forwards the request, with its request ID, to a downstream
+// service
+func serveProxy(c *gin.Context, proxy *httputil.ReverseProxy) {
+	requestid.Forward(c)
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
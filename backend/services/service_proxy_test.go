@@ -0,0 +1,96 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-shared/requestid"
+	"investorcenter-shared/securityheaders"
+	"investorcenter-shared/tracing"
+	"investorcenter-shared/tracing/tracingtest"
+)
+
+func TestServiceProxyForwardsRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotPath, gotID string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotID = r.Header.Get(requestid.Header)
+		w.Header().Set(requestid.Header, gotID)
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer downstream.Close()
+
+	target, err := url.Parse(downstream.URL)
+	require.NoError(t, err)
+	proxy := newServiceProxy(target)
+
+	r := gin.New()
+	r.Use(securityheaders.Middleware(securityheaders.Defaults), requestid.Middleware())
+	r.Any("/api/v1/tasks/*path", func(c *gin.Context) { serveProxy(c, proxy) })
+
+	// ReverseProxy needs a real connection (CloseNotify), not a recorder
+	backend := httptest.NewServer(r)
+	defer backend.Close()
+
+	// A generated ID is forwarded, not just one the client sent
+	resp, err := http.Get(backend.URL + "/api/v1/tasks/next")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/tasks/next", gotPath)
+	assert.NotEmpty(t, gotID)
+	assert.Equal(t, []string{gotID}, resp.Header.Values(requestid.Header), "echoed once, not once per hop")
+	assert.Equal(t, []string{"DENY"}, resp.Header.Values("X-Frame-Options"), "the service's security headers are replaced by ours")
+
+	req, err := http.NewRequest(http.MethodGet, backend.URL+"/api/v1/tasks/next", nil)
+	require.NoError(t, err)
+	req.Header.Set(requestid.Header, "client-trace-1")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "client-trace-1", gotID)
+	assert.Equal(t, []string{"client-trace-1"}, resp.Header.Values(requestid.Header))
+}
+
+func TestServiceProxyPropagatesTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracingtest.RecordSpans(t)
+
+	var gotTraceparent string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+	}))
+	defer downstream.Close()
+
+	target, err := url.Parse(downstream.URL)
+	require.NoError(t, err)
+	proxy := newServiceProxy(target)
+
+	r := gin.New()
+	r.Use(tracing.Middleware())
+	r.Any("/api/v1/ingest/*path", func(c *gin.Context) { serveProxy(c, proxy) })
+	backend := httptest.NewServer(r)
+	defer backend.Close()
+
+	resp, err := http.Post(backend.URL+"/api/v1/ingest/x", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// The request span and the proxied call's client span, in one trace that
+	// the downstream service continues
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	client, server := spans[0], spans[1]
+	assert.Equal(t, "POST /api/v1/ingest/*path", server.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), client.Parent().SpanID())
+	assert.Equal(t, "00-"+client.SpanContext().TraceID().String()+"-"+client.SpanContext().SpanID().String()+"-01", gotTraceparent)
+}
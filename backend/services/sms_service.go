@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// twilioAPIBase is the Twilio REST API root
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// SMSService sends text messages through Twilio
+type SMSService struct {
+	accountSID string
+	authToken  string
+	fromNumber string
+	baseURL    string
+	client     *http.Client
+}
+
+// NewSMSService creates an SMSService from TWILIO_ACCOUNT_SID,
+// TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER
+func NewSMSService() *SMSService {
+	return &SMSService{
+		accountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+		authToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+		fromNumber: os.Getenv("TWILIO_FROM_NUMBER"),
+		baseURL:    twilioAPIBase,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Configured reports whether Twilio credentials are set
+func (s *SMSService) Configured() bool {
+	return s.accountSID != "" && s.authToken != "" && s.fromNumber != ""
+}
+
+// Send texts body to the E.164 number to
+func (s *SMSService) Send(ctx context.Context, to, body string) error {
+	// If Twilio is not configured, skip sending (for development)
+	if !s.Configured() {
+		log.Printf("Twilio not configured. Skipping SMS to %s", to)
+		return nil
+	}
+
+	form := url.Values{"To": {to}, "From": {s.fromNumber}, "Body": {body}}
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.baseURL, url.PathEscape(s.accountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.accountSID, s.authToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("SMS request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("twilio returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
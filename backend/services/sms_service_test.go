@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMSService_Send(t *testing.T) {
+	var gotPath, gotUser, gotPass string
+	var gotForm map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, gotPass, _ = r.BasicAuth()
+		require.NoError(t, r.ParseForm())
+		gotForm = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	s := &SMSService{accountSID: "AC123", authToken: "token", fromNumber: "+15005550006", baseURL: srv.URL, client: srv.Client()}
+	require.NoError(t, s.Send(context.Background(), "+14155550123", "hello"))
+
+	assert.Equal(t, "/Accounts/AC123/Messages.json", gotPath)
+	assert.Equal(t, "AC123", gotUser)
+	assert.Equal(t, "token", gotPass)
+	assert.Equal(t, []string{"+14155550123"}, gotForm["To"])
+	assert.Equal(t, []string{"+15005550006"}, gotForm["From"])
+	assert.Equal(t, []string{"hello"}, gotForm["Body"])
+}
+
+func TestSMSService_SendErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code": 21211, "message": "Invalid 'To' Phone Number"}`))
+	}))
+	defer srv.Close()
+
+	s := &SMSService{accountSID: "AC123", authToken: "token", fromNumber: "+15005550006", baseURL: srv.URL, client: srv.Client()}
+	err := s.Send(context.Background(), "+14155550123", "hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "21211")
+}
+
+func TestSMSService_SendUnconfigured(t *testing.T) {
+	s := &SMSService{baseURL: "http://127.0.0.1:0"}
+	assert.False(t, s.Configured())
+	assert.NoError(t, s.Send(context.Background(), "+14155550123", "hello"))
+}
@@ -6,8 +6,12 @@ import (
 	"os"
 	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	"investorcenter-shared/tracing"
 )
 
 var (
@@ -37,3 +41,16 @@ func GetSNSClient() *sns.Client {
 	})
 	return snsClient
 }
+
+// snsTraceAttributes returns ctx's trace context as SNS message attributes.
+// SNS passes them to the notification queue inside the message envelope.
+func snsTraceAttributes(ctx context.Context) map[string]snstypes.MessageAttributeValue {
+	attrs := make(map[string]snstypes.MessageAttributeValue)
+	for k, v := range tracing.MessageAttributes(ctx) {
+		attrs[k] = snstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-api/models"
+	"investorcenter-shared/tracing"
+)
+
+// SNS PublishBatch limits: at most 10 entries, and 256 KiB across all of
+// them. The byte budget leaves headroom for the message attributes.
+const (
+	snsMaxBatchEntries = 10
+	snsMaxBatchBytes   = 240 * 1024
+)
+
+// priceUpdateChunkSymbols is how many symbols go in one price update
+// message, keeping each far below the SNS message size limit
+const priceUpdateChunkSymbols = 500
+
+// priceUpdateMessageGroup is the FIFO message group of every price update
+const priceUpdateMessageGroup = "price_updates"
+
+// priceUpdateBatchTimeout bounds sending one batch, including the resend of
+// rejected entries
+const priceUpdateBatchTimeout = 10 * time.Second
+
+// snsPublishAPI is the subset of the SNS client PriceUpdatePublisher uses
+type snsPublishAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+	PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error)
+}
+
+// PriceUpdatePublisher publishes price update messages to an SNS topic.
+//
+// With a batch window, messages are buffered and sent with PublishBatch
+// once 10 are waiting, the next one wouldn't fit in the batch size limit,
+// or the window has passed since the first was buffered. Without one, each
+// message is published on its own.
+//
+// Messages go out in the order they were published: the notification
+// service's throttle treats the last quote it sees for a symbol as the
+// latest. Buffered messages identical to one already waiting are dropped.
+// On FIFO topics (ARN ending in .fifo) every message shares one message
+// group, so subscribers also receive them in order, and carries a
+// content-based deduplication ID.
+type PriceUpdatePublisher struct {
+	client   snsPublishAPI
+	topicARN string
+	window   time.Duration
+	fifo     bool
+
+	mu        sync.Mutex // guards the buffer; not held while sending
+	pending   []snstypes.PublishBatchRequestEntry
+	bytes     int
+	timer     *time.Timer
+	nextBatch uint64 // sequence number of the next batch taken from pending
+
+	sendMu      sync.Mutex // guards sentBatches
+	sendCond    *sync.Cond // signalled when sentBatches advances
+	sentBatches uint64     // batches sent so far; batches go out in sequence order
+}
+
+// priceUpdateBatch is a batch taken from the buffer, numbered in the order
+// it was taken
+type priceUpdateBatch struct {
+	seq     uint64
+	entries []snstypes.PublishBatchRequestEntry
+}
+
+// NewPriceUpdatePublisher creates a publisher for topicARN. A window of
+// zero or less disables batching.
+func NewPriceUpdatePublisher(client snsPublishAPI, topicARN string, window time.Duration) *PriceUpdatePublisher {
+	p := &PriceUpdatePublisher{
+		client:   client,
+		topicARN: topicARN,
+		window:   window,
+		fifo:     strings.HasSuffix(topicARN, ".fifo"),
+	}
+	p.sendCond = sync.NewCond(&p.sendMu)
+	return p
+}
+
+var (
+	priceUpdatePublisher     *PriceUpdatePublisher
+	priceUpdatePublisherOnce sync.Once
+)
+
+// GetPriceUpdatePublisher returns the publisher for SNS_PRICE_UPDATES_ARN,
+// or nil when SNS isn't configured. SNS_PUBLISH_BATCH_WINDOW (e.g. "500ms")
+// turns on batching.
+func GetPriceUpdatePublisher() *PriceUpdatePublisher {
+	priceUpdatePublisherOnce.Do(func() {
+		topicARN := os.Getenv("SNS_PRICE_UPDATES_ARN")
+		if topicARN == "" {
+			return // SNS not configured (local dev)
+		}
+		client := GetSNSClient()
+		if client == nil {
+			return
+		}
+
+		var window time.Duration
+		if raw := os.Getenv("SNS_PUBLISH_BATCH_WINDOW"); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil || d < 0 {
+				log.Printf("⚠️ Invalid SNS_PUBLISH_BATCH_WINDOW %q, publishing unbatched", raw)
+			} else {
+				window = d
+			}
+		}
+		priceUpdatePublisher = NewPriceUpdatePublisher(client, topicARN, window)
+	})
+	return priceUpdatePublisher
+}
+
+// Publish sends msg, or buffers it when batching. ctx's trace context
+// travels with the message. Errors from buffered sends are logged rather
+// than returned.
+func (p *PriceUpdatePublisher) Publish(ctx context.Context, msg models.PriceUpdateMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal price update: %w", err)
+	}
+	body := string(data)
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	attrs := snsTraceAttributes(ctx)
+	attrs[models.PriceUpdateIdempotencyKey] = snstypes.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(digest),
+	}
+
+	if p.window <= 0 {
+		input := &sns.PublishInput{
+			TopicArn:          aws.String(p.topicARN),
+			Message:           aws.String(body),
+			MessageAttributes: attrs,
+		}
+		if p.fifo {
+			input.MessageGroupId = aws.String(priceUpdateMessageGroup)
+			input.MessageDeduplicationId = aws.String(digest)
+		}
+		if _, err := p.client.Publish(ctx, input); err != nil {
+			return fmt.Errorf("failed to publish price update: %w", err)
+		}
+		return nil
+	}
+
+	for _, batch := range p.buffer(body, digest, attrs) {
+		p.send(batch)
+	}
+	return nil
+}
+
+// buffer adds a message to the pending batch and returns the batches that
+// are now ready to send: the previous one if the message didn't fit in it,
+// and the new one once it is full
+func (p *PriceUpdatePublisher) buffer(body, digest string, attrs map[string]snstypes.MessageAttributeValue) []priceUpdateBatch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.pending {
+		if aws.ToString(e.Message) == body {
+			return nil // already waiting to go out
+		}
+	}
+	var ready []priceUpdateBatch
+	if len(p.pending) > 0 && p.bytes+len(body) > snsMaxBatchBytes {
+		ready = append(ready, p.takeLocked())
+	}
+
+	entry := snstypes.PublishBatchRequestEntry{
+		Id:                aws.String(strconv.Itoa(len(p.pending))),
+		Message:           aws.String(body),
+		MessageAttributes: attrs,
+	}
+	if p.fifo {
+		entry.MessageGroupId = aws.String(priceUpdateMessageGroup)
+		entry.MessageDeduplicationId = aws.String(digest)
+	}
+	p.pending = append(p.pending, entry)
+	p.bytes += len(body)
+
+	if len(p.pending) >= snsMaxBatchEntries {
+		ready = append(ready, p.takeLocked())
+	} else if p.timer == nil {
+		p.timer = time.AfterFunc(p.window, p.Flush)
+	}
+	return ready
+}
+
+// Flush sends any buffered messages now, returning once they and every
+// batch taken before them have been sent
+func (p *PriceUpdatePublisher) Flush() {
+	p.mu.Lock()
+	batch := p.takeLocked()
+	p.mu.Unlock()
+	p.send(batch)
+}
+
+// takeLocked empties the buffer into a numbered batch. An empty buffer
+// gives an empty batch without using up a number.
+func (p *PriceUpdatePublisher) takeLocked() priceUpdateBatch {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if len(p.pending) == 0 {
+		return priceUpdateBatch{}
+	}
+	batch := priceUpdateBatch{seq: p.nextBatch, entries: p.pending}
+	p.nextBatch++
+	p.pending, p.bytes = nil, 0
+	return batch
+}
+
+// send publishes batch once every batch taken before it has gone out.
+// Entries SNS rejects through no fault of ours (throttling, internal
+// errors) are resent once.
+func (p *PriceUpdatePublisher) send(batch priceUpdateBatch) {
+	entries := batch.entries
+	if len(entries) == 0 {
+		return
+	}
+
+	p.sendMu.Lock()
+	for p.sentBatches != batch.seq {
+		p.sendCond.Wait()
+	}
+	p.sendMu.Unlock()
+	defer func() {
+		p.sendMu.Lock()
+		p.sentBatches++
+		p.sendCond.Broadcast()
+		p.sendMu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), priceUpdateBatchTimeout)
+	defer cancel()
+	ctx, span := tracing.Tracer().Start(ctx, "price_updates publish_batch",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(entries))))
+	defer span.End()
+
+	for attempt := 1; len(entries) > 0; attempt++ {
+		out, err := p.client.PublishBatch(ctx, &sns.PublishBatchInput{
+			TopicArn:                   aws.String(p.topicARN),
+			PublishBatchRequestEntries: entries,
+		})
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			log.Printf("⚠️ Failed to publish %d price updates to SNS: %v", len(entries), err)
+			return
+		}
+
+		var retry []snstypes.PublishBatchRequestEntry
+		var failures []error
+		for _, f := range out.Failed {
+			failures = append(failures, fmt.Errorf("entry %s: %s: %s", aws.ToString(f.Id), aws.ToString(f.Code), aws.ToString(f.Message)))
+			if !f.SenderFault && attempt == 1 {
+				if e, ok := batchEntryByID(entries, aws.ToString(f.Id)); ok {
+					retry = append(retry, e)
+				}
+			}
+		}
+		if len(failures) > 0 {
+			err := errors.Join(failures...)
+			span.RecordError(err)
+			log.Printf("⚠️ SNS rejected %d of %d price updates (resending %d): %v", len(failures), len(entries), len(retry), err)
+			if len(retry) == 0 {
+				span.SetStatus(codes.Error, "price updates rejected")
+			}
+		}
+		entries = retry
+	}
+}
+
+func batchEntryByID(entries []snstypes.PublishBatchRequestEntry, id string) (snstypes.PublishBatchRequestEntry, bool) {
+	for _, e := range entries {
+		if aws.ToString(e.Id) == id {
+			return e, true
+		}
+	}
+	return snstypes.PublishBatchRequestEntry{}, false
+}
+
+// splitPriceUpdate splits msg into messages of at most size symbols each,
+// taking symbols in sorted order. Every part keeps msg's timestamp and
+// source.
+func splitPriceUpdate(msg models.PriceUpdateMessage, size int) []models.PriceUpdateMessage {
+	if len(msg.Symbols) <= size {
+		return []models.PriceUpdateMessage{msg}
+	}
+
+	symbols := make([]string, 0, len(msg.Symbols))
+	for s := range msg.Symbols {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+
+	parts := make([]models.PriceUpdateMessage, 0, (len(symbols)+size-1)/size)
+	for start := 0; start < len(symbols); start += size {
+		end := min(start+size, len(symbols))
+		part := models.PriceUpdateMessage{
+			Timestamp: msg.Timestamp,
+			Source:    msg.Source,
+			Symbols:   make(map[string]models.SymbolQuote, end-start),
+		}
+		for _, s := range symbols[start:end] {
+			part.Symbols[s] = msg.Symbols[s]
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
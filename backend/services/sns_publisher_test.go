@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+// fakeSNS records Publish and PublishBatch calls. failOnce lists batch entry
+// IDs to reject (as SNS-side failures) the first time they are sent.
+type fakeSNS struct {
+	mu       sync.Mutex
+	single   []*sns.PublishInput
+	batches  []*sns.PublishBatchInput
+	failOnce map[string]bool
+}
+
+func (f *fakeSNS) Publish(ctx context.Context, in *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.single = append(f.single, in)
+	return &sns.PublishOutput{}, nil
+}
+
+func (f *fakeSNS) PublishBatch(ctx context.Context, in *sns.PublishBatchInput, _ ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, in)
+	out := &sns.PublishBatchOutput{}
+	for _, e := range in.PublishBatchRequestEntries {
+		if id := aws.ToString(e.Id); f.failOnce[id] {
+			delete(f.failOnce, id)
+			out.Failed = append(out.Failed, snstypes.BatchResultErrorEntry{
+				Id: e.Id, Code: aws.String("InternalError"), SenderFault: false,
+			})
+			continue
+		}
+		out.Successful = append(out.Successful, snstypes.PublishBatchResultEntry{Id: e.Id})
+	}
+	return out, nil
+}
+
+// published returns the timestamps of the messages PublishBatch delivered,
+// in send order, and the size of each batch
+func (f *fakeSNS) published(t *testing.T) (timestamps []int64, sizes []int) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, b := range f.batches {
+		sizes = append(sizes, len(b.PublishBatchRequestEntries))
+		for _, e := range b.PublishBatchRequestEntries {
+			var msg models.PriceUpdateMessage
+			require.NoError(t, json.Unmarshal([]byte(aws.ToString(e.Message)), &msg))
+			timestamps = append(timestamps, msg.Timestamp)
+		}
+	}
+	return timestamps, sizes
+}
+
+func priceUpdate(ts int64) models.PriceUpdateMessage {
+	return models.PriceUpdateMessage{
+		Timestamp: ts,
+		Source:    "polygon_snapshot",
+		Symbols:   map[string]models.SymbolQuote{"AAPL": {Price: float64(ts)}},
+	}
+}
+
+func TestPriceUpdatePublisher_BatchesAndPublishesAll(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", time.Hour)
+
+	for ts := int64(1); ts <= 25; ts++ {
+		require.NoError(t, p.Publish(context.Background(), priceUpdate(ts)))
+	}
+	p.Flush()
+
+	timestamps, sizes := fake.published(t)
+	assert.Equal(t, []int{10, 10, 5}, sizes, "full batches go out as soon as 10 are buffered")
+	require.Len(t, timestamps, 25)
+	for i, ts := range timestamps {
+		assert.Equal(t, int64(i+1), ts, "messages must go out in publish order")
+	}
+	assert.Empty(t, fake.single)
+}
+
+// slowSNS holds every PublishBatch until release is closed
+type slowSNS struct {
+	fakeSNS
+	started     chan struct{}
+	release     chan struct{}
+	hasDeadline bool
+}
+
+func (f *slowSNS) PublishBatch(ctx context.Context, in *sns.PublishBatchInput, opts ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	_, ok := ctx.Deadline()
+	f.mu.Lock()
+	f.hasDeadline = ok
+	f.mu.Unlock()
+	select {
+	case f.started <- struct{}{}:
+	default:
+	}
+	<-f.release
+	return f.fakeSNS.PublishBatch(ctx, in, opts...)
+}
+
+func TestPriceUpdatePublisher_BuffersWhileSending(t *testing.T) {
+	fake := &slowSNS{started: make(chan struct{}, 1), release: make(chan struct{})}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", time.Hour)
+
+	// The 10th message fills a batch, and its Publish sends it
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for ts := int64(1); ts <= 10; ts++ {
+			assert.NoError(t, p.Publish(context.Background(), priceUpdate(ts)))
+		}
+	}()
+	<-fake.started
+
+	// SNS is slow, but later messages are still buffered without waiting on it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ts := int64(11); ts <= 13; ts++ {
+			assert.NoError(t, p.Publish(context.Background(), priceUpdate(ts)))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked behind a batch being sent")
+	}
+
+	close(fake.release)
+	<-sent
+	p.Flush()
+
+	timestamps, sizes := fake.published(t)
+	assert.Equal(t, []int{10, 3}, sizes)
+	for i, ts := range timestamps {
+		assert.Equal(t, int64(i+1), ts, "messages must go out in publish order")
+	}
+	assert.True(t, fake.hasDeadline, "batches are sent with a bounded context")
+}
+
+func TestPriceUpdatePublisher_FlushesAfterWindow(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", 10*time.Millisecond)
+
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(2)))
+
+	assert.Eventually(t, func() bool {
+		_, sizes := fake.published(t)
+		return len(sizes) == 1 && sizes[0] == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestPriceUpdatePublisher_RespectsBatchByteLimit(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", time.Hour)
+
+	// Three ~100 KiB messages can't share one 256 KiB batch
+	big := strings.Repeat("x", 100*1024)
+	for ts := int64(1); ts <= 3; ts++ {
+		msg := priceUpdate(ts)
+		msg.Source = big
+		require.NoError(t, p.Publish(context.Background(), msg))
+	}
+	p.Flush()
+
+	timestamps, sizes := fake.published(t)
+	assert.Equal(t, []int{2, 1}, sizes)
+	assert.Equal(t, []int64{1, 2, 3}, timestamps)
+}
+
+func TestPriceUpdatePublisher_DropsDuplicateBufferedMessages(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", time.Hour)
+
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(2)))
+	p.Flush()
+
+	timestamps, _ := fake.published(t)
+	assert.Equal(t, []int64{1, 2}, timestamps)
+}
+
+func TestPriceUpdatePublisher_ResendsEntriesSNSFailed(t *testing.T) {
+	fake := &fakeSNS{failOnce: map[string]bool{"1": true}}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", time.Hour)
+
+	for ts := int64(1); ts <= 3; ts++ {
+		require.NoError(t, p.Publish(context.Background(), priceUpdate(ts)))
+	}
+	p.Flush()
+
+	require.Len(t, fake.batches, 2)
+	resent := fake.batches[1].PublishBatchRequestEntries
+	require.Len(t, resent, 1)
+	assert.Equal(t, "1", aws.ToString(resent[0].Id))
+}
+
+func TestPriceUpdatePublisher_FIFOTopic(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates.fifo", time.Hour)
+
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(2)))
+	p.Flush()
+
+	require.Len(t, fake.batches, 1)
+	entries := fake.batches[0].PublishBatchRequestEntries
+	for _, e := range entries {
+		assert.Equal(t, priceUpdateMessageGroup, aws.ToString(e.MessageGroupId))
+		assert.Len(t, aws.ToString(e.MessageDeduplicationId), 64)
+	}
+	assert.NotEqual(t, aws.ToString(entries[0].MessageDeduplicationId), aws.ToString(entries[1].MessageDeduplicationId))
+}
+
+func TestPriceUpdatePublisher_Unbatched(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", 0)
+
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(2)))
+
+	assert.Len(t, fake.single, 2)
+	assert.Empty(t, fake.batches)
+}
+
+func TestPriceUpdatePublisher_IdempotencyKey(t *testing.T) {
+	fake := &fakeSNS{}
+	p := NewPriceUpdatePublisher(fake, "arn:aws:sns:us-east-1:1:price-updates", 0)
+
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(1)))
+	require.NoError(t, p.Publish(context.Background(), priceUpdate(2)))
+
+	require.Len(t, fake.single, 3)
+	key := func(i int) string {
+		return aws.ToString(fake.single[i].MessageAttributes[models.PriceUpdateIdempotencyKey].StringValue)
+	}
+	assert.Len(t, key(0), 64)
+	assert.Equal(t, key(0), key(1), "identical updates share a key")
+	assert.NotEqual(t, key(0), key(2))
+}
+
+func TestSplitPriceUpdate(t *testing.T) {
+	msg := models.PriceUpdateMessage{Timestamp: 42, Source: "polygon_snapshot", Symbols: map[string]models.SymbolQuote{}}
+	for i := 0; i < 1201; i++ {
+		msg.Symbols[fmt.Sprintf("S%04d", i)] = models.SymbolQuote{Price: float64(i)}
+	}
+
+	parts := splitPriceUpdate(msg, 500)
+	require.Len(t, parts, 3)
+	assert.Len(t, parts[0].Symbols, 500)
+	assert.Len(t, parts[2].Symbols, 201)
+
+	seen := 0
+	for _, part := range parts {
+		assert.Equal(t, int64(42), part.Timestamp)
+		assert.Equal(t, "polygon_snapshot", part.Source)
+		seen += len(part.Symbols)
+	}
+	assert.Equal(t, 1201, seen, "every symbol lands in exactly one part")
+	assert.Contains(t, parts[0].Symbols, "S0000")
+	assert.Contains(t, parts[2].Symbols, "S1200")
+
+	small := splitPriceUpdate(priceUpdate(1), 500)
+	assert.Equal(t, []models.PriceUpdateMessage{priceUpdate(1)}, small)
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"investorcenter-api/database"
+	"investorcenter-api/models"
+)
+
+// PolygonSplitsResponse is the Polygon /v3/reference/splits response
+type PolygonSplitsResponse struct {
+	Status  string `json:"status"`
+	Results []struct {
+		Ticker        string  `json:"ticker"`
+		ExecutionDate string  `json:"execution_date"`
+		SplitFrom     float64 `json:"split_from"`
+		SplitTo       float64 `json:"split_to"`
+	} `json:"results"`
+}
+
+// FMPSplitRecord is a single record from the FMP splits endpoint.
+// Numerator:Denominator is new:old shares (4:1 for a 4-for-1 split).
+type FMPSplitRecord struct {
+	Symbol      string  `json:"symbol"`
+	Date        string  `json:"date"`
+	Numerator   float64 `json:"numerator"`
+	Denominator float64 `json:"denominator"`
+}
+
+// GetStockSplits fetches a ticker's split history from Polygon
+func (p *PolygonClient) GetStockSplits(symbol string) ([]models.StockSplit, error) {
+	params := url.Values{}
+	params.Set("ticker", strings.ToUpper(symbol))
+	params.Set("limit", "1000")
+	params.Set("apikey", p.APIKey)
+	reqURL := fmt.Sprintf("%s/v3/reference/splits?%s", PolygonBaseURL, params.Encode())
+
+	resp, err := p.Get(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch splits: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("splits API request failed with status: %d", resp.StatusCode)
+	}
+
+	var splitsResp PolygonSplitsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&splitsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode splits response: %w", err)
+	}
+	if splitsResp.Status != "OK" {
+		return nil, fmt.Errorf("API error: %s", splitsResp.Status)
+	}
+
+	splits := make([]models.StockSplit, 0, len(splitsResp.Results))
+	for _, r := range splitsResp.Results {
+		date, err := time.Parse("2006-01-02", r.ExecutionDate)
+		if err != nil || r.SplitFrom <= 0 || r.SplitTo <= 0 {
+			continue
+		}
+		splits = append(splits, models.StockSplit{
+			Ticker:        strings.ToUpper(symbol),
+			ExecutionDate: date,
+			SplitFrom:     r.SplitFrom,
+			SplitTo:       r.SplitTo,
+			Source:        "polygon",
+		})
+	}
+	return splits, nil
+}
+
+// GetStockSplits fetches a ticker's split history from FMP
+func (c *FMPClient) GetStockSplits(symbol string) ([]models.StockSplit, error) {
+	if c.APIKey == "" {
+		return nil, fmt.Errorf("FMP API key not configured")
+	}
+
+	params := url.Values{}
+	params.Set("symbol", strings.ToUpper(symbol))
+	params.Set("apikey", c.APIKey)
+	reqURL := fmt.Sprintf("%s/splits?%s", FMPBaseURL, params.Encode())
+
+	resp, err := c.doRequest(reqURL)
+	if err != nil {
+		return nil, fmt.Errorf("FMP splits request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FMP splits returned status %d", resp.StatusCode)
+	}
+
+	var records []FMPSplitRecord
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("failed to decode FMP splits response: %w", err)
+	}
+
+	splits := make([]models.StockSplit, 0, len(records))
+	for _, r := range records {
+		date, err := time.Parse("2006-01-02", r.Date)
+		if err != nil || r.Numerator <= 0 || r.Denominator <= 0 {
+			continue
+		}
+		splits = append(splits, models.StockSplit{
+			Ticker:        strings.ToUpper(symbol),
+			ExecutionDate: date,
+			SplitFrom:     r.Denominator,
+			SplitTo:       r.Numerator,
+			Source:        "fmp",
+		})
+	}
+	return splits, nil
+}
+
+// SplitFetcher fetches split history from an upstream provider
+type SplitFetcher interface {
+	GetStockSplits(symbol string) ([]models.StockSplit, error)
+}
+
+// SplitService serves split history from stock_splits, ingesting it from
+// Polygon (falling back to FMP) when a ticker's last sync is older than its
+// TTL
+type SplitService struct {
+	sources []SplitFetcher
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// defaultSplitsSyncTTL is how long fetched split history is trusted before
+// it is refetched to pick up new splits
+const defaultSplitsSyncTTL = 24 * time.Hour
+
+// NewSplitService creates a split service backed by Polygon then FMP
+func NewSplitService() *SplitService {
+	return NewSplitServiceWith(NewPolygonClient(), NewFMPClient())
+}
+
+// NewSplitServiceWith creates a split service that tries sources in order.
+// The sync TTL comes from SPLITS_SYNC_TTL_HOURS.
+func NewSplitServiceWith(sources ...SplitFetcher) *SplitService {
+	return &SplitService{
+		sources: sources,
+		ttl:     time.Duration(envInt("SPLITS_SYNC_TTL_HOURS", int(defaultSplitsSyncTTL/time.Hour))) * time.Hour,
+		now:     time.Now,
+	}
+}
+
+// GetSplits returns a ticker's splits, oldest first. They are refetched
+// upstream first if never synced or synced longer than the TTL ago. If that
+// fails, stored splits are still served; the error is returned only when
+// there is nothing stored to fall back on.
+func (s *SplitService) GetSplits(ticker string) ([]models.StockSplit, error) {
+	syncedAt, err := database.GetStockSplitsSyncedAt(ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	var syncErr error
+	if syncedAt == nil || s.now().Sub(*syncedAt) > s.ttl {
+		if syncErr = s.SyncSplits(ticker); syncErr != nil {
+			log.Printf("Serving stored splits for %s: %v", ticker, syncErr)
+		}
+	}
+
+	splits, err := database.GetStockSplits(ticker)
+	if err != nil {
+		return nil, err
+	}
+	if syncErr != nil && syncedAt == nil && len(splits) == 0 {
+		return nil, syncErr
+	}
+	return splits, nil
+}
+
+// SyncSplits fetches a ticker's splits from the first source that answers,
+// upserts them into stock_splits and records the sync time
+func (s *SplitService) SyncSplits(ticker string) error {
+	var lastErr error
+	for _, source := range s.sources {
+		splits, err := source.GetStockSplits(ticker)
+		if err != nil {
+			log.Printf("Split fetch failed for %s: %v", ticker, err)
+			lastErr = err
+			continue
+		}
+		return database.SyncStockSplits(ticker, splits)
+	}
+	if lastErr != nil {
+		return fmt.Errorf("failed to fetch splits for %s: %w", ticker, lastErr)
+	}
+	return nil
+}
+
+// SplitDetail is a split with the derived fields the API returns
+type SplitDetail struct {
+	models.StockSplit
+	Ratio     float64 `json:"ratio"`
+	IsReverse bool    `json:"is_reverse"`
+	// AdjustmentFactor is what a price from before this split is divided by
+	// to express it in today's shares (this split and all later ones)
+	AdjustmentFactor float64 `json:"adjustment_factor"`
+}
+
+// DescribeSplits adds ratio, direction and cumulative adjustment factor to
+// each split. splits must be sorted oldest first.
+func DescribeSplits(splits []models.StockSplit) []SplitDetail {
+	details := make([]SplitDetail, len(splits))
+	factor := 1.0
+	for i := len(splits) - 1; i >= 0; i-- {
+		factor *= splits[i].Ratio()
+		details[i] = SplitDetail{
+			StockSplit:       splits[i],
+			Ratio:            splits[i].Ratio(),
+			IsReverse:        splits[i].IsReverse(),
+			AdjustmentFactor: factor,
+		}
+	}
+	return details
+}
+
+// SplitAdjustmentFactor returns what a price observed on date is divided by
+// to be comparable with today's price: the product of the ratios of every
+// split executed after date. Bars on the execution date are already
+// post-split. Reverse splits have ratios below 1 and so raise old prices.
+func SplitAdjustmentFactor(splits []models.StockSplit, date time.Time) float64 {
+	day := date.Format("2006-01-02")
+	factor := 1.0
+	for _, s := range splits {
+		if s.ExecutionDate.Format("2006-01-02") > day {
+			factor *= s.Ratio()
+		}
+	}
+	return factor
+}
+
+// AdjustForSplits returns a copy of points with prices divided and volume
+// multiplied by each bar's split adjustment factor
+func AdjustForSplits(points []models.ChartDataPoint, splits []models.StockSplit) []models.ChartDataPoint {
+	adjusted := make([]models.ChartDataPoint, len(points))
+	for i, p := range points {
+		factor := SplitAdjustmentFactor(splits, p.Timestamp)
+		if factor == 1 {
+			adjusted[i] = p
+			continue
+		}
+		d := decimal.NewFromFloat(factor)
+		adjusted[i] = models.ChartDataPoint{
+			Timestamp: p.Timestamp,
+			Open:      p.Open.Div(d),
+			High:      p.High.Div(d),
+			Low:       p.Low.Div(d),
+			Close:     p.Close.Div(d),
+			Volume:    int64(float64(p.Volume) * factor),
+		}
+	}
+	return adjusted
+}
+
+// AdjustUnreflectedSplits split-adjusts stored daily bars. stock_prices
+// mixes bars backfilled already adjusted (as of the backfill) with bars
+// stored as traded, so a split may or may not be reflected in a series
+// already. A split is applied to the bars before it only where the series
+// jumps across its execution date by roughly the split ratio; the last bar
+// before and the first bar on or after that date decide. points must be
+// sorted oldest first.
+func AdjustUnreflectedSplits(points []models.ChartDataPoint, splits []models.StockSplit) []models.ChartDataPoint {
+	var pending []models.StockSplit
+	for _, split := range splits {
+		if splitVisible(points, split) {
+			pending = append(pending, split)
+		}
+	}
+	if len(pending) == 0 {
+		return points
+	}
+	return AdjustForSplits(points, pending)
+}
+
+// splitVisible reports whether the close-to-close move across the split's
+// execution date is closer to the split ratio than to no change
+func splitVisible(points []models.ChartDataPoint, split models.StockSplit) bool {
+	ratio := split.Ratio()
+	if ratio == 1 {
+		return false
+	}
+	day := split.ExecutionDate.Format("2006-01-02")
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Timestamp.Format("2006-01-02") >= day
+	})
+	if i == 0 || i == len(points) {
+		return false
+	}
+	before, _ := points[i-1].Close.Float64()
+	after, _ := points[i].Close.Float64()
+	if before <= 0 || after <= 0 {
+		return false
+	}
+	move := math.Log(before / after)
+	return math.Abs(move-math.Log(ratio)) < math.Abs(move)
+}
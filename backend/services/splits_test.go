@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"investorcenter-api/models"
+)
+
+func splitOn(date string, from, to float64) models.StockSplit {
+	d, _ := time.Parse("2006-01-02", date)
+	return models.StockSplit{Ticker: "TEST", ExecutionDate: d, SplitFrom: from, SplitTo: to}
+}
+
+func TestSplitAdjustmentFactor(t *testing.T) {
+	splits := []models.StockSplit{
+		splitOn("2014-06-09", 1, 7),
+		splitOn("2020-08-31", 1, 4),
+	}
+
+	day := func(s string) time.Time { d, _ := time.Parse("2006-01-02", s); return d }
+
+	assert.Equal(t, 28.0, SplitAdjustmentFactor(splits, day("2010-01-04")))
+	assert.Equal(t, 4.0, SplitAdjustmentFactor(splits, day("2014-06-09")), "execution date is already post-split")
+	assert.Equal(t, 4.0, SplitAdjustmentFactor(splits, day("2020-08-28")))
+	assert.Equal(t, 1.0, SplitAdjustmentFactor(splits, day("2020-08-31")))
+	assert.Equal(t, 1.0, SplitAdjustmentFactor(nil, day("2020-08-28")))
+}
+
+func TestSplitAdjustmentFactor_ReverseSplit(t *testing.T) {
+	// 1-for-8 reverse split: $10 before is $80 in today's shares
+	splits := []models.StockSplit{splitOn("2021-08-02", 8, 1)}
+	d, _ := time.Parse("2006-01-02", "2021-07-30")
+
+	factor := SplitAdjustmentFactor(splits, d)
+	assert.InDelta(t, 0.125, factor, 1e-9)
+	assert.True(t, splits[0].IsReverse())
+}
+
+func TestDescribeSplits(t *testing.T) {
+	details := DescribeSplits([]models.StockSplit{
+		splitOn("2010-01-04", 1, 2),
+		splitOn("2015-01-05", 10, 1),
+		splitOn("2020-01-06", 1, 3),
+	})
+
+	require.Len(t, details, 3)
+	assert.Equal(t, 2.0, details[0].Ratio)
+	assert.False(t, details[0].IsReverse)
+	assert.InDelta(t, 0.6, details[0].AdjustmentFactor, 1e-9) // 2 * 0.1 * 3
+	assert.True(t, details[1].IsReverse)
+	assert.InDelta(t, 0.3, details[1].AdjustmentFactor, 1e-9)
+	assert.Equal(t, 3.0, details[2].AdjustmentFactor)
+}
+
+func TestAdjustForSplits(t *testing.T) {
+	splits := []models.StockSplit{splitOn("2020-08-31", 1, 4)}
+	before, _ := time.Parse("2006-01-02", "2020-08-28")
+	after, _ := time.Parse("2006-01-02", "2020-08-31")
+	points := []models.ChartDataPoint{
+		{Timestamp: before, Open: decimal.NewFromInt(500), High: decimal.NewFromInt(508), Low: decimal.NewFromInt(496), Close: decimal.NewFromInt(500), Volume: 1000},
+		{Timestamp: after, Open: decimal.NewFromInt(127), High: decimal.NewFromInt(131), Low: decimal.NewFromInt(126), Close: decimal.NewFromInt(129), Volume: 4500},
+	}
+
+	adjusted := AdjustForSplits(points, splits)
+
+	assert.True(t, adjusted[0].Close.Equal(decimal.NewFromInt(125)))
+	assert.True(t, adjusted[0].High.Equal(decimal.NewFromInt(127)))
+	assert.Equal(t, int64(4000), adjusted[0].Volume)
+	assert.Equal(t, points[1], adjusted[1])
+	assert.True(t, points[0].Close.Equal(decimal.NewFromInt(500)), "input is not modified")
+}
+
+func TestAdjustUnreflectedSplits(t *testing.T) {
+	day := func(s string) time.Time { d, _ := time.Parse("2006-01-02", s); return d }
+	bar := func(date string, close int64) models.ChartDataPoint {
+		return models.ChartDataPoint{Timestamp: day(date), Close: decimal.NewFromInt(close), Volume: 100}
+	}
+	splits := []models.StockSplit{splitOn("2014-06-09", 1, 7), splitOn("2020-08-31", 1, 4)}
+
+	// Backfilled after 2014 so only the 2020 split shows as a jump
+	points := []models.ChartDataPoint{
+		bar("2014-06-06", 92), bar("2014-06-09", 93),
+		bar("2020-08-28", 500), bar("2020-08-31", 129),
+	}
+	adjusted := AdjustUnreflectedSplits(points, splits)
+	assert.True(t, adjusted[0].Close.Equal(decimal.NewFromInt(23)), "got %s", adjusted[0].Close)
+	assert.True(t, adjusted[2].Close.Equal(decimal.NewFromInt(125)))
+	assert.Equal(t, points[3], adjusted[3])
+
+	// A fully adjusted series is left alone
+	adjustedAlready := []models.ChartDataPoint{bar("2020-08-28", 125), bar("2020-08-31", 129)}
+	assert.Equal(t, adjustedAlready, AdjustUnreflectedSplits(adjustedAlready, splits))
+
+	// Reverse split: price jumps up
+	reverse := []models.StockSplit{splitOn("2021-08-02", 8, 1)}
+	ge := []models.ChartDataPoint{bar("2021-07-30", 13), bar("2021-08-02", 104)}
+	assert.True(t, AdjustUnreflectedSplits(ge, reverse)[0].Close.Equal(decimal.NewFromInt(104)))
+
+	// A split outside the series can't be judged and isn't applied
+	assert.Equal(t, points[2:], AdjustUnreflectedSplits(points[2:], splits[:1]))
+}
+
+func TestPolygon_HTTP_GetStockSplits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/reference/splits", r.URL.Path)
+		assert.Equal(t, "AAPL", r.URL.Query().Get("ticker"))
+		w.Write([]byte(`{"status":"OK","results":[
+			{"ticker":"AAPL","execution_date":"2020-08-31","split_from":1,"split_to":4},
+			{"ticker":"AAPL","execution_date":"bad","split_from":1,"split_to":2}
+		]}`))
+	}))
+	defer server.Close()
+	defer savePolygonBaseURL()()
+	PolygonBaseURL = server.URL
+
+	splits, err := newPolygonTestClient().GetStockSplits("aapl")
+	require.NoError(t, err)
+	require.Len(t, splits, 1)
+	assert.Equal(t, "AAPL", splits[0].Ticker)
+	assert.Equal(t, 4.0, splits[0].Ratio())
+	assert.Equal(t, "polygon", splits[0].Source)
+}
+
+func TestPolygon_HTTP_RetriesTransientErrors(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"status":"OK","results":[{"ticker":"AAPL","execution_date":"2020-08-31","split_from":1,"split_to":4}]}`))
+	}))
+	defer server.Close()
+	defer savePolygonBaseURL()()
+	PolygonBaseURL = server.URL
+
+	var delays []time.Duration
+	origSleep := polygonSleep
+	polygonSleep = func(d time.Duration) { delays = append(delays, d) }
+	defer func() { polygonSleep = origSleep }()
+
+	client := newPolygonTestClient()
+	client.MaxRetries = 2
+	splits, err := client.GetStockSplits("AAPL")
+	require.NoError(t, err)
+	assert.Len(t, splits, 1)
+	assert.Equal(t, 2, calls)
+	require.Len(t, delays, 1)
+	assert.LessOrEqual(t, delays[0], PolygonRetryBaseDelay)
+	assert.GreaterOrEqual(t, delays[0], PolygonRetryBaseDelay/2)
+}
+
+func TestFMP_HTTP_GetStockSplits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/splits", r.URL.Path)
+		json.NewEncoder(w).Encode([]FMPSplitRecord{
+			{Symbol: "GE", Date: "2021-08-02", Numerator: 1, Denominator: 8},
+		})
+	}))
+	defer server.Close()
+	defer saveFMPBaseURL()()
+	FMPBaseURL = server.URL
+
+	splits, err := newFMPTestClient(server.URL).GetStockSplits("GE")
+	require.NoError(t, err)
+	require.Len(t, splits, 1)
+	assert.Equal(t, 8.0, splits[0].SplitFrom)
+	assert.Equal(t, 1.0, splits[0].SplitTo)
+	assert.True(t, splits[0].IsReverse())
+	assert.Equal(t, "fmp", splits[0].Source)
+}
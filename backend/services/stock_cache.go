@@ -2,16 +2,15 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"log"
-	"os"
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"investorcenter-api/models"
+	"investorcenter-shared/tracing"
 )
 
 // StockCache manages real-time stock price cache
@@ -174,19 +173,15 @@ func (sc *StockCache) updateCache() {
 }
 
 // publishPriceUpdate sends the current cache snapshot to SNS for the alert
-// evaluation Lambda. Runs in a goroutine to avoid blocking the cache updater.
+// evaluation Lambda, in messages of up to priceUpdateChunkSymbols symbols.
+// Runs in a goroutine to avoid blocking the cache updater.
 // Silently skips if SNS is not configured (local dev without AWS credentials).
 func (sc *StockCache) publishPriceUpdate() {
-	topicARN := os.Getenv("SNS_PRICE_UPDATES_ARN")
-	if topicARN == "" {
+	publisher := GetPriceUpdatePublisher()
+	if publisher == nil {
 		return // SNS not configured — skip silently (local dev)
 	}
 
-	client := GetSNSClient()
-	if client == nil {
-		return
-	}
-
 	sc.mutex.RLock()
 	msg := models.PriceUpdateMessage{
 		Timestamp: time.Now().Unix(),
@@ -204,18 +199,18 @@ func (sc *StockCache) publishPriceUpdate() {
 	}
 	sc.mutex.RUnlock()
 
-	data, err := json.Marshal(msg)
-	if err != nil {
-		log.Printf("⚠️ Failed to marshal price update for SNS: %v", err)
-		return
-	}
-
-	_, err = client.Publish(context.Background(), &sns.PublishInput{
-		TopicArn: aws.String(topicARN),
-		Message:  aws.String(string(data)),
-	})
-	if err != nil {
-		log.Printf("⚠️ Failed to publish price update to SNS: %v", err)
+	// The notification service continues this trace from the message
+	// attributes when it processes the update.
+	ctx, span := tracing.Tracer().Start(context.Background(), "price_updates publish",
+		trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
+	for _, part := range splitPriceUpdate(msg, priceUpdateChunkSymbols) {
+		if err := publisher.Publish(ctx, part); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			log.Printf("⚠️ Failed to publish price update to SNS: %v", err)
+		}
 	}
 }
 
@@ -27,8 +27,15 @@ func (s *StockService) GetStockBySymbol(ctx context.Context, symbol string) (*mo
 	return database.GetStockBySymbol(symbol)
 }
 
-// SearchStocks searches for stocks by symbol or name
-func (s *StockService) SearchStocks(ctx context.Context, query string, limit int) ([]models.Stock, error) {
+// SearchStocks searches for stocks by symbol or name.
+// Inactive (delisted) tickers are skipped unless includeInactive is set.
+func (s *StockService) SearchStocks(ctx context.Context, query string, limit int, includeInactive bool) ([]models.Stock, error) {
 	// Use the database layer function
-	return database.SearchStocks(query, limit)
+	return database.SearchStocks(query, limit, includeInactive)
+}
+
+// SearchSecurities searches tickers by symbol or name as narrowed by opts,
+// with the reason each one matched
+func (s *StockService) SearchSecurities(ctx context.Context, query string, opts database.SearchOptions) ([]models.SecuritySearchResult, error) {
+	return database.SearchSecurities(query, opts)
 }
@@ -144,6 +144,8 @@ func (s *SubscriptionService) CheckLimit(userID string, limitType string, curren
 		maxLimit = limits.MaxAlertRules
 	case "heatmap_configs":
 		maxLimit = limits.MaxHeatmapConfigs
+	case "saved_screens":
+		maxLimit = limits.MaxSavedScreens
 	default:
 		return false, errors.New("invalid limit type")
 	}
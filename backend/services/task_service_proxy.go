@@ -6,9 +6,10 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	apihttp "investorcenter-api/httputil"
 )
 
 var taskServiceProxy *httputil.ReverseProxy
@@ -25,25 +26,17 @@ func init() {
 		return
 	}
 
-	taskServiceProxy = httputil.NewSingleHostReverseProxy(target)
-
-	originalDirector := taskServiceProxy.Director
-	taskServiceProxy.Director = func(req *http.Request) {
-		originalDirector(req)
-		// Strip /api/v1 prefix — task service routes start at /tasks or /task-types
-		req.URL.Path = strings.TrimPrefix(req.URL.Path, "/api/v1")
-		req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, "/api/v1")
-	}
+	// Task service routes start at /tasks or /task-types
+	taskServiceProxy = newServiceProxy(target)
 }
 
 // TaskServiceProxy returns a Gin handler that proxies requests to the task service.
 func TaskServiceProxy() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if taskServiceProxy == nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Task service not configured"})
-			c.Abort()
+			apihttp.RespondError(c, http.StatusServiceUnavailable, apihttp.CodeServiceUnavailable, "Task service not configured")
 			return
 		}
-		taskServiceProxy.ServeHTTP(c.Writer, c.Request)
+		serveProxy(c, taskServiceProxy)
 	}
 }
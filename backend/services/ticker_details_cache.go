@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"sync"
+)
+
+// TickerDetailsFetcher fetches ticker details. Implemented by PolygonClient.
+type TickerDetailsFetcher interface {
+	GetTickerDetails(symbol string) (*TickerDetailsResponse, error)
+}
+
+// TickerDetailsCache remembers ticker details responses, failures included,
+// so each symbol is fetched at most once. Meant for one batch run: entries
+// never expire.
+type TickerDetailsCache struct {
+	fetcher TickerDetailsFetcher
+
+	mu      sync.Mutex
+	entries map[string]tickerDetailsEntry
+	calls   int
+}
+
+type tickerDetailsEntry struct {
+	details *TickerDetailsResponse
+	err     error
+}
+
+// NewTickerDetailsCache creates a cache in front of fetcher
+func NewTickerDetailsCache(fetcher TickerDetailsFetcher) *TickerDetailsCache {
+	return &TickerDetailsCache{fetcher: fetcher, entries: make(map[string]tickerDetailsEntry)}
+}
+
+// GetTickerDetails returns the cached details for symbol, fetching them on
+// first use
+func (c *TickerDetailsCache) GetTickerDetails(symbol string) (*TickerDetailsResponse, error) {
+	symbol = strings.ToUpper(symbol)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[symbol]; ok {
+		return e.details, e.err
+	}
+	details, err := c.fetcher.GetTickerDetails(symbol)
+	c.calls++
+	c.entries[symbol] = tickerDetailsEntry{details: details, err: err}
+	return details, err
+}
+
+// GetTickerLogoURL returns symbol's logo URL from its cached details
+func (c *TickerDetailsCache) GetTickerLogoURL(symbol string) (string, error) {
+	details, err := c.GetTickerDetails(symbol)
+	if err != nil {
+		return "", err
+	}
+	return details.LogoURL(), nil
+}
+
+// Fetches returns how many details requests went upstream
+func (c *TickerDetailsCache) Fetches() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
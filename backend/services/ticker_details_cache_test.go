@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDetailsFetcher struct {
+	calls map[string]int
+	logos map[string]TickerBranding
+}
+
+func (f *fakeDetailsFetcher) GetTickerDetails(symbol string) (*TickerDetailsResponse, error) {
+	f.calls[symbol]++
+	branding, ok := f.logos[symbol]
+	if !ok {
+		return nil, errors.New("API error: NOT_FOUND")
+	}
+	d := &TickerDetailsResponse{Status: "OK"}
+	d.Results.Ticker = symbol
+	d.Results.Branding = branding
+	return d, nil
+}
+
+func TestTickerDetailsCache_FetchesEachSymbolOnce(t *testing.T) {
+	f := &fakeDetailsFetcher{calls: map[string]int{}, logos: map[string]TickerBranding{
+		"AAPL": {LogoURL: "https://api.polygon.io/v1/reference/company-branding/aapl/logo.svg"},
+		"SPY":  {IconURL: "https://api.polygon.io/v1/reference/company-branding/spy/icon.png"},
+	}}
+	cache := NewTickerDetailsCache(f)
+
+	for i := 0; i < 3; i++ {
+		url, err := cache.GetTickerLogoURL("aapl")
+		require.NoError(t, err)
+		assert.Equal(t, "https://api.polygon.io/v1/reference/company-branding/aapl/logo.svg", url)
+	}
+	url, err := cache.GetTickerLogoURL("SPY")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.polygon.io/v1/reference/company-branding/spy/icon.png", url, "falls back to the icon")
+
+	// Failures are cached too
+	_, err = cache.GetTickerLogoURL("ZZZZ")
+	assert.Error(t, err)
+	_, err = cache.GetTickerLogoURL("ZZZZ")
+	assert.Error(t, err)
+
+	assert.Equal(t, map[string]int{"AAPL": 1, "SPY": 1, "ZZZZ": 1}, f.calls)
+	assert.Equal(t, 3, cache.Fetches())
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Upstream sources tracked by the quota tracker
+const (
+	QuotaSourceFMP       = "fmp"
+	QuotaSourcePolygon   = "polygon"
+	QuotaSourceCoinGecko = "coingecko"
+)
+
+// Quota tracker defaults, overridable with UPSTREAM_QUOTA_THROTTLE_AT and
+// UPSTREAM_QUOTA_THROTTLE_DELAY
+const (
+	DefaultQuotaThrottleAt    = 0.9
+	DefaultQuotaThrottleDelay = 2 * time.Second
+	defaultQuotaFlushEvery    = 50
+)
+
+// ErrQuotaExhausted is returned by QuotaTracker.Wait once a source has used
+// its whole budget for the period. Batch jobs should stop calling it until
+// the next period.
+var ErrQuotaExhausted = errors.New("upstream quota exhausted")
+
+// QuotaStore persists per-source call counts for a period
+type QuotaStore interface {
+	LoadUsage(period time.Time) (map[string]int64, error)
+	AddUsage(source string, period time.Time, calls int64) error
+}
+
+// QuotaConfig configures a QuotaTracker
+type QuotaConfig struct {
+	// Limits is each source's calls per calendar month. Sources without a
+	// limit (or a limit of 0) are counted but never throttled.
+	Limits map[string]int64
+	// ThrottleAt is the fraction of the limit after which Wait slows
+	// callers down by ThrottleDelay per call. Zero uses
+	// DefaultQuotaThrottleAt.
+	ThrottleAt    float64
+	ThrottleDelay time.Duration
+	// FlushEvery persists a source's counter after this many unsaved calls
+	FlushEvery int
+}
+
+// QuotaStatus is one source's usage in the current period, as reported by
+// the health endpoint
+type QuotaStatus struct {
+	Source      string    `json:"source"`
+	PeriodStart time.Time `json:"period_start"`
+	Used        int64     `json:"used"`
+	Limit       int64     `json:"limit,omitempty"`
+	Remaining   *int64    `json:"remaining,omitempty"`
+	State       string    `json:"state"` // ok, throttled or exhausted
+}
+
+// Quota states
+const (
+	QuotaStateOK        = "ok"
+	QuotaStateThrottled = "throttled"
+	QuotaStateExhausted = "exhausted"
+)
+
+// QuotaTracker counts upstream API calls per source per calendar month
+// (UTC). The upstream clients Record every request they send, retries
+// included; batch jobs call Wait before each call to slow down near the
+// limit and stop once it's reached. Counts are periodically written to a
+// QuotaStore so they survive restarts.
+type QuotaTracker struct {
+	config QuotaConfig
+
+	mu      sync.Mutex
+	store   QuotaStore
+	period  time.Time
+	used    map[string]int64
+	pending map[string]int64
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewQuotaTracker creates a tracker for config. store may be nil to keep
+// counts in memory only.
+func NewQuotaTracker(config QuotaConfig, store QuotaStore) *QuotaTracker {
+	if config.ThrottleAt <= 0 {
+		config.ThrottleAt = DefaultQuotaThrottleAt
+	}
+	if config.FlushEvery <= 0 {
+		config.FlushEvery = defaultQuotaFlushEvery
+	}
+	return &QuotaTracker{
+		config:  config,
+		store:   store,
+		used:    make(map[string]int64),
+		pending: make(map[string]int64),
+		now:     time.Now,
+		sleep:   time.Sleep,
+	}
+}
+
+// QuotaConfigFromEnv reads FMP_MONTHLY_QUOTA, POLYGON_MONTHLY_QUOTA,
+// COINGECKO_MONTHLY_QUOTA, UPSTREAM_QUOTA_THROTTLE_AT (a fraction, default
+// 0.9) and UPSTREAM_QUOTA_THROTTLE_DELAY (a duration, default 2s). Invalid
+// values are logged and ignored.
+func QuotaConfigFromEnv() QuotaConfig {
+	config := QuotaConfig{
+		Limits:        make(map[string]int64),
+		ThrottleAt:    DefaultQuotaThrottleAt,
+		ThrottleDelay: DefaultQuotaThrottleDelay,
+	}
+	for source, key := range map[string]string{
+		QuotaSourceFMP:       "FMP_MONTHLY_QUOTA",
+		QuotaSourcePolygon:   "POLYGON_MONTHLY_QUOTA",
+		QuotaSourceCoinGecko: "COINGECKO_MONTHLY_QUOTA",
+	} {
+		raw := os.Getenv(key)
+		if raw == "" {
+			continue
+		}
+		limit, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || limit < 0 {
+			log.Printf("⚠️ Invalid %s %q, not enforcing a quota", key, raw)
+			continue
+		}
+		config.Limits[source] = limit
+	}
+	if raw := os.Getenv("UPSTREAM_QUOTA_THROTTLE_AT"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0 && v <= 1 {
+			config.ThrottleAt = v
+		} else {
+			log.Printf("⚠️ Invalid UPSTREAM_QUOTA_THROTTLE_AT %q, using %.2f", raw, DefaultQuotaThrottleAt)
+		}
+	}
+	if raw := os.Getenv("UPSTREAM_QUOTA_THROTTLE_DELAY"); raw != "" {
+		if v, err := time.ParseDuration(raw); err == nil && v >= 0 {
+			config.ThrottleDelay = v
+		} else {
+			log.Printf("⚠️ Invalid UPSTREAM_QUOTA_THROTTLE_DELAY %q, using %s", raw, DefaultQuotaThrottleDelay)
+		}
+	}
+	return config
+}
+
+// quotaPeriod returns the start of the calendar month (UTC) containing t
+func quotaPeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// rollover resets the counters when the month changes. Unsaved counts from
+// the old period are flushed first. Caller holds q.mu.
+func (q *QuotaTracker) rollover() {
+	period := quotaPeriod(q.now())
+	if period.Equal(q.period) {
+		return
+	}
+	if !q.period.IsZero() {
+		q.flushLocked()
+	}
+	q.period = period
+	q.used = make(map[string]int64)
+	q.pending = make(map[string]int64)
+	q.loadLocked()
+}
+
+// loadLocked seeds the counters from the store. Caller holds q.mu.
+func (q *QuotaTracker) loadLocked() {
+	if q.store == nil {
+		return
+	}
+	usage, err := q.store.LoadUsage(q.period)
+	if err != nil {
+		log.Printf("⚠️ Failed to load upstream quota usage: %v", err)
+		return
+	}
+	for source, calls := range usage {
+		q.used[source] += calls
+	}
+}
+
+// SetStore attaches store and loads the current period's persisted counts.
+// Calls recorded before then are kept and saved on the next flush. Call it
+// once, at startup.
+func (q *QuotaTracker) SetStore(store QuotaStore) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	q.store = store
+	q.loadLocked()
+}
+
+// Record counts one call to source. Nil trackers ignore it.
+func (q *QuotaTracker) Record(source string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	q.used[source]++
+	q.pending[source]++
+	if q.pending[source] >= int64(q.config.FlushEvery) {
+		q.flushLocked()
+	}
+}
+
+// Wait is called by batch jobs before calling source. It returns
+// ErrQuotaExhausted once the limit is reached, sleeps ThrottleDelay past
+// the throttle threshold, and otherwise returns straight away.
+func (q *QuotaTracker) Wait(source string) error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	q.rollover()
+	state := q.stateLocked(source)
+	q.mu.Unlock()
+
+	switch state {
+	case QuotaStateExhausted:
+		return fmt.Errorf("%s: %w", source, ErrQuotaExhausted)
+	case QuotaStateThrottled:
+		q.sleep(q.config.ThrottleDelay)
+	}
+	return nil
+}
+
+// stateLocked classifies source's usage. Caller holds q.mu.
+func (q *QuotaTracker) stateLocked(source string) string {
+	limit := q.config.Limits[source]
+	if limit <= 0 {
+		return QuotaStateOK
+	}
+	used := q.used[source]
+	switch {
+	case used >= limit:
+		return QuotaStateExhausted
+	case float64(used) >= float64(limit)*q.config.ThrottleAt:
+		return QuotaStateThrottled
+	}
+	return QuotaStateOK
+}
+
+// Flush saves unsaved counts to the store
+func (q *QuotaTracker) Flush() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.flushLocked()
+}
+
+// flushLocked saves pending counts. Counts that fail to save stay pending
+// and are retried on the next flush. Caller holds q.mu.
+func (q *QuotaTracker) flushLocked() {
+	if q.store == nil {
+		return
+	}
+	for source, calls := range q.pending {
+		if calls == 0 {
+			continue
+		}
+		if err := q.store.AddUsage(source, q.period, calls); err != nil {
+			log.Printf("⚠️ Failed to save upstream quota usage for %s: %v", source, err)
+			continue
+		}
+		delete(q.pending, source)
+	}
+}
+
+// Refresh saves unsaved counts and reloads the period's totals from the
+// store, picking up calls made by other processes (API server replicas,
+// batch jobs) since this tracker loaded them
+func (q *QuotaTracker) Refresh() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	if q.store == nil {
+		return
+	}
+	q.flushLocked()
+	usage, err := q.store.LoadUsage(q.period)
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh upstream quota usage: %v", err)
+		return
+	}
+	// Anything still pending failed to save and isn't in the store's totals
+	for source := range q.used {
+		q.used[source] = usage[source] + q.pending[source]
+	}
+	for source, calls := range usage {
+		q.used[source] = calls + q.pending[source]
+	}
+}
+
+// Store returns the tracker's store, or nil when counts are in memory only
+func (q *QuotaTracker) Store() QuotaStore {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.store
+}
+
+// Status reports every source with a limit or recorded calls this period,
+// sorted by source
+func (q *QuotaTracker) Status() []QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+
+	sources := make(map[string]bool)
+	for source := range q.config.Limits {
+		sources[source] = true
+	}
+	for source := range q.used {
+		sources[source] = true
+	}
+
+	statuses := make([]QuotaStatus, 0, len(sources))
+	for source := range sources {
+		status := QuotaStatus{
+			Source:      source,
+			PeriodStart: q.period,
+			Used:        q.used[source],
+			State:       q.stateLocked(source),
+		}
+		if limit := q.config.Limits[source]; limit > 0 {
+			remaining := limit - status.Used
+			if remaining < 0 {
+				remaining = 0
+			}
+			status.Limit = limit
+			status.Remaining = &remaining
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Source < statuses[j].Source })
+	return statuses
+}
+
+var (
+	upstreamQuota     *QuotaTracker
+	upstreamQuotaOnce sync.Once
+)
+
+// UpstreamQuota returns the process-wide tracker the upstream clients record
+// into, configured from the environment (see QuotaConfigFromEnv)
+func UpstreamQuota() *QuotaTracker {
+	upstreamQuotaOnce.Do(func() {
+		upstreamQuota = NewQuotaTracker(QuotaConfigFromEnv(), nil)
+	})
+	return upstreamQuota
+}
+
+// StartUpstreamQuotaFlush saves UpstreamQuota's counts every interval, so a
+// long-running process loses at most one interval of counts on a crash
+func StartUpstreamQuotaFlush(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			UpstreamQuota().Flush()
+		}
+	}()
+}
+
+// SQLQuotaStore keeps quota counters in the upstream_quota_usage table
+type SQLQuotaStore struct {
+	DB *sql.DB
+}
+
+// NewSQLQuotaStore creates a store backed by db
+func NewSQLQuotaStore(db *sql.DB) *SQLQuotaStore {
+	return &SQLQuotaStore{DB: db}
+}
+
+// LoadUsage returns each source's persisted calls for period
+func (s *SQLQuotaStore) LoadUsage(period time.Time) (map[string]int64, error) {
+	rows, err := s.DB.Query(
+		"SELECT source, calls FROM upstream_quota_usage WHERE period_start = $1",
+		period.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upstream quota usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[string]int64)
+	for rows.Next() {
+		var source string
+		var calls int64
+		if err := rows.Scan(&source, &calls); err != nil {
+			return nil, fmt.Errorf("failed to scan upstream quota usage: %w", err)
+		}
+		usage[source] = calls
+	}
+	return usage, rows.Err()
+}
+
+// ClaimAlert marks the quota alert for source and period as sent. It
+// reports false when it had already been claimed, so each alert is sent
+// once across processes.
+func (s *SQLQuotaStore) ClaimAlert(source string, period time.Time) (bool, error) {
+	var claimed string
+	err := s.DB.QueryRow(`
+		INSERT INTO upstream_quota_usage (source, period_start, calls, alert_sent_at)
+		VALUES ($1, $2, 0, NOW())
+		ON CONFLICT (source, period_start)
+		DO UPDATE SET alert_sent_at = NOW() WHERE upstream_quota_usage.alert_sent_at IS NULL
+		RETURNING source`,
+		source, period.Format("2006-01-02")).Scan(&claimed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim upstream quota alert: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseAlert clears the claim on source's alert for period, so the
+// alert can be sent again
+func (s *SQLQuotaStore) ReleaseAlert(source string, period time.Time) error {
+	_, err := s.DB.Exec(`
+		UPDATE upstream_quota_usage SET alert_sent_at = NULL
+		WHERE source = $1 AND period_start = $2`,
+		source, period.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("failed to release upstream quota alert: %w", err)
+	}
+	return nil
+}
+
+// AddUsage adds calls to source's counter for period
+func (s *SQLQuotaStore) AddUsage(source string, period time.Time, calls int64) error {
+	_, err := s.DB.Exec(`
+		INSERT INTO upstream_quota_usage (source, period_start, calls, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (source, period_start)
+		DO UPDATE SET calls = upstream_quota_usage.calls + EXCLUDED.calls, updated_at = NOW()`,
+		source, period.Format("2006-01-02"), calls)
+	if err != nil {
+		return fmt.Errorf("failed to save upstream quota usage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota alert defaults, overridable with UPSTREAM_QUOTA_ALERT_AT
+const (
+	DefaultQuotaAlertAt = 0.8
+	// quotaRateWindow is how far back the call rate used for the
+	// exhaustion projection looks
+	quotaRateWindow = time.Hour
+)
+
+// QuotaAlert warns that a source has used most of its monthly quota
+type QuotaAlert struct {
+	Source      string    `json:"source"`
+	PeriodStart time.Time `json:"period_start"`
+	ResetsAt    time.Time `json:"resets_at"`
+	Used        int64     `json:"used"`
+	Limit       int64     `json:"limit"`
+	PercentUsed float64   `json:"percent_used"`
+	// CallsPerHour is the recent call rate the projection is based on
+	CallsPerHour float64 `json:"calls_per_hour"`
+	// ProjectedExhaustion is when the quota runs out at CallsPerHour; nil
+	// when no calls are being made
+	ProjectedExhaustion *time.Time `json:"projected_exhaustion"`
+}
+
+// Message is a one-line human readable description of the alert
+func (a QuotaAlert) Message() string {
+	msg := fmt.Sprintf("%s: %.0f%% of monthly quota used (%d of %d)", a.Source, a.PercentUsed, a.Used, a.Limit)
+	if a.ProjectedExhaustion == nil {
+		return msg + "; no recent calls"
+	}
+	when := "before"
+	if a.ProjectedExhaustion.After(a.ResetsAt) {
+		when = "after"
+	}
+	return fmt.Sprintf("%s; at %.0f calls/h projected to run out %s, %s the quota resets %s",
+		msg, a.CallsPerHour, a.ProjectedExhaustion.UTC().Format("2006-01-02 15:04 MST"),
+		when, a.ResetsAt.UTC().Format("2006-01-02"))
+}
+
+// QuotaAlertNotifier delivers quota alerts
+type QuotaAlertNotifier interface {
+	NotifyQuota(ctx context.Context, alerts []QuotaAlert) error
+}
+
+// QuotaAlertClaimer records that a source's alert was sent for a period,
+// reporting false if another process already sent it, and gives the claim
+// back when the alert couldn't be delivered. Implemented by SQLQuotaStore.
+type QuotaAlertClaimer interface {
+	ClaimAlert(source string, period time.Time) (bool, error)
+	ReleaseAlert(source string, period time.Time) error
+}
+
+// quotaSample is a source's usage at a point in time
+type quotaSample struct {
+	at   time.Time
+	used int64
+}
+
+// QuotaAlerter watches a QuotaTracker and notifies once per source per
+// month when usage crosses the alert threshold
+type QuotaAlerter struct {
+	tracker   *QuotaTracker
+	threshold float64
+	notifiers []QuotaAlertNotifier
+	now       func() time.Time
+
+	samples map[string][]quotaSample
+	alerted map[string]time.Time // source -> period already alerted
+}
+
+// NewQuotaAlerter creates an alerter for UpstreamQuota with the threshold
+// from UPSTREAM_QUOTA_ALERT_AT (a fraction, default 0.8) and whichever
+// notifiers are configured: the webhook when UPSTREAM_QUOTA_ALERT_WEBHOOK_URL
+// is set and email when UPSTREAM_QUOTA_ALERT_EMAILS (comma-separated) is.
+func NewQuotaAlerter() *QuotaAlerter {
+	threshold := DefaultQuotaAlertAt
+	if raw := os.Getenv("UPSTREAM_QUOTA_ALERT_AT"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0 && v <= 1 {
+			threshold = v
+		} else {
+			log.Printf("⚠️ Invalid UPSTREAM_QUOTA_ALERT_AT %q, using %.2f", raw, DefaultQuotaAlertAt)
+		}
+	}
+
+	var notifiers []QuotaAlertNotifier
+	if url := os.Getenv("UPSTREAM_QUOTA_ALERT_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewWebhookQuotaNotifier(url))
+	}
+	if emails := os.Getenv("UPSTREAM_QUOTA_ALERT_EMAILS"); emails != "" {
+		notifiers = append(notifiers, NewEmailQuotaNotifier(strings.Split(emails, ",")))
+	}
+	return NewQuotaAlerterWith(UpstreamQuota(), threshold, notifiers...)
+}
+
+// NewQuotaAlerterWith creates an alerter with explicit dependencies
+func NewQuotaAlerterWith(tracker *QuotaTracker, threshold float64, notifiers ...QuotaAlertNotifier) *QuotaAlerter {
+	return &QuotaAlerter{
+		tracker:   tracker,
+		threshold: threshold,
+		notifiers: notifiers,
+		now:       time.Now,
+		samples:   make(map[string][]quotaSample),
+		alerted:   make(map[string]time.Time),
+	}
+}
+
+// Check refreshes usage and returns an alert for each source at or past the
+// threshold that hasn't been alerted this period. Returned alerts are
+// claimed but not yet marked sent; Run marks them once they're delivered.
+func (a *QuotaAlerter) Check() []QuotaAlert {
+	a.tracker.Refresh()
+	now := a.now()
+	claimer, _ := a.tracker.Store().(QuotaAlertClaimer)
+
+	var alerts []QuotaAlert
+	for _, status := range a.tracker.Status() {
+		rate := a.sample(status, now)
+		if status.Limit <= 0 || float64(status.Used) < float64(status.Limit)*a.threshold {
+			continue
+		}
+		if period, ok := a.alerted[status.Source]; ok && period.Equal(status.PeriodStart) {
+			continue
+		}
+		if claimer != nil {
+			claimed, err := claimer.ClaimAlert(status.Source, status.PeriodStart)
+			if err != nil {
+				log.Printf("Quota alerts: %v", err)
+				continue
+			}
+			if !claimed {
+				a.alerted[status.Source] = status.PeriodStart
+				continue
+			}
+		}
+		alerts = append(alerts, newQuotaAlert(status, rate, now))
+	}
+	return alerts
+}
+
+// sample records status and returns source's calls per hour over the
+// last quotaRateWindow, or since the period started when there's no
+// earlier sample yet
+func (a *QuotaAlerter) sample(status QuotaStatus, now time.Time) float64 {
+	samples := a.samples[status.Source]
+	// Drop samples from an earlier period or older than the window, but
+	// keep one to measure from
+	for len(samples) > 0 && (samples[0].at.Before(status.PeriodStart) || samples[0].used > status.Used) {
+		samples = samples[1:]
+	}
+	for len(samples) > 1 && now.Sub(samples[1].at) >= quotaRateWindow {
+		samples = samples[1:]
+	}
+	a.samples[status.Source] = append(samples, quotaSample{at: now, used: status.Used})
+
+	from := quotaSample{at: status.PeriodStart}
+	if len(samples) > 0 {
+		from = samples[0]
+	}
+	hours := now.Sub(from.at).Hours()
+	if hours <= 0 {
+		return 0
+	}
+	return float64(status.Used-from.used) / hours
+}
+
+func newQuotaAlert(status QuotaStatus, callsPerHour float64, now time.Time) QuotaAlert {
+	alert := QuotaAlert{
+		Source:       status.Source,
+		PeriodStart:  status.PeriodStart,
+		ResetsAt:     status.PeriodStart.AddDate(0, 1, 0),
+		Used:         status.Used,
+		Limit:        status.Limit,
+		PercentUsed:  float64(status.Used) / float64(status.Limit) * 100,
+		CallsPerHour: callsPerHour,
+	}
+	if callsPerHour > 0 {
+		remaining := status.Limit - status.Used
+		if remaining < 0 {
+			remaining = 0
+		}
+		at := now.Add(time.Duration(float64(remaining) / callsPerHour * float64(time.Hour)))
+		alert.ProjectedExhaustion = &at
+	}
+	return alert
+}
+
+// Run checks quotas and sends any alerts to every notifier. Alerts are
+// marked sent only when every notifier delivered them; otherwise their
+// claims are released so the next run tries again. Notifier failures are
+// logged and joined into the error.
+func (a *QuotaAlerter) Run(ctx context.Context) ([]QuotaAlert, error) {
+	alerts := a.Check()
+	if len(alerts) == 0 {
+		return nil, nil
+	}
+
+	var errs []string
+	for _, n := range a.notifiers {
+		if err := n.NotifyQuota(ctx, alerts); err != nil {
+			log.Printf("Quota alerts: notifier failed: %v", err)
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		a.release(alerts)
+		return alerts, fmt.Errorf("failed to deliver quota alerts: %s", strings.Join(errs, "; "))
+	}
+	for _, alert := range alerts {
+		a.alerted[alert.Source] = alert.PeriodStart
+	}
+	return alerts, nil
+}
+
+// release gives back the claims Check took for alerts that weren't
+// delivered
+func (a *QuotaAlerter) release(alerts []QuotaAlert) {
+	claimer, ok := a.tracker.Store().(QuotaAlertClaimer)
+	if !ok {
+		return
+	}
+	for _, alert := range alerts {
+		if err := claimer.ReleaseAlert(alert.Source, alert.PeriodStart); err != nil {
+			log.Printf("Quota alerts: %v", err)
+		}
+	}
+}
+
+// StartUpstreamQuotaAlerts checks UpstreamQuota every interval and sends
+// alerts through the env-configured notifiers. It does nothing when no
+// quota or no notifier is configured.
+func StartUpstreamQuotaAlerts(interval time.Duration) {
+	alerter := NewQuotaAlerter()
+	if len(alerter.notifiers) == 0 || len(alerter.tracker.config.Limits) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			alerts, _ := alerter.Run(ctx)
+			cancel()
+			for _, alert := range alerts {
+				log.Printf("⚠️ Upstream quota: %s", alert.Message())
+			}
+		}
+	}()
+}
+
+func quotaAlertSummary(alerts []QuotaAlert) string {
+	lines := make([]string, 0, len(alerts)+1)
+	lines = append(lines, fmt.Sprintf("⚠️ %d upstream API quota(s) nearly exhausted:", len(alerts)))
+	for _, a := range alerts {
+		lines = append(lines, "• "+a.Message())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WebhookQuotaNotifier posts quota alerts as JSON to a webhook
+type WebhookQuotaNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookQuotaNotifier creates a webhook notifier for url
+func NewWebhookQuotaNotifier(url string) *WebhookQuotaNotifier {
+	return &WebhookQuotaNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NotifyQuota posts all alerts in a single request
+func (w *WebhookQuotaNotifier) NotifyQuota(ctx context.Context, alerts []QuotaAlert) error {
+	return postAlertWebhook(ctx, w.Client, w.URL, quotaAlertSummary(alerts), alerts)
+}
+
+// EmailQuotaNotifier emails quota alerts to ops recipients
+type EmailQuotaNotifier struct {
+	Recipients []string
+	send       func(to, subject, text string) error
+}
+
+// NewEmailQuotaNotifier creates a notifier sending through EmailService
+func NewEmailQuotaNotifier(recipients []string) *EmailQuotaNotifier {
+	trimmed := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		if r = strings.TrimSpace(r); r != "" {
+			trimmed = append(trimmed, r)
+		}
+	}
+	return &EmailQuotaNotifier{Recipients: trimmed, send: NewEmailService().SendOpsAlertEmail}
+}
+
+// NotifyQuota sends one email per recipient summarizing all alerts
+func (e *EmailQuotaNotifier) NotifyQuota(ctx context.Context, alerts []QuotaAlert) error {
+	subject := fmt.Sprintf("[InvestorCenter] %d upstream API quota alert(s)", len(alerts))
+	text := quotaAlertSummary(alerts)
+	for _, to := range e.Recipients {
+		if err := e.send(to, subject, text); err != nil {
+			return fmt.Errorf("failed to email %s: %w", to, err)
+		}
+	}
+	return nil
+}
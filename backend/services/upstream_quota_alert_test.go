@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureQuotaNotifier records every batch of alerts it is sent
+type captureQuotaNotifier struct {
+	batches [][]QuotaAlert
+	err     error
+}
+
+func (c *captureQuotaNotifier) NotifyQuota(ctx context.Context, alerts []QuotaAlert) error {
+	c.batches = append(c.batches, alerts)
+	return c.err
+}
+
+func recordCalls(q *QuotaTracker, source string, n int) {
+	for i := 0; i < n; i++ {
+		q.Record(source)
+	}
+}
+
+func TestQuotaAlerter_FiresAtThresholdWithProjection(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	q, _ := newTestQuotaTracker(QuotaConfig{Limits: map[string]int64{QuotaSourcePolygon: 1000}}, newMemoryQuotaStore(), &now)
+	notifier := &captureQuotaNotifier{}
+	alerter := NewQuotaAlerterWith(q, 0.8, notifier)
+	alerter.now = func() time.Time { return now }
+
+	recordCalls(q, QuotaSourcePolygon, 799)
+	alerts, err := alerter.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, alerts, "no alert below the threshold")
+	assert.Empty(t, notifier.batches)
+
+	// 100 calls in the next hour crosses 80% with 200 calls left
+	now = now.Add(time.Hour)
+	recordCalls(q, QuotaSourcePolygon, 100)
+	alerts, err = alerter.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Len(t, notifier.batches, 1)
+
+	alert := notifier.batches[0][0]
+	assert.Equal(t, QuotaSourcePolygon, alert.Source)
+	assert.Equal(t, int64(899), alert.Used)
+	assert.Equal(t, int64(1000), alert.Limit)
+	assert.InDelta(t, 89.9, alert.PercentUsed, 0.001)
+	assert.InDelta(t, 100, alert.CallsPerHour, 0.001)
+	require.NotNil(t, alert.ProjectedExhaustion)
+	assert.Equal(t, now.Add(time.Duration(101.0/100*float64(time.Hour))), *alert.ProjectedExhaustion)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), alert.ResetsAt)
+	assert.Contains(t, alert.Message(), "projected to run out 2026-03-10 02:00 UTC, before the quota resets")
+
+	// Only once per period
+	now = now.Add(time.Hour)
+	recordCalls(q, QuotaSourcePolygon, 50)
+	alerts, err = alerter.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+	assert.Len(t, notifier.batches, 1)
+}
+
+func TestQuotaAlerter_RateFallsBackToPeriodAverage(t *testing.T) {
+	now := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+	q, _ := newTestQuotaTracker(QuotaConfig{Limits: map[string]int64{QuotaSourceFMP: 300}}, nil, &now)
+	alerter := NewQuotaAlerterWith(q, 0.8)
+	alerter.now = func() time.Time { return now }
+
+	// 240 calls over the first 10 days of the month
+	recordCalls(q, QuotaSourceFMP, 240)
+	alerts := alerter.Check()
+	require.Len(t, alerts, 1)
+	assert.InDelta(t, 1, alerts[0].CallsPerHour, 0.001)
+	require.NotNil(t, alerts[0].ProjectedExhaustion)
+	assert.Equal(t, now.Add(60*time.Hour), *alerts[0].ProjectedExhaustion)
+}
+
+func TestQuotaAlerter_NotifierErrorIsReturned(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	q, _ := newTestQuotaTracker(QuotaConfig{Limits: map[string]int64{QuotaSourceCoinGecko: 10}}, nil, &now)
+	alerter := NewQuotaAlerterWith(q, 0.5, &captureQuotaNotifier{err: errors.New("webhook down")})
+	alerter.now = func() time.Time { return now }
+
+	recordCalls(q, QuotaSourceCoinGecko, 10)
+	alerts, err := alerter.Run(context.Background())
+	assert.Len(t, alerts, 1)
+	assert.ErrorContains(t, err, "webhook down")
+}
+
+// claimingQuotaStore is a memoryQuotaStore that also claims alerts
+type claimingQuotaStore struct {
+	*memoryQuotaStore
+	claimed  map[string]bool
+	released []string
+}
+
+func (s *claimingQuotaStore) ClaimAlert(source string, period time.Time) (bool, error) {
+	key := source + " " + period.Format("2006-01-02")
+	if s.claimed[key] {
+		return false, nil
+	}
+	s.claimed[key] = true
+	return true, nil
+}
+
+func (s *claimingQuotaStore) ReleaseAlert(source string, period time.Time) error {
+	key := source + " " + period.Format("2006-01-02")
+	delete(s.claimed, key)
+	s.released = append(s.released, key)
+	return nil
+}
+
+func TestQuotaAlerter_UndeliveredAlertIsRetried(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	store := &claimingQuotaStore{memoryQuotaStore: newMemoryQuotaStore(), claimed: map[string]bool{}}
+	q, _ := newTestQuotaTracker(QuotaConfig{Limits: map[string]int64{QuotaSourceFMP: 10}}, store, &now)
+	notifier := &captureQuotaNotifier{err: errors.New("webhook down")}
+	alerter := NewQuotaAlerterWith(q, 0.5, notifier)
+	alerter.now = func() time.Time { return now }
+
+	recordCalls(q, QuotaSourceFMP, 10)
+	_, err := alerter.Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, []string{"fmp 2026-03-01"}, store.released, "the failed alert's claim is given back")
+
+	// The next run sends it again, and then it's done for the period
+	notifier.err = nil
+	alerts, err := alerter.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Len(t, notifier.batches, 2)
+
+	alerts, err = alerter.Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, alerts)
+	assert.Len(t, notifier.batches, 2)
+	assert.True(t, store.claimed["fmp 2026-03-01"])
+}
+
+func TestEmailQuotaNotifier(t *testing.T) {
+	var sent []string
+	n := NewEmailQuotaNotifier([]string{" ops@example.com", "", "oncall@example.com "})
+	n.send = func(to, subject, text string) error {
+		sent = append(sent, to)
+		assert.Contains(t, subject, "1 upstream API quota alert")
+		assert.Contains(t, text, "fmp: 90% of monthly quota used (90 of 100)")
+		return nil
+	}
+
+	err := n.NotifyQuota(context.Background(), []QuotaAlert{{Source: "fmp", Used: 90, Limit: 100, PercentUsed: 90}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops@example.com", "oncall@example.com"}, sent)
+}
+
+func TestSQLQuotaStore_ClaimAlert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	store := NewSQLQuotaStore(db)
+	period := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("INSERT INTO upstream_quota_usage").
+		WithArgs("fmp", "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"source"}).AddRow("fmp"))
+	claimed, err := store.ClaimAlert("fmp", period)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	mock.ExpectQuery("INSERT INTO upstream_quota_usage").
+		WithArgs("fmp", "2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"source"}))
+	claimed, err = store.ClaimAlert("fmp", period)
+	require.NoError(t, err)
+	assert.False(t, claimed, "already claimed by another process")
+
+	mock.ExpectExec("UPDATE upstream_quota_usage SET alert_sent_at = NULL").
+		WithArgs("fmp", "2026-03-01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.ReleaseAlert("fmp", period))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
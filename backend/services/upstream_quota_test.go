@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryQuotaStore keeps quota counters in a map keyed by period then source
+type memoryQuotaStore struct {
+	usage map[string]map[string]int64
+	adds  int
+	fail  bool
+}
+
+func newMemoryQuotaStore() *memoryQuotaStore {
+	return &memoryQuotaStore{usage: make(map[string]map[string]int64)}
+}
+
+func (s *memoryQuotaStore) LoadUsage(period time.Time) (map[string]int64, error) {
+	usage := make(map[string]int64)
+	for source, calls := range s.usage[period.Format("2006-01")] {
+		usage[source] = calls
+	}
+	return usage, nil
+}
+
+func (s *memoryQuotaStore) AddUsage(source string, period time.Time, calls int64) error {
+	if s.fail {
+		return errors.New("db down")
+	}
+	s.adds++
+	key := period.Format("2006-01")
+	if s.usage[key] == nil {
+		s.usage[key] = make(map[string]int64)
+	}
+	s.usage[key][source] += calls
+	return nil
+}
+
+// newTestQuotaTracker returns a tracker at now whose sleeps are recorded
+func newTestQuotaTracker(config QuotaConfig, store QuotaStore, now *time.Time) (*QuotaTracker, *[]time.Duration) {
+	q := NewQuotaTracker(config, store)
+	q.now = func() time.Time { return *now }
+	var sleeps []time.Duration
+	q.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	return q, &sleeps
+}
+
+func quotaStatus(t *testing.T, q *QuotaTracker, source string) QuotaStatus {
+	t.Helper()
+	for _, s := range q.Status() {
+		if s.Source == source {
+			return s
+		}
+	}
+	t.Fatalf("no status for %s", source)
+	return QuotaStatus{}
+}
+
+func TestQuotaTracker_RecordIncrementsPerSource(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	q, _ := newTestQuotaTracker(QuotaConfig{Limits: map[string]int64{QuotaSourcePolygon: 100}}, nil, &now)
+
+	for i := 0; i < 3; i++ {
+		q.Record(QuotaSourcePolygon)
+	}
+	q.Record(QuotaSourceFMP)
+
+	polygon := quotaStatus(t, q, QuotaSourcePolygon)
+	assert.Equal(t, int64(3), polygon.Used)
+	assert.Equal(t, int64(100), polygon.Limit)
+	require.NotNil(t, polygon.Remaining)
+	assert.Equal(t, int64(97), *polygon.Remaining)
+	assert.Equal(t, QuotaStateOK, polygon.State)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), polygon.PeriodStart)
+
+	fmp := quotaStatus(t, q, QuotaSourceFMP)
+	assert.Equal(t, int64(1), fmp.Used)
+	assert.Nil(t, fmp.Remaining, "sources without a limit report no remaining budget")
+}
+
+func TestQuotaTracker_WaitThrottlesAtThreshold(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	config := QuotaConfig{
+		Limits:        map[string]int64{QuotaSourcePolygon: 10},
+		ThrottleAt:    0.8,
+		ThrottleDelay: 3 * time.Second,
+	}
+	q, sleeps := newTestQuotaTracker(config, nil, &now)
+
+	for i := 0; i < 7; i++ {
+		q.Record(QuotaSourcePolygon)
+	}
+	require.NoError(t, q.Wait(QuotaSourcePolygon))
+	assert.Empty(t, *sleeps, "below the threshold Wait returns straight away")
+
+	q.Record(QuotaSourcePolygon) // 8 of 10: at the threshold
+	require.NoError(t, q.Wait(QuotaSourcePolygon))
+	assert.Equal(t, []time.Duration{3 * time.Second}, *sleeps)
+	assert.Equal(t, QuotaStateThrottled, quotaStatus(t, q, QuotaSourcePolygon).State)
+
+	q.Record(QuotaSourcePolygon)
+	q.Record(QuotaSourcePolygon) // 10 of 10
+	err := q.Wait(QuotaSourcePolygon)
+	assert.ErrorIs(t, err, ErrQuotaExhausted)
+	assert.Len(t, *sleeps, 1, "an exhausted quota stops the job instead of sleeping")
+
+	status := quotaStatus(t, q, QuotaSourcePolygon)
+	assert.Equal(t, QuotaStateExhausted, status.State)
+	assert.Equal(t, int64(0), *status.Remaining)
+
+	// Other sources are unaffected
+	assert.NoError(t, q.Wait(QuotaSourceFMP))
+}
+
+func TestQuotaTracker_PersistsAcrossRestarts(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := newMemoryQuotaStore()
+	config := QuotaConfig{Limits: map[string]int64{QuotaSourceFMP: 100}, FlushEvery: 5}
+
+	q, _ := newTestQuotaTracker(config, store, &now)
+	for i := 0; i < 7; i++ {
+		q.Record(QuotaSourceFMP)
+	}
+	assert.Equal(t, int64(5), store.usage["2026-03"][QuotaSourceFMP], "flushes after FlushEvery calls")
+	q.Flush()
+	assert.Equal(t, int64(7), store.usage["2026-03"][QuotaSourceFMP])
+
+	restarted, _ := newTestQuotaTracker(config, nil, &now)
+	restarted.Record(QuotaSourceFMP) // recorded before the store is attached
+	restarted.SetStore(store)
+	assert.Equal(t, int64(8), quotaStatus(t, restarted, QuotaSourceFMP).Used)
+	restarted.Flush()
+	assert.Equal(t, int64(8), store.usage["2026-03"][QuotaSourceFMP])
+}
+
+func TestQuotaTracker_FailedFlushIsRetried(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := newMemoryQuotaStore()
+	store.fail = true
+	q, _ := newTestQuotaTracker(QuotaConfig{}, store, &now)
+
+	q.Record(QuotaSourceCoinGecko)
+	q.Flush()
+	assert.Empty(t, store.usage)
+
+	store.fail = false
+	q.Flush()
+	assert.Equal(t, int64(1), store.usage["2026-03"][QuotaSourceCoinGecko])
+}
+
+func TestQuotaTracker_ResetsEachMonth(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+	store := newMemoryQuotaStore()
+	q, _ := newTestQuotaTracker(QuotaConfig{Limits: map[string]int64{QuotaSourcePolygon: 2}}, store, &now)
+
+	q.Record(QuotaSourcePolygon)
+	q.Record(QuotaSourcePolygon)
+	assert.ErrorIs(t, q.Wait(QuotaSourcePolygon), ErrQuotaExhausted)
+
+	now = time.Date(2026, 4, 1, 0, 1, 0, 0, time.UTC)
+	assert.NoError(t, q.Wait(QuotaSourcePolygon))
+	assert.Equal(t, int64(0), quotaStatus(t, q, QuotaSourcePolygon).Used)
+	assert.Equal(t, int64(2), store.usage["2026-03"][QuotaSourcePolygon], "the old month's counts are saved on rollover")
+}
+
+func TestQuotaTracker_NilIsNoop(t *testing.T) {
+	var q *QuotaTracker
+	q.Record(QuotaSourcePolygon)
+	q.Flush()
+	assert.NoError(t, q.Wait(QuotaSourcePolygon))
+}
+
+func TestQuotaConfigFromEnv(t *testing.T) {
+	t.Setenv("FMP_MONTHLY_QUOTA", "250000")
+	t.Setenv("POLYGON_MONTHLY_QUOTA", "not-a-number")
+	t.Setenv("COINGECKO_MONTHLY_QUOTA", "")
+	t.Setenv("UPSTREAM_QUOTA_THROTTLE_AT", "0.75")
+	t.Setenv("UPSTREAM_QUOTA_THROTTLE_DELAY", "500ms")
+
+	config := QuotaConfigFromEnv()
+	assert.Equal(t, map[string]int64{QuotaSourceFMP: 250000}, config.Limits)
+	assert.Equal(t, 0.75, config.ThrottleAt)
+	assert.Equal(t, 500*time.Millisecond, config.ThrottleDelay)
+
+	t.Setenv("UPSTREAM_QUOTA_THROTTLE_AT", "2")
+	assert.Equal(t, DefaultQuotaThrottleAt, QuotaConfigFromEnv().ThrottleAt)
+}
+
+func TestSQLQuotaStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	store := NewSQLQuotaStore(db)
+	period := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT source, calls FROM upstream_quota_usage WHERE period_start = \\$1").
+		WithArgs("2026-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "calls"}).AddRow("fmp", 120).AddRow("polygon", 4))
+	usage, err := store.LoadUsage(period)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"fmp": 120, "polygon": 4}, usage)
+
+	mock.ExpectExec("INSERT INTO upstream_quota_usage").
+		WithArgs("fmp", "2026-03-01", int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, store.AddUsage("fmp", period, 5))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
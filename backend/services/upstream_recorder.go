@@ -0,0 +1,273 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"investorcenter-api/httputil"
+	"investorcenter-shared/tracing"
+)
+
+// Upstream recording captures raw FMP/Polygon/CoinGecko responses so the
+// exact payload behind a bad number can be inspected and replayed through
+// the same mapping code. It is off unless UPSTREAM_RECORD_ENABLED=true.
+
+const defaultUpstreamRecordPrefix = "upstream-recordings"
+
+// Query params that carry credentials and are stripped from recorded URLs
+var upstreamSecretParams = []string{"apikey", "apiKey", "api_key", "x_cg_pro_api_key", "x_cg_demo_api_key"}
+
+// UpstreamRecording is one recorded upstream response
+type UpstreamRecording struct {
+	Source     string    `json:"source"`
+	Ticker     string    `json:"ticker"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"` // Credentials redacted
+	StatusCode int       `json:"status_code"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Body       string    `json:"body"`
+}
+
+// RecordingStore persists recordings. Implemented by S3RecordingStore.
+type RecordingStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// UpstreamRecordingKey returns the object key for a recording:
+// <source>/<TICKER>/<UTC timestamp>.json
+func UpstreamRecordingKey(source, ticker string, at time.Time) string {
+	if ticker == "" {
+		ticker = "_"
+	}
+	return fmt.Sprintf("%s/%s/%s.json", source, strings.ToUpper(ticker), at.UTC().Format("20060102T150405.000000000Z"))
+}
+
+// RecordingTransport wraps an http.RoundTripper and saves every response it
+// returns. Recording failures are logged and never fail the request.
+type RecordingTransport struct {
+	Source string
+	Store  RecordingStore
+	Prefix string
+	Base   http.RoundTripper
+	now    func() time.Time
+}
+
+// NewRecordingTransport creates a transport recording source's responses
+// into store under prefix, delegating to http.DefaultTransport
+func NewRecordingTransport(source string, store RecordingStore, prefix string) *RecordingTransport {
+	return &RecordingTransport{Source: source, Store: store, Prefix: prefix, now: time.Now}
+}
+
+// RoundTrip performs the request and records the response body
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	rec := &UpstreamRecording{
+		Source:     t.Source,
+		Ticker:     upstreamTicker(req.URL),
+		Method:     req.Method,
+		URL:        redactUpstreamURL(req.URL),
+		StatusCode: resp.StatusCode,
+		RecordedAt: now().UTC(),
+		Body:       string(body),
+	}
+	key := UpstreamRecordingKey(rec.Source, rec.Ticker, rec.RecordedAt)
+	if t.Prefix != "" {
+		key = t.Prefix + "/" + key
+	}
+
+	data, err := json.Marshal(rec)
+	if err == nil {
+		err = t.Store.Put(req.Context(), key, data)
+	}
+	if err != nil {
+		log.Printf("Upstream recording: failed to save %s: %v", key, err)
+	}
+	return resp, nil
+}
+
+// LoadUpstreamRecording fetches and decodes a recording by key
+func LoadUpstreamRecording(ctx context.Context, store RecordingStore, key string) (*UpstreamRecording, error) {
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recording %s: %w", key, err)
+	}
+	var rec UpstreamRecording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode recording %s: %w", key, err)
+	}
+	return &rec, nil
+}
+
+// ReplayTransport answers every request with a recorded response, so a
+// client method re-runs its decoding and mapping over the recorded payload
+// without touching the network
+type ReplayTransport struct {
+	Recording *UpstreamRecording
+}
+
+// RoundTrip returns the recorded status and body
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: t.Recording.StatusCode,
+		Status:     fmt.Sprintf("%d %s", t.Recording.StatusCode, http.StatusText(t.Recording.StatusCode)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(t.Recording.Body)),
+		Request:    req,
+	}, nil
+}
+
+// NewReplayClient returns an http.Client that replays rec. Assign it to a
+// PolygonClient/FMPClient/CoinGeckoClient's Client field and call the
+// method that originally made the request.
+func NewReplayClient(rec *UpstreamRecording) *http.Client {
+	return &http.Client{Transport: &ReplayTransport{Recording: rec}}
+}
+
+var (
+	upstreamRecordStore     RecordingStore
+	upstreamRecordStoreOnce sync.Once
+)
+
+// upstreamTransport returns a recording transport for source when
+// UPSTREAM_RECORD_ENABLED=true and UPSTREAM_RECORD_BUCKET is set, and nil
+// (the default transport) otherwise
+func upstreamTransport(source string) http.RoundTripper {
+	if os.Getenv("UPSTREAM_RECORD_ENABLED") != "true" {
+		return nil
+	}
+
+	upstreamRecordStoreOnce.Do(func() {
+		bucket := os.Getenv("UPSTREAM_RECORD_BUCKET")
+		if bucket == "" {
+			log.Println("⚠️ UPSTREAM_RECORD_ENABLED is set but UPSTREAM_RECORD_BUCKET is empty (recording disabled)")
+			return
+		}
+		store, err := NewS3RecordingStore(bucket)
+		if err != nil {
+			log.Printf("⚠️ Failed to set up upstream recording: %v (recording disabled)", err)
+			return
+		}
+		upstreamRecordStore = store
+		log.Printf("✅ Recording upstream responses to s3://%s", bucket)
+	})
+	if upstreamRecordStore == nil {
+		return nil
+	}
+
+	prefix := os.Getenv("UPSTREAM_RECORD_PREFIX")
+	if prefix == "" {
+		prefix = defaultUpstreamRecordPrefix
+	}
+	return NewRecordingTransport(source, upstreamRecordStore, prefix)
+}
+
+// upstreamClient returns the HTTP client for source's API client: the shared
+// pooled client, or when recording is enabled a client with the same timeout
+// whose recording transport sits on the shared pool
+func upstreamClient(source string) *http.Client {
+	transport := upstreamTransport(source)
+	if transport == nil {
+		return httputil.SharedClient()
+	}
+	if rt, ok := transport.(*RecordingTransport); ok && rt.Base == nil {
+		rt.Base = httputil.SharedTransport()
+	}
+	return &http.Client{Timeout: httputil.ClientTimeout, Transport: tracing.Transport(transport)}
+}
+
+// upstreamTicker picks the ticker (or coin id) a request is about from the
+// usual query params, or the path segment after ticker/tickers/coins
+func upstreamTicker(u *url.URL) string {
+	q := u.Query()
+	for _, param := range []string{"symbol", "ticker", "ids"} {
+		if v := q.Get(param); v != "" {
+			return sanitizeKeySegment(v)
+		}
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		switch segments[i] {
+		case "ticker", "tickers", "coins":
+			return sanitizeKeySegment(segments[i+1])
+		}
+	}
+	return ""
+}
+
+func sanitizeKeySegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+func redactUpstreamURL(u *url.URL) string {
+	redacted := *u
+	q := redacted.Query()
+	for _, p := range upstreamSecretParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+		}
+	}
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
+
+// S3RecordingStore stores recordings as JSON objects in an S3 bucket
+type S3RecordingStore struct {
+	bucket *s3Bucket
+}
+
+// NewS3RecordingStore creates a store for bucket in AWS_REGION (default us-east-1)
+func NewS3RecordingStore(bucket string) (*S3RecordingStore, error) {
+	b, err := newS3Bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &S3RecordingStore{bucket: b}, nil
+}
+
+// Put uploads data under key
+func (s *S3RecordingStore) Put(ctx context.Context, key string, data []byte) error {
+	return s.bucket.put(ctx, key, data, "application/json")
+}
+
+// Get downloads the object at key
+func (s *S3RecordingStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.bucket.get(ctx, key)
+}
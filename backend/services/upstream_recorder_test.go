@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"investorcenter-api/httputil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecordingStore keeps recordings in a map
+type memoryRecordingStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	putErr  error
+}
+
+func newMemoryRecordingStore() *memoryRecordingStore {
+	return &memoryRecordingStore{objects: make(map[string][]byte)}
+}
+
+func (m *memoryRecordingStore) Put(ctx context.Context, key string, data []byte) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryRecordingStore) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+const recordedSplitsPayload = `{"status":"OK","results":[
+	{"ticker":"NVDA","execution_date":"2021-07-20","split_from":1,"split_to":4},
+	{"ticker":"NVDA","execution_date":"2024-06-10","split_from":1,"split_to":10}]}`
+
+func TestRecordingTransport_RecordsPolygonResponse(t *testing.T) {
+	defer savePolygonBaseURL()()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(recordedSplitsPayload))
+	}))
+	defer server.Close()
+	PolygonBaseURL = server.URL
+
+	store := newMemoryRecordingStore()
+	transport := NewRecordingTransport("polygon", store, "recordings")
+	transport.now = func() time.Time { return time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC) }
+
+	client := newPolygonTestClient()
+	client.Client.Transport = transport
+
+	splits, err := client.GetStockSplits("nvda")
+	require.NoError(t, err)
+	require.Len(t, splits, 2, "recording must not consume the body the client decodes")
+
+	key := "recordings/polygon/NVDA/20260310T143000.000000000Z.json"
+	require.Contains(t, store.objects, key)
+
+	var rec UpstreamRecording
+	require.NoError(t, json.Unmarshal(store.objects[key], &rec))
+	assert.Equal(t, "polygon", rec.Source)
+	assert.Equal(t, "NVDA", rec.Ticker)
+	assert.Equal(t, http.StatusOK, rec.StatusCode)
+	assert.Equal(t, recordedSplitsPayload, rec.Body)
+	assert.Contains(t, rec.URL, "apikey=REDACTED")
+	assert.NotContains(t, rec.URL, "test-key")
+}
+
+func TestRecordingTransport_StoreFailureDoesNotFailRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	store := newMemoryRecordingStore()
+	store.putErr = errors.New("s3 unavailable")
+	client := &http.Client{Transport: NewRecordingTransport("fmp", store, "")}
+
+	resp, err := client.Get(server.URL + "/stable/quote?symbol=AAPL")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"ok":true}`, string(body))
+}
+
+func TestReplay_IsDeterministic(t *testing.T) {
+	store := newMemoryRecordingStore()
+	rec := UpstreamRecording{
+		Source:     "polygon",
+		Ticker:     "NVDA",
+		StatusCode: http.StatusOK,
+		Body:       recordedSplitsPayload,
+	}
+	data, _ := json.Marshal(rec)
+	store.objects["polygon/NVDA/x.json"] = data
+
+	loaded, err := LoadUpstreamRecording(context.Background(), store, "polygon/NVDA/x.json")
+	require.NoError(t, err)
+
+	client := &PolygonClient{APIKey: "unused", Client: NewReplayClient(loaded)}
+	first, err := client.GetStockSplits("NVDA")
+	require.NoError(t, err)
+	second, err := client.GetStockSplits("NVDA")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	require.Len(t, first, 2)
+	assert.Equal(t, 10.0, first[1].SplitTo)
+	assert.Equal(t, "2024-06-10", first[1].ExecutionDate.Format("2006-01-02"))
+
+	// A recorded error status replays as the same failure
+	failed := &PolygonClient{Client: NewReplayClient(&UpstreamRecording{StatusCode: http.StatusTooManyRequests, Body: "{}"})}
+	_, err = failed.GetStockSplits("NVDA")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+
+	_, err = LoadUpstreamRecording(context.Background(), store, "missing")
+	assert.Error(t, err)
+}
+
+func TestUpstreamTicker(t *testing.T) {
+	cases := map[string]string{
+		"https://api.polygon.io/v2/aggs/ticker/AAPL/range/1/day/2024-01-01/2024-02-01":     "AAPL",
+		"https://api.polygon.io/v2/snapshot/locale/global/markets/crypto/tickers/X:BTCUSD": "X_BTCUSD",
+		"https://financialmodelingprep.com/stable/ratios-ttm?symbol=MSFT&apikey=k":         "MSFT",
+		"https://api.coingecko.com/api/v3/coins/bitcoin/market_chart?days=7":               "bitcoin",
+		"https://api.coingecko.com/api/v3/simple/price?ids=bitcoin,ethereum":               "bitcoin_ethereum",
+		"https://api.polygon.io/v1/marketstatus/now":                                       "",
+	}
+	for raw, want := range cases {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		assert.Equal(t, want, upstreamTicker(u), raw)
+	}
+	assert.True(t, strings.HasPrefix(UpstreamRecordingKey("polygon", "", time.Now()), "polygon/_/"))
+}
+
+func TestUpstreamTransport_DisabledByDefault(t *testing.T) {
+	t.Setenv("UPSTREAM_RECORD_ENABLED", "")
+	assert.Nil(t, upstreamTransport("polygon"))
+	assert.Same(t, httputil.SharedClient(), NewPolygonClient().Client)
+}
+
+func TestUpstreamClients_ShareTransport(t *testing.T) {
+	t.Setenv("UPSTREAM_RECORD_ENABLED", "")
+	shared := httputil.SharedClient().Transport
+
+	clients := map[string]*http.Client{
+		"polygon":      NewPolygonClient().Client,
+		"fmp":          NewFMPClient().Client,
+		"coingecko":    NewCoinGeckoClient().Client,
+		"nasdaqtrader": NewNasdaqTraderSource().Client,
+	}
+	for name, client := range clients {
+		assert.Same(t, shared, client.Transport, name)
+		assert.Equal(t, httputil.ClientTimeout, client.Timeout, name)
+	}
+}
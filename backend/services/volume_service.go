@@ -7,6 +7,8 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"investorcenter-shared/tracing"
 )
 
 // VolumeData represents real-time volume and price data
@@ -61,7 +63,7 @@ func NewVolumeService() *VolumeService {
 	return &VolumeService{
 		apiKey:      apiKey,
 		baseURL:     "https://api.polygon.io",
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		httpClient:  &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
 		cache:       make(map[string]*cachedVolume),
 		cacheExpiry: 1 * time.Minute, // Cache for 1 minute
 	}
@@ -155,14 +155,15 @@ func average(vals []float64) float64 {
 	return sum / float64(len(vals))
 }
 
-// ValidateWatchListOwnership checks if user owns the watch list
+// ValidateWatchListOwnership checks if user owns the watch list. Another
+// user's watch list is reported as database.ErrWatchListNotFound.
 func (s *WatchListService) ValidateWatchListOwnership(watchListID string, userID string) error {
 	watchList, err := database.GetWatchListByID(watchListID, userID)
 	if err != nil {
 		return err
 	}
 	if watchList.UserID != userID {
-		return fmt.Errorf("unauthorized access to watch list")
+		return database.ErrWatchListNotFound
 	}
 	return nil
 }
@@ -173,7 +174,8 @@ func (s *WatchListService) SearchTickers(query string, limit int) ([]models.Stoc
 	query = strings.ToUpper(query)
 
 	// Use database search function
-	results, err := database.SearchStocks(query, limit)
+	// Delisted tickers can't be meaningfully watched
+	results, err := database.SearchStocks(query, limit, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search tickers: %w", err)
 	}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -20,9 +21,9 @@ type IngestionLog struct {
 }
 
 // InsertIngestionLog inserts a new record and returns the ID
-func InsertIngestionLog(source string, ticker *string, dataType string, sourceURL *string, s3Key string, s3Bucket string, fileSize int64, collectedAt time.Time) (int64, error) {
+func InsertIngestionLog(ctx context.Context, source string, ticker *string, dataType string, sourceURL *string, s3Key string, s3Bucket string, fileSize int64, collectedAt time.Time) (int64, error) {
 	var id int64
-	err := DB.QueryRow(
+	err := DB.QueryRowContext(ctx,
 		`INSERT INTO ingestion_log (source, ticker, data_type, source_url, s3_key, s3_bucket, file_size, collected_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		 RETURNING id`,
@@ -35,7 +36,7 @@ func InsertIngestionLog(source string, ticker *string, dataType string, sourceUR
 }
 
 // GetIngestionLogs retrieves ingestion log records with optional filtering
-func GetIngestionLogs(source, ticker, dataType string, limit, offset int) ([]IngestionLog, int, error) {
+func GetIngestionLogs(ctx context.Context, source, ticker, dataType string, limit, offset int) ([]IngestionLog, int, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 100
 	}
@@ -67,7 +68,7 @@ func GetIngestionLogs(source, ticker, dataType string, limit, offset int) ([]Ing
 	// Get total count
 	var total int
 	countQuery := "SELECT COUNT(*) FROM ingestion_log " + where
-	err := DB.QueryRow(countQuery, args...).Scan(&total)
+	err := DB.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count ingestion logs: %w", err)
 	}
@@ -80,7 +81,7 @@ func GetIngestionLogs(source, ticker, dataType string, limit, offset int) ([]Ing
 	args = append(args, limit, offset)
 
 	rows := []IngestionLog{}
-	err = DB.Select(&rows, query, args...)
+	err = DB.SelectContext(ctx, &rows, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to query ingestion logs: %w", err)
 	}
@@ -89,9 +90,9 @@ func GetIngestionLogs(source, ticker, dataType string, limit, offset int) ([]Ing
 }
 
 // GetIngestionLog retrieves a single ingestion log by ID
-func GetIngestionLog(id int64) (*IngestionLog, error) {
+func GetIngestionLog(ctx context.Context, id int64) (*IngestionLog, error) {
 	var log IngestionLog
-	err := DB.Get(&log,
+	err := DB.GetContext(ctx, &log,
 		`SELECT id, source, ticker, data_type, source_url, s3_key, s3_bucket, file_size, collected_at, created_at
 		 FROM ingestion_log WHERE id = $1`, id)
 	if err != nil {
@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,6 +10,7 @@ import (
 	"data-ingestion-service/auth"
 	"data-ingestion-service/database"
 	"data-ingestion-service/storage"
+	"investorcenter-shared/requestid"
 
 	"github.com/gin-gonic/gin"
 )
@@ -91,20 +91,21 @@ func PostIngest(c *gin.Context) {
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal payload: %v", err)
+		requestid.Logger(c).Printf("Failed to marshal payload: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare data"})
 		return
 	}
 
 	// Upload to S3
-	if err := storage.Upload(s3Key, payloadBytes, "application/json"); err != nil {
-		log.Printf("Failed to upload to S3: %v", err)
+	if err := storage.Upload(c.Request.Context(), s3Key, payloadBytes, "application/json"); err != nil {
+		requestid.Logger(c).Printf("Failed to upload to S3: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload data to storage"})
 		return
 	}
 
 	// Write index record to Postgres
 	id, err := database.InsertIngestionLog(
+		c.Request.Context(),
 		req.Source,
 		req.Ticker,
 		req.DataType,
@@ -115,7 +116,7 @@ func PostIngest(c *gin.Context) {
 		collectedAt,
 	)
 	if err != nil {
-		log.Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
+		requestid.Logger(c).Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
 		// S3 upload succeeded but DB write failed — return success with warning
 		c.JSON(http.StatusCreated, gin.H{
 			"success": true,
@@ -127,7 +128,7 @@ func PostIngest(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Ingestion success: id=%d source=%s ticker=%v type=%s key=%s size=%d",
+	requestid.Logger(c).Printf("Ingestion success: id=%d source=%s ticker=%v type=%s key=%s size=%d",
 		id, req.Source, req.Ticker, req.DataType, s3Key, len(payloadBytes))
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -159,9 +160,9 @@ func ListIngestionLogs(c *gin.Context) {
 		}
 	}
 
-	records, total, err := database.GetIngestionLogs(source, ticker, dataType, limit, offset)
+	records, total, err := database.GetIngestionLogs(c.Request.Context(), source, ticker, dataType, limit, offset)
 	if err != nil {
-		log.Printf("Failed to list ingestion logs: %v", err)
+		requestid.Logger(c).Printf("Failed to list ingestion logs: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve records"})
 		return
 	}
@@ -184,7 +185,7 @@ func GetIngestionLogByID(c *gin.Context) {
 		return
 	}
 
-	record, err := database.GetIngestionLog(id)
+	record, err := database.GetIngestionLog(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
 		return
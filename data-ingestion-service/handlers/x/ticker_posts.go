@@ -15,6 +15,7 @@ import (
 	"data-ingestion-service/cache"
 	"data-ingestion-service/database"
 	"data-ingestion-service/storage"
+	"investorcenter-shared/requestid"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xeipuuv/gojsonschema"
@@ -86,7 +87,7 @@ func PostTickerPosts(c *gin.Context) {
 
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 	if err != nil {
-		log.Printf("Schema validation error for x/ticker_posts: %v", err)
+		requestid.Logger(c).Printf("Schema validation error for x/ticker_posts: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Schema validation failed"})
 		return
 	}
@@ -119,14 +120,14 @@ func PostTickerPosts(c *gin.Context) {
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal payload: %v", err)
+		requestid.Logger(c).Printf("Failed to marshal payload: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare data"})
 		return
 	}
 
 	// Upload to S3 (archival)
-	if err := storage.Upload(s3Key, payloadBytes, "application/json"); err != nil {
-		log.Printf("Failed to upload to S3: %v", err)
+	if err := storage.Upload(c.Request.Context(), s3Key, payloadBytes, "application/json"); err != nil {
+		requestid.Logger(c).Printf("Failed to upload to S3: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload data to storage"})
 		return
 	}
@@ -143,6 +144,7 @@ func PostTickerPosts(c *gin.Context) {
 	// Write index record
 	tickerStr := ticker
 	id, err := database.InsertIngestionLog(
+		c.Request.Context(),
 		"x",
 		&tickerStr,
 		"ticker_posts",
@@ -153,7 +155,7 @@ func PostTickerPosts(c *gin.Context) {
 		collectedAt,
 	)
 	if err != nil {
-		log.Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
+		requestid.Logger(c).Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
 		c.JSON(http.StatusCreated, gin.H{
 			"success": true,
 			"data": gin.H{
@@ -166,7 +168,7 @@ func PostTickerPosts(c *gin.Context) {
 		return
 	}
 
-	log.Printf("X Ticker Posts ingestion success: id=%d ticker=%s key=%s size=%d redis=%v",
+	requestid.Logger(c).Printf("X Ticker Posts ingestion success: id=%d ticker=%s key=%s size=%d redis=%v",
 		id, ticker, s3Key, len(payloadBytes), redisWritten)
 
 	c.JSON(http.StatusCreated, gin.H{
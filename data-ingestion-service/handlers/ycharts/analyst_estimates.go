@@ -3,7 +3,6 @@ package ycharts
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"regexp"
 	"strings"
@@ -12,6 +11,7 @@ import (
 	"data-ingestion-service/auth"
 	"data-ingestion-service/database"
 	"data-ingestion-service/storage"
+	"investorcenter-shared/requestid"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xeipuuv/gojsonschema"
@@ -74,7 +74,7 @@ func PostAnalystEstimates(c *gin.Context) {
 
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 	if err != nil {
-		log.Printf("Schema validation error for analyst_estimates: %v", err)
+		requestid.Logger(c).Printf("Schema validation error for analyst_estimates: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Schema validation failed"})
 		return
 	}
@@ -107,14 +107,14 @@ func PostAnalystEstimates(c *gin.Context) {
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal payload: %v", err)
+		requestid.Logger(c).Printf("Failed to marshal payload: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare data"})
 		return
 	}
 
 	// Upload to S3
-	if err := storage.Upload(s3Key, payloadBytes, "application/json"); err != nil {
-		log.Printf("Failed to upload to S3: %v", err)
+	if err := storage.Upload(c.Request.Context(), s3Key, payloadBytes, "application/json"); err != nil {
+		requestid.Logger(c).Printf("Failed to upload to S3: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload data to storage"})
 		return
 	}
@@ -128,6 +128,7 @@ func PostAnalystEstimates(c *gin.Context) {
 	// Write index record
 	tickerStr := ticker
 	id, err := database.InsertIngestionLog(
+		c.Request.Context(),
 		"ycharts",
 		&tickerStr,
 		"analyst_estimates",
@@ -138,7 +139,7 @@ func PostAnalystEstimates(c *gin.Context) {
 		collectedAt,
 	)
 	if err != nil {
-		log.Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
+		requestid.Logger(c).Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
 		c.JSON(http.StatusCreated, gin.H{
 			"success": true,
 			"data": gin.H{
@@ -150,7 +151,7 @@ func PostAnalystEstimates(c *gin.Context) {
 		return
 	}
 
-	log.Printf("YCharts Analyst Estimates ingestion success: id=%d ticker=%s key=%s size=%d",
+	requestid.Logger(c).Printf("YCharts Analyst Estimates ingestion success: id=%d ticker=%s key=%s size=%d",
 		id, ticker, s3Key, len(payloadBytes))
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -3,7 +3,6 @@ package ycharts
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -11,6 +10,7 @@ import (
 	"data-ingestion-service/auth"
 	"data-ingestion-service/database"
 	"data-ingestion-service/storage"
+	"investorcenter-shared/requestid"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xeipuuv/gojsonschema"
@@ -71,7 +71,7 @@ func PostPerformance(c *gin.Context) {
 
 	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
 	if err != nil {
-		log.Printf("Schema validation error for performance: %v", err)
+		requestid.Logger(c).Printf("Schema validation error for performance: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Schema validation failed"})
 		return
 	}
@@ -104,14 +104,14 @@ func PostPerformance(c *gin.Context) {
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Failed to marshal payload: %v", err)
+		requestid.Logger(c).Printf("Failed to marshal payload: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare data"})
 		return
 	}
 
 	// Upload to S3
-	if err := storage.Upload(s3Key, payloadBytes, "application/json"); err != nil {
-		log.Printf("Failed to upload to S3: %v", err)
+	if err := storage.Upload(c.Request.Context(), s3Key, payloadBytes, "application/json"); err != nil {
+		requestid.Logger(c).Printf("Failed to upload to S3: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload data to storage"})
 		return
 	}
@@ -125,6 +125,7 @@ func PostPerformance(c *gin.Context) {
 	// Write index record
 	tickerStr := ticker
 	id, err := database.InsertIngestionLog(
+		c.Request.Context(),
 		"ycharts",
 		&tickerStr,
 		"performance",
@@ -135,7 +136,7 @@ func PostPerformance(c *gin.Context) {
 		collectedAt,
 	)
 	if err != nil {
-		log.Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
+		requestid.Logger(c).Printf("Failed to insert ingestion log (S3 upload succeeded at %s): %v", s3Key, err)
 		c.JSON(http.StatusCreated, gin.H{
 			"success": true,
 			"data": gin.H{
@@ -147,7 +148,7 @@ func PostPerformance(c *gin.Context) {
 		return
 	}
 
-	log.Printf("YCharts Performance ingestion success: id=%d ticker=%s key=%s size=%d",
+	requestid.Logger(c).Printf("YCharts Performance ingestion success: id=%d ticker=%s key=%s size=%d",
 		id, ticker, s3Key, len(payloadBytes))
 
 	c.JSON(http.StatusCreated, gin.H{
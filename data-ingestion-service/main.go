@@ -19,15 +19,30 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"investorcenter-shared/logging"
+	"investorcenter-shared/requestid"
+	"investorcenter-shared/securityheaders"
+	"investorcenter-shared/tracing"
 )
 
 func main() {
 	// Load .env for local dev (ignore error in production)
 	godotenv.Load()
 
+	// Mask emails, tokens and passwords in everything we log
+	redactor := logging.Install()
+
 	// Validate JWT secret before starting — fail fast if missing or too short
 	auth.ValidateJWTSecret()
 
+	// Trace requests, queries and S3 calls when an OTLP endpoint is set
+	shutdownTracing, err := tracing.Init(context.Background(), "data-ingestion-service")
+	if err != nil {
+		log.Printf("Warning: tracing disabled: %v", err)
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
 	// Initialize database
 	if err := database.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -43,7 +58,17 @@ func main() {
 	cache.Initialize()
 	defer cache.Close()
 
-	r := gin.Default()
+	// The access log writes to gin.DefaultWriter, so route it through the
+	// same redaction as the standard logger
+	if redactor != nil {
+		gin.DefaultWriter = logging.NewWriter(os.Stdout, redactor)
+		gin.DefaultErrorWriter = logging.NewWriter(os.Stderr, redactor)
+	}
+
+	// Request IDs forwarded by the backend tag every log line for the request
+	r := gin.New()
+	r.Use(requestid.Middleware(), requestid.AccessLog(), tracing.Middleware(), gin.Recovery())
+	r.Use(securityheaders.Middleware(securityheaders.FromEnv()))
 
 	// Increase max request body size to 12MB (raw_data can be up to 10MB + metadata)
 	r.MaxMultipartMemory = 12 << 20
@@ -5,9 +5,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
+	"investorcenter-shared/tracing"
+
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
@@ -29,8 +32,11 @@ func Initialize() error {
 		region = "us-east-1"
 	}
 
+	// S3 calls go through a traced client so uploads show up in the
+	// ingest request's trace
 	cfg, err := config.LoadDefaultConfig(context.Background(),
 		config.WithRegion(region),
+		config.WithHTTPClient(&http.Client{Transport: tracing.Transport(nil)}),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to load AWS config: %w", err)
@@ -42,12 +48,12 @@ func Initialize() error {
 }
 
 // Upload uploads raw data to S3 and returns the key
-func Upload(key string, data []byte, contentType string) error {
+func Upload(ctx context.Context, key string, data []byte, contentType string) error {
 	if s3Client == nil {
 		return fmt.Errorf("S3 client not initialized")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -150,25 +151,25 @@ func TestUploadWithoutInit(t *testing.T) {
 	defer func() { s3Client = originalClient }()
 
 	t.Run("returns error when S3 client not initialized", func(t *testing.T) {
-		err := Upload("test-key", []byte("data"), "application/json")
+		err := Upload(context.Background(), "test-key", []byte("data"), "application/json")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "S3 client not initialized")
 	})
 
 	t.Run("returns error with empty key when client nil", func(t *testing.T) {
-		err := Upload("", []byte("data"), "application/json")
+		err := Upload(context.Background(), "", []byte("data"), "application/json")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "S3 client not initialized")
 	})
 
 	t.Run("returns error with empty data when client nil", func(t *testing.T) {
-		err := Upload("key", []byte{}, "application/json")
+		err := Upload(context.Background(), "key", []byte{}, "application/json")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "S3 client not initialized")
 	})
 
 	t.Run("returns error with nil data when client nil", func(t *testing.T) {
-		err := Upload("key", nil, "application/json")
+		err := Upload(context.Background(), "key", nil, "application/json")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "S3 client not initialized")
 	})
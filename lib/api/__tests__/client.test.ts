@@ -157,7 +157,7 @@ describe('apiClient', () => {
       mockFetch.mockResolvedValueOnce({
         ok: true,
         status: 200,
-        json: async () => ({ access_token: 'new-token' }),
+        json: async () => ({ access_token: 'new-token', refresh_token: 'rotated-refresh' }),
       });
 
       // Retry call: success
@@ -174,6 +174,9 @@ describe('apiClient', () => {
       // Verify refresh endpoint was called
       const refreshCall = mockFetch.mock.calls[1];
       expect(refreshCall[0]).toContain('/auth/refresh');
+
+      // The rotated refresh token replaces the used one
+      expect(window.localStorage.setItem).toHaveBeenCalledWith('refresh_token', 'rotated-refresh');
     });
 
     it('clears tokens and throws on refresh failure', async () => {
@@ -232,6 +235,49 @@ describe('apiClient', () => {
     });
   });
 
+  describe('refreshSessionTokens()', () => {
+    it('shares one refresh request between concurrent callers', async () => {
+      (window.localStorage.getItem as jest.Mock).mockImplementation((key: string) =>
+        key === 'refresh_token' ? 'valid-refresh' : null
+      );
+
+      jest.resetModules();
+      const mod = await import('../client');
+
+      mockFetch.mockResolvedValueOnce({
+        ok: true,
+        status: 200,
+        json: async () => ({ access_token: 'new-token', refresh_token: 'rotated-refresh' }),
+      });
+
+      const results = await Promise.all([mod.refreshSessionTokens(), mod.refreshSessionTokens()]);
+
+      expect(results).toEqual(['new-token', 'new-token']);
+      expect(mockFetch).toHaveBeenCalledTimes(1);
+      expect(window.localStorage.setItem).toHaveBeenCalledWith('refresh_token', 'rotated-refresh');
+    });
+
+    it('starts a new request once the previous one settles', async () => {
+      (window.localStorage.getItem as jest.Mock).mockImplementation((key: string) =>
+        key === 'refresh_token' ? 'valid-refresh' : null
+      );
+
+      jest.resetModules();
+      const mod = await import('../client');
+
+      mockFetch.mockResolvedValueOnce({ ok: false, status: 401, json: async () => ({}) });
+      await expect(mod.refreshSessionTokens()).rejects.toThrow('Token refresh failed');
+
+      mockFetch.mockResolvedValueOnce({
+        ok: true,
+        status: 200,
+        json: async () => ({ access_token: 'new-token' }),
+      });
+      await expect(mod.refreshSessionTokens()).resolves.toBe('new-token');
+      expect(mockFetch).toHaveBeenCalledTimes(2);
+    });
+  });
+
   describe('error handling', () => {
     it('throws with error message from response body', async () => {
       mockFetch.mockResolvedValueOnce({
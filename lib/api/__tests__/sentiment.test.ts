@@ -82,4 +82,28 @@ describe('getSentimentPosts', () => {
     await getSentimentPosts('tsla');
     expect(mockGet).toHaveBeenCalledWith('/sentiment/TSLA/posts?sort=recent&limit=10');
   });
+
+  it('passes pagination and filters', async () => {
+    mockGet.mockResolvedValueOnce({});
+    await getSentimentPosts('TSLA', 'upvotes', 10, {
+      offset: 20,
+      source: 'reddit',
+      subreddit: 'wallstreetbets',
+    });
+    expect(mockGet).toHaveBeenCalledWith(
+      '/sentiment/TSLA/posts?sort=upvotes&limit=10&offset=20&source=reddit&subreddit=wallstreetbets'
+    );
+  });
+
+  it('unwraps the data/meta envelope', async () => {
+    mockGet.mockResolvedValueOnce({
+      data: [{ id: 1, title: 'post' }],
+      meta: { ticker: 'TSLA', total: 5, sort: 'upvotes', offset: 0, has_more: true },
+    });
+    const result = await getSentimentPosts('TSLA', 'upvotes', 1);
+    expect(result.posts).toHaveLength(1);
+    expect(result.total).toBe(5);
+    expect(result.sort).toBe('upvotes');
+    expect(result.hasMore).toBe(true);
+  });
 });
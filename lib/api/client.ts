@@ -18,17 +18,17 @@ const getRefreshToken = (): string | null => {
   return null;
 };
 
-let isRefreshing = false;
 let refreshPromise: Promise<string> | null = null;
 
-// Refresh the access token using refresh token
-async function refreshAccessToken(): Promise<string> {
-  // If already refreshing, wait for that promise
-  if (isRefreshing && refreshPromise) {
+// Exchange the stored refresh token for new tokens, store them and resolve
+// with the new access token. Every refresh in the app goes through here so
+// concurrent callers share one request: refresh tokens are single-use, and
+// exchanging the same one twice looks like token theft to the API.
+export function refreshSessionTokens(): Promise<string> {
+  if (refreshPromise) {
     return refreshPromise;
   }
 
-  isRefreshing = true;
   refreshPromise = (async () => {
     try {
       const refreshToken = getRefreshToken();
@@ -50,24 +50,15 @@ async function refreshAccessToken(): Promise<string> {
 
       const data = await response.json();
 
-      // Update stored tokens
+      // Update stored tokens. Refresh tokens are single-use: the API
+      // returns a new one on every refresh.
       localStorage.setItem('access_token', data.access_token);
-
-      return data.access_token;
-    } catch (error) {
-      // Refresh failed - clear tokens and redirect to login
-      localStorage.removeItem('access_token');
-      localStorage.removeItem('refresh_token');
-      localStorage.removeItem('user');
-
-      // Redirect to login page
-      if (typeof window !== 'undefined') {
-        window.location.href = '/auth/login?session_expired=true';
+      if (data.refresh_token) {
+        localStorage.setItem('refresh_token', data.refresh_token);
       }
 
-      throw error;
+      return data.access_token;
     } finally {
-      isRefreshing = false;
       refreshPromise = null;
     }
   })();
@@ -75,6 +66,25 @@ async function refreshAccessToken(): Promise<string> {
   return refreshPromise;
 }
 
+// Refresh the access token, signing out if the session can't be refreshed
+async function refreshAccessToken(): Promise<string> {
+  try {
+    return await refreshSessionTokens();
+  } catch (error) {
+    // Refresh failed - clear tokens and redirect to login
+    localStorage.removeItem('access_token');
+    localStorage.removeItem('refresh_token');
+    localStorage.removeItem('user');
+
+    // Redirect to login page
+    if (typeof window !== 'undefined') {
+      window.location.href = '/auth/login?session_expired=true';
+    }
+
+    throw error;
+  }
+}
+
 // Make an authenticated request with automatic token refresh
 async function makeRequest<T>(endpoint: string, options: RequestInit, retryCount = 0): Promise<T> {
   const token = getAuthToken();
@@ -10,9 +10,11 @@ import type {
   SentimentResponse,
   SentimentHistoryResponse,
   TrendingResponse,
+  RepresentativePostsEnvelope,
   RepresentativePostsResponse,
   TrendingPeriod,
   PostSortOption,
+  PostSource,
 } from '@/lib/types/sentiment';
 
 /**
@@ -55,16 +57,31 @@ export async function getTrendingSentiment(
 /**
  * Get representative social media posts for a ticker
  * @param ticker - Stock ticker symbol
- * @param sort - Sort order (recent, engagement, bullish, bearish)
+ * @param sort - Sort order (recent, engagement, upvotes, confidence, bullish, bearish)
  * @param limit - Maximum number of posts
+ * @param options - Optional pagination offset and source/subreddit filters
  * @returns List of curated posts
  */
 export async function getSentimentPosts(
   ticker: string,
   sort: PostSortOption = 'recent',
-  limit: number = 10
+  limit: number = 10,
+  options: { offset?: number; source?: PostSource; subreddit?: string } = {}
 ): Promise<RepresentativePostsResponse> {
-  return apiClient.get<RepresentativePostsResponse>(
-    `${sentiment.posts(ticker.toUpperCase())}?sort=${sort}&limit=${limit}`
+  const params = new URLSearchParams({ sort, limit: String(limit) });
+  if (options.offset) params.set('offset', String(options.offset));
+  if (options.source) params.set('source', options.source);
+  if (options.subreddit) params.set('subreddit', options.subreddit);
+
+  const envelope = await apiClient.get<RepresentativePostsEnvelope>(
+    `${sentiment.posts(ticker.toUpperCase())}?${params.toString()}`
   );
+  return {
+    ticker: envelope.meta?.ticker ?? ticker.toUpperCase(),
+    posts: envelope.data ?? [],
+    total: envelope.meta?.total ?? 0,
+    sort: envelope.meta?.sort ?? sort,
+    offset: envelope.meta?.offset ?? 0,
+    hasMore: envelope.meta?.has_more ?? false,
+  };
 }
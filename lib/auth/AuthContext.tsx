@@ -4,6 +4,7 @@ import React, { createContext, useContext, useState, useEffect } from 'react';
 import { useRouter } from 'next/navigation';
 import { auth } from '@/lib/api/routes';
 import { API_BASE_URL } from '@/lib/api';
+import { refreshSessionTokens } from '@/lib/api/client';
 
 interface User {
   id: string;
@@ -47,7 +48,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       setUser(JSON.parse(storedUser));
     } else if (storedRefreshToken) {
       // Try to refresh token
-      refreshTokens(storedRefreshToken);
+      refreshTokens();
     }
 
     setLoading(false);
@@ -60,9 +61,8 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     // Refresh token 5 minutes before expiry (assuming 1 hour expiry = 55 min interval)
     const refreshInterval = setInterval(
       () => {
-        const refreshToken = localStorage.getItem('refresh_token');
-        if (refreshToken) {
-          refreshTokens(refreshToken);
+        if (localStorage.getItem('refresh_token')) {
+          refreshTokens();
         }
       },
       55 * 60 * 1000
@@ -71,22 +71,11 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     return () => clearInterval(refreshInterval);
   }, [accessToken]);
 
-  const refreshTokens = async (refreshToken: string) => {
+  // Shares the API client's in-flight refresh, so a scheduled refresh and a
+  // request retrying after a 401 never exchange the same refresh token twice
+  const refreshTokens = async () => {
     try {
-      const response = await fetch(`${API_BASE_URL}${auth.refresh}`, {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ refresh_token: refreshToken }),
-      });
-
-      if (response.ok) {
-        const data = await response.json();
-        setAccessToken(data.access_token);
-        localStorage.setItem('access_token', data.access_token);
-      } else {
-        // Refresh failed, logout
-        logout();
-      }
+      setAccessToken(await refreshSessionTokens());
     } catch (error) {
       console.error('Failed to refresh token:', error);
       logout();
@@ -171,9 +160,8 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
   };
 
   const refreshAuth = async () => {
-    const refreshToken = localStorage.getItem('refresh_token');
-    if (refreshToken) {
-      await refreshTokens(refreshToken);
+    if (localStorage.getItem('refresh_token')) {
+      await refreshTokens();
     }
   };
 
@@ -107,19 +107,51 @@ export interface RepresentativePost {
 }
 
 /**
- * GET /api/v1/sentiment/:ticker/posts?sort=recent|engagement|bullish|bearish&limit=N
+ * Raw envelope returned by
+ * GET /api/v1/sentiment/:ticker/posts?sort=...&limit=N&offset=N&source=...&subreddit=...
+ */
+export interface RepresentativePostsEnvelope {
+  data: RepresentativePost[];
+  meta: {
+    ticker: string;
+    total: number;
+    sort: string;
+    source: string;
+    subreddit: string;
+    limit: number;
+    offset: number;
+    has_more: boolean;
+    timestamp: string;
+  };
+}
+
+/**
+ * Flattened posts response used by components
  */
 export interface RepresentativePostsResponse {
   ticker: string;
   posts: RepresentativePost[];
   total: number;
   sort: string; // Sort option that was applied
+  offset: number;
+  hasMore: boolean;
 }
 
 /**
  * Sort options for posts
  */
-export type PostSortOption = 'recent' | 'engagement' | 'bullish' | 'bearish';
+export type PostSortOption =
+  | 'recent'
+  | 'engagement'
+  | 'upvotes'
+  | 'confidence'
+  | 'bullish'
+  | 'bearish';
+
+/**
+ * Source filter for posts
+ */
+export type PostSource = 'reddit' | 'stocktwits';
 
 /**
  * Period options for trending
@@ -0,0 +1,170 @@
+// Command replay runs alert rules against recorded price updates and
+// reports which rules would have fired and when, without writing alert
+// logs or sending notifications. Use it to check new alert types and
+// condition changes against real market moves before release.
+//
+//	go run ./cmd/replay -updates prices.jsonl -rules rules.json
+//
+// The updates file holds one price update message per line, as published
+// to SNS. Rules come from -rules, a JSON array, or else are the active
+// rules for the replayed symbols, read from the database configured by
+// the usual DB_* variables.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"notification-service/config"
+	"notification-service/database"
+	"notification-service/evaluator"
+	"notification-service/models"
+)
+
+// Command line flags
+var (
+	updatesFlag = flag.String("updates", "", "Recorded price updates, one JSON message per line (\"-\" for stdin)")
+	rulesFlag   = flag.String("rules", "", "JSON array of alert rules to replay (default: active rules from the database)")
+	jsonFlag    = flag.Bool("json", false, "Print firings as JSON lines")
+)
+
+// ruleFile is an alert rule in a -rules file
+type ruleFile struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Symbol     string          `json:"symbol"`
+	AlertType  string          `json:"alert_type"`
+	Conditions json.RawMessage `json:"conditions"`
+	Frequency  string          `json:"frequency"` // default "always"
+}
+
+func main() {
+	flag.Parse()
+	if *updatesFlag == "" {
+		log.Fatal("-updates is required")
+	}
+
+	updates, err := readUpdates(*updatesFlag)
+	if err != nil {
+		log.Fatalf("Failed to read price updates: %v", err)
+	}
+
+	var rules []models.AlertRule
+	if *rulesFlag != "" {
+		f, err := os.Open(*rulesFlag)
+		if err != nil {
+			log.Fatalf("Failed to open rules: %v", err)
+		}
+		rules, err = readRules(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to read rules: %v", err)
+		}
+	} else {
+		db := database.Initialize(config.Load())
+		defer db.Close()
+		rules, err = db.GetActiveAlertsForSymbols(context.Background(), replayedSymbols(updates))
+		if err != nil {
+			log.Fatalf("Failed to load alert rules: %v", err)
+		}
+	}
+
+	log.Printf("Replaying %d price updates against %d rules", len(updates), len(rules))
+	firings, err := evaluator.Replay(rules, updates)
+	if err != nil {
+		log.Fatalf("Replay failed: %v", err)
+	}
+
+	if err := writeFirings(os.Stdout, firings, *jsonFlag); err != nil {
+		log.Fatalf("Failed to write firings: %v", err)
+	}
+	log.Printf("%d firings", len(firings))
+}
+
+func readUpdates(path string) ([]models.PriceUpdateMessage, error) {
+	if path == "-" {
+		return evaluator.ReadPriceUpdates(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return evaluator.ReadPriceUpdates(f)
+}
+
+// readRules parses a -rules file. Rules without an ID are numbered, and
+// rules without a frequency fire on every match (after the 5-minute
+// cooldown).
+func readRules(r io.Reader) ([]models.AlertRule, error) {
+	var entries []ruleFile
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	rules := make([]models.AlertRule, len(entries))
+	for i, e := range entries {
+		if e.Symbol == "" || e.AlertType == "" {
+			return nil, fmt.Errorf("rule %d: symbol and alert_type are required", i+1)
+		}
+		if e.ID == "" {
+			e.ID = fmt.Sprintf("rule-%d", i+1)
+		}
+		if e.Frequency == "" {
+			e.Frequency = "always"
+		}
+		rules[i] = models.AlertRule{
+			ID:         e.ID,
+			Name:       e.Name,
+			Symbol:     e.Symbol,
+			AlertType:  e.AlertType,
+			Conditions: e.Conditions,
+			Frequency:  e.Frequency,
+			IsActive:   true,
+		}
+	}
+	return rules, nil
+}
+
+// replayedSymbols returns every symbol in updates, sorted
+func replayedSymbols(updates []models.PriceUpdateMessage) []string {
+	seen := make(map[string]bool)
+	for _, u := range updates {
+		for s := range u.Symbols {
+			seen[s] = true
+		}
+	}
+	symbols := make([]string, 0, len(seen))
+	for s := range seen {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func writeFirings(w io.Writer, firings []evaluator.ReplayFiring, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		for _, f := range firings {
+			if err := enc.Encode(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tALERT\tSYMBOL\tTYPE\tPRICE\tCHANGE")
+	for _, f := range firings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f\t%+.2f%%\n",
+			f.At.Format(time.RFC3339), f.AlertID, f.Symbol, f.AlertType, f.Quote.Price, f.Quote.ChangePct)
+	}
+	return tw.Flush()
+}
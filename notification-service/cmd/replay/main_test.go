@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/evaluator"
+	"notification-service/models"
+)
+
+func TestReadRules(t *testing.T) {
+	rules, err := readRules(strings.NewReader(`[
+		{"symbol": "AAPL", "alert_type": "price_above", "conditions": {"threshold": 200}},
+		{"id": "msft", "symbol": "MSFT", "alert_type": "price_below", "conditions": {"threshold": 380}, "frequency": "once"}
+	]`))
+	if err != nil {
+		t.Fatalf("readRules: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if rules[0].ID != "rule-1" || rules[0].Frequency != "always" || string(rules[0].Conditions) != `{"threshold": 200}` {
+		t.Errorf("unexpected defaults: %+v", rules[0])
+	}
+	if rules[1].ID != "msft" || rules[1].Frequency != "once" {
+		t.Errorf("unexpected rule: %+v", rules[1])
+	}
+
+	if _, err := readRules(strings.NewReader(`[{"symbol": "AAPL"}]`)); err == nil {
+		t.Error("expected an error for a rule without alert_type")
+	}
+}
+
+func TestReplayedSymbols(t *testing.T) {
+	updates := []models.PriceUpdateMessage{
+		{Symbols: map[string]models.SymbolQuote{"MSFT": {}, "AAPL": {}}},
+		{Symbols: map[string]models.SymbolQuote{"AAPL": {}, "NVDA": {}}},
+	}
+	got := replayedSymbols(updates)
+	if strings.Join(got, ",") != "AAPL,MSFT,NVDA" {
+		t.Errorf("replayedSymbols = %v", got)
+	}
+}
+
+func TestWriteFirings(t *testing.T) {
+	firings := []evaluator.ReplayFiring{{
+		AlertID: "rule-1", Symbol: "AAPL", AlertType: "price_above",
+		At:    time.Unix(1741617060, 0).UTC(),
+		Quote: models.SymbolQuote{Price: 201, ChangePct: 2},
+	}}
+
+	var table bytes.Buffer
+	if err := writeFirings(&table, firings, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "2025-03-10T14:31:00Z  rule-1") || !strings.Contains(table.String(), "+2.00%") {
+		t.Errorf("unexpected table:\n%s", table.String())
+	}
+
+	var lines bytes.Buffer
+	if err := writeFirings(&lines, firings, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lines.String(), `"alert_id":"rule-1"`) {
+		t.Errorf("unexpected JSON output: %s", lines.String())
+	}
+}
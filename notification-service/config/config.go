@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"time"
 )
 
 // RedactedString wraps a sensitive string value to prevent accidental logging.
@@ -47,6 +49,22 @@ type Config struct {
 	// SQS Consumer settings
 	SQSMaxMessages int32 // Max messages per poll (1-10, default 1)
 
+	// How long a handled message's idempotency key is remembered, so SQS
+	// redeliveries of it are skipped. 0 handles every copy.
+	MessageDedupeTTL time.Duration
+
+	// Scheduled evaluation of alert types not driven by price ticks
+	// (volume_spike, ic_score, dividend). 0 disables.
+	ScheduledEvalInterval time.Duration
+
+	// How often triggered alerts are re-sent on the channels that failed.
+	// 0 disables.
+	DeliveryRetryInterval time.Duration
+
+	// Minimum time between evaluations of one symbol's price alerts; updates
+	// in between are coalesced. 0 evaluates every update.
+	PriceEvalInterval time.Duration
+
 	// Database
 	DBHost     string
 	DBPort     string
@@ -65,11 +83,22 @@ type Config struct {
 	SMTPFromEmail string
 	SMTPFromName  string
 
+	// SMS (Twilio). Alerts aren't texted when these are unset.
+	TwilioAccountSID string
+	TwilioAuthToken  RedactedString
+	TwilioFromNumber string
+
 	// Frontend URL (for email links)
 	FrontendURL string
 
 	// Canary token for authenticated test endpoints
 	CanaryToken string
+
+	// SES bounce/complaint feedback over SNS: the token the subscription
+	// URL carries and the one topic accepted. Both are required; the
+	// webhook rejects every delivery until they are set.
+	SESFeedbackToken    string
+	SESFeedbackTopicARN string
 }
 
 // Load reads configuration from environment variables.
@@ -89,6 +118,12 @@ func Load() *Config {
 		SQSQueueURL:    getEnv("SQS_QUEUE_URL", ""),
 		SQSMaxMessages: maxMessages,
 
+		MessageDedupeTTL: getDurationEnv("MESSAGE_DEDUPE_TTL", time.Hour),
+
+		ScheduledEvalInterval: getDurationEnv("SCHEDULED_EVAL_INTERVAL", 15*time.Minute),
+		DeliveryRetryInterval: getDurationEnv("DELIVERY_RETRY_INTERVAL", 5*time.Minute),
+		PriceEvalInterval:     getDurationEnv("PRICE_EVAL_INTERVAL", 0),
+
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBName:     getEnv("DB_NAME", "investorcenter_db"),
@@ -105,9 +140,16 @@ func Load() *Config {
 		SMTPFromEmail: getEnv("SMTP_FROM_EMAIL", "alerts@investorcenter.ai"),
 		SMTPFromName:  getEnv("SMTP_FROM_NAME", "InvestorCenter Alerts"),
 
+		TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+		TwilioAuthToken:  RedactedString{val: getEnv("TWILIO_AUTH_TOKEN", "")},
+		TwilioFromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
+
 		FrontendURL: getEnv("FRONTEND_URL", "https://investorcenter.ai"),
 
 		CanaryToken: getEnv("CANARY_TOKEN", ""),
+
+		SESFeedbackToken:    getEnv("SES_FEEDBACK_TOKEN", ""),
+		SESFeedbackTopicARN: getEnv("SES_FEEDBACK_TOPIC_ARN", ""),
 	}
 }
 
@@ -117,3 +159,18 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getDurationEnv parses a non-negative duration such as "15m", logging and
+// returning fallback if the value is invalid.
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("Invalid %s %q, using %v", key, v, fallback)
+		return fallback
+	}
+	return d
+}
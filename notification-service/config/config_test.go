@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -106,6 +107,7 @@ func TestLoad_WithEnvVars(t *testing.T) {
 	t.Setenv("SMTP_HOST", "smtp.example.com")
 	t.Setenv("SMTP_PASSWORD", "s3cret!")
 	t.Setenv("CANARY_TOKEN", "canary-tok-42")
+	t.Setenv("SES_FEEDBACK_TOKEN", "ses-tok-7")
 	t.Setenv("FRONTEND_URL", "https://app.example.com")
 
 	cfg := Load()
@@ -134,6 +136,9 @@ func TestLoad_WithEnvVars(t *testing.T) {
 	if cfg.FrontendURL != "https://app.example.com" {
 		t.Errorf("FrontendURL = %s, want https://app.example.com", cfg.FrontendURL)
 	}
+	if cfg.SESFeedbackToken != "ses-tok-7" {
+		t.Errorf("SESFeedbackToken = %s, want ses-tok-7", cfg.SESFeedbackToken)
+	}
 }
 
 func TestLoad_SQSMaxMessages_Valid(t *testing.T) {
@@ -175,3 +180,70 @@ func TestLoad_SQSMaxMessages_Zero(t *testing.T) {
 		t.Errorf("SQSMaxMessages = %d, want 1 (fallback for zero)", cfg.SQSMaxMessages)
 	}
 }
+
+func TestLoad_ScheduledEvalInterval(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 15 * time.Minute},
+		{"1h", time.Hour},
+		{"0", 0},
+		{"soon", 15 * time.Minute},
+		{"-5m", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Setenv("SCHEDULED_EVAL_INTERVAL", tt.env)
+
+		cfg := Load()
+
+		if cfg.ScheduledEvalInterval != tt.want {
+			t.Errorf("SCHEDULED_EVAL_INTERVAL=%q: ScheduledEvalInterval = %v, want %v", tt.env, cfg.ScheduledEvalInterval, tt.want)
+		}
+	}
+}
+
+func TestLoad_DeliveryRetryInterval(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 5 * time.Minute},
+		{"1m", time.Minute},
+		{"0", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("DELIVERY_RETRY_INTERVAL", tt.env)
+
+		if got := Load().DeliveryRetryInterval; got != tt.want {
+			t.Errorf("DELIVERY_RETRY_INTERVAL=%q: DeliveryRetryInterval = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_MessageDedupeTTL(t *testing.T) {
+	if cfg := Load(); cfg.MessageDedupeTTL != time.Hour {
+		t.Errorf("MessageDedupeTTL = %v, want 1h by default", cfg.MessageDedupeTTL)
+	}
+
+	t.Setenv("MESSAGE_DEDUPE_TTL", "0")
+	if cfg := Load(); cfg.MessageDedupeTTL != 0 {
+		t.Errorf("MessageDedupeTTL = %v, want 0 (disabled)", cfg.MessageDedupeTTL)
+	}
+}
+
+func TestLoad_PriceEvalInterval(t *testing.T) {
+	if cfg := Load(); cfg.PriceEvalInterval != 0 {
+		t.Errorf("PriceEvalInterval = %v, want 0 (disabled) by default", cfg.PriceEvalInterval)
+	}
+
+	t.Setenv("PRICE_EVAL_INTERVAL", "5s")
+	if cfg := Load(); cfg.PriceEvalInterval != 5*time.Second {
+		t.Errorf("PriceEvalInterval = %v, want 5s", cfg.PriceEvalInterval)
+	}
+
+	t.Setenv("PRICE_EVAL_INTERVAL", "fast")
+	if cfg := Load(); cfg.PriceEvalInterval != 0 {
+		t.Errorf("PriceEvalInterval = %v, want 0 (fallback for invalid)", cfg.PriceEvalInterval)
+	}
+}
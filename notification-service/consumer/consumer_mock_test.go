@@ -11,6 +11,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/tracing/tracingtest"
 )
 
 // ---------------------------------------------------------------------------
@@ -114,7 +119,7 @@ func TestPoll_SuccessfulReceiveAndDelete(t *testing.T) {
 
 	c := newTestConsumer(mock)
 
-	handler := func(msg []byte) error {
+	handler := func(_ context.Context, msg []byte) error {
 		handlerCalled = true
 		if string(msg) != innerPayload {
 			t.Errorf("handler got %q, want %q", string(msg), innerPayload)
@@ -144,7 +149,7 @@ func TestPoll_ZeroMessages_NoHandlerCall(t *testing.T) {
 	c := newTestConsumer(mock)
 	handlerCalled := false
 
-	handler := func(msg []byte) error {
+	handler := func(_ context.Context, msg []byte) error {
 		handlerCalled = true
 		return nil
 	}
@@ -165,7 +170,7 @@ func TestPoll_ReceiveError_IncrementsFailsStaysHealthy(t *testing.T) {
 
 	c := newTestConsumer(mock)
 
-	c.poll(context.Background(), func(msg []byte) error { return nil })
+	c.poll(context.Background(), func(_ context.Context, msg []byte) error { return nil })
 
 	fails := atomic.LoadInt32(&c.consecutiveFails)
 	if fails != 1 {
@@ -184,7 +189,7 @@ func TestPoll_ReceiveError_ThreeTimesMarksUnhealthy(t *testing.T) {
 	}
 
 	c := newTestConsumer(mock)
-	handler := func(msg []byte) error { return nil }
+	handler := func(_ context.Context, msg []byte) error { return nil }
 
 	// Fail maxConsecutiveFailures times (3)
 	for i := 0; i < int(maxConsecutiveFailures); i++ {
@@ -215,7 +220,7 @@ func TestPoll_SuccessAfterFailures_ResetsAndMarksHealthy(t *testing.T) {
 	}
 
 	c := newTestConsumer(mock)
-	handler := func(msg []byte) error { return nil }
+	handler := func(_ context.Context, msg []byte) error { return nil }
 
 	// Two failures
 	c.poll(context.Background(), handler)
@@ -258,7 +263,7 @@ func TestPoll_HandlerError_MessageNotDeleted(t *testing.T) {
 
 	c := newTestConsumer(mock)
 
-	handler := func(msg []byte) error {
+	handler := func(_ context.Context, msg []byte) error {
 		return errors.New("processing failed")
 	}
 
@@ -295,7 +300,7 @@ func TestPoll_NilBody_DeletesMessageAnyway(t *testing.T) {
 	c := newTestConsumer(mock)
 	handlerCalled := false
 
-	handler := func(msg []byte) error {
+	handler := func(_ context.Context, msg []byte) error {
 		handlerCalled = true
 		return nil
 	}
@@ -332,7 +337,7 @@ func TestPoll_ContextCancelled_ReturnsWithoutSleep(t *testing.T) {
 	}
 	c.healthy.Store(true)
 
-	c.poll(ctx, func(msg []byte) error { return nil })
+	c.poll(ctx, func(_ context.Context, msg []byte) error { return nil })
 
 	if sleepCalled {
 		t.Fatal("sleep should not be called when context is cancelled")
@@ -362,7 +367,7 @@ func TestStart_ContextCancelled_StopsAndMarksUnhealthy(t *testing.T) {
 
 	done := make(chan struct{})
 	go func() {
-		c.Start(ctx, func(msg []byte) error { return nil })
+		c.Start(ctx, func(_ context.Context, msg []byte) error { return nil })
 		close(done)
 	}()
 
@@ -419,3 +424,128 @@ func TestDeleteMessage_Error_NoPanic(t *testing.T) {
 	c.deleteMessage(context.Background(), aws.String("receipt-fail"))
 	// If we reach here without panicking, the test passes.
 }
+
+// ---------------------------------------------------------------------------
+// Trace propagation tests
+// ---------------------------------------------------------------------------
+
+const (
+	publisherTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	publisherSpanID      = "00f067aa0ba902b7"
+	publisherTraceparent = "00-" + publisherTraceID + "-" + publisherSpanID + "-01"
+)
+
+func TestPoll_ProcessSpanContinuesPublisherTrace(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	// SNS puts the publisher's message attributes in the envelope
+	body := `{"Type":"Notification","Message":"{}","MessageAttributes":{"traceparent":{"Type":"String","Value":"` + publisherTraceparent + `"}}}`
+	mock := &mockSQSClient{
+		receiveFn: func(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			return &sqs.ReceiveMessageOutput{
+				Messages: []sqstypes.Message{{Body: aws.String(body), ReceiptHandle: aws.String("receipt-1")}},
+			}, nil
+		},
+	}
+
+	c := newTestConsumer(mock)
+	c.poll(context.Background(), func(_ context.Context, msg []byte) error { return nil })
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "price_updates process" {
+		t.Errorf("span name = %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("span kind = %v, want consumer", span.SpanKind())
+	}
+	if got := span.SpanContext().TraceID().String(); got != publisherTraceID {
+		t.Errorf("trace ID = %s, want the publisher's %s", got, publisherTraceID)
+	}
+	if got := span.Parent().SpanID().String(); got != publisherSpanID {
+		t.Errorf("parent span ID = %s, want %s", got, publisherSpanID)
+	}
+}
+
+func TestPoll_HandlerSpansAreChildrenOfProcessSpan(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	mock := &mockSQSClient{
+		receiveFn: func(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			return &sqs.ReceiveMessageOutput{
+				Messages: []sqstypes.Message{{Body: snsEnvelope("{}"), ReceiptHandle: aws.String("receipt-1")}},
+			}, nil
+		},
+	}
+
+	c := newTestConsumer(mock)
+	c.poll(context.Background(), func(ctx context.Context, msg []byte) error {
+		_, span := otel.Tracer("test").Start(ctx, "deliver email")
+		span.End()
+		return nil
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	child, process := spans[0], spans[1]
+	if process.Name() != "price_updates process" {
+		t.Fatalf("last span = %q, want the process span", process.Name())
+	}
+	if child.Parent().SpanID() != process.SpanContext().SpanID() {
+		t.Errorf("handler span parent = %s, want the process span %s", child.Parent().SpanID(), process.SpanContext().SpanID())
+	}
+}
+
+func TestPoll_HandlerErrorRecordedOnSpan(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	mock := &mockSQSClient{
+		receiveFn: func(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			return &sqs.ReceiveMessageOutput{
+				Messages: []sqstypes.Message{{
+					Body:          snsEnvelope("{}"),
+					ReceiptHandle: aws.String("receipt-1"),
+					// Raw message delivery puts the attributes on the SQS message
+					MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+						"traceparent": {DataType: aws.String("String"), StringValue: aws.String(publisherTraceparent)},
+					},
+				}},
+			}, nil
+		},
+	}
+
+	c := newTestConsumer(mock)
+	c.poll(context.Background(), func(_ context.Context, msg []byte) error { return errors.New("db down") })
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != publisherTraceID {
+		t.Errorf("trace ID = %s, want the publisher's %s", got, publisherTraceID)
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("span status = %v, want error", spans[0].Status().Code)
+	}
+}
+
+func TestPoll_RequestsMessageAttributes(t *testing.T) {
+	var names []string
+	mock := &mockSQSClient{
+		receiveFn: func(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			names = params.MessageAttributeNames
+			return &sqs.ReceiveMessageOutput{}, nil
+		},
+	}
+
+	newTestConsumer(mock).poll(context.Background(), func(_ context.Context, msg []byte) error { return nil })
+
+	if len(names) != 1 || names[0] != "All" {
+		t.Errorf("MessageAttributeNames = %v, want [All]", names)
+	}
+}
@@ -0,0 +1,227 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"notification-service/delivery"
+	"notification-service/evaluator"
+	"notification-service/models"
+)
+
+// memDeduper is an in-memory Deduper with the same claim semantics as the
+// processed_messages table.
+type memDeduper struct {
+	mu       sync.Mutex
+	expires  map[string]time.Time
+	claimErr error
+	released []string
+}
+
+func newMemDeduper() *memDeduper { return &memDeduper{expires: make(map[string]time.Time)} }
+
+func (d *memDeduper) ClaimMessage(id string, lease time.Duration) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.claimErr != nil {
+		return false, d.claimErr
+	}
+	if exp, ok := d.expires[id]; ok && time.Now().Before(exp) {
+		return false, nil
+	}
+	d.expires[id] = time.Now().Add(lease)
+	return true, nil
+}
+
+func (d *memDeduper) CompleteMessage(id string, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expires[id] = time.Now().Add(ttl)
+	return nil
+}
+
+func (d *memDeduper) ReleaseMessage(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.expires, id)
+	d.released = append(d.released, id)
+	return nil
+}
+
+func (d *memDeduper) PurgeExpiredMessages() (int64, error) { return 0, nil }
+
+// alertStore is a database.Store holding one always-claimable price_above
+// rule, recording the alert logs created for it.
+type alertStore struct {
+	mu   sync.Mutex
+	logs []*models.AlertLog
+}
+
+func (s *alertStore) GetActiveAlertsForSymbols(_ context.Context, symbols []string) ([]models.AlertRule, error) {
+	return []models.AlertRule{{
+		ID: "alert-1", UserID: "user-1", Symbol: "AAPL", AlertType: "price_above",
+		Conditions: json.RawMessage(`{"threshold": 200}`), Frequency: "always", IsActive: true,
+	}}, nil
+}
+func (s *alertStore) GetActiveAlertsByTypes(context.Context, []string) ([]models.AlertRule, error) {
+	return nil, nil
+}
+func (s *alertStore) GetSymbolSnapshots(context.Context, []string) (map[string]*models.SymbolSnapshot, error) {
+	return nil, nil
+}
+func (s *alertStore) CreateAlertLog(_ context.Context, l *models.AlertLog) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, l)
+	return "log-1", nil
+}
+func (s *alertStore) ClaimAlertTrigger(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+func (s *alertStore) UpdateAlertLogNotificationSent(context.Context, string, bool) error { return nil }
+func (s *alertStore) ReserveDailyCount(context.Context, string, time.Time, string, int) (bool, error) {
+	return true, nil
+}
+func (s *alertStore) ReleaseDailyCount(context.Context, string, time.Time, string) error { return nil }
+func (s *alertStore) GetNotificationPreferences(context.Context, string) (*models.NotificationPreferences, error) {
+	return nil, nil
+}
+func (s *alertStore) GetUserEmail(context.Context, string) (*models.UserEmail, error) {
+	return nil, nil
+}
+func (s *alertStore) IsEmailSuppressed(context.Context, string) (bool, error) { return false, nil }
+func (s *alertStore) GetUserSubscription(context.Context, string) (*models.UserSubscription, error) {
+	return nil, nil
+}
+func (s *alertStore) CreateInAppNotification(context.Context, *models.InAppNotification) error {
+	return nil
+}
+func (s *alertStore) GetAlertRule(context.Context, string) (*models.AlertRule, error) {
+	return nil, nil
+}
+func (s *alertStore) GetRetryableAlertLogs(context.Context, time.Time, int, int) ([]models.AlertLog, error) {
+	return nil, nil
+}
+
+// redeliveringSQS returns msg on every receive and records deletions.
+func redeliveringSQS(msg sqstypes.Message, deleted *int) *mockSQSClient {
+	return &mockSQSClient{
+		receiveFn: func(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+			return &sqs.ReceiveMessageOutput{Messages: []sqstypes.Message{msg}}, nil
+		},
+		deleteFn: func(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+			*deleted++
+			return &sqs.DeleteMessageOutput{}, nil
+		},
+	}
+}
+
+func priceUpdateMessage(snsMessageID string) sqstypes.Message {
+	body, _ := json.Marshal(map[string]string{
+		"Type":      "Notification",
+		"MessageId": snsMessageID,
+		"Message":   `{"timestamp": 1741617060, "source": "polygon_snapshot", "symbols": {"AAPL": {"price": 201.5}}}`,
+	})
+	return sqstypes.Message{Body: aws.String(string(body)), ReceiptHandle: aws.String("receipt-1")}
+}
+
+func TestPoll_DuplicateDeliveryFiresAlertOnce(t *testing.T) {
+	store := &alertStore{}
+	eval := evaluator.New(store, delivery.NewRouter())
+
+	var deleted int
+	c := newTestConsumer(redeliveringSQS(priceUpdateMessage("sns-msg-1"), &deleted))
+	c.SetDeduper(newMemDeduper(), time.Hour)
+
+	c.poll(context.Background(), eval.HandlePriceUpdate)
+	c.poll(context.Background(), eval.HandlePriceUpdate)
+
+	if len(store.logs) != 1 {
+		t.Fatalf("expected 1 alert log, got %d", len(store.logs))
+	}
+	if deleted != 2 {
+		t.Errorf("expected both copies to be acked, got %d deletes", deleted)
+	}
+}
+
+func TestPoll_WithoutDeduperHandlesEveryCopy(t *testing.T) {
+	store := &alertStore{}
+	eval := evaluator.New(store, delivery.NewRouter())
+
+	var deleted int
+	c := newTestConsumer(redeliveringSQS(priceUpdateMessage("sns-msg-1"), &deleted))
+
+	c.poll(context.Background(), eval.HandlePriceUpdate)
+	c.poll(context.Background(), eval.HandlePriceUpdate)
+
+	if len(store.logs) != 2 {
+		t.Fatalf("expected 2 alert logs without dedupe, got %d", len(store.logs))
+	}
+}
+
+func TestPoll_HandlerErrorReleasesClaim(t *testing.T) {
+	var deleted int
+	c := newTestConsumer(redeliveringSQS(priceUpdateMessage("sns-msg-1"), &deleted))
+	dedupe := newMemDeduper()
+	c.SetDeduper(dedupe, time.Hour)
+
+	calls := 0
+	handler := func(context.Context, []byte) error {
+		calls++
+		if calls == 1 {
+			return errors.New("db down")
+		}
+		return nil
+	}
+	c.poll(context.Background(), handler)
+	c.poll(context.Background(), handler)
+
+	if calls != 2 {
+		t.Errorf("expected the retry to be handled, got %d handler calls", calls)
+	}
+	if len(dedupe.released) != 1 || dedupe.released[0] != "sns-msg-1" {
+		t.Errorf("expected the failed claim to be released, got %v", dedupe.released)
+	}
+	if deleted != 1 {
+		t.Errorf("expected only the successful attempt to be acked, got %d", deleted)
+	}
+}
+
+func TestPoll_ClaimErrorHandlesAnyway(t *testing.T) {
+	var deleted int
+	c := newTestConsumer(redeliveringSQS(priceUpdateMessage("sns-msg-1"), &deleted))
+	dedupe := newMemDeduper()
+	dedupe.claimErr = errors.New("db down")
+	c.SetDeduper(dedupe, time.Hour)
+
+	calls := 0
+	c.poll(context.Background(), func(context.Context, []byte) error { calls++; return nil })
+
+	if calls != 1 || deleted != 1 {
+		t.Errorf("expected the message to be handled and acked, got %d calls, %d deletes", calls, deleted)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	msg := priceUpdateMessage("sns-msg-1")
+	msg.MessageId = aws.String("sqs-msg-1")
+
+	if got := idempotencyKey(msg, map[string]string{models.PriceUpdateIdempotencyKey: "abc123"}); got != "abc123" {
+		t.Errorf("expected the publisher's key, got %q", got)
+	}
+	if got := idempotencyKey(msg, nil); got != "sns-msg-1" {
+		t.Errorf("expected the SNS message ID, got %q", got)
+	}
+	raw := sqstypes.Message{Body: aws.String(`{"timestamp": 1}`), MessageId: aws.String("sqs-msg-1")}
+	if got := idempotencyKey(raw, nil); got != "sqs-msg-1" {
+		t.Errorf("expected the SQS message ID for a raw message, got %q", got)
+	}
+}
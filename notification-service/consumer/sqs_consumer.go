@@ -12,10 +12,15 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/tracing"
+	"notification-service/models"
 )
 
 // Handler processes a raw message payload (the inner SNS Message body).
-type Handler func(msg []byte) error
+// ctx carries the message's processing span.
+type Handler func(ctx context.Context, msg []byte) error
 
 // sqsAPI is the subset of the SQS client used by Consumer.
 // Defined as an interface for testability.
@@ -24,6 +29,16 @@ type sqsAPI interface {
 	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
 }
 
+// Deduper records which messages have been handled, so copies SQS
+// redelivers are acknowledged without running the handler again.
+// Implemented by database.DB.
+type Deduper interface {
+	ClaimMessage(messageID string, lease time.Duration) (bool, error)
+	CompleteMessage(messageID string, ttl time.Duration) error
+	ReleaseMessage(messageID string) error
+	PurgeExpiredMessages() (int64, error)
+}
+
 // Consumer long-polls an SQS queue and dispatches messages to a handler.
 type Consumer struct {
 	client           sqsAPI
@@ -32,6 +47,10 @@ type Consumer struct {
 	healthy          atomic.Bool
 	consecutiveFails int32               // tracks consecutive SQS receive failures
 	sleepFn          func(time.Duration) // injectable for testing
+
+	dedupe    Deduper // nil handles every copy
+	dedupeTTL time.Duration
+	lastPurge time.Time
 }
 
 // maxConsecutiveFailures is the number of consecutive SQS receive errors before
@@ -39,6 +58,13 @@ type Consumer struct {
 // causing unnecessary K8s pod restarts.
 const maxConsecutiveFailures = 3
 
+// visibilityTimeout is how long a received message stays hidden from other
+// receives; a message that isn't deleted by then is redelivered.
+const visibilityTimeout = 30 * time.Second
+
+// dedupePurgeInterval is how often expired dedupe records are deleted.
+const dedupePurgeInterval = time.Hour
+
 // New creates an SQS consumer for the given queue URL and AWS region.
 // maxMessages controls how many messages to receive per poll (1-10).
 func New(queueURL, region string, maxMessages int32) (*Consumer, error) {
@@ -63,6 +89,15 @@ func New(queueURL, region string, maxMessages int32) (*Consumer, error) {
 	return c, nil
 }
 
+// SetDeduper skips messages already handled, by idempotency key, for ttl
+// after they were handled. Each message is claimed before it is handled,
+// with a lease that ends with its visibility timeout, so a copy received
+// while the original is still being handled is left for redelivery.
+func (c *Consumer) SetDeduper(d Deduper, ttl time.Duration) {
+	c.dedupe = d
+	c.dedupeTTL = ttl
+}
+
 // IsHealthy returns whether the consumer is actively polling.
 func (c *Consumer) IsHealthy() bool {
 	return c.healthy.Load()
@@ -84,6 +119,7 @@ func (c *Consumer) Start(ctx context.Context, handler Handler) {
 			return
 		default:
 			c.poll(ctx, handler)
+			c.purgeDedupe()
 		}
 	}
 }
@@ -94,7 +130,9 @@ func (c *Consumer) poll(ctx context.Context, handler Handler) {
 		QueueUrl:            aws.String(c.queueURL),
 		MaxNumberOfMessages: c.maxMessages,
 		WaitTimeSeconds:     20, // Long polling — blocks up to 20s
-		VisibilityTimeout:   30,
+		VisibilityTimeout:   int32(visibilityTimeout / time.Second),
+		// Trace context, when SNS raw message delivery puts it here
+		MessageAttributeNames: []string{"All"},
 	})
 	if err != nil {
 		// Context cancelled is expected during shutdown
@@ -121,23 +159,87 @@ func (c *Consumer) poll(ctx context.Context, handler Handler) {
 	}
 
 	for _, msg := range output.Messages {
-		// SNS wraps the original message in an envelope.
-		// Extract the actual payload from the "Message" field.
-		payload, err := extractSNSPayload(msg.Body)
-		if err != nil {
-			log.Printf("Failed to extract SNS payload: %v — skipping message", err)
+		c.process(ctx, msg, handler)
+	}
+}
+
+// process passes one message to handler and deletes it once handled. Its
+// span continues the trace the publisher attached to the message. With a
+// Deduper, a message that was already handled is deleted unhandled.
+func (c *Consumer) process(ctx context.Context, msg sqstypes.Message, handler Handler) {
+	attrs := messageTraceAttributes(msg)
+	spanCtx, span := tracing.Tracer().Start(
+		tracing.FromMessageAttributes(ctx, attrs),
+		"price_updates process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+	)
+
+	// SNS wraps the original message in an envelope.
+	// Extract the actual payload from the "Message" field.
+	payload, err := extractSNSPayload(msg.Body)
+	if err != nil {
+		tracing.EndSpan(span, err)
+		log.Printf("Failed to extract SNS payload: %v — skipping message", err)
+		c.deleteMessage(ctx, msg.ReceiptHandle)
+		return
+	}
+
+	key := ""
+	if c.dedupe != nil {
+		key = idempotencyKey(msg, attrs)
+	}
+	if key != "" {
+		claimed, err := c.dedupe.ClaimMessage(key, visibilityTimeout)
+		switch {
+		case err != nil:
+			// Better to risk a duplicate alert than to drop the update
+			log.Printf("Warning: failed to claim message %s: %v — handling it anyway", key, err)
+			key = ""
+		case !claimed:
+			tracing.EndSpan(span, nil)
+			log.Printf("Skipping duplicate message %s", key)
 			c.deleteMessage(ctx, msg.ReceiptHandle)
-			continue
+			return
 		}
+	}
 
-		if err := handler(payload); err != nil {
-			log.Printf("Handler error: %v — message will be retried", err)
-			// Don't delete — message returns to queue after visibility timeout
-			continue
+	err = handler(spanCtx, payload)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		log.Printf("Handler error: %v — message will be retried", err)
+		if key != "" {
+			if err := c.dedupe.ReleaseMessage(key); err != nil {
+				log.Printf("Warning: %v", err)
+			}
 		}
+		// Don't delete — message returns to queue after visibility timeout
+		return
+	}
 
-		// Success — delete the message
-		c.deleteMessage(ctx, msg.ReceiptHandle)
+	if key != "" {
+		if err := c.dedupe.CompleteMessage(key, c.dedupeTTL); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Success — delete the message
+	c.deleteMessage(ctx, msg.ReceiptHandle)
+}
+
+// purgeDedupe deletes expired dedupe records, at most once per
+// dedupePurgeInterval.
+func (c *Consumer) purgeDedupe() {
+	if c.dedupe == nil || time.Since(c.lastPurge) < dedupePurgeInterval {
+		return
+	}
+	c.lastPurge = time.Now()
+	n, err := c.dedupe.PurgeExpiredMessages()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d expired processed-message records", n)
 	}
 }
 
@@ -181,5 +283,51 @@ func extractSNSPayload(body *string) ([]byte, error) {
 	return []byte(envelope.Message), nil
 }
 
-// Ensure sqstypes is used (needed for test mock return types).
-var _ sqstypes.Message
+// messageTraceAttributes returns the string attributes a message was
+// published with, which carry its trace context. SNS delivers its message
+// attributes inside the envelope; with raw message delivery they arrive as
+// SQS message attributes instead.
+func messageTraceAttributes(msg sqstypes.Message) map[string]string {
+	attrs := make(map[string]string)
+	for k, v := range msg.MessageAttributes {
+		if v.StringValue != nil {
+			attrs[k] = *v.StringValue
+		}
+	}
+	if msg.Body == nil {
+		return attrs
+	}
+
+	var envelope struct {
+		MessageAttributes map[string]struct {
+			Type  string `json:"Type"`
+			Value string `json:"Value"`
+		} `json:"MessageAttributes"`
+	}
+	if err := json.Unmarshal([]byte(*msg.Body), &envelope); err == nil {
+		for k, v := range envelope.MessageAttributes {
+			if v.Type == "String" {
+				attrs[k] = v.Value
+			}
+		}
+	}
+	return attrs
+}
+
+// idempotencyKey identifies a message for deduplication: the publisher's
+// idempotency key attribute when it set one, else the SNS message ID, which
+// SNS keeps across its own retries, else the SQS message ID.
+func idempotencyKey(msg sqstypes.Message, attrs map[string]string) string {
+	if key := attrs[models.PriceUpdateIdempotencyKey]; key != "" {
+		return key
+	}
+	if msg.Body != nil {
+		var envelope struct {
+			MessageID string `json:"MessageId"`
+		}
+		if err := json.Unmarshal([]byte(*msg.Body), &envelope); err == nil && envelope.MessageID != "" {
+			return envelope.MessageID
+		}
+	}
+	return aws.ToString(msg.MessageId)
+}
@@ -1,18 +1,21 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
-	"time"
+
+	"github.com/lib/pq"
 
 	"notification-service/models"
 )
 
-// GetActiveAlertsForSymbols fetches all active alert rules whose symbol
-// is in the given set. Returns an empty slice if no matches.
-func (db *DB) GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, error) {
+// GetActiveAlertsForSymbols fetches all active, unsnoozed alert rules whose
+// symbol is in the given set. Returns an empty slice if no matches.
+func (db *DB) GetActiveAlertsForSymbols(ctx context.Context, symbols []string) ([]models.AlertRule, error) {
 	if len(symbols) == 0 {
 		return nil, nil
 	}
@@ -28,16 +31,66 @@ func (db *DB) GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, e
 	query := fmt.Sprintf(`
 		SELECT id, user_id, watch_list_id, symbol, alert_type, conditions,
 		       is_active, frequency, notify_email, notify_in_app, name,
-		       last_triggered_at, trigger_count, created_at, updated_at
+		       last_triggered_at, trigger_count, created_at, updated_at, snoozed_until
 		FROM alert_rules
 		WHERE is_active = true AND symbol IN (%s)
+		  AND (snoozed_until IS NULL OR snoozed_until <= NOW())
 		ORDER BY created_at ASC
 	`, strings.Join(placeholders, ", "))
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query active alerts: %w", err)
 	}
+	return scanAlertRules(rows)
+}
+
+// GetActiveAlertsByTypes fetches all active, unsnoozed alert rules of the
+// given types, for the scheduled evaluator. Returns an empty slice if no
+// matches.
+func (db *DB) GetActiveAlertsByTypes(ctx context.Context, alertTypes []string) ([]models.AlertRule, error) {
+	if len(alertTypes) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, user_id, watch_list_id, symbol, alert_type, conditions,
+		       is_active, frequency, notify_email, notify_in_app, name,
+		       last_triggered_at, trigger_count, created_at, updated_at, snoozed_until
+		FROM alert_rules
+		WHERE is_active = true AND alert_type = ANY($1)
+		  AND (snoozed_until IS NULL OR snoozed_until <= NOW())
+		ORDER BY created_at ASC
+	`, pq.Array(alertTypes))
+	if err != nil {
+		return nil, fmt.Errorf("query active alerts by type: %w", err)
+	}
+	return scanAlertRules(rows)
+}
+
+// GetAlertRule fetches one alert rule by ID, active or not. Returns nil
+// if it has been deleted.
+func (db *DB) GetAlertRule(ctx context.Context, alertID string) (*models.AlertRule, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, user_id, watch_list_id, symbol, alert_type, conditions,
+		       is_active, frequency, notify_email, notify_in_app, name,
+		       last_triggered_at, trigger_count, created_at, updated_at, snoozed_until
+		FROM alert_rules
+		WHERE id = $1
+	`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("query alert rule: %w", err)
+	}
+	alerts, err := scanAlertRules(rows)
+	if err != nil || len(alerts) == 0 {
+		return nil, err
+	}
+	return &alerts[0], nil
+}
+
+// scanAlertRules reads alert_rules rows selected with the column list above
+// and closes rows.
+func scanAlertRules(rows *sql.Rows) ([]models.AlertRule, error) {
 	defer rows.Close()
 
 	var alerts []models.AlertRule
@@ -46,7 +99,7 @@ func (db *DB) GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, e
 		if err := rows.Scan(
 			&a.ID, &a.UserID, &a.WatchListID, &a.Symbol, &a.AlertType, &a.Conditions,
 			&a.IsActive, &a.Frequency, &a.NotifyEmail, &a.NotifyInApp, &a.Name,
-			&a.LastTriggeredAt, &a.TriggerCount, &a.CreatedAt, &a.UpdatedAt,
+			&a.LastTriggeredAt, &a.TriggerCount, &a.CreatedAt, &a.UpdatedAt, &a.SnoozedUntil,
 		); err != nil {
 			return nil, fmt.Errorf("scan alert: %w", err)
 		}
@@ -57,7 +110,7 @@ func (db *DB) GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, e
 }
 
 // CreateAlertLog inserts a new alert trigger log and returns the generated ID.
-func (db *DB) CreateAlertLog(alertLog *models.AlertLog) (string, error) {
+func (db *DB) CreateAlertLog(ctx context.Context, alertLog *models.AlertLog) (string, error) {
 	conditionMet, err := json.Marshal(alertLog.ConditionMet)
 	if err != nil {
 		log.Printf("Warning: failed to marshal condition_met for alert %s: %v", alertLog.AlertRuleID, err)
@@ -70,7 +123,7 @@ func (db *DB) CreateAlertLog(alertLog *models.AlertLog) (string, error) {
 	}
 
 	var id string
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		INSERT INTO alert_logs (alert_rule_id, user_id, symbol, alert_type,
 		                        condition_met, market_data, notification_sent)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -95,7 +148,7 @@ func (db *DB) CreateAlertLog(alertLog *models.AlertLog) (string, error) {
 // For "always" alerts: only claims if last_triggered_at is >5min ago or NULL.
 //
 // Returns true if the alert was successfully claimed (row was updated).
-func (db *DB) ClaimAlertTrigger(alertID string, frequency string) (bool, error) {
+func (db *DB) ClaimAlertTrigger(ctx context.Context, alertID string, frequency string) (bool, error) {
 	var query string
 
 	switch frequency {
@@ -131,7 +184,7 @@ func (db *DB) ClaimAlertTrigger(alertID string, frequency string) (bool, error)
 		return false, fmt.Errorf("unknown frequency: %s", frequency)
 	}
 
-	result, err := db.Exec(query, alertID)
+	result, err := db.ExecContext(ctx, query, alertID)
 	if err != nil {
 		return false, fmt.Errorf("claim alert trigger: %w", err)
 	}
@@ -145,29 +198,10 @@ func (db *DB) ClaimAlertTrigger(alertID string, frequency string) (bool, error)
 }
 
 // UpdateAlertLogNotificationSent updates the notification_sent flag on an alert log.
-func (db *DB) UpdateAlertLogNotificationSent(logID string, sent bool) error {
-	_, err := db.Exec(`UPDATE alert_logs SET notification_sent = $1 WHERE id = $2`, sent, logID)
+func (db *DB) UpdateAlertLogNotificationSent(ctx context.Context, logID string, sent bool) error {
+	_, err := db.ExecContext(ctx, `UPDATE alert_logs SET notification_sent = $1 WHERE id = $2`, sent, logID)
 	if err != nil {
 		return fmt.Errorf("update alert log notification_sent: %w", err)
 	}
 	return nil
 }
-
-// GetTodayEmailCount returns the number of alert emails sent today for a user.
-func (db *DB) GetTodayEmailCount(userID string) (int, error) {
-	var count int
-	err := db.QueryRow(`
-		SELECT COUNT(*) FROM alert_logs
-		WHERE user_id = $1 AND triggered_at >= $2 AND notification_sent = true
-	`, userID, todayStart()).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("get today email count: %w", err)
-	}
-	return count, nil
-}
-
-// todayStart returns the start of the current UTC day.
-func todayStart() time.Time {
-	now := time.Now().UTC()
-	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-}
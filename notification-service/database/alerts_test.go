@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -28,7 +29,7 @@ func newMockDB(t *testing.T) (*DB, sqlmock.Sqlmock) {
 func TestGetActiveAlertsForSymbols_EmptySymbols(t *testing.T) {
 	db, mock := newMockDB(t)
 
-	alerts, err := db.GetActiveAlertsForSymbols([]string{})
+	alerts, err := db.GetActiveAlertsForSymbols(context.Background(), []string{})
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -46,12 +47,13 @@ func TestGetActiveAlertsForSymbols_Success(t *testing.T) {
 
 	now := time.Now().UTC().Truncate(time.Second)
 	lastTriggered := now.Add(-1 * time.Hour)
+	snoozeEnded := now.Add(-30 * time.Minute)
 	conditions := json.RawMessage(`{"threshold":150.0}`)
 
 	columns := []string{
 		"id", "user_id", "watch_list_id", "symbol", "alert_type", "conditions",
 		"is_active", "frequency", "notify_email", "notify_in_app", "name",
-		"last_triggered_at", "trigger_count", "created_at", "updated_at",
+		"last_triggered_at", "trigger_count", "created_at", "updated_at", "snoozed_until",
 	}
 
 	rows := sqlmock.NewRows(columns).AddRow(
@@ -70,13 +72,14 @@ func TestGetActiveAlertsForSymbols_Success(t *testing.T) {
 		3,                // trigger_count
 		now,              // created_at
 		now,              // updated_at
+		snoozeEnded,      // snoozed_until
 	)
 
 	mock.ExpectQuery("SELECT id, user_id, watch_list_id, symbol, alert_type, conditions").
 		WithArgs("AAPL").
 		WillReturnRows(rows)
 
-	alerts, err := db.GetActiveAlertsForSymbols([]string{"AAPL"})
+	alerts, err := db.GetActiveAlertsForSymbols(context.Background(), []string{"AAPL"})
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -130,6 +133,9 @@ func TestGetActiveAlertsForSymbols_Success(t *testing.T) {
 	if !a.UpdatedAt.Equal(now) {
 		t.Errorf("expected UpdatedAt %v, got %v", now, a.UpdatedAt)
 	}
+	if a.SnoozedUntil == nil || !a.SnoozedUntil.Equal(snoozeEnded) {
+		t.Errorf("expected SnoozedUntil %v, got %v", snoozeEnded, a.SnoozedUntil)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unexpected mock expectations: %v", err)
@@ -143,7 +149,7 @@ func TestGetActiveAlertsForSymbols_QueryError(t *testing.T) {
 		WithArgs("AAPL").
 		WillReturnError(fmt.Errorf("connection refused"))
 
-	alerts, err := db.GetActiveAlertsForSymbols([]string{"AAPL"})
+	alerts, err := db.GetActiveAlertsForSymbols(context.Background(), []string{"AAPL"})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -167,7 +173,7 @@ func TestGetActiveAlertsForSymbols_ScanError(t *testing.T) {
 		WithArgs("AAPL").
 		WillReturnRows(rows)
 
-	_, err := db.GetActiveAlertsForSymbols([]string{"AAPL"})
+	_, err := db.GetActiveAlertsForSymbols(context.Background(), []string{"AAPL"})
 	if err == nil {
 		t.Fatal("expected scan error, got nil")
 	}
@@ -177,6 +183,83 @@ func TestGetActiveAlertsForSymbols_ScanError(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// GetActiveAlertsByTypes
+// ---------------------------------------------------------------------------
+
+func TestGetActiveAlertsByTypes_Success(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	columns := []string{
+		"id", "user_id", "watch_list_id", "symbol", "alert_type", "conditions",
+		"is_active", "frequency", "notify_email", "notify_in_app", "name",
+		"last_triggered_at", "trigger_count", "created_at", "updated_at", "snoozed_until",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("alert-001", "user-123", "wl-456", "AAPL", "volume_spike",
+			json.RawMessage(`{"volume_multiplier":2}`), true, "daily", true, true,
+			"AAPL volume", nil, 0, now, now, nil).
+		AddRow("alert-002", "user-123", "wl-456", "MSFT", "dividend",
+			json.RawMessage(`{}`), true, "always", true, false,
+			"MSFT dividend", nil, 0, now, now, nil)
+
+	mock.ExpectQuery(regexp.QuoteMeta("alert_type = ANY($1)")).
+		WithArgs(`{"volume_spike","dividend"}`).
+		WillReturnRows(rows)
+
+	alerts, err := db.GetActiveAlertsByTypes(context.Background(), []string{"volume_spike", "dividend"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(alerts))
+	}
+	if alerts[0].AlertType != "volume_spike" || alerts[1].Symbol != "MSFT" {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+	if alerts[0].LastTriggeredAt != nil {
+		t.Errorf("expected nil LastTriggeredAt, got %v", alerts[0].LastTriggeredAt)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestGetActiveAlerts_SkipsSnoozed(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	snoozeFilter := regexp.QuoteMeta("(snoozed_until IS NULL OR snoozed_until <= NOW())")
+	mock.ExpectQuery(snoozeFilter).WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectQuery(snoozeFilter).WillReturnRows(sqlmock.NewRows(nil))
+
+	if _, err := db.GetActiveAlertsForSymbols(context.Background(), []string{"AAPL"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := db.GetActiveAlertsByTypes(context.Background(), []string{"dividend"}); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestGetActiveAlertsByTypes_QueryError(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("alert_type = ANY($1)")).
+		WillReturnError(fmt.Errorf("connection refused"))
+
+	if _, err := db.GetActiveAlertsByTypes(context.Background(), []string{"ic_score"}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // CreateAlertLog
 // ---------------------------------------------------------------------------
@@ -213,7 +296,7 @@ func TestCreateAlertLog_Success(t *testing.T) {
 		).
 		WillReturnRows(rows)
 
-	id, err := db.CreateAlertLog(alertLog)
+	id, err := db.CreateAlertLog(context.Background(), alertLog)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -256,7 +339,7 @@ func TestCreateAlertLog_Error(t *testing.T) {
 		).
 		WillReturnError(fmt.Errorf("unique violation"))
 
-	id, err := db.CreateAlertLog(alertLog)
+	id, err := db.CreateAlertLog(context.Background(), alertLog)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -280,7 +363,7 @@ func TestClaimAlertTrigger_Once_Claimed(t *testing.T) {
 		WithArgs("alert-001").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	claimed, err := db.ClaimAlertTrigger("alert-001", "once")
+	claimed, err := db.ClaimAlertTrigger(context.Background(), "alert-001", "once")
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -300,7 +383,7 @@ func TestClaimAlertTrigger_Once_NotClaimed(t *testing.T) {
 		WithArgs("alert-001").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	claimed, err := db.ClaimAlertTrigger("alert-001", "once")
+	claimed, err := db.ClaimAlertTrigger(context.Background(), "alert-001", "once")
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -320,7 +403,7 @@ func TestClaimAlertTrigger_Daily(t *testing.T) {
 		WithArgs("alert-001").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	claimed, err := db.ClaimAlertTrigger("alert-001", "daily")
+	claimed, err := db.ClaimAlertTrigger(context.Background(), "alert-001", "daily")
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -340,7 +423,7 @@ func TestClaimAlertTrigger_Always(t *testing.T) {
 		WithArgs("alert-001").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	claimed, err := db.ClaimAlertTrigger("alert-001", "always")
+	claimed, err := db.ClaimAlertTrigger(context.Background(), "alert-001", "always")
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -356,7 +439,7 @@ func TestClaimAlertTrigger_Always(t *testing.T) {
 func TestClaimAlertTrigger_UnknownFrequency(t *testing.T) {
 	db, _ := newMockDB(t)
 
-	_, err := db.ClaimAlertTrigger("alert-001", "weekly")
+	_, err := db.ClaimAlertTrigger(context.Background(), "alert-001", "weekly")
 	if err == nil {
 		t.Fatal("expected error for unknown frequency, got nil")
 	}
@@ -372,7 +455,7 @@ func TestClaimAlertTrigger_ExecError(t *testing.T) {
 		WithArgs("alert-001").
 		WillReturnError(fmt.Errorf("deadlock detected"))
 
-	claimed, err := db.ClaimAlertTrigger("alert-001", "once")
+	claimed, err := db.ClaimAlertTrigger(context.Background(), "alert-001", "once")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -396,7 +479,7 @@ func TestUpdateAlertLogNotificationSent_Success(t *testing.T) {
 		WithArgs(true, "log-001").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err := db.UpdateAlertLogNotificationSent("log-001", true)
+	err := db.UpdateAlertLogNotificationSent(context.Background(), "log-001", true)
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -413,7 +496,7 @@ func TestUpdateAlertLogNotificationSent_Error(t *testing.T) {
 		WithArgs(true, "log-001").
 		WillReturnError(fmt.Errorf("connection lost"))
 
-	err := db.UpdateAlertLogNotificationSent("log-001", true)
+	err := db.UpdateAlertLogNotificationSent(context.Background(), "log-001", true)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -422,58 +505,3 @@ func TestUpdateAlertLogNotificationSent_Error(t *testing.T) {
 		t.Errorf("unexpected mock expectations: %v", err)
 	}
 }
-
-// ---------------------------------------------------------------------------
-// GetTodayEmailCount
-// ---------------------------------------------------------------------------
-
-func TestGetTodayEmailCount_Success(t *testing.T) {
-	db, mock := newMockDB(t)
-
-	rows := sqlmock.NewRows([]string{"count"}).AddRow(7)
-
-	// The query uses two arguments: userID and todayStart().
-	// We use sqlmock.AnyArg() for the time argument since todayStart() is
-	// computed inside the function and we cannot predict the exact value.
-	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT COUNT(*) FROM alert_logs
-		WHERE user_id = $1 AND triggered_at >= $2 AND notification_sent = true`,
-	)).
-		WithArgs("user-123", sqlmock.AnyArg()).
-		WillReturnRows(rows)
-
-	count, err := db.GetTodayEmailCount("user-123")
-	if err != nil {
-		t.Fatalf("expected nil error, got %v", err)
-	}
-	if count != 7 {
-		t.Errorf("expected count 7, got %d", count)
-	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unexpected mock expectations: %v", err)
-	}
-}
-
-func TestGetTodayEmailCount_Error(t *testing.T) {
-	db, mock := newMockDB(t)
-
-	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT COUNT(*) FROM alert_logs
-		WHERE user_id = $1 AND triggered_at >= $2 AND notification_sent = true`,
-	)).
-		WithArgs("user-123", sqlmock.AnyArg()).
-		WillReturnError(fmt.Errorf("timeout"))
-
-	count, err := db.GetTodayEmailCount("user-123")
-	if err == nil {
-		t.Fatal("expected error, got nil")
-	}
-	if count != 0 {
-		t.Errorf("expected count 0 on error, got %d", count)
-	}
-
-	if err := mock.ExpectationsWereMet(); err != nil {
-		t.Errorf("unexpected mock expectations: %v", err)
-	}
-}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"log"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
+	"investorcenter-shared/tracing"
 	"notification-service/config"
 )
 
@@ -23,10 +24,12 @@ func Initialize(cfg *config.Config) *DB {
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode,
 	)
 
-	db, err := sql.Open("postgres", connStr)
+	// Queries are traced
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
 		log.Fatalf("Failed to open database: %v", err)
 	}
+	db := tracing.OpenDB(connector)
 
 	// Conservative pool settings for Lambda (reserved concurrency = 1)
 	db.SetMaxOpenConns(2)
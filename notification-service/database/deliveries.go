@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"notification-service/models"
+)
+
+// ClaimDelivery takes a lease on sending the alert log's notification on
+// channel. It succeeds for a channel not yet tried, one that failed fewer
+// than maxAttempts times, or one whose previous lease ran out mid-send.
+// Otherwise it returns false with the channel's current status, so a
+// delivered channel is never sent twice.
+func (db *DB) ClaimDelivery(ctx context.Context, alertLogID, channel string, lease time.Duration, maxAttempts int) (bool, string, error) {
+	var status string
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO alert_deliveries (alert_log_id, channel, status, attempts, lease_until, updated_at)
+		VALUES ($1, $2, 'sending', 1, NOW() + $3 * INTERVAL '1 second', NOW())
+		ON CONFLICT (alert_log_id, channel) DO UPDATE
+		SET status = 'sending',
+		    attempts = alert_deliveries.attempts + 1,
+		    lease_until = EXCLUDED.lease_until,
+		    updated_at = NOW()
+		WHERE alert_deliveries.attempts < $4
+		  AND (alert_deliveries.status = 'failed'
+		       OR (alert_deliveries.status = 'sending' AND alert_deliveries.lease_until <= NOW()))
+		RETURNING status
+	`, alertLogID, channel, lease.Seconds(), maxAttempts).Scan(&status)
+	if err == nil {
+		return true, status, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, "", fmt.Errorf("claim delivery: %w", err)
+	}
+
+	// Not claimable: report where the channel stands
+	err = db.QueryRowContext(ctx, `
+		SELECT status FROM alert_deliveries WHERE alert_log_id = $1 AND channel = $2
+	`, alertLogID, channel).Scan(&status)
+	if err != nil {
+		return false, "", fmt.Errorf("claim delivery: %w", err)
+	}
+	return false, status, nil
+}
+
+// FinishDelivery records the outcome of a claimed send: status is
+// delivered, failed or suppressed, and detail the error, if any.
+func (db *DB) FinishDelivery(ctx context.Context, alertLogID, channel, status, detail string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE alert_deliveries
+		SET status = $3, last_error = NULLIF($4, ''), lease_until = NULL, updated_at = NOW()
+		WHERE alert_log_id = $1 AND channel = $2
+	`, alertLogID, channel, status, detail)
+	if err != nil {
+		return fmt.Errorf("finish delivery: %w", err)
+	}
+	return nil
+}
+
+// GetRetryableAlertLogs returns alert logs triggered after since with a
+// channel that failed fewer than maxAttempts times, oldest first.
+func (db *DB) GetRetryableAlertLogs(ctx context.Context, since time.Time, maxAttempts, limit int) ([]models.AlertLog, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT l.id, l.alert_rule_id, l.user_id, l.symbol, l.triggered_at, l.alert_type,
+		       l.condition_met, l.market_data, l.notification_sent
+		FROM alert_logs l
+		WHERE l.triggered_at > $1
+		  AND EXISTS (
+		      SELECT 1 FROM alert_deliveries d
+		      WHERE d.alert_log_id = l.id AND d.status = 'failed' AND d.attempts < $2
+		  )
+		ORDER BY l.triggered_at ASC
+		LIMIT $3
+	`, since, maxAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query retryable alert logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []models.AlertLog
+	for rows.Next() {
+		var l models.AlertLog
+		if err := rows.Scan(
+			&l.ID, &l.AlertRuleID, &l.UserID, &l.Symbol, &l.TriggeredAt, &l.AlertType,
+			&l.ConditionMet, &l.MarketData, &l.NotificationSent,
+		); err != nil {
+			return nil, fmt.Errorf("scan alert log: %w", err)
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestClaimDelivery_Claimed(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO alert_deliveries`)).
+		WithArgs("log-1", "email", 120.0, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("sending"))
+
+	ok, status, err := db.ClaimDelivery(context.Background(), "log-1", "email", 2*time.Minute, 5)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !ok || status != "sending" {
+		t.Errorf("expected the channel to be claimed, got %v %q", ok, status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestClaimDelivery_AlreadyDelivered(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// The conflict update only takes over failed or expired rows, so a
+	// delivered channel returns no row and its status is looked up.
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO alert_deliveries`)).
+		WithArgs("log-1", "in_app", 120.0, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT status FROM alert_deliveries`)).
+		WithArgs("log-1", "in_app").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("delivered"))
+
+	ok, status, err := db.ClaimDelivery(context.Background(), "log-1", "in_app", 2*time.Minute, 5)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if ok || status != "delivered" {
+		t.Errorf("expected a delivered channel not to be claimed, got %v %q", ok, status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestFinishDelivery(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE alert_deliveries`)).
+		WithArgs("log-1", "email", "failed", "smtp: 421").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := db.FinishDelivery(context.Background(), "log-1", "email", "failed", "smtp: 421"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestGetRetryableAlertLogs(t *testing.T) {
+	db, mock := newMockDB(t)
+	since := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "alert_rule_id", "user_id", "symbol", "triggered_at", "alert_type",
+		"condition_met", "market_data", "notification_sent"}).
+		AddRow("log-1", "alert-1", "user-1", "AAPL", since.Add(time.Hour), "price_above",
+			[]byte(`{}`), []byte(`{"price": 155}`), false)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM alert_logs l`)).
+		WithArgs(since, 5, 100).
+		WillReturnRows(rows)
+
+	logs, err := db.GetRetryableAlertLogs(context.Background(), since, 5, 100)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(logs) != 1 || logs[0].ID != "log-1" || string(logs[0].MarketData) != `{"price": 155}` {
+		t.Errorf("unexpected logs: %+v", logs)
+	}
+}
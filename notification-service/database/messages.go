@@ -0,0 +1,67 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClaimMessage takes a lease on handling the message with the given
+// idempotency key. It returns false when the message was already handled,
+// or another consumer holds an unexpired lease on it. The lease should be
+// no longer than the SQS visibility timeout, so a copy redelivered after
+// a crash mid-handling is claimed again.
+func (db *DB) ClaimMessage(messageID string, lease time.Duration) (bool, error) {
+	res, err := db.Exec(`
+		INSERT INTO processed_messages (message_id, expires_at)
+		VALUES ($1, NOW() + $2 * INTERVAL '1 second')
+		ON CONFLICT (message_id) DO UPDATE
+		SET expires_at = EXCLUDED.expires_at
+		WHERE processed_messages.expires_at <= NOW()
+	`, messageID, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("claim message: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claim message: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CompleteMessage records a claimed message as handled, so copies are
+// skipped until ttl has passed.
+func (db *DB) CompleteMessage(messageID string, ttl time.Duration) error {
+	_, err := db.Exec(`
+		UPDATE processed_messages
+		SET expires_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE message_id = $1
+	`, messageID, ttl.Seconds())
+	if err != nil {
+		return fmt.Errorf("complete message: %w", err)
+	}
+	return nil
+}
+
+// ReleaseMessage gives up the claim on a message that wasn't handled, so
+// its retry is processed.
+func (db *DB) ReleaseMessage(messageID string) error {
+	_, err := db.Exec(`DELETE FROM processed_messages WHERE message_id = $1`, messageID)
+	if err != nil {
+		return fmt.Errorf("release message: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredMessages deletes processed message records past their
+// expiry, returning how many were removed.
+func (db *DB) PurgeExpiredMessages() (int64, error) {
+	res, err := db.Exec(`DELETE FROM processed_messages WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("purge processed messages: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge processed messages: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestClaimMessage_Claimed(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO processed_messages (message_id, expires_at)`)).
+		WithArgs("msg-1", 30.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ok, err := db.ClaimMessage("msg-1", 30*time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !ok {
+		t.Error("expected the message to be claimed")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestClaimMessage_AlreadyHandled(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// The conflict update only takes over expired rows, so a live record
+	// leaves no row affected.
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE processed_messages.expires_at <= NOW()`)).
+		WithArgs("msg-1", 30.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := db.ClaimMessage("msg-1", 30*time.Second)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if ok {
+		t.Error("expected a handled message not to be claimed")
+	}
+}
+
+func TestClaimMessage_Error(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO processed_messages`)).
+		WillReturnError(fmt.Errorf("connection refused"))
+
+	if _, err := db.ClaimMessage("msg-1", 30*time.Second); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCompleteAndReleaseMessage(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE processed_messages`)).
+		WithArgs("msg-1", 3600.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM processed_messages WHERE message_id = $1`)).
+		WithArgs("msg-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := db.CompleteMessage("msg-1", time.Hour); err != nil {
+		t.Fatalf("CompleteMessage: %v", err)
+	}
+	if err := db.ReleaseMessage("msg-2"); err != nil {
+		t.Fatalf("ReleaseMessage: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestPurgeExpiredMessages(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM processed_messages WHERE expires_at <= NOW()`)).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	n, err := db.PurgeExpiredMessages()
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if n != 42 {
+		t.Errorf("expected 42 purged, got %d", n)
+	}
+}
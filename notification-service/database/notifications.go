@@ -1,27 +1,32 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"notification-service/models"
 )
 
 // GetNotificationPreferences retrieves the notification preferences for a user.
 // Returns nil if no preferences row exists.
-func (db *DB) GetNotificationPreferences(userID string) (*models.NotificationPreferences, error) {
+func (db *DB) GetNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
 	var prefs models.NotificationPreferences
-	err := db.QueryRow(`
+	err := db.QueryRowContext(ctx, `
 		SELECT user_id, email_enabled, email_address, email_verified,
 		       quiet_hours_enabled, quiet_hours_start, quiet_hours_end, quiet_hours_timezone,
-		       max_alerts_per_day, max_emails_per_day
+		       max_alerts_per_day, max_emails_per_day, webhook_url, webhook_secret,
+		       sms_enabled, phone_number, phone_verified
 		FROM notification_preferences
 		WHERE user_id = $1
 	`, userID).Scan(
 		&prefs.UserID, &prefs.EmailEnabled, &prefs.EmailAddress, &prefs.EmailVerified,
 		&prefs.QuietHoursEnabled, &prefs.QuietHoursStart, &prefs.QuietHoursEnd, &prefs.QuietHoursTimezone,
-		&prefs.MaxAlertsPerDay, &prefs.MaxEmailsPerDay,
+		&prefs.MaxAlertsPerDay, &prefs.MaxEmailsPerDay, &prefs.WebhookURL, &prefs.WebhookSecret,
+		&prefs.SMSEnabled, &prefs.PhoneNumber, &prefs.PhoneVerified,
 	)
 
 	if err != nil {
@@ -33,15 +38,107 @@ func (db *DB) GetNotificationPreferences(userID string) (*models.NotificationPre
 	return &prefs, nil
 }
 
-// GetUserEmail retrieves the email address and name for a user.
-func (db *DB) GetUserEmail(userID string) (*models.UserEmail, error) {
+// GetUserEmail retrieves the email address, name and timezone for a user.
+func (db *DB) GetUserEmail(ctx context.Context, userID string) (*models.UserEmail, error) {
 	var user models.UserEmail
-	err := db.QueryRow(`
-		SELECT email, full_name FROM users WHERE id = $1
-	`, userID).Scan(&user.Email, &user.FullName)
+	err := db.QueryRowContext(ctx, `
+		SELECT email, full_name, COALESCE(timezone, '') FROM users WHERE id = $1
+	`, userID).Scan(&user.Email, &user.FullName, &user.Timezone)
 
 	if err != nil {
 		return nil, fmt.Errorf("get user email: %w", err)
 	}
 	return &user, nil
 }
+
+// GetUserSubscription retrieves the user's most recent subscription and
+// its plan's features. Returns nil if the user has never subscribed.
+func (db *DB) GetUserSubscription(ctx context.Context, userID string) (*models.UserSubscription, error) {
+	var sub models.UserSubscription
+	err := db.QueryRowContext(ctx, `
+		SELECT sp.name, us.status, sp.features
+		FROM user_subscriptions us
+		JOIN subscription_plans sp ON us.plan_id = sp.id
+		WHERE us.user_id = $1
+		ORDER BY us.created_at DESC
+		LIMIT 1
+	`, userID).Scan(&sub.PlanName, &sub.Status, &sub.Features)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get user subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// dailyCountColumn maps a models.DailyCount* counter to its column
+func dailyCountColumn(counter string) (string, error) {
+	switch counter {
+	case models.DailyCountAlerts, models.DailyCountEmails:
+		return counter, nil
+	}
+	return "", fmt.Errorf("unknown daily counter %q", counter)
+}
+
+// ReserveDailyCount takes one of a user's counter slots for day (their
+// local date), returning false without taking one once the counter has
+// reached limit. The check and increment are one statement, so concurrent
+// callers can't overshoot the limit.
+func (db *DB) ReserveDailyCount(ctx context.Context, userID string, day time.Time, counter string, limit int) (bool, error) {
+	col, err := dailyCountColumn(counter)
+	if err != nil {
+		return false, err
+	}
+	res, err := db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO notification_daily_counts (user_id, day, %[1]s)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, day) DO UPDATE
+		SET %[1]s = notification_daily_counts.%[1]s + 1
+		WHERE notification_daily_counts.%[1]s < $3
+	`, col), userID, day.Format("2006-01-02"), limit)
+	if err != nil {
+		return false, fmt.Errorf("reserve daily %s: %w", counter, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("reserve daily %s: %w", counter, err)
+	}
+	return n > 0, nil
+}
+
+// ReleaseDailyCount gives back a slot taken by ReserveDailyCount whose
+// alert or email didn't go out.
+func (db *DB) ReleaseDailyCount(ctx context.Context, userID string, day time.Time, counter string) error {
+	col, err := dailyCountColumn(counter)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE notification_daily_counts
+		SET %[1]s = GREATEST(%[1]s - 1, 0)
+		WHERE user_id = $1 AND day = $2
+	`, col), userID, day.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("release daily %s: %w", counter, err)
+	}
+	return nil
+}
+
+// CreateInAppNotification adds an entry to the user's in-app notification
+// list.
+func (db *DB) CreateInAppNotification(ctx context.Context, n *models.InAppNotification) error {
+	metadata := n.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage(`{}`)
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO notification_queue (user_id, alert_log_id, type, title, message, metadata)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6)
+	`, n.UserID, n.AlertLogID, n.Type, n.Title, n.Message, []byte(metadata))
+	if err != nil {
+		return fmt.Errorf("create in-app notification: %w", err)
+	}
+	return nil
+}
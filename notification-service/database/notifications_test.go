@@ -1,10 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
@@ -21,32 +23,39 @@ func TestGetNotificationPreferences_Success(t *testing.T) {
 	columns := []string{
 		"user_id", "email_enabled", "email_address", "email_verified",
 		"quiet_hours_enabled", "quiet_hours_start", "quiet_hours_end", "quiet_hours_timezone",
-		"max_alerts_per_day", "max_emails_per_day",
+		"max_alerts_per_day", "max_emails_per_day", "webhook_url", "webhook_secret",
+		"sms_enabled", "phone_number", "phone_verified",
 	}
 	rows := sqlmock.NewRows(columns).AddRow(
-		"user-123",         // user_id
-		true,               // email_enabled
-		emailAddr,          // email_address
-		true,               // email_verified
-		true,               // quiet_hours_enabled
-		"22:00:00",         // quiet_hours_start
-		"08:00:00",         // quiet_hours_end
-		"America/New_York", // quiet_hours_timezone
-		50,                 // max_alerts_per_day
-		10,                 // max_emails_per_day
+		"user-123",                         // user_id
+		true,                               // email_enabled
+		emailAddr,                          // email_address
+		true,                               // email_verified
+		true,                               // quiet_hours_enabled
+		"22:00:00",                         // quiet_hours_start
+		"08:00:00",                         // quiet_hours_end
+		"America/New_York",                 // quiet_hours_timezone
+		50,                                 // max_alerts_per_day
+		10,                                 // max_emails_per_day
+		"https://hooks.example.com/alerts", // webhook_url
+		nil,                                // webhook_secret
+		true,                               // sms_enabled
+		"+14155550123",                     // phone_number
+		true,                               // phone_verified
 	)
 
 	mock.ExpectQuery(regexp.QuoteMeta(
 		`SELECT user_id, email_enabled, email_address, email_verified,
 		       quiet_hours_enabled, quiet_hours_start, quiet_hours_end, quiet_hours_timezone,
-		       max_alerts_per_day, max_emails_per_day
+		       max_alerts_per_day, max_emails_per_day, webhook_url, webhook_secret,
+		       sms_enabled, phone_number, phone_verified
 		FROM notification_preferences
 		WHERE user_id = $1`,
 	)).
 		WithArgs("user-123").
 		WillReturnRows(rows)
 
-	prefs, err := db.GetNotificationPreferences("user-123")
+	prefs, err := db.GetNotificationPreferences(context.Background(), "user-123")
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -84,6 +93,16 @@ func TestGetNotificationPreferences_Success(t *testing.T) {
 	if prefs.MaxEmailsPerDay != 10 {
 		t.Errorf("expected MaxEmailsPerDay 10, got %d", prefs.MaxEmailsPerDay)
 	}
+	if prefs.WebhookURL == nil || *prefs.WebhookURL != "https://hooks.example.com/alerts" {
+		t.Errorf("expected WebhookURL to be scanned, got %v", prefs.WebhookURL)
+	}
+	if prefs.WebhookSecret != nil {
+		t.Errorf("expected nil WebhookSecret, got %q", *prefs.WebhookSecret)
+	}
+	if !prefs.SMSEnabled || !prefs.PhoneVerified || prefs.PhoneNumber == nil || *prefs.PhoneNumber != "+14155550123" {
+		t.Errorf("expected SMS settings to be scanned, got enabled=%v verified=%v phone=%v",
+			prefs.SMSEnabled, prefs.PhoneVerified, prefs.PhoneNumber)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unexpected mock expectations: %v", err)
@@ -96,14 +115,15 @@ func TestGetNotificationPreferences_NoRows(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(
 		`SELECT user_id, email_enabled, email_address, email_verified,
 		       quiet_hours_enabled, quiet_hours_start, quiet_hours_end, quiet_hours_timezone,
-		       max_alerts_per_day, max_emails_per_day
+		       max_alerts_per_day, max_emails_per_day, webhook_url, webhook_secret,
+		       sms_enabled, phone_number, phone_verified
 		FROM notification_preferences
 		WHERE user_id = $1`,
 	)).
 		WithArgs("user-999").
 		WillReturnError(sql.ErrNoRows)
 
-	prefs, err := db.GetNotificationPreferences("user-999")
+	prefs, err := db.GetNotificationPreferences(context.Background(), "user-999")
 	if err != nil {
 		t.Fatalf("expected nil error for no rows, got %v", err)
 	}
@@ -122,14 +142,15 @@ func TestGetNotificationPreferences_Error(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(
 		`SELECT user_id, email_enabled, email_address, email_verified,
 		       quiet_hours_enabled, quiet_hours_start, quiet_hours_end, quiet_hours_timezone,
-		       max_alerts_per_day, max_emails_per_day
+		       max_alerts_per_day, max_emails_per_day, webhook_url, webhook_secret,
+		       sms_enabled, phone_number, phone_verified
 		FROM notification_preferences
 		WHERE user_id = $1`,
 	)).
 		WithArgs("user-123").
 		WillReturnError(fmt.Errorf("connection refused"))
 
-	prefs, err := db.GetNotificationPreferences("user-123")
+	prefs, err := db.GetNotificationPreferences(context.Background(), "user-123")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -149,16 +170,16 @@ func TestGetNotificationPreferences_Error(t *testing.T) {
 func TestGetUserEmail_Success(t *testing.T) {
 	db, mock := newMockDB(t)
 
-	rows := sqlmock.NewRows([]string{"email", "full_name"}).
-		AddRow("john@example.com", "John Doe")
+	rows := sqlmock.NewRows([]string{"email", "full_name", "timezone"}).
+		AddRow("john@example.com", "John Doe", "America/Mexico_City")
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT email, full_name FROM users WHERE id = $1`,
+		`SELECT email, full_name, COALESCE(timezone, '') FROM users WHERE id = $1`,
 	)).
 		WithArgs("user-123").
 		WillReturnRows(rows)
 
-	user, err := db.GetUserEmail("user-123")
+	user, err := db.GetUserEmail(context.Background(), "user-123")
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -171,6 +192,9 @@ func TestGetUserEmail_Success(t *testing.T) {
 	if user.FullName != "John Doe" {
 		t.Errorf("expected FullName 'John Doe', got %q", user.FullName)
 	}
+	if user.Timezone != "America/Mexico_City" {
+		t.Errorf("expected Timezone 'America/Mexico_City', got %q", user.Timezone)
+	}
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unexpected mock expectations: %v", err)
@@ -181,12 +205,12 @@ func TestGetUserEmail_Error(t *testing.T) {
 	db, mock := newMockDB(t)
 
 	mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT email, full_name FROM users WHERE id = $1`,
+		`SELECT email, full_name, COALESCE(timezone, '') FROM users WHERE id = $1`,
 	)).
 		WithArgs("user-123").
 		WillReturnError(fmt.Errorf("user not found"))
 
-	user, err := db.GetUserEmail("user-123")
+	user, err := db.GetUserEmail(context.Background(), "user-123")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -198,3 +222,146 @@ func TestGetUserEmail_Error(t *testing.T) {
 		t.Errorf("unexpected mock expectations: %v", err)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// GetUserSubscription
+// ---------------------------------------------------------------------------
+
+const userSubscriptionQuery = `SELECT sp.name, us.status, sp.features
+		FROM user_subscriptions us
+		JOIN subscription_plans sp ON us.plan_id = sp.id
+		WHERE us.user_id = $1
+		ORDER BY us.created_at DESC
+		LIMIT 1`
+
+func TestGetUserSubscription_Success(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	rows := sqlmock.NewRows([]string{"name", "status", "features"}).
+		AddRow("premium", "active", []byte(`{"sms_alerts": true}`))
+	mock.ExpectQuery(regexp.QuoteMeta(userSubscriptionQuery)).
+		WithArgs("user-123").
+		WillReturnRows(rows)
+
+	sub, err := db.GetUserSubscription(context.Background(), "user-123")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if sub == nil || sub.PlanName != "premium" || sub.Status != "active" {
+		t.Fatalf("unexpected subscription: %+v", sub)
+	}
+	if !sub.HasFeature("sms_alerts") {
+		t.Error("expected sms_alerts feature")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestGetUserSubscription_NoRows(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(userSubscriptionQuery)).
+		WithArgs("user-999").
+		WillReturnError(sql.ErrNoRows)
+
+	sub, err := db.GetUserSubscription(context.Background(), "user-999")
+	if err != nil {
+		t.Fatalf("expected nil error for no rows, got %v", err)
+	}
+	if sub != nil {
+		t.Fatalf("expected nil subscription for no rows, got %+v", sub)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// ReserveDailyCount / ReleaseDailyCount
+// ---------------------------------------------------------------------------
+
+var countDay = time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func TestReserveDailyCount_Reserved(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO notification_daily_counts (user_id, day, emails)`)).
+		WithArgs("user-123", "2026-03-10", 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ok, err := db.ReserveDailyCount(context.Background(), "user-123", countDay, "emails", 10)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !ok {
+		t.Error("expected a slot to be reserved")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestReserveDailyCount_AtLimit(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// The conflict update's WHERE fails once the counter is at the limit,
+	// so no row is touched.
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE notification_daily_counts.alerts < $3`)).
+		WithArgs("user-123", "2026-03-10", 50).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := db.ReserveDailyCount(context.Background(), "user-123", countDay, "alerts", 50)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if ok {
+		t.Error("expected no slot at the limit")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestReserveDailyCount_Error(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO notification_daily_counts`)).
+		WillReturnError(fmt.Errorf("connection reset"))
+
+	if _, err := db.ReserveDailyCount(context.Background(), "user-123", countDay, "emails", 10); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestReserveDailyCount_UnknownCounter(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	if _, err := db.ReserveDailyCount(context.Background(), "user-123", countDay, "user_id", 10); err == nil {
+		t.Fatal("expected error for unknown counter, got nil")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestReleaseDailyCount_Success(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`SET emails = GREATEST(emails - 1, 0)`)).
+		WithArgs("user-123", "2026-03-10").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := db.ReleaseDailyCount(context.Background(), "user-123", countDay, "emails"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"notification-service/models"
+)
+
+// GetSymbolSnapshots loads the end-of-day data the scheduled evaluator needs
+// for each symbol: the latest daily bar with volume averages and trailing
+// P/E, the latest IC Score, and the most recent dividend declaration.
+// Symbols with no data at all are absent from the map.
+func (db *DB) GetSymbolSnapshots(ctx context.Context, symbols []string) (map[string]*models.SymbolSnapshot, error) {
+	snapshots := make(map[string]*models.SymbolSnapshot)
+	if len(symbols) == 0 {
+		return snapshots, nil
+	}
+	get := func(symbol string) *models.SymbolSnapshot {
+		snap, ok := snapshots[symbol]
+		if !ok {
+			snap = &models.SymbolSnapshot{}
+			snapshots[symbol] = snap
+		}
+		return snap
+	}
+
+	// Volume averages cover the sessions before the latest bar, so a spike
+	// isn't diluted by itself. 140 calendar days comfortably holds 91 sessions.
+	rows, err := db.QueryContext(ctx, `
+		WITH bars AS (
+			SELECT ticker, close, volume,
+			       ROW_NUMBER() OVER (PARTITION BY ticker ORDER BY time DESC) AS rn
+			FROM stock_prices
+			WHERE ticker = ANY($1) AND interval = '1day' AND close IS NOT NULL
+			  AND time >= NOW() - INTERVAL '140 days'
+		)
+		SELECT ticker,
+		       MAX(close) FILTER (WHERE rn = 1),
+		       MAX(volume) FILTER (WHERE rn = 1),
+		       MAX(close) FILTER (WHERE rn = 2),
+		       AVG(volume) FILTER (WHERE rn BETWEEN 2 AND 31),
+		       AVG(volume) FILTER (WHERE rn BETWEEN 2 AND 91)
+		FROM bars
+		GROUP BY ticker
+	`, pq.Array(symbols))
+	if err != nil {
+		return nil, fmt.Errorf("query daily bars: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var symbol string
+		var closePrice, prevClose, avg30, avg90 sql.NullFloat64
+		var volume sql.NullInt64
+		if err := rows.Scan(&symbol, &closePrice, &volume, &prevClose, &avg30, &avg90); err != nil {
+			return nil, fmt.Errorf("scan daily bars: %w", err)
+		}
+		snap := get(symbol)
+		snap.Quote.Price = closePrice.Float64
+		snap.Quote.Volume = volume.Int64
+		if prevClose.Valid && prevClose.Float64 > 0 {
+			snap.Quote.ChangePct = (closePrice.Float64 - prevClose.Float64) / prevClose.Float64 * 100
+		}
+		snap.Quote.AvgVolume30d = avg30.Float64
+		snap.Quote.AvgVolume90d = avg90.Float64
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate daily bars: %w", err)
+	}
+
+	// valuation_ratios holds the P/E at the price on its calculation date;
+	// earnings don't move with price, so rescale it to the latest close.
+	peRows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (ticker) ticker, stock_price, ttm_pe_ratio
+		FROM valuation_ratios
+		WHERE ticker = ANY($1) AND ttm_pe_ratio > 0 AND stock_price > 0
+		ORDER BY ticker, calculation_date DESC
+	`, pq.Array(symbols))
+	if err != nil {
+		return nil, fmt.Errorf("query valuation ratios: %w", err)
+	}
+	defer peRows.Close()
+	for peRows.Next() {
+		var symbol string
+		var stockPrice, pe float64
+		if err := peRows.Scan(&symbol, &stockPrice, &pe); err != nil {
+			return nil, fmt.Errorf("scan valuation ratios: %w", err)
+		}
+		snap := get(symbol)
+		if snap.Quote.Price > 0 {
+			pe = pe * snap.Quote.Price / stockPrice
+		}
+		snap.Quote.PERatio = pe
+	}
+	if err := peRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate valuation ratios: %w", err)
+	}
+
+	scoreRows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (ticker) ticker, overall_score
+		FROM ic_scores
+		WHERE ticker = ANY($1)
+		ORDER BY ticker, date DESC
+	`, pq.Array(symbols))
+	if err != nil {
+		return nil, fmt.Errorf("query ic scores: %w", err)
+	}
+	defer scoreRows.Close()
+	for scoreRows.Next() {
+		var symbol string
+		var score float64
+		if err := scoreRows.Scan(&symbol, &score); err != nil {
+			return nil, fmt.Errorf("scan ic scores: %w", err)
+		}
+		get(symbol).ICScore = &score
+	}
+	if err := scoreRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ic scores: %w", err)
+	}
+
+	divRows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT ON (symbol) symbol, COALESCE(declaration_date, ex_date), ex_date, amount
+		FROM dividends
+		WHERE symbol = ANY($1)
+		ORDER BY symbol, COALESCE(declaration_date, ex_date) DESC
+	`, pq.Array(symbols))
+	if err != nil {
+		return nil, fmt.Errorf("query dividends: %w", err)
+	}
+	defer divRows.Close()
+	for divRows.Next() {
+		var symbol string
+		var declaredOn, exDate time.Time
+		var amount float64
+		if err := divRows.Scan(&symbol, &declaredOn, &exDate, &amount); err != nil {
+			return nil, fmt.Errorf("scan dividends: %w", err)
+		}
+		get(symbol).Dividend = &models.DividendDeclaration{DeclaredOn: declaredOn, ExDate: exDate, Amount: amount}
+	}
+	if err := divRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate dividends: %w", err)
+	}
+
+	return snapshots, nil
+}
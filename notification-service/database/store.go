@@ -1,15 +1,28 @@
 package database
 
-import "notification-service/models"
+import (
+	"context"
+	"time"
+
+	"notification-service/models"
+)
 
 // Store defines the database operations used by the notification service.
 // This interface allows business logic to be tested with mock implementations.
 type Store interface {
-	GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, error)
-	CreateAlertLog(alertLog *models.AlertLog) (string, error)
-	ClaimAlertTrigger(alertID string, frequency string) (bool, error)
-	UpdateAlertLogNotificationSent(logID string, sent bool) error
-	GetTodayEmailCount(userID string) (int, error)
-	GetNotificationPreferences(userID string) (*models.NotificationPreferences, error)
-	GetUserEmail(userID string) (*models.UserEmail, error)
+	GetActiveAlertsForSymbols(ctx context.Context, symbols []string) ([]models.AlertRule, error)
+	GetActiveAlertsByTypes(ctx context.Context, alertTypes []string) ([]models.AlertRule, error)
+	GetSymbolSnapshots(ctx context.Context, symbols []string) (map[string]*models.SymbolSnapshot, error)
+	CreateAlertLog(ctx context.Context, alertLog *models.AlertLog) (string, error)
+	ClaimAlertTrigger(ctx context.Context, alertID string, frequency string) (bool, error)
+	UpdateAlertLogNotificationSent(ctx context.Context, logID string, sent bool) error
+	ReserveDailyCount(ctx context.Context, userID string, day time.Time, counter string, limit int) (bool, error)
+	ReleaseDailyCount(ctx context.Context, userID string, day time.Time, counter string) error
+	GetNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	GetUserEmail(ctx context.Context, userID string) (*models.UserEmail, error)
+	IsEmailSuppressed(ctx context.Context, address string) (bool, error)
+	GetUserSubscription(ctx context.Context, userID string) (*models.UserSubscription, error)
+	CreateInAppNotification(ctx context.Context, n *models.InAppNotification) error
+	GetAlertRule(ctx context.Context, alertID string) (*models.AlertRule, error)
+	GetRetryableAlertLogs(ctx context.Context, since time.Time, maxAttempts, limit int) ([]models.AlertLog, error)
 }
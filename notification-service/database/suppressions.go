@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SuppressEmail adds address to email_suppressions for reason (a
+// models.Suppression* value) and marks the notification preferences that
+// send to it unverified; a complaint also turns their email off. It returns
+// how many preferences changed. A complaint is kept over a later bounce.
+func (db *DB) SuppressEmail(address, reason, detail string) (int64, error) {
+	// One statement, so the suppression and the preference change land
+	// together. Preferences with their own email_address are matched on it;
+	// the rest on the account email.
+	res, err := db.Exec(`
+		WITH suppressed AS (
+			INSERT INTO email_suppressions (email, reason, detail)
+			VALUES ($1, $2, NULLIF($3, ''))
+			ON CONFLICT (email) DO UPDATE
+			SET reason = CASE WHEN email_suppressions.reason = 'complaint' THEN 'complaint' ELSE EXCLUDED.reason END,
+			    detail = EXCLUDED.detail,
+			    updated_at = NOW()
+		)
+		UPDATE notification_preferences p
+		SET email_verified = false,
+		    email_enabled = CASE WHEN $2 = 'complaint' THEN false ELSE p.email_enabled END
+		WHERE LOWER(p.email_address) = $1
+		   OR (COALESCE(p.email_address, '') = ''
+		       AND p.user_id IN (SELECT id FROM users WHERE LOWER(email) = $1))
+	`, normalizeEmail(address), reason, detail)
+	if err != nil {
+		return 0, fmt.Errorf("suppress email: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("suppress email: %w", err)
+	}
+	return n, nil
+}
+
+// IsEmailSuppressed reports whether address hard-bounced or complained
+func (db *DB) IsEmailSuppressed(ctx context.Context, address string) (bool, error) {
+	var suppressed bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM email_suppressions WHERE email = $1)
+	`, normalizeEmail(address)).Scan(&suppressed)
+	if err != nil {
+		return false, fmt.Errorf("check email suppression: %w", err)
+	}
+	return suppressed, nil
+}
+
+func normalizeEmail(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
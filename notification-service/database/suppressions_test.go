@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSuppressEmail(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(`INSERT INTO email_suppressions .* UPDATE notification_preferences`).
+		WithArgs("gone@example.com", "bounce", "smtp; 550 user unknown").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := db.SuppressEmail("  Gone@Example.com ", "bounce", "smtp; 550 user unknown")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 preferences updated, got %d", n)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
+
+func TestSuppressEmail_Error(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(`INSERT INTO email_suppressions`).WillReturnError(fmt.Errorf("connection reset"))
+
+	if _, err := db.SuppressEmail("gone@example.com", "complaint", ""); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestIsEmailSuppressed(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM email_suppressions WHERE email = \$1\)`).
+		WithArgs("gone@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	suppressed, err := db.IsEmailSuppressed(context.Background(), "Gone@example.com")
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !suppressed {
+		t.Error("expected the address to be suppressed")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected mock expectations: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"context"
 	"errors"
 	"os"
 	"strings"
@@ -67,7 +68,7 @@ func TestDeliver_SendError(t *testing.T) {
 	alert := sampleAlert()
 	alert.NotifyEmail = true
 
-	err := router.Deliver(alert, sampleAlertLog(), sampleQuote())
+	err := router.Deliver(context.Background(), alert, sampleAlertLog(), sampleQuote())
 	if err == nil {
 		t.Fatal("expected error from Deliver, got nil")
 	}
@@ -94,7 +95,7 @@ func TestSend_SendFuncError(t *testing.T) {
 	rec := &sendRecorder{err: errors.New("SMTP timeout")}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -108,7 +109,7 @@ func TestSend_SendFuncError(t *testing.T) {
 }
 
 func TestSend_RateLimitCountError(t *testing.T) {
-	// When GetTodayEmailCount fails, we log a warning but still send
+	// When ReserveDailyCount fails, we log a warning but still send
 	store := &mockStore{
 		notifPrefs: &models.NotificationPreferences{
 			EmailEnabled:    true,
@@ -124,7 +125,7 @@ func TestSend_RateLimitCountError(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -153,7 +154,7 @@ func TestSend_QuietHoursTimezoneError(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -180,7 +181,7 @@ func TestSend_EmptyPrefsEmailAddress(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -193,14 +194,14 @@ func TestSend_EmptyPrefsEmailAddress(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// formatAlertEmailBody
+// formatAlertEmail
 // ---------------------------------------------------------------------------
 
 func TestFormatAlertEmailBody(t *testing.T) {
 	alert := sampleAlert()
 	quote := sampleQuote()
 
-	body := formatAlertEmailBody(alert, quote, "John Doe", "https://example.com")
+	body := renderAlertEmail(t, alert, quote, "John Doe", "https://example.com").HTML
 	if body == "" {
 		t.Fatal("expected non-empty email body")
 	}
@@ -1,32 +1,152 @@
 package delivery
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/tracing"
 	"notification-service/models"
 )
 
+// Channel is one way of notifying a user that their alert triggered.
+type Channel interface {
+	// Name labels the channel in logs and errors (e.g. "email").
+	Name() string
+	// Enabled reports whether the alert rule asks for this channel. The
+	// user's notification preferences are checked by Send.
+	Enabled(alert *models.AlertRule) bool
+	// Send delivers the notification. It returns nil without sending when
+	// the user's preferences turn the channel off.
+	Send(ctx context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error
+}
+
+// MaxDeliveryAttempts is how many times a channel is tried for one
+// triggered alert before it is given up on.
+const MaxDeliveryAttempts = 5
+
+// deliveryLease bounds how long a claimed send may take before another
+// attempt may claim it; it covers the webhook's retries with backoff.
+const deliveryLease = 2 * time.Minute
+
+// errDeliveryInFlight is returned for a channel another attempt is
+// sending right now.
+var errDeliveryInFlight = errors.New("delivery already in progress")
+
+// DeliveryLedger records each channel's delivery of a triggered alert, so
+// a retry re-sends only the channels that failed. Implemented by
+// database.DB.
+type DeliveryLedger interface {
+	// ClaimDelivery takes a lease on sending on channel; when it can't,
+	// it returns the channel's current status.
+	ClaimDelivery(ctx context.Context, alertLogID, channel string, lease time.Duration, maxAttempts int) (bool, string, error)
+	// FinishDelivery records the outcome of a claimed send.
+	FinishDelivery(ctx context.Context, alertLogID, channel, status, detail string) error
+}
+
 // Router dispatches notifications to the appropriate delivery channels
 // based on the alert rule's configuration.
 type Router struct {
-	email *EmailDelivery
+	channels []Channel
+	ledger   DeliveryLedger // nil: every channel is sent every time
+}
+
+// NewRouter creates a new delivery Router sending over channels.
+func NewRouter(channels ...Channel) *Router {
+	return &Router{channels: channels}
+}
+
+// SetLedger makes delivery per-channel idempotent: a channel that already
+// delivered an alert log, or was held back for it, isn't sent again.
+func (r *Router) SetLedger(ledger DeliveryLedger) {
+	r.ledger = ledger
 }
 
-// NewRouter creates a new delivery Router.
-func NewRouter(email *EmailDelivery) *Router {
-	return &Router{email: email}
+// Deliver sends notifications for a triggered alert via every enabled
+// channel. Channels send concurrently, so a slow or retrying webhook
+// doesn't hold up the email. The returned error joins each channel's
+// failure, prefixed with the channel name; it is nil only when every
+// enabled channel delivered.
+//
+// With a ledger, Deliver can be called again for the same alert log to
+// retry: channels that already delivered count as delivered without being
+// sent again.
+func (r *Router) Deliver(ctx context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
+	errs := make([]error, len(r.channels))
+	var wg sync.WaitGroup
+	for i, ch := range r.channels {
+		if !ch.Enabled(alert) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ch Channel) {
+			defer wg.Done()
+			if err := r.send(ctx, ch, alert, alertLog, quote); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", ch.Name(), err)
+			}
+		}(i, ch)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
 }
 
-// Deliver sends notifications for a triggered alert via configured channels.
-func (r *Router) Deliver(alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
-	// Email notification
-	if alert.NotifyEmail {
-		if err := r.email.Send(alert, alertLog, quote); err != nil {
-			log.Printf("Email delivery failed for alert %s: %v", alert.ID, err)
-			return fmt.Errorf("email: %w", err)
+// send delivers on one channel, through the ledger when there is one. A
+// ledger that can't be reached doesn't stop the send: a rare duplicate
+// beats a lost notification. Each send has its own span.
+func (r *Router) send(ctx context.Context, ch Channel, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "deliver "+ch.Name(),
+		trace.WithAttributes(attribute.String("alert.id", alert.ID)),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	tracked := r.ledger != nil && alertLog.ID != ""
+	if tracked {
+		claimed, status, err := r.ledger.ClaimDelivery(ctx, alertLog.ID, ch.Name(), deliveryLease, MaxDeliveryAttempts)
+		switch {
+		case err != nil:
+			log.Printf("Warning: %s for alert log %s sent without a claim: %v", ch.Name(), alertLog.ID, err)
+			tracked = false
+		case !claimed:
+			return claimStatusError(status)
 		}
 	}
 
-	return nil
+	err = ch.Send(ctx, alert, alertLog, quote)
+	status, detail := models.DeliveryDelivered, ""
+	switch {
+	case errors.Is(err, ErrSuppressed):
+		log.Printf("%s for alert %s held back: %v", ch.Name(), alert.ID, err)
+		status, detail = models.DeliverySuppressed, err.Error()
+	case err != nil:
+		log.Printf("%s delivery failed for alert %s: %v", ch.Name(), alert.ID, err)
+		status, detail = models.DeliveryFailed, err.Error()
+	}
+	if tracked {
+		if ferr := r.ledger.FinishDelivery(ctx, alertLog.ID, ch.Name(), status, detail); ferr != nil {
+			log.Printf("Warning: %v", ferr)
+		}
+	}
+	return err
+}
+
+// claimStatusError is the result of a channel that couldn't be claimed
+// because it was already in status.
+func claimStatusError(status string) error {
+	switch status {
+	case models.DeliveryDelivered:
+		return nil
+	case models.DeliverySuppressed:
+		return fmt.Errorf("%w: held back earlier", ErrSuppressed)
+	case models.DeliverySending:
+		return errDeliveryInFlight
+	default:
+		return fmt.Errorf("gave up after %d attempts", MaxDeliveryAttempts)
+	}
 }
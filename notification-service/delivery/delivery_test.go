@@ -1,7 +1,15 @@
 package delivery
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"notification-service/models"
 )
 
 // ---------------------------------------------------------------------------
@@ -9,21 +17,197 @@ import (
 // ---------------------------------------------------------------------------
 
 func TestNewRouter(t *testing.T) {
-	// Verify router can be constructed with nil dependencies (for unit testing).
-	// In production this would be a real EmailDelivery instance.
-	router := NewRouter(nil)
+	// Verify router can be constructed without channels (for unit testing).
+	router := NewRouter()
 	if router == nil {
 		t.Fatal("expected non-nil router")
 	}
-	if router.email != nil {
-		t.Error("expected nil email field")
+	if len(router.channels) != 0 {
+		t.Errorf("expected no channels, got %d", len(router.channels))
 	}
 }
 
 func TestNewRouter_WithEmail(t *testing.T) {
 	email := &EmailDelivery{}
 	router := NewRouter(email)
-	if router.email != email {
+	if len(router.channels) != 1 || router.channels[0] != email {
 		t.Error("email delivery not set correctly")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Fan-out
+// ---------------------------------------------------------------------------
+
+type fakeChannel struct {
+	name    string
+	enabled bool
+	err     error
+	calls   atomic.Int32
+}
+
+func (f *fakeChannel) Name() string                         { return f.name }
+func (f *fakeChannel) Enabled(alert *models.AlertRule) bool { return f.enabled }
+func (f *fakeChannel) Send(_ context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
+	f.calls.Add(1)
+	return f.err
+}
+
+func TestDeliver_FansOutToEnabledChannels(t *testing.T) {
+	email := &fakeChannel{name: "email", enabled: true}
+	webhook := &fakeChannel{name: "webhook", enabled: true}
+	off := &fakeChannel{name: "off"}
+
+	if err := NewRouter(email, webhook, off).Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if email.calls.Load() != 1 || webhook.calls.Load() != 1 {
+		t.Errorf("expected one send per enabled channel, got email=%d webhook=%d", email.calls.Load(), webhook.calls.Load())
+	}
+	if off.calls.Load() != 0 {
+		t.Error("disabled channel should not be sent to")
+	}
+}
+
+func TestDeliver_FailingChannelDoesNotBlockOthers(t *testing.T) {
+	webhook := &fakeChannel{name: "webhook", enabled: true, err: errors.New("status 503")}
+	email := &fakeChannel{name: "email", enabled: true}
+
+	err := NewRouter(webhook, email).Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if err == nil || !strings.Contains(err.Error(), "webhook: status 503") {
+		t.Fatalf("expected the webhook failure, got %v", err)
+	}
+	if email.calls.Load() != 1 {
+		t.Error("email should still be sent when the webhook fails")
+	}
+}
+
+func TestDeliver_SuppressedChannelPassesErrSuppressedThrough(t *testing.T) {
+	email := &fakeChannel{name: "email", enabled: true, err: ErrSuppressed}
+	webhook := &fakeChannel{name: "webhook", enabled: true}
+
+	err := NewRouter(email, webhook).Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected ErrSuppressed, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Per-channel idempotency
+// ---------------------------------------------------------------------------
+
+// memLedger is an in-memory DeliveryLedger with alert_deliveries' rules.
+type memLedger struct {
+	mu       sync.Mutex
+	status   map[string]string
+	attempts map[string]int
+	claimErr error
+}
+
+func newMemLedger() *memLedger {
+	return &memLedger{status: map[string]string{}, attempts: map[string]int{}}
+}
+
+func (l *memLedger) ClaimDelivery(_ context.Context, alertLogID, channel string, lease time.Duration, maxAttempts int) (bool, string, error) {
+	if l.claimErr != nil {
+		return false, "", l.claimErr
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := alertLogID + "/" + channel
+	status, seen := l.status[key]
+	if seen && (status != models.DeliveryFailed || l.attempts[key] >= maxAttempts) {
+		return false, status, nil
+	}
+	l.status[key] = models.DeliverySending
+	l.attempts[key]++
+	return true, models.DeliverySending, nil
+}
+
+func (l *memLedger) FinishDelivery(_ context.Context, alertLogID, channel, status, detail string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status[alertLogID+"/"+channel] = status
+	return nil
+}
+
+func TestDeliver_RetryAfterEmailFailureDoesNotDuplicateInApp(t *testing.T) {
+	store := &mockStore{}
+	email := &fakeChannel{name: "email", enabled: true, err: errors.New("smtp: 421 try again later")}
+	router := NewRouter(email, NewInAppDelivery(store))
+	router.SetLedger(newMemLedger())
+
+	alert := sampleAlert()
+	alert.NotifyInApp = true
+
+	err := router.Deliver(context.Background(), alert, sampleAlertLog(), sampleQuote())
+	if err == nil || !strings.Contains(err.Error(), "email: smtp: 421") {
+		t.Fatalf("expected the email failure, got %v", err)
+	}
+
+	// Retry once the mail server is back
+	email.err = nil
+	if err := router.Deliver(context.Background(), alert, sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+
+	if email.calls.Load() != 2 {
+		t.Errorf("expected email to be re-attempted, got %d sends", email.calls.Load())
+	}
+	if len(store.inAppNotifications) != 1 {
+		t.Errorf("expected one in-app notification, got %d", len(store.inAppNotifications))
+	}
+
+	// A further retry sends nothing
+	if err := router.Deliver(context.Background(), alert, sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if email.calls.Load() != 2 || len(store.inAppNotifications) != 1 {
+		t.Errorf("delivered channels were sent again: email=%d in_app=%d", email.calls.Load(), len(store.inAppNotifications))
+	}
+}
+
+func TestDeliver_LedgerGivesUpAfterMaxAttempts(t *testing.T) {
+	email := &fakeChannel{name: "email", enabled: true, err: errors.New("down")}
+	router := NewRouter(email)
+	router.SetLedger(newMemLedger())
+
+	for i := 0; i < MaxDeliveryAttempts+2; i++ {
+		if err := router.Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if got := email.calls.Load(); got != MaxDeliveryAttempts {
+		t.Errorf("expected %d attempts, got %d", MaxDeliveryAttempts, got)
+	}
+}
+
+func TestDeliver_SuppressedChannelIsNotRetried(t *testing.T) {
+	email := &fakeChannel{name: "email", enabled: true, err: ErrSuppressed}
+	router := NewRouter(email)
+	router.SetLedger(newMemLedger())
+
+	for i := 0; i < 2; i++ {
+		if err := router.Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); !errors.Is(err, ErrSuppressed) {
+			t.Fatalf("expected ErrSuppressed, got %v", err)
+		}
+	}
+	if email.calls.Load() != 1 {
+		t.Errorf("expected one send, got %d", email.calls.Load())
+	}
+}
+
+func TestDeliver_LedgerErrorStillSends(t *testing.T) {
+	email := &fakeChannel{name: "email", enabled: true}
+	ledger := newMemLedger()
+	ledger.claimErr = errors.New("connection refused")
+	router := NewRouter(email)
+	router.SetLedger(ledger)
+
+	if err := router.Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if email.calls.Load() != 1 {
+		t.Error("expected email to be sent when the ledger is unavailable")
+	}
+}
@@ -1,22 +1,35 @@
 package delivery
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/smtp"
 	"strings"
 	"time"
 
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/emailtmpl"
+	"investorcenter-shared/tracing"
 	"notification-service/config"
 	"notification-service/database"
 	"notification-service/models"
 )
 
+// ErrSuppressed is returned, wrapped with the reason, when the user's
+// quiet hours or max_emails_per_day hold back an alert email or text. The
+// alert is not marked as notified.
+var ErrSuppressed = errors.New("suppressed by notification preferences")
+
 // EmailDelivery sends alert notification emails via SMTP.
 type EmailDelivery struct {
 	cfg      *config.Config
 	db       database.Store
-	sendFunc func(to, subject, htmlBody string) error // injectable for testing
+	sendFunc func(ctx context.Context, to string, msg *emailtmpl.Message) error // injectable for testing
+	now      func() time.Time                                                   // injectable for testing; nil means time.Now
 }
 
 // NewEmailDelivery creates a new EmailDelivery.
@@ -26,10 +39,18 @@ func NewEmailDelivery(cfg *config.Config, db database.Store) *EmailDelivery {
 	return d
 }
 
+// Name implements Channel.
+func (d *EmailDelivery) Name() string { return "email" }
+
+// Enabled implements Channel: email goes out for rules with notify_email.
+func (d *EmailDelivery) Enabled(alert *models.AlertRule) bool { return alert.NotifyEmail }
+
 // Send sends an alert notification email to the user.
-// Checks preferences (email enabled, verified), quiet hours, and daily rate
-// limits before sending.
-func (d *EmailDelivery) Send(alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
+// Checks preferences (email enabled, verified), quiet hours, the bounce and
+// complaint suppression list, and daily rate limits before sending. Emails
+// held back by quiet hours, suppression or the daily limit return an error
+// wrapping ErrSuppressed.
+func (d *EmailDelivery) Send(ctx context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
 	// Skip if SMTP not configured (local dev)
 	if d.cfg.SMTPHost == "" || d.cfg.SMTPPassword.Value() == "" {
 		log.Printf("SMTP not configured — skipping email for alert %s", alert.ID)
@@ -37,10 +58,11 @@ func (d *EmailDelivery) Send(alert *models.AlertRule, alertLog *models.AlertLog,
 	}
 
 	// Check notification preferences
-	prefs, err := d.db.GetNotificationPreferences(alert.UserID)
+	prefs, err := d.db.GetNotificationPreferences(ctx, alert.UserID)
 	if err != nil {
 		return fmt.Errorf("get notification preferences: %w", err)
 	}
+	now := d.clock()
 
 	// If preferences exist, check email settings
 	if prefs != nil {
@@ -51,32 +73,19 @@ func (d *EmailDelivery) Send(alert *models.AlertRule, alertLog *models.AlertLog,
 			return nil // Email not verified
 		}
 
-		// Check quiet hours
+		// Check quiet hours, in the user's quiet_hours_timezone
 		if prefs.QuietHoursEnabled {
-			inQuietHours, err := isInQuietHours(prefs)
+			inQuietHours, err := isInQuietHours(prefs, now)
 			if err != nil {
 				log.Printf("Error checking quiet hours: %v", err)
 			} else if inQuietHours {
-				log.Printf("Skipping email for alert %s — user in quiet hours", alert.ID)
-				return nil
-			}
-		}
-
-		// Check daily email rate limit
-		if prefs.MaxEmailsPerDay > 0 {
-			count, err := d.db.GetTodayEmailCount(alert.UserID)
-			if err != nil {
-				log.Printf("Warning: failed to get today's email count: %v", err)
-			} else if count >= prefs.MaxEmailsPerDay {
-				log.Printf("Skipping email for alert %s — user %s exceeded daily email limit (%d/%d)",
-					alert.ID, alert.UserID, count, prefs.MaxEmailsPerDay)
-				return nil
+				return fmt.Errorf("%w: user in quiet hours", ErrSuppressed)
 			}
 		}
 	}
 
 	// Get user email address
-	user, err := d.db.GetUserEmail(alert.UserID)
+	user, err := d.db.GetUserEmail(ctx, alert.UserID)
 	if err != nil {
 		return fmt.Errorf("get user email: %w", err)
 	}
@@ -87,32 +96,85 @@ func (d *EmailDelivery) Send(alert *models.AlertRule, alertLog *models.AlertLog,
 		toEmail = *prefs.EmailAddress
 	}
 
-	// Build and send email
-	subject := fmt.Sprintf("Alert: %s %s", alert.Symbol, alertTypeLabel(alert.AlertType))
-	body := formatAlertEmailBody(alert, quote, user.FullName, d.cfg.FrontendURL)
+	// Sending to an address that hard-bounced or complained hurts
+	// deliverability for everyone. A failed lookup sends anyway.
+	suppressed, err := d.db.IsEmailSuppressed(ctx, toEmail)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	} else if suppressed {
+		return fmt.Errorf("%w: %s bounced or complained", ErrSuppressed, toEmail)
+	}
+
+	// Take a slot under the daily email limit last, so emails that are
+	// skipped above don't use it up. A slot whose send fails is given back.
+	release := func() {}
+	if prefs != nil && prefs.MaxEmailsPerDay > 0 {
+		day := prefs.LocalDay(now)
+		reserved, err := d.db.ReserveDailyCount(ctx, alert.UserID, day, models.DailyCountEmails, prefs.MaxEmailsPerDay)
+		if err != nil {
+			log.Printf("Warning: failed to reserve daily email slot: %v", err)
+		} else if !reserved {
+			return fmt.Errorf("%w: user %s reached daily email limit (%d)", ErrSuppressed, alert.UserID, prefs.MaxEmailsPerDay)
+		} else {
+			release = func() {
+				if err := d.db.ReleaseDailyCount(ctx, alert.UserID, day, models.DailyCountEmails); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}
+		}
+	}
+
+	// Build and send email, in the user's language and timezone
+	triggeredAt := alertLog.TriggeredAt
+	if triggeredAt.IsZero() {
+		triggeredAt = now
+	}
+	msg, err := formatAlertEmail(alert, quote, user, d.cfg.FrontendURL, triggeredAt)
+	if err != nil {
+		release()
+		return err
+	}
+
+	if err := d.sendFunc(ctx, toEmail, msg); err != nil {
+		release()
+		return err
+	}
+	return nil
+}
 
-	return d.sendFunc(toEmail, subject, body)
+func (d *EmailDelivery) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
 }
 
-// sendEmail sends an HTML email via SMTP.
-func (d *EmailDelivery) sendEmail(to, subject, htmlBody string) error {
+// sendEmail sends a plaintext and HTML email via SMTP.
+func (d *EmailDelivery) sendEmail(ctx context.Context, to string, email *emailtmpl.Message) error {
 	from := d.cfg.SMTPFromEmail
 	auth := smtp.PlainAuth("", d.cfg.SMTPUsername, d.cfg.SMTPPassword.Value(), d.cfg.SMTPHost)
 
 	// Sanitize header values to prevent CRLF header injection.
 	// Strip any CR/LF characters from values that will appear in headers.
 	safeTo := sanitizeHeader(to)
-	safeSubject := sanitizeHeader(subject)
+	safeSubject := sanitizeHeader(email.Subject)
 	safeFrom := sanitizeHeader(from)
 	safeFromName := sanitizeHeader(d.cfg.SMTPFromName)
 
-	msg := fmt.Sprintf(
-		"From: %s <%s>\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s",
-		safeFromName, safeFrom, safeTo, safeSubject, htmlBody,
-	)
+	msg, err := (&emailtmpl.Message{Subject: safeSubject, Text: email.Text, HTML: email.HTML}).
+		Bytes(fmt.Sprintf("%s <%s>", safeFromName, safeFrom), safeTo)
+	if err != nil {
+		return fmt.Errorf("build email: %w", err)
+	}
 
 	addr := fmt.Sprintf("%s:%s", d.cfg.SMTPHost, d.cfg.SMTPPort)
-	if err := smtp.SendMail(addr, auth, from, []string{safeTo}, []byte(msg)); err != nil {
+	_, span := tracing.Tracer().Start(ctx, "smtp send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.ServerAddress(d.cfg.SMTPHost)),
+	)
+	err = smtp.SendMail(addr, auth, from, []string{safeTo}, msg)
+	tracing.EndSpan(span, err)
+	if err != nil {
 		return fmt.Errorf("send email: %w", err)
 	}
 
@@ -128,8 +190,10 @@ func sanitizeHeader(s string) string {
 	return s
 }
 
-// isInQuietHours checks if the current time falls within the user's quiet hours.
-func isInQuietHours(prefs *models.NotificationPreferences) (bool, error) {
+// isInQuietHours checks if now falls within the user's quiet hours, read in
+// their quiet_hours_timezone. The window includes its start and excludes its
+// end, and may wrap past midnight (e.g. 22:00 to 08:00).
+func isInQuietHours(prefs *models.NotificationPreferences, now time.Time) (bool, error) {
 	if !prefs.QuietHoursEnabled {
 		return false, nil
 	}
@@ -139,18 +203,17 @@ func isInQuietHours(prefs *models.NotificationPreferences) (bool, error) {
 		return false, fmt.Errorf("load timezone %s: %w", prefs.QuietHoursTimezone, err)
 	}
 
-	now := time.Now().In(loc)
-	currentTime := now.Format("15:04:05")
+	currentTime := now.In(loc).Format("15:04:05")
 
 	start := prefs.QuietHoursStart
 	end := prefs.QuietHoursEnd
 
 	if start <= end {
 		// Same-day range (e.g., 08:00 to 22:00)
-		return currentTime >= start && currentTime <= end, nil
+		return currentTime >= start && currentTime < end, nil
 	}
 	// Overnight range (e.g., 22:00 to 08:00)
-	return currentTime >= start || currentTime <= end, nil
+	return currentTime >= start || currentTime < end, nil
 }
 
 // alertTypeLabel returns a human-readable label for an alert type.
@@ -162,6 +225,8 @@ func alertTypeLabel(alertType string) string {
 		"volume_above":     "Volume Above",
 		"volume_below":     "Volume Below",
 		"volume_spike":     "Volume Spike",
+		"index_move":       "Index Move",
+		"sector_move":      "Sector Move",
 		"news":             "News Alert",
 		"earnings":         "Earnings Report",
 	}
@@ -185,60 +250,28 @@ func formatVolume(vol float64) string {
 	}
 }
 
-// formatAlertEmailBody generates the HTML email body for an alert notification.
-func formatAlertEmailBody(alert *models.AlertRule, quote *models.SymbolQuote, userName, frontendURL string) string {
-	watchlistURL := fmt.Sprintf("%s/watchlist/%s", frontendURL, alert.WatchListID)
-
-	return fmt.Sprintf(`<!DOCTYPE html>
-<html>
-<head><meta charset="UTF-8"></head>
-<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px;">
-  <div style="background: #1a1a2e; color: #e0e0e0; padding: 24px; border-radius: 8px;">
-    <h2 style="color: #4fc3f7; margin-top: 0;">Alert Triggered: %s</h2>
-    <p>Hi %s,</p>
-    <p>Your alert <strong>%s</strong> has been triggered:</p>
-    <div style="background: #16213e; padding: 16px; border-radius: 6px; margin: 16px 0;">
-      <table style="width: 100%%; border-collapse: collapse; color: #e0e0e0;">
-        <tr>
-          <td style="padding: 8px 0;"><strong>Symbol</strong></td>
-          <td style="padding: 8px 0; text-align: right;">%s</td>
-        </tr>
-        <tr>
-          <td style="padding: 8px 0;"><strong>Current Price</strong></td>
-          <td style="padding: 8px 0; text-align: right;">$%.2f</td>
-        </tr>
-        <tr>
-          <td style="padding: 8px 0;"><strong>Change</strong></td>
-          <td style="padding: 8px 0; text-align: right;">%.2f%%</td>
-        </tr>
-        <tr>
-          <td style="padding: 8px 0;"><strong>Volume</strong></td>
-          <td style="padding: 8px 0; text-align: right;">%s</td>
-        </tr>
-      </table>
-    </div>
-    <p>
-      <a href="%s" style="display: inline-block; background: #4fc3f7; color: #1a1a2e; padding: 10px 24px; border-radius: 6px; text-decoration: none; font-weight: bold;">
-        View Watchlist
-      </a>
-    </p>
-    <hr style="border: none; border-top: 1px solid #333; margin: 20px 0;">
-    <p style="color: #888; font-size: 12px;">
-      You received this email because you have email alerts enabled for this watchlist.
-      To manage your notification preferences, visit your
-      <a href="%s/settings" style="color: #4fc3f7;">account settings</a>.
-    </p>
-  </div>
-</body>
-</html>`,
-		alert.Name,
-		userName,
-		alert.Name,
-		alert.Symbol,
-		quote.Price,
-		quote.ChangePct,
-		formatVolume(float64(quote.Volume)),
-		watchlistURL,
-		frontendURL,
-	)
+// formatAlertEmail renders the alert email in the user's locale, with the
+// trigger time in their timezone (UTC when unset or unknown).
+func formatAlertEmail(alert *models.AlertRule, quote *models.SymbolQuote, user *models.UserEmail, frontendURL string, triggeredAt time.Time) (*emailtmpl.Message, error) {
+	renderer, err := emailtmpl.Default()
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return renderer.Render(emailtmpl.AlertTriggeredTemplate, emailtmpl.ResolveLocale(user.Timezone), emailtmpl.AlertTriggered{
+		UserName:     user.FullName,
+		AlertName:    alert.Name,
+		Symbol:       alert.Symbol,
+		AlertType:    alert.AlertType,
+		Price:        quote.Price,
+		ChangePct:    quote.ChangePct,
+		Volume:       formatVolume(float64(quote.Volume)),
+		TriggeredAt:  triggeredAt.In(loc),
+		WatchlistURL: fmt.Sprintf("%s/watchlist/%s", frontendURL, alert.WatchListID),
+		SettingsURL:  frontendURL + "/settings",
+	})
 }
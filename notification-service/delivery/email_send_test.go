@@ -1,11 +1,14 @@
 package delivery
 
 import (
+	"context"
 	"errors"
 	"os"
 	"sync"
 	"testing"
+	"time"
 
+	"investorcenter-shared/emailtmpl"
 	"notification-service/config"
 	"notification-service/models"
 )
@@ -30,9 +33,10 @@ type mockStore struct {
 	// UpdateAlertLogNotificationSent
 	updateLogErr error
 
-	// GetTodayEmailCount
+	// ReserveDailyCount / ReleaseDailyCount, as one user's emails counter
 	todayEmailCount    int
 	todayEmailCountErr error
+	releasedEmails     int
 
 	// GetNotificationPreferences
 	notifPrefs    *models.NotificationPreferences
@@ -41,36 +45,96 @@ type mockStore struct {
 	// GetUserEmail
 	userEmail    *models.UserEmail
 	userEmailErr error
+
+	// IsEmailSuppressed
+	suppressedEmails map[string]bool
+	suppressedErr    error
+
+	// GetUserSubscription
+	subscription    *models.UserSubscription
+	subscriptionErr error
+
+	// CreateInAppNotification
+	inAppMu            sync.Mutex
+	inAppNotifications []models.InAppNotification
+	inAppErr           error
 }
 
-func (m *mockStore) GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, error) {
+func (m *mockStore) GetActiveAlertsForSymbols(_ context.Context, symbols []string) ([]models.AlertRule, error) {
 	return m.activeAlerts, m.activeAlertsErr
 }
 
-func (m *mockStore) CreateAlertLog(alertLog *models.AlertLog) (string, error) {
+func (m *mockStore) GetActiveAlertsByTypes(_ context.Context, alertTypes []string) ([]models.AlertRule, error) {
+	return m.activeAlerts, m.activeAlertsErr
+}
+
+func (m *mockStore) GetSymbolSnapshots(_ context.Context, symbols []string) (map[string]*models.SymbolSnapshot, error) {
+	return nil, nil
+}
+
+func (m *mockStore) CreateAlertLog(_ context.Context, alertLog *models.AlertLog) (string, error) {
 	return m.createAlertLogID, m.createAlertLogErr
 }
 
-func (m *mockStore) ClaimAlertTrigger(alertID string, frequency string) (bool, error) {
+func (m *mockStore) ClaimAlertTrigger(_ context.Context, alertID string, frequency string) (bool, error) {
 	return m.claimResult, m.claimErr
 }
 
-func (m *mockStore) UpdateAlertLogNotificationSent(logID string, sent bool) error {
+func (m *mockStore) UpdateAlertLogNotificationSent(_ context.Context, logID string, sent bool) error {
 	return m.updateLogErr
 }
 
-func (m *mockStore) GetTodayEmailCount(userID string) (int, error) {
-	return m.todayEmailCount, m.todayEmailCountErr
+func (m *mockStore) ReserveDailyCount(_ context.Context, userID string, day time.Time, counter string, limit int) (bool, error) {
+	if m.todayEmailCountErr != nil {
+		return false, m.todayEmailCountErr
+	}
+	if m.todayEmailCount >= limit {
+		return false, nil
+	}
+	m.todayEmailCount++
+	return true, nil
 }
 
-func (m *mockStore) GetNotificationPreferences(userID string) (*models.NotificationPreferences, error) {
+func (m *mockStore) ReleaseDailyCount(_ context.Context, userID string, day time.Time, counter string) error {
+	m.todayEmailCount--
+	m.releasedEmails++
+	return nil
+}
+
+func (m *mockStore) GetNotificationPreferences(_ context.Context, userID string) (*models.NotificationPreferences, error) {
 	return m.notifPrefs, m.notifPrefsErr
 }
 
-func (m *mockStore) GetUserEmail(userID string) (*models.UserEmail, error) {
+func (m *mockStore) GetUserEmail(_ context.Context, userID string) (*models.UserEmail, error) {
 	return m.userEmail, m.userEmailErr
 }
 
+func (m *mockStore) IsEmailSuppressed(_ context.Context, address string) (bool, error) {
+	return m.suppressedEmails[address], m.suppressedErr
+}
+
+func (m *mockStore) GetUserSubscription(_ context.Context, userID string) (*models.UserSubscription, error) {
+	return m.subscription, m.subscriptionErr
+}
+
+func (m *mockStore) CreateInAppNotification(_ context.Context, n *models.InAppNotification) error {
+	m.inAppMu.Lock()
+	defer m.inAppMu.Unlock()
+	if m.inAppErr != nil {
+		return m.inAppErr
+	}
+	m.inAppNotifications = append(m.inAppNotifications, *n)
+	return nil
+}
+
+func (m *mockStore) GetAlertRule(_ context.Context, alertID string) (*models.AlertRule, error) {
+	return nil, nil
+}
+
+func (m *mockStore) GetRetryableAlertLogs(_ context.Context, since time.Time, maxAttempts, limit int) ([]models.AlertLog, error) {
+	return nil, nil
+}
+
 // ---------------------------------------------------------------------------
 // sendRecorder tracks calls to sendFunc.
 // ---------------------------------------------------------------------------
@@ -84,13 +148,14 @@ type sendRecorder struct {
 type sendCall struct {
 	to      string
 	subject string
-	body    string
+	body    string // HTML part
+	text    string // plaintext part
 }
 
-func (r *sendRecorder) sendFunc(to, subject, body string) error {
+func (r *sendRecorder) sendFunc(_ context.Context, to string, msg *emailtmpl.Message) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.calls = append(r.calls, sendCall{to: to, subject: subject, body: body})
+	r.calls = append(r.calls, sendCall{to: to, subject: msg.Subject, body: msg.HTML, text: msg.Text})
 	return r.err
 }
 
@@ -188,7 +253,7 @@ func TestSend_SMTPNotConfigured(t *testing.T) {
 		sendFunc: rec.sendFunc,
 	}
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -204,7 +269,7 @@ func TestSend_GetNotificationPreferencesError(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -223,7 +288,7 @@ func TestSend_EmailDisabled(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -242,7 +307,7 @@ func TestSend_EmailNotVerified(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -266,9 +331,9 @@ func TestSend_QuietHours(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
-	if err != nil {
-		t.Fatalf("expected nil error, got %v", err)
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected ErrSuppressed, got %v", err)
 	}
 	if rec.callCount() != 0 {
 		t.Fatalf("expected sendFunc not called during quiet hours, got %d calls", rec.callCount())
@@ -283,13 +348,17 @@ func TestSend_RateLimitExceeded(t *testing.T) {
 			MaxEmailsPerDay: 5,
 		},
 		todayEmailCount: 5, // at the limit (count >= max)
+		userEmail: &models.UserEmail{
+			Email:    "user@example.com",
+			FullName: "Test User",
+		},
 	}
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
-	if err != nil {
-		t.Fatalf("expected nil error, got %v", err)
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected ErrSuppressed, got %v", err)
 	}
 	if rec.callCount() != 0 {
 		t.Fatalf("expected sendFunc not called when rate limited, got %d calls", rec.callCount())
@@ -304,7 +373,7 @@ func TestSend_GetUserEmailError(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -324,7 +393,7 @@ func TestSend_HappyPath_NilPrefs(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -356,7 +425,7 @@ func TestSend_HappyPath_PrefsEmailOverride(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -385,13 +454,100 @@ func TestSend_HappyPath_RateLimitNotExceeded(t *testing.T) {
 	rec := &sendRecorder{}
 	d := newTestEmailDelivery(t, store, rec)
 
-	err := d.Send(sampleAlert(), sampleAlertLog(), sampleQuote())
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
 	if rec.callCount() != 1 {
 		t.Fatalf("expected sendFunc called once, got %d calls", rec.callCount())
 	}
+	if store.todayEmailCount != 4 {
+		t.Errorf("expected the email to take a daily slot (4), got %d", store.todayEmailCount)
+	}
+}
+
+func TestSend_FailedSendReleasesDailySlot(t *testing.T) {
+	store := &mockStore{
+		notifPrefs: &models.NotificationPreferences{
+			EmailEnabled:    true,
+			EmailVerified:   true,
+			MaxEmailsPerDay: 10,
+		},
+		todayEmailCount: 3,
+		userEmail: &models.UserEmail{
+			Email:    "user@example.com",
+			FullName: "Test User",
+		},
+	}
+	rec := &sendRecorder{err: errors.New("SMTP timeout")}
+	d := newTestEmailDelivery(t, store, rec)
+
+	if err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err == nil {
+		t.Fatal("expected send error")
+	}
+	if store.todayEmailCount != 3 || store.releasedEmails != 1 {
+		t.Errorf("expected the slot given back (count 3, 1 release), got count %d, %d releases",
+			store.todayEmailCount, store.releasedEmails)
+	}
+}
+
+func TestSend_QuietHoursUseUserTimezone(t *testing.T) {
+	store := &mockStore{
+		notifPrefs: &models.NotificationPreferences{
+			EmailEnabled:       true,
+			EmailVerified:      true,
+			QuietHoursEnabled:  true,
+			QuietHoursStart:    "22:00:00",
+			QuietHoursEnd:      "08:00:00",
+			QuietHoursTimezone: "America/New_York",
+		},
+		userEmail: &models.UserEmail{
+			Email:    "user@example.com",
+			FullName: "Test User",
+		},
+	}
+	rec := &sendRecorder{}
+	d := newTestEmailDelivery(t, store, rec)
+
+	// 03:00 UTC is 23:00 in New York, inside the user's quiet hours
+	d.now = func() time.Time { return time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC) }
+	if err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected ErrSuppressed at 23:00 New York time, got %v", err)
+	}
+
+	// 13:00 UTC is 09:00 in New York
+	d.now = func() time.Time { return time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC) }
+	if err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error at 09:00 New York time, got %v", err)
+	}
+	if rec.callCount() != 1 {
+		t.Fatalf("expected one email sent, got %d", rec.callCount())
+	}
+}
+
+func TestDeliver_SuppressedEmail(t *testing.T) {
+	store := &mockStore{
+		notifPrefs: &models.NotificationPreferences{
+			EmailEnabled:    true,
+			EmailVerified:   true,
+			MaxEmailsPerDay: 1,
+		},
+		todayEmailCount: 1,
+		userEmail: &models.UserEmail{
+			Email:    "user@example.com",
+			FullName: "Test User",
+		},
+	}
+	rec := &sendRecorder{}
+	router := NewRouter(newTestEmailDelivery(t, store, rec))
+
+	err := router.Deliver(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected Deliver to pass ErrSuppressed through, got %v", err)
+	}
+	if rec.callCount() != 0 {
+		t.Fatalf("expected no email once the daily limit is reached, got %d", rec.callCount())
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -413,7 +569,7 @@ func TestDeliver_NotifyEmailTrue(t *testing.T) {
 	alert := sampleAlert()
 	alert.NotifyEmail = true
 
-	err := router.Deliver(alert, sampleAlertLog(), sampleQuote())
+	err := router.Deliver(context.Background(), alert, sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -431,7 +587,7 @@ func TestDeliver_NotifyEmailFalse(t *testing.T) {
 	alert := sampleAlert()
 	alert.NotifyEmail = false
 
-	err := router.Deliver(alert, sampleAlertLog(), sampleQuote())
+	err := router.Deliver(context.Background(), alert, sampleAlertLog(), sampleQuote())
 	if err != nil {
 		t.Fatalf("expected nil error, got %v", err)
 	}
@@ -439,3 +595,31 @@ func TestDeliver_NotifyEmailFalse(t *testing.T) {
 		t.Fatalf("expected sendFunc not called, got %d calls", rec.callCount())
 	}
 }
+
+func TestSend_SuppressedAddressNotEmailed(t *testing.T) {
+	store := &mockStore{
+		notifPrefs: &models.NotificationPreferences{
+			EmailEnabled:    true,
+			EmailVerified:   true,
+			MaxEmailsPerDay: 10,
+		},
+		userEmail: &models.UserEmail{
+			Email:    "bounced@example.com",
+			FullName: "Test User",
+		},
+		suppressedEmails: map[string]bool{"bounced@example.com": true},
+	}
+	rec := &sendRecorder{}
+	d := newTestEmailDelivery(t, store, rec)
+
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected ErrSuppressed, got %v", err)
+	}
+	if rec.callCount() != 0 {
+		t.Fatalf("expected sendFunc not called for a suppressed address, got %d calls", rec.callCount())
+	}
+	if store.todayEmailCount != 0 {
+		t.Errorf("expected no daily slot taken, got %d", store.todayEmailCount)
+	}
+}
@@ -3,7 +3,9 @@ package delivery
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"investorcenter-shared/emailtmpl"
 	"notification-service/models"
 )
 
@@ -11,11 +13,25 @@ import (
 // isInQuietHours
 // ---------------------------------------------------------------------------
 
+// at returns 2026-03-10 at hh:mm UTC
+func at(hh, mm int) time.Time {
+	return time.Date(2026, 3, 10, hh, mm, 0, 0, time.UTC)
+}
+
+func quietPrefs(start, end, tz string) *models.NotificationPreferences {
+	return &models.NotificationPreferences{
+		QuietHoursEnabled:  true,
+		QuietHoursStart:    start,
+		QuietHoursEnd:      end,
+		QuietHoursTimezone: tz,
+	}
+}
+
 func TestIsInQuietHours_Disabled(t *testing.T) {
 	prefs := &models.NotificationPreferences{
 		QuietHoursEnabled: false,
 	}
-	inQuiet, err := isInQuietHours(prefs)
+	inQuiet, err := isInQuietHours(prefs, at(23, 0))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -24,72 +40,62 @@ func TestIsInQuietHours_Disabled(t *testing.T) {
 	}
 }
 
-func TestIsInQuietHours_SameDay_Inside(t *testing.T) {
-	// We can't control time.Now() without refactoring, so we use a range
-	// that covers the full day to guarantee the current time is inside.
-	prefs := &models.NotificationPreferences{
-		QuietHoursEnabled:  true,
-		QuietHoursStart:    "00:00:00",
-		QuietHoursEnd:      "23:59:59",
-		QuietHoursTimezone: "UTC",
-	}
-	inQuiet, err := isInQuietHours(prefs)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+func TestIsInQuietHours_Windows(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		now        time.Time
+		want       bool
+	}{
+		{"same day inside", "09:00:00", "17:00:00", at(12, 0), true},
+		{"same day before", "09:00:00", "17:00:00", at(8, 59), false},
+		{"same day start is quiet", "09:00:00", "17:00:00", at(9, 0), true},
+		{"same day end is not quiet", "09:00:00", "17:00:00", at(17, 0), false},
+		{"overnight late evening", "22:00:00", "08:00:00", at(23, 30), true},
+		{"overnight early morning", "22:00:00", "08:00:00", at(3, 0), true},
+		{"overnight daytime", "22:00:00", "08:00:00", at(12, 0), false},
+		{"overnight end is not quiet", "22:00:00", "08:00:00", at(8, 0), false},
+		{"empty window", "08:00:00", "08:00:00", at(8, 0), false},
+		{"HH:MM values", "22:00", "08:00", at(23, 0), true},
 	}
-	if !inQuiet {
-		t.Error("expected true when quiet hours span full day (00:00-23:59)")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isInQuietHours(quietPrefs(tt.start, tt.end, "UTC"), tt.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isInQuietHours(%s-%s at %s) = %v, want %v",
+					tt.start, tt.end, tt.now.Format("15:04"), got, tt.want)
+			}
+		})
 	}
 }
 
-func TestIsInQuietHours_SameDay_Outside(t *testing.T) {
-	// Use a range that's always in the past (yesterday)
-	// by picking a very narrow range of a single second at midnight.
-	// This test uses an impossible-to-hit range.
-	prefs := &models.NotificationPreferences{
-		QuietHoursEnabled:  true,
-		QuietHoursStart:    "00:00:00",
-		QuietHoursEnd:      "00:00:01",
-		QuietHoursTimezone: "UTC",
-	}
-	// This will be "inside" only at exactly midnight UTC, which is extremely
-	// unlikely. We can't deterministically test this without mocking time.Now().
-	// Instead, test the overnight range logic below which is deterministic.
-	_, err := isInQuietHours(prefs)
+func TestIsInQuietHours_UsesTimezone(t *testing.T) {
+	prefs := quietPrefs("22:00:00", "08:00:00", "Asia/Tokyo")
+
+	// 14:00 UTC is 23:00 in Tokyo
+	inQuiet, err := isInQuietHours(prefs, at(14, 0))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-}
-
-func TestIsInQuietHours_Overnight_FullCoverage(t *testing.T) {
-	// Overnight range that covers the full day (22:00 to 23:59).
-	// Actually, an overnight range where start > end covers currentTime >= start OR <= end.
-	// Use start=00:00:01 end=00:00:00 — this covers almost everything.
-	prefs := &models.NotificationPreferences{
-		QuietHoursEnabled:  true,
-		QuietHoursStart:    "00:00:01",
-		QuietHoursEnd:      "00:00:00",
-		QuietHoursTimezone: "UTC",
+	if !inQuiet {
+		t.Error("expected 23:00 Tokyo time to be in quiet hours")
 	}
-	// This overnight range covers currentTime >= "00:00:01" OR currentTime <= "00:00:00"
-	// which is basically the entire day.
-	inQuiet, err := isInQuietHours(prefs)
+
+	// 23:00 UTC is 08:00 the next day in Tokyo
+	inQuiet, err = isInQuietHours(prefs, at(23, 0))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !inQuiet {
-		t.Error("expected true when overnight range covers nearly full day")
+	if inQuiet {
+		t.Error("expected 08:00 Tokyo time to be outside quiet hours")
 	}
 }
 
 func TestIsInQuietHours_InvalidTimezone(t *testing.T) {
-	prefs := &models.NotificationPreferences{
-		QuietHoursEnabled:  true,
-		QuietHoursStart:    "22:00:00",
-		QuietHoursEnd:      "08:00:00",
-		QuietHoursTimezone: "Invalid/Timezone",
-	}
-	_, err := isInQuietHours(prefs)
+	_, err := isInQuietHours(quietPrefs("22:00:00", "08:00:00", "Invalid/Timezone"), at(23, 0))
 	if err == nil {
 		t.Error("expected error for invalid timezone")
 	}
@@ -105,13 +111,7 @@ func TestIsInQuietHours_ValidTimezones(t *testing.T) {
 	}
 	for _, tz := range timezones {
 		t.Run(tz, func(t *testing.T) {
-			prefs := &models.NotificationPreferences{
-				QuietHoursEnabled:  true,
-				QuietHoursStart:    "22:00:00",
-				QuietHoursEnd:      "08:00:00",
-				QuietHoursTimezone: tz,
-			}
-			_, err := isInQuietHours(prefs)
+			_, err := isInQuietHours(quietPrefs("22:00:00", "08:00:00", tz), at(12, 0))
 			if err != nil {
 				t.Errorf("unexpected error for timezone %s: %v", tz, err)
 			}
@@ -160,9 +160,58 @@ func TestSanitizeHeader_EmptyString(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// formatAlertEmailBody
+// formatAlertEmail
 // ---------------------------------------------------------------------------
 
+// renderAlertEmail renders the alert email for userName, in English and UTC
+func renderAlertEmail(t *testing.T, alert *models.AlertRule, quote *models.SymbolQuote, userName, frontendURL string) *emailtmpl.Message {
+	t.Helper()
+	msg, err := formatAlertEmail(alert, quote, &models.UserEmail{FullName: userName}, frontendURL, at(14, 30))
+	if err != nil {
+		t.Fatalf("formatAlertEmail: %v", err)
+	}
+	return msg
+}
+
+func TestFormatAlertEmail_PlaintextPart(t *testing.T) {
+	alert := &models.AlertRule{Name: "My AAPL Alert", Symbol: "AAPL", AlertType: "price_above", WatchListID: "wl-123"}
+	quote := &models.SymbolQuote{Price: 152.30, Volume: 45000000, ChangePct: 1.25}
+
+	msg := renderAlertEmail(t, alert, quote, "John Doe", "https://investorcenter.ai")
+
+	if msg.Subject != "Alert: AAPL Price Above" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	for _, check := range []string{"Hi John Doe,", "$152.30", "+1.25%", "45.0M", "Mar 10, 2026 2:30 PM UTC", "https://investorcenter.ai/watchlist/wl-123"} {
+		if !strings.Contains(msg.Text, check) {
+			t.Errorf("plaintext part missing %q:\n%s", check, msg.Text)
+		}
+	}
+	if strings.Contains(msg.Text, "<") {
+		t.Errorf("expected no markup in the plaintext part:\n%s", msg.Text)
+	}
+}
+
+func TestFormatAlertEmail_LocalizedByTimezone(t *testing.T) {
+	alert := &models.AlertRule{Name: "Alerta AAPL", Symbol: "AAPL", AlertType: "price_below", WatchListID: "wl-1"}
+	quote := &models.SymbolQuote{Price: 150, Volume: 1000, ChangePct: -2}
+	user := &models.UserEmail{FullName: "Ana", Timezone: "America/Mexico_City"}
+
+	msg, err := formatAlertEmail(alert, quote, user, "https://investorcenter.ai", at(14, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Alerta: AAPL Precio por debajo" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	// 14:30 UTC is 08:30 in Mexico City (CST, no DST since 2022)
+	for _, part := range []string{msg.Text, msg.HTML} {
+		if !strings.Contains(part, "Hola Ana:") || !strings.Contains(part, "10 Mar 2026 08:30 CST") {
+			t.Errorf("expected Spanish wording and local time:\n%s", part)
+		}
+	}
+}
+
 func TestFormatAlertEmailBody_ContainsExpectedContent(t *testing.T) {
 	alert := &models.AlertRule{
 		Name:        "My AAPL Alert",
@@ -176,7 +225,7 @@ func TestFormatAlertEmailBody_ContainsExpectedContent(t *testing.T) {
 		ChangePct: 1.25,
 	}
 
-	body := formatAlertEmailBody(alert, quote, "John Doe", "https://investorcenter.ai")
+	body := renderAlertEmail(t, alert, quote, "John Doe", "https://investorcenter.ai").HTML
 
 	// Check key elements are present in the HTML
 	checks := []string{
@@ -207,7 +256,7 @@ func TestFormatAlertEmailBody_IsHTML(t *testing.T) {
 	}
 	quote := &models.SymbolQuote{Price: 200.0, Volume: 30000000, ChangePct: -0.5}
 
-	body := formatAlertEmailBody(alert, quote, "Jane", "https://example.com")
+	body := renderAlertEmail(t, alert, quote, "Jane", "https://example.com").HTML
 
 	if !strings.Contains(body, "<!DOCTYPE html>") {
 		t.Error("expected HTML doctype")
@@ -226,7 +275,7 @@ func TestFormatAlertEmailBody_WatchlistURL(t *testing.T) {
 	}
 	quote := &models.SymbolQuote{Price: 400.0, Volume: 20000000, ChangePct: 2.0}
 
-	body := formatAlertEmailBody(alert, quote, "User", "https://investorcenter.ai")
+	body := renderAlertEmail(t, alert, quote, "User", "https://investorcenter.ai").HTML
 
 	expected := "https://investorcenter.ai/watchlist/abc-def-123"
 	if !strings.Contains(body, expected) {
@@ -0,0 +1,56 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"notification-service/database"
+	"notification-service/models"
+)
+
+// inAppNotificationType is the notification_queue type of alert entries.
+const inAppNotificationType = "alert_triggered"
+
+// InAppDelivery adds triggered alerts to the user's in-app notification
+// list.
+type InAppDelivery struct {
+	db database.Store
+}
+
+// NewInAppDelivery creates a new InAppDelivery.
+func NewInAppDelivery(db database.Store) *InAppDelivery {
+	return &InAppDelivery{db: db}
+}
+
+// Name implements Channel.
+func (d *InAppDelivery) Name() string { return "in_app" }
+
+// Enabled implements Channel: in-app entries are added for rules with
+// notify_in_app.
+func (d *InAppDelivery) Enabled(alert *models.AlertRule) bool { return alert.NotifyInApp }
+
+// Send adds the alert to the user's notification list. Quiet hours and
+// daily limits don't apply: nothing is pushed to the user.
+func (d *InAppDelivery) Send(ctx context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
+	metadata, err := json.Marshal(map[string]interface{}{
+		"alert_id":      alert.ID,
+		"watch_list_id": alert.WatchListID,
+		"symbol":        alert.Symbol,
+		"alert_type":    alert.AlertType,
+		"price":         quote.Price,
+		"change_pct":    quote.ChangePct,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal in-app metadata: %w", err)
+	}
+
+	return d.db.CreateInAppNotification(ctx, &models.InAppNotification{
+		UserID:     alert.UserID,
+		AlertLogID: alertLog.ID,
+		Type:       inAppNotificationType,
+		Title:      fmt.Sprintf("%s %s", alert.Symbol, alertTypeLabel(alert.AlertType)),
+		Message:    fmt.Sprintf("%s: %s at $%.2f (%+.2f%%)", alert.Name, alert.Symbol, quote.Price, quote.ChangePct),
+		Metadata:   metadata,
+	})
+}
@@ -1,9 +1,60 @@
 package delivery
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 )
 
+// ---------------------------------------------------------------------------
+// InAppDelivery
+// ---------------------------------------------------------------------------
+
+func TestInAppDelivery_Send(t *testing.T) {
+	store := &mockStore{}
+	alert := sampleAlert()
+	alert.NotifyInApp = true
+	d := NewInAppDelivery(store)
+
+	if !d.Enabled(alert) {
+		t.Fatal("expected in-app to be enabled for notify_in_app rules")
+	}
+	if err := d.Send(context.Background(), alert, sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.inAppNotifications) != 1 {
+		t.Fatalf("expected one notification, got %d", len(store.inAppNotifications))
+	}
+	n := store.inAppNotifications[0]
+	if n.UserID != "user-1" || n.AlertLogID != "log-1" || n.Type != "alert_triggered" {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+	if n.Title != "AAPL Price Above" {
+		t.Errorf("title = %q", n.Title)
+	}
+	if n.Message != "AAPL above 200: AAPL at $210.50 (+2.35%)" {
+		t.Errorf("message = %q", n.Message)
+	}
+	var meta map[string]interface{}
+	if err := json.Unmarshal(n.Metadata, &meta); err != nil || meta["alert_id"] != "alert-1" {
+		t.Errorf("metadata = %s (%v)", n.Metadata, err)
+	}
+}
+
+func TestInAppDelivery_DisabledWithoutNotifyInApp(t *testing.T) {
+	if NewInAppDelivery(&mockStore{}).Enabled(sampleAlert()) {
+		t.Error("expected in-app to be off for rules without notify_in_app")
+	}
+}
+
+func TestInAppDelivery_SendError(t *testing.T) {
+	store := &mockStore{inAppErr: errors.New("db down")}
+	if err := NewInAppDelivery(store).Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // alertTypeLabel (lives in email.go, tested here for coverage)
 // ---------------------------------------------------------------------------
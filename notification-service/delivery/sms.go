@@ -0,0 +1,182 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/tracing"
+	"notification-service/config"
+	"notification-service/database"
+	"notification-service/models"
+)
+
+// twilioAPIBase is the Twilio REST API root.
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// smsMaxLength is the length of a single-segment SMS; longer messages are
+// split and billed per segment.
+const smsMaxLength = 160
+
+// smsAlertTypes are the alert types texted to users.
+var smsAlertTypes = map[string]bool{
+	"price_above":      true,
+	"price_below":      true,
+	"price_change_pct": true,
+	"pct_change":       true,
+}
+
+// SMSDelivery texts price alerts via Twilio to users whose subscription
+// plan includes SMS alerts and who have turned them on and verified their
+// phone number.
+type SMSDelivery struct {
+	cfg      *config.Config
+	db       database.Store
+	client   *http.Client
+	baseURL  string
+	sendFunc func(ctx context.Context, to, body string) error // injectable for testing
+	now      func() time.Time                                 // injectable for testing; nil means time.Now
+}
+
+// NewSMSDelivery creates a new SMSDelivery.
+func NewSMSDelivery(cfg *config.Config, db database.Store) *SMSDelivery {
+	d := &SMSDelivery{
+		cfg:     cfg,
+		db:      db,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		baseURL: twilioAPIBase,
+	}
+	d.sendFunc = d.sendSMS
+	return d
+}
+
+// Name implements Channel.
+func (d *SMSDelivery) Name() string { return "sms" }
+
+// Enabled implements Channel: only price alerts are texted.
+func (d *SMSDelivery) Enabled(alert *models.AlertRule) bool { return smsAlertTypes[alert.AlertType] }
+
+// Send texts the alert to the user's verified phone number. It returns nil
+// without sending unless the user has SMS enabled and an active
+// subscription with the sms_alerts feature. Texts held back by quiet hours
+// return an error wrapping ErrSuppressed.
+func (d *SMSDelivery) Send(ctx context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
+	// Skip if Twilio not configured (local dev)
+	if d.cfg.TwilioAccountSID == "" || d.cfg.TwilioAuthToken.Value() == "" || d.cfg.TwilioFromNumber == "" {
+		return nil
+	}
+
+	prefs, err := d.db.GetNotificationPreferences(ctx, alert.UserID)
+	if err != nil {
+		return fmt.Errorf("get notification preferences: %w", err)
+	}
+	if prefs == nil || !prefs.SMSEnabled || !prefs.PhoneVerified || prefs.PhoneNumber == nil || *prefs.PhoneNumber == "" {
+		return nil
+	}
+
+	sub, err := d.db.GetUserSubscription(ctx, alert.UserID)
+	if err != nil {
+		return fmt.Errorf("get user subscription: %w", err)
+	}
+	if !sub.HasFeature(models.FeatureSMSAlerts) {
+		return nil // Plan lapsed or downgraded since SMS was turned on
+	}
+
+	if prefs.QuietHoursEnabled {
+		inQuietHours, err := isInQuietHours(prefs, d.clock())
+		if err != nil {
+			log.Printf("Error checking quiet hours: %v", err)
+		} else if inQuietHours {
+			return fmt.Errorf("%w: user in quiet hours", ErrSuppressed)
+		}
+	}
+
+	return d.sendFunc(ctx, *prefs.PhoneNumber, formatAlertSMS(alert, quote))
+}
+
+func (d *SMSDelivery) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// sendSMS sends a text message through the Twilio Messages API.
+func (d *SMSDelivery) sendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{"To": {to}, "From": {d.cfg.TwilioFromNumber}, "Body": {body}}
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", d.baseURL, url.PathEscape(d.cfg.TwilioAccountSID))
+
+	ctx, span := tracing.Tracer().Start(ctx, "twilio send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.ServerAddress("api.twilio.com")),
+	)
+	err := d.postSMS(ctx, endpoint, form)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("send sms: %w", err)
+	}
+	return nil
+}
+
+func (d *SMSDelivery) postSMS(ctx context.Context, endpoint string, form url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(d.cfg.TwilioAccountSID, d.cfg.TwilioAuthToken.Value())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("twilio returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// formatAlertSMS returns the alert text: the symbol, the rule's condition
+// and the current price, within one SMS segment.
+func formatAlertSMS(alert *models.AlertRule, quote *models.SymbolQuote) string {
+	msg := fmt.Sprintf("InvestorCenter: %s %s. Now $%.2f (%+.2f%%)",
+		alert.Symbol, smsCondition(alert), quote.Price, quote.ChangePct)
+	if len(msg) > smsMaxLength {
+		msg = msg[:smsMaxLength-3] + "..."
+	}
+	return msg
+}
+
+// smsCondition describes the rule's trigger condition, e.g. "above $200.00".
+func smsCondition(alert *models.AlertRule) string {
+	switch alert.AlertType {
+	case "price_above", "price_below":
+		var cond models.ThresholdCondition
+		if err := json.Unmarshal(alert.Conditions, &cond); err == nil {
+			return fmt.Sprintf("%s $%.2f", strings.TrimPrefix(alert.AlertType, "price_"), cond.Threshold)
+		}
+	case "price_change_pct", "pct_change":
+		var cond models.PriceChangeCondition
+		if err := json.Unmarshal(alert.Conditions, &cond); err == nil {
+			switch cond.Direction {
+			case "up", "down":
+				return fmt.Sprintf("moved %s %.2f%%", cond.Direction, cond.PercentChange)
+			default:
+				return fmt.Sprintf("moved %.2f%%", cond.PercentChange)
+			}
+		}
+	}
+	return strings.ToLower(alertTypeLabel(alert.AlertType))
+}
@@ -0,0 +1,199 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/config"
+	"notification-service/models"
+)
+
+// smsRecorder tracks calls to SMSDelivery.sendFunc.
+type smsRecorder struct {
+	to, body []string
+	err      error
+}
+
+func (r *smsRecorder) send(_ context.Context, to, body string) error {
+	r.to = append(r.to, to)
+	r.body = append(r.body, body)
+	return r.err
+}
+
+func twilioConfig(t *testing.T) *config.Config {
+	t.Helper()
+	t.Setenv("TWILIO_ACCOUNT_SID", "AC123")
+	t.Setenv("TWILIO_AUTH_TOKEN", "token")
+	t.Setenv("TWILIO_FROM_NUMBER", "+15005550006")
+	return config.Load()
+}
+
+func smsPrefs() *models.NotificationPreferences {
+	return &models.NotificationPreferences{SMSEnabled: true, PhoneVerified: true, PhoneNumber: stringPtr("+14155550123")}
+}
+
+func smsSubscription(status string, smsAlerts bool) *models.UserSubscription {
+	features, _ := json.Marshal(map[string]bool{models.FeatureSMSAlerts: smsAlerts})
+	return &models.UserSubscription{PlanName: "premium", Status: status, Features: features}
+}
+
+func newTestSMSDelivery(t *testing.T, store *mockStore, rec *smsRecorder) *SMSDelivery {
+	return &SMSDelivery{cfg: twilioConfig(t), db: store, sendFunc: rec.send}
+}
+
+func TestSMSEnabled_PriceAlertsOnly(t *testing.T) {
+	d := &SMSDelivery{}
+	for alertType, want := range map[string]bool{
+		"price_above": true, "price_below": true, "price_change_pct": true, "pct_change": true,
+		"volume_spike": false, "pe_crosses": false, "ic_score": false,
+	} {
+		if got := d.Enabled(&models.AlertRule{AlertType: alertType}); got != want {
+			t.Errorf("Enabled(%s) = %v, want %v", alertType, got, want)
+		}
+	}
+}
+
+func TestSMSSend_HappyPath(t *testing.T) {
+	rec := &smsRecorder{}
+	d := newTestSMSDelivery(t, &mockStore{notifPrefs: smsPrefs(), subscription: smsSubscription("active", true)}, rec)
+
+	if err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(rec.to) != 1 || rec.to[0] != "+14155550123" {
+		t.Fatalf("expected one text to +14155550123, got %v", rec.to)
+	}
+	if !strings.Contains(rec.body[0], "AAPL") || !strings.Contains(rec.body[0], "$210.50") {
+		t.Errorf("expected symbol and price in body, got %q", rec.body[0])
+	}
+}
+
+func TestSMSSend_Skipped(t *testing.T) {
+	unverified := smsPrefs()
+	unverified.PhoneVerified = false
+	disabled := smsPrefs()
+	disabled.SMSEnabled = false
+
+	cases := map[string]*mockStore{
+		"no prefs":           {subscription: smsSubscription("active", true)},
+		"sms disabled":       {notifPrefs: disabled, subscription: smsSubscription("active", true)},
+		"phone unverified":   {notifPrefs: unverified, subscription: smsSubscription("active", true)},
+		"no subscription":    {notifPrefs: smsPrefs()},
+		"plan without sms":   {notifPrefs: smsPrefs(), subscription: smsSubscription("active", false)},
+		"subscription ended": {notifPrefs: smsPrefs(), subscription: smsSubscription("canceled", true)},
+	}
+	for name, store := range cases {
+		rec := &smsRecorder{}
+		if err := newTestSMSDelivery(t, store, rec).Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+			t.Errorf("%s: expected nil error, got %v", name, err)
+		}
+		if len(rec.to) != 0 {
+			t.Errorf("%s: expected no text, got %v", name, rec.to)
+		}
+	}
+}
+
+func TestSMSSend_TwilioNotConfigured(t *testing.T) {
+	rec := &smsRecorder{}
+	d := &SMSDelivery{cfg: &config.Config{}, db: &mockStore{notifPrefs: smsPrefs()}, sendFunc: rec.send}
+	if err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(rec.to) != 0 {
+		t.Errorf("expected no text without Twilio credentials, got %v", rec.to)
+	}
+}
+
+func TestSMSSend_SubscriptionError(t *testing.T) {
+	rec := &smsRecorder{}
+	d := newTestSMSDelivery(t, &mockStore{notifPrefs: smsPrefs(), subscriptionErr: errors.New("db down")}, rec)
+	if err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestSMSSend_QuietHoursSuppressed(t *testing.T) {
+	prefs := smsPrefs()
+	prefs.QuietHoursEnabled = true
+	prefs.QuietHoursStart = "22:00:00"
+	prefs.QuietHoursEnd = "08:00:00"
+	prefs.QuietHoursTimezone = "UTC"
+	rec := &smsRecorder{}
+	d := newTestSMSDelivery(t, &mockStore{notifPrefs: prefs, subscription: smsSubscription("active", true)}, rec)
+	d.now = func() time.Time { return time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC) }
+
+	err := d.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, ErrSuppressed) {
+		t.Fatalf("expected ErrSuppressed, got %v", err)
+	}
+	if len(rec.to) != 0 {
+		t.Errorf("expected no text in quiet hours, got %v", rec.to)
+	}
+}
+
+func TestFormatAlertSMS(t *testing.T) {
+	quote := &models.SymbolQuote{Price: 210.5, ChangePct: 2.35}
+	cases := []struct {
+		alertType, conditions, want string
+	}{
+		{"price_above", `{"threshold": 200}`, "InvestorCenter: AAPL above $200.00. Now $210.50 (+2.35%)"},
+		{"price_below", `{"threshold": 220}`, "InvestorCenter: AAPL below $220.00. Now $210.50 (+2.35%)"},
+		{"price_change_pct", `{"percent_change": 2, "direction": "up"}`, "InvestorCenter: AAPL moved up 2.00%. Now $210.50 (+2.35%)"},
+		{"pct_change", `{"percent_change": 2, "direction": "either"}`, "InvestorCenter: AAPL moved 2.00%. Now $210.50 (+2.35%)"},
+	}
+	for _, tc := range cases {
+		alert := &models.AlertRule{Symbol: "AAPL", AlertType: tc.alertType, Conditions: json.RawMessage(tc.conditions)}
+		if got := formatAlertSMS(alert, quote); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.alertType, got, tc.want)
+		}
+	}
+
+	long := &models.AlertRule{Symbol: strings.Repeat("X", 200), AlertType: "price_above", Conditions: json.RawMessage(`{"threshold": 1}`)}
+	if got := formatAlertSMS(long, quote); len(got) > smsMaxLength {
+		t.Errorf("expected at most %d characters, got %d", smsMaxLength, len(got))
+	}
+}
+
+func TestSendSMS_Twilio(t *testing.T) {
+	var gotPath, gotUser, gotPass, gotTo, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, gotPass, _ = r.BasicAuth()
+		_ = r.ParseForm()
+		gotTo, gotBody = r.PostForm.Get("To"), r.PostForm.Get("Body")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	d := NewSMSDelivery(twilioConfig(t), &mockStore{})
+	d.baseURL = srv.URL
+	if err := d.sendSMS(context.Background(), "+14155550123", "hello"); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if gotPath != "/Accounts/AC123/Messages.json" || gotUser != "AC123" || gotPass != "token" {
+		t.Errorf("unexpected request: path %q, auth %q/%q", gotPath, gotUser, gotPass)
+	}
+	if gotTo != "+14155550123" || gotBody != "hello" {
+		t.Errorf("unexpected form: To %q, Body %q", gotTo, gotBody)
+	}
+}
+
+func TestSendSMS_TwilioError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code": 21211}`))
+	}))
+	defer srv.Close()
+
+	d := NewSMSDelivery(twilioConfig(t), &mockStore{})
+	d.baseURL = srv.URL
+	if err := d.sendSMS(context.Background(), "+14155550123", "hello"); err == nil || !strings.Contains(err.Error(), "21211") {
+		t.Fatalf("expected Twilio error, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"notification-service/config"
+	"notification-service/database"
+	"notification-service/models"
+)
+
+// Webhook request headers. When the user has set a webhook secret,
+// SignatureHeader is "sha256=" followed by the hex HMAC-SHA256, keyed by the
+// secret, of TimestampHeader's value, a ".", and the request body.
+// Receivers should recompute it and reject stale timestamps.
+const (
+	WebhookSignatureHeader = "X-InvestorCenter-Signature"
+	WebhookTimestampHeader = "X-InvestorCenter-Timestamp"
+)
+
+const (
+	webhookAttempts = 3
+	webhookBackoff  = time.Second // doubles after each failed attempt
+	webhookTimeout  = 5 * time.Second
+)
+
+// errBlockedAddress is returned for webhook URLs that resolve to a
+// loopback, private or link-local address.
+var errBlockedAddress = errors.New("webhook address is not public")
+
+// WebhookDelivery POSTs alert notifications as JSON to the webhook URL in
+// the user's notification preferences (e.g. a Slack or Discord incoming
+// webhook). Failed requests are retried with backoff; a notification that
+// still can't be delivered is written to the dead-letter log with its
+// payload so it can be replayed.
+type WebhookDelivery struct {
+	cfg      *config.Config
+	db       database.Store
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+	sleep    func(time.Duration) // injectable for testing
+	now      func() time.Time    // injectable for testing; nil means time.Now
+}
+
+// NewWebhookDelivery creates a new WebhookDelivery. Its client refuses to
+// connect to non-public addresses, since the URL is user supplied.
+func NewWebhookDelivery(cfg *config.Config, db database.Store) *WebhookDelivery {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: refuseNonPublic}
+	return &WebhookDelivery{
+		cfg: cfg,
+		db:  db,
+		client: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+		},
+		attempts: webhookAttempts,
+		backoff:  webhookBackoff,
+		sleep:    time.Sleep,
+	}
+}
+
+// Name implements Channel.
+func (w *WebhookDelivery) Name() string { return "webhook" }
+
+// Enabled implements Channel: the webhook applies to every rule, for users
+// who have set a webhook URL.
+func (w *WebhookDelivery) Enabled(alert *models.AlertRule) bool { return true }
+
+// Send posts the alert to the user's webhook, if they have one.
+func (w *WebhookDelivery) Send(ctx context.Context, alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote) error {
+	prefs, err := w.db.GetNotificationPreferences(ctx, alert.UserID)
+	if err != nil {
+		return fmt.Errorf("get notification preferences: %w", err)
+	}
+	if prefs == nil || prefs.WebhookURL == nil || *prefs.WebhookURL == "" {
+		return nil
+	}
+	secret := ""
+	if prefs.WebhookSecret != nil {
+		secret = *prefs.WebhookSecret
+	}
+
+	body, err := json.Marshal(newWebhookPayload(alert, alertLog, quote, w.cfg.FrontendURL))
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	delay := w.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, *prefs.WebhookURL, secret, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.attempts {
+			// The URL isn't logged: incoming webhook URLs are credentials.
+			log.Printf("Webhook dead letter: alert %s, user %s, log %s after %d attempt(s): %v; payload: %s",
+				alert.ID, alert.UserID, alertLog.ID, attempt, err, body)
+			return err
+		}
+		w.sleep(delay)
+		delay *= 2
+	}
+}
+
+// post makes one delivery attempt. retry reports whether a failure may be
+// transient: network errors, 429 and 5xx responses.
+func (w *WebhookDelivery) post(ctx context.Context, url, secret string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		ts := strconv.FormatInt(w.clock().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, ts)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(secret, ts, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, errBlockedAddress), fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // lets the connection be reused
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
+
+func (w *WebhookDelivery) clock() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+// signWebhook returns the hex HMAC-SHA256 of timestamp "." body under secret.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// refuseNonPublic is a net.Dialer Control func that stops webhooks from
+// reaching internal services. It sees the resolved address, so it also
+// covers DNS names and redirects that point inside.
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+	return nil
+}
+
+// webhookPayload is the JSON body posted to webhooks. Text and Content
+// carry a readable summary for Slack and Discord incoming webhooks, which
+// display those fields.
+type webhookPayload struct {
+	Text        string    `json:"text"`
+	Content     string    `json:"content"`
+	Event       string    `json:"event"`
+	AlertID     string    `json:"alert_id"`
+	AlertLogID  string    `json:"alert_log_id"`
+	AlertName   string    `json:"alert_name"`
+	AlertType   string    `json:"alert_type"`
+	Symbol      string    `json:"symbol"`
+	Price       float64   `json:"price"`
+	ChangePct   float64   `json:"change_pct"`
+	Volume      int64     `json:"volume"`
+	TriggeredAt time.Time `json:"triggered_at"`
+	URL         string    `json:"url"`
+}
+
+func newWebhookPayload(alert *models.AlertRule, alertLog *models.AlertLog, quote *models.SymbolQuote, frontendURL string) webhookPayload {
+	text := fmt.Sprintf("%s: %s %s at $%.2f (%+.2f%%)",
+		alert.Name, alert.Symbol, alertTypeLabel(alert.AlertType), quote.Price, quote.ChangePct)
+	return webhookPayload{
+		Text:        text,
+		Content:     text,
+		Event:       "alert.triggered",
+		AlertID:     alert.ID,
+		AlertLogID:  alertLog.ID,
+		AlertName:   alert.Name,
+		AlertType:   alert.AlertType,
+		Symbol:      alert.Symbol,
+		Price:       quote.Price,
+		ChangePct:   quote.ChangePct,
+		Volume:      quote.Volume,
+		TriggeredAt: alertLog.TriggeredAt,
+		URL:         fmt.Sprintf("%s/watchlist/%s", frontendURL, alert.WatchListID),
+	}
+}
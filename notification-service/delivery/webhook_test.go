@@ -0,0 +1,206 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/config"
+	"notification-service/models"
+)
+
+// webhookServer records the requests it receives and answers each with the
+// next status in statuses (the last one repeats).
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []webhookRequest
+}
+
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	t.Helper()
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, webhookRequest{header: r.Header.Clone(), body: body})
+		status := s.statuses[0]
+		if len(s.statuses) > 1 {
+			s.statuses = s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []webhookRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]webhookRequest(nil), s.requests...)
+}
+
+// newTestWebhookDelivery returns a WebhookDelivery for the user's webhook at
+// url, using the test server's client (which may dial loopback) and
+// recording backoff sleeps instead of sleeping.
+func newTestWebhookDelivery(url string, secret *string, sleeps *[]time.Duration) *WebhookDelivery {
+	store := &mockStore{notifPrefs: &models.NotificationPreferences{WebhookURL: &url, WebhookSecret: secret}}
+	return &WebhookDelivery{
+		cfg:      &config.Config{FrontendURL: "https://example.com"},
+		db:       store,
+		client:   &http.Client{Timeout: time.Second},
+		attempts: webhookAttempts,
+		backoff:  webhookBackoff,
+		sleep:    func(d time.Duration) { *sleeps = append(*sleeps, d) },
+		now:      func() time.Time { return time.Unix(1740000000, 0) },
+	}
+}
+
+func TestWebhookSend_SignedPayload(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusNoContent)
+	var sleeps []time.Duration
+	w := newTestWebhookDelivery(srv.URL, stringPtr("s3cret"), &sleeps)
+
+	if err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	reqs := srv.received()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	req := reqs[0]
+	if got := req.header.Get(WebhookTimestampHeader); got != "1740000000" {
+		t.Errorf("timestamp header = %q, want 1740000000", got)
+	}
+	want := "sha256=" + signWebhook("s3cret", "1740000000", req.body)
+	if got := req.header.Get(WebhookSignatureHeader); got != want {
+		t.Errorf("signature header = %q, want %q", got, want)
+	}
+	// Known-answer check, so receivers can verify against the documented scheme
+	// (printf '1.{}' | openssl dgst -sha256 -hmac key)
+	if got := signWebhook("key", "1", []byte("{}")); got != "1ba6b8171186efc613e8bcc0cbdab2748f24984d7c5a84faa2637afa0e40d224" {
+		t.Errorf("signWebhook = %q, want HMAC-SHA256 of timestamp.body", got)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if payload.Event != "alert.triggered" || payload.AlertID != "alert-1" || payload.AlertLogID != "log-1" {
+		t.Errorf("unexpected payload identifiers: %+v", payload)
+	}
+	if payload.Symbol != "AAPL" || payload.Price != 210.50 {
+		t.Errorf("unexpected payload quote: %+v", payload)
+	}
+	if payload.Text == "" || payload.Text != payload.Content {
+		t.Errorf("expected a summary in text and content, got %q / %q", payload.Text, payload.Content)
+	}
+	if payload.URL != "https://example.com/watchlist/wl-1" {
+		t.Errorf("url = %q", payload.URL)
+	}
+}
+
+func TestWebhookSend_NoSecretNoSignature(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusOK)
+	var sleeps []time.Duration
+	w := newTestWebhookDelivery(srv.URL, nil, &sleeps)
+
+	if err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if got := srv.received()[0].header.Get(WebhookSignatureHeader); got != "" {
+		t.Errorf("expected no signature without a secret, got %q", got)
+	}
+}
+
+func TestWebhookSend_NoWebhookConfigured(t *testing.T) {
+	for _, prefs := range []*models.NotificationPreferences{nil, {}, {WebhookURL: stringPtr("")}} {
+		w := &WebhookDelivery{db: &mockStore{notifPrefs: prefs}}
+		if err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+			t.Errorf("prefs %+v: expected nil error, got %v", prefs, err)
+		}
+	}
+}
+
+func TestWebhookSend_PreferencesError(t *testing.T) {
+	w := &WebhookDelivery{db: &mockStore{notifPrefsErr: errors.New("db down")}}
+	if err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestWebhookSend_RetriesTransientFailures(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK)
+	var sleeps []time.Duration
+	w := newTestWebhookDelivery(srv.URL, nil, &sleeps)
+
+	if err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err != nil {
+		t.Fatalf("expected delivery on the third attempt, got %v", err)
+	}
+	if n := len(srv.received()); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+	if len(sleeps) != 2 || sleeps[0] != webhookBackoff || sleeps[1] != 2*webhookBackoff {
+		t.Errorf("expected doubling backoff, got %v", sleeps)
+	}
+}
+
+func TestWebhookSend_GivesUpAfterMaxAttempts(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusServiceUnavailable)
+	var sleeps []time.Duration
+	w := newTestWebhookDelivery(srv.URL, nil, &sleeps)
+
+	err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if err == nil {
+		t.Fatal("expected error once retries are exhausted")
+	}
+	if n := len(srv.received()); n != webhookAttempts {
+		t.Errorf("expected %d attempts, got %d", webhookAttempts, n)
+	}
+}
+
+func TestWebhookSend_ClientErrorNotRetried(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusNotFound)
+	var sleeps []time.Duration
+	w := newTestWebhookDelivery(srv.URL, nil, &sleeps)
+
+	if err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote()); err == nil {
+		t.Fatal("expected error for 404")
+	}
+	if n := len(srv.received()); n != 1 {
+		t.Errorf("expected a single attempt for a 4xx, got %d", n)
+	}
+	if len(sleeps) != 0 {
+		t.Errorf("expected no backoff, got %v", sleeps)
+	}
+}
+
+func TestNewWebhookDelivery_RefusesLoopback(t *testing.T) {
+	srv := newWebhookServer(t, http.StatusOK)
+	w := NewWebhookDelivery(&config.Config{}, &mockStore{
+		notifPrefs: &models.NotificationPreferences{WebhookURL: &srv.URL},
+	})
+	w.sleep = func(time.Duration) { t.Error("blocked addresses should not be retried") }
+
+	err := w.Send(context.Background(), sampleAlert(), sampleAlertLog(), sampleQuote())
+	if !errors.Is(err, errBlockedAddress) {
+		t.Fatalf("expected errBlockedAddress, got %v", err)
+	}
+	if n := len(srv.received()); n != 0 {
+		t.Errorf("expected no request to reach the server, got %d", n)
+	}
+}
@@ -0,0 +1,27 @@
+package evaluator
+
+import (
+	"errors"
+
+	"investorcenter-shared/alertcond"
+	"notification-service/models"
+)
+
+// EvaluateCondition checks an alert's condition against a quote. The rules
+// live in the shared alertcond package, which the backend's test-fire
+// endpoint uses too. Alert types that aren't decided by a quote never
+// trigger and compare nothing.
+func EvaluateCondition(alert *models.AlertRule, quote *models.SymbolQuote) (alertcond.Result, error) {
+	result, err := alertcond.Evaluate(alert.AlertType, alert.Conditions, alertcond.Quote{
+		Price:        quote.Price,
+		Volume:       quote.Volume,
+		ChangePct:    quote.ChangePct,
+		AvgVolume30d: quote.AvgVolume30d,
+		AvgVolume90d: quote.AvgVolume90d,
+		PERatio:      quote.PERatio,
+	})
+	if errors.Is(err, alertcond.ErrNotQuoteEvaluated) {
+		return alertcond.Result{}, nil
+	}
+	return result, err
+}
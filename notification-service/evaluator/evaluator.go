@@ -1,11 +1,18 @@
 package evaluator
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/alertcond"
+	"investorcenter-shared/tracing"
 	"notification-service/database"
 	"notification-service/delivery"
 	"notification-service/models"
@@ -15,6 +22,7 @@ import (
 type Evaluator struct {
 	db       database.Store
 	delivery *delivery.Router
+	throttle *Throttle // nil evaluates every update immediately
 }
 
 // New creates a new Evaluator.
@@ -22,10 +30,23 @@ func New(db database.Store, delivery *delivery.Router) *Evaluator {
 	return &Evaluator{db: db, delivery: delivery}
 }
 
+// SetThrottle coalesces price updates so each symbol's rules are evaluated
+// at most once per interval. Pair with RunThrottleFlush so updates held back
+// are still evaluated. An interval <= 0 evaluates every update.
+func (e *Evaluator) SetThrottle(interval time.Duration) {
+	if interval <= 0 {
+		e.throttle = nil
+		return
+	}
+	e.throttle = NewThrottle(interval)
+}
+
 // HandlePriceUpdate processes a single SNS price update message.
 // It parses the message, queries matching alerts, evaluates conditions,
-// and delivers notifications for triggered alerts.
-func (e *Evaluator) HandlePriceUpdate(msg []byte) error {
+// and delivers notifications for triggered alerts. When throttled, symbols
+// evaluated within the interval are coalesced instead. ctx carries the
+// message's span, which the queries and deliveries are traced under.
+func (e *Evaluator) HandlePriceUpdate(ctx context.Context, msg []byte) error {
 	var update models.PriceUpdateMessage
 	if err := json.Unmarshal(msg, &update); err != nil {
 		return fmt.Errorf("parse price update: %w", err)
@@ -35,14 +56,35 @@ func (e *Evaluator) HandlePriceUpdate(msg []byte) error {
 		return nil
 	}
 
+	var samples map[string]*quoteSamples
+	if e.throttle != nil {
+		samples = e.throttle.Offer(update.Symbols)
+	} else {
+		now := time.Now()
+		samples = make(map[string]*quoteSamples, len(update.Symbols))
+		for symbol, quote := range update.Symbols {
+			samples[symbol] = newQuoteSamples(quote, now)
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	return e.evaluateSamples(ctx, samples)
+}
+
+// evaluateSamples fetches the active alerts for the sampled symbols, and
+// triggers each whose condition is met by any of its symbol's candidate
+// quotes.
+func (e *Evaluator) evaluateSamples(ctx context.Context, samples map[string]*quoteSamples) error {
 	// Extract symbol list for DB query
-	symbols := make([]string, 0, len(update.Symbols))
-	for symbol := range update.Symbols {
+	symbols := make([]string, 0, len(samples))
+	for symbol := range samples {
 		symbols = append(symbols, symbol)
 	}
 
 	// Query only alerts for symbols in this update
-	alerts, err := e.db.GetActiveAlertsForSymbols(symbols)
+	alerts, err := e.db.GetActiveAlertsForSymbols(ctx, symbols)
 	if err != nil {
 		return fmt.Errorf("fetch alerts: %w", err)
 	}
@@ -54,7 +96,7 @@ func (e *Evaluator) HandlePriceUpdate(msg []byte) error {
 	var triggered int
 	for i := range alerts {
 		alert := &alerts[i]
-		quote, exists := update.Symbols[alert.Symbol]
+		sample, exists := samples[alert.Symbol]
 		if !exists {
 			continue
 		}
@@ -67,17 +109,17 @@ func (e *Evaluator) HandlePriceUpdate(msg []byte) error {
 		}
 
 		// Evaluate the alert condition
-		conditionMet, err := evaluate(alert, &quote)
+		quote, eval, err := evaluateCandidates(alert, sample)
 		if err != nil {
 			log.Printf("Error evaluating alert %s: %v", alert.ID, err)
 			continue
 		}
-		if !conditionMet {
+		if !eval.Triggered {
 			continue
 		}
 
 		// Alert triggered — log, update, and deliver
-		if err := e.trigger(alert, &quote); err != nil {
+		if err := e.triggerWithData(ctx, alert, &quote, map[string]interface{}{"compared": eval.Compared}); err != nil {
 			log.Printf("Error triggering alert %s: %v", alert.ID, err)
 			// Continue processing other alerts
 		} else {
@@ -92,20 +134,56 @@ func (e *Evaluator) HandlePriceUpdate(msg []byte) error {
 	return nil
 }
 
+// evaluateCandidates evaluates an alert against each candidate quote and
+// returns the first that meets its condition.
+func evaluateCandidates(alert *models.AlertRule, sample *quoteSamples) (models.SymbolQuote, alertcond.Result, error) {
+	for _, quote := range sample.candidatesFor(alert) {
+		eval, err := EvaluateCondition(alert, &quote)
+		if err != nil {
+			return quote, alertcond.Result{}, err
+		}
+		if eval.Triggered {
+			return quote, eval, nil
+		}
+	}
+	return sample.latest, alertcond.Result{}, nil
+}
+
 // trigger handles a single triggered alert: atomically claims the trigger slot,
 // creates a log entry, and delivers notifications.
-func (e *Evaluator) trigger(alert *models.AlertRule, quote *models.SymbolQuote) error {
+func (e *Evaluator) trigger(ctx context.Context, alert *models.AlertRule, quote *models.SymbolQuote) error {
+	return e.triggerWithData(ctx, alert, quote, nil)
+}
+
+// triggerWithData is trigger with extra fields merged into the alert log's
+// market_data.
+func (e *Evaluator) triggerWithData(ctx context.Context, alert *models.AlertRule, quote *models.SymbolQuote, extra map[string]interface{}) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "trigger alert",
+		trace.WithAttributes(attribute.String("alert.id", alert.ID), attribute.String("alert.type", alert.AlertType)),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Atomically claim the trigger slot in the DB. This prevents race conditions
 	// where multiple consumers (or a future multi-replica setup) could trigger
 	// the same alert simultaneously. The UPDATE uses a WHERE clause that checks
 	// frequency constraints, so only one caller wins the claim.
-	claimed, err := e.db.ClaimAlertTrigger(alert.ID, alert.Frequency)
+	//
+	// The user's max_alerts_per_day is checked first, so an alert held back
+	// by it isn't marked triggered and can still fire once the day rolls
+	// over.
+	release, allowed := e.reserveDailyAlert(ctx, alert)
+	if !allowed {
+		return nil
+	}
+	claimed, err := e.db.ClaimAlertTrigger(ctx, alert.ID, alert.Frequency)
 	if err != nil {
+		release()
 		return fmt.Errorf("claim alert trigger: %w", err)
 	}
 	if !claimed {
 		// Another consumer already triggered this alert, or the frequency
 		// constraint was not met at the DB level. Skip silently.
+		release()
 		return nil
 	}
 
@@ -120,13 +198,17 @@ func (e *Evaluator) trigger(alert *models.AlertRule, quote *models.SymbolQuote)
 		conditionMet = []byte(`{"triggered":true}`)
 	}
 
-	marketData, err := json.Marshal(map[string]interface{}{
+	data := map[string]interface{}{
 		"symbol":     alert.Symbol,
 		"price":      quote.Price,
 		"volume":     quote.Volume,
 		"change_pct": quote.ChangePct,
 		"timestamp":  time.Now().Unix(),
-	})
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	marketData, err := json.Marshal(data)
 	if err != nil {
 		log.Printf("Warning: failed to marshal market_data for alert %s: %v", alert.ID, err)
 		marketData = []byte(`{}`)
@@ -143,21 +225,25 @@ func (e *Evaluator) trigger(alert *models.AlertRule, quote *models.SymbolQuote)
 		MarketData:       marketData,
 		NotificationSent: false,
 	}
-	logID, err := e.db.CreateAlertLog(alertLog)
+	logID, err := e.db.CreateAlertLog(ctx, alertLog)
 	if err != nil {
 		return fmt.Errorf("create alert log: %w", err)
 	}
 	alertLog.ID = logID
 
-	// 2. Deliver notifications (email)
-	deliveryErr := e.delivery.Deliver(alert, alertLog, quote)
-	if deliveryErr != nil {
+	// 2. Deliver notifications (email, in-app, webhook, SMS)
+	deliveryErr := e.delivery.Deliver(ctx, alert, alertLog, quote)
+	switch {
+	case errors.Is(deliveryErr, delivery.ErrSuppressed):
+		// Held back by quiet hours or the daily email limit; the alert is
+		// in-app only and notification_sent stays false.
+	case deliveryErr != nil:
 		log.Printf("Delivery error for alert %s: %v", alert.ID, deliveryErr)
 		// Don't return error — the alert was still triggered successfully.
 		// notification_sent remains false to reflect failed delivery.
-	} else {
+	default:
 		// Mark notification as successfully sent
-		if err := e.db.UpdateAlertLogNotificationSent(logID, true); err != nil {
+		if err := e.db.UpdateAlertLogNotificationSent(ctx, logID, true); err != nil {
 			log.Printf("Warning: failed to update notification_sent for log %s: %v", logID, err)
 		}
 	}
@@ -165,6 +251,38 @@ func (e *Evaluator) trigger(alert *models.AlertRule, quote *models.SymbolQuote)
 	return nil
 }
 
+// reserveDailyAlert takes one of the alert owner's max_alerts_per_day slots
+// for their local day. It returns false once they have reached the limit,
+// and otherwise a func that gives the slot back if the alert doesn't fire.
+// When the limit can't be checked the alert is let through.
+func (e *Evaluator) reserveDailyAlert(ctx context.Context, alert *models.AlertRule) (release func(), allowed bool) {
+	release = func() {}
+	prefs, err := e.db.GetNotificationPreferences(ctx, alert.UserID)
+	if err != nil {
+		log.Printf("Warning: failed to get notification preferences for user %s: %v", alert.UserID, err)
+		return release, true
+	}
+	if prefs == nil || prefs.MaxAlertsPerDay <= 0 {
+		return release, true
+	}
+
+	day := prefs.LocalDay(time.Now())
+	reserved, err := e.db.ReserveDailyCount(ctx, alert.UserID, day, models.DailyCountAlerts, prefs.MaxAlertsPerDay)
+	if err != nil {
+		log.Printf("Warning: failed to reserve daily alert slot: %v", err)
+		return release, true
+	}
+	if !reserved {
+		log.Printf("Skipping alert %s — user %s reached daily alert limit (%d)", alert.ID, alert.UserID, prefs.MaxAlertsPerDay)
+		return release, false
+	}
+	return func() {
+		if err := e.db.ReleaseDailyCount(ctx, alert.UserID, day, models.DailyCountAlerts); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}, true
+}
+
 // getThreshold extracts the numeric threshold from an alert's conditions JSON.
 func getThreshold(alert *models.AlertRule) float64 {
 	var cond models.ThresholdCondition
@@ -172,8 +290,17 @@ func getThreshold(alert *models.AlertRule) float64 {
 		return cond.Threshold
 	}
 	var spike models.VolumeSpikeCondition
-	if err := json.Unmarshal(alert.Conditions, &spike); err == nil && spike.VolumeMultiplier > 0 {
-		return spike.VolumeMultiplier
+	if err := json.Unmarshal(alert.Conditions, &spike); err == nil {
+		if spike.VolumeMultiplier > 0 {
+			return spike.VolumeMultiplier
+		}
+		if spike.PercentAbove > 0 {
+			return spike.PercentAbove
+		}
+	}
+	var change models.PriceChangeCondition
+	if err := json.Unmarshal(alert.Conditions, &change); err == nil && change.PercentChange > 0 {
+		return change.PercentChange
 	}
 	return 0
 }
@@ -184,6 +311,11 @@ func getThreshold(alert *models.AlertRule) float64 {
 // NOTE: This is a pre-filter only. The actual atomic claim happens in
 // ClaimAlertTrigger at the DB level, which prevents race conditions.
 func shouldTriggerBasedOnFrequency(alert *models.AlertRule) bool {
+	return shouldTriggerAt(alert, time.Now())
+}
+
+// shouldTriggerAt is shouldTriggerBasedOnFrequency as of now.
+func shouldTriggerAt(alert *models.AlertRule, now time.Time) bool {
 	switch alert.Frequency {
 	case "once":
 		// Only trigger if never triggered before
@@ -193,7 +325,7 @@ func shouldTriggerBasedOnFrequency(alert *models.AlertRule) bool {
 		if alert.LastTriggeredAt == nil {
 			return true
 		}
-		return time.Since(*alert.LastTriggeredAt) >= 24*time.Hour
+		return now.Sub(*alert.LastTriggeredAt) >= 24*time.Hour
 	case "always":
 		// Trigger on every evaluation cycle, but with a 5-minute cooldown
 		// to prevent notification spam. Users selecting "always" will receive
@@ -201,23 +333,17 @@ func shouldTriggerBasedOnFrequency(alert *models.AlertRule) bool {
 		if alert.LastTriggeredAt == nil {
 			return true
 		}
-		return time.Since(*alert.LastTriggeredAt) >= 5*time.Minute
+		return now.Sub(*alert.LastTriggeredAt) >= 5*time.Minute
 	default:
 		return false
 	}
 }
 
-// evaluate dispatches to the appropriate evaluator based on alert type.
+// evaluate reports whether a quote meets an alert's condition. Alert types
+// that aren't decided by a quote never trigger here: ic_score and dividend
+// are evaluated on a schedule (see scheduled.go), and volume_above,
+// volume_below, news and earnings are not yet implemented.
 func evaluate(alert *models.AlertRule, quote *models.SymbolQuote) (bool, error) {
-	switch alert.AlertType {
-	case "price_above":
-		return evaluatePriceAbove(alert, quote)
-	case "price_below":
-		return evaluatePriceBelow(alert, quote)
-	case "price_change_pct":
-		return evaluatePriceChangePct(alert, quote)
-	// volume_above, volume_below, volume_spike, news, earnings — not yet implemented
-	default:
-		return false, nil
-	}
+	eval, err := EvaluateCondition(alert, quote)
+	return eval.Triggered, err
 }
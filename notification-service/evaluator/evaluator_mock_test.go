@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -19,6 +20,12 @@ type mockStore struct {
 	// GetActiveAlertsForSymbols
 	getActiveAlertsForSymbolsFn func(symbols []string) ([]models.AlertRule, error)
 
+	// GetActiveAlertsByTypes
+	getActiveAlertsByTypesFn func(alertTypes []string) ([]models.AlertRule, error)
+
+	// GetSymbolSnapshots
+	getSymbolSnapshotsFn func(symbols []string) (map[string]*models.SymbolSnapshot, error)
+
 	// ClaimAlertTrigger
 	claimAlertTriggerFn func(alertID string, frequency string) (bool, error)
 
@@ -28,8 +35,9 @@ type mockStore struct {
 	// UpdateAlertLogNotificationSent
 	updateAlertLogNotificationSentFn func(logID string, sent bool) error
 
-	// GetTodayEmailCount
-	getTodayEmailCountFn func(userID string) (int, error)
+	// ReserveDailyCount / ReleaseDailyCount
+	reserveDailyCountFn func(userID string, day time.Time, counter string, limit int) (bool, error)
+	releasedDailyCounts []string
 
 	// GetNotificationPreferences
 	getNotificationPreferencesFn func(userID string) (*models.NotificationPreferences, error)
@@ -37,6 +45,17 @@ type mockStore struct {
 	// GetUserEmail
 	getUserEmailFn func(userID string) (*models.UserEmail, error)
 
+	// GetUserSubscription
+	getUserSubscriptionFn func(userID string) (*models.UserSubscription, error)
+
+	// CreateInAppNotification
+	inAppNotifications []models.InAppNotification
+	createInAppFn      func(n *models.InAppNotification) error
+
+	// GetAlertRule / GetRetryableAlertLogs
+	getAlertRuleFn          func(alertID string) (*models.AlertRule, error)
+	getRetryableAlertLogsFn func(since time.Time, maxAttempts, limit int) ([]models.AlertLog, error)
+
 	// Call tracking
 	createAlertLogCalls             []*models.AlertLog
 	updateAlertLogNotificationCalls []updateNotificationCall
@@ -53,14 +72,28 @@ type claimTriggerCall struct {
 	Frequency string
 }
 
-func (m *mockStore) GetActiveAlertsForSymbols(symbols []string) ([]models.AlertRule, error) {
+func (m *mockStore) GetActiveAlertsForSymbols(_ context.Context, symbols []string) ([]models.AlertRule, error) {
 	if m.getActiveAlertsForSymbolsFn != nil {
 		return m.getActiveAlertsForSymbolsFn(symbols)
 	}
 	return nil, nil
 }
 
-func (m *mockStore) ClaimAlertTrigger(alertID string, frequency string) (bool, error) {
+func (m *mockStore) GetActiveAlertsByTypes(_ context.Context, alertTypes []string) ([]models.AlertRule, error) {
+	if m.getActiveAlertsByTypesFn != nil {
+		return m.getActiveAlertsByTypesFn(alertTypes)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) GetSymbolSnapshots(_ context.Context, symbols []string) (map[string]*models.SymbolSnapshot, error) {
+	if m.getSymbolSnapshotsFn != nil {
+		return m.getSymbolSnapshotsFn(symbols)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) ClaimAlertTrigger(_ context.Context, alertID string, frequency string) (bool, error) {
 	m.claimAlertTriggerCalls = append(m.claimAlertTriggerCalls, claimTriggerCall{alertID, frequency})
 	if m.claimAlertTriggerFn != nil {
 		return m.claimAlertTriggerFn(alertID, frequency)
@@ -68,7 +101,7 @@ func (m *mockStore) ClaimAlertTrigger(alertID string, frequency string) (bool, e
 	return true, nil
 }
 
-func (m *mockStore) CreateAlertLog(alertLog *models.AlertLog) (string, error) {
+func (m *mockStore) CreateAlertLog(_ context.Context, alertLog *models.AlertLog) (string, error) {
 	m.createAlertLogCalls = append(m.createAlertLogCalls, alertLog)
 	if m.createAlertLogFn != nil {
 		return m.createAlertLogFn(alertLog)
@@ -76,7 +109,7 @@ func (m *mockStore) CreateAlertLog(alertLog *models.AlertLog) (string, error) {
 	return "log-001", nil
 }
 
-func (m *mockStore) UpdateAlertLogNotificationSent(logID string, sent bool) error {
+func (m *mockStore) UpdateAlertLogNotificationSent(_ context.Context, logID string, sent bool) error {
 	m.updateAlertLogNotificationCalls = append(m.updateAlertLogNotificationCalls, updateNotificationCall{logID, sent})
 	if m.updateAlertLogNotificationSentFn != nil {
 		return m.updateAlertLogNotificationSentFn(logID, sent)
@@ -84,27 +117,67 @@ func (m *mockStore) UpdateAlertLogNotificationSent(logID string, sent bool) erro
 	return nil
 }
 
-func (m *mockStore) GetTodayEmailCount(userID string) (int, error) {
-	if m.getTodayEmailCountFn != nil {
-		return m.getTodayEmailCountFn(userID)
+func (m *mockStore) ReserveDailyCount(_ context.Context, userID string, day time.Time, counter string, limit int) (bool, error) {
+	if m.reserveDailyCountFn != nil {
+		return m.reserveDailyCountFn(userID, day, counter, limit)
 	}
-	return 0, nil
+	return true, nil
 }
 
-func (m *mockStore) GetNotificationPreferences(userID string) (*models.NotificationPreferences, error) {
+func (m *mockStore) ReleaseDailyCount(_ context.Context, userID string, day time.Time, counter string) error {
+	m.releasedDailyCounts = append(m.releasedDailyCounts, counter)
+	return nil
+}
+
+func (m *mockStore) GetNotificationPreferences(_ context.Context, userID string) (*models.NotificationPreferences, error) {
 	if m.getNotificationPreferencesFn != nil {
 		return m.getNotificationPreferencesFn(userID)
 	}
 	return nil, nil
 }
 
-func (m *mockStore) GetUserEmail(userID string) (*models.UserEmail, error) {
+func (m *mockStore) GetUserEmail(_ context.Context, userID string) (*models.UserEmail, error) {
 	if m.getUserEmailFn != nil {
 		return m.getUserEmailFn(userID)
 	}
 	return &models.UserEmail{Email: "test@example.com", FullName: "Test User"}, nil
 }
 
+func (m *mockStore) IsEmailSuppressed(_ context.Context, address string) (bool, error) {
+	return false, nil
+}
+
+func (m *mockStore) GetUserSubscription(_ context.Context, userID string) (*models.UserSubscription, error) {
+	if m.getUserSubscriptionFn != nil {
+		return m.getUserSubscriptionFn(userID)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) CreateInAppNotification(_ context.Context, n *models.InAppNotification) error {
+	if m.createInAppFn != nil {
+		if err := m.createInAppFn(n); err != nil {
+			return err
+		}
+	}
+	m.inAppNotifications = append(m.inAppNotifications, *n)
+	return nil
+}
+
+func (m *mockStore) GetAlertRule(_ context.Context, alertID string) (*models.AlertRule, error) {
+	if m.getAlertRuleFn != nil {
+		return m.getAlertRuleFn(alertID)
+	}
+	return nil, nil
+}
+
+func (m *mockStore) GetRetryableAlertLogs(_ context.Context, since time.Time, maxAttempts, limit int) ([]models.AlertLog, error) {
+	if m.getRetryableAlertLogsFn != nil {
+		return m.getRetryableAlertLogsFn(since, maxAttempts, limit)
+	}
+	return nil, nil
+}
+
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -182,7 +255,7 @@ func TestHandlePriceUpdate_InvalidJSON(t *testing.T) {
 	store := &mockStore{}
 	ev := newTestEvaluator(store)
 
-	err := ev.HandlePriceUpdate([]byte(`{not valid json`))
+	err := ev.HandlePriceUpdate(context.Background(), []byte(`{not valid json`))
 	if err == nil {
 		t.Fatal("expected error for invalid JSON")
 	}
@@ -193,7 +266,7 @@ func TestHandlePriceUpdate_EmptySymbols(t *testing.T) {
 	ev := newTestEvaluator(store)
 
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error for empty symbols, got: %v", err)
 	}
@@ -210,7 +283,7 @@ func TestHandlePriceUpdate_NoMatchingAlerts(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 1.5},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error when no alerts match, got: %v", err)
 	}
@@ -228,7 +301,7 @@ func TestHandlePriceUpdate_DBError_GetActiveAlerts(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 1.5},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err == nil {
 		t.Fatal("expected error when DB fails")
 	}
@@ -257,7 +330,7 @@ func TestHandlePriceUpdate_AlertMatches_ConditionMet_HappyPath(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 155.0, Volume: 2000000, ChangePct: 2.0},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error on happy path, got: %v", err)
 	}
@@ -304,7 +377,7 @@ func TestHandlePriceUpdate_AlertMatches_ConditionNotMet(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 1.0},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -335,7 +408,7 @@ func TestHandlePriceUpdate_FrequencyGating_Blocks(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 1.0},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -379,7 +452,7 @@ func TestHandlePriceUpdate_MultipleAlerts_SomeTrigger(t *testing.T) {
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 1.0},
 		"GOOG": {Price: 100.0, Volume: 500000, ChangePct: -0.5},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -424,7 +497,7 @@ func TestHandlePriceUpdate_SymbolNotInUpdate(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 1.0},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -451,7 +524,7 @@ func TestTrigger_ClaimReturnsFalse_AlreadyClaimed(t *testing.T) {
 		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("expected nil error when claim returns false, got: %v", err)
 	}
@@ -479,7 +552,7 @@ func TestTrigger_ClaimReturnsError(t *testing.T) {
 		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err == nil {
 		t.Fatal("expected error when ClaimAlertTrigger fails")
 	}
@@ -488,6 +561,58 @@ func TestTrigger_ClaimReturnsError(t *testing.T) {
 	}
 }
 
+func TestTrigger_DailyAlertLimitReached(t *testing.T) {
+	store := &mockStore{
+		getNotificationPreferencesFn: func(userID string) (*models.NotificationPreferences, error) {
+			return &models.NotificationPreferences{UserID: userID, MaxAlertsPerDay: 20}, nil
+		},
+		reserveDailyCountFn: func(userID string, day time.Time, counter string, limit int) (bool, error) {
+			if counter != models.DailyCountAlerts || limit != 20 {
+				t.Errorf("expected alerts counter with limit 20, got %s/%d", counter, limit)
+			}
+			return false, nil
+		},
+	}
+	ev := newTestEvaluator(store)
+
+	alert := makeAlert("AAPL", "price_above", "always",
+		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
+	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
+
+	if err := ev.trigger(context.Background(), &alert, quote); err != nil {
+		t.Fatalf("expected nil error at the daily limit, got: %v", err)
+	}
+	if len(store.claimAlertTriggerCalls) != 0 {
+		t.Errorf("expected no ClaimAlertTrigger calls at the daily limit, got %d", len(store.claimAlertTriggerCalls))
+	}
+	if len(store.createAlertLogCalls) != 0 {
+		t.Errorf("expected no CreateAlertLog calls at the daily limit, got %d", len(store.createAlertLogCalls))
+	}
+}
+
+func TestTrigger_ClaimLostReleasesDailyAlertSlot(t *testing.T) {
+	store := &mockStore{
+		getNotificationPreferencesFn: func(userID string) (*models.NotificationPreferences, error) {
+			return &models.NotificationPreferences{UserID: userID, MaxAlertsPerDay: 20}, nil
+		},
+		claimAlertTriggerFn: func(alertID string, frequency string) (bool, error) {
+			return false, nil
+		},
+	}
+	ev := newTestEvaluator(store)
+
+	alert := makeAlert("AAPL", "price_above", "always",
+		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
+	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
+
+	if err := ev.trigger(context.Background(), &alert, quote); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if len(store.releasedDailyCounts) != 1 || store.releasedDailyCounts[0] != models.DailyCountAlerts {
+		t.Errorf("expected the alert slot to be released, got %v", store.releasedDailyCounts)
+	}
+}
+
 func TestTrigger_HappyPath_ClaimSucceeds_LogCreated_DeliverySucceeds(t *testing.T) {
 	store := &mockStore{
 		claimAlertTriggerFn: func(alertID string, frequency string) (bool, error) {
@@ -508,7 +633,7 @@ func TestTrigger_HappyPath_ClaimSucceeds_LogCreated_DeliverySucceeds(t *testing.
 		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 2000000, ChangePct: 3.5}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("expected nil error on happy path, got: %v", err)
 	}
@@ -592,7 +717,7 @@ func TestTrigger_CreateAlertLog_Error(t *testing.T) {
 		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err == nil {
 		t.Fatal("expected error when CreateAlertLog fails")
 	}
@@ -632,7 +757,7 @@ func TestTrigger_DeliveryError_LogStillCreated_NotificationSentStaysFalse(t *tes
 	alert.NotifyEmail = true
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("expected nil error from trigger (delivery errors are not returned), got: %v", err)
 	}
@@ -666,7 +791,7 @@ func TestTrigger_NotifyEmailFalse_NoDelivery(t *testing.T) {
 	alert.NotifyEmail = false // email delivery disabled
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -704,7 +829,7 @@ func TestTrigger_UpdateNotificationSent_ErrorIsNonFatal(t *testing.T) {
 
 	// trigger should still return nil even when UpdateAlertLogNotificationSent fails
 	// (it's a non-fatal warning)
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("expected nil error (update failure is non-fatal), got: %v", err)
 	}
@@ -725,7 +850,7 @@ func TestTrigger_ConditionMetJSON_ContainsThreshold(t *testing.T) {
 		mustJSON(models.ThresholdCondition{Threshold: 175.50}))
 	quote := &models.SymbolQuote{Price: 180.0, Volume: 1000000, ChangePct: 1.5}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -774,7 +899,7 @@ func TestTrigger_MarketData_ContainsVolume(t *testing.T) {
 		mustJSON(models.ThresholdCondition{Threshold: 200.0}))
 	quote := &models.SymbolQuote{Price: 250.0, Volume: 5000000, ChangePct: 4.2}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -811,7 +936,7 @@ func TestTrigger_FrequencyPassedToClaimAlertTrigger(t *testing.T) {
 		mustJSON(models.ThresholdCondition{Threshold: 100.0}))
 	quote := &models.SymbolQuote{Price: 150.0, Volume: 1000000, ChangePct: 2.0}
 
-	err := ev.trigger(&alert, quote)
+	err := ev.trigger(context.Background(), &alert, quote)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -852,7 +977,7 @@ func TestHandlePriceUpdate_EndToEnd_PriceBelow_Triggers(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"MSFT": {Price: 280.0, Volume: 3000000, ChangePct: -2.1},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -895,7 +1020,7 @@ func TestHandlePriceUpdate_EndToEnd_PriceChangePct_Triggers(t *testing.T) {
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"NVDA": {Price: 800.0, Volume: 10000000, ChangePct: -5.5},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	if err != nil {
 		t.Fatalf("expected nil error, got: %v", err)
 	}
@@ -944,7 +1069,7 @@ func TestHandlePriceUpdate_TriggerError_ContinuesProcessingOtherAlerts(t *testin
 	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{
 		"AAPL": {Price: 150.0, Volume: 1000000, ChangePct: 2.0},
 	})
-	err := ev.HandlePriceUpdate(msg)
+	err := ev.HandlePriceUpdate(context.Background(), msg)
 	// HandlePriceUpdate should return nil even if individual triggers fail
 	if err != nil {
 		t.Fatalf("expected nil error (individual trigger errors are logged, not returned), got: %v", err)
@@ -149,6 +149,82 @@ func TestEvaluate_PriceBelow_NotTriggered(t *testing.T) {
 	}
 }
 
+func TestEvaluate_PctChange_Triggered(t *testing.T) {
+	alert := &models.AlertRule{
+		AlertType:  "pct_change",
+		Conditions: mustJSON(models.PriceChangeCondition{PercentChange: 5, Direction: "down"}),
+	}
+	quote := &models.SymbolQuote{Price: 95.0, ChangePct: -6.2}
+	triggered, err := evaluate(alert, quote)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !triggered {
+		t.Error("expected pct_change to trigger when the daily move exceeds the threshold")
+	}
+}
+
+func TestEvaluate_VolumeSpike_OnTick(t *testing.T) {
+	alert := &models.AlertRule{
+		AlertType:  "volume_spike",
+		Conditions: json.RawMessage(`{"percent_above":200,"lookback_days":30}`),
+	}
+	quote := &models.SymbolQuote{Volume: 3_500_000, AvgVolume30d: 1_000_000}
+	triggered, err := evaluate(alert, quote)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !triggered {
+		t.Error("expected volume_spike to trigger on a tick carrying the average")
+	}
+
+	quote.AvgVolume30d = 0
+	triggered, err = evaluate(alert, quote)
+	if err != nil || triggered {
+		t.Errorf("expected (false, nil) on a tick without the average, got (%v, %v)", triggered, err)
+	}
+}
+
+func TestEvaluate_PECrosses_OnTick(t *testing.T) {
+	alert := &models.AlertRule{
+		AlertType:  "pe_crosses",
+		Conditions: mustJSON(models.PECrossesCondition{Threshold: 30, Direction: "above"}),
+	}
+	// P/E 31 after a 5% gain was ~29.5 at the prior close.
+	quote := &models.SymbolQuote{Price: 210, ChangePct: 5, PERatio: 31}
+	triggered, err := evaluate(alert, quote)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !triggered {
+		t.Error("expected pe_crosses to trigger when P/E crosses the threshold")
+	}
+}
+
+func TestEvaluate_IndexMove_OnTick(t *testing.T) {
+	// "S&P down 3% today", evaluated on SPY standing in for the index
+	alert := &models.AlertRule{
+		Symbol:     "SPY",
+		AlertType:  "index_move",
+		Conditions: json.RawMessage(`{"index":"SPX","percent_change":3,"direction":"down"}`),
+	}
+	eval, err := EvaluateCondition(alert, &models.SymbolQuote{Price: 480, ChangePct: -3.2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !eval.Triggered {
+		t.Error("expected index_move to trigger when the index falls past the threshold")
+	}
+	if eval.Compared["change_pct"] != -3.2 || eval.Compared["percent_change"] != 3 {
+		t.Errorf("unexpected compared values: %v", eval.Compared)
+	}
+
+	eval, err = EvaluateCondition(alert, &models.SymbolQuote{Price: 490, ChangePct: -1.1})
+	if err != nil || eval.Triggered {
+		t.Errorf("expected no trigger on a smaller drop, got (%v, %v)", eval.Triggered, err)
+	}
+}
+
 func TestEvaluate_UnknownType(t *testing.T) {
 	alert := &models.AlertRule{
 		AlertType:  "news",
@@ -188,6 +264,16 @@ func TestGetThreshold_VolumeSpikeCondition(t *testing.T) {
 	}
 }
 
+func TestGetThreshold_PriceChangeCondition(t *testing.T) {
+	alert := &models.AlertRule{
+		Conditions: mustJSON(models.PriceChangeCondition{PercentChange: 4, Direction: "either"}),
+	}
+	got := getThreshold(alert)
+	if got != 4 {
+		t.Errorf("expected 4, got %f", got)
+	}
+}
+
 func TestGetThreshold_InvalidJSON(t *testing.T) {
 	alert := &models.AlertRule{
 		Conditions: json.RawMessage(`{invalid`),
@@ -197,3 +283,54 @@ func TestGetThreshold_InvalidJSON(t *testing.T) {
 		t.Errorf("expected 0 for invalid JSON, got %f", got)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// EvaluateCondition
+// ---------------------------------------------------------------------------
+
+func TestEvaluateCondition_ReportsComparedValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		alertType string
+		cond      string
+		quote     models.SymbolQuote
+		triggered bool
+		compared  map[string]float64
+	}{
+		{"price_above", "price_above", `{"threshold":150}`, models.SymbolQuote{Price: 151},
+			true, map[string]float64{"price": 151, "threshold": 150}},
+		{"pct_change", "pct_change", `{"percent_change":5}`, models.SymbolQuote{ChangePct: -3},
+			false, map[string]float64{"change_pct": -3, "percent_change": 5}},
+		{"volume_spike", "volume_spike", `{"percent_above":100,"lookback_days":90}`, models.SymbolQuote{Volume: 500, AvgVolume90d: 200},
+			true, map[string]float64{"volume": 500, "baseline_volume": 200, "required_volume": 400}},
+		{"pe_crosses", "pe_crosses", `{"threshold":22}`, models.SymbolQuote{ChangePct: 25, PERatio: 25},
+			true, map[string]float64{"pe_ratio": 25, "prior_pe_ratio": 20, "threshold": 22}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := &models.AlertRule{AlertType: tt.alertType, Conditions: json.RawMessage(tt.cond)}
+			eval, err := EvaluateCondition(alert, &tt.quote)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if eval.Triggered != tt.triggered {
+				t.Errorf("Triggered = %v, want %v", eval.Triggered, tt.triggered)
+			}
+			if len(eval.Compared) != len(tt.compared) {
+				t.Fatalf("Compared = %v, want %v", eval.Compared, tt.compared)
+			}
+			for k, want := range tt.compared {
+				if got := eval.Compared[k]; got != want {
+					t.Errorf("Compared[%q] = %v, want %v", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateCondition_InvalidConditions(t *testing.T) {
+	alert := &models.AlertRule{AlertType: "price_above", Conditions: json.RawMessage(`{"threshold":0}`)}
+	if _, err := EvaluateCondition(alert, &models.SymbolQuote{Price: 10}); err == nil {
+		t.Error("expected an error for a zero threshold")
+	}
+}
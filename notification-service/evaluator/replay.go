@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"notification-service/models"
+)
+
+// ReplayFiring is an alert that would have triggered during a replay.
+type ReplayFiring struct {
+	AlertID   string             `json:"alert_id"`
+	AlertName string             `json:"alert_name"`
+	Symbol    string             `json:"symbol"`
+	AlertType string             `json:"alert_type"`
+	At        time.Time          `json:"at"`
+	Quote     models.SymbolQuote `json:"quote"`
+	Compared  map[string]float64 `json:"compared,omitempty"`
+}
+
+// Replay runs alerts against a recorded sequence of price updates and
+// returns the firings it would have produced, in order. Updates are taken
+// in timestamp order and each is evaluated as it arrived, with frequency
+// gating and snoozes judged at the update's timestamp.
+//
+// Nothing is written or delivered: the alerts are evaluated from copies,
+// so it is safe to replay live rules. Per-user limits and quiet hours,
+// which only hold back notifications, are not applied.
+func Replay(alerts []models.AlertRule, updates []models.PriceUpdateMessage) ([]ReplayFiring, error) {
+	rules := make([]models.AlertRule, len(alerts))
+	copy(rules, alerts)
+
+	ordered := make([]models.PriceUpdateMessage, len(updates))
+	copy(ordered, updates)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Timestamp < ordered[j].Timestamp })
+
+	var firings []ReplayFiring
+	for _, update := range ordered {
+		at := time.Unix(update.Timestamp, 0).UTC()
+		for i := range rules {
+			alert := &rules[i]
+			quote, ok := update.Symbols[alert.Symbol]
+			if !ok {
+				continue
+			}
+			if alert.SnoozedUntil != nil && !at.After(*alert.SnoozedUntil) {
+				continue
+			}
+			if !shouldTriggerAt(alert, at) {
+				continue
+			}
+
+			eval, err := EvaluateCondition(alert, &quote)
+			if err != nil {
+				return firings, fmt.Errorf("alert %s at %s: %w", alert.ID, at.Format(time.RFC3339), err)
+			}
+			if !eval.Triggered {
+				continue
+			}
+
+			firings = append(firings, ReplayFiring{
+				AlertID:   alert.ID,
+				AlertName: alert.Name,
+				Symbol:    alert.Symbol,
+				AlertType: alert.AlertType,
+				At:        at,
+				Quote:     quote,
+				Compared:  eval.Compared,
+			})
+			triggeredAt := at
+			alert.LastTriggeredAt = &triggeredAt
+			alert.TriggerCount++
+		}
+	}
+	return firings, nil
+}
+
+// ReadPriceUpdates reads recorded price updates, one PriceUpdateMessage
+// JSON object per line as published to SNS. Blank lines are skipped.
+func ReadPriceUpdates(r io.Reader) ([]models.PriceUpdateMessage, error) {
+	var updates []models.PriceUpdateMessage
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) // a full-market update is large
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var update models.PriceUpdateMessage
+		if err := json.Unmarshal([]byte(text), &update); err != nil {
+			return nil, fmt.Errorf("line %d: parse price update: %w", line, err)
+		}
+		updates = append(updates, update)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read price updates: %w", err)
+	}
+	return updates, nil
+}
@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"notification-service/models"
+)
+
+// replaySessionStart is the first timestamp in testdata/replay_session.jsonl
+const replaySessionStart = 1741617000
+
+func loadReplaySession(t *testing.T) []models.PriceUpdateMessage {
+	t.Helper()
+	f, err := os.Open("testdata/replay_session.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	updates, err := ReadPriceUpdates(f)
+	if err != nil {
+		t.Fatalf("ReadPriceUpdates: %v", err)
+	}
+	return updates
+}
+
+func replayRule(id, symbol, alertType, conditions, frequency string) models.AlertRule {
+	return models.AlertRule{
+		ID: id, Symbol: symbol, AlertType: alertType, Frequency: frequency,
+		Conditions: json.RawMessage(conditions), IsActive: true,
+	}
+}
+
+func TestReadPriceUpdates(t *testing.T) {
+	updates := loadReplaySession(t)
+	if len(updates) != 5 {
+		t.Fatalf("expected 5 updates (blank line skipped), got %d", len(updates))
+	}
+	if got := updates[0].Symbols["MSFT"].Price; got != 400 {
+		t.Errorf("MSFT price = %v, want 400", got)
+	}
+
+	_, err := ReadPriceUpdates(strings.NewReader("{\"timestamp\": 1}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a line 2 parse error, got %v", err)
+	}
+}
+
+func TestReplay_RecordedSession(t *testing.T) {
+	snoozedUntil := time.Unix(replaySessionStart+200, 0)
+	snoozed := replayRule("aapl-snoozed", "AAPL", "price_above", `{"threshold": 200}`, "always")
+	snoozed.SnoozedUntil = &snoozedUntil
+
+	rules := []models.AlertRule{
+		replayRule("aapl-above", "AAPL", "price_above", `{"threshold": 200}`, "always"),
+		replayRule("aapl-once", "AAPL", "price_above", `{"threshold": 200}`, "once"),
+		replayRule("msft-drop", "MSFT", "price_change_pct", `{"percent_change": 3, "direction": "down"}`, "daily"),
+		replayRule("msft-below", "MSFT", "price_below", `{"threshold": 380}`, "always"),
+		snoozed,
+	}
+
+	firings, err := Replay(rules, loadReplaySession(t))
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	type firing struct {
+		id     string
+		offset int64
+	}
+	want := []firing{
+		{"aapl-above", 60}, // first update above 200; the out-of-order 199 tick at +30 is replayed before it
+		{"aapl-once", 60},
+		{"aapl-above", 420}, // +120 is inside the 5-minute cooldown
+		{"msft-drop", 420},
+		{"aapl-snoozed", 420}, // +60 and +120 are before the snooze ended
+	}
+	if len(firings) != len(want) {
+		t.Fatalf("expected %d firings, got %d: %+v", len(want), len(firings), firings)
+	}
+	for i, w := range want {
+		got := firings[i]
+		if got.AlertID != w.id || got.At.Unix() != replaySessionStart+w.offset {
+			t.Errorf("firing %d = %s at +%ds, want %s at +%ds",
+				i, got.AlertID, got.At.Unix()-replaySessionStart, w.id, w.offset)
+		}
+	}
+	if c := firings[0].Compared; c["price"] != 201 || c["threshold"] != 200 {
+		t.Errorf("unexpected compared values: %v", c)
+	}
+
+	// The caller's rules are left untouched
+	if rules[0].LastTriggeredAt != nil || rules[1].TriggerCount != 0 {
+		t.Error("Replay modified the input rules")
+	}
+}
+
+func TestReplay_InvalidConditions(t *testing.T) {
+	rules := []models.AlertRule{replayRule("bad", "AAPL", "price_change_pct", `{"percent_change": 0}`, "always")}
+	if _, err := Replay(rules, loadReplaySession(t)); err == nil || !strings.Contains(err.Error(), "alert bad") {
+		t.Fatalf("expected an error naming the alert, got %v", err)
+	}
+}
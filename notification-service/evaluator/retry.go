@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"notification-service/delivery"
+	"notification-service/models"
+)
+
+// Delivery retries pick up alerts triggered within retryWindow, at most
+// retryBatchSize per pass.
+const (
+	retryWindow    = 24 * time.Hour
+	retryBatchSize = 100
+)
+
+// RunDeliveryRetries calls RetryDeliveries every interval until ctx is
+// cancelled. The router needs a ledger, or retries would resend every
+// channel.
+func (e *Evaluator) RunDeliveryRetries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.RetryDeliveries(ctx); err != nil {
+				log.Printf("Delivery retry failed: %v", err)
+			}
+		}
+	}
+}
+
+// RetryDeliveries re-sends triggered alerts with a failed channel. The
+// router skips channels that already delivered, so only the failed ones
+// are sent again; the alert isn't re-evaluated. Returns how many alerts
+// are now fully delivered.
+func (e *Evaluator) RetryDeliveries(ctx context.Context) (int, error) {
+	logs, err := e.db.GetRetryableAlertLogs(ctx, time.Now().Add(-retryWindow), delivery.MaxDeliveryAttempts, retryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("fetch retryable alert logs: %w", err)
+	}
+
+	delivered := 0
+	for i := range logs {
+		alertLog := &logs[i]
+		alert, err := e.db.GetAlertRule(ctx, alertLog.AlertRuleID)
+		if err != nil {
+			log.Printf("Delivery retry: load alert %s: %v", alertLog.AlertRuleID, err)
+			continue
+		}
+		if alert == nil {
+			continue // rule deleted since it fired
+		}
+
+		var quote models.SymbolQuote
+		if err := json.Unmarshal(alertLog.MarketData, &quote); err != nil {
+			log.Printf("Delivery retry: parse market_data of alert log %s: %v", alertLog.ID, err)
+			continue
+		}
+
+		err = e.delivery.Deliver(ctx, alert, alertLog, &quote)
+		switch {
+		case errors.Is(err, delivery.ErrSuppressed):
+		case err != nil:
+			log.Printf("Delivery retry for alert log %s: %v", alertLog.ID, err)
+		default:
+			delivered++
+			if err := e.db.UpdateAlertLogNotificationSent(ctx, alertLog.ID, true); err != nil {
+				log.Printf("Warning: failed to update notification_sent for log %s: %v", alertLog.ID, err)
+			}
+		}
+	}
+	return delivered, nil
+}
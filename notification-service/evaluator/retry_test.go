@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/delivery"
+	"notification-service/models"
+)
+
+// flakyChannel fails until err is cleared.
+type flakyChannel struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (c *flakyChannel) Name() string                   { return c.name }
+func (c *flakyChannel) Enabled(*models.AlertRule) bool { return true }
+func (c *flakyChannel) Send(context.Context, *models.AlertRule, *models.AlertLog, *models.SymbolQuote) error {
+	c.calls++
+	return c.err
+}
+
+// memLedger is an in-memory delivery.DeliveryLedger.
+type memLedger struct {
+	mu     sync.Mutex
+	status map[string]string
+}
+
+func (l *memLedger) ClaimDelivery(_ context.Context, alertLogID, channel string, _ time.Duration, _ int) (bool, string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := alertLogID + "/" + channel
+	if status, ok := l.status[key]; ok && status != models.DeliveryFailed {
+		return false, status, nil
+	}
+	l.status[key] = models.DeliverySending
+	return true, models.DeliverySending, nil
+}
+
+func (l *memLedger) FinishDelivery(_ context.Context, alertLogID, channel, status, _ string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status[alertLogID+"/"+channel] = status
+	return nil
+}
+
+func TestRetryDeliveries_ResendsOnlyFailedChannel(t *testing.T) {
+	alert := makeAlert("AAPL", "price_above", "always", mustJSON(models.ThresholdCondition{Threshold: 140.0}))
+	store := &mockStore{
+		getActiveAlertsForSymbolsFn: func([]string) ([]models.AlertRule, error) {
+			return []models.AlertRule{alert}, nil
+		},
+		getAlertRuleFn: func(string) (*models.AlertRule, error) { return &alert, nil },
+	}
+	store.getRetryableAlertLogsFn = func(time.Time, int, int) ([]models.AlertLog, error) {
+		l := *store.createAlertLogCalls[0]
+		l.ID = "log-001"
+		return []models.AlertLog{l}, nil
+	}
+
+	email := &flakyChannel{name: "email", err: errors.New("smtp: 421")}
+	router := delivery.NewRouter(email, delivery.NewInAppDelivery(store))
+	router.SetLedger(&memLedger{status: map[string]string{}})
+	ev := New(store, router)
+
+	msg := makePriceUpdateJSON(map[string]models.SymbolQuote{"AAPL": {Price: 155.0, Volume: 1000, ChangePct: 2.0}})
+	if err := ev.HandlePriceUpdate(context.Background(), msg); err != nil {
+		t.Fatalf("HandlePriceUpdate: %v", err)
+	}
+	if len(store.updateAlertLogNotificationCalls) != 0 {
+		t.Fatal("notification_sent should stay false after the email failed")
+	}
+
+	email.err = nil
+	delivered, err := ev.RetryDeliveries(context.Background())
+	if err != nil {
+		t.Fatalf("RetryDeliveries: %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("expected 1 alert delivered, got %d", delivered)
+	}
+	if email.calls != 2 {
+		t.Errorf("expected email to be re-attempted, got %d sends", email.calls)
+	}
+	if len(store.inAppNotifications) != 1 {
+		t.Errorf("expected one in-app notification, got %d", len(store.inAppNotifications))
+	}
+	if len(store.updateAlertLogNotificationCalls) != 1 || !store.updateAlertLogNotificationCalls[0].Sent {
+		t.Errorf("expected notification_sent to be set after the retry, got %+v", store.updateAlertLogNotificationCalls)
+	}
+	if len(store.claimAlertTriggerCalls) != 1 {
+		t.Errorf("retry should not re-trigger the alert, got %d claims", len(store.claimAlertTriggerCalls))
+	}
+}
+
+func TestRetryDeliveries_SkipsDeletedRule(t *testing.T) {
+	store := &mockStore{
+		getRetryableAlertLogsFn: func(time.Time, int, int) ([]models.AlertLog, error) {
+			return []models.AlertLog{{ID: "log-1", AlertRuleID: "gone", MarketData: []byte(`{"price": 1}`)}}, nil
+		},
+	}
+	email := &flakyChannel{name: "email"}
+	ev := New(store, delivery.NewRouter(email))
+
+	delivered, err := ev.RetryDeliveries(context.Background())
+	if err != nil || delivered != 0 {
+		t.Fatalf("expected nothing delivered, got %d, %v", delivered, err)
+	}
+	if email.calls != 0 {
+		t.Error("nothing should be sent for a deleted rule")
+	}
+}
+
+func TestRetryDeliveries_FetchError(t *testing.T) {
+	store := &mockStore{
+		getRetryableAlertLogsFn: func(time.Time, int, int) ([]models.AlertLog, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	if _, err := New(store, delivery.NewRouter()).RetryDeliveries(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+}
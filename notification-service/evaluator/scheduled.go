@@ -0,0 +1,174 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"notification-service/models"
+)
+
+// ScheduledAlertTypes are evaluated in bulk on a timer, because the data
+// they depend on (daily volume averages, trailing P/E, IC Scores, dividend
+// declarations) changes at most once a day. volume_spike and pe_crosses are
+// also evaluated on price ticks that carry their fields.
+var ScheduledAlertTypes = []string{"volume_spike", "pe_crosses", "ic_score", "dividend"}
+
+// RunScheduled calls EvaluateScheduled every interval until ctx is cancelled.
+func (e *Evaluator) RunScheduled(ctx context.Context, interval time.Duration) {
+	log.Printf("Scheduled alert evaluation every %v for %v", interval, ScheduledAlertTypes)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.EvaluateScheduled(ctx); err != nil {
+				log.Printf("Scheduled alert evaluation failed: %v", err)
+			}
+		}
+	}
+}
+
+// EvaluateScheduled loads every active rule of a scheduled type, evaluates
+// it against the latest end-of-day data for its symbol, and triggers the
+// matches through the same claim/log/deliver path as price alerts, so
+// frequency cooldowns apply identically. Returns the number triggered.
+func (e *Evaluator) EvaluateScheduled(ctx context.Context) (int, error) {
+	alerts, err := e.db.GetActiveAlertsByTypes(ctx, ScheduledAlertTypes)
+	if err != nil {
+		return 0, fmt.Errorf("fetch scheduled alerts: %w", err)
+	}
+	if len(alerts) == 0 {
+		return 0, nil
+	}
+
+	seen := make(map[string]bool)
+	symbols := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		if !seen[a.Symbol] {
+			seen[a.Symbol] = true
+			symbols = append(symbols, a.Symbol)
+		}
+	}
+
+	snapshots, err := e.db.GetSymbolSnapshots(ctx, symbols)
+	if err != nil {
+		return 0, fmt.Errorf("fetch symbol snapshots: %w", err)
+	}
+
+	var triggered int
+	for i := range alerts {
+		alert := &alerts[i]
+		snap, ok := snapshots[alert.Symbol]
+		if !ok {
+			continue
+		}
+
+		if !shouldTriggerBasedOnFrequency(alert) {
+			continue
+		}
+
+		conditionMet, err := evaluateScheduled(alert, snap)
+		if err != nil {
+			log.Printf("Error evaluating alert %s: %v", alert.ID, err)
+			continue
+		}
+		if !conditionMet {
+			continue
+		}
+
+		if err := e.triggerWithData(ctx, alert, &snap.Quote, scheduledMarketData(alert, snap)); err != nil {
+			log.Printf("Error triggering alert %s: %v", alert.ID, err)
+		} else {
+			triggered++
+		}
+	}
+
+	log.Printf("Scheduled evaluation: %d alerts, triggered %d", len(alerts), triggered)
+	return triggered, nil
+}
+
+// evaluateScheduled dispatches to the evaluator for a scheduled alert type.
+func evaluateScheduled(alert *models.AlertRule, snap *models.SymbolSnapshot) (bool, error) {
+	switch alert.AlertType {
+	case "volume_spike", "pe_crosses":
+		return evaluate(alert, &snap.Quote)
+	case "ic_score":
+		return evaluateICScore(alert, snap)
+	case "dividend":
+		return evaluateDividend(alert, snap), nil
+	default:
+		return false, nil
+	}
+}
+
+// evaluateICScore returns true if the latest IC Score is at or past the
+// threshold in the configured direction (default "above").
+func evaluateICScore(alert *models.AlertRule, snap *models.SymbolSnapshot) (bool, error) {
+	var cond models.ICScoreCondition
+	if err := json.Unmarshal(alert.Conditions, &cond); err != nil {
+		return false, fmt.Errorf("parse ic_score conditions: %w", err)
+	}
+	if cond.Threshold <= 0 || cond.Threshold > 100 {
+		return false, fmt.Errorf("invalid threshold: %f", cond.Threshold)
+	}
+	if snap.ICScore == nil {
+		return false, nil
+	}
+
+	if cond.Direction == "below" {
+		return *snap.ICScore <= cond.Threshold, nil
+	}
+	return *snap.ICScore >= cond.Threshold, nil
+}
+
+// evaluateDividend returns true if a dividend was declared after the alert
+// last fired (or, if it never has, on or after the day the rule was created),
+// so each declaration notifies once. Declarations up to the end of the
+// rule's last snooze are never notified.
+func evaluateDividend(alert *models.AlertRule, snap *models.SymbolSnapshot) bool {
+	if snap.Dividend == nil {
+		return false
+	}
+	declared := snap.Dividend.DeclaredOn
+	if alert.SnoozedUntil != nil && !declared.After(*alert.SnoozedUntil) {
+		return false
+	}
+	if alert.LastTriggeredAt != nil {
+		return declared.After(*alert.LastTriggeredAt)
+	}
+	created := alert.CreatedAt.UTC().Truncate(24 * time.Hour)
+	return !declared.Before(created)
+}
+
+// scheduledMarketData adds the data a scheduled alert fired on to the
+// alert log's market_data.
+func scheduledMarketData(alert *models.AlertRule, snap *models.SymbolSnapshot) map[string]interface{} {
+	switch alert.AlertType {
+	case "volume_spike":
+		return map[string]interface{}{
+			"avg_volume_30d": snap.Quote.AvgVolume30d,
+			"avg_volume_90d": snap.Quote.AvgVolume90d,
+		}
+	case "pe_crosses":
+		return map[string]interface{}{"pe_ratio": snap.Quote.PERatio}
+	case "ic_score":
+		if snap.ICScore != nil {
+			return map[string]interface{}{"ic_score": *snap.ICScore}
+		}
+	case "dividend":
+		if d := snap.Dividend; d != nil {
+			return map[string]interface{}{
+				"dividend_amount":      d.Amount,
+				"dividend_declared_on": d.DeclaredOn.Format("2006-01-02"),
+				"dividend_ex_date":     d.ExDate.Format("2006-01-02"),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"notification-service/models"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+
+// scheduledStore returns the given alerts and snapshots for a scheduled run.
+func scheduledStore(alerts []models.AlertRule, snapshots map[string]*models.SymbolSnapshot) *mockStore {
+	return &mockStore{
+		getActiveAlertsByTypesFn: func(alertTypes []string) ([]models.AlertRule, error) {
+			return alerts, nil
+		},
+		getSymbolSnapshotsFn: func(symbols []string) (map[string]*models.SymbolSnapshot, error) {
+			return snapshots, nil
+		},
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests for EvaluateScheduled
+// ---------------------------------------------------------------------------
+
+func TestEvaluateScheduled_FiresMatchingAlerts(t *testing.T) {
+	spike := makeAlert("AAPL", "volume_spike", "daily", json.RawMessage(`{"volume_multiplier":2,"baseline":"avg_30d"}`))
+	spike.ID = "alert-spike"
+	score := makeAlert("MSFT", "ic_score", "daily", json.RawMessage(`{"threshold":80,"direction":"above"}`))
+	score.ID = "alert-score"
+	quiet := makeAlert("MSFT", "volume_spike", "daily", json.RawMessage(`{"volume_multiplier":2}`))
+	quiet.ID = "alert-quiet"
+
+	snapshots := map[string]*models.SymbolSnapshot{
+		"AAPL": {Quote: models.SymbolQuote{Price: 190, Volume: 3_000_000, AvgVolume30d: 1_000_000}},
+		"MSFT": {Quote: models.SymbolQuote{Price: 400, Volume: 1_100_000, AvgVolume30d: 1_000_000}, ICScore: floatPtr(84.5)},
+	}
+	store := scheduledStore([]models.AlertRule{spike, score, quiet}, snapshots)
+	var requested []string
+	store.getSymbolSnapshotsFn = func(symbols []string) (map[string]*models.SymbolSnapshot, error) {
+		requested = symbols
+		return snapshots, nil
+	}
+	ev := newTestEvaluator(store)
+
+	triggered, err := ev.EvaluateScheduled(context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if triggered != 2 {
+		t.Fatalf("expected 2 triggered, got %d", triggered)
+	}
+	if len(requested) != 2 {
+		t.Errorf("expected snapshots for 2 distinct symbols, got %v", requested)
+	}
+	if len(store.claimAlertTriggerCalls) != 2 ||
+		store.claimAlertTriggerCalls[0].AlertID != "alert-spike" ||
+		store.claimAlertTriggerCalls[1].AlertID != "alert-score" {
+		t.Fatalf("unexpected claims: %+v", store.claimAlertTriggerCalls)
+	}
+
+	// The alert log carries the data the rule fired on
+	var marketData map[string]interface{}
+	if err := json.Unmarshal(store.createAlertLogCalls[1].MarketData, &marketData); err != nil {
+		t.Fatalf("unmarshal market_data: %v", err)
+	}
+	if marketData["ic_score"] != 84.5 {
+		t.Errorf("expected ic_score 84.5 in market_data, got %v", marketData["ic_score"])
+	}
+	if marketData["price"] != 400.0 {
+		t.Errorf("expected price 400 in market_data, got %v", marketData["price"])
+	}
+}
+
+func TestEvaluateScheduled_RespectsCooldown(t *testing.T) {
+	recent := time.Now().Add(-2 * time.Minute)
+	old := time.Now().Add(-25 * time.Hour)
+
+	cooling := makeAlert("AAPL", "volume_spike", "always", json.RawMessage(`{"volume_multiplier":2}`))
+	cooling.ID = "alert-cooling"
+	cooling.LastTriggeredAt = &recent // inside the 5-minute cooldown
+	firedToday := makeAlert("AAPL", "volume_spike", "daily", json.RawMessage(`{"volume_multiplier":2}`))
+	firedToday.ID = "alert-fired-today"
+	firedToday.LastTriggeredAt = &recent
+	onceDone := makeAlert("AAPL", "volume_spike", "once", json.RawMessage(`{"volume_multiplier":2}`))
+	onceDone.ID = "alert-once"
+	onceDone.LastTriggeredAt = &old
+	due := makeAlert("AAPL", "volume_spike", "daily", json.RawMessage(`{"volume_multiplier":2}`))
+	due.ID = "alert-due"
+	due.LastTriggeredAt = &old
+
+	store := scheduledStore([]models.AlertRule{cooling, firedToday, onceDone, due}, map[string]*models.SymbolSnapshot{
+		"AAPL": {Quote: models.SymbolQuote{Volume: 5_000_000, AvgVolume30d: 1_000_000}},
+	})
+	ev := newTestEvaluator(store)
+
+	triggered, err := ev.EvaluateScheduled(context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if triggered != 1 {
+		t.Fatalf("expected only the due alert to trigger, got %d", triggered)
+	}
+	if len(store.claimAlertTriggerCalls) != 1 || store.claimAlertTriggerCalls[0].AlertID != "alert-due" {
+		t.Fatalf("expected a single claim for alert-due, got %+v", store.claimAlertTriggerCalls)
+	}
+}
+
+func TestEvaluateScheduled_ClaimLostToCooldown(t *testing.T) {
+	// The in-memory pre-check passes but the DB claim enforces the cooldown
+	// (e.g. a concurrent run already fired it)
+	alert := makeAlert("AAPL", "volume_spike", "daily", json.RawMessage(`{"volume_multiplier":2}`))
+	store := scheduledStore([]models.AlertRule{alert}, map[string]*models.SymbolSnapshot{
+		"AAPL": {Quote: models.SymbolQuote{Volume: 5_000_000, AvgVolume30d: 1_000_000}},
+	})
+	store.claimAlertTriggerFn = func(alertID, frequency string) (bool, error) { return false, nil }
+	ev := newTestEvaluator(store)
+
+	if _, err := ev.EvaluateScheduled(context.Background()); err != nil {
+		t.Fatalf("expected nil error, got: %v", err)
+	}
+	if len(store.createAlertLogCalls) != 0 {
+		t.Errorf("expected no alert log when the claim is lost, got %d", len(store.createAlertLogCalls))
+	}
+}
+
+func TestEvaluateScheduled_NoAlerts(t *testing.T) {
+	store := scheduledStore(nil, nil)
+	store.getSymbolSnapshotsFn = func(symbols []string) (map[string]*models.SymbolSnapshot, error) {
+		t.Fatal("snapshots should not be loaded when there are no alerts")
+		return nil, nil
+	}
+	ev := newTestEvaluator(store)
+
+	triggered, err := ev.EvaluateScheduled(context.Background())
+	if err != nil || triggered != 0 {
+		t.Fatalf("expected (0, nil), got (%d, %v)", triggered, err)
+	}
+}
+
+func TestEvaluateScheduled_StoreError(t *testing.T) {
+	store := &mockStore{
+		getActiveAlertsByTypesFn: func(alertTypes []string) ([]models.AlertRule, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+	ev := newTestEvaluator(store)
+
+	if _, err := ev.EvaluateScheduled(context.Background()); err == nil {
+		t.Fatal("expected error when alerts can't be loaded")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests for scheduled condition evaluators
+// ---------------------------------------------------------------------------
+
+func TestEvaluateICScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions string
+		score      *float64
+		want       bool
+		wantErr    bool
+	}{
+		{"above met", `{"threshold":70,"direction":"above"}`, floatPtr(72), true, false},
+		{"above not met", `{"threshold":70}`, floatPtr(65), false, false},
+		{"below met", `{"threshold":40,"direction":"below"}`, floatPtr(40), true, false},
+		{"no score", `{"threshold":40}`, nil, false, false},
+		{"out of range", `{"threshold":120}`, floatPtr(50), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := makeAlert("AAPL", "ic_score", "daily", json.RawMessage(tt.conditions))
+			got, err := evaluateICScore(&alert, &models.SymbolSnapshot{ICScore: tt.score})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateDividend(t *testing.T) {
+	day := 24 * time.Hour
+	today := time.Now().UTC().Truncate(day)
+	snap := func(declared time.Time) *models.SymbolSnapshot {
+		return &models.SymbolSnapshot{Dividend: &models.DividendDeclaration{DeclaredOn: declared, ExDate: declared.Add(14 * day), Amount: 0.25}}
+	}
+
+	alert := makeAlert("AAPL", "dividend", "always", json.RawMessage(`{}`))
+	alert.CreatedAt = today.Add(10 * time.Hour)
+
+	if !evaluateDividend(&alert, snap(today)) {
+		t.Error("expected a declaration on the day the rule was created to fire")
+	}
+	if evaluateDividend(&alert, snap(today.Add(-day))) {
+		t.Error("expected a declaration before the rule existed not to fire")
+	}
+	if evaluateDividend(&alert, &models.SymbolSnapshot{}) {
+		t.Error("expected no dividend data not to fire")
+	}
+
+	fired := today.Add(12 * time.Hour)
+	alert.LastTriggeredAt = &fired
+	if evaluateDividend(&alert, snap(today)) {
+		t.Error("expected an already-notified declaration not to fire again")
+	}
+	if !evaluateDividend(&alert, snap(today.Add(day))) {
+		t.Error("expected a newer declaration to fire")
+	}
+}
+
+func TestEvaluateDividend_SnoozeEnded(t *testing.T) {
+	day := 24 * time.Hour
+	today := time.Now().UTC().Truncate(day)
+	snap := func(declared time.Time) *models.SymbolSnapshot {
+		return &models.SymbolSnapshot{Dividend: &models.DividendDeclaration{DeclaredOn: declared, ExDate: declared.Add(14 * day), Amount: 0.25}}
+	}
+
+	alert := makeAlert("AAPL", "dividend", "always", json.RawMessage(`{}`))
+	alert.CreatedAt = today.Add(-10 * day)
+	unsnoozed := today.Add(-2*day + 9*time.Hour)
+	alert.SnoozedUntil = &unsnoozed
+
+	if evaluateDividend(&alert, snap(today.Add(-3*day))) {
+		t.Error("expected a declaration while the rule was snoozed not to fire once unsnoozed")
+	}
+	if evaluateDividend(&alert, snap(today.Add(-2*day))) {
+		t.Error("expected a declaration on the day the snooze ended not to fire")
+	}
+	if !evaluateDividend(&alert, snap(today.Add(-day))) {
+		t.Error("expected a declaration after the snooze ended to fire")
+	}
+}
@@ -0,0 +1,178 @@
+package evaluator
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"notification-service/models"
+)
+
+// Throttle coalesces price updates so each symbol's rules are evaluated at
+// most once per interval. Updates that arrive before a symbol is due are
+// folded into a pending sample and evaluated by the next due update or by
+// RunThrottleFlush, whichever comes first.
+type Throttle struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	symbols map[string]*symbolWindow
+}
+
+// symbolWindow tracks a symbol between evaluations
+type symbolWindow struct {
+	lastEval time.Time
+	pending  *quoteSamples // nil when nothing has arrived since lastEval
+}
+
+// NewThrottle creates a Throttle that evaluates each symbol at most once per
+// interval.
+func NewThrottle(interval time.Duration) *Throttle {
+	return &Throttle{
+		interval: interval,
+		now:      time.Now,
+		symbols:  make(map[string]*symbolWindow),
+	}
+}
+
+// Offer folds an update's quotes into each symbol's pending sample and
+// returns the samples of symbols now due for evaluation.
+func (t *Throttle) Offer(quotes map[string]models.SymbolQuote) map[string]*quoteSamples {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	due := make(map[string]*quoteSamples)
+	for symbol, quote := range quotes {
+		w, ok := t.symbols[symbol]
+		if !ok {
+			w = &symbolWindow{}
+			t.symbols[symbol] = w
+		}
+		if w.pending == nil {
+			w.pending = newQuoteSamples(quote, now)
+		} else {
+			w.pending.add(quote, now)
+		}
+		if now.Sub(w.lastEval) >= t.interval {
+			due[symbol] = w.pending
+			w.pending = nil
+			w.lastEval = now
+		}
+	}
+	return due
+}
+
+// Due returns the pending samples of symbols whose interval has elapsed,
+// so coalesced updates are evaluated even if no further update arrives.
+func (t *Throttle) Due() map[string]*quoteSamples {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	due := make(map[string]*quoteSamples)
+	for symbol, w := range t.symbols {
+		if w.pending != nil && now.Sub(w.lastEval) >= t.interval {
+			due[symbol] = w.pending
+			w.pending = nil
+			w.lastEval = now
+		}
+	}
+	return due
+}
+
+// quoteSamples summarizes the updates coalesced into one evaluation: the
+// latest quote plus the quotes at the price and change extremes. Price
+// conditions are monotonic, so a threshold crossed at any point between
+// evaluations is crossed by one of these, even if the price came back.
+type quoteSamples struct {
+	latest    models.SymbolQuote
+	high      models.SymbolQuote
+	low       models.SymbolQuote
+	maxChange models.SymbolQuote
+	minChange models.SymbolQuote
+
+	first, last time.Time // when the first and latest quotes arrived
+}
+
+func newQuoteSamples(q models.SymbolQuote, at time.Time) *quoteSamples {
+	return &quoteSamples{latest: q, high: q, low: q, maxChange: q, minChange: q, first: at, last: at}
+}
+
+func (s *quoteSamples) add(q models.SymbolQuote, at time.Time) {
+	s.latest = q
+	s.last = at
+	if q.Price > s.high.Price {
+		s.high = q
+	}
+	if q.Price < s.low.Price {
+		s.low = q
+	}
+	if q.ChangePct > s.maxChange.ChangePct {
+		s.maxChange = q
+	}
+	if q.ChangePct < s.minChange.ChangePct {
+		s.minChange = q
+	}
+}
+
+// candidatesFor returns the quotes alert may trigger on. Quotes that
+// arrived while the rule was snoozed are left out: if its snooze ended
+// during the window only the latest quote counts, and if it ended after the
+// latest quote arrived none do.
+func (s *quoteSamples) candidatesFor(alert *models.AlertRule) []models.SymbolQuote {
+	if alert.SnoozedUntil != nil {
+		if !alert.SnoozedUntil.Before(s.last) {
+			return nil
+		}
+		if alert.SnoozedUntil.After(s.first) {
+			return []models.SymbolQuote{s.latest}
+		}
+	}
+	return s.candidates()
+}
+
+// candidates returns the distinct quotes to evaluate, latest first so an
+// alert that still holds is reported at the current price.
+func (s *quoteSamples) candidates() []models.SymbolQuote {
+	out := []models.SymbolQuote{s.latest}
+	for _, q := range []models.SymbolQuote{s.high, s.low, s.maxChange, s.minChange} {
+		seen := false
+		for _, c := range out {
+			if c == q {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// RunThrottleFlush evaluates coalesced updates that are still pending once
+// their symbol's interval elapses, until ctx is cancelled. It is a no-op if
+// the evaluator isn't throttled.
+func (e *Evaluator) RunThrottleFlush(ctx context.Context) {
+	if e.throttle == nil {
+		return
+	}
+	ticker := time.NewTicker(e.throttle.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if due := e.throttle.Due(); len(due) > 0 {
+				if err := e.evaluateSamples(ctx, due); err != nil {
+					log.Printf("Throttled alert evaluation failed: %v", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"notification-service/models"
+)
+
+// fakeClock is a controllable time source for Throttle
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newThrottledEvaluator returns an evaluator throttled to interval on a fake
+// clock, and a counter of alert fetches (one per evaluation batch).
+func newThrottledEvaluator(store *mockStore, interval time.Duration) (*Evaluator, *fakeClock, *int) {
+	fetches := 0
+	fetch := store.getActiveAlertsForSymbolsFn
+	store.getActiveAlertsForSymbolsFn = func(symbols []string) ([]models.AlertRule, error) {
+		fetches++
+		if fetch == nil {
+			return nil, nil
+		}
+		return fetch(symbols)
+	}
+
+	ev := newTestEvaluator(store)
+	ev.SetThrottle(interval)
+	clock := &fakeClock{t: time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)}
+	ev.throttle.now = clock.now
+	return ev, clock, &fetches
+}
+
+func priceUpdate(t *testing.T, symbol string, price, changePct float64) []byte {
+	t.Helper()
+	msg, err := json.Marshal(models.PriceUpdateMessage{
+		Symbols: map[string]models.SymbolQuote{symbol: {Price: price, Volume: 1000, ChangePct: changePct}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestThrottle_CoalescesRapidUpdates(t *testing.T) {
+	alert := makeAlert("AAPL", "price_above", "always", json.RawMessage(`{"threshold":500}`))
+	store := &mockStore{
+		getActiveAlertsForSymbolsFn: func(symbols []string) ([]models.AlertRule, error) {
+			return []models.AlertRule{alert}, nil
+		},
+	}
+	ev, clock, fetches := newThrottledEvaluator(store, time.Minute)
+
+	for i := 0; i < 20; i++ {
+		if err := ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 190+float64(i)*0.1, 0.5)); err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+		clock.advance(time.Second)
+	}
+	if *fetches != 1 {
+		t.Fatalf("expected 20 updates within the interval to evaluate once, got %d", *fetches)
+	}
+
+	// Nothing is due until the interval has elapsed since the last evaluation
+	if due := ev.throttle.Due(); len(due) != 0 {
+		t.Fatalf("expected nothing due yet, got %d", len(due))
+	}
+
+	clock.advance(time.Minute)
+	due := ev.throttle.Due()
+	if len(due) != 1 {
+		t.Fatalf("expected the coalesced AAPL sample to be due, got %d", len(due))
+	}
+	if got := due["AAPL"].latest.Price; got < 191.89 || got > 191.91 {
+		t.Errorf("expected coalesced sample to carry the latest price 191.9, got %v", got)
+	}
+	if err := ev.evaluateSamples(context.Background(), due); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if *fetches != 2 {
+		t.Errorf("expected 2 evaluations in total, got %d", *fetches)
+	}
+	if len(store.claimAlertTriggerCalls) != 0 {
+		t.Errorf("expected no trigger below threshold, got %d", len(store.claimAlertTriggerCalls))
+	}
+}
+
+func TestThrottle_IndependentPerSymbol(t *testing.T) {
+	store := &mockStore{}
+	ev, clock, fetches := newThrottledEvaluator(store, time.Minute)
+
+	ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 190, 0))
+	clock.advance(time.Second)
+	ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "MSFT", 400, 0))
+	clock.advance(time.Second)
+	ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 191, 0))
+
+	if *fetches != 2 {
+		t.Errorf("expected AAPL and MSFT first updates to each evaluate, got %d fetches", *fetches)
+	}
+}
+
+func TestThrottle_CatchesCrossingBetweenSamples(t *testing.T) {
+	tests := []struct {
+		name       string
+		alertType  string
+		conditions string
+		wantPrice  float64
+	}{
+		{"spike above and back", "price_above", `{"threshold":200}`, 205},
+		{"dip below and back", "price_below", `{"threshold":180}`, 178},
+		{"change swing", "price_change_pct", `{"percent_change":3,"direction":"either"}`, 205},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := makeAlert("AAPL", tt.alertType, "always", json.RawMessage(tt.conditions))
+			store := &mockStore{
+				getActiveAlertsForSymbolsFn: func(symbols []string) ([]models.AlertRule, error) {
+					return []models.AlertRule{alert}, nil
+				},
+			}
+			ev, clock, _ := newThrottledEvaluator(store, time.Minute)
+
+			// Evaluated immediately; no crossing yet
+			ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 190, 0.5))
+			// Coalesced: crosses and comes back before the next sample
+			for _, q := range []struct{ price, change float64 }{{205, 3.5}, {178, -1}, {191, 0.6}} {
+				clock.advance(10 * time.Second)
+				ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", q.price, q.change))
+			}
+			if len(store.createAlertLogCalls) != 0 {
+				t.Fatalf("expected no trigger before the sample is due")
+			}
+
+			// The next update after the interval evaluates the whole window
+			clock.advance(time.Minute)
+			ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 192, 0.7))
+
+			if len(store.createAlertLogCalls) != 1 {
+				t.Fatalf("expected the crossing to trigger once, got %d", len(store.createAlertLogCalls))
+			}
+			var marketData map[string]interface{}
+			if err := json.Unmarshal(store.createAlertLogCalls[0].MarketData, &marketData); err != nil {
+				t.Fatal(err)
+			}
+			if marketData["price"] != tt.wantPrice {
+				t.Errorf("expected alert logged at the crossing price %v, got %v", tt.wantPrice, marketData["price"])
+			}
+		})
+	}
+}
+
+func TestThrottle_LatestQuoteReportedWhenStillMet(t *testing.T) {
+	alert := makeAlert("AAPL", "price_above", "always", json.RawMessage(`{"threshold":200}`))
+	store := &mockStore{
+		getActiveAlertsForSymbolsFn: func(symbols []string) ([]models.AlertRule, error) {
+			return []models.AlertRule{alert}, nil
+		},
+	}
+	ev, clock, _ := newThrottledEvaluator(store, time.Minute)
+
+	ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 190, 0))
+	clock.advance(time.Second)
+	ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 210, 0))
+	clock.advance(time.Second)
+	ev.HandlePriceUpdate(context.Background(), priceUpdate(t, "AAPL", 204, 0))
+	clock.advance(time.Minute)
+	if err := ev.evaluateSamples(context.Background(), ev.throttle.Due()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.createAlertLogCalls) != 1 {
+		t.Fatalf("expected 1 trigger, got %d", len(store.createAlertLogCalls))
+	}
+	var marketData map[string]interface{}
+	json.Unmarshal(store.createAlertLogCalls[0].MarketData, &marketData)
+	if marketData["price"] != 204.0 {
+		t.Errorf("expected the latest price 204, got %v", marketData["price"])
+	}
+}
+
+func TestSetThrottle_ZeroDisables(t *testing.T) {
+	ev := newTestEvaluator(&mockStore{})
+	ev.SetThrottle(time.Second)
+	if ev.throttle == nil {
+		t.Fatal("expected throttle to be set")
+	}
+	ev.SetThrottle(0)
+	if ev.throttle != nil {
+		t.Fatal("expected throttle to be cleared")
+	}
+}
+
+func TestQuoteSamples_CandidatesDistinct(t *testing.T) {
+	now := time.Now()
+	s := newQuoteSamples(models.SymbolQuote{Price: 100, ChangePct: 1}, now)
+	if got := len(s.candidates()); got != 1 {
+		t.Fatalf("expected 1 candidate for a single quote, got %d", got)
+	}
+
+	s.add(models.SymbolQuote{Price: 110, ChangePct: 2}, now)
+	s.add(models.SymbolQuote{Price: 105, ChangePct: 1.5}, now)
+	c := s.candidates()
+	// latest (105), high (110), low = first (100); change extremes repeat those
+	if len(c) != 3 {
+		t.Fatalf("expected 3 distinct candidates, got %d: %+v", len(c), c)
+	}
+	if c[0].Price != 105 {
+		t.Errorf("expected the latest quote first, got %+v", c[0])
+	}
+}
+
+func TestQuoteSamples_CandidatesForSnoozedRule(t *testing.T) {
+	start := time.Now()
+	s := newQuoteSamples(models.SymbolQuote{Price: 120}, start)
+	s.add(models.SymbolQuote{Price: 100}, start.Add(2*time.Second))
+
+	alert := makeAlert("AAPL", "price_above", "always", json.RawMessage(`{"threshold":110}`))
+	if got := len(s.candidatesFor(&alert)); got != 2 {
+		t.Fatalf("expected both candidates for a rule that was never snoozed, got %d", got)
+	}
+
+	// Snooze ended between the two quotes: the 120 high came while muted
+	ended := start.Add(time.Second)
+	alert.SnoozedUntil = &ended
+	c := s.candidatesFor(&alert)
+	if len(c) != 1 || c[0].Price != 100 {
+		t.Fatalf("expected only the latest quote, got %+v", c)
+	}
+	if _, eval, _ := evaluateCandidates(&alert, s); eval.Triggered {
+		t.Error("expected a threshold crossed while snoozed not to fire")
+	}
+
+	// Snooze ended after every quote in the window
+	ended = start.Add(3 * time.Second)
+	if got := len(s.candidatesFor(&alert)); got != 0 {
+		t.Fatalf("expected no candidates, got %d", got)
+	}
+
+	// Snooze ended before the window
+	ended = start.Add(-time.Second)
+	if got := len(s.candidatesFor(&alert)); got != 2 {
+		t.Fatalf("expected both candidates, got %d", got)
+	}
+}
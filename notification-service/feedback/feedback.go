@@ -0,0 +1,367 @@
+// Package feedback handles email bounce and complaint notifications from
+// Amazon SES, delivered through an SNS topic subscribed over HTTPS. Hard
+// bounces and complaints put the address on the suppression list and mark
+// the notification preferences sending to it unverified, so alert emails
+// stop going to it.
+//
+// Deliveries must carry the shared token, come from the configured topic
+// and be signed by SNS: the signature is checked against the certificate at
+// SigningCertURL, which is only fetched from SNS hosts.
+package feedback
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1"   // SignatureVersion 1
+	_ "crypto/sha256" // SignatureVersion 2
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"notification-service/models"
+)
+
+// maxBodyBytes bounds a notification; SNS messages are at most 256 KB
+const maxBodyBytes = 512 << 10
+
+// maxCertBytes bounds a signing certificate download
+const maxCertBytes = 64 << 10
+
+// Store records suppressed addresses. Implemented by database.DB.
+type Store interface {
+	SuppressEmail(address, reason, detail string) (int64, error)
+}
+
+// Handler serves the SNS subscription endpoint for SES feedback.
+type Handler struct {
+	store    Store
+	token    string
+	topicARN string
+	client   *http.Client // confirms subscriptions and fetches signing certificates; injectable for testing
+
+	certsMu sync.Mutex
+	certs   map[string]*x509.Certificate // signing certificates by URL
+}
+
+// NewHandler creates a feedback Handler. token is the shared secret SNS
+// passes in the endpoint URL (?token=...) and topicARN the only topic
+// accepted; leaving either empty denies every request.
+func NewHandler(store Store, token, topicARN string) *Handler {
+	return &Handler{
+		store:    store,
+		token:    token,
+		topicARN: topicARN,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		certs:    make(map[string]*x509.Certificate),
+	}
+}
+
+// snsMessage is the SNS HTTP(S) delivery envelope
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject,omitempty"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	Token            string `json:"Token,omitempty"`
+	SubscribeURL     string `json:"SubscribeURL,omitempty"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// sesNotification is an SES bounce or complaint, from a feedback
+// notification (notificationType) or an event destination (eventType)
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Bounce           *struct {
+		BounceType        string      `json:"bounceType"` // Permanent, Transient or Undetermined
+		BounceSubType     string      `json:"bounceSubType"`
+		BouncedRecipients []recipient `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		ComplaintFeedbackType string      `json:"complaintFeedbackType"`
+		ComplainedRecipients  []recipient `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+type recipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	DiagnosticCode string `json:"diagnosticCode"`
+}
+
+// response is the JSON body of every reply
+type response struct {
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	Suppressed int    `json:"suppressed,omitempty"`
+	Updated    int64  `json:"preferences_updated,omitempty"`
+}
+
+// HandleSES processes one SNS delivery. Errors saving feedback return 500
+// so SNS retries the delivery.
+//
+//	POST /webhooks/ses?token=<SES_FEEDBACK_TOKEN>
+func (h *Handler) HandleSES(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		reply(w, http.StatusMethodNotAllowed, response{Status: "error", Message: "method not allowed"})
+		return
+	}
+	if !h.authenticate(r) {
+		reply(w, http.StatusUnauthorized, response{Status: "error", Message: "unauthorized"})
+		return
+	}
+
+	// SNS posts JSON with Content-Type text/plain, so don't check it
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		reply(w, http.StatusBadRequest, response{Status: "error", Message: "read body: " + err.Error()})
+		return
+	}
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		reply(w, http.StatusBadRequest, response{Status: "error", Message: "invalid SNS message: " + err.Error()})
+		return
+	}
+	if msg.TopicArn != h.topicARN {
+		reply(w, http.StatusForbidden, response{Status: "error", Message: "unexpected topic"})
+		return
+	}
+	if err := h.verify(&msg); err != nil {
+		log.Printf("SES feedback: rejecting message %s: %v", msg.MessageID, err)
+		reply(w, http.StatusForbidden, response{Status: "error", Message: "invalid signature"})
+		return
+	}
+
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		if err := h.confirmSubscription(msg.SubscribeURL); err != nil {
+			log.Printf("SES feedback: confirm subscription to %s: %v", msg.TopicArn, err)
+			reply(w, http.StatusBadGateway, response{Status: "error", Message: err.Error()})
+			return
+		}
+		log.Printf("SES feedback: subscribed to %s", msg.TopicArn)
+		reply(w, http.StatusOK, response{Status: "ok", Message: "subscription confirmed"})
+
+	case "UnsubscribeConfirmation":
+		log.Printf("SES feedback: unsubscribed from %s", msg.TopicArn)
+		reply(w, http.StatusOK, response{Status: "ok", Message: "unsubscribed"})
+
+	case "Notification":
+		var n sesNotification
+		if err := json.Unmarshal([]byte(msg.Message), &n); err != nil {
+			reply(w, http.StatusBadRequest, response{Status: "error", Message: "invalid SES notification: " + err.Error()})
+			return
+		}
+		suppressed, updated, err := h.process(&n)
+		if err != nil {
+			log.Printf("SES feedback: message %s: %v", msg.MessageID, err)
+			reply(w, http.StatusInternalServerError, response{Status: "error", Message: "failed to record feedback"})
+			return
+		}
+		reply(w, http.StatusOK, response{Status: "ok", Message: "processed", Suppressed: suppressed, Updated: updated})
+
+	default:
+		reply(w, http.StatusBadRequest, response{Status: "error", Message: fmt.Sprintf("unsupported message type %q", msg.Type)})
+	}
+}
+
+// process suppresses the recipients of a hard bounce or a complaint,
+// returning how many addresses and preferences it changed. Transient and
+// undetermined bounces, and other event types, are only logged.
+func (h *Handler) process(n *sesNotification) (int, int64, error) {
+	kind := n.NotificationType
+	if kind == "" {
+		kind = n.EventType
+	}
+
+	var reason string
+	var recipients []recipient
+	switch {
+	case kind == "Bounce" && n.Bounce != nil:
+		if n.Bounce.BounceType != "Permanent" {
+			log.Printf("SES feedback: %s bounce (%s) for %d recipients, not suppressing",
+				n.Bounce.BounceType, n.Bounce.BounceSubType, len(n.Bounce.BouncedRecipients))
+			return 0, 0, nil
+		}
+		reason, recipients = models.SuppressionBounce, n.Bounce.BouncedRecipients
+	case kind == "Complaint" && n.Complaint != nil:
+		reason, recipients = models.SuppressionComplaint, n.Complaint.ComplainedRecipients
+	default:
+		log.Printf("SES feedback: ignoring %q notification", kind)
+		return 0, 0, nil
+	}
+
+	var suppressed int
+	var updated int64
+	for _, rcpt := range recipients {
+		if rcpt.EmailAddress == "" {
+			continue
+		}
+		detail := rcpt.DiagnosticCode
+		if reason == models.SuppressionBounce && detail == "" {
+			detail = n.Bounce.BounceSubType
+		} else if reason == models.SuppressionComplaint {
+			detail = n.Complaint.ComplaintFeedbackType
+		}
+
+		changed, err := h.store.SuppressEmail(rcpt.EmailAddress, reason, detail)
+		if err != nil {
+			return suppressed, updated, err
+		}
+		suppressed++
+		updated += changed
+		log.Printf("SES feedback: suppressed %s after %s (%d preferences updated)", rcpt.EmailAddress, reason, changed)
+	}
+	return suppressed, updated, nil
+}
+
+// confirmSubscription visits the SubscribeURL of a subscription
+// confirmation. Only SNS URLs are followed, so the endpoint can't be used
+// to make requests elsewhere.
+func (h *Handler) confirmSubscription(subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !isSNSHost(u.Hostname()) {
+		return fmt.Errorf("refusing SubscribeURL %q: not an SNS endpoint", subscribeURL)
+	}
+	resp, err := h.client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SubscribeURL returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// isSNSHost matches SNS endpoints such as sns.us-east-1.amazonaws.com
+func isSNSHost(host string) bool {
+	return strings.HasPrefix(host, "sns.") && strings.HasSuffix(host, ".amazonaws.com")
+}
+
+// verify checks msg's SNS signature against the certificate at its
+// SigningCertURL
+func (h *Handler) verify(msg *snsMessage) error {
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("unsupported SignatureVersion %q", msg.SignatureVersion)
+	}
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+	cert, err := h.signingCert(msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate has no RSA key")
+	}
+
+	digest := hash.New()
+	digest.Write([]byte(msg.stringToSign()))
+	return rsa.VerifyPKCS1v15(key, hash, digest.Sum(nil), sig)
+}
+
+// signingCert returns the certificate at certURL, which must be a .pem on
+// an SNS host. Certificates are cached once fetched.
+func (h *Handler) signingCert(certURL string) (*x509.Certificate, error) {
+	h.certsMu.Lock()
+	cert, ok := h.certs[certURL]
+	h.certsMu.Unlock()
+	if ok && time.Now().Before(cert.NotAfter) {
+		return cert, nil
+	}
+
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || !isSNSHost(u.Hostname()) || !strings.HasSuffix(u.Path, ".pem") {
+		return nil, fmt.Errorf("refusing SigningCertURL %q: not an SNS certificate", certURL)
+	}
+	resp, err := h.client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("fetch signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch signing certificate: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCertBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing certificate is not PEM")
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing certificate: %w", err)
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, errors.New("signing certificate is not currently valid")
+	}
+
+	h.certsMu.Lock()
+	h.certs[certURL] = cert
+	h.certsMu.Unlock()
+	return cert, nil
+}
+
+// stringToSign builds the text SNS signs: the message's signed fields as
+// alternating name and value lines, in the order SNS documents for its type
+func (m *snsMessage) stringToSign() string {
+	var fields []string
+	if m.Type == "Notification" {
+		fields = []string{"Message", m.Message, "MessageId", m.MessageID}
+		if m.Subject != "" {
+			fields = append(fields, "Subject", m.Subject)
+		}
+		fields = append(fields, "Timestamp", m.Timestamp, "TopicArn", m.TopicArn, "Type", m.Type)
+	} else {
+		fields = []string{
+			"Message", m.Message, "MessageId", m.MessageID, "SubscribeURL", m.SubscribeURL,
+			"Timestamp", m.Timestamp, "Token", m.Token, "TopicArn", m.TopicArn, "Type", m.Type,
+		}
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// authenticate checks the token query parameter against the shared secret.
+// With no token or topic configured every request is denied.
+func (h *Handler) authenticate(r *http.Request) bool {
+	if h.token == "" || h.topicARN == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.token)) == 1
+}
+
+func reply(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,336 @@
+package feedback
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"notification-service/models"
+)
+
+// suppressCall is one SuppressEmail call
+type suppressCall struct {
+	address, reason, detail string
+}
+
+// fakeStore records suppressions and reports one preference per address
+type fakeStore struct {
+	calls []suppressCall
+	err   error
+}
+
+func (s *fakeStore) SuppressEmail(address, reason, detail string) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.calls = append(s.calls, suppressCall{address, reason, detail})
+	return 1, nil
+}
+
+// roundTripFunc stubs the subscription confirmation request
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+const (
+	testTopicARN = "arn:aws:sns:us-east-1:123456789012:ses-feedback"
+	testCertURL  = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+)
+
+var (
+	signerOnce sync.Once
+	signerKey  *rsa.PrivateKey
+	signerPEM  []byte
+)
+
+// testSigner returns the key test messages are signed with and its
+// self-signed certificate, standing in for SNS's
+func testSigner(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	signerOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signerKey = key
+		signerPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	})
+	return signerKey, signerPEM
+}
+
+// newTestHandler creates a Handler for the test topic whose HTTP client
+// serves the test signing certificate and records every other request
+func newTestHandler(t *testing.T, store Store) (*Handler, *[]string) {
+	t.Helper()
+	_, certPEM := testSigner(t)
+	h := NewHandler(store, "s3cret", testTopicARN)
+	var visited []string
+	h.client = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.String() == testCertURL {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(certPEM)))}, nil
+		}
+		visited = append(visited, r.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("<ok/>"))}, nil
+	})}
+	return h, &visited
+}
+
+// signed signs msg as SNS would, with SignatureVersion 2, and returns it
+// as a delivery body
+func signed(t *testing.T, msg snsMessage) string {
+	t.Helper()
+	key, _ := testSigner(t)
+	if msg.Timestamp == "" {
+		msg.Timestamp = "2026-10-18T12:00:00.000Z"
+	}
+	msg.SignatureVersion = "2"
+	msg.SigningCertURL = testCertURL
+	digest := sha256.Sum256([]byte(msg.stringToSign()))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(sig)
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// snsNotification wraps an SES notification in a signed SNS envelope
+func snsNotification(t *testing.T, ses string) string {
+	t.Helper()
+	return signed(t, snsMessage{
+		Type:      "Notification",
+		MessageID: "msg-1",
+		TopicArn:  testTopicARN,
+		Message:   ses,
+	})
+}
+
+func post(h *Handler, query, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/ses"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain; charset=UTF-8")
+	w := httptest.NewRecorder()
+	h.HandleSES(w, req)
+	return w
+}
+
+const hardBounce = `{
+	"notificationType": "Bounce",
+	"bounce": {
+		"bounceType": "Permanent",
+		"bounceSubType": "General",
+		"bouncedRecipients": [
+			{"emailAddress": "gone@example.com", "diagnosticCode": "smtp; 550 5.1.1 user unknown"},
+			{"emailAddress": "old@example.com"}
+		]
+	},
+	"mail": {"messageId": "ses-1"}
+}`
+
+func TestHandleSES_HardBounceSuppresses(t *testing.T) {
+	store := &fakeStore{}
+	h, _ := newTestHandler(t, store)
+
+	w := post(h, "?token=s3cret", snsNotification(t, hardBounce))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	want := []suppressCall{
+		{"gone@example.com", models.SuppressionBounce, "smtp; 550 5.1.1 user unknown"},
+		{"old@example.com", models.SuppressionBounce, "General"},
+	}
+	if len(store.calls) != len(want) || store.calls[0] != want[0] || store.calls[1] != want[1] {
+		t.Errorf("expected suppressions %v, got %v", want, store.calls)
+	}
+
+	var resp response
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Suppressed != 2 || resp.Updated != 2 {
+		t.Errorf("expected 2 suppressed and 2 preferences updated, got %+v", resp)
+	}
+}
+
+func TestHandleSES_ComplaintSuppresses(t *testing.T) {
+	store := &fakeStore{}
+	h, _ := newTestHandler(t, store)
+
+	complaint := `{
+		"eventType": "Complaint",
+		"complaint": {
+			"complaintFeedbackType": "abuse",
+			"complainedRecipients": [{"emailAddress": "angry@example.com"}]
+		}
+	}`
+	w := post(h, "?token=s3cret", snsNotification(t, complaint))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(store.calls) != 1 || store.calls[0] != (suppressCall{"angry@example.com", models.SuppressionComplaint, "abuse"}) {
+		t.Errorf("expected a complaint suppression, got %v", store.calls)
+	}
+}
+
+func TestHandleSES_TransientBounceIgnored(t *testing.T) {
+	store := &fakeStore{}
+	h, _ := newTestHandler(t, store)
+
+	transient := `{"notificationType": "Bounce", "bounce": {"bounceType": "Transient", "bounceSubType": "MailboxFull",
+		"bouncedRecipients": [{"emailAddress": "full@example.com"}]}}`
+	w := post(h, "?token=s3cret", snsNotification(t, transient))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if len(store.calls) != 0 {
+		t.Errorf("expected a transient bounce not to suppress, got %v", store.calls)
+	}
+}
+
+func TestHandleSES_AcceptsSignatureVersion1(t *testing.T) {
+	store := &fakeStore{}
+	h, _ := newTestHandler(t, store)
+	key, _ := testSigner(t)
+
+	msg := snsMessage{
+		Type:             "Notification",
+		MessageID:        "msg-1",
+		TopicArn:         testTopicARN,
+		Subject:          "Amazon SES Email Event Notification",
+		Message:          hardBounce,
+		Timestamp:        "2026-10-18T12:00:00.000Z",
+		SignatureVersion: "1",
+		SigningCertURL:   testCertURL,
+	}
+	digest := sha1.Sum([]byte(msg.stringToSign()))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(sig)
+	body, _ := json.Marshal(msg)
+
+	if w := post(h, "?token=s3cret", string(body)); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(store.calls) != 2 {
+		t.Errorf("expected 2 suppressions, got %v", store.calls)
+	}
+}
+
+func TestHandleSES_StoreErrorAsksForRetry(t *testing.T) {
+	h, _ := newTestHandler(t, &fakeStore{err: errors.New("db down")})
+
+	if w := post(h, "?token=s3cret", snsNotification(t, hardBounce)); w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 so SNS retries, got %d", w.Code)
+	}
+}
+
+func TestHandleSES_Rejects(t *testing.T) {
+	store := &fakeStore{}
+	h, _ := newTestHandler(t, store)
+	body := snsNotification(t, hardBounce)
+
+	var msg snsMessage
+	if err := json.Unmarshal([]byte(body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	reencode := func(edit func(*snsMessage)) string {
+		m := msg
+		edit(&m)
+		out, _ := json.Marshal(m)
+		return string(out)
+	}
+
+	cases := []struct {
+		name  string
+		h     *Handler
+		query string
+		body  string
+		want  int
+	}{
+		{"missing token", h, "", body, http.StatusUnauthorized},
+		{"wrong token", h, "?token=nope", body, http.StatusUnauthorized},
+		{"no token configured", NewHandler(store, "", testTopicARN), "?token=", body, http.StatusUnauthorized},
+		{"no topic configured", NewHandler(store, "s3cret", ""), "?token=s3cret", body, http.StatusUnauthorized},
+		{"other topic", NewHandler(store, "s3cret", "arn:aws:sns:us-east-1:123456789012:other"), "?token=s3cret", body, http.StatusForbidden},
+		{"not JSON", h, "?token=s3cret", "nope", http.StatusBadRequest},
+		{"unknown type", h, "?token=s3cret", signed(t, snsMessage{Type: "Weird", TopicArn: testTopicARN}), http.StatusBadRequest},
+		{"unsigned", h, "?token=s3cret", reencode(func(m *snsMessage) { m.Signature = "" }), http.StatusForbidden},
+		{"tampered", h, "?token=s3cret", reencode(func(m *snsMessage) { m.Message = strings.Replace(m.Message, "gone@", "someone@", 1) }), http.StatusForbidden},
+		{"unsupported signature version", h, "?token=s3cret", reencode(func(m *snsMessage) { m.SignatureVersion = "3" }), http.StatusForbidden},
+		{"certificate off SNS", h, "?token=s3cret", reencode(func(m *snsMessage) { m.SigningCertURL = "https://evil.example.com/cert.pem" }), http.StatusForbidden},
+		{"certificate on other AWS host", h, "?token=s3cret", reencode(func(m *snsMessage) { m.SigningCertURL = "https://my-bucket.s3.amazonaws.com/cert.pem" }), http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := post(tc.h, tc.query, tc.body); w.Code != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, w.Code)
+			}
+		})
+	}
+	if len(store.calls) != 0 {
+		t.Errorf("expected no suppressions, got %v", store.calls)
+	}
+}
+
+func TestHandleSES_SubscriptionConfirmation(t *testing.T) {
+	h, visitedPtr := newTestHandler(t, &fakeStore{})
+
+	confirm := signed(t, snsMessage{
+		Type:         "SubscriptionConfirmation",
+		MessageID:    "msg-2",
+		TopicArn:     testTopicARN,
+		Message:      "You have chosen to subscribe to the topic.",
+		Token:        "abc",
+		SubscribeURL: "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=abc",
+	})
+	if w := post(h, "?token=s3cret", confirm); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if visited := *visitedPtr; len(visited) != 1 || !strings.HasPrefix(visited[0], "https://sns.us-east-1.amazonaws.com/") {
+		t.Errorf("expected the SubscribeURL to be visited, got %v", visited)
+	}
+
+	// URLs outside SNS are never requested, even in a signed message
+	*visitedPtr = nil
+	evil := signed(t, snsMessage{
+		Type:         "SubscriptionConfirmation",
+		TopicArn:     testTopicARN,
+		SubscribeURL: "http://169.254.169.254/latest/meta-data",
+	})
+	if w := post(h, "?token=s3cret", evil); w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", w.Code)
+	}
+	if len(*visitedPtr) != 0 {
+		t.Errorf("expected no request, got %v", *visitedPtr)
+	}
+}
@@ -0,0 +1,69 @@
+package httptracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/tracing/tracingtest"
+)
+
+// callerTraceparent is a sampled W3C trace context from an upstream caller
+const (
+	callerTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID      = "00f067aa0ba902b7"
+	callerTraceparent = "00-" + callerTraceID + "-" + callerSpanID + "-01"
+)
+
+func TestHandler_CreatesSpanForHandledRequest(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanFromContext(r.Context()).SpanContext().IsValid() {
+			t.Error("handlers should see the request span in the request context")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	w := httptest.NewRecorder()
+	Handler(mux).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "GET /health" {
+		t.Errorf("span name = %q, want %q", spans[0].Name(), "GET /health")
+	}
+	if spans[0].SpanKind() != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want server", spans[0].SpanKind())
+	}
+	if spans[0].Status().Description != "Service Unavailable" {
+		t.Errorf("span status = %+v, want an error for the 503", spans[0].Status())
+	}
+}
+
+func TestHandler_ContinuesCallerTrace(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("traceparent", callerTraceparent)
+	Handler(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != callerTraceID {
+		t.Errorf("trace ID = %s, want the caller's %s", got, callerTraceID)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != callerSpanID {
+		t.Errorf("parent span ID = %s, want %s", got, callerSpanID)
+	}
+}
@@ -2,7 +2,8 @@
 //
 // Consumes stock price updates from an SQS queue (published by the backend
 // via SNS), evaluates alert rules in near real-time, and delivers
-// email notifications.
+// email notifications. Alert types that don't depend on price ticks
+// (volume spikes, IC Scores, dividends) are evaluated in bulk on a timer.
 //
 // Designed to run as a single-replica K8s deployment in the investorcenter
 // namespace. Exposes only a /health endpoint for liveness/readiness probes.
@@ -18,20 +19,35 @@ import (
 	"syscall"
 	"time"
 
+	"investorcenter-shared/logging"
+	"investorcenter-shared/tracing"
 	"notification-service/canary"
 	"notification-service/config"
 	"notification-service/consumer"
 	"notification-service/database"
 	"notification-service/delivery"
 	"notification-service/evaluator"
+	"notification-service/feedback"
+	"notification-service/httptracing"
 )
 
 func main() {
+	// Mask emails, tokens and passwords in everything we log
+	logging.Install()
+
 	log.Println("Starting notification service...")
 
 	// 1. Load config
 	cfg := config.Load()
 
+	// Trace message processing, queries and sends when an OTLP endpoint is set
+	shutdownTracing, err := tracing.Init(context.Background(), "notification-service")
+	if err != nil {
+		log.Printf("Warning: tracing disabled: %v", err)
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
 	// 2. Initialize database
 	db := database.Initialize(cfg)
 	defer db.Close()
@@ -44,20 +60,42 @@ func main() {
 
 	// 4. Initialize delivery channels
 	emailDelivery := delivery.NewEmailDelivery(cfg, db)
-	router := delivery.NewRouter(emailDelivery)
+	webhookDelivery := delivery.NewWebhookDelivery(cfg, db)
+	smsDelivery := delivery.NewSMSDelivery(cfg, db)
+	inAppDelivery := delivery.NewInAppDelivery(db)
+	router := delivery.NewRouter(emailDelivery, inAppDelivery, webhookDelivery, smsDelivery)
+	router.SetLedger(db) // a retry re-sends only the channels that failed
 
 	// 5. Initialize evaluator
 	eval := evaluator.New(db, router)
+	eval.SetThrottle(cfg.PriceEvalInterval)
+
+	// Skip copies of price updates SQS delivers more than once
+	if cfg.MessageDedupeTTL > 0 {
+		sqsConsumer.SetDeduper(db, cfg.MessageDedupeTTL)
+	}
 
 	// 6. Start SQS consumer in background
 	ctx, cancel := context.WithCancel(context.Background())
 	go sqsConsumer.Start(ctx, eval.HandlePriceUpdate)
+	go eval.RunThrottleFlush(ctx)
+	if cfg.ScheduledEvalInterval > 0 {
+		go eval.RunScheduled(ctx, cfg.ScheduledEvalInterval)
+	}
+	if cfg.DeliveryRetryInterval > 0 {
+		go eval.RunDeliveryRetries(ctx, cfg.DeliveryRetryInterval)
+	}
 
-	// 7. Initialize canary handler (for email integration tests)
+	// 7. Initialize canary handler (for email integration tests) and the
+	// SES bounce/complaint webhook
 	canaryHandler := canary.NewHandler(cfg, cfg.CanaryToken)
+	feedbackHandler := feedback.NewHandler(db, cfg.SESFeedbackToken, cfg.SESFeedbackTopicARN)
+	if cfg.SESFeedbackToken == "" || cfg.SESFeedbackTopicARN == "" {
+		log.Println("Warning: SES_FEEDBACK_TOKEN or SES_FEEDBACK_TOPIC_ARN unset, rejecting SES feedback")
+	}
 
 	// 8. Start health server
-	healthSrv := startHealthServer(cfg.Port, db, sqsConsumer, canaryHandler)
+	healthSrv := startHealthServer(cfg.Port, db, sqsConsumer, canaryHandler, feedbackHandler)
 
 	log.Printf("Notification service running on port %s", cfg.Port)
 
@@ -67,7 +105,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down notification service...")
-	cancel() // Stop SQS consumer
+	cancel() // Stop SQS consumer, throttle flush, scheduled evaluation and delivery retries
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
@@ -80,12 +118,17 @@ func main() {
 
 // startHealthServer creates an HTTP server with a /health endpoint
 // for Kubernetes liveness and readiness probes.
-func startHealthServer(port string, db *database.DB, sqsConsumer *consumer.Consumer, canaryHandler *canary.Handler) *http.Server {
+func startHealthServer(port string, db *database.DB, sqsConsumer *consumer.Consumer, canaryHandler *canary.Handler, feedbackHandler *feedback.Handler) *http.Server {
 	mux := http.NewServeMux()
 
 	// Canary endpoint for integration testing email delivery
 	mux.HandleFunc("/canary/email", canaryHandler.HandleEmail)
 
+	// SES bounce and complaint notifications, via SNS
+	if feedbackHandler != nil {
+		mux.HandleFunc("/webhooks/ses", feedbackHandler.HandleSES)
+	}
+
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		status := "ok"
 		dbStatus := "connected"
@@ -117,7 +160,7 @@ func startHealthServer(port string, db *database.DB, sqsConsumer *consumer.Consu
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: httptracing.Handler(mux),
 	}
 
 	go func() {
@@ -52,7 +52,7 @@ func TestStartHealthServer_HealthEndpoint_AllHealthy(t *testing.T) {
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19876"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 	defer srv.Shutdown(context.Background())
 
 	// Give the server a moment to start listening
@@ -101,7 +101,7 @@ func TestStartHealthServer_HealthEndpoint_DBUnhealthy(t *testing.T) {
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19877"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 	defer srv.Shutdown(context.Background())
 
 	time.Sleep(50 * time.Millisecond)
@@ -145,7 +145,7 @@ func TestStartHealthServer_HealthEndpoint_ContentType(t *testing.T) {
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19878"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 	defer srv.Shutdown(context.Background())
 
 	time.Sleep(50 * time.Millisecond)
@@ -175,7 +175,7 @@ func TestStartHealthServer_CanaryEndpoint_MethodNotAllowed(t *testing.T) {
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19879"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 	defer srv.Shutdown(context.Background())
 
 	time.Sleep(50 * time.Millisecond)
@@ -202,7 +202,7 @@ func TestStartHealthServer_UnknownPath(t *testing.T) {
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19880"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 	defer srv.Shutdown(context.Background())
 
 	time.Sleep(50 * time.Millisecond)
@@ -229,7 +229,7 @@ func TestStartHealthServer_Shutdown(t *testing.T) {
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19881"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 
 	time.Sleep(50 * time.Millisecond)
 
@@ -274,12 +274,12 @@ func TestStartHealthServer_HealthEndpoint_BothUnhealthy(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // cancel immediately
 	// Start will see the cancelled context and set healthy=false
-	sqsConsumer.Start(ctx, func(msg []byte) error { return nil })
+	sqsConsumer.Start(ctx, func(_ context.Context, msg []byte) error { return nil })
 
 	canaryHandler := canary.NewHandler(&config.Config{}, "test-token")
 
 	port := "19882"
-	srv := startHealthServer(port, db, sqsConsumer, canaryHandler)
+	srv := startHealthServer(port, db, sqsConsumer, canaryHandler, nil)
 	defer srv.Shutdown(context.Background())
 
 	time.Sleep(50 * time.Millisecond)
@@ -9,6 +9,10 @@ import (
 // SNS Message Types (must match backend/models/price_event.go)
 // ---------------------------------------------------------------------------
 
+// PriceUpdateIdempotencyKey is the message attribute carrying a price
+// update's idempotency key, the hex SHA-256 of its body.
+const PriceUpdateIdempotencyKey = "idempotency_key"
+
 // PriceUpdateMessage is the SNS payload published by the backend every ~5 seconds.
 type PriceUpdateMessage struct {
 	Timestamp int64                  `json:"timestamp"`
@@ -17,10 +21,43 @@ type PriceUpdateMessage struct {
 }
 
 // SymbolQuote is a lightweight price snapshot for a single symbol.
+//
+// Fields each alert type reads, so publishers know what to include:
+//
+//	price_above, price_below       price
+//	price_change_pct, pct_change   change_pct
+//	index_move, sector_move        change_pct
+//	volume_spike                   volume, avg_volume_30d or avg_volume_90d
+//	pe_crosses                     pe_ratio, change_pct
+//
+// volume_spike and pe_crosses are also evaluated daily from stored data
+// (see evaluator/scheduled.go), so their fields are optional on ticks; a
+// tick without them simply doesn't fire those alerts.
 type SymbolQuote struct {
-	Price     float64 `json:"price"`
-	Volume    int64   `json:"volume"`
-	ChangePct float64 `json:"change_pct"`
+	Price        float64 `json:"price"`
+	Volume       int64   `json:"volume"`
+	ChangePct    float64 `json:"change_pct"`               // change vs prior close, in percent
+	AvgVolume30d float64 `json:"avg_volume_30d,omitempty"` // average daily volume, 30 sessions
+	AvgVolume90d float64 `json:"avg_volume_90d,omitempty"` // average daily volume, 90 sessions
+	PERatio      float64 `json:"pe_ratio,omitempty"`       // trailing P/E at price; 0 if unknown or unprofitable
+}
+
+// SymbolSnapshot is the end-of-day data the scheduled evaluator checks
+// alerts against. Zero/nil fields mean the data isn't available.
+type SymbolSnapshot struct {
+	// Quote is the latest daily close, volume and change vs prior close,
+	// with volume averages over the 30 and 90 sessions before it and the
+	// trailing P/E at the close.
+	Quote    SymbolQuote
+	ICScore  *float64 // latest overall IC Score
+	Dividend *DividendDeclaration
+}
+
+// DividendDeclaration is the most recently declared dividend for a symbol.
+type DividendDeclaration struct {
+	DeclaredOn time.Time // declaration date, or ex-date when not reported
+	ExDate     time.Time
+	Amount     float64
 }
 
 // ---------------------------------------------------------------------------
@@ -50,6 +87,10 @@ type AlertRule struct {
 	TriggerCount    int        `db:"trigger_count"`
 	CreatedAt       time.Time  `db:"created_at"`
 	UpdatedAt       time.Time  `db:"updated_at"`
+	// SnoozedUntil mutes the rule while it is in the future; muted rules
+	// aren't loaded for evaluation. After a snooze ends it holds the time it
+	// ended, and nothing that happened before then may trigger the rule.
+	SnoozedUntil *time.Time `db:"snoozed_until"`
 }
 
 // AlertLog records a single alert trigger event.
@@ -67,6 +108,25 @@ type AlertLog struct {
 	IsDismissed      bool            `db:"is_dismissed"`
 }
 
+// InAppNotification is an entry in the user's in-app notification list
+// (the notification_queue table).
+type InAppNotification struct {
+	UserID     string          `db:"user_id"`
+	AlertLogID string          `db:"alert_log_id"`
+	Type       string          `db:"type"`
+	Title      string          `db:"title"`
+	Message    string          `db:"message"`
+	Metadata   json.RawMessage `db:"metadata"`
+}
+
+// Per-channel delivery states in alert_deliveries
+const (
+	DeliverySending    = "sending"    // claimed; a send is in progress
+	DeliveryDelivered  = "delivered"  // sent; never sent again
+	DeliveryFailed     = "failed"     // send failed; retried
+	DeliverySuppressed = "suppressed" // held back by notification preferences; not retried
+)
+
 // NotificationPreferences holds user notification settings.
 type NotificationPreferences struct {
 	UserID             string  `db:"user_id"`
@@ -79,12 +139,69 @@ type NotificationPreferences struct {
 	QuietHoursTimezone string  `db:"quiet_hours_timezone"` // e.g. "America/New_York"
 	MaxAlertsPerDay    int     `db:"max_alerts_per_day"`
 	MaxEmailsPerDay    int     `db:"max_emails_per_day"`
+	WebhookURL         *string `db:"webhook_url"`    // alerts are POSTed here when set
+	WebhookSecret      *string `db:"webhook_secret"` // HMAC key for webhook signatures
+	SMSEnabled         bool    `db:"sms_enabled"`
+	PhoneNumber        *string `db:"phone_number"` // E.164
+	PhoneVerified      bool    `db:"phone_verified"`
+}
+
+// LocalDay returns the user's calendar date at now, in their
+// quiet_hours_timezone (UTC when it is unset or unknown), as midnight UTC.
+// max_alerts_per_day and max_emails_per_day reset when it changes.
+func (p *NotificationPreferences) LocalDay(now time.Time) time.Time {
+	loc := time.UTC
+	if p.QuietHoursTimezone != "" {
+		if l, err := time.LoadLocation(p.QuietHoursTimezone); err == nil {
+			loc = l
+		}
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
 }
 
+// Counters in notification_daily_counts
+const (
+	DailyCountAlerts = "alerts"
+	DailyCountEmails = "emails"
+)
+
+// Reasons in email_suppressions
+const (
+	SuppressionBounce    = "bounce"    // hard (permanent) bounce
+	SuppressionComplaint = "complaint" // recipient marked an email as spam
+)
+
 // UserEmail holds the minimal user data needed for email delivery.
 type UserEmail struct {
 	Email    string `db:"email"`
 	FullName string `db:"full_name"`
+	Timezone string `db:"timezone"` // e.g. "America/New_York"; "" when unset
+}
+
+// FeatureSMSAlerts is the subscription plan feature that unlocks SMS alerts.
+const FeatureSMSAlerts = "sms_alerts"
+
+// UserSubscription is a user's subscription status and their plan's
+// features (subscription_plans.features).
+type UserSubscription struct {
+	PlanName string          `db:"plan_name"`
+	Status   string          `db:"status"`
+	Features json.RawMessage `db:"features"`
+}
+
+// HasFeature reports whether the subscription is active (or trialing) and
+// its plan turns feature on.
+func (s *UserSubscription) HasFeature(feature string) bool {
+	if s == nil || (s.Status != "active" && s.Status != "trialing") {
+		return false
+	}
+	var features map[string]interface{}
+	if err := json.Unmarshal(s.Features, &features); err != nil {
+		return false
+	}
+	on, _ := features[feature].(bool)
+	return on
 }
 
 // ---------------------------------------------------------------------------
@@ -96,13 +213,42 @@ type ThresholdCondition struct {
 	Threshold float64 `json:"threshold"`
 }
 
-// VolumeSpikeCondition covers the volume_spike alert type.
+// VolumeSpikeCondition covers the volume_spike alert type. The spike is
+// either volume_multiplier times the baseline average or percent_above
+// percent over it. The baseline is the lookback_days (30 or 90) average, or
+// the older "avg_30d"/"avg_90d" baseline; it defaults to 30 days.
 type VolumeSpikeCondition struct {
-	VolumeMultiplier float64 `json:"volume_multiplier"`
-	Baseline         string  `json:"baseline"` // "avg_30d"
+	VolumeMultiplier float64 `json:"volume_multiplier,omitempty"`
+	PercentAbove     float64 `json:"percent_above,omitempty"`
+	LookbackDays     int     `json:"lookback_days,omitempty"`
+	Baseline         string  `json:"baseline,omitempty"` // "avg_30d" or "avg_90d"
+}
+
+// ICScoreCondition covers the ic_score alert type.
+type ICScoreCondition struct {
+	Threshold float64 `json:"threshold"`
+	Direction string  `json:"direction"` // "above" or "below"
+}
+
+// PECrossesCondition covers the pe_crosses alert type: the trailing P/E
+// crossing threshold since the prior close.
+type PECrossesCondition struct {
+	Threshold float64 `json:"threshold"`
+	Direction string  `json:"direction"` // "above", "below", "either"
+}
+
+// MarketMoveCondition covers the market-wide index_move and sector_move
+// alert types: the index or sector moving PercentChange percent on the day.
+// The rule's symbol is the ETF standing in for Index or Sector, so the move
+// is read from that symbol's change_pct.
+type MarketMoveCondition struct {
+	Index         string  `json:"index,omitempty"`  // index_move, e.g. "SPX"
+	Sector        string  `json:"sector,omitempty"` // sector_move, e.g. "Technology"
+	PercentChange float64 `json:"percent_change"`
+	Direction     string  `json:"direction"` // "up", "down", "either"
 }
 
-// PriceChangeCondition covers the price_change_pct alert type.
+// PriceChangeCondition covers the price_change_pct and pct_change alert types.
 type PriceChangeCondition struct {
 	PercentChange float64 `json:"percent_change"`
 	Direction     string  `json:"direction"` // "up", "down", "either"
@@ -23,7 +23,7 @@ aws ecr get-login-password --region ${AWS_REGION} | docker login --username AWS
 
 # Build the Docker image for linux/amd64 platform (EKS runs on AMD64)
 echo "Building Docker image..."
-docker build --platform linux/amd64 -t investorcenter/backend:${IMAGE_TAG} -f backend/Dockerfile backend/
+docker build --platform linux/amd64 -t investorcenter/backend:${IMAGE_TAG} -f backend/Dockerfile .
 
 # Tag the image for ECR
 echo "Tagging image for ECR..."
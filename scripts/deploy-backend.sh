@@ -61,9 +61,9 @@ echo ""
 echo "🏗️  Step 1: Building backend Docker image..."
 echo "============================================"
 
-# Build from backend directory for linux/amd64 platform (EKS runs on AMD64)
-cd backend
-docker build --platform linux/amd64 -t $IMAGE_NAME:$IMAGE_TAG .
+# Build from the repo root (the backend uses the shared module) for
+# linux/amd64 platform (EKS runs on AMD64)
+docker build --platform linux/amd64 -t $IMAGE_NAME:$IMAGE_TAG -f backend/Dockerfile .
 
 echo ""
 echo "🔐 Step 2: Authenticating with ECR..."
@@ -85,9 +85,6 @@ echo ""
 echo "⚙️  Step 4: Updating Kubernetes deployment..."
 echo "============================================"
 
-# Go back to root
-cd ..
-
 # Apply the deployment (this will trigger a rolling update)
 kubectl apply -f k8s/backend-deployment.yaml
 
@@ -2,15 +2,35 @@
 
 import logging
 import os
+from dataclasses import dataclass, field
 from typing import List, Set
 
 import psycopg2
-from psycopg2.extras import execute_batch
+from psycopg2.extras import execute_values
 
 from .models import RedditPost
 
 logger = logging.getLogger(__name__)
 
+# Rows per multi-row INSERT in bulk_upsert_raw_posts
+UPSERT_BATCH_SIZE = 100
+
+
+@dataclass
+class UpsertResult:
+    """Outcome of a bulk upsert, split by what happened to each row."""
+
+    inserted: int = 0
+    updated: int = 0
+    # Rows in batches the database rejected
+    failed: int = 0
+    errors: List[str] = field(default_factory=list)
+
+    @property
+    def written(self) -> int:
+        """Rows inserted or updated."""
+        return self.inserted + self.updated
+
 
 class Database:
     """Database operations for social posts and sentiment data."""
@@ -133,35 +153,58 @@ class Database:
 
         return lexicon
 
-    def bulk_upsert_raw_posts(self, posts: List["RedditPost"]) -> int:
+    def bulk_upsert_raw_posts(
+        self, posts: List["RedditPost"], batch_size: int = UPSERT_BATCH_SIZE
+    ) -> UpsertResult:
         """Bulk insert raw posts to reddit_posts_raw table for V2 AI processing.
 
         This stores the raw post content without any ticker extraction.
         The AI processor will later extract tickers from these posts.
 
+        Each batch is one multi-row INSERT ... ON CONFLICT run inside its own
+        savepoint, so a rejected batch is rolled back and reported without
+        losing the batches around it. Posts repeated within the input are
+        written once (last copy wins).
+
         Args:
             posts: List of RedditPost objects from Arctic Shift
+            batch_size: Rows per INSERT statement
 
         Returns:
-            Number of records inserted/updated
+            UpsertResult with inserted, updated and failed counts
         """
+        result = UpsertResult()
         if not posts:
-            return 0
+            return result
+
+        # ON CONFLICT DO UPDATE can't touch the same row twice in one statement
+        unique = list({post.id: post for post in posts}.values())
+
+        # xmax is 0 only for freshly inserted row versions, so it tells
+        # inserts apart from conflict updates without a second query
+        query = """
+            INSERT INTO reddit_posts_raw (
+                external_id, subreddit, author, title, body, url,
+                upvotes, comment_count, award_count, flair, posted_at, fetched_at
+            ) VALUES %s
+            ON CONFLICT (external_id) DO UPDATE SET
+                upvotes = EXCLUDED.upvotes,
+                comment_count = EXCLUDED.comment_count,
+                award_count = EXCLUDED.award_count
+            RETURNING (xmax = 0) AS inserted
+        """
+        template = "(%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, NOW())"
 
         try:
             cursor = self.conn.cursor()
+        except Exception as e:
+            logger.error(f"Failed to bulk upsert raw posts: {e}")
+            result.failed = len(unique)
+            result.errors.append(str(e))
+            return result
 
-            query = """
-                INSERT INTO reddit_posts_raw (
-                    external_id, subreddit, author, title, body, url,
-                    upvotes, comment_count, award_count, flair, posted_at, fetched_at
-                ) VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, NOW())
-                ON CONFLICT (external_id) DO UPDATE SET
-                    upvotes = EXCLUDED.upvotes,
-                    comment_count = EXCLUDED.comment_count,
-                    award_count = EXCLUDED.award_count
-            """
-
+        for start in range(0, len(unique), batch_size):
+            batch = unique[start:start + batch_size]
             data = [
                 (
                     post.id,  # external_id (Reddit's post ID)
@@ -176,20 +219,36 @@ class Database:
                     post.flair,
                     post.created_utc,
                 )
-                for post in posts
+                for post in batch
             ]
 
-            execute_batch(cursor, query, data, page_size=100)
-            self.conn.commit()
-
-            cursor.close()
-            logger.info(f"Bulk upserted {len(posts)} raw posts to reddit_posts_raw")
-            return len(posts)
-
-        except Exception as e:
-            logger.error(f"Failed to bulk upsert raw posts: {e}")
-            self.conn.rollback()
-            return 0
+            try:
+                cursor.execute("SAVEPOINT bulk_upsert_batch")
+                rows = execute_values(
+                    cursor, query, data, template=template,
+                    page_size=len(data), fetch=True,
+                )
+                cursor.execute("RELEASE SAVEPOINT bulk_upsert_batch")
+            except Exception as e:
+                cursor.execute("ROLLBACK TO SAVEPOINT bulk_upsert_batch")
+                result.failed += len(batch)
+                result.errors.append(
+                    f"batch {start // batch_size} ({batch[0].id}..{batch[-1].id}): {e}"
+                )
+                logger.error(f"Failed to upsert raw posts batch {start // batch_size}: {e}")
+                continue
+
+            inserted = sum(1 for (was_inserted,) in rows if was_inserted)
+            result.inserted += inserted
+            result.updated += len(rows) - inserted
+
+        self.conn.commit()
+        cursor.close()
+        logger.info(
+            f"Bulk upserted {result.written} raw posts to reddit_posts_raw "
+            f"({result.inserted} inserted, {result.updated} updated, {result.failed} failed)"
+        )
+        return result
 
     def get_raw_posts_stats(self) -> dict:
         """Get statistics about raw posts and AI processing status.
@@ -19,8 +19,15 @@ import psycopg2
 
 from .aggregator import SentimentAggregator
 from .ai_processor import RedditAIProcessor
-from .database import Database
+from .database import Database, UpsertResult
 from .fetcher import RedditFetcher
+from .spam_filter import (
+    DEFAULT_BLOCKED_AUTHORS,
+    DEFAULT_STOP_WORDS,
+    SpamFilter,
+    SpamFilterConfig,
+    parse_name_list,
+)
 
 logger = logging.getLogger(__name__)
 
@@ -66,7 +73,7 @@ DEFAULT_SUBREDDITS = [
 
 
 def run_collect(db, fetcher, subreddits, limit, sort, min_score,
-                max_age):
+                max_age, spam_filter=None):
     """Phase 1: Fetch posts from Reddit via Arctic Shift API.
 
     Args:
@@ -77,6 +84,7 @@ def run_collect(db, fetcher, subreddits, limit, sort, min_score,
         sort: Sort order (new, hot, top)
         min_score: Minimum post score
         max_age: Maximum post age in days
+        spam_filter: Optional SpamFilter applied before upserting
 
     Returns:
         Total number of posts collected
@@ -90,6 +98,7 @@ def run_collect(db, fetcher, subreddits, limit, sort, min_score,
     logger.info("=" * 50)
 
     total_collected = 0
+    totals = UpsertResult()
 
     for subreddit in subreddits:
         try:
@@ -101,21 +110,47 @@ def run_collect(db, fetcher, subreddits, limit, sort, min_score,
                 max_age_days=max_age,
             )
 
+            fetched = len(posts)
+            if spam_filter is not None:
+                posts = spam_filter.filter(posts)
+
             if posts:
-                inserted = db.bulk_upsert_raw_posts(posts)
-                total_collected += inserted
+                result = db.bulk_upsert_raw_posts(posts)
+                total_collected += result.written
+                totals.inserted += result.inserted
+                totals.updated += result.updated
+                totals.failed += result.failed
                 logger.info(
-                    f"  r/{subreddit}: {len(posts)} fetched, "
-                    f"{inserted} upserted"
+                    f"  r/{subreddit}: {fetched} fetched, "
+                    f"{fetched - len(posts)} filtered, "
+                    f"{result.inserted} new, {result.updated} updated"
+                    + (f", {result.failed} failed" if result.failed else "")
                 )
             else:
-                logger.info(f"  r/{subreddit}: no posts found")
+                logger.info(
+                    f"  r/{subreddit}: no posts kept "
+                    f"({fetched} fetched)"
+                )
 
         except Exception as e:
             logger.error(f"  r/{subreddit}: failed - {e}")
             continue
 
-    logger.info(f"Collection complete: {total_collected} posts upserted")
+    logger.info(
+        f"Collection complete: {total_collected} posts upserted "
+        f"({totals.inserted} new, {totals.updated} updated, {totals.failed} failed)"
+    )
+    if spam_filter is not None:
+        summary = spam_filter.summary()
+        breakdown = ", ".join(
+            f"{reason}={count}"
+            for reason, count in sorted(summary.items())
+            if reason != "total"
+        )
+        logger.info(
+            f"  Filtered: {summary['total']} posts"
+            + (f" ({breakdown})" if breakdown else "")
+        )
     return total_collected
 
 
@@ -240,6 +275,43 @@ def main():
         help="Maximum post age in days (default: 14)",
     )
 
+    # Spam filter args
+    parser.add_argument(
+        "--no-spam-filter",
+        action="store_true",
+        help="Disable spam/noise filtering during collection",
+    )
+    parser.add_argument(
+        "--min-engagement",
+        type=int,
+        default=0,
+        help="Minimum upvotes + comments to keep a post (default: 0)",
+    )
+    parser.add_argument(
+        "--min-content-words",
+        type=int,
+        default=3,
+        help="Minimum non-stop-word words to keep a post (default: 3)",
+    )
+    parser.add_argument(
+        "--blocked-subreddits",
+        nargs="*",
+        default=[],
+        help="Subreddits whose posts are always dropped (comma or space separated)",
+    )
+    parser.add_argument(
+        "--blocked-authors",
+        nargs="*",
+        default=[],
+        help="Extra authors whose posts are always dropped (comma or space separated)",
+    )
+    parser.add_argument(
+        "--stop-words",
+        nargs="*",
+        default=[],
+        help="Extra stop words ignored when measuring post content",
+    )
+
     # Processor args
     # --process-all is always enabled in the pipeline context
     # (the pipeline always processes all unprocessed posts).
@@ -329,6 +401,17 @@ def main():
         # Phase 1: Collect
         if not args.skip_collect:
             fetcher = RedditFetcher()
+            spam_filter = None
+            if not args.no_spam_filter:
+                spam_filter = SpamFilter(SpamFilterConfig(
+                    min_engagement=args.min_engagement,
+                    min_content_words=args.min_content_words,
+                    blocked_subreddits=parse_name_list(args.blocked_subreddits),
+                    blocked_authors=DEFAULT_BLOCKED_AUTHORS
+                    | parse_name_list(args.blocked_authors),
+                    stop_words=DEFAULT_STOP_WORDS
+                    | parse_name_list(args.stop_words),
+                ))
             run_collect(
                 db=db,
                 fetcher=fetcher,
@@ -337,6 +420,7 @@ def main():
                 sort=args.sort,
                 min_score=args.min_score,
                 max_age=args.max_age,
+                spam_filter=spam_filter,
             )
         else:
             logger.info("Skipping Phase 1 (collect)")
@@ -8,6 +8,37 @@ from .models import LexiconEntry, MatchedTerm, SentimentResult
 
 logger = logging.getLogger(__name__)
 
+# Default emoji sentiment used when the lexicon has no entry for an emoji.
+# Lexicon rows with category "emoji" override these, so weights can be tuned
+# (or an emoji neutralized) without a deploy.
+DEFAULT_EMOJI_SENTIMENT: Dict[str, LexiconEntry] = {
+    emoji: LexiconEntry(term=emoji, sentiment=sentiment, weight=weight, category="emoji")
+    for emoji, sentiment, weight in [
+        ("🚀", "bullish", 1.5),
+        ("🌙", "bullish", 1.0),
+        ("💎", "bullish", 1.0),
+        ("🙌", "bullish", 0.5),
+        ("📈", "bullish", 1.0),
+        ("🐂", "bullish", 1.0),
+        ("🔥", "bullish", 0.5),
+        ("💰", "bullish", 0.5),
+        ("🤑", "bullish", 0.5),
+        ("📉", "bearish", 1.0),
+        ("🐻", "bearish", 1.0),
+        ("🩸", "bearish", 1.0),
+        ("💀", "bearish", 1.0),
+        ("🔻", "bearish", 1.0),
+        ("🤡", "bearish", 0.5),
+        ("😭", "bearish", 0.5),
+    ]
+}
+
+# Matches a single emoji codepoint (pictographs, symbols, dingbats). Used to
+# split runs like "moon🚀🚀" into separate tokens so each emoji is scored.
+EMOJI_PATTERN = re.compile(
+    "[\U0001F300-\U0001FAFF\u2600-\u27BF\u2B00-\u2BFF]"
+)
+
 # TODD: Improve the analyzer to use ML to analyze sentiment.
 class SentimentAnalyzer:
     """Performs sentiment analysis using a lexicon of terms."""
@@ -16,9 +47,10 @@ class SentimentAnalyzer:
         """Initialize analyzer.
 
         Args:
-            lexicon: Dict mapping lowercase terms to LexiconEntry objects
+            lexicon: Dict mapping lowercase terms to LexiconEntry objects.
+                     Entries override DEFAULT_EMOJI_SENTIMENT.
         """
-        self.lexicon = lexicon or {}
+        self.lexicon = {**DEFAULT_EMOJI_SENTIMENT, **(lexicon or {})}
         logger.info(f"SentimentAnalyzer initialized with {len(self.lexicon)} terms")
 
     @classmethod
@@ -234,7 +266,10 @@ class SentimentAnalyzer:
         # Allow letters, numbers, spaces, and unicode chars > 127 (emojis)
         cleaned = []
         for char in text:
-            if char.isalnum() or char.isspace() or ord(char) > 127:
+            if EMOJI_PATTERN.match(char):
+                # Emojis become standalone tokens so "🚀🚀" scores twice
+                cleaned.append(f" {char} ")
+            elif char.isalnum() or char.isspace() or ord(char) > 127:
                 cleaned.append(char)
             else:
                 cleaned.append(" ")
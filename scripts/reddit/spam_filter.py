@@ -0,0 +1,164 @@
+"""Spam and noise filtering for collected Reddit posts.
+
+Runs in Phase 1 (collect) before posts are written to reddit_posts_raw, so
+junk never reaches the LLM processor or the sentiment aggregates.
+"""
+
+import hashlib
+import logging
+import re
+from collections import Counter
+from dataclasses import dataclass, field
+from typing import Dict, Iterable, List, Set
+
+from .models import RedditPost
+
+logger = logging.getLogger(__name__)
+
+# Words that carry no signal on their own. A post made only of these
+# ("lol same", "this!!") is noise even if it mentions nothing spammy.
+DEFAULT_STOP_WORDS = {
+    "a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from",
+    "has", "have", "he", "her", "his", "i", "if", "in", "is", "it", "its",
+    "me", "my", "no", "not", "of", "on", "or", "our", "she", "so", "that",
+    "the", "their", "them", "they", "this", "to", "too", "us", "was", "we",
+    "were", "what", "when", "which", "who", "will", "with", "you", "your",
+    # Reddit filler
+    "lol", "lmao", "same", "yes", "yep", "nope", "ok", "okay", "bump",
+    "up", "thoughts", "edit",
+}
+
+# Bots and accounts whose posts are never organic discussion
+DEFAULT_BLOCKED_AUTHORS = {"automoderator", "[deleted]"}
+
+URL_PATTERN = re.compile(r"https?://\S+|www\.\S+")
+WORD_PATTERN = re.compile(r"[a-z0-9$']+")
+
+# Filter reasons, in the order they are checked
+REASON_BLOCKED_SUBREDDIT = "blocked_subreddit"
+REASON_BLOCKED_AUTHOR = "blocked_author"
+REASON_LOW_ENGAGEMENT = "low_engagement"
+REASON_LINK_ONLY = "link_only"
+REASON_NO_CONTENT = "no_content"
+REASON_DUPLICATE = "duplicate"
+
+
+@dataclass
+class SpamFilterConfig:
+    """Tunable spam heuristics for the collector."""
+
+    # Minimum upvotes + comments a post needs to be kept
+    min_engagement: int = 0
+    # Minimum words left after removing stop words and URLs
+    min_content_words: int = 3
+    # Drop posts whose text is nothing but links
+    drop_link_only: bool = True
+    # Drop posts whose normalized title+body was already seen this run
+    drop_duplicates: bool = True
+    blocked_subreddits: Set[str] = field(default_factory=set)
+    blocked_authors: Set[str] = field(default_factory=lambda: set(DEFAULT_BLOCKED_AUTHORS))
+    stop_words: Set[str] = field(default_factory=lambda: set(DEFAULT_STOP_WORDS))
+
+    def __post_init__(self):
+        # Reddit names are case-insensitive
+        self.blocked_subreddits = {s.lower().removeprefix("r/") for s in self.blocked_subreddits}
+        self.blocked_authors = {a.lower().removeprefix("u/") for a in self.blocked_authors}
+        self.stop_words = {w.lower() for w in self.stop_words}
+
+
+class SpamFilter:
+    """Drops spam and low-quality posts and counts why each was dropped.
+
+    One instance should be used per pipeline run so duplicate detection
+    catches the same text cross-posted to several subreddits.
+    """
+
+    def __init__(self, config: SpamFilterConfig = None):
+        self.config = config or SpamFilterConfig()
+        self.filtered: Counter = Counter()
+        self._seen_hashes: Set[str] = set()
+
+    def filter(self, posts: Iterable[RedditPost]) -> List[RedditPost]:
+        """Return the posts that pass every heuristic.
+
+        Args:
+            posts: Posts to check
+
+        Returns:
+            Kept posts, in their original order
+        """
+        kept = []
+        for post in posts:
+            reason = self.check(post)
+            if reason:
+                self.filtered[reason] += 1
+                logger.debug(f"Filtered post {post.id} ({reason})")
+                continue
+            kept.append(post)
+        return kept
+
+    def check(self, post: RedditPost) -> str:
+        """Return the reason a post should be filtered, or "" to keep it.
+
+        Args:
+            post: Post to check
+
+        Returns:
+            Filter reason constant, or empty string
+        """
+        cfg = self.config
+
+        if post.subreddit.lower() in cfg.blocked_subreddits:
+            return REASON_BLOCKED_SUBREDDIT
+
+        if (post.author or "").lower() in cfg.blocked_authors:
+            return REASON_BLOCKED_AUTHOR
+
+        if post.score + post.num_comments < cfg.min_engagement:
+            return REASON_LOW_ENGAGEMENT
+
+        text = f"{post.title} {post.body or ''}".lower()
+        without_links = URL_PATTERN.sub(" ", text)
+
+        if cfg.drop_link_only and self._is_link_only(post):
+            return REASON_LINK_ONLY
+
+        words = WORD_PATTERN.findall(without_links)
+        content_words = [w for w in words if w not in cfg.stop_words]
+        if len(content_words) < cfg.min_content_words:
+            return REASON_NO_CONTENT
+
+        if cfg.drop_duplicates:
+            digest = hashlib.sha1(" ".join(words).encode("utf-8")).hexdigest()
+            if digest in self._seen_hashes:
+                return REASON_DUPLICATE
+            self._seen_hashes.add(digest)
+
+        return ""
+
+    def summary(self) -> Dict[str, int]:
+        """Filtered counts by reason, plus a total."""
+        counts = dict(self.filtered)
+        counts["total"] = sum(self.filtered.values())
+        return counts
+
+    @staticmethod
+    def _is_link_only(post: RedditPost) -> bool:
+        """True when the body, or a body-less title, is nothing but URLs."""
+        body = (post.body or "").lower()
+        if body.strip():
+            return bool(URL_PATTERN.search(body)) and not WORD_PATTERN.search(
+                URL_PATTERN.sub(" ", body)
+            )
+        title = post.title.lower()
+        return bool(URL_PATTERN.search(title)) and not WORD_PATTERN.search(
+            URL_PATTERN.sub(" ", title)
+        )
+
+
+def parse_name_list(values: List[str]) -> Set[str]:
+    """Flatten CLI values like ["a,b", "c"] into {"a", "b", "c"}."""
+    names: Set[str] = set()
+    for value in values or []:
+        names.update(v.strip() for v in value.split(",") if v.strip())
+    return names
@@ -80,8 +80,9 @@ FALSE_POSITIVES = {
 class TickerExtractor:
     """Extracts and validates stock ticker symbols from text."""
 
-    # Pattern for explicit $TICKER mentions (highest confidence)
-    DOLLAR_PATTERN = re.compile(r"\$([A-Z]{1,5})\b")
+    # Pattern for explicit $TICKER cashtags (highest confidence). Matches
+    # lowercase too ("$aapl") but not dollar amounts ("$5", "$1.2B").
+    DOLLAR_PATTERN = re.compile(r"(?<![A-Za-z0-9$])\$([A-Za-z]{1,5})\b")
 
     # Pattern for standalone uppercase words (1-5 letters)
     STANDALONE_PATTERN = re.compile(r"\b([A-Z]{1,5})\b")
@@ -182,11 +183,11 @@ class TickerExtractor:
         if not text:
             return
 
-        # First: Find $TICKER patterns (high confidence, always include)
+        # First: Find $TICKER cashtags (high confidence)
         for match in self.DOLLAR_PATTERN.finditer(text):
             ticker = match.group(1).upper()
 
-            if ticker in self.false_positives:
+            if not self._is_valid_cashtag(ticker):
                 continue
 
             if ticker in mentions:
@@ -236,6 +237,24 @@ class TickerExtractor:
                     mentions[ticker].in_title = True
                 mentions[ticker].positions.append(match.start())
 
+    def _is_valid_cashtag(self, ticker: str) -> bool:
+        """Check whether a $TICKER cashtag should count as a mention.
+
+        When a valid-ticker set is loaded it is authoritative, so cashtags
+        on real tickers that collide with common words ($ALL, $NOW) are
+        kept while junk like "$LOL" is dropped. Without a ticker set we
+        fall back to the false positive filter.
+
+        Args:
+            ticker: Uppercase ticker symbol from the cashtag
+
+        Returns:
+            True if the cashtag should be recorded
+        """
+        if self.valid_tickers:
+            return ticker in self.valid_tickers
+        return ticker not in self.false_positives
+
     def get_primary_ticker(self, mentions: List[TickerMention]) -> str:
         """Determine the main ticker being discussed in a post.
 
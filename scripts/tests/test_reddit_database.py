@@ -0,0 +1,126 @@
+"""Tests for Reddit collector bulk upserts.
+
+The mock tests need no database. TestBulkUpsertIntegration runs against a
+real Postgres with the backend test schema when INTEGRATION_TEST_DB=true
+(same DB_* env vars as the backend integration tests).
+"""
+
+import os
+from datetime import datetime
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from scripts.reddit.database import Database, UpsertResult
+from scripts.reddit.models import RedditPost
+
+
+def _post(post_id, score=10, num_comments=2):
+    return RedditPost(
+        id=post_id,
+        title=f"Post {post_id} about NVDA",
+        body="",
+        author="trader",
+        subreddit="stocks",
+        score=score,
+        num_comments=num_comments,
+        created_utc=datetime(2026, 1, 1),
+        permalink=f"/r/stocks/comments/{post_id}/",
+        url=f"https://reddit.com/r/stocks/comments/{post_id}/",
+    )
+
+
+def _mock_db():
+    db = Database()
+    db.conn = MagicMock()
+    return db, db.conn.cursor.return_value
+
+
+class TestBulkUpsertAccounting:
+    def test_empty_input(self):
+        db, _ = _mock_db()
+        assert db.bulk_upsert_raw_posts([]) == UpsertResult()
+
+    def test_counts_inserts_and_updates(self):
+        db, cursor = _mock_db()
+        with patch("scripts.reddit.database.execute_values") as ev:
+            ev.return_value = [(True,), (False,), (True,)]
+            result = db.bulk_upsert_raw_posts([_post("a"), _post("b"), _post("c")])
+
+        assert (result.inserted, result.updated, result.failed) == (2, 1, 0)
+        assert result.written == 3
+        assert ev.call_count == 1, "one multi-row statement per batch"
+        db.conn.commit.assert_called_once()
+
+    def test_duplicates_in_input_written_once(self):
+        db, _ = _mock_db()
+        with patch("scripts.reddit.database.execute_values") as ev:
+            ev.return_value = [(True,), (True,)]
+            db.bulk_upsert_raw_posts([_post("a", score=1), _post("b"), _post("a", score=5)])
+
+        rows = ev.call_args[0][2]
+        assert [r[0] for r in rows] == ["a", "b"]
+        assert rows[0][6] == 5, "last copy of a repeated post wins"
+
+    def test_failed_batch_does_not_fail_others(self):
+        db, cursor = _mock_db()
+        with patch("scripts.reddit.database.execute_values") as ev:
+            ev.side_effect = [
+                [(True,), (False,)],
+                Exception("value too long for type character varying(255)"),
+                [(True,)],
+            ]
+            posts = [_post(str(i)) for i in range(5)]
+            result = db.bulk_upsert_raw_posts(posts, batch_size=2)
+
+        assert (result.inserted, result.updated, result.failed) == (2, 1, 2)
+        assert len(result.errors) == 1
+        assert "batch 1 (2..3)" in result.errors[0]
+        executed = [c[0][0] for c in cursor.execute.call_args_list]
+        assert "ROLLBACK TO SAVEPOINT bulk_upsert_batch" in executed
+        db.conn.commit.assert_called_once()
+
+
+def test_upsert_result_written():
+    assert UpsertResult(inserted=3, updated=2, failed=1).written == 5
+
+
+@pytest.mark.skipif(
+    os.getenv("INTEGRATION_TEST_DB") != "true",
+    reason="Skipping integration test: INTEGRATION_TEST_DB not set",
+)
+class TestBulkUpsertIntegration:
+    def test_mixed_batch_accounting(self):
+        db = Database(
+            host=os.getenv("DB_HOST", "localhost"),
+            user=os.getenv("DB_USER", "testuser"),
+            password=os.getenv("DB_PASSWORD", "testpass"),
+            database=os.getenv("DB_NAME", "investorcenter_test"),
+        )
+        db.connect()
+        try:
+            cursor = db.conn.cursor()
+            cursor.execute(
+                "DELETE FROM reddit_posts_raw WHERE external_id LIKE 'bulktest_%'"
+            )
+            db.conn.commit()
+
+            first = db.bulk_upsert_raw_posts([_post("bulktest_1"), _post("bulktest_2")])
+            assert (first.inserted, first.updated, first.failed) == (2, 0, 0)
+
+            # One existing post with new engagement, one new post
+            second = db.bulk_upsert_raw_posts(
+                [_post("bulktest_2", score=99), _post("bulktest_3")]
+            )
+            assert (second.inserted, second.updated, second.failed) == (1, 1, 0)
+
+            cursor.execute(
+                "SELECT upvotes FROM reddit_posts_raw WHERE external_id = 'bulktest_2'"
+            )
+            assert cursor.fetchone()[0] == 99
+        finally:
+            cursor.execute(
+                "DELETE FROM reddit_posts_raw WHERE external_id LIKE 'bulktest_%'"
+            )
+            db.conn.commit()
+            db.close()
@@ -0,0 +1,91 @@
+"""Unit tests for Reddit ticker extraction and lexicon sentiment scoring.
+
+Pure in-memory tests — no database or network access required.
+"""
+
+from scripts.reddit.models import LexiconEntry
+from scripts.reddit.sentiment_analyzer import SentimentAnalyzer
+from scripts.reddit.ticker_extractor import TickerExtractor
+
+VALID_TICKERS = {"AAPL", "GME", "NOW", "TSLA"}
+
+
+def _tickers(mentions):
+    return {m.ticker for m in mentions}
+
+
+# ==================================================================
+# Cashtag extraction
+# ==================================================================
+
+
+class TestCashtagExtraction:
+    def test_cashtag_on_valid_ticker(self):
+        extractor = TickerExtractor(VALID_TICKERS)
+        mentions = extractor.extract("Loading up on $GME before earnings")
+        assert _tickers(mentions) == {"GME"}
+        assert mentions[0].count >= 2
+        assert mentions[0].in_title
+
+    def test_lowercase_cashtag(self):
+        extractor = TickerExtractor(VALID_TICKERS)
+        mentions = extractor.extract("thoughts on $tsla?")
+        assert _tickers(mentions) == {"TSLA"}
+
+    def test_cashtag_not_in_ticker_set_is_dropped(self):
+        extractor = TickerExtractor(VALID_TICKERS)
+        mentions = extractor.extract("$FAKE to the moon", "$FAKE $FAKE")
+        assert mentions == []
+
+    def test_cashtag_on_common_word_ticker_is_kept(self):
+        # NOW is a false positive as a bare word but a real ticker as a cashtag
+        extractor = TickerExtractor(VALID_TICKERS)
+        mentions = extractor.extract("$NOW earnings beat")
+        assert _tickers(mentions) == {"NOW"}
+
+    def test_dollar_amounts_are_not_cashtags(self):
+        extractor = TickerExtractor(VALID_TICKERS)
+        mentions = extractor.extract("Spent $500 on calls, up $1.2K")
+        assert mentions == []
+
+    def test_all_caps_common_words_are_ignored(self):
+        extractor = TickerExtractor(VALID_TICKERS)
+        mentions = extractor.extract("THIS IS THE BEST DAY NOW", "AAPL looks good")
+        assert _tickers(mentions) == {"AAPL"}
+
+    def test_without_ticker_set_falls_back_to_false_positives(self):
+        extractor = TickerExtractor()
+        assert _tickers(extractor.extract("$LOL $XYZ")) == {"XYZ"}
+
+
+# ==================================================================
+# Emoji sentiment
+# ==================================================================
+
+
+class TestEmojiSentiment:
+    def test_rocket_is_bullish(self):
+        result = SentimentAnalyzer().analyze("GME 🚀🚀🚀")
+        assert result.sentiment == "bullish"
+        assert len(result.matched_terms) == 6  # title is counted twice
+
+    def test_emoji_attached_to_word(self):
+        result = SentimentAnalyzer().analyze("going down📉📉")
+        assert result.sentiment == "bearish"
+
+    def test_mixed_emojis_offset(self):
+        result = SentimentAnalyzer().analyze("📈 📉")
+        assert result.sentiment == "neutral"
+        assert result.score == 0
+
+    def test_lexicon_overrides_default_emoji(self):
+        lexicon = {
+            "🚀": LexiconEntry(term="🚀", sentiment="bearish", weight=1.0, category="emoji"),
+        }
+        result = SentimentAnalyzer(lexicon).analyze("🚀")
+        assert result.sentiment == "bearish"
+
+    def test_no_emoji_no_signal(self):
+        result = SentimentAnalyzer().analyze("just a regular post")
+        assert result.sentiment == "neutral"
+        assert result.confidence == 0.0
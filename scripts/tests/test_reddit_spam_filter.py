@@ -0,0 +1,103 @@
+"""Unit tests for the Reddit collector spam/noise filter.
+
+Pure in-memory tests — no database or network access required.
+"""
+
+from datetime import datetime
+
+from scripts.reddit.models import RedditPost
+from scripts.reddit.spam_filter import (
+    REASON_BLOCKED_AUTHOR,
+    REASON_BLOCKED_SUBREDDIT,
+    REASON_DUPLICATE,
+    REASON_LINK_ONLY,
+    REASON_LOW_ENGAGEMENT,
+    REASON_NO_CONTENT,
+    SpamFilter,
+    SpamFilterConfig,
+    parse_name_list,
+)
+
+
+def _post(post_id="p1", title="NVDA earnings look strong this quarter", body="",
+          author="trader", subreddit="stocks", score=10, num_comments=5, is_self=True):
+    return RedditPost(
+        id=post_id,
+        title=title,
+        body=body,
+        author=author,
+        subreddit=subreddit,
+        score=score,
+        num_comments=num_comments,
+        created_utc=datetime(2026, 1, 1),
+        permalink=f"/r/{subreddit}/comments/{post_id}/",
+        url=f"https://reddit.com/r/{subreddit}/comments/{post_id}/",
+        is_self=is_self,
+    )
+
+
+class TestSpamFilter:
+    def test_keeps_normal_post(self):
+        assert SpamFilter().check(_post()) == ""
+
+    def test_low_engagement_excluded(self):
+        f = SpamFilter(SpamFilterConfig(min_engagement=5))
+        assert f.check(_post(score=1, num_comments=1)) == REASON_LOW_ENGAGEMENT
+        assert f.check(_post(post_id="p2", title="AMD guidance raised again", score=3, num_comments=2)) == ""
+
+    def test_link_only_body_excluded(self):
+        post = _post(title="check this", body="https://spam.example.com/deal")
+        assert SpamFilter().check(post) == REASON_LINK_ONLY
+
+    def test_link_only_title_excluded(self):
+        post = _post(title="https://spam.example.com/deal", is_self=False)
+        assert SpamFilter().check(post) == REASON_LINK_ONLY
+
+    def test_link_with_discussion_kept(self):
+        post = _post(body="Good writeup on margins https://example.com/article worth reading")
+        assert SpamFilter().check(post) == ""
+
+    def test_stop_words_only_excluded(self):
+        assert SpamFilter().check(_post(title="lol same", body="this is it")) == REASON_NO_CONTENT
+
+    def test_custom_stop_words(self):
+        config = SpamFilterConfig(stop_words={"moon", "soon", "wen"})
+        assert SpamFilter(config).check(_post(title="wen moon soon")) == REASON_NO_CONTENT
+
+    def test_repeated_content_excluded(self):
+        f = SpamFilter()
+        first = _post(post_id="a", subreddit="stocks")
+        crosspost = _post(post_id="b", subreddit="investing")
+        assert f.filter([first, crosspost]) == [first]
+        assert f.summary() == {REASON_DUPLICATE: 1, "total": 1}
+
+    def test_blocked_subreddit_excluded(self):
+        f = SpamFilter(SpamFilterConfig(blocked_subreddits={"r/PennyStockPromos"}))
+        assert f.check(_post(subreddit="pennystockpromos")) == REASON_BLOCKED_SUBREDDIT
+
+    def test_blocked_author_excluded(self):
+        assert SpamFilter().check(_post(author="AutoModerator")) == REASON_BLOCKED_AUTHOR
+        f = SpamFilter(SpamFilterConfig(blocked_authors={"u/PumpBot"}))
+        assert f.check(_post(author="pumpbot")) == REASON_BLOCKED_AUTHOR
+
+    def test_summary_counts_by_reason(self):
+        f = SpamFilter(SpamFilterConfig(min_engagement=3))
+        posts = [
+            _post(post_id="1"),
+            _post(post_id="2", score=0, num_comments=0),
+            _post(post_id="3", title="ok", body="https://x.example.com"),
+            _post(post_id="4", author="AutoModerator"),
+        ]
+        kept = f.filter(posts)
+        assert [p.id for p in kept] == ["1"]
+        assert f.summary() == {
+            REASON_LOW_ENGAGEMENT: 1,
+            REASON_LINK_ONLY: 1,
+            REASON_BLOCKED_AUTHOR: 1,
+            "total": 3,
+        }
+
+
+def test_parse_name_list():
+    assert parse_name_list(["a,b", " c ", ""]) == {"a", "b", "c"}
+    assert parse_name_list(None) == set()
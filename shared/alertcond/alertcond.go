@@ -0,0 +1,225 @@
+// Package alertcond decides whether an alert rule's condition is met by a
+// quote. It is the one definition of the quote-evaluated alert types: the
+// notification service fires alerts with it and the API's test-fire
+// endpoint previews them with it.
+package alertcond
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNotQuoteEvaluated is returned for alert types that aren't decided by
+// a quote (news, earnings, IC Score, dividends, ...).
+var ErrNotQuoteEvaluated = errors.New("alert type isn't evaluated against a quote")
+
+// Quote is the market data an alert is checked against. Zero averages or
+// P/E mean the data isn't available.
+type Quote struct {
+	Price        float64
+	Volume       int64
+	ChangePct    float64 // change vs prior close, in percent
+	AvgVolume30d float64 // average daily volume, 30 sessions
+	AvgVolume90d float64 // average daily volume, 90 sessions
+	PERatio      float64 // trailing P/E at price; 0 if unknown or unprofitable
+}
+
+// Result is the outcome of checking an alert's condition against a quote:
+// whether it triggered, and the quote and condition values it was decided
+// on by name (price and threshold for price_above, say).
+type Result struct {
+	Triggered bool
+	Compared  map[string]float64
+}
+
+type thresholdCondition struct {
+	Threshold float64 `json:"threshold"`
+}
+
+type priceChangeCondition struct {
+	PercentChange float64 `json:"percent_change"`
+	Direction     string  `json:"direction"` // "up", "down", "either"
+}
+
+type marketMoveCondition struct {
+	Index         string  `json:"index,omitempty"`
+	Sector        string  `json:"sector,omitempty"`
+	PercentChange float64 `json:"percent_change"`
+	Direction     string  `json:"direction"` // "up", "down", "either"
+}
+
+type volumeSpikeCondition struct {
+	VolumeMultiplier float64 `json:"volume_multiplier,omitempty"`
+	PercentAbove     float64 `json:"percent_above,omitempty"`
+	LookbackDays     int     `json:"lookback_days,omitempty"`
+	Baseline         string  `json:"baseline,omitempty"` // "avg_30d" or "avg_90d"
+}
+
+type peCrossesCondition struct {
+	Threshold float64 `json:"threshold"`
+	Direction string  `json:"direction"` // "above", "below", "either"
+}
+
+// Evaluate checks conditions for an alert of alertType against quote.
+// Malformed or out-of-range conditions return an error; alert types that
+// aren't quote-evaluated return ErrNotQuoteEvaluated.
+func Evaluate(alertType string, conditions json.RawMessage, quote Quote) (Result, error) {
+	switch alertType {
+	case "price_above", "price_below":
+		return evaluatePrice(alertType, conditions, quote)
+	case "price_change_pct", "pct_change":
+		return evaluatePriceChange(alertType, conditions, quote)
+	case "index_move", "sector_move":
+		return evaluateMarketMove(alertType, conditions, quote)
+	case "volume_spike":
+		return evaluateVolumeSpike(conditions, quote)
+	case "pe_crosses":
+		return evaluatePECrosses(conditions, quote)
+	}
+	return Result{}, fmt.Errorf("%w: %s", ErrNotQuoteEvaluated, alertType)
+}
+
+// evaluatePrice triggers price_above when the price is at or above the
+// threshold and price_below when it is at or below it.
+func evaluatePrice(alertType string, conditions json.RawMessage, quote Quote) (Result, error) {
+	var cond thresholdCondition
+	if err := json.Unmarshal(conditions, &cond); err != nil {
+		return Result{}, fmt.Errorf("parse %s conditions: %w", alertType, err)
+	}
+	if cond.Threshold <= 0 {
+		return Result{}, fmt.Errorf("invalid threshold: %f", cond.Threshold)
+	}
+	triggered := quote.Price >= cond.Threshold
+	if alertType == "price_below" {
+		triggered = quote.Price <= cond.Threshold
+	}
+	return Result{
+		Triggered: triggered,
+		Compared:  map[string]float64{"price": quote.Price, "threshold": cond.Threshold},
+	}, nil
+}
+
+// evaluatePriceChange triggers when the day's change is a move of at least
+// the configured percentage in the configured direction.
+func evaluatePriceChange(alertType string, conditions json.RawMessage, quote Quote) (Result, error) {
+	var cond priceChangeCondition
+	if err := json.Unmarshal(conditions, &cond); err != nil {
+		return Result{}, fmt.Errorf("parse %s conditions: %w", alertType, err)
+	}
+	if cond.PercentChange <= 0 {
+		return Result{}, fmt.Errorf("invalid percent_change: %f", cond.PercentChange)
+	}
+	return Result{
+		Triggered: movedBy(quote.ChangePct, cond.PercentChange, cond.Direction),
+		Compared:  map[string]float64{"change_pct": quote.ChangePct, "percent_change": cond.PercentChange},
+	}, nil
+}
+
+// evaluateMarketMove triggers when the index or sector a market-wide alert
+// references has moved the configured percentage on the day. The quote is
+// the rule's symbol, the ETF standing in for that index or sector.
+func evaluateMarketMove(alertType string, conditions json.RawMessage, quote Quote) (Result, error) {
+	var cond marketMoveCondition
+	if err := json.Unmarshal(conditions, &cond); err != nil {
+		return Result{}, fmt.Errorf("parse %s conditions: %w", alertType, err)
+	}
+	if alertType == "index_move" && cond.Index == "" {
+		return Result{}, fmt.Errorf("index_move conditions have no index")
+	}
+	if alertType == "sector_move" && cond.Sector == "" {
+		return Result{}, fmt.Errorf("sector_move conditions have no sector")
+	}
+	if cond.PercentChange <= 0 {
+		return Result{}, fmt.Errorf("invalid percent_change: %f", cond.PercentChange)
+	}
+	return Result{
+		Triggered: movedBy(quote.ChangePct, cond.PercentChange, cond.Direction),
+		Compared:  map[string]float64{"change_pct": quote.ChangePct, "percent_change": cond.PercentChange},
+	}, nil
+}
+
+// movedBy returns true if changePct is a move of at least percentChange in
+// direction ("up", "down", or "either" when empty).
+func movedBy(changePct, percentChange float64, direction string) bool {
+	switch direction {
+	case "up":
+		return changePct >= percentChange
+	case "down":
+		return changePct <= -percentChange
+	default: // "either" or empty
+		return math.Abs(changePct) >= percentChange
+	}
+}
+
+// evaluateVolumeSpike triggers when volume is at least the configured
+// multiple of (or percentage over) the baseline average volume. Quotes
+// without the baseline average never trigger.
+func evaluateVolumeSpike(conditions json.RawMessage, quote Quote) (Result, error) {
+	var cond volumeSpikeCondition
+	if err := json.Unmarshal(conditions, &cond); err != nil {
+		return Result{}, fmt.Errorf("parse volume_spike conditions: %w", err)
+	}
+	if cond.LookbackDays != 0 && cond.LookbackDays != 30 && cond.LookbackDays != 90 {
+		return Result{}, fmt.Errorf("invalid lookback_days: %d", cond.LookbackDays)
+	}
+
+	multiplier := cond.VolumeMultiplier
+	if multiplier == 0 && cond.PercentAbove > 0 {
+		multiplier = 1 + cond.PercentAbove/100
+	}
+	if multiplier <= 0 {
+		return Result{}, fmt.Errorf("invalid volume_multiplier: %f", cond.VolumeMultiplier)
+	}
+	baseline := quote.AvgVolume30d
+	if cond.LookbackDays == 90 || (cond.LookbackDays == 0 && cond.Baseline == "avg_90d") {
+		baseline = quote.AvgVolume90d
+	}
+
+	return Result{
+		Triggered: baseline > 0 && float64(quote.Volume) >= multiplier*baseline,
+		Compared: map[string]float64{
+			"volume":          float64(quote.Volume),
+			"baseline_volume": baseline,
+			"required_volume": multiplier * baseline,
+		},
+	}, nil
+}
+
+// evaluatePECrosses triggers when the trailing P/E has crossed the
+// threshold in the configured direction (default "either") since the prior
+// close. Quotes without a positive P/E never trigger.
+func evaluatePECrosses(conditions json.RawMessage, quote Quote) (Result, error) {
+	var cond peCrossesCondition
+	if err := json.Unmarshal(conditions, &cond); err != nil {
+		return Result{}, fmt.Errorf("parse pe_crosses conditions: %w", err)
+	}
+	if cond.Threshold <= 0 {
+		return Result{}, fmt.Errorf("invalid threshold: %f", cond.Threshold)
+	}
+
+	result := Result{
+		Compared: map[string]float64{"pe_ratio": quote.PERatio, "threshold": cond.Threshold},
+	}
+	if quote.PERatio <= 0 || quote.ChangePct <= -100 {
+		return result, nil
+	}
+
+	// Earnings don't change intraday, so the prior close's P/E is the
+	// current P/E scaled back by the day's price change.
+	pe, prior := quote.PERatio, quote.PERatio/(1+quote.ChangePct/100)
+	result.Compared["prior_pe_ratio"] = prior
+	crossedAbove := prior < cond.Threshold && pe >= cond.Threshold
+	crossedBelow := prior > cond.Threshold && pe <= cond.Threshold
+
+	switch cond.Direction {
+	case "above":
+		result.Triggered = crossedAbove
+	case "below":
+		result.Triggered = crossedBelow
+	default: // "either" or empty
+		result.Triggered = crossedAbove || crossedBelow
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package alertcond
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type evalCase struct {
+	name       string
+	conditions string
+	quote      Quote
+	want       bool
+	wantErr    bool
+}
+
+func runCases(t *testing.T, alertType string, tests []evalCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(alertType, json.RawMessage(tt.conditions), tt.quote)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Triggered != tt.want {
+				t.Errorf("Triggered = %v, want %v", got.Triggered, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceAbove(t *testing.T) {
+	runCases(t, "price_above", []evalCase{
+		{"at threshold", `{"threshold":150}`, Quote{Price: 150}, true, false},
+		{"above threshold", `{"threshold":150}`, Quote{Price: 200}, true, false},
+		{"below threshold", `{"threshold":150}`, Quote{Price: 149.99}, false, false},
+		{"invalid json", `{invalid`, Quote{Price: 200}, false, true},
+		{"zero threshold", `{"threshold":0}`, Quote{Price: 200}, false, true},
+		{"negative threshold", `{"threshold":-10}`, Quote{Price: 200}, false, true},
+	})
+}
+
+func TestPriceBelow(t *testing.T) {
+	runCases(t, "price_below", []evalCase{
+		{"at threshold", `{"threshold":100}`, Quote{Price: 100}, true, false},
+		{"below threshold", `{"threshold":100}`, Quote{Price: 50}, true, false},
+		{"above threshold", `{"threshold":100}`, Quote{Price: 100.01}, false, false},
+		{"invalid json", `{invalid`, Quote{Price: 50}, false, true},
+		{"zero threshold", `{"threshold":0}`, Quote{Price: 50}, false, true},
+	})
+}
+
+func TestPriceChangePct(t *testing.T) {
+	tests := []evalCase{
+		{"up triggered", `{"percent_change":5,"direction":"up"}`, Quote{ChangePct: 6}, true, false},
+		{"up at threshold", `{"percent_change":5,"direction":"up"}`, Quote{ChangePct: 5}, true, false},
+		{"up below threshold", `{"percent_change":5,"direction":"up"}`, Quote{ChangePct: 3}, false, false},
+		{"up ignores a fall", `{"percent_change":5,"direction":"up"}`, Quote{ChangePct: -6}, false, false},
+		{"down triggered", `{"percent_change":5,"direction":"down"}`, Quote{ChangePct: -6}, true, false},
+		{"down ignores a rise", `{"percent_change":5,"direction":"down"}`, Quote{ChangePct: 6}, false, false},
+		{"either rise", `{"percent_change":5,"direction":"either"}`, Quote{ChangePct: 7}, true, false},
+		{"either fall", `{"percent_change":5,"direction":"either"}`, Quote{ChangePct: -7}, true, false},
+		{"either too small", `{"percent_change":5,"direction":"either"}`, Quote{ChangePct: 2}, false, false},
+		{"empty direction is either", `{"percent_change":5}`, Quote{ChangePct: -6}, true, false},
+		{"invalid json", `{invalid`, Quote{ChangePct: 10}, false, true},
+		{"zero percent_change", `{"percent_change":0,"direction":"up"}`, Quote{ChangePct: 1}, false, true},
+	}
+	runCases(t, "price_change_pct", tests)
+	runCases(t, "pct_change", tests)
+}
+
+func TestMarketMove(t *testing.T) {
+	spxDown3 := `{"index":"SPX","percent_change":3,"direction":"down"}`
+	runCases(t, "index_move", []evalCase{
+		{"crosses threshold", spxDown3, Quote{ChangePct: -3.4}, true, false},
+		{"at threshold", spxDown3, Quote{ChangePct: -3}, true, false},
+		{"short of threshold", spxDown3, Quote{ChangePct: -2.9}, false, false},
+		{"moved the other way", spxDown3, Quote{ChangePct: 3.5}, false, false},
+		{"without index", `{"sector":"Energy","percent_change":2}`, Quote{ChangePct: -5}, false, true},
+		{"invalid percent_change", `{"index":"SPX"}`, Quote{ChangePct: -5}, false, true},
+	})
+	runCases(t, "sector_move", []evalCase{
+		{"either direction", `{"sector":"Technology","percent_change":2}`, Quote{ChangePct: 2.1}, true, false},
+		{"without sector", `{"index":"SPX","percent_change":2}`, Quote{ChangePct: -5}, false, true},
+	})
+}
+
+func TestVolumeSpike(t *testing.T) {
+	quote := Quote{Volume: 2_500_000, AvgVolume30d: 1_000_000, AvgVolume90d: 2_000_000}
+	runCases(t, "volume_spike", []evalCase{
+		{"above 30d multiple", `{"volume_multiplier":2.5,"baseline":"avg_30d"}`, quote, true, false},
+		{"default baseline is 30d", `{"volume_multiplier":2}`, quote, true, false},
+		{"below 90d multiple", `{"volume_multiplier":1.5,"baseline":"avg_90d"}`, quote, false, false},
+		{"percent above 30d", `{"percent_above":150,"lookback_days":30}`, quote, true, false},
+		{"percent above 90d", `{"percent_above":50,"lookback_days":90}`, quote, false, false},
+		{"lookback overrides baseline", `{"volume_multiplier":2,"lookback_days":30,"baseline":"avg_90d"}`, quote, true, false},
+		{"no baseline", `{"volume_multiplier":2}`, Quote{Volume: 100}, false, false},
+		{"unsupported lookback", `{"volume_multiplier":2,"lookback_days":10}`, quote, false, true},
+		{"invalid multiplier", `{"volume_multiplier":0}`, quote, false, true},
+		{"bad json", `{`, quote, false, true},
+	})
+}
+
+func TestPECrosses(t *testing.T) {
+	// Prior-close P/E is pe_ratio / (1 + change_pct/100).
+	runCases(t, "pe_crosses", []evalCase{
+		{"crossed above", `{"threshold":20,"direction":"above"}`, Quote{ChangePct: 10, PERatio: 21}, true, false},
+		{"already above at prior close", `{"threshold":20,"direction":"above"}`, Quote{ChangePct: 2, PERatio: 25}, false, false},
+		{"crossed below", `{"threshold":20,"direction":"below"}`, Quote{ChangePct: -10, PERatio: 19}, true, false},
+		{"below ignores upward cross", `{"threshold":20,"direction":"below"}`, Quote{ChangePct: 10, PERatio: 21}, false, false},
+		{"either catches downward cross", `{"threshold":20}`, Quote{ChangePct: -10, PERatio: 19}, true, false},
+		{"flat day", `{"threshold":20}`, Quote{PERatio: 20}, false, false},
+		{"no P/E", `{"threshold":20}`, Quote{ChangePct: 10}, false, false},
+		{"invalid threshold", `{}`, Quote{ChangePct: 10, PERatio: 21}, false, true},
+	})
+}
+
+func TestEvaluate_ReportsComparedValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		alertType string
+		cond      string
+		quote     Quote
+		compared  map[string]float64
+	}{
+		{"price_below", "price_below", `{"threshold":150}`, Quote{Price: 151},
+			map[string]float64{"price": 151, "threshold": 150}},
+		{"pct_change", "pct_change", `{"percent_change":5}`, Quote{ChangePct: -3},
+			map[string]float64{"change_pct": -3, "percent_change": 5}},
+		{"index_move", "index_move", `{"index":"SPX","percent_change":2}`, Quote{ChangePct: -3},
+			map[string]float64{"change_pct": -3, "percent_change": 2}},
+		{"volume_spike", "volume_spike", `{"percent_above":100,"lookback_days":90}`, Quote{Volume: 500, AvgVolume90d: 200},
+			map[string]float64{"volume": 500, "baseline_volume": 200, "required_volume": 400}},
+		{"volume_spike without history", "volume_spike", `{"volume_multiplier":2}`, Quote{Volume: 500},
+			map[string]float64{"volume": 500, "baseline_volume": 0, "required_volume": 0}},
+		{"pe_crosses", "pe_crosses", `{"threshold":22}`, Quote{ChangePct: 25, PERatio: 25},
+			map[string]float64{"pe_ratio": 25, "prior_pe_ratio": 20, "threshold": 22}},
+		{"pe_crosses without P/E", "pe_crosses", `{"threshold":22}`, Quote{ChangePct: 25},
+			map[string]float64{"pe_ratio": 0, "threshold": 22}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.alertType, json.RawMessage(tt.cond), tt.quote)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got.Compared) != len(tt.compared) {
+				t.Fatalf("Compared = %v, want %v", got.Compared, tt.compared)
+			}
+			for k, want := range tt.compared {
+				if v := got.Compared[k]; v != want {
+					t.Errorf("Compared[%q] = %v, want %v", k, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluate_NotQuoteEvaluated(t *testing.T) {
+	for _, alertType := range []string{"news", "earnings", "ic_score", "dividend", ""} {
+		_, err := Evaluate(alertType, json.RawMessage(`{}`), Quote{Price: 10})
+		if !errors.Is(err, ErrNotQuoteEvaluated) {
+			t.Errorf("%q: err = %v, want ErrNotQuoteEvaluated", alertType, err)
+		}
+	}
+}
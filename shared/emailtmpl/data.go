@@ -0,0 +1,60 @@
+package emailtmpl
+
+import "time"
+
+// AlertTriggeredTemplate is the email sent when an alert rule fires
+const AlertTriggeredTemplate = "alert_triggered"
+
+// AlertTriggered is the data AlertTriggeredTemplate renders
+type AlertTriggered struct {
+	UserName     string
+	AlertName    string
+	Symbol       string
+	AlertType    string // e.g. "price_above", labelled per locale
+	Price        float64
+	ChangePct    float64
+	Volume       string    // already formatted, e.g. "45.0M"
+	TriggeredAt  time.Time // in the user's timezone
+	WatchlistURL string
+	SettingsURL  string
+}
+
+// DigestTemplate is the daily or weekly digest email
+const DigestTemplate = "digest"
+
+// Digest is the data DigestTemplate renders. Sections without data are
+// left out.
+type Digest struct {
+	Weekly       bool
+	UserName     string
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Portfolio    *DigestPortfolio
+	TopMovers    []DigestMover
+	RecentAlerts []DigestAlert
+	SettingsURL  string
+}
+
+// DigestPortfolio is a digest's portfolio summary
+type DigestPortfolio struct {
+	TotalValue    float64
+	DayChange     float64
+	DayChangePct  float64
+	WeekChange    float64
+	WeekChangePct float64
+}
+
+// DigestMover is one of a digest's top movers
+type DigestMover struct {
+	Symbol    string
+	Name      string
+	Price     float64
+	ChangePct float64
+}
+
+// DigestAlert is an alert that fired in a digest's period
+type DigestAlert struct {
+	Symbol      string
+	RuleName    string
+	TriggeredAt time.Time
+}
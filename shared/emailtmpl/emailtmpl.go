@@ -0,0 +1,105 @@
+// Package emailtmpl renders user emails from named templates, as a
+// plaintext and an HTML part in the recipient's language. The notification
+// service sends alert emails with it and the API's digest sender digests.
+//
+// Each notification type has a name (e.g. "alert_triggered") and two files
+// under templates/: <name>.txt.tmpl, defining "<name>.subject" and
+// "<name>.text" as text/template, and <name>.html.tmpl, defining
+// "<name>.html" as html/template. Templates look up their wording with
+// {{t "key" args...}} in the catalog of the locale being rendered (see
+// messages.go), so translating an email doesn't touch its layout.
+package emailtmpl
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	Text    string // text/plain part
+	HTML    string // text/html part
+}
+
+// Renderer renders the named email templates
+type Renderer struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var (
+	defaultRenderer    *Renderer
+	defaultRendererErr error
+	defaultOnce        sync.Once
+)
+
+// Default returns the renderer over the embedded templates, parsed once
+func Default() (*Renderer, error) {
+	defaultOnce.Do(func() {
+		defaultRenderer, defaultRendererErr = Parse()
+	})
+	return defaultRenderer, defaultRendererErr
+}
+
+// Parse parses the embedded templates
+func Parse() (*Renderer, error) {
+	// Placeholder funcs so the templates parse; Render binds the locale's
+	funcs := funcsFor(DefaultLocale)
+
+	text, err := texttemplate.New("emails").Funcs(texttemplate.FuncMap(funcs)).ParseFS(templateFS, "templates/*.txt.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse text templates: %w", err)
+	}
+	html, err := htmltemplate.New("emails").Funcs(htmltemplate.FuncMap(funcs)).ParseFS(templateFS, "templates/*.html.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("parse html templates: %w", err)
+	}
+	return &Renderer{text: text, html: html}, nil
+}
+
+// Render renders the name email for data in locale. An unsupported locale
+// renders in DefaultLocale.
+func (r *Renderer) Render(name, locale string, data interface{}) (*Message, error) {
+	funcs := funcsFor(locale)
+
+	// Clone so concurrent renders in different locales don't share funcs
+	text, err := r.text.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", name, err)
+	}
+	text.Funcs(texttemplate.FuncMap(funcs))
+	html, err := r.html.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", name, err)
+	}
+	html.Funcs(htmltemplate.FuncMap(funcs))
+
+	var msg Message
+	var buf bytes.Buffer
+	if err := text.ExecuteTemplate(&buf, name+".subject", data); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	msg.Subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := text.ExecuteTemplate(&buf, name+".text", data); err != nil {
+		return nil, fmt.Errorf("render %s text: %w", name, err)
+	}
+	msg.Text = strings.TrimSpace(buf.String()) + "\n"
+
+	buf.Reset()
+	if err := html.ExecuteTemplate(&buf, name+".html", data); err != nil {
+		return nil, fmt.Errorf("render %s html: %w", name, err)
+	}
+	msg.HTML = buf.String()
+	return &msg, nil
+}
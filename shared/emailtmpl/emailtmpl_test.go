@@ -0,0 +1,191 @@
+package emailtmpl
+
+import (
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleAlertTriggered() AlertTriggered {
+	return AlertTriggered{
+		UserName:     "Jane <Doe>",
+		AlertName:    "AAPL above 200",
+		Symbol:       "AAPL",
+		AlertType:    "price_above",
+		Price:        210.5,
+		ChangePct:    2.35,
+		Volume:       "45.0M",
+		TriggeredAt:  time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC),
+		WatchlistURL: "https://example.com/watchlist/wl-1",
+		SettingsURL:  "https://example.com/settings",
+	}
+}
+
+func render(t *testing.T, locale string) *Message {
+	t.Helper()
+	r, err := Default()
+	if err != nil {
+		t.Fatalf("Default: %v", err)
+	}
+	msg, err := r.Render(AlertTriggeredTemplate, locale, sampleAlertTriggered())
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return msg
+}
+
+func TestRender_ProducesBothParts(t *testing.T) {
+	msg := render(t, "en")
+
+	if msg.Subject != "Alert: AAPL Price Above" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	for _, check := range []string{"Hi Jane <Doe>,", "AAPL above 200", "$210.50", "+2.35%", "45.0M", "Mar 10, 2026 2:30 PM UTC", "https://example.com/watchlist/wl-1"} {
+		if !strings.Contains(msg.Text, check) {
+			t.Errorf("text part missing %q", check)
+		}
+	}
+	for _, check := range []string{"<!DOCTYPE html>", "Hi Jane &lt;Doe&gt;,", "$210.50", `href="https://example.com/watchlist/wl-1"`, "View Watchlist", "account settings"} {
+		if !strings.Contains(msg.HTML, check) {
+			t.Errorf("html part missing %q", check)
+		}
+	}
+}
+
+func TestRender_Localized(t *testing.T) {
+	msg := render(t, "es")
+
+	if msg.Subject != "Alerta: AAPL Precio por encima" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	for _, part := range []string{msg.Text, msg.HTML} {
+		if !strings.Contains(part, "Precio actual") || !strings.Contains(part, "10 Mar 2026 14:30 UTC") {
+			t.Errorf("expected Spanish wording and date layout:\n%s", part)
+		}
+	}
+
+	// Unsupported locales fall back to English
+	if msg := render(t, "fr"); msg.Subject != "Alert: AAPL Price Above" {
+		t.Errorf("expected English fallback, got %q", msg.Subject)
+	}
+}
+
+func TestRender_Digest(t *testing.T) {
+	r, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := Digest{
+		Weekly:      true,
+		UserName:    "Jane <Doe>",
+		PeriodStart: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		Portfolio:   &DigestPortfolio{TotalValue: 10500, DayChange: -12.5, DayChangePct: -0.12, WeekChange: 250, WeekChangePct: 2.44},
+		TopMovers:   []DigestMover{{Symbol: "NVDA", Name: "NVIDIA", Price: 900, ChangePct: 5.5}},
+		RecentAlerts: []DigestAlert{
+			{Symbol: "AAPL", RuleName: "AAPL above 200", TriggeredAt: time.Date(2026, 3, 9, 14, 30, 0, 0, time.UTC)},
+		},
+		SettingsURL: "https://example.com/alerts",
+	}
+
+	msg, err := r.Render(DigestTemplate, "en", digest)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if msg.Subject != "Your weekly InvestorCenter.ai digest" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	for _, check := range []string{"from Mar 3 to Mar 10", "$10500.00", "-$12.50 (-0.12%)", "+$250.00 (+2.44%)", "NVDA NVIDIA $900.00 (+5.50%)", "AAPL above 200", "https://example.com/alerts"} {
+		if !strings.Contains(msg.Text, check) {
+			t.Errorf("text part missing %q:\n%s", check, msg.Text)
+		}
+	}
+	for _, check := range []string{"Hi Jane &lt;Doe&gt;", "Top movers", "Recent alerts", `href="https://example.com/alerts"`} {
+		if !strings.Contains(msg.HTML, check) {
+			t.Errorf("html part missing %q", check)
+		}
+	}
+	if strings.Contains(msg.Text, "Nothing to report") {
+		t.Error("a digest with content shouldn't say there's nothing to report")
+	}
+
+	// An empty daily digest, in Spanish
+	msg, err = r.Render(DigestTemplate, "es", Digest{UserName: "Ana", PeriodStart: digest.PeriodStart, PeriodEnd: digest.PeriodEnd})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if msg.Subject != "Tu resumen diario de InvestorCenter.ai" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	for _, part := range []string{msg.Text, msg.HTML} {
+		if !strings.Contains(part, "del 3 Mar al 10 Mar") || !strings.Contains(part, "No hay nada que destacar") {
+			t.Errorf("expected a Spanish empty digest:\n%s", part)
+		}
+		if strings.Contains(part, "Cartera") {
+			t.Errorf("expected no portfolio section:\n%s", part)
+		}
+	}
+}
+
+func TestRender_UnknownTemplate(t *testing.T) {
+	r, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Render("no_such_email", "en", nil); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	cases := map[string]string{
+		"America/Mexico_City":            "es",
+		"Europe/Madrid":                  "es",
+		"America/Argentina/Buenos_Aires": "es",
+		"America/New_York":               "en",
+		"":                               "en",
+		"Not/AZone":                      "en",
+	}
+	for tz, want := range cases {
+		if got := ResolveLocale(tz); got != want {
+			t.Errorf("ResolveLocale(%q) = %q, want %q", tz, got, want)
+		}
+	}
+}
+
+func TestMessageBytes_Multipart(t *testing.T) {
+	msg := render(t, "es")
+	raw, err := msg.Bytes("Alerts <alerts@example.com>", "jane@example.com")
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("expected subject %q, got %q (%v)", msg.Subject, subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("expected plaintext then HTML parts, got %v", types)
+	}
+}
@@ -0,0 +1,177 @@
+package emailtmpl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultLocale is used for users without a supported locale, and for keys
+// a locale's catalog is missing
+const DefaultLocale = "en"
+
+// catalogs holds each supported locale's wording, by key. Keys are
+// "<template name>.<part>" for template text and "alert_type.<type>" for
+// alert type labels; values are fmt formats for the {{t}} arguments.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"datetime_layout": "Jan 2, 2006 3:04 PM MST",
+		"date_layout":     "Jan 2",
+
+		"alert_type.price_above":      "Price Above",
+		"alert_type.price_below":      "Price Below",
+		"alert_type.price_change_pct": "Price Change %",
+		"alert_type.volume_above":     "Volume Above",
+		"alert_type.volume_below":     "Volume Below",
+		"alert_type.volume_spike":     "Volume Spike",
+		"alert_type.index_move":       "Index Move",
+		"alert_type.sector_move":      "Sector Move",
+		"alert_type.news":             "News Alert",
+		"alert_type.earnings":         "Earnings Report",
+
+		"alert_triggered.subject":   "Alert: %s %s",
+		"alert_triggered.heading":   "Alert Triggered: %s",
+		"alert_triggered.greeting":  "Hi %s,",
+		"alert_triggered.intro":     "Your alert \"%s\" has been triggered:",
+		"alert_triggered.symbol":    "Symbol",
+		"alert_triggered.price":     "Current Price",
+		"alert_triggered.change":    "Change",
+		"alert_triggered.volume":    "Volume",
+		"alert_triggered.time":      "Triggered",
+		"alert_triggered.cta":       "View Watchlist",
+		"alert_triggered.footer":    "You received this email because you have email alerts enabled for this watchlist.",
+		"alert_triggered.manage":    "To manage your notification preferences, visit your",
+		"alert_triggered.settings":  "account settings",
+		"alert_triggered.manage_at": "Manage your notification preferences: %s",
+
+		"digest.subject_daily":  "Your daily InvestorCenter.ai digest",
+		"digest.subject_weekly": "Your weekly InvestorCenter.ai digest",
+		"digest.intro":          "Hi %s, here's what happened from %s to %s.",
+		"digest.portfolio":      "Portfolio",
+		"digest.total_value":    "Total value",
+		"digest.day":            "Day",
+		"digest.week":           "Week",
+		"digest.top_movers":     "Top movers",
+		"digest.recent_alerts":  "Recent alerts",
+		"digest.empty":          "Nothing to report this time.",
+		"digest.manage":         "Manage digest settings",
+		"digest.manage_at":      "Manage digest settings: %s",
+	},
+	"es": {
+		"datetime_layout": "2 Jan 2006 15:04 MST",
+		"date_layout":     "2 Jan",
+
+		"alert_type.price_above":      "Precio por encima",
+		"alert_type.price_below":      "Precio por debajo",
+		"alert_type.price_change_pct": "Cambio de precio %",
+		"alert_type.volume_above":     "Volumen por encima",
+		"alert_type.volume_below":     "Volumen por debajo",
+		"alert_type.volume_spike":     "Pico de volumen",
+		"alert_type.index_move":       "Movimiento del índice",
+		"alert_type.sector_move":      "Movimiento del sector",
+		"alert_type.news":             "Alerta de noticias",
+		"alert_type.earnings":         "Informe de resultados",
+
+		"alert_triggered.subject":   "Alerta: %s %s",
+		"alert_triggered.heading":   "Alerta activada: %s",
+		"alert_triggered.greeting":  "Hola %s:",
+		"alert_triggered.intro":     "Se ha activado tu alerta \"%s\":",
+		"alert_triggered.symbol":    "Símbolo",
+		"alert_triggered.price":     "Precio actual",
+		"alert_triggered.change":    "Cambio",
+		"alert_triggered.volume":    "Volumen",
+		"alert_triggered.time":      "Activada",
+		"alert_triggered.cta":       "Ver lista de seguimiento",
+		"alert_triggered.footer":    "Recibes este correo porque tienes activadas las alertas por correo para esta lista de seguimiento.",
+		"alert_triggered.manage":    "Para gestionar tus preferencias de notificación, visita la",
+		"alert_triggered.settings":  "configuración de tu cuenta",
+		"alert_triggered.manage_at": "Gestiona tus preferencias de notificación: %s",
+
+		"digest.subject_daily":  "Tu resumen diario de InvestorCenter.ai",
+		"digest.subject_weekly": "Tu resumen semanal de InvestorCenter.ai",
+		"digest.intro":          "Hola %s, esto es lo que pasó del %s al %s.",
+		"digest.portfolio":      "Cartera",
+		"digest.total_value":    "Valor total",
+		"digest.day":            "Día",
+		"digest.week":           "Semana",
+		"digest.top_movers":     "Mayores movimientos",
+		"digest.recent_alerts":  "Alertas recientes",
+		"digest.empty":          "No hay nada que destacar esta vez.",
+		"digest.manage":         "Gestionar la configuración del resumen",
+		"digest.manage_at":      "Gestiona la configuración del resumen: %s",
+	},
+}
+
+// spanishTimezones are the profile timezones whose users are emailed in
+// Spanish
+var spanishTimezones = map[string]bool{
+	"Europe/Madrid": true, "Atlantic/Canary": true,
+	"America/Mexico_City": true, "America/Monterrey": true, "America/Cancun": true, "America/Tijuana": true,
+	"America/Bogota": true, "America/Lima": true, "America/Santiago": true, "America/Caracas": true,
+	"America/Montevideo": true, "America/Asuncion": true, "America/La_Paz": true, "America/Guayaquil": true,
+	"America/Guatemala": true, "America/El_Salvador": true, "America/Tegucigalpa": true, "America/Managua": true,
+	"America/Costa_Rica": true, "America/Panama": true, "America/Havana": true, "America/Santo_Domingo": true,
+}
+
+// ResolveLocale picks the locale to email a user in from the timezone on
+// their profile, DefaultLocale for zones without a supported language. Users
+// don't pick a language yet, so this is a guess at the local one.
+func ResolveLocale(timezone string) string {
+	if spanishTimezones[timezone] || strings.HasPrefix(timezone, "America/Argentina/") {
+		return "es"
+	}
+	return DefaultLocale
+}
+
+// lookup returns key's wording in locale, falling back to DefaultLocale
+func lookup(locale, key string) (string, bool) {
+	if s, ok := catalogs[locale][key]; ok {
+		return s, true
+	}
+	s, ok := catalogs[DefaultLocale][key]
+	return s, ok
+}
+
+// funcsFor returns the template funcs for locale
+func funcsFor(locale string) map[string]interface{} {
+	if _, ok := catalogs[locale]; !ok {
+		locale = DefaultLocale
+	}
+	return map[string]interface{}{
+		// t formats key's wording with args; a missing key renders as itself
+		"t": func(key string, args ...interface{}) string {
+			s, ok := lookup(locale, key)
+			if !ok {
+				return key
+			}
+			if len(args) == 0 {
+				return s
+			}
+			return fmt.Sprintf(s, args...)
+		},
+		"alertType": func(alertType string) string {
+			if s, ok := lookup(locale, "alert_type."+alertType); ok {
+				return s
+			}
+			return strings.ReplaceAll(alertType, "_", " ")
+		},
+		"datetime": func(t time.Time) string {
+			layout, _ := lookup(locale, "datetime_layout")
+			return t.Format(layout)
+		},
+		"date": func(t time.Time) string {
+			layout, _ := lookup(locale, "date_layout")
+			return t.Format(layout)
+		},
+		// change renders a money change with its percentage, e.g. "+$12.50 (+1.20%)"
+		"change": func(v, pct float64) string {
+			sign := "+"
+			if v < 0 {
+				sign, v = "-", -v
+			}
+			return fmt.Sprintf("%s$%.2f (%+.2f%%)", sign, v, pct)
+		},
+		"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+		"pct":   func(v float64) string { return fmt.Sprintf("%+.2f%%", v) },
+	}
+}
@@ -0,0 +1,137 @@
+// Package logging keeps PII and credentials out of log output by redacting
+// emails, tokens and password-like fields before anything is written. Every
+// service calls Install at startup.
+package logging
+
+import (
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// DefaultSensitiveFields are field names whose values are always masked in
+// key=value, key: value and JSON "key":"value" forms
+var DefaultSensitiveFields = []string{
+	"password", "new_password", "current_password", "passwd",
+	"token", "access_token", "refresh_token", "id_token", "reset_token",
+	"secret", "client_secret", "api_key", "apikey", "authorization",
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	bearerPattern = regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+// Redactor masks sensitive substrings in log text
+type Redactor struct {
+	fieldPattern *regexp.Regexp
+	fields       map[string]bool
+	extra        []*regexp.Regexp
+}
+
+// NewRedactor creates a redactor masking emails, bearer tokens, JWTs, the
+// values of fields, and any match of extraPatterns
+func NewRedactor(fields []string, extraPatterns []*regexp.Regexp) *Redactor {
+	r := &Redactor{fields: make(map[string]bool, len(fields)), extra: extraPatterns}
+	quoted := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || r.fields[f] {
+			continue
+		}
+		r.fields[f] = true
+		quoted = append(quoted, regexp.QuoteMeta(f))
+	}
+	if len(quoted) > 0 {
+		// key=value, key: value, "key":"value" and "key": value
+		r.fieldPattern = regexp.MustCompile(`(?i)("?\b(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*)("[^"]*"|(?:Bearer\s+)?[^\s,&;}]+)`)
+	}
+	return r
+}
+
+// NewRedactorFromEnv builds a redactor from DefaultSensitiveFields plus
+// LOG_REDACT_FIELDS (comma-separated field names) and LOG_REDACT_PATTERNS
+// (regular expressions separated by ";;"). Invalid patterns are skipped.
+func NewRedactorFromEnv() *Redactor {
+	fields := append([]string{}, DefaultSensitiveFields...)
+	if extra := os.Getenv("LOG_REDACT_FIELDS"); extra != "" {
+		fields = append(fields, strings.Split(extra, ",")...)
+	}
+
+	var patterns []*regexp.Regexp
+	for _, p := range strings.Split(os.Getenv("LOG_REDACT_PATTERNS"), ";;") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			// Written straight to stderr so the warning itself isn't redacted away
+			os.Stderr.WriteString("logging: ignoring invalid LOG_REDACT_PATTERNS entry: " + err.Error() + "\n")
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	return NewRedactor(fields, patterns)
+}
+
+// Redact returns s with sensitive values masked. Emails keep their first
+// character and domain (j***@example.com) so logs stay useful for debugging.
+func (r *Redactor) Redact(s string) string {
+	if r.fieldPattern != nil {
+		s = r.fieldPattern.ReplaceAllStringFunc(s, func(m string) string {
+			sub := r.fieldPattern.FindStringSubmatch(m)
+			if strings.HasPrefix(sub[2], `"`) {
+				return sub[1] + `"` + redacted + `"`
+			}
+			return sub[1] + redacted
+		})
+	}
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	for _, re := range r.extra {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// Writer redacts everything written through it before passing it on
+type Writer struct {
+	mu       sync.Mutex
+	out      io.Writer
+	redactor *Redactor
+}
+
+// NewWriter wraps out so every write is redacted by r
+func NewWriter(out io.Writer, r *Redactor) *Writer {
+	return &Writer{out: out, redactor: r}
+}
+
+// Write redacts p and writes it. It reports len(p) on success so callers
+// like log.Logger don't treat the changed length as a short write.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := io.WriteString(w.out, w.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Install routes the standard logger through a redacting writer configured
+// from the environment, and returns that redactor so other log sinks (e.g.
+// the gin request logger) can share it. LOG_REDACT_DISABLED=true skips it.
+func Install() *Redactor {
+	if os.Getenv("LOG_REDACT_DISABLED") == "true" {
+		return nil
+	}
+	r := NewRedactorFromEnv()
+	log.SetOutput(NewWriter(os.Stderr, r))
+	return r
+}
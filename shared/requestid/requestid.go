@@ -0,0 +1,96 @@
+// Package requestid tags each request with an X-Request-ID and carries it
+// into logs. The backend forwards the ID on proxied calls, so one request
+// can be followed across services.
+package requestid
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Header carries the request ID in both directions
+const Header = "X-Request-ID"
+
+// contextKey is the gin context key Middleware stores the ID under
+const contextKey = "request_id"
+
+// maxLength bounds caller-supplied IDs so they can't bloat logs
+const maxLength = 128
+
+// Middleware tags each request with an ID, reusing a well-formed
+// X-Request-ID from the caller (a client, a proxy in front of us, or the
+// backend) and generating one otherwise. The ID is echoed in the response.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = uuid.NewString()
+		}
+		c.Set(contextKey, id)
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// FromContext returns the request's ID, or "" when Middleware didn't run
+func FromContext(c *gin.Context) string {
+	return c.GetString(contextKey)
+}
+
+// Forward copies the request's ID onto c.Request, so a proxied call to
+// another service carries it even when the ID was generated here rather
+// than sent by the client
+func Forward(c *gin.Context) {
+	if id := FromContext(c); id != "" {
+		c.Request.Header.Set(Header, id)
+	}
+}
+
+// Logger returns a logger that tags each line with the request's ID
+func Logger(c *gin.Context) *log.Logger {
+	id := FromContext(c)
+	if id == "" {
+		return log.Default()
+	}
+	return log.New(log.Writer(), "request_id="+id+" ", log.Flags()|log.Lmsgprefix)
+}
+
+// AccessLog is gin's default request logger with the request ID appended
+func AccessLog() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(AccessLogFormatter)
+}
+
+// AccessLogFormatter is gin's default request log line with the request ID
+// appended, so lines can be matched across services
+func AccessLogFormatter(p gin.LogFormatterParams) string {
+	if p.Latency > time.Minute {
+		p.Latency = p.Latency.Truncate(time.Second)
+	}
+	id, _ := p.Keys[contextKey].(string)
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | request_id=%s\n%s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		p.StatusCode,
+		p.Latency,
+		p.ClientIP,
+		p.Method,
+		p.Path,
+		id,
+		p.ErrorMessage,
+	)
+}
+
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, ch := range id {
+		if !((ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '-' || ch == '_' || ch == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package requestid
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// captureLogs routes gin's access log and the standard logger into buf for
+// the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prevGin, prevLog := gin.DefaultWriter, log.Writer()
+	gin.DefaultWriter = &buf
+	log.SetOutput(&buf)
+	t.Cleanup(func() {
+		gin.DefaultWriter = prevGin
+		log.SetOutput(prevLog)
+	})
+	return &buf
+}
+
+func newRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(Middleware(), AccessLog())
+	r.POST("/tasks/next", func(c *gin.Context) {
+		Logger(c).Printf("Error claiming next task: %v", "no rows")
+		c.String(http.StatusOK, FromContext(c))
+	})
+	return r
+}
+
+func serve(r *gin.Engine, sent string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks/next", nil)
+	req.Header.Set(Header, sent)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestForwardedRequestIDIsLogged(t *testing.T) {
+	buf := captureLogs(t)
+	w := serve(newRouter(), "backend-req-42")
+
+	if got := w.Header().Get(Header); got != "backend-req-42" {
+		t.Errorf("%s = %q, want the caller's ID", Header, got)
+	}
+	if got := w.Body.String(); got != "backend-req-42" {
+		t.Errorf("FromContext = %q, want the caller's ID", got)
+	}
+	for _, want := range []string{
+		"request_id=backend-req-42 Error claiming next task: no rows",
+		`"/tasks/next" | request_id=backend-req-42`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("logs missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMissingOrMalformedRequestIDIsGenerated(t *testing.T) {
+	buf := captureLogs(t)
+	r := newRouter()
+
+	for _, sent := range []string{"", "bad id\r\n", "has space", strings.Repeat("a", maxLength+1)} {
+		w := serve(r, sent)
+
+		id := w.Header().Get(Header)
+		if id == sent || len(id) != 36 {
+			t.Errorf("sent %q: got ID %q, want a generated UUID", sent, id)
+		}
+		if !strings.Contains(buf.String(), "request_id="+id) {
+			t.Errorf("sent %q: logs missing generated ID %q", sent, id)
+		}
+	}
+}
+
+func TestForward(t *testing.T) {
+	r := gin.New()
+	r.Use(Middleware())
+	var forwarded string
+	r.GET("/proxy", func(c *gin.Context) {
+		Forward(c)
+		forwarded = c.Request.Header.Get(Header)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proxy", nil))
+
+	if forwarded == "" || forwarded != w.Header().Get(Header) {
+		t.Errorf("forwarded ID %q, want the generated %q", forwarded, w.Header().Get(Header))
+	}
+}
@@ -0,0 +1,87 @@
+package securityheaders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(cfg Config, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(cfg))
+	r.GET("/health", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "healthy"}) })
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Defaults(t *testing.T) {
+	w := serve(Defaults, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	want := map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+		"Strict-Transport-Security": "", // no HSTS over plain HTTP
+	}
+	for name, v := range want {
+		if got := w.Header().Get(name); got != v {
+			t.Errorf("%s = %q, want %q", name, got, v)
+		}
+	}
+}
+
+func TestMiddleware_HSTSBehindTLS(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	w := serve(Defaults, req)
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+
+	w = serve(Config{HSTSMaxAge: 0}, req)
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("a zero max age should disable HSTS, got %q", got)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SECURITY_CSP", "default-src 'self'")
+	t.Setenv("SECURITY_FRAME_OPTIONS", "")
+	t.Setenv("SECURITY_HSTS_MAX_AGE", "600")
+
+	cfg := FromEnv()
+	if cfg.ContentSecurityPolicy != "default-src 'self'" {
+		t.Errorf("ContentSecurityPolicy = %q", cfg.ContentSecurityPolicy)
+	}
+	if cfg.FrameOptions != "" {
+		t.Errorf("an empty variable should turn the header off, got %q", cfg.FrameOptions)
+	}
+	if cfg.ReferrerPolicy != Defaults.ReferrerPolicy {
+		t.Errorf("unset variables should keep the default, got %q", cfg.ReferrerPolicy)
+	}
+	if cfg.HSTSMaxAge != 600 {
+		t.Errorf("HSTSMaxAge = %d, want 600", cfg.HSTSMaxAge)
+	}
+
+	w := serve(cfg, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if got := w.Header().Get("Content-Security-Policy"); got != "default-src 'self'" {
+		t.Errorf("Content-Security-Policy = %q", got)
+	}
+	if _, ok := w.Header()["X-Frame-Options"]; ok {
+		t.Error("X-Frame-Options should not be sent when disabled")
+	}
+
+	t.Setenv("SECURITY_HSTS_MAX_AGE", "forever")
+	if got := FromEnv().HSTSMaxAge; got != Defaults.HSTSMaxAge {
+		t.Errorf("an invalid max age should keep the default, got %d", got)
+	}
+}
@@ -0,0 +1,189 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"investorcenter-shared/tracing/tracingtest"
+)
+
+// callerTraceparent is a sampled W3C trace context from an upstream caller
+const (
+	callerTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID      = "00f067aa0ba902b7"
+	callerTraceparent = "00-" + callerTraceID + "-" + callerSpanID + "-01"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddleware_CreatesSpanForHandledRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracingtest.RecordSpans(t)
+
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/api/v1/tickers/:symbol", func(c *gin.Context) {
+		if !trace.SpanFromContext(c.Request.Context()).SpanContext().IsValid() {
+			t.Error("handlers should see the request span in the request context")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tickers/AAPL", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/v1/tickers/:symbol" {
+		t.Errorf("span name = %q, want the route template", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want server", span.SpanKind())
+	}
+	if got := spanAttr(span, semconv.HTTPRouteKey).AsString(); got != "/api/v1/tickers/:symbol" {
+		t.Errorf("http.route = %q", got)
+	}
+	if got := spanAttr(span, semconv.HTTPResponseStatusCodeKey).AsInt64(); got != http.StatusOK {
+		t.Errorf("http.response.status_code = %d, want 200", got)
+	}
+}
+
+func TestMiddleware_ContinuesCallerTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracingtest.RecordSpans(t)
+
+	r := gin.New()
+	r.Use(Middleware())
+	r.POST("/tasks", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	req.Header.Set("traceparent", callerTraceparent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != callerTraceID {
+		t.Errorf("trace ID = %s, want the caller's %s", got, callerTraceID)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != callerSpanID {
+		t.Errorf("parent span ID = %s, want %s", got, callerSpanID)
+	}
+	if got := spans[0].Status().Description; got != "Internal Server Error" {
+		t.Errorf("span status = %q, want an error for the 500", got)
+	}
+}
+
+func TestTransport_PropagatesTraceAndOmitsQuery(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	var gotTraceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v2/aggs?apiKey=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: Transport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if req.Header.Get("traceparent") != "" {
+		t.Error("the caller's request must not be modified")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	client := spans[0]
+	if client.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span kind = %v, want client", client.SpanKind())
+	}
+	if client.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("client span should be a child of the caller's span")
+	}
+	if !strings.Contains(gotTraceparent, client.SpanContext().SpanID().String()) {
+		t.Errorf("server got traceparent %q, want the client span's", gotTraceparent)
+	}
+	if got := spanAttr(client, semconv.URLPathKey).AsString(); got != "/v2/aggs" {
+		t.Errorf("url.path = %q", got)
+	}
+	for _, kv := range client.Attributes() {
+		if strings.Contains(kv.Value.Emit(), "secret") {
+			t.Errorf("attribute %s leaks the query string", kv.Key)
+		}
+	}
+}
+
+func TestMessageAttributes_RoundTrip(t *testing.T) {
+	tracingtest.RecordSpans(t)
+
+	ctx, span := Tracer().Start(context.Background(), "publish")
+	defer span.End()
+
+	attrs := MessageAttributes(ctx)
+	if !strings.Contains(attrs["traceparent"], span.SpanContext().TraceID().String()) {
+		t.Errorf("traceparent = %q, want the publishing trace", attrs["traceparent"])
+	}
+	if len(MessageAttributes(context.Background())) != 0 {
+		t.Error("no trace, no attributes")
+	}
+
+	consumed := FromMessageAttributes(context.Background(), attrs)
+	if got := trace.SpanContextFromContext(consumed).TraceID(); got != span.SpanContext().TraceID() {
+		t.Errorf("consumer trace ID = %s, want the publisher's", got)
+	}
+	if trace.SpanContextFromContext(FromMessageAttributes(context.Background(), nil)).IsValid() {
+		t.Error("no attributes should leave the context without a trace")
+	}
+}
+
+func TestEndSpan_RecordsError(t *testing.T) {
+	recorder := tracingtest.RecordSpans(t)
+
+	_, span := Tracer().Start(context.Background(), "send")
+	EndSpan(span, errors.New("smtp down"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error || spans[0].Status().Description != "smtp down" {
+		t.Errorf("span status = %+v, want the error", spans[0].Status())
+	}
+}
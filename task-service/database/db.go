@@ -8,7 +8,8 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"investorcenter-shared/tracing"
 )
 
 // DB holds the database connection
@@ -52,10 +53,13 @@ func Connect() (*sqlx.DB, error) {
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode,
 	)
 
-	db, err := sqlx.Connect("postgres", connStr)
+	// Queries are traced; handlers pass the request context so their query
+	// spans nest under the request's
+	connector, err := pq.NewConnector(connStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("invalid database connection settings: %w", err)
 	}
+	db := sqlx.NewDb(tracing.OpenDB(connector), "postgres")
 
 	db.SetMaxOpenConns(10)
 	db.SetMaxIdleConns(5)
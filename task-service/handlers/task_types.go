@@ -3,12 +3,12 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
-	"log"
 	"net/http"
 	"regexp"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"investorcenter-shared/requestid"
 	"task-service/database"
 )
 
@@ -32,13 +32,13 @@ func ListTaskTypes(c *gin.Context) {
 		return
 	}
 
-	rows, err := database.DB.Query(`
+	rows, err := database.DB.QueryContext(c.Request.Context(), `
 		SELECT id, name, skill_path, param_schema, created_at, updated_at
 		FROM task_types
 		ORDER BY name ASC
 	`)
 	if err != nil {
-		log.Printf("Error fetching task types: %v", err)
+		requestid.Logger(c).Printf("Error fetching task types: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task types"})
 		return
 	}
@@ -49,7 +49,7 @@ func ListTaskTypes(c *gin.Context) {
 		var t TaskType
 		err := rows.Scan(&t.ID, &t.Name, &t.SkillPath, &t.ParamSchema, &t.CreatedAt, &t.UpdatedAt)
 		if err != nil {
-			log.Printf("Error scanning task type: %v", err)
+			requestid.Logger(c).Printf("Error scanning task type: %v", err)
 			continue
 		}
 		taskTypes = append(taskTypes, t)
@@ -89,7 +89,7 @@ func CreateTaskType(c *gin.Context) {
 	}
 
 	var taskType TaskType
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(c.Request.Context(),
 		`INSERT INTO task_types (name, skill_path, param_schema)
 		 VALUES ($1, $2, $3)
 		 RETURNING id, name, skill_path, param_schema, created_at, updated_at`,
@@ -97,7 +97,7 @@ func CreateTaskType(c *gin.Context) {
 	).Scan(&taskType.ID, &taskType.Name, &taskType.SkillPath, &taskType.ParamSchema,
 		&taskType.CreatedAt, &taskType.UpdatedAt)
 	if err != nil {
-		log.Printf("Error creating task type: %v", err)
+		requestid.Logger(c).Printf("Error creating task type: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task type. Name may already be in use."})
 		return
 	}
@@ -132,7 +132,7 @@ func UpdateTaskType(c *gin.Context) {
 	}
 
 	var taskType TaskType
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(c.Request.Context(),
 		`UPDATE task_types SET
 			skill_path = COALESCE($2, skill_path),
 			param_schema = COALESCE($3, param_schema)
@@ -146,7 +146,7 @@ func UpdateTaskType(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task type not found"})
 			return
 		}
-		log.Printf("Error updating task type: %v", err)
+		requestid.Logger(c).Printf("Error updating task type: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task type"})
 		return
 	}
@@ -166,9 +166,9 @@ func DeleteTaskType(c *gin.Context) {
 
 	id := c.Param("id")
 
-	result, err := database.DB.Exec("DELETE FROM task_types WHERE id = $1", id)
+	result, err := database.DB.ExecContext(c.Request.Context(), "DELETE FROM task_types WHERE id = $1", id)
 	if err != nil {
-		log.Printf("Error deleting task type: %v", err)
+		requestid.Logger(c).Printf("Error deleting task type: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task type"})
 		return
 	}
@@ -1,15 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"investorcenter-shared/requestid"
 	"task-service/database"
 )
 
@@ -98,9 +99,9 @@ func scanTask(row interface{ Scan(dest ...interface{}) error }, t *Task) error {
 	)
 }
 
-func fetchTaskType(taskTypeID int) *TaskType {
+func fetchTaskType(ctx context.Context, taskTypeID int) *TaskType {
 	var tt TaskType
-	err := database.DB.QueryRow(
+	err := database.DB.QueryRowContext(ctx,
 		`SELECT id, name, skill_path, param_schema FROM task_types WHERE id = $1`,
 		taskTypeID,
 	).Scan(&tt.ID, &tt.Name, &tt.SkillPath, &tt.ParamSchema)
@@ -157,18 +158,18 @@ func ClaimNextTask(c *gin.Context) {
 	`, taskColumns)
 
 	var t Task
-	err := scanTask(database.DB.QueryRow(query, args...), &t)
+	err := scanTask(database.DB.QueryRowContext(c.Request.Context(), query, args...), &t)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNoContent, nil)
 			return
 		}
-		log.Printf("Error claiming next task: %v", err)
+		requestid.Logger(c).Printf("Error claiming next task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim task"})
 		return
 	}
 
-	t.TaskType = fetchTaskType(t.TaskTypeID)
+	t.TaskType = fetchTaskType(c.Request.Context(), t.TaskTypeID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -212,9 +213,9 @@ func CreateTask(c *gin.Context) {
 	)
 
 	var t Task
-	err := scanTask(database.DB.QueryRow(query, req.TaskTypeID, priority, userID, req.Params), &t)
+	err := scanTask(database.DB.QueryRowContext(c.Request.Context(), query, req.TaskTypeID, priority, userID, req.Params), &t)
 	if err != nil {
-		log.Printf("Error creating task: %v", err)
+		requestid.Logger(c).Printf("Error creating task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
 		return
 	}
@@ -282,13 +283,13 @@ func UpdateTask(c *gin.Context) {
 	args = append(args, taskID)
 
 	var t Task
-	err := scanTask(database.DB.QueryRow(query, args...), &t)
+	err := scanTask(database.DB.QueryRowContext(c.Request.Context(), query, args...), &t)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 			return
 		}
-		log.Printf("Error updating task: %v", err)
+		requestid.Logger(c).Printf("Error updating task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
@@ -308,9 +309,9 @@ func DeleteTask(c *gin.Context) {
 
 	taskID := c.Param("id")
 
-	result, err := database.DB.Exec("DELETE FROM tasks WHERE id = $1", taskID)
+	result, err := database.DB.ExecContext(c.Request.Context(), "DELETE FROM tasks WHERE id = $1", taskID)
 	if err != nil {
-		log.Printf("Error deleting task: %v", err)
+		requestid.Logger(c).Printf("Error deleting task: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
 		return
 	}
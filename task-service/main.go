@@ -12,6 +12,10 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"investorcenter-shared/logging"
+	"investorcenter-shared/requestid"
+	"investorcenter-shared/securityheaders"
+	"investorcenter-shared/tracing"
 	"task-service/auth"
 	"task-service/database"
 	"task-service/handlers"
@@ -20,12 +24,33 @@ import (
 func main() {
 	godotenv.Load()
 
+	// Mask emails, tokens and passwords in everything we log
+	redactor := logging.Install()
+
 	auth.ValidateJWTSecret()
 
+	// Trace requests and queries when an OTLP endpoint is set
+	shutdownTracing, err := tracing.Init(context.Background(), "task-service")
+	if err != nil {
+		log.Printf("Warning: tracing disabled: %v", err)
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
 	database.Initialize()
 	defer database.Close()
 
-	r := gin.Default()
+	// The access log writes to gin.DefaultWriter, so route it through the
+	// same redaction as the standard logger
+	if redactor != nil {
+		gin.DefaultWriter = logging.NewWriter(os.Stdout, redactor)
+		gin.DefaultErrorWriter = logging.NewWriter(os.Stderr, redactor)
+	}
+
+	// Request IDs forwarded by the backend tag every log line for the request
+	r := gin.New()
+	r.Use(requestid.Middleware(), requestid.AccessLog(), tracing.Middleware(), gin.Recovery())
+	r.Use(securityheaders.Middleware(securityheaders.FromEnv()))
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:8080"},